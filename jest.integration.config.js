@@ -0,0 +1,11 @@
+import baseConfig from './jest.config.js';
+
+// End-to-end tests against real Postgres and Redis containers (see test/integration/globalSetup.js)
+export default {
+  ...baseConfig,
+  testPathIgnorePatterns: ['/node_modules/'],
+  testMatch: ['<rootDir>/test/integration/**/*.test.js'],
+  globalSetup: '<rootDir>/test/integration/globalSetup.js',
+  globalTeardown: '<rootDir>/test/integration/globalTeardown.js',
+  testTimeout: 30000,
+};
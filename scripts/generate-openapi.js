@@ -0,0 +1,10 @@
+// Generates src/openapi.json from the @swagger annotations of routes and controllers.
+// Run from the repository root: node scripts/generate-openapi.js [output path]
+import fs from "fs";
+import { buildSpec, GENERATED_SPEC_PATH } from "../src/config/swagger.js";
+
+const output = process.argv[2] || GENERATED_SPEC_PATH;
+const spec = buildSpec();
+
+fs.writeFileSync(output, `${JSON.stringify(spec, null, 2)}\n`);
+console.log(`OpenAPI spec written to ${output} (${Object.keys(spec.paths).length} paths)`);
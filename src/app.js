@@ -1,31 +1,48 @@
 import express from "express";
 import cors from "cors";
-import helmet from "helmet";
 import morgan from "morgan";
 import path from "path";
 import logger from "./config/logger.js";
+import config from "./config/index.js";
 import routes from "./routes/index.js";
 import { errorHandler } from "./middleware/error.middleware.js";
+import { requestContext } from "./middleware/requestContext.middleware.js";
+import { jsonBody, secureHeaders, reportUnexpectedErrors } from "./middleware/security.middleware.js";
+import { resolveTenant } from "./middleware/tenant.middleware.js";
+import { compress } from "./middleware/compression.middleware.js";
+import { meterUsage } from "./middleware/usage.middleware.js";
+import tenantDomainService from "./services/tenantDomain.service.js";
 import { swaggerUi, specs } from "./config/swagger.js";
+import { buildCorsOptions } from "./utils/cors.js";
 
 const app = express();
 
 // Trust proxy for rate limiting behind reverse proxy/load balancer
 app.set('trust proxy', 1);
 
-app.use(express.json());
-app.use(cors());
-app.use(helmet({
-  crossOriginResourcePolicy: { policy: "cross-origin" }
-}));
+app.use(jsonBody());
+// Request ID and deadline, after the body is read so the deadline covers handling only
+app.use(requestContext);
+// Request counts and costs per user and API key, once the response is sent
+app.use(meterUsage);
+if (config.env === "production" && config.cors.origins.includes("*")) {
+  logger.warn("CORS_ORIGINS allows any origin; set the frontend origins explicitly");
+}
+// Web apps on tenants' custom domains are allowed too
+app.use(cors(buildCorsOptions(config.cors, (origin) => tenantDomainService.isTenantOrigin(origin))));
+app.use(secureHeaders());
+app.use(compress());
 app.use(morgan("dev"));
 
 // Serve static files for uploads
 app.use('/uploads/avatars', express.static(path.join(process.cwd(), 'uploads', 'avatars')));
 app.use('/uploads/reviews', express.static(path.join(process.cwd(), 'uploads', 'reviews')));
 
-// Swagger documentation
-app.use('/docs', swaggerUi.serve, swaggerUi.setup(specs));
+// API documentation: the OpenAPI spec for client generators and Swagger UI
+if (config.docs.enabled) {
+  app.get('/openapi.json', (req, res) => res.json(specs));
+  app.use('/docs', swaggerUi.serve, swaggerUi.setup(specs));
+}
 
 /**
  * @swagger
@@ -90,9 +107,11 @@ app.get('/health', async (req, res) => {
 });
 
 // Load routes
+app.use(resolveTenant);
 app.use("", routes);
 
 // Global error handler
+app.use(reportUnexpectedErrors);
 app.use(errorHandler);
 
 export default app;
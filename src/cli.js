@@ -0,0 +1,52 @@
+// Entry point of every process: node src/cli.js <command>
+// Each command loads the shared config (src/config/index.js, .env included).
+// Commands are imported on demand so migrate or seed do not load the API.
+import logger from "./config/logger.js";
+
+const COMMANDS = {
+  serve: {
+    description: "Run the API and socket server (and the job worker unless JOBS_WORKER_ENABLED=false)",
+    load: () => import("./server.js"),
+  },
+  worker: {
+    description: "Process background jobs only (run the API with JOBS_WORKER_ENABLED=false)",
+    load: () => import("./commands/worker.js"),
+  },
+  migrate: {
+    description: "Create the database if missing, sync the schema and run pending migrations",
+    load: () => import("./commands/migrate.js"),
+  },
+  seed: {
+    description: "Load reference and dev data, or test fixtures (--reference-only, --fixture <name>)",
+    load: () => import("./commands/seed.js"),
+  },
+};
+
+const usage = () =>
+  [
+    "Usage: node src/cli.js <command> [options]",
+    "",
+    "Commands:",
+    ...Object.entries(COMMANDS).map(([name, command]) => `  ${name.padEnd(10)}${command.description}`),
+  ].join("\n");
+
+const name = process.argv[2] || "serve";
+
+if (["help", "--help", "-h"].includes(name)) {
+  console.log(usage());
+  process.exit(0);
+}
+
+const command = COMMANDS[name];
+if (!command) {
+  console.error(`Unknown command "${name}"\n\n${usage()}`);
+  process.exit(1);
+}
+
+try {
+  const { default: run } = await command.load();
+  await run(process.argv.slice(3));
+} catch (error) {
+  logger.error(`Command ${name} failed: ${error.message}`);
+  process.exit(1);
+}
@@ -0,0 +1,138 @@
+import http2 from "http2";
+import crypto from "crypto";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+
+const PRODUCTION_HOST = "https://api.push.apple.com";
+const SANDBOX_HOST = "https://api.sandbox.push.apple.com";
+
+const base64url = (input) =>
+  Buffer.from(input)
+    .toString("base64")
+    .replace(/=/g, "")
+    .replace(/\+/g, "-")
+    .replace(/\//g, "_");
+
+/**
+ * Apple Push Notification service client (token-based auth over HTTP/2).
+ */
+class ApnsClient {
+  constructor() {
+    this.providerToken = null;
+    this.providerTokenIssuedAt = 0;
+    this.session = null;
+  }
+
+  isConfigured() {
+    const { teamId, keyId, privateKey, bundleId } = config.push.apns;
+    return Boolean(teamId && keyId && privateKey && bundleId);
+  }
+
+  /**
+   * Apple rejects provider tokens older than one hour, so refresh every 50 minutes
+   * @returns {string}
+   */
+  getProviderToken() {
+    const now = Math.floor(Date.now() / 1000);
+    if (this.providerToken && now - this.providerTokenIssuedAt < 50 * 60) {
+      return this.providerToken;
+    }
+
+    const { teamId, keyId, privateKey } = config.push.apns;
+    const header = base64url(JSON.stringify({ alg: "ES256", kid: keyId }));
+    const claims = base64url(JSON.stringify({ iss: teamId, iat: now }));
+    const signature = crypto.sign("sha256", Buffer.from(`${header}.${claims}`), {
+      key: privateKey,
+      dsaEncoding: "ieee-p1363",
+    });
+
+    this.providerToken = `${header}.${claims}.${base64url(signature)}`;
+    this.providerTokenIssuedAt = now;
+    return this.providerToken;
+  }
+
+  getSession() {
+    if (this.session && !this.session.closed && !this.session.destroyed) {
+      return this.session;
+    }
+    const host = config.push.apns.production ? PRODUCTION_HOST : SANDBOX_HOST;
+    this.session = http2.connect(host);
+    this.session.on("error", (error) => {
+      logger.error(`[APNs] Session error: ${error.message}`);
+      this.session = null;
+    });
+    // Do not keep the process alive just for an idle APNs connection
+    this.session.unref();
+    return this.session;
+  }
+
+  /**
+   * Sends a push notification to a single device
+   * @param {string} token - APNs device token
+   * @param {Object} payload - { title, body, data }
+   * @returns {Promise<Object>} - { success, invalidToken, error }
+   */
+  send(token, { title, body, data = {} }) {
+    return new Promise((resolve, reject) => {
+      let request;
+      try {
+        request = this.getSession().request({
+          ":method": "POST",
+          ":path": `/3/device/${token}`,
+          authorization: `bearer ${this.getProviderToken()}`,
+          "apns-topic": config.push.apns.bundleId,
+          "apns-push-type": "alert",
+          "content-type": "application/json",
+        });
+      } catch (error) {
+        return reject(error);
+      }
+
+      let status = 0;
+      let responseBody = "";
+
+      request.setEncoding("utf8");
+      request.on("response", (headers) => {
+        status = headers[":status"];
+      });
+      request.on("data", (chunk) => {
+        responseBody += chunk;
+      });
+      request.on("error", reject);
+      request.on("end", () => {
+        if (status === 200) {
+          return resolve({ success: true, invalidToken: false });
+        }
+
+        let reason = `HTTP ${status}`;
+        try {
+          reason = JSON.parse(responseBody).reason || reason;
+        } catch {
+          // Body is not JSON, keep the status as reason
+        }
+
+        logger.warn(`[APNs] Send failed with status ${status} (${reason})`);
+        resolve({
+          success: false,
+          invalidToken:
+            status === 410 ||
+            reason === "BadDeviceToken" ||
+            reason === "Unregistered",
+          error: reason,
+        });
+      });
+
+      request.end(
+        JSON.stringify({
+          aps: {
+            alert: { title, body },
+            sound: "default",
+          },
+          ...data,
+        })
+      );
+    });
+  }
+}
+
+export default new ApnsClient();
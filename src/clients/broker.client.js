@@ -0,0 +1,210 @@
+import net from "net";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { createHttpClient } from "./http.client.js";
+import { ExternalServiceError } from "../utils/customErrors.js";
+
+/**
+ * Every broker implements publish(event): Promise<void>, resolving once the
+ * broker has accepted the event. Events are the envelopes built by the
+ * domain event service: { eventId, type, version, aggregateType, aggregateId,
+ * occurredAt, payload }.
+ */
+
+/**
+ * Core NATS over TCP, in verbose mode so the server acknowledges every PUB
+ * with +OK (or -ERR); acknowledgements come in order, so each one settles the
+ * oldest pending publish. Subjects are <prefix>.<EventType>.
+ */
+class NatsBroker {
+  name = "nats";
+
+  constructor() {
+    this.socket = null;
+    // Socket waiting for the server's INFO
+    this.pendingSocket = null;
+    this.connecting = null;
+    this.pending = [];
+    this.buffer = "";
+    this.onInfo = null;
+  }
+
+  connect() {
+    if (this.socket) {
+      return Promise.resolve();
+    }
+    if (this.connecting) {
+      return this.connecting;
+    }
+
+    const url = new URL(config.events.natsUrl);
+    this.connecting = new Promise((resolve, reject) => {
+      const socket = net.createConnection({ host: url.hostname, port: parseInt(url.port, 10) || 4222 });
+      this.pendingSocket = socket;
+      const fail = (error) => {
+        if (this.connecting) {
+          this.connecting = null;
+          reject(new ExternalServiceError(`NATS no disponible: ${error.message}`));
+        }
+        this.reset(error);
+      };
+
+      socket.setTimeout(config.events.timeoutMs, () => socket.destroy(new Error("tiempo de espera agotado")));
+      // The server greets with INFO; CONNECT goes after it and is acknowledged like a PUB
+      this.onInfo = () => {
+        this.onInfo = null;
+        socket.setTimeout(0);
+        this.pendingSocket = null;
+        this.socket = socket;
+        const options = { verbose: true, pedantic: false, name: "join-travel-backend", lang: "node", version: "1.0.0" };
+        if (url.username) {
+          options.user = decodeURIComponent(url.username);
+          options.pass = decodeURIComponent(url.password);
+        }
+        this.send(`CONNECT ${JSON.stringify(options)}\r\n`).then(
+          () => {
+            this.connecting = null;
+            resolve();
+          },
+          fail
+        );
+      };
+      socket.on("data", (data) => this.onData(data));
+      socket.on("error", (error) => {
+        logger.error(`[Events] NATS connection error: ${error.message}`);
+        fail(error);
+      });
+      socket.on("close", () => fail(new Error("conexión cerrada")));
+    });
+    return this.connecting;
+  }
+
+  async publish(event) {
+    await this.connect();
+    const data = JSON.stringify(event);
+    await this.send(`PUB ${config.events.natsSubjectPrefix}.${event.type} ${Buffer.byteLength(data)}\r\n${data}\r\n`);
+  }
+
+  send(frame) {
+    return new Promise((resolve, reject) => {
+      if (!this.socket) {
+        reject(new ExternalServiceError("NATS no disponible: sin conexión"));
+        return;
+      }
+      // A missing acknowledgement would shift every later one, so drop the connection
+      const timer = setTimeout(() => this.reset(new Error("tiempo de espera agotado")), config.events.timeoutMs);
+      this.pending.push({ resolve, reject, timer });
+      this.socket.write(frame);
+    });
+  }
+
+  onData(data) {
+    this.buffer += data.toString("utf8");
+    let lineEnd;
+    while ((lineEnd = this.buffer.indexOf("\r\n")) !== -1) {
+      const line = this.buffer.slice(0, lineEnd);
+      this.buffer = this.buffer.slice(lineEnd + 2);
+
+      if (line.startsWith("INFO")) {
+        this.onInfo?.();
+      } else if (line === "PING") {
+        (this.socket || this.pendingSocket)?.write("PONG\r\n");
+      } else if (line === "+OK" || line.startsWith("-ERR")) {
+        const command = this.pending.shift();
+        if (!command) continue;
+        clearTimeout(command.timer);
+        if (line === "+OK") {
+          command.resolve();
+        } else {
+          command.reject(new ExternalServiceError(`NATS: ${line.slice(5).replace(/'/g, "")}`));
+        }
+      }
+    }
+  }
+
+  /**
+   * Drops the connection and fails every pending publish
+   */
+  reset(error) {
+    for (const socket of [this.socket, this.pendingSocket]) {
+      if (socket) {
+        socket.removeAllListeners("close");
+        socket.destroy();
+      }
+    }
+    this.socket = null;
+    this.pendingSocket = null;
+    this.onInfo = null;
+    this.buffer = "";
+    const pending = this.pending;
+    this.pending = [];
+    for (const command of pending) {
+      clearTimeout(command.timer);
+      command.reject(new ExternalServiceError(`NATS no disponible: ${error.message}`));
+    }
+  }
+
+  async disconnect() {
+    this.socket?.end();
+  }
+}
+
+/**
+ * Kafka through its REST Proxy (v2 API). The aggregate ID is the record key,
+ * so the events of a trip land in one partition and keep their order.
+ */
+class KafkaBroker {
+  name = "kafka";
+
+  constructor() {
+    this.http = createHttpClient("kafka", { timeoutMs: config.events.timeoutMs });
+  }
+
+  async publish(event) {
+    if (!config.events.kafkaRestUrl) {
+      throw new Error("EVENTS_KAFKA_REST_URL is not configured");
+    }
+    const url = `${config.events.kafkaRestUrl.replace(/\/$/, "")}/topics/${encodeURIComponent(config.events.kafkaTopic)}`;
+    const response = await this.http.fetch(url, {
+      method: "POST",
+      // Consumers deduplicate on eventId, so a retried POST is harmless
+      idempotent: true,
+      headers: {
+        "Content-Type": "application/vnd.kafka.json.v2+json",
+        Accept: "application/vnd.kafka.v2+json",
+      },
+      body: JSON.stringify({ records: [{ key: event.aggregateId, value: event }] }),
+    });
+    const body = await response.json().catch(() => ({}));
+    if (!response.ok) {
+      throw new ExternalServiceError(`Kafka REST Proxy: ${body.message || response.status}`);
+    }
+    const rejected = (body.offsets || []).find((offset) => offset.error_code);
+    if (rejected) {
+      throw new ExternalServiceError(`Kafka: ${rejected.error || rejected.error_code}`);
+    }
+  }
+
+  async disconnect() {}
+}
+
+const BROKERS = {
+  nats: NatsBroker,
+  kafka: KafkaBroker,
+};
+
+/**
+ * Returns the broker selected by EVENTS_BROKER, or null for "none"
+ */
+export const createBroker = (name = config.events.broker) => {
+  if (name === "none") {
+    return null;
+  }
+  const Broker = BROKERS[name];
+  if (!Broker) {
+    throw new Error(`Unknown events broker: ${name}`);
+  }
+  return new Broker();
+};
+
+export default createBroker();
@@ -0,0 +1,72 @@
+import net from "net";
+import config from "../config/index.js";
+import { ExternalServiceError } from "../utils/customErrors.js";
+
+const CHUNK_SIZE = 64 * 1024;
+
+/**
+ * ClamAV (clamd) client using the INSTREAM command over TCP
+ */
+class ClamAvClient {
+  isConfigured() {
+    return Boolean(config.scanner.host);
+  }
+
+  /**
+   * Scans a buffer
+   * @param {Buffer} buffer
+   * @returns {Promise<Object>} - { clean, signature } where signature is the detected threat
+   */
+  scan(buffer) {
+    const { host, port, timeoutMs } = config.scanner;
+
+    return new Promise((resolve, reject) => {
+      const socket = net.createConnection({ host, port });
+      const chunks = [];
+      let settled = false;
+
+      const fail = (message) => {
+        if (settled) return;
+        settled = true;
+        socket.destroy();
+        reject(new ExternalServiceError(`Antivirus no disponible: ${message}`));
+      };
+
+      socket.setTimeout(timeoutMs, () => fail("tiempo de espera agotado"));
+      socket.on("error", (error) => fail(error.message));
+
+      socket.on("connect", () => {
+        socket.write("zINSTREAM\0");
+        for (let offset = 0; offset < buffer.length; offset += CHUNK_SIZE) {
+          const chunk = buffer.subarray(offset, offset + CHUNK_SIZE);
+          const size = Buffer.alloc(4);
+          size.writeUInt32BE(chunk.length, 0);
+          socket.write(size);
+          socket.write(chunk);
+        }
+        // A zero-length chunk ends the stream
+        socket.write(Buffer.alloc(4));
+      });
+
+      socket.on("data", (data) => chunks.push(data));
+
+      socket.on("end", () => {
+        if (settled) return;
+        settled = true;
+
+        // "stream: OK" | "stream: <signature> FOUND" | "<message> ERROR"
+        const reply = Buffer.concat(chunks).toString("utf8").replace(/\0/g, "").trim();
+        if (reply.endsWith("OK")) {
+          resolve({ clean: true, signature: null });
+        } else if (reply.endsWith("FOUND")) {
+          const signature = reply.replace(/^stream:\s*/, "").replace(/\s*FOUND$/, "");
+          resolve({ clean: false, signature });
+        } else {
+          reject(new ExternalServiceError(`Respuesta inesperada del antivirus: ${reply}`));
+        }
+      });
+    });
+  }
+}
+
+export default new ClamAvClient();
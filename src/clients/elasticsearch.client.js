@@ -0,0 +1,107 @@
+import config from "../config/index.js";
+import { ExternalServiceError } from "../utils/customErrors.js";
+import { createHttpClient } from "./http.client.js";
+
+const http = createHttpClient("elasticsearch");
+
+/**
+ * Minimal Elasticsearch search engine over the REST API. Implements the same
+ * interface as the Postgres engine (searchDocument.repository).
+ */
+class ElasticsearchClient {
+  async request(method, path, body) {
+    const { url, apiKey, timeoutMs } = config.search.elasticsearch;
+    const headers = { "Content-Type": "application/json" };
+    if (apiKey) {
+      headers.Authorization = `ApiKey ${apiKey}`;
+    }
+
+    const response = await http.fetch(`${url.replace(/\/$/, "")}${path}`, {
+      method,
+      headers,
+      body: body ? JSON.stringify(body) : undefined,
+      timeoutMs,
+    });
+    if (!response.ok && response.status !== 404) {
+      const text = await response.text();
+      throw new ExternalServiceError(`Elasticsearch ${method} ${path} failed: ${response.status} ${text.slice(0, 200)}`);
+    }
+    return response.status === 404 ? null : await response.json();
+  }
+
+  documentPath(entityType, entityId) {
+    return `/${config.search.elasticsearch.index}/_doc/${entityType}:${entityId}`;
+  }
+
+  /**
+   * Creates the index with its mapping when missing
+   */
+  async ensureIndexes() {
+    const index = config.search.elasticsearch.index;
+    const existing = await this.request("GET", `/${index}`);
+    if (existing) {
+      return;
+    }
+    await this.request("PUT", `/${index}`, {
+      mappings: {
+        properties: {
+          entityType: { type: "keyword" },
+          entityId: { type: "keyword" },
+          title: { type: "text", analyzer: "spanish" },
+          subtitle: { type: "text", analyzer: "spanish" },
+          body: { type: "text", analyzer: "spanish" },
+          isPublic: { type: "boolean" },
+          updatedAt: { type: "date" },
+        },
+      },
+    });
+  }
+
+  async upsert({ entityType, entityId, title, subtitle = null, body = null, isPublic = true }) {
+    await this.request("PUT", this.documentPath(entityType, entityId), {
+      entityType,
+      entityId,
+      title,
+      subtitle,
+      body,
+      isPublic,
+      updatedAt: new Date().toISOString(),
+    });
+  }
+
+  async remove(entityType, entityId) {
+    await this.request("DELETE", this.documentPath(entityType, entityId));
+  }
+
+  /**
+   * The index has no blocks to join: users blocked with the viewer and their
+   * trips come as excludeIds
+   * @param {string} query
+   * @param {Object} options - { types, limit, offset, excludeIds }
+   */
+  async search(query, { types, limit = 20, offset = 0, excludeIds = [] }) {
+    const result = await this.request("POST", `/${config.search.elasticsearch.index}/_search`, {
+      from: offset,
+      size: limit,
+      query: {
+        bool: {
+          must: {
+            multi_match: { query, fields: ["title^3", "subtitle^2", "body"], fuzziness: "AUTO" },
+          },
+          filter: [{ term: { isPublic: true } }, { terms: { entityType: types } }],
+          must_not: excludeIds.length ? [{ terms: { entityId: excludeIds } }] : [],
+        },
+      },
+    });
+
+    return (result?.hits?.hits || []).map((hit) => ({
+      entityType: hit._source.entityType,
+      entityId: hit._source.entityId,
+      title: hit._source.title,
+      subtitle: hit._source.subtitle,
+      score: hit._score,
+    }));
+  }
+}
+
+export default new ElasticsearchClient();
@@ -0,0 +1,83 @@
+import config from "../config/index.js";
+import { createHttpClient } from "./http.client.js";
+
+const ECB_URL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml";
+const OPENEXCHANGERATES_URL = "https://openexchangerates.org/api/latest.json";
+
+const http = createHttpClient("exchange_rates", { timeoutMs: config.exchangeRates.timeoutMs });
+
+/**
+ * Every provider implements fetchLatest(): Promise<{ base, date, rates }>, where
+ * rates maps ISO 4217 codes to units per 1 `base` (the base itself included).
+ */
+
+/**
+ * European Central Bank daily reference rates (EUR based, no key needed)
+ */
+class EcbProvider {
+  name = "ecb";
+
+  async fetchLatest() {
+    const response = await http.fetch(ECB_URL);
+    if (!response.ok) {
+      throw new Error(`ECB rates request failed with status ${response.status}`);
+    }
+
+    const xml = await response.text();
+    const date = xml.match(/time=['"](\d{4}-\d{2}-\d{2})['"]/)?.[1];
+    const rates = { EUR: 1 };
+    for (const [, currency, rate] of xml.matchAll(/currency=['"]([A-Z]{3})['"]\s+rate=['"]([\d.]+)['"]/g)) {
+      rates[currency] = parseFloat(rate);
+    }
+    if (!date || Object.keys(rates).length === 1) {
+      throw new Error("ECB rates response could not be parsed");
+    }
+
+    return { base: "EUR", date, rates };
+  }
+}
+
+/**
+ * openexchangerates.org latest rates (USD based on the free plan)
+ */
+class OpenExchangeRatesProvider {
+  name = "openexchangerates";
+
+  async fetchLatest() {
+    if (!config.exchangeRates.openExchangeRatesAppId) {
+      throw new Error("OPENEXCHANGERATES_APP_ID is not configured");
+    }
+
+    const url = new URL(OPENEXCHANGERATES_URL);
+    url.searchParams.set("app_id", config.exchangeRates.openExchangeRatesAppId);
+    const response = await http.fetch(url);
+    const body = await response.json();
+    if (!response.ok || body.error) {
+      throw new Error(`openexchangerates request failed: ${body.description || response.status}`);
+    }
+
+    return {
+      base: body.base,
+      date: new Date(body.timestamp * 1000).toISOString().slice(0, 10),
+      rates: body.rates,
+    };
+  }
+}
+
+const PROVIDERS = {
+  ecb: EcbProvider,
+  openexchangerates: OpenExchangeRatesProvider,
+};
+
+/**
+ * Returns the provider selected by EXCHANGE_RATES_PROVIDER
+ */
+export const createExchangeRateProvider = (name = config.exchangeRates.provider) => {
+  const Provider = PROVIDERS[name];
+  if (!Provider) {
+    throw new Error(`Unknown exchange rate provider: ${name}`);
+  }
+  return new Provider();
+};
+
+export default createExchangeRateProvider();
@@ -0,0 +1,141 @@
+import crypto from "crypto";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { createHttpClient } from "./http.client.js";
+
+const TOKEN_URL = "https://oauth2.googleapis.com/token";
+const SCOPE = "https://www.googleapis.com/auth/firebase.messaging";
+
+const http = createHttpClient("fcm");
+
+const base64url = (input) =>
+  Buffer.from(input)
+    .toString("base64")
+    .replace(/=/g, "")
+    .replace(/\+/g, "-")
+    .replace(/\//g, "_");
+
+/**
+ * Firebase Cloud Messaging (HTTP v1) client.
+ * Authenticates with a service account and caches the OAuth access token.
+ */
+class FcmClient {
+  constructor() {
+    this.accessToken = null;
+    this.accessTokenExpiresAt = 0;
+  }
+
+  isConfigured() {
+    const { projectId, clientEmail, privateKey } = config.push.fcm;
+    return Boolean(projectId && clientEmail && privateKey);
+  }
+
+  /**
+   * Gets an OAuth access token for the service account, reusing the cached one
+   * @returns {Promise<string>}
+   */
+  async getAccessToken() {
+    if (this.accessToken && Date.now() < this.accessTokenExpiresAt - 60 * 1000) {
+      return this.accessToken;
+    }
+
+    const { clientEmail, privateKey } = config.push.fcm;
+    const now = Math.floor(Date.now() / 1000);
+    const header = base64url(JSON.stringify({ alg: "RS256", typ: "JWT" }));
+    const claims = base64url(
+      JSON.stringify({
+        iss: clientEmail,
+        scope: SCOPE,
+        aud: TOKEN_URL,
+        iat: now,
+        exp: now + 3600,
+      })
+    );
+    const signature = crypto
+      .createSign("RSA-SHA256")
+      .update(`${header}.${claims}`)
+      .sign(privateKey);
+    const assertion = `${header}.${claims}.${base64url(signature)}`;
+
+    const response = await http.fetch(TOKEN_URL, {
+      method: "POST",
+      // Asking for a token again has no side effects
+      idempotent: true,
+      headers: { "Content-Type": "application/x-www-form-urlencoded" },
+      body: new URLSearchParams({
+        grant_type: "urn:ietf:params:oauth:grant-type:jwt-bearer",
+        assertion,
+      }),
+    });
+
+    if (!response.ok) {
+      throw new Error(`FCM auth failed with status ${response.status}`);
+    }
+
+    const body = await response.json();
+    this.accessToken = body.access_token;
+    this.accessTokenExpiresAt = Date.now() + body.expires_in * 1000;
+    return this.accessToken;
+  }
+
+  /**
+   * Sends a push message to a single device
+   * @param {string} token - FCM registration token
+   * @param {Object} payload - { title, body, data }
+   * @returns {Promise<Object>} - { success, invalidToken, error }
+   */
+  async send(token, { title, body, data = {} }) {
+    const accessToken = await this.getAccessToken();
+
+    // FCM only accepts string values in the data map
+    const stringData = Object.fromEntries(
+      Object.entries(data)
+        .filter(([, value]) => value !== undefined && value !== null)
+        .map(([key, value]) => [
+          key,
+          typeof value === "string" ? value : JSON.stringify(value),
+        ])
+    );
+
+    const response = await http.fetch(
+      `https://fcm.googleapis.com/v1/projects/${config.push.fcm.projectId}/messages:send`,
+      {
+        method: "POST",
+        headers: {
+          Authorization: `Bearer ${accessToken}`,
+          "Content-Type": "application/json",
+        },
+        body: JSON.stringify({
+          message: {
+            token,
+            notification: { title, body },
+            data: stringData,
+          },
+        }),
+      }
+    );
+
+    if (response.ok) {
+      return { success: true, invalidToken: false };
+    }
+
+    const errorBody = await response.json().catch(() => ({}));
+    const errorCode = errorBody?.error?.details?.find((d) => d.errorCode)?.errorCode;
+    const invalidToken =
+      response.status === 404 ||
+      errorCode === "UNREGISTERED" ||
+      errorCode === "INVALID_ARGUMENT";
+
+    logger.warn(
+      `[FCM] Send failed with status ${response.status}${errorCode ? ` (${errorCode})` : ""}`
+    );
+
+    return {
+      success: false,
+      invalidToken,
+      error: errorCode || `HTTP ${response.status}`,
+    };
+  }
+}
+
+export default new FcmClient();
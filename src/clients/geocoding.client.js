@@ -0,0 +1,93 @@
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { createHttpClient } from "./http.client.js";
+
+const GOOGLE_URL = "https://maps.googleapis.com/maps/api/geocode/json";
+const NOMINATIM_URL = "https://nominatim.openstreetmap.org/search";
+
+const http = createHttpClient("geocoding", { timeoutMs: config.geocoding.timeoutMs });
+
+/**
+ * Resolves place names to coordinates. Uses the Google Geocoding API when
+ * GOOGLE_MAPS_API_KEY is set and falls back to OpenStreetMap Nominatim otherwise.
+ */
+class GeocodingClient {
+  /**
+   * @param {string} query - Destination name (e.g. "Bariloche, Argentina")
+   * @returns {Promise<Object|null>} - { lat, lng, formattedAddress } or null if not found
+   */
+  async geocode(query) {
+    if (!query || !query.trim()) {
+      return null;
+    }
+    return config.geocoding.googleApiKey
+      ? await this.geocodeWithGoogle(query.trim())
+      : await this.geocodeWithNominatim(query.trim());
+  }
+
+  async geocodeWithGoogle(query) {
+    const url = new URL(GOOGLE_URL);
+    url.searchParams.set("address", query);
+    url.searchParams.set("key", config.geocoding.googleApiKey);
+    url.searchParams.set("language", "es");
+
+    const response = await http.fetch(url);
+    const body = await response.json();
+
+    if (body.status === "ZERO_RESULTS") {
+      return null;
+    }
+    if (body.status !== "OK") {
+      throw new Error(`Google geocoding failed: ${body.status} ${body.error_message || ""}`.trim());
+    }
+
+    const [result] = body.results;
+    return {
+      lat: result.geometry.location.lat,
+      lng: result.geometry.location.lng,
+      formattedAddress: result.formatted_address,
+    };
+  }
+
+  async geocodeWithNominatim(query) {
+    const url = new URL(NOMINATIM_URL);
+    url.searchParams.set("q", query);
+    url.searchParams.set("format", "json");
+    url.searchParams.set("limit", "1");
+
+    const response = await http.fetch(url, {
+      headers: {
+        // Nominatim's usage policy requires an identifying User-Agent
+        "User-Agent": config.geocoding.userAgent,
+        "Accept-Language": "es",
+      },
+    });
+    if (!response.ok) {
+      throw new Error(`Nominatim geocoding failed with status ${response.status}`);
+    }
+
+    const [result] = await response.json();
+    if (!result) {
+      return null;
+    }
+    return {
+      lat: parseFloat(result.lat),
+      lng: parseFloat(result.lon),
+      formattedAddress: result.display_name,
+    };
+  }
+
+  /**
+   * Same as geocode but never throws: failures are logged and return null
+   */
+  async tryGeocode(query) {
+    try {
+      return await this.geocode(query);
+    } catch (error) {
+      logger.warn(`Geocoding "${query}" failed: ${error.message}`);
+      return null;
+    }
+  }
+}
+
+export default new GeocodingClient();
@@ -0,0 +1,223 @@
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { CircuitOpenError } from "../utils/customErrors.js";
+import { getContext, getRemainingMs, getRequestId, requestSignal } from "../utils/requestContext.js";
+
+const IDEMPOTENT_METHODS = new Set(["GET", "HEAD", "OPTIONS", "PUT", "DELETE"]);
+const RETRYABLE_STATUSES = new Set([429, 502, 503, 504]);
+// Retry-After values past this are not waited for
+const MAX_RETRY_AFTER_MS = 10 * 1000;
+
+// "client host" -> { client, host, breaker, stats }, one per integration and host
+const hosts = new Map();
+
+const sleep = (ms) => new Promise((resolve) => setTimeout(resolve, ms));
+
+/**
+ * Circuit breaker of one host. Opens after breakerFailureThreshold failures
+ * in a row (network errors, timeouts, 5xx); while open calls fail at once,
+ * and after breakerResetSeconds a single probe call decides whether it closes.
+ */
+class CircuitBreaker {
+  constructor() {
+    this.state = "closed";
+    this.failures = 0;
+    this.openedAt = null;
+    this.probing = false;
+  }
+
+  allow() {
+    if (this.state === "closed") return true;
+    if (this.state === "open" && Date.now() - this.openedAt >= config.httpClient.breakerResetSeconds * 1000) {
+      this.state = "half_open";
+    }
+    if (this.state === "half_open" && !this.probing) {
+      this.probing = true;
+      return true;
+    }
+    return false;
+  }
+
+  onSuccess() {
+    this.state = "closed";
+    this.failures = 0;
+    this.openedAt = null;
+    this.probing = false;
+  }
+
+  onFailure() {
+    this.failures += 1;
+    this.probing = false;
+    if (this.state === "half_open" || this.failures >= config.httpClient.breakerFailureThreshold) {
+      this.state = "open";
+      this.openedAt = Date.now();
+    }
+  }
+
+  // A call that ended without telling anything about the host (e.g. our request was abandoned)
+  onNeutral() {
+    this.probing = false;
+  }
+}
+
+const hostEntry = (clientName, host) => {
+  const key = `${clientName} ${host}`;
+  let entry = hosts.get(key);
+  if (!entry) {
+    entry = {
+      client: clientName,
+      host,
+      breaker: new CircuitBreaker(),
+      stats: { calls: 0, failures: 0, retries: 0, rejected: 0, totalMs: 0, maxMs: 0, lastError: null, lastErrorAt: null },
+    };
+    hosts.set(key, entry);
+  }
+  return entry;
+};
+
+// Full jitter: a random wait up to the exponential backoff of the attempt
+const backoffMs = (attempt) =>
+  Math.random() * Math.min(config.httpClient.retryMaxMs, config.httpClient.retryBaseMs * 2 ** attempt);
+
+const retryAfterMs = (response) => {
+  const header = response.headers.get("retry-after");
+  if (!header) return null;
+  const seconds = Number(header);
+  const ms = Number.isFinite(seconds) ? seconds * 1000 : new Date(header).getTime() - Date.now();
+  return Number.isFinite(ms) && ms >= 0 ? ms : null;
+};
+
+/**
+ * Outbound HTTP for the integration clients (geocoding, exchange rates,
+ * Stripe, OAuth, weather...). Same interface as fetch, plus:
+ * - a timeout per attempt that also honours the deadline of the API request
+ * - retries with jitter for idempotent calls (network errors, 429, 502-504)
+ * - a circuit breaker per host, listed on the admin status page
+ * - stats per host and the X-Request-Id of the request on every call
+ * Sentry (whose failures are logged, which would report them again) and S3
+ * (streamed bodies cannot be replayed) keep calling fetch directly.
+ */
+class HttpClient {
+  /**
+   * @param {string} name - Integration name, e.g. "geocoding"
+   * @param {Object} options - { timeoutMs, retries }
+   */
+  constructor(name, { timeoutMs = 10000, retries = config.httpClient.retries } = {}) {
+    this.name = name;
+    this.timeoutMs = timeoutMs;
+    this.retries = retries;
+  }
+
+  /**
+   * @param {string|URL} url
+   * @param {Object} options - fetch options plus { timeoutMs, retries, idempotent: retry a
+   *   non-idempotent method anyway (e.g. a POST with an Idempotency-Key) }
+   * @returns {Promise<Response>} Responses with any status; throws on network errors,
+   *   timeouts and while the breaker of the host is open
+   */
+  async fetch(url, { timeoutMs = this.timeoutMs, retries = this.retries, idempotent, headers = {}, ...options } = {}) {
+    const target = new URL(url);
+    const method = (options.method || "GET").toUpperCase();
+    const entry = hostEntry(this.name, target.host);
+    const canRetry = idempotent ?? IDEMPOTENT_METHODS.has(method);
+    const requestId = getRequestId();
+    const callHeaders = requestId ? { "X-Request-Id": requestId, ...headers } : headers;
+
+    for (let attempt = 0; ; attempt++) {
+      if (!entry.breaker.allow()) {
+        entry.stats.rejected += 1;
+        throw new CircuitOpenError(`${this.name}: circuit open for ${target.host}`);
+      }
+
+      const startedAt = Date.now();
+      let response = null;
+      let failure = null;
+      try {
+        response = await fetch(target, { ...options, method, headers: callHeaders, signal: requestSignal(timeoutMs) });
+      } catch (error) {
+        failure = error;
+      }
+      const elapsedMs = Date.now() - startedAt;
+      entry.stats.calls += 1;
+      entry.stats.totalMs += elapsedMs;
+      entry.stats.maxMs = Math.max(entry.stats.maxMs, elapsedMs);
+
+      // The API request was abandoned: nothing learned about the host, nothing to retry
+      if (failure && getContext()?.signal?.aborted) {
+        entry.breaker.onNeutral();
+        throw failure;
+      }
+
+      const hostFailed = failure !== null || response.status >= 500;
+      if (hostFailed) {
+        entry.breaker.onFailure();
+        entry.stats.failures += 1;
+        entry.stats.lastError = failure ? failure.message : `HTTP ${response.status}`;
+        entry.stats.lastErrorAt = new Date();
+      } else {
+        entry.breaker.onSuccess();
+      }
+
+      const retryable = failure !== null || RETRYABLE_STATUSES.has(response.status);
+      let waitMs = backoffMs(attempt);
+      if (response?.status === 429 || response?.status === 503) {
+        waitMs = retryAfterMs(response) ?? waitMs;
+      }
+      const remainingMs = getRemainingMs();
+      const retry =
+        retryable &&
+        canRetry &&
+        attempt < retries &&
+        waitMs <= MAX_RETRY_AFTER_MS &&
+        (remainingMs === null || remainingMs > waitMs + timeoutMs / 2);
+
+      logger.debug(`[HTTP] ${this.name} ${method} ${target.host}${target.pathname}`, {
+        requestId,
+        attempt: attempt + 1,
+        status: response?.status ?? null,
+        error: failure?.message,
+        durationMs: elapsedMs,
+      });
+
+      if (!retry) {
+        if (failure) {
+          logger.warn(`[HTTP] ${this.name} ${method} ${target.host} failed after ${attempt + 1} attempts: ${failure.message}`);
+          throw failure;
+        }
+        return response;
+      }
+
+      entry.stats.retries += 1;
+      // Free the connection before retrying
+      await response?.body?.cancel().catch(() => {});
+      await sleep(waitMs);
+    }
+  }
+}
+
+/**
+ * @param {string} name - Integration name, shown with the host on the status page
+ * @param {Object} options - { timeoutMs, retries }
+ * @returns {HttpClient}
+ */
+export const createHttpClient = (name, options) => new HttpClient(name, options);
+
+/**
+ * Breaker state and call stats of every host called so far
+ * @returns {Array} - [{ name, state, failures, calls, errors, retries, rejected, avgMs, maxMs, lastError, lastErrorAt }]
+ */
+export const getHostStates = () =>
+  [...hosts.values()].map(({ client, host, breaker, stats }) => ({
+    name: `${client} (${host})`,
+    state: breaker.state,
+    failures: breaker.failures,
+    openedAt: breaker.openedAt ? new Date(breaker.openedAt) : null,
+    calls: stats.calls,
+    errors: stats.failures,
+    retries: stats.retries,
+    rejected: stats.rejected,
+    avgMs: stats.calls ? Math.round(stats.totalMs / stats.calls) : null,
+    maxMs: stats.maxMs,
+    lastError: stats.lastError,
+    lastErrorAt: stats.lastErrorAt,
+  }));
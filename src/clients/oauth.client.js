@@ -0,0 +1,125 @@
+import crypto from "crypto";
+import jwt from "jsonwebtoken";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { createHttpClient } from "./http.client.js";
+
+// Signing keys rotate rarely; an unknown kid refetches them, at most once a minute
+const JWKS_TTL_MS = 60 * 60 * 1000;
+const JWKS_MIN_REFETCH_MS = 60 * 1000;
+
+const http = createHttpClient("oauth", { timeoutMs: config.oauth.timeoutMs });
+
+const PROVIDERS = {
+  google: {
+    jwksUrl: "https://www.googleapis.com/oauth2/v3/certs",
+    issuers: ["https://accounts.google.com", "accounts.google.com"],
+    tokenInfoUrl: "https://oauth2.googleapis.com/tokeninfo",
+  },
+  apple: {
+    jwksUrl: "https://appleid.apple.com/auth/keys",
+    issuers: ["https://appleid.apple.com"],
+  },
+};
+
+// Apple sends booleans in claims as strings
+const isTrue = (value) => value === true || value === "true";
+
+/**
+ * Verifies the tokens the apps get from Sign in with Google and Sign in
+ * with Apple, and returns who they belong to. Errors mean the token is not
+ * valid for us (bad signature, expired, other audience...).
+ */
+class OAuthClient {
+  constructor() {
+    // provider -> { keys: Map(kid -> KeyObject), fetchedAt }
+    this.jwks = new Map();
+  }
+
+  isEnabled(provider) {
+    return Boolean(PROVIDERS[provider]) && config.oauth[provider].clientIds.length > 0;
+  }
+
+  /**
+   * @param {string} provider - google | apple
+   * @param {string} idToken - OpenID Connect ID token
+   * @param {string} nonce - Nonce the app sent to the provider, if any
+   * @returns {Promise<Object>} - { subject, email, emailVerified, name }
+   */
+  async verifyIdToken(provider, idToken, nonce = null) {
+    const decoded = jwt.decode(idToken, { complete: true });
+    if (!decoded?.header?.kid) {
+      throw new Error("Malformed ID token");
+    }
+    const key = await this.getSigningKey(provider, decoded.header.kid);
+    const claims = jwt.verify(idToken, key, {
+      algorithms: ["RS256"],
+      audience: config.oauth[provider].clientIds,
+      issuer: PROVIDERS[provider].issuers,
+    });
+    if (nonce && claims.nonce !== nonce) {
+      throw new Error("ID token nonce mismatch");
+    }
+    return {
+      subject: claims.sub,
+      email: claims.email ? claims.email.toLowerCase() : null,
+      emailVerified: isTrue(claims.email_verified),
+      name: claims.name || null,
+    };
+  }
+
+  /**
+   * Google access tokens (web flows without an ID token), checked against
+   * Google's tokeninfo endpoint since they are opaque to us
+   * @param {string} accessToken
+   * @returns {Promise<Object>} - { subject, email, emailVerified, name }
+   */
+  async verifyGoogleAccessToken(accessToken) {
+    const url = new URL(PROVIDERS.google.tokenInfoUrl);
+    url.searchParams.set("access_token", accessToken);
+    const response = await http.fetch(url);
+    if (!response.ok) {
+      throw new Error(`Google rejected the access token (${response.status})`);
+    }
+    const info = await response.json();
+    if (!config.oauth.google.clientIds.includes(info.aud) && !config.oauth.google.clientIds.includes(info.azp)) {
+      throw new Error("Access token issued to another client");
+    }
+    return {
+      subject: info.sub,
+      email: info.email ? info.email.toLowerCase() : null,
+      emailVerified: isTrue(info.email_verified),
+      name: null,
+    };
+  }
+
+  async getSigningKey(provider, kid) {
+    let cached = this.jwks.get(provider);
+    const age = cached ? Date.now() - cached.fetchedAt : Infinity;
+    if (age > JWKS_TTL_MS || (!cached.keys.has(kid) && age > JWKS_MIN_REFETCH_MS)) {
+      cached = await this.fetchJwks(provider);
+    }
+    const key = cached.keys.get(kid);
+    if (!key) {
+      throw new Error(`Unknown ${provider} signing key ${kid}`);
+    }
+    return key;
+  }
+
+  async fetchJwks(provider) {
+    const response = await http.fetch(PROVIDERS[provider].jwksUrl);
+    if (!response.ok) {
+      throw new Error(`Could not fetch ${provider} signing keys (${response.status})`);
+    }
+    const { keys = [] } = await response.json();
+    const cached = {
+      keys: new Map(keys.map((jwk) => [jwk.kid, crypto.createPublicKey({ key: jwk, format: "jwk" })])),
+      fetchedAt: Date.now(),
+    };
+    this.jwks.set(provider, cached);
+    logger.info(`Loaded ${cached.keys.size} ${provider} signing keys`);
+    return cached;
+  }
+}
+
+export default new OAuthClient();
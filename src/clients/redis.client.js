@@ -0,0 +1,243 @@
+import net from "net";
+import tls from "tls";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { ExternalServiceError } from "../utils/customErrors.js";
+
+/**
+ * Encodes a command as a RESP array of bulk strings
+ */
+const encodeCommand = (args) => {
+  let out = `*${args.length}\r\n`;
+  for (const arg of args) {
+    const value = String(arg);
+    out += `$${Buffer.byteLength(value)}\r\n${value}\r\n`;
+  }
+  return out;
+};
+
+/**
+ * Parses one RESP2 reply starting at `start`
+ * @returns {Object|null} - { value, offset }, or null when the reply is incomplete
+ */
+const parseReply = (buffer, start) => {
+  const lineEnd = buffer.indexOf("\r\n", start);
+  if (lineEnd === -1) {
+    return null;
+  }
+  const type = String.fromCharCode(buffer[start]);
+  const line = buffer.toString("utf8", start + 1, lineEnd);
+  const next = lineEnd + 2;
+
+  switch (type) {
+    case "+":
+      return { value: line, offset: next };
+    case "-":
+      return { value: new ExternalServiceError(`Redis: ${line}`), offset: next };
+    case ":":
+      return { value: parseInt(line, 10), offset: next };
+    case "$": {
+      const length = parseInt(line, 10);
+      if (length === -1) {
+        return { value: null, offset: next };
+      }
+      if (buffer.length < next + length + 2) {
+        return null;
+      }
+      return { value: buffer.toString("utf8", next, next + length), offset: next + length + 2 };
+    }
+    case "*": {
+      const count = parseInt(line, 10);
+      if (count === -1) {
+        return { value: null, offset: next };
+      }
+      const items = [];
+      let offset = next;
+      for (let i = 0; i < count; i++) {
+        const item = parseReply(buffer, offset);
+        if (!item) {
+          return null;
+        }
+        items.push(item.value);
+        offset = item.offset;
+      }
+      return { value: items, offset };
+    }
+    default:
+      throw new ExternalServiceError(`Redis: unexpected reply type "${type}"`);
+  }
+};
+
+/**
+ * Minimal Redis client (RESP2 over TCP/TLS) for the handful of commands the
+ * API needs. Keeps one connection open and pipelines commands on it; Redis
+ * answers in order, so each reply resolves the oldest pending command.
+ */
+class RedisClient {
+  constructor() {
+    this.socket = null;
+    this.connecting = null;
+    this.pending = [];
+    this.buffer = Buffer.alloc(0);
+  }
+
+  isConfigured() {
+    return Boolean(config.redis.url);
+  }
+
+  connect() {
+    if (this.socket) {
+      return Promise.resolve();
+    }
+    if (this.connecting) {
+      return this.connecting;
+    }
+
+    const url = new URL(config.redis.url);
+    const options = { host: url.hostname, port: parseInt(url.port, 10) || 6379 };
+
+    this.connecting = new Promise((resolve, reject) => {
+      const socket =
+        url.protocol === "rediss:"
+          ? tls.connect({ ...options, servername: url.hostname })
+          : net.createConnection(options);
+      const readyEvent = url.protocol === "rediss:" ? "secureConnect" : "connect";
+
+      socket.setTimeout(config.redis.timeoutMs, () => {
+        socket.destroy(new Error("tiempo de espera agotado"));
+      });
+      socket.once(readyEvent, () => {
+        socket.setTimeout(0);
+        socket.setNoDelay(true);
+        this.socket = socket;
+        this.connecting = null;
+
+        // Queued before any caller command, so they run first on the connection
+        if (url.password) {
+          const auth = url.username
+            ? ["AUTH", decodeURIComponent(url.username), decodeURIComponent(url.password)]
+            : ["AUTH", decodeURIComponent(url.password)];
+          this.send(auth).catch(() => {});
+        }
+        const db = url.pathname.replace("/", "");
+        if (db) {
+          this.send(["SELECT", db]).catch(() => {});
+        }
+        resolve();
+      });
+      socket.on("data", (data) => this.onData(data));
+      socket.on("error", (error) => {
+        logger.error(`[Redis] Connection error: ${error.message}`);
+        if (this.connecting) {
+          this.connecting = null;
+          reject(new ExternalServiceError(`Redis no disponible: ${error.message}`));
+        }
+        this.reset(error);
+      });
+      socket.on("close", () => this.reset(new Error("conexión cerrada")));
+    });
+
+    return this.connecting;
+  }
+
+  /**
+   * Runs a command
+   * @param {...string|number} args - e.g. ("HGETALL", key)
+   * @returns {Promise<*>} Reply
+   */
+  async command(...args) {
+    await this.connect();
+    return await this.send(args);
+  }
+
+  send(args) {
+    return new Promise((resolve, reject) => {
+      if (!this.socket) {
+        reject(new ExternalServiceError("Redis no disponible: sin conexión"));
+        return;
+      }
+      // A reply that never arrives would shift every later reply, so drop the connection
+      const timer = setTimeout(
+        () => this.reset(new Error("tiempo de espera agotado")),
+        config.redis.timeoutMs
+      );
+      this.pending.push({ resolve, reject, timer });
+      this.socket.write(encodeCommand(args));
+    });
+  }
+
+  onData(data) {
+    this.buffer = this.buffer.length ? Buffer.concat([this.buffer, data]) : data;
+
+    while (this.buffer.length > 0) {
+      let reply;
+      try {
+        reply = parseReply(this.buffer, 0);
+      } catch (error) {
+        this.reset(error);
+        return;
+      }
+      if (!reply) {
+        return;
+      }
+      this.buffer = this.buffer.subarray(reply.offset);
+
+      const command = this.pending.shift();
+      if (!command) {
+        continue;
+      }
+      clearTimeout(command.timer);
+      if (reply.value instanceof Error) {
+        command.reject(reply.value);
+      } else {
+        command.resolve(reply.value);
+      }
+    }
+  }
+
+  /**
+   * Drops the connection and fails every pending command
+   */
+  reset(error) {
+    if (this.socket) {
+      this.socket.removeAllListeners("close");
+      this.socket.destroy();
+      this.socket = null;
+    }
+    this.buffer = Buffer.alloc(0);
+    const pending = this.pending;
+    this.pending = [];
+    for (const command of pending) {
+      clearTimeout(command.timer);
+      command.reject(new ExternalServiceError(`Redis no disponible: ${error.message}`));
+    }
+  }
+
+  disconnect() {
+    if (this.socket) {
+      this.socket.end();
+    }
+  }
+
+  /**
+   * Hash fields as an object ({} when the key does not exist)
+   */
+  async hgetall(key) {
+    const reply = (await this.command("HGETALL", key)) || [];
+    const hash = {};
+    for (let i = 0; i < reply.length; i += 2) {
+      hash[reply[i]] = reply[i + 1];
+    }
+    return hash;
+  }
+
+  async eval(script, keys = [], args = []) {
+    return await this.command("EVAL", script, keys.length, ...keys, ...args);
+  }
+
+  async del(...keys) {
+    return keys.length ? await this.command("DEL", ...keys) : 0;
+  }
+}
+
+export default new RedisClient();
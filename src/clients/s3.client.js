@@ -0,0 +1,255 @@
+import crypto from "crypto";
+import config from "../config/index.js";
+import { ExternalServiceError } from "../utils/customErrors.js";
+import { requestSignal } from "../utils/requestContext.js";
+
+const ALGORITHM = "AWS4-HMAC-SHA256";
+const UNSIGNED_PAYLOAD = "UNSIGNED-PAYLOAD";
+
+const sha256 = (data) => crypto.createHash("sha256").update(data).digest("hex");
+const hmac = (key, data) => crypto.createHmac("sha256", key).update(data).digest();
+
+// RFC 3986 encoding as required by SigV4
+const encodeRfc3986 = (value) =>
+  encodeURIComponent(value).replace(
+    /[!'()*]/g,
+    (char) => `%${char.charCodeAt(0).toString(16).toUpperCase()}`
+  );
+
+const encodeKey = (key) => key.split("/").map(encodeRfc3986).join("/");
+
+/**
+ * Minimal S3-compatible client (AWS S3, MinIO, R2...) signing requests with SigV4.
+ * Covers presigned URLs and the few object operations the upload pipeline needs.
+ */
+class S3Client {
+  isConfigured() {
+    const { endpoint, bucket, accessKeyId, secretAccessKey } = config.storage;
+    return Boolean(endpoint && bucket && accessKeyId && secretAccessKey);
+  }
+
+  /**
+   * Resolves the URL of an object (path-style for MinIO, virtual-hosted otherwise)
+   * @param {string} key
+   * @returns {URL}
+   */
+  objectUrl(key) {
+    const { endpoint, bucket, forcePathStyle } = config.storage;
+    const base = new URL(endpoint);
+    if (forcePathStyle) {
+      return new URL(`${base.origin}/${bucket}/${encodeKey(key)}`);
+    }
+    return new URL(`${base.protocol}//${bucket}.${base.host}/${encodeKey(key)}`);
+  }
+
+  getSigningKey(dateStamp) {
+    const { secretAccessKey, region } = config.storage;
+    const kDate = hmac(`AWS4${secretAccessKey}`, dateStamp);
+    const kRegion = hmac(kDate, region);
+    const kService = hmac(kRegion, "s3");
+    return hmac(kService, "aws4_request");
+  }
+
+  /**
+   * Signs a canonical request and returns the hex signature
+   */
+  sign({ method, url, query, headers, payloadHash, amzDate }) {
+    const dateStamp = amzDate.slice(0, 8);
+    const scope = `${dateStamp}/${config.storage.region}/s3/aws4_request`;
+
+    const headerNames = Object.keys(headers)
+      .map((name) => name.toLowerCase())
+      .sort();
+    const canonicalHeaders = headerNames
+      .map((name) => {
+        const value = Object.entries(headers).find(([key]) => key.toLowerCase() === name)[1];
+        return `${name}:${String(value).trim()}\n`;
+      })
+      .join("");
+    const signedHeaders = headerNames.join(";");
+
+    const canonicalQuery = Object.keys(query)
+      .sort()
+      .map((key) => `${encodeRfc3986(key)}=${encodeRfc3986(query[key])}`)
+      .join("&");
+
+    const canonicalRequest = [
+      method,
+      url.pathname,
+      canonicalQuery,
+      canonicalHeaders,
+      signedHeaders,
+      payloadHash,
+    ].join("\n");
+
+    const stringToSign = [ALGORITHM, amzDate, scope, sha256(canonicalRequest)].join("\n");
+    const signature = crypto
+      .createHmac("sha256", this.getSigningKey(dateStamp))
+      .update(stringToSign)
+      .digest("hex");
+
+    return { signature, scope, signedHeaders };
+  }
+
+  amzDate(date = new Date()) {
+    return date.toISOString().replace(/[:-]|\.\d{3}/g, "");
+  }
+
+  /**
+   * Builds a presigned URL for a direct client upload/download
+   * @param {string} method - "PUT" | "GET"
+   * @param {string} key - Object key
+   * @param {Object} options - { expiresIn (seconds), contentType (PUT only) }
+   * @returns {string}
+   */
+  presignUrl(method, key, { expiresIn = config.storage.presignExpiresIn, contentType } = {}) {
+    const url = this.objectUrl(key);
+    const amzDate = this.amzDate();
+    const headers = { host: url.host };
+    if (contentType) {
+      headers["content-type"] = contentType;
+    }
+
+    const { accessKeyId, region } = config.storage;
+    const query = {
+      "X-Amz-Algorithm": ALGORITHM,
+      "X-Amz-Credential": `${accessKeyId}/${amzDate.slice(0, 8)}/${region}/s3/aws4_request`,
+      "X-Amz-Date": amzDate,
+      "X-Amz-Expires": String(expiresIn),
+      "X-Amz-SignedHeaders": Object.keys(headers).sort().join(";"),
+    };
+
+    const { signature } = this.sign({
+      method,
+      url,
+      query,
+      headers,
+      payloadHash: UNSIGNED_PAYLOAD,
+      amzDate,
+    });
+
+    const search = Object.keys(query)
+      .sort()
+      .map((name) => `${encodeRfc3986(name)}=${encodeRfc3986(query[name])}`)
+      .join("&");
+    return `${url.origin}${url.pathname}?${search}&X-Amz-Signature=${signature}`;
+  }
+
+  /**
+   * Sends a header-signed request to the storage
+   */
+  async request(method, key, { body = null, contentType = null, headers: extraHeaders = {} } = {}) {
+    if (!this.isConfigured()) {
+      throw new ExternalServiceError("El almacenamiento de archivos no está configurado");
+    }
+
+    const url = this.objectUrl(key);
+    const amzDate = this.amzDate();
+    const payloadHash = sha256(body || "");
+    const headers = {
+      host: url.host,
+      "x-amz-content-sha256": payloadHash,
+      "x-amz-date": amzDate,
+      ...extraHeaders,
+    };
+    if (contentType) {
+      headers["content-type"] = contentType;
+    }
+
+    const { signature, scope, signedHeaders } = this.sign({
+      method,
+      url,
+      query: {},
+      headers,
+      payloadHash,
+      amzDate,
+    });
+
+    const { host, ...fetchHeaders } = headers;
+    try {
+      return await fetch(url, {
+        method,
+        headers: {
+          ...fetchHeaders,
+          Authorization: `${ALGORITHM} Credential=${config.storage.accessKeyId}/${scope}, SignedHeaders=${signedHeaders}, Signature=${signature}`,
+        },
+        body,
+        signal: requestSignal(30000),
+      });
+    } catch (error) {
+      throw new ExternalServiceError(`Error de conexión con el almacenamiento: ${error.message}`);
+    }
+  }
+
+  /**
+   * Uploads an object
+   * @param {string} key
+   * @param {Buffer} body
+   * @param {string} contentType
+   */
+  async putObject(key, body, contentType) {
+    const response = await this.request("PUT", key, { body, contentType });
+    if (!response.ok) {
+      throw new ExternalServiceError(`Error al subir el archivo (${response.status})`);
+    }
+  }
+
+  /**
+   * Copies an object inside the bucket
+   * @param {string} sourceKey
+   * @param {string} key
+   */
+  async copyObject(sourceKey, key) {
+    const response = await this.request("PUT", key, {
+      headers: { "x-amz-copy-source": `/${config.storage.bucket}/${encodeKey(sourceKey)}` },
+    });
+    if (!response.ok) {
+      throw new ExternalServiceError(`Error al copiar el archivo (${response.status})`);
+    }
+  }
+
+  /**
+   * Gets the metadata of an object
+   * @param {string} key
+   * @returns {Promise<Object|null>} - { size, contentType } or null if it does not exist
+   */
+  async headObject(key) {
+    const response = await this.request("HEAD", key);
+    if (response.status === 404) {
+      return null;
+    }
+    if (!response.ok) {
+      throw new ExternalServiceError(`Error al consultar el archivo (${response.status})`);
+    }
+    return {
+      size: parseInt(response.headers.get("content-length"), 10) || 0,
+      contentType: response.headers.get("content-type"),
+    };
+  }
+
+  /**
+   * Downloads an object
+   * @param {string} key
+   * @returns {Promise<Buffer>}
+   */
+  async getObject(key) {
+    const response = await this.request("GET", key);
+    if (!response.ok) {
+      throw new ExternalServiceError(`Error al descargar el archivo (${response.status})`);
+    }
+    return Buffer.from(await response.arrayBuffer());
+  }
+
+  /**
+   * Deletes an object (missing objects are not an error)
+   * @param {string} key
+   */
+  async deleteObject(key) {
+    const response = await this.request("DELETE", key);
+    if (!response.ok && response.status !== 404) {
+      throw new ExternalServiceError(`Error al eliminar el archivo (${response.status})`);
+    }
+  }
+}
+
+export default new S3Client();
@@ -0,0 +1,121 @@
+import crypto from "crypto";
+import os from "os";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { getRequestId } from "../utils/requestContext.js";
+
+const SEND_TIMEOUT_MS = 3000;
+const STACK_LINE_REGEX = /^\s*at (?:(.+?) \()?(.+?):(\d+):(\d+)\)?$/;
+// Request headers never sent along with an event
+const REDACTED_HEADERS = ["authorization", "cookie", "x-api-key", "stripe-signature"];
+
+/**
+ * Frames of an Error stack, oldest call first as Sentry expects
+ */
+const parseStack = (stack = "") =>
+  stack
+    .split("\n")
+    .slice(1)
+    .map((line) => STACK_LINE_REGEX.exec(line))
+    .filter(Boolean)
+    .map(([, fn, file, line, column]) => ({
+      function: fn || "<anonymous>",
+      filename: file.replace(/^file:\/\//, ""),
+      lineno: Number(line),
+      colno: Number(column),
+      in_app: !file.includes("node_modules") && !file.startsWith("node:"),
+    }))
+    .reverse();
+
+/**
+ * Minimal Sentry client: sends unexpected errors to the project of SENTRY_DSN
+ * through the envelope endpoint. Without a DSN it does nothing.
+ */
+class SentryClient {
+  constructor() {
+    this.endpoint = null;
+    this.dsn = config.sentry.dsn;
+    if (this.dsn) {
+      try {
+        const url = new URL(this.dsn);
+        const projectId = url.pathname.split("/").filter(Boolean).pop();
+        this.publicKey = url.username;
+        this.endpoint = `${url.protocol}//${url.host}/api/${projectId}/envelope/`;
+      } catch {
+        logger.error("SENTRY_DSN is not a valid DSN; errors will not be reported");
+      }
+    }
+  }
+
+  isConfigured() {
+    return Boolean(this.endpoint);
+  }
+
+  /**
+   * Reports an error. Never throws and never waits on Sentry for longer than
+   * a few seconds.
+   * @param {Error|*} error
+   * @param {Object} context - { req, userId, tags, extra }
+   * @returns {Promise<string|null>} Event ID, or null when not sent
+   */
+  async captureException(error, { req = null, userId = null, tags = {}, extra = {} } = {}) {
+    if (!this.isConfigured()) {
+      return null;
+    }
+    const eventId = crypto.randomUUID().replace(/-/g, "");
+    const err = error instanceof Error ? error : new Error(String(error));
+    const requestId = req?.id || getRequestId();
+
+    const event = {
+      event_id: eventId,
+      timestamp: Date.now() / 1000,
+      platform: "node",
+      level: "error",
+      environment: config.sentry.environment,
+      release: config.sentry.release,
+      server_name: os.hostname(),
+      exception: {
+        values: [{ type: err.name, value: err.message, stacktrace: { frames: parseStack(err.stack) } }],
+      },
+      tags: { ...(requestId && { requestId }), ...tags },
+      extra,
+      ...(userId || req?.user?.id ? { user: { id: userId || req.user.id } } : {}),
+      ...(req && {
+        request: {
+          method: req.method,
+          url: `${req.protocol}://${req.get("host")}${req.originalUrl}`,
+          headers: Object.fromEntries(
+            Object.entries(req.headers).filter(([name]) => !REDACTED_HEADERS.includes(name))
+          ),
+        },
+      }),
+    };
+    const envelope = [
+      JSON.stringify({ event_id: eventId, sent_at: new Date().toISOString(), dsn: this.dsn }),
+      JSON.stringify({ type: "event" }),
+      JSON.stringify(event),
+    ].join("\n");
+
+    try {
+      const response = await fetch(this.endpoint, {
+        method: "POST",
+        headers: {
+          "Content-Type": "application/x-sentry-envelope",
+          "X-Sentry-Auth": `Sentry sentry_version=7, sentry_key=${this.publicKey}, sentry_client=jointravel-backend/1.0`,
+        },
+        body: envelope,
+        signal: AbortSignal.timeout(SEND_TIMEOUT_MS),
+      });
+      if (!response.ok) {
+        logger.warn(`Sentry rejected event ${eventId} with status ${response.status}`);
+        return null;
+      }
+      return eventId;
+    } catch (sendError) {
+      logger.warn(`Could not report error to Sentry: ${sendError.message}`);
+      return null;
+    }
+  }
+}
+
+export default new SentryClient();
@@ -0,0 +1,138 @@
+import crypto from "crypto";
+import config from "../config/index.js";
+import { ExternalServiceError } from "../utils/customErrors.js";
+import { createHttpClient } from "./http.client.js";
+
+const API_URL = "https://api.stripe.com/v1";
+
+const http = createHttpClient("stripe", { timeoutMs: config.stripe.timeoutMs });
+
+/**
+ * Encodes nested params the way the Stripe API expects (metadata[key]=value)
+ */
+const encodeForm = (params, prefix = null, form = new URLSearchParams()) => {
+  for (const [key, value] of Object.entries(params)) {
+    if (value === undefined || value === null) continue;
+    const name = prefix ? `${prefix}[${key}]` : key;
+    if (typeof value === "object") {
+      encodeForm(value, name, form);
+    } else {
+      form.append(name, String(value));
+    }
+  }
+  return form;
+};
+
+/**
+ * Minimal Stripe client: the few REST calls trip deposits need plus
+ * webhook signature verification
+ */
+class StripeClient {
+  isConfigured() {
+    return Boolean(config.stripe.secretKey);
+  }
+
+  async request(method, path, params = null, { idempotencyKey = null } = {}) {
+    if (!this.isConfigured()) {
+      throw new ExternalServiceError("Los pagos no están configurados");
+    }
+
+    const headers = { Authorization: `Bearer ${config.stripe.secretKey}` };
+    if (params) {
+      headers["Content-Type"] = "application/x-www-form-urlencoded";
+    }
+    if (idempotencyKey) {
+      headers["Idempotency-Key"] = idempotencyKey;
+    }
+
+    let response;
+    try {
+      response = await http.fetch(`${API_URL}${path}`, {
+        method,
+        headers,
+        body: params ? encodeForm(params).toString() : undefined,
+        // Stripe replays the first result of an Idempotency-Key, so those posts are safe to retry
+        idempotent: method === "GET" || Boolean(idempotencyKey),
+      });
+    } catch (error) {
+      throw new ExternalServiceError(`Error de conexión con Stripe: ${error.message}`);
+    }
+
+    const body = await response.json();
+    if (!response.ok) {
+      throw new ExternalServiceError(`Stripe: ${body.error?.message || response.status}`);
+    }
+    return body;
+  }
+
+  /**
+   * @param {Object} params - { amount (cents), currency, metadata, description }
+   * @param {string} idempotencyKey - Avoids duplicate intents on retries
+   */
+  async createPaymentIntent({ amount, currency, metadata = {}, description = null }, idempotencyKey) {
+    return await this.request(
+      "POST",
+      "/payment_intents",
+      {
+        amount,
+        currency: currency.toLowerCase(),
+        description,
+        metadata,
+        automatic_payment_methods: { enabled: true },
+      },
+      { idempotencyKey }
+    );
+  }
+
+  async cancelPaymentIntent(paymentIntentId) {
+    return await this.request("POST", `/payment_intents/${paymentIntentId}/cancel`, {});
+  }
+
+  /**
+   * Refunds the full amount of a succeeded payment intent
+   */
+  async createRefund(paymentIntentId, idempotencyKey) {
+    return await this.request(
+      "POST",
+      "/refunds",
+      { payment_intent: paymentIntentId },
+      { idempotencyKey }
+    );
+  }
+
+  /**
+   * Verifies the Stripe-Signature header of a webhook and parses the event
+   * @param {Buffer} rawBody - Exact request body
+   * @param {string} header - Stripe-Signature header
+   * @returns {Object|null} - Event, or null when the signature is invalid or too old
+   */
+  verifyWebhook(rawBody, header) {
+    if (!config.stripe.webhookSecret || !rawBody || !header) {
+      return null;
+    }
+
+    const parts = header.split(",").map((part) => part.split("="));
+    const timestamp = parts.find(([key]) => key === "t")?.[1];
+    const signatures = parts.filter(([key]) => key === "v1").map(([, value]) => value);
+    if (!timestamp || !signatures.length) {
+      return null;
+    }
+    if (Math.abs(Date.now() / 1000 - Number(timestamp)) > config.stripe.webhookToleranceSec) {
+      return null;
+    }
+
+    const expected = crypto
+      .createHmac("sha256", config.stripe.webhookSecret)
+      .update(`${timestamp}.${rawBody.toString("utf8")}`)
+      .digest("hex");
+    const valid = signatures.some(
+      (signature) =>
+        signature.length === expected.length &&
+        crypto.timingSafeEqual(Buffer.from(signature), Buffer.from(expected))
+    );
+
+    return valid ? JSON.parse(rawBody.toString("utf8")) : null;
+  }
+}
+
+export default new StripeClient();
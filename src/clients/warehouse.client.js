@@ -0,0 +1,113 @@
+import fs from "fs/promises";
+import path from "path";
+import crypto from "crypto";
+import config from "../config/index.js";
+import s3Client from "./s3.client.js";
+import { createHttpClient } from "./http.client.js";
+import { ExternalServiceError } from "../utils/customErrors.js";
+
+/**
+ * Every sink implements write(events): Promise<void>, resolving once the
+ * whole batch is stored; on a rejection the batch is retried as a whole, so
+ * the warehouse should deduplicate on eventId. Events are the flat rows built
+ * by the analytics event service.
+ */
+
+const toNdjson = (events) => events.map((event) => JSON.stringify(event)).join("\n") + "\n";
+
+const utcDay = (date = new Date()) => date.toISOString().slice(0, 10);
+
+/**
+ * Appends to one NDJSON file per UTC day, for development or for a log
+ * shipper to pick up
+ */
+class FileSink {
+  name = "file";
+
+  async write(events) {
+    const dir = config.analyticsEvents.fileDir;
+    await fs.mkdir(dir, { recursive: true });
+    await fs.appendFile(path.join(dir, `events-${utcDay()}.ndjson`), toNdjson(events));
+  }
+}
+
+/**
+ * One NDJSON object per batch in the upload bucket, partitioned by day
+ * (<prefix>/dt=YYYY-MM-DD/...) so Athena, BigQuery or Snowflake can load them
+ */
+class S3Sink {
+  name = "s3";
+
+  async write(events) {
+    if (!s3Client.isConfigured()) {
+      throw new Error("S3 storage is not configured");
+    }
+    const now = new Date();
+    const key = `${config.analyticsEvents.s3Prefix}/dt=${utcDay(now)}/${now.getTime()}-${crypto
+      .randomBytes(6)
+      .toString("hex")}.ndjson`;
+    await s3Client.putObject(key, Buffer.from(toNdjson(events)), "application/x-ndjson");
+  }
+}
+
+/**
+ * ClickHouse over its HTTP interface, one INSERT ... FORMAT JSONEachRow per
+ * batch. Unknown columns are skipped, so the table may hold a subset.
+ */
+class ClickHouseSink {
+  name = "clickhouse";
+
+  constructor() {
+    this.http = createHttpClient("clickhouse", { timeoutMs: config.analyticsEvents.clickhouseTimeoutMs });
+  }
+
+  async write(events) {
+    const { clickhouseUrl, clickhouseTable, clickhouseUser, clickhousePassword } = config.analyticsEvents;
+    if (!clickhouseUrl) {
+      throw new Error("ANALYTICS_EVENTS_CLICKHOUSE_URL is not configured");
+    }
+    const url = new URL(clickhouseUrl);
+    url.searchParams.set("query", `INSERT INTO ${clickhouseTable} FORMAT JSONEachRow`);
+    url.searchParams.set("input_format_skip_unknown_fields", "1");
+
+    const response = await this.http.fetch(url.toString(), {
+      method: "POST",
+      // The table deduplicates on eventId (ReplacingMergeTree), so a retried insert is harmless
+      idempotent: true,
+      headers: {
+        "Content-Type": "application/x-ndjson",
+        ...(clickhouseUser && {
+          "X-ClickHouse-User": clickhouseUser,
+          "X-ClickHouse-Key": clickhousePassword || "",
+        }),
+      },
+      body: toNdjson(events),
+    });
+    if (!response.ok) {
+      const message = await response.text().catch(() => "");
+      throw new ExternalServiceError(`ClickHouse: ${message.slice(0, 200) || response.status}`);
+    }
+  }
+}
+
+const SINKS = {
+  file: FileSink,
+  s3: S3Sink,
+  clickhouse: ClickHouseSink,
+};
+
+/**
+ * Returns the sink selected by ANALYTICS_EVENTS_SINK, or null for "none"
+ */
+export const createWarehouseSink = (name = config.analyticsEvents.sink) => {
+  if (name === "none") {
+    return null;
+  }
+  const Sink = SINKS[name];
+  if (!Sink) {
+    throw new Error(`Unknown analytics events sink: ${name}`);
+  }
+  return new Sink();
+};
+
+export default createWarehouseSink();
@@ -0,0 +1,169 @@
+import config from "../config/index.js";
+import { createHttpClient } from "./http.client.js";
+
+const METNO_URL = "https://api.met.no/weatherapi/locationforecast/2.0/compact";
+const OPENWEATHER_URL = "https://api.openweathermap.org/data/2.5/forecast";
+
+const http = createHttpClient("weather", { timeoutMs: config.weather.timeoutMs });
+
+/**
+ * Every provider implements fetchForecast(lat, lng): Promise<{ days, expiresAt }>,
+ * where days are [{ date, minTemp, maxTemp, precipitationMm, windSpeedMax, condition }]
+ * (°C, mm, m/s) by local date of the destination, and expiresAt is when the
+ * provider says the forecast should be fetched again (null if it does not say).
+ */
+
+// From mildest to most severe; a day takes the most severe condition of its hours
+export const WEATHER_CONDITIONS = ["clear", "partly_cloudy", "cloudy", "fog", "rain", "snow", "thunderstorm"];
+
+const metnoCondition = (symbol = "") => {
+  if (symbol.includes("thunder")) return "thunderstorm";
+  if (symbol.includes("snow") || symbol.includes("sleet")) return "snow";
+  if (symbol.includes("rain")) return "rain";
+  if (symbol.startsWith("fog")) return "fog";
+  if (symbol.startsWith("cloudy")) return "cloudy";
+  if (symbol.startsWith("partlycloudy") || symbol.startsWith("fair")) return "partly_cloudy";
+  return "clear";
+};
+
+const OPENWEATHER_CONDITIONS = {
+  Thunderstorm: "thunderstorm",
+  Snow: "snow",
+  Rain: "rain",
+  Drizzle: "rain",
+  Clouds: "cloudy",
+  Clear: "clear",
+};
+
+const openWeatherCondition = ({ main, id } = {}) => {
+  if (main === "Clouds" && id <= 802) return "partly_cloudy";
+  // Mist, Fog, Haze, Dust...
+  return OPENWEATHER_CONDITIONS[main] || "fog";
+};
+
+/**
+ * Folds hourly or 3-hourly entries into days
+ * @param {Array} entries - [{ date, temp, precipitationMm, windSpeed, condition }]
+ */
+const toDays = (entries) => {
+  const days = new Map();
+  for (const entry of entries) {
+    const day = days.get(entry.date) || {
+      date: entry.date,
+      minTemp: Infinity,
+      maxTemp: -Infinity,
+      precipitationMm: 0,
+      windSpeedMax: 0,
+      condition: "clear",
+    };
+    day.minTemp = Math.min(day.minTemp, entry.temp);
+    day.maxTemp = Math.max(day.maxTemp, entry.temp);
+    day.precipitationMm += entry.precipitationMm || 0;
+    day.windSpeedMax = Math.max(day.windSpeedMax, entry.windSpeed || 0);
+    if (WEATHER_CONDITIONS.indexOf(entry.condition) > WEATHER_CONDITIONS.indexOf(day.condition)) {
+      day.condition = entry.condition;
+    }
+    days.set(entry.date, day);
+  }
+  return [...days.values()].map((day) => ({
+    ...day,
+    minTemp: Math.round(day.minTemp * 10) / 10,
+    maxTemp: Math.round(day.maxTemp * 10) / 10,
+    precipitationMm: Math.round(day.precipitationMm * 10) / 10,
+  }));
+};
+
+const localDate = (timestampMs, offsetSeconds) =>
+  new Date(timestampMs + offsetSeconds * 1000).toISOString().slice(0, 10);
+
+/**
+ * MET Norway Locationforecast (free, worldwide, about 9 days). Its terms
+ * require an identifying User-Agent, at most 4 decimals in the coordinates
+ * and not asking again before the Expires header.
+ */
+class MetnoProvider {
+  name = "metno";
+  horizonDays = 9;
+
+  async fetchForecast(lat, lng) {
+    const url = new URL(METNO_URL);
+    url.searchParams.set("lat", lat.toFixed(4));
+    url.searchParams.set("lon", lng.toFixed(4));
+    const response = await http.fetch(url, { headers: { "User-Agent": config.weather.userAgent } });
+    if (!response.ok) {
+      throw new Error(`met.no forecast request failed with status ${response.status}`);
+    }
+    const body = await response.json();
+    // met.no answers in UTC and says nothing of the time zone: the solar offset is close enough for daily figures
+    const offsetSeconds = Math.round(lng / 15) * 3600;
+
+    const entries = (body.properties?.timeseries || []).map(({ time, data }) => {
+      const next = data.next_1_hours || data.next_6_hours || {};
+      return {
+        date: localDate(new Date(time).getTime(), offsetSeconds),
+        temp: data.instant.details.air_temperature,
+        // Only the hourly amounts, so 6-hour blocks do not count the same rain twice
+        precipitationMm: data.next_1_hours?.details?.precipitation_amount ?? 0,
+        windSpeed: data.instant.details.wind_speed,
+        condition: metnoCondition(next.summary?.symbol_code),
+      };
+    });
+    const expires = response.headers.get("expires");
+    return {
+      days: toDays(entries),
+      expiresAt: expires && !Number.isNaN(new Date(expires).getTime()) ? new Date(expires) : null,
+    };
+  }
+}
+
+/**
+ * OpenWeather 5 day / 3 hour forecast (free plan: 60 calls a minute)
+ */
+class OpenWeatherProvider {
+  name = "openweather";
+  horizonDays = 5;
+
+  async fetchForecast(lat, lng) {
+    if (!config.weather.openWeatherApiKey) {
+      throw new Error("OPENWEATHER_API_KEY is not configured");
+    }
+    const url = new URL(OPENWEATHER_URL);
+    url.searchParams.set("lat", lat.toFixed(4));
+    url.searchParams.set("lon", lng.toFixed(4));
+    url.searchParams.set("units", "metric");
+    url.searchParams.set("appid", config.weather.openWeatherApiKey);
+    const response = await http.fetch(url);
+    const body = await response.json();
+    if (!response.ok) {
+      throw new Error(`OpenWeather forecast request failed: ${body.message || response.status}`);
+    }
+    const offsetSeconds = body.city?.timezone ?? 0;
+
+    const entries = (body.list || []).map((item) => ({
+      date: localDate(item.dt * 1000, offsetSeconds),
+      temp: item.main.temp,
+      precipitationMm: (item.rain?.["3h"] ?? 0) + (item.snow?.["3h"] ?? 0),
+      windSpeed: item.wind?.speed,
+      condition: openWeatherCondition(item.weather?.[0]),
+    }));
+    return { days: toDays(entries), expiresAt: null };
+  }
+}
+
+const PROVIDERS = {
+  metno: MetnoProvider,
+  openweather: OpenWeatherProvider,
+};
+
+/**
+ * Returns the provider selected by WEATHER_PROVIDER
+ */
+export const createWeatherProvider = (name = config.weather.provider) => {
+  const Provider = PROVIDERS[name];
+  if (!Provider) {
+    throw new Error(`Unknown weather provider: ${name}`);
+  }
+  return new Provider();
+};
+
+export default createWeatherProvider();
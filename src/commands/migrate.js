@@ -0,0 +1,20 @@
+import logger from "../config/logger.js";
+import { createAppLifecycle } from "../load/lifecycle.js";
+
+/**
+ * Creates the database if missing, syncs the schema and runs pending
+ * migrations, then exits. Safe to run on every deploy before the API starts.
+ */
+const migrate = async () => {
+  const lifecycle = createAppLifecycle({ seed: false });
+  await lifecycle.start("database");
+
+  const migrations = await lifecycle.get("database").runMigrations({ transaction: "each" });
+  for (const migration of migrations) {
+    logger.info(`Migration ${migration.name} applied`);
+  }
+  logger.info(`Schema up to date (${migrations.length} migrations applied)`);
+  await lifecycle.stop();
+};
+
+export default migrate;
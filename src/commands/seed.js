@@ -0,0 +1,47 @@
+import { parseArgs } from "node:util";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { createAppLifecycle } from "../load/lifecycle.js";
+import seedDatabase from "../load/seed.loader.js";
+import { seedDevData, loadFixture } from "../seed/index.js";
+
+/**
+ * Loads the reference data (levels, badges, places and reviews) and the dev
+ * dataset. Every row is keyed, so it can be rerun.
+ *
+ *   seed                      reference + dev data
+ *   seed --reference-only     reference data only
+ *   seed --fixture openTrip   reference data + a test fixture
+ *
+ * Dev data and fixtures are refused in production unless --force is passed.
+ */
+const seed = async (args = []) => {
+  const { values } = parseArgs({
+    args,
+    options: {
+      "reference-only": { type: "boolean", default: false },
+      fixture: { type: "string", multiple: true, default: [] },
+      force: { type: "boolean", default: false },
+    },
+  });
+  const loadsDemoData = !values["reference-only"];
+  if (loadsDemoData && config.env === "production" && !values.force) {
+    throw new Error("Refusing to load dev data in production (use --reference-only or --force)");
+  }
+
+  const lifecycle = createAppLifecycle({ seed: false });
+  await lifecycle.start("database");
+  await seedDatabase();
+  if (values.fixture.length) {
+    for (const name of values.fixture) {
+      await loadFixture(name);
+      logger.info(`Fixture ${name} loaded`);
+    }
+  } else if (loadsDemoData) {
+    await seedDevData();
+  }
+  logger.info("Database seeded");
+  await lifecycle.stop();
+};
+
+export default seed;
@@ -0,0 +1,41 @@
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import sentryClient from "../clients/sentry.client.js";
+import { createAppLifecycle } from "../load/lifecycle.js";
+
+/**
+ * Processes background jobs without serving HTTP. Realtime events of jobs are
+ * stored and reach clients through the API instances (see socket/realtime.js).
+ */
+const runWorker = async () => {
+  const lifecycle = createAppLifecycle({ seed: false, forceJobs: true });
+  await lifecycle.start("jobs", "scheduler", "events");
+  logger.info(`Worker running (concurrency ${config.jobs.concurrency})`);
+
+  lifecycle.stopOnSignals((signal) => logger.info(`${signal} received, stopping worker`));
+
+  // A crash outside a handler's promise (a throw in a timer or event listener)
+  // takes the process down; log which jobs were running so the payload that
+  // caused it can be found. Their rows are released as stale and retried.
+  const jobs = lifecycle.get("jobs");
+  process.on("unhandledRejection", (reason) => {
+    logger.error("[Jobs] Unhandled rejection in worker", {
+      error: reason instanceof Error ? reason.message : String(reason),
+      stack: reason instanceof Error ? reason.stack : undefined,
+      activeJobs: jobs.activeJobs(),
+    });
+    sentryClient.captureException(reason, { extra: { activeJobs: jobs.activeJobs() } });
+  });
+  process.once("uncaughtException", async (error) => {
+    logger.error("[Jobs] Worker crashed", {
+      error: error.message,
+      stack: error.stack,
+      activeJobs: jobs.activeJobs(),
+    });
+    await sentryClient.captureException(error, { extra: { activeJobs: jobs.activeJobs() } });
+    await lifecycle.stop();
+    process.exit(1);
+  });
+};
+
+export default runWorker;
@@ -3,13 +3,53 @@ dotenv.config();
 
 const config = {
   env: process.env.NODE_ENV || "development",
-  port: process.env.PORT || 8080,
+  server: {
+    // Address and port the API listens on
+    host: process.env.HOST || "0.0.0.0",
+    port: parseInt(process.env.PORT, 10) || 8080,
+    tls: {
+      // Terminate TLS here instead of at a proxy; needs the certificate and key
+      // files, or autocert. For HTTP/2 put a proxy in front
+      enabled: process.env.TLS_ENABLED === "true",
+      certPath: process.env.TLS_CERT_PATH,
+      keyPath: process.env.TLS_KEY_PATH,
+      // Let's Encrypt certificate issued and renewed by the server (HTTP-01 on
+      // redirectPort) instead of the certificate files
+      autocert: {
+        domains: (process.env.TLS_AUTOCERT_DOMAINS || "").split(",").map((domain) => domain.trim()).filter(Boolean),
+        email: process.env.TLS_AUTOCERT_EMAIL,
+        cacheDir: process.env.TLS_AUTOCERT_CACHE_DIR || "./data/autocert",
+        staging: process.env.TLS_AUTOCERT_STAGING === "true",
+        renewDays: parseInt(process.env.TLS_AUTOCERT_RENEW_DAYS, 10) || 30,
+      },
+      // Plain HTTP port that redirects to HTTPS and answers ACME challenges; 0 disables it
+      redirectPort: parseInt(process.env.TLS_REDIRECT_PORT ?? "80", 10) || 0,
+      // Directory where certbot (--webroot) writes ACME HTTP-01 challenges, when not using autocert
+      acmeWebroot: process.env.TLS_ACME_WEBROOT,
+      // Host and port redirects point to, when they differ from the request's
+      publicHost: process.env.TLS_PUBLIC_HOST,
+      publicPort: parseInt(process.env.TLS_PUBLIC_PORT, 10) || null,
+      // Certificates of tenant custom domains, as <dir>/<hostname>/{fullchain,privkey}.pem
+      // (certbot's live directory), picked by SNI
+      customCertsDir: process.env.TLS_CUSTOM_CERTS_DIR,
+    },
+  },
+  customDomains: {
+    // Called with { hostname, tenantId } when a domain is verified, to issue its certificate
+    certificateHookUrl: process.env.CUSTOM_DOMAIN_CERT_HOOK_URL,
+    // Sent as a bearer token to the hook
+    certificateHookSecret: process.env.CUSTOM_DOMAIN_CERT_HOOK_SECRET,
+    // How long the tenant of a Host is cached
+    cacheTtlSeconds: parseInt(process.env.CUSTOM_DOMAIN_CACHE_TTL_SECONDS ?? "60", 10),
+  },
   db: {
     host: process.env.POSTGRES_HOST || (process.env.NODE_ENV === 'test' ? 'postgres_db' : 'localhost'),
     port: parseInt(process.env.POSTGRES_PORT, 10) || 5432,
     user: process.env.POSTGRES_USER,
     password: process.env.POSTGRES_PASSWORD,
     name: process.env.POSTGRES_DB,
+    // Seed reference data on every start (set DB_SEED_ON_START=false and run `cli.js seed` instead)
+    seedOnStart: process.env.DB_SEED_ON_START !== "false",
   },
   email: {
     host: process.env.EMAIL_HOST || "smtp.gmail.com",
@@ -18,14 +58,414 @@ const config = {
     user: process.env.EMAIL_USER,
     password: process.env.EMAIL_PASSWORD,
     from: process.env.EMAIL_FROM || process.env.EMAIL_USER,
+    // SPF include of the SMTP provider, asked of tenants that send from their own domain
+    spfInclude: process.env.EMAIL_SPF_INCLUDE,
   },
   frontendUrl: process.env.FRONTEND_URL || "http://localhost:5173",
+  push: {
+    fcm: {
+      projectId: process.env.FCM_PROJECT_ID,
+      clientEmail: process.env.FCM_CLIENT_EMAIL,
+      // Keys usually come from env vars with escaped newlines
+      privateKey: process.env.FCM_PRIVATE_KEY?.replace(/\\n/g, "\n"),
+    },
+    apns: {
+      teamId: process.env.APNS_TEAM_ID,
+      keyId: process.env.APNS_KEY_ID,
+      privateKey: process.env.APNS_PRIVATE_KEY?.replace(/\\n/g, "\n"),
+      bundleId: process.env.APNS_BUNDLE_ID,
+      production: process.env.APNS_PRODUCTION === "true",
+    },
+  },
+  geocoding: {
+    googleApiKey: process.env.GOOGLE_MAPS_API_KEY,
+    userAgent: process.env.GEOCODING_USER_AGENT || "JoinTravel/1.0",
+    timeoutMs: parseInt(process.env.GEOCODING_TIMEOUT_MS, 10) || 5000,
+  },
+  storage: {
+    // S3-compatible object storage (MinIO in local development)
+    endpoint: process.env.S3_ENDPOINT,
+    region: process.env.S3_REGION || "us-east-1",
+    bucket: process.env.S3_BUCKET,
+    accessKeyId: process.env.S3_ACCESS_KEY_ID,
+    secretAccessKey: process.env.S3_SECRET_ACCESS_KEY,
+    forcePathStyle: process.env.S3_FORCE_PATH_STYLE !== "false",
+    presignExpiresIn: parseInt(process.env.S3_PRESIGN_EXPIRES_IN, 10) || 900,
+  },
+  scanner: {
+    // ClamAV daemon; when no host is set uploads are marked as not scanned
+    host: process.env.CLAMAV_HOST,
+    port: parseInt(process.env.CLAMAV_PORT, 10) || 3310,
+    timeoutMs: parseInt(process.env.CLAMAV_TIMEOUT_MS, 10) || 60000,
+    // Scan jobs are retried with backoff while the scanner is down (12 attempts ≈ 5 hours)
+    maxAttempts: parseInt(process.env.SCANNER_MAX_ATTEMPTS, 10) || 12,
+    // Uploads still pending after this long are queued again by the daily maintenance
+    requeueAfterHours: parseInt(process.env.SCANNER_REQUEUE_AFTER_HOURS, 10) || 12,
+    quarantinePrefix: process.env.SCANNER_QUARANTINE_PREFIX || "quarantine/",
+  },
+  documents: {
+    maxFileSize: parseInt(process.env.DOCUMENTS_MAX_FILE_SIZE, 10) || 20 * 1024 * 1024,
+    // Storage quota per group, counting every version of every document
+    groupQuotaBytes: parseInt(process.env.DOCUMENTS_GROUP_QUOTA_BYTES, 10) || 200 * 1024 * 1024,
+  },
+  search: {
+    // "postgres" (tsvector, default) or "elasticsearch"
+    engine: process.env.SEARCH_ENGINE || "postgres",
+    elasticsearch: {
+      url: process.env.ELASTICSEARCH_URL || "http://localhost:9200",
+      apiKey: process.env.ELASTICSEARCH_API_KEY,
+      index: process.env.ELASTICSEARCH_INDEX || "jointravel-search",
+      timeoutMs: parseInt(process.env.ELASTICSEARCH_TIMEOUT_MS, 10) || 5000,
+    },
+  },
+  jobs: {
+    // Background job worker inside the API process (set JOBS_WORKER_ENABLED=false
+    // to run the API without it and run `cli.js worker` as a separate process)
+    workerEnabled: process.env.JOBS_WORKER_ENABLED !== "false",
+    pollIntervalMs: parseInt(process.env.JOBS_POLL_INTERVAL_MS, 10) || 2000,
+    concurrency: parseInt(process.env.JOBS_CONCURRENCY, 10) || 2,
+    staleAfterMs: parseInt(process.env.JOBS_STALE_AFTER_MS, 10) || 10 * 60 * 1000,
+    // Failed attempts of the same payload (across jobs, last 7 days) before it is quarantined
+    poisonThreshold: parseInt(process.env.JOBS_POISON_THRESHOLD, 10) || 10,
+    // Share of failed attempts per job type tolerated over the error-rate window
+    errorBudget: parseFloat(process.env.JOBS_ERROR_BUDGET) || 0.05,
+  },
+  events: {
+    // Where the outbox publishes domain events: "none" (in-process handlers
+    // only, nothing is written to the outbox), "nats" or "kafka"
+    broker: process.env.EVENTS_BROKER || "none",
+    // nats://[user:pass@]host:port; subjects are <prefix>.<EventType>
+    natsUrl: process.env.EVENTS_NATS_URL || "nats://localhost:4222",
+    natsSubjectPrefix: process.env.EVENTS_NATS_SUBJECT_PREFIX || "jointravel",
+    // Kafka is reached through its REST Proxy (v2 API); the aggregate ID is the record key
+    kafkaRestUrl: process.env.EVENTS_KAFKA_REST_URL,
+    kafkaTopic: process.env.EVENTS_KAFKA_TOPIC || "jointravel.events",
+    timeoutMs: parseInt(process.env.EVENTS_PUBLISH_TIMEOUT_MS, 10) || 5000,
+    relayIntervalMs: parseInt(process.env.EVENTS_RELAY_INTERVAL_MS, 10) || 1000,
+    relayBatchSize: parseInt(process.env.EVENTS_RELAY_BATCH_SIZE, 10) || 100,
+    // An event failing this many times is marked failed and skipped
+    relayMaxAttempts: parseInt(process.env.EVENTS_RELAY_MAX_ATTEMPTS, 10) || 10,
+    // How long a relay holds the batch it claimed; it stops publishing before then
+    relayClaimMs: parseInt(process.env.EVENTS_RELAY_CLAIM_MS, 10) || 60000,
+    // Published events are kept this long in the outbox for replays
+    retentionDays: parseInt(process.env.EVENTS_RETENTION_DAYS, 10) || 7,
+  },
+  analytics: {
+    // Minimum cohort size exposed by admin analytics and warehouse exports
+    kAnonymityThreshold: parseInt(process.env.ANALYTICS_K_THRESHOLD, 10) || 5,
+  },
+  analyticsEvents: {
+    // Where client analytics events end up: "none" (accepted and dropped), "file", "s3" or "clickhouse"
+    sink: process.env.ANALYTICS_EVENTS_SINK || "none",
+    // Events wait in "memory" (lost if the process dies) or in a "redis" list shared by every instance
+    buffer: process.env.ANALYTICS_EVENTS_BUFFER || "memory",
+    redisKey: process.env.ANALYTICS_EVENTS_REDIS_KEY || "analytics:events",
+    flushIntervalMs: parseInt(process.env.ANALYTICS_EVENTS_FLUSH_INTERVAL_MS, 10) || 5000,
+    flushBatchSize: parseInt(process.env.ANALYTICS_EVENTS_FLUSH_BATCH_SIZE, 10) || 500,
+    // Buffered events beyond this are dropped while the sink is down
+    maxBuffered: parseInt(process.env.ANALYTICS_EVENTS_MAX_BUFFERED, 10) || 50000,
+    maxEventsPerRequest: parseInt(process.env.ANALYTICS_EVENTS_MAX_PER_REQUEST, 10) || 100,
+    fileDir: process.env.ANALYTICS_EVENTS_FILE_DIR || "./data/analytics-events",
+    s3Prefix: process.env.ANALYTICS_EVENTS_S3_PREFIX || "analytics-events",
+    // e.g. https://clickhouse.internal:8443/?database=analytics
+    clickhouseUrl: process.env.ANALYTICS_EVENTS_CLICKHOUSE_URL,
+    clickhouseTable: process.env.ANALYTICS_EVENTS_CLICKHOUSE_TABLE || "client_events",
+    clickhouseUser: process.env.ANALYTICS_EVENTS_CLICKHOUSE_USER,
+    clickhousePassword: process.env.ANALYTICS_EVENTS_CLICKHOUSE_PASSWORD,
+    clickhouseTimeoutMs: parseInt(process.env.ANALYTICS_EVENTS_CLICKHOUSE_TIMEOUT_MS, 10) || 10000,
+  },
+  recommendations: {
+    // Frequency cap for the monthly recommendation email
+    minDaysBetweenEmails: parseInt(process.env.RECOMMENDATIONS_MIN_DAYS, 10) || 28,
+    itemsPerEmail: parseInt(process.env.RECOMMENDATIONS_ITEMS_PER_EMAIL, 10) || 5,
+  },
+  tripRecommendations: {
+    // Ranked trips are served from the cache and refreshed in the background once this old
+    ttlHours: parseInt(process.env.TRIP_RECOMMENDATIONS_TTL_HOURS, 10) || 24,
+    maxItems: parseInt(process.env.TRIP_RECOMMENDATIONS_MAX_ITEMS, 10) || 50,
+    // Open trips scored per user, soonest first
+    candidateLimit: parseInt(process.env.TRIP_RECOMMENDATIONS_CANDIDATES, 10) || 500,
+    // Trips within this distance of a past destination count as a similar destination
+    destinationRadiusKm: parseInt(process.env.TRIP_RECOMMENDATIONS_DESTINATION_RADIUS_KM, 10) || 300,
+    // The daily refresh skips users not seen for this long (and drops their cache)
+    activeDays: parseInt(process.env.TRIP_RECOMMENDATIONS_ACTIVE_DAYS, 10) || 30,
+  },
+  expenses: {
+    // Currency used when an expense does not specify one (ISO 4217)
+    defaultCurrency: (process.env.EXPENSES_DEFAULT_CURRENCY || "ARS").toUpperCase(),
+  },
+  weather: {
+    // "metno" (default, free, needs an identifying User-Agent) or "openweather" (needs an API key)
+    provider: process.env.WEATHER_PROVIDER || "metno",
+    openWeatherApiKey: process.env.OPENWEATHER_API_KEY,
+    userAgent: process.env.WEATHER_USER_AGENT || "JoinTravel/1.0",
+    timeoutMs: parseInt(process.env.WEATHER_TIMEOUT_MS, 10) || 5000,
+    // A destination's forecast is reused at least this long, longer when the provider says it is still valid
+    cacheTtlMinutes: parseInt(process.env.WEATHER_CACHE_TTL_MINUTES, 10) || 60,
+    // Past its TTL a forecast is still served if the provider fails, for this long
+    staleHours: parseInt(process.env.WEATHER_STALE_HOURS, 10) || 6,
+  },
+  exchangeRates: {
+    // "openexchangerates" needs an app id; "ecb" (default) is free but has no ARS
+    provider: process.env.EXCHANGE_RATES_PROVIDER || "ecb",
+    openExchangeRatesAppId: process.env.OPENEXCHANGERATES_APP_ID,
+    timeoutMs: parseInt(process.env.EXCHANGE_RATES_TIMEOUT_MS, 10) || 10000,
+  },
+  ageVerification: {
+    // Rules per ISO 3166-1 alpha-2 country ("default" applies everywhere else).
+    // mode "block" rejects sign-ups under minimumAge, "gate" accepts them with restricted features
+    rules: (() => {
+      try {
+        return JSON.parse(process.env.AGE_RULES);
+      } catch {
+        return null;
+      }
+    })() || { default: { minimumAge: 18, mode: "block" } },
+    // Nobody younger can register anywhere
+    absoluteMinimumAge: 13,
+  },
+  policies: (() => {
+    // Feature policy overrides: { default, countries: { AR: {...} }, tenants: { id: {...} } }
+    try {
+      return JSON.parse(process.env.FEATURE_POLICIES);
+    } catch {
+      return null;
+    }
+  })() || {},
+  featureFlags: {
+    // Flags and their starting values: { key: { enabled, rolloutPercent, description } }
+    defaults: (() => {
+      try {
+        return JSON.parse(process.env.FEATURE_FLAGS);
+      } catch {
+        return null;
+      }
+    })() || {},
+    // How long each instance keeps the flags before reading admin changes
+    cacheTtlSeconds: parseInt(process.env.FEATURE_FLAGS_CACHE_TTL_SECONDS, 10) || 30,
+  },
+  joinEligibility: (() => {
+    // Join eligibility rule overrides: { default: { [rule]: {...} }, tenants: { id: {...} } }
+    try {
+      return JSON.parse(process.env.JOIN_ELIGIBILITY_RULES);
+    } catch {
+      return null;
+    }
+  })() || {},
+  stripe: {
+    secretKey: process.env.STRIPE_SECRET_KEY,
+    webhookSecret: process.env.STRIPE_WEBHOOK_SECRET,
+    // Maximum age of a webhook signature (replay protection)
+    webhookToleranceSec: parseInt(process.env.STRIPE_WEBHOOK_TOLERANCE_SEC, 10) || 300,
+    timeoutMs: parseInt(process.env.STRIPE_TIMEOUT_MS, 10) || 10000,
+  },
+  tripReviews: {
+    // Reviews are hidden until an admin checks them once they get this many flags
+    autoHideFlags: parseInt(process.env.TRIP_REVIEW_AUTO_HIDE_FLAGS, 10) || 3,
+    // Users rating each other 5 stars as organizers it takes to suspect a review ring
+    ringMinMembers: parseInt(process.env.TRIP_REVIEW_RING_MIN_MEMBERS, 10) || 3,
+  },
+  tripPublication: {
+    // How far ahead a launch can be scheduled
+    maxScheduleDays: parseInt(process.env.TRIP_PUBLICATION_MAX_SCHEDULE_DAYS, 10) || 180,
+  },
+  followerNotifications: {
+    // Trips an organizer publishes within this window go out as a single notification
+    batchWindowMinutes: parseInt(process.env.FOLLOWER_NOTIFICATIONS_BATCH_WINDOW_MINUTES, 10) || 15,
+    // Followers notified per fan-out job
+    pageSize: parseInt(process.env.FOLLOWER_NOTIFICATIONS_PAGE_SIZE, 10) || 500,
+  },
+  redis: {
+    // redis://[:password@]host:port[/db] or rediss:// for TLS; unset disables Redis
+    url: process.env.REDIS_URL,
+    timeoutMs: parseInt(process.env.REDIS_TIMEOUT_MS, 10) || 1000,
+  },
+  badges: {
+    // Cached badge counters expire after this long so any drift heals on its own
+    cacheTtlSeconds: parseInt(process.env.BADGES_CACHE_TTL_SECONDS, 10) || 900,
+  },
+  discovery: {
+    // Trips whose origin is within this distance of a user's home city depart "from their city"
+    originRadiusKm: parseInt(process.env.DISCOVERY_ORIGIN_RADIUS_KM, 10) || 50,
+    // Newly published trips from the home city stay on top of the feed for this long
+    feedBoostHours: parseInt(process.env.DISCOVERY_FEED_BOOST_HOURS, 10) || 72,
+  },
+  accountDeletion: {
+    // Days between a deletion request and the erasure of the account's data
+    graceDays: parseInt(process.env.ACCOUNT_DELETION_GRACE_DAYS, 10) || 14,
+    // Accounts without a password confirm the deletion with a login this recent
+    recentLoginMinutes: parseInt(process.env.ACCOUNT_DELETION_RECENT_LOGIN_MINUTES, 10) || 10,
+  },
+  cors: {
+    // Origins allowed to call the API and open sockets, comma separated. Entries are
+    // exact origins ("https://app.jointravel.app"), wildcard subdomains
+    // ("https://*.jointravel.app"; without a scheme https is assumed), any port
+    // ("http://localhost:*") or "*" for any origin. Defaults to the frontend URL,
+    // plus localhost outside production.
+    origins: (process.env.CORS_ORIGINS
+      ? process.env.CORS_ORIGINS.split(",")
+      : process.env.NODE_ENV === "production"
+        ? [process.env.FRONTEND_URL || "http://localhost:5173"]
+        : [process.env.FRONTEND_URL || "http://localhost:5173", "http://localhost:*", "http://127.0.0.1:*"]
+    ).map((origin) => origin.trim()).filter(Boolean),
+    credentials: process.env.CORS_CREDENTIALS !== "false",
+    // How long browsers may cache a preflight response
+    maxAgeSeconds: parseInt(process.env.CORS_MAX_AGE_SECONDS, 10) || 600,
+    // Response headers readable by browser clients
+    exposedHeaders: (
+      process.env.CORS_EXPOSED_HEADERS ||
+      "X-Request-Id,X-RateLimit-Limit,X-RateLimit-Remaining,X-RateLimit-Reset,Retry-After,Deprecation,Content-Disposition,ETag,Idempotent-Replayed"
+    ).split(",").map((header) => header.trim()).filter(Boolean),
+  },
+  organizerWidget: {
+    // Sites allowed to load the embeddable widget ("*" for any), comma separated
+    allowedOrigins: (process.env.ORGANIZER_WIDGET_ORIGINS || "*").split(",").map((origin) => origin.trim()),
+    // Browsers and CDNs may reuse widget and public page data for this long
+    cacheSeconds: parseInt(process.env.ORGANIZER_WIDGET_CACHE_SECONDS, 10) || 300,
+  },
+  availabilityFeed: {
+    // Feed requests per UTC day of a new aggregator (adjustable per partner)
+    defaultDailyQuota: parseInt(process.env.AVAILABILITY_FEED_DAILY_QUOTA, 10) || 5000,
+    // Burst limit per aggregator
+    requestsPerMinute: parseInt(process.env.AVAILABILITY_FEED_PER_MINUTE, 10) || 60,
+    // The snapshot aggregators read is rebuilt at most this often
+    refreshSeconds: parseInt(process.env.AVAILABILITY_FEED_REFRESH_SECONDS, 10) || 60,
+  },
+  requests: {
+    // Deadline of an API request: past it the client gets a 504 and pending
+    // database queries and outgoing calls of the request are cancelled
+    timeoutEnabled: process.env.REQUEST_TIMEOUT_ENABLED !== "false",
+    timeoutMs: parseInt(process.env.REQUEST_TIMEOUT_MS, 10) || 15000,
+    // File uploads and /cron tasks
+    longTimeoutMs: parseInt(process.env.REQUEST_LONG_TIMEOUT_MS, 10) || 5 * 60 * 1000,
+  },
+  http: {
+    compression: {
+      // gzip/brotli for JSON and text responses
+      enabled: process.env.COMPRESSION_ENABLED !== "false",
+      // Smaller bodies are not worth compressing
+      thresholdBytes: parseInt(process.env.COMPRESSION_THRESHOLD_BYTES, 10) || 1024,
+    },
+  },
+  safety: {
+    // Priority channel for safety staff (e.g. a Slack or pager webhook), called on every trip incident
+    alertWebhookUrl: process.env.SAFETY_ALERT_WEBHOOK_URL,
+  },
+  succession: {
+    // An organizer not seen for this many days counts as gone if the trip departs soon
+    inactiveDays: parseInt(process.env.SUCCESSION_INACTIVE_DAYS, 10) || 14,
+    departureWindowDays: parseInt(process.env.SUCCESSION_DEPARTURE_WINDOW_DAYS, 10) || 30,
+    // Time each candidate has to take over the trip, and members to vote if nobody does
+    offerHours: parseInt(process.env.SUCCESSION_OFFER_HOURS, 10) || 48,
+    votingHours: parseInt(process.env.SUCCESSION_VOTING_HOURS, 10) || 72,
+  },
+  idempotency: {
+    // How long the response to an Idempotency-Key is kept for retries
+    ttlHours: parseInt(process.env.IDEMPOTENCY_TTL_HOURS, 10) || 24,
+    // A retry while the first request is still running gets a 409 until this
+    // passes; then the first one is taken as lost
+    lockSeconds: parseInt(process.env.IDEMPOTENCY_LOCK_SECONDS, 10) || 60,
+  },
+  security: {
+    // Largest JSON body accepted (uploads have their own limits)
+    bodyLimit: process.env.SECURITY_BODY_LIMIT || "100kb",
+    hsts: {
+      // Only honored by browsers over HTTPS
+      enabled: process.env.SECURITY_HSTS_ENABLED
+        ? process.env.SECURITY_HSTS_ENABLED === "true"
+        : process.env.NODE_ENV === "production",
+      maxAgeSeconds: parseInt(process.env.SECURITY_HSTS_MAX_AGE_SECONDS, 10) || 180 * 24 * 60 * 60,
+      includeSubDomains: process.env.SECURITY_HSTS_INCLUDE_SUBDOMAINS !== "false",
+      preload: process.env.SECURITY_HSTS_PRELOAD === "true",
+    },
+    // X-Frame-Options: "DENY", "SAMEORIGIN" or "off"
+    frameOptions: (process.env.SECURITY_FRAME_OPTIONS || "DENY").toUpperCase(),
+    noSniff: process.env.SECURITY_NO_SNIFF !== "false",
+    // Answer unexpected errors with a generic message instead of theirs
+    hideInternalErrors: process.env.SECURITY_HIDE_INTERNAL_ERRORS
+      ? process.env.SECURITY_HIDE_INTERNAL_ERRORS === "true"
+      : process.env.NODE_ENV === "production",
+  },
+  sentry: {
+    // Unexpected errors of requests, jobs and crashes are reported here; unset disables it
+    dsn: process.env.SENTRY_DSN,
+    environment: process.env.SENTRY_ENVIRONMENT || process.env.NODE_ENV || "development",
+    release: process.env.SENTRY_RELEASE,
+  },
+  scheduler: {
+    // Bearer token the external scheduler sends to trigger the /cron tasks; unset disables them
+    secret: process.env.CRON_SECRET,
+    // How often API and worker processes look for scheduled tasks that stopped running
+    checkIntervalMinutes: parseInt(process.env.SCHEDULER_CHECK_INTERVAL_MINUTES, 10) || 10,
+  },
+  docs: {
+    // /openapi.json and Swagger UI (/docs); off in production unless enabled explicitly
+    enabled: process.env.API_DOCS_ENABLED
+      ? process.env.API_DOCS_ENABLED === "true"
+      : (process.env.NODE_ENV || "development") !== "production",
+  },
+  baseUrl: process.env.BASE_URL || "http://localhost:3000",
   jwt: {
     secret: process.env.JWT_SECRET || (() => { throw new Error("JWT_SECRET is required"); })(),
     expiresIn: process.env.JWT_EXPIRES_IN || "15m",
     refreshSecret: process.env.JWT_REFRESH_SECRET || (() => { throw new Error("JWT_REFRESH_SECRET is required"); })(),
     refreshExpiresIn: process.env.JWT_REFRESH_EXPIRES_IN || "7d",
   },
+  httpClient: {
+    // Outbound calls: retries of idempotent requests (network errors, 429, 502-504) with jittered backoff
+    retries: parseInt(process.env.HTTP_CLIENT_RETRIES ?? "2", 10),
+    retryBaseMs: parseInt(process.env.HTTP_CLIENT_RETRY_BASE_MS, 10) || 200,
+    retryMaxMs: parseInt(process.env.HTTP_CLIENT_RETRY_MAX_MS, 10) || 2000,
+    // Consecutive failures that open the breaker of a host, and seconds before a probe call
+    breakerFailureThreshold: parseInt(process.env.HTTP_CLIENT_BREAKER_FAILURES, 10) || 5,
+    breakerResetSeconds: parseInt(process.env.HTTP_CLIENT_BREAKER_RESET_SECONDS, 10) || 30,
+  },
+  realtime: {
+    // Long-polling fallback (GET /api/poll) for clients that cannot keep a socket
+    pollHoldSeconds: parseInt(process.env.REALTIME_POLL_HOLD_SECONDS, 10) || 25,
+    // Events stay available to resume from a cursor for this long (and up to this many per room)
+    pollBufferSeconds: parseInt(process.env.REALTIME_POLL_BUFFER_SECONDS, 10) || 120,
+    pollBufferPerRoom: parseInt(process.env.REALTIME_POLL_BUFFER_PER_ROOM, 10) || 200,
+    pollMaxEvents: parseInt(process.env.REALTIME_POLL_MAX_EVENTS, 10) || 100,
+    // Held polls per user (a new poll on the same connection replaces the previous one) and per process
+    pollMaxPerUser: parseInt(process.env.REALTIME_POLL_MAX_PER_USER, 10) || 3,
+    pollMaxWaiters: parseInt(process.env.REALTIME_POLL_MAX_WAITERS, 10) || 5000,
+  },
+  usage: {
+    // Per-user and per-API-key request counts and compute-weighted costs
+    enabled: process.env.USAGE_METERING_ENABLED !== "false",
+    // Counters are kept in memory and written to the daily aggregates this often
+    flushSeconds: parseInt(process.env.USAGE_FLUSH_SECONDS, 10) || 10,
+    // Every this many milliseconds a request takes adds one cost unit
+    msPerUnit: parseInt(process.env.USAGE_MS_PER_UNIT, 10) || 250,
+    // Daily aggregates older than this are purged by the maintenance task
+    retentionMonths: parseInt(process.env.USAGE_RETENTION_MONTHS, 10) || 13,
+    // Cost units a user may spend per UTC day (0 disables the limit; admins are exempt)
+    userDailyCostQuota: parseInt(process.env.USAGE_USER_DAILY_COST_QUOTA, 10) || 0,
+    // Partner billing when the aggregator has no price of its own
+    defaultPricePerThousandUnits: parseFloat(process.env.USAGE_PRICE_PER_THOUSAND_UNITS) || 0,
+    billingCurrency: process.env.USAGE_BILLING_CURRENCY || "USD",
+  },
+  twoFactor: {
+    // 32-byte key (base64 or hex) encrypting the TOTP secrets at rest; without it 2FA cannot be enrolled
+    encryptionKey: process.env.TWO_FACTOR_ENCRYPTION_KEY,
+    issuer: process.env.TWO_FACTOR_ISSUER || "JoinTravel",
+    // Time to enter the code after the password
+    challengeMinutes: parseInt(process.env.TWO_FACTOR_CHALLENGE_MINUTES, 10) || 5,
+    // Admin routes need a session that went through 2FA
+    enforceForAdmins: process.env.TWO_FACTOR_ENFORCE_ADMINS !== "false",
+  },
+  oauth: {
+    // Client IDs the ID tokens must be issued to (web, iOS and Android apps); empty disables the provider
+    google: {
+      clientIds: (process.env.OAUTH_GOOGLE_CLIENT_IDS || "").split(",").map((id) => id.trim()).filter(Boolean),
+    },
+    // Services ID of the web and bundle IDs of the apps
+    apple: {
+      clientIds: (process.env.OAUTH_APPLE_CLIENT_IDS || "").split(",").map((id) => id.trim()).filter(Boolean),
+    },
+    timeoutMs: parseInt(process.env.OAUTH_TIMEOUT_MS, 10) || 5000,
+  },
 };
 
 export default config;
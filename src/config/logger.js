@@ -1,8 +1,24 @@
 import winston from 'winston';
+import { getContext, getRequestId } from '../utils/requestContext.js';
+
+// Tags lines logged while serving a request with its ID, and with the
+// booking it works on when known
+const requestId = winston.format((info) => {
+  const id = getRequestId();
+  if (id) {
+    info.requestId = id;
+  }
+  const bookingId = getContext()?.bookingId;
+  if (bookingId) {
+    info.bookingId = bookingId;
+  }
+  return info;
+});
 
 const logger = winston.createLogger({
   level: 'info',
   format: winston.format.combine(
+    requestId(),
     winston.format.timestamp(),
     winston.format.errors({ stack: true }),
     winston.format.json()
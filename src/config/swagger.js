@@ -1,6 +1,11 @@
+import fs from 'fs';
+import path from 'path';
 import swaggerJSDoc from 'swagger-jsdoc';
 import swaggerUi from 'swagger-ui-express';
 
+// Written at build time by scripts/generate-openapi.js
+export const GENERATED_SPEC_PATH = path.join(process.cwd(), 'src', 'openapi.json');
+
 const options = {
   definition: {
     openapi: '3.0.0',
@@ -205,12 +210,35 @@ const options = {
           },
         },
       },
+      parameters: {
+        IdempotencyKey: {
+          in: 'header',
+          name: 'Idempotency-Key',
+          required: false,
+          schema: { type: 'string', minLength: 8, maxLength: 255 },
+          description:
+            'Unique per operation (e.g. a UUID). Retries with the same key and body get the first response ' +
+            'back with Idempotent-Replayed: true; the same key with another body is 422 IDEMPOTENCY_KEY_REUSED, ' +
+            'and a retry while the first request is still running is 409 IDEMPOTENCY_REQUEST_IN_PROGRESS.',
+        },
+      },
       securitySchemes: {
         bearerAuth: {
           type: 'http',
           scheme: 'bearer',
           bearerFormat: 'JWT',
         },
+        aggregatorApiKey: {
+          type: 'apiKey',
+          in: 'header',
+          name: 'X-Api-Key',
+          description: 'Issued to approved aggregators (POST /api/v1/admin/aggregators)',
+        },
+        schedulerSecret: {
+          type: 'http',
+          scheme: 'bearer',
+          description: 'CRON_SECRET of the deployment, sent by the external scheduler',
+        },
       },
     },
     security: [
@@ -222,6 +250,24 @@ const options = {
   apis: ['./src/routes/*.js', './src/controllers/*.js', './src/app.js'], // Paths to files containing OpenAPI definitions
 };
 
-const specs = swaggerJSDoc(options);
+/**
+ * Builds the OpenAPI spec from the @swagger annotations. Routes are documented
+ * under /api and served under /api/v1 too; the spec points clients at /api/v1.
+ */
+export const buildSpec = () => {
+  const spec = swaggerJSDoc(options);
+  spec.paths = Object.fromEntries(
+    Object.entries(spec.paths).map(([route, operations]) => [
+      route.startsWith('/api/') && !route.startsWith('/api/v1/') ? `/api/v1/${route.slice(5)}` : route,
+      operations,
+    ])
+  );
+  return spec;
+};
+
+// Prefer the spec generated at build time, build it on the fly in development
+const specs = fs.existsSync(GENERATED_SPEC_PATH)
+  ? JSON.parse(fs.readFileSync(GENERATED_SPEC_PATH, 'utf8'))
+  : buildSpec();
 
 export { swaggerUi, specs };
\ No newline at end of file
@@ -0,0 +1,405 @@
+import adminService from "../services/admin.service.js";
+import auditService from "../services/audit.service.js";
+import statusService from "../services/status.service.js";
+import bookingTraceService from "../services/bookingTrace.service.js";
+import joinEligibilityService from "../services/joinEligibility.service.js";
+import pushTemplateService from "../services/pushTemplate.service.js";
+import logger from "../config/logger.js";
+import { ValidationError } from "../utils/customErrors.js";
+
+/**
+ * Lists users
+ * GET /api/admin/users
+ */
+export const listUsers = async (req, res, next) => {
+  try {
+    const result = await adminService.listUsers({
+      q: req.query.q,
+      status: req.query.status,
+      role: req.query.role,
+      limit: req.query.limit,
+      offset: req.query.offset,
+    });
+
+    res.status(200).json({
+      success: true,
+      data: result,
+    });
+  } catch (err) {
+    logger.error(`Admin list users failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Changes the role of a user
+ * PATCH /api/admin/users/:userId/role
+ */
+export const changeRole = async (req, res, next) => {
+  try {
+    const user = await adminService.changeRole(
+      req.user.id,
+      req.params.userId,
+      req.body.role
+    );
+    res.status(200).json({
+      success: true,
+      data: user,
+      message: "Rol actualizado",
+    });
+  } catch (err) {
+    logger.error(`Admin change role failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Suspends a user
+ * POST /api/admin/users/:userId/suspension
+ */
+export const suspendUser = async (req, res, next) => {
+  try {
+    const user = await adminService.suspendUser(req.user.id, req.params.userId, req.body.reason);
+    res.status(200).json({
+      success: true,
+      data: user,
+      message: "Cuenta suspendida",
+    });
+  } catch (err) {
+    logger.error(`Admin suspend user failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lifts the suspension of a user
+ * DELETE /api/admin/users/:userId/suspension
+ */
+export const reinstateUser = async (req, res, next) => {
+  try {
+    const user = await adminService.reinstateUser(req.user.id, req.params.userId);
+    res.status(200).json({
+      success: true,
+      data: user,
+      message: "Suspensión levantada",
+    });
+  } catch (err) {
+    logger.error(`Admin reinstate user failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Marks the identity of a user as verified
+ * POST /api/admin/users/:userId/id-verification
+ */
+export const verifyIdentity = async (req, res, next) => {
+  try {
+    const user = await adminService.verifyIdentity(req.user.id, req.params.userId);
+    res.status(200).json({
+      success: true,
+      data: user,
+      message: "Identidad verificada",
+    });
+  } catch (err) {
+    logger.error(`Admin verify identity failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Withdraws the identity verification of a user
+ * DELETE /api/admin/users/:userId/id-verification
+ */
+export const revokeIdVerification = async (req, res, next) => {
+  try {
+    const user = await adminService.revokeIdVerification(req.user.id, req.params.userId);
+    res.status(200).json({
+      success: true,
+      data: user,
+      message: "Verificación de identidad retirada",
+    });
+  } catch (err) {
+    logger.error(`Admin revoke id verification failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Deletes a trip regardless of its organizer
+ * DELETE /api/admin/trips/:groupId
+ */
+export const forceDeleteTrip = async (req, res, next) => {
+  try {
+    const result = await adminService.forceDeleteTrip(
+      req.user.id,
+      req.params.groupId,
+      req.body?.reason
+    );
+    res.status(200).json({
+      success: true,
+      data: result,
+      message: "Viaje eliminado",
+    });
+  } catch (err) {
+    logger.error(`Admin delete trip failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists soft-deleted records of a type
+ * GET /api/admin/trash/:type
+ */
+export const listTrash = async (req, res, next) => {
+  try {
+    const result = await adminService.listTrash(req.params.type, {
+      limit: req.query.limit,
+      offset: req.query.offset,
+    });
+
+    res.status(200).json({
+      success: true,
+      data: result,
+    });
+  } catch (err) {
+    logger.error(`Admin list trash failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Restores a soft-deleted record
+ * POST /api/admin/trash/:type/:id/restore
+ */
+export const restoreFromTrash = async (req, res, next) => {
+  try {
+    const result = await adminService.restoreFromTrash(req.user.id, req.params.type, req.params.id);
+    res.status(200).json({
+      success: true,
+      data: result,
+      message: "Elemento restaurado",
+    });
+  } catch (err) {
+    logger.error(`Admin restore from trash failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists reports for review
+ * GET /api/admin/reports
+ */
+export const getReports = async (req, res, next) => {
+  try {
+    const reports = await adminService.getReports({
+      status: req.query.status,
+      targetType: req.query.targetType,
+    });
+
+    res.status(200).json({
+      success: true,
+      data: reports,
+    });
+  } catch (err) {
+    logger.error(`Admin get reports failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Actions or dismisses a report
+ * POST /api/admin/reports/:id/resolve
+ */
+export const resolveReport = async (req, res, next) => {
+  try {
+    const report = await adminService.resolveReport(req.user.id, req.params.id, req.body);
+    res.status(200).json({
+      success: true,
+      data: report,
+      message: "Reporte resuelto",
+    });
+  } catch (err) {
+    logger.error(`Admin resolve report failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Platform stats
+ * GET /api/admin/stats
+ */
+export const getStats = async (req, res, next) => {
+  try {
+    const stats = await adminService.getStats();
+
+    res.status(200).json({
+      success: true,
+      data: stats,
+    });
+  } catch (err) {
+    logger.error(`Admin get stats failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Queries the audit log
+ * GET /api/admin/audit-logs
+ */
+export const getAuditLogs = async (req, res, next) => {
+  try {
+    const result = await auditService.query({
+      actorId: req.query.actorId,
+      action: req.query.action,
+      from: req.query.from,
+      to: req.query.to,
+      before: req.query.before,
+      limit: req.query.limit,
+    });
+
+    res.status(200).json({
+      success: true,
+      data: result,
+    });
+  } catch (err) {
+    logger.error(`Admin get audit logs failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Status of the database, Redis, job queue, breakers, webhooks and scheduler
+ * GET /api/admin/status
+ */
+export const getStatus = async (req, res, next) => {
+  try {
+    const status = await statusService.getStatus();
+
+    res.set("Cache-Control", "no-store");
+    res.status(200).json({
+      success: true,
+      data: status,
+    });
+  } catch (err) {
+    logger.error(`Admin get status failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Dry run of the join eligibility rules for any user and trip
+ * GET /api/admin/join-eligibility?groupId=&userId=
+ */
+export const getJoinEligibility = async (req, res, next) => {
+  try {
+    const { groupId, userId } = req.query;
+    if (!groupId || !userId) {
+      return next(new ValidationError("groupId y userId son requeridos"));
+    }
+    // Uses the user's own country and tenant, not the admin's
+    const result = await joinEligibilityService.explain(String(groupId), String(userId));
+    res.status(200).json({ success: true, data: result });
+  } catch (err) {
+    logger.error(`Admin get join eligibility failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Timeline of a booking across payments, webhooks, notifications and jobs
+ * GET /api/admin/bookings/:bookingId/timeline
+ */
+export const getBookingTimeline = async (req, res, next) => {
+  try {
+    const trace = await bookingTraceService.getTimeline(req.params.bookingId);
+    res.set("Cache-Control", "no-store");
+    res.status(200).json({
+      success: true,
+      data: trace,
+    });
+  } catch (err) {
+    logger.error(`Admin get booking timeline failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Push templates with their built-in and overridden texts
+ * GET /api/admin/push-templates
+ */
+export const listPushTemplates = async (req, res, next) => {
+  try {
+    const templates = await pushTemplateService.listTemplates();
+
+    res.status(200).json({
+      success: true,
+      data: templates,
+    });
+  } catch (err) {
+    logger.error(`Admin list push templates failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Overrides the text of a push template in a language
+ * PUT /api/admin/push-templates/:type/:language
+ */
+export const updatePushTemplate = async (req, res, next) => {
+  try {
+    const { type, language } = req.params;
+    const override = await pushTemplateService.setOverride(req.user.id, type, language, {
+      title: req.body.title,
+      body: req.body.body,
+    });
+    res.status(200).json({
+      success: true,
+      data: override,
+      message: "Plantilla actualizada",
+    });
+  } catch (err) {
+    logger.error(`Admin update push template failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Goes back to the built-in text of a push template in a language
+ * DELETE /api/admin/push-templates/:type/:language
+ */
+export const resetPushTemplate = async (req, res, next) => {
+  try {
+    const { type, language } = req.params;
+    await pushTemplateService.deleteOverride(type, language);
+    res.status(200).json({
+      success: true,
+      message: "Plantilla restablecida",
+    });
+  } catch (err) {
+    logger.error(`Admin reset push template failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  listUsers,
+  changeRole,
+  suspendUser,
+  reinstateUser,
+  verifyIdentity,
+  revokeIdVerification,
+  forceDeleteTrip,
+  listTrash,
+  restoreFromTrash,
+  getReports,
+  resolveReport,
+  getStats,
+  getAuditLogs,
+  getStatus,
+  getJoinEligibility,
+  getBookingTimeline,
+  listPushTemplates,
+  updatePushTemplate,
+  resetPushTemplate,
+};
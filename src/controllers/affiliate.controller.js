@@ -0,0 +1,88 @@
+import affiliateService from "../services/affiliate.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Creates an affiliate partner
+ * POST /api/admin/affiliates
+ */
+export const createPartner = async (req, res, next) => {
+  try {
+    const partner = await affiliateService.createPartner(req.body);
+    res.status(201).json({
+      success: true,
+      data: partner,
+      message: "Partner creado",
+    });
+  } catch (err) {
+    logger.error(`Create affiliate partner failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists affiliate partners
+ * GET /api/admin/affiliates
+ */
+export const listPartners = async (req, res, next) => {
+  try {
+    const partners = await affiliateService.listPartners();
+    res.status(200).json({ success: true, data: partners });
+  } catch (err) {
+    logger.error(`List affiliate partners failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Updates an affiliate partner
+ * PATCH /api/admin/affiliates/:partnerId
+ */
+export const updatePartner = async (req, res, next) => {
+  try {
+    const partner = await affiliateService.updatePartner(req.params.partnerId, req.body || {});
+    res.status(200).json({
+      success: true,
+      data: partner,
+      message: "Partner actualizado",
+    });
+  } catch (err) {
+    logger.error(`Update affiliate partner failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Commission report of every partner for a month
+ * GET /api/admin/affiliates/report?month=YYYY-MM
+ */
+export const getMonthlyReport = async (req, res, next) => {
+  try {
+    const report = await affiliateService.getMonthlyReport(req.query.month);
+    res.status(200).json({ success: true, data: report });
+  } catch (err) {
+    logger.error(`Affiliate report failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Monthly stats of the partner linked to the logged-in user
+ * GET /api/affiliates/me/stats?months=
+ */
+export const getMyStats = async (req, res, next) => {
+  try {
+    const stats = await affiliateService.getPartnerStats(req.user.id, { months: req.query.months });
+    res.status(200).json({ success: true, data: stats });
+  } catch (err) {
+    logger.error(`Affiliate stats failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  createPartner,
+  listPartners,
+  updatePartner,
+  getMonthlyReport,
+  getMyStats,
+};
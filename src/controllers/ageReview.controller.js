@@ -0,0 +1,64 @@
+import ageReviewService from "../services/ageReview.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Reports an account as possibly underage
+ * POST /api/users/:userId/age-report
+ */
+export const reportUser = async (req, res, next) => {
+  try {
+    await ageReviewService.reportUser(req.user.id, req.params.userId, req.body.reason);
+
+    // The reporter doesn't get to see the case
+    res.status(202).json({
+      success: true,
+      message: "Gracias, un administrador revisará la cuenta",
+    });
+  } catch (err) {
+    logger.error(`Age report failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists age reviews
+ * GET /api/admin/age-reviews?status=open|cleared|restricted
+ */
+export const getReviews = async (req, res, next) => {
+  try {
+    const reviews = await ageReviewService.getReviews(req.query.status || "open");
+
+    res.status(200).json({
+      success: true,
+      data: reviews,
+    });
+  } catch (err) {
+    logger.error(`Get age reviews failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Resolves an age review
+ * POST /api/admin/age-reviews/:id/resolve
+ */
+export const resolveReview = async (req, res, next) => {
+  try {
+    const review = await ageReviewService.resolveReview(req.user.id, req.params.id, req.body);
+
+    res.status(200).json({
+      success: true,
+      data: review,
+      message: "Revisión resuelta",
+    });
+  } catch (err) {
+    logger.error(`Resolve age review failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  reportUser,
+  getReviews,
+  resolveReview,
+};
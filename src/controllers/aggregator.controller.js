@@ -0,0 +1,104 @@
+import aggregatorService from "../services/aggregator.service.js";
+import usageService from "../services/usage.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Approves an aggregator and issues its API key
+ * POST /api/admin/aggregators
+ */
+export const createPartner = async (req, res, next) => {
+  try {
+    const result = await aggregatorService.createPartner(req.user.id, req.body);
+    res.status(201).json({
+      success: true,
+      data: result,
+      message: "Agregador aprobado. Guarda la API key: no se vuelve a mostrar.",
+    });
+  } catch (err) {
+    logger.error(`Create aggregator failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists aggregators
+ * GET /api/admin/aggregators
+ */
+export const listPartners = async (req, res, next) => {
+  try {
+    const partners = await aggregatorService.listPartners();
+    res.status(200).json({ success: true, data: partners });
+  } catch (err) {
+    logger.error(`List aggregators failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Aggregator details with its recent usage
+ * GET /api/admin/aggregators/:partnerId
+ */
+export const getPartner = async (req, res, next) => {
+  try {
+    const partner = await aggregatorService.getPartner(req.params.partnerId);
+    res.status(200).json({ success: true, data: partner });
+  } catch (err) {
+    logger.error(`Get aggregator failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Updates the quota, contact or approval of an aggregator
+ * PATCH /api/admin/aggregators/:partnerId
+ */
+export const updatePartner = async (req, res, next) => {
+  try {
+    const partner = await aggregatorService.updatePartner(req.params.partnerId, req.body || {});
+    res.status(200).json({ success: true, data: partner, message: "Agregador actualizado" });
+  } catch (err) {
+    logger.error(`Update aggregator failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Issues a new API key, revoking the current one
+ * POST /api/admin/aggregators/:partnerId/rotate-key
+ */
+export const rotateKey = async (req, res, next) => {
+  try {
+    const result = await aggregatorService.rotateKey(req.params.partnerId);
+    res.status(200).json({
+      success: true,
+      data: result,
+      message: "API key renovada. La anterior dejó de funcionar.",
+    });
+  } catch (err) {
+    logger.error(`Rotate aggregator key failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Monthly billing summary of an aggregator
+ * GET /api/admin/aggregators/:partnerId/billing?month=YYYY-MM
+ */
+export const getBilling = async (req, res, next) => {
+  try {
+    const billing = await usageService.getPartnerBilling(req.params.partnerId, req.query.month);
+    res.status(200).json({ success: true, data: billing });
+  } catch (err) {
+    logger.error(`Get aggregator billing failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  createPartner,
+  listPartners,
+  getPartner,
+  updatePartner,
+  rotateKey,
+  getBilling,
+};
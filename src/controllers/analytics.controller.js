@@ -0,0 +1,65 @@
+import analyticsService from "../services/analytics.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Lists available aggregated metrics
+ * GET /api/admin/analytics
+ */
+export const listMetrics = async (req, res, next) => {
+  try {
+    res.status(200).json({
+      success: true,
+      data: analyticsService.listMetrics(),
+    });
+  } catch (err) {
+    logger.error(`List analytics metrics failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Gets an aggregated metric with small cohorts suppressed
+ * GET /api/admin/analytics/metrics/:name
+ */
+export const getMetric = async (req, res, next) => {
+  try {
+    const { name } = req.params;
+    const result = await analyticsService.getMetric(name, req.query.k);
+
+    res.status(200).json({
+      success: true,
+      data: result,
+    });
+  } catch (err) {
+    logger.error(`Get analytics metric failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Exports every metric for the data warehouse
+ * GET /api/admin/analytics/export?format=json|csv
+ */
+export const exportMetrics = async (req, res, next) => {
+  try {
+    const format = req.query.format || "json";
+    const result = await analyticsService.exportMetrics(format);
+
+    logger.info(`Analytics export generated by admin ${req.user.id} (${format})`);
+    res.setHeader("Content-Type", result.contentType);
+    res.setHeader(
+      "Content-Disposition",
+      `attachment; filename="analytics-export.${format}"`
+    );
+    res.status(200).send(result.body);
+  } catch (err) {
+    logger.error(`Export analytics failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  listMetrics,
+  getMetric,
+  exportMetrics,
+};
@@ -0,0 +1,26 @@
+import analyticsEventService from "../services/analyticsEvent.service.js";
+import { getPolicyContext } from "../middleware/policy.middleware.js";
+import { getRequestLocale } from "../utils/i18n.js";
+import logger from "../config/logger.js";
+
+/**
+ * Ingests a batch of client analytics events
+ * POST /api/events
+ */
+export const ingestEvents = async (req, res, next) => {
+  try {
+    const result = await analyticsEventService.ingest(req.body, {
+      userId: req.user?.id ?? null,
+      tenant: getPolicyContext(req).tenant,
+      locale: getRequestLocale(req),
+    });
+    res.status(202).json({ success: true, data: result });
+  } catch (err) {
+    logger.error(`Ingest analytics events failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  ingestEvents,
+};
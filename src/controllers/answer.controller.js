@@ -1,5 +1,6 @@
 import answerService from "../services/answer.service.js";
 import logger from "../config/logger.js";
+import { ValidationError } from "../utils/customErrors.js";
 
 /**
  * Crea una nueva respuesta
@@ -14,17 +15,11 @@ export const createAnswer = async (req, res, next) => {
     const userId = req.user.id;
 
     if (!questionId) {
-      return res.status(400).json({
-        success: false,
-        message: "ID de la pregunta es requerido.",
-      });
+      return next(new ValidationError("ID de la pregunta es requerido."));
     }
 
     if (!content) {
-      return res.status(400).json({
-        success: false,
-        message: "El contenido de la respuesta es requerido.",
-      });
+      return next(new ValidationError("El contenido de la respuesta es requerido."));
     }
 
     const result = await answerService.createAnswer({
@@ -41,12 +36,6 @@ export const createAnswer = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Create answer endpoint failed: ${err.message}`);
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
     next(err);
   }
 };
@@ -61,10 +50,7 @@ export const getAnswersByQuestion = async (req, res, next) => {
     const { questionId } = req.params;
 
     if (!questionId) {
-      return res.status(400).json({
-        success: false,
-        message: "ID de la pregunta es requerido.",
-      });
+      return next(new ValidationError("ID de la pregunta es requerido."));
     }
 
     const answers = await answerService.getAnswersByQuestion(questionId);
@@ -76,12 +62,6 @@ export const getAnswersByQuestion = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Get answers by question endpoint failed: ${err.message}`);
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
     next(err);
   }
 };
@@ -97,10 +77,7 @@ export const voteAnswer = async (req, res, next) => {
     const userId = req.user.id;
 
     if (!answerId) {
-      return res.status(400).json({
-        success: false,
-        message: "ID de la respuesta es requerido.",
-      });
+      return next(new ValidationError("ID de la respuesta es requerido."));
     }
 
     const result = await answerService.voteAnswer(answerId, userId);
@@ -113,12 +90,6 @@ export const voteAnswer = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Vote answer endpoint failed: ${err.message}`);
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
     next(err);
   }
 };
@@ -134,10 +105,7 @@ export const getAnswerVoteStatus = async (req, res, next) => {
     const userId = req.user.id;
 
     if (!answerId) {
-      return res.status(400).json({
-        success: false,
-        message: "ID de la respuesta es requerido.",
-      });
+      return next(new ValidationError("ID de la respuesta es requerido."));
     }
 
     const result = await answerService.getVoteStatus(answerId, userId);
@@ -149,12 +117,6 @@ export const getAnswerVoteStatus = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Get answer vote status endpoint failed: ${err.message}`);
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
     next(err);
   }
 };
\ No newline at end of file
@@ -0,0 +1,154 @@
+import attachmentService from "../services/attachment.service.js";
+import logger from "../config/logger.js";
+import { ValidationError } from "../utils/customErrors.js";
+
+/**
+ * Creates a presigned upload URL
+ * POST /api/attachments/presign
+ */
+export const createPresignedUpload = async (req, res, next) => {
+  try {
+    const { kind, groupId, contentType, size, filename } = req.body || {};
+    const result = await attachmentService.createPresignedUpload(req.user.id, {
+      kind,
+      groupId,
+      contentType,
+      size: Number(size),
+      filename,
+    });
+
+    res.status(201).json({
+      success: true,
+      data: result,
+    });
+  } catch (err) {
+    logger.error(`Create presigned upload failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Confirms a presigned upload
+ * POST /api/attachments/:id/complete
+ */
+export const completeUpload = async (req, res, next) => {
+  try {
+    const attachment = await attachmentService.completeUpload(req.user.id, req.params.id);
+
+    res.status(200).json({
+      success: true,
+      data: attachment,
+      message: "Archivo subido correctamente",
+    });
+  } catch (err) {
+    logger.error(`Complete upload failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Uploads a file through the API
+ * POST /api/attachments
+ */
+export const uploadFile = async (req, res, next) => {
+  try {
+    const { kind, groupId } = req.body || {};
+    const attachment = await attachmentService.uploadFile(req.user.id, {
+      kind,
+      groupId,
+      file: req.file,
+    });
+
+    res.status(201).json({
+      success: true,
+      data: attachment,
+      message: "Archivo subido correctamente",
+    });
+  } catch (err) {
+    logger.error(`Upload file failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Gets an attachment with a temporary download URL
+ * GET /api/attachments/:id
+ */
+export const getAttachment = async (req, res, next) => {
+  try {
+    const attachment = await attachmentService.getAttachment(req.user.id, req.params.id);
+
+    res.status(200).json({
+      success: true,
+      data: attachment,
+    });
+  } catch (err) {
+    logger.error(`Get attachment failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists the photos of a group
+ * GET /api/attachments?groupId=
+ */
+export const getGroupAttachments = async (req, res, next) => {
+  try {
+    const { groupId } = req.query;
+    if (!groupId) {
+      return next(new ValidationError("groupId es requerido"));
+    }
+
+    const attachments = await attachmentService.getGroupAttachments(req.user.id, groupId);
+
+    res.status(200).json({
+      success: true,
+      data: attachments,
+    });
+  } catch (err) {
+    logger.error(`Get group attachments failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Redirects to the content of a public attachment (avatars)
+ * GET /api/attachments/:id/content
+ */
+export const getContent = async (req, res, next) => {
+  try {
+    const url = await attachmentService.getPublicContentUrl(req.params.id);
+    res.redirect(302, url);
+  } catch (err) {
+    logger.error(`Get attachment content failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Deletes an attachment
+ * DELETE /api/attachments/:id
+ */
+export const deleteAttachment = async (req, res, next) => {
+  try {
+    await attachmentService.deleteAttachment(req.user.id, req.params.id);
+
+    res.status(200).json({
+      success: true,
+      message: "Archivo eliminado",
+    });
+  } catch (err) {
+    logger.error(`Delete attachment failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  createPresignedUpload,
+  completeUpload,
+  uploadFile,
+  getAttachment,
+  getGroupAttachments,
+  getContent,
+  deleteAttachment,
+};
@@ -1,23 +1,46 @@
 import authService from "../services/auth.service.js";
+import affiliateService from "../services/affiliate.service.js";
+import twoFactorService from "../services/twoFactor.service.js";
 import logger from "../config/logger.js";
 import { ValidationError, AuthenticationError } from "../utils/customErrors.js";
+import { negotiateLocale } from "../utils/i18n.js";
 
 /**
  * Registra un nuevo usuario
  * POST /api/auth/register
- * Body: { email, password, name (optional), age (optional) }
+ * Body: { email, password, dateOfBirth, name (optional), country (optional),
+ *         affiliateCode (optional), utm: { source, medium, campaign } (optional) }
  */
 export const register = async (req, res, next) => {
   logger.info(`Register endpoint called with email: ${req.body.email}`);
   try {
-    const { email, password, name, age } = req.body;
+    const { email, password, name, dateOfBirth, country, affiliateCode, utm } = req.body;
 
     // Validar que se envíen los campos requeridos
     if (!email || !password) {
-      throw new ValidationError("Email y contraseña son requeridos.");
+      throw new ValidationError("Email y contraseña son requeridos.", null, "CREDENTIALS_REQUIRED");
     }
 
-    const result = await authService.register({ email, password, name, age });
+    const result = await authService.register({
+      email,
+      password,
+      name,
+      dateOfBirth,
+      country,
+      // Only a verified custom domain puts the account in a tenant; X-Tenant-Id is unverified
+      tenantId: req.domainTenantId,
+      // Emails start in the language of the device the account was created on
+      locale: negotiateLocale(req),
+    });
+    // Atribuir el registro al partner del código o de la fuente UTM
+    if (affiliateCode || utm?.source) {
+      await affiliateService.recordSignup(result.user.id, {
+        code: affiliateCode,
+        utmSource: utm?.source,
+        utmMedium: utm?.medium,
+        utmCampaign: utm?.campaign,
+      });
+    }
 
     logger.info(`Register endpoint completed successfully for email: ${req.body.email}`);
     res.status(201).json({
@@ -32,6 +55,41 @@ export const register = async (req, res, next) => {
   }
 };
 
+/**
+ * Dispositivo que inicia sesión: nombre enviado por la app (deviceName o
+ * X-Device-Name), user agent e IP
+ * @param {Object} req
+ * @returns {Object} - { deviceName, userAgent, ipAddress }
+ */
+const sessionClient = (req) => ({
+  deviceName: req.body?.deviceName ?? req.get("x-device-name"),
+  userAgent: req.get("user-agent"),
+  ipAddress: req.ip,
+});
+
+/**
+ * Cuerpo de respuesta de un login: los tokens, o el desafío de 2FA
+ * @param {Object} result - De authService.issueSession
+ * @returns {Object}
+ */
+const sessionData = (result) => {
+  if (result.twoFactorRequired) {
+    return { twoFactorRequired: true, challengeToken: result.challengeToken };
+  }
+  return {
+    user: {
+      id: result.user.id,
+      email: result.user.email,
+      isEmailConfirmed: result.user.isEmailConfirmed,
+      createdAt: result.user.createdAt,
+      updatedAt: result.user.updatedAt,
+    },
+    accessToken: result.accessToken,
+    refreshToken: result.refreshToken,
+    ...(result.twoFactorSetupRequired && { twoFactorSetupRequired: true }),
+  };
+};
+
 export const login = async (req, res, next) => {
   logger.info(`Login endpoint called with email: ${req.body.email}`);
   try {
@@ -39,31 +97,145 @@ export const login = async (req, res, next) => {
 
     // Validar que se envíen los campos requeridos
     if (!email || !password) {
-      throw new ValidationError("Email y contraseña son requeridos.");
+      throw new ValidationError("Email y contraseña son requeridos.", null, "CREDENTIALS_REQUIRED");
     }
 
-    const result = await authService.login({ email, password });
+    const result = await authService.login({ email, password }, sessionClient(req));
     logger.info(`Login endpoint completed successfully for email: ${req.body.email}`);
 
+    res.status(200).json({ success: true, data: sessionData(result) });
+  } catch (err) {
+    logger.error(`Login endpoint failed for email: ${req.body.email}, error: ${err.message}`);
+    next(err);
+  }
+};
+/**
+ * Segundo paso del login con verificación en dos pasos
+ * POST /api/auth/2fa/verify
+ * Body: { challengeToken, code, deviceName (opcional) }
+ */
+export const verifyTwoFactorLogin = async (req, res, next) => {
+  try {
+    const { challengeToken, code } = req.body;
+
+    const result = await authService.completeTwoFactorLogin(challengeToken, code, sessionClient(req));
+
+    res.status(200).json({ success: true, data: sessionData(result) });
+  } catch (err) {
+    logger.error(`Two-factor login failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Estado de la verificación en dos pasos del usuario
+ * GET /api/auth/2fa
+ */
+export const getTwoFactorStatus = async (req, res, next) => {
+  try {
+    const status = await twoFactorService.getStatus(req.user.id);
+    res.status(200).json({ success: true, data: status });
+  } catch (err) {
+    logger.error(`Get two-factor status failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Inicia la configuración: secreto y URI otpauth:// para el código QR
+ * POST /api/auth/2fa/setup
+ */
+export const setupTwoFactor = async (req, res, next) => {
+  try {
+    const enrollment = await twoFactorService.startEnrollment(req.user.id);
     res.status(200).json({
       success: true,
-      data: {
-        user: {
-          id: result.user.id,
-          email: result.user.email,
-          isEmailConfirmed: result.user.isEmailConfirmed,
-          createdAt: result.user.createdAt,
-          updatedAt: result.user.updatedAt,
-        },
-        accessToken: result.accessToken,
-        refreshToken: result.refreshToken,
-      },
+      data: enrollment,
+      message: "Escanea el código QR con tu app de autenticación y confirma con un código.",
     });
   } catch (err) {
-    logger.error(`Login endpoint failed for email: ${req.body.email}, error: ${err.message}`);
+    logger.error(`Two-factor setup failed: ${err.message}`);
     next(err);
   }
 };
+
+/**
+ * Activa la verificación en dos pasos con un código de la app
+ * POST /api/auth/2fa/enable
+ * Body: { code }
+ */
+export const enableTwoFactor = async (req, res, next) => {
+  try {
+    const result = await twoFactorService.enable(req.user.id, req.body.code);
+    res.status(200).json({
+      success: true,
+      data: result,
+      message: "Verificación en dos pasos activada. Guarda los códigos de respaldo en un lugar seguro.",
+    });
+  } catch (err) {
+    logger.error(`Enable two-factor failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Desactiva la verificación en dos pasos
+ * POST /api/auth/2fa/disable
+ * Body: { code }
+ */
+export const disableTwoFactor = async (req, res, next) => {
+  try {
+    await twoFactorService.disable(req.user.id, req.body.code);
+    res.status(200).json({ success: true, message: "Verificación en dos pasos desactivada." });
+  } catch (err) {
+    logger.error(`Disable two-factor failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Genera códigos de respaldo nuevos (los anteriores dejan de servir)
+ * POST /api/auth/2fa/backup-codes
+ * Body: { code }
+ */
+export const regenerateBackupCodes = async (req, res, next) => {
+  try {
+    const result = await twoFactorService.regenerateBackupCodes(req.user.id, req.body.code);
+    res.status(200).json({ success: true, data: result });
+  } catch (err) {
+    logger.error(`Regenerate backup codes failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Inicia sesión (o registra) con Google o Apple
+ * POST /api/auth/oauth/:provider
+ * Body: { idToken, accessToken (solo google), nonce, name, dateOfBirth, country, deviceName (opcional) }
+ */
+export const oauthLogin = async (req, res, next) => {
+  const { provider } = req.params;
+  logger.info(`OAuth login endpoint called with provider: ${provider}`);
+  try {
+    const { idToken, accessToken, nonce, name, dateOfBirth, country } = req.body;
+
+    const result = await authService.loginWithProvider(
+      provider,
+      { idToken, accessToken, nonce, name, dateOfBirth, country },
+      { tenantId: req.domainTenantId, client: sessionClient(req) }
+    );
+    logger.info(`OAuth login endpoint completed successfully for user: ${result.user.id}`);
+
+    res.status(result.isNewUser ? 201 : 200).json({
+      success: true,
+      data: { ...sessionData(result), isNewUser: result.isNewUser },
+    });
+  } catch (err) {
+    logger.error(`OAuth login endpoint failed for provider: ${provider}, error: ${err.message}`);
+    next(err);
+  }
+};
+
 /**
  * Confirma el email de un usuario
  * GET /api/auth/confirm-email/:token
@@ -146,7 +318,7 @@ export const refreshToken = async (req, res, next) => {
       throw new ValidationError("Refresh token es requerido.");
     }
 
-    const result = await authService.refreshToken(refreshToken);
+    const result = await authService.refreshToken(refreshToken, sessionClient(req));
 
     logger.info(`Refresh token endpoint completed successfully`);
     res.status(200).json({
@@ -201,7 +373,7 @@ export const resetPassword = async (req, res, next) => {
     const { token, password } = req.body;
 
     if (!token || !password) {
-      throw new ValidationError("Token y contraseña son requeridos.");
+      throw new ValidationError("Token y contraseña son requeridos.", null, "RESET_FIELDS_REQUIRED");
     }
 
     const result = await authService.resetPassword(token, password);
@@ -216,3 +388,26 @@ export const resetPassword = async (req, res, next) => {
     next(err);
   }
 };
+
+/**
+ * Cambia la contraseña del usuario autenticado
+ * POST /api/auth/change-password
+ * Body: { currentPassword, newPassword }
+ */
+export const changePassword = async (req, res, next) => {
+  try {
+    const { currentPassword, newPassword } = req.body;
+    const result = await authService.changePassword(
+      req.user.id,
+      { currentPassword, newPassword },
+      req.user.sessionId
+    );
+    res.status(200).json({
+      success: true,
+      message: result.message,
+    });
+  } catch (err) {
+    logger.error(`Change password endpoint failed for user ${req.user.id}, error: ${err.message}`);
+    next(err);
+  }
+};
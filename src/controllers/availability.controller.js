@@ -0,0 +1,35 @@
+import availabilityFeedService from "../services/availabilityFeed.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Trip availability feed for aggregators
+ * GET /api/availability/trips?cursor=&since=&limit=
+ */
+export const getFeed = async (req, res, next) => {
+  try {
+    const prepared = await availabilityFeedService.prepare({
+      cursor: req.query.cursor,
+      since: req.query.since,
+      limit: req.query.limit,
+    });
+
+    res.set("Cache-Control", "private, no-cache");
+    res.set("ETag", prepared.etag);
+    if (prepared.lastModified) {
+      res.set("Last-Modified", prepared.lastModified.toUTCString());
+    }
+    if (req.fresh) {
+      return res.status(304).end();
+    }
+
+    const page = await availabilityFeedService.getPage(prepared);
+    res.status(200).json({ success: true, data: page });
+  } catch (err) {
+    logger.error(`Availability feed failed for aggregator ${req.aggregator?.id}: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  getFeed,
+};
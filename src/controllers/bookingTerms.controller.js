@@ -0,0 +1,39 @@
+import bookingTermsService from "../services/bookingTerms.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Terms the caller agreed to when booking a trip
+ * GET /api/groups/:groupId/booking-terms
+ */
+export const getMyTerms = async (req, res, next) => {
+  try {
+    const terms = await bookingTermsService.getMyTerms(req.params.groupId, req.user.id);
+    res.status(200).json({ success: true, data: terms });
+  } catch (err) {
+    logger.error(`Get booking terms failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Terms a member agreed to (organizer only)
+ * GET /api/groups/:groupId/booking-terms/:userId
+ */
+export const getMemberTerms = async (req, res, next) => {
+  try {
+    const terms = await bookingTermsService.getMemberTerms(
+      req.params.groupId,
+      req.params.userId,
+      req.user.id
+    );
+    res.status(200).json({ success: true, data: terms });
+  } catch (err) {
+    logger.error(`Get member booking terms failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  getMyTerms,
+  getMemberTerms,
+};
@@ -0,0 +1,90 @@
+import calendarService from "../services/calendar.service.js";
+import logger from "../config/logger.js";
+import { NotFoundError } from "../utils/customErrors.js";
+
+/**
+ * iCalendar file of a trip
+ * GET /api/trips/:id/calendar.ics
+ */
+export const getTripCalendar = async (req, res, next) => {
+  try {
+    const { filename, content } = await calendarService.getTripCalendar(req.params.id, req.user.id);
+    res.set("Content-Type", "text/calendar; charset=utf-8");
+    res.set("Content-Disposition", `attachment; filename="${filename}"`);
+    res.set("Cache-Control", "private, no-cache");
+    res.status(200).send(content);
+  } catch (err) {
+    logger.error(`Get calendar failed for trip ${req.params.id}: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Calendar feed of the user the token belongs to
+ * GET /api/calendar/feed/:token
+ */
+export const getFeed = async (req, res, next) => {
+  try {
+    const content = await calendarService.getFeed(req.params.token);
+    if (!content) {
+      return next(new NotFoundError("Calendario no encontrado"));
+    }
+    res.set("Content-Type", "text/calendar; charset=utf-8");
+    res.set("Cache-Control", "private, max-age=900");
+    res.status(200).send(content);
+  } catch (err) {
+    logger.error(`Get calendar feed failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Whether the caller has a calendar feed
+ * GET /api/me/calendar-feed
+ */
+export const getFeedInfo = async (req, res, next) => {
+  try {
+    const info = await calendarService.getFeedInfo(req.user.id);
+    res.status(200).json({ success: true, data: info });
+  } catch (err) {
+    logger.error(`Get calendar feed info failed for user ${req.user?.id}: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Creates the caller's calendar feed or gives it a new URL
+ * POST /api/me/calendar-feed
+ */
+export const createFeed = async (req, res, next) => {
+  try {
+    const feed = await calendarService.createFeed(req.user.id);
+    res.set("Cache-Control", "no-store");
+    res.status(201).json({ success: true, data: feed });
+  } catch (err) {
+    logger.error(`Create calendar feed failed for user ${req.user?.id}: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Deletes the caller's calendar feed
+ * DELETE /api/me/calendar-feed
+ */
+export const deleteFeed = async (req, res, next) => {
+  try {
+    await calendarService.deleteFeed(req.user.id);
+    res.status(200).json({ success: true, message: "Calendario eliminado" });
+  } catch (err) {
+    logger.error(`Delete calendar feed failed for user ${req.user?.id}: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  getTripCalendar,
+  getFeed,
+  getFeedInfo,
+  createFeed,
+  deleteFeed,
+};
@@ -1,6 +1,7 @@
 import chatService from "../services/chat.service.js";
 import rateLimitService from "../services/rateLimit.service.js";
 import logger from "../config/logger.js";
+import { AppError, ValidationError } from "../utils/customErrors.js";
 
 /**
  * Send a chat message
@@ -15,33 +16,25 @@ export const sendMessage = async (req, res, next) => {
 
     // Validate required fields
     if (!message || !timestamp) {
-      return res.status(400).json({
-        success: false,
-        message: "message and timestamp are required.",
-      });
+      return next(new ValidationError("message and timestamp are required."));
     }
 
     // Validate message length
     if (message.trim().length < 1 || message.trim().length > 2000) {
-      return res.status(400).json({
-        success: false,
-        message: "Message must be between 1 and 2000 characters.",
-      });
+      return next(new ValidationError("Message must be between 1 and 2000 characters."));
     }
 
     // Check rate limits before processing message
     const rateLimitCheck = await rateLimitService.checkLimits(userId);
     if (!rateLimitCheck.canSend) {
       logger.warn(`Rate limit exceeded for user ${userId}: ${rateLimitCheck.reason}`);
-      return res.status(429).json({
-        success: false,
-        message: rateLimitCheck.message,
-        rateLimit: {
+      return next(
+        new AppError(rateLimitCheck.message, 429, "RATE_LIMIT_ERROR", {
           reason: rateLimitCheck.reason,
           blockedUntil: rateLimitCheck.blockedUntil,
           remainingSeconds: rateLimitCheck.remainingSeconds,
-        },
-      });
+        })
+      );
     }
 
     const result = await chatService.sendMessage({
@@ -61,13 +54,6 @@ export const sendMessage = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Send message endpoint failed for user: ${req.body.userId}, error: ${err.message}`);
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-        ...(err.details && { errors: err.details }),
-      });
-    }
     next(err);
   }
 };
@@ -101,13 +87,6 @@ export const getChatHistory = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Get chat history endpoint failed for user: ${req.params.userId}, error: ${err.message}`);
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-        ...(err.details && { errors: err.details }),
-      });
-    }
     next(err);
   }
 };
@@ -130,13 +109,6 @@ export const getConversations = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Get conversations endpoint failed for user: ${req.params.userId}, error: ${err.message}`);
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-        ...(err.details && { errors: err.details }),
-      });
-    }
     next(err);
   }
 };
@@ -164,13 +136,6 @@ export const createConversation = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Create conversation endpoint failed for user: ${req.user.id}, error: ${err.message}`);
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-        ...(err.details && { errors: err.details }),
-      });
-    }
     next(err);
   }
 };
@@ -193,13 +158,6 @@ export const deleteCurrentConversation = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Delete current conversation endpoint failed for user: ${req.user.id}, error: ${err.message}`);
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-        ...(err.details && { errors: err.details }),
-      });
-    }
     next(err);
   }
 };
@@ -222,13 +180,6 @@ export const deleteAllChatHistory = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Delete all chat history endpoint failed for user: ${req.user.id}, error: ${err.message}`);
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-        ...(err.details && { errors: err.details }),
-      });
-    }
     next(err);
   }
 };
\ No newline at end of file
@@ -0,0 +1,31 @@
+import policyService from "../services/policy.service.js";
+import tenantService from "../services/tenant.service.js";
+import featureFlagService from "../services/featureFlag.service.js";
+import { getPolicyContext } from "../middleware/policy.middleware.js";
+import logger from "../config/logger.js";
+
+/**
+ * Gets the features, requirements and theme that apply to the caller
+ * GET /api/client-config
+ */
+export const getClientConfig = async (req, res, next) => {
+  try {
+    const context = getPolicyContext(req);
+    res.status(200).json({
+      success: true,
+      data: {
+        ...policyService.getClientConfig(context),
+        // Only a theme, so the tenant the app says it is will do
+        branding: await tenantService.getBranding(context.tenant || req.tenantId),
+        flags: await featureFlagService.getAllForUser({ userId: req.user?.id }),
+      },
+    });
+  } catch (err) {
+    logger.error(`Get client config failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  getClientConfig,
+};
@@ -0,0 +1,97 @@
+import companionReferenceService from "../services/companionReference.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Writes a reference about a co-traveler
+ * POST /api/references
+ */
+export const createReference = async (req, res, next) => {
+  try {
+    const { subjectId, groupId, content } = req.body || {};
+    const reference = await companionReferenceService.createReference(req.user.id, {
+      subjectId,
+      groupId,
+      content,
+    });
+    res.status(201).json({
+      success: true,
+      data: reference,
+      message: "Referencia enviada. Se mostrará cuando tu compañero la confirme",
+    });
+  } catch (err) {
+    logger.error(`Create reference failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists the references waiting for the current user's confirmation
+ * GET /api/references/pending
+ */
+export const getPendingReferences = async (req, res, next) => {
+  try {
+    const references = await companionReferenceService.getPendingReferences(req.user.id);
+    res.status(200).json({ success: true, data: references });
+  } catch (err) {
+    logger.error(`Get pending references failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Confirms a reference
+ * POST /api/references/:id/confirm
+ */
+export const confirmReference = async (req, res, next) => {
+  try {
+    const reference = await companionReferenceService.respondToReference(
+      req.params.id,
+      req.user.id,
+      true
+    );
+    res.status(200).json({ success: true, data: reference, message: "Referencia confirmada" });
+  } catch (err) {
+    logger.error(`Confirm reference failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Declines a reference
+ * POST /api/references/:id/decline
+ */
+export const declineReference = async (req, res, next) => {
+  try {
+    const reference = await companionReferenceService.respondToReference(
+      req.params.id,
+      req.user.id,
+      false
+    );
+    res.status(200).json({ success: true, data: reference, message: "Referencia rechazada" });
+  } catch (err) {
+    logger.error(`Decline reference failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists the confirmed references of a user with their trust score
+ * GET /api/users/:userId/references
+ */
+export const getUserReferences = async (req, res, next) => {
+  try {
+    const result = await companionReferenceService.getUserReferences(req.params.userId);
+    res.status(200).json({ success: true, data: result });
+  } catch (err) {
+    logger.error(`Get user references failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  createReference,
+  getPendingReferences,
+  confirmReference,
+  declineReference,
+  getUserReferences,
+};
@@ -1,5 +1,6 @@
 import directMessageService from "../services/directMessage.service.js";
 import logger from "../config/logger.js";
+import { ValidationError } from "../utils/customErrors.js";
 
 class DirectMessageController {
   /**
@@ -12,17 +13,11 @@ class DirectMessageController {
       const { receiverId, content } = req.body;
 
       if (!receiverId) {
-        return res.status(400).json({
-          success: false,
-          message: "Receiver ID is required",
-        });
+        return next(new ValidationError("Receiver ID is required"));
       }
 
       if (!content) {
-        return res.status(400).json({
-          success: false,
-          message: "Message content is required",
-        });
+        return next(new ValidationError("Message content is required"));
       }
 
       const result = await directMessageService.sendMessage({
@@ -49,10 +44,7 @@ class DirectMessageController {
       const { limit = 50, offset = 0 } = req.query;
 
       if (!otherUserId) {
-        return res.status(400).json({
-          success: false,
-          message: "Other user ID is required",
-        });
+        return next(new ValidationError("Other user ID is required"));
       }
 
       const result = await directMessageService.getConversationHistory(
@@ -104,6 +96,24 @@ class DirectMessageController {
       next(error);
     }
   }
+
+  /**
+   * Delete a message sent by the user
+   * DELETE /api/direct-messages/:messageId
+   */
+  async deleteMessage(req, res, next) {
+    try {
+      const userId = req.user.id; // From auth middleware
+      const { messageId } = req.params;
+
+      const result = await directMessageService.deleteMessage(userId, messageId);
+
+      res.status(200).json(result);
+    } catch (error) {
+      logger.error(`Error in deleteMessage controller: ${error.message}`);
+      next(error);
+    }
+  }
 }
 
 export default new DirectMessageController();
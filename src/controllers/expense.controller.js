@@ -2,12 +2,12 @@ import expenseService from "../services/expense.service.js";
 
 export const createExpense = async (req, res, next) => {
   try {
-    const { concept, amount } = req.body;
+    const { concept, amount, currency, paidById, participantIds } = req.body;
     const { groupId } = req.params;
     const userId = req.user.id;
 
     const expense = await expenseService.createExpense(
-      { concept, amount, groupId },
+      { concept, amount, currency, paidById, participantIds, groupId },
       userId
     );
 
@@ -26,7 +26,11 @@ export const getGroupExpenses = async (req, res, next) => {
     const { groupId } = req.params;
     const userId = req.user.id;
 
-    const result = await expenseService.getGroupExpenses(groupId, userId);
+    const result = await expenseService.getGroupExpenses(
+      groupId,
+      userId,
+      req.query.currency
+    );
 
     res.status(200).json({
       success: true,
@@ -41,7 +45,30 @@ export const getUserExpenses = async (req, res, next) => {
   try {
     const userId = req.user.id;
 
-    const result = await expenseService.getGroupExpenses(null, userId);
+    const result = await expenseService.getGroupExpenses(
+      null,
+      userId,
+      req.query.currency
+    );
+
+    res.status(200).json({
+      success: true,
+      data: result,
+    });
+  } catch (error) {
+    next(error);
+  }
+};
+
+export const getGroupBalances = async (req, res, next) => {
+  try {
+    const userId = req.user.id;
+
+    const result = await expenseService.getGroupBalances(
+      req.params.id,
+      userId,
+      req.query.currency
+    );
 
     res.status(200).json({
       success: true,
@@ -94,6 +121,7 @@ export default {
   createExpense,
   getGroupExpenses,
   getUserExpenses,
+  getGroupBalances,
   deleteExpense,
   assignExpense,
 };
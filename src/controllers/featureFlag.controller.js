@@ -0,0 +1,55 @@
+import featureFlagService from "../services/featureFlag.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Lists every feature flag with its source
+ * GET /api/admin/feature-flags
+ */
+export const listFlags = async (req, res, next) => {
+  try {
+    const flags = await featureFlagService.listFlags();
+    res.status(200).json({ success: true, data: flags });
+  } catch (err) {
+    logger.error(`Admin list feature flags failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Sets a flag at runtime
+ * PUT /api/admin/feature-flags/:key
+ */
+export const setFlag = async (req, res, next) => {
+  try {
+    const { enabled, rolloutPercent, description } = req.body || {};
+    const flag = await featureFlagService.setFlag(
+      req.params.key,
+      { enabled, rolloutPercent, description },
+      req.user.id
+    );
+    res.status(200).json({ success: true, data: flag, message: "Flag actualizado" });
+  } catch (err) {
+    logger.error(`Admin set feature flag ${req.params.key} failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Drops the runtime setting of a flag
+ * DELETE /api/admin/feature-flags/:key
+ */
+export const resetFlag = async (req, res, next) => {
+  try {
+    await featureFlagService.resetFlag(req.params.key);
+    res.status(200).json({ success: true, message: "Flag restablecido a su valor configurado" });
+  } catch (err) {
+    logger.error(`Admin reset feature flag ${req.params.key} failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  listFlags,
+  setFlag,
+  resetFlag,
+};
@@ -0,0 +1,23 @@
+import feedService from "../services/feed.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Gets the activity feed of the users the current user follows
+ * GET /api/feed
+ */
+export const getFeed = async (req, res, next) => {
+  try {
+    const result = await feedService.getFeed(req.user.id, {
+      limit: req.query.limit,
+      offset: req.query.offset,
+    });
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Get feed failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  getFeed,
+};
@@ -0,0 +1,198 @@
+import formService from "../services/form.service.js";
+import logger from "../config/logger.js";
+import { DEFAULT_FORM_LANGUAGE, FORM_LANGUAGES } from "../utils/dynamicForms.js";
+
+/**
+ * Language of the labels: ?lang=, else the best match of Accept-Language, else Spanish
+ */
+const formLanguage = (req) => {
+  if (FORM_LANGUAGES.includes(req.query.lang)) {
+    return req.query.lang;
+  }
+  return req.acceptsLanguages(...FORM_LANGUAGES) || DEFAULT_FORM_LANGUAGE;
+};
+
+/**
+ * Latest published version of a form, localized
+ * GET /api/forms/:formKey?lang=
+ */
+export const getForm = async (req, res, next) => {
+  try {
+    const form = await formService.getForm(req.params.formKey, formLanguage(req));
+    res.set("Vary", "Accept-Language");
+    res.status(200).json({ success: true, data: form });
+  } catch (err) {
+    logger.error(`Get form ${req.params.formKey} failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Answers of the caller to a user form
+ * GET /api/forms/:formKey/answers
+ */
+export const getMyAnswers = async (req, res, next) => {
+  try {
+    const result = await formService.getUserAnswers(req.params.formKey, req.user.id, formLanguage(req));
+    res.status(200).json({ success: true, data: result });
+  } catch (err) {
+    logger.error(`Get ${req.params.formKey} answers failed for user ${req.user?.id}: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Saves answers of the caller to a user form
+ * PUT /api/forms/:formKey/answers
+ */
+export const saveMyAnswers = async (req, res, next) => {
+  try {
+    const result = await formService.saveUserAnswers(
+      req.params.formKey,
+      req.user.id,
+      req.body?.answers,
+      formLanguage(req)
+    );
+    res.status(200).json({ success: true, data: result, message: "Respuestas guardadas" });
+  } catch (err) {
+    logger.error(`Save ${req.params.formKey} answers failed for user ${req.user?.id}: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Answers of a trip to a group form
+ * GET /api/groups/:groupId/forms/:formKey/answers
+ */
+export const getGroupAnswers = async (req, res, next) => {
+  try {
+    const result = await formService.getGroupAnswers(
+      req.params.formKey,
+      req.params.groupId,
+      req.user.id,
+      formLanguage(req)
+    );
+    res.status(200).json({ success: true, data: result });
+  } catch (err) {
+    logger.error(`Get ${req.params.formKey} answers failed for group ${req.params.groupId}: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Saves answers of a trip to a group form (organizer only)
+ * PUT /api/groups/:groupId/forms/:formKey/answers
+ */
+export const saveGroupAnswers = async (req, res, next) => {
+  try {
+    const result = await formService.saveGroupAnswers(
+      req.params.formKey,
+      req.params.groupId,
+      req.user.id,
+      req.body?.answers,
+      formLanguage(req)
+    );
+    res.status(200).json({ success: true, data: result, message: "Respuestas guardadas" });
+  } catch (err) {
+    logger.error(`Save ${req.params.formKey} answers failed for group ${req.params.groupId}: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Every version of a form
+ * GET /api/admin/forms/:formKey/versions
+ */
+export const listVersions = async (req, res, next) => {
+  try {
+    const versions = await formService.listVersions(req.params.formKey);
+    res.status(200).json({ success: true, data: versions });
+  } catch (err) {
+    logger.error(`Admin list ${req.params.formKey} versions failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * A version of a form with its fields in every language
+ * GET /api/admin/forms/:formKey/versions/:version
+ */
+export const getVersion = async (req, res, next) => {
+  try {
+    const schema = await formService.getVersion(req.params.formKey, req.params.version);
+    res.status(200).json({ success: true, data: schema });
+  } catch (err) {
+    logger.error(`Admin get ${req.params.formKey} v${req.params.version} failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Drafts the next version of a form
+ * POST /api/admin/forms/:formKey/versions
+ */
+export const createDraft = async (req, res, next) => {
+  try {
+    const schema = await formService.createDraft(req.user.id, req.params.formKey, req.body?.fields);
+    res.status(201).json({ success: true, data: schema, message: "Borrador creado" });
+  } catch (err) {
+    logger.error(`Admin create ${req.params.formKey} draft failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Replaces the fields of a draft
+ * PUT /api/admin/forms/:formKey/versions/:version
+ */
+export const updateDraft = async (req, res, next) => {
+  try {
+    const schema = await formService.updateDraft(req.params.formKey, req.params.version, req.body?.fields);
+    res.status(200).json({ success: true, data: schema, message: "Borrador actualizado" });
+  } catch (err) {
+    logger.error(`Admin update ${req.params.formKey} v${req.params.version} failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Publishes a draft
+ * POST /api/admin/forms/:formKey/versions/:version/publish
+ */
+export const publishVersion = async (req, res, next) => {
+  try {
+    const schema = await formService.publish(req.params.formKey, req.params.version);
+    res.status(200).json({ success: true, data: schema, message: "Versión publicada" });
+  } catch (err) {
+    logger.error(`Admin publish ${req.params.formKey} v${req.params.version} failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Deletes a draft
+ * DELETE /api/admin/forms/:formKey/versions/:version
+ */
+export const deleteDraft = async (req, res, next) => {
+  try {
+    await formService.deleteDraft(req.params.formKey, req.params.version);
+    res.status(200).json({ success: true, message: "Borrador eliminado" });
+  } catch (err) {
+    logger.error(`Admin delete ${req.params.formKey} v${req.params.version} failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  getForm,
+  getMyAnswers,
+  saveMyAnswers,
+  getGroupAnswers,
+  saveGroupAnswers,
+  listVersions,
+  getVersion,
+  createDraft,
+  updateDraft,
+  publishVersion,
+  deleteDraft,
+};
@@ -4,6 +4,7 @@ import UserRepository from "../repository/user.repository.js";
 import logger from "../config/logger.js";
 import { v4 as uuidv4 } from "uuid";
 import bcrypt from "bcrypt";
+import { AppError, AuthorizationError, NotFoundError, ValidationError } from "../utils/customErrors.js";
 
 /**
  * GET /api/users/{userId}/stats
@@ -28,13 +29,6 @@ export const getUserStats = async (req, res, next) => {
   } catch (err) {
     logger.error(`Get user stats endpoint failed for user: ${userId}, error: ${err.message}`);
 
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
-
     next(err);
   }
 };
@@ -58,29 +52,20 @@ export const awardPoints = async (req, res, next) => {
     const isAdmin = req.user?.role === 'admin';
 
     if (!isOwner && !isAdmin) {
-      return res.status(403).json({
-        success: false,
-        message: "No tienes permiso para actualizar los puntos de este usuario.",
-      });
+      return next(new AuthorizationError("No tienes permiso para actualizar los puntos de este usuario."));
     }
 
     // Validate required fields
     if (!action) {
-      return res.status(400).json({
-        success: false,
-        message: "El campo 'action' es requerido.",
-        details: ["action: es requerido"],
-      });
+      return next(new ValidationError("El campo 'action' es requerido.", ["action: es requerido"]));
     }
 
     // Validate action type
     const validActions = ['review_created', 'vote_received', 'profile_completed', 'comment_posted', 'media_upload', 'place_added', 'expense_created'];
     if (!validActions.includes(action)) {
-      return res.status(400).json({
-        success: false,
-        message: "Tipo de acción inválido.",
-        details: [`action: debe ser uno de ${validActions.join(', ')}`],
-      });
+      return next(
+        new ValidationError("Tipo de acción inválido.", [`action: debe ser uno de ${validActions.join(', ')}`])
+      );
     }
 
     const result = await gamificationService.awardPoints(userId, action, metadata || {});
@@ -94,17 +79,13 @@ export const awardPoints = async (req, res, next) => {
     logger.error(`Award points endpoint failed for user: ${userId}, action: ${action}, error: ${err.message}`);
 
     if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
+      return next(err);
     }
 
-    // Return generic error for database issues
-    res.status(500).json({
-      success: false,
-      message: "Error temporal de actualización. Inténtalo de nuevo más tarde.",
-    });
+    // Generic error for database issues
+    next(
+      new AppError("Error temporal de actualización. Inténtalo de nuevo más tarde.", 500, "TEMPORARY_UPDATE_ERROR")
+    );
   }
 };
 
@@ -132,13 +113,6 @@ export const getAllLevels = async (req, res, next) => {
   } catch (err) {
     logger.error(`Get all levels endpoint failed: ${err.message}`);
 
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
-
     next(err);
   }
 };
@@ -161,20 +135,17 @@ export const createBulkLikes = async (req, res, next) => {
 
     // Validate required fields
     if (!count || count < 1 || count > 1000) {
-      return res.status(400).json({
-        success: false,
-        message: "El campo 'count' debe ser un número entre 1 y 1000.",
-        details: ["count: debe ser un entero entre 1 y 1000"],
-      });
+      return next(
+        new ValidationError("El campo 'count' debe ser un número entre 1 y 1000.", [
+          "count: debe ser un entero entre 1 y 1000",
+        ])
+      );
     }
 
     // Check if review exists
     const review = await reviewService.getLikeStatus(reviewId, null);
     if (!review.success) {
-      return res.status(404).json({
-        success: false,
-        message: "Reseña no encontrada.",
-      });
+      return next(new NotFoundError("Reseña no encontrada."));
     }
 
     const userRepo = new UserRepository();
@@ -235,13 +206,6 @@ export const createBulkLikes = async (req, res, next) => {
   } catch (err) {
     logger.error(`Bulk likes endpoint failed for review ${reviewId}, error: ${err.message}`);
 
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
-
     next(err);
   }
 };
@@ -269,13 +233,6 @@ export const getAllBadges = async (req, res, next) => {
   } catch (err) {
     logger.error(`Get all badges endpoint failed: ${err.message}`);
 
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
-
     next(err);
   }
 };
@@ -303,13 +260,6 @@ export const getUserMilestones = async (req, res, next) => {
   } catch (err) {
     logger.error(`Get user milestones endpoint failed for user: ${userId}, error: ${err.message}`);
 
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
-
     next(err);
   }
 };
\ No newline at end of file
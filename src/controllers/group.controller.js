@@ -7,19 +7,40 @@ import logger from "../config/logger.js";
  */
 export const createGroup = async (req, res, next) => {
   try {
-    const { name, description } = req.body;
+    const {
+      name,
+      description,
+      capacity,
+      expectedSize,
+      destinationName,
+      destinationLat,
+      destinationLng,
+      originName,
+      originLat,
+      originLng,
+      isPublic,
+      formAnswers,
+    } = req.body;
     const adminId = req.user.id;
 
-    const result = await groupService.createGroup({ name, description, adminId });
+    const result = await groupService.createGroup({
+      name,
+      description,
+      capacity,
+      expectedSize,
+      destinationName,
+      destinationLat,
+      destinationLng,
+      originName,
+      originLat,
+      originLng,
+      isPublic: isPublic ?? false,
+      adminId,
+      formAnswers,
+    });
     res.status(201).json(result);
   } catch (err) {
     logger.error(`Create group failed: ${err.message}`);
-    if (err.status === 409) {
-      return res.status(409).json({ success: false, message: err.message });
-    }
-    if (err.status === 400) {
-      return res.status(400).json({ success: false, message: err.message });
-    }
     next(err);
   }
 };
@@ -38,6 +59,136 @@ export const getUserGroups = async (req, res, next) => {
   }
 };
 
+/**
+ * Finds public groups near a location
+ * GET /api/trips/nearby?lat=&lng=&radius_km=
+ */
+export const getNearbyGroups = async (req, res, next) => {
+  try {
+    const lat = parseFloat(req.query.lat);
+    const lng = parseFloat(req.query.lng);
+    const radiusKm = req.query.radius_km ? parseFloat(req.query.radius_km) : 50;
+    const limit = parseInt(req.query.limit) || 20;
+
+    const result = await groupService.findNearbyGroups({
+      lat,
+      lng,
+      radiusKm,
+      limit,
+      size: req.query.size || null,
+      viewerId: req.user.id,
+    });
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Get nearby groups failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Finds public groups departing near a location
+ * GET /api/groups/departing?lat=&lng=&radius_km=
+ */
+export const getGroupsDepartingNear = async (req, res, next) => {
+  try {
+    const result = await groupService.findGroupsDepartingNear({
+      lat: parseFloat(req.query.lat),
+      lng: parseFloat(req.query.lng),
+      radiusKm: req.query.radius_km ? parseFloat(req.query.radius_km) : undefined,
+      limit: parseInt(req.query.limit) || 20,
+      offset: parseInt(req.query.offset) || 0,
+      size: req.query.size || null,
+      viewerId: req.user.id,
+    });
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Get groups departing near failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Finds public groups departing from the current user's home city
+ * GET /api/groups/from-my-city
+ */
+export const getGroupsFromMyCity = async (req, res, next) => {
+  try {
+    const result = await groupService.findGroupsFromHomeCity(req.user.id, {
+      limit: parseInt(req.query.limit) || 20,
+      offset: parseInt(req.query.offset) || 0,
+      size: req.query.size || null,
+    });
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Get groups from my city failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Browses upcoming public groups by month of travel and trip length
+ * GET /api/groups/browse?month=&duration=
+ */
+export const browseGroups = async (req, res, next) => {
+  try {
+    const result = await groupService.browseGroups({
+      month: req.query.month !== undefined ? Number(req.query.month) : null,
+      duration: req.query.duration || null,
+      size: req.query.size || null,
+      limit: parseInt(req.query.limit) || 20,
+      offset: parseInt(req.query.offset) || 0,
+      viewerId: req.user.id,
+    });
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Browse groups failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Updates a group
+ * PATCH /api/groups/:id
+ */
+export const updateGroup = async (req, res, next) => {
+  try {
+    const { id } = req.params;
+    const {
+      description,
+      expectedSize,
+      destinationName,
+      destinationLat,
+      destinationLng,
+      originName,
+      originLat,
+      originLng,
+      isPublic,
+      cancellationPolicy,
+    } = req.body;
+
+    const result = await groupService.updateGroup(
+      id,
+      {
+        description,
+        expectedSize,
+        destinationName,
+        destinationLat,
+        destinationLng,
+        originName,
+        originLat,
+        originLng,
+        isPublic,
+        cancellationPolicy,
+      },
+      req.user.id
+    );
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Update group failed: ${err.message}`);
+    next(err);
+  }
+};
+
 /**
  * Gets a specific group by ID
  * GET /api/groups/:id
@@ -49,9 +200,6 @@ export const getGroupById = async (req, res, next) => {
     res.status(200).json(result);
   } catch (err) {
     logger.error(`Get group by ID failed: ${err.message}`);
-    if (err.status === 404) {
-      return res.status(404).json({ success: false, message: err.message });
-    }
     next(err);
   }
 };
@@ -70,12 +218,6 @@ export const addMembers = async (req, res, next) => {
     res.status(200).json(result);
   } catch (err) {
     logger.error(`Add members failed: ${err.message}`);
-    if (err.status === 403) {
-      return res.status(403).json({ success: false, message: err.message });
-    }
-    if (err.status === 404) {
-      return res.status(404).json({ success: false, message: err.message });
-    }
     next(err);
   }
 };
@@ -93,12 +235,6 @@ export const removeMember = async (req, res, next) => {
     res.status(200).json(result);
   } catch (err) {
     logger.error(`Remove member failed: ${err.message}`);
-    if (err.status === 403 || err.status === 400) {
-      return res.status(err.status).json({ success: false, message: err.message });
-    }
-    if (err.status === 404) {
-      return res.status(404).json({ success: false, message: err.message });
-    }
     next(err);
   }
 };
@@ -113,15 +249,6 @@ export const removeGroup = async (req, res, next) => {
     res.status(200).json(result);
   } catch (err) {
     logger.error(`Remove group failed: ${err.message}`);
-    if (err.status === 403) {
-      return res.status(403).json({ success: false, message: err.message });
-    }
-    if (err.status === 404) {
-      return res.status(404).json({ success: false, message: err.message });
-    }
-    if (err.status === 400) {
-      return res.status(400).json({ success: false, message: err.message });
-    }
     next(err);
   }
 };
@@ -140,9 +267,6 @@ export const assignItinerary = async (req, res, next) => {
     res.status(200).json(result);
   } catch (err) {
     logger.error(`Assign itinerary failed: ${err.message}`);
-    if (err.status === 403 || err.status === 400 || err.status === 404) {
-      return res.status(err.status).json({ success: false, message: err.message });
-    }
     next(err);
   }
 };
@@ -160,9 +284,6 @@ export const removeItinerary = async (req, res, next) => {
     res.status(200).json(result);
   } catch (err) {
     logger.error(`Remove itinerary failed: ${err.message}`);
-    if (err.status === 403 || err.status === 400 || err.status === 404) {
-      return res.status(err.status).json({ success: false, message: err.message });
-    }
     next(err);
   }
 };
@@ -170,7 +291,12 @@ export const removeItinerary = async (req, res, next) => {
 export default {
   createGroup,
   getUserGroups,
+  getNearbyGroups,
+  browseGroups,
+  getGroupsDepartingNear,
+  getGroupsFromMyCity,
   getGroupById,
+  updateGroup,
   addMembers,
   removeGroup,
   removeMember,
@@ -0,0 +1,94 @@
+import groupCapacityService from "../services/groupCapacity.service.js";
+import logger from "../config/logger.js";
+import { ValidationError } from "../utils/customErrors.js";
+
+const parseCapacityBody = (body = {}) => ({
+  capacity: body.capacity === undefined ? undefined : body.capacity,
+  strategy: body.strategy || "join_order",
+  memberIds: body.memberIds || [],
+});
+
+/**
+ * Previews a capacity change (admin only)
+ * POST /api/groups/:groupId/capacity/preview
+ */
+export const previewCapacityChange = async (req, res, next) => {
+  try {
+    const { groupId } = req.params;
+    const data = parseCapacityBody(req.body);
+    if (data.capacity === undefined) {
+      return next(new ValidationError("El cupo es requerido"));
+    }
+
+    const result = await groupCapacityService.previewCapacityChange(groupId, req.user.id, data);
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Preview capacity change failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Applies a capacity change (admin only)
+ * PUT /api/groups/:groupId/capacity
+ */
+export const changeCapacity = async (req, res, next) => {
+  try {
+    const { groupId } = req.params;
+    const data = parseCapacityBody(req.body);
+    if (data.capacity === undefined) {
+      return next(new ValidationError("El cupo es requerido"));
+    }
+
+    const result = await groupCapacityService.changeCapacity(groupId, req.user.id, {
+      ...data,
+      reason: req.body.reason ?? null,
+      waitlistRemoved: req.body.waitlistRemoved !== false,
+    });
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Change capacity failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists the capacity changes of a group (admin only)
+ * GET /api/groups/:groupId/capacity/changes
+ */
+export const getCapacityChanges = async (req, res, next) => {
+  try {
+    const result = await groupCapacityService.getCapacityChanges(req.params.groupId, req.user.id);
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Get capacity changes failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Sets the tier of a member (admin only)
+ * PUT /api/groups/:groupId/members/:userId/tier
+ */
+export const setMemberTier = async (req, res, next) => {
+  try {
+    const { groupId, userId } = req.params;
+    const result = await groupCapacityService.setMemberTier(
+      groupId,
+      userId,
+      req.body?.tier,
+      req.user.id
+    );
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Set member tier failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  previewCapacityChange,
+  changeCapacity,
+  getCapacityChanges,
+  setMemberTier,
+};
@@ -0,0 +1,147 @@
+import groupDocumentService from "../services/groupDocument.service.js";
+import logger from "../config/logger.js";
+import { ValidationError } from "../utils/customErrors.js";
+
+/**
+ * Shares a document with the group
+ * POST /api/groups/:groupId/documents
+ */
+export const createDocument = async (req, res, next) => {
+  try {
+    const { groupId } = req.params;
+    const { title, category } = req.body || {};
+    const document = await groupDocumentService.createDocument(groupId, req.user.id, {
+      title,
+      category: category || "other",
+      file: req.file,
+    });
+
+    res.status(201).json({
+      success: true,
+      data: document,
+      message: "Documento compartido",
+    });
+  } catch (err) {
+    logger.error(`Create group document failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Uploads a new version of a document
+ * POST /api/groups/:groupId/documents/:documentId/versions
+ */
+export const addVersion = async (req, res, next) => {
+  try {
+    const { groupId, documentId } = req.params;
+    const document = await groupDocumentService.addVersion(
+      groupId,
+      documentId,
+      req.user.id,
+      req.file
+    );
+
+    res.status(201).json({
+      success: true,
+      data: document,
+      message: "Nueva versión subida",
+    });
+  } catch (err) {
+    logger.error(`Add document version failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists the documents of a group
+ * GET /api/groups/:groupId/documents
+ */
+export const getDocuments = async (req, res, next) => {
+  try {
+    const result = await groupDocumentService.getDocuments(req.params.groupId, req.user.id);
+
+    res.status(200).json({
+      success: true,
+      data: result,
+    });
+  } catch (err) {
+    logger.error(`Get group documents failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Gets a document with its version history
+ * GET /api/groups/:groupId/documents/:documentId
+ */
+export const getDocument = async (req, res, next) => {
+  try {
+    const { groupId, documentId } = req.params;
+    const document = await groupDocumentService.getDocument(groupId, documentId, req.user.id);
+
+    res.status(200).json({
+      success: true,
+      data: document,
+    });
+  } catch (err) {
+    logger.error(`Get group document failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Gets a temporary download URL of a document version
+ * GET /api/groups/:groupId/documents/:documentId/download?version=
+ */
+export const getDownloadUrl = async (req, res, next) => {
+  try {
+    const { groupId, documentId } = req.params;
+    const version = req.query.version ? parseInt(req.query.version, 10) : null;
+    if (req.query.version && (!Number.isInteger(version) || version < 1)) {
+      return next(new ValidationError("La versión debe ser un número entero positivo"));
+    }
+
+    const url = await groupDocumentService.getDownloadUrl(
+      groupId,
+      documentId,
+      req.user.id,
+      version
+    );
+
+    res.status(200).json({
+      success: true,
+      data: { url, expiresIn: 300 },
+    });
+  } catch (err) {
+    logger.error(`Get document download URL failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Deletes a document
+ * DELETE /api/groups/:groupId/documents/:documentId
+ */
+export const deleteDocument = async (req, res, next) => {
+  try {
+    const { groupId, documentId } = req.params;
+    await groupDocumentService.deleteDocument(groupId, documentId, req.user.id);
+
+    res.status(200).json({
+      success: true,
+      message: "Documento eliminado",
+    });
+  } catch (err) {
+    logger.error(`Delete group document failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  createDocument,
+  addVersion,
+  getDocuments,
+  getDocument,
+  getDownloadUrl,
+  deleteDocument,
+};
@@ -0,0 +1,82 @@
+import groupInviteService from "../services/groupInvite.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Creates an invite link (organizer only)
+ * POST /api/groups/:groupId/invites
+ */
+export const createInvite = async (req, res, next) => {
+  try {
+    const { maxUses = null, expiresAt = null } = req.body || {};
+    const invite = await groupInviteService.createInvite(req.params.groupId, req.user.id, { maxUses, expiresAt });
+    res.status(201).json({ success: true, data: invite, message: "Link de invitación creado" });
+  } catch (err) {
+    logger.error(`Create invite failed for group ${req.params.groupId}: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists the invite links of a group (organizer only)
+ * GET /api/groups/:groupId/invites
+ */
+export const listInvites = async (req, res, next) => {
+  try {
+    const invites = await groupInviteService.listInvites(req.params.groupId, req.user.id);
+    res.status(200).json({ success: true, data: invites });
+  } catch (err) {
+    logger.error(`List invites failed for group ${req.params.groupId}: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Revokes an invite link (organizer only)
+ * DELETE /api/groups/:groupId/invites/:inviteId
+ */
+export const revokeInvite = async (req, res, next) => {
+  try {
+    const { groupId, inviteId } = req.params;
+    await groupInviteService.revokeInvite(groupId, inviteId, req.user.id);
+    res.status(200).json({ success: true, message: "Link de invitación revocado" });
+  } catch (err) {
+    logger.error(`Revoke invite ${req.params.inviteId} failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Preview of the trip an invite link is for
+ * GET /api/invite/:token
+ */
+export const getPreview = async (req, res, next) => {
+  try {
+    const preview = await groupInviteService.getPreview(req.params.token, req.user?.id || null);
+    res.status(200).json({ success: true, data: preview });
+  } catch (err) {
+    logger.error(`Get invite preview failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Joins the trip of an invite link
+ * POST /api/invite/:token/accept
+ */
+export const acceptInvite = async (req, res, next) => {
+  try {
+    const { request, message } = await groupInviteService.acceptInvite(req.params.token, req.user.id);
+    res.status(200).json({ success: true, data: request, message });
+  } catch (err) {
+    logger.error(`Accept invite failed for user ${req.user?.id}: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  createInvite,
+  listInvites,
+  revokeInvite,
+  getPreview,
+  acceptInvite,
+};
@@ -0,0 +1,115 @@
+import groupJoinRequestService from "../services/groupJoinRequest.service.js";
+import joinEligibilityService from "../services/joinEligibility.service.js";
+import logger from "../config/logger.js";
+import { ValidationError } from "../utils/customErrors.js";
+
+/**
+ * Requests to join a group
+ * POST /api/groups/:groupId/join-requests
+ */
+export const requestToJoin = async (req, res, next) => {
+  try {
+    const { groupId } = req.params;
+    const { message } = req.body || {};
+
+    if (message !== undefined && message !== null && typeof message !== "string") {
+      return next(new ValidationError("El mensaje debe ser un texto"));
+    }
+
+    const result = await groupJoinRequestService.requestToJoin(
+      groupId,
+      req.user.id,
+      message || null
+    );
+    res.status(201).json(result);
+  } catch (err) {
+    logger.error(`Request to join group failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Dry run of the eligibility rules for the caller, without requesting
+ * GET /api/groups/:groupId/join-eligibility
+ */
+export const checkEligibility = async (req, res, next) => {
+  try {
+    const result = await joinEligibilityService.explain(req.params.groupId, req.user.id);
+    res.status(200).json({ success: true, data: result });
+  } catch (err) {
+    logger.error(`Check join eligibility failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists the join requests of a group (admin only)
+ * GET /api/groups/:groupId/join-requests
+ */
+export const getGroupRequests = async (req, res, next) => {
+  try {
+    const { groupId } = req.params;
+    const result = await groupJoinRequestService.getGroupRequests(
+      groupId,
+      req.user.id,
+      req.query.status || null
+    );
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Get join requests failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Approves or rejects a join request (admin only)
+ * PATCH /api/groups/:groupId/join-requests/:requestId
+ */
+export const decideRequest = async (req, res, next) => {
+  try {
+    const { groupId, requestId } = req.params;
+    const { action } = req.body || {};
+
+    if (action !== "approve" && action !== "reject") {
+      return next(new ValidationError("La acción debe ser 'approve' o 'reject'"));
+    }
+
+    const result = await groupJoinRequestService.decideRequest(
+      groupId,
+      requestId,
+      req.user.id,
+      action === "approve"
+    );
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Decide join request failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Cancels the current user's pending join request
+ * DELETE /api/groups/:groupId/join-requests/:requestId
+ */
+export const cancelRequest = async (req, res, next) => {
+  try {
+    const { groupId, requestId } = req.params;
+    const result = await groupJoinRequestService.cancelRequest(
+      groupId,
+      requestId,
+      req.user.id
+    );
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Cancel join request failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  requestToJoin,
+  checkEligibility,
+  getGroupRequests,
+  decideRequest,
+  cancelRequest,
+};
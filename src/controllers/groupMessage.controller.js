@@ -1,69 +1,179 @@
 import groupMessageService from "../services/groupMessage.service.js";
+import chatRetentionService from "../services/chatRetention.service.js";
 import logger from "../config/logger.js";
+import { ValidationError } from "../utils/customErrors.js";
 
 /**
  * Envía un mensaje a un grupo
- * POST /api/groups/:groupId/messages
+ * POST /api/trips/:id/messages
  */
 export const sendMessage = async (req, res, next) => {
   try {
-    const { groupId } = req.params;
-    const { content } = req.body;
+    const { id: groupId } = req.params;
+    const { content, clientMessageId } = req.body;
     const senderId = req.user.id;
 
     if (!content || !content.trim()) {
-      return res.status(400).json({
-        success: false,
-        message: "El contenido del mensaje es requerido",
-      });
+      return next(new ValidationError("El contenido del mensaje es requerido"));
+    }
+
+    if (
+      clientMessageId !== undefined &&
+      (typeof clientMessageId !== "string" || clientMessageId.length > 64)
+    ) {
+      return next(new ValidationError("clientMessageId debe ser un texto de hasta 64 caracteres"));
     }
 
     const result = await groupMessageService.sendMessage({
       groupId,
       senderId,
       content: content.trim(),
+      clientMessageId: clientMessageId || null,
     });
 
-    res.status(201).json(result);
+    // Un reintento de un mensaje ya enviado devuelve 200 en lugar de 201
+    res.status(result.duplicate ? 200 : 201).json(result);
   } catch (err) {
     logger.error(`Send group message failed: ${err.message}`);
-    if (err.status === 404) {
-      return res.status(404).json({ success: false, message: err.message });
-    }
-    if (err.status === 403) {
-      return res.status(403).json({ success: false, message: err.message });
-    }
     next(err);
   }
 };
 
 /**
  * Obtiene el historial de mensajes de un grupo
- * GET /api/groups/:groupId/messages
+ * GET /api/trips/:id/messages
  */
 export const getMessages = async (req, res, next) => {
   try {
-    const { groupId } = req.params;
+    const { id: groupId } = req.params;
     const userId = req.user.id;
     const limit = parseInt(req.query.limit) || 50;
     const offset = parseInt(req.query.offset) || 0;
+    const before = req.query.before !== undefined ? req.query.before : null;
 
     const result = await groupMessageService.getGroupMessages(
       groupId,
       userId,
       limit,
-      offset
+      offset,
+      before
     );
 
     res.status(200).json(result);
   } catch (err) {
     logger.error(`Get group messages failed: ${err.message}`);
-    if (err.status === 404) {
-      return res.status(404).json({ success: false, message: err.message });
-    }
-    if (err.status === 403) {
-      return res.status(403).json({ success: false, message: err.message });
-    }
+    next(err);
+  }
+};
+
+/**
+ * Marca los mensajes de un grupo como leídos
+ * POST /api/trips/:id/messages/read
+ */
+export const markAsRead = async (req, res, next) => {
+  try {
+    const { id: groupId } = req.params;
+    const { messageId } = req.body || {};
+    const userId = req.user.id;
+
+    const result = await groupMessageService.markAsRead(
+      groupId,
+      userId,
+      messageId || null
+    );
+
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Mark group messages as read failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Borra un mensaje de un grupo
+ * DELETE /api/trips/:id/messages/:messageId
+ */
+export const deleteMessage = async (req, res, next) => {
+  try {
+    const { id: groupId, messageId } = req.params;
+    const userId = req.user.id;
+
+    const result = await groupMessageService.deleteMessage(groupId, messageId, userId);
+
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Delete group message failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Obtiene las confirmaciones de lectura de un grupo
+ * GET /api/trips/:id/messages/read-receipts
+ */
+export const getReadReceipts = async (req, res, next) => {
+  try {
+    const { id: groupId } = req.params;
+    const userId = req.user.id;
+
+    const result = await groupMessageService.getReadReceipts(groupId, userId);
+
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Get group read receipts failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Obtiene la política de conservación del chat de un grupo
+ * GET /api/trips/:id/messages/retention
+ */
+export const getRetention = async (req, res, next) => {
+  try {
+    const data = await chatRetentionService.getRetention(req.params.id, req.user.id);
+    res.status(200).json({ success: true, data });
+  } catch (err) {
+    logger.error(`Get chat retention failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Cambia la política de conservación del chat (solo el organizador)
+ * PUT /api/trips/:id/messages/retention
+ */
+export const setRetention = async (req, res, next) => {
+  try {
+    const data = await chatRetentionService.setRetention(
+      req.params.id,
+      req.user.id,
+      req.body?.policy
+    );
+    res.status(200).json({
+      success: true,
+      data,
+      message: "Conservación del chat actualizada",
+    });
+  } catch (err) {
+    logger.error(`Set chat retention failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Descarga el chat completo de un grupo en JSON
+ * GET /api/trips/:id/messages/export
+ */
+export const exportChat = async (req, res, next) => {
+  try {
+    const { id: groupId } = req.params;
+    const data = await chatRetentionService.exportChat(groupId, req.user.id);
+    res.set("Content-Disposition", `attachment; filename="chat-${groupId}.json"`);
+    res.set("Cache-Control", "no-store");
+    res.status(200).json(data);
+  } catch (err) {
+    logger.error(`Export group chat failed: ${err.message}`);
     next(err);
   }
 };
@@ -71,4 +181,10 @@ export const getMessages = async (req, res, next) => {
 export default {
   sendMessage,
   getMessages,
+  markAsRead,
+  deleteMessage,
+  getReadReceipts,
+  getRetention,
+  setRetention,
+  exportChat,
 };
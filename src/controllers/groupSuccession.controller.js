@@ -0,0 +1,93 @@
+import groupSuccessionService from "../services/groupSuccession.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Succession in progress of a trip
+ * GET /api/groups/:groupId/succession
+ */
+export const getSuccession = async (req, res, next) => {
+  try {
+    const result = await groupSuccessionService.getSuccession(req.params.groupId, req.user.id);
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Get succession failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Accepts or declines organizing the trip
+ * POST /api/groups/:groupId/succession/offer
+ */
+export const respondToOffer = async (req, res, next) => {
+  try {
+    const result = await groupSuccessionService.respondToOffer(
+      req.params.groupId,
+      req.user.id,
+      req.body?.accept
+    );
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Respond to succession offer failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Votes for the new organizer
+ * POST /api/groups/:groupId/succession/votes
+ */
+export const vote = async (req, res, next) => {
+  try {
+    const result = await groupSuccessionService.vote(
+      req.params.groupId,
+      req.user.id,
+      req.body?.candidateId
+    );
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Succession vote failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Cancels the succession (previous organizer only)
+ * DELETE /api/groups/:groupId/succession
+ */
+export const cancelSuccession = async (req, res, next) => {
+  try {
+    const result = await groupSuccessionService.cancelSuccession(req.params.groupId, req.user.id);
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Cancel succession failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Marks or unmarks a member as co-organizer (admin only)
+ * PUT /api/groups/:groupId/members/:userId/co-organizer
+ */
+export const setCoOrganizer = async (req, res, next) => {
+  try {
+    const result = await groupSuccessionService.setCoOrganizer(
+      req.params.groupId,
+      req.params.userId,
+      req.body?.coOrganizer,
+      req.user.id
+    );
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Set co-organizer failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  getSuccession,
+  respondToOffer,
+  vote,
+  cancelSuccession,
+  setCoOrganizer,
+};
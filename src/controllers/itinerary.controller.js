@@ -1,5 +1,6 @@
 import itineraryService from "../services/itinerary.service.js";
 import logger from "../config/logger.js";
+import { ValidationError } from "../utils/customErrors.js";
 
 /**
  * Creates a new itinerary
@@ -13,33 +14,21 @@ export const createItinerary = async (req, res, next) => {
 
     // Validate required fields
     if (!name || !items) {
-      return res.status(400).json({
-        success: false,
-        message: "Nombre del itinerario e items son requeridos.",
-      });
+      return next(new ValidationError("Nombre del itinerario e items son requeridos."));
     }
 
     if (typeof name !== "string" || name.trim() === "") {
-      return res.status(400).json({
-        success: false,
-        message: "El nombre del itinerario es requerido.",
-      });
+      return next(new ValidationError("El nombre del itinerario es requerido."));
     }
 
     if (!Array.isArray(items) || items.length === 0) {
-      return res.status(400).json({
-        success: false,
-        message: "El itinerario debe tener al menos un lugar.",
-      });
+      return next(new ValidationError("El itinerario debe tener al menos un lugar."));
     }
 
     // Validate each item
     for (const item of items) {
       if (!item.placeId || !item.date) {
-        return res.status(400).json({
-          success: false,
-          message: "Cada lugar debe tener un ID de lugar y una fecha.",
-        });
+        return next(new ValidationError("Cada lugar debe tener un ID de lugar y una fecha."));
       }
     }
 
@@ -55,14 +44,7 @@ export const createItinerary = async (req, res, next) => {
     res.status(201).json(result);
   } catch (err) {
     logger.error(`Create itinerary endpoint failed for user: ${req.user.id}, error: ${err.message}`);
-    
-    if (err.status && err.message) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
-    
+
     next(err);
   }
 };
@@ -77,10 +59,7 @@ export const getItineraryById = async (req, res, next) => {
     const { id } = req.params;
 
     if (!id) {
-      return res.status(400).json({
-        success: false,
-        message: "ID del itinerario es requerido.",
-      });
+      return next(new ValidationError("ID del itinerario es requerido."));
     }
 
     const result = await itineraryService.getItineraryById(id, req.user.id);
@@ -89,14 +68,7 @@ export const getItineraryById = async (req, res, next) => {
     res.status(200).json(result);
   } catch (err) {
     logger.error(`Get itinerary by ID endpoint failed: ${req.params.id}, error: ${err.message}`);
-    
-    if (err.status && err.message) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
-    
+
     next(err);
   }
 };
@@ -114,14 +86,7 @@ export const getUserItineraries = async (req, res, next) => {
     res.status(200).json(result);
   } catch (err) {
     logger.error(`Get user itineraries endpoint failed for user: ${req.user.id}, error: ${err.message}`);
-    
-    if (err.status && err.message) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
-    
+
     next(err);
   }
 };
@@ -138,43 +103,28 @@ export const updateItinerary = async (req, res, next) => {
     const { name, items } = req.body;
 
     if (!id) {
-      return res.status(400).json({
-        success: false,
-        message: "ID del itinerario es requerido.",
-      });
+      return next(new ValidationError("ID del itinerario es requerido."));
     }
 
     // Validate that at least one field is being updated
     if (!name && !items) {
-      return res.status(400).json({
-        success: false,
-        message: "Al menos un campo debe ser actualizado (nombre o items).",
-      });
+      return next(new ValidationError("Al menos un campo debe ser actualizado (nombre o items)."));
     }
 
     // Validate name if provided
     if (name && (typeof name !== "string" || name.trim() === "")) {
-      return res.status(400).json({
-        success: false,
-        message: "El nombre del itinerario no puede estar vacío.",
-      });
+      return next(new ValidationError("El nombre del itinerario no puede estar vacío."));
     }
 
     // Validate items if provided
     if (items) {
       if (!Array.isArray(items) || items.length === 0) {
-        return res.status(400).json({
-          success: false,
-          message: "El itinerario debe tener al menos un lugar.",
-        });
+        return next(new ValidationError("El itinerario debe tener al menos un lugar."));
       }
 
       for (const item of items) {
         if (!item.placeId || !item.date) {
-          return res.status(400).json({
-            success: false,
-            message: "Cada lugar debe tener un ID de lugar y una fecha.",
-          });
+          return next(new ValidationError("Cada lugar debe tener un ID de lugar y una fecha."));
         }
       }
     }
@@ -189,14 +139,7 @@ export const updateItinerary = async (req, res, next) => {
     res.status(200).json(result);
   } catch (err) {
     logger.error(`Update itinerary endpoint failed: ${req.params.id}, error: ${err.message}`);
-    
-    if (err.status && err.message) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
-    
+
     next(err);
   }
 };
@@ -211,10 +154,7 @@ export const deleteItinerary = async (req, res, next) => {
     const { id } = req.params;
 
     if (!id) {
-      return res.status(400).json({
-        success: false,
-        message: "ID del itinerario es requerido.",
-      });
+      return next(new ValidationError("ID del itinerario es requerido."));
     }
 
     const result = await itineraryService.deleteItinerary(id, req.user.id);
@@ -223,14 +163,7 @@ export const deleteItinerary = async (req, res, next) => {
     res.status(200).json(result);
   } catch (err) {
     logger.error(`Delete itinerary endpoint failed: ${req.params.id}, error: ${err.message}`);
-    
-    if (err.status && err.message) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
-    
+
     next(err);
   }
 };
@@ -245,10 +178,7 @@ export const getItineraryGroups = async (req, res, next) => {
     const { id } = req.params;
 
     if (!id) {
-      return res.status(400).json({
-        success: false,
-        message: "ID del itinerario es requerido.",
-      });
+      return next(new ValidationError("ID del itinerario es requerido."));
     }
 
     const result = await itineraryService.getItineraryGroups(id, req.user.id);
@@ -257,14 +187,7 @@ export const getItineraryGroups = async (req, res, next) => {
     res.status(200).json(result);
   } catch (err) {
     logger.error(`Get itinerary groups endpoint failed: ${req.params.id}, error: ${err.message}`);
-    
-    if (err.status && err.message) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
-    
+
     next(err);
   }
 };
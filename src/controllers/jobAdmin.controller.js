@@ -0,0 +1,152 @@
+import jobAdminService from "../services/jobAdmin.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Depth and pause state of every job queue
+ * GET /api/admin/jobs/queues
+ */
+export const getQueues = async (req, res, next) => {
+  try {
+    const queues = await jobAdminService.getQueues();
+    res.status(200).json({ success: true, data: queues });
+  } catch (err) {
+    logger.error(`Get job queues failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists failed jobs
+ * GET /api/admin/jobs/failed
+ */
+export const listFailed = async (req, res, next) => {
+  try {
+    const result = await jobAdminService.listStuck("failed", {
+      type: req.query.type,
+      limit: req.query.limit,
+      offset: req.query.offset,
+    });
+    res.status(200).json({ success: true, data: result });
+  } catch (err) {
+    logger.error(`List failed jobs failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists jobs quarantined because their payload kept failing
+ * GET /api/admin/jobs/quarantined
+ */
+export const listQuarantined = async (req, res, next) => {
+  try {
+    const result = await jobAdminService.listStuck("quarantined", {
+      type: req.query.type,
+      limit: req.query.limit,
+      offset: req.query.offset,
+    });
+    res.status(200).json({ success: true, data: result });
+  } catch (err) {
+    logger.error(`List quarantined jobs failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Attempt error rates per job type against the error budget
+ * GET /api/admin/jobs/error-rates
+ */
+export const getErrorRates = async (req, res, next) => {
+  try {
+    const result = await jobAdminService.getErrorRates({ hours: req.query.hours });
+    res.status(200).json({ success: true, data: result });
+  } catch (err) {
+    logger.error(`Get job error rates failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Requeues a failed or quarantined job
+ * POST /api/admin/jobs/:jobId/retry
+ */
+export const retryJob = async (req, res, next) => {
+  try {
+    const job = await jobAdminService.retryJob(req.params.jobId);
+    res.status(200).json({ success: true, data: job, message: "Job reencolado" });
+  } catch (err) {
+    logger.error(`Retry job failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Discards a failed or quarantined job
+ * POST /api/admin/jobs/:jobId/discard
+ */
+export const discardJob = async (req, res, next) => {
+  try {
+    const job = await jobAdminService.discardJob(req.params.jobId);
+    res.status(200).json({ success: true, data: job, message: "Job descartado" });
+  } catch (err) {
+    logger.error(`Discard job failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Requeues every failed (or quarantined) job of a type
+ * POST /api/admin/jobs/queues/:type/retry-failed
+ */
+export const retryFailedOfType = async (req, res, next) => {
+  try {
+    const result = await jobAdminService.retryStuckOfType(req.params.type, req.body?.status);
+    res.status(200).json({
+      success: true,
+      data: result,
+      message: `${result.requeued} jobs reencolados`,
+    });
+  } catch (err) {
+    logger.error(`Retry failed jobs of type failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Pauses a job queue
+ * POST /api/admin/jobs/queues/:type/pause
+ */
+export const pauseQueue = async (req, res, next) => {
+  try {
+    const pause = await jobAdminService.pauseQueue(req.user.id, req.params.type, req.body?.reason);
+    res.status(200).json({ success: true, data: pause, message: "Cola pausada" });
+  } catch (err) {
+    logger.error(`Pause job queue failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Resumes a paused job queue
+ * POST /api/admin/jobs/queues/:type/resume
+ */
+export const resumeQueue = async (req, res, next) => {
+  try {
+    await jobAdminService.resumeQueue(req.params.type);
+    res.status(200).json({ success: true, message: "Cola reanudada" });
+  } catch (err) {
+    logger.error(`Resume job queue failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  getQueues,
+  listFailed,
+  listQuarantined,
+  getErrorRates,
+  retryJob,
+  discardJob,
+  retryFailedOfType,
+  pauseQueue,
+  resumeQueue,
+};
@@ -0,0 +1,157 @@
+import legalHoldService from "../services/legalHold.service.js";
+import logger from "../config/logger.js";
+
+const requestContext = (req) => ({
+  ip: req.ip,
+  userAgent: req.get("user-agent") || null,
+});
+
+/**
+ * Places a legal hold on an account
+ * POST /api/admin/legal/holds
+ */
+export const createHold = async (req, res, next) => {
+  try {
+    const hold = await legalHoldService.createHold(req.user, req.body, requestContext(req));
+
+    res.status(201).json({
+      success: true,
+      data: legalHoldService.formatHold(hold),
+      message: "Retención legal creada",
+    });
+  } catch (err) {
+    logger.error(`Create legal hold failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists legal holds
+ * GET /api/admin/legal/holds?userId=&status=
+ */
+export const getHolds = async (req, res, next) => {
+  try {
+    const holds = await legalHoldService.getHolds({
+      userId: req.query.userId || null,
+      status: req.query.status || null,
+    });
+
+    res.status(200).json({
+      success: true,
+      data: holds,
+    });
+  } catch (err) {
+    logger.error(`Get legal holds failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Releases a legal hold
+ * POST /api/admin/legal/holds/:id/release
+ */
+export const releaseHold = async (req, res, next) => {
+  try {
+    const hold = await legalHoldService.releaseHold(req.user, req.params.id, requestContext(req));
+
+    res.status(200).json({
+      success: true,
+      data: hold,
+      message: "Retención legal liberada",
+    });
+  } catch (err) {
+    logger.error(`Release legal hold failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Requests a full export of an account
+ * POST /api/admin/legal/exports
+ */
+export const requestExport = async (req, res, next) => {
+  try {
+    const legalExport = await legalHoldService.requestExport(req.user, req.body, requestContext(req));
+
+    res.status(202).json({
+      success: true,
+      data: legalExport,
+      message: "Exportación en proceso",
+    });
+  } catch (err) {
+    logger.error(`Request legal export failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Gets the status of an export
+ * GET /api/admin/legal/exports/:id
+ */
+export const getExport = async (req, res, next) => {
+  try {
+    const legalExport = await legalHoldService.getExport(req.user, req.params.id, requestContext(req));
+
+    res.status(200).json({
+      success: true,
+      data: legalExport,
+    });
+  } catch (err) {
+    logger.error(`Get legal export failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Gets a temporary download URL for a ready export
+ * GET /api/admin/legal/exports/:id/download
+ */
+export const getExportDownloadUrl = async (req, res, next) => {
+  try {
+    const download = await legalHoldService.getExportDownloadUrl(
+      req.user,
+      req.params.id,
+      requestContext(req)
+    );
+
+    res.status(200).json({
+      success: true,
+      data: download,
+    });
+  } catch (err) {
+    logger.error(`Get legal export download failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists the legal access log
+ * GET /api/admin/legal/access-log?userId=&adminId=&limit=
+ */
+export const getAccessLog = async (req, res, next) => {
+  try {
+    const entries = await legalHoldService.getAccessLog({
+      targetUserId: req.query.userId || null,
+      actorId: req.query.adminId || null,
+      limit: req.query.limit,
+    });
+
+    res.status(200).json({
+      success: true,
+      data: entries,
+    });
+  } catch (err) {
+    logger.error(`Get legal access log failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  createHold,
+  getHolds,
+  releaseHold,
+  requestExport,
+  getExport,
+  getExportDownloadUrl,
+  getAccessLog,
+};
@@ -1,5 +1,6 @@
 import listService from "../services/list.service.js";
 import logger from "../config/logger.js";
+import { AppError, AuthorizationError, NotFoundError, ValidationError } from "../utils/customErrors.js";
 
 /**
  * Busca listas públicamente por título o por ciudad/nombre del lugar
@@ -52,10 +53,7 @@ export const createList = async (req, res, next) => {
 
     // Validar campos requeridos
     if (!title) {
-      return res.status(400).json({
-        success: false,
-        message: "El título es requerido.",
-      });
+      return next(new ValidationError("El título es requerido."));
     }
 
     const result = await listService.createList({ title, description, userId });
@@ -75,13 +73,6 @@ export const createList = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Create list endpoint failed for user: ${req.user.id}, error: ${err.message}`);
-    if (err.details) {
-      return res.status(err.status || 400).json({
-        success: false,
-        message: err.message,
-        errors: err.details,
-      });
-    }
     next(err);
   }
 };
@@ -137,10 +128,7 @@ export const getListById = async (req, res, next) => {
     const userId = req.user.id;
 
     if (!id) {
-      return res.status(400).json({
-        success: false,
-        message: "El ID de la lista es requerido.",
-      });
+      return next(new ValidationError("El ID de la lista es requerido."));
     }
 
     const list = await listService.getListById(id, userId);
@@ -171,16 +159,10 @@ export const getListById = async (req, res, next) => {
   } catch (err) {
     logger.error(`Get list by ID endpoint failed for id: ${req.params.id}, user: ${req.user.id}, error: ${err.message}`);
     if (err.status === 404) {
-      return res.status(404).json({
-        success: false,
-        message: "Lista no encontrada.",
-      });
+      return next(new NotFoundError("Lista no encontrada."));
     }
     if (err.status === 403) {
-      return res.status(403).json({
-        success: false,
-        message: "Acceso denegado.",
-      });
+      return next(new AuthorizationError("Acceso denegado."));
     }
     next(err);
   }
@@ -199,10 +181,7 @@ export const updateList = async (req, res, next) => {
     const userId = req.user.id;
 
     if (!id) {
-      return res.status(400).json({
-        success: false,
-        message: "El ID de la lista es requerido.",
-      });
+      return next(new ValidationError("El ID de la lista es requerido."));
     }
 
     const result = await listService.updateList(id, { title, description }, userId);
@@ -222,23 +201,13 @@ export const updateList = async (req, res, next) => {
   } catch (err) {
     logger.error(`Update list endpoint failed for id: ${req.params.id}, user: ${req.user.id}, error: ${err.message}`);
     if (err.details) {
-      return res.status(err.status || 400).json({
-        success: false,
-        message: err.message,
-        errors: err.details,
-      });
+      return next(err);
     }
     if (err.status === 404) {
-      return res.status(404).json({
-        success: false,
-        message: "Lista no encontrada.",
-      });
+      return next(new NotFoundError("Lista no encontrada."));
     }
     if (err.status === 403) {
-      return res.status(403).json({
-        success: false,
-        message: "Acceso denegado.",
-      });
+      return next(new AuthorizationError("Acceso denegado."));
     }
     next(err);
   }
@@ -255,10 +224,7 @@ export const deleteList = async (req, res, next) => {
     const userId = req.user.id;
 
     if (!id) {
-      return res.status(400).json({
-        success: false,
-        message: "El ID de la lista es requerido.",
-      });
+      return next(new ValidationError("El ID de la lista es requerido."));
     }
 
     const result = await listService.deleteList(id, userId);
@@ -271,16 +237,10 @@ export const deleteList = async (req, res, next) => {
   } catch (err) {
     logger.error(`Delete list endpoint failed for id: ${req.params.id}, user: ${req.user.id}, error: ${err.message}`);
     if (err.status === 404) {
-      return res.status(404).json({
-        success: false,
-        message: "Lista no encontrada.",
-      });
+      return next(new NotFoundError("Lista no encontrada."));
     }
     if (err.status === 403) {
-      return res.status(403).json({
-        success: false,
-        message: "Acceso denegado.",
-      });
+      return next(new AuthorizationError("Acceso denegado."));
     }
     next(err);
   }
@@ -297,10 +257,7 @@ export const addPlaceToList = async (req, res, next) => {
     const userId = req.user.id;
 
     if (!listId || !placeId) {
-      return res.status(400).json({
-        success: false,
-        message: "Los IDs de lista y lugar son requeridos.",
-      });
+      return next(new ValidationError("Los IDs de lista y lugar son requeridos."));
     }
 
     const result = await listService.addPlaceToList(listId, placeId, userId);
@@ -328,35 +285,16 @@ export const addPlaceToList = async (req, res, next) => {
   } catch (err) {
     logger.error(`Add place to list endpoint failed for list: ${req.params.listId}, place: ${req.params.placeId}, user: ${req.user.id}, error: ${err.message}`);
     if (err.message === "Place already in list") {
-      return res.status(409).json({
-        success: false,
-        message: "El lugar ya está en la lista.",
-      });
+      return next(new AppError("El lugar ya está en la lista.", 409));
     }
     if (err.message === "List cannot have more than 20 places") {
-      return res.status(400).json({
-        success: false,
-        message: "Límite alcanzado.",
-      });
+      return next(new ValidationError("Límite alcanzado."));
     }
     if (err.status === 404) {
-      return res.status(404).json({
-        success: false,
-        message: err.message === "Place not found" ? "Lugar no encontrado." : "Lista no encontrada.",
-      });
+      return next(new NotFoundError(err.message === "Place not found" ? "Lugar no encontrado." : "Lista no encontrada."));
     }
     if (err.status === 403) {
-      return res.status(403).json({
-        success: false,
-        message: "Acceso denegado.",
-      });
-    }
-    if (err.details) {
-      return res.status(err.status || 400).json({
-        success: false,
-        message: err.message,
-        errors: err.details,
-      });
+      return next(new AuthorizationError("Acceso denegado."));
     }
     next(err);
   }
@@ -373,10 +311,7 @@ export const removePlaceFromList = async (req, res, next) => {
     const userId = req.user.id;
 
     if (!listId || !placeId) {
-      return res.status(400).json({
-        success: false,
-        message: "Los IDs de lista y lugar son requeridos.",
-      });
+      return next(new ValidationError("Los IDs de lista y lugar son requeridos."));
     }
 
     const result = await listService.removePlaceFromList(listId, placeId, userId);
@@ -404,23 +339,10 @@ export const removePlaceFromList = async (req, res, next) => {
   } catch (err) {
     logger.error(`Remove place from list endpoint failed for list: ${req.params.listId}, place: ${req.params.placeId}, user: ${req.user.id}, error: ${err.message}`);
     if (err.status === 404) {
-      return res.status(404).json({
-        success: false,
-        message: err.message === "Place not found" ? "Lugar no encontrado." : "Lista no encontrada.",
-      });
+      return next(new NotFoundError(err.message === "Place not found" ? "Lugar no encontrado." : "Lista no encontrada."));
     }
     if (err.status === 403) {
-      return res.status(403).json({
-        success: false,
-        message: "Acceso denegado.",
-      });
-    }
-    if (err.details) {
-      return res.status(err.status || 400).json({
-        success: false,
-        message: err.message,
-        errors: err.details,
-      });
+      return next(new AuthorizationError("Acceso denegado."));
     }
     next(err);
   }
@@ -436,7 +358,7 @@ export const getPublicListsByAuthor = async (req, res, next) => {
     const { authorId } = req.params;
 
     if (!authorId) {
-      return res.status(400).json({ success: false, message: 'Author ID is required' });
+      return next(new ValidationError('Author ID is required'));
     }
 
     const lists = await listService.getListsByAuthor(authorId);
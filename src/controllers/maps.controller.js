@@ -1,10 +1,11 @@
 import logger from "../config/logger.js";
+import { AppError } from "../utils/customErrors.js";
 
 /**
  * Obtener la clave API de Google Maps
  * GET /api/maps/key
  */
-export const getMapsApiKey = (req, res) => {
+export const getMapsApiKey = (req, res, next) => {
   logger.info('Maps API key requested');
 
   try {
@@ -12,10 +13,7 @@ export const getMapsApiKey = (req, res) => {
 
     if (!apiKey) {
       logger.error('Google Maps API key not configured in environment variables');
-      return res.status(500).json({
-        success: false,
-        message: 'Google Maps API key not configured'
-      });
+      return next(new AppError('Google Maps API key not configured', 500, 'MAPS_NOT_CONFIGURED'));
     }
 
     logger.info('Maps API key successfully provided');
@@ -25,9 +23,6 @@ export const getMapsApiKey = (req, res) => {
     });
   } catch (error) {
     logger.error(`Error retrieving Google Maps API key: ${error.message}`);
-    res.status(500).json({
-      success: false,
-      message: 'Failed to retrieve API key'
-    });
+    next(error);
   }
 };
\ No newline at end of file
@@ -0,0 +1,37 @@
+import participantService from "../services/participant.service.js";
+import badgeService from "../services/badge.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Gets the dashboard of the current user as participant
+ * GET /api/me/dashboard
+ */
+export const getDashboard = async (req, res, next) => {
+  try {
+    const result = await participantService.getDashboard(req.user.id);
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Get participant dashboard failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Gets the unread badge counters of the current user
+ * GET /api/me/badges
+ */
+export const getBadges = async (req, res, next) => {
+  try {
+    const badges = await badgeService.getBadges(req.user.id);
+    res.set("Cache-Control", "private, max-age=15");
+    res.status(200).json({ success: true, data: badges });
+  } catch (err) {
+    logger.error(`Get badges failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  getDashboard,
+  getBadges,
+};
@@ -1,5 +1,6 @@
 import reviewMediaRepository from "../repository/reviewMedia.repository.js";
 import logger from "../config/logger.js";
+import { NotFoundError, ValidationError } from "../utils/customErrors.js";
 
 /**
  * Obtiene una lista paginada de media reciente público
@@ -16,17 +17,11 @@ export const getRecentMedia = async (req, res, next) => {
     const limitNum = parseInt(limit, 10);
 
     if (isNaN(pageNum) || pageNum < 1) {
-      return res.status(400).json({
-        success: false,
-        message: "El parámetro 'page' debe ser un número entero mayor o igual a 1.",
-      });
+      return next(new ValidationError("El parámetro 'page' debe ser un número entero mayor o igual a 1."));
     }
 
     if (isNaN(limitNum) || limitNum < 1 || limitNum > 100) {
-      return res.status(400).json({
-        success: false,
-        message: "El parámetro 'limit' debe ser un número entero entre 1 y 100.",
-      });
+      return next(new ValidationError("El parámetro 'limit' debe ser un número entero entre 1 y 100."));
     }
 
     const mediaList = await reviewMediaRepository.getRecentMedia(pageNum, limitNum);
@@ -53,14 +48,6 @@ export const getRecentMedia = async (req, res, next) => {
       `Get recent media endpoint failed, error: ${err.message}, stack: ${err.stack}`
     );
 
-    // Si es un error conocido con status
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
-
     next(err);
   }
 };
@@ -78,19 +65,13 @@ export const getMediaFile = async (req, res, next) => {
     // Validate UUID format
     const uuidRegex = /^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$/i;
     if (!uuidRegex.test(id)) {
-      return res.status(400).json({
-        success: false,
-        message: "El ID del archivo multimedia debe ser un UUID válido.",
-      });
+      return next(new ValidationError("El ID del archivo multimedia debe ser un UUID válido."));
     }
 
     const mediaRecord = await reviewMediaRepository.findById(id);
 
     if (!mediaRecord) {
-      return res.status(404).json({
-        success: false,
-        message: "Archivo multimedia no encontrado.",
-      });
+      return next(new NotFoundError("Archivo multimedia no encontrado."));
     }
 
     // Set appropriate headers
@@ -115,14 +96,6 @@ export const getMediaFile = async (req, res, next) => {
       `Get media file endpoint failed for media: ${id}, error: ${err.message}, stack: ${err.stack}`
     );
 
-    // Si es un error conocido con status
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
-
     next(err);
   }
 };
\ No newline at end of file
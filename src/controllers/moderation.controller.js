@@ -0,0 +1,133 @@
+import moderationService from "../services/moderation.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Hides every trip and trip review of a user
+ * POST /api/admin/moderation/users/:userId/hide-content
+ */
+export const hideUserContent = async (req, res, next) => {
+  try {
+    const { resolveReports = false, notes = null } = req.body || {};
+    const result = await moderationService.hideUserContent(req.params.userId, {
+      resolveReports: resolveReports === true,
+      adminId: req.user.id,
+      notes,
+    });
+    res.status(200).json({ success: true, data: result, message: "Contenido ocultado" });
+  } catch (err) {
+    logger.error(`Admin hide content of ${req.params.userId} failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Dismisses several reports
+ * POST /api/admin/moderation/reports/dismiss
+ */
+export const dismissReports = async (req, res, next) => {
+  try {
+    const { reportIds, notes = null } = req.body || {};
+    const result = await moderationService.dismissReports(req.user.id, reportIds, notes);
+    res.status(200).json({ success: true, data: result });
+  } catch (err) {
+    logger.error(`Admin dismiss reports failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Dismisses open reports repeating an older one on the same target
+ * POST /api/admin/moderation/reports/dismiss-duplicates
+ */
+export const dismissDuplicateReports = async (req, res, next) => {
+  try {
+    const result = await moderationService.dismissDuplicateReports(req.user.id, {
+      targetType: req.body?.targetType ?? null,
+    });
+    res.status(200).json({ success: true, data: result });
+  } catch (err) {
+    logger.error(`Admin dismiss duplicate reports failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Automation rules
+ * GET /api/admin/moderation/rules
+ */
+export const listRules = async (req, res, next) => {
+  try {
+    const rules = await moderationService.listRules();
+    res.status(200).json({ success: true, data: rules });
+  } catch (err) {
+    logger.error(`Admin list moderation rules failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Creates an automation rule
+ * POST /api/admin/moderation/rules
+ */
+export const createRule = async (req, res, next) => {
+  try {
+    const rule = await moderationService.createRule(req.user.id, req.body || {});
+    res.status(201).json({ success: true, data: rule, message: "Regla creada" });
+  } catch (err) {
+    logger.error(`Admin create moderation rule failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Updates an automation rule
+ * PATCH /api/admin/moderation/rules/:ruleId
+ */
+export const updateRule = async (req, res, next) => {
+  try {
+    const rule = await moderationService.updateRule(req.params.ruleId, req.body || {});
+    res.status(200).json({ success: true, data: rule, message: "Regla actualizada" });
+  } catch (err) {
+    logger.error(`Admin update moderation rule ${req.params.ruleId} failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Deletes an automation rule
+ * DELETE /api/admin/moderation/rules/:ruleId
+ */
+export const deleteRule = async (req, res, next) => {
+  try {
+    await moderationService.deleteRule(req.params.ruleId);
+    res.status(200).json({ success: true, message: "Regla eliminada" });
+  } catch (err) {
+    logger.error(`Admin delete moderation rule ${req.params.ruleId} failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Targets an automation rule acted on
+ * GET /api/admin/moderation/rules/:ruleId/hits
+ */
+export const getRuleHits = async (req, res, next) => {
+  try {
+    const hits = await moderationService.getRuleHits(req.params.ruleId);
+    res.status(200).json({ success: true, data: hits });
+  } catch (err) {
+    logger.error(`Admin get hits of moderation rule ${req.params.ruleId} failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  hideUserContent,
+  dismissReports,
+  dismissDuplicateReports,
+  listRules,
+  createRule,
+  updateRule,
+  deleteRule,
+  getRuleHits,
+};
@@ -1,15 +1,24 @@
 import notificationService from "../services/notification.service.js";
+import notificationPreferenceService from "../services/notificationPreference.service.js";
+import pushService from "../services/push.service.js";
 import logger from "../config/logger.js";
+import { NotFoundError } from "../utils/customErrors.js";
 
 export const getNotifications = async (req, res, next) => {
   try {
     const userId = req.user.id;
-    const limit = parseInt(req.query.limit) || 50;
+    const limit = Math.min(parseInt(req.query.limit) || 50, 100);
+    const { before, type } = req.query;
+    const unreadOnly = req.query.unreadOnly === "true";
 
-    const notifications = await notificationService.getUserNotifications(
+    // Pedimos uno extra para saber si hay más páginas
+    const page = await notificationService.getUserNotifications(
       userId,
-      limit
+      limit + 1,
+      { before, unreadOnly, type }
     );
+    const hasMore = page.length > limit;
+    const notifications = hasMore ? page.slice(0, limit) : page;
     const unreadCount = await notificationService.getUnreadCount(userId);
 
     res.status(200).json({
@@ -17,6 +26,8 @@ export const getNotifications = async (req, res, next) => {
       data: {
         notifications,
         unreadCount,
+        hasMore,
+        nextCursor: hasMore ? notifications[notifications.length - 1].id : null,
       },
     });
   } catch (error) {
@@ -42,11 +53,19 @@ export const getUnreadCount = async (req, res, next) => {
 
 export const markAsRead = async (req, res, next) => {
   try {
+    const userId = req.user.id;
     const { notificationId } = req.params;
-    await notificationService.markAsRead(notificationId);
+    const updated = await notificationService.markAsRead(notificationId, userId);
+
+    if (!updated) {
+      return next(new NotFoundError("Notification not found"));
+    }
+
+    const unreadCount = await notificationService.getUnreadCount(userId);
 
     res.status(200).json({
       success: true,
+      data: { unreadCount },
       message: "Notification marked as read",
     });
   } catch (error) {
@@ -58,10 +77,11 @@ export const markAsRead = async (req, res, next) => {
 export const markAllAsRead = async (req, res, next) => {
   try {
     const userId = req.user.id;
-    await notificationService.markAllAsRead(userId);
+    const updated = await notificationService.markAllAsRead(userId);
 
     res.status(200).json({
       success: true,
+      data: { updated, unreadCount: 0 },
       message: "All notifications marked as read",
     });
   } catch (error) {
@@ -81,10 +101,7 @@ export const deleteNotification = async (req, res, next) => {
     );
 
     if (!deleted) {
-      return res.status(404).json({
-        success: false,
-        message: "Notification not found",
-      });
+      return next(new NotFoundError("Notification not found"));
     }
 
     res.status(200).json({
@@ -97,10 +114,96 @@ export const deleteNotification = async (req, res, next) => {
   }
 };
 
+export const registerDevice = async (req, res, next) => {
+  try {
+    const userId = req.user.id;
+    const { token, platform, provider, deviceName } = req.body;
+
+    const device = await pushService.registerDevice(userId, {
+      token,
+      platform,
+      provider,
+      deviceName,
+    });
+
+    res.status(201).json({
+      success: true,
+      data: {
+        id: device.id,
+        platform: device.platform,
+        provider: device.provider,
+        deviceName: device.deviceName,
+        lastSeenAt: device.lastSeenAt,
+      },
+      message: "Device registered",
+    });
+  } catch (error) {
+    logger.error("Error in registerDevice:", error);
+    next(error);
+  }
+};
+
+export const unregisterDevice = async (req, res, next) => {
+  try {
+    const userId = req.user.id;
+    const { token } = req.params;
+
+    await pushService.unregisterDevice(userId, token);
+
+    res.status(200).json({
+      success: true,
+      message: "Device unregistered",
+    });
+  } catch (error) {
+    logger.error("Error in unregisterDevice:", error);
+    next(error);
+  }
+};
+
+export const getPreferences = async (req, res, next) => {
+  try {
+    const preferences = await notificationPreferenceService.getPreferences(
+      req.user.id
+    );
+
+    res.status(200).json({
+      success: true,
+      data: preferences,
+    });
+  } catch (error) {
+    logger.error("Error in getPreferences:", error);
+    next(error);
+  }
+};
+
+export const updatePreferences = async (req, res, next) => {
+  try {
+    const { pushEnabled, emailEnabled, categories, quietHours, timezone } = req.body;
+
+    const preferences = await notificationPreferenceService.updatePreferences(
+      req.user.id,
+      { pushEnabled, emailEnabled, categories, quietHours, timezone }
+    );
+
+    res.status(200).json({
+      success: true,
+      data: preferences,
+      message: "Preferences updated",
+    });
+  } catch (error) {
+    logger.error("Error in updatePreferences:", error);
+    next(error);
+  }
+};
+
 export default {
   getNotifications,
   getUnreadCount,
   markAsRead,
   markAllAsRead,
   deleteNotification,
+  registerDevice,
+  unregisterDevice,
+  getPreferences,
+  updatePreferences,
 };
@@ -0,0 +1,60 @@
+import organizerService from "../services/organizer.service.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+
+/**
+ * Gets the dashboard of the current user as organizer
+ * GET /api/organizer/dashboard
+ */
+export const getDashboard = async (req, res, next) => {
+  try {
+    const result = await organizerService.getDashboard(req.user.id);
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Get organizer dashboard failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Public page of an organizer
+ * GET /api/organizers/:organizerId
+ */
+export const getPublicPage = async (req, res, next) => {
+  try {
+    const result = await organizerService.getPublicPage(req.params.organizerId, req.user?.id || null);
+    // Anonymous responses are the same for everyone; logged-in ones depend on blocks
+    res.set(
+      "Cache-Control",
+      req.user ? "private, no-cache" : `public, max-age=${config.organizerWidget.cacheSeconds}`
+    );
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Get organizer page failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Data for the organizer widget embedded on third-party sites
+ * GET /api/organizers/:organizerId/widget
+ */
+export const getWidget = async (req, res, next) => {
+  try {
+    const result = await organizerService.getWidget(req.params.organizerId, {
+      limit: req.query.limit,
+    });
+    const maxAge = config.organizerWidget.cacheSeconds;
+    res.set("Cache-Control", `public, max-age=${maxAge}, stale-while-revalidate=${maxAge * 2}`);
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Get organizer widget failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  getDashboard,
+  getPublicPage,
+  getWidget,
+};
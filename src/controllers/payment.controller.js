@@ -0,0 +1,86 @@
+import paymentService from "../services/payment.service.js";
+import stripeClient from "../clients/stripe.client.js";
+import logger from "../config/logger.js";
+import { ValidationError } from "../utils/customErrors.js";
+
+/**
+ * Creates the deposit payment intent of the user's join request
+ * POST /api/groups/:groupId/payments/deposit
+ */
+export const createDepositIntent = async (req, res, next) => {
+  try {
+    const result = await paymentService.createDepositIntent(req.params.groupId, req.user.id);
+
+    res.status(201).json({
+      success: true,
+      data: result,
+    });
+  } catch (err) {
+    logger.error(`Create deposit intent failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists the payments of a group (admin)
+ * GET /api/groups/:groupId/payments
+ */
+export const getGroupPayments = async (req, res, next) => {
+  try {
+    const payments = await paymentService.getGroupPayments(req.params.groupId, req.user.id);
+
+    res.status(200).json({
+      success: true,
+      data: payments,
+    });
+  } catch (err) {
+    logger.error(`Get group payments failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists the payments of the current user
+ * GET /api/payments/me
+ */
+export const getMyPayments = async (req, res, next) => {
+  try {
+    const payments = await paymentService.getUserPayments(req.user.id);
+
+    res.status(200).json({
+      success: true,
+      data: payments,
+    });
+  } catch (err) {
+    logger.error(`Get user payments failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Receives Stripe webhooks
+ * POST /api/payments/webhook
+ */
+export const handleWebhook = async (req, res, next) => {
+  try {
+    const event = stripeClient.verifyWebhook(req.rawBody, req.get("stripe-signature"));
+    if (!event) {
+      logger.warn("Rejected Stripe webhook with an invalid signature");
+      return next(new ValidationError("Invalid signature"));
+    }
+
+    await paymentService.handleWebhookEvent(event);
+    res.status(200).json({ received: true });
+  } catch (err) {
+    // A non-2xx answer makes Stripe retry the event
+    logger.error(`Stripe webhook failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  createDepositIntent,
+  getGroupPayments,
+  getMyPayments,
+  handleWebhook,
+};
@@ -1,6 +1,7 @@
 import placeService from "../services/place.service.js";
 import logger from "../config/logger.js";
 import { authenticate } from "../middleware/auth.middleware.js";
+import { NotFoundError, ValidationError } from "../utils/customErrors.js";
 
 /**
  * Agrega un nuevo lugar
@@ -14,10 +15,7 @@ export const addPlace = async (req, res, next) => {
 
     // Validar que se envíen los campos requeridos
     if (!name || !address || latitude === undefined || longitude === undefined) {
-      return res.status(400).json({
-        success: false,
-        message: "Nombre, dirección, latitud y longitud son requeridos.",
-      });
+      return next(new ValidationError("Nombre, dirección, latitud y longitud son requeridos."));
     }
 
     const result = await placeService.addPlace({ name, address, latitude, longitude, image, description, city });
@@ -40,14 +38,6 @@ export const addPlace = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Add place endpoint failed for name: ${req.body.name}, error: ${err.message}`);
-    // Si el error tiene detalles (errores de validación)
-    if (err.details) {
-      return res.status(err.status || 400).json({
-        success: false,
-        message: err.message,
-        errors: err.details,
-      });
-    }
     next(err);
   }
 };
@@ -63,10 +53,7 @@ export const checkPlace = async (req, res, next) => {
 
     // Validar parámetros requeridos
     if (!name || !address) {
-      return res.status(400).json({
-        success: false,
-        message: "Nombre y dirección son requeridos como parámetros de consulta.",
-      });
+      return next(new ValidationError("Nombre y dirección son requeridos como parámetros de consulta."));
     }
 
     const result = await placeService.checkPlaceExistence(name, address);
@@ -79,14 +66,6 @@ export const checkPlace = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Check place endpoint failed for name: ${req.query.name}, error: ${err.message}`);
-    // Si el error tiene detalles (errores de validación)
-    if (err.details) {
-      return res.status(err.status || 400).json({
-        success: false,
-        message: err.message,
-        errors: err.details,
-      });
-    }
     next(err);
   }
 };
@@ -109,14 +88,6 @@ export const getPlaces = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Get places endpoint failed: ${err.message}`);
-    // Si el error tiene detalles (errores de validación)
-    if (err.details) {
-      return res.status(err.status || 400).json({
-        success: false,
-        message: err.message,
-        errors: err.details,
-      });
-    }
     next(err);
   }
 };
@@ -131,17 +102,11 @@ export const updatePlaceDescription = async (req, res, next) => {
     const { description } = req.body;
 
     if (!id) {
-      return res.status(400).json({
-        success: false,
-        message: "El ID del lugar es requerido.",
-      });
+      return next(new ValidationError("El ID del lugar es requerido."));
     }
 
     if (!description) {
-      return res.status(400).json({
-        success: false,
-        message: "La descripción es requerida.",
-      });
+      return next(new ValidationError("La descripción es requerida."));
     }
 
     const result = await placeService.updateDescription(id, description);
@@ -154,20 +119,6 @@ export const updatePlaceDescription = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Update place description endpoint failed for id: ${req.params.id}, description length: ${req.body?.description?.length || 0} characters, error: ${err.message}`);
-    if (err.details) {
-      return res.status(err.status || 400).json({
-        success: false,
-        message: err.message,
-        error: err.details,
-      });
-    }
-    if (err.status === 404) {
-      return res.status(404).json({
-        success: false,
-        message: "Place not found",
-        error: "Place with given ID not found",
-      });
-    }
     next(err);
   }
 };
@@ -182,19 +133,13 @@ export const getPlaceById = async (req, res, next) => {
     const { id } = req.params;
 
     if (!id) {
-      return res.status(400).json({
-        success: false,
-        message: "El ID del lugar es requerido.",
-      });
+      return next(new ValidationError("El ID del lugar es requerido."));
     }
 
     const place = await placeService.getPlaceById(id);
 
     if (!place) {
-      return res.status(404).json({
-        success: false,
-        message: "Lugar no encontrado.",
-      });
+      return next(new NotFoundError("Lugar no encontrado."));
     }
 
     logger.info(`Get place by ID endpoint completed successfully for id: ${id}`);
@@ -204,13 +149,6 @@ export const getPlaceById = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Get place by ID endpoint failed for id: ${req.params.id}, error: ${err.message}`);
-    if (err.details) {
-      return res.status(err.status || 400).json({
-        success: false,
-        message: err.message,
-        errors: err.details,
-      });
-    }
     next(err);
   }
 };
@@ -226,10 +164,7 @@ export const toggleFavorite = async (req, res, next) => {
     const userId = req.user.id;
 
     if (!placeId) {
-      return res.status(400).json({
-        success: false,
-        message: "Place ID is required.",
-      });
+      return next(new ValidationError("Place ID is required."));
     }
 
     const result = await placeService.toggleFavorite(userId, placeId);
@@ -243,17 +178,10 @@ export const toggleFavorite = async (req, res, next) => {
   } catch (err) {
     logger.error(`Toggle favorite endpoint failed for place: ${req.params.placeId}, user: ${req.user.id}, error: ${err.message}`);
     if (err.details) {
-      return res.status(err.status || 400).json({
-        success: false,
-        message: err.message,
-        errors: err.details,
-      });
+      return next(err);
     }
     if (err.status === 404) {
-      return res.status(404).json({
-        success: false,
-        message: "Place not found",
-      });
+      return next(new NotFoundError("Place not found"));
     }
     next(err);
   }
@@ -270,10 +198,7 @@ export const getFavoriteStatus = async (req, res, next) => {
     const userId = req.user.id;
 
     if (!placeId) {
-      return res.status(400).json({
-        success: false,
-        message: "Place ID is required.",
-      });
+      return next(new ValidationError("Place ID is required."));
     }
 
     const result = await placeService.getFavoriteStatus(userId, placeId);
@@ -286,17 +211,10 @@ export const getFavoriteStatus = async (req, res, next) => {
   } catch (err) {
     logger.error(`Get favorite status endpoint failed for place: ${req.params.placeId}, user: ${req.user.id}, error: ${err.message}`);
     if (err.details) {
-      return res.status(err.status || 400).json({
-        success: false,
-        message: err.message,
-        errors: err.details,
-      });
+      return next(err);
     }
     if (err.status === 404) {
-      return res.status(404).json({
-        success: false,
-        message: "Place not found",
-      });
+      return next(new NotFoundError("Place not found"));
     }
     next(err);
   }
@@ -321,13 +239,6 @@ export const getUserFavorites = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Get user favorites endpoint failed for user: ${req.user.id}, error: ${err.message}`);
-    if (err.details) {
-      return res.status(err.status || 400).json({
-        success: false,
-        message: err.message,
-        errors: err.details,
-      });
-    }
     next(err);
   }
 };
@@ -358,13 +269,6 @@ export const searchPlaces = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Search places endpoint failed: ${err.message}`);
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-        data: null,
-      });
-    }
     next(err);
   }
 };
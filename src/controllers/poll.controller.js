@@ -0,0 +1,36 @@
+import longPollService from "../services/longPoll.service.js";
+import logger from "../config/logger.js";
+
+const CONNECTION_ID_REGEX = /^[A-Za-z0-9_-]{1,64}$/;
+
+/**
+ * Long-polling fallback of the realtime events
+ * GET /api/poll?cursor=
+ */
+export const poll = async (req, res, next) => {
+  try {
+    // A client may keep several connections (tabs) apart with X-Poll-Connection
+    const connectionId = req.get("x-poll-connection");
+    const connection = CONNECTION_ID_REGEX.test(connectionId || "")
+      ? connectionId
+      : req.user.sessionId || "default";
+
+    const result = await longPollService.poll({
+      userId: req.user.id,
+      connectionKey: `${req.user.id}:${connection}`,
+      cursor: req.query.cursor,
+      signal: req.context?.signal,
+    });
+
+    if (res.headersSent) return;
+    res.set("Cache-Control", "no-store");
+    res.status(200).json({ success: true, data: result });
+  } catch (err) {
+    logger.error(`Long poll failed for user ${req.user?.id}: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  poll,
+};
@@ -1,5 +1,6 @@
 import questionService from "../services/question.service.js";
 import logger from "../config/logger.js";
+import { ValidationError } from "../utils/customErrors.js";
 
 /**
  * Crea una nueva pregunta
@@ -14,17 +15,11 @@ export const createQuestion = async (req, res, next) => {
     const userId = req.user.id;
 
     if (!placeId) {
-      return res.status(400).json({
-        success: false,
-        message: "ID del lugar es requerido.",
-      });
+      return next(new ValidationError("ID del lugar es requerido."));
     }
 
     if (!content) {
-      return res.status(400).json({
-        success: false,
-        message: "El contenido de la pregunta es requerido.",
-      });
+      return next(new ValidationError("El contenido de la pregunta es requerido."));
     }
 
     const result = await questionService.createQuestion({
@@ -41,12 +36,6 @@ export const createQuestion = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Create question endpoint failed: ${err.message}`);
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
     next(err);
   }
 };
@@ -62,10 +51,7 @@ export const getQuestionsByPlace = async (req, res, next) => {
     const { limit = 50, offset = 0 } = req.query;
 
     if (!placeId) {
-      return res.status(400).json({
-        success: false,
-        message: "ID del lugar es requerido.",
-      });
+      return next(new ValidationError("ID del lugar es requerido."));
     }
 
     const questions = await questionService.getQuestionsByPlace(placeId, limit, offset);
@@ -77,12 +63,6 @@ export const getQuestionsByPlace = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Get questions by place endpoint failed: ${err.message}`);
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
     next(err);
   }
 };
@@ -98,10 +78,7 @@ export const voteQuestion = async (req, res, next) => {
     const userId = req.user.id;
 
     if (!questionId) {
-      return res.status(400).json({
-        success: false,
-        message: "ID de la pregunta es requerido.",
-      });
+      return next(new ValidationError("ID de la pregunta es requerido."));
     }
 
     const result = await questionService.voteQuestion(questionId, userId);
@@ -114,12 +91,6 @@ export const voteQuestion = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Vote question endpoint failed: ${err.message}`);
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
     next(err);
   }
 };
@@ -135,10 +106,7 @@ export const getQuestionVoteStatus = async (req, res, next) => {
     const userId = req.user.id;
 
     if (!questionId) {
-      return res.status(400).json({
-        success: false,
-        message: "ID de la pregunta es requerido.",
-      });
+      return next(new ValidationError("ID de la pregunta es requerido."));
     }
 
     const result = await questionService.getVoteStatus(questionId, userId);
@@ -150,12 +118,6 @@ export const getQuestionVoteStatus = async (req, res, next) => {
     });
   } catch (err) {
     logger.error(`Get question vote status endpoint failed: ${err.message}`);
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
     next(err);
   }
 };
\ No newline at end of file
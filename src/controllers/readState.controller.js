@@ -0,0 +1,43 @@
+import readStateService from "../services/readState.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Gets the read state of the current user across notifications and chats
+ * GET /api/sync/read-state
+ */
+export const getReadState = async (req, res, next) => {
+  try {
+    const state = await readStateService.getReadState(req.user.id);
+
+    res.status(200).json({
+      success: true,
+      data: state,
+    });
+  } catch (err) {
+    logger.error(`Get read state failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Applies reads made on a device and returns the resulting read state
+ * POST /api/sync/read-state
+ */
+export const applyReads = async (req, res, next) => {
+  try {
+    const state = await readStateService.applyReads(req.user.id, req.body);
+
+    res.status(200).json({
+      success: true,
+      data: state,
+    });
+  } catch (err) {
+    logger.error(`Apply reads failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  getReadState,
+  applyReads,
+};
@@ -0,0 +1,37 @@
+import recommendationService from "../services/recommendation.service.js";
+import logger from "../config/logger.js";
+
+export const getMyRecommendations = async (req, res, next) => {
+  try {
+    const limit = Math.min(parseInt(req.query.limit) || 10, 50);
+    const recommendations = await recommendationService.getRecommendations(
+      req.user.id,
+      limit
+    );
+
+    res.status(200).json({
+      success: true,
+      data: recommendations,
+    });
+  } catch (error) {
+    logger.error("Error in getMyRecommendations:", error);
+    next(error);
+  }
+};
+
+export const trackClick = async (req, res, next) => {
+  try {
+    const { emailId, groupId } = req.params;
+    const redirectUrl = await recommendationService.trackClick(emailId, groupId);
+
+    res.redirect(302, redirectUrl);
+  } catch (error) {
+    logger.error("Error in trackClick:", error);
+    next(error);
+  }
+};
+
+export default {
+  getMyRecommendations,
+  trackClick,
+};
@@ -1,6 +1,7 @@
 import reviewService from "../services/review.service.js";
 import { uploadReviewMedia } from "../utils/fileUpload.js";
 import logger from "../config/logger.js";
+import { AuthenticationError, ValidationError } from "../utils/customErrors.js";
 
 /**
  * Crea una nueva reseña para un lugar
@@ -36,22 +37,17 @@ export const createReview = [
     try {
       // Verificar que el usuario esté autenticado
       if (!userId) {
-        return res.status(401).json({
-          success: false,
-          message: "Usuario no está autenticado.",
-        });
+        return next(new AuthenticationError("Usuario no está autenticado."));
       }
 
       // Validar que se envíen los campos requeridos
       if (!rating || !content) {
-        return res.status(400).json({
-          success: false,
-          message: "Calificación y contenido son requeridos.",
-          details: [
+        return next(
+          new ValidationError("Calificación y contenido son requeridos.", [
             ...(!rating ? ["rating: es requerido"] : []),
             ...(!content ? ["content: es requerido"] : []),
-          ],
-        });
+          ])
+        );
       }
 
       const result = await reviewService.createReview({
@@ -89,23 +85,6 @@ export const createReview = [
          logger.error(`Multer unexpected field error. Field: ${err.field}, received: ${Object.keys(req.body || {})}`);
        }
 
-       // Si el error tiene detalles (errores de validación)
-       if (err.details) {
-         return res.status(err.status || 400).json({
-           success: false,
-           message: err.message,
-           details: err.details,
-         });
-       }
-
-       // Si es un error conocido con status
-       if (err.status) {
-         return res.status(err.status).json({
-           success: false,
-           message: err.message,
-         });
-       }
-
        next(err);
      }
   }
@@ -135,14 +114,6 @@ export const getReviewsByPlace = async (req, res, next) => {
       `Get reviews endpoint failed for place: ${placeId}, error: ${err.message}`
     );
 
-    // Si es un error conocido con status
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
-
     next(err);
   }
 };
@@ -161,18 +132,12 @@ export const getReviewsByPlace = async (req, res, next) => {
    try {
      // Verificar que el usuario esté autenticado
      if (!userId) {
-       return res.status(401).json({
-         success: false,
-         message: "Usuario no está autenticado.",
-       });
+       return next(new AuthenticationError("Usuario no está autenticado."));
      }
 
      // Validar el tipo de reacción
      if (!type || !['like', 'dislike'].includes(type)) {
-       return res.status(400).json({
-         success: false,
-         message: "Tipo de reacción inválido. Debe ser 'like' o 'dislike'.",
-       });
+       return next(new ValidationError("Tipo de reacción inválido. Debe ser 'like' o 'dislike'."));
      }
 
      const result = await reviewService.toggleReaction(reviewId, userId, type);
@@ -190,14 +155,6 @@ export const getReviewsByPlace = async (req, res, next) => {
        `Toggle reaction endpoint failed for review: ${reviewId}, user: ${userId}, error: ${err.message}`
      );
 
-     // Si es un error conocido con status
-     if (err.status) {
-       return res.status(err.status).json({
-         success: false,
-         message: err.message,
-       });
-     }
-
      next(err);
    }
  };
@@ -228,14 +185,6 @@ export const getReviewsByPlace = async (req, res, next) => {
        `Get reaction status endpoint failed for review: ${reviewId}, error: ${err.message}`
      );
 
-     // Si es un error conocido con status
-     if (err.status) {
-       return res.status(err.status).json({
-         success: false,
-         message: err.message,
-       });
-     }
-
      next(err);
    }
  };
@@ -261,14 +210,6 @@ export const getReviewStats = async (req, res, next) => {
       `Get review stats endpoint failed for place: ${placeId}, error: ${err.message}`
     );
 
-    // Si es un error conocido con status
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-      });
-    }
-
     next(err);
   }
 };
@@ -298,14 +239,6 @@ export const getAllReviews = async (req, res, next) => {
   } catch (err) {
     logger.error(`Get all reviews endpoint failed: ${err.message}`);
 
-    if (err.status) {
-      return res.status(err.status).json({
-        success: false,
-        message: err.message,
-        details: err.details
-      });
-    }
-
     next(err);
   }
 };
\ No newline at end of file
@@ -0,0 +1,26 @@
+import searchService from "../services/search.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Searches trips, destinations and users
+ * GET /api/search?q=&type=&limit=&offset=
+ */
+export const search = async (req, res, next) => {
+  try {
+    const result = await searchService.search({
+      q: req.query.q,
+      type: req.query.type,
+      limit: parseInt(req.query.limit) || 20,
+      offset: parseInt(req.query.offset) || 0,
+      viewerId: req.user?.id,
+    });
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Search failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  search,
+};
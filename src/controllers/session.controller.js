@@ -0,0 +1,58 @@
+import sessionService from "../services/session.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Devices the user is signed in on
+ * GET /api/users/me/sessions
+ */
+export const listSessions = async (req, res, next) => {
+  try {
+    const sessions = await sessionService.listSessions(req.user.id, req.user.sessionId);
+    res.set("Cache-Control", "no-store");
+    res.status(200).json({ success: true, data: sessions });
+  } catch (err) {
+    logger.error(`List sessions failed for user ${req.user?.id}: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Logs one device out
+ * DELETE /api/users/me/sessions/:sessionId
+ */
+export const revokeSession = async (req, res, next) => {
+  try {
+    await sessionService.revokeSession(req.user.id, req.params.sessionId);
+
+    res.status(200).json({ success: true, message: "Sesión cerrada" });
+  } catch (err) {
+    logger.error(`Revoke session failed for user ${req.user?.id}: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Logs every other device out (?includeCurrent=true logs this one out too)
+ * DELETE /api/users/me/sessions
+ */
+export const revokeAllSessions = async (req, res, next) => {
+  try {
+    const includeCurrent = req.query.includeCurrent === "true";
+    const revoked = await sessionService.revokeDevices(req.user.id, req.user.sessionId, { includeCurrent });
+
+    res.status(200).json({
+      success: true,
+      data: { revoked },
+      message: includeCurrent ? "Cerraste sesión en todos los dispositivos" : "Cerraste sesión en los demás dispositivos",
+    });
+  } catch (err) {
+    logger.error(`Revoke all sessions failed for user ${req.user?.id}: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  listSessions,
+  revokeSession,
+  revokeAllSessions,
+};
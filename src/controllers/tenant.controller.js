@@ -0,0 +1,176 @@
+import tenantService from "../services/tenant.service.js";
+import tenantDomainService from "../services/tenantDomain.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Creates a white-label tenant
+ * POST /api/admin/tenants
+ */
+export const createTenant = async (req, res, next) => {
+  try {
+    const tenant = await tenantService.createTenant(req.body || {});
+    res.status(201).json({
+      success: true,
+      data: tenant,
+      message: tenant.senderDomain
+        ? "Tenant creado. Publica los registros DNS del dominio de envío y verifícalos."
+        : "Tenant creado",
+    });
+  } catch (err) {
+    logger.error(`Create tenant failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists tenants
+ * GET /api/admin/tenants
+ */
+export const listTenants = async (req, res, next) => {
+  try {
+    const tenants = await tenantService.listTenants();
+    res.status(200).json({ success: true, data: tenants });
+  } catch (err) {
+    logger.error(`List tenants failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Tenant details
+ * GET /api/admin/tenants/:tenantId
+ */
+export const getTenant = async (req, res, next) => {
+  try {
+    const tenant = await tenantService.getTenant(req.params.tenantId);
+    res.status(200).json({ success: true, data: tenant });
+  } catch (err) {
+    logger.error(`Get tenant failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Updates the branding, sender or reply-to of a tenant
+ * PATCH /api/admin/tenants/:tenantId
+ */
+export const updateTenant = async (req, res, next) => {
+  try {
+    const tenant = await tenantService.updateTenant(req.params.tenantId, req.body || {});
+    res.status(200).json({ success: true, data: tenant, message: "Tenant actualizado" });
+  } catch (err) {
+    logger.error(`Update tenant failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * DNS records of the sender domain and whether they are published
+ * GET /api/admin/tenants/:tenantId/dns-records
+ */
+export const getDnsRecords = async (req, res, next) => {
+  try {
+    const result = await tenantService.checkDnsRecords(req.params.tenantId);
+    res.status(200).json({ success: true, data: result });
+  } catch (err) {
+    logger.error(`Check tenant DNS records failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Issues a new DKIM key for the sender domain
+ * POST /api/admin/tenants/:tenantId/rotate-dkim
+ */
+export const rotateDkimKey = async (req, res, next) => {
+  try {
+    const tenant = await tenantService.rotateDkimKey(req.params.tenantId);
+    res.status(200).json({
+      success: true,
+      data: tenant,
+      message: "Clave DKIM renovada. Publica el nuevo registro y verifícalo.",
+    });
+  } catch (err) {
+    logger.error(`Rotate tenant DKIM key failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Custom domains of a tenant
+ * GET /api/admin/tenants/:tenantId/domains
+ */
+export const listDomains = async (req, res, next) => {
+  try {
+    const domains = await tenantDomainService.listDomains(req.params.tenantId);
+    res.status(200).json({ success: true, data: domains });
+  } catch (err) {
+    logger.error(`List tenant domains failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Adds a custom domain pending its DNS challenge
+ * POST /api/admin/tenants/:tenantId/domains
+ */
+export const addDomain = async (req, res, next) => {
+  try {
+    const domain = await tenantDomainService.addDomain(req.params.tenantId, req.body?.hostname);
+    res.status(201).json({
+      success: true,
+      data: domain,
+      message: "Dominio agregado. Publica el registro TXT y verifícalo.",
+    });
+  } catch (err) {
+    logger.error(`Add tenant domain failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Checks the DNS challenge of a custom domain
+ * POST /api/admin/tenants/:tenantId/domains/:domainId/verify
+ */
+export const verifyDomain = async (req, res, next) => {
+  try {
+    const domain = await tenantDomainService.verifyDomain(req.params.tenantId, req.params.domainId);
+    res.status(200).json({
+      success: true,
+      data: domain,
+      message: domain.found
+        ? "Dominio verificado"
+        : "No se encontró el registro TXT. Los cambios de DNS pueden tardar en propagarse.",
+    });
+  } catch (err) {
+    logger.error(`Verify tenant domain failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Removes a custom domain
+ * DELETE /api/admin/tenants/:tenantId/domains/:domainId
+ */
+export const removeDomain = async (req, res, next) => {
+  try {
+    await tenantDomainService.removeDomain(req.params.tenantId, req.params.domainId);
+    res.status(200).json({ success: true, message: "Dominio eliminado" });
+  } catch (err) {
+    logger.error(`Remove tenant domain failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  createTenant,
+  listTenants,
+  getTenant,
+  updateTenant,
+  getDnsRecords,
+  rotateDkimKey,
+  listDomains,
+  addDomain,
+  verifyDomain,
+  removeDomain,
+};
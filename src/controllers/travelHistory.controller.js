@@ -0,0 +1,55 @@
+import travelHistoryService from "../services/travelHistory.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Imports past trips into the travel history
+ * POST /api/me/travel-history/import
+ */
+export const importTrips = async (req, res, next) => {
+  try {
+    const { trips, photos } = req.body || {};
+    const result = await travelHistoryService.importTrips(req.user.id, { trips, photos });
+    res.status(201).json({
+      success: true,
+      data: result,
+      message: `${result.imported.length} viajes importados`,
+    });
+  } catch (err) {
+    logger.error(`Import past trips failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Travel history with countries-visited stats
+ * GET /api/me/travel-history
+ */
+export const getHistory = async (req, res, next) => {
+  try {
+    const history = await travelHistoryService.getHistory(req.user.id);
+    res.status(200).json({ success: true, data: history });
+  } catch (err) {
+    logger.error(`Get travel history failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Removes an imported trip
+ * DELETE /api/me/travel-history/:id
+ */
+export const deleteTrip = async (req, res, next) => {
+  try {
+    await travelHistoryService.deleteTrip(req.user.id, req.params.id);
+    res.status(200).json({ success: true, message: "Viaje eliminado del historial" });
+  } catch (err) {
+    logger.error(`Delete past trip failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  importTrips,
+  getHistory,
+  deleteTrip,
+};
@@ -0,0 +1,59 @@
+import tripIncidentService from "../services/tripIncident.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Reports a safety incident during a trip
+ * POST /api/groups/:groupId/incidents
+ */
+export const reportIncident = async (req, res, next) => {
+  try {
+    const incident = await tripIncidentService.reportIncident(req.params.groupId, req.user.id, req.body);
+
+    res.status(201).json({
+      success: true,
+      data: incident,
+      message: "Incidente reportado. Nuestro equipo de seguridad ya fue avisado",
+    });
+  } catch (err) {
+    logger.error(`Report incident failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Gets the emergency contacts of the current user
+ * GET /api/me/emergency-contacts
+ */
+export const getEmergencySettings = async (req, res, next) => {
+  try {
+    const settings = await tripIncidentService.getEmergencySettings(req.user.id);
+    res.status(200).json({ success: true, data: settings });
+  } catch (err) {
+    logger.error(`Get emergency contacts failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Updates the emergency contacts of the current user
+ * PUT /api/me/emergency-contacts
+ */
+export const updateEmergencySettings = async (req, res, next) => {
+  try {
+    const settings = await tripIncidentService.updateEmergencySettings(req.user.id, req.body);
+    res.status(200).json({
+      success: true,
+      data: settings,
+      message: "Contactos de emergencia actualizados",
+    });
+  } catch (err) {
+    logger.error(`Update emergency contacts failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  reportIncident,
+  getEmergencySettings,
+  updateEmergencySettings,
+};
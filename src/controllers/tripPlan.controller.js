@@ -0,0 +1,113 @@
+import tripPlanService from "../services/tripPlan.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Gets the day-by-day plan of a trip
+ * GET /api/groups/:groupId/plan
+ */
+export const getPlan = async (req, res, next) => {
+  try {
+    const plan = await tripPlanService.getPlan(req.params.groupId, req.user.id);
+    res.status(200).json({ success: true, data: plan });
+  } catch (err) {
+    logger.error(`Get trip plan failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Gets an item of the plan
+ * GET /api/groups/:groupId/plan/items/:itemId
+ */
+export const getItem = async (req, res, next) => {
+  try {
+    const { groupId, itemId } = req.params;
+    const item = await tripPlanService.getItem(groupId, itemId, req.user.id);
+    res.status(200).json({ success: true, data: item });
+  } catch (err) {
+    logger.error(`Get trip plan item failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Adds an item to the plan (admin only)
+ * POST /api/groups/:groupId/plan/items
+ */
+export const createItem = async (req, res, next) => {
+  try {
+    const { date, time, placeId, notes, cost } = req.body || {};
+    const item = await tripPlanService.createItem(req.params.groupId, req.user.id, {
+      date,
+      time,
+      placeId,
+      notes,
+      cost,
+    });
+    res.status(201).json({ success: true, data: item, message: "Ítem agregado al itinerario" });
+  } catch (err) {
+    logger.error(`Create trip plan item failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Updates an item of the plan (admin only)
+ * PATCH /api/groups/:groupId/plan/items/:itemId
+ */
+export const updateItem = async (req, res, next) => {
+  try {
+    const { groupId, itemId } = req.params;
+    const { date, time, placeId, notes, cost } = req.body || {};
+    const item = await tripPlanService.updateItem(groupId, itemId, req.user.id, {
+      date,
+      time,
+      placeId,
+      notes,
+      cost,
+    });
+    res.status(200).json({ success: true, data: item, message: "Ítem actualizado" });
+  } catch (err) {
+    logger.error(`Update trip plan item failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Deletes an item of the plan (admin only)
+ * DELETE /api/groups/:groupId/plan/items/:itemId
+ */
+export const deleteItem = async (req, res, next) => {
+  try {
+    const { groupId, itemId } = req.params;
+    await tripPlanService.deleteItem(groupId, itemId, req.user.id);
+    res.status(200).json({ success: true, message: "Ítem eliminado" });
+  } catch (err) {
+    logger.error(`Delete trip plan item failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Reorders the items of a day (admin only)
+ * PUT /api/groups/:groupId/plan/days/:date/order
+ */
+export const reorderDay = async (req, res, next) => {
+  try {
+    const { groupId, date } = req.params;
+    const items = await tripPlanService.reorderDay(groupId, date, req.user.id, req.body?.itemIds);
+    res.status(200).json({ success: true, data: items, message: "Día reordenado" });
+  } catch (err) {
+    logger.error(`Reorder trip plan day failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  getPlan,
+  getItem,
+  createItem,
+  updateItem,
+  deleteItem,
+  reorderDay,
+};
@@ -0,0 +1,54 @@
+import tripPublicationService from "../services/tripPublication.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Schedules the launch of a trip (admin only)
+ * PUT /api/groups/:groupId/publication
+ */
+export const schedulePublication = async (req, res, next) => {
+  try {
+    const result = await tripPublicationService.schedulePublication(
+      req.params.groupId,
+      req.user.id,
+      req.body.publishAt
+    );
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Schedule publication failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Cancels the scheduled launch of a trip (admin only)
+ * DELETE /api/groups/:groupId/publication
+ */
+export const cancelPublication = async (req, res, next) => {
+  try {
+    const result = await tripPublicationService.cancelPublication(req.params.groupId, req.user.id);
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Cancel publication failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Launch status and countdown of a trip
+ * GET /api/groups/:groupId/publication
+ */
+export const getCountdown = async (req, res, next) => {
+  try {
+    const result = await tripPublicationService.getCountdown(req.params.groupId, req.user.id);
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Get publication countdown failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  schedulePublication,
+  cancelPublication,
+  getCountdown,
+};
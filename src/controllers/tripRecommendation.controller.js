@@ -0,0 +1,23 @@
+import tripRecommendationService from "../services/tripRecommendation.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Open trips ranked for the authenticated user
+ * GET /api/trips/recommended?limit=&offset=
+ */
+export const getRecommendedTrips = async (req, res, next) => {
+  try {
+    const result = await tripRecommendationService.getRecommended(req.user.id, {
+      limit: parseInt(req.query.limit) || 20,
+      offset: parseInt(req.query.offset) || 0,
+    });
+    res.status(200).json({ success: true, data: result });
+  } catch (err) {
+    logger.error(`Get recommended trips failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  getRecommendedTrips,
+};
@@ -0,0 +1,140 @@
+import tripReviewService from "../services/tripReview.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Reviews a finished trip and its organizer
+ * POST /api/groups/:groupId/reviews
+ */
+export const createReview = async (req, res, next) => {
+  try {
+    const review = await tripReviewService.createReview(req.params.groupId, req.user.id, req.body);
+
+    res.status(201).json({
+      success: true,
+      data: review,
+      message: "Reseña publicada",
+    });
+  } catch (err) {
+    logger.error(`Create trip review failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists the reviews of a trip with average ratings
+ * GET /api/groups/:groupId/reviews
+ */
+export const getTripReviews = async (req, res, next) => {
+  try {
+    const result = await tripReviewService.getTripReviews(req.params.groupId);
+
+    res.status(200).json({
+      success: true,
+      data: result,
+    });
+  } catch (err) {
+    logger.error(`Get trip reviews failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Edits your review
+ * PATCH /api/trip-reviews/:id
+ */
+export const updateReview = async (req, res, next) => {
+  try {
+    const review = await tripReviewService.updateReview(req.params.id, req.user.id, req.body);
+
+    res.status(200).json({
+      success: true,
+      data: review,
+      message: "Reseña actualizada",
+    });
+  } catch (err) {
+    logger.error(`Update trip review failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Deletes a review
+ * DELETE /api/trip-reviews/:id
+ */
+export const deleteReview = async (req, res, next) => {
+  try {
+    await tripReviewService.deleteReview(req.params.id, req.user);
+
+    res.status(200).json({
+      success: true,
+      message: "Reseña eliminada",
+    });
+  } catch (err) {
+    logger.error(`Delete trip review failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Flags a review as inappropriate
+ * POST /api/trip-reviews/:id/flags
+ */
+export const flagReview = async (req, res, next) => {
+  try {
+    await tripReviewService.flagReview(req.params.id, req.user.id, req.body);
+
+    res.status(201).json({
+      success: true,
+      message: "Gracias, revisaremos la reseña",
+    });
+  } catch (err) {
+    logger.error(`Flag trip review failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists flagged reviews
+ * GET /api/admin/trip-reviews/flagged
+ */
+export const getFlaggedReviews = async (req, res, next) => {
+  try {
+    const reviews = await tripReviewService.getFlaggedReviews(req.query.status || null);
+
+    res.status(200).json({
+      success: true,
+      data: reviews,
+    });
+  } catch (err) {
+    logger.error(`Get flagged trip reviews failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Shows or hides a review
+ * PATCH /api/admin/trip-reviews/:id/moderation
+ */
+export const moderateReview = async (req, res, next) => {
+  try {
+    const review = await tripReviewService.moderateReview(req.params.id, req.body.status);
+
+    res.status(200).json({
+      success: true,
+      data: review,
+    });
+  } catch (err) {
+    logger.error(`Moderate trip review failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  createReview,
+  getTripReviews,
+  updateReview,
+  deleteReview,
+  flagReview,
+  getFlaggedReviews,
+  moderateReview,
+};
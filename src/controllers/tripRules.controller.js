@@ -0,0 +1,75 @@
+import tripRulesService from "../services/tripRules.service.js";
+import logger from "../config/logger.js";
+import { ValidationError } from "../utils/customErrors.js";
+
+/**
+ * Rules of a trip and the caller's acceptance
+ * GET /api/groups/:groupId/rules
+ */
+export const getRules = async (req, res, next) => {
+  try {
+    const result = await tripRulesService.getRules(req.params.groupId, req.user.id);
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Get trip rules failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Sets or clears the rules of a trip (admin only)
+ * PUT /api/groups/:groupId/rules
+ */
+export const updateRules = async (req, res, next) => {
+  try {
+    const result = await tripRulesService.updateRules(
+      req.params.groupId,
+      req.user.id,
+      req.body?.rules ?? null
+    );
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Update trip rules failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Accepts the current rules of a trip
+ * POST /api/groups/:groupId/rules/acceptance
+ */
+export const acceptRules = async (req, res, next) => {
+  try {
+    const { version } = req.body || {};
+    if (!Number.isInteger(version)) {
+      return next(new ValidationError("version es requerida (la versión de las normas que se aceptan)"));
+    }
+
+    const result = await tripRulesService.acceptRules(req.params.groupId, req.user.id, version);
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Accept trip rules failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Which members accepted the current rules (admin only)
+ * GET /api/groups/:groupId/rules/acceptances
+ */
+export const getAcceptances = async (req, res, next) => {
+  try {
+    const result = await tripRulesService.getAcceptances(req.params.groupId, req.user.id);
+    res.status(200).json(result);
+  } catch (err) {
+    logger.error(`Get trip rule acceptances failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  getRules,
+  updateRules,
+  acceptRules,
+  getAcceptances,
+};
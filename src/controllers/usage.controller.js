@@ -0,0 +1,76 @@
+import usageService from "../services/usage.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Requests and cost of the user per day
+ * GET /api/me/usage?days=
+ */
+export const getMyUsage = async (req, res, next) => {
+  try {
+    const usage = await usageService.getUserUsage(req.user, req.query.days ?? 30);
+    res.status(200).json({ success: true, data: usage });
+  } catch (err) {
+    logger.error(`Get usage failed for user ${req.user?.id}: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Requests and cost of the calling aggregator per day
+ * GET /api/availability/usage?days=
+ */
+export const getAggregatorUsage = async (req, res, next) => {
+  try {
+    const usage = await usageService.getUsage("api_key", req.aggregator.id, {
+      days: req.query.days ?? 30,
+      dailyCostQuota: req.aggregator.dailyCostQuota,
+    });
+    res.status(200).json({ success: true, data: usage });
+  } catch (err) {
+    logger.error(`Get usage failed for aggregator ${req.aggregator?.id}: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Users and API keys with the highest cost
+ * GET /api/admin/usage?subjectType=&days=&limit=
+ */
+export const listTopConsumers = async (req, res, next) => {
+  try {
+    const result = await usageService.getTopConsumers({
+      subjectType: req.query.subjectType,
+      days: req.query.days ?? 1,
+      limit: req.query.limit ?? 20,
+    });
+    res.status(200).json({ success: true, data: result });
+  } catch (err) {
+    logger.error(`List usage failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Requests and cost of a user or API key per day
+ * GET /api/admin/usage/:subjectType/:subjectId?days=
+ */
+export const getSubjectUsage = async (req, res, next) => {
+  try {
+    const usage = await usageService.getSubjectUsage(
+      req.params.subjectType,
+      req.params.subjectId,
+      req.query.days ?? 30
+    );
+    res.status(200).json({ success: true, data: usage });
+  } catch (err) {
+    logger.error(`Get usage failed for ${req.params.subjectType} ${req.params.subjectId}: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  getMyUsage,
+  getAggregatorUsage,
+  listTopConsumers,
+  getSubjectUsage,
+};
@@ -0,0 +1,62 @@
+import userBlockService from "../services/userBlock.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Blocks a user
+ * POST /api/users/:userId/block
+ */
+export const blockUser = async (req, res, next) => {
+  try {
+    await userBlockService.blockUser(req.user.id, req.params.userId);
+
+    res.status(201).json({
+      success: true,
+      message: "Usuario bloqueado",
+    });
+  } catch (err) {
+    logger.error(`Block user failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Unblocks a user
+ * DELETE /api/users/:userId/block
+ */
+export const unblockUser = async (req, res, next) => {
+  try {
+    await userBlockService.unblockUser(req.user.id, req.params.userId);
+
+    res.status(200).json({
+      success: true,
+      message: "Usuario desbloqueado",
+    });
+  } catch (err) {
+    logger.error(`Unblock user failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Lists the users you blocked
+ * GET /api/me/blocks
+ */
+export const getBlockedUsers = async (req, res, next) => {
+  try {
+    const users = await userBlockService.getBlockedUsers(req.user.id);
+
+    res.status(200).json({
+      success: true,
+      data: users,
+    });
+  } catch (err) {
+    logger.error(`Get blocked users failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  blockUser,
+  unblockUser,
+  getBlockedUsers,
+};
@@ -0,0 +1,25 @@
+import userReportService from "../services/userReport.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Reports a user or a trip
+ * POST /api/reports
+ */
+export const createReport = async (req, res, next) => {
+  try {
+    const report = await userReportService.createReport(req.user.id, req.body);
+
+    res.status(201).json({
+      success: true,
+      data: report,
+      message: "Reporte enviado. Nuestro equipo lo revisará",
+    });
+  } catch (err) {
+    logger.error(`Create report failed: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  createReport,
+};
@@ -5,9 +5,15 @@ import reviewRepository from "../repository/review.repository.js";
 import reviewLikeRepository from "../repository/reviewLike.repository.js";
 import listRepository from "../repository/list.repository.js";
 import gamificationService from "../services/gamification.service.js";
+import trustScoreService from "../services/trustScore.service.js";
+import tripReviewService from "../services/tripReview.service.js";
+import profileService from "../services/profile.service.js";
+import userBlockService from "../services/userBlock.service.js";
+import accountService from "../services/account.service.js";
 import UserFollowerRepository from "../repository/userFollower.repository.js";
 import logger from "../config/logger.js";
-import { ValidationError } from "../utils/customErrors.js";
+import { AuthorizationError, NotFoundError, ValidationError } from "../utils/customErrors.js";
+import { getUserAge } from "../utils/ageVerification.js";
 import { validateAvatarFile, saveAvatarFile, getAvatarUrl, deleteFile } from "../utils/fileUpload.js";
 import path from "path";
 
@@ -37,7 +43,7 @@ export const searchUsers = async (req, res, next) => {
 
     // Buscar usuarios por nombre o email
     const userRepo = new UserRepository();
-    const users = await userRepo.searchByQuery(query.trim(), 20);
+    const users = await userRepo.searchByQuery(query.trim(), 20, req.user.id);
 
     // Formatear respuesta con stats si están disponibles
     const formattedUsers = await Promise.all(
@@ -54,14 +60,7 @@ export const searchUsers = async (req, res, next) => {
         }
 
         return {
-          id: user.id,
-          email: user.email,
-          name: user.name,
-          age: user.age,
-          profilePicture: user.profilePicture,
-          isEmailConfirmed: user.isEmailConfirmed,
-          createdAt: user.createdAt,
-          updatedAt: user.updatedAt,
+          ...profileService.formatForViewer(user, req.user.id),
           stats,
         };
       })
@@ -104,13 +103,12 @@ export const getUserByEmail = async (req, res, next) => {
     const user = await userRepo.findByEmail(email.trim());
 
     if (!user) {
-      return res.status(404).json({
-        success: false,
-        data: null,
-        message: "Usuario no encontrado",
-      });
+      return next(new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND"));
     }
 
+    // Perfil según la visibilidad elegida por el usuario, como en getUserById
+    const profile = await profileService.getProfile(user.id, req.user.id);
+
     // Obtener stats del usuario si están disponibles
     let stats = null;
     try {
@@ -123,17 +121,26 @@ export const getUserByEmail = async (req, res, next) => {
       // Continuar sin stats si hay error
     }
 
+    let trustScore = null;
+    try {
+      trustScore = (await trustScoreService.getTrustScore(user.id)).score;
+    } catch (trustError) {
+      logger.warn(`Failed to get trust score for user ${user.id}:`, trustError.message);
+    }
+
+    let organizerRating = null;
+    try {
+      organizerRating = await tripReviewService.getOrganizerRating(user.id);
+    } catch (ratingError) {
+      logger.warn(`Failed to get organizer rating for user ${user.id}:`, ratingError.message);
+    }
+
     // Formatear respuesta
     const formattedUser = {
-      id: user.id,
-      email: user.email,
-      name: user.name,
-      age: user.age,
-      profilePicture: user.profilePicture,
-      isEmailConfirmed: user.isEmailConfirmed,
-      createdAt: user.createdAt.toISOString(),
-      updatedAt: user.updatedAt.toISOString(),
+      ...profile,
       stats,
+      trustScore,
+      organizerRating,
     };
 
     logger.info(
@@ -168,18 +175,14 @@ export const getUserFavorites = async (req, res, next) => {
     const uuidRegex =
       /^[0-9a-f]{8}-[0-9a-f]{4}-[1-5][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/i;
     if (!uuidRegex.test(userId)) {
-      throw new ValidationError("ID de usuario inválido");
+      throw new ValidationError("ID de usuario inválido", null, "INVALID_USER_ID");
     }
 
     // Verificar que el usuario existe
     const userRepo = new UserRepository();
     const targetUser = await userRepo.findById(userId);
     if (!targetUser) {
-      return res.status(404).json({
-        success: false,
-        data: null,
-        message: "Usuario no encontrado",
-      });
+      return next(new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND"));
     }
 
     // Nota: Cualquier usuario autenticado puede ver los favoritos de otros usuarios
@@ -236,18 +239,14 @@ export const getUserLists = async (req, res, next) => {
     const uuidRegex =
       /^[0-9a-f]{8}-[0-9a-f]{4}-[1-5][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/i;
     if (!uuidRegex.test(userId)) {
-      throw new ValidationError("ID de usuario inválido");
+      throw new ValidationError("ID de usuario inválido", null, "INVALID_USER_ID");
     }
 
     // Verificar que el usuario existe
     const userRepo = new UserRepository();
     const targetUser = await userRepo.findById(userId);
     if (!targetUser) {
-      return res.status(404).json({
-        success: false,
-        data: null,
-        message: "Usuario no encontrado",
-      });
+      return next(new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND"));
     }
 
     // Nota: Cualquier usuario autenticado puede ver las listas de otros usuarios
@@ -308,19 +307,11 @@ export const getUserById = async (req, res, next) => {
     const uuidRegex =
       /^[0-9a-f]{8}-[0-9a-f]{4}-[1-5][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/i;
     if (!uuidRegex.test(userId)) {
-      throw new ValidationError("ID de usuario inválido");
+      throw new ValidationError("ID de usuario inválido", null, "INVALID_USER_ID");
     }
 
-    // Buscar el usuario
-    const userRepo = new UserRepository();
-    const user = await userRepo.findById(userId);
-    if (!user) {
-      return res.status(404).json({
-        success: false,
-        data: null,
-        message: "Usuario no encontrado",
-      });
-    }
+    // Perfil según la visibilidad elegida por el usuario (el dueño lo ve completo)
+    const profile = await profileService.getProfile(userId, req.user.id);
 
     // Obtener stats del usuario si están disponibles
     let stats = null;
@@ -334,17 +325,26 @@ export const getUserById = async (req, res, next) => {
       // Continuar sin stats si hay error
     }
 
+    let trustScore = null;
+    try {
+      trustScore = (await trustScoreService.getTrustScore(userId)).score;
+    } catch (trustError) {
+      logger.warn(`Failed to get trust score for user ${userId}:`, trustError.message);
+    }
+
+    let organizerRating = null;
+    try {
+      organizerRating = await tripReviewService.getOrganizerRating(userId);
+    } catch (ratingError) {
+      logger.warn(`Failed to get organizer rating for user ${userId}:`, ratingError.message);
+    }
+
     // Formatear respuesta
     const formattedUser = {
-      id: user.id,
-      email: user.email,
-      name: user.name,
-      age: user.age,
-      profilePicture: user.profilePicture,
-      isEmailConfirmed: user.isEmailConfirmed,
-      createdAt: user.createdAt.toISOString(),
-      updatedAt: user.updatedAt.toISOString(),
+      ...profile,
       stats,
+      trustScore,
+      organizerRating,
     };
 
     logger.info(
@@ -378,18 +378,14 @@ export const getUserMedia = async (req, res, next) => {
     const uuidRegex =
       /^[0-9a-f]{8}-[0-9a-f]{4}-[1-5][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/i;
     if (!uuidRegex.test(userId)) {
-      throw new ValidationError("ID de usuario inválido");
+      throw new ValidationError("ID de usuario inválido", null, "INVALID_USER_ID");
     }
 
     // Verificar que el usuario existe
     const userRepo = new UserRepository();
     const targetUser = await userRepo.findById(userId);
     if (!targetUser) {
-      return res.status(404).json({
-        success: false,
-        data: null,
-        message: "Usuario no encontrado",
-      });
+      return next(new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND"));
     }
 
     // Obtener los archivos multimedia del usuario (de sus reseñas publicadas)
@@ -438,18 +434,14 @@ export const getUserReviews = async (req, res, next) => {
     const uuidRegex =
       /^[0-9a-f]{8}-[0-9a-f]{4}-[1-5][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/i;
     if (!uuidRegex.test(userId)) {
-      throw new ValidationError("ID de usuario inválido");
+      throw new ValidationError("ID de usuario inválido", null, "INVALID_USER_ID");
     }
 
     // Verificar que el usuario existe
     const userRepo = new UserRepository();
     const targetUser = await userRepo.findById(userId);
     if (!targetUser) {
-      return res.status(404).json({
-        success: false,
-        data: null,
-        message: "Usuario no encontrado",
-      });
+      return next(new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND"));
     }
 
     // Obtener las reseñas del usuario
@@ -517,18 +509,14 @@ export const getUserReviewStats = async (req, res, next) => {
     const uuidRegex =
       /^[0-9a-f]{8}-[0-9a-f]{4}-[1-5][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/i;
     if (!uuidRegex.test(userId)) {
-      throw new ValidationError("ID de usuario inválido");
+      throw new ValidationError("ID de usuario inválido", null, "INVALID_USER_ID");
     }
 
     // Verificar que el usuario existe
     const userRepo = new UserRepository();
     const targetUser = await userRepo.findById(userId);
     if (!targetUser) {
-      return res.status(404).json({
-        success: false,
-        data: null,
-        message: "Usuario no encontrado",
-      });
+      return next(new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND"));
     }
 
     // Obtener estadísticas de reseñas del usuario
@@ -566,18 +554,18 @@ export const followUser = async (req, res, next) => {
     const uuidRegex =
       /^[0-9a-f]{8}-[0-9a-f]{4}-[1-5][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/i;
     if (!uuidRegex.test(followedId)) {
-      throw new ValidationError("ID de usuario inválido");
+      throw new ValidationError("ID de usuario inválido", null, "INVALID_USER_ID");
     }
 
     // Verificar que el usuario a seguir existe
     const userRepo = new UserRepository();
     const targetUser = await userRepo.findById(followedId);
     if (!targetUser) {
-      return res.status(404).json({
-        success: false,
-        data: null,
-        message: "Usuario no encontrado",
-      });
+      return next(new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND"));
+    }
+
+    if (await userBlockService.isBlockedBetween(followerId, followedId)) {
+      throw new AuthorizationError("No puedes seguir a este usuario");
     }
 
     // Crear la relación de seguimiento
@@ -595,18 +583,10 @@ export const followUser = async (req, res, next) => {
     });
   } catch (err) {
     if (err.message === "Ya sigues a este usuario") {
-      return res.status(400).json({
-        success: false,
-        data: null,
-        message: err.message,
-      });
+      return next(new ValidationError(err.message, null, "ALREADY_FOLLOWING"));
     }
     if (err.message === "No puedes seguirte a ti mismo") {
-      return res.status(400).json({
-        success: false,
-        data: null,
-        message: err.message,
-      });
+      return next(new ValidationError(err.message, null, "SELF_FOLLOW"));
     }
     logger.error(`Follow user endpoint failed: ${err.message}`);
     next(err);
@@ -630,7 +610,7 @@ export const unfollowUser = async (req, res, next) => {
     const uuidRegex =
       /^[0-9a-f]{8}-[0-9a-f]{4}-[1-5][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/i;
     if (!uuidRegex.test(followedId)) {
-      throw new ValidationError("ID de usuario inválido");
+      throw new ValidationError("ID de usuario inválido", null, "INVALID_USER_ID");
     }
 
     // Eliminar la relación de seguimiento
@@ -638,11 +618,7 @@ export const unfollowUser = async (req, res, next) => {
     const success = await followerRepo.unfollow(followerId, followedId);
 
     if (!success) {
-      return res.status(404).json({
-        success: false,
-        data: null,
-        message: "No sigues a este usuario",
-      });
+      return next(new NotFoundError("No sigues a este usuario", "NOT_FOLLOWING"));
     }
 
     logger.info(
@@ -677,12 +653,15 @@ export const isFollowingUser = async (req, res, next) => {
     const uuidRegex =
       /^[0-9a-f]{8}-[0-9a-f]{4}-[1-5][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/i;
     if (!uuidRegex.test(followedId)) {
-      throw new ValidationError("ID de usuario inválido");
+      throw new ValidationError("ID de usuario inválido", null, "INVALID_USER_ID");
     }
 
     // Verificar la relación de seguimiento
     const followerRepo = new UserFollowerRepository();
-    const isFollowing = await followerRepo.isFollowing(followerId, followedId);
+    const [isFollowing, followsYou] = await Promise.all([
+      followerRepo.isFollowing(followerId, followedId),
+      followerRepo.isFollowing(followedId, followerId),
+    ]);
 
     logger.info(
       `Is following user endpoint completed - follower: ${followerId}, target: ${followedId}, result: ${isFollowing}`
@@ -690,7 +669,8 @@ export const isFollowingUser = async (req, res, next) => {
 
     res.status(200).json({
       success: true,
-      data: { isFollowing },
+      // Los seguimientos mutuos cuentan como amistad
+      data: { isFollowing, followsYou, isFriend: isFollowing && followsYou },
       message: null,
     });
   } catch (err) {
@@ -715,18 +695,14 @@ export const getUserFollowStats = async (req, res, next) => {
     const uuidRegex =
       /^[0-9a-f]{8}-[0-9a-f]{4}-[1-5][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/i;
     if (!uuidRegex.test(userId)) {
-      throw new ValidationError("ID de usuario inválido");
+      throw new ValidationError("ID de usuario inválido", null, "INVALID_USER_ID");
     }
 
     // Verificar que el usuario existe
     const userRepo = new UserRepository();
     const targetUser = await userRepo.findById(userId);
     if (!targetUser) {
-      return res.status(404).json({
-        success: false,
-        data: null,
-        message: "Usuario no encontrado",
-      });
+      return next(new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND"));
     }
 
     // Obtener estadísticas de seguimiento
@@ -766,18 +742,14 @@ export const getUserFollowers = async (req, res, next) => {
     const uuidRegex =
       /^[0-9a-f]{8}-[0-9a-f]{4}-[1-5][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/i;
     if (!uuidRegex.test(userId)) {
-      throw new ValidationError("ID de usuario inválido");
+      throw new ValidationError("ID de usuario inválido", null, "INVALID_USER_ID");
     }
 
     // Verificar que el usuario existe
     const userRepo = new UserRepository();
     const targetUser = await userRepo.findById(userId);
     if (!targetUser) {
-      return res.status(404).json({
-        success: false,
-        data: null,
-        message: "Usuario no encontrado",
-      });
+      return next(new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND"));
     }
 
     // Obtener lista de seguidores
@@ -789,7 +761,7 @@ export const getUserFollowers = async (req, res, next) => {
       id: follow.follower.id,
       email: follow.follower.email,
       name: follow.follower.name,
-      age: follow.follower.age,
+      age: getUserAge(follow.follower),
       profilePicture: follow.follower.profilePicture,
       isEmailConfirmed: follow.follower.isEmailConfirmed,
       followedAt: follow.createdAt,
@@ -828,18 +800,14 @@ export const getUserFollowing = async (req, res, next) => {
     const uuidRegex =
       /^[0-9a-f]{8}-[0-9a-f]{4}-[1-5][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/i;
     if (!uuidRegex.test(userId)) {
-      throw new ValidationError("ID de usuario inválido");
+      throw new ValidationError("ID de usuario inválido", null, "INVALID_USER_ID");
     }
 
     // Verificar que el usuario existe
     const userRepo = new UserRepository();
     const targetUser = await userRepo.findById(userId);
     if (!targetUser) {
-      return res.status(404).json({
-        success: false,
-        data: null,
-        message: "Usuario no encontrado",
-      });
+      return next(new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND"));
     }
 
     // Obtener lista de usuarios seguidos
@@ -851,7 +819,7 @@ export const getUserFollowing = async (req, res, next) => {
       id: follow.followed.id,
       email: follow.followed.email,
       name: follow.followed.name,
-      age: follow.followed.age,
+      age: getUserAge(follow.followed),
       profilePicture: follow.followed.profilePicture,
       isEmailConfirmed: follow.followed.isEmailConfirmed,
       followedAt: follow.createdAt,
@@ -881,40 +849,12 @@ export const updateUserProfile = async (req, res, next) => {
   logger.info(`Update user profile endpoint called for user: ${userId}`);
 
   try {
-    const { name, age } = req.body;
-
-    // Validaciones
-    if (name !== undefined) {
-      if (typeof name !== "string" || name.trim().length === 0) {
-        throw new ValidationError("El nombre debe ser una cadena no vacía");
-      }
-      if (name.length > 30) {
-        throw new ValidationError("El nombre no puede exceder 30 caracteres");
-      }
-    }
-
-    if (age !== undefined && age !== null) {
-      const ageNum = parseInt(age);
-      if (isNaN(ageNum) || ageNum < 13 || ageNum > 120) {
-        throw new ValidationError("La edad debe estar entre 13 y 120 años");
-      }
-    }
-
-    // Actualizar usuario
-    const userRepo = new UserRepository();
-    const updateData = {};
-    if (name !== undefined) updateData.name = name.trim();
-    if (age !== undefined) updateData.age = age === null ? null : parseInt(age);
-
-    logger.info(`Updating user ${userId} with data:`, updateData);
-    await userRepo.updateUser(userId, updateData);
-
-    // Obtener usuario actualizado
-    const updatedUser = await userRepo.findUserById(userId);
-    logger.info(`User profile updated successfully for user: ${userId}, new values:`, {
-      name: updatedUser.name,
-      age: updatedUser.age,
-      profilePicture: updatedUser.profilePicture
+    const { name, age, preferredCurrency, dateOfBirth } = req.body;
+    const updatedUser = await profileService.updateProfile(userId, {
+      name,
+      age,
+      preferredCurrency,
+      dateOfBirth,
     });
 
     res.status(200).json({
@@ -924,6 +864,8 @@ export const updateUserProfile = async (req, res, next) => {
         email: updatedUser.email,
         name: updatedUser.name,
         age: updatedUser.age,
+        dateOfBirth: updatedUser.dateOfBirth,
+        preferredCurrency: updatedUser.preferredCurrency,
         profilePicture: updatedUser.profilePicture,
       },
       message: "Perfil actualizado correctamente",
@@ -934,6 +876,107 @@ export const updateUserProfile = async (req, res, next) => {
   }
 };
 
+/**
+ * Obtiene el perfil completo del usuario autenticado
+ * GET /api/users/me
+ */
+export const getMyProfile = async (req, res, next) => {
+  try {
+    const profile = await profileService.getOwnProfile(req.user.id);
+
+    res.status(200).json({
+      success: true,
+      data: profile,
+    });
+  } catch (err) {
+    logger.error(`Get own profile failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Actualiza el perfil del usuario autenticado (datos, preferencias de viaje y visibilidad)
+ * PATCH /api/users/me
+ */
+export const updateMyProfile = async (req, res, next) => {
+  try {
+    const {
+      name,
+      age,
+      dateOfBirth,
+      preferredCurrency,
+      bio,
+      homeCity,
+      languages,
+      locale,
+      travelPreferences,
+      visibility,
+    } = req.body;
+    const profile = await profileService.updateProfile(req.user.id, {
+      name,
+      age,
+      dateOfBirth,
+      preferredCurrency,
+      bio,
+      homeCity,
+      languages,
+      locale,
+      travelPreferences,
+      visibility,
+    });
+
+    res.status(200).json({
+      success: true,
+      data: profile,
+      message: "Perfil actualizado correctamente",
+    });
+  } catch (err) {
+    logger.error(`Update own profile failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Da de baja la cuenta del usuario autenticado; los datos se borran o anonimizan
+ * en segundo plano al terminar el período de gracia
+ * DELETE /api/users/me
+ */
+export const deleteMyAccount = async (req, res, next) => {
+  try {
+    const result = await accountService.requestDeletion(req.user.id, {
+      password: req.body?.password,
+      sessionId: req.user.sessionId,
+    });
+
+    res.status(200).json({
+      success: true,
+      data: result,
+      message: "Tu cuenta fue dada de baja",
+    });
+  } catch (err) {
+    logger.error(`Delete own account failed: ${err.message}`);
+    next(err);
+  }
+};
+
+/**
+ * Descarga en JSON todos los datos guardados del usuario autenticado
+ * GET /api/users/me/export
+ */
+export const exportMyData = async (req, res, next) => {
+  try {
+    const archive = await accountService.exportData(req.user.id);
+
+    const date = archive.exportedAt.slice(0, 10);
+    res.set("Content-Disposition", `attachment; filename="jointravel-export-${date}.json"`);
+    res.set("Cache-Control", "no-store");
+    res.status(200).json({ success: true, data: archive });
+  } catch (err) {
+    logger.error(`Export own data failed: ${err.message}`);
+    next(err);
+  }
+};
+
 /**
  * Sube o actualiza el avatar del usuario
  * POST /api/users/profile/avatar
@@ -945,7 +988,7 @@ export const uploadUserAvatar = async (req, res, next) => {
   try {
     // Validar que se subió un archivo
     if (!req.file) {
-      throw new ValidationError("No se proporcionó ningún archivo");
+      throw new ValidationError("No se proporcionó ningún archivo", null, "FILE_REQUIRED");
     }
 
     // Validar el archivo
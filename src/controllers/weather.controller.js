@@ -0,0 +1,20 @@
+import weatherService from "../services/weather.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Forecast for the destination and dates of a trip
+ * GET /api/trips/:id/weather
+ */
+export const getTripWeather = async (req, res, next) => {
+  try {
+    const weather = await weatherService.getTripWeather(req.params.id);
+    res.status(200).json({ success: true, data: weather });
+  } catch (err) {
+    logger.error(`Get weather failed for trip ${req.params.id}: ${err.message}`);
+    next(err);
+  }
+};
+
+export default {
+  getTripWeather,
+};
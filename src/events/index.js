@@ -0,0 +1,41 @@
+import domainEventService, { DOMAIN_EVENTS } from "../services/domainEvent.service.js";
+import searchService from "../services/search.service.js";
+import followerNotificationService from "../services/followerNotification.service.js";
+import auditService, { AUDIT_ACTIONS } from "../services/audit.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Indexes a new trip for search and, when public, tells the organizer's followers
+ */
+export const onTripCreated = async ({ payload }) => {
+  await searchService.scheduleIndex("trip", payload.groupId);
+  if (payload.isPublic) {
+    await followerNotificationService.queueTripPublished(payload.adminId, payload.groupId);
+  }
+};
+
+/**
+ * Records who joined a trip and how
+ */
+export const onMemberJoined = async ({ payload }) => {
+  await auditService.record({
+    action: AUDIT_ACTIONS.TRIP_MEMBER_JOINED,
+    actorId: payload.addedById ?? null,
+    targetType: "trip",
+    targetId: payload.groupId,
+    metadata: { userId: payload.userId, via: payload.via },
+  });
+};
+
+/**
+ * Subscribes the in-process handlers of every domain event. MessageSent has
+ * none: the request that sends a message notifies the members in real time,
+ * the event is there for consumers on the broker.
+ */
+export const registerEventHandlers = () => {
+  domainEventService.subscribe(DOMAIN_EVENTS.TRIP_CREATED, onTripCreated);
+  domainEventService.subscribe(DOMAIN_EVENTS.MEMBER_JOINED, onMemberJoined);
+  logger.debug("[Events] Handlers registered");
+};
+
+export default registerEventHandlers;
@@ -0,0 +1,11 @@
+import accountService from "../services/account.service.js";
+
+export const ACCOUNT_DELETION_JOB = "accounts.erase";
+
+/**
+ * Erases a closed account once its grace period is over. Accounts already
+ * erased (or deleted some other way) are skipped.
+ */
+export const eraseClosedAccount = async ({ userId }) => {
+  await accountService.eraseAccount(userId);
+};
@@ -0,0 +1,42 @@
+import attachmentRepository from "../repository/attachment.repository.js";
+import attachmentService from "../services/attachment.service.js";
+import malwareScanService from "../services/malwareScan.service.js";
+import logger from "../config/logger.js";
+
+export const ATTACHMENT_SCAN_JOB = "attachment.scan";
+
+/**
+ * Scans an uploaded attachment. A clean file is released (image processing,
+ * avatar update); an infected one is moved to quarantine. Scanner errors are
+ * thrown so the job queue retries them.
+ * @param {Object} payload - { attachmentId }
+ */
+export const scanAttachment = async ({ attachmentId }) => {
+  const attachment = await attachmentRepository.findById(attachmentId);
+  if (!attachment || attachment.status !== "uploaded" || attachment.scanStatus !== "pending") {
+    return;
+  }
+
+  const { clean, signature } = await malwareScanService.scanObject(attachment.storageKey);
+
+  if (clean) {
+    const scanned = await attachmentRepository.update(attachment.id, {
+      scanStatus: "clean",
+      scannedAt: new Date(),
+    });
+    await attachmentService.releaseUpload(scanned);
+    return;
+  }
+
+  logger.warn(`[Attachment Scan] Attachment ${attachment.id} infected (${signature})`);
+  const quarantineKey = await malwareScanService.quarantineObject(
+    attachment.storageKey,
+    attachment.contentType
+  );
+  await attachmentRepository.update(attachment.id, {
+    scanStatus: "quarantined",
+    scanResult: signature,
+    scannedAt: new Date(),
+    storageKey: quarantineKey,
+  });
+};
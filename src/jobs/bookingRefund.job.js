@@ -0,0 +1,14 @@
+import paymentService from "../services/payment.service.js";
+
+export const BOOKING_REFUND_JOB = "payments.refund_booking";
+
+/**
+ * Refunds the payments of a rejected or cancelled join request. Payments
+ * already refunded or canceled are skipped, so a retry only redoes what failed.
+ */
+export const refundBooking = async ({ bookingId }) => {
+  const refund = await paymentService.refundJoinRequest(bookingId);
+  if (refund.status === "failed") {
+    throw new Error(`Refund of booking ${bookingId} failed`);
+  }
+};
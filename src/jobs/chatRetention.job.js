@@ -0,0 +1,12 @@
+import chatRetentionService from "../services/chatRetention.service.js";
+
+export const CHAT_RETENTION_JOB = "chats.retention";
+
+/**
+ * Applies the chat retention chosen by each trip organizer: warns members a
+ * week ahead and deletes the chats that expired. Enqueued daily by the
+ * maintenance task; a rerun the same day finds nothing left to do.
+ */
+export const enforceChatRetention = async () => {
+  await chatRetentionService.enforce();
+};
@@ -0,0 +1,11 @@
+import tenantDomainService from "../services/tenantDomain.service.js";
+
+export const CUSTOM_DOMAIN_CERTIFICATE_JOB = "tenant_domains.certificate";
+
+/**
+ * Requests the certificate of a newly verified custom domain. Hook errors
+ * are thrown so the job queue retries them.
+ */
+export const requestDomainCertificate = async ({ domainId }) => {
+  await tenantDomainService.requestCertificate(domainId);
+};
@@ -0,0 +1,39 @@
+import groupDocumentRepository from "../repository/groupDocument.repository.js";
+import malwareScanService from "../services/malwareScan.service.js";
+import logger from "../config/logger.js";
+
+export const DOCUMENT_SCAN_JOB = "document.scan_version";
+
+/**
+ * Scans a document version with ClamAV. Scanner errors are thrown so the job
+ * queue retries them; an infected file is moved to quarantine.
+ * @param {Object} payload - { versionId }
+ */
+export const scanDocumentVersion = async ({ versionId }) => {
+  const version = await groupDocumentRepository.findVersionById(versionId);
+  if (!version || version.scanStatus !== "pending") {
+    return;
+  }
+
+  const { clean, signature } = await malwareScanService.scanObject(version.storageKey);
+
+  if (clean) {
+    await groupDocumentRepository.updateVersion(version.id, {
+      scanStatus: "clean",
+      scannedAt: new Date(),
+    });
+    return;
+  }
+
+  logger.warn(`[Document Scan] Version ${version.id} infected (${signature})`);
+  const quarantineKey = await malwareScanService.quarantineObject(
+    version.storageKey,
+    version.contentType
+  );
+  await groupDocumentRepository.updateVersion(version.id, {
+    scanStatus: "quarantined",
+    scanResult: signature,
+    scannedAt: new Date(),
+    storageKey: quarantineKey,
+  });
+};
@@ -0,0 +1,11 @@
+import exchangeRateService from "../services/exchangeRate.service.js";
+
+export const EXCHANGE_RATE_REFRESH_JOB = "exchange_rates.refresh";
+
+/**
+ * Refreshes the stored exchange rates. Provider errors are thrown so the job
+ * queue retries them; the previous rates stay in use meanwhile.
+ */
+export const refreshExchangeRates = async () => {
+  await exchangeRateService.refreshRates();
+};
@@ -0,0 +1,11 @@
+import followerNotificationService from "../services/followerNotification.service.js";
+
+export const FOLLOWER_BATCH_JOB = "followers.notify_batch";
+
+/**
+ * Notifies one page of an organizer's followers about a batch of published
+ * trips and queues the next page, so a retry never repeats a finished page
+ */
+export const notifyFollowerBatch = async ({ batchId, afterId = null }) => {
+  await followerNotificationService.deliverBatch(batchId, afterId);
+};
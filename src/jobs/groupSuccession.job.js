@@ -0,0 +1,11 @@
+import groupSuccessionService from "../services/groupSuccession.service.js";
+
+export const GROUP_SUCCESSION_JOB = "group_successions.advance";
+
+/**
+ * Moves a succession on when an offer expires or the voting ends. Jobs of
+ * steps already resolved (accepted, declined, cancelled) do nothing.
+ */
+export const advanceSuccession = async ({ successionId }) => {
+  await groupSuccessionService.advance(successionId);
+};
@@ -0,0 +1,71 @@
+import sharp from "sharp";
+import attachmentRepository from "../repository/attachment.repository.js";
+import s3Client from "../clients/s3.client.js";
+import logger from "../config/logger.js";
+
+export const IMAGE_PROCESSING_JOB = "attachment.process_image";
+
+// Thumbnail widths generated for every uploaded photo
+const THUMBNAIL_SIZES = {
+  small: 150,
+  medium: 480,
+  large: 1080,
+};
+
+const variantKey = (storageKey, name) =>
+  `${storageKey.replace(/\.[^./]+$/, "")}_${name}.webp`;
+
+/**
+ * Strips EXIF/GPS metadata from an uploaded photo and stores its thumbnails.
+ * The photo is not served until this succeeds; when the last attempt fails
+ * the upload is marked as failed.
+ * @param {Object} payload - { attachmentId }
+ * @param {Object} job - { attempts, maxAttempts }
+ */
+export const processImage = async ({ attachmentId }, job) => {
+  const attachment = await attachmentRepository.findById(attachmentId);
+  if (!attachment || attachment.status !== "uploaded") {
+    logger.warn(`[Image Processing] Attachment ${attachmentId} not found or not uploaded, skipping`);
+    return;
+  }
+  if (attachment.scanStatus !== "clean" && attachment.scanStatus !== "skipped") {
+    logger.warn(`[Image Processing] Attachment ${attachmentId} has not passed the antivirus, skipping`);
+    return;
+  }
+
+  try {
+    const original = await s3Client.getObject(attachment.storageKey);
+
+    // rotate() applies the EXIF orientation before the metadata is dropped
+    const stripped = await sharp(original).rotate().toBuffer();
+
+    const variants = {};
+    for (const [name, width] of Object.entries(THUMBNAIL_SIZES)) {
+      const { data, info } = await sharp(original)
+        .rotate()
+        .resize({ width, height: width, fit: "inside", withoutEnlargement: true })
+        .webp({ quality: 80 })
+        .toBuffer({ resolveWithObject: true });
+
+      const key = variantKey(attachment.storageKey, name);
+      await s3Client.putObject(key, data, "image/webp");
+      variants[name] = { key, width: info.width, height: info.height, size: info.size };
+    }
+
+    await s3Client.putObject(attachment.storageKey, stripped, attachment.contentType);
+    await attachmentRepository.update(attachment.id, {
+      variants,
+      metadataStripped: true,
+      processingStatus: "processed",
+      size: stripped.length,
+    });
+  } catch (error) {
+    if (job && job.attempts >= job.maxAttempts) {
+      await attachmentRepository.update(attachment.id, { processingStatus: "failed" });
+      logger.error(`[Image Processing] Attachment ${attachment.id} could not be stripped of its metadata: ${error.message}`);
+    }
+    throw error;
+  }
+
+  logger.info(`[Image Processing] Processed attachment ${attachment.id}`);
+};
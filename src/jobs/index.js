@@ -0,0 +1,43 @@
+import jobQueueService from "../services/jobQueue.service.js";
+import { IMAGE_PROCESSING_JOB, processImage } from "./imageProcessing.job.js";
+import { DOCUMENT_SCAN_JOB, scanDocumentVersion } from "./documentScan.job.js";
+import { ATTACHMENT_SCAN_JOB, scanAttachment } from "./attachmentScan.job.js";
+import { SEARCH_INDEX_JOB, indexSearchDocument } from "./searchIndex.job.js";
+import { LEGAL_EXPORT_JOB, generateLegalExport } from "./legalExport.job.js";
+import { EXCHANGE_RATE_REFRESH_JOB, refreshExchangeRates } from "./exchangeRateRefresh.job.js";
+import { TRIP_PUBLICATION_JOB, publishScheduledTrip } from "./tripPublication.job.js";
+import { FOLLOWER_BATCH_JOB, notifyFollowerBatch } from "./followerNotification.job.js";
+import { PUSH_DIGEST_JOB, sendPushDigest } from "./pushDigest.job.js";
+import { ACCOUNT_DELETION_JOB, eraseClosedAccount } from "./accountDeletion.job.js";
+import { CHAT_RETENTION_JOB, enforceChatRetention } from "./chatRetention.job.js";
+import { CUSTOM_DOMAIN_CERTIFICATE_JOB, requestDomainCertificate } from "./customDomainCertificate.job.js";
+import { GROUP_SUCCESSION_JOB, advanceSuccession } from "./groupSuccession.job.js";
+import { TRIP_RECOMMENDATIONS_JOB, refreshTripRecommendations } from "./tripRecommendation.job.js";
+import { MEMBER_REFUND_JOB, refundRemovedMember } from "./memberRefund.job.js";
+import { BOOKING_REFUND_JOB, refundBooking } from "./bookingRefund.job.js";
+import { RECOMMENDATION_EMAIL_JOB, sendRecommendationEmail } from "./recommendationEmail.job.js";
+
+/**
+ * Registers the handlers of every background job type
+ */
+export const registerJobHandlers = () => {
+  jobQueueService.registerHandler(IMAGE_PROCESSING_JOB, processImage);
+  jobQueueService.registerHandler(DOCUMENT_SCAN_JOB, scanDocumentVersion);
+  jobQueueService.registerHandler(ATTACHMENT_SCAN_JOB, scanAttachment);
+  jobQueueService.registerHandler(SEARCH_INDEX_JOB, indexSearchDocument);
+  jobQueueService.registerHandler(LEGAL_EXPORT_JOB, generateLegalExport);
+  jobQueueService.registerHandler(EXCHANGE_RATE_REFRESH_JOB, refreshExchangeRates);
+  jobQueueService.registerHandler(TRIP_PUBLICATION_JOB, publishScheduledTrip);
+  jobQueueService.registerHandler(FOLLOWER_BATCH_JOB, notifyFollowerBatch);
+  jobQueueService.registerHandler(PUSH_DIGEST_JOB, sendPushDigest);
+  jobQueueService.registerHandler(ACCOUNT_DELETION_JOB, eraseClosedAccount);
+  jobQueueService.registerHandler(CHAT_RETENTION_JOB, enforceChatRetention);
+  jobQueueService.registerHandler(CUSTOM_DOMAIN_CERTIFICATE_JOB, requestDomainCertificate);
+  jobQueueService.registerHandler(GROUP_SUCCESSION_JOB, advanceSuccession);
+  jobQueueService.registerHandler(TRIP_RECOMMENDATIONS_JOB, refreshTripRecommendations);
+  jobQueueService.registerHandler(MEMBER_REFUND_JOB, refundRemovedMember);
+  jobQueueService.registerHandler(BOOKING_REFUND_JOB, refundBooking);
+  jobQueueService.registerHandler(RECOMMENDATION_EMAIL_JOB, sendRecommendationEmail);
+};
+
+export default registerJobHandlers;
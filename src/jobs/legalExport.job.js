@@ -0,0 +1,20 @@
+import legalHoldService from "../services/legalHold.service.js";
+
+export const LEGAL_EXPORT_JOB = "legal.export";
+
+/**
+ * Generates a legal export. Errors are rethrown so the queue retries them;
+ * the export is marked as failed once the last attempt fails.
+ * @param {Object} payload - { exportId }
+ * @param {Object} job
+ */
+export const generateLegalExport = async ({ exportId }, job) => {
+  try {
+    await legalHoldService.generateExport(exportId);
+  } catch (err) {
+    if (job && job.attempts >= job.maxAttempts) {
+      await legalHoldService.failExport(exportId, err.message);
+    }
+    throw err;
+  }
+};
@@ -0,0 +1,16 @@
+import paymentService from "../services/payment.service.js";
+
+export const MEMBER_REFUND_JOB = "payments.refund_removed_member";
+
+/**
+ * Refunds the deposit of a member removed from a group. Payments already
+ * refunded or canceled are skipped, so a retry only redoes what failed.
+ * The payload's bookingId (the request the member joined through, if any)
+ * only ties the job to the booking trace.
+ */
+export const refundRemovedMember = async ({ groupId, userId }) => {
+  const refund = await paymentService.refundGroupMember(groupId, userId);
+  if (refund.status === "failed") {
+    throw new Error(`Refund of member ${userId} in group ${groupId} failed`);
+  }
+};
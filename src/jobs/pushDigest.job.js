@@ -0,0 +1,10 @@
+import pushService from "../services/push.service.js";
+
+export const PUSH_DIGEST_JOB = "push.quiet_hours_digest";
+
+/**
+ * Sends the pushes held during a user's quiet hours once they end
+ */
+export const sendPushDigest = async ({ userId }) => {
+  await pushService.deliverHeld(userId);
+};
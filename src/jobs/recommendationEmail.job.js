@@ -0,0 +1,14 @@
+import recommendationService from "../services/recommendation.service.js";
+
+export const RECOMMENDATION_EMAIL_JOB = "recommendations.monthly_email";
+
+/**
+ * Sends the monthly trip recommendation email of a user. A failed send is
+ * thrown so the queue retries it; the frequency cap only counts sent emails.
+ */
+export const sendRecommendationEmail = async ({ userId }) => {
+  const status = await recommendationService.sendToUser(userId);
+  if (status === "failed") {
+    throw new Error(`Recommendation email of user ${userId} could not be sent`);
+  }
+};
@@ -0,0 +1,11 @@
+import searchService from "../services/search.service.js";
+
+export const SEARCH_INDEX_JOB = "search.index";
+
+/**
+ * Refreshes the search document of an entity (removed when the entity is gone)
+ * @param {Object} payload - { entityType, entityId }
+ */
+export const indexSearchDocument = async ({ entityType, entityId }) => {
+  await searchService.indexEntity(entityType, entityId);
+};
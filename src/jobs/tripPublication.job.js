@@ -0,0 +1,11 @@
+import tripPublicationService from "../services/tripPublication.service.js";
+
+export const TRIP_PUBLICATION_JOB = "trips.publish";
+
+/**
+ * Publishes a trip at its scheduled launch time. Jobs left behind by a
+ * rescheduled or cancelled launch find nothing due and do nothing.
+ */
+export const publishScheduledTrip = async ({ groupId }) => {
+  await tripPublicationService.publishIfDue(groupId);
+};
@@ -0,0 +1,10 @@
+import tripRecommendationService from "../services/tripRecommendation.service.js";
+
+export const TRIP_RECOMMENDATIONS_JOB = "trips.recommendations_refresh";
+
+/**
+ * Ranks the open trips for a user again
+ */
+export const refreshTripRecommendations = async ({ userId }) => {
+  await tripRecommendationService.refreshForUser(userId);
+};
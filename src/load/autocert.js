@@ -0,0 +1,138 @@
+import fs from "node:fs/promises";
+import path from "node:path";
+import tlsModule from "node:tls";
+import acme from "acme-client";
+import logger from "../config/logger.js";
+
+// How often the certificate is checked for a renewal
+const RENEW_CHECK_INTERVAL_MS = 12 * 60 * 60 * 1000;
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+/**
+ * Certificate of the API domains issued and renewed from Let's Encrypt by the
+ * server itself (ACME HTTP-01). The account key and the certificate are kept
+ * in cacheDir so restarts reuse them; challenges are answered from memory by
+ * the plain HTTP listener (see createRedirectServer).
+ * @param {Object} autocertConfig - config.server.tls.autocert
+ * @returns {Object} - { start, stop, getSecureContext, getChallengeResponse }
+ */
+export const createAutocert = ({ domains, email, cacheDir, staging, renewDays }) => {
+  const files = {
+    accountKey: path.join(cacheDir, "account.pem"),
+    cert: path.join(cacheDir, "fullchain.pem"),
+    key: path.join(cacheDir, "privkey.pem"),
+  };
+  // token -> key authorization of the pending HTTP-01 challenges
+  const challenges = new Map();
+  let current = null; // { context, notAfter }
+  let timer = null;
+  let renewing = null;
+
+  const load = async () => {
+    try {
+      const [cert, key] = await Promise.all([fs.readFile(files.cert), fs.readFile(files.key)]);
+      const { notAfter, domains: covered } = acme.crypto.readCertificateInfo(cert);
+      const names = new Set([covered.commonName, ...covered.altNames]);
+      // A cached certificate of another domain list is issued again
+      if (!domains.every((domain) => names.has(domain))) {
+        return null;
+      }
+      return { context: tlsModule.createSecureContext({ cert, key }), notAfter };
+    } catch (error) {
+      if (error.code !== "ENOENT") {
+        logger.error(`Cached certificate could not be read: ${error.message}`);
+      }
+      return null;
+    }
+  };
+
+  const getAccountKey = async () => {
+    try {
+      return await fs.readFile(files.accountKey);
+    } catch (error) {
+      if (error.code !== "ENOENT") {
+        throw error;
+      }
+      const accountKey = await acme.crypto.createPrivateKey();
+      await fs.writeFile(files.accountKey, accountKey, { mode: 0o600 });
+      return accountKey;
+    }
+  };
+
+  const issue = async () => {
+    await fs.mkdir(cacheDir, { recursive: true });
+    const client = new acme.Client({
+      directoryUrl: staging ? acme.directory.letsencrypt.staging : acme.directory.letsencrypt.production,
+      accountKey: await getAccountKey(),
+    });
+    const [key, csr] = await acme.crypto.createCsr({ commonName: domains[0], altNames: domains });
+    const cert = await client.auto({
+      csr,
+      email,
+      termsOfServiceAgreed: true,
+      challengePriority: ["http-01"],
+      challengeCreateFn: async (authz, challenge, keyAuthorization) => {
+        challenges.set(challenge.token, keyAuthorization);
+      },
+      challengeRemoveFn: async (authz, challenge) => {
+        challenges.delete(challenge.token);
+      },
+    });
+    await fs.writeFile(files.key, key, { mode: 0o600 });
+    await fs.writeFile(files.cert, cert);
+    logger.info(`TLS certificate issued for ${domains.join(", ")}`);
+  };
+
+  const isDue = () => !current || current.notAfter.getTime() - Date.now() < renewDays * DAY_MS;
+
+  // Issues a certificate when none is cached or it expires within renewDays
+  const renewIfDue = async () => {
+    current = current || (await load());
+    if (!isDue()) {
+      return;
+    }
+    renewing = renewing || issue().finally(() => (renewing = null));
+    await renewing;
+    current = await load();
+  };
+
+  return {
+    /**
+     * Loads the cached certificate, issuing one first when missing or due.
+     * A failed renewal keeps a still valid certificate and is retried later.
+     */
+    async start() {
+      try {
+        await renewIfDue();
+      } catch (error) {
+        if (!current || current.notAfter <= new Date()) {
+          throw new Error(`TLS certificate for ${domains.join(", ")} could not be issued: ${error.message}`);
+        }
+        logger.error(`TLS certificate renewal failed, keeping the current one: ${error.message}`);
+      }
+      timer = setInterval(() => {
+        renewIfDue().catch((error) => logger.error(`TLS certificate renewal failed: ${error.message}`));
+      }, RENEW_CHECK_INTERVAL_MS);
+      timer.unref();
+    },
+
+    stop() {
+      clearInterval(timer);
+    },
+
+    /**
+     * @returns {tls.SecureContext|null} null until a certificate is loaded
+     */
+    getSecureContext() {
+      return current?.context || null;
+    },
+
+    /**
+     * @param {string} token
+     * @returns {string|null} Key authorization of a pending challenge
+     */
+    getChallengeResponse(token) {
+      return challenges.get(token) || null;
+    },
+  };
+};
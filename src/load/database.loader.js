@@ -2,8 +2,17 @@ import logger from "../config/logger.js";
 import { AppDataSource } from "./typeorm.loader.js";
 import { createDatabase } from "typeorm-extension";
 import seedDatabase from "./seed.loader.js";
+import config from "../config/index.js";
 
-export default async function connectDB(maxRetries = 5, retryDelay = 2000) {
+/**
+ * Creates the database if missing, connects (syncing the schema) and seeds it
+ * @param {Object} options - { maxRetries, retryDelay, seed }
+ */
+export default async function connectDB({
+  maxRetries = 5,
+  retryDelay = 2000,
+  seed = config.db.seedOnStart,
+} = {}) {
   let retries = 0;
 
   while (retries < maxRetries) {
@@ -27,7 +36,9 @@ export default async function connectDB(maxRetries = 5, retryDelay = 2000) {
       logger.info("Database connected successfully");
 
       // Seed the database with initial data
-      await seedDatabase();
+      if (seed) {
+        await seedDatabase();
+      }
 
       return;
     } catch (err) {
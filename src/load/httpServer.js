@@ -0,0 +1,172 @@
+import fs from "node:fs";
+import path from "node:path";
+import http from "node:http";
+import https from "node:https";
+import tlsModule from "node:tls";
+import logger from "../config/logger.js";
+
+// How often certificate files are checked for a renewal
+const CERT_CHECK_INTERVAL_MS = 60 * 60 * 1000;
+const ACME_CHALLENGE_PREFIX = "/.well-known/acme-challenge/";
+const ACME_TOKEN_REGEX = /^[A-Za-z0-9_-]+$/;
+const SERVERNAME_REGEX = /^(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$/;
+
+const readCertificate = ({ certPath, keyPath }) => ({
+  cert: fs.readFileSync(certPath),
+  key: fs.readFileSync(keyPath),
+});
+
+const modifiedAt = ({ certPath, keyPath }) =>
+  [certPath, keyPath].map((file) => fs.statSync(file).mtimeMs).join(":");
+
+/**
+ * SNI handler serving the certificates of tenant custom domains from
+ * <customCertsDir>/<hostname>/, reloaded when renewed. Other names (and
+ * domains without a certificate yet) get the default certificate.
+ * @param {string|undefined} customCertsDir
+ * @param {Function} getDefaultContext - () => SecureContext, or null for the server's own
+ * @returns {Function} - (servername, callback)
+ */
+const createSniCallback = (customCertsDir, getDefaultContext = () => null) => {
+  // servername -> { version, context }
+  const contexts = new Map();
+  return (servername, callback) => {
+    const name = String(servername || "").toLowerCase();
+    if (!customCertsDir || !SERVERNAME_REGEX.test(name)) {
+      return callback(null, getDefaultContext());
+    }
+    const files = {
+      certPath: path.join(customCertsDir, name, "fullchain.pem"),
+      keyPath: path.join(customCertsDir, name, "privkey.pem"),
+    };
+    try {
+      const version = modifiedAt(files);
+      let cached = contexts.get(name);
+      if (cached?.version !== version) {
+        cached = { version, context: tlsModule.createSecureContext(readCertificate(files)) };
+        contexts.set(name, cached);
+      }
+      callback(null, cached.context);
+    } catch (error) {
+      if (error.code !== "ENOENT") {
+        logger.error(`Certificate of ${name} could not be loaded: ${error.message}`);
+      }
+      contexts.delete(name);
+      callback(null, getDefaultContext());
+    }
+  };
+};
+
+/**
+ * Server for the API and sockets: plain HTTP, or HTTPS when TLS is enabled.
+ * The certificate comes from autocert when given, or else from the
+ * certificate files, reloaded without a restart when renewed. Custom domains
+ * of tenants get their own by SNI. HTTP/2 is left to a proxy in front:
+ * Express does not run on Node's HTTP/2 server.
+ * @param {Function} app - Request handler
+ * @param {Object} serverConfig - config.server
+ * @param {Object|null} autocert - From createAutocert
+ * @returns {http.Server|https.Server}
+ */
+export const createHttpServer = (app, { tls }, autocert = null) => {
+  if (!tls.enabled) {
+    return http.createServer(app);
+  }
+  if (autocert) {
+    const SNICallback = createSniCallback(tls.customCertsDir, () => autocert.getSecureContext());
+    return https.createServer({ SNICallback }, app);
+  }
+  if (!tls.certPath || !tls.keyPath) {
+    throw new Error("TLS_CERT_PATH and TLS_KEY_PATH (or TLS_AUTOCERT_DOMAINS) are required when TLS_ENABLED=true");
+  }
+
+  const options = {
+    ...readCertificate(tls),
+    ...(tls.customCertsDir && { SNICallback: createSniCallback(tls.customCertsDir) }),
+  };
+  const server = https.createServer(options, app);
+
+  let version = modifiedAt(tls);
+  const timer = setInterval(() => {
+    try {
+      const current = modifiedAt(tls);
+      if (current !== version) {
+        server.setSecureContext(readCertificate(tls));
+        version = current;
+        logger.info("TLS certificate reloaded");
+      }
+    } catch (error) {
+      logger.error(`TLS certificate reload failed, keeping the current one: ${error.message}`);
+    }
+  }, CERT_CHECK_INTERVAL_MS);
+  timer.unref();
+  server.on("close", () => clearInterval(timer));
+  return server;
+};
+
+/**
+ * Plain HTTP listener next to the TLS one: answers ACME HTTP-01 challenges of
+ * autocert, or else from the webroot (so certbot and similar clients can issue
+ * and renew the certificate), and redirects everything else to HTTPS.
+ * @param {Object} serverConfig - config.server
+ * @param {Object|null} autocert - From createAutocert
+ * @returns {http.Server}
+ */
+export const createRedirectServer = ({ port, tls }, autocert = null) =>
+  http.createServer((req, res) => {
+    const url = new URL(req.url, "http://localhost");
+
+    if (url.pathname.startsWith(ACME_CHALLENGE_PREFIX)) {
+      const token = url.pathname.slice(ACME_CHALLENGE_PREFIX.length);
+      const keyAuthorization = autocert?.getChallengeResponse(token);
+      if (keyAuthorization) {
+        res.writeHead(200, { "Content-Type": "text/plain" });
+        res.end(keyAuthorization);
+        return;
+      }
+      if (tls.acmeWebroot && ACME_TOKEN_REGEX.test(token)) {
+        const file = path.join(tls.acmeWebroot, ".well-known", "acme-challenge", token);
+        fs.readFile(file, (error, content) => {
+          res.writeHead(error ? 404 : 200, { "Content-Type": "text/plain" });
+          res.end(error ? "" : content);
+        });
+        return;
+      }
+    }
+
+    const host = tls.publicHost || (req.headers.host || "").replace(/:\d+$/, "");
+    if (!host) {
+      res.writeHead(400).end();
+      return;
+    }
+    const publicPort = tls.publicPort || port;
+    const authority = publicPort === 443 ? host : `${host}:${publicPort}`;
+    // 308 keeps the method and body of non-GET requests
+    res.writeHead(308, { Location: `https://${authority}${url.pathname}${url.search}` });
+    res.end();
+  });
+
+/**
+ * @param {http.Server} server
+ * @param {number} port
+ * @param {string} host
+ * @returns {Promise<http.Server>}
+ */
+export const listen = (server, port, host) =>
+  new Promise((resolve, reject) => {
+    server.once("error", reject);
+    server.listen(port, host, () => {
+      server.off("error", reject);
+      resolve(server);
+    });
+  });
+
+/**
+ * Closes a server, dropping idle keep-alive connections so it does not wait on them
+ * @param {http.Server} server
+ */
+export const close = (server) =>
+  new Promise((resolve) => {
+    server.close(() => resolve());
+    server.closeIdleConnections?.();
+  });
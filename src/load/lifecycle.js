@@ -0,0 +1,261 @@
+import logger from "../config/logger.js";
+import connectDB from "./database.loader.js";
+import { AppDataSource } from "./typeorm.loader.js";
+import redisClient from "../clients/redis.client.js";
+
+// Time given to running jobs to finish on shutdown; unfinished ones are
+// released as stale and retried by the next worker
+const JOBS_DRAIN_TIMEOUT_MS = 30 * 1000;
+// Longest wait for the logger to write its last lines
+const LOGGER_FLUSH_TIMEOUT_MS = 2000;
+
+/**
+ * Registry of the components of a process that have to be opened and closed:
+ * connections, pollers, listeners. Each one declares the components it needs;
+ * start() brings up the requested ones after their dependencies, and stop()
+ * tears them down in reverse order.
+ *
+ * It does not wire services: services and repositories stay module
+ * singletons imported where they are used. They open nothing on import, so
+ * only what has a start or stop hook is registered here.
+ */
+export class Lifecycle {
+  constructor() {
+    this.definitions = new Map();
+    this.instances = new Map();
+    this.starting = new Map();
+    this.startOrder = [];
+    this.stopping = null;
+  }
+
+  /**
+   * @param {string} name
+   * @param {Object} definition - { deps: [names], start: async (deps) => instance, stop: async (instance) => void }
+   * @returns {Lifecycle}
+   */
+  register(name, { deps = [], start = async () => null, stop = null } = {}) {
+    if (this.definitions.has(name)) {
+      throw new Error(`Component ${name} is already registered`);
+    }
+    this.definitions.set(name, { deps, start, stop });
+    return this;
+  }
+
+  /**
+   * Instance of a started component
+   * @param {string} name
+   */
+  get(name) {
+    if (!this.instances.has(name)) {
+      throw new Error(`Component ${name} has not been started`);
+    }
+    return this.instances.get(name);
+  }
+
+  /**
+   * Starts components and, first, the components they depend on. A component
+   * needed by several others starts once.
+   * @param {...string} names
+   */
+  async start(...names) {
+    for (const name of names) {
+      await this.startComponent(name, []);
+    }
+  }
+
+  async startComponent(name, path) {
+    if (this.instances.has(name)) {
+      return this.instances.get(name);
+    }
+    if (path.includes(name)) {
+      throw new Error(`Circular dependency: ${[...path, name].join(" -> ")}`);
+    }
+    const definition = this.definitions.get(name);
+    if (!definition) {
+      throw new Error(`Unknown component ${name}`);
+    }
+    if (!this.starting.has(name)) {
+      this.starting.set(
+        name,
+        (async () => {
+          const deps = {};
+          for (const dep of definition.deps) {
+            deps[dep] = await this.startComponent(dep, [...path, name]);
+          }
+          const instance = await definition.start(deps);
+          this.instances.set(name, instance);
+          this.startOrder.push(name);
+          return instance;
+        })()
+      );
+    }
+    return await this.starting.get(name);
+  }
+
+  /**
+   * Stops every started component, dependents first. A failing stop hook is
+   * logged and the rest still run. Calling it again returns the same promise.
+   */
+  async stop() {
+    if (!this.stopping) {
+      this.stopping = (async () => {
+        for (const name of [...this.startOrder].reverse()) {
+          const { stop } = this.definitions.get(name);
+          if (!stop) {
+            continue;
+          }
+          try {
+            await stop(this.instances.get(name));
+          } catch (error) {
+            // The logger may already be closed when its own hook fails
+            console.error(`Failed to stop ${name}: ${error.message}`);
+          }
+        }
+      })();
+    }
+    return await this.stopping;
+  }
+
+  /**
+   * Stops every component and exits on SIGTERM/SIGINT
+   * @param {Function} onSignal - Called with the signal before stopping
+   */
+  stopOnSignals(onSignal = () => {}) {
+    const handler = async (signal) => {
+      onSignal(signal);
+      await this.stop();
+      process.exit(0);
+    };
+    process.once("SIGTERM", () => handler("SIGTERM"));
+    process.once("SIGINT", () => handler("SIGINT"));
+  }
+}
+
+/**
+ * Lifecycle with the components shared by the serve, worker, migrate and seed
+ * commands. Commands register their own on top (e.g. the HTTP server). Search,
+ * jobs, usage, analyticsEvents, scheduler and events are imported when started
+ * so migrate and seed do not load them.
+ * @param {Object} options - { seed: load reference data on connect,
+ *                             forceJobs: run the job worker even with JOBS_WORKER_ENABLED=false }
+ * @returns {Lifecycle}
+ */
+export const createAppLifecycle = ({ seed, forceJobs = false } = {}) =>
+  new Lifecycle()
+    .register("logger", {
+      start: async () => logger,
+      stop: (instance) =>
+        new Promise((resolve) => {
+          const timer = setTimeout(resolve, LOGGER_FLUSH_TIMEOUT_MS);
+          instance.on("finish", () => {
+            clearTimeout(timer);
+            resolve();
+          });
+          instance.end();
+        }),
+    })
+    .register("database", {
+      deps: ["logger"],
+      start: async () => {
+        await connectDB({ seed });
+        return AppDataSource;
+      },
+      stop: async (dataSource) => {
+        if (dataSource.isInitialized) {
+          await dataSource.destroy();
+          logger.info("Database connection closed");
+        }
+      },
+    })
+    .register("redis", {
+      deps: ["logger"],
+      // Connects on the first command
+      start: async () => redisClient,
+      stop: async (client) => client.disconnect(),
+    })
+    .register("realtime", {
+      deps: ["database"],
+      // Sends events published by other processes to the sockets and long polls held here
+      start: async () => {
+        const { default: realtimeHub } = await import("../socket/realtime.js");
+        await realtimeHub.start();
+        return realtimeHub;
+      },
+      stop: async (realtimeHub) => realtimeHub.stop(),
+    })
+    .register("search", {
+      deps: ["database"],
+      start: async () => {
+        const { default: searchService } = await import("../services/search.service.js");
+        await searchService.init();
+        return searchService;
+      },
+    })
+    .register("jobs", {
+      deps: ["database", "redis", "search"],
+      start: async () => {
+        const { default: jobQueueService } = await import("../services/jobQueue.service.js");
+        const { registerJobHandlers } = await import("../jobs/index.js");
+        registerJobHandlers();
+        jobQueueService.start({ force: forceJobs });
+        return jobQueueService;
+      },
+      stop: async (queue) => {
+        queue.stop();
+        if (!(await queue.drain(JOBS_DRAIN_TIMEOUT_MS))) {
+          logger.warn("[Jobs] Stopped with jobs still running");
+        }
+      },
+    })
+    .register("usage", {
+      deps: ["database"],
+      // Buffered request counters are written on an interval and once more on shutdown
+      start: async () => {
+        const { default: usageService } = await import("../services/usage.service.js");
+        usageService.start();
+        return usageService;
+      },
+      stop: async (usageService) => {
+        await usageService.stop().catch((error) => {
+          logger.warn(`[Usage] Counters lost on shutdown: ${error.message}`);
+        });
+      },
+    })
+    .register("analyticsEvents", {
+      deps: ["redis"],
+      // Buffered client events are written to the warehouse on an interval and once more on shutdown
+      start: async () => {
+        const { default: analyticsEventService } = await import("../services/analyticsEvent.service.js");
+        analyticsEventService.start();
+        return analyticsEventService;
+      },
+      stop: async (analyticsEventService) => {
+        await analyticsEventService.stop().catch((error) => {
+          logger.warn(`[AnalyticsEvents] Events left unwritten on shutdown: ${error.message}`);
+        });
+      },
+    })
+    .register("scheduler", {
+      deps: ["database"],
+      // Watches for /cron tasks that stopped running
+      start: async () => {
+        const { default: cronService } = await import("../services/cron.service.js");
+        cronService.startWatching();
+        return cronService;
+      },
+      stop: async (cronService) => cronService.stopWatching(),
+    })
+    .register("events", {
+      deps: ["database", "search"],
+      // In-process handlers of domain events, and the outbox relay when a broker is configured
+      start: async () => {
+        const { default: domainEventService } = await import("../services/domainEvent.service.js");
+        const { registerEventHandlers } = await import("../events/index.js");
+        registerEventHandlers();
+        domainEventService.startRelay();
+        return domainEventService;
+      },
+      stop: async (domainEventService) => domainEventService.stopRelay(),
+    });
+
+export default createAppLifecycle;
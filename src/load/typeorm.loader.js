@@ -16,6 +16,24 @@ import Conversation from "../models/conversation.model.js";
 import ChatMessage from "../models/chatMessage.model.js";
 import Group from "../models/group.model.js";
 import GroupMessage from "../models/groupMessage.model.js";
+import GroupMessageRead from "../models/groupMessageRead.model.js";
+import GroupJoinRequest from "../models/groupJoinRequest.model.js";
+import GroupMembership from "../models/groupMembership.model.js";
+import GroupCapacityChange from "../models/groupCapacityChange.model.js";
+import CompanionReference from "../models/companionReference.model.js";
+import LegalHold from "../models/legalHold.model.js";
+import LegalExport from "../models/legalExport.model.js";
+import LegalAccessLog from "../models/legalAccessLog.model.js";
+import ExchangeRate from "../models/exchangeRate.model.js";
+import AgeReview from "../models/ageReview.model.js";
+import Payment from "../models/payment.model.js";
+import TripReview from "../models/tripReview.model.js";
+import TripReviewFlag from "../models/tripReviewFlag.model.js";
+import FollowerNotificationBatch from "../models/followerNotificationBatch.model.js";
+import HeldPushNotification from "../models/heldPushNotification.model.js";
+import UserBlock from "../models/userBlock.model.js";
+import UserReport from "../models/userReport.model.js";
+import AuditLog from "../models/auditLog.model.js";
 import DirectMessage from "../models/directMessage.model.js";
 import Expense from "../models/expense.model.js";
 import Question from "../models/question.model.js";
@@ -23,8 +41,49 @@ import Answer from "../models/answer.model.js";
 import QuestionVote from "../models/questionVote.model.js";
 import AnswerVote from "../models/answerVote.model.js";
 import Notification from "../models/notification.model.js";
+import DeviceToken from "../models/deviceToken.model.js";
+import NotificationPreference from "../models/notificationPreference.model.js";
 import UserRateLimit from "../models/userRateLimit.model.js";
 import UserFollower from "../models/userFollower.model.js";
+import Attachment from "../models/attachment.model.js";
+import Job from "../models/job.model.js";
+import GroupDocument from "../models/groupDocument.model.js";
+import GroupDocumentVersion from "../models/groupDocumentVersion.model.js";
+import RecommendationEmail from "../models/recommendationEmail.model.js";
+import RecommendationClick from "../models/recommendationClick.model.js";
+import SearchDocument from "../models/searchDocument.model.js";
+import PastTrip from "../models/pastTrip.model.js";
+import AffiliatePartner from "../models/affiliatePartner.model.js";
+import AffiliateAttribution from "../models/affiliateAttribution.model.js";
+import AggregatorPartner from "../models/aggregatorPartner.model.js";
+import AggregatorUsage from "../models/aggregatorUsage.model.js";
+import TripAvailability from "../models/tripAvailability.model.js";
+import CronRun from "../models/cronRun.model.js";
+import JobQueuePause from "../models/jobQueuePause.model.js";
+import JobStat from "../models/jobStat.model.js";
+import BookingEvent from "../models/bookingEvent.model.js";
+import PushTemplateOverride from "../models/pushTemplateOverride.model.js";
+import Tenant from "../models/tenant.model.js";
+import TenantDomain from "../models/tenantDomain.model.js";
+import IdempotencyKey from "../models/idempotencyKey.model.js";
+import TripRuleAcceptance from "../models/tripRuleAcceptance.model.js";
+import TripIncident from "../models/tripIncident.model.js";
+import UserIdentity from "../models/userIdentity.model.js";
+import GroupSuccession from "../models/groupSuccession.model.js";
+import GroupSuccessionVote from "../models/groupSuccessionVote.model.js";
+import ApiUsageDaily from "../models/apiUsageDaily.model.js";
+import UserSession from "../models/userSession.model.js";
+import FormSchema from "../models/formSchema.model.js";
+import FormAnswer from "../models/formAnswer.model.js";
+import ModerationRule from "../models/moderationRule.model.js";
+import ModerationRuleHit from "../models/moderationRuleHit.model.js";
+import CalendarFeed from "../models/calendarFeed.model.js";
+import GroupInvite from "../models/groupInvite.model.js";
+import OutboxEvent from "../models/outboxEvent.model.js";
+import BookingTerms from "../models/bookingTerms.model.js";
+import FeatureFlag from "../models/featureFlag.model.js";
+import TripRecommendation from "../models/tripRecommendation.model.js";
+import RealtimeEvent from "../models/realtimeEvent.model.js";
 
 import config from "../config/index.js";
 
@@ -41,6 +100,8 @@ export const AppDataSource = new DataSource({
   entities: [
     Group,
     GroupMessage,
+    GroupMessageRead,
+    GroupJoinRequest,
     User,
     UserAction,
     Level,
@@ -65,6 +126,63 @@ export const AppDataSource = new DataSource({
     UserRateLimit,
     AnswerVote,
     Notification,
+    DeviceToken,
+    NotificationPreference,
+    Attachment,
+    Job,
+    GroupDocument,
+    GroupDocumentVersion,
+    RecommendationEmail,
+    RecommendationClick,
+    SearchDocument,
+    GroupMembership,
+    GroupCapacityChange,
+    CompanionReference,
+    LegalHold,
+    LegalExport,
+    LegalAccessLog,
+    ExchangeRate,
+    AgeReview,
+    Payment,
+    TripReview,
+    TripReviewFlag,
+    FollowerNotificationBatch,
+    HeldPushNotification,
+    UserBlock,
+    UserReport,
+    AuditLog,
+    PastTrip,
+    AffiliatePartner,
+    AffiliateAttribution,
+    AggregatorPartner,
+    AggregatorUsage,
+    TripAvailability,
+    CronRun,
+    JobQueuePause,
+    JobStat,
+    BookingEvent,
+    PushTemplateOverride,
+    Tenant,
+    TenantDomain,
+    IdempotencyKey,
+    TripRuleAcceptance,
+    TripIncident,
+    UserIdentity,
+    GroupSuccession,
+    GroupSuccessionVote,
+    ApiUsageDaily,
+    UserSession,
+    FormSchema,
+    FormAnswer,
+    ModerationRule,
+    ModerationRuleHit,
+    CalendarFeed,
+    GroupInvite,
+    OutboxEvent,
+    BookingTerms,
+    FeatureFlag,
+    TripRecommendation,
+    RealtimeEvent,
   ],
   migrations: ["./src/migrations/*.js"],
   timezone: "America/Argentina/Buenos_Aires", // Usar zona horaria de Argentina
@@ -0,0 +1,48 @@
+import aggregatorService from "../services/aggregator.service.js";
+import usageService from "../services/usage.service.js";
+import { AuthenticationError, RateLimitError } from "../utils/customErrors.js";
+
+/**
+ * Identifies the aggregator by its API key (X-Api-Key header) and attaches it
+ * as req.aggregator
+ */
+export const authenticateAggregator = async (req, res, next) => {
+  try {
+    const partner = await aggregatorService.findByApiKey(req.get("x-api-key"));
+    if (!partner) {
+      return next(new AuthenticationError("API key inválida o sin aprobar.", "INVALID_API_KEY"));
+    }
+    req.aggregator = partner;
+    next();
+  } catch (err) {
+    next(err);
+  }
+};
+
+/**
+ * Counts the request against the aggregator's daily quota and rejects it
+ * once the quota is spent. Conditional requests answered with 304 count too.
+ * Partners with a daily cost quota are also stopped once it is spent.
+ */
+export const enforceAggregatorQuota = async (req, res, next) => {
+  try {
+    const quota = await aggregatorService.consumeQuota(req.aggregator);
+    res.set({
+      "X-RateLimit-Limit": String(quota.limit),
+      "X-RateLimit-Remaining": String(quota.remaining),
+      "X-RateLimit-Reset": String(Math.floor(quota.resetAt.getTime() / 1000)),
+    });
+    if (quota.exceeded) {
+      res.set("Retry-After", String(Math.ceil((quota.resetAt.getTime() - Date.now()) / 1000)));
+      return next(new RateLimitError("Cuota diaria agotada.", "QUOTA_EXCEEDED"));
+    }
+    const costQuota = await usageService.checkCostQuota("api_key", req.aggregator.id, req.aggregator.dailyCostQuota);
+    if (costQuota?.exceeded) {
+      res.set("Retry-After", String(Math.ceil((costQuota.resetAt.getTime() - Date.now()) / 1000)));
+      return next(new RateLimitError("Cuota diaria de costo agotada.", "COST_QUOTA_EXCEEDED"));
+    }
+    next();
+  } catch (err) {
+    next(err);
+  }
+};
@@ -2,52 +2,71 @@ import jwt from "jsonwebtoken";
 import { AppDataSource } from "../load/typeorm.loader.js";
 import User from "../models/user.model.js";
 import authService from "../services/auth.service.js";
+import sessionService from "../services/session.service.js";
+import config from "../config/index.js";
+import { AuthenticationError, AuthorizationError } from "../utils/customErrors.js";
+import { isAgeRestricted } from "../utils/ageVerification.js";
+import { getContext } from "../utils/requestContext.js";
+
+const LAST_SEEN_INTERVAL_MS = 60 * 60 * 1000;
 
 /**
  * Authentication Middleware
  * Verifies JWT tokens and attaches user information to the request object
  */
 export const authenticate = async (req, res, next) => {
+  // Already authenticated by the route group
+  if (req.user) {
+    return next();
+  }
+
   try {
     // Get token from Authorization header (Bearer token)
     const authHeader = req.headers.authorization;
 
     if (!authHeader || !authHeader.startsWith("Bearer ")) {
-      return res.status(401).json({
-        success: false,
-        message: "Access denied. No token provided."
-      });
+      return next(new AuthenticationError("Access denied. No token provided.", "TOKEN_MISSING"));
     }
 
     const token = authHeader.substring(7); // Remove "Bearer " prefix
 
     if (!token) {
-      return res.status(401).json({
-        success: false,
-        message: "Access denied. Invalid token format."
-      });
+      return next(new AuthenticationError("Access denied. Invalid token format.", "TOKEN_INVALID"));
     }
 
     // Verify token using your JWT secret from environment
     const decoded = jwt.verify(token, process.env.JWT_SECRET);
 
+    // Tokens issued for one step (e.g. the two-factor challenge) are not sessions
+    if (decoded.purpose) {
+      return next(new AuthenticationError("Invalid token.", "TOKEN_INVALID"));
+    }
+
     // Check if token is revoked
     const isRevoked = await authService.isTokenRevoked(token);
     if (isRevoked) {
-      return res.status(401).json({
-        success: false,
-        message: "Token has been revoked."
-      });
+      return next(new AuthenticationError("Token has been revoked.", "TOKEN_REVOKED"));
+    }
+
+    // Tokens of a device that was logged out, or older tokens that carry no
+    // session and so could never be revoked
+    if (!decoded.sid || !(await sessionService.touchIfActive(decoded.sid))) {
+      return next(new AuthenticationError("Session has been revoked.", "SESSION_REVOKED"));
     }
 
     // Find user in database
     const user = await AppDataSource.getRepository(User).findOne({ where: { id: decoded.id } });
 
     if (!user) {
-      return res.status(401).json({
-        success: false,
-        message: "User not found. Token is invalid."
-      });
+      return next(new AuthenticationError("User not found. Token is invalid.", "TOKEN_INVALID"));
+    }
+
+    if (user.deletedAt) {
+      return next(new AuthenticationError("La cuenta fue dada de baja.", "ACCOUNT_DELETED"));
+    }
+
+    if (user.suspendedAt) {
+      return next(new AuthorizationError("Tu cuenta está suspendida.", "ACCOUNT_SUSPENDED"));
     }
 
     // Attach user information to request object for use in subsequent middleware/controllers
@@ -55,33 +74,56 @@ export const authenticate = async (req, res, next) => {
       id: user.id,
       email: user.email,
       role: user.role,
+      ageRestricted: isAgeRestricted(user),
+      country: user.country,
+      tenantId: user.tenantId,
+      idVerifiedAt: user.idVerifiedAt,
+      // Language of the API messages (utils/i18n.js)
+      locale: user.locale,
+      // The session went through two-factor authentication
+      mfa: decoded.mfa === true,
+      sessionId: decoded.sid,
       // Add other user properties you need
     };
+    // A signed-in user stays in their own tenant whatever X-Tenant-Id says
+    req.tenantId = user.tenantId || req.domainTenantId || null;
+    // Actor of what the services audit during this request
+    const context = getContext();
+    if (context) {
+      context.userId = user.id;
+    }
+
+    // Throttled so it costs one write per user and hour at most
+    if (!user.lastSeenAt || Date.now() - user.lastSeenAt.getTime() > LAST_SEEN_INTERVAL_MS) {
+      AppDataSource.getRepository(User)
+        .update(user.id, { lastSeenAt: new Date() })
+        .catch(() => {});
+    }
 
     next();
   } catch (error) {
     if (error.name === "TokenExpiredError") {
-      return res.status(401).json({
-        success: false,
-        message: "Token expired. Please login again."
-      });
+      return next(new AuthenticationError("Token expired. Please login again.", "TOKEN_EXPIRED"));
     }
 
     if (error.name === "JsonWebTokenError") {
-      return res.status(401).json({
-        success: false,
-        message: "Invalid token."
-      });
+      return next(new AuthenticationError("Invalid token.", "TOKEN_INVALID"));
     }
 
-    // Handle other types of errors
-    return res.status(500).json({
-      success: false,
-      message: "Server error during authentication."
-    });
+    next(error);
   }
 };
 
+/**
+ * Authenticates when a token is sent and lets anonymous requests through
+ */
+export const optionalAuthenticate = (req, res, next) => {
+  if (!req.headers.authorization) {
+    return next();
+  }
+  return authenticate(req, res, next);
+};
+
 /**
  * Alias for authenticate middleware to maintain backward compatibility
  */
@@ -94,19 +136,46 @@ export const authenticateToken = authenticate;
 export const authorize = (roles = []) => {
   return (req, res, next) => {
     if (!req.user) {
-      return res.status(401).json({
-        success: false,
-        message: "Authentication required."
-      });
+      return next(new AuthenticationError("Authentication required."));
     }
 
     if (roles.length > 0 && !roles.includes(req.user.role)) {
-      return res.status(403).json({
-        success: false,
-        message: "Insufficient permissions."
-      });
+      return next(new AuthorizationError("Insufficient permissions."));
     }
 
     next();
   };
-};
\ No newline at end of file
+};
+
+/**
+ * Admin routes need a session that went through two-factor authentication
+ * (unless TWO_FACTOR_ENFORCE_ADMINS=false). Must run after authorize
+ */
+export const requireTwoFactor = (req, res, next) => {
+  if (config.twoFactor.enforceForAdmins && !req.user?.mfa) {
+    return next(
+      new AuthorizationError(
+        "Inicia sesión con la verificación en dos pasos para usar la administración.",
+        "TWO_FACTOR_REQUIRED"
+      )
+    );
+  }
+  next();
+};
+
+/**
+ * Blocks underage accounts with restricted features (see AGE_RULES)
+ * Must run after authenticate
+ */
+export const blockAgeRestricted = (req, res, next) => {
+  if (req.user?.ageRestricted) {
+    return next(
+      new AuthorizationError(
+        "Esta función no está disponible para tu cuenta por la edad mínima de tu país.",
+        "AGE_RESTRICTED"
+      )
+    );
+  }
+
+  next();
+};
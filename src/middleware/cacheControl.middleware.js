@@ -0,0 +1,11 @@
+/**
+ * Lets clients cache a GET response and revalidate it on every use: they
+ * send back its ETag in If-None-Match and get a bodiless 304 while it has not
+ * changed. Express computes the ETag from the body and answers the 304.
+ * Responses depend on the caller, so shared caches must not store them.
+ */
+export const revalidate = (req, res, next) => {
+  res.set("Cache-Control", "private, no-cache");
+  res.vary("Authorization");
+  next();
+};
@@ -0,0 +1,60 @@
+import zlib from "node:zlib";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+
+const COMPRESSIBLE_TYPE_REGEX = /^(?:application\/(?:json|xml|javascript)|text\/)/i;
+
+const ENCODERS = {
+  // Low brotli quality: close to gzip's speed with smaller output
+  br: (body, callback) =>
+    zlib.brotliCompress(body, { params: { [zlib.constants.BROTLI_PARAM_QUALITY]: 4 } }, callback),
+  gzip: (body, callback) => zlib.gzip(body, callback),
+};
+
+/**
+ * Compresses whole responses (JSON, text) with brotli or gzip, whichever the
+ * client prefers. Streamed responses (exports, event streams) and small
+ * bodies are sent as they are. The ETag of the uncompressed body is kept:
+ * Express's ETags are weak, so they stay valid for every encoding.
+ */
+export const compress = () => (req, res, next) => {
+  const { enabled, thresholdBytes } = config.http.compression;
+  if (!enabled || req.method === "HEAD") {
+    return next();
+  }
+
+  let streaming = false;
+  const write = res.write.bind(res);
+  const end = res.end.bind(res);
+  res.write = (...args) => {
+    streaming = true;
+    return write(...args);
+  };
+  res.end = (chunk, encoding, callback) => {
+    if (typeof encoding === "function") {
+      [callback, encoding] = [encoding, undefined];
+    }
+    const type = String(res.getHeader("Content-Type") || "");
+    if (streaming || !chunk || !COMPRESSIBLE_TYPE_REGEX.test(type) || res.getHeader("Content-Encoding")) {
+      return end(chunk, encoding, callback);
+    }
+    res.vary("Accept-Encoding");
+
+    const body = Buffer.isBuffer(chunk) ? chunk : Buffer.from(chunk, encoding);
+    const contentEncoding = body.length >= thresholdBytes && req.acceptsEncodings("br", "gzip", "identity");
+    if (!ENCODERS[contentEncoding] || /no-transform/.test(String(res.getHeader("Cache-Control") || ""))) {
+      return end(body, callback);
+    }
+    ENCODERS[contentEncoding](body, (error, compressed) => {
+      if (error) {
+        logger.warn(`Response compression failed, sending it uncompressed: ${error.message}`);
+        return end(body, callback);
+      }
+      res.setHeader("Content-Encoding", contentEncoding);
+      res.setHeader("Content-Length", compressed.length);
+      end(compressed, callback);
+    });
+    return res;
+  };
+  next();
+};
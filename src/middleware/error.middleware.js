@@ -1,4 +1,13 @@
 import logger from "../config/logger.js";
+import config from "../config/index.js";
+import { isUnexpectedError } from "./security.middleware.js";
+import { getRequestLocale, translateMessage, translateDetails } from "../utils/i18n.js";
+
+// Errors of the JSON body parser
+const BODY_ERRORS = {
+  "entity.too.large": { message: "El cuerpo de la solicitud es demasiado grande", errorCode: "PAYLOAD_TOO_LARGE" },
+  "entity.parse.failed": { message: "El cuerpo de la solicitud no es un JSON válido", errorCode: "INVALID_JSON" },
+};
 
 export const errorHandler = (err, req, res, _next) => {
   // Log the error with context
@@ -7,28 +16,50 @@ export const errorHandler = (err, req, res, _next) => {
     errorCode: err.errorCode,
     stack: err.stack,
     userId: req.user?.id,
+    requestId: req.id,
     ip: req.ip,
     userAgent: req.get('User-Agent'),
   });
 
+  // The request already got its answer (e.g. a 504 at its deadline)
+  if (res.headersSent) {
+    return;
+  }
+
   // Status code del error (default 500)
   const statusCode = err.status || 500;
+  const bodyError = BODY_ERRORS[err.type];
+  // Unexpected errors may carry internals (SQL, paths) in their message
+  const hidden = config.security.hideInternalErrors && isUnexpectedError(err);
+
+  // Messages are thrown in Spanish and answered in the caller's language
+  const locale = getRequestLocale(req);
+  const errorCode = bodyError?.errorCode || err.errorCode;
+
+  const message = bodyError?.message || (hidden ? null : err.message);
 
   // Respuesta de error
   const response = {
     success: false,
-    message: err.message || "Error interno del servidor",
+    message: message
+      ? translateMessage(locale, message, errorCode)
+      : translateMessage(locale, "Error interno del servidor", "INTERNAL_ERROR"),
   };
 
   // Include error code if available
-  if (err.errorCode) {
-    response.errorCode = err.errorCode;
+  if (errorCode) {
+    response.errorCode = errorCode;
+  }
+  if (hidden) {
+    response.requestId = req.id;
   }
 
   // Si hay detalles adicionales (como errores de validación)
   if (err.details) {
-    response.errors = err.details;
+    response.errors = translateDetails(locale, err.details);
   }
 
+  res.set("Content-Language", locale);
+  res.vary("Accept-Language");
   res.status(statusCode).json(response);
 };
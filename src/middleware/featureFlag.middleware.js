@@ -0,0 +1,20 @@
+import featureFlagService from "../services/featureFlag.service.js";
+import { NotFoundError } from "../utils/customErrors.js";
+
+/**
+ * Rejects the request when the flag is off for the caller (see services/featureFlag.service.js).
+ * Runs after authenticate when the rollout is per user.
+ * @param {string} key - Flag key
+ */
+export const requireFlag = (key) => {
+  return async (req, res, next) => {
+    try {
+      if (!(await featureFlagService.isEnabled(key, { userId: req.user?.id }))) {
+        return next(new NotFoundError("Esta función todavía no está disponible.", "FEATURE_NOT_RELEASED"));
+      }
+      next();
+    } catch (err) {
+      next(err);
+    }
+  };
+};
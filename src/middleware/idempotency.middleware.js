@@ -0,0 +1,49 @@
+import idempotencyService from "../services/idempotency.service.js";
+import { runWithContext } from "../utils/requestContext.js";
+import { ValidationError } from "../utils/customErrors.js";
+
+const KEY_REGEX = /^[A-Za-z0-9_:.-]{8,255}$/;
+
+/**
+ * Makes a POST endpoint safe to retry: with an Idempotency-Key header the
+ * first response is stored and replayed (with Idempotent-Replayed: true) on
+ * retries of the same request. Requests without the header run as usual.
+ * Goes after authenticate; keys are per user.
+ */
+export const idempotent = async (req, res, next) => {
+  const key = req.get("idempotency-key");
+  if (!key) {
+    return next();
+  }
+  if (!KEY_REGEX.test(key)) {
+    return next(new ValidationError("Idempotency-Key inválida (8 a 255 letras, números, _ : . -)"));
+  }
+
+  const scope = req.user.id;
+  let outcome;
+  try {
+    outcome = await idempotencyService.begin(scope, key, {
+      method: req.method,
+      path: req.originalUrl,
+      body: req.body,
+    });
+  } catch (error) {
+    return next(error);
+  }
+  if (!outcome.claimed) {
+    res.set("Idempotent-Replayed", "true");
+    return res.status(outcome.replay.status).json(outcome.replay.body);
+  }
+
+  // Stored when the response is sent rather than when it finishes, as the
+  // client may already be gone; outside the request context so its deadline
+  // does not cancel the write
+  const json = res.json.bind(res);
+  res.json = (body) => {
+    runWithContext({ requestId: req.id }, () =>
+      idempotencyService.finish(scope, key, { status: res.statusCode, body })
+    );
+    return json(body);
+  };
+  next();
+};
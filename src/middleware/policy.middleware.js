@@ -0,0 +1,47 @@
+import policyService from "../services/policy.service.js";
+import { AppError } from "../utils/customErrors.js";
+
+/**
+ * Policy context of a request: the user's country and tenant, or for anonymous
+ * requests ?country= and the tenant of the verified custom domain. Nothing a
+ * signed-in user sends can change theirs.
+ */
+export const getPolicyContext = (req) => {
+  if (req.user) {
+    return { country: req.user.country || null, tenant: req.user.tenantId || req.domainTenantId || null };
+  }
+  return {
+    country: req.query.country ? String(req.query.country).toUpperCase() : null,
+    tenant: req.domainTenantId || null,
+  };
+};
+
+/**
+ * Rejects the request when the feature is disabled for the user's country/tenant
+ * @param {string} feature - One of FEATURES in policy.service
+ */
+export const requireFeature = (feature) => {
+  return (req, res, next) => {
+    if (!policyService.isEnabled(feature, getPolicyContext(req))) {
+      return next(new AppError("Esta función no está disponible en tu país.", 403, "FEATURE_UNAVAILABLE"));
+    }
+
+    next();
+  };
+};
+
+/**
+ * Rejects users who have not verified their identity where the policy of
+ * their country/tenant requires it (requirements.idVerification).
+ * Must run after authenticate
+ */
+export const requireIdVerification = (req, res, next) => {
+  const { requirements } = policyService.resolve(getPolicyContext(req));
+  if (requirements.idVerification && !req.user?.idVerifiedAt) {
+    return next(
+      new AppError("Necesitas verificar tu identidad para usar esta función.", 403, "ID_VERIFICATION_REQUIRED")
+    );
+  }
+
+  next();
+};
@@ -0,0 +1,88 @@
+import { randomUUID } from "node:crypto";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { runWithContext, getContext } from "../utils/requestContext.js";
+import { DeadlineExceededError } from "../utils/customErrors.js";
+import { errorHandler } from "./error.middleware.js";
+
+// IDs accepted from the X-Request-Id header of a proxy or client
+const REQUEST_ID_REGEX = /^[A-Za-z0-9._-]{8,128}$/;
+
+/**
+ * (Re)arms the deadline timer of a context. At the deadline the request is
+ * aborted, which cancels what it is waiting on, and gets a 504 unless the
+ * response has already started.
+ */
+const armDeadline = (context, req, res) => {
+  clearTimeout(context.timer);
+  if (!context.deadline) {
+    return;
+  }
+  context.timer = setTimeout(() => {
+    const error = new DeadlineExceededError("La solicitud excedió su tiempo límite");
+    context.controller.abort(error);
+    logger.warn(`Request ${context.requestId} ${req.method} ${req.originalUrl} exceeded its deadline`);
+    errorHandler(error, req, res);
+  }, Math.max(0, context.deadline - Date.now()));
+};
+
+/**
+ * Gives every request an ID (echoed in X-Request-Id) and a deadline of
+ * REQUEST_TIMEOUT_MS (unless REQUEST_TIMEOUT_ENABLED=false), and runs the
+ * rest of the chain inside that context.
+ * A client that disconnects aborts the request too.
+ */
+export const requestContext = (req, res, next) => {
+  const incomingId = req.get("x-request-id");
+  const requestId = REQUEST_ID_REGEX.test(incomingId || "") ? incomingId : randomUUID();
+  const controller = new AbortController();
+  const context = {
+    requestId,
+    startedAt: Date.now(),
+    deadline: config.requests.timeoutEnabled ? Date.now() + config.requests.timeoutMs : null,
+    controller,
+    signal: controller.signal,
+    timer: null,
+    // Who is acting, for the audit trail; userId is set by authenticate
+    ip: req.ip || null,
+    userAgent: req.get("User-Agent")?.slice(0, 255) || null,
+    userId: null,
+  };
+
+  req.id = requestId;
+  req.context = context;
+  res.set("X-Request-Id", requestId);
+  armDeadline(context, req, res);
+  res.on("close", () => {
+    clearTimeout(context.timer);
+    if (!res.writableFinished && !controller.signal.aborted) {
+      controller.abort(new DeadlineExceededError("El cliente cerró la conexión"));
+    }
+  });
+
+  runWithContext(context, next);
+};
+
+/**
+ * Replaces the default deadline of the routes it is mounted on; counts from
+ * the start of the request
+ * @param {number} timeoutMs
+ */
+export const deadline = (timeoutMs) => (req, res, next) => {
+  const context = getContext() || req.context;
+  if (context && config.requests.timeoutEnabled) {
+    context.deadline = context.startedAt + timeoutMs;
+    armDeadline(context, req, res);
+  }
+  next();
+};
+
+/**
+ * Wraps a middleware that calls next() from a stream event (multer reading a
+ * multipart body), where the request context would otherwise be lost
+ * @param {Function} middleware
+ */
+export const keepContext = (middleware) => (req, res, next) =>
+  middleware(req, res, (err) =>
+    req.context ? runWithContext(req.context, () => next(err)) : next(err)
+  );
@@ -0,0 +1,21 @@
+import crypto from "crypto";
+import config from "../config/index.js";
+import { AuthenticationError } from "../utils/customErrors.js";
+
+const digest = (value) => crypto.createHash("sha256").update(value).digest();
+
+/**
+ * Lets in the external scheduler that triggers the /cron tasks, identified by
+ * CRON_SECRET as a bearer token. No user session is involved; with no secret
+ * configured every request is rejected.
+ */
+export const authenticateScheduler = (req, res, next) => {
+  const secret = config.scheduler.secret;
+  const header = req.get("authorization") || "";
+  const token = header.startsWith("Bearer ") ? header.slice(7) : "";
+  // Digests have the same length, so the comparison leaks neither the secret nor its length
+  if (!secret || !token || !crypto.timingSafeEqual(digest(token), digest(secret))) {
+    return next(new AuthenticationError("Credencial del programador de tareas inválida.", "INVALID_SCHEDULER_SECRET"));
+  }
+  next();
+};
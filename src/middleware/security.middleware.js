@@ -0,0 +1,53 @@
+import express from "express";
+import helmet from "helmet";
+import config from "../config/index.js";
+import sentryClient from "../clients/sentry.client.js";
+import { AppError } from "../utils/customErrors.js";
+
+/**
+ * Whether an error is a bug or outage rather than an expected answer
+ * (validation, not found, deadline exceeded...)
+ */
+export const isUnexpectedError = (err) =>
+  !(err instanceof AppError) && !(err.status && err.status < 500) && !err.expose;
+
+/**
+ * JSON body parser capped at SECURITY_BODY_LIMIT. Keeps the exact body for
+ * webhook signature checks.
+ */
+export const jsonBody = () =>
+  express.json({
+    limit: config.security.bodyLimit,
+    verify: (req, res, buf) => {
+      req.rawBody = buf;
+    },
+  });
+
+/**
+ * Security headers (HSTS, X-Content-Type-Options, X-Frame-Options and the
+ * rest of helmet's defaults), each toggled in config.security
+ */
+export const secureHeaders = () => {
+  const { hsts, frameOptions, noSniff } = config.security;
+  return helmet({
+    crossOriginResourcePolicy: { policy: "cross-origin" },
+    strictTransportSecurity: hsts.enabled
+      ? { maxAge: hsts.maxAgeSeconds, includeSubDomains: hsts.includeSubDomains, preload: hsts.preload }
+      : false,
+    xFrameOptions: frameOptions === "OFF" ? false : { action: frameOptions === "SAMEORIGIN" ? "sameorigin" : "deny" },
+    xContentTypeOptions: noSniff,
+  });
+};
+
+/**
+ * Reports unexpected errors of a request to Sentry with its request ID and
+ * user, then hands them to the error handler. Express 5 routes thrown and
+ * rejected errors of handlers here, so a failing handler never takes the
+ * process down.
+ */
+export const reportUnexpectedErrors = (err, req, res, next) => {
+  if (isUnexpectedError(err)) {
+    sentryClient.captureException(err, { req });
+  }
+  next(err);
+};
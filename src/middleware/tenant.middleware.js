@@ -0,0 +1,23 @@
+import tenantDomainService from "../services/tenantDomain.service.js";
+import logger from "../config/logger.js";
+
+/**
+ * Sets req.tenantId: the tenant whose verified custom domain the request came
+ * in on, else the X-Tenant-Id sent by the app, else null. req.domainTenantId
+ * is only the verified one; anything deciding access must use that (see
+ * getPolicyContext), since the header is whatever the client sends.
+ * authenticate replaces req.tenantId with the user's own tenant, so the
+ * header only counts for anonymous requests (e.g. the branding of the app),
+ * and is never stored: signups only join the tenant of a verified domain.
+ */
+export const resolveTenant = async (req, res, next) => {
+  let tenantId = null;
+  try {
+    tenantId = await tenantDomainService.resolveTenantId(req.hostname);
+  } catch (error) {
+    logger.error(`Tenant lookup of host ${req.hostname} failed: ${error.message}`);
+  }
+  req.domainTenantId = tenantId;
+  req.tenantId = tenantId || req.get("x-tenant-id") || null;
+  next();
+};
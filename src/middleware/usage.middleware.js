@@ -0,0 +1,52 @@
+import usageService from "../services/usage.service.js";
+import config from "../config/index.js";
+import { computeRequestCost } from "../utils/requestCost.js";
+import { RateLimitError } from "../utils/customErrors.js";
+
+/**
+ * Counts every finished request against the aggregator (API key) or user
+ * that made it. Requests without either are not metered.
+ */
+export const meterUsage = (req, res, next) => {
+  if (!config.usage.enabled) {
+    return next();
+  }
+  res.on("finish", () => {
+    const subject = req.aggregator
+      ? { subjectType: "api_key", subjectId: req.aggregator.id }
+      : req.user
+        ? { subjectType: "user", subjectId: req.user.id }
+        : null;
+    if (!subject) return;
+    const cost = computeRequestCost(
+      {
+        method: req.method,
+        path: req.originalUrl.split("?")[0],
+        durationMs: Date.now() - (req.context?.startedAt ?? Date.now()),
+      },
+      config.usage.msPerUnit
+    );
+    usageService.record({ ...subject, cost, failed: res.statusCode >= 400 });
+  });
+  next();
+};
+
+/**
+ * Rejects requests of a user who spent the daily cost quota
+ * (USAGE_USER_DAILY_COST_QUOTA). Admins are not limited.
+ */
+export const enforceUserCostQuota = async (req, res, next) => {
+  try {
+    if (!req.user || req.user.role === "admin") {
+      return next();
+    }
+    const quota = await usageService.checkCostQuota("user", req.user.id, config.usage.userDailyCostQuota);
+    if (quota?.exceeded) {
+      res.set("Retry-After", String(Math.ceil((quota.resetAt.getTime() - Date.now()) / 1000)));
+      return next(new RateLimitError("Alcanzaste el límite de uso diario. Prueba de nuevo mañana.", "COST_QUOTA_EXCEEDED"));
+    }
+    next();
+  } catch (err) {
+    next(err);
+  }
+};
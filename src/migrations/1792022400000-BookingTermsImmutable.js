@@ -0,0 +1,31 @@
+/**
+ * Booking terms are written once and never changed. The only update allowed
+ * is the one deleting their trip makes (ON DELETE SET NULL on groupId).
+ */
+export class BookingTermsImmutable1792022400000 {
+  name = "BookingTermsImmutable1792022400000";
+
+  async up(queryRunner) {
+    await queryRunner.query(
+      `CREATE OR REPLACE FUNCTION booking_terms_immutable() RETURNS trigger AS $$
+       BEGIN
+         IF NEW."groupId" IS NULL AND to_jsonb(NEW) - 'groupId' = to_jsonb(OLD) - 'groupId' THEN
+           RETURN NEW;
+         END IF;
+         RAISE EXCEPTION 'booking_terms cannot be updated';
+       END;
+       $$ LANGUAGE plpgsql`
+    );
+    await queryRunner.query(`DROP TRIGGER IF EXISTS booking_terms_immutable ON booking_terms`);
+    await queryRunner.query(
+      `CREATE TRIGGER booking_terms_immutable
+       BEFORE UPDATE ON booking_terms
+       FOR EACH ROW EXECUTE FUNCTION booking_terms_immutable()`
+    );
+  }
+
+  async down(queryRunner) {
+    await queryRunner.query(`DROP TRIGGER IF EXISTS booking_terms_immutable ON booking_terms`);
+    await queryRunner.query(`DROP FUNCTION IF EXISTS booking_terms_immutable()`);
+  }
+}
@@ -0,0 +1,27 @@
+/**
+ * audit_logs only takes inserts: updates, deletes and truncates fail.
+ */
+export class AuditLogsAppendOnly1792108800000 {
+  name = "AuditLogsAppendOnly1792108800000";
+
+  async up(queryRunner) {
+    await queryRunner.query(
+      `CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+       BEGIN
+         RAISE EXCEPTION 'audit_logs is append-only';
+       END;
+       $$ LANGUAGE plpgsql`
+    );
+    await queryRunner.query(`DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs`);
+    await queryRunner.query(
+      `CREATE TRIGGER audit_logs_append_only
+       BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_logs
+       FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only()`
+    );
+  }
+
+  async down(queryRunner) {
+    await queryRunner.query(`DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs`);
+    await queryRunner.query(`DROP FUNCTION IF EXISTS audit_logs_append_only()`);
+  }
+}
@@ -0,0 +1,87 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Signup brought in by an affiliate partner, with the first booking the user
+ * paid for. Only the first booking earns a commission.
+ */
+export default new EntitySchema({
+  name: "AffiliateAttribution",
+  tableName: "affiliate_attributions",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    partnerId: {
+      type: "uuid",
+      nullable: false,
+    },
+    userId: {
+      type: "uuid",
+      nullable: false,
+      unique: true,
+    },
+    utmSource: {
+      type: "varchar",
+      length: 100,
+      nullable: true,
+    },
+    utmMedium: {
+      type: "varchar",
+      length: 100,
+      nullable: true,
+    },
+    utmCampaign: {
+      type: "varchar",
+      length: 100,
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+    firstBookingAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    firstBookingPaymentId: {
+      type: "uuid",
+      nullable: true,
+    },
+    // Cents, in the partner currency
+    firstBookingAmount: {
+      type: "int",
+      nullable: true,
+    },
+    // Cents, in the partner currency, at the rate in force when the booking was paid
+    commissionAmount: {
+      type: "int",
+      nullable: true,
+    },
+  },
+  relations: {
+    partner: {
+      type: "many-to-one",
+      target: "AffiliatePartner",
+      joinColumn: {
+        name: "partnerId",
+      },
+      onDelete: "CASCADE",
+    },
+    user: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "userId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_AFFILIATE_ATTRIBUTION_PARTNER_DATE",
+      columns: ["partnerId", "createdAt"],
+    },
+  ],
+});
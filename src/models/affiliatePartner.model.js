@@ -0,0 +1,80 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Partner (travel blog, agency, creator) that brings users in with an
+ * affiliate code and earns a commission on their signups and first booking
+ */
+export default new EntitySchema({
+  name: "AffiliatePartner",
+  tableName: "affiliate_partners",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    name: {
+      type: "varchar",
+      length: 120,
+      nullable: false,
+    },
+    // Uppercase; also matched against utm_source when no code is sent
+    code: {
+      type: "varchar",
+      length: 40,
+      nullable: false,
+      unique: true,
+    },
+    // Account the partner logs in with to see their stats
+    userId: {
+      type: "uuid",
+      nullable: true,
+      unique: true,
+    },
+    contactEmail: {
+      type: "varchar",
+      length: 255,
+      nullable: true,
+    },
+    // Fraction of the first booking, e.g. 0.05
+    commissionRate: {
+      type: "decimal",
+      precision: 5,
+      scale: 4,
+      default: 0,
+    },
+    // Cents per attributed signup that confirmed its email
+    signupBounty: {
+      type: "int",
+      default: 0,
+    },
+    // Commissions are reported in this currency
+    currency: {
+      type: "varchar",
+      length: 3,
+      default: "USD",
+    },
+    active: {
+      type: "boolean",
+      default: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+    updatedAt: {
+      type: "timestamp",
+      updateDate: true,
+    },
+  },
+  relations: {
+    user: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "userId",
+      },
+      onDelete: "SET NULL",
+    },
+  },
+});
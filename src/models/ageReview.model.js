@@ -0,0 +1,72 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Case opened for an account suspected to be under the minimum age of its
+ * jurisdiction, resolved by an administrator
+ */
+export default new EntitySchema({
+  name: "AgeReview",
+  tableName: "age_reviews",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    userId: {
+      type: "uuid",
+      nullable: false,
+    },
+    // "report" (another user) | "profile" (declared age under the minimum)
+    source: {
+      type: "varchar",
+      length: 20,
+      nullable: false,
+    },
+    reportedById: {
+      type: "uuid",
+      nullable: true,
+    },
+    reason: {
+      type: "text",
+      nullable: true,
+    },
+    status: {
+      type: "varchar",
+      length: 20,
+      default: "open", // "open" | "cleared" | "restricted"
+    },
+    resolvedById: {
+      type: "uuid",
+      nullable: true,
+    },
+    resolutionNotes: {
+      type: "text",
+      nullable: true,
+    },
+    resolvedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    user: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "userId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_AGE_REVIEW_STATUS",
+      columns: ["status", "createdAt"],
+    },
+  ],
+});
@@ -0,0 +1,77 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Aggregator (metasearch, travel marketplace) approved to sync trip
+ * availability through the feed with its own API key and daily quota
+ */
+export default new EntitySchema({
+  name: "AggregatorPartner",
+  tableName: "aggregator_partners",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    name: {
+      type: "varchar",
+      length: 120,
+      nullable: false,
+    },
+    contactEmail: {
+      type: "varchar",
+      length: 255,
+      nullable: true,
+    },
+    // SHA-256 of the API key; the key itself is only shown when issued
+    apiKeyHash: {
+      type: "varchar",
+      length: 64,
+      nullable: false,
+      unique: true,
+    },
+    // First characters of the key, to tell keys apart in the admin
+    apiKeyPrefix: {
+      type: "varchar",
+      length: 12,
+      nullable: false,
+    },
+    // Feed requests per UTC day
+    dailyQuota: {
+      type: "int",
+      nullable: false,
+    },
+    // Cost units per UTC day on top of the request quota (null for no limit)
+    dailyCostQuota: {
+      type: "int",
+      nullable: true,
+    },
+    // Billing price per thousand cost units (null uses the configured default)
+    pricePerThousandUnits: {
+      type: "decimal",
+      precision: 10,
+      scale: 4,
+      nullable: true,
+    },
+    active: {
+      type: "boolean",
+      default: true,
+    },
+    approvedById: {
+      type: "uuid",
+      nullable: true,
+    },
+    lastUsedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+    updatedAt: {
+      type: "timestamp",
+      updateDate: true,
+    },
+  },
+});
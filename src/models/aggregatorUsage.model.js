@@ -0,0 +1,45 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Feed requests of an aggregator per UTC day, checked against its quota
+ */
+export default new EntitySchema({
+  name: "AggregatorUsage",
+  tableName: "aggregator_usage",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    partnerId: {
+      type: "uuid",
+      nullable: false,
+    },
+    day: {
+      type: "date",
+      nullable: false,
+    },
+    requests: {
+      type: "int",
+      default: 0,
+    },
+  },
+  relations: {
+    partner: {
+      type: "many-to-one",
+      target: "AggregatorPartner",
+      joinColumn: {
+        name: "partnerId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_AGGREGATOR_USAGE_DAY",
+      columns: ["partnerId", "day"],
+      unique: true,
+    },
+  ],
+});
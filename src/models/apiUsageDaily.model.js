@@ -0,0 +1,56 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Requests and compute-weighted cost of a user or an API key per UTC day.
+ * Kept for the configured retention (13 months by default) for usage
+ * reports and partner billing.
+ */
+export default new EntitySchema({
+  name: "ApiUsageDaily",
+  tableName: "api_usage_daily",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    // "user" or "api_key" (an aggregator partner)
+    subjectType: {
+      type: "varchar",
+      length: 10,
+      nullable: false,
+    },
+    subjectId: {
+      type: "uuid",
+      nullable: false,
+    },
+    day: {
+      type: "date",
+      nullable: false,
+    },
+    requests: {
+      type: "int",
+      default: 0,
+    },
+    costUnits: {
+      type: "int",
+      default: 0,
+    },
+    // Responses with a 4xx or 5xx status
+    errors: {
+      type: "int",
+      default: 0,
+    },
+  },
+  indices: [
+    {
+      name: "IDX_API_USAGE_DAILY_SUBJECT_DAY",
+      columns: ["subjectType", "subjectId", "day"],
+      unique: true,
+    },
+    {
+      name: "IDX_API_USAGE_DAILY_DAY",
+      columns: ["day"],
+    },
+  ],
+});
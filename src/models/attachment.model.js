@@ -0,0 +1,121 @@
+import { EntitySchema } from "typeorm";
+
+export default new EntitySchema({
+  name: "Attachment",
+  tableName: "attachments",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    // Uploader
+    userId: {
+      type: "uuid",
+      nullable: false,
+    },
+    // What the file belongs to: a group (trip photo) or a user profile (avatar)
+    entityType: {
+      type: "varchar",
+      length: 20,
+      nullable: false, // "group" | "user"
+    },
+    entityId: {
+      type: "uuid",
+      nullable: false,
+    },
+    kind: {
+      type: "varchar",
+      length: 20,
+      nullable: false, // "group_photo" | "avatar"
+    },
+    storageKey: {
+      type: "varchar",
+      length: 512,
+      nullable: false,
+    },
+    originalName: {
+      type: "varchar",
+      length: 255,
+      nullable: true,
+    },
+    contentType: {
+      type: "varchar",
+      length: 100,
+      nullable: false,
+    },
+    size: {
+      type: "int",
+      nullable: false,
+    },
+    status: {
+      type: "varchar",
+      length: 20,
+      default: "pending", // "pending" (presigned, not uploaded yet) | "uploaded"
+    },
+    // Antivirus: only "clean" and "skipped" files are downloadable
+    scanStatus: {
+      type: "varchar",
+      length: 20,
+      default: "skipped", // "pending" | "clean" | "quarantined" | "skipped"
+    },
+    scanResult: {
+      type: "varchar",
+      length: 255,
+      nullable: true,
+    },
+    scannedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    // Image processing: "pending" until thumbnails are generated and metadata
+    // stripped, then "processed" (or "failed"); only processed photos are served
+    processingStatus: {
+      type: "varchar",
+      length: 20,
+      nullable: true,
+    },
+    metadataStripped: {
+      type: "boolean",
+      default: false,
+    },
+    // { small: { key, width, height, size }, medium: {...}, large: {...} }
+    variants: {
+      type: "jsonb",
+      default: {},
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+    updatedAt: {
+      type: "timestamp",
+      updateDate: true,
+    },
+  },
+  relations: {
+    user: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "userId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_ATTACHMENT_ENTITY",
+      columns: ["entityType", "entityId"],
+    },
+    {
+      name: "IDX_ATTACHMENT_SCAN_STATUS",
+      columns: ["scanStatus", "createdAt"],
+    },
+    {
+      name: "IDX_ATTACHMENT_STORAGE_KEY",
+      columns: ["storageKey"],
+      unique: true,
+    },
+  ],
+});
@@ -0,0 +1,71 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Append-only record of a security-relevant or admin action. Rows are never
+ * updated or deleted (enforced by a trigger, see auditLog.repository), and
+ * carry no foreign keys so they outlive the users and trips they mention.
+ */
+export default new EntitySchema({
+  name: "AuditLog",
+  tableName: "audit_logs",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    // null for anonymous actions such as a failed login
+    actorId: {
+      type: "uuid",
+      nullable: true,
+    },
+    // e.g. "auth.login", "admin.user_suspended"
+    action: {
+      type: "varchar",
+      length: 64,
+      nullable: false,
+    },
+    targetType: {
+      type: "varchar",
+      length: 32,
+      nullable: true,
+    },
+    targetId: {
+      type: "varchar",
+      length: 64,
+      nullable: true,
+    },
+    metadata: {
+      type: "jsonb",
+      default: {},
+    },
+    ip: {
+      type: "varchar",
+      length: 64,
+      nullable: true,
+    },
+    userAgent: {
+      type: "varchar",
+      length: 255,
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  indices: [
+    {
+      name: "IDX_AUDIT_LOG_ACTOR_CREATED",
+      columns: ["actorId", "createdAt"],
+    },
+    {
+      name: "IDX_AUDIT_LOG_ACTION_CREATED",
+      columns: ["action", "createdAt"],
+    },
+    {
+      name: "IDX_AUDIT_LOG_CREATED",
+      columns: ["createdAt"],
+    },
+  ],
+});
@@ -0,0 +1,52 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Step in the life of a booking (a join request and its payments), recorded
+ * by the subsystem that handled it. Read back by the admin booking timeline.
+ */
+export default new EntitySchema({
+  name: "BookingEvent",
+  tableName: "booking_events",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    // Join request ID, the correlation ID carried by every event of the booking
+    bookingId: {
+      type: "uuid",
+      nullable: false,
+    },
+    source: {
+      type: "varchar",
+      length: 20,
+      nullable: false, // "booking" | "payment" | "webhook" | "job"
+    },
+    type: {
+      type: "varchar",
+      length: 60,
+      nullable: false,
+    },
+    // ID of the HTTP request (or job) that caused the event, as in the logs
+    requestId: {
+      type: "varchar",
+      length: 128,
+      nullable: true,
+    },
+    data: {
+      type: "jsonb",
+      nullable: true,
+    },
+    occurredAt: {
+      type: "timestamptz",
+      default: () => "CURRENT_TIMESTAMP",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_BOOKING_EVENT_BOOKING_OCCURRED",
+      columns: ["bookingId", "occurredAt"],
+    },
+  ],
+});
@@ -0,0 +1,66 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * What a member agreed to when they booked a trip (or were added to it):
+ * price, cancellation policy, itinerary and code of conduct as they were at
+ * that moment. Written once per booking and never updated (a trigger rejects
+ * updates, see migrations), so disputes can be settled against these terms
+ * rather than the current, edited trip. They outlive the trip itself.
+ */
+export default new EntitySchema({
+  name: "BookingTerms",
+  tableName: "booking_terms",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    // The booking (see services/bookingTrace.service.js); null for members added by the organizer
+    joinRequestId: {
+      type: "uuid",
+      nullable: true,
+      unique: true,
+    },
+    // null once the trip is deleted; the terms keep its details
+    groupId: {
+      type: "uuid",
+      nullable: true,
+    },
+    userId: {
+      type: "uuid",
+      nullable: false,
+    },
+    // { trip, price, cancellationPolicy, rules, itinerary }
+    terms: {
+      type: "jsonb",
+      nullable: false,
+    },
+    // SHA-256 of the terms as written, to show they were not altered since
+    contentHash: {
+      type: "varchar",
+      length: 64,
+      nullable: false,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    group: {
+      type: "many-to-one",
+      target: "Group",
+      joinColumn: {
+        name: "groupId",
+      },
+      onDelete: "SET NULL",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_BOOKING_TERMS_GROUP_USER",
+      columns: ["groupId", "userId"],
+    },
+  ],
+});
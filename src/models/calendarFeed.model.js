@@ -0,0 +1,47 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Private calendar feed of a user's trips. Calendar apps cannot send a
+ * session, so the feed URL carries a token; only its hash is stored and
+ * rotating it breaks the old URL.
+ */
+export default new EntitySchema({
+  name: "CalendarFeed",
+  tableName: "calendar_feeds",
+  columns: {
+    userId: {
+      primary: true,
+      type: "uuid",
+    },
+    tokenHash: {
+      type: "varchar",
+      length: 64,
+      nullable: false,
+      unique: true,
+    },
+    // First characters of the token, to tell URLs apart
+    tokenPrefix: {
+      type: "varchar",
+      length: 12,
+      nullable: false,
+    },
+    lastFetchedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    user: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "userId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+});
@@ -0,0 +1,89 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Short written reference from a past co-traveler. Only visible once the
+ * subject confirms having traveled with the author on the referenced trip.
+ */
+export default new EntitySchema({
+  name: "CompanionReference",
+  tableName: "companion_references",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    authorId: {
+      type: "uuid",
+      nullable: false,
+    },
+    subjectId: {
+      type: "uuid",
+      nullable: false,
+    },
+    // Completed trip both users were members of
+    groupId: {
+      type: "uuid",
+      nullable: false,
+    },
+    content: {
+      type: "varchar",
+      length: 500,
+      nullable: false,
+    },
+    status: {
+      type: "varchar",
+      length: 20,
+      default: "pending", // "pending" | "confirmed" | "declined"
+    },
+    confirmedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+    updatedAt: {
+      type: "timestamp",
+      updateDate: true,
+    },
+  },
+  relations: {
+    author: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "authorId",
+      },
+      onDelete: "CASCADE",
+    },
+    subject: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "subjectId",
+      },
+      onDelete: "CASCADE",
+    },
+    group: {
+      type: "many-to-one",
+      target: "Group",
+      joinColumn: {
+        name: "groupId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_COMPANION_REFERENCE_UNIQUE",
+      columns: ["authorId", "subjectId", "groupId"],
+      unique: true,
+    },
+    {
+      name: "IDX_COMPANION_REFERENCE_SUBJECT",
+      columns: ["subjectId", "status"],
+    },
+  ],
+});
@@ -0,0 +1,49 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Execution of a scheduled task triggered through /cron, or a "missed" entry
+ * recorded when a task did not run within its expected interval
+ */
+export default new EntitySchema({
+  name: "CronRun",
+  tableName: "cron_runs",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    task: {
+      type: "varchar",
+      length: 60,
+      nullable: false,
+    },
+    status: {
+      type: "varchar",
+      length: 20,
+      default: "running", // "running" | "succeeded" | "failed" | "missed"
+    },
+    startedAt: {
+      type: "timestamptz",
+      nullable: false,
+    },
+    finishedAt: {
+      type: "timestamptz",
+      nullable: true,
+    },
+    durationMs: {
+      type: "int",
+      nullable: true,
+    },
+    error: {
+      type: "text",
+      nullable: true,
+    },
+  },
+  indices: [
+    {
+      name: "IDX_CRON_RUN_TASK_STARTED",
+      columns: ["task", "startedAt"],
+    },
+  ],
+});
@@ -0,0 +1,66 @@
+import { EntitySchema } from "typeorm";
+
+export default new EntitySchema({
+  name: "DeviceToken",
+  tableName: "device_tokens",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    userId: {
+      type: "uuid",
+      nullable: false,
+    },
+    token: {
+      type: "varchar",
+      length: 512,
+      nullable: false,
+    },
+    platform: {
+      type: "varchar",
+      length: 20,
+      nullable: false, // "android" | "ios" | "web"
+    },
+    provider: {
+      type: "varchar",
+      length: 20,
+      nullable: false, // "fcm" | "apns"
+    },
+    deviceName: {
+      type: "varchar",
+      length: 100,
+      nullable: true,
+    },
+    lastSeenAt: {
+      type: "timestamp",
+      default: () => "CURRENT_TIMESTAMP",
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    user: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "userId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_DEVICE_TOKEN_TOKEN",
+      columns: ["token"],
+      unique: true,
+    },
+    {
+      name: "IDX_DEVICE_TOKEN_USER",
+      columns: ["userId"],
+    },
+  ],
+});
@@ -41,6 +41,12 @@ export default new EntitySchema({
       default: () => "CURRENT_TIMESTAMP",
       onUpdate: "CURRENT_TIMESTAMP",
     },
+    // Soft delete (see repository/softDelete.js)
+    deletedAt: {
+      type: "timestamp",
+      nullable: true,
+      deleteDate: true,
+    },
   },
   relations: {
     sender: {
@@ -0,0 +1,42 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Latest exchange rate of each currency. Every row shares the same base
+ * (the whole table is replaced on each refresh).
+ */
+export default new EntitySchema({
+  name: "ExchangeRate",
+  tableName: "exchange_rates",
+  columns: {
+    currency: {
+      primary: true,
+      type: "varchar",
+      length: 3,
+    },
+    // Units of `currency` per 1 unit of `base`
+    rate: {
+      type: "decimal",
+      precision: 20,
+      scale: 10,
+      nullable: false,
+    },
+    base: {
+      type: "varchar",
+      length: 3,
+      nullable: false,
+    },
+    source: {
+      type: "varchar",
+      length: 30,
+      nullable: false,
+    },
+    rateDate: {
+      type: "date",
+      nullable: false,
+    },
+    updatedAt: {
+      type: "timestamp",
+      updateDate: true,
+    },
+  },
+});
@@ -20,6 +20,12 @@ export default new EntitySchema({
       scale: 2,
       nullable: false,
     },
+    // ISO 4217 code of the amount (stored in cents like every expense)
+    currency: {
+      type: "varchar",
+      length: 3,
+      default: "ARS",
+    },
     groupId: {
       type: "uuid",
       nullable: false,
@@ -32,6 +38,11 @@ export default new EntitySchema({
       type: "uuid",
       nullable: true,
     },
+    // Members sharing the expense, snapshotted when it is recorded
+    participantIds: {
+      type: "jsonb",
+      nullable: true,
+    },
     createdAt: {
       type: "timestamp",
       createDate: true,
@@ -0,0 +1,44 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Runtime setting of a feature flag, set by an admin. Overrides the value of
+ * the flag in FEATURE_FLAGS until it is deleted.
+ */
+export default new EntitySchema({
+  name: "FeatureFlag",
+  tableName: "feature_flags",
+  columns: {
+    key: {
+      primary: true,
+      type: "varchar",
+      length: 60,
+    },
+    description: {
+      type: "varchar",
+      length: 255,
+      nullable: true,
+    },
+    enabled: {
+      type: "boolean",
+      default: false,
+    },
+    // Share of users (0-100) the flag is on for while enabled; each user
+    // always falls in the same bucket, so raising it only adds users
+    rolloutPercent: {
+      type: "int",
+      default: 100,
+    },
+    updatedById: {
+      type: "uuid",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+    updatedAt: {
+      type: "timestamp",
+      updateDate: true,
+    },
+  },
+});
@@ -0,0 +1,65 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Trips published by an organizer within the batching window. Followers get a
+ * single notification per batch instead of one per trip.
+ */
+export default new EntitySchema({
+  name: "FollowerNotificationBatch",
+  tableName: "follower_notification_batches",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    organizerId: {
+      type: "uuid",
+      nullable: false,
+    },
+    groupIds: {
+      type: "jsonb",
+      default: [],
+    },
+    // pending: still collecting trips; sending: followers being notified; sent
+    status: {
+      type: "varchar",
+      length: 20,
+      default: "pending",
+    },
+    sendAt: {
+      type: "timestamp",
+      nullable: false,
+    },
+    notifiedCount: {
+      type: "int",
+      default: 0,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+    updatedAt: {
+      type: "timestamp",
+      updateDate: true,
+    },
+  },
+  relations: {
+    organizer: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "organizerId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_FOLLOWER_BATCH_PENDING_ORGANIZER",
+      columns: ["organizerId"],
+      unique: true,
+      where: `status = 'pending'`,
+    },
+  ],
+});
@@ -0,0 +1,61 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Answers of a user (onboarding) or a trip (trip creation) to a form, keyed
+ * by field ID so they survive new schema versions
+ */
+export default new EntitySchema({
+  name: "FormAnswer",
+  tableName: "form_answers",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    formKey: {
+      type: "varchar",
+      length: 50,
+      nullable: false,
+    },
+    // User ID or group ID, see FORMS in utils/dynamicForms.js
+    subjectId: {
+      type: "uuid",
+      nullable: false,
+    },
+    // Version the answers were last checked against
+    schemaVersion: {
+      type: "int",
+      nullable: false,
+    },
+    answers: {
+      type: "jsonb",
+      nullable: false,
+      default: {},
+    },
+    updatedById: {
+      type: "uuid",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+    updatedAt: {
+      type: "timestamp",
+      updateDate: true,
+    },
+  },
+  uniques: [
+    {
+      name: "UQ_FORM_ANSWERS_KEY_SUBJECT",
+      columns: ["formKey", "subjectId"],
+    },
+  ],
+  indices: [
+    {
+      name: "IDX_FORM_ANSWERS_SUBJECT",
+      columns: ["subjectId"],
+    },
+  ],
+});
@@ -0,0 +1,54 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * A version of a form the apps render from the backend (onboarding
+ * questionnaire, trip creation). Drafts can be edited; once published a
+ * version is frozen and the latest published one is served.
+ */
+export default new EntitySchema({
+  name: "FormSchema",
+  tableName: "form_schemas",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    formKey: {
+      type: "varchar",
+      length: 50,
+      nullable: false,
+    },
+    version: {
+      type: "int",
+      nullable: false,
+    },
+    // [{ id, type, required, label: { es, en, pt }, help, options, validation }]
+    fields: {
+      type: "jsonb",
+      nullable: false,
+    },
+    createdById: {
+      type: "uuid",
+      nullable: true,
+    },
+    publishedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+    updatedAt: {
+      type: "timestamp",
+      updateDate: true,
+    },
+  },
+  uniques: [
+    {
+      name: "UQ_FORM_SCHEMAS_KEY_VERSION",
+      columns: ["formKey", "version"],
+    },
+  ],
+});
@@ -25,8 +25,159 @@ export default new EntitySchema({
     assignedItineraryId: {
       type: "uuid",
       nullable: true
+    },
+    // Maximum number of members (admin included); null means unlimited
+    capacity: {
+      type: "int",
+      nullable: true
+    },
+    // Group size the organizer plans for ("small" | "medium" | "large", see utils/groupSize.js);
+    // when null the size follows from the capacity
+    expectedSize: {
+      type: "varchar",
+      length: 10,
+      nullable: true
+    },
+    destinationName: {
+      type: "varchar",
+      length: 150,
+      nullable: true
+    },
+    destinationLat: {
+      type: "decimal",
+      precision: 10,
+      scale: 8,
+      nullable: true
+    },
+    destinationLng: {
+      type: "decimal",
+      precision: 11,
+      scale: 8,
+      nullable: true
+    },
+    // Departure city, optional ("trips from my city" discovery)
+    originName: {
+      type: "varchar",
+      length: 150,
+      nullable: true
+    },
+    originLat: {
+      type: "decimal",
+      precision: 10,
+      scale: 8,
+      nullable: true
+    },
+    originLng: {
+      type: "decimal",
+      precision: 11,
+      scale: 8,
+      nullable: true
+    },
+    // Public groups can be discovered by users who are not members
+    isPublic: {
+      type: "boolean",
+      default: false
+    },
+    // Deposit charged when requesting to join (cents), null for free trips
+    depositAmount: {
+      type: "int",
+      nullable: true
+    },
+    depositCurrency: {
+      type: "varchar",
+      length: 3,
+      nullable: true
+    },
+    // Scheduled launch: the trip turns public at this time
+    publishAt: {
+      type: "timestamp",
+      nullable: true
+    },
+    publishedAt: {
+      type: "timestamp",
+      nullable: true
+    },
+    // Soft delete (see repository/softDelete.js): deleted trips keep their
+    // members, expenses and messages and can be restored by an admin
+    deletedAt: {
+      type: "timestamp",
+      nullable: true,
+      deleteDate: true
+    },
+    // Travel dimensions derived from the assigned itinerary at write time
+    // (see groupRepository.refreshTravelDimensions)
+    tripStartDate: {
+      type: "date",
+      nullable: true
+    },
+    tripEndDate: {
+      type: "date",
+      nullable: true
+    },
+    travelMonth: {
+      type: "smallint",
+      nullable: true
+    },
+    durationBucket: {
+      type: "varchar",
+      length: 10,
+      nullable: true // "weekend" | "week" | "long"
+    },
+    // Chat retention chosen by the organizer (see services/chatRetention.service.js):
+    // "forever" | "after_trip_90d" (messages deleted 90 days after tripEndDate)
+    chatRetention: {
+      type: "varchar",
+      length: 20,
+      default: "forever"
+    },
+    // When members were warned that the chat is about to be deleted
+    chatDeletionNoticeAt: {
+      type: "timestamptz",
+      nullable: true
+    },
+    chatPurgedAt: {
+      type: "timestamptz",
+      nullable: true
+    },
+    // Refunds and cancellations as the organizer states them; captured with
+    // each booking (see services/bookingTerms.service.js)
+    cancellationPolicy: {
+      type: "text",
+      nullable: true
+    },
+    // House rules / code of conduct joiners accept before their spot is confirmed
+    rules: {
+      type: "text",
+      nullable: true
+    },
+    // Bumped on every change of the rules; acceptances are per version
+    rulesVersion: {
+      type: "int",
+      default: 0
+    },
+    rulesUpdatedAt: {
+      type: "timestamp",
+      nullable: true
     }
   },
+  indices: [
+    {
+      name: "IDX_GROUP_DESTINATION_COORDS",
+      columns: ["destinationLat", "destinationLng"]
+    },
+    {
+      name: "IDX_GROUP_ORIGIN_COORDS",
+      columns: ["originLat", "originLng"]
+    },
+    {
+      name: "IDX_GROUP_PUBLISH_AT",
+      columns: ["publishAt"]
+    },
+    {
+      name: "IDX_GROUP_TRAVEL_DIMENSIONS",
+      columns: ["travelMonth", "durationBucket", "tripStartDate"]
+    }
+  ],
   relations: {
     admin: {
       type: "many-to-one",
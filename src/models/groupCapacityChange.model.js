@@ -0,0 +1,77 @@
+import { EntitySchema } from "typeorm";
+
+export default new EntitySchema({
+  name: "GroupCapacityChange",
+  tableName: "group_capacity_changes",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    groupId: {
+      type: "uuid",
+      nullable: false,
+    },
+    changedById: {
+      type: "uuid",
+      nullable: false,
+    },
+    // null means unlimited
+    previousCapacity: {
+      type: "int",
+      nullable: true,
+    },
+    newCapacity: {
+      type: "int",
+      nullable: true,
+    },
+    strategy: {
+      type: "varchar",
+      length: 20,
+      nullable: true, // "join_order" | "tier" | "manual" (only when members are removed)
+    },
+    reason: {
+      type: "varchar",
+      length: 500,
+      nullable: true,
+    },
+    // [{ userId, waitlisted, refund: { status, amount } }]; refunds made by a job record status "queued"
+    removedMembers: {
+      type: "jsonb",
+      default: [],
+    },
+    // [userId] promoted from the waitlist
+    promotedMembers: {
+      type: "jsonb",
+      default: [],
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    group: {
+      type: "many-to-one",
+      target: "Group",
+      joinColumn: {
+        name: "groupId",
+      },
+      onDelete: "CASCADE",
+    },
+    changedBy: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "changedById",
+      },
+    },
+  },
+  indices: [
+    {
+      name: "IDX_GROUP_CAPACITY_CHANGE_GROUP",
+      columns: ["groupId", "createdAt"],
+    },
+  ],
+});
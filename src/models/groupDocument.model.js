@@ -0,0 +1,71 @@
+import { EntitySchema } from "typeorm";
+
+export default new EntitySchema({
+  name: "GroupDocument",
+  tableName: "group_documents",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    groupId: {
+      type: "uuid",
+      nullable: false,
+    },
+    title: {
+      type: "varchar",
+      length: 150,
+      nullable: false,
+    },
+    category: {
+      type: "varchar",
+      length: 20,
+      default: "other", // "ticket" | "reservation" | "insurance" | "other"
+    },
+    currentVersion: {
+      type: "int",
+      default: 1,
+    },
+    createdById: {
+      type: "uuid",
+      nullable: false,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+    updatedAt: {
+      type: "timestamp",
+      updateDate: true,
+    },
+  },
+  relations: {
+    group: {
+      type: "many-to-one",
+      target: "Group",
+      joinColumn: {
+        name: "groupId",
+      },
+      onDelete: "CASCADE",
+    },
+    createdBy: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "createdById",
+      },
+    },
+    versions: {
+      type: "one-to-many",
+      target: "GroupDocumentVersion",
+      inverseSide: "document",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_GROUP_DOCUMENT_GROUP",
+      columns: ["groupId"],
+    },
+  ],
+});
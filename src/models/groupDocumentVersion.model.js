@@ -0,0 +1,95 @@
+import { EntitySchema } from "typeorm";
+
+export default new EntitySchema({
+  name: "GroupDocumentVersion",
+  tableName: "group_document_versions",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    documentId: {
+      type: "uuid",
+      nullable: false,
+    },
+    // Denormalized to compute the group storage quota in one query
+    groupId: {
+      type: "uuid",
+      nullable: false,
+    },
+    version: {
+      type: "int",
+      nullable: false,
+    },
+    storageKey: {
+      type: "varchar",
+      length: 512,
+      nullable: false,
+    },
+    originalName: {
+      type: "varchar",
+      length: 255,
+      nullable: true,
+    },
+    contentType: {
+      type: "varchar",
+      length: 100,
+      nullable: false,
+    },
+    size: {
+      type: "int",
+      nullable: false,
+    },
+    uploadedById: {
+      type: "uuid",
+      nullable: false,
+    },
+    scanStatus: {
+      type: "varchar",
+      length: 20,
+      default: "pending", // "pending" | "clean" | "quarantined" | "skipped"
+    },
+    scanResult: {
+      type: "varchar",
+      length: 255,
+      nullable: true,
+    },
+    scannedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    document: {
+      type: "many-to-one",
+      target: "GroupDocument",
+      joinColumn: {
+        name: "documentId",
+      },
+      onDelete: "CASCADE",
+    },
+    uploadedBy: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "uploadedById",
+      },
+    },
+  },
+  indices: [
+    {
+      name: "IDX_GROUP_DOCUMENT_VERSION_NUMBER",
+      columns: ["documentId", "version"],
+      unique: true,
+    },
+    {
+      name: "IDX_GROUP_DOCUMENT_VERSION_GROUP",
+      columns: ["groupId"],
+    },
+  ],
+});
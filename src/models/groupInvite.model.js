@@ -0,0 +1,83 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Shareable invite link of a trip. Whoever opens it sees a preview of the
+ * trip and joins without going through the approval queue; only the hash of
+ * the token is stored, so the link is shown once, when created.
+ */
+export default new EntitySchema({
+  name: "GroupInvite",
+  tableName: "group_invites",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    groupId: {
+      type: "uuid",
+      nullable: false,
+    },
+    createdById: {
+      type: "uuid",
+      nullable: false,
+    },
+    tokenHash: {
+      type: "varchar",
+      length: 64,
+      nullable: false,
+      unique: true,
+    },
+    // First characters of the token, to tell links apart
+    tokenPrefix: {
+      type: "varchar",
+      length: 12,
+      nullable: false,
+    },
+    // null: unlimited
+    maxUses: {
+      type: "int",
+      nullable: true,
+    },
+    useCount: {
+      type: "int",
+      default: 0,
+    },
+    expiresAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    revokedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    group: {
+      type: "many-to-one",
+      target: "Group",
+      joinColumn: {
+        name: "groupId",
+      },
+      onDelete: "CASCADE",
+    },
+    createdBy: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "createdById",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_GROUP_INVITE_GROUP",
+      columns: ["groupId"],
+    },
+  ],
+});
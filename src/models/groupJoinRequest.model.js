@@ -0,0 +1,82 @@
+import { EntitySchema } from "typeorm";
+
+export default new EntitySchema({
+  name: "GroupJoinRequest",
+  tableName: "group_join_requests",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    groupId: {
+      type: "uuid",
+      nullable: false,
+    },
+    userId: {
+      type: "uuid",
+      nullable: false,
+    },
+    status: {
+      type: "varchar",
+      length: 20,
+      // "pending" | "waitlisted" | "awaiting_rules" (approved, waiting for the
+      // joiner to accept the trip rules) | "approved" | "rejected" | "cancelled"
+      default: "pending",
+    },
+    message: {
+      type: "varchar",
+      length: 500,
+      nullable: true,
+    },
+    // Invite link the requester joined through, if any
+    inviteId: {
+      type: "uuid",
+      nullable: true,
+    },
+    decidedById: {
+      type: "uuid",
+      nullable: true,
+    },
+    decidedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+    updatedAt: {
+      type: "timestamp",
+      updateDate: true,
+    },
+  },
+  relations: {
+    group: {
+      type: "many-to-one",
+      target: "Group",
+      joinColumn: {
+        name: "groupId",
+      },
+      onDelete: "CASCADE",
+    },
+    user: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "userId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_GROUP_JOIN_REQUEST_GROUP_STATUS",
+      columns: ["groupId", "status"],
+    },
+    {
+      name: "IDX_GROUP_JOIN_REQUEST_USER",
+      columns: ["userId"],
+    },
+  ],
+});
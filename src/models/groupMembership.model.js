@@ -0,0 +1,66 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Details of a group membership that the group_members join table cannot hold.
+ * Used to pick who loses their spot when the organizer reduces capacity.
+ */
+export default new EntitySchema({
+  name: "GroupMembership",
+  tableName: "group_memberships",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    groupId: {
+      type: "uuid",
+      nullable: false,
+    },
+    userId: {
+      type: "uuid",
+      nullable: false,
+    },
+    // Set by the organizer (e.g. early bird, paid deposit); higher tiers keep their spot
+    tier: {
+      type: "int",
+      default: 0,
+    },
+    // Set by the organizer; first in line to take over the trip if the organizer is gone
+    coOrganizer: {
+      type: "boolean",
+      default: false,
+    },
+    // null for members that joined before memberships were tracked
+    joinedAt: {
+      type: "timestamp",
+      nullable: true,
+      default: () => "now()",
+    },
+  },
+  relations: {
+    group: {
+      type: "many-to-one",
+      target: "Group",
+      joinColumn: {
+        name: "groupId",
+      },
+      onDelete: "CASCADE",
+    },
+    user: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "userId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_GROUP_MEMBERSHIP_UNIQUE",
+      columns: ["groupId", "userId"],
+      unique: true,
+    },
+  ],
+});
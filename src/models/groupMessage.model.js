@@ -24,6 +24,12 @@ export default new EntitySchema({
       nullable: false,
       comment: "Contenido del mensaje",
     },
+    clientMessageId: {
+      type: "varchar",
+      length: 64,
+      nullable: true,
+      comment: "ID generado por el cliente para deduplicar envíos offline",
+    },
     createdAt: {
       type: "timestamp",
       default: () => "CURRENT_TIMESTAMP",
@@ -33,6 +39,12 @@ export default new EntitySchema({
       default: () => "CURRENT_TIMESTAMP",
       onUpdate: "CURRENT_TIMESTAMP",
     },
+    deletedAt: {
+      type: "timestamp",
+      nullable: true,
+      deleteDate: true,
+      comment: "Borrado lógico (ver repository/softDelete.js)",
+    },
   },
   relations: {
     group: {
@@ -64,5 +76,10 @@ export default new EntitySchema({
       name: "IDX_GROUP_MESSAGE_CREATED_AT",
       columns: ["createdAt"],
     },
+    {
+      name: "IDX_GROUP_MESSAGE_CLIENT_ID",
+      columns: ["groupId", "senderId", "clientMessageId"],
+      unique: true,
+    },
   ],
 });
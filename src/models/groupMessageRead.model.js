@@ -0,0 +1,62 @@
+import { EntitySchema } from "typeorm";
+
+export default new EntitySchema({
+  name: "GroupMessageRead",
+  tableName: "group_message_reads",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    groupId: {
+      type: "uuid",
+      nullable: false,
+      comment: "ID del grupo al que pertenece el marcador de lectura",
+    },
+    userId: {
+      type: "uuid",
+      nullable: false,
+      comment: "ID del usuario dueño del marcador",
+    },
+    lastReadMessageId: {
+      type: "uuid",
+      nullable: true,
+      comment: "Último mensaje leído por el usuario",
+    },
+    lastReadAt: {
+      type: "timestamp",
+      nullable: false,
+      comment: "Fecha de creación del último mensaje leído",
+    },
+    updatedAt: {
+      type: "timestamp",
+      updateDate: true,
+    },
+  },
+  relations: {
+    group: {
+      target: "Group",
+      type: "many-to-one",
+      joinColumn: {
+        name: "groupId",
+      },
+      onDelete: "CASCADE",
+    },
+    user: {
+      target: "User",
+      type: "many-to-one",
+      joinColumn: {
+        name: "userId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_GROUP_MESSAGE_READ_GROUP_USER",
+      columns: ["groupId", "userId"],
+      unique: true,
+    },
+  ],
+});
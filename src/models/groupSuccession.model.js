@@ -0,0 +1,96 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Handover of a trip whose organizer was suspended or stopped showing up
+ * before departure. Ownership is offered to each candidate in turn
+ * (co-organizers, then the most senior member); if nobody takes it, the
+ * members vote.
+ */
+export default new EntitySchema({
+  name: "GroupSuccession",
+  tableName: "group_successions",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    groupId: {
+      type: "uuid",
+      nullable: false,
+    },
+    previousAdminId: {
+      type: "uuid",
+      nullable: false,
+    },
+    // suspended | inactive
+    reason: {
+      type: "varchar",
+      length: 20,
+      nullable: false,
+    },
+    // offering | voting | completed | cancelled | failed
+    status: {
+      type: "varchar",
+      length: 20,
+      default: "offering",
+    },
+    // User IDs still to be offered the trip, in order
+    pendingCandidateIds: {
+      type: "jsonb",
+      default: () => "'[]'",
+    },
+    // Candidates that declined or let the offer expire; not offered again
+    declinedIds: {
+      type: "jsonb",
+      default: () => "'[]'",
+    },
+    currentCandidateId: {
+      type: "uuid",
+      nullable: true,
+    },
+    offerExpiresAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    votingEndsAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    newAdminId: {
+      type: "uuid",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+    resolvedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+  },
+  relations: {
+    group: {
+      type: "many-to-one",
+      target: "Group",
+      joinColumn: {
+        name: "groupId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_GROUP_SUCCESSION_GROUP",
+      columns: ["groupId"],
+    },
+    // At most one succession in progress per trip
+    {
+      name: "IDX_GROUP_SUCCESSION_ACTIVE",
+      columns: ["groupId"],
+      unique: true,
+      where: `status IN ('offering', 'voting')`,
+    },
+  ],
+});
@@ -0,0 +1,50 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * A member's vote for the new organizer when no candidate accepted the
+ * trip. One per member and succession; voting again changes it.
+ */
+export default new EntitySchema({
+  name: "GroupSuccessionVote",
+  tableName: "group_succession_votes",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    successionId: {
+      type: "uuid",
+      nullable: false,
+    },
+    voterId: {
+      type: "uuid",
+      nullable: false,
+    },
+    candidateId: {
+      type: "uuid",
+      nullable: false,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    succession: {
+      type: "many-to-one",
+      target: "GroupSuccession",
+      joinColumn: {
+        name: "successionId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_GROUP_SUCCESSION_VOTE_UNIQUE",
+      columns: ["successionId", "voterId"],
+      unique: true,
+    },
+  ],
+});
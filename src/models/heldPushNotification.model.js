@@ -0,0 +1,57 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Push notification held during the user's quiet hours, delivered in the
+ * digest sent when they end
+ */
+export default new EntitySchema({
+  name: "HeldPushNotification",
+  tableName: "held_push_notifications",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    userId: {
+      type: "uuid",
+      nullable: false,
+    },
+    notificationId: {
+      type: "uuid",
+      nullable: false,
+    },
+    releaseAt: {
+      type: "timestamp",
+      nullable: false,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    user: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "userId",
+      },
+      onDelete: "CASCADE",
+    },
+    notification: {
+      type: "many-to-one",
+      target: "Notification",
+      joinColumn: {
+        name: "notificationId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_HELD_PUSH_USER_RELEASE",
+      columns: ["userId", "releaseAt"],
+    },
+  ],
+});
@@ -0,0 +1,61 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Idempotency-Key sent by a client on a mutating request, with the response
+ * it got, replayed when the client retries the same request
+ */
+export default new EntitySchema({
+  name: "IdempotencyKey",
+  tableName: "idempotency_keys",
+  columns: {
+    // ID of the user the key belongs to; keys of different users never clash
+    scope: {
+      primary: true,
+      type: "varchar",
+      length: 64,
+    },
+    key: {
+      primary: true,
+      type: "varchar",
+      length: 255,
+    },
+    // SHA-256 of method, path and body; a retry must match it
+    requestHash: {
+      type: "varchar",
+      length: 64,
+      nullable: false,
+    },
+    status: {
+      type: "varchar",
+      length: 20,
+      default: "processing", // "processing" | "completed"
+    },
+    responseStatus: {
+      type: "int",
+      nullable: true,
+    },
+    responseBody: {
+      type: "jsonb",
+      nullable: true,
+    },
+    // A request still processing past this is taken as lost and can be retried
+    lockedUntil: {
+      type: "timestamptz",
+      nullable: false,
+    },
+    expiresAt: {
+      type: "timestamptz",
+      nullable: false,
+    },
+    createdAt: {
+      type: "timestamptz",
+      createDate: true,
+    },
+  },
+  indices: [
+    {
+      name: "IDX_IDEMPOTENCY_KEY_EXPIRES",
+      columns: ["expiresAt"],
+    },
+  ],
+});
@@ -48,6 +48,12 @@ export default new EntitySchema({
 export const ItineraryItemSchema = new EntitySchema({
   name: "ItineraryItem",
   tableName: "itinerary_items",
+  indices: [
+    {
+      name: "IDX_ITINERARY_ITEM_DAY",
+      columns: ["itineraryId", "date", "order"],
+    },
+  ],
   columns: {
     id: {
       primary: true,
@@ -66,11 +72,25 @@ export const ItineraryItemSchema = new EntitySchema({
       type: "date",
       nullable: false,
     },
+    // Position within its day
     order: {
       type: "int",
       nullable: false,
       default: 0,
     },
+    time: {
+      type: "time",
+      nullable: true,
+    },
+    notes: {
+      type: "text",
+      nullable: true,
+    },
+    // Estimated cost in cents, like expenses
+    cost: {
+      type: "int",
+      nullable: true,
+    },
     createdAt: {
       type: "timestamp",
       createDate: true,
@@ -0,0 +1,80 @@
+import { EntitySchema } from "typeorm";
+
+export default new EntitySchema({
+  name: "Job",
+  tableName: "jobs",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    type: {
+      type: "varchar",
+      length: 100,
+      nullable: false,
+    },
+    payload: {
+      type: "jsonb",
+      default: {},
+    },
+    // sha256 of the canonical payload, to spot the same payload failing across jobs
+    payloadHash: {
+      type: "varchar",
+      length: 64,
+      nullable: true,
+    },
+    status: {
+      type: "varchar",
+      length: 20,
+      default: "pending", // "pending" | "running" | "completed" | "failed" | "quarantined" | "discarded"
+    },
+    attempts: {
+      type: "int",
+      default: 0,
+    },
+    maxAttempts: {
+      type: "int",
+      default: 5,
+    },
+    runAt: {
+      type: "timestamp",
+      default: () => "CURRENT_TIMESTAMP",
+    },
+    lockedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    lastError: {
+      type: "text",
+      nullable: true,
+    },
+    // Stack of the last failure when it was a crash (a bug rather than a dependency error)
+    lastCrashStack: {
+      type: "text",
+      nullable: true,
+    },
+    completedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+    updatedAt: {
+      type: "timestamp",
+      updateDate: true,
+    },
+  },
+  indices: [
+    {
+      name: "IDX_JOB_STATUS_RUN_AT",
+      columns: ["status", "runAt"],
+    },
+    {
+      name: "IDX_JOB_TYPE_PAYLOAD_HASH",
+      columns: ["type", "payloadHash"],
+    },
+  ],
+});
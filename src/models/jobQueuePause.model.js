@@ -0,0 +1,30 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Job type an admin paused: workers leave its pending jobs in the queue until
+ * it is resumed
+ */
+export default new EntitySchema({
+  name: "JobQueuePause",
+  tableName: "job_queue_pauses",
+  columns: {
+    type: {
+      primary: true,
+      type: "varchar",
+      length: 100,
+    },
+    reason: {
+      type: "varchar",
+      length: 500,
+      nullable: true,
+    },
+    pausedById: {
+      type: "uuid",
+      nullable: true,
+    },
+    pausedAt: {
+      type: "timestamptz",
+      createDate: true,
+    },
+  },
+});
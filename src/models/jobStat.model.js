@@ -0,0 +1,46 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Outcome counters of job attempts per type and hour, for error rates
+ */
+export default new EntitySchema({
+  name: "JobStat",
+  tableName: "job_stats",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    type: {
+      type: "varchar",
+      length: 100,
+      nullable: false,
+    },
+    // Start of the hour (UTC)
+    hour: {
+      type: "timestamptz",
+      nullable: false,
+    },
+    succeeded: {
+      type: "int",
+      default: 0,
+    },
+    failed: {
+      type: "int",
+      default: 0,
+    },
+    // Failures that were crashes (TypeError and the like, or a non-Error thrown)
+    crashed: {
+      type: "int",
+      default: 0,
+    },
+  },
+  indices: [
+    {
+      name: "IDX_JOB_STAT_TYPE_HOUR",
+      columns: ["type", "hour"],
+      unique: true,
+    },
+  ],
+});
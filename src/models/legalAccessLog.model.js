@@ -0,0 +1,58 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Append-only log of every action taken with the legal hold/export tool
+ */
+export default new EntitySchema({
+  name: "LegalAccessLog",
+  tableName: "legal_access_logs",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    actorId: {
+      type: "uuid",
+      nullable: false,
+    },
+    // "hold_created" | "hold_released" | "export_requested" | "export_viewed" | "export_downloaded"
+    action: {
+      type: "varchar",
+      length: 30,
+      nullable: false,
+    },
+    targetUserId: {
+      type: "uuid",
+      nullable: false,
+    },
+    holdId: {
+      type: "uuid",
+      nullable: true,
+    },
+    exportId: {
+      type: "uuid",
+      nullable: true,
+    },
+    ip: {
+      type: "varchar",
+      length: 64,
+      nullable: true,
+    },
+    userAgent: {
+      type: "varchar",
+      length: 255,
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  indices: [
+    {
+      name: "IDX_LEGAL_ACCESS_LOG_TARGET",
+      columns: ["targetUserId", "createdAt"],
+    },
+  ],
+});
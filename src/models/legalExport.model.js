@@ -0,0 +1,73 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Targeted export of an account's data for a legal/compliance request.
+ * Not to be confused with the user-facing data export.
+ */
+export default new EntitySchema({
+  name: "LegalExport",
+  tableName: "legal_exports",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    userId: {
+      type: "uuid",
+      nullable: false,
+    },
+    reference: {
+      type: "varchar",
+      length: 150,
+      nullable: false,
+    },
+    requestedById: {
+      type: "uuid",
+      nullable: false,
+    },
+    status: {
+      type: "varchar",
+      length: 20,
+      default: "pending", // "pending" | "ready" | "failed"
+    },
+    storageKey: {
+      type: "varchar",
+      length: 512,
+      nullable: true,
+    },
+    size: {
+      type: "int",
+      nullable: true,
+    },
+    // Lets the recipient verify the file was not altered
+    sha256: {
+      type: "varchar",
+      length: 64,
+      nullable: true,
+    },
+    // Row count per section
+    summary: {
+      type: "jsonb",
+      nullable: true,
+    },
+    error: {
+      type: "text",
+      nullable: true,
+    },
+    completedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  indices: [
+    {
+      name: "IDX_LEGAL_EXPORT_USER",
+      columns: ["userId", "createdAt"],
+    },
+  ],
+});
@@ -0,0 +1,66 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Freezes deletion of an account's data while a legal/compliance request is open
+ */
+export default new EntitySchema({
+  name: "LegalHold",
+  tableName: "legal_holds",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    userId: {
+      type: "uuid",
+      nullable: false,
+    },
+    // Case number or identifier of the request (court order, subpoena...)
+    reference: {
+      type: "varchar",
+      length: 150,
+      nullable: false,
+    },
+    reason: {
+      type: "text",
+      nullable: true,
+    },
+    status: {
+      type: "varchar",
+      length: 20,
+      default: "active", // "active" | "released"
+    },
+    createdById: {
+      type: "uuid",
+      nullable: false,
+    },
+    releasedById: {
+      type: "uuid",
+      nullable: true,
+    },
+    releasedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    user: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "userId",
+      },
+    },
+  },
+  indices: [
+    {
+      name: "IDX_LEGAL_HOLD_USER_STATUS",
+      columns: ["userId", "status"],
+    },
+  ],
+});
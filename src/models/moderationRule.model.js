@@ -0,0 +1,78 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Automation rule: hides a trip, a trip review or everything a user
+ * published once enough distinct (and trusted enough) users reported it
+ */
+export default new EntitySchema({
+  name: "ModerationRule",
+  tableName: "moderation_rules",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    name: {
+      type: "varchar",
+      length: 100,
+      nullable: false,
+    },
+    // "trip" | "user" (user reports) | "trip_review" (review flags)
+    targetType: {
+      type: "varchar",
+      length: 20,
+      nullable: false,
+    },
+    // Distinct reporters needed to trigger the rule
+    minReporters: {
+      type: "int",
+      nullable: false,
+    },
+    // Reporters with a lower trust score (0-100) are not counted
+    minTrustScore: {
+      type: "int",
+      default: 0,
+    },
+    // Only reports with these reasons count; empty counts any reason
+    reasons: {
+      type: "jsonb",
+      default: [],
+    },
+    // Only reports of the last windowHours count; null counts every open report
+    windowHours: {
+      type: "int",
+      nullable: true,
+    },
+    enabled: {
+      type: "boolean",
+      default: true,
+    },
+    triggerCount: {
+      type: "int",
+      default: 0,
+    },
+    lastTriggeredAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    createdById: {
+      type: "uuid",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+    updatedAt: {
+      type: "timestamp",
+      updateDate: true,
+    },
+  },
+  indices: [
+    {
+      name: "IDX_MODERATION_RULES_TARGET",
+      columns: ["targetType", "enabled"],
+    },
+  ],
+});
@@ -0,0 +1,56 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * A rule acting on a target. A rule acts once per target, so content an
+ * admin restores is not hidden again by the same rule.
+ */
+export default new EntitySchema({
+  name: "ModerationRuleHit",
+  tableName: "moderation_rule_hits",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    ruleId: {
+      type: "uuid",
+      nullable: false,
+    },
+    targetType: {
+      type: "varchar",
+      length: 20,
+      nullable: false,
+    },
+    // Group, user or trip review ID
+    targetId: {
+      type: "uuid",
+      nullable: false,
+    },
+    // Reporters that counted towards the rule
+    reporterIds: {
+      type: "jsonb",
+      default: [],
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    rule: {
+      type: "many-to-one",
+      target: "ModerationRule",
+      joinColumn: {
+        name: "ruleId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  uniques: [
+    {
+      name: "UQ_MODERATION_RULE_HITS_TARGET",
+      columns: ["ruleId", "targetId"],
+    },
+  ],
+});
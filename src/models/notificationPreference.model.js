@@ -0,0 +1,72 @@
+import { EntitySchema } from "typeorm";
+
+export default new EntitySchema({
+  name: "NotificationPreference",
+  tableName: "notification_preferences",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    userId: {
+      type: "uuid",
+      nullable: false,
+    },
+    pushEnabled: {
+      type: "boolean",
+      default: true,
+    },
+    emailEnabled: {
+      type: "boolean",
+      default: true,
+    },
+    // Per-category overrides: { chat: { push: false }, trip_updates: { email: true } }
+    categories: {
+      type: "jsonb",
+      default: {},
+    },
+    // Quiet hours in the user's local time ("HH:MM"); null when not set
+    quietHoursStart: {
+      type: "varchar",
+      length: 5,
+      nullable: true,
+    },
+    quietHoursEnd: {
+      type: "varchar",
+      length: 5,
+      nullable: true,
+    },
+    // IANA time zone used to evaluate quiet hours and to show dates in pushes
+    timezone: {
+      type: "varchar",
+      length: 64,
+      default: "UTC",
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+    updatedAt: {
+      type: "timestamp",
+      updateDate: true,
+    },
+  },
+  relations: {
+    user: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "userId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_NOTIFICATION_PREFERENCE_USER",
+      columns: ["userId"],
+      unique: true,
+    },
+  ],
+});
@@ -0,0 +1,85 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Domain event waiting to be published to the message broker (transactional
+ * outbox): written in the same transaction as the change it describes, then
+ * published in order by the relay (see services/domainEvent.service.js).
+ * An event that keeps failing is parked as failed so it stops holding back
+ * the ones after it.
+ */
+export default new EntitySchema({
+  name: "OutboxEvent",
+  tableName: "outbox_events",
+  columns: {
+    // Publishing order
+    id: {
+      primary: true,
+      type: "bigint",
+      generated: "increment",
+    },
+    // ID consumers deduplicate on (delivery is at least once)
+    eventId: {
+      type: "uuid",
+      nullable: false,
+      unique: true,
+    },
+    // e.g. "TripCreated"
+    type: {
+      type: "varchar",
+      length: 100,
+      nullable: false,
+    },
+    aggregateType: {
+      type: "varchar",
+      length: 50,
+      nullable: false,
+    },
+    aggregateId: {
+      type: "varchar",
+      length: 100,
+      nullable: false,
+    },
+    payload: {
+      type: "jsonb",
+      default: {},
+    },
+    occurredAt: {
+      type: "timestamp",
+      nullable: false,
+    },
+    publishedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    // pending | published | failed (gave up after EVENTS_RELAY_MAX_ATTEMPTS)
+    status: {
+      type: "varchar",
+      length: 20,
+      default: "pending",
+    },
+    attempts: {
+      type: "int",
+      default: 0,
+    },
+    lastError: {
+      type: "text",
+      nullable: true,
+    },
+    // Not retried before this after a failed publish
+    availableAt: {
+      type: "timestamp",
+      nullable: false,
+    },
+    // Set while a relay publishes the event; other relays wait until it passes
+    claimedUntil: {
+      type: "timestamp",
+      nullable: true,
+    },
+  },
+  indices: [
+    {
+      name: "IDX_OUTBOX_EVENT_UNPUBLISHED",
+      columns: ["publishedAt", "id"],
+    },
+  ],
+});
@@ -0,0 +1,81 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Trip a user made outside JoinTravel, imported to backfill their travel
+ * history (entered by hand or clustered from photo metadata on the client)
+ */
+export default new EntitySchema({
+  name: "PastTrip",
+  tableName: "past_trips",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    userId: {
+      type: "uuid",
+      nullable: false,
+    },
+    city: {
+      type: "varchar",
+      length: 120,
+      nullable: true,
+    },
+    // ISO 3166-1 alpha-2, feeds the countries-visited stats
+    countryCode: {
+      type: "varchar",
+      length: 2,
+      nullable: true,
+    },
+    lat: {
+      type: "decimal",
+      precision: 10,
+      scale: 8,
+      nullable: true,
+    },
+    lng: {
+      type: "decimal",
+      precision: 11,
+      scale: 8,
+      nullable: true,
+    },
+    startDate: {
+      type: "date",
+      nullable: false,
+    },
+    endDate: {
+      type: "date",
+      nullable: false,
+    },
+    source: {
+      type: "varchar",
+      length: 10,
+      default: "manual", // "manual" | "photos"
+    },
+    photoCount: {
+      type: "int",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    user: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "userId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_PAST_TRIP_USER_DATES",
+      columns: ["userId", "startDate"],
+    },
+  ],
+});
@@ -0,0 +1,100 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Payment made through Stripe for a trip (deposit or fee).
+ * Status changes go through the state machine in payment.service.
+ */
+export default new EntitySchema({
+  name: "Payment",
+  tableName: "payments",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    userId: {
+      type: "uuid",
+      nullable: false,
+    },
+    groupId: {
+      type: "uuid",
+      nullable: false,
+    },
+    joinRequestId: {
+      type: "uuid",
+      nullable: true,
+    },
+    kind: {
+      type: "varchar",
+      length: 20,
+      default: "deposit", // "deposit" | "fee"
+    },
+    // Cents
+    amount: {
+      type: "int",
+      nullable: false,
+    },
+    currency: {
+      type: "varchar",
+      length: 3,
+      nullable: false,
+    },
+    // "requires_payment" | "processing" | "succeeded" | "failed" | "canceled" | "refund_pending" | "refunded"
+    status: {
+      type: "varchar",
+      length: 20,
+      default: "requires_payment",
+    },
+    stripePaymentIntentId: {
+      type: "varchar",
+      length: 255,
+      nullable: true,
+      unique: true,
+    },
+    stripeRefundId: {
+      type: "varchar",
+      length: 255,
+      nullable: true,
+    },
+    failureReason: {
+      type: "text",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+    updatedAt: {
+      type: "timestamp",
+      updateDate: true,
+    },
+  },
+  relations: {
+    user: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "userId",
+      },
+    },
+    group: {
+      type: "many-to-one",
+      target: "Group",
+      joinColumn: {
+        name: "groupId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_PAYMENT_GROUP_USER",
+      columns: ["groupId", "userId"],
+    },
+    {
+      name: "IDX_PAYMENT_JOIN_REQUEST",
+      columns: ["joinRequestId"],
+    },
+  ],
+});
@@ -0,0 +1,38 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Push text set by an admin for a notification type and language, used
+ * instead of the built-in template in utils/pushTemplates.js
+ */
+export default new EntitySchema({
+  name: "PushTemplateOverride",
+  tableName: "push_template_overrides",
+  columns: {
+    type: {
+      primary: true,
+      type: "varchar",
+      length: 60,
+    },
+    language: {
+      primary: true,
+      type: "varchar",
+      length: 5,
+    },
+    title: {
+      type: "varchar",
+      length: 150,
+    },
+    body: {
+      type: "varchar",
+      length: 500,
+    },
+    updatedById: {
+      type: "uuid",
+      nullable: true,
+    },
+    updatedAt: {
+      type: "timestamptz",
+      updateDate: true,
+    },
+  },
+});
@@ -0,0 +1,51 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Event sent to a realtime room, kept for REALTIME_POLL_BUFFER_SECONDS so
+ * long-polling clients of any API instance can read it after their cursor
+ * (see socket/realtime.js)
+ */
+export default new EntitySchema({
+  name: "RealtimeEvent",
+  tableName: "realtime_events",
+  columns: {
+    // Poll cursor
+    id: {
+      primary: true,
+      type: "bigint",
+      generated: "increment",
+    },
+    // A user ID or group_<groupId>
+    room: {
+      type: "varchar",
+      length: 100,
+      nullable: false,
+    },
+    // e.g. "new_group_message"
+    event: {
+      type: "varchar",
+      length: 100,
+      nullable: false,
+    },
+    data: {
+      type: "jsonb",
+      nullable: true,
+    },
+    // Process that published it
+    origin: {
+      type: "varchar",
+      length: 50,
+      nullable: false,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  indices: [
+    {
+      name: "IDX_REALTIME_EVENT_ROOM",
+      columns: ["room", "id"],
+    },
+  ],
+});
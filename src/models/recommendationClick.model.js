@@ -0,0 +1,53 @@
+import { EntitySchema } from "typeorm";
+
+export default new EntitySchema({
+  name: "RecommendationClick",
+  tableName: "recommendation_clicks",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    emailId: {
+      type: "uuid",
+      nullable: false,
+    },
+    userId: {
+      type: "uuid",
+      nullable: false,
+    },
+    groupId: {
+      type: "uuid",
+      nullable: false,
+    },
+    clickedAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    email: {
+      type: "many-to-one",
+      target: "RecommendationEmail",
+      joinColumn: {
+        name: "emailId",
+      },
+      onDelete: "CASCADE",
+    },
+    group: {
+      type: "many-to-one",
+      target: "Group",
+      joinColumn: {
+        name: "groupId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_RECOMMENDATION_CLICK_USER",
+      columns: ["userId"],
+    },
+  ],
+});
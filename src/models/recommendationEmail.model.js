@@ -0,0 +1,51 @@
+import { EntitySchema } from "typeorm";
+
+export default new EntitySchema({
+  name: "RecommendationEmail",
+  tableName: "recommendation_emails",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    userId: {
+      type: "uuid",
+      nullable: false,
+    },
+    // [{ groupId, score }] in the order they were shown
+    items: {
+      type: "jsonb",
+      default: [],
+    },
+    status: {
+      type: "varchar",
+      length: 20,
+      default: "pending", // "pending" | "sent" | "failed"
+    },
+    sentAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    user: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "userId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_RECOMMENDATION_EMAIL_USER_SENT",
+      columns: ["userId", "sentAt"],
+    },
+  ],
+});
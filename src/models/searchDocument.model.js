@@ -0,0 +1,58 @@
+import { EntitySchema } from "typeorm";
+
+export default new EntitySchema({
+  name: "SearchDocument",
+  tableName: "search_documents",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    entityType: {
+      type: "varchar",
+      length: 20,
+      nullable: false, // "trip" | "destination" | "user"
+    },
+    entityId: {
+      type: "uuid",
+      nullable: false,
+    },
+    title: {
+      type: "varchar",
+      length: 255,
+      nullable: false,
+    },
+    subtitle: {
+      type: "varchar",
+      length: 255,
+      nullable: true,
+    },
+    body: {
+      type: "text",
+      nullable: true,
+    },
+    // Private trips stay indexed so they reappear in results when made public
+    isPublic: {
+      type: "boolean",
+      default: true,
+    },
+    // Weighted title (A), subtitle (B) and body (C); the GIN index is created at startup
+    searchVector: {
+      type: "tsvector",
+      nullable: true,
+      select: false,
+    },
+    updatedAt: {
+      type: "timestamp",
+      updateDate: true,
+    },
+  },
+  indices: [
+    {
+      name: "IDX_SEARCH_DOCUMENT_ENTITY",
+      columns: ["entityType", "entityId"],
+      unique: true,
+    },
+  ],
+});
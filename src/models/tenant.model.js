@@ -0,0 +1,77 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * White-label brand. Its ID is the X-Tenant-Id clients send; users who sign
+ * up under it get its branding in every email.
+ */
+export default new EntitySchema({
+  name: "Tenant",
+  tableName: "tenants",
+  columns: {
+    // Lowercase slug, e.g. "andes-trips"
+    id: {
+      primary: true,
+      type: "varchar",
+      length: 40,
+    },
+    name: {
+      type: "varchar",
+      length: 120,
+      nullable: false,
+    },
+    // { logoUrl, primaryColor, textColor, footerText }
+    branding: {
+      type: "jsonb",
+      default: {},
+    },
+    // Domain emails are sent from once its DKIM record is verified
+    senderDomain: {
+      type: "varchar",
+      length: 253,
+      nullable: true,
+    },
+    // Local part of the sender address ("viajes" for viajes@senderDomain)
+    senderLocalPart: {
+      type: "varchar",
+      length: 64,
+      default: "no-reply",
+    },
+    replyTo: {
+      type: "varchar",
+      length: 255,
+      nullable: true,
+    },
+    dkimSelector: {
+      type: "varchar",
+      length: 63,
+      nullable: true,
+    },
+    dkimPublicKey: {
+      type: "text",
+      nullable: true,
+    },
+    // Never returned by default queries
+    dkimPrivateKey: {
+      type: "text",
+      nullable: true,
+      select: false,
+    },
+    // Set when the DNS records were last found in place; cleared on changes
+    senderVerifiedAt: {
+      type: "timestamptz",
+      nullable: true,
+    },
+    active: {
+      type: "boolean",
+      default: true,
+    },
+    createdAt: {
+      type: "timestamptz",
+      createDate: true,
+    },
+    updatedAt: {
+      type: "timestamptz",
+      updateDate: true,
+    },
+  },
+});
@@ -0,0 +1,76 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Custom domain a tenant serves its API and web apps on. Requests to it are
+ * resolved to the tenant once the DNS TXT challenge is verified.
+ */
+export default new EntitySchema({
+  name: "TenantDomain",
+  tableName: "tenant_domains",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    tenantId: {
+      type: "varchar",
+      length: 40,
+      nullable: false,
+    },
+    // Lowercase, e.g. "api.andestrips.com"
+    hostname: {
+      type: "varchar",
+      length: 253,
+      nullable: false,
+      unique: true,
+    },
+    // Value the tenant publishes in the TXT challenge record
+    verificationToken: {
+      type: "varchar",
+      length: 64,
+      nullable: false,
+    },
+    verifiedAt: {
+      type: "timestamptz",
+      nullable: true,
+    },
+    certificateStatus: {
+      type: "varchar",
+      length: 20,
+      default: "none", // "none" | "requested" | "issued" | "failed"
+    },
+    certificateError: {
+      type: "text",
+      nullable: true,
+    },
+    certificateRequestedAt: {
+      type: "timestamptz",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamptz",
+      createDate: true,
+    },
+    updatedAt: {
+      type: "timestamptz",
+      updateDate: true,
+    },
+  },
+  relations: {
+    tenant: {
+      type: "many-to-one",
+      target: "Tenant",
+      joinColumn: {
+        name: "tenantId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_TENANT_DOMAIN_TENANT",
+      columns: ["tenantId"],
+    },
+  ],
+});
@@ -0,0 +1,82 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Last availability published to aggregators for a trip. changedAt only moves
+ * when a field the feed exposes changes, which is what delta syncs query.
+ * A trip that stops being bookable stays here as "closed" so aggregators
+ * syncing deltas learn to drop it.
+ */
+export default new EntitySchema({
+  name: "TripAvailability",
+  tableName: "trip_availability",
+  columns: {
+    // No foreign key: the row outlives a trip erased for good
+    groupId: {
+      primary: true,
+      type: "uuid",
+    },
+    status: {
+      type: "varchar",
+      length: 10,
+      nullable: false, // "open" | "full" | "closed"
+    },
+    name: {
+      type: "varchar",
+      nullable: true,
+    },
+    destinationName: {
+      type: "varchar",
+      nullable: true,
+    },
+    destinationLat: {
+      type: "decimal",
+      precision: 10,
+      scale: 8,
+      nullable: true,
+    },
+    destinationLng: {
+      type: "decimal",
+      precision: 11,
+      scale: 8,
+      nullable: true,
+    },
+    startDate: {
+      type: "date",
+      nullable: true,
+    },
+    endDate: {
+      type: "date",
+      nullable: true,
+    },
+    capacity: {
+      type: "int",
+      nullable: true,
+    },
+    spotsLeft: {
+      type: "int",
+      nullable: true,
+    },
+    // Deposit charged to join, in cents; null for free trips
+    priceAmount: {
+      type: "int",
+      nullable: true,
+    },
+    priceCurrency: {
+      type: "varchar",
+      length: 3,
+      nullable: true,
+    },
+    // With time zone and millisecond precision: aggregators send it back as an
+    // ISO cursor and it has to compare equal
+    changedAt: {
+      type: "timestamptz",
+      nullable: false,
+    },
+  },
+  indices: [
+    {
+      name: "IDX_TRIP_AVAILABILITY_CHANGED",
+      columns: ["changedAt", "groupId"],
+    },
+  ],
+});
@@ -0,0 +1,96 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Safety incident reported by a traveler during a trip. Opens a high
+ * severity moderation case (user_reports) and alerts the safety staff.
+ */
+export default new EntitySchema({
+  name: "TripIncident",
+  tableName: "trip_incidents",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    groupId: {
+      type: "uuid",
+      nullable: false,
+    },
+    reporterId: {
+      type: "uuid",
+      nullable: false,
+    },
+    type: {
+      type: "varchar",
+      length: 20,
+      nullable: false, // "harassment" | "injury" | "theft" | "other"
+    },
+    description: {
+      type: "text",
+      nullable: false,
+    },
+    // Fellow traveler involved, if any
+    involvedUserId: {
+      type: "uuid",
+      nullable: true,
+    },
+    lat: {
+      type: "decimal",
+      precision: 9,
+      scale: 6,
+      nullable: true,
+    },
+    lng: {
+      type: "decimal",
+      precision: 9,
+      scale: 6,
+      nullable: true,
+    },
+    // Moderation case opened for the incident
+    reportId: {
+      type: "uuid",
+      nullable: false,
+    },
+    emergencyContactsNotified: {
+      type: "int",
+      default: 0,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    group: {
+      type: "many-to-one",
+      target: "Group",
+      joinColumn: {
+        name: "groupId",
+      },
+      onDelete: "CASCADE",
+    },
+    reporter: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "reporterId",
+      },
+      onDelete: "CASCADE",
+    },
+    report: {
+      type: "many-to-one",
+      target: "UserReport",
+      joinColumn: {
+        name: "reportId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_TRIP_INCIDENT_GROUP",
+      columns: ["groupId", "createdAt"],
+    },
+  ],
+});
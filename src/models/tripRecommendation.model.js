@@ -0,0 +1,41 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Ranked open trips for a user, computed by services/tripRecommendation.service.js
+ * and refreshed by a background job. Only ids and scores are kept: the trips
+ * are read fresh when served, so full or joined trips drop out on their own.
+ */
+export default new EntitySchema({
+  name: "TripRecommendation",
+  tableName: "trip_recommendations",
+  columns: {
+    userId: {
+      primary: true,
+      type: "uuid",
+    },
+    // [{ groupId, score, reasons }], best first
+    items: {
+      type: "jsonb",
+      default: [],
+    },
+    computedAt: {
+      type: "timestamp",
+    },
+    // Set when a refresh job is queued, so stale reads queue one job at most
+    refreshQueuedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+  },
+  relations: {
+    user: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "userId",
+        referencedColumnName: "id",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+});
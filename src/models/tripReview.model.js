@@ -0,0 +1,103 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Rating of a finished trip and its organizer by a participant (one per trip)
+ */
+export default new EntitySchema({
+  name: "TripReview",
+  tableName: "trip_reviews",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    groupId: {
+      type: "uuid",
+      nullable: false,
+    },
+    authorId: {
+      type: "uuid",
+      nullable: false,
+    },
+    // Organizer when the review was written, so the rating stays with them
+    organizerId: {
+      type: "uuid",
+      nullable: false,
+    },
+    tripRating: {
+      type: "int",
+      nullable: false,
+    },
+    organizerRating: {
+      type: "int",
+      nullable: false,
+    },
+    comment: {
+      type: "text",
+      nullable: true,
+    },
+    status: {
+      type: "varchar",
+      length: 20,
+      default: "visible", // "visible" | "hidden"
+    },
+    flagCount: {
+      type: "int",
+      default: 0,
+    },
+    // Weight of the author in weighted averages, from their trust score
+    // (see trustScoreService.getReviewWeight); refreshed daily
+    authorWeight: {
+      type: "decimal",
+      precision: 3,
+      scale: 2,
+      default: 1,
+    },
+    // "suspected" when part of a cluster of reciprocal 5-star organizer
+    // ratings: left out of weighted averages and listed for moderation until
+    // an admin restores it ("cleared", not flagged again)
+    ringStatus: {
+      type: "varchar",
+      length: 10,
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+    updatedAt: {
+      type: "timestamp",
+      updateDate: true,
+    },
+  },
+  relations: {
+    author: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "authorId",
+      },
+    },
+    group: {
+      type: "many-to-one",
+      target: "Group",
+      joinColumn: {
+        name: "groupId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  uniques: [
+    {
+      name: "UQ_TRIP_REVIEW_AUTHOR",
+      columns: ["groupId", "authorId"],
+    },
+  ],
+  indices: [
+    {
+      name: "IDX_TRIP_REVIEW_ORGANIZER",
+      columns: ["organizerId", "status"],
+    },
+  ],
+});
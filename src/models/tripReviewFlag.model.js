@@ -0,0 +1,53 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Report of an inappropriate trip review (one per user and review)
+ */
+export default new EntitySchema({
+  name: "TripReviewFlag",
+  tableName: "trip_review_flags",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    reviewId: {
+      type: "uuid",
+      nullable: false,
+    },
+    userId: {
+      type: "uuid",
+      nullable: false,
+    },
+    reason: {
+      type: "varchar",
+      length: 30,
+      nullable: false, // "spam" | "offensive" | "false_information" | "other"
+    },
+    details: {
+      type: "text",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    review: {
+      type: "many-to-one",
+      target: "TripReview",
+      joinColumn: {
+        name: "reviewId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  uniques: [
+    {
+      name: "UQ_TRIP_REVIEW_FLAG_USER",
+      columns: ["reviewId", "userId"],
+    },
+  ],
+});
@@ -0,0 +1,59 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * A user's acceptance of one version of a trip's rules. Kept for every
+ * version, so the organizer can tell who agreed to what and when.
+ */
+export default new EntitySchema({
+  name: "TripRuleAcceptance",
+  tableName: "trip_rule_acceptances",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    groupId: {
+      type: "uuid",
+      nullable: false,
+    },
+    userId: {
+      type: "uuid",
+      nullable: false,
+    },
+    // groups.rulesVersion accepted
+    version: {
+      type: "int",
+      nullable: false,
+    },
+    acceptedAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    group: {
+      type: "many-to-one",
+      target: "Group",
+      joinColumn: {
+        name: "groupId",
+      },
+      onDelete: "CASCADE",
+    },
+    user: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "userId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_TRIP_RULE_ACCEPTANCE_UNIQUE",
+      columns: ["groupId", "userId", "version"],
+      unique: true,
+    },
+  ],
+});
@@ -27,10 +27,150 @@ export default new EntitySchema({
       type: "integer",
       nullable: true,
     },
+    dateOfBirth: {
+      type: "date",
+      nullable: true,
+    },
+    // ISO 3166-1 alpha-2, selects the jurisdiction rules
+    country: {
+      type: "varchar",
+      length: 2,
+      nullable: true,
+    },
+    // White-label tenant the user signed up under (custom domain or X-Tenant-Id); brands their emails
+    tenantId: {
+      type: "varchar",
+      length: 40,
+      nullable: true,
+    },
+    // Home city, matched against trip origins ("trips from my city")
+    homeCity: {
+      type: "varchar",
+      length: 150,
+      nullable: true,
+    },
+    homeLat: {
+      type: "decimal",
+      precision: 10,
+      scale: 8,
+      nullable: true,
+    },
+    homeLng: {
+      type: "decimal",
+      precision: 11,
+      scale: 8,
+      nullable: true,
+    },
+    // Features restricted by an admin age review. Accounts under the minimum age
+    // of a "gate" jurisdiction are restricted from their date of birth until they
+    // come of age (isAgeRestricted in utils/ageVerification.js)
+    ageRestricted: {
+      type: "boolean",
+      default: false,
+    },
+    // ISO 4217 code used to display amounts (expenses, balances)
+    preferredCurrency: {
+      type: "varchar",
+      length: 3,
+      nullable: true,
+    },
     profilePicture: {
       type: "varchar",
       nullable: true,
     },
+    bio: {
+      type: "varchar",
+      length: 500,
+      nullable: true,
+    },
+    // ISO 639-1 codes of the languages the user speaks
+    languages: {
+      type: "jsonb",
+      default: [],
+    },
+    // Language of the API messages, emails and pushes (SUPPORTED_LOCALES in utils/i18n.js)
+    locale: {
+      type: "varchar",
+      length: 5,
+      nullable: true,
+    },
+    // { styles: [], budget, pace }
+    travelPreferences: {
+      type: "jsonb",
+      default: {},
+    },
+    // Per-field visibility for other users: { bio: "public", email: "private" }
+    profileVisibility: {
+      type: "jsonb",
+      default: {},
+    },
+    role: {
+      type: "varchar",
+      length: 20,
+      default: "user", // "user" | "admin"
+    },
+    // Who to alert when the user reports a safety incident: [{ name, email, phone }]
+    emergencyContacts: {
+      type: "jsonb",
+      default: [],
+    },
+    // "never" | "on_request" (when the report asks for it) | "always"
+    emergencyContactAlerts: {
+      type: "varchar",
+      length: 20,
+      default: "on_request",
+    },
+    // Last authenticated request, updated at most hourly; spots organizers who abandoned a trip
+    lastSeenAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    // TOTP secret encrypted with TWO_FACTOR_ENCRYPTION_KEY; 2FA is on once twoFactorEnabledAt is set
+    twoFactorSecret: {
+      type: "text",
+      nullable: true,
+      select: false,
+    },
+    twoFactorEnabledAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    // SHA-256 of the unused backup codes
+    twoFactorBackupCodes: {
+      type: "jsonb",
+      nullable: true,
+      select: false,
+    },
+    // Time step of the last code accepted, so a code cannot be replayed
+    twoFactorLastStep: {
+      type: "integer",
+      nullable: true,
+    },
+    // Suspended accounts cannot log in or use their tokens until an admin lifts it
+    suspendedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    // Identity checked by an admin, for policies with requirements.idVerification
+    idVerifiedAt: {
+      type: "timestamptz",
+      nullable: true,
+    },
+    suspensionReason: {
+      type: "text",
+      nullable: true,
+    },
+    // Account deletion requested by the user: the account is closed right away
+    // and its data erased by a background job after a grace period.
+    // Soft delete without a TypeORM delete date column (see repository/softDelete.js)
+    deletedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    anonymizedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
     isEmailConfirmed: {
       type: "boolean",
       default: false,
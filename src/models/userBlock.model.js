@@ -0,0 +1,57 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * A user blocking another. Blocks hide trips, messages and profiles in both directions.
+ */
+export default new EntitySchema({
+  name: "UserBlock",
+  tableName: "user_blocks",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    blockerId: {
+      type: "uuid",
+      nullable: false,
+    },
+    blockedId: {
+      type: "uuid",
+      nullable: false,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    blocker: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "blockerId",
+      },
+      onDelete: "CASCADE",
+    },
+    blocked: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "blockedId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_USER_BLOCK_PAIR",
+      columns: ["blockerId", "blockedId"],
+      unique: true,
+    },
+    {
+      name: "IDX_USER_BLOCK_BLOCKED",
+      columns: ["blockedId"],
+    },
+  ],
+});
@@ -0,0 +1,69 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * A Google or Apple account linked to a user, so they can sign in with it.
+ * Identified by the provider's stable subject, not the email, which users
+ * can change (or hide behind Apple's relay) on the provider side.
+ */
+export default new EntitySchema({
+  name: "UserIdentity",
+  tableName: "user_identities",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    userId: {
+      type: "uuid",
+      nullable: false,
+    },
+    // google | apple
+    provider: {
+      type: "varchar",
+      length: 20,
+      nullable: false,
+    },
+    // "sub" claim of the provider's ID token
+    subject: {
+      type: "varchar",
+      length: 255,
+      nullable: false,
+    },
+    // Email the provider reported when the account was linked
+    email: {
+      type: "varchar",
+      length: 255,
+      nullable: true,
+    },
+    lastLoginAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    user: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "userId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_USER_IDENTITY_PROVIDER_SUBJECT",
+      columns: ["provider", "subject"],
+      unique: true,
+    },
+    {
+      name: "IDX_USER_IDENTITY_USER",
+      columns: ["userId"],
+    },
+  ],
+});
@@ -0,0 +1,113 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * Report of a user or a trip, reviewed and actioned by an administrator
+ */
+export default new EntitySchema({
+  name: "UserReport",
+  tableName: "user_reports",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    reporterId: {
+      type: "uuid",
+      nullable: false,
+    },
+    // "user" | "trip"
+    targetType: {
+      type: "varchar",
+      length: 10,
+      nullable: false,
+    },
+    // Reported user, or organizer of the reported trip
+    targetUserId: {
+      type: "uuid",
+      nullable: false,
+    },
+    targetGroupId: {
+      type: "uuid",
+      nullable: true,
+    },
+    reason: {
+      type: "varchar",
+      length: 30,
+      nullable: false,
+    },
+    details: {
+      type: "text",
+      nullable: true,
+    },
+    status: {
+      type: "varchar",
+      length: 20,
+      default: "open", // "open" | "actioned" | "dismissed"
+    },
+    // "high" for safety incidents reported during a trip, reviewed first
+    severity: {
+      type: "varchar",
+      length: 10,
+      default: "normal", // "normal" | "high"
+    },
+    // Action taken when actioned: "warning" | "trip_hidden" | "content_hidden" (bulk, every trip and review of the user)
+    action: {
+      type: "varchar",
+      length: 20,
+      nullable: true,
+    },
+    resolvedById: {
+      type: "uuid",
+      nullable: true,
+    },
+    resolutionNotes: {
+      type: "text",
+      nullable: true,
+    },
+    resolvedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    reporter: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "reporterId",
+      },
+      onDelete: "CASCADE",
+    },
+    targetUser: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "targetUserId",
+      },
+      onDelete: "CASCADE",
+    },
+    targetGroup: {
+      type: "many-to-one",
+      target: "Group",
+      joinColumn: {
+        name: "targetGroupId",
+      },
+      onDelete: "SET NULL",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_USER_REPORT_STATUS",
+      columns: ["status", "createdAt"],
+    },
+    {
+      name: "IDX_USER_REPORT_TARGET",
+      columns: ["targetUserId"],
+    },
+  ],
+});
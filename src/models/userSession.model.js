@@ -0,0 +1,79 @@
+import { EntitySchema } from "typeorm";
+
+/**
+ * A signed-in device. Its refresh token carries the session ID and the ID of
+ * the current token (refreshJti), which changes on every refresh; revoking
+ * the session logs the device out.
+ */
+export default new EntitySchema({
+  name: "UserSession",
+  tableName: "user_sessions",
+  columns: {
+    id: {
+      primary: true,
+      type: "uuid",
+      generated: "uuid",
+    },
+    userId: {
+      type: "uuid",
+      nullable: false,
+    },
+    // Sent by the app (e.g. "iPhone de Ana"); the user agent is shown otherwise
+    deviceName: {
+      type: "varchar",
+      length: 100,
+      nullable: true,
+    },
+    userAgent: {
+      type: "varchar",
+      length: 255,
+      nullable: true,
+    },
+    ipAddress: {
+      type: "varchar",
+      length: 45,
+      nullable: true,
+    },
+    refreshJti: {
+      type: "uuid",
+      nullable: false,
+    },
+    lastSeenAt: {
+      type: "timestamp",
+      nullable: false,
+    },
+    // Expiry of the current refresh token
+    expiresAt: {
+      type: "timestamp",
+      nullable: false,
+    },
+    revokedAt: {
+      type: "timestamp",
+      nullable: true,
+    },
+    createdAt: {
+      type: "timestamp",
+      createDate: true,
+    },
+  },
+  relations: {
+    user: {
+      type: "many-to-one",
+      target: "User",
+      joinColumn: {
+        name: "userId",
+      },
+      onDelete: "CASCADE",
+    },
+  },
+  indices: [
+    {
+      name: "IDX_USER_SESSIONS_USER",
+      columns: ["userId"],
+    },
+    {
+      name: "IDX_USER_SESSIONS_EXPIRES_AT",
+      columns: ["expiresAt"],
+    },
+  ],
+});
@@ -0,0 +1,106 @@
+import txManager from "./transactionManager.js";
+
+// Private data of the user, deleted outright when the account is erased
+const PERSONAL_TABLES = {
+  device_tokens: ["userId"],
+  notifications: ["userId"],
+  notification_preferences: ["userId"],
+  past_trips: ["userId"],
+  user_followers: ["followerId", "followedId"],
+  user_blocks: ["blockerId", "blockedId"],
+  user_favorites: ["userId"],
+  user_actions: ["userId"],
+  user_rate_limits: ["userId"],
+  recommendation_emails: ["userId"],
+  recommendation_clicks: ["userId"],
+  trip_recommendations: ["userId"],
+  chat_messages: ["userId"],
+  conversations: ["userId"],
+  group_message_reads: ["userId"],
+  group_join_requests: ["userId"],
+  group_invites: ["createdById"],
+  group_members: ["userId"],
+  group_memberships: ["userId"],
+  user_identities: ["userId"],
+  user_sessions: ["userId"],
+  calendar_feeds: ["userId"],
+  // Their stored files are deleted first (accountService.eraseAccount)
+  attachments: ["userId"],
+  // Onboarding answers; trip answers are keyed by group ID and never match
+  form_answers: ["subjectId"],
+  group_succession_votes: ["voterId", "candidateId"],
+};
+
+/**
+ * Erasure of accounts whose deletion was requested by their owner
+ */
+class AccountRepository {
+  /**
+   * Groups organized by the user with the member that should take over,
+   * the longest-standing one (null when nobody else is left)
+   * @param {string} userId
+   * @returns {Promise<Array>} - [{ groupId, successorId }]
+   */
+  async findOrganizedGroupsWithSuccessor(userId) {
+    return await txManager.query(
+      `SELECT g.id AS "groupId",
+              (SELECT gm."userId"
+               FROM group_members gm
+               LEFT JOIN group_memberships ms ON ms."groupId" = gm."groupId" AND ms."userId" = gm."userId"
+               WHERE gm."groupId" = g.id AND gm."userId" <> $1
+               ORDER BY ms."joinedAt" ASC NULLS LAST, gm."userId" ASC
+               LIMIT 1) AS "successorId"
+       FROM groups g
+       WHERE g."adminId" = $1`,
+      [userId]
+    );
+  }
+
+  /**
+   * Deletes the private data of the user and anonymizes the account row.
+   * Content shared with others (messages, reviews, expenses, payments) is kept
+   * under the anonymized account so group history and balances stay consistent.
+   * @param {string} userId
+   */
+  async anonymize(userId) {
+    await txManager.run(async (manager) => {
+      for (const [table, columns] of Object.entries(PERSONAL_TABLES)) {
+        const conditions = columns.map((column) => `"${column}" = $1`).join(" OR ");
+        await manager.query(`DELETE FROM ${table} WHERE ${conditions}`, [userId]);
+      }
+      await manager.query(`DELETE FROM api_usage_daily WHERE "subjectType" = 'user' AND "subjectId" = $1`, [
+        userId,
+      ]);
+      await manager.query(
+        `DELETE FROM list_places WHERE "listId" IN (SELECT id FROM lists WHERE "userId" = $1)`,
+        [userId]
+      );
+      await manager.query(`DELETE FROM lists WHERE "userId" = $1`, [userId]);
+      // Itineraries still assigned to a group belong to that group's trip now
+      await manager.query(
+        `DELETE FROM itineraries
+         WHERE "userId" = $1
+           AND id NOT IN (SELECT "assignedItineraryId" FROM groups WHERE "assignedItineraryId" IS NOT NULL)`,
+        [userId]
+      );
+
+      await manager.query(
+        `UPDATE users SET
+           email = 'deleted+' || id || '@jointravel.invalid',
+           password = '!',
+           name = NULL, age = NULL, "dateOfBirth" = NULL, country = NULL,
+           "homeCity" = NULL, "homeLat" = NULL, "homeLng" = NULL,
+           "preferredCurrency" = NULL, "profilePicture" = NULL, bio = NULL,
+           languages = '[]', "travelPreferences" = '{}', "profileVisibility" = '{}',
+           "emergencyContacts" = '[]',
+           "emailConfirmationToken" = NULL, "emailConfirmationExpires" = NULL,
+           "passwordResetToken" = NULL, "passwordResetExpires" = NULL,
+           "anonymizedAt" = NOW()
+         WHERE id = $1`,
+        [userId]
+      );
+    });
+  }
+}
+
+export default new AccountRepository();
@@ -0,0 +1,132 @@
+import txManager from "./transactionManager.js";
+import AffiliatePartner from "../models/affiliatePartner.model.js";
+import AffiliateAttribution from "../models/affiliateAttribution.model.js";
+
+// A first booking counts while its payment has not been refunded
+const COUNTED_PAYMENT_STATUSES = ["succeeded", "refund_pending"];
+
+class AffiliateRepository {
+  getPartnerRepository() {
+    return txManager.getRepository(AffiliatePartner);
+  }
+
+  getAttributionRepository() {
+    return txManager.getRepository(AffiliateAttribution);
+  }
+
+  async createPartner(data) {
+    const repo = this.getPartnerRepository();
+    return await repo.save(repo.create(data));
+  }
+
+  async findPartnerById(id) {
+    return await this.getPartnerRepository().findOne({ where: { id } });
+  }
+
+  async findPartnerByCode(code) {
+    return await this.getPartnerRepository().findOne({ where: { code } });
+  }
+
+  async findPartnerByUser(userId) {
+    return await this.getPartnerRepository().findOne({ where: { userId } });
+  }
+
+  async findPartners() {
+    return await this.getPartnerRepository().find({ order: { createdAt: "DESC" } });
+  }
+
+  async updatePartner(id, data) {
+    await this.getPartnerRepository().update(id, data);
+    return await this.findPartnerById(id);
+  }
+
+  async createAttribution(data) {
+    const repo = this.getAttributionRepository();
+    return await repo.save(repo.create(data));
+  }
+
+  async findAttributionByUser(userId) {
+    return await this.getAttributionRepository().findOne({ where: { userId } });
+  }
+
+  /**
+   * Stores the first booking of an attributed user. Later bookings, and a
+   * webhook delivered twice, leave the attribution untouched.
+   * @param {string} userId
+   * @param {Object} data - { firstBookingAt, firstBookingPaymentId, firstBookingAmount, commissionAmount }
+   * @returns {Promise<boolean>} Whether this was the first booking
+   */
+  async recordFirstBooking(userId, data) {
+    const result = await this.getAttributionRepository()
+      .createQueryBuilder()
+      .update()
+      .set(data)
+      .where(`"userId" = :userId AND "firstBookingAt" IS NULL`, { userId })
+      .execute();
+    return result.affected > 0;
+  }
+
+  /**
+   * Signups and first bookings of every partner within a date window
+   * @param {string} from - YYYY-MM-DD, inclusive
+   * @param {string} to - YYYY-MM-DD, exclusive
+   * @returns {Promise<Array>} - [{ partnerId, signups, confirmedSignups, firstBookings, bookingVolume, bookingCommission }]
+   */
+  async getTotalsByPartner(from, to) {
+    return await txManager.query(
+      `SELECT p.id AS "partnerId",
+              COUNT(a.id) FILTER (WHERE a."createdAt" >= $1 AND a."createdAt" < $2)::int AS signups,
+              COUNT(a.id) FILTER (WHERE a."createdAt" >= $1 AND a."createdAt" < $2
+                                    AND u."isEmailConfirmed")::int AS "confirmedSignups",
+              COUNT(pay.id) FILTER (WHERE a."firstBookingAt" >= $1 AND a."firstBookingAt" < $2)::int AS "firstBookings",
+              COALESCE(SUM(a."firstBookingAmount") FILTER (WHERE pay.id IS NOT NULL
+                         AND a."firstBookingAt" >= $1 AND a."firstBookingAt" < $2), 0)::int AS "bookingVolume",
+              COALESCE(SUM(a."commissionAmount") FILTER (WHERE pay.id IS NOT NULL
+                         AND a."firstBookingAt" >= $1 AND a."firstBookingAt" < $2), 0)::int AS "bookingCommission"
+       FROM affiliate_partners p
+       LEFT JOIN affiliate_attributions a ON a."partnerId" = p.id
+       LEFT JOIN users u ON u.id = a."userId"
+       LEFT JOIN payments pay ON pay.id = a."firstBookingPaymentId"
+                             AND pay.status = ANY($3)
+       GROUP BY p.id`,
+      [from, to, COUNTED_PAYMENT_STATUSES]
+    );
+  }
+
+  /**
+   * Signups and first bookings of a partner per calendar month
+   * @param {string} partnerId
+   * @param {string} since - YYYY-MM-DD
+   * @returns {Promise<Array>} - [{ month: "YYYY-MM", signups, confirmedSignups, firstBookings, bookingVolume, bookingCommission }]
+   */
+  async getMonthlyTotals(partnerId, since) {
+    return await txManager.query(
+      `WITH events AS (
+         SELECT to_char(a."createdAt", 'YYYY-MM') AS month,
+                1 AS signup, CASE WHEN u."isEmailConfirmed" THEN 1 ELSE 0 END AS confirmed,
+                0 AS booking, 0 AS amount, 0 AS commission
+         FROM affiliate_attributions a
+         JOIN users u ON u.id = a."userId"
+         WHERE a."partnerId" = $1 AND a."createdAt" >= $2
+         UNION ALL
+         SELECT to_char(a."firstBookingAt", 'YYYY-MM'),
+                0, 0, 1, a."firstBookingAmount", a."commissionAmount"
+         FROM affiliate_attributions a
+         JOIN payments pay ON pay.id = a."firstBookingPaymentId" AND pay.status = ANY($3)
+         WHERE a."partnerId" = $1 AND a."firstBookingAt" >= $2
+       )
+       SELECT month,
+              SUM(signup)::int AS signups,
+              SUM(confirmed)::int AS "confirmedSignups",
+              SUM(booking)::int AS "firstBookings",
+              COALESCE(SUM(amount), 0)::int AS "bookingVolume",
+              COALESCE(SUM(commission), 0)::int AS "bookingCommission"
+       FROM events
+       GROUP BY month
+       ORDER BY month DESC`,
+      [partnerId, since, COUNTED_PAYMENT_STATUSES]
+    );
+  }
+}
+
+export default new AffiliateRepository();
@@ -0,0 +1,44 @@
+import txManager from "./transactionManager.js";
+import AgeReview from "../models/ageReview.model.js";
+
+class AgeReviewRepository {
+  getRepository() {
+    return txManager.getRepository(AgeReview);
+  }
+
+  async create(data) {
+    const review = this.getRepository().create(data);
+    return await this.getRepository().save(review);
+  }
+
+  async findById(id) {
+    return await this.getRepository().findOne({
+      where: { id },
+      relations: ["user"],
+    });
+  }
+
+  async findOpenByUser(userId) {
+    return await this.getRepository().findOne({
+      where: { userId, status: "open" },
+    });
+  }
+
+  /**
+   * @param {string|null} status - "open" | "cleared" | "restricted"
+   */
+  async findByStatus(status = null) {
+    return await this.getRepository().find({
+      where: status ? { status } : {},
+      relations: ["user"],
+      order: { createdAt: "ASC" },
+    });
+  }
+
+  async update(id, data) {
+    await this.getRepository().update(id, data);
+    return await this.findById(id);
+  }
+}
+
+export default new AgeReviewRepository();
@@ -0,0 +1,65 @@
+import txManager from "./transactionManager.js";
+import AggregatorPartner from "../models/aggregatorPartner.model.js";
+
+class AggregatorRepository {
+  getRepository() {
+    return txManager.getRepository(AggregatorPartner);
+  }
+
+  async create(data) {
+    const repo = this.getRepository();
+    return await repo.save(repo.create(data));
+  }
+
+  async findById(id) {
+    return await this.getRepository().findOne({ where: { id } });
+  }
+
+  async findByKeyHash(apiKeyHash) {
+    return await this.getRepository().findOne({ where: { apiKeyHash } });
+  }
+
+  async findAll() {
+    return await this.getRepository().find({ order: { createdAt: "DESC" } });
+  }
+
+  async update(id, data) {
+    await this.getRepository().update(id, data);
+    return await this.findById(id);
+  }
+
+  /**
+   * Counts a feed request against today's usage (UTC)
+   * @param {string} partnerId
+   * @returns {Promise<number>} Requests made today, this one included
+   */
+  async incrementUsage(partnerId) {
+    const [row] = await txManager.query(
+      `INSERT INTO aggregator_usage (id, "partnerId", day, requests)
+       VALUES (gen_random_uuid(), $1, (now() AT TIME ZONE 'UTC')::date, 1)
+       ON CONFLICT ("partnerId", day) DO UPDATE SET requests = aggregator_usage.requests + 1
+       RETURNING requests`,
+      [partnerId]
+    );
+    await this.getRepository().update(partnerId, { lastUsedAt: new Date() });
+    return row.requests;
+  }
+
+  /**
+   * Requests per day of a partner, most recent first
+   * @param {string} partnerId
+   * @param {number} days
+   * @returns {Promise<Array>} - [{ day, requests }]
+   */
+  async findUsage(partnerId, days) {
+    return await txManager.query(
+      `SELECT day::text AS day, requests
+       FROM aggregator_usage
+       WHERE "partnerId" = $1 AND day > (now() AT TIME ZONE 'UTC')::date - $2::int
+       ORDER BY day DESC`,
+      [partnerId, days]
+    );
+  }
+}
+
+export default new AggregatorRepository();
@@ -1,4 +1,4 @@
-import { AppDataSource } from "../load/typeorm.loader.js";
+import txManager from "./transactionManager.js";
 import Answer from "../models/answer.model.js";
 
 class AnswerRepository {
@@ -6,7 +6,7 @@ class AnswerRepository {
 
   // Inicializa el repositorio cuando TypeORM esté listo
   getRepository() {
-    return AppDataSource.getRepository(Answer);
+    return txManager.getRepository(Answer);
   }
 
   /**
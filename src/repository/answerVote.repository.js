@@ -1,4 +1,4 @@
-import { AppDataSource } from "../load/typeorm.loader.js";
+import txManager from "./transactionManager.js";
 import AnswerVote from "../models/answerVote.model.js";
 
 class AnswerVoteRepository {
@@ -6,7 +6,7 @@ class AnswerVoteRepository {
 
   // Inicializa el repositorio cuando TypeORM esté listo
   getRepository() {
-    return AppDataSource.getRepository(AnswerVote);
+    return txManager.getRepository(AnswerVote);
   }
 
   /**
@@ -0,0 +1,86 @@
+import txManager from "./transactionManager.js";
+import ApiUsageDaily from "../models/apiUsageDaily.model.js";
+
+class ApiUsageRepository {
+  getRepository() {
+    return txManager.getRepository(ApiUsageDaily);
+  }
+
+  /**
+   * Adds buffered counters to the daily aggregates in one statement
+   * @param {Array} rows - [{ subjectType, subjectId, day, requests, costUnits, errors }]
+   */
+  async addBatch(rows) {
+    if (rows.length === 0) return;
+    const values = [];
+    const params = [];
+    rows.forEach((row, index) => {
+      const base = index * 6;
+      values.push(
+        `(gen_random_uuid(), $${base + 1}, $${base + 2}, $${base + 3}::date, $${base + 4}, $${base + 5}, $${base + 6})`
+      );
+      params.push(row.subjectType, row.subjectId, row.day, row.requests, row.costUnits, row.errors);
+    });
+    await txManager.query(
+      `INSERT INTO api_usage_daily (id, "subjectType", "subjectId", day, requests, "costUnits", errors)
+       VALUES ${values.join(", ")}
+       ON CONFLICT ("subjectType", "subjectId", day) DO UPDATE SET
+         requests = api_usage_daily.requests + EXCLUDED.requests,
+         "costUnits" = api_usage_daily."costUnits" + EXCLUDED."costUnits",
+         errors = api_usage_daily.errors + EXCLUDED.errors`,
+      params
+    );
+  }
+
+  async findDay(subjectType, subjectId, day) {
+    return await this.getRepository().findOne({ where: { subjectType, subjectId, day } });
+  }
+
+  /**
+   * Daily aggregates of a subject between two UTC days (inclusive), most recent first
+   */
+  async findRange(subjectType, subjectId, from, to) {
+    return await txManager.query(
+      `SELECT day::text AS day, requests, "costUnits", errors
+       FROM api_usage_daily
+       WHERE "subjectType" = $1 AND "subjectId" = $2 AND day BETWEEN $3::date AND $4::date
+       ORDER BY day DESC`,
+      [subjectType, subjectId, from, to]
+    );
+  }
+
+  /**
+   * Subjects with the highest cost between two UTC days (inclusive)
+   * @returns {Promise<Array>} - [{ subjectType, subjectId, requests, costUnits, errors }]
+   */
+  async findTop({ subjectType, from, to, limit }) {
+    const params = [from, to, limit];
+    let filter = "";
+    if (subjectType) {
+      params.push(subjectType);
+      filter = `AND "subjectType" = $4`;
+    }
+    const rows = await txManager.query(
+      `SELECT "subjectType", "subjectId",
+              SUM(requests)::int AS requests, SUM("costUnits")::int AS "costUnits", SUM(errors)::int AS errors
+       FROM api_usage_daily
+       WHERE day BETWEEN $1::date AND $2::date ${filter}
+       GROUP BY "subjectType", "subjectId"
+       ORDER BY "costUnits" DESC
+       LIMIT $3`,
+      params
+    );
+    return rows;
+  }
+
+  async deleteBefore(day) {
+    const result = await this.getRepository()
+      .createQueryBuilder()
+      .delete()
+      .where("day < :day", { day })
+      .execute();
+    return result.affected || 0;
+  }
+}
+
+export default new ApiUsageRepository();
@@ -0,0 +1,60 @@
+import { LessThan } from "typeorm";
+import txManager from "./transactionManager.js";
+import Attachment from "../models/attachment.model.js";
+
+class AttachmentRepository {
+  getRepository() {
+    return txManager.getRepository(Attachment);
+  }
+
+  async create(data) {
+    const attachment = this.getRepository().create(data);
+    return await this.getRepository().save(attachment);
+  }
+
+  async findById(id) {
+    return await this.getRepository().findOne({ where: { id } });
+  }
+
+  /**
+   * Lists the uploaded attachments of an entity, newest first
+   * @param {string} entityType - "group" | "user"
+   * @param {string} entityId
+   */
+  async findByEntity(entityType, entityId) {
+    return await this.getRepository().find({
+      where: { entityType, entityId, status: "uploaded" },
+      order: { createdAt: "DESC" },
+    });
+  }
+
+  /**
+   * Every attachment a user uploaded, confirmed or not
+   * @param {string} userId
+   */
+  async findByUser(userId) {
+    return await this.getRepository().find({ where: { userId } });
+  }
+
+  /**
+   * Uploaded attachments still waiting for the antivirus since before a date
+   * @param {Date} before
+   */
+  async findPendingScans(before) {
+    return await this.getRepository().find({
+      where: { status: "uploaded", scanStatus: "pending", createdAt: LessThan(before) },
+      select: ["id"],
+    });
+  }
+
+  async update(id, data) {
+    await this.getRepository().update(id, data);
+    return await this.findById(id);
+  }
+
+  async delete(id) {
+    await this.getRepository().delete(id);
+  }
+}
+
+export default new AttachmentRepository();
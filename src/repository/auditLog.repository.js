@@ -0,0 +1,62 @@
+import txManager from "./transactionManager.js";
+import AuditLog from "../models/auditLog.model.js";
+
+/**
+ * Audit entries can only be inserted and read; there is deliberately no
+ * update or delete here, and the database rejects them as well (see the
+ * AuditLogsAppendOnly migration).
+ */
+class AuditLogRepository {
+  getRepository() {
+    return txManager.getRepository(AuditLog);
+  }
+
+  async create(data) {
+    await this.getRepository().insert(data);
+  }
+
+  /**
+   * Entries matching the filters, newest first
+   * @param {Object} filters - { actorId, action, from, to, before, limit }
+   *   where before is the entry ID to continue after
+   */
+  async find({ actorId = null, action = null, from = null, to = null, before = null, limit = 50 }) {
+    const query = this.getRepository().createQueryBuilder("log");
+
+    if (actorId) {
+      query.andWhere("log.actorId = :actorId", { actorId });
+    }
+    if (action) {
+      // "admin.*" matches every admin action
+      if (action.endsWith(".*")) {
+        query.andWhere("log.action LIKE :prefix", { prefix: `${action.slice(0, -1)}%` });
+      } else {
+        query.andWhere("log.action = :action", { action });
+      }
+    }
+    if (from) {
+      query.andWhere("log.createdAt >= :from", { from });
+    }
+    if (to) {
+      query.andWhere("log.createdAt < :to", { to });
+    }
+    if (before) {
+      const cursor = await this.getRepository().findOne({ where: { id: before } });
+      if (!cursor) {
+        return null;
+      }
+      query.andWhere(
+        "(log.createdAt < :cursorAt OR (log.createdAt = :cursorAt AND log.id < :cursorId))",
+        { cursorAt: cursor.createdAt, cursorId: cursor.id }
+      );
+    }
+
+    return await query
+      .orderBy("log.createdAt", "DESC")
+      .addOrderBy("log.id", "DESC")
+      .take(limit)
+      .getMany();
+  }
+}
+
+export default new AuditLogRepository();
@@ -0,0 +1,71 @@
+import txManager from "./transactionManager.js";
+import BookingEvent from "../models/bookingEvent.model.js";
+
+class BookingEventRepository {
+  getRepository() {
+    return txManager.getRepository(BookingEvent);
+  }
+
+  async create(data) {
+    await this.getRepository().insert(data);
+  }
+
+  /**
+   * Events of a booking, oldest first
+   * @param {string} bookingId
+   */
+  async findByBooking(bookingId) {
+    return await this.getRepository().find({
+      where: { bookingId },
+      order: { occurredAt: "ASC", id: "ASC" },
+    });
+  }
+
+  /**
+   * Notifications sent about a booking. Older notifications carry only the
+   * join request or payment ID in their data.
+   * @param {string} bookingId
+   * @param {string[]} paymentIds
+   */
+  async findNotifications(bookingId, paymentIds) {
+    return await txManager.query(
+      `SELECT id, "userId", type, title, read, "createdAt"
+       FROM notifications
+       WHERE data->>'bookingId' = $1
+          OR data->>'requestId' = $1
+          OR data->>'paymentId' = ANY($2::text[])
+       ORDER BY "createdAt" ASC`,
+      [bookingId, paymentIds]
+    );
+  }
+
+  /**
+   * Background jobs enqueued for a booking
+   * @param {string} bookingId
+   */
+  async findJobs(bookingId) {
+    return await txManager.query(
+      `SELECT id, type, status, attempts, "lastError", "runAt", "createdAt", "updatedAt"
+       FROM jobs
+       WHERE payload->>'bookingId' = $1
+       ORDER BY "createdAt" ASC`,
+      [bookingId]
+    );
+  }
+
+  /**
+   * Audit entries about the booking or its payments
+   * @param {string[]} targetIds
+   */
+  async findAuditEntries(targetIds) {
+    return await txManager.query(
+      `SELECT id, "actorId", action, "targetType", "targetId", metadata, "createdAt"
+       FROM audit_logs
+       WHERE "targetId" = ANY($1::text[])
+       ORDER BY "createdAt" ASC`,
+      [targetIds]
+    );
+  }
+}
+
+export default new BookingEventRepository();
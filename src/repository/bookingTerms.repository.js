@@ -0,0 +1,44 @@
+import txManager from "./transactionManager.js";
+import BookingTerms from "../models/bookingTerms.model.js";
+
+/**
+ * Insert-only: booking terms are never updated (the database rejects it) or
+ * deleted on their own
+ */
+class BookingTermsRepository {
+  getRepository() {
+    return txManager.getRepository(BookingTerms);
+  }
+
+  /**
+   * Stores the terms of a booking; a booking that already has them keeps the first ones
+   * @returns {Promise<Object>} The stored terms
+   */
+  async create({ joinRequestId, groupId, userId, terms, contentHash }) {
+    const [row] = await txManager.query(
+      `INSERT INTO booking_terms (id, "joinRequestId", "groupId", "userId", terms, "contentHash", "createdAt")
+       VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, now())
+       ON CONFLICT ("joinRequestId") DO NOTHING
+       RETURNING *`,
+      [joinRequestId, groupId, userId, JSON.stringify(terms), contentHash]
+    );
+    return row || (await this.findByJoinRequest(joinRequestId));
+  }
+
+  async findByJoinRequest(joinRequestId) {
+    return await this.getRepository().findOne({ where: { joinRequestId } });
+  }
+
+  /**
+   * Terms of every booking of a user on a trip, latest first (a member who
+   * left and rejoined has one per booking)
+   */
+  async findByGroupAndUser(groupId, userId) {
+    return await this.getRepository().find({
+      where: { groupId, userId },
+      order: { createdAt: "DESC" },
+    });
+  }
+}
+
+export default new BookingTermsRepository();
@@ -0,0 +1,41 @@
+import txManager from "./transactionManager.js";
+import CalendarFeed from "../models/calendarFeed.model.js";
+
+class CalendarFeedRepository {
+  getRepository() {
+    return txManager.getRepository(CalendarFeed);
+  }
+
+  async findByUser(userId) {
+    return await this.getRepository().findOne({ where: { userId } });
+  }
+
+  async findByTokenHash(tokenHash) {
+    return await this.getRepository().findOne({ where: { tokenHash } });
+  }
+
+  /**
+   * Creates the feed of a user or replaces its token
+   */
+  async upsert({ userId, tokenHash, tokenPrefix }) {
+    await this.getRepository().upsert(
+      { userId, tokenHash, tokenPrefix, lastFetchedAt: null, createdAt: new Date() },
+      ["userId"]
+    );
+    return await this.findByUser(userId);
+  }
+
+  async touch(userId) {
+    await this.getRepository().update({ userId }, { lastFetchedAt: new Date() });
+  }
+
+  /**
+   * @returns {Promise<boolean>} Whether the user had a feed
+   */
+  async delete(userId) {
+    const result = await this.getRepository().delete({ userId });
+    return result.affected > 0;
+  }
+}
+
+export default new CalendarFeedRepository();
@@ -1,8 +1,9 @@
-import { AppDataSource } from "../load/typeorm.loader.js";
+import txManager from "./transactionManager.js";
 
 class ChatMessageRepository {
-  constructor() {
-    this.repository = AppDataSource.getRepository("ChatMessage");
+  // Resolved on use so it picks up the open transaction
+  get repository() {
+    return txManager.getRepository("ChatMessage");
   }
 
   /**
@@ -0,0 +1,95 @@
+import txManager from "./transactionManager.js";
+import CompanionReference from "../models/companionReference.model.js";
+
+class CompanionReferenceRepository {
+  getRepository() {
+    return txManager.getRepository(CompanionReference);
+  }
+
+  async create(data) {
+    const reference = this.getRepository().create(data);
+    return await this.getRepository().save(reference);
+  }
+
+  async findById(id) {
+    return await this.getRepository().findOne({
+      where: { id },
+      relations: ["author", "group"],
+    });
+  }
+
+  async findExisting(authorId, subjectId, groupId) {
+    return await this.getRepository().findOne({
+      where: { authorId, subjectId, groupId },
+    });
+  }
+
+  /**
+   * References about a user with a given status, newest first
+   * @param {string} subjectId
+   * @param {string} status - "pending" | "confirmed" | "declined"
+   */
+  async findBySubject(subjectId, status) {
+    return await this.getRepository().find({
+      where: { subjectId, status },
+      relations: ["author", "group"],
+      order: { createdAt: "DESC" },
+    });
+  }
+
+  async update(id, data) {
+    await this.getRepository().update(id, data);
+    return await this.findById(id);
+  }
+
+  /**
+   * Checks that both users were members of a trip that has already ended
+   * (end date derived from the assigned itinerary)
+   * @returns {Promise<boolean>}
+   */
+  async sharedCompletedTrip(userA, userB, groupId) {
+    const rows = await txManager.query(
+      `SELECT 1
+       FROM groups g
+       JOIN group_members a ON a."groupId" = g.id AND a."userId" = $1
+       JOIN group_members b ON b."groupId" = g.id AND b."userId" = $2
+       WHERE g.id = $3
+         AND g."deletedAt" IS NULL
+         AND (SELECT MAX(ii.date) FROM itinerary_items ii
+              WHERE ii."itineraryId" = g."assignedItineraryId") < CURRENT_DATE`,
+      [userA, userB, groupId]
+    );
+    return rows.length > 0;
+  }
+
+  /**
+   * Number of completed trips of a user
+   */
+  async countCompletedTrips(userId) {
+    const [row] = await txManager.query(
+      `SELECT COUNT(*)::int AS count
+       FROM groups g
+       JOIN group_members gm ON gm."groupId" = g.id AND gm."userId" = $1
+       WHERE g."deletedAt" IS NULL
+         AND (SELECT MAX(ii.date) FROM itinerary_items ii
+              WHERE ii."itineraryId" = g."assignedItineraryId") < CURRENT_DATE`,
+      [userId]
+    );
+    return row.count;
+  }
+
+  /**
+   * Number of distinct co-travelers who left a confirmed reference about a user
+   */
+  async countConfirmedAuthors(subjectId) {
+    const [row] = await txManager.query(
+      `SELECT COUNT(DISTINCT "authorId")::int AS count
+       FROM companion_references
+       WHERE "subjectId" = $1 AND status = 'confirmed'`,
+      [subjectId]
+    );
+    return row.count;
+  }
+}
+
+export default new CompanionReferenceRepository();
@@ -1,8 +1,9 @@
-import { AppDataSource } from "../load/typeorm.loader.js";
+import txManager from "./transactionManager.js";
 
 class ConversationRepository {
-  constructor() {
-    this.repository = AppDataSource.getRepository("Conversation");
+  // Resolved on use so it follows the data source in place at the time
+  get repository() {
+    return txManager.getRepository("Conversation");
   }
 
   /**
@@ -0,0 +1,82 @@
+import txManager from "./transactionManager.js";
+import CronRun from "../models/cronRun.model.js";
+
+class CronRunRepository {
+  getRepository() {
+    return txManager.getRepository(CronRun);
+  }
+
+  async start(task) {
+    const repo = this.getRepository();
+    return await repo.save(repo.create({ task, status: "running", startedAt: new Date() }));
+  }
+
+  /**
+   * @param {Object} run - Run returned by start()
+   * @param {Object} outcome - { status: "succeeded" | "failed", error }
+   */
+  async finish(run, { status, error = null }) {
+    const finishedAt = new Date();
+    await this.getRepository().update(run.id, {
+      status,
+      finishedAt,
+      durationMs: finishedAt.getTime() - new Date(run.startedAt).getTime(),
+      error: error ? String(error).slice(0, 2000) : null,
+    });
+  }
+
+  /**
+   * Runs, newest first
+   * @param {Object} filters - { task, status, limit, offset }
+   * @returns {Promise<Object>} - { runs, total }
+   */
+  async findHistory({ task, status, limit, offset }) {
+    const [runs, total] = await this.getRepository().findAndCount({
+      where: { ...(task ? { task } : {}), ...(status ? { status } : {}) },
+      order: { startedAt: "DESC", id: "ASC" },
+      take: limit,
+      skip: offset,
+    });
+    return { runs, total };
+  }
+
+  /**
+   * Most recent entry of every task, plus when it last ran and last succeeded
+   * @returns {Promise<Array>} - [{ task, status, startedAt, finishedAt, durationMs, error, lastRunAt, lastSucceededAt }]
+   */
+  async findLatestByTask() {
+    return await txManager.query(
+      `SELECT DISTINCT ON (r.task) r.task, r.status, r."startedAt", r."finishedAt", r."durationMs", r.error,
+              (SELECT MAX(s."startedAt") FROM cron_runs s
+               WHERE s.task = r.task AND s.status <> 'missed') AS "lastRunAt",
+              (SELECT MAX(s."finishedAt") FROM cron_runs s
+               WHERE s.task = r.task AND s.status = 'succeeded') AS "lastSucceededAt"
+       FROM cron_runs r
+       ORDER BY r.task, r."startedAt" DESC`
+    );
+  }
+
+  /**
+   * Records a missed run unless the task ran, or was already reported as
+   * missed, after `since`. Every API and worker process watches the schedule,
+   * so the check runs under a per-task lock to report it once.
+   * @param {string} task
+   * @param {Date} since
+   * @returns {Promise<Object|null>} Recorded entry, or null when there was nothing to report
+   */
+  async recordMissed(task, since) {
+    return await txManager.run(async (manager) => {
+      await manager.query(`SELECT pg_advisory_xact_lock(hashtext($1))`, [`cron_runs:${task}`]);
+      const rows = await manager.query(
+        `INSERT INTO cron_runs (id, task, status, "startedAt")
+         SELECT gen_random_uuid(), $1, 'missed', NOW()
+         WHERE NOT EXISTS (SELECT 1 FROM cron_runs WHERE task = $1 AND "startedAt" > $2)
+         RETURNING *`,
+        [task, since]
+      );
+      return rows[0] || null;
+    });
+  }
+}
+
+export default new CronRunRepository();
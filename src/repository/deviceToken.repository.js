@@ -0,0 +1,79 @@
+import txManager from "./transactionManager.js";
+import DeviceToken from "../models/deviceToken.model.js";
+
+class DeviceTokenRepository {
+  getRepository() {
+    return txManager.getRepository(DeviceToken);
+  }
+
+  /**
+   * Registers a device token, moving it to the given user if it already exists
+   * (a device that logs in with another account must stop receiving the old user's pushes)
+   * @param {Object} data - { userId, token, platform, provider, deviceName }
+   * @returns {Promise<DeviceToken>}
+   */
+  async upsert({ userId, token, platform, provider, deviceName = null }) {
+    const existing = await this.getRepository().findOne({ where: { token } });
+
+    if (existing) {
+      existing.userId = userId;
+      existing.platform = platform;
+      existing.provider = provider;
+      existing.deviceName = deviceName;
+      existing.lastSeenAt = new Date();
+      return await this.getRepository().save(existing);
+    }
+
+    const deviceToken = this.getRepository().create({
+      userId,
+      token,
+      platform,
+      provider,
+      deviceName,
+      lastSeenAt: new Date(),
+    });
+    return await this.getRepository().save(deviceToken);
+  }
+
+  /**
+   * Gets all device tokens of a user
+   * @param {string} userId
+   * @returns {Promise<DeviceToken[]>}
+   */
+  async findByUserId(userId) {
+    return await this.getRepository().find({
+      where: { userId },
+      order: { lastSeenAt: "DESC" },
+    });
+  }
+
+  /**
+   * Removes a token owned by a user
+   * @param {string} userId
+   * @param {string} token
+   * @returns {Promise<boolean>} true if a token was removed
+   */
+  async deleteForUser(userId, token) {
+    const result = await this.getRepository().delete({ userId, token });
+    return result.affected > 0;
+  }
+
+  /**
+   * Removes tokens rejected by the push provider
+   * @param {string[]} tokens
+   * @returns {Promise<number>} Number of removed tokens
+   */
+  async deleteTokens(tokens) {
+    if (!tokens || tokens.length === 0) {
+      return 0;
+    }
+    const result = await this.getRepository()
+      .createQueryBuilder()
+      .delete()
+      .where("token IN (:...tokens)", { tokens })
+      .execute();
+    return result.affected || 0;
+  }
+}
+
+export default new DeviceTokenRepository();
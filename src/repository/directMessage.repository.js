@@ -1,9 +1,12 @@
-import { AppDataSource } from "../load/typeorm.loader.js";
+import txManager from "./transactionManager.js";
 import DirectMessage from "../models/directMessage.model.js";
+import { softDeleteById, restoreById, findTrashed, findByIdWithDeleted } from "./softDelete.js";
+import { notBlockedBetween } from "./userBlock.repository.js";
 
 class DirectMessageRepository {
-  constructor() {
-    this.repository = AppDataSource.getRepository("DirectMessage");
+  // Resolved on use so it follows the data source in place at the time
+  get repository() {
+    return txManager.getRepository("DirectMessage");
   }
 
   /**
@@ -53,7 +56,11 @@ class DirectMessageRepository {
       .createQueryBuilder("dm")
       .leftJoinAndSelect("dm.sender", "sender")
       .leftJoinAndSelect("dm.receiver", "receiver")
-      .where("dm.senderId = :userId OR dm.receiverId = :userId", { userId })
+      .where("(dm.senderId = :userId OR dm.receiverId = :userId)", { userId })
+      // Conversations with users blocked in either direction are hidden
+      .andWhere(
+        notBlockedBetween(":userId", `CASE WHEN dm."senderId" = :userId THEN dm."receiverId" ELSE dm."senderId" END`)
+      )
       .orderBy("dm.createdAt", "DESC")
       .getMany();
 
@@ -76,13 +83,14 @@ class DirectMessageRepository {
    * Mark messages as read
    * @param {string} conversationId - Conversation ID
    * @param {string} userId - User ID (receiver)
-   * @returns {Promise<void>}
+   * @returns {Promise<number>} Messages marked as read
    */
   async markAsRead(conversationId, userId) {
-    await this.repository.update(
+    const result = await this.repository.update(
       { conversationId, receiverId: userId, isRead: false },
       { isRead: true }
     );
+    return result.affected || 0;
   }
 
   /**
@@ -107,6 +115,68 @@ class DirectMessageRepository {
       where: { conversationId, receiverId: userId, isRead: false },
     });
   }
+
+  /**
+   * Count unread messages of a user per conversation
+   * @param {string} userId - User ID (receiver)
+   * @returns {Promise<Array>} [{ conversationId, otherUserId, unreadCount }]
+   */
+  async countUnreadGroupedByConversation(userId) {
+    return await this.repository
+      .createQueryBuilder("dm")
+      .select('dm."conversationId"', "conversationId")
+      .addSelect('dm."senderId"', "otherUserId")
+      .addSelect("COUNT(dm.id)::int", "unreadCount")
+      .where("dm.receiverId = :userId AND dm.isRead = false", { userId })
+      .groupBy('dm."conversationId"')
+      .addGroupBy('dm."senderId"')
+      .getRawMany();
+  }
+
+  /**
+   * Find a message by ID
+   * @param {string} messageId
+   * @returns {Promise<Object|null>}
+   */
+  async findById(messageId) {
+    return await this.repository.findOne({ where: { id: messageId } });
+  }
+
+  /**
+   * Soft-delete a message (restorable by an admin)
+   * @param {string} messageId
+   * @returns {Promise<boolean>} false if it does not exist or was already deleted
+   */
+  async softDelete(messageId) {
+    return await softDeleteById(this.repository, messageId);
+  }
+
+  /**
+   * Restore a deleted message
+   * @param {string} messageId
+   * @returns {Promise<boolean>} false if it does not exist or was not deleted
+   */
+  async restore(messageId) {
+    return await restoreById(this.repository, messageId);
+  }
+
+  /**
+   * Deleted messages, most recently deleted first
+   * @param {Object} options - { limit, offset }
+   * @returns {Promise<Array>}
+   */
+  async findTrashed({ limit, offset }) {
+    return await findTrashed(this.repository, { relations: ["sender", "receiver"], limit, offset });
+  }
+
+  /**
+   * Find a message by ID, deleted or not
+   * @param {string} messageId
+   * @returns {Promise<Object|null>}
+   */
+  async findByIdWithDeleted(messageId) {
+    return await findByIdWithDeleted(this.repository, messageId);
+  }
 }
 
 export default new DirectMessageRepository();
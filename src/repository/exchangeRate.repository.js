@@ -0,0 +1,25 @@
+import txManager from "./transactionManager.js";
+import ExchangeRate from "../models/exchangeRate.model.js";
+
+class ExchangeRateRepository {
+  getRepository() {
+    return txManager.getRepository(ExchangeRate);
+  }
+
+  async findAll() {
+    return await this.getRepository().find({ order: { currency: "ASC" } });
+  }
+
+  /**
+   * Replaces every stored rate so all rows keep sharing one base
+   * @param {Object[]} rows - [{ currency, rate, base, source, rateDate }]
+   */
+  async replaceAll(rows) {
+    await txManager.run(async (manager) => {
+      await manager.query(`DELETE FROM exchange_rates`);
+      await manager.getRepository(ExchangeRate).save(rows);
+    });
+  }
+}
+
+export default new ExchangeRateRepository();
@@ -0,0 +1,35 @@
+import txManager from "./transactionManager.js";
+import FeatureFlag from "../models/featureFlag.model.js";
+
+class FeatureFlagRepository {
+  getRepository() {
+    return txManager.getRepository(FeatureFlag);
+  }
+
+  async findAll() {
+    return await this.getRepository().find({ order: { key: "ASC" } });
+  }
+
+  async findByKey(key) {
+    return await this.getRepository().findOne({ where: { key } });
+  }
+
+  /**
+   * Creates or replaces the runtime setting of a flag
+   * @param {Object} data - { key, description, enabled, rolloutPercent, updatedById }
+   */
+  async upsert(data) {
+    await this.getRepository().upsert(data, ["key"]);
+    return await this.findByKey(data.key);
+  }
+
+  /**
+   * @returns {Promise<boolean>} Whether the flag had a runtime setting
+   */
+  async delete(key) {
+    const result = await this.getRepository().delete({ key });
+    return result.affected > 0;
+  }
+}
+
+export default new FeatureFlagRepository();
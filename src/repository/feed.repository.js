@@ -0,0 +1,77 @@
+import txManager from "./transactionManager.js";
+
+/**
+ * Activity feed built on read from the follows of a user
+ */
+class FeedRepository {
+  /**
+   * Public trips published or completed by the users someone follows, newest first.
+   * Trips organized by users blocked with the follower are left out.
+   * Upcoming trips departing within originRadiusKm of the follower's home city,
+   * published in the last boostHours, go first.
+   * @param {string} userId - Follower
+   * @param {number} limit
+   * @param {number} offset
+   * @param {Object} boost - { originRadiusKm, boostHours }
+   * @returns {Promise<Array>} - [{ type, occurredAt, groupId, groupName, destinationName, originName,
+   *   departsFromHomeCity, actorId, actorName, actorPicture }]
+   */
+  async findActivity(userId, limit, offset, { originRadiusKm, boostHours }) {
+    return await txManager.query(
+      `SELECT * FROM (
+       SELECT activity.*,
+              COALESCE(6371 * 2 * ASIN(SQRT(
+                POWER(SIN(RADIANS(activity."originLat" - home."homeLat") / 2), 2) +
+                COS(RADIANS(home."homeLat")) * COS(RADIANS(activity."originLat")) *
+                POWER(SIN(RADIANS(activity."originLng" - home."homeLng") / 2), 2)
+              )) <= $4, false) AS "departsFromHomeCity"
+       FROM (
+         SELECT 'trip_published' AS type, g."publishedAt" AS "occurredAt",
+                g.id AS "groupId", g.name AS "groupName", g."destinationName",
+                g."originName", g."originLat", g."originLng", g."tripStartDate",
+                u.id AS "actorId", u.name AS "actorName", u."profilePicture" AS "actorPicture"
+         FROM groups g
+         JOIN user_followers uf ON uf."followedId" = g."adminId" AND uf."followerId" = $1
+         JOIN users u ON u.id = g."adminId"
+         WHERE g."isPublic" = true AND g."publishedAt" IS NOT NULL AND g."deletedAt" IS NULL
+           AND NOT EXISTS (
+             SELECT 1 FROM user_blocks ub
+             WHERE (ub."blockerId" = $1 AND ub."blockedId" = g."adminId")
+                OR (ub."blockerId" = g."adminId" AND ub."blockedId" = $1)
+           )
+
+         UNION ALL
+
+         SELECT 'trip_completed' AS type, dates."endDate"::timestamp AS "occurredAt",
+                g.id AS "groupId", g.name AS "groupName", g."destinationName",
+                g."originName", g."originLat", g."originLng", g."tripStartDate",
+                u.id AS "actorId", u.name AS "actorName", u."profilePicture" AS "actorPicture"
+         FROM group_members gm
+         JOIN user_followers uf ON uf."followedId" = gm."userId" AND uf."followerId" = $1
+         JOIN groups g ON g.id = gm."groupId"
+         JOIN users u ON u.id = gm."userId"
+         JOIN LATERAL (
+           SELECT MAX(ii.date) AS "endDate"
+           FROM itinerary_items ii
+           WHERE ii."itineraryId" = g."assignedItineraryId"
+         ) dates ON true
+         WHERE g."isPublic" = true AND g."deletedAt" IS NULL AND dates."endDate" < CURRENT_DATE
+           AND NOT EXISTS (
+             SELECT 1 FROM user_blocks ub
+             WHERE (ub."blockerId" = $1 AND ub."blockedId" = g."adminId")
+                OR (ub."blockerId" = g."adminId" AND ub."blockedId" = $1)
+           )
+       ) activity
+       LEFT JOIN users home ON home.id = $1
+       ) ranked
+       ORDER BY COALESCE("departsFromHomeCity" AND type = 'trip_published'
+                 AND "tripStartDate" >= CURRENT_DATE
+                 AND "occurredAt" >= NOW() - make_interval(hours => $5), false) DESC,
+                "occurredAt" DESC, "groupId" ASC, "actorId" ASC
+       LIMIT $2 OFFSET $3`,
+      [userId, limit, offset, originRadiusKm, boostHours]
+    );
+  }
+}
+
+export default new FeedRepository();
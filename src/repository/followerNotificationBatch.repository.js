@@ -0,0 +1,51 @@
+import txManager from "./transactionManager.js";
+import FollowerNotificationBatch from "../models/followerNotificationBatch.model.js";
+
+class FollowerNotificationBatchRepository {
+  getRepository() {
+    return txManager.getRepository(FollowerNotificationBatch);
+  }
+
+  async create(data) {
+    const batch = this.getRepository().create(data);
+    return await this.getRepository().save(batch);
+  }
+
+  async findById(id) {
+    return await this.getRepository().findOne({ where: { id } });
+  }
+
+  /**
+   * Adds a trip to the pending batch of an organizer
+   * @param {string} organizerId
+   * @param {string} groupId
+   * @returns {Promise<boolean>} false when the organizer has no pending batch
+   */
+  async appendToPending(organizerId, groupId) {
+    const [, affected] = await txManager.query(
+      `UPDATE follower_notification_batches
+       SET "groupIds" = CASE WHEN "groupIds" @> $2::jsonb THEN "groupIds" ELSE "groupIds" || $2::jsonb END,
+           "updatedAt" = NOW()
+       WHERE "organizerId" = $1 AND status = 'pending'`,
+      [organizerId, JSON.stringify([groupId])]
+    );
+    return affected > 0;
+  }
+
+  /**
+   * Moves a batch to a new status if it is still in the expected one
+   * @returns {Promise<boolean>} true if this call changed it
+   */
+  async transition(id, fromStatus, toStatus) {
+    const result = await this.getRepository().update({ id, status: fromStatus }, { status: toStatus });
+    return result.affected > 0;
+  }
+
+  async incrementNotified(id, count) {
+    if (count > 0) {
+      await this.getRepository().increment({ id }, "notifiedCount", count);
+    }
+  }
+}
+
+export default new FollowerNotificationBatchRepository();
@@ -0,0 +1,22 @@
+import txManager from "./transactionManager.js";
+import FormAnswer from "../models/formAnswer.model.js";
+
+class FormAnswerRepository {
+  getRepository() {
+    return txManager.getRepository(FormAnswer);
+  }
+
+  async findBySubject(formKey, subjectId) {
+    return await this.getRepository().findOne({ where: { formKey, subjectId } });
+  }
+
+  async upsert({ formKey, subjectId, schemaVersion, answers, updatedById }) {
+    await this.getRepository().upsert({ formKey, subjectId, schemaVersion, answers, updatedById }, [
+      "formKey",
+      "subjectId",
+    ]);
+    return await this.findBySubject(formKey, subjectId);
+  }
+}
+
+export default new FormAnswerRepository();
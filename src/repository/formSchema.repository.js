@@ -0,0 +1,64 @@
+import { IsNull, Not } from "typeorm";
+import txManager from "./transactionManager.js";
+import FormSchema from "../models/formSchema.model.js";
+
+class FormSchemaRepository {
+  getRepository() {
+    return txManager.getRepository(FormSchema);
+  }
+
+  async create(data) {
+    const schema = this.getRepository().create(data);
+    return await this.getRepository().save(schema);
+  }
+
+  async findVersion(formKey, version) {
+    return await this.getRepository().findOne({ where: { formKey, version } });
+  }
+
+  /**
+   * The version the apps get: the last one published
+   */
+  async findLatestPublished(formKey) {
+    return await this.getRepository().findOne({
+      where: { formKey, publishedAt: Not(IsNull()) },
+      order: { version: "DESC" },
+    });
+  }
+
+  async findPublished(formKey) {
+    return await this.getRepository().find({
+      where: { formKey, publishedAt: Not(IsNull()) },
+      order: { version: "ASC" },
+    });
+  }
+
+  async findAllVersions(formKey) {
+    return await this.getRepository().find({ where: { formKey }, order: { version: "DESC" } });
+  }
+
+  async findMaxVersion(formKey) {
+    const row = await this.getRepository()
+      .createQueryBuilder("schema")
+      .select("MAX(schema.version)", "max")
+      .where("schema.formKey = :formKey", { formKey })
+      .getRawOne();
+    return Number(row?.max ?? 0);
+  }
+
+  /**
+   * Updates a version only while it is a draft
+   * @returns {Promise<boolean>} Whether the draft was updated
+   */
+  async updateDraft(id, data) {
+    const result = await this.getRepository().update({ id, publishedAt: IsNull() }, data);
+    return result.affected > 0;
+  }
+
+  async deleteDraft(id) {
+    const result = await this.getRepository().delete({ id, publishedAt: IsNull() });
+    return result.affected > 0;
+  }
+}
+
+export default new FormSchemaRepository();
@@ -1,9 +1,11 @@
-import { AppDataSource } from "../load/typeorm.loader.js";
+import txManager from "./transactionManager.js";
 import Group from "../models/group.model.js";
+import { sizeCategorySql, sizeFitSql } from "../utils/groupSize.js";
+import { softDeleteById, restoreById, findTrashed, findByIdWithDeleted } from "./softDelete.js";
 
 class GroupRepository {
   getRepository() {
-    return AppDataSource.getRepository(Group);
+    return txManager.getRepository(Group);
   }
 
   /**
@@ -12,36 +14,35 @@ class GroupRepository {
    * @returns {Promise<Group>} The created group with relations loaded
    */
   async create(groupData) {
-    const queryRunner = AppDataSource.createQueryRunner();
-    await queryRunner.connect();
-    await queryRunner.startTransaction();
-
+    let savedGroup;
     try {
-      // Create and save the group (adminId is required in groupData)
-      const group = this.getRepository().create(groupData);
-      const savedGroup = await queryRunner.manager.save(Group, group);
-
-      // Add the admin as a member in the join table
-      await queryRunner.query(
-        `INSERT INTO group_members ("groupId", "userId") VALUES ($1, $2)`,
-        [savedGroup.id, groupData.adminId]
-      );
-
-      await queryRunner.commitTransaction();
-
-      // Return the group with relations loaded
-      return await this.getRepository().findOne({
-        where: { id: savedGroup.id },
-        relations: ["admin", "members"]
+      savedGroup = await txManager.run(async (manager) => {
+        // Create and save the group (adminId is required in groupData)
+        const group = await manager.save(Group, this.getRepository().create(groupData));
+
+        // Add the admin as a member in the join table
+        await manager.query(
+          `INSERT INTO group_members ("groupId", "userId") VALUES ($1, $2)`,
+          [group.id, groupData.adminId]
+        );
+        await manager.query(
+          `INSERT INTO group_memberships ("groupId", "userId") VALUES ($1, $2)`,
+          [group.id, groupData.adminId]
+        );
+        return group;
       });
     } catch (error) {
-      await queryRunner.rollbackTransaction();
       throw new Error(`Error creating group: ${error.message}`);
-    } finally {
-      await queryRunner.release();
     }
+
+    // Return the group with relations loaded
+    return await this.getRepository().findOne({
+      where: { id: savedGroup.id },
+      relations: ["admin", "members"]
+    });
   }
 
+
   /**
    * Finds a group by its name
    */
@@ -59,12 +60,44 @@ class GroupRepository {
     });
   }
 
+  /**
+   * Locks the group row until the current transaction ends, so concurrent
+   * membership changes see each other's seats. Call inside txManager.run().
+   * @param {string} groupId
+   */
+  async lockForUpdate(groupId) {
+    await txManager.query(`SELECT id FROM groups WHERE id = $1 FOR UPDATE`, [groupId]);
+  }
+
+  /**
+   * IDs of the groups a user is a member of
+   */
+  async findIdsByMember(userId) {
+    const rows = await txManager.query(`SELECT "groupId" FROM group_members WHERE "userId" = $1`, [userId]);
+    return rows.map((row) => row.groupId);
+  }
+
+  /**
+   * Counts the trips a user is a member of that have not ended (undated ones included)
+   */
+  async countUpcomingByMember(userId) {
+    const [row] = await txManager.query(
+      `SELECT COUNT(*)::int AS count
+       FROM group_members gm
+       JOIN groups g ON g.id = gm."groupId"
+       WHERE gm."userId" = $1 AND g."deletedAt" IS NULL
+         AND (g."tripEndDate" IS NULL OR g."tripEndDate" >= CURRENT_DATE)`,
+      [userId]
+    );
+    return row.count;
+  }
+
   /**
    * Finds all groups for a user (as admin or member)
    */
   async findByUserId(userId) {
     // Use raw query to get group IDs where user is admin or member
-    const result = await AppDataSource.query(
+    const result = await txManager.query(
       `SELECT DISTINCT g.id 
        FROM groups g 
        LEFT JOIN group_members gm ON g.id = gm."groupId" 
@@ -92,6 +125,227 @@ class GroupRepository {
       .getMany();
   }
 
+  /**
+   * Updates group columns
+   * @param {string} groupId - Group ID
+   * @param {Object} data - Columns to update
+   * @returns {Promise<Group>} Updated group
+   */
+  async update(groupId, data) {
+    if (Object.keys(data).length > 0) {
+      await this.getRepository().update(groupId, data);
+    }
+    return await this.findById(groupId);
+  }
+
+  /**
+   * Trips of an organizer that are listed or scheduled to be
+   * @param {string} adminId
+   * @returns {Promise<Group[]>}
+   */
+  async findListedByAdmin(adminId) {
+    return await this.getRepository()
+      .createQueryBuilder("group")
+      .where("group.adminId = :adminId", { adminId })
+      .andWhere("(group.isPublic = true OR group.publishAt IS NOT NULL)")
+      .getMany();
+  }
+
+  /**
+   * Finds groups whose scheduled launch time has passed
+   * @param {Date} now
+   * @returns {Promise<Group[]>}
+   */
+  async findDueForPublication(now = new Date()) {
+    return await this.getRepository()
+      .createQueryBuilder("group")
+      .where("group.publishAt <= :now", { now })
+      .orderBy("group.publishAt", "ASC")
+      .getMany();
+  }
+
+  /**
+   * Publishes a scheduled group once its launch time has passed. The update is
+   * conditional so concurrent runs publish (and notify) only once.
+   * @param {string} groupId
+   * @param {Date} now
+   * @returns {Promise<boolean>} true if this call published the group
+   */
+  async publishScheduled(groupId, now = new Date()) {
+    const result = await this.getRepository()
+      .createQueryBuilder()
+      .update(Group)
+      .set({ isPublic: true, publishedAt: now, publishAt: null })
+      .where('id = :groupId AND "publishAt" <= :now AND "deletedAt" IS NULL', { groupId, now })
+      .execute();
+    return result.affected > 0;
+  }
+
+  /**
+   * Groups that delete their chat after the trip, whose trip ended on or
+   * before a date and whose chat is still there
+   * @param {string} tripEndedBefore - YYYY-MM-DD
+   * @param {Object} options - { unnoticedOnly: skip groups whose members were already warned }
+   * @returns {Promise<Group[]>}
+   */
+  async findExpiringChats(tripEndedBefore, { unnoticedOnly = false } = {}) {
+    const query = this.getRepository()
+      .createQueryBuilder("group")
+      .leftJoinAndSelect("group.members", "member")
+      .where("group.chatRetention = :policy", { policy: "after_trip_90d" })
+      .andWhere("group.tripEndDate <= :tripEndedBefore", { tripEndedBefore })
+      .andWhere("group.chatPurgedAt IS NULL");
+    if (unnoticedOnly) {
+      query.andWhere("group.chatDeletionNoticeAt IS NULL");
+    }
+    return await query.orderBy("group.tripEndDate", "ASC").getMany();
+  }
+
+  /**
+   * Updates the chat retention fields of a group
+   * @param {string} groupId
+   * @param {Object} data - { chatRetention, chatDeletionNoticeAt, chatPurgedAt }
+   */
+  async updateChatRetention(groupId, data) {
+    await this.getRepository().update(groupId, data);
+  }
+
+  /**
+   * Finds public groups that have not ended within a radius of a point.
+   * A bounding box on the indexed coordinates narrows the rows before the
+   * haversine distance is computed.
+   * @param {number} lat
+   * @param {number} lng
+   * @param {number} radiusKm
+   * @param {number} limit
+   * @param {string|null} viewerId - Excludes groups of organizers blocked with the viewer
+   * @param {Object} sizeOptions - { size, preferredSizes }: only groups of that size;
+   *   groups fitting the preferred sizes rank as if 20% closer, others 25% farther
+   * @returns {Promise<Array>} Groups with distanceKm, closest first
+   */
+  async findNearby(lat, lng, radiusKm, limit, viewerId = null, { size = null, preferredSizes = null } = {}) {
+    const latDelta = radiusKm / 111.32;
+    const lngDelta = radiusKm / (111.32 * Math.max(Math.cos((lat * Math.PI) / 180), 0.01));
+
+    return await txManager.query(
+      `SELECT * FROM (
+         SELECT g.id, g.name, g.description, g.capacity, g."destinationName",
+                g."destinationLat"::float AS "destinationLat",
+                g."destinationLng"::float AS "destinationLng",
+                (SELECT COUNT(*)::int FROM group_members gm WHERE gm."groupId" = g.id) AS "memberCount",
+                dates."startDate", dates."endDate",
+                ${sizeCategorySql("g")} AS "sizeCategory",
+                ${sizeFitSql("g", "$11")} AS "sizeFit",
+                6371 * 2 * ASIN(SQRT(
+                  POWER(SIN(RADIANS(g."destinationLat" - $1) / 2), 2) +
+                  COS(RADIANS($1)) * COS(RADIANS(g."destinationLat")) *
+                  POWER(SIN(RADIANS(g."destinationLng" - $2) / 2), 2)
+                )) AS "distanceKm"
+         FROM groups g
+         LEFT JOIN LATERAL (
+           SELECT MIN(ii.date) AS "startDate", MAX(ii.date) AS "endDate"
+           FROM itinerary_items ii
+           WHERE ii."itineraryId" = g."assignedItineraryId"
+         ) dates ON true
+         WHERE g."isPublic" = true AND g."deletedAt" IS NULL
+           AND g."destinationLat" BETWEEN $3 AND $4
+           AND g."destinationLng" BETWEEN $5 AND $6
+           AND (dates."endDate" IS NULL OR dates."endDate" >= CURRENT_DATE)
+           AND ($10::varchar IS NULL OR ${sizeCategorySql("g")} = $10)
+           AND ($9::uuid IS NULL OR NOT EXISTS (
+             SELECT 1 FROM user_blocks ub
+             WHERE (ub."blockerId" = $9 AND ub."blockedId" = g."adminId")
+                OR (ub."blockerId" = g."adminId" AND ub."blockedId" = $9)
+           ))
+       ) nearby
+       WHERE "distanceKm" <= $7
+       ORDER BY "distanceKm" * CASE "sizeFit" WHEN 1 THEN 0.8 WHEN -1 THEN 1.25 ELSE 1 END ASC
+       LIMIT $8`,
+      [
+        lat,
+        lng,
+        lat - latDelta,
+        lat + latDelta,
+        lng - lngDelta,
+        lng + lngDelta,
+        radiusKm,
+        limit,
+        viewerId,
+        size,
+        preferredSizes,
+      ]
+    );
+  }
+
+  /**
+   * Finds public groups departing within a radius of a location, soonest trips first
+   * @param {number} lat
+   * @param {number} lng
+   * @param {number} radiusKm
+   * @param {number} limit
+   * @param {number} offset
+   * @param {string|null} viewerId - Leaves out groups of organizers blocked with the viewer
+   * @param {Object} sizeOptions - { size, preferredSizes }: only groups of that size;
+   *   groups fitting the preferred sizes go first
+   * @returns {Promise<Array>} Groups with memberCount, startDate, endDate and originDistanceKm
+   */
+  async findDepartingNear(
+    lat,
+    lng,
+    radiusKm,
+    limit,
+    offset,
+    viewerId = null,
+    { size = null, preferredSizes = null } = {}
+  ) {
+    const latDelta = radiusKm / 111.32;
+    const lngDelta = radiusKm / (111.32 * Math.max(Math.cos((lat * Math.PI) / 180), 0.01));
+
+    return await txManager.query(
+      `SELECT * FROM (
+         SELECT g.id, g.name, g.description, g.capacity, g."destinationName", g."originName",
+                g."tripStartDate" AS "startDate", g."tripEndDate" AS "endDate",
+                g."travelMonth", g."durationBucket",
+                ${sizeCategorySql("g")} AS "sizeCategory",
+                ${sizeFitSql("g", "$12")} AS "sizeFit",
+                (SELECT COUNT(*)::int FROM group_members gm WHERE gm."groupId" = g.id) AS "memberCount",
+                6371 * 2 * ASIN(SQRT(
+                  POWER(SIN(RADIANS(g."originLat" - $1) / 2), 2) +
+                  COS(RADIANS($1)) * COS(RADIANS(g."originLat")) *
+                  POWER(SIN(RADIANS(g."originLng" - $2) / 2), 2)
+                )) AS "originDistanceKm"
+         FROM groups g
+         WHERE g."isPublic" = true AND g."deletedAt" IS NULL
+           AND g."originLat" BETWEEN $3 AND $4
+           AND g."originLng" BETWEEN $5 AND $6
+           AND (g."tripEndDate" IS NULL OR g."tripEndDate" >= CURRENT_DATE)
+           AND ($11::varchar IS NULL OR ${sizeCategorySql("g")} = $11)
+           AND ($10::uuid IS NULL OR NOT EXISTS (
+             SELECT 1 FROM user_blocks ub
+             WHERE (ub."blockerId" = $10 AND ub."blockedId" = g."adminId")
+                OR (ub."blockerId" = g."adminId" AND ub."blockedId" = $10)
+           ))
+       ) departing
+       WHERE "originDistanceKm" <= $7
+       ORDER BY "sizeFit" DESC, "startDate" ASC NULLS LAST, "originDistanceKm" ASC, id ASC
+       LIMIT $8 OFFSET $9`,
+      [
+        lat,
+        lng,
+        lat - latDelta,
+        lat + latDelta,
+        lng - lngDelta,
+        lng + lngDelta,
+        radiusKm,
+        limit,
+        offset,
+        viewerId,
+        size,
+        preferredSizes,
+      ]
+    );
+  }
+
   /**
    * Assigns an itinerary to a group
    * @param {string} groupId - Group ID
@@ -100,6 +354,7 @@ class GroupRepository {
    */
   async assignItinerary(groupId, itineraryId) {
     await this.getRepository().update(groupId, { assignedItineraryId: itineraryId });
+    await this.refreshTravelDimensions({ groupIds: [groupId] });
     return await this.findById(groupId);
   }
 
@@ -110,43 +365,139 @@ class GroupRepository {
    */
   async removeItinerary(groupId) {
     await this.getRepository().update(groupId, { assignedItineraryId: null });
+    await this.refreshTravelDimensions({ groupIds: [groupId] });
     return await this.findById(groupId);
   }
 
+  /**
+   * Recomputes the travel dates, month and duration bucket of groups from the
+   * items of their assigned itinerary. Browsing by month or duration is then an
+   * indexed lookup instead of date math over itinerary_items on every query.
+   * Buckets: weekend (up to 3 days), week (4 to 9 days), long (10 or more).
+   * @param {Object} scope - { groupIds } | { itineraryId } | { missing: true }
+   * @returns {Promise<number>} Groups updated
+   */
+  async refreshTravelDimensions({ groupIds = null, itineraryId = null, missing = false } = {}) {
+    let condition;
+    let params;
+    if (groupIds) {
+      condition = `g2.id = ANY($1)`;
+      params = [groupIds];
+    } else if (itineraryId) {
+      condition = `g2."assignedItineraryId" = $1`;
+      params = [itineraryId];
+    } else if (missing) {
+      condition = `g2."assignedItineraryId" IS NOT NULL AND g2."travelMonth" IS NULL`;
+      params = [];
+    } else {
+      return 0;
+    }
+
+    const [, affected] = await txManager.query(
+      `UPDATE groups g SET
+         "tripStartDate" = d."startDate",
+         "tripEndDate" = d."endDate",
+         "travelMonth" = EXTRACT(MONTH FROM d."startDate")::smallint,
+         "durationBucket" = CASE
+           WHEN d."startDate" IS NULL THEN NULL
+           WHEN d."endDate" - d."startDate" + 1 <= 3 THEN 'weekend'
+           WHEN d."endDate" - d."startDate" + 1 <= 9 THEN 'week'
+           ELSE 'long'
+         END
+       FROM (
+         SELECT g2.id, MIN(ii.date) AS "startDate", MAX(ii.date) AS "endDate"
+         FROM groups g2
+         LEFT JOIN itinerary_items ii ON ii."itineraryId" = g2."assignedItineraryId"
+         WHERE ${condition}
+         GROUP BY g2.id
+       ) d
+       WHERE g.id = d.id`,
+      params
+    );
+    return affected;
+  }
+
+  /**
+   * Public upcoming groups by travel month, duration bucket and/or size, soonest first
+   * (groups fitting the viewer's preferred sizes go before the rest)
+   * @param {Object} filters - { month, durationBucket, size, preferredSizes, viewerId, limit, offset }
+   *   viewerId excludes groups of organizers blocked with the viewer
+   * @returns {Promise<Array>}
+   */
+  async findByTravelDimensions({
+    month = null,
+    durationBucket = null,
+    size = null,
+    preferredSizes = null,
+    viewerId = null,
+    limit,
+    offset,
+  }) {
+    return await txManager.query(
+      `SELECT g.id, g.name, g.description, g.capacity, g."destinationName",
+              g."tripStartDate" AS "startDate", g."tripEndDate" AS "endDate",
+              g."travelMonth", g."durationBucket",
+              ${sizeCategorySql("g")} AS "sizeCategory",
+              ${sizeFitSql("g", "$7")} AS "sizeFit",
+              (SELECT COUNT(*)::int FROM group_members gm WHERE gm."groupId" = g.id) AS "memberCount"
+       FROM groups g
+       WHERE g."isPublic" = true AND g."deletedAt" IS NULL
+         AND g."tripStartDate" >= CURRENT_DATE
+         AND ($1::smallint IS NULL OR g."travelMonth" = $1)
+         AND ($2::varchar IS NULL OR g."durationBucket" = $2)
+         AND ($6::varchar IS NULL OR ${sizeCategorySql("g")} = $6)
+         AND ($3::uuid IS NULL OR NOT EXISTS (
+           SELECT 1 FROM user_blocks ub
+           WHERE (ub."blockerId" = $3 AND ub."blockedId" = g."adminId")
+              OR (ub."blockerId" = g."adminId" AND ub."blockedId" = $3)
+         ))
+       ORDER BY "sizeFit" DESC, g."tripStartDate" ASC, g.id ASC
+       LIMIT $4 OFFSET $5`,
+      [month, durationBucket, viewerId, limit, offset, size, preferredSizes]
+    );
+  }
+
   /**
    * Adds members to a group
    */
   async addMembers(groupId, userIds) {
-    const queryRunner = AppDataSource.createQueryRunner();
-    await queryRunner.connect();
-    await queryRunner.startTransaction();
     try {
-      for (const userId of userIds) {
-        await queryRunner.query(
-          `INSERT INTO group_members ("groupId", "userId") VALUES ($1, $2) ON CONFLICT DO NOTHING`,
-          [groupId, userId]
-        );
-      }
-      await queryRunner.commitTransaction();
-      return await this.findById(groupId);
+      await txManager.run(async (manager) => {
+        for (const userId of userIds) {
+          await manager.query(
+            `INSERT INTO group_members ("groupId", "userId") VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+            [groupId, userId]
+          );
+          // Re-adding a current member keeps the original join time
+          await manager.query(
+            `INSERT INTO group_memberships ("groupId", "userId") VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+            [groupId, userId]
+          );
+        }
+      });
     } catch (error) {
-      await queryRunner.rollbackTransaction();
       throw new Error(`Error adding members: ${error.message}`);
-    } finally {
-      await queryRunner.release();
     }
+    return await this.findById(groupId);
   }
 
+
   /**
    * Removes a member from a group
    */
   async removeMember(groupId, userId) {
-    await AppDataSource
+    await txManager.manager
       .createQueryBuilder()
       .delete()
       .from("group_members")
       .where('"groupId" = :groupId AND "userId" = :userId', { groupId, userId })
       .execute();
+    await txManager.manager
+      .createQueryBuilder()
+      .delete()
+      .from("group_memberships")
+      .where('"groupId" = :groupId AND "userId" = :userId', { groupId, userId })
+      .execute();
     return await this.findById(groupId);
   }
 
@@ -154,34 +505,64 @@ class GroupRepository {
    * Deletes a group and its member relations and associated expenses, returns the deleted group data
    */
   async delete(groupId) {
-    const groupToDelete = await this.findById(groupId);
+    const groupToDelete = await this.getRepository().findOne({ where: { id: groupId }, withDeleted: true });
     if (!groupToDelete) {
       throw new Error(`Group with id ${groupId} not found`);
     }
 
-    const queryRunner = AppDataSource.createQueryRunner();
-    await queryRunner.connect();
-    await queryRunner.startTransaction();
-
     try {
-      // Delete associated expenses first (cascade delete)
-      await queryRunner.query(`DELETE FROM expenses WHERE "groupId" = $1`, [groupId]);
+      await txManager.run(async (manager) => {
+        // Delete associated expenses first (cascade delete)
+        await manager.query(`DELETE FROM expenses WHERE "groupId" = $1`, [groupId]);
 
-      // Remove join table entries
-      await queryRunner.query(`DELETE FROM group_members WHERE "groupId" = $1`, [groupId]);
+        // Remove join table entries
+        await manager.query(`DELETE FROM group_members WHERE "groupId" = $1`, [groupId]);
 
-      // Delete the group
-      await queryRunner.manager.delete(Group, groupId);
-
-      await queryRunner.commitTransaction();
+        // Delete the group
+        await manager.delete(Group, groupId);
+      });
       return groupToDelete;
     } catch (error) {
-      await queryRunner.rollbackTransaction();
       throw new Error(`Error deleting group: ${error.message}`);
-    } finally {
-      await queryRunner.release();
     }
   }
+
+  /**
+   * Soft-deletes a group: it disappears from every query but keeps its members,
+   * expenses and messages so an admin can restore it
+   * @param {string} groupId
+   * @returns {Promise<boolean>} false if it does not exist or was already deleted
+   */
+  async softDelete(groupId) {
+    return await softDeleteById(this.getRepository(), groupId);
+  }
+
+  /**
+   * Restores a soft-deleted group
+   * @param {string} groupId
+   * @returns {Promise<boolean>} false if it does not exist or was not deleted
+   */
+  async restore(groupId) {
+    return await restoreById(this.getRepository(), groupId);
+  }
+
+  /**
+   * Soft-deleted groups, most recently deleted first
+   * @param {Object} options - { limit, offset }
+   * @returns {Promise<Group[]>}
+   */
+  async findTrashed({ limit, offset }) {
+    return await findTrashed(this.getRepository(), { relations: ["admin"], limit, offset });
+  }
+
+  /**
+   * Finds a group by ID, deleted or not
+   * @param {string} groupId
+   * @returns {Promise<Group|null>}
+   */
+  async findByIdWithDeleted(groupId) {
+    return await findByIdWithDeleted(this.getRepository(), groupId);
+  }
 }
 
 export default new GroupRepository();
\ No newline at end of file
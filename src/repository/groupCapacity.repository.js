@@ -0,0 +1,68 @@
+import txManager from "./transactionManager.js";
+import GroupMembership from "../models/groupMembership.model.js";
+import GroupCapacityChange from "../models/groupCapacityChange.model.js";
+
+class GroupCapacityRepository {
+  getMembershipRepository() {
+    return txManager.getRepository(GroupMembership);
+  }
+
+  getChangeRepository() {
+    return txManager.getRepository(GroupCapacityChange);
+  }
+
+  /**
+   * Lists the members of a group with their tier and join time, earliest first.
+   * Members that joined before memberships were tracked have no joinedAt and
+   * count as the earliest.
+   * @param {string} groupId
+   * @returns {Promise<Array>} [{ userId, name, email, tier, joinedAt }]
+   */
+  async findMembers(groupId) {
+    return await txManager.query(
+      `SELECT gm."userId", u.name, u.email,
+              COALESCE(ms.tier, 0) AS tier, ms."joinedAt"
+       FROM group_members gm
+       JOIN users u ON u.id = gm."userId"
+       LEFT JOIN group_memberships ms
+         ON ms."groupId" = gm."groupId" AND ms."userId" = gm."userId"
+       WHERE gm."groupId" = $1
+       ORDER BY ms."joinedAt" ASC NULLS FIRST, gm."userId" ASC`,
+      [groupId]
+    );
+  }
+
+  /**
+   * Sets the tier of a member, creating the membership record if it predates tracking
+   */
+  async setTier(groupId, userId, tier) {
+    await txManager.query(
+      `INSERT INTO group_memberships ("groupId", "userId", tier, "joinedAt")
+       VALUES ($1, $2, $3, NULL)
+       ON CONFLICT ("groupId", "userId") DO UPDATE SET tier = EXCLUDED.tier`,
+      [groupId, userId, tier]
+    );
+  }
+
+  /**
+   * Records a capacity change
+   * @param {Object} data - { groupId, changedById, previousCapacity, newCapacity, strategy, reason, removedMembers, promotedMembers }
+   */
+  async createChange(data) {
+    const change = this.getChangeRepository().create(data);
+    return await this.getChangeRepository().save(change);
+  }
+
+  /**
+   * Lists the capacity changes of a group, newest first
+   */
+  async findChanges(groupId) {
+    return await this.getChangeRepository().find({
+      where: { groupId },
+      relations: ["changedBy"],
+      order: { createdAt: "DESC" },
+    });
+  }
+}
+
+export default new GroupCapacityRepository();
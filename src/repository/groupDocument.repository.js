@@ -0,0 +1,131 @@
+import { LessThan } from "typeorm";
+import txManager from "./transactionManager.js";
+import GroupDocument from "../models/groupDocument.model.js";
+import GroupDocumentVersion from "../models/groupDocumentVersion.model.js";
+
+class GroupDocumentRepository {
+  getRepository() {
+    return txManager.getRepository(GroupDocument);
+  }
+
+  getVersionRepository() {
+    return txManager.getRepository(GroupDocumentVersion);
+  }
+
+  /**
+   * Creates a document with its first version in a single transaction
+   * @param {Object} documentData - { groupId, title, category, createdById }
+   * @param {Object} versionData - { storageKey, originalName, contentType, size, uploadedById, scanStatus }
+   */
+  async createWithVersion(documentData, versionData) {
+    return await txManager.run(async (manager) => {
+      const document = await manager.save(
+        GroupDocument,
+        manager.create(GroupDocument, { ...documentData, currentVersion: 1 })
+      );
+      const version = await manager.save(
+        GroupDocumentVersion,
+        manager.create(GroupDocumentVersion, {
+          ...versionData,
+          documentId: document.id,
+          groupId: document.groupId,
+          version: 1,
+        })
+      );
+      return { document, version };
+    });
+  }
+
+  /**
+   * Adds a new version to a document and makes it the current one
+   */
+  async addVersion(document, versionData) {
+    return await txManager.run(async (manager) => {
+      const nextVersion = document.currentVersion + 1;
+      const version = await manager.save(
+        GroupDocumentVersion,
+        manager.create(GroupDocumentVersion, {
+          ...versionData,
+          documentId: document.id,
+          groupId: document.groupId,
+          version: nextVersion,
+        })
+      );
+      await manager.update(GroupDocument, document.id, { currentVersion: nextVersion });
+      return version;
+    });
+  }
+
+  async findByIdInGroup(groupId, documentId) {
+    return await this.getRepository().findOne({ where: { id: documentId, groupId } });
+  }
+
+  /**
+   * Lists the documents of a group with their current version
+   * @param {string} groupId
+   */
+  async findByGroupId(groupId) {
+    return await this.getRepository()
+      .createQueryBuilder("document")
+      .leftJoinAndSelect(
+        "document.versions",
+        "version",
+        "version.version = document.currentVersion"
+      )
+      .leftJoinAndSelect("version.uploadedBy", "uploadedBy")
+      .where("document.groupId = :groupId", { groupId })
+      .orderBy("document.updatedAt", "DESC")
+      .getMany();
+  }
+
+  async findVersions(documentId) {
+    return await this.getVersionRepository().find({
+      where: { documentId },
+      relations: ["uploadedBy"],
+      order: { version: "DESC" },
+    });
+  }
+
+  async findVersion(documentId, version) {
+    return await this.getVersionRepository().findOne({ where: { documentId, version } });
+  }
+
+  async findVersionById(id) {
+    return await this.getVersionRepository().findOne({ where: { id } });
+  }
+
+  /**
+   * Versions still waiting for the antivirus since before a date
+   * @param {Date} before
+   */
+  async findPendingScans(before) {
+    return await this.getVersionRepository().find({
+      where: { scanStatus: "pending", createdAt: LessThan(before) },
+      select: ["id"],
+    });
+  }
+
+  async updateVersion(id, data) {
+    await this.getVersionRepository().update(id, data);
+  }
+
+  /**
+   * Bytes used by every version of every document of a group
+   * @param {string} groupId
+   * @returns {Promise<number>}
+   */
+  async sumStorageByGroup(groupId) {
+    const result = await this.getVersionRepository()
+      .createQueryBuilder("version")
+      .select("COALESCE(SUM(version.size), 0)", "total")
+      .where("version.groupId = :groupId", { groupId })
+      .getRawOne();
+    return Number(result.total);
+  }
+
+  async delete(documentId) {
+    await this.getRepository().delete(documentId);
+  }
+}
+
+export default new GroupDocumentRepository();
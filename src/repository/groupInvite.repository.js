@@ -0,0 +1,52 @@
+import { IsNull } from "typeorm";
+import txManager from "./transactionManager.js";
+import GroupInvite from "../models/groupInvite.model.js";
+
+class GroupInviteRepository {
+  getRepository() {
+    return txManager.getRepository(GroupInvite);
+  }
+
+  async create(data) {
+    const invite = this.getRepository().create(data);
+    return await this.getRepository().save(invite);
+  }
+
+  async findByTokenHash(tokenHash) {
+    return await this.getRepository().findOne({ where: { tokenHash } });
+  }
+
+  async findByIdInGroup(groupId, inviteId) {
+    return await this.getRepository().findOne({ where: { id: inviteId, groupId } });
+  }
+
+  /**
+   * Invites of a group that were not revoked, newest first
+   */
+  async findByGroup(groupId) {
+    return await this.getRepository().find({
+      where: { groupId, revokedAt: IsNull() },
+      order: { createdAt: "DESC" },
+    });
+  }
+
+  /**
+   * Counts a use of an invite unless it ran out meanwhile
+   * @returns {Promise<boolean>} Whether the use was counted
+   */
+  async incrementUse(inviteId) {
+    const [rows] = await txManager.query(
+      `UPDATE group_invites SET "useCount" = "useCount" + 1
+       WHERE id = $1 AND ("maxUses" IS NULL OR "useCount" < "maxUses")
+       RETURNING id`,
+      [inviteId]
+    );
+    return rows.length > 0;
+  }
+
+  async revoke(inviteId) {
+    await this.getRepository().update({ id: inviteId }, { revokedAt: new Date() });
+  }
+}
+
+export default new GroupInviteRepository();
@@ -0,0 +1,120 @@
+import { In } from "typeorm";
+import txManager from "./transactionManager.js";
+import GroupJoinRequest from "../models/groupJoinRequest.model.js";
+
+class GroupJoinRequestRepository {
+  getRepository() {
+    return txManager.getRepository(GroupJoinRequest);
+  }
+
+  /**
+   * Creates a join request
+   * @param {Object} data - { groupId, userId, message }
+   * @returns {Promise<GroupJoinRequest>}
+   */
+  async create(data) {
+    const request = this.getRepository().create(data);
+    return await this.getRepository().save(request);
+  }
+
+  /**
+   * Finds a join request by its ID, including the requester and the group
+   */
+  async findById(requestId) {
+    return await this.getRepository().findOne({
+      where: { id: requestId },
+      relations: ["user", "group"],
+    });
+  }
+
+  /**
+   * Finds a join request of a group by its ID, including the requester
+   */
+  async findByIdInGroup(groupId, requestId) {
+    return await this.getRepository().findOne({
+      where: { id: requestId, groupId },
+      relations: ["user"],
+    });
+  }
+
+  /**
+   * Finds the open (pending, waitlisted or awaiting_rules) request of a user for a group, if any
+   */
+  async findPending(groupId, userId) {
+    return await this.getRepository().findOne({
+      where: { groupId, userId, status: In(["pending", "waitlisted", "awaiting_rules"]) },
+    });
+  }
+
+  /**
+   * Finds the request through which a member got their spot, if any
+   */
+  async findLatestApproved(groupId, userId) {
+    return await this.getRepository().findOne({
+      where: { groupId, userId, status: "approved" },
+      order: { decidedAt: "DESC" },
+    });
+  }
+
+  /**
+   * Counts the open requests of a user across groups
+   */
+  async countOpenByUser(userId) {
+    return await this.getRepository().count({
+      where: { userId, status: In(["pending", "waitlisted", "awaiting_rules"]) },
+    });
+  }
+
+  /**
+   * Lists the waitlist of a group in order (first come, first promoted)
+   * @param {string} groupId
+   * @param {number|null} limit
+   */
+  async findWaitlist(groupId, limit = null) {
+    return await this.getRepository().find({
+      where: { groupId, status: "waitlisted" },
+      relations: ["user"],
+      order: { createdAt: "ASC" },
+      ...(limit !== null && { take: limit }),
+    });
+  }
+
+  /**
+   * Lists the join requests of a group, oldest first (join order)
+   * @param {string} groupId
+   * @param {string|null} status - Optional status filter
+   */
+  async findByGroupId(groupId, status = null) {
+    return await this.getRepository().find({
+      where: status ? { groupId, status } : { groupId },
+      relations: ["user"],
+      order: { createdAt: "ASC" },
+    });
+  }
+
+  /**
+   * Lists the join requests made by a user, newest first
+   */
+  async findByUserId(userId) {
+    return await this.getRepository().find({
+      where: { userId },
+      relations: ["group"],
+      order: { createdAt: "DESC" },
+    });
+  }
+
+  /**
+   * Updates the status of a request
+   * @param {string} requestId
+   * @param {Object} data - { status, decidedById, decidedAt }
+   */
+  async update(requestId, data) {
+    await this.getRepository().update(requestId, data);
+    return await this.getRepository().findOne({
+      where: { id: requestId },
+      relations: ["user"],
+    });
+  }
+}
+
+export default new GroupJoinRequestRepository();
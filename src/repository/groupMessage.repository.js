@@ -1,8 +1,14 @@
-import { AppDataSource } from "../load/typeorm.loader.js";
+import txManager from "./transactionManager.js";
+import { softDeleteById, restoreById, findTrashed, findByIdWithDeleted } from "./softDelete.js";
+import { notBlockedBetween } from "./userBlock.repository.js";
+
+// Los mensajes de usuarios bloqueados con quien lee (en cualquier sentido) no se cuentan ni se devuelven
+const VISIBLE_TO_VIEWER = notBlockedBetween(":viewerId", `message."senderId"`);
 
 class GroupMessageRepository {
-  constructor() {
-    this.repository = AppDataSource.getRepository("GroupMessage");
+  // Resolved on use so it picks up the open transaction
+  get repository() {
+    return txManager.getRepository("GroupMessage");
   }
 
   /**
@@ -26,29 +32,173 @@ class GroupMessageRepository {
    * @param {string} groupId - ID del grupo
    * @param {number} limit - Número de mensajes a obtener
    * @param {number} offset - Offset para paginación
+   * @param {string} viewerId - Usuario que lee
    * @returns {Promise<Array>} Lista de mensajes
    */
-  async findByGroupId(groupId, limit = 50, offset = 0) {
+  async findByGroupId(groupId, limit = 50, offset = 0, viewerId) {
+    return await this.repository
+      .createQueryBuilder("message")
+      .leftJoinAndSelect("message.sender", "sender")
+      .where("message.groupId = :groupId", { groupId })
+      .andWhere(VISIBLE_TO_VIEWER, { viewerId })
+      .orderBy("message.createdAt", "ASC")
+      .take(limit)
+      .skip(offset)
+      .getMany();
+  }
+
+  /**
+   * Obtiene todos los mensajes de un grupo sin paginar (exportación del chat)
+   * @param {string} groupId - ID del grupo
+   * @returns {Promise<Array>} Mensajes en orden cronológico, con el remitente
+   */
+  async findAllByGroupId(groupId) {
     return await this.repository.find({
       where: { groupId },
       relations: ["sender"],
-      order: { createdAt: "ASC" },
-      take: limit,
-      skip: offset,
+      order: { createdAt: "ASC", id: "ASC" },
     });
   }
 
   /**
-   * Cuenta los mensajes de un grupo
+   * IDs de quienes escribieron en el chat de un grupo, incluidos mensajes borrados
    * @param {string} groupId - ID del grupo
-   * @returns {Promise<number>} Cantidad de mensajes
+   * @returns {Promise<string[]>}
    */
-  async countByGroupId(groupId) {
-    return await this.repository.count({
-      where: { groupId },
+  async findSenderIds(groupId) {
+    const rows = await txManager.query(
+      `SELECT DISTINCT "senderId" FROM group_messages WHERE "groupId" = $1`,
+      [groupId]
+    );
+    return rows.map((row) => row.senderId);
+  }
+
+  /**
+   * Obtiene una página de mensajes anteriores a un cursor (paginación por cursor)
+   * @param {string} groupId - ID del grupo
+   * @param {Object} options - { before, limit, viewerId }
+   * @param {Object|null} options.before - Mensaje desde el cual paginar hacia atrás
+   * @param {number} options.limit - Número de mensajes a obtener
+   * @param {string} options.viewerId - Usuario que lee
+   * @returns {Promise<Array>} Lista de mensajes en orden cronológico
+   */
+  async findPageBefore(groupId, { before = null, limit = 50, viewerId } = {}) {
+    const query = this.repository
+      .createQueryBuilder("message")
+      .leftJoinAndSelect("message.sender", "sender")
+      .where("message.groupId = :groupId", { groupId })
+      .andWhere(VISIBLE_TO_VIEWER, { viewerId });
+
+    if (before) {
+      query.andWhere(
+        "(message.createdAt < :createdAt OR (message.createdAt = :createdAt AND message.id < :id))",
+        { createdAt: before.createdAt, id: before.id }
+      );
+    }
+
+    const messages = await query
+      .orderBy("message.createdAt", "DESC")
+      .addOrderBy("message.id", "DESC")
+      .take(limit)
+      .getMany();
+
+    return messages.reverse();
+  }
+
+  /**
+   * Busca un mensaje de un grupo por su ID
+   * @param {string} groupId - ID del grupo
+   * @param {string} messageId - ID del mensaje
+   * @returns {Promise<Object|null>} Mensaje o null
+   */
+  async findByIdInGroup(groupId, messageId) {
+    return await this.repository.findOne({
+      where: { id: messageId, groupId },
+    });
+  }
+
+  /**
+   * Busca un mensaje por el ID generado por el cliente (envíos offline)
+   * @param {string} groupId - ID del grupo
+   * @param {string} senderId - ID del remitente
+   * @param {string} clientMessageId - ID generado por el cliente
+   * @returns {Promise<Object|null>} Mensaje o null
+   */
+  async findByClientMessageId(groupId, senderId, clientMessageId) {
+    // Incluye los borrados: el índice único también los cubre
+    return await this.repository.findOne({
+      where: { groupId, senderId, clientMessageId },
+      relations: ["sender"],
+      withDeleted: true,
     });
   }
 
+  /**
+   * Cuenta los mensajes no leídos de un usuario en un grupo
+   * @param {string} groupId - ID del grupo
+   * @param {string} userId - ID del usuario
+   * @param {Date|null} since - Fecha del último mensaje leído
+   * @returns {Promise<number>} Cantidad de mensajes no leídos
+   */
+  async countUnread(groupId, userId, since = null) {
+    const query = this.repository
+      .createQueryBuilder("message")
+      .where("message.groupId = :groupId", { groupId })
+      .andWhere("message.senderId != :userId", { userId })
+      .andWhere(VISIBLE_TO_VIEWER, { viewerId: userId });
+
+    if (since) {
+      query.andWhere("message.createdAt > :since", { since });
+    }
+
+    return await query.getCount();
+  }
+
+  /**
+   * Cuenta los mensajes no leídos de un usuario para varios grupos a la vez
+   * @param {string[]} groupIds - IDs de los grupos
+   * @param {string} userId - ID del usuario
+   * @returns {Promise<Object>} Mapa groupId -> cantidad de no leídos
+   */
+  async countUnreadByGroupIds(groupIds, userId) {
+    if (!groupIds || groupIds.length === 0) {
+      return {};
+    }
+
+    const rows = await txManager.query(
+      `SELECT gm."groupId" AS "groupId", COUNT(gm.id)::int AS "unreadCount"
+       FROM group_messages gm
+       LEFT JOIN group_message_reads r
+         ON r."groupId" = gm."groupId" AND r."userId" = $1
+       WHERE gm."groupId" = ANY($2)
+         AND gm."deletedAt" IS NULL
+         AND gm."senderId" != $1
+         AND (r."lastReadAt" IS NULL OR gm."createdAt" > r."lastReadAt")
+         AND ${notBlockedBetween("$1", `gm."senderId"`)}
+       GROUP BY gm."groupId"`,
+      [userId, groupIds]
+    );
+
+    return rows.reduce((acc, row) => {
+      acc[row.groupId] = row.unreadCount;
+      return acc;
+    }, {});
+  }
+
+  /**
+   * Cuenta los mensajes de un grupo que ve un usuario
+   * @param {string} groupId - ID del grupo
+   * @param {string} viewerId - Usuario que lee
+   * @returns {Promise<number>} Cantidad de mensajes
+   */
+  async countByGroupId(groupId, viewerId) {
+    return await this.repository
+      .createQueryBuilder("message")
+      .where("message.groupId = :groupId", { groupId })
+      .andWhere(VISIBLE_TO_VIEWER, { viewerId })
+      .getCount();
+  }
+
   /**
    * Elimina todos los mensajes de un grupo
    * @param {string} groupId - ID del grupo
@@ -70,6 +220,42 @@ class GroupMessageRepository {
       order: { createdAt: "DESC" },
     });
   }
+
+  /**
+   * Borra un mensaje de forma lógica (se puede restaurar)
+   * @param {string} messageId - ID del mensaje
+   * @returns {Promise<boolean>} false si no existe o ya estaba borrado
+   */
+  async softDelete(messageId) {
+    return await softDeleteById(this.repository, messageId);
+  }
+
+  /**
+   * Restaura un mensaje borrado
+   * @param {string} messageId - ID del mensaje
+   * @returns {Promise<boolean>} false si no existe o no estaba borrado
+   */
+  async restore(messageId) {
+    return await restoreById(this.repository, messageId);
+  }
+
+  /**
+   * Mensajes borrados, los más recientes primero
+   * @param {Object} options - { limit, offset }
+   * @returns {Promise<Array>}
+   */
+  async findTrashed({ limit, offset }) {
+    return await findTrashed(this.repository, { relations: ["sender"], limit, offset });
+  }
+
+  /**
+   * Busca un mensaje por ID, esté borrado o no
+   * @param {string} messageId - ID del mensaje
+   * @returns {Promise<Object|null>}
+   */
+  async findByIdWithDeleted(messageId) {
+    return await findByIdWithDeleted(this.repository, messageId);
+  }
 }
 
 export default new GroupMessageRepository();
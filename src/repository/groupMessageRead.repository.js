@@ -0,0 +1,98 @@
+import txManager from "./transactionManager.js";
+import { notBlockedBetween } from "./userBlock.repository.js";
+
+class GroupMessageReadRepository {
+  // Resolved on use so it picks up the open transaction
+  get repository() {
+    return txManager.getRepository("GroupMessageRead");
+  }
+
+  /**
+   * Obtiene el marcador de lectura de un usuario en un grupo
+   * @param {string} groupId - ID del grupo
+   * @param {string} userId - ID del usuario
+   * @returns {Promise<Object|null>} Marcador de lectura o null
+   */
+  async findByGroupAndUser(groupId, userId) {
+    return await this.repository.findOne({
+      where: { groupId, userId },
+    });
+  }
+
+  /**
+   * Elimina los marcadores de lectura de un grupo
+   * @param {string} groupId - ID del grupo
+   * @returns {Promise<void>}
+   */
+  async deleteByGroupId(groupId) {
+    await this.repository.delete({ groupId });
+  }
+
+  /**
+   * Obtiene todos los marcadores de lectura de un grupo
+   * @param {string} groupId - ID del grupo
+   * @returns {Promise<Array>} Lista de marcadores con el usuario
+   */
+  async findByGroupId(groupId) {
+    return await this.repository.find({
+      where: { groupId },
+      relations: ["user"],
+      order: { lastReadAt: "DESC" },
+    });
+  }
+
+  /**
+   * Estado de lectura de un usuario en todos sus grupos. Los mensajes de
+   * usuarios con bloqueo respecto a él no cuentan como no leídos
+   * @param {string} userId - ID del usuario
+   * @returns {Promise<Array>} [{ groupId, lastReadMessageId, lastReadAt, unreadCount }]
+   */
+  async findReadStateByUser(userId) {
+    return await txManager.query(
+      `SELECT g.id AS "groupId", r."lastReadMessageId", r."lastReadAt",
+              (SELECT COUNT(*)::int FROM group_messages gm
+               WHERE gm."groupId" = g.id
+                 AND gm."deletedAt" IS NULL
+                 AND gm."senderId" != $1
+                 AND (r."lastReadAt" IS NULL OR gm."createdAt" > r."lastReadAt")
+                 AND ${notBlockedBetween("$1", `gm."senderId"`)}) AS "unreadCount"
+       FROM groups g
+       LEFT JOIN group_message_reads r ON r."groupId" = g.id AND r."userId" = $1
+       WHERE g."deletedAt" IS NULL
+         AND (g."adminId" = $1
+              OR EXISTS (SELECT 1 FROM group_members m WHERE m."groupId" = g.id AND m."userId" = $1))`,
+      [userId]
+    );
+  }
+
+  /**
+   * Crea o avanza el marcador de lectura de un usuario.
+   * Nunca retrocede: si el marcador existente es más reciente se conserva.
+   * @param {string} groupId - ID del grupo
+   * @param {string} userId - ID del usuario
+   * @param {Object} message - Mensaje leído ({ id, createdAt })
+   * @returns {Promise<Object>} Marcador actualizado
+   */
+  async markRead(groupId, userId, message) {
+    const existing = await this.findByGroupAndUser(groupId, userId);
+
+    if (existing) {
+      if (existing.lastReadAt && existing.lastReadAt >= message.createdAt) {
+        return existing;
+      }
+      existing.lastReadMessageId = message.id;
+      existing.lastReadAt = message.createdAt;
+      return await this.repository.save(existing);
+    }
+
+    const marker = this.repository.create({
+      groupId,
+      userId,
+      lastReadMessageId: message.id,
+      lastReadAt: message.createdAt,
+    });
+    return await this.repository.save(marker);
+  }
+}
+
+export default new GroupMessageReadRepository();
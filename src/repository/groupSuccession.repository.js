@@ -0,0 +1,164 @@
+import txManager from "./transactionManager.js";
+import GroupSuccession from "../models/groupSuccession.model.js";
+import GroupSuccessionVote from "../models/groupSuccessionVote.model.js";
+
+const ACTIVE_STATUSES = ["offering", "voting"];
+
+class GroupSuccessionRepository {
+  getRepository() {
+    return txManager.getRepository(GroupSuccession);
+  }
+
+  getVoteRepository() {
+    return txManager.getRepository(GroupSuccessionVote);
+  }
+
+  async create(data) {
+    const succession = this.getRepository().create(data);
+    return await this.getRepository().save(succession);
+  }
+
+  async findById(id) {
+    return await this.getRepository().findOne({ where: { id } });
+  }
+
+  /**
+   * Succession in progress of a trip, if any
+   */
+  async findActiveByGroup(groupId) {
+    return await this.getRepository()
+      .createQueryBuilder("succession")
+      .where("succession.groupId = :groupId", { groupId })
+      .andWhere("succession.status IN (:...statuses)", { statuses: ACTIVE_STATUSES })
+      .getOne();
+  }
+
+  /**
+   * Successions in progress started because of a user
+   * @param {string} previousAdminId
+   * @param {string} reason
+   */
+  async findActiveByPreviousAdmin(previousAdminId, reason) {
+    return await this.getRepository()
+      .createQueryBuilder("succession")
+      .where("succession.previousAdminId = :previousAdminId", { previousAdminId })
+      .andWhere("succession.reason = :reason", { reason })
+      .andWhere("succession.status IN (:...statuses)", { statuses: ACTIVE_STATUSES })
+      .getMany();
+  }
+
+  async update(id, data) {
+    await this.getRepository().update(id, data);
+    return await this.findById(id);
+  }
+
+  /**
+   * Members that can take over a trip, excluding the leaving organizer and
+   * suspended or closed accounts: co-organizers first, then by seniority
+   * @param {string} groupId
+   * @param {string} previousAdminId
+   * @returns {Promise<Array>} [{ userId, name, email, coOrganizer, joinedAt }]
+   */
+  async findEligibleMembers(groupId, previousAdminId) {
+    return await txManager.query(
+      `SELECT gm."userId", u.name, u.email,
+              COALESCE(ms."coOrganizer", false) AS "coOrganizer", ms."joinedAt"
+       FROM group_members gm
+       JOIN users u ON u.id = gm."userId"
+       LEFT JOIN group_memberships ms
+         ON ms."groupId" = gm."groupId" AND ms."userId" = gm."userId"
+       WHERE gm."groupId" = $1
+         AND gm."userId" <> $2
+         AND u."suspendedAt" IS NULL
+         AND u."deletedAt" IS NULL
+       ORDER BY COALESCE(ms."coOrganizer", false) DESC, ms."joinedAt" ASC NULLS FIRST, gm."userId" ASC`,
+      [groupId, previousAdminId]
+    );
+  }
+
+  /**
+   * Marks or unmarks a member as co-organizer, creating the membership
+   * record if it predates tracking
+   */
+  async setCoOrganizer(groupId, userId, coOrganizer) {
+    await txManager.query(
+      `INSERT INTO group_memberships ("groupId", "userId", "coOrganizer", "joinedAt")
+       VALUES ($1, $2, $3, NULL)
+       ON CONFLICT ("groupId", "userId") DO UPDATE SET "coOrganizer" = EXCLUDED."coOrganizer"`,
+      [groupId, userId, coOrganizer]
+    );
+  }
+
+  /**
+   * Trips departing within the window whose organizer has not been seen
+   * since the cutoff and that have no succession in progress
+   * @param {Date} seenBefore
+   * @param {Date} departingBefore
+   * @returns {Promise<Array>} [{ groupId, adminId }]
+   */
+  async findAbandonedGroups(seenBefore, departingBefore) {
+    return await txManager.query(
+      `SELECT g.id AS "groupId", g."adminId"
+       FROM groups g
+       JOIN users u ON u.id = g."adminId"
+       WHERE g."deletedAt" IS NULL
+         AND g."tripStartDate" > now()
+         AND g."tripStartDate" <= $2
+         AND COALESCE(u."lastSeenAt", u."createdAt") < $1
+         AND u."suspendedAt" IS NULL
+         AND NOT EXISTS (
+           SELECT 1 FROM group_successions s
+           WHERE s."groupId" = g.id AND s.status IN ('offering', 'voting')
+         )`,
+      [seenBefore, departingBefore]
+    );
+  }
+
+  /**
+   * Trips organized by a user that have not ended yet
+   * @param {string} adminId
+   * @returns {Promise<string[]>} Group IDs
+   */
+  async findOngoingGroupIdsByAdmin(adminId) {
+    const rows = await txManager.query(
+      `SELECT id FROM groups
+       WHERE "adminId" = $1
+         AND "deletedAt" IS NULL
+         AND ("tripEndDate" IS NULL OR "tripEndDate" >= CURRENT_DATE)`,
+      [adminId]
+    );
+    return rows.map((row) => row.id);
+  }
+
+  /**
+   * Records or changes a member's vote
+   */
+  async upsertVote(successionId, voterId, candidateId) {
+    await txManager.query(
+      `INSERT INTO group_succession_votes (id, "successionId", "voterId", "candidateId", "createdAt")
+       VALUES (gen_random_uuid(), $1, $2, $3, now())
+       ON CONFLICT ("successionId", "voterId") DO UPDATE SET "candidateId" = EXCLUDED."candidateId"`,
+      [successionId, voterId, candidateId]
+    );
+  }
+
+  /**
+   * @returns {Promise<Array>} [{ candidateId, votes }], most voted first
+   */
+  async countVotes(successionId) {
+    return await txManager.query(
+      `SELECT "candidateId", COUNT(*)::int AS votes
+       FROM group_succession_votes
+       WHERE "successionId" = $1
+       GROUP BY "candidateId"
+       ORDER BY votes DESC`,
+      [successionId]
+    );
+  }
+
+  async findVote(successionId, voterId) {
+    return await this.getVoteRepository().findOne({ where: { successionId, voterId } });
+  }
+}
+
+export default new GroupSuccessionRepository();
@@ -0,0 +1,34 @@
+import txManager from "./transactionManager.js";
+import HeldPushNotification from "../models/heldPushNotification.model.js";
+
+class HeldPushNotificationRepository {
+  getRepository() {
+    return txManager.getRepository(HeldPushNotification);
+  }
+
+  /**
+   * Holds a push until the given time
+   * @returns {Promise<boolean>} true if it is the first push held for that release time
+   */
+  async hold({ userId, notificationId, releaseAt }) {
+    const alreadyHeld = await this.getRepository().count({ where: { userId, releaseAt } });
+    await this.getRepository().save(this.getRepository().create({ userId, notificationId, releaseAt }));
+    return alreadyHeld === 0;
+  }
+
+  /**
+   * Removes and returns the held pushes of a user that are due
+   * @returns {Promise<string[]>} Notification IDs
+   */
+  async takeDue(userId, now = new Date()) {
+    const [rows] = await txManager.query(
+      `DELETE FROM held_push_notifications
+       WHERE "userId" = $1 AND "releaseAt" <= $2
+       RETURNING "notificationId"`,
+      [userId, now]
+    );
+    return rows.map((row) => row.notificationId);
+  }
+}
+
+export default new HeldPushNotificationRepository();
@@ -0,0 +1,60 @@
+import txManager from "./transactionManager.js";
+import IdempotencyKey from "../models/idempotencyKey.model.js";
+
+class IdempotencyKeyRepository {
+  getRepository() {
+    return txManager.getRepository(IdempotencyKey);
+  }
+
+  async find(scope, key) {
+    return await this.getRepository().findOne({ where: { scope, key } });
+  }
+
+  /**
+   * Takes a key for a request: inserts it, or takes over an expired key or
+   * one whose request was lost while processing
+   * @returns {Promise<boolean>} Whether the key is now held by this request
+   */
+  async claim({ scope, key, requestHash, lockedUntil, expiresAt }) {
+    const rows = await txManager.query(
+      `INSERT INTO idempotency_keys (scope, key, "requestHash", status, "lockedUntil", "expiresAt", "createdAt")
+       VALUES ($1, $2, $3, 'processing', $4, $5, now())
+       ON CONFLICT (scope, key) DO UPDATE
+         SET "requestHash" = EXCLUDED."requestHash", status = 'processing',
+             "responseStatus" = NULL, "responseBody" = NULL,
+             "lockedUntil" = EXCLUDED."lockedUntil", "expiresAt" = EXCLUDED."expiresAt",
+             "createdAt" = now()
+         WHERE idempotency_keys."expiresAt" < now()
+            OR (idempotency_keys.status = 'processing' AND idempotency_keys."lockedUntil" < now()
+                AND idempotency_keys."requestHash" = EXCLUDED."requestHash")
+       RETURNING key`,
+      [scope, key, requestHash, lockedUntil, expiresAt]
+    );
+    return rows.length > 0;
+  }
+
+  async complete(scope, key, { responseStatus, responseBody }) {
+    await this.getRepository().update(
+      { scope, key },
+      { status: "completed", responseStatus, responseBody }
+    );
+  }
+
+  async release(scope, key) {
+    await this.getRepository().delete({ scope, key, status: "processing" });
+  }
+
+  /**
+   * @returns {Promise<number>} Keys removed
+   */
+  async deleteExpired() {
+    const result = await this.getRepository()
+      .createQueryBuilder()
+      .delete()
+      .where(`"expiresAt" < now()`)
+      .execute();
+    return result.affected || 0;
+  }
+}
+
+export default new IdempotencyKeyRepository();
@@ -1,12 +1,16 @@
 import { Repository } from "typeorm";
-import { AppDataSource } from "../load/typeorm.loader.js";
+import txManager from "./transactionManager.js";
 import Itinerary, { ItineraryItemSchema } from "../models/itinerary.model.js";
 import logger from "../config/logger.js";
 
 class ItineraryRepository {
-  constructor() {
-    this.itineraryRepository = AppDataSource.getRepository(Itinerary);
-    this.itineraryItemRepository = AppDataSource.getRepository(ItineraryItemSchema);
+  // Resolved on use so they pick up the open transaction
+  get itineraryRepository() {
+    return txManager.getRepository(Itinerary);
+  }
+
+  get itineraryItemRepository() {
+    return txManager.getRepository(ItineraryItemSchema);
   }
 
   /**
@@ -18,50 +22,44 @@ class ItineraryRepository {
    * @returns {Promise<Object>} The created itinerary
    */
   async createItinerary(itineraryData) {
-    const queryRunner = AppDataSource.createQueryRunner();
-    await queryRunner.connect();
-    await queryRunner.startTransaction();
-
     try {
-      logger.info(`Creating itinerary: ${itineraryData.name} for user: ${itineraryData.userId}`);
-
-      // Create the itinerary
-      const itinerary = this.itineraryRepository.create({
-        name: itineraryData.name,
-        userId: itineraryData.userId,
-      });
+      return await txManager.run(async (manager) => {
+        logger.info(`Creating itinerary: ${itineraryData.name} for user: ${itineraryData.userId}`);
 
-      const savedItinerary = await queryRunner.manager.save(Itinerary, itinerary);
+        // Create the itinerary
+        const itinerary = this.itineraryRepository.create({
+          name: itineraryData.name,
+          userId: itineraryData.userId,
+        });
 
-      // Create itinerary items
-      const items = itineraryData.items.map((item, index) => 
-        this.itineraryItemRepository.create({
-          itineraryId: savedItinerary.id,
-          placeId: item.placeId,
-          date: item.date,
-          order: index,
-        })
-      );
+        const savedItinerary = await manager.save(Itinerary, itinerary);
 
-      const savedItems = await queryRunner.manager.save(ItineraryItemSchema, items);
+        // Create itinerary items
+        const items = itineraryData.items.map((item, index) =>
+          this.itineraryItemRepository.create({
+            itineraryId: savedItinerary.id,
+            placeId: item.placeId,
+            date: item.date,
+            order: index,
+          })
+        );
 
-      await queryRunner.commitTransaction();
+        const savedItems = await manager.save(ItineraryItemSchema, items);
 
-      logger.info(`Itinerary created successfully: ${savedItinerary.id}`);
+        logger.info(`Itinerary created successfully: ${savedItinerary.id}`);
 
-      return {
-        ...savedItinerary,
-        items: savedItems,
-      };
+        return {
+          ...savedItinerary,
+          items: savedItems,
+        };
+      });
     } catch (error) {
-      await queryRunner.rollbackTransaction();
       logger.error(`Error creating itinerary: ${error.message}`);
       throw error;
-    } finally {
-      await queryRunner.release();
     }
   }
 
+
   /**
    * Gets an itinerary by ID with its items and place details
    * @param {string} itineraryId - The itinerary ID
@@ -146,59 +144,93 @@ class ItineraryRepository {
   }
 
   /**
-   * Updates an itinerary
+   * Updates an itinerary. Items sent with the id of an existing item are
+   * updated in place, so their trip plan details (time, notes, cost, order)
+   * survive; items without one are added at the end of their day, and
+   * existing items left out are removed.
    * @param {string} itineraryId - The itinerary ID
    * @param {Object} updateData - The data to update
    * @returns {Promise<Object>} The updated itinerary
    */
   async updateItinerary(itineraryId, updateData) {
-    const queryRunner = AppDataSource.createQueryRunner();
-    await queryRunner.connect();
-    await queryRunner.startTransaction();
-
     try {
-      logger.info(`Updating itinerary: ${itineraryId}`);
-
-      // Update itinerary basic info
-      if (updateData.name) {
-        await queryRunner.manager.update(Itinerary, itineraryId, {
-          name: updateData.name,
-        });
-      }
-
-      // Update items if provided
-      if (updateData.items) {
-        // Delete existing items
-        await queryRunner.manager.delete(ItineraryItemSchema, { itineraryId });
-
-        // Create new items
-        const items = updateData.items.map((item, index) => 
-          this.itineraryItemRepository.create({
-            itineraryId,
-            placeId: item.placeId,
-            date: item.date,
-            order: index,
-          })
-        );
-
-        await queryRunner.manager.save(ItineraryItemSchema, items);
-      }
+      await txManager.run(async (manager) => {
+        logger.info(`Updating itinerary: ${itineraryId}`);
+
+        // Update itinerary basic info
+        if (updateData.name) {
+          await manager.update(Itinerary, itineraryId, {
+            name: updateData.name,
+          });
+        }
+
+        // Update items if provided
+        if (updateData.items) {
+          const existing = new Map(
+            (await manager.find(ItineraryItemSchema, { where: { itineraryId } })).map((item) => [item.id, item])
+          );
+          const kept = updateData.items.filter((item) => item.id && existing.has(item.id));
+          const keptIds = new Set(kept.map((item) => item.id));
+
+          const removedIds = [...existing.keys()].filter((id) => !keptIds.has(id));
+          if (removedIds.length > 0) {
+            await manager.delete(ItineraryItemSchema, removedIds);
+          }
 
-      await queryRunner.commitTransaction();
+          // Items staying on their day keep their place in it; the rest go last
+          const lastOrder = new Map();
+          for (const item of kept) {
+            const current = existing.get(item.id);
+            if (current.date === item.date) {
+              lastOrder.set(item.date, Math.max(lastOrder.get(item.date) ?? -1, current.order));
+            }
+          }
+          const nextOrder = (date) => {
+            const order = (lastOrder.get(date) ?? -1) + 1;
+            lastOrder.set(date, order);
+            return order;
+          };
+
+          const items = updateData.items.map((item) => {
+            const current = item.id ? existing.get(item.id) : null;
+            const details = {
+              ...(item.time !== undefined && { time: item.time }),
+              ...(item.notes !== undefined && { notes: item.notes }),
+              ...(item.cost !== undefined && { cost: item.cost }),
+            };
+            if (current) {
+              return {
+                ...current,
+                placeId: item.placeId,
+                date: item.date,
+                order: current.date === item.date ? current.order : nextOrder(item.date),
+                ...details,
+              };
+            }
+            return this.itineraryItemRepository.create({
+              itineraryId,
+              placeId: item.placeId,
+              date: item.date,
+              order: nextOrder(item.date),
+              ...details,
+            });
+          });
+
+          await manager.save(ItineraryItemSchema, items);
+        }
+      });
 
       // Return updated itinerary
       const updatedItinerary = await this.getItineraryById(itineraryId);
       logger.info(`Itinerary updated successfully: ${itineraryId}`);
       return updatedItinerary;
     } catch (error) {
-      await queryRunner.rollbackTransaction();
       logger.error(`Error updating itinerary: ${error.message}`);
       throw error;
-    } finally {
-      await queryRunner.release();
     }
   }
 
+
   /**
    * Deletes an itinerary
    * @param {string} itineraryId - The itinerary ID
@@ -0,0 +1,256 @@
+import { In } from "typeorm";
+import txManager from "./transactionManager.js";
+import Job from "../models/job.model.js";
+import JobQueuePause from "../models/jobQueuePause.model.js";
+import JobStat from "../models/jobStat.model.js";
+
+class JobRepository {
+  getRepository() {
+    return txManager.getRepository(Job);
+  }
+
+  /**
+   * Adds a job to the queue
+   * @param {Object} data - { type, payload, payloadHash, status, runAt, maxAttempts, lastError }
+   * @returns {Promise<Job>}
+   */
+  async create(data) {
+    const job = this.getRepository().create(data);
+    return await this.getRepository().save(job);
+  }
+
+  async findById(id) {
+    return await this.getRepository().findOne({ where: { id } });
+  }
+
+  /**
+   * Atomically claims the next due job of a type that is not paused. SKIP
+   * LOCKED lets several workers poll the same table without handing out a job twice.
+   * @returns {Promise<Object|null>} Claimed job or null if the queue is empty
+   */
+  async claimNext() {
+    const rows = await txManager.query(
+      `UPDATE jobs
+       SET status = 'running', "lockedAt" = NOW(), attempts = attempts + 1, "updatedAt" = NOW()
+       WHERE id = (
+         SELECT id FROM jobs
+         WHERE status = 'pending' AND "runAt" <= NOW()
+           AND type NOT IN (SELECT type FROM job_queue_pauses)
+         ORDER BY "runAt" ASC
+         FOR UPDATE SKIP LOCKED
+         LIMIT 1
+       )
+       RETURNING *`
+    );
+    // pg returns [rows, affected] for UPDATE ... RETURNING
+    const claimed = Array.isArray(rows[0]) ? rows[0] : rows;
+    return claimed[0] || null;
+  }
+
+  async markCompleted(id) {
+    await this.getRepository().update(id, {
+      status: "completed",
+      completedAt: new Date(),
+      lockedAt: null,
+      lastError: null,
+      lastCrashStack: null,
+    });
+  }
+
+  /**
+   * Puts a failed job back in the queue, or marks it failed when out of attempts
+   * @param {Object} job
+   * @param {string} errorMessage
+   * @param {Date|null} retryAt - null when the job must not be retried
+   * @param {string|null} crashStack - Stack when the failure was a crash
+   */
+  async markFailed(job, errorMessage, retryAt, crashStack = null) {
+    await this.getRepository().update(job.id, {
+      status: retryAt ? "pending" : "failed",
+      runAt: retryAt || job.runAt,
+      lockedAt: null,
+      lastError: errorMessage ? String(errorMessage).slice(0, 2000) : null,
+      lastCrashStack: crashStack ? String(crashStack).slice(0, 8000) : null,
+    });
+  }
+
+  /**
+   * Takes a job out of the queue because its payload keeps failing
+   * @param {Object} job
+   * @param {string} errorMessage
+   * @param {string|null} crashStack
+   */
+  async markQuarantined(job, errorMessage, crashStack = null) {
+    await this.getRepository().update(job.id, {
+      status: "quarantined",
+      lockedAt: null,
+      lastError: errorMessage ? String(errorMessage).slice(0, 2000) : null,
+      lastCrashStack: crashStack ? String(crashStack).slice(0, 8000) : null,
+    });
+  }
+
+  /**
+   * Whether a payload of this type is in quarantine
+   */
+  async isQuarantined(type, payloadHash) {
+    return await this.getRepository().exists({
+      where: { type, payloadHash, status: "quarantined" },
+    });
+  }
+
+  /**
+   * Failed attempts of the other jobs with the same payload since a date
+   * (jobs that eventually completed no longer count)
+   * @param {Object} job
+   * @param {Date} since
+   * @returns {Promise<number>}
+   */
+  async countPayloadFailures(job, since) {
+    const [row] = await txManager.query(
+      `SELECT COALESCE(SUM(attempts), 0)::int AS failures
+       FROM jobs
+       WHERE type = $1 AND "payloadHash" = $2 AND id <> $3
+         AND "lastError" IS NOT NULL AND status <> 'completed' AND "updatedAt" > $4`,
+      [job.type, job.payloadHash, job.id, since]
+    );
+    return row.failures;
+  }
+
+  /**
+   * Counts the outcome of an attempt in the hourly stats of its type
+   * @param {string} type
+   * @param {Object} outcome - { succeeded, crashed }
+   */
+  async recordOutcome(type, { succeeded, crashed = false }) {
+    await txManager.query(
+      `INSERT INTO job_stats (id, type, hour, succeeded, failed, crashed)
+       VALUES (gen_random_uuid(), $1, date_trunc('hour', NOW()), $2, $3, $4)
+       ON CONFLICT (type, hour) DO UPDATE SET
+         succeeded = job_stats.succeeded + EXCLUDED.succeeded,
+         failed = job_stats.failed + EXCLUDED.failed,
+         crashed = job_stats.crashed + EXCLUDED.crashed`,
+      [type, succeeded ? 1 : 0, succeeded ? 0 : 1, crashed ? 1 : 0]
+    );
+  }
+
+  /**
+   * Attempt outcomes per type since a date
+   * @param {Date} since
+   * @returns {Promise<Array>} - [{ type, succeeded, failed, crashed }]
+   */
+  async sumOutcomes(since) {
+    return await txManager.getRepository(JobStat)
+      .createQueryBuilder("stat")
+      .select("stat.type", "type")
+      .addSelect("SUM(stat.succeeded)::int", "succeeded")
+      .addSelect("SUM(stat.failed)::int", "failed")
+      .addSelect("SUM(stat.crashed)::int", "crashed")
+      .where("stat.hour >= date_trunc('hour', CAST(:since AS timestamptz))", { since })
+      .groupBy("stat.type")
+      .orderBy("stat.type", "ASC")
+      .getRawMany();
+  }
+
+  /**
+   * Releases jobs left running by a worker that died
+   * @param {number} staleAfterMs
+   * @returns {Promise<number>} Released jobs
+   */
+  async releaseStale(staleAfterMs) {
+    const rows = await txManager.query(
+      `UPDATE jobs
+       SET status = 'pending', "lockedAt" = NULL, "updatedAt" = NOW()
+       WHERE status = 'running' AND "lockedAt" < NOW() - ($1 || ' milliseconds')::interval
+       RETURNING id`,
+      [String(staleAfterMs)]
+    );
+    const released = Array.isArray(rows[0]) ? rows[0] : rows;
+    return released.length;
+  }
+
+  /**
+   * Jobs per type and status, with the oldest due pending job of each type
+   * @returns {Promise<Array>} - [{ type, status, count, oldestDueAt }]
+   */
+  async countByTypeAndStatus() {
+    return await txManager.query(
+      `SELECT type, status, COUNT(*)::int AS count,
+              MIN("runAt") FILTER (WHERE status = 'pending' AND "runAt" <= NOW()) AS "oldestDueAt"
+       FROM jobs
+       WHERE status IN ('pending', 'running', 'failed', 'quarantined')
+       GROUP BY type, status
+       ORDER BY type, status`
+    );
+  }
+
+  /**
+   * Failed or quarantined jobs, most recently updated first
+   * @param {Object} filters - { status: "failed" | "quarantined", type, limit, offset }
+   * @returns {Promise<Object>} - { jobs, total }
+   */
+  async findStuck({ status, type, limit, offset }) {
+    const [jobs, total] = await this.getRepository().findAndCount({
+      where: { status, ...(type ? { type } : {}) },
+      order: { updatedAt: "DESC", id: "ASC" },
+      take: limit,
+      skip: offset,
+    });
+    return { jobs, total };
+  }
+
+  /**
+   * Puts failed or quarantined jobs back in the queue with a fresh set of attempts
+   * @param {Object} where - { id } or { type, status }
+   * @returns {Promise<Array>} IDs of the requeued jobs
+   */
+  async requeueStuck({ id, type, status }) {
+    const rows = await txManager.query(
+      `UPDATE jobs
+       SET status = 'pending', attempts = 0, "runAt" = NOW(), "lockedAt" = NULL, "updatedAt" = NOW()
+       WHERE status IN ('failed', 'quarantined')
+         AND ($1::uuid IS NULL OR id = $1)
+         AND ($2::varchar IS NULL OR type = $2)
+         AND ($3::varchar IS NULL OR status = $3)
+       RETURNING id`,
+      [id || null, type || null, status || null]
+    );
+    const requeued = Array.isArray(rows[0]) ? rows[0] : rows;
+    return requeued.map((row) => row.id);
+  }
+
+  /**
+   * Marks a failed or quarantined job as discarded: it stays for reference but
+   * is never retried
+   * @returns {Promise<boolean>} false when the job is neither failed nor quarantined
+   */
+  async discardStuck(id) {
+    const result = await this.getRepository().update(
+      { id, status: In(["failed", "quarantined"]) },
+      { status: "discarded" }
+    );
+    return result.affected > 0;
+  }
+
+  async findPauses() {
+    return await txManager.getRepository(JobQueuePause).find({ order: { pausedAt: "ASC" } });
+  }
+
+  async findPause(type) {
+    return await txManager.getRepository(JobQueuePause).findOne({ where: { type } });
+  }
+
+  async createPause(data) {
+    const repo = txManager.getRepository(JobQueuePause);
+    return await repo.save(repo.create(data));
+  }
+
+  /**
+   * @returns {Promise<boolean>} false when the type was not paused
+   */
+  async deletePause(type) {
+    const result = await txManager.getRepository(JobQueuePause).delete({ type });
+    return result.affected > 0;
+  }
+}
+
+export default new JobRepository();
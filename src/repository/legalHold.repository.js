@@ -0,0 +1,200 @@
+import txManager from "./transactionManager.js";
+import LegalHold from "../models/legalHold.model.js";
+import LegalExport from "../models/legalExport.model.js";
+import LegalAccessLog from "../models/legalAccessLog.model.js";
+
+// Columns never included in an export, even for legal requests
+const USER_SECRET_COLUMNS = ["password", "emailConfirmationToken", "passwordResetToken", "passwordResetExpires"];
+
+// Every table holding user data and the column(s) that reference the user.
+// Keep in sync when adding models with a user reference.
+const EXPORT_SECTIONS = {
+  affiliate_attributions: ["userId"],
+  affiliate_partners: ["userId"],
+  age_reviews: ["userId", "reportedById"],
+  answers: ["userId"],
+  answer_votes: ["userId"],
+  attachments: ["userId"],
+  chat_messages: ["userId"],
+  companion_references: ["authorId", "subjectId"],
+  conversations: ["userId"],
+  device_tokens: ["userId"],
+  direct_messages: ["senderId", "receiverId"],
+  expenses: ["userId", "paidById"],
+  groups: ["adminId"],
+  group_members: ["userId"],
+  group_memberships: ["userId"],
+  group_documents: ["createdById"],
+  group_document_versions: ["uploadedById"],
+  group_join_requests: ["userId"],
+  group_messages: ["senderId"],
+  group_message_reads: ["userId"],
+  itineraries: ["userId"],
+  lists: ["userId"],
+  notifications: ["userId"],
+  notification_preferences: ["userId"],
+  past_trips: ["userId"],
+  payments: ["userId"],
+  questions: ["userId"],
+  question_votes: ["userId"],
+  recommendation_emails: ["userId"],
+  recommendation_clicks: ["userId"],
+  reviews: ["userId"],
+  review_likes: ["userId"],
+  trip_reviews: ["authorId", "organizerId"],
+  trip_review_flags: ["userId"],
+  user_actions: ["userId"],
+  user_blocks: ["blockerId", "blockedId"],
+  user_favorites: ["userId"],
+  user_followers: ["followerId", "followedId"],
+  user_rate_limits: ["userId"],
+  user_reports: ["reporterId", "targetUserId"],
+};
+
+// Self-service export (GET /api/users/me/export): the same data minus what would
+// expose other users' moderation actions (who reported or blocked the user)
+export const SELF_EXPORT_SECTIONS = Object.fromEntries(
+  Object.entries({
+    ...EXPORT_SECTIONS,
+    user_blocks: ["blockerId"],
+    user_reports: ["reporterId"],
+  }).filter(([table]) => table !== "age_reviews")
+);
+
+// Child rows only reachable through a parent owned by the user
+const NESTED_SECTIONS = {
+  itinerary_items: `SELECT * FROM itinerary_items WHERE "itineraryId" IN (SELECT id FROM itineraries WHERE "userId" = $1)`,
+  list_places: `SELECT * FROM list_places WHERE "listId" IN (SELECT id FROM lists WHERE "userId" = $1)`,
+  review_media: `SELECT * FROM review_media WHERE "reviewId" IN (SELECT id FROM reviews WHERE "userId" = $1)`,
+};
+
+class LegalHoldRepository {
+  getRepository() {
+    return txManager.getRepository(LegalHold);
+  }
+
+  getExportRepository() {
+    return txManager.getRepository(LegalExport);
+  }
+
+  getAccessLogRepository() {
+    return txManager.getRepository(LegalAccessLog);
+  }
+
+  async create(data) {
+    const hold = this.getRepository().create(data);
+    return await this.getRepository().save(hold);
+  }
+
+  async findById(id) {
+    return await this.getRepository().findOne({ where: { id } });
+  }
+
+  async update(id, data) {
+    await this.getRepository().update(id, data);
+    return await this.findById(id);
+  }
+
+  /**
+   * @param {Object} filters - { userId, status }
+   */
+  async findAll({ userId = null, status = null } = {}) {
+    const where = {};
+    if (userId) where.userId = userId;
+    if (status) where.status = status;
+    return await this.getRepository().find({
+      where,
+      relations: ["user"],
+      order: { createdAt: "DESC" },
+    });
+  }
+
+  async findActiveByUser(userId) {
+    return await this.getRepository().findOne({
+      where: { userId, status: "active" },
+    });
+  }
+
+  /**
+   * Which of the given users have an active hold
+   * @param {string[]} userIds
+   * @returns {Promise<string[]>}
+   */
+  async findUsersOnHold(userIds) {
+    if (!userIds.length) {
+      return [];
+    }
+    const holds = await this.getRepository()
+      .createQueryBuilder("hold")
+      .select("DISTINCT hold.userId", "userId")
+      .where("hold.userId IN (:...userIds)", { userIds })
+      .andWhere("hold.status = :status", { status: "active" })
+      .getRawMany();
+    return holds.map((hold) => hold.userId);
+  }
+
+  async createExport(data) {
+    const legalExport = this.getExportRepository().create(data);
+    return await this.getExportRepository().save(legalExport);
+  }
+
+  async findExportById(id) {
+    return await this.getExportRepository().findOne({ where: { id } });
+  }
+
+  async updateExport(id, data) {
+    await this.getExportRepository().update(id, data);
+    return await this.findExportById(id);
+  }
+
+  async logAccess(data) {
+    const entry = this.getAccessLogRepository().create(data);
+    return await this.getAccessLogRepository().save(entry);
+  }
+
+  /**
+   * @param {Object} filters - { targetUserId, actorId, limit }
+   */
+  async findAccessLog({ targetUserId = null, actorId = null, limit = 100 } = {}) {
+    const where = {};
+    if (targetUserId) where.targetUserId = targetUserId;
+    if (actorId) where.actorId = actorId;
+    return await this.getAccessLogRepository().find({
+      where,
+      order: { createdAt: "DESC" },
+      take: limit,
+    });
+  }
+
+  /**
+   * Collects every row referencing the user, grouped by table
+   * @param {string} userId
+   * @param {Object} sectionColumns - Tables to collect and their user columns (all by default)
+   * @returns {Promise<Object|null>} - { account, sections } or null if the user does not exist
+   */
+  async collectUserData(userId, sectionColumns = EXPORT_SECTIONS) {
+    const [account] = await txManager.query(`SELECT * FROM users WHERE id = $1`, [userId]);
+    if (!account) {
+      return null;
+    }
+    for (const column of USER_SECRET_COLUMNS) {
+      delete account[column];
+    }
+
+    const sections = {};
+    for (const [table, columns] of Object.entries(sectionColumns)) {
+      const conditions = columns.map((column) => `"${column}" = $1`).join(" OR ");
+      sections[table] = await txManager.query(
+        `SELECT * FROM ${table} WHERE ${conditions}`,
+        [userId]
+      );
+    }
+    for (const [table, query] of Object.entries(NESTED_SECTIONS)) {
+      sections[table] = await txManager.query(query, [userId]);
+    }
+
+    return { account, sections };
+  }
+}
+
+export default new LegalHoldRepository();
@@ -1,17 +1,9 @@
-import { AppDataSource } from "../load/typeorm.loader.js";
+import txManager from "./transactionManager.js";
 import List from "../models/list.model.js";
 
 class ListRepository {
-  constructor() {
-    this.repository = null;
-  }
-
-  // Inicializa el repositorio cuando TypeORM esté listo
   getRepository() {
-    if (!this.repository) {
-      this.repository = AppDataSource.getRepository(List);
-    }
-    return this.repository;
+    return txManager.getRepository(List);
   }
 
   /**
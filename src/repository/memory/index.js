@@ -0,0 +1,126 @@
+import { AppDataSource } from "../../load/typeorm.loader.js";
+import MemoryRepository from "./memoryRepository.js";
+
+const PATCHED = ["getRepository", "query", "transaction", "createQueryRunner", "manager"];
+
+const rawSqlError = (sql) =>
+  new Error(
+    `Raw SQL is not supported by the in-memory repositories; stub the repository method in the test: ${String(sql).trim().slice(0, 80)}`
+  );
+
+/**
+ * Swaps the data source behind every repository for in-memory tables, so
+ * service tests run without a database.
+ *
+ * Repositories keep their code: getRepository() now returns a MemoryRepository
+ * for the entity. Methods built on raw SQL or query builders throw a clear
+ * error instead; stub those in the test (jest.spyOn(repository, "method")).
+ *
+ *   const memory = useMemoryRepositories();
+ *   afterEach(() => memory.reset());
+ *   afterAll(() => memory.restore());
+ *
+ *   await memory.seed("PastTrip", [{ userId, startDate: "2024-03-01", endDate: "2024-03-05" }]);
+ *
+ * Call it before the code under test first touches a repository: some
+ * repositories cache getRepository() on first use.
+ *
+ * @returns {Object} - { repository(entity), seed(entity, rows), reset(), restore() }
+ */
+export const useMemoryRepositories = () => {
+  const repositories = new Map();
+  for (const schema of AppDataSource.options.entities) {
+    repositories.set(schema.options.name, new MemoryRepository(schema));
+  }
+
+  const repository = (target) => {
+    const name = typeof target === "string" ? target : target?.options?.name;
+    const found = repositories.get(name);
+    if (!found) {
+      throw new Error(`Entity ${name} is not registered in the data source`);
+    }
+    return found;
+  };
+
+  const manager = {
+    getRepository: repository,
+    create: (target, data) => repository(target).create(data),
+    save: async (target, entity) => await repository(target).save(entity),
+    insert: async (target, entity) => await repository(target).insert(entity),
+    find: async (target, options) => await repository(target).find(options),
+    findOne: async (target, options) => await repository(target).findOne(options),
+    count: async (target, options) => await repository(target).count(options),
+    update: async (target, criteria, partial) => await repository(target).update(criteria, partial),
+    delete: async (target, criteria) => await repository(target).delete(criteria),
+    increment: async (target, where, column, value) =>
+      await repository(target).increment(where, column, value),
+    query: async (sql) => {
+      throw rawSqlError(sql);
+    },
+    createQueryBuilder: () => {
+      throw rawSqlError("query builder");
+    },
+    // Rows are written right away; a rollback does not undo them
+    transaction: async (work) => await work(manager),
+  };
+
+  const originals = Object.fromEntries(
+    PATCHED.map((key) => [key, Object.getOwnPropertyDescriptor(AppDataSource, key)])
+  );
+  const patch = {
+    getRepository: repository,
+    query: manager.query,
+    transaction: manager.transaction,
+    createQueryRunner: () => ({
+      manager,
+      query: manager.query,
+      connect: async () => {},
+      startTransaction: async () => {},
+      commitTransaction: async () => {},
+      rollbackTransaction: async () => {},
+      release: async () => {},
+    }),
+    manager,
+  };
+  for (const [key, value] of Object.entries(patch)) {
+    Object.defineProperty(AppDataSource, key, { value, configurable: true, writable: true });
+  }
+
+  return {
+    repository,
+
+    /**
+     * Inserts rows, filling in generated IDs, defaults and timestamps
+     * @param {string|EntitySchema} target
+     * @param {Array<Object>} rows
+     * @returns {Promise<Array<Object>>} Saved rows
+     */
+    async seed(target, rows) {
+      return await repository(target).save(rows.map((row) => ({ ...row })));
+    },
+
+    /**
+     * Empties every table
+     */
+    reset() {
+      for (const table of repositories.values()) {
+        table.clear();
+      }
+    },
+
+    /**
+     * Puts the real data source back
+     */
+    restore() {
+      for (const key of PATCHED) {
+        if (originals[key]) {
+          Object.defineProperty(AppDataSource, key, originals[key]);
+        } else {
+          delete AppDataSource[key];
+        }
+      }
+    },
+  };
+};
+
+export { MemoryRepository };
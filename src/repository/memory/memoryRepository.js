@@ -0,0 +1,369 @@
+import { randomUUID } from "crypto";
+import { FindOperator } from "typeorm";
+
+// TypeORM tags its classes so the check survives duplicated installs
+const isFindOperator = (value) =>
+  value instanceof FindOperator || value?.["@instanceof"] === Symbol.for("FindOperator");
+
+const clone = (value) => (value === undefined ? undefined : structuredClone(value));
+
+const compare = (a, b) => {
+  if (a === b) return 0;
+  if (a === null || a === undefined) return -1;
+  if (b === null || b === undefined) return 1;
+  const left = a instanceof Date ? a.getTime() : a;
+  const right = b instanceof Date ? b.getTime() : b;
+  return left < right ? -1 : left > right ? 1 : 0;
+};
+
+const likeToRegExp = (pattern, flags) =>
+  new RegExp(
+    `^${String(pattern)
+      .replace(/[.*+?^${}()|[\]\\]/g, "\\$&")
+      .replace(/%/g, ".*")
+      .replace(/_/g, ".")}$`,
+    flags
+  );
+
+/**
+ * Whether a column value satisfies a TypeORM find operator
+ */
+const matchesOperator = (value, operator) => {
+  const inner = operator.child !== undefined ? operator.child : operator.value;
+  switch (operator.type) {
+    case "not":
+      return !matchesValue(value, inner);
+    case "isNull":
+      return value === null || value === undefined;
+    case "equal":
+      return compare(value, inner) === 0;
+    case "in":
+    case "any":
+      return inner.some((candidate) => compare(value, candidate) === 0);
+    case "lessThan":
+      return value !== null && compare(value, inner) < 0;
+    case "lessThanOrEqual":
+      return value !== null && compare(value, inner) <= 0;
+    case "moreThan":
+      return value !== null && compare(value, inner) > 0;
+    case "moreThanOrEqual":
+      return value !== null && compare(value, inner) >= 0;
+    case "between":
+      return value !== null && compare(value, inner[0]) >= 0 && compare(value, inner[1]) <= 0;
+    case "like":
+      return value !== null && likeToRegExp(inner).test(String(value));
+    case "ilike":
+      return value !== null && likeToRegExp(inner, "i").test(String(value));
+    case "and":
+      return inner.every((child) => matchesValue(value, child));
+    case "or":
+      return inner.some((child) => matchesValue(value, child));
+    default:
+      throw new Error(`Operator ${operator.type} is not supported by the in-memory repositories`);
+  }
+};
+
+const matchesValue = (value, expected) => {
+  if (isFindOperator(expected)) {
+    return matchesOperator(value, expected);
+  }
+  if (expected === null) {
+    return value === null || value === undefined;
+  }
+  if (expected && typeof expected === "object" && !(expected instanceof Date)) {
+    // Nested where on an embedded object or a loaded relation
+    return Boolean(value) && matchesWhere(value, expected);
+  }
+  return compare(value, expected) === 0;
+};
+
+const matchesWhere = (row, where) =>
+  Object.entries(where).every(
+    ([column, expected]) => expected === undefined || matchesValue(row[column], expected)
+  );
+
+/**
+ * Stand-in for a TypeORM Repository that keeps rows in an array.
+ *
+ * Covers what repositories call through getRepository(): create, save, find,
+ * findOne, count, update, delete, increment and their *By variants, with
+ * where objects (or arrays of them for OR), find operators (In, Not, IsNull,
+ * LessThan, MoreThan, Between, Like...), order, skip/take and soft-deleted
+ * rows. Column defaults, generated UUIDs and create/update/delete date columns
+ * come from the EntitySchema. Relations are not loaded and query builders are
+ * not supported.
+ */
+export default class MemoryRepository {
+  /**
+   * @param {EntitySchema} schema
+   */
+  constructor(schema) {
+    this.schema = schema;
+    this.columns = schema.options.columns;
+    this.primaryColumns = Object.keys(this.columns).filter((name) => this.columns[name].primary);
+    this.deleteDateColumn =
+      Object.keys(this.columns).find((name) => this.columns[name].deleteDate) || null;
+    this.rows = [];
+  }
+
+  get name() {
+    return this.schema.options.name;
+  }
+
+  /**
+   * Removes every row
+   */
+  async clear() {
+    this.rows = [];
+  }
+
+  create(data = {}) {
+    if (Array.isArray(data)) {
+      return data.map((item) => this.create(item));
+    }
+    return clone(data);
+  }
+
+  merge(entity, ...sources) {
+    return Object.assign(entity, ...sources.map(clone));
+  }
+
+  async save(entity) {
+    if (Array.isArray(entity)) {
+      const saved = [];
+      for (const item of entity) {
+        saved.push(await this.save(item));
+      }
+      return saved;
+    }
+
+    const now = new Date();
+    const existing = this.hasPrimary(entity) ? this.findStored(entity) : null;
+    if (existing) {
+      Object.assign(existing, clone(entity), this.updateDates(now));
+      return Object.assign(entity, clone(existing));
+    }
+
+    const row = { ...this.defaults(now), ...clone(entity) };
+    this.rows.push(row);
+    return Object.assign(entity, clone(row));
+  }
+
+  async insert(entity) {
+    const saved = await this.save(Array.isArray(entity) ? entity.map(clone) : clone(entity));
+    const rows = Array.isArray(saved) ? saved : [saved];
+    return {
+      identifiers: rows.map((row) => this.pickPrimary(row)),
+      generatedMaps: rows,
+      raw: rows,
+    };
+  }
+
+  async find(options = {}) {
+    let rows = this.filter(options.where, options);
+    if (options.order) {
+      rows = this.sort(rows, options.order);
+    }
+    const skip = options.skip ?? 0;
+    const take = options.take ?? rows.length;
+    return rows.slice(skip, skip + take).map((row) => this.select(row, options.select));
+  }
+
+  async findBy(where) {
+    return await this.find({ where });
+  }
+
+  async findAndCount(options = {}) {
+    const rows = await this.find(options);
+    return [rows, await this.count(options)];
+  }
+
+  async findOne(options = {}) {
+    const [row] = await this.find({ ...options, take: 1 });
+    return row || null;
+  }
+
+  async findOneBy(where) {
+    return await this.findOne({ where });
+  }
+
+  async findOneOrFail(options = {}) {
+    const row = await this.findOne(options);
+    if (!row) {
+      throw new Error(`Could not find any entity of type "${this.name}"`);
+    }
+    return row;
+  }
+
+  async count(options = {}) {
+    return this.filter(options.where, options).length;
+  }
+
+  async countBy(where) {
+    return await this.count({ where });
+  }
+
+  async exists(options = {}) {
+    return (await this.count(options)) > 0;
+  }
+
+  async exist(options = {}) {
+    return await this.exists(options);
+  }
+
+  async update(criteria, partial) {
+    const rows = this.filterByCriteria(criteria);
+    const changes = clone(partial);
+    const dates = this.updateDates(new Date());
+    for (const row of rows) {
+      Object.assign(row, changes, dates);
+    }
+    return { affected: rows.length, raw: [], generatedMaps: [] };
+  }
+
+  async delete(criteria) {
+    const rows = new Set(this.filterByCriteria(criteria));
+    this.rows = this.rows.filter((row) => !rows.has(row));
+    return { affected: rows.size, raw: [] };
+  }
+
+  async remove(entity) {
+    const entities = Array.isArray(entity) ? entity : [entity];
+    for (const item of entities) {
+      await this.delete(this.pickPrimary(item));
+    }
+    return entity;
+  }
+
+  async softDelete(criteria) {
+    return await this.update(criteria, { [this.deleteDateColumn]: new Date() });
+  }
+
+  async restore(criteria) {
+    const rows = this.filterByCriteria(criteria);
+    for (const row of rows) {
+      row[this.deleteDateColumn] = null;
+    }
+    return { affected: rows.length, raw: [], generatedMaps: [] };
+  }
+
+  async increment(where, column, value) {
+    const rows = this.filter(where);
+    for (const row of rows) {
+      row[column] = Number(row[column] || 0) + Number(value);
+    }
+    return { affected: rows.length, raw: [], generatedMaps: [] };
+  }
+
+  async decrement(where, column, value) {
+    return await this.increment(where, column, -Number(value));
+  }
+
+  createQueryBuilder() {
+    throw new Error(
+      `Query builders are not supported by the in-memory repositories (${this.name}); stub the repository method in the test`
+    );
+  }
+
+  async query(sql) {
+    throw new Error(
+      `Raw SQL is not supported by the in-memory repositories (${this.name}): ${String(sql).trim().slice(0, 80)}`
+    );
+  }
+
+  filter(where, { withDeleted = false } = {}) {
+    const conditions = Array.isArray(where) ? where : [where || {}];
+    return this.rows.filter(
+      (row) =>
+        (withDeleted || !this.deleteDateColumn || !row[this.deleteDateColumn]) &&
+        conditions.some((condition) => matchesWhere(row, condition))
+    );
+  }
+
+  /**
+   * Rows matched by the criteria of update/delete: a primary key, a list of
+   * them or a where object. Like TypeORM, soft-deleted rows are included.
+   */
+  filterByCriteria(criteria) {
+    if (Array.isArray(criteria)) {
+      return [...new Set(criteria.flatMap((item) => this.filterByCriteria(item)))];
+    }
+    if (criteria === null || typeof criteria !== "object" || criteria instanceof Date) {
+      return this.filter({ [this.primaryColumns[0]]: criteria }, { withDeleted: true });
+    }
+    return this.filter(criteria, { withDeleted: true });
+  }
+
+  sort(rows, order) {
+    const keys = Object.entries(order).map(([column, direction]) => {
+      const value = typeof direction === "object" ? direction.direction : direction;
+      return [column, String(value).toUpperCase() === "DESC" ? -1 : 1];
+    });
+    return [...rows].sort((a, b) => {
+      for (const [column, sign] of keys) {
+        const result = compare(a[column], b[column]);
+        if (result !== 0) {
+          return result * sign;
+        }
+      }
+      return 0;
+    });
+  }
+
+  select(row, select) {
+    const copy = clone(row);
+    if (!select) {
+      return copy;
+    }
+    const columns = Array.isArray(select)
+      ? select
+      : Object.keys(select).filter((column) => select[column]);
+    return Object.fromEntries(columns.map((column) => [column, copy[column]]));
+  }
+
+  hasPrimary(entity) {
+    return this.primaryColumns.every((column) => entity[column] !== undefined && entity[column] !== null);
+  }
+
+  pickPrimary(entity) {
+    return Object.fromEntries(this.primaryColumns.map((column) => [column, entity[column]]));
+  }
+
+  findStored(entity) {
+    return this.rows.find((row) =>
+      this.primaryColumns.every((column) => compare(row[column], entity[column]) === 0)
+    );
+  }
+
+  /**
+   * Values the database would fill in on insert
+   */
+  defaults(now) {
+    const values = {};
+    for (const [name, column] of Object.entries(this.columns)) {
+      if (column.generated === "uuid") {
+        values[name] = randomUUID();
+      } else if (column.generated === "increment") {
+        values[name] = this.rows.reduce((max, row) => Math.max(max, Number(row[name]) || 0), 0) + 1;
+      } else if (column.createDate || column.updateDate) {
+        values[name] = new Date(now);
+      } else if (typeof column.default === "function") {
+        // Function defaults are all CURRENT_TIMESTAMP / now()
+        values[name] = new Date(now);
+      } else if (column.default !== undefined) {
+        values[name] = clone(column.default);
+      } else if (column.nullable || column.deleteDate) {
+        values[name] = null;
+      }
+    }
+    return values;
+  }
+
+  updateDates(now) {
+    return Object.fromEntries(
+      Object.keys(this.columns)
+        .filter((name) => this.columns[name].updateDate)
+        .map((name) => [name, new Date(now)])
+    );
+  }
+}
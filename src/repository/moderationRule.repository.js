@@ -0,0 +1,82 @@
+import txManager from "./transactionManager.js";
+import ModerationRule from "../models/moderationRule.model.js";
+import ModerationRuleHit from "../models/moderationRuleHit.model.js";
+
+class ModerationRuleRepository {
+  getRepository() {
+    return txManager.getRepository(ModerationRule);
+  }
+
+  getHitRepository() {
+    return txManager.getRepository(ModerationRuleHit);
+  }
+
+  async create(data) {
+    const rule = this.getRepository().create(data);
+    return await this.getRepository().save(rule);
+  }
+
+  async findById(id) {
+    return await this.getRepository().findOne({ where: { id } });
+  }
+
+  async findAll() {
+    return await this.getRepository().find({ order: { createdAt: "ASC" } });
+  }
+
+  async findEnabledByTarget(targetType) {
+    return await this.getRepository().find({
+      where: { targetType, enabled: true },
+      order: { createdAt: "ASC" },
+    });
+  }
+
+  async update(id, data) {
+    await this.getRepository().update(id, data);
+    return await this.findById(id);
+  }
+
+  async delete(id) {
+    const result = await this.getRepository().delete(id);
+    return result.affected > 0;
+  }
+
+  /**
+   * Records that a rule acted on a target and bumps its counter
+   * @returns {Promise<boolean>} False when the rule had already acted on it
+   */
+  async recordHit({ ruleId, targetType, targetId, reporterIds }) {
+    return await txManager.run(async (manager) => {
+      const inserted = await manager.query(
+        `INSERT INTO moderation_rule_hits (id, "ruleId", "targetType", "targetId", "reporterIds", "createdAt")
+         VALUES (gen_random_uuid(), $1, $2, $3, $4, now())
+         ON CONFLICT ("ruleId", "targetId") DO NOTHING
+         RETURNING id`,
+        [ruleId, targetType, targetId, JSON.stringify(reporterIds)]
+      );
+      if (inserted.length === 0) {
+        return false;
+      }
+      await manager.query(
+        `UPDATE moderation_rules SET "triggerCount" = "triggerCount" + 1, "lastTriggeredAt" = now()
+         WHERE id = $1`,
+        [ruleId]
+      );
+      return true;
+    });
+  }
+
+  async hasHit(ruleId, targetId) {
+    return await this.getHitRepository().exists({ where: { ruleId, targetId } });
+  }
+
+  async findHits(ruleId, limit = 50) {
+    return await this.getHitRepository().find({
+      where: { ruleId },
+      order: { createdAt: "DESC" },
+      take: limit,
+    });
+  }
+}
+
+export default new ModerationRuleRepository();
@@ -0,0 +1,50 @@
+import txManager from "./transactionManager.js";
+import { In } from "typeorm";
+import NotificationPreference from "../models/notificationPreference.model.js";
+
+class NotificationPreferenceRepository {
+  getRepository() {
+    return txManager.getRepository(NotificationPreference);
+  }
+
+  /**
+   * Gets the preferences of a user (null if the user never changed them)
+   * @param {string} userId
+   * @returns {Promise<NotificationPreference|null>}
+   */
+  async findByUserId(userId) {
+    return await this.getRepository().findOne({ where: { userId } });
+  }
+
+  /**
+   * Gets the stored preferences of several users
+   * @param {string[]} userIds
+   * @returns {Promise<NotificationPreference[]>} Only users who changed their preferences
+   */
+  async findByUserIds(userIds) {
+    if (userIds.length === 0) {
+      return [];
+    }
+    return await this.getRepository().find({ where: { userId: In(userIds) } });
+  }
+
+  /**
+   * Creates or updates the preferences of a user
+   * @param {string} userId
+   * @param {Object} data - Fields to update
+   * @returns {Promise<NotificationPreference>}
+   */
+  async upsert(userId, data) {
+    const existing = await this.findByUserId(userId);
+
+    if (existing) {
+      Object.assign(existing, data);
+      return await this.getRepository().save(existing);
+    }
+
+    const preference = this.getRepository().create({ userId, ...data });
+    return await this.getRepository().save(preference);
+  }
+}
+
+export default new NotificationPreferenceRepository();
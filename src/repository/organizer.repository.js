@@ -0,0 +1,154 @@
+import txManager from "./transactionManager.js";
+
+/**
+ * Aggregate queries for the organizer dashboard and public organizer pages.
+ * Each dashboard method answers one section for all the organizer's groups at once.
+ */
+class OrganizerRepository {
+  /**
+   * Groups administered by the user that have not ended yet, with member count
+   * and dates derived from the assigned itinerary
+   * @param {string} adminId
+   * @returns {Promise<Array>} - [{ id, name, capacity, memberCount, startDate, endDate }]
+   */
+  async findUpcomingGroups(adminId) {
+    return await txManager.query(
+      `SELECT g.id, g.name, g.capacity,
+              (SELECT COUNT(*)::int FROM group_members gm WHERE gm."groupId" = g.id) AS "memberCount",
+              dates."startDate", dates."endDate"
+       FROM groups g
+       LEFT JOIN LATERAL (
+         SELECT MIN(ii.date) AS "startDate", MAX(ii.date) AS "endDate"
+         FROM itinerary_items ii
+         WHERE ii."itineraryId" = g."assignedItineraryId"
+       ) dates ON true
+       WHERE g."adminId" = $1
+         AND g."deletedAt" IS NULL
+         AND (dates."endDate" IS NULL OR dates."endDate" >= CURRENT_DATE)
+       ORDER BY dates."startDate" ASC NULLS LAST, g.name ASC`,
+      [adminId]
+    );
+  }
+
+  /**
+   * Pending join requests of the given groups, oldest first
+   * @param {string[]} groupIds
+   * @param {number} limit
+   */
+  async findPendingJoinRequests(groupIds, limit = 20) {
+    return await txManager.query(
+      `SELECT r.id, r."groupId", r.message, r."createdAt",
+              u.id AS "userId", u.name AS "userName", u."profilePicture" AS "userProfilePicture"
+       FROM group_join_requests r
+       JOIN users u ON u.id = r."userId"
+       WHERE r."groupId" = ANY($1) AND r.status = 'pending'
+       ORDER BY r."createdAt" ASC
+       LIMIT $2`,
+      [groupIds, limit]
+    );
+  }
+
+  /**
+   * Number of pending join requests per group
+   * @param {string[]} groupIds
+   * @returns {Promise<Object>} Map groupId -> count
+   */
+  async countPendingJoinRequests(groupIds) {
+    const rows = await txManager.query(
+      `SELECT "groupId", COUNT(*)::int AS count
+       FROM group_join_requests
+       WHERE "groupId" = ANY($1) AND status = 'pending'
+       GROUP BY "groupId"`,
+      [groupIds]
+    );
+    return Object.fromEntries(rows.map((row) => [row.groupId, row.count]));
+  }
+
+  /**
+   * Total of expenses nobody has paid yet, per group and currency (amounts are
+   * stored in cents; different currencies are never added up)
+   * @param {string[]} groupIds
+   * @returns {Promise<Object>} Map groupId -> { count, totals: [{ currency, amount }] }
+   */
+  async sumUnpaidExpenses(groupIds) {
+    const rows = await txManager.query(
+      `SELECT "groupId", currency, COALESCE(SUM(amount), 0)::bigint AS cents, COUNT(*)::int AS count
+       FROM expenses
+       WHERE "groupId" = ANY($1) AND "paidById" IS NULL
+       GROUP BY "groupId", currency
+       ORDER BY currency`,
+      [groupIds]
+    );
+    const unpaid = {};
+    for (const row of rows) {
+      const group = (unpaid[row.groupId] = unpaid[row.groupId] || { count: 0, totals: [] });
+      group.count += row.count;
+      group.totals.push({ currency: row.currency, amount: Number(row.cents) / 100 });
+    }
+    return unpaid;
+  }
+
+  /**
+   * Latest reviews of places included in the itineraries of the given groups
+   * @param {string[]} groupIds
+   * @param {number} limit
+   */
+  async findRecentReviews(groupIds, limit = 5) {
+    return await txManager.query(
+      `SELECT DISTINCT ON (r."createdAt", r.id)
+              r.id, r.rating, r.content, r."createdAt",
+              p.id AS "placeId", p.name AS "placeName",
+              u.id AS "userId", u.name AS "userName"
+       FROM reviews r
+       JOIN places p ON p.id = r."placeId"
+       JOIN users u ON u.id = r."userId"
+       JOIN itinerary_items ii ON ii."placeId" = r."placeId"
+       JOIN groups g ON g."assignedItineraryId" = ii."itineraryId"
+       WHERE g.id = ANY($1)
+       ORDER BY r."createdAt" DESC, r.id
+       LIMIT $2`,
+      [groupIds, limit]
+    );
+  }
+
+  /**
+   * Public trips of an organizer that have not started yet, soonest first
+   * @param {string} adminId
+   * @param {number} limit
+   * @returns {Promise<Array>} - [{ id, name, destinationName, startDate, endDate, capacity, memberCount }]
+   */
+  async findPublicUpcomingTrips(adminId, limit) {
+    return await txManager.query(
+      `SELECT g.id, g.name, g."destinationName",
+              g."tripStartDate"::text AS "startDate", g."tripEndDate"::text AS "endDate",
+              g.capacity,
+              (SELECT COUNT(*)::int FROM group_members gm WHERE gm."groupId" = g.id) AS "memberCount"
+       FROM groups g
+       WHERE g."adminId" = $1
+         AND g."isPublic" = true
+         AND g."deletedAt" IS NULL
+         AND g."tripStartDate" >= CURRENT_DATE
+       ORDER BY g."tripStartDate" ASC, g.id ASC
+       LIMIT $2`,
+      [adminId, limit]
+    );
+  }
+
+  /**
+   * How many public trips the organizer has published and how many already ended
+   * @param {string} adminId
+   * @returns {Promise<Object>} - { publicTrips, completedTrips }
+   */
+  async countPublicTrips(adminId) {
+    const [row] = await txManager.query(
+      `SELECT COUNT(*)::int AS "publicTrips",
+              COUNT(*) FILTER (WHERE g."tripEndDate" < CURRENT_DATE)::int AS "completedTrips"
+       FROM groups g
+       WHERE g."adminId" = $1 AND g."isPublic" = true AND g."deletedAt" IS NULL`,
+      [adminId]
+    );
+    return row;
+  }
+}
+
+export default new OrganizerRepository();
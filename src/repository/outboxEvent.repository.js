@@ -0,0 +1,117 @@
+import { LessThan } from "typeorm";
+import txManager from "./transactionManager.js";
+import OutboxEvent from "../models/outboxEvent.model.js";
+
+class OutboxEventRepository {
+  getRepository() {
+    return txManager.getRepository(OutboxEvent);
+  }
+
+  /**
+   * Stores an event, in the caller's transaction when there is one
+   */
+  async create(data) {
+    const event = this.getRepository().create(data);
+    return await this.getRepository().save(event);
+  }
+
+  /**
+   * Claims the oldest pending events, up to the first one waiting for a retry
+   * (later events wait behind it to keep the order), for claimMs. The claim
+   * commits right away, so the relay publishes without holding a transaction
+   * or lock; while a claim is live no other relay claims anything, which keeps
+   * events going out in order.
+   * @param {number} limit
+   * @param {number} claimMs
+   * @returns {Promise<Array>} Claimed events in publishing order
+   */
+  async claimNextBatch(limit, claimMs) {
+    return await txManager.run(async () => {
+      const [lock] = await txManager.query(`SELECT pg_try_advisory_xact_lock(hashtext('outbox_relay')) AS locked`);
+      if (!lock.locked) {
+        return [];
+      }
+      const [claim] = await txManager.query(
+        `SELECT EXISTS (
+           SELECT 1 FROM outbox_events WHERE status = 'pending' AND "claimedUntil" > NOW()
+         ) AS live`
+      );
+      if (claim.live) {
+        return [];
+      }
+      // pg returns [rows, affected] for UPDATE ... RETURNING
+      const [rows] = await txManager.query(
+        `UPDATE outbox_events SET "claimedUntil" = NOW() + $2 * INTERVAL '1 millisecond'
+         WHERE id IN (
+           SELECT id FROM outbox_events
+           WHERE status = 'pending' AND "publishedAt" IS NULL
+             AND id < COALESCE(
+               (SELECT MIN(id) FROM outbox_events
+                WHERE status = 'pending' AND "publishedAt" IS NULL AND "availableAt" > NOW()),
+               9223372036854775807)
+           ORDER BY id ASC
+           LIMIT $1)
+         RETURNING *`,
+        [limit, claimMs]
+      );
+      // RETURNING keeps no order; ids are bigint strings
+      return rows.sort((a, b) => (BigInt(a.id) < BigInt(b.id) ? -1 : 1));
+    });
+  }
+
+  async markPublished(ids) {
+    await txManager.query(
+      `UPDATE outbox_events SET status = 'published', "publishedAt" = NOW(), "claimedUntil" = NULL
+       WHERE id = ANY($1)`,
+      [ids]
+    );
+  }
+
+  /**
+   * Records a failed publish; past maxAttempts the event is marked failed
+   * @returns {Promise<string>} New status
+   */
+  async markFailed(id, error, availableAt, maxAttempts) {
+    const [[row]] = await txManager.query(
+      `UPDATE outbox_events
+       SET attempts = attempts + 1, "lastError" = $2, "availableAt" = $3, "claimedUntil" = NULL,
+           status = CASE WHEN attempts + 1 >= $4 THEN 'failed' ELSE status END
+       WHERE id = $1
+       RETURNING status`,
+      [id, error, availableAt, maxAttempts]
+    );
+    return row?.status;
+  }
+
+  /**
+   * Gives back claimed events the relay did not get to
+   */
+  async releaseClaims(ids) {
+    await txManager.query(`UPDATE outbox_events SET "claimedUntil" = NULL WHERE id = ANY($1)`, [ids]);
+  }
+
+  /**
+   * Pending and failed events and the oldest pending one, for the status page
+   * @returns {Promise<Object>} - { pending, failed, oldestAt }
+   */
+  async getBacklog() {
+    const [row] = await txManager.query(
+      `SELECT COUNT(*) FILTER (WHERE status = 'pending')::int AS pending,
+              COUNT(*) FILTER (WHERE status = 'failed')::int AS failed,
+              MIN("occurredAt") FILTER (WHERE status = 'pending') AS "oldestAt"
+       FROM outbox_events WHERE "publishedAt" IS NULL`
+    );
+    return row;
+  }
+
+  /**
+   * Deletes events published before a date
+   * @returns {Promise<number>} Events deleted
+   */
+  async purgePublished(before) {
+    const result = await this.getRepository().delete({ publishedAt: LessThan(before) });
+    return result.affected ?? 0;
+  }
+}
+
+export default new OutboxEventRepository();
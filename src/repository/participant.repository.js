@@ -0,0 +1,70 @@
+import txManager from "./transactionManager.js";
+
+/**
+ * Aggregate queries for the participant dashboard
+ */
+class ParticipantRepository {
+  /**
+   * Groups the user belongs to that have not ended yet, with dates derived from
+   * the assigned itinerary
+   * @param {string} userId
+   * @returns {Promise<Array>} - [{ id, name, adminId, memberCount, startDate, endDate }]
+   */
+  async findUpcomingGroups(userId) {
+    return await txManager.query(
+      `SELECT g.id, g.name, g."adminId",
+              (SELECT COUNT(*)::int FROM group_members m WHERE m."groupId" = g.id) AS "memberCount",
+              dates."startDate", dates."endDate"
+       FROM groups g
+       JOIN group_members gm ON gm."groupId" = g.id AND gm."userId" = $1
+       LEFT JOIN LATERAL (
+         SELECT MIN(ii.date) AS "startDate", MAX(ii.date) AS "endDate"
+         FROM itinerary_items ii
+         WHERE ii."itineraryId" = g."assignedItineraryId"
+       ) dates ON true
+       WHERE g."deletedAt" IS NULL
+         AND (dates."endDate" IS NULL OR dates."endDate" >= CURRENT_DATE)
+       ORDER BY dates."startDate" ASC NULLS LAST, g.name ASC`,
+      [userId]
+    );
+  }
+
+  /**
+   * Expenses nobody has paid yet in the user's groups, with the user's even
+   * share. Amounts in different currencies are never added up: each group
+   * lists one total per currency.
+   * @param {string} userId
+   * @returns {Promise<Array>} - [{ groupId, groupName, count, totals: [{ currency, amount, share }] }]
+   */
+  async findUnpaidExpenses(userId) {
+    const rows = await txManager.query(
+      `SELECT e."groupId", g.name AS "groupName", e.currency, COUNT(*)::int AS count,
+              SUM(e.amount)::bigint AS cents,
+              (SELECT COUNT(*)::int FROM group_members m WHERE m."groupId" = e."groupId") AS "memberCount"
+       FROM expenses e
+       JOIN groups g ON g.id = e."groupId"
+       JOIN group_members gm ON gm."groupId" = e."groupId" AND gm."userId" = $1
+       WHERE e."paidById" IS NULL AND g."deletedAt" IS NULL
+       GROUP BY e."groupId", g.name, e.currency
+       ORDER BY e."groupId", e.currency`,
+      [userId]
+    );
+    const groups = new Map();
+    for (const row of rows) {
+      if (!groups.has(row.groupId)) {
+        groups.set(row.groupId, { groupId: row.groupId, groupName: row.groupName, count: 0, totals: [] });
+      }
+      const group = groups.get(row.groupId);
+      const amount = Number(row.cents) / 100;
+      group.count += row.count;
+      group.totals.push({
+        currency: row.currency,
+        amount,
+        share: row.memberCount ? Math.round((amount / row.memberCount) * 100) / 100 : amount,
+      });
+    }
+    return [...groups.values()];
+  }
+}
+
+export default new ParticipantRepository();
@@ -0,0 +1,88 @@
+import txManager from "./transactionManager.js";
+import PastTrip from "../models/pastTrip.model.js";
+
+class PastTripRepository {
+  getRepository() {
+    return txManager.getRepository(PastTrip);
+  }
+
+  async createMany(trips) {
+    return await this.getRepository().save(this.getRepository().create(trips));
+  }
+
+  async findById(id) {
+    return await this.getRepository().findOne({ where: { id } });
+  }
+
+  /**
+   * Travel history of a user, most recent first
+   * @param {string} userId
+   */
+  async findByUser(userId) {
+    return await this.getRepository().find({
+      where: { userId },
+      order: { startDate: "DESC" },
+    });
+  }
+
+  /**
+   * Imported trips of a user overlapping a date window
+   * @param {string} userId
+   * @param {string} from - YYYY-MM-DD
+   * @param {string} to - YYYY-MM-DD
+   */
+  async findByUserInRange(userId, from, to) {
+    return await this.getRepository()
+      .createQueryBuilder("trip")
+      .where("trip.userId = :userId", { userId })
+      .andWhere("trip.startDate <= :to AND trip.endDate >= :from", { from, to })
+      .getMany();
+  }
+
+  /**
+   * JoinTravel trips the user organized or joined overlapping a date window,
+   * with the same shape as an imported trip
+   * @param {string} userId
+   * @param {string} from - YYYY-MM-DD
+   * @param {string} to - YYYY-MM-DD
+   */
+  async findGroupTripsInRange(userId, from, to) {
+    return await txManager.query(
+      `SELECT g.id, g."destinationName" AS city,
+              g."destinationLat" AS lat, g."destinationLng" AS lng,
+              g."tripStartDate"::text AS "startDate",
+              COALESCE(g."tripEndDate", g."tripStartDate")::text AS "endDate"
+       FROM groups g
+       WHERE g."deletedAt" IS NULL
+         AND g."tripStartDate" IS NOT NULL
+         AND g."tripStartDate" <= $3
+         AND COALESCE(g."tripEndDate", g."tripStartDate") >= $2
+         AND (g."adminId" = $1
+              OR EXISTS (SELECT 1 FROM group_members gm
+                         WHERE gm."groupId" = g.id AND gm."userId" = $1))`,
+      [userId, from, to]
+    );
+  }
+
+  /**
+   * Countries the user has imported trips to, most visited first
+   * @param {string} userId
+   * @returns {Promise<Array>} - [{ countryCode, trips }]
+   */
+  async countCountries(userId) {
+    return await txManager.query(
+      `SELECT "countryCode", COUNT(*)::int AS trips
+       FROM past_trips
+       WHERE "userId" = $1 AND "countryCode" IS NOT NULL
+       GROUP BY "countryCode"
+       ORDER BY trips DESC, "countryCode" ASC`,
+      [userId]
+    );
+  }
+
+  async delete(id) {
+    await this.getRepository().delete(id);
+  }
+}
+
+export default new PastTripRepository();
@@ -0,0 +1,86 @@
+import txManager from "./transactionManager.js";
+import Payment from "../models/payment.model.js";
+
+class PaymentRepository {
+  getRepository() {
+    return txManager.getRepository(Payment);
+  }
+
+  async create(data) {
+    const payment = this.getRepository().create(data);
+    return await this.getRepository().save(payment);
+  }
+
+  async findById(id) {
+    return await this.getRepository().findOne({ where: { id } });
+  }
+
+  async findByPaymentIntent(stripePaymentIntentId) {
+    return await this.getRepository().findOne({ where: { stripePaymentIntentId } });
+  }
+
+  async findByJoinRequest(joinRequestId) {
+    return await this.getRepository().find({
+      where: { joinRequestId },
+      order: { createdAt: "DESC" },
+    });
+  }
+
+  async findByGroupAndUser(groupId, userId) {
+    return await this.getRepository().find({
+      where: { groupId, userId },
+      order: { createdAt: "DESC" },
+    });
+  }
+
+  async findByGroup(groupId) {
+    return await this.getRepository().find({
+      where: { groupId },
+      relations: ["user"],
+      order: { createdAt: "DESC" },
+    });
+  }
+
+  async findByUser(userId) {
+    return await this.getRepository().find({
+      where: { userId },
+      relations: ["group"],
+      order: { createdAt: "DESC" },
+    });
+  }
+
+  async update(id, data) {
+    await this.getRepository().update(id, data);
+    return await this.findById(id);
+  }
+
+  /**
+   * Updates the status only if it is still the expected one, so concurrent
+   * webhooks cannot apply a transition twice
+   * @returns {Promise<Object|null>} - Updated payment, or null if it changed meanwhile
+   */
+  async transition(id, fromStatus, data) {
+    const result = await this.getRepository().update({ id, status: fromStatus }, data);
+    if (!result.affected) {
+      return null;
+    }
+    return await this.findById(id);
+  }
+
+  /**
+   * Payments waiting for Stripe to confirm them by webhook
+   * @param {Date} before - Only count payments unchanged since then
+   * @returns {Promise<Array>} - [{ status, count, oldestUpdatedAt }]
+   */
+  async countAwaitingWebhook(before) {
+    return await txManager.query(
+      `SELECT status, COUNT(*)::int AS count, MIN("updatedAt") AS "oldestUpdatedAt"
+       FROM payments
+       WHERE status IN ('processing', 'refund_pending') AND "updatedAt" < $1
+       GROUP BY status`,
+      [before]
+    );
+  }
+}
+
+export default new PaymentRepository();
@@ -1,18 +1,10 @@
-import { AppDataSource } from "../load/typeorm.loader.js";
+import txManager from "./transactionManager.js";
 import Place from "../models/place.model.js";
 import Fuse from "fuse.js";
 
 class PlaceRepository {
-  constructor() {
-    this.repository = null;
-  }
-
-  // Inicializa el repositorio cuando TypeORM esté listo
   getRepository() {
-    if (!this.repository) {
-      this.repository = AppDataSource.getRepository(Place);
-    }
-    return this.repository;
+    return txManager.getRepository(Place);
   }
 
   /**
@@ -165,7 +157,7 @@ class PlaceRepository {
     const placesWithCalculatedRatings = await Promise.all(
       allPlaces.map(async (place) => {
         // Get reviews for this place
-        const reviews = await AppDataSource.getRepository("Review").find({
+        const reviews = await txManager.getRepository("Review").find({
           where: { placeId: place.id },
           select: ["rating"]
         });
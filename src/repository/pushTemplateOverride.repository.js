@@ -0,0 +1,42 @@
+import txManager from "./transactionManager.js";
+import { In } from "typeorm";
+import PushTemplateOverride from "../models/pushTemplateOverride.model.js";
+
+class PushTemplateOverrideRepository {
+  getRepository() {
+    return txManager.getRepository(PushTemplateOverride);
+  }
+
+  async findAll() {
+    return await this.getRepository().find({ order: { type: "ASC", language: "ASC" } });
+  }
+
+  /**
+   * Overrides of some notification types, in any language
+   * @param {string[]} types
+   */
+  async findByTypes(types) {
+    if (types.length === 0) {
+      return [];
+    }
+    return await this.getRepository().find({ where: { type: In(types) } });
+  }
+
+  async upsert({ type, language, title, body, updatedById }) {
+    await this.getRepository().upsert({ type, language, title, body, updatedById }, [
+      "type",
+      "language",
+    ]);
+    return await this.getRepository().findOne({ where: { type, language } });
+  }
+
+  /**
+   * @returns {Promise<boolean>} Whether there was an override
+   */
+  async delete(type, language) {
+    const result = await this.getRepository().delete({ type, language });
+    return result.affected > 0;
+  }
+}
+
+export default new PushTemplateOverrideRepository();
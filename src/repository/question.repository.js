@@ -1,4 +1,4 @@
-import { AppDataSource } from "../load/typeorm.loader.js";
+import txManager from "./transactionManager.js";
 import Question from "../models/question.model.js";
 
 class QuestionRepository {
@@ -6,7 +6,7 @@ class QuestionRepository {
 
   // Inicializa el repositorio cuando TypeORM esté listo
   getRepository() {
-    return AppDataSource.getRepository(Question);
+    return txManager.getRepository(Question);
   }
 
   /**
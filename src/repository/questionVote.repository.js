@@ -1,4 +1,4 @@
-import { AppDataSource } from "../load/typeorm.loader.js";
+import txManager from "./transactionManager.js";
 import QuestionVote from "../models/questionVote.model.js";
 
 class QuestionVoteRepository {
@@ -6,7 +6,7 @@ class QuestionVoteRepository {
 
   // Inicializa el repositorio cuando TypeORM esté listo
   getRepository() {
-    return AppDataSource.getRepository(QuestionVote);
+    return txManager.getRepository(QuestionVote);
   }
 
   /**
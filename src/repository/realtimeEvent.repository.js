@@ -0,0 +1,87 @@
+import { AppDataSource } from "../load/typeorm.loader.js";
+
+export const REALTIME_CHANNEL = "realtime_events";
+
+/**
+ * Realtime events are written on their own, never in the caller's
+ * transaction: they are published after the change they describe commits.
+ */
+class RealtimeEventRepository {
+  /**
+   * Stores an event and notifies every instance listening on REALTIME_CHANNEL.
+   * Inserts are serialized so IDs commit in order and a poller that read
+   * past an ID never misses a lower one committed later.
+   * @returns {Promise<string>} ID of the event
+   */
+  async create({ room, event, data, origin }) {
+    return await AppDataSource.transaction(async (manager) => {
+      await manager.query(`SELECT pg_advisory_xact_lock(hashtext('realtime_events'))`);
+      const [row] = await manager.query(
+        `WITH inserted AS (
+           INSERT INTO realtime_events (room, event, data, origin) VALUES ($1, $2, $3, $4)
+           RETURNING id, room, origin
+         )
+         SELECT id, pg_notify($5, json_build_object('id', id, 'room', room, 'origin', origin)::text)
+         FROM inserted`,
+        [room, event, JSON.stringify(data ?? null), origin, REALTIME_CHANNEL]
+      );
+      return row.id;
+    });
+  }
+
+  async findById(id) {
+    const [row] = await AppDataSource.query(`SELECT room, event, data FROM realtime_events WHERE id = $1`, [id]);
+    return row || null;
+  }
+
+  /**
+   * @returns {Promise<Object>} - { oldest, latest } IDs still stored, 0 when there are none
+   */
+  async getBounds() {
+    const [row] = await AppDataSource.query(
+      `SELECT COALESCE(MIN(id), 0) AS oldest, COALESCE(MAX(id), 0) AS latest FROM realtime_events`
+    );
+    return { oldest: Number(row.oldest), latest: Number(row.latest) };
+  }
+
+  /**
+   * Whether a room got more than perRoom events after an ID
+   */
+  async anyRoomOver(rooms, afterId, perRoom) {
+    const rows = await AppDataSource.query(
+      `SELECT 1 FROM realtime_events
+       WHERE room = ANY($1) AND id > $2
+       GROUP BY room HAVING COUNT(*) > $3
+       LIMIT 1`,
+      [rooms, afterId, perRoom]
+    );
+    return rows.length > 0;
+  }
+
+  async findAfter(rooms, afterId, limit) {
+    return await AppDataSource.query(
+      `SELECT id, event, data, "createdAt" FROM realtime_events
+       WHERE room = ANY($1) AND id > $2
+       ORDER BY id ASC
+       LIMIT $3`,
+      [rooms, afterId, limit]
+    );
+  }
+
+  /**
+   * Deletes events older than a number of seconds. The latest one is kept so
+   * IDs (and cursors) never go back after a quiet period.
+   * @returns {Promise<number>} Deleted events
+   */
+  async deleteOlderThan(seconds) {
+    const [, deleted] = await AppDataSource.query(
+      `DELETE FROM realtime_events
+       WHERE "createdAt" < NOW() - $1 * INTERVAL '1 second'
+         AND id < (SELECT MAX(id) FROM realtime_events)`,
+      [seconds]
+    );
+    return deleted;
+  }
+}
+
+export default new RealtimeEventRepository();
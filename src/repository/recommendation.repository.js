@@ -0,0 +1,67 @@
+import txManager from "./transactionManager.js";
+import RecommendationEmail from "../models/recommendationEmail.model.js";
+import RecommendationClick from "../models/recommendationClick.model.js";
+
+class RecommendationRepository {
+  getEmailRepository() {
+    return txManager.getRepository(RecommendationEmail);
+  }
+
+  getClickRepository() {
+    return txManager.getRepository(RecommendationClick);
+  }
+
+  /**
+   * Destinations of the trips the user clicked in previous emails
+   * @param {string} userId
+   * @returns {Promise<Object>} Map destinationName -> clicks
+   */
+  async findClickedDestinations(userId) {
+    const rows = await txManager.query(
+      `SELECT g."destinationName", COUNT(*)::int AS count
+       FROM recommendation_clicks c
+       JOIN groups g ON g.id = c."groupId"
+       WHERE c."userId" = $1 AND g."destinationName" IS NOT NULL
+       GROUP BY g."destinationName"`,
+      [userId]
+    );
+    return Object.fromEntries(rows.map((row) => [row.destinationName, row.count]));
+  }
+
+  /**
+   * Date of the last recommendation email successfully sent to the user
+   * @param {string} userId
+   * @returns {Promise<Date|null>}
+   */
+  async findLastSentAt(userId) {
+    const last = await this.getEmailRepository().findOne({
+      where: { userId, status: "sent" },
+      order: { sentAt: "DESC" },
+    });
+    return last?.sentAt || null;
+  }
+
+  async createEmail(data) {
+    const email = this.getEmailRepository().create(data);
+    return await this.getEmailRepository().save(email);
+  }
+
+  async updateEmail(id, data) {
+    await this.getEmailRepository().update(id, data);
+  }
+
+  async findEmailById(id) {
+    return await this.getEmailRepository().findOne({ where: { id } });
+  }
+
+  /**
+   * Records a click on a recommended trip
+   * @param {Object} data - { emailId, userId, groupId }
+   */
+  async createClick(data) {
+    const click = this.getClickRepository().create(data);
+    return await this.getClickRepository().save(click);
+  }
+}
+
+export default new RecommendationRepository();
@@ -1,17 +1,9 @@
-import { AppDataSource } from "../load/typeorm.loader.js";
+import txManager from "./transactionManager.js";
 import Review from "../models/review.model.js";
 
 class ReviewRepository {
-  constructor() {
-    this.repository = null;
-  }
-
-  // Inicializa el repositorio cuando TypeORM esté listo
   getRepository() {
-    if (!this.repository) {
-      this.repository = AppDataSource.getRepository(Review);
-    }
-    return this.repository;
+    return txManager.getRepository(Review);
   }
 
   /**
@@ -1,8 +1,9 @@
-import { AppDataSource } from "../load/typeorm.loader.js";
+import txManager from "./transactionManager.js";
 
 class ReviewLikeRepository {
-  constructor() {
-    this.repository = AppDataSource.getRepository("ReviewLike");
+  // Resolved on use so it picks up the open transaction
+  get repository() {
+    return txManager.getRepository("ReviewLike");
   }
 
   /**
@@ -1,17 +1,9 @@
-import { AppDataSource } from "../load/typeorm.loader.js";
+import txManager from "./transactionManager.js";
 import ReviewMedia from "../models/reviewMedia.model.js";
 
 class ReviewMediaRepository {
-  constructor() {
-    this.repository = null;
-  }
-
-  // Inicializa el repositorio cuando TypeORM esté listo
   getRepository() {
-    if (!this.repository) {
-      this.repository = AppDataSource.getRepository(ReviewMedia);
-    }
-    return this.repository;
+    return txManager.getRepository(ReviewMedia);
   }
 
   /**
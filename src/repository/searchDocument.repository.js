@@ -0,0 +1,83 @@
+import txManager from "./transactionManager.js";
+import SearchDocument from "../models/searchDocument.model.js";
+
+// Spanish stemming for user content ("viajes" matches "viaje")
+const TEXT_SEARCH_CONFIG = "spanish";
+
+/**
+ * Postgres full-text search engine backed by the search_documents table
+ */
+class SearchDocumentRepository {
+  getRepository() {
+    return txManager.getRepository(SearchDocument);
+  }
+
+  /**
+   * Creates the GIN index on the search vector (EntitySchema cannot declare it)
+   */
+  async ensureIndexes() {
+    await txManager.query(
+      `CREATE INDEX IF NOT EXISTS "IDX_SEARCH_DOCUMENT_VECTOR"
+       ON search_documents USING GIN ("searchVector")`
+    );
+  }
+
+  /**
+   * Inserts or replaces the document of an entity and recomputes its vector
+   * @param {Object} doc - { entityType, entityId, title, subtitle, body, isPublic }
+   */
+  async upsert({ entityType, entityId, title, subtitle = null, body = null, isPublic = true }) {
+    await txManager.query(
+      `INSERT INTO search_documents ("entityType", "entityId", title, subtitle, body, "isPublic", "searchVector", "updatedAt")
+       VALUES ($1, $2, $3, $4, $5, $6,
+         setweight(to_tsvector('${TEXT_SEARCH_CONFIG}', coalesce($3, '')), 'A') ||
+         setweight(to_tsvector('${TEXT_SEARCH_CONFIG}', coalesce($4, '')), 'B') ||
+         setweight(to_tsvector('${TEXT_SEARCH_CONFIG}', coalesce($5, '')), 'C'),
+         NOW())
+       ON CONFLICT ("entityType", "entityId") DO UPDATE SET
+         title = EXCLUDED.title,
+         subtitle = EXCLUDED.subtitle,
+         body = EXCLUDED.body,
+         "isPublic" = EXCLUDED."isPublic",
+         "searchVector" = EXCLUDED."searchVector",
+         "updatedAt" = NOW()`,
+      [entityType, entityId, title, subtitle, body, isPublic]
+    );
+  }
+
+  async remove(entityType, entityId) {
+    await this.getRepository().delete({ entityType, entityId });
+  }
+
+  /**
+   * Ranks public documents against a web-style query ("quoted phrases", -exclusions, or).
+   * Users blocked with the viewer (in either direction) and the trips they
+   * organize are left out.
+   * @param {string} query
+   * @param {Object} options - { types, limit, offset, viewerId }
+   * @returns {Promise<Array>} [{ entityType, entityId, title, subtitle, score }]
+   */
+  async search(query, { types, limit = 20, offset = 0, viewerId = null }) {
+    const rows = await txManager.query(
+      `SELECT sd."entityType", sd."entityId", sd.title, sd.subtitle,
+              ts_rank_cd(sd."searchVector", q) AS score
+       FROM search_documents sd
+       CROSS JOIN websearch_to_tsquery('${TEXT_SEARCH_CONFIG}', $1) q
+       LEFT JOIN groups g ON sd."entityType" = 'trip' AND g.id = sd."entityId"
+       WHERE sd."isPublic" = true
+         AND sd."entityType" = ANY($2)
+         AND sd."searchVector" @@ q
+         AND ($5::uuid IS NULL OR NOT EXISTS (
+           SELECT 1 FROM user_blocks ub
+           WHERE (ub."blockerId" = $5 AND ub."blockedId" IN (g."adminId", CASE WHEN sd."entityType" = 'user' THEN sd."entityId" END))
+              OR (ub."blockedId" = $5 AND ub."blockerId" IN (g."adminId", CASE WHEN sd."entityType" = 'user' THEN sd."entityId" END))
+         ))
+       ORDER BY score DESC, sd."updatedAt" DESC
+       LIMIT $3 OFFSET $4`,
+      [query, types, limit, offset, viewerId]
+    );
+    return rows.map((row) => ({ ...row, score: parseFloat(row.score) }));
+  }
+}
+
+export default new SearchDocumentRepository();
@@ -0,0 +1,71 @@
+import { IsNull, Not } from "typeorm";
+
+/**
+ * Soft-delete convention shared by repositories.
+ *
+ * Soft-deletable entities have a nullable `deletedAt` timestamp. Deleting sets it
+ * and restoring clears it; the row and every foreign key pointing at it stay.
+ * Trips and messages declare it as a TypeORM delete date column, so find(),
+ * findOne() and query builders (relation joins included) skip deleted rows
+ * unless `withDeleted: true` is passed. Raw SQL has to add `"deletedAt" IS NULL`
+ * itself.
+ *
+ * Users keep `deletedAt` as a plain column. Their rows must still resolve as the
+ * author of messages, reviews and expenses through relation joins, so the user
+ * repository filters closed accounts in its own finders and searches instead;
+ * the `...WithDeleted` variants are for callers that handle closed accounts
+ * (registration, erasure, administration).
+ */
+
+/**
+ * Marks a row as deleted
+ * @param {Repository} repository - TypeORM repository
+ * @param {string} id
+ * @returns {Promise<boolean>} false when the row does not exist or is already deleted
+ */
+export const softDeleteById = async (repository, id) => {
+  const result = await repository.update({ id, deletedAt: IsNull() }, { deletedAt: new Date() });
+  return result.affected > 0;
+};
+
+/**
+ * Brings back a deleted row
+ * @param {Repository} repository - TypeORM repository
+ * @param {string} id
+ * @param {Object} where - Extra conditions for the row to be restorable
+ * @returns {Promise<boolean>} false when the row does not exist or is not deleted
+ */
+export const restoreById = async (repository, id, where = {}) => {
+  const result = await repository.update({ ...where, id, deletedAt: Not(IsNull()) }, { deletedAt: null });
+  return result.affected > 0;
+};
+
+/**
+ * Deleted rows, most recently deleted first
+ * @param {Repository} repository - TypeORM repository
+ * @param {Object} options - { where, relations, limit, offset }
+ * @returns {Promise<Array>}
+ */
+export const findTrashed = async (
+  repository,
+  { where = {}, relations = [], limit = 50, offset = 0 } = {}
+) => {
+  return await repository.find({
+    withDeleted: true,
+    where: { ...where, deletedAt: Not(IsNull()) },
+    relations,
+    order: { deletedAt: "DESC" },
+    take: limit,
+    skip: offset,
+  });
+};
+
+/**
+ * Finds a row by ID whether it is deleted or not
+ * @param {Repository} repository - TypeORM repository
+ * @param {string} id
+ * @returns {Promise<Object|null>}
+ */
+export const findByIdWithDeleted = async (repository, id) => {
+  return await repository.findOne({ where: { id }, withDeleted: true });
+};
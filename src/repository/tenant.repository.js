@@ -0,0 +1,40 @@
+import txManager from "./transactionManager.js";
+import Tenant from "../models/tenant.model.js";
+
+class TenantRepository {
+  getRepository() {
+    return txManager.getRepository(Tenant);
+  }
+
+  async create(data) {
+    const repo = this.getRepository();
+    return await repo.save(repo.create(data));
+  }
+
+  async findById(id) {
+    return await this.getRepository().findOne({ where: { id } });
+  }
+
+  /**
+   * Tenant with its DKIM private key, for signing emails
+   * @param {string} id
+   */
+  async findWithSigningKey(id) {
+    return await this.getRepository()
+      .createQueryBuilder("tenant")
+      .addSelect("tenant.dkimPrivateKey")
+      .where("tenant.id = :id", { id })
+      .getOne();
+  }
+
+  async findAll() {
+    return await this.getRepository().find({ order: { name: "ASC" } });
+  }
+
+  async update(id, data) {
+    await this.getRepository().update(id, data);
+    return await this.findById(id);
+  }
+}
+
+export default new TenantRepository();
@@ -0,0 +1,40 @@
+import txManager from "./transactionManager.js";
+import TenantDomain from "../models/tenantDomain.model.js";
+
+class TenantDomainRepository {
+  getRepository() {
+    return txManager.getRepository(TenantDomain);
+  }
+
+  async create(data) {
+    const repo = this.getRepository();
+    return await repo.save(repo.create(data));
+  }
+
+  async findById(id) {
+    return await this.getRepository().findOne({ where: { id } });
+  }
+
+  async findByHostname(hostname) {
+    return await this.getRepository().findOne({ where: { hostname } });
+  }
+
+  async findByTenant(tenantId) {
+    return await this.getRepository().find({
+      where: { tenantId },
+      order: { createdAt: "ASC" },
+    });
+  }
+
+  async update(id, data) {
+    await this.getRepository().update(id, data);
+    return await this.findById(id);
+  }
+
+  async delete(id) {
+    const result = await this.getRepository().delete(id);
+    return result.affected > 0;
+  }
+}
+
+export default new TenantDomainRepository();
@@ -0,0 +1,105 @@
+import { AsyncLocalStorage } from "node:async_hooks";
+import { AppDataSource } from "../load/typeorm.loader.js";
+import logger from "../config/logger.js";
+import { getRemainingMs, throwIfExpired } from "../utils/requestContext.js";
+
+/**
+ * Unit of work shared by every repository. Inside run(), repositories pick up
+ * the open transaction on their own, so a service can make several of their
+ * calls atomic without passing a manager around:
+ *
+ *   await txManager.run(async () => {
+ *     await groupRepository.addMembers(groupId, [userId]);
+ *     await groupJoinRequestRepository.update(requestId, { status: "approved" });
+ *     await createAndEmitNotification({ ... });
+ *   });
+ *
+ * A run() inside another joins the outer transaction. Work that must not
+ * happen unless the transaction commits (socket events, pushes, calls to
+ * payment providers) goes in afterCommit().
+ *
+ * Within an HTTP request, repository calls made after its deadline fail
+ * without touching the database, and its transactions get a statement
+ * timeout of the time left.
+ */
+class TransactionManager {
+  constructor() {
+    this.storage = new AsyncLocalStorage();
+  }
+
+  /**
+   * Runs work in a transaction, or in the one already open
+   * @param {Function} work - async (manager) => result
+   * @returns {Promise<*>} What work returned
+   */
+  async run(work) {
+    const current = this.storage.getStore();
+    if (current) {
+      return await work(current.manager);
+    }
+
+    throwIfExpired();
+    const callbacks = [];
+    const remainingMs = getRemainingMs();
+    const result = await AppDataSource.transaction(async (manager) => {
+      if (remainingMs !== null) {
+        await manager.query(`SELECT set_config('statement_timeout', $1, true)`, [
+          String(Math.max(1, remainingMs)),
+        ]);
+      }
+      return await this.storage.run({ manager, callbacks }, () => work(manager));
+    });
+    for (const callback of callbacks) {
+      try {
+        await callback();
+      } catch (error) {
+        logger.error(`[Transaction] After-commit callback failed: ${error.message}`);
+      }
+    }
+    return result;
+  }
+
+  /**
+   * Defers a callback until the current transaction commits; it is dropped on
+   * rollback. Outside a transaction it runs right away.
+   * @param {Function} callback
+   */
+  async afterCommit(callback) {
+    const current = this.storage.getStore();
+    if (current) {
+      current.callbacks.push(callback);
+      return;
+    }
+    await callback();
+  }
+
+  /**
+   * Whether the caller runs inside run()
+   */
+  get active() {
+    return Boolean(this.storage.getStore());
+  }
+
+  /**
+   * Entity manager of the open transaction, or the default one
+   */
+  get manager() {
+    return this.storage.getStore()?.manager || AppDataSource.manager;
+  }
+
+  getRepository(entity) {
+    throwIfExpired();
+    const current = this.storage.getStore();
+    return current ? current.manager.getRepository(entity) : AppDataSource.getRepository(entity);
+  }
+
+  async query(sql, parameters) {
+    throwIfExpired();
+    const current = this.storage.getStore();
+    return current
+      ? await current.manager.query(sql, parameters)
+      : await AppDataSource.query(sql, parameters);
+  }
+}
+
+export default new TransactionManager();
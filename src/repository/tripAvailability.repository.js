@@ -0,0 +1,121 @@
+import txManager from "./transactionManager.js";
+
+// Columns whose change moves changedAt (what the feed exposes)
+const TRACKED_COLUMNS = [
+  "status",
+  "name",
+  "destinationName",
+  "destinationLat",
+  "destinationLng",
+  "startDate",
+  "endDate",
+  "capacity",
+  "spotsLeft",
+  "priceAmount",
+  "priceCurrency",
+];
+
+const quoted = (columns, prefix = "") => columns.map((column) => `${prefix}"${column}"`).join(", ");
+
+// Dates as YYYY-MM-DD, not shifted by the driver's time zone
+const SELECTED_COLUMNS = TRACKED_COLUMNS.map((column) =>
+  ["startDate", "endDate"].includes(column) ? `"${column}"::text AS "${column}"` : `"${column}"`
+).join(", ");
+
+class TripAvailabilityRepository {
+  /**
+   * Brings the snapshot up to date with the trips: public trips that have not
+   * started yet are "open" or "full"; any trip already in the snapshot that
+   * was unpublished, deleted, started or erased becomes "closed". Rows whose
+   * tracked fields did not change keep their changedAt.
+   * @returns {Promise<void>}
+   */
+  async refresh() {
+    await txManager.run(async (manager) => {
+      await manager.query(
+        `WITH current AS (
+           SELECT g.id AS "groupId",
+                  CASE
+                    WHEN NOT (g."deletedAt" IS NULL AND g."isPublic" AND g."tripStartDate" > CURRENT_DATE) THEN 'closed'
+                    WHEN g.capacity IS NOT NULL AND m.members >= g.capacity THEN 'full'
+                    ELSE 'open'
+                  END AS status,
+                  g.name, g."destinationName", g."destinationLat", g."destinationLng",
+                  g."tripStartDate" AS "startDate", g."tripEndDate" AS "endDate",
+                  g.capacity,
+                  CASE WHEN g.capacity IS NULL THEN NULL ELSE GREATEST(g.capacity - m.members, 0) END AS "spotsLeft",
+                  g."depositAmount" AS "priceAmount", g."depositCurrency" AS "priceCurrency"
+           FROM groups g
+           CROSS JOIN LATERAL (
+             SELECT COUNT(*)::int AS members FROM group_members gm WHERE gm."groupId" = g.id
+           ) m
+           WHERE (g."deletedAt" IS NULL AND g."isPublic" AND g."tripStartDate" > CURRENT_DATE)
+              OR EXISTS (SELECT 1 FROM trip_availability ta WHERE ta."groupId" = g.id)
+         )
+         INSERT INTO trip_availability ("groupId", ${quoted(TRACKED_COLUMNS)}, "changedAt")
+         SELECT "groupId", ${quoted(TRACKED_COLUMNS)}, date_trunc('milliseconds', now())
+         FROM current
+         ON CONFLICT ("groupId") DO UPDATE SET
+           ${TRACKED_COLUMNS.map((column) => `"${column}" = EXCLUDED."${column}"`).join(", ")},
+           "changedAt" = EXCLUDED."changedAt"
+         WHERE (${quoted(TRACKED_COLUMNS, "trip_availability.")})
+               IS DISTINCT FROM (${quoted(TRACKED_COLUMNS, "EXCLUDED.")})`
+      );
+      // Trips erased for good
+      await manager.query(
+        `UPDATE trip_availability ta
+         SET status = 'closed', "changedAt" = date_trunc('milliseconds', now())
+         WHERE ta.status <> 'closed'
+           AND NOT EXISTS (SELECT 1 FROM groups g WHERE g.id = ta."groupId")`
+      );
+    });
+  }
+
+  /**
+   * Size and last change of a feed query, to answer conditional requests
+   * without reading the page
+   * @param {Object} filter - { after: { changedAt, groupId } | null, includeClosed }
+   * @returns {Promise<Object>} - { total, lastChangedAt }
+   */
+  async getState(filter) {
+    const { where, params } = this.buildWhere(filter);
+    const [row] = await txManager.query(
+      `SELECT COUNT(*)::int AS total, MAX("changedAt") AS "lastChangedAt"
+       FROM trip_availability WHERE ${where}`,
+      params
+    );
+    return row;
+  }
+
+  /**
+   * Feed page in change order
+   * @param {Object} filter - { after: { changedAt, groupId } | null, includeClosed }
+   * @param {number} limit
+   */
+  async findPage(filter, limit) {
+    const { where, params } = this.buildWhere(filter);
+    return await txManager.query(
+      `SELECT "groupId", ${SELECTED_COLUMNS}, "changedAt"
+       FROM trip_availability
+       WHERE ${where}
+       ORDER BY "changedAt" ASC, "groupId" ASC
+       LIMIT $${params.length + 1}`,
+      [...params, limit]
+    );
+  }
+
+  buildWhere({ after = null, includeClosed = false }) {
+    const conditions = ["TRUE"];
+    const params = [];
+    if (!includeClosed) {
+      conditions.push(`status <> 'closed'`);
+    }
+    if (after) {
+      params.push(after.changedAt, after.groupId);
+      conditions.push(`("changedAt", "groupId") > ($1::timestamptz, $2::uuid)`);
+    }
+    return { where: conditions.join(" AND "), params };
+  }
+}
+
+export default new TripAvailabilityRepository();
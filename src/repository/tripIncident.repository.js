@@ -0,0 +1,20 @@
+import txManager from "./transactionManager.js";
+import TripIncident from "../models/tripIncident.model.js";
+
+class TripIncidentRepository {
+  getRepository() {
+    return txManager.getRepository(TripIncident);
+  }
+
+  async create(data) {
+    const repo = this.getRepository();
+    return await repo.save(repo.create(data));
+  }
+
+  async update(id, data) {
+    await this.getRepository().update(id, data);
+    return await this.getRepository().findOne({ where: { id } });
+  }
+}
+
+export default new TripIncidentRepository();
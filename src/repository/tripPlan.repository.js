@@ -0,0 +1,83 @@
+import txManager from "./transactionManager.js";
+import { ItineraryItemSchema } from "../models/itinerary.model.js";
+
+/**
+ * Items of the itinerary assigned to a trip (group), organized by day
+ */
+class TripPlanRepository {
+  getRepository() {
+    return txManager.getRepository(ItineraryItemSchema);
+  }
+
+  /**
+   * All items of an itinerary in day order, with their place
+   * @param {string} itineraryId
+   */
+  async findItems(itineraryId) {
+    return await this.getRepository().find({
+      where: { itineraryId },
+      relations: ["place"],
+      order: { date: "ASC", order: "ASC", time: "ASC" },
+    });
+  }
+
+  async findItem(itineraryId, itemId) {
+    return await this.getRepository().findOne({
+      where: { id: itemId, itineraryId },
+      relations: ["place"],
+    });
+  }
+
+  /**
+   * Items of one day, in order
+   * @param {string} itineraryId
+   * @param {string} date - YYYY-MM-DD
+   */
+  async findDayItems(itineraryId, date) {
+    return await this.getRepository().find({
+      where: { itineraryId, date },
+      order: { order: "ASC", time: "ASC" },
+    });
+  }
+
+  /**
+   * Next free position at the end of a day
+   */
+  async nextOrder(itineraryId, date) {
+    const row = await this.getRepository()
+      .createQueryBuilder("item")
+      .select('MAX(item."order")', "max")
+      .where('item."itineraryId" = :itineraryId AND item.date = :date', { itineraryId, date })
+      .getRawOne();
+    return row?.max === null || row?.max === undefined ? 0 : Number(row.max) + 1;
+  }
+
+  async create(data) {
+    const item = this.getRepository().create(data);
+    const saved = await this.getRepository().save(item);
+    return await this.findItem(saved.itineraryId, saved.id);
+  }
+
+  async update(itineraryId, itemId, data) {
+    await this.getRepository().update({ id: itemId, itineraryId }, data);
+    return await this.findItem(itineraryId, itemId);
+  }
+
+  async delete(itemId) {
+    await this.getRepository().delete(itemId);
+  }
+
+  /**
+   * Rewrites the positions of a day's items in the given order
+   * @param {Array<string>} itemIds - Every item of the day, in their new order
+   */
+  async reorderDay(itemIds) {
+    await txManager.run(async (manager) => {
+      for (const [index, id] of itemIds.entries()) {
+        await manager.update(ItineraryItemSchema, id, { order: index });
+      }
+    });
+  }
+}
+
+export default new TripPlanRepository();
@@ -0,0 +1,151 @@
+import txManager from "./transactionManager.js";
+import TripRecommendation from "../models/tripRecommendation.model.js";
+
+/**
+ * Open public trips a user could join: upcoming, with room left, not
+ * organized or joined by them and not from an organizer blocked with them
+ * @param {string} extraCondition - SQL over g, with $1 the user
+ */
+const candidateTripsSql = (extraCondition) =>
+  `SELECT * FROM (
+     SELECT g.id, g.name, g.description, g.capacity, g."destinationName",
+            g."destinationLat"::float AS "destinationLat",
+            g."destinationLng"::float AS "destinationLng",
+            g."tripStartDate"::text AS "startDate", g."tripEndDate"::text AS "endDate",
+            g."travelMonth", g."durationBucket", g."depositAmount", g."depositCurrency",
+            (SELECT COUNT(*)::int FROM group_members gm WHERE gm."groupId" = g.id) AS "memberCount",
+            (SELECT COUNT(*)::int FROM user_followers uf
+             WHERE uf."followerId" = $1
+               AND (uf."followedId" = g."adminId"
+                    OR uf."followedId" IN (SELECT gm."userId" FROM group_members gm WHERE gm."groupId" = g.id))
+            ) AS "followedCount"
+     FROM groups g
+     WHERE g."isPublic" = true AND g."deletedAt" IS NULL
+       AND g."tripStartDate" >= CURRENT_DATE
+       AND g."adminId" <> $1
+       AND NOT EXISTS (SELECT 1 FROM group_members gm WHERE gm."groupId" = g.id AND gm."userId" = $1)
+       AND NOT EXISTS (
+         SELECT 1 FROM user_blocks ub
+         WHERE (ub."blockerId" = $1 AND ub."blockedId" = g."adminId")
+            OR (ub."blockerId" = g."adminId" AND ub."blockedId" = $1)
+       )
+       AND ${extraCondition}
+   ) candidates
+   WHERE capacity IS NULL OR "memberCount" < capacity`;
+
+class TripRecommendationRepository {
+  getRepository() {
+    return txManager.getRepository(TripRecommendation);
+  }
+
+  async findByUser(userId) {
+    return await this.getRepository().findOne({ where: { userId } });
+  }
+
+  /**
+   * Replaces the recommendations of a user
+   * @param {string} userId
+   * @param {Array} items - [{ groupId, score, reasons }]
+   */
+  async save(userId, items) {
+    await this.getRepository().upsert(
+      { userId, items, computedAt: new Date(), refreshQueuedAt: null },
+      ["userId"]
+    );
+    return await this.findByUser(userId);
+  }
+
+  /**
+   * Claims the refresh of a user's recommendations
+   * @param {string} userId
+   * @param {Date} queuedBefore - A refresh queued since then is still pending
+   * @returns {Promise<boolean>} false when a refresh is already queued
+   */
+  async markRefreshQueued(userId, queuedBefore) {
+    const [rows] = await txManager.query(
+      `UPDATE trip_recommendations SET "refreshQueuedAt" = now()
+       WHERE "userId" = $1 AND ("refreshQueuedAt" IS NULL OR "refreshQueuedAt" < $2)
+       RETURNING "userId"`,
+      [userId, queuedBefore]
+    );
+    return rows.length > 0;
+  }
+
+  /**
+   * Users with recommendations who were seen since a date
+   * @param {Date} activeSince
+   * @returns {Promise<Array<string>>}
+   */
+  async findActiveUserIds(activeSince) {
+    const rows = await txManager.query(
+      `SELECT tr."userId" FROM trip_recommendations tr
+       JOIN users u ON u.id = tr."userId"
+       WHERE u."lastSeenAt" >= $1 AND u."deletedAt" IS NULL`,
+      [activeSince]
+    );
+    return rows.map((row) => row.userId);
+  }
+
+  /**
+   * Drops the recommendations of users not seen since a date; they are
+   * computed again on their next visit
+   * @returns {Promise<number>} Rows deleted
+   */
+  async deleteInactive(activeSince) {
+    const [, affected] = await txManager.query(
+      `DELETE FROM trip_recommendations tr
+       USING users u
+       WHERE u.id = tr."userId"
+         AND (u."lastSeenAt" IS NULL OR u."lastSeenAt" < $1 OR u."deletedAt" IS NOT NULL)`,
+      [activeSince]
+    );
+    return affected;
+  }
+
+  /**
+   * Trips a user could join, soonest first
+   * @param {string} userId
+   * @param {number} limit
+   */
+  async findCandidateTrips(userId, limit) {
+    return await txManager.query(`${candidateTripsSql("true")} ORDER BY "startDate" ASC, id ASC LIMIT $2`, [
+      userId,
+      limit,
+    ]);
+  }
+
+  /**
+   * Those of the given trips the user can still join
+   * @param {string} userId
+   * @param {Array<string>} groupIds
+   */
+  async findCandidateTripsByIds(userId, groupIds) {
+    return await txManager.query(candidateTripsSql(`g.id = ANY($2)`), [userId, groupIds]);
+  }
+
+  /**
+   * Trips the user organized or joined (past and upcoming) and trips imported
+   * into their travel history, with what they cost when known
+   * @param {string} userId
+   * @returns {Promise<Array>} - [{ lat, lng, startDate, endDate, depositAmount, depositCurrency }]
+   */
+  async findTravelHistory(userId) {
+    return await txManager.query(
+      `SELECT g."destinationLat"::float AS lat, g."destinationLng"::float AS lng,
+              g."tripStartDate"::text AS "startDate",
+              COALESCE(g."tripEndDate", g."tripStartDate")::text AS "endDate",
+              g."depositAmount", g."depositCurrency"
+       FROM groups g
+       WHERE g."deletedAt" IS NULL
+         AND (g."adminId" = $1
+              OR EXISTS (SELECT 1 FROM group_members gm WHERE gm."groupId" = g.id AND gm."userId" = $1))
+       UNION ALL
+       SELECT pt.lat::float, pt.lng::float, pt."startDate"::text, pt."endDate"::text, NULL, NULL
+       FROM past_trips pt
+       WHERE pt."userId" = $1`,
+      [userId]
+    );
+  }
+}
+
+export default new TripRecommendationRepository();
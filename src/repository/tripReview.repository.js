@@ -0,0 +1,198 @@
+import txManager from "./transactionManager.js";
+import TripReview from "../models/tripReview.model.js";
+import TripReviewFlag from "../models/tripReviewFlag.model.js";
+
+// Average of a rating weighted by author trust, without suspected rings
+const weightedAverage = (column) =>
+  `(SUM(${column} * "authorWeight") FILTER (WHERE "ringStatus" IS DISTINCT FROM 'suspected')
+    / NULLIF(SUM("authorWeight") FILTER (WHERE "ringStatus" IS DISTINCT FROM 'suspected'), 0))::float`;
+
+class TripReviewRepository {
+  getRepository() {
+    return txManager.getRepository(TripReview);
+  }
+
+  getFlagRepository() {
+    return txManager.getRepository(TripReviewFlag);
+  }
+
+  async create(data) {
+    const review = this.getRepository().create(data);
+    return await this.getRepository().save(review);
+  }
+
+  async findById(id) {
+    return await this.getRepository().findOne({
+      where: { id },
+      relations: ["author"],
+    });
+  }
+
+  async findByGroupAndAuthor(groupId, authorId) {
+    return await this.getRepository().findOne({ where: { groupId, authorId } });
+  }
+
+  async findVisibleByGroup(groupId) {
+    return await this.getRepository().find({
+      where: { groupId, status: "visible" },
+      relations: ["author"],
+      order: { createdAt: "DESC" },
+    });
+  }
+
+  async update(id, data) {
+    await this.getRepository().update(id, data);
+    return await this.findById(id);
+  }
+
+  /**
+   * Hides every visible review of an author
+   * @returns {Promise<number>} Reviews hidden
+   */
+  async hideByAuthor(authorId) {
+    const result = await this.getRepository().update({ authorId, status: "visible" }, { status: "hidden" });
+    return result.affected;
+  }
+
+  async delete(id) {
+    await this.getRepository().delete(id);
+  }
+
+  /**
+   * Checks that the user was a member (not the organizer) of a trip that has
+   * already ended (end date derived from the assigned itinerary)
+   * @returns {Promise<Object|null>} - { id, adminId } of the trip or null
+   */
+  async findCompletedTripOfParticipant(userId, groupId) {
+    const [trip] = await txManager.query(
+      `SELECT g.id, g."adminId"
+       FROM groups g
+       JOIN group_members gm ON gm."groupId" = g.id AND gm."userId" = $1
+       WHERE g.id = $2
+         AND g."deletedAt" IS NULL
+         AND g."adminId" <> $1
+         AND (SELECT MAX(ii.date) FROM itinerary_items ii
+              WHERE ii."itineraryId" = g."assignedItineraryId") < CURRENT_DATE`,
+      [userId, groupId]
+    );
+    return trip || null;
+  }
+
+  /**
+   * Average ratings of visible reviews of a trip, plain and weighted by
+   * author (suspected review rings left out of the weighted ones)
+   * @returns {Promise<Object>} - { tripAverage, organizerAverage, weightedTripAverage,
+   *   weightedOrganizerAverage, count }
+   */
+  async getGroupAverages(groupId) {
+    const [row] = await txManager.query(
+      `SELECT AVG("tripRating")::float AS "tripAverage",
+              AVG("organizerRating")::float AS "organizerAverage",
+              ${weightedAverage('"tripRating"')} AS "weightedTripAverage",
+              ${weightedAverage('"organizerRating"')} AS "weightedOrganizerAverage",
+              COUNT(*)::int AS count
+       FROM trip_reviews
+       WHERE "groupId" = $1 AND status = 'visible'`,
+      [groupId]
+    );
+    return row;
+  }
+
+  /**
+   * Average organizer rating of a user across all their trips, plain and weighted
+   * @returns {Promise<Object>} - { average, weightedAverage, count }
+   */
+  async getOrganizerAverage(organizerId) {
+    const [row] = await txManager.query(
+      `SELECT AVG("organizerRating")::float AS average,
+              ${weightedAverage('"organizerRating"')} AS "weightedAverage",
+              COUNT(*)::int AS count
+       FROM trip_reviews
+       WHERE "organizerId" = $1 AND status = 'visible'`,
+      [organizerId]
+    );
+    return row;
+  }
+
+  /**
+   * Pairs of visible reviews where two users gave each other 5 stars as
+   * organizers, skipping reviews an admin already cleared
+   * @returns {Promise<Array>} - [{ reviewId, authorId, organizerId, reciprocalId }], one row per direction
+   */
+  async findReciprocalTopRatings() {
+    return await txManager.query(
+      `SELECT a.id AS "reviewId", a."authorId", a."organizerId", b.id AS "reciprocalId"
+       FROM trip_reviews a
+       JOIN trip_reviews b ON b."authorId" = a."organizerId" AND b."organizerId" = a."authorId"
+       WHERE a."organizerRating" = 5 AND b."organizerRating" = 5
+         AND a.status = 'visible' AND b.status = 'visible'
+         AND a."ringStatus" IS DISTINCT FROM 'cleared'
+         AND b."ringStatus" IS DISTINCT FROM 'cleared'`
+    );
+  }
+
+  /**
+   * Marks reviews as part of a suspected ring
+   * @returns {Promise<string[]>} IDs of the reviews newly marked
+   */
+  async markSuspectedRing(reviewIds) {
+    const [rows] = await txManager.query(
+      `UPDATE trip_reviews SET "ringStatus" = 'suspected'
+       WHERE id = ANY($1) AND "ringStatus" IS NULL
+       RETURNING id`,
+      [reviewIds]
+    );
+    return rows.map((row) => row.id);
+  }
+
+  async findAuthorIds() {
+    const rows = await txManager.query(`SELECT DISTINCT "authorId" FROM trip_reviews`);
+    return rows.map((row) => row.authorId);
+  }
+
+  async updateAuthorWeight(authorId, weight) {
+    await this.getRepository().update({ authorId }, { authorWeight: weight });
+  }
+
+  async findFlag(reviewId, userId) {
+    return await this.getFlagRepository().findOne({ where: { reviewId, userId } });
+  }
+
+  /**
+   * Stores a flag and bumps the review's counter
+   * @returns {Promise<number>} - New flag count
+   */
+  async addFlag(data) {
+    return await txManager.run(async (manager) => {
+      await manager.getRepository(TripReviewFlag).save(data);
+      await manager.getRepository(TripReview).increment({ id: data.reviewId }, "flagCount", 1);
+      const review = await manager.getRepository(TripReview).findOne({ where: { id: data.reviewId } });
+      return review.flagCount;
+    });
+  }
+
+  /**
+   * Reviews with at least one flag or in a suspected ring, most flagged first
+   */
+  async findFlagged(status = null) {
+    const query = this.getRepository()
+      .createQueryBuilder("review")
+      .leftJoinAndSelect("review.author", "author")
+      .where("(review.flagCount > 0 OR review.ringStatus = 'suspected')")
+      .orderBy("review.flagCount", "DESC")
+      .addOrderBy("review.createdAt", "ASC");
+    if (status) {
+      query.andWhere("review.status = :status", { status });
+    }
+    return await query.getMany();
+  }
+
+  async findFlags(reviewId) {
+    return await this.getFlagRepository().find({
+      where: { reviewId },
+      order: { createdAt: "ASC" },
+    });
+  }
+}
+
+export default new TripReviewRepository();
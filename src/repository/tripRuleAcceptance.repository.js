@@ -0,0 +1,44 @@
+import txManager from "./transactionManager.js";
+import TripRuleAcceptance from "../models/tripRuleAcceptance.model.js";
+
+class TripRuleAcceptanceRepository {
+  getRepository() {
+    return txManager.getRepository(TripRuleAcceptance);
+  }
+
+  /**
+   * Records an acceptance; accepting the same version again keeps the first one
+   * @returns {Promise<Object>} Acceptance
+   */
+  async accept(groupId, userId, version) {
+    await txManager.query(
+      `INSERT INTO trip_rule_acceptances (id, "groupId", "userId", version, "acceptedAt")
+       VALUES (gen_random_uuid(), $1, $2, $3, now())
+       ON CONFLICT ("groupId", "userId", version) DO NOTHING`,
+      [groupId, userId, version]
+    );
+    return await this.getRepository().findOne({ where: { groupId, userId, version } });
+  }
+
+  /**
+   * Latest version each user accepted
+   * @param {string} groupId
+   * @param {string[]} userIds
+   * @returns {Promise<Map>} userId -> { version, acceptedAt }
+   */
+  async findLatestByUsers(groupId, userIds) {
+    if (userIds.length === 0) {
+      return new Map();
+    }
+    const rows = await txManager.query(
+      `SELECT DISTINCT ON ("userId") "userId", version, "acceptedAt"
+       FROM trip_rule_acceptances
+       WHERE "groupId" = $1 AND "userId" = ANY($2)
+       ORDER BY "userId", version DESC`,
+      [groupId, userIds]
+    );
+    return new Map(rows.map((row) => [row.userId, { version: row.version, acceptedAt: row.acceptedAt }]));
+  }
+}
+
+export default new TripRuleAcceptanceRepository();
@@ -1,6 +1,9 @@
-import { AppDataSource } from "../load/typeorm.loader.js";
+import txManager from "./transactionManager.js";
 import User from "../models/user.model.js";
 import Fuse from "fuse.js";
+import { IsNull } from "typeorm";
+import { softDeleteById, restoreById, findTrashed } from "./softDelete.js";
+import { notBlockedBetween } from "./userBlock.repository.js";
 
 class UserRepository {
   constructor() {
@@ -8,24 +11,42 @@ class UserRepository {
 
   // Inicializa el repositorio cuando TypeORM esté listo
   getRepository() {
-    return AppDataSource.getRepository(User);
+    return txManager.getRepository(User);
   }
 
   /**
-   * Busca un usuario por email
+   * Busca un usuario por email (las cuentas dadas de baja no se devuelven)
    * @param {string} email - Email del usuario
    * @returns {Promise<User|null>} - Usuario encontrado o null
    */
   async findByEmail(email) {
+    return await this.getRepository().findOne({ where: { email, deletedAt: IsNull() } });
+  }
+
+  /**
+   * Busca un usuario por email, incluidas las cuentas dadas de baja
+   * @param {string} email - Email del usuario
+   * @returns {Promise<User|null>} - Usuario encontrado o null
+   */
+  async findByEmailWithDeleted(email) {
     return await this.getRepository().findOne({ where: { email } });
   }
 
   /**
-   * Busca un usuario por ID
+   * Busca un usuario por ID (las cuentas dadas de baja no se devuelven)
    * @param {string} id - ID del usuario
    * @returns {Promise<User|null>} - Usuario encontrado o null
    */
   async findById(id) {
+    return await this.getRepository().findOne({ where: { id, deletedAt: IsNull() } });
+  }
+
+  /**
+   * Busca un usuario por ID, incluidas las cuentas dadas de baja
+   * @param {string} id - ID del usuario
+   * @returns {Promise<User|null>} - Usuario encontrado o null
+   */
+  async findByIdWithDeleted(id) {
     return await this.getRepository().findOne({ where: { id } });
   }
 
@@ -36,7 +57,7 @@ class UserRepository {
    */
   async findByConfirmationToken(token) {
     return await this.getRepository().findOne({
-      where: { emailConfirmationToken: token },
+      where: { emailConfirmationToken: token, deletedAt: IsNull() },
     });
   }
 
@@ -47,7 +68,7 @@ class UserRepository {
    */
   async findByPasswordResetToken(token) {
     return await this.getRepository().findOne({
-      where: { passwordResetToken: token },
+      where: { passwordResetToken: token, deletedAt: IsNull() },
     });
   }
 
@@ -69,7 +90,7 @@ class UserRepository {
    */
   async update(id, updateData) {
     await this.getRepository().update(id, updateData);
-    return await this.findById(id);
+    return await this.findByIdWithDeleted(id);
   }
 
   /**
@@ -82,7 +103,8 @@ class UserRepository {
     // Primero obtener todos los usuarios (limitado para performance)
     const allUsers = await this.getRepository()
       .createQueryBuilder("user")
-      .select(["user.id", "user.email", "user.name", "user.age", "user.profilePicture", "user.isEmailConfirmed", "user.createdAt", "user.updatedAt"])
+      .select(["user.id", "user.email", "user.name", "user.age", "user.dateOfBirth", "user.profilePicture", "user.isEmailConfirmed", "user.createdAt", "user.updatedAt"])
+      .where(`"user"."deletedAt" IS NULL`)
       .orderBy("user.email", "ASC")
       .limit(1000) // Limitar para evitar cargar demasiados usuarios
       .getMany();
@@ -111,13 +133,19 @@ class UserRepository {
    * Busca usuarios por query (nombre o email) con búsqueda fuzzy
    * @param {string} query - Query a buscar (puede ser nombre o email)
    * @param {number} limit - Límite de resultados (default: 20)
+   * @param {string|null} viewerId - Excluye a los usuarios bloqueados con quien busca (en cualquier sentido)
    * @returns {Promise<User[]>} - Lista de usuarios encontrados
    */
-  async searchByQuery(query, limit = 20) {
+  async searchByQuery(query, limit = 20, viewerId = null) {
     // Primero obtener todos los usuarios (limitado para performance)
-    const allUsers = await this.getRepository()
+    const candidates = this.getRepository()
       .createQueryBuilder("user")
-      .select(["user.id", "user.email", "user.name", "user.age", "user.profilePicture", "user.isEmailConfirmed", "user.createdAt", "user.updatedAt"])
+      .select(["user.id", "user.email", "user.name", "user.age", "user.dateOfBirth", "user.profilePicture", "user.isEmailConfirmed", "user.createdAt", "user.updatedAt"])
+      .where(`"user"."deletedAt" IS NULL`);
+    if (viewerId) {
+      candidates.andWhere(notBlockedBetween(":viewerId", `"user"."id"`), { viewerId });
+    }
+    const allUsers = await candidates
       .orderBy("user.email", "ASC")
       .limit(1000) // Limitar para evitar cargar demasiados usuarios
       .getMany();
@@ -142,6 +170,110 @@ class UserRepository {
     return matchedUsers;
   }
 
+  /**
+   * Lista usuarios para administración, más recientes primero
+   * @param {Object} filters - { query, status: "active" | "suspended", role, limit, offset }
+   * @returns {Promise<[User[], number]>} - Usuarios de la página y total
+   */
+  async findForAdmin({ query = null, status = null, role = null, limit = 50, offset = 0 } = {}) {
+    const qb = this.getRepository()
+      .createQueryBuilder("user")
+      .select([
+        "user.id",
+        "user.email",
+        "user.name",
+        "user.role",
+        "user.country",
+        "user.isEmailConfirmed",
+        "user.dateOfBirth",
+        "user.ageRestricted",
+        "user.suspendedAt",
+        "user.suspensionReason",
+        "user.deletedAt",
+        "user.lastActivity",
+        "user.createdAt",
+      ]);
+
+    if (query) {
+      qb.andWhere("(user.email ILIKE :query OR user.name ILIKE :query)", { query: `%${query}%` });
+    }
+    if (status === "suspended") {
+      qb.andWhere("user.suspendedAt IS NOT NULL");
+    } else if (status === "active") {
+      qb.andWhere("user.suspendedAt IS NULL");
+    }
+    if (role) {
+      qb.andWhere("user.role = :role", { role });
+    }
+
+    return await qb
+      .orderBy("user.createdAt", "DESC")
+      .addOrderBy("user.id", "ASC")
+      .take(limit)
+      .skip(offset)
+      .getManyAndCount();
+  }
+
+  /**
+   * IDs de los administradores activos (no suspendidos ni dados de baja)
+   * @returns {Promise<string[]>}
+   */
+  async findAdminIds() {
+    const admins = await this.getRepository().find({
+      select: ["id"],
+      where: { role: "admin", suspendedAt: IsNull(), deletedAt: IsNull() },
+    });
+    return admins.map((admin) => admin.id);
+  }
+
+  /**
+   * Usuario con su secreto TOTP y códigos de respaldo (no se cargan por defecto)
+   * @param {string} id - ID del usuario
+   * @returns {Promise<User|null>}
+   */
+  async findWithTwoFactorSecrets(id) {
+    return await this.getRepository()
+      .createQueryBuilder("user")
+      .addSelect(["user.twoFactorSecret", "user.twoFactorBackupCodes"])
+      .where("user.id = :id", { id })
+      .getOne();
+  }
+
+  /**
+   * Registra el paso de tiempo de un código TOTP aceptado, solo si es
+   * posterior al último; así un código no sirve dos veces
+   * @param {string} id - ID del usuario
+   * @param {number} step
+   * @returns {Promise<boolean>} - false si el código ya se usó
+   */
+  async consumeTwoFactorStep(id, step) {
+    const rows = await txManager.query(
+      `UPDATE users SET "twoFactorLastStep" = $2
+       WHERE id = $1 AND ("twoFactorLastStep" IS NULL OR "twoFactorLastStep" < $2)
+       RETURNING id`,
+      [id, step]
+    );
+    const updated = Array.isArray(rows[0]) ? rows[0] : rows;
+    return updated.length > 0;
+  }
+
+  /**
+   * Quita un código de respaldo sin usar
+   * @param {string} id - ID del usuario
+   * @param {string} codeHash - SHA-256 del código
+   * @returns {Promise<boolean>} - false si no existía o ya se usó
+   */
+  async consumeBackupCode(id, codeHash) {
+    const rows = await txManager.query(
+      `UPDATE users SET "twoFactorBackupCodes" = "twoFactorBackupCodes" - $2::text
+       WHERE id = $1 AND "twoFactorBackupCodes" ? $2::text
+       RETURNING id`,
+      [id, codeHash]
+    );
+    const updated = Array.isArray(rows[0]) ? rows[0] : rows;
+    return updated.length > 0;
+  }
+
   /**
    * Alias para findById - Busca un usuario por ID
    * @param {string} id - ID del usuario
@@ -161,6 +293,33 @@ class UserRepository {
     return await this.update(id, updateData);
   }
 
+  /**
+   * Da de baja una cuenta de forma lógica (restaurable hasta que se anonimice)
+   * @param {string} id - ID del usuario
+   * @returns {Promise<boolean>} - false si no existe o ya estaba dada de baja
+   */
+  async softDelete(id) {
+    return await softDeleteById(this.getRepository(), id);
+  }
+
+  /**
+   * Restaura una cuenta dada de baja que todavía no fue anonimizada
+   * @param {string} id - ID del usuario
+   * @returns {Promise<boolean>} - false si no existe o no se puede restaurar
+   */
+  async restore(id) {
+    return await restoreById(this.getRepository(), id, { anonymizedAt: IsNull() });
+  }
+
+  /**
+   * Cuentas dadas de baja y todavía restaurables, las más recientes primero
+   * @param {Object} options - { limit, offset }
+   * @returns {Promise<User[]>}
+   */
+  async findTrashed({ limit, offset }) {
+    return await findTrashed(this.getRepository(), { where: { anonymizedAt: IsNull() }, limit, offset });
+  }
+
   async findAtus() {
     return {
       "atus?": "yes, atus",
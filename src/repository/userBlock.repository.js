@@ -0,0 +1,98 @@
+import txManager from "./transactionManager.js";
+import UserBlock from "../models/userBlock.model.js";
+
+/**
+ * SQL condition that leaves out the rows of users blocked with a viewer, in
+ * either direction
+ * @param {string} viewer - Parameter or column holding the viewer's ID
+ * @param {string} user - Column holding the user of the row
+ * @returns {string}
+ */
+export const notBlockedBetween = (viewer, user) =>
+  `NOT EXISTS (
+     SELECT 1 FROM user_blocks ub
+     WHERE (ub."blockerId" = ${viewer} AND ub."blockedId" = ${user})
+        OR (ub."blockerId" = ${user} AND ub."blockedId" = ${viewer})
+   )`;
+
+class UserBlockRepository {
+  getRepository() {
+    return txManager.getRepository(UserBlock);
+  }
+
+  async create(blockerId, blockedId) {
+    const block = this.getRepository().create({ blockerId, blockedId });
+    return await this.getRepository().save(block);
+  }
+
+  async delete(blockerId, blockedId) {
+    const result = await this.getRepository().delete({ blockerId, blockedId });
+    return result.affected > 0;
+  }
+
+  async findOne(blockerId, blockedId) {
+    return await this.getRepository().findOne({ where: { blockerId, blockedId } });
+  }
+
+  /**
+   * Whether either user blocked the other
+   */
+  async existsBetween(userId, otherUserId) {
+    const count = await this.getRepository().count({
+      where: [
+        { blockerId: userId, blockedId: otherUserId },
+        { blockerId: otherUserId, blockedId: userId },
+      ],
+    });
+    return count > 0;
+  }
+
+  /**
+   * Users hidden from someone: those they blocked and those who blocked them
+   * @param {string} userId
+   * @returns {Promise<string[]>}
+   */
+  async findHiddenUserIds(userId) {
+    const rows = await txManager.query(
+      `SELECT "blockedId" AS id FROM user_blocks WHERE "blockerId" = $1
+       UNION
+       SELECT "blockerId" AS id FROM user_blocks WHERE "blockedId" = $1`,
+      [userId]
+    );
+    return rows.map((row) => row.id);
+  }
+
+  /**
+   * IDs of the search documents hidden from someone: the users blocked in
+   * either direction and the trips they organize
+   * @param {string} userId
+   * @returns {Promise<string[]>}
+   */
+  async findHiddenSearchIds(userId) {
+    const rows = await txManager.query(
+      `WITH hidden AS (
+         SELECT "blockedId" AS id FROM user_blocks WHERE "blockerId" = $1
+         UNION
+         SELECT "blockerId" AS id FROM user_blocks WHERE "blockedId" = $1
+       )
+       SELECT id FROM hidden
+       UNION ALL
+       SELECT g.id FROM groups g JOIN hidden ON hidden.id = g."adminId"`,
+      [userId]
+    );
+    return rows.map((row) => row.id);
+  }
+
+  /**
+   * Users blocked by someone, most recent first
+   */
+  async findBlockedBy(blockerId) {
+    return await this.getRepository().find({
+      where: { blockerId },
+      relations: ["blocked"],
+      order: { createdAt: "DESC" },
+    });
+  }
+}
+
+export default new UserBlockRepository();
@@ -1,17 +1,9 @@
-import { AppDataSource } from "../load/typeorm.loader.js";
+import txManager from "./transactionManager.js";
 import UserFavorite from "../models/userFavorite.model.js";
 
 class UserFavoriteRepository {
-  constructor() {
-    this.repository = null;
-  }
-
-  // Inicializa el repositorio cuando TypeORM esté listo
   getRepository() {
-    if (!this.repository) {
-      this.repository = AppDataSource.getRepository(UserFavorite);
-    }
-    return this.repository;
+    return txManager.getRepository(UserFavorite);
   }
 
   /**
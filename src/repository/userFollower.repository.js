@@ -1,4 +1,4 @@
-import { AppDataSource } from "../load/typeorm.loader.js";
+import txManager from "./transactionManager.js";
 import UserFollower from "../models/userFollower.model.js";
 
 class UserFollowerRepository {
@@ -6,7 +6,7 @@ class UserFollowerRepository {
 
   // Inicializa el repositorio cuando TypeORM esté listo
   getRepository() {
-    return AppDataSource.getRepository(UserFollower);
+    return txManager.getRepository(UserFollower);
   }
 
   /**
@@ -123,6 +123,27 @@ class UserFollowerRepository {
     });
   }
 
+  /**
+   * Obtiene los IDs de seguidores de un usuario en lotes (paginación por cursor)
+   * @param {string} userId - ID del usuario seguido
+   * @param {number} limit - Tamaño del lote
+   * @param {string|null} afterId - Último ID de seguidor del lote anterior
+   * @returns {Promise<string[]>} - IDs de seguidores ordenados
+   */
+  async getFollowerIdsBatch(userId, limit = 500, afterId = null) {
+    const query = this.getRepository()
+      .createQueryBuilder("uf")
+      .select('uf."followerId"', "followerId")
+      .where('uf."followedId" = :userId', { userId })
+      .orderBy('uf."followerId"', "ASC")
+      .limit(limit);
+    if (afterId) {
+      query.andWhere('uf."followerId" > :afterId', { afterId });
+    }
+    const rows = await query.getRawMany();
+    return rows.map((row) => row.followerId);
+  }
+
   /**
    * Obtiene estadísticas de seguimiento de un usuario
    * @param {string} userId - ID del usuario
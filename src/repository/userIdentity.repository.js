@@ -0,0 +1,27 @@
+import txManager from "./transactionManager.js";
+import UserIdentity from "../models/userIdentity.model.js";
+
+class UserIdentityRepository {
+  getRepository() {
+    return txManager.getRepository(UserIdentity);
+  }
+
+  async findByProviderSubject(provider, subject) {
+    return await this.getRepository().findOne({ where: { provider, subject } });
+  }
+
+  async findByUser(userId) {
+    return await this.getRepository().find({ where: { userId }, order: { createdAt: "ASC" } });
+  }
+
+  async create(data) {
+    const identity = this.getRepository().create(data);
+    return await this.getRepository().save(identity);
+  }
+
+  async touch(id) {
+    await this.getRepository().update(id, { lastLoginAt: new Date() });
+  }
+}
+
+export default new UserIdentityRepository();
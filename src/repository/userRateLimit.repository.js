@@ -1,4 +1,4 @@
-import { AppDataSource } from "../load/typeorm.loader.js";
+import txManager from "./transactionManager.js";
 import UserRateLimit from "../models/userRateLimit.model.js";
 
 class UserRateLimitRepository {
@@ -6,7 +6,7 @@ class UserRateLimitRepository {
 
   // Inicializa el repositorio cuando TypeORM esté listo
   getRepository() {
-    return AppDataSource.getRepository(UserRateLimit);
+    return txManager.getRepository(UserRateLimit);
   }
 
   /**
@@ -0,0 +1,108 @@
+import { In, MoreThanOrEqual } from "typeorm";
+import txManager from "./transactionManager.js";
+import UserReport from "../models/userReport.model.js";
+
+class UserReportRepository {
+  getRepository() {
+    return txManager.getRepository(UserReport);
+  }
+
+  async create(data) {
+    const report = this.getRepository().create(data);
+    return await this.getRepository().save(report);
+  }
+
+  async findById(id) {
+    return await this.getRepository().findOne({
+      where: { id },
+      relations: ["reporter", "targetUser", "targetGroup"],
+    });
+  }
+
+  /**
+   * Open report of the same reporter on the same target, to avoid duplicates
+   */
+  async findOpenDuplicate({ reporterId, targetType, targetUserId, targetGroupId }) {
+    return await this.getRepository().findOne({
+      where: {
+        reporterId,
+        targetType,
+        targetUserId,
+        ...(targetGroupId ? { targetGroupId } : {}),
+        status: "open",
+      },
+    });
+  }
+
+  /**
+   * @param {Object} filters - { status, targetType }
+   */
+  async findAll({ status = null, targetType = null } = {}) {
+    const where = {};
+    if (status) where.status = status;
+    if (targetType) where.targetType = targetType;
+    return await this.getRepository().find({
+      where,
+      relations: ["reporter", "targetUser", "targetGroup"],
+      // "high" sorts before "normal": incidents first
+      order: { severity: "ASC", createdAt: "ASC" },
+    });
+  }
+
+  /**
+   * Reports per target user, to spot repeat offenders
+   */
+  async countByTargetUser(targetUserId) {
+    return await this.getRepository().count({ where: { targetUserId } });
+  }
+
+  /**
+   * Open reports on a trip or a user, oldest first
+   * @param {Object} target - { targetType, targetId: group ID for trips, user ID for users }
+   * @param {Date|null} since
+   */
+  async findOpenByTarget({ targetType, targetId }, since = null) {
+    return await this.getRepository().find({
+      where: {
+        targetType,
+        ...(targetType === "trip" ? { targetGroupId: targetId } : { targetUserId: targetId }),
+        status: "open",
+        ...(since ? { createdAt: MoreThanOrEqual(since) } : {}),
+      },
+      order: { createdAt: "ASC" },
+    });
+  }
+
+  async findOpenByIds(ids) {
+    if (ids.length === 0) {
+      return [];
+    }
+    return await this.getRepository().find({ where: { id: In(ids), status: "open" } });
+  }
+
+  /**
+   * Open reports against a user, including those on the trips they organize
+   */
+  async findOpenByTargetUser(targetUserId) {
+    return await this.getRepository().find({ where: { targetUserId, status: "open" } });
+  }
+
+  /**
+   * Resolves several open reports at once; reports resolved meanwhile are left alone
+   * @returns {Promise<number>} Reports resolved
+   */
+  async resolveMany(ids, data) {
+    if (ids.length === 0) {
+      return 0;
+    }
+    const result = await this.getRepository().update({ id: In(ids), status: "open" }, data);
+    return result.affected;
+  }
+
+  async update(id, data) {
+    await this.getRepository().update(id, data);
+    return await this.findById(id);
+  }
+}
+
+export default new UserReportRepository();
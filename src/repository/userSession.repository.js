@@ -0,0 +1,93 @@
+import { IsNull, MoreThan, Not } from "typeorm";
+import txManager from "./transactionManager.js";
+import UserSession from "../models/userSession.model.js";
+
+class UserSessionRepository {
+  getRepository() {
+    return txManager.getRepository(UserSession);
+  }
+
+  async create(data) {
+    const session = this.getRepository().create(data);
+    return await this.getRepository().save(session);
+  }
+
+  async findById(id) {
+    return await this.getRepository().findOne({ where: { id } });
+  }
+
+  /**
+   * Sessions of a user that are neither revoked nor expired, most recently used first
+   */
+  async findActiveByUser(userId) {
+    return await this.getRepository().find({
+      where: { userId, revokedAt: IsNull(), expiresAt: MoreThan(new Date()) },
+      order: { lastSeenAt: "DESC" },
+    });
+  }
+
+  async isActive(id) {
+    return await this.getRepository().exists({
+      where: { id, revokedAt: IsNull(), expiresAt: MoreThan(new Date()) },
+    });
+  }
+
+  /**
+   * Swaps the refresh token of a session, only if the one presented is still
+   * the current one, so a token can be used once
+   * @returns {Promise<Object|null>} The updated session, null if it did not match
+   */
+  async rotate(id, currentJti, { refreshJti, expiresAt, ipAddress }) {
+    const rows = await txManager.query(
+      `UPDATE user_sessions
+       SET "refreshJti" = $3, "expiresAt" = $4, "ipAddress" = COALESCE($5, "ipAddress"), "lastSeenAt" = now()
+       WHERE id = $1 AND "refreshJti" = $2 AND "revokedAt" IS NULL AND "expiresAt" > now()
+       RETURNING *`,
+      [id, currentJti, refreshJti, expiresAt, ipAddress]
+    );
+    const updated = Array.isArray(rows[0]) ? rows[0] : rows;
+    return updated[0] || null;
+  }
+
+  async touch(id) {
+    await this.getRepository().update(id, { lastSeenAt: new Date() });
+  }
+
+  /**
+   * @returns {Promise<boolean>} Whether an active session was revoked
+   */
+  async revoke(id, userId) {
+    const result = await this.getRepository().update(
+      { id, userId, revokedAt: IsNull() },
+      { revokedAt: new Date() }
+    );
+    return (result.affected || 0) > 0;
+  }
+
+  /**
+   * Revokes every active session of a user but the one given (if any)
+   * @returns {Promise<number>} Sessions revoked
+   */
+  async revokeAllByUser(userId, exceptId = null) {
+    const where = { userId, revokedAt: IsNull() };
+    if (exceptId) {
+      where.id = Not(exceptId);
+    }
+    const result = await this.getRepository().update(where, { revokedAt: new Date() });
+    return result.affected || 0;
+  }
+
+  /**
+   * Deletes sessions expired or revoked before a date
+   */
+  async deleteEndedBefore(date) {
+    const result = await this.getRepository()
+      .createQueryBuilder()
+      .delete()
+      .where(`"expiresAt" < :date OR "revokedAt" < :date`, { date })
+      .execute();
+    return result.affected || 0;
+  }
+}
+
+export default new UserSessionRepository();
@@ -0,0 +1,680 @@
+import { Router } from "express";
+import { authenticate, authorize } from "../middleware/auth.middleware.js";
+import adminController from "../controllers/admin.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/admin/users:
+ *   get:
+ *     summary: List users
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: q
+ *         description: Matches email or name
+ *         schema:
+ *           type: string
+ *       - in: query
+ *         name: status
+ *         schema:
+ *           type: string
+ *           enum: [active, suspended]
+ *       - in: query
+ *         name: role
+ *         schema:
+ *           type: string
+ *           enum: [user, admin]
+ *       - in: query
+ *         name: limit
+ *         schema:
+ *           type: integer
+ *           default: 50
+ *           maximum: 100
+ *       - in: query
+ *         name: offset
+ *         schema:
+ *           type: integer
+ *           default: 0
+ *     responses:
+ *       200:
+ *         description: users and pagination ({ limit, offset, total })
+ *       403:
+ *         description: Admin role required
+ */
+router.get("/users", authenticate, authorize(["admin"]), adminController.listUsers);
+
+/**
+ * @swagger
+ * /api/admin/users/{userId}/role:
+ *   patch:
+ *     summary: Change the role of a user
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: userId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [role]
+ *             properties:
+ *               role:
+ *                 type: string
+ *                 enum: [user, admin]
+ *     responses:
+ *       200:
+ *         description: Role updated
+ *       400:
+ *         description: Invalid role, or changing your own role
+ *       404:
+ *         description: User not found
+ *       409:
+ *         description: The user already has that role
+ */
+router.patch("/users/:userId/role", authenticate, authorize(["admin"]), adminController.changeRole);
+
+/**
+ * @swagger
+ * /api/admin/users/{userId}/suspension:
+ *   post:
+ *     summary: Suspend a user
+ *     description: >
+ *       The user can no longer log in, refresh or use existing tokens, and is
+ *       hidden from search. Admin accounts cannot be suspended.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: userId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [reason]
+ *             properties:
+ *               reason:
+ *                 type: string
+ *     responses:
+ *       200:
+ *         description: User suspended
+ *       403:
+ *         description: Admin role required, or the target is an admin
+ *       404:
+ *         description: User not found
+ *       409:
+ *         description: User already suspended
+ *   delete:
+ *     summary: Lift a suspension
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: userId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: User reinstated
+ *       404:
+ *         description: User not found
+ *       409:
+ *         description: User is not suspended
+ */
+router.post(
+  "/users/:userId/suspension",
+  authenticate,
+  authorize(["admin"]),
+  adminController.suspendUser
+);
+router.delete(
+  "/users/:userId/suspension",
+  authenticate,
+  authorize(["admin"]),
+  adminController.reinstateUser
+);
+
+/**
+ * @swagger
+ * /api/admin/users/{userId}/id-verification:
+ *   post:
+ *     summary: Mark a user's identity as verified
+ *     description: >
+ *       Where FEATURE_POLICIES sets requirements.idVerification for the user's
+ *       country or tenant, creating, joining and paying for trips need it.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: userId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Identity verified
+ *       404:
+ *         description: User not found
+ *       409:
+ *         description: Already verified
+ *   delete:
+ *     summary: Withdraw a user's identity verification
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: userId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Verification withdrawn
+ *       404:
+ *         description: User not found
+ *       409:
+ *         description: Not verified
+ */
+router.post(
+  "/users/:userId/id-verification",
+  authenticate,
+  authorize(["admin"]),
+  adminController.verifyIdentity
+);
+router.delete(
+  "/users/:userId/id-verification",
+  authenticate,
+  authorize(["admin"]),
+  adminController.revokeIdVerification
+);
+
+/**
+ * @swagger
+ * /api/admin/trips/{groupId}:
+ *   delete:
+ *     summary: Force-delete a trip
+ *     description: Deletes the trip whoever organizes it and notifies its members. The trip goes to the trash and can be restored.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             properties:
+ *               reason:
+ *                 type: string
+ *     responses:
+ *       200:
+ *         description: Trip deleted ({ id, name, notifiedUsers })
+ *       404:
+ *         description: Trip not found
+ */
+router.delete("/trips/:groupId", authenticate, authorize(["admin"]), adminController.forceDeleteTrip);
+
+/**
+ * @swagger
+ * /api/admin/trash/{type}:
+ *   get:
+ *     summary: Soft-deleted records
+ *     description: Most recently deleted first. Closed accounts are listed until their data is erased.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: type
+ *         required: true
+ *         schema:
+ *           type: string
+ *           enum: [trips, group_messages, direct_messages, users]
+ *       - in: query
+ *         name: limit
+ *         schema:
+ *           type: integer
+ *           default: 50
+ *           maximum: 100
+ *       - in: query
+ *         name: offset
+ *         schema:
+ *           type: integer
+ *           default: 0
+ *     responses:
+ *       200:
+ *         description: Deleted records ({ type, items, pagination })
+ *       400:
+ *         description: Unknown type
+ */
+router.get("/trash/:type", authenticate, authorize(["admin"]), adminController.listTrash);
+
+/**
+ * @swagger
+ * /api/admin/trash/{type}/{id}/restore:
+ *   post:
+ *     summary: Restore a soft-deleted record
+ *     description: Restoring a closed account cancels its pending erasure.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: type
+ *         required: true
+ *         schema:
+ *           type: string
+ *           enum: [trips, group_messages, direct_messages, users]
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Record restored ({ type, id })
+ *       400:
+ *         description: Unknown type
+ *       404:
+ *         description: No restorable record with that ID
+ */
+router.post(
+  "/trash/:type/:id/restore",
+  authenticate,
+  authorize(["admin"]),
+  adminController.restoreFromTrash
+);
+
+/**
+ * @swagger
+ * /api/admin/reports:
+ *   get:
+ *     summary: Reports to review
+ *     description: >
+ *       High severity first (safety incidents reported during trips), then oldest
+ *       first, with how many reports the reported user has in total.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: status
+ *         schema:
+ *           type: string
+ *           enum: [open, actioned, dismissed]
+ *           default: open
+ *       - in: query
+ *         name: targetType
+ *         schema:
+ *           type: string
+ *           enum: [user, trip]
+ *     responses:
+ *       200:
+ *         description: Reports
+ *       403:
+ *         description: Admin role required
+ */
+router.get("/reports", authenticate, authorize(["admin"]), adminController.getReports);
+
+/**
+ * @swagger
+ * /api/admin/reports/{id}/resolve:
+ *   post:
+ *     summary: Action or dismiss a report
+ *     description: >
+ *       "warning" notifies the reported user; "trip_hidden" makes the reported
+ *       trip private and notifies its organizer.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [status]
+ *             properties:
+ *               status:
+ *                 type: string
+ *                 enum: [actioned, dismissed]
+ *               action:
+ *                 type: string
+ *                 enum: [warning, trip_hidden]
+ *                 description: Required when status is actioned
+ *               notes:
+ *                 type: string
+ *     responses:
+ *       200:
+ *         description: Report resolved
+ *       403:
+ *         description: Admin role required
+ *       404:
+ *         description: Report not found
+ *       409:
+ *         description: Report already resolved
+ */
+router.post(
+  "/reports/:id/resolve",
+  authenticate,
+  authorize(["admin"]),
+  adminController.resolveReport
+);
+
+/**
+ * @swagger
+ * /api/admin/stats:
+ *   get:
+ *     summary: Platform stats
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: >
+ *           users ({ total, suspended, newLast7Days, activeLast30Days }),
+ *           trips ({ total, public, scheduled, publishedLast7Days }),
+ *           reports ({ open, resolvedLast7Days }) and activity
+ *           ({ directMessagesLast24h, groupMessagesLast24h, pendingJoinRequests })
+ *       403:
+ *         description: Admin role required
+ */
+router.get("/stats", authenticate, authorize(["admin"]), adminController.getStats);
+
+/**
+ * @swagger
+ * /api/admin/audit-logs:
+ *   get:
+ *     summary: Query the audit log
+ *     description: >
+ *       Logins, password resets, trip deletions and admin actions, newest first.
+ *       Entries are append-only. Page with the `before` cursor (nextCursor).
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: actorId
+ *         schema:
+ *           type: string
+ *       - in: query
+ *         name: action
+ *         description: Exact action (e.g. auth.login) or a prefix such as admin.*
+ *         schema:
+ *           type: string
+ *       - in: query
+ *         name: from
+ *         description: Inclusive start (ISO 8601)
+ *         schema:
+ *           type: string
+ *           format: date-time
+ *       - in: query
+ *         name: to
+ *         description: Exclusive end (ISO 8601)
+ *         schema:
+ *           type: string
+ *           format: date-time
+ *       - in: query
+ *         name: before
+ *         description: Entry ID to continue after
+ *         schema:
+ *           type: string
+ *       - in: query
+ *         name: limit
+ *         schema:
+ *           type: integer
+ *           default: 50
+ *           maximum: 200
+ *     responses:
+ *       200:
+ *         description: entries and nextCursor
+ *       400:
+ *         description: Invalid dates or cursor
+ *       403:
+ *         description: Admin role required
+ */
+router.get("/audit-logs", authenticate, authorize(["admin"]), adminController.getAuditLogs);
+
+/**
+ * @swagger
+ * /api/admin/status:
+ *   get:
+ *     summary: Dependency status for on-call
+ *     description: >
+ *       Runs every check concurrently (each one times out after 3 seconds) and
+ *       always answers 200; read `status` ("ok", "degraded" or "down", which only
+ *       happens when the database is unreachable). Checks report their own status
+ *       ("ok", "degraded", "down" or "disabled") and latencyMs.
+ *       - database: pool connections (total, idle, waiting, max)
+ *       - redis: PING reply, disabled when REDIS_URL is unset
+ *       - jobQueue: pending, running and failed jobs, per type, paused types and
+ *         how long the oldest due job of a running queue has waited (degraded
+ *         after 10 minutes)
+ *       - breakers: state of the circuit breakers around external clients
+ *       - webhooks: payments Stripe has not confirmed after 30 minutes
+ *         (processing or refund pending)
+ *       - scheduler: latest entry of each /cron task (a run, or "missed" when it
+ *         did not run on schedule) and its last success
+ *       - events: domain events waiting in the outbox for the broker and those
+ *         that failed for good (degraded after 5 minutes of lag or with any
+ *         failed), disabled when EVENTS_BROKER is none
+ *       - analyticsEvents: client analytics events waiting for the warehouse,
+ *         dropped ones and the last write error (degraded until a write
+ *         succeeds), disabled when ANALYTICS_EVENTS_SINK is none
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: status, checkedAt and checks
+ *       403:
+ *         description: Admin role required
+ */
+router.get("/status", authenticate, authorize(["admin"]), adminController.getStatus);
+
+/**
+ * @swagger
+ * /api/admin/join-eligibility:
+ *   get:
+ *     summary: Explain whether a user can join a trip (dry run)
+ *     description: >
+ *       Same as GET /api/groups/{groupId}/join-eligibility for any user, with
+ *       their own country and tenant. Useful to answer why a join was refused.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: query
+ *         name: userId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: "{ groupId, userId, eligible, country, tenant, rules: [{ rule, enabled, passed, errorCode, reason, details, settings }] }"
+ *       400:
+ *         description: groupId or userId missing
+ *       404:
+ *         description: Group or user not found
+ */
+router.get("/join-eligibility", authenticate, authorize(["admin"]), adminController.getJoinEligibility);
+
+/**
+ * @swagger
+ * /api/admin/bookings/{bookingId}/timeline:
+ *   get:
+ *     summary: Trace a booking across subsystems
+ *     description: >
+ *       A booking is a join request (its ID is the booking ID) and its payments.
+ *       Returns the booking, its payments and, in order, every step recorded for
+ *       it: request and decisions, payment transitions, Stripe webhooks,
+ *       notifications, jobs and audit entries. Each step carries the ID of the
+ *       request or job that made it (`requestId`, also in the X-Request-Id header
+ *       and the logs); `requestIds` lists them all. Bookings older than tracing
+ *       only show their current state. `terms` are the terms the member agreed
+ *       to when their spot was confirmed (null until then), for disputes.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: bookingId
+ *         required: true
+ *         schema:
+ *           type: string
+ *           format: uuid
+ *     responses:
+ *       200:
+ *         description: "{ booking, payments, terms, requestIds, timeline: [{ at, source, type, requestId, data }] }"
+ *       400:
+ *         description: Invalid booking ID
+ *       404:
+ *         description: Booking not found
+ */
+router.get(
+  "/bookings/:bookingId/timeline",
+  authenticate,
+  authorize(["admin"]),
+  adminController.getBookingTimeline
+);
+
+/**
+ * @swagger
+ * /api/admin/push-templates:
+ *   get:
+ *     summary: Push notification templates
+ *     description: >
+ *       One entry per notification type with a template: the placeholders its
+ *       notifications carry and, per language (es, en, pt, ru), the text in use,
+ *       whether an admin overrode it and the built-in text. Pushes are rendered
+ *       when sent, in the recipient's language (falling back to Spanish, then to
+ *       the text stored with the notification) and time zone.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: "[{ type, placeholders, languages: { es: { title, body, overridden, builtIn, updatedAt } } }]"
+ *       403:
+ *         description: Admin role required
+ */
+router.get("/push-templates", authenticate, authorize(["admin"]), adminController.listPushTemplates);
+
+/**
+ * @swagger
+ * /api/admin/push-templates/{type}/{language}:
+ *   put:
+ *     summary: Override a push template in a language
+ *     description: >
+ *       Replaces the built-in text from the next push on. Placeholders such as
+ *       {groupName} or {deletesAt:date} must be among those of the template.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: type
+ *         required: true
+ *         schema:
+ *           type: string
+ *           example: JOIN_REQUEST_APPROVED
+ *       - in: path
+ *         name: language
+ *         required: true
+ *         schema:
+ *           type: string
+ *           enum: [es, en, pt, ru]
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [title, body]
+ *             properties:
+ *               title:
+ *                 type: string
+ *                 maxLength: 150
+ *               body:
+ *                 type: string
+ *                 maxLength: 500
+ *     responses:
+ *       200:
+ *         description: Template overridden
+ *       400:
+ *         description: Invalid text, language or placeholders
+ *       404:
+ *         description: No template for the type
+ *   delete:
+ *     summary: Go back to the built-in text of a push template
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: type
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: language
+ *         required: true
+ *         schema:
+ *           type: string
+ *           enum: [es, en, pt, ru]
+ *     responses:
+ *       200:
+ *         description: Template reset
+ *       404:
+ *         description: No template for the type, or no override in the language
+ */
+router.put(
+  "/push-templates/:type/:language",
+  authenticate,
+  authorize(["admin"]),
+  adminController.updatePushTemplate
+);
+router.delete(
+  "/push-templates/:type/:language",
+  authenticate,
+  authorize(["admin"]),
+  adminController.resetPushTemplate
+);
+
+export default router;
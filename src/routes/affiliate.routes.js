@@ -0,0 +1,31 @@
+import { Router } from "express";
+import { authenticate } from "../middleware/auth.middleware.js";
+import affiliateController from "../controllers/affiliate.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/affiliates/me/stats:
+ *   get:
+ *     summary: Stats of my affiliate partner account
+ *     description: Signups, first bookings and commission per month for the partner linked to the user.
+ *     tags: [Affiliates]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: months
+ *         schema:
+ *           type: integer
+ *           default: 12
+ *           maximum: 24
+ *     responses:
+ *       200:
+ *         description: "{ partner, months: [{ month, signups, confirmedSignups, firstBookings, bookingVolume, commission, currency }] }"
+ *       404:
+ *         description: The account is not linked to a partner
+ */
+router.get("/me/stats", authenticate, affiliateController.getMyStats);
+
+export default router;
@@ -0,0 +1,125 @@
+import { Router } from "express";
+import { authenticate, authorize } from "../middleware/auth.middleware.js";
+import affiliateController from "../controllers/affiliate.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * tags:
+ *   name: Affiliates
+ *   description: >
+ *     Partners that bring users in with an affiliate code. Signups are attributed at
+ *     registration (affiliateCode, or utm.source matching a partner code) and the
+ *     first paid booking of each attributed user earns the partner a commission.
+ */
+
+/**
+ * @swagger
+ * /api/admin/affiliates:
+ *   post:
+ *     summary: Create an affiliate partner
+ *     tags: [Affiliates]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required:
+ *               - name
+ *               - code
+ *             properties:
+ *               name:
+ *                 type: string
+ *               code:
+ *                 type: string
+ *                 example: MOCHILEROS
+ *                 description: 3-40 letters, digits, - or _; stored uppercase and cannot change
+ *               userId:
+ *                 type: string
+ *                 description: Account the partner uses to see their stats
+ *               contactEmail:
+ *                 type: string
+ *               commissionRate:
+ *                 type: number
+ *                 example: 0.05
+ *                 description: Fraction of the first booking
+ *               signupBounty:
+ *                 type: integer
+ *                 description: Cents per attributed signup with a confirmed email
+ *               currency:
+ *                 type: string
+ *                 default: USD
+ *     responses:
+ *       201:
+ *         description: Partner created
+ *       400:
+ *         description: Invalid fields
+ *       409:
+ *         description: Code or user already taken
+ *   get:
+ *     summary: List affiliate partners
+ *     tags: [Affiliates]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: Partners, newest first
+ */
+router.post("/", authenticate, authorize(["admin"]), affiliateController.createPartner);
+router.get("/", authenticate, authorize(["admin"]), affiliateController.listPartners);
+
+/**
+ * @swagger
+ * /api/admin/affiliates/report:
+ *   get:
+ *     summary: Monthly commission report
+ *     description: >
+ *       Signups and first bookings of every partner in the month. Commission is the
+ *       signup bounty per confirmed signup plus the rate on each first booking,
+ *       in the partner currency. Refunded bookings earn nothing.
+ *     tags: [Affiliates]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: month
+ *         description: YYYY-MM, defaults to the previous month
+ *         schema:
+ *           type: string
+ *           example: "2026-09"
+ *     responses:
+ *       200:
+ *         description: "{ month, partners: [{ partner, signups, confirmedSignups, firstBookings, bookingVolume, commission, currency }] }"
+ *       400:
+ *         description: Invalid month
+ */
+router.get("/report", authenticate, authorize(["admin"]), affiliateController.getMonthlyReport);
+
+/**
+ * @swagger
+ * /api/admin/affiliates/{partnerId}:
+ *   patch:
+ *     summary: Update an affiliate partner
+ *     description: Any of name, userId, contactEmail, commissionRate, signupBounty, currency, active. The code cannot change.
+ *     tags: [Affiliates]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: partnerId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Partner updated
+ *       404:
+ *         description: Partner not found
+ */
+router.patch("/:partnerId", authenticate, authorize(["admin"]), affiliateController.updatePartner);
+
+export default router;
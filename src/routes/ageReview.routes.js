@@ -0,0 +1,119 @@
+import { Router } from "express";
+import { authenticate, authorize, requireTwoFactor } from "../middleware/auth.middleware.js";
+import ageReviewController from "../controllers/ageReview.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * tags:
+ *   name: Age Reviews
+ *   description: >
+ *     Accounts suspected to be under the minimum age of their jurisdiction. Users
+ *     report them (or the declared age triggers a review) and an administrator
+ *     clears the account or restricts it: restricted accounts cannot create trips,
+ *     join groups or send direct messages.
+ */
+
+/**
+ * @swagger
+ * /api/users/{userId}/age-report:
+ *   post:
+ *     summary: Report an account as possibly underage
+ *     tags: [Age Reviews]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: userId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             properties:
+ *               reason:
+ *                 type: string
+ *                 maxLength: 1000
+ *     responses:
+ *       202:
+ *         description: Report received
+ *       400:
+ *         description: Invalid report (e.g. reporting yourself)
+ *       404:
+ *         description: User not found
+ */
+router.post("/users/:userId/age-report", authenticate, ageReviewController.reportUser);
+
+/**
+ * @swagger
+ * /api/admin/age-reviews:
+ *   get:
+ *     summary: List age reviews, oldest first
+ *     tags: [Age Reviews]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: status
+ *         schema:
+ *           type: string
+ *           enum: [open, cleared, restricted]
+ *           default: open
+ *     responses:
+ *       200:
+ *         description: Reviews with the account's date of birth, age and country
+ *       403:
+ *         description: Admin role required
+ */
+router.get("/admin/age-reviews", authenticate, authorize(["admin"]), requireTwoFactor, ageReviewController.getReviews);
+
+/**
+ * @swagger
+ * /api/admin/age-reviews/{id}/resolve:
+ *   post:
+ *     summary: Resolve an age review
+ *     tags: [Age Reviews]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required:
+ *               - decision
+ *             properties:
+ *               decision:
+ *                 type: string
+ *                 enum: [cleared, restricted]
+ *                 description: "`cleared` lifts any restriction, `restricted` limits the account's features"
+ *               notes:
+ *                 type: string
+ *     responses:
+ *       200:
+ *         description: Review resolved
+ *       404:
+ *         description: Review not found
+ *       409:
+ *         description: Review already resolved
+ */
+router.post(
+  "/admin/age-reviews/:id/resolve",
+  authenticate,
+  authorize(["admin"]),
+  requireTwoFactor,
+  ageReviewController.resolveReview
+);
+
+export default router;
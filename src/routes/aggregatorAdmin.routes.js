@@ -0,0 +1,151 @@
+import { Router } from "express";
+import { authenticate, authorize } from "../middleware/auth.middleware.js";
+import aggregatorController from "../controllers/aggregator.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/admin/aggregators:
+ *   post:
+ *     summary: Approve an aggregator
+ *     description: Creates the aggregator and returns its API key. The key is not stored and cannot be shown again.
+ *     tags: [Availability Feed]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required:
+ *               - name
+ *             properties:
+ *               name:
+ *                 type: string
+ *               contactEmail:
+ *                 type: string
+ *               dailyQuota:
+ *                 type: integer
+ *                 description: Feed requests per UTC day (defaults to AVAILABILITY_FEED_DAILY_QUOTA)
+ *     responses:
+ *       201:
+ *         description: "{ partner, apiKey }"
+ *       400:
+ *         description: Invalid fields
+ *   get:
+ *     summary: List aggregators
+ *     tags: [Availability Feed]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: Aggregators, newest first
+ */
+router.post("/", authenticate, authorize(["admin"]), aggregatorController.createPartner);
+router.get("/", authenticate, authorize(["admin"]), aggregatorController.listPartners);
+
+/**
+ * @swagger
+ * /api/admin/aggregators/{partnerId}:
+ *   get:
+ *     summary: Aggregator details with its requests per day (last 30 days)
+ *     tags: [Availability Feed]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: partnerId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Aggregator with usage [{ day, requests }]
+ *       404:
+ *         description: Aggregator not found
+ *   patch:
+ *     summary: Update an aggregator
+ *     description: >
+ *       Any of name, contactEmail, dailyQuota, dailyCostQuota, pricePerThousandUnits
+ *       (null for no cost limit or the default price) and active (false revokes access).
+ *     tags: [Availability Feed]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: partnerId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Aggregator updated
+ *       404:
+ *         description: Aggregator not found
+ */
+router.get("/:partnerId", authenticate, authorize(["admin"]), aggregatorController.getPartner);
+router.patch("/:partnerId", authenticate, authorize(["admin"]), aggregatorController.updatePartner);
+
+/**
+ * @swagger
+ * /api/admin/aggregators/{partnerId}/rotate-key:
+ *   post:
+ *     summary: Rotate the API key of an aggregator
+ *     description: Returns a new key; the current one stops working immediately.
+ *     tags: [Availability Feed]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: partnerId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: "{ partner, apiKey }"
+ *       404:
+ *         description: Aggregator not found
+ */
+router.post(
+  "/:partnerId/rotate-key",
+  authenticate,
+  authorize(["admin"]),
+  aggregatorController.rotateKey
+);
+
+/**
+ * @swagger
+ * /api/admin/aggregators/{partnerId}/billing:
+ *   get:
+ *     summary: Monthly billing summary of an aggregator
+ *     description: >
+ *       Cost units per day of a calendar month (UTC) and the amount at the
+ *       aggregator's price per thousand units (USAGE_PRICE_PER_THOUSAND_UNITS if unset).
+ *     tags: [Availability Feed]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: partnerId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: query
+ *         name: month
+ *         description: YYYY-MM, the current month by default
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: "{ month, days, totals, pricePerThousandUnits, currency, amount }"
+ *       400:
+ *         description: Invalid month
+ *       404:
+ *         description: Aggregator not found
+ */
+router.get("/:partnerId/billing", authenticate, authorize(["admin"]), aggregatorController.getBilling);
+
+export default router;
@@ -0,0 +1,101 @@
+import { Router } from "express";
+import { authenticate, authorize } from "../middleware/auth.middleware.js";
+import analyticsController from "../controllers/analytics.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * tags:
+ *   name: Admin Analytics
+ *   description: Aggregated, k-anonymized metrics for administrators
+ */
+
+/**
+ * @swagger
+ * /api/admin/analytics:
+ *   get:
+ *     summary: List available aggregated metrics
+ *     tags: [Admin Analytics]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: Metric catalog
+ *       401:
+ *         description: Unauthorized
+ *       403:
+ *         description: Admin role required
+ */
+router.get("/", authenticate, authorize(["admin"]), analyticsController.listMetrics);
+
+/**
+ * @swagger
+ * /api/admin/analytics/metrics/{name}:
+ *   get:
+ *     summary: Get an aggregated metric
+ *     description: >
+ *       Cohorts with fewer than `k` users are suppressed (and merged into a
+ *       `suppressed` cohort only when that merged cohort itself reaches `k`).
+ *       The threshold comes from ANALYTICS_K_THRESHOLD and can only be raised per request.
+ *     tags: [Admin Analytics]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: name
+ *         required: true
+ *         schema:
+ *           type: string
+ *           enum: [usersByAgeBracket, usersByLevel, signupsByMonth, groupsBySize, reviewersByCity]
+ *       - in: query
+ *         name: k
+ *         schema:
+ *           type: integer
+ *         description: Stricter k-anonymity threshold for this request
+ *     responses:
+ *       200:
+ *         description: Metric with small cohorts suppressed
+ *       403:
+ *         description: Admin role required
+ *       404:
+ *         description: Metric not found
+ */
+router.get(
+  "/metrics/:name",
+  authenticate,
+  authorize(["admin"]),
+  analyticsController.getMetric
+);
+
+/**
+ * @swagger
+ * /api/admin/analytics/export:
+ *   get:
+ *     summary: Export every k-anonymized metric for the data warehouse
+ *     tags: [Admin Analytics]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: format
+ *         schema:
+ *           type: string
+ *           enum: [json, csv]
+ *           default: json
+ *     responses:
+ *       200:
+ *         description: Export file
+ *       400:
+ *         description: Invalid format
+ *       403:
+ *         description: Admin role required
+ */
+router.get(
+  "/export",
+  authenticate,
+  authorize(["admin"]),
+  analyticsController.exportMetrics
+);
+
+export default router;
@@ -0,0 +1,94 @@
+import { Router } from "express";
+import rateLimit from "express-rate-limit";
+import { optionalAuthenticate } from "../middleware/auth.middleware.js";
+import analyticsEventController from "../controllers/analyticsEvent.controller.js";
+
+const router = Router();
+
+// Apps send a batch every few seconds at most; this only stops floods
+const eventsLimiter = rateLimit({
+  windowMs: 60 * 1000,
+  max: 60,
+  message: {
+    success: false,
+    message: "Demasiados pedidos, prueba de nuevo en un minuto.",
+    errorCode: "RATE_LIMITED",
+  },
+  standardHeaders: true,
+  legacyHeaders: false,
+});
+
+/**
+ * @swagger
+ * tags:
+ *   name: Analytics Events
+ *   description: Product analytics events sent by the apps
+ */
+
+/**
+ * @swagger
+ * /api/events:
+ *   post:
+ *     summary: Send a batch of product analytics events
+ *     description: >
+ *       For the apps' product analytics (screen views, trip interactions).
+ *       Events are checked one by one against the schema of their name:
+ *       invalid ones are listed in `rejected` with their index and errors, the
+ *       rest are accepted and written to the warehouse within seconds
+ *       (ANALYTICS_EVENTS_SINK). occurredAt must be within the last 7 days.
+ *       Works without a session; with one, events carry the user ID.
+ *       Event names and their properties:
+ *       - screen_view: screen (required), previousScreen, durationMs
+ *       - trip_view: groupId (required), source
+ *       - trip_share: groupId (required), channel
+ *       - trip_join_click: groupId (required), source
+ *       - trip_save: groupId (required), saved (required)
+ *       - search_performed: query, resultCount (required)
+ *       - recommendation_click: groupId (required), position (required)
+ *     tags: [Analytics Events]
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [events]
+ *             properties:
+ *               events:
+ *                 type: array
+ *                 maxItems: 100
+ *                 items:
+ *                   type: object
+ *                   required: [name, occurredAt]
+ *                   properties:
+ *                     name:
+ *                       type: string
+ *                       enum: [screen_view, trip_view, trip_share, trip_join_click, trip_save, search_performed, recommendation_click]
+ *                     occurredAt:
+ *                       type: string
+ *                       format: date-time
+ *                     sessionId:
+ *                       type: string
+ *                       maxLength: 64
+ *                     properties:
+ *                       type: object
+ *               context:
+ *                 type: object
+ *                 properties:
+ *                   platform:
+ *                     type: string
+ *                     enum: [ios, android, web]
+ *                   appVersion:
+ *                     type: string
+ *                     maxLength: 20
+ *     responses:
+ *       202:
+ *         description: "{ accepted, rejected: [{ index, errors }] }"
+ *       400:
+ *         description: Malformed batch (no events, too many, or an invalid context)
+ *       429:
+ *         description: Too many batches
+ */
+router.post("/", eventsLimiter, optionalAuthenticate, analyticsEventController.ingestEvents);
+
+export default router;
@@ -0,0 +1,221 @@
+import { Router } from "express";
+import attachmentController from "../controllers/attachment.controller.js";
+import { authenticate } from "../middleware/auth.middleware.js";
+import { uploadAttachment } from "../utils/fileUpload.js";
+import { deadline, keepContext } from "../middleware/requestContext.middleware.js";
+import config from "../config/index.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * tags:
+ *   name: Attachments
+ *   description: >
+ *     Group photos and avatars stored in S3-compatible object storage. Every upload
+ *     is scanned by the antivirus in the background; `scanStatus` is `pending` until
+ *     then, `clean` or `skipped` (no scanner configured) when downloadable and
+ *     `quarantined` when malware was found. Photos are then stripped of their
+ *     EXIF/GPS metadata and get thumbnails; they are only served once
+ *     `processingStatus` is `processed` (`failed` when the photo can't be processed).
+ */
+
+/**
+ * @swagger
+ * /api/attachments/presign:
+ *   post:
+ *     summary: Get a presigned URL to upload a file directly to storage
+ *     description: >
+ *       Creates a pending attachment. Upload the file with a PUT to `upload.url`
+ *       sending `upload.headers`, then call `/api/attachments/{id}/complete`.
+ *     tags: [Attachments]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required:
+ *               - kind
+ *               - contentType
+ *               - size
+ *             properties:
+ *               kind:
+ *                 type: string
+ *                 enum: [avatar, group_photo]
+ *               groupId:
+ *                 type: string
+ *                 description: Required for group_photo
+ *               contentType:
+ *                 type: string
+ *                 enum: [image/jpeg, image/png, image/gif, image/webp]
+ *               size:
+ *                 type: integer
+ *                 description: File size in bytes (avatar max 5MB, group_photo max 15MB)
+ *               filename:
+ *                 type: string
+ *     responses:
+ *       201:
+ *         description: Pending attachment and upload instructions
+ *       400:
+ *         description: Invalid kind, content type or size
+ *       403:
+ *         description: Not a member of the group
+ *       503:
+ *         description: Storage not configured
+ */
+router.post("/presign", authenticate, attachmentController.createPresignedUpload);
+
+/**
+ * @swagger
+ * /api/attachments:
+ *   post:
+ *     summary: Upload a file through the API (multipart proxy upload)
+ *     tags: [Attachments]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         multipart/form-data:
+ *           schema:
+ *             type: object
+ *             required:
+ *               - file
+ *               - kind
+ *             properties:
+ *               file:
+ *                 type: string
+ *                 format: binary
+ *               kind:
+ *                 type: string
+ *                 enum: [avatar, group_photo]
+ *               groupId:
+ *                 type: string
+ *     responses:
+ *       201:
+ *         description: File uploaded
+ *       400:
+ *         description: Invalid file
+ *       403:
+ *         description: Not a member of the group
+ *   get:
+ *     summary: List the photos of a group (members only)
+ *     tags: [Attachments]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Attachments, with temporary download URLs once they pass the scan
+ *       403:
+ *         description: Not a member of the group
+ */
+router.post(
+  "/",
+  authenticate,
+  deadline(config.requests.longTimeoutMs),
+  keepContext(uploadAttachment.single("file")),
+  attachmentController.uploadFile
+);
+router.get("/", authenticate, attachmentController.getGroupAttachments);
+
+/**
+ * @swagger
+ * /api/attachments/{id}/complete:
+ *   post:
+ *     summary: Confirm a presigned upload
+ *     tags: [Attachments]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: >
+ *           Upload confirmed (avatars update the profile picture once the scan is clean).
+ *           The file is moved out of the upload URL, so uploading again to it has no effect.
+ *       400:
+ *         description: File missing or not matching the declared type/size
+ *       404:
+ *         description: Attachment not found
+ */
+router.post("/:id/complete", authenticate, attachmentController.completeUpload);
+
+/**
+ * @swagger
+ * /api/attachments/{id}/content:
+ *   get:
+ *     summary: Redirect to the content of a public attachment (avatars)
+ *     tags: [Attachments]
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       302:
+ *         description: Redirect to a temporary storage URL
+ *       404:
+ *         description: Attachment not found
+ */
+router.get("/:id/content", attachmentController.getContent);
+
+/**
+ * @swagger
+ * /api/attachments/{id}:
+ *   get:
+ *     summary: Get an attachment with a temporary download URL
+ *     tags: [Attachments]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Attachment
+ *       403:
+ *         description: File quarantined by the antivirus
+ *       404:
+ *         description: Attachment not found
+ *       409:
+ *         description: Scan still in progress
+ *   delete:
+ *     summary: Delete an attachment (uploader or group admin)
+ *     tags: [Attachments]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Attachment deleted
+ *       403:
+ *         description: Not allowed
+ *       404:
+ *         description: Attachment not found
+ */
+router.get("/:id", authenticate, attachmentController.getAttachment);
+router.delete("/:id", authenticate, attachmentController.deleteAttachment);
+
+export default router;
@@ -4,12 +4,20 @@ import {
   getAtus,
   register,
   login,
+  oauthLogin,
+  verifyTwoFactorLogin,
+  getTwoFactorStatus,
+  setupTwoFactor,
+  enableTwoFactor,
+  disableTwoFactor,
+  regenerateBackupCodes,
   confirmEmail,
   refreshToken,
   logout,
   getProfile,
   forgotPassword,
   resetPassword,
+  changePassword,
 } from "../controllers/auth.controller.js";
 import { authenticate } from "../middleware/auth.middleware.js";
 
@@ -28,10 +36,49 @@ const authLimiter = rateLimit({
   legacyHeaders: false,
 });
 
-// Ruta de refresh token
+/**
+ * @swagger
+ * /api/auth/refresh:
+ *   post:
+ *     summary: Refresh the access token
+ *     description: >
+ *       The refresh token can be used once; use the new one from the response.
+ *       Presenting an already used refresh token logs that device out.
+ *     tags: [Authentication]
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required:
+ *               - refreshToken
+ *             properties:
+ *               refreshToken:
+ *                 type: string
+ *     responses:
+ *       200:
+ *         description: New token pair ({ accessToken, refreshToken })
+ *       400:
+ *         description: Refresh token missing
+ *       401:
+ *         description: Invalid, expired or already used refresh token, a token issued without a session (log in again), or the session was revoked
+ */
 router.post("/refresh", refreshToken);
 
-// Ruta de logout
+/**
+ * @swagger
+ * /api/auth/logout:
+ *   post:
+ *     summary: Log out
+ *     description: Revokes the access token sent in the Authorization header, if any, and ends its session.
+ *     tags: [Authentication]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: Logged out
+ */
 router.post("/logout", logout);
 
 /**
@@ -72,6 +119,7 @@ router.get("/", authenticate, getProfile);
  *             required:
  *               - email
  *               - password
+ *               - dateOfBirth
  *             properties:
  *               email:
  *                 type: string
@@ -82,6 +130,33 @@ router.get("/", authenticate, getProfile);
  *                 format: password
  *                 minLength: 8
  *                 example: Password123!
+ *               dateOfBirth:
+ *                 type: string
+ *                 format: date
+ *                 example: "1995-04-21"
+ *               country:
+ *                 type: string
+ *                 example: AR
+ *                 description: >
+ *                   ISO 3166-1 alpha-2 code selecting the age rules (AGE_RULES). Under the
+ *                   minimum age sign-up is rejected, or accepted with restricted features
+ *                   where the jurisdiction allows it.
+ *               affiliateCode:
+ *                 type: string
+ *                 example: MOCHILEROS
+ *                 description: Code of the affiliate partner that referred the user
+ *               utm:
+ *                 type: object
+ *                 description: >
+ *                   UTM parameters of the landing page. When no affiliateCode is sent the
+ *                   signup is attributed to the partner whose code matches utm.source.
+ *                 properties:
+ *                   source:
+ *                     type: string
+ *                   medium:
+ *                     type: string
+ *                   campaign:
+ *                     type: string
  *     responses:
  *       201:
  *         description: User registered successfully
@@ -104,6 +179,8 @@ router.get("/", authenticate, getProfile);
  *           application/json:
  *             schema:
  *               $ref: '#/components/schemas/Error'
+ *       403:
+ *         description: Under the minimum age of the jurisdiction (UNDERAGE)
  *       409:
  *         description: User already exists
  *         content:
@@ -137,6 +214,10 @@ router.post("/register", authLimiter, register);
  *                 type: string
  *                 format: password
  *                 example: Password123!
+ *               deviceName:
+ *                 type: string
+ *                 description: Shown in the session list (X-Device-Name header also accepted)
+ *                 example: iPhone de Ana
  *     responses:
  *       200:
  *         description: Login successful
@@ -153,6 +234,9 @@ router.post("/register", authLimiter, register);
  *                   example: "Login exitoso."
  *                 data:
  *                   type: object
+ *                   description: >
+ *                     Token pair, or `{ twoFactorRequired: true, challengeToken }`
+ *                     for accounts with two-factor authentication (see /api/auth/2fa/verify)
  *                   properties:
  *                     token:
  *                       type: string
@@ -179,6 +263,222 @@ router.post("/register", authLimiter, register);
  */
 router.post("/login", authLimiter, login);
 
+/**
+ * @swagger
+ * /api/auth/2fa/verify:
+ *   post:
+ *     summary: Second login step for accounts with two-factor authentication
+ *     description: >
+ *       Login (password or provider) answers `{ twoFactorRequired: true, challengeToken }`
+ *       for these accounts. The challenge plus a code of the authenticator app
+ *       (or an unused backup code) returns the usual token pair.
+ *     tags: [Authentication]
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [challengeToken, code]
+ *             properties:
+ *               challengeToken:
+ *                 type: string
+ *               code:
+ *                 type: string
+ *                 example: "123456"
+ *     responses:
+ *       200:
+ *         description: Logged in ({ user, accessToken, refreshToken })
+ *       400:
+ *         description: challengeToken or code missing
+ *       401:
+ *         description: Invalid code or expired challenge
+ */
+router.post("/2fa/verify", authLimiter, verifyTwoFactorLogin);
+
+/**
+ * @swagger
+ * /api/auth/2fa:
+ *   get:
+ *     summary: Two-factor authentication status of the current user
+ *     tags: [Authentication]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: "{ enabled, enabledAt, backupCodesLeft, required }"
+ */
+router.get("/2fa", authenticate, getTwoFactorStatus);
+
+/**
+ * @swagger
+ * /api/auth/2fa/setup:
+ *   post:
+ *     summary: Start two-factor enrollment
+ *     description: >
+ *       Returns a new secret and its otpauth:// URI to show as a QR code. It
+ *       is not active until confirmed with /api/auth/2fa/enable.
+ *     tags: [Authentication]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: "{ secret, otpauthUrl }"
+ *       409:
+ *         description: Two-factor authentication already enabled
+ *       503:
+ *         description: Two-factor authentication not configured on the server
+ */
+router.post("/2fa/setup", authenticate, setupTwoFactor);
+
+/**
+ * @swagger
+ * /api/auth/2fa/enable:
+ *   post:
+ *     summary: Confirm enrollment with a code and turn two-factor authentication on
+ *     description: Returns the backup codes; they are not shown again.
+ *     tags: [Authentication]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [code]
+ *             properties:
+ *               code:
+ *                 type: string
+ *     responses:
+ *       200:
+ *         description: "Enabled ({ backupCodes })"
+ *       400:
+ *         description: Invalid code or enrollment not started
+ *       409:
+ *         description: Already enabled
+ */
+router.post("/2fa/enable", authenticate, authLimiter, enableTwoFactor);
+
+/**
+ * @swagger
+ * /api/auth/2fa/disable:
+ *   post:
+ *     summary: Turn two-factor authentication off
+ *     tags: [Authentication]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [code]
+ *             properties:
+ *               code:
+ *                 type: string
+ *                 description: Code of the app or a backup code
+ *     responses:
+ *       200:
+ *         description: Disabled
+ *       400:
+ *         description: Invalid code
+ *       409:
+ *         description: Not enabled
+ */
+router.post("/2fa/disable", authenticate, authLimiter, disableTwoFactor);
+
+/**
+ * @swagger
+ * /api/auth/2fa/backup-codes:
+ *   post:
+ *     summary: Replace the backup codes
+ *     tags: [Authentication]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [code]
+ *             properties:
+ *               code:
+ *                 type: string
+ *                 description: Code of the app
+ *     responses:
+ *       200:
+ *         description: "New codes ({ backupCodes })"
+ *       400:
+ *         description: Invalid code
+ *       409:
+ *         description: Not enabled
+ */
+router.post("/2fa/backup-codes", authenticate, authLimiter, regenerateBackupCodes);
+
+/**
+ * @swagger
+ * /api/auth/oauth/{provider}:
+ *   post:
+ *     summary: Login or sign up with Google or Apple
+ *     description: >
+ *       Exchanges the provider's ID token (or a Google access token) for the
+ *       same token pair as password login. The first login links the provider
+ *       to the account with the same verified email, or creates one, which
+ *       needs dateOfBirth (400 DATE_OF_BIRTH_REQUIRED otherwise). Linking an
+ *       account whose email was never confirmed replaces its password with a
+ *       random one and logs its other sessions out.
+ *     tags: [Authentication]
+ *     parameters:
+ *       - in: path
+ *         name: provider
+ *         required: true
+ *         schema:
+ *           type: string
+ *           enum: [google, apple]
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             properties:
+ *               idToken:
+ *                 type: string
+ *               accessToken:
+ *                 type: string
+ *                 description: Google only, when the client has no ID token
+ *               nonce:
+ *                 type: string
+ *                 description: Nonce sent to the provider; must match the ID token
+ *               name:
+ *                 type: string
+ *                 maxLength: 30
+ *               dateOfBirth:
+ *                 type: string
+ *                 format: date
+ *                 description: Required when the login creates the account
+ *               country:
+ *                 type: string
+ *                 example: AR
+ *     responses:
+ *       200:
+ *         description: Logged in ({ user, accessToken, refreshToken, isNewUser })
+ *       201:
+ *         description: Account created and logged in
+ *       400:
+ *         description: Token missing, or dateOfBirth needed to create the account
+ *       401:
+ *         description: Invalid provider token
+ *       403:
+ *         description: Email not verified by the provider, underage or suspended account
+ *       404:
+ *         description: Provider not available
+ */
+router.post("/oauth/:provider", authLimiter, oauthLogin);
+
 /**
  * @swagger
  * /api/auth/confirm-email/{token}:
@@ -305,4 +605,38 @@ router.post("/forgot-password", authLimiter, forgotPassword);
  */
 router.post("/reset-password", authLimiter, resetPassword);
 
+/**
+ * @swagger
+ * /api/auth/change-password:
+ *   post:
+ *     summary: Change the password of the authenticated user
+ *     description: The other sessions of the user are closed; the current one stays open.
+ *     tags: [Authentication]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [currentPassword, newPassword]
+ *             properties:
+ *               currentPassword:
+ *                 type: string
+ *                 format: password
+ *               newPassword:
+ *                 type: string
+ *                 format: password
+ *                 minLength: 8
+ *     responses:
+ *       200:
+ *         description: Password changed
+ *       400:
+ *         description: Wrong current password or new password not meeting the requirements
+ *       401:
+ *         description: Unauthorized
+ */
+router.post("/change-password", authenticate, authLimiter, changePassword);
+
 export default router;
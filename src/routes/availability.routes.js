@@ -0,0 +1,116 @@
+import { Router } from "express";
+import rateLimit from "express-rate-limit";
+import availabilityController from "../controllers/availability.controller.js";
+import usageController from "../controllers/usage.controller.js";
+import { authenticateAggregator, enforceAggregatorQuota } from "../middleware/aggregator.middleware.js";
+import config from "../config/index.js";
+
+const router = Router();
+
+// Burst limit per aggregator, on top of its daily quota
+const aggregatorLimiter = rateLimit({
+  windowMs: 60 * 1000,
+  max: config.availabilityFeed.requestsPerMinute,
+  keyGenerator: (req) => req.aggregator.id,
+  message: {
+    success: false,
+    message: "Demasiados pedidos, prueba de nuevo en un minuto.",
+    errorCode: "RATE_LIMITED",
+  },
+  standardHeaders: true,
+  legacyHeaders: false,
+});
+
+/**
+ * @swagger
+ * tags:
+ *   name: Availability Feed
+ *   description: >
+ *     Trip availability for approved aggregators, authenticated with an API key
+ *     (X-Api-Key). Each aggregator has a daily quota (X-RateLimit-* headers, 429
+ *     QUOTA_EXCEEDED once spent) and a per-minute burst limit.
+ */
+
+/**
+ * @swagger
+ * /api/availability/trips:
+ *   get:
+ *     summary: Trip availability feed
+ *     description: >
+ *       Without a cursor, returns the open and full public trips that have not
+ *       started. With the cursor of the previous response (or `since`), returns
+ *       every change after it, including trips that were closed (unpublished,
+ *       deleted or started), which only carry tripId, status and changedAt.
+ *       Keep calling with the returned cursor while hasMore is true, then poll
+ *       with it. Send If-None-Match / If-Modified-Since to get a 304 when
+ *       nothing changed. Data is refreshed about once a minute.
+ *     tags: [Availability Feed]
+ *     security:
+ *       - aggregatorApiKey: []
+ *     parameters:
+ *       - in: query
+ *         name: cursor
+ *         description: Opaque cursor from the previous response
+ *         schema:
+ *           type: string
+ *       - in: query
+ *         name: since
+ *         description: ISO 8601 date-time; changes after it (ignored when cursor is sent)
+ *         schema:
+ *           type: string
+ *           format: date-time
+ *       - in: query
+ *         name: limit
+ *         schema:
+ *           type: integer
+ *           default: 200
+ *           maximum: 1000
+ *     responses:
+ *       200:
+ *         description: >
+ *           { trips: [{ tripId, status (open|full|closed), name, destination: { name, lat, lng },
+ *           startDate, endDate, capacity, spotsLeft, price: { amount (cents), currency }, url,
+ *           changedAt }], cursor, hasMore }
+ *       304:
+ *         description: Nothing changed since the ETag / Last-Modified sent
+ *       400:
+ *         description: Invalid cursor or since
+ *       401:
+ *         description: Missing, unknown or revoked API key
+ *       429:
+ *         description: Daily quota (QUOTA_EXCEEDED) or burst limit exceeded
+ */
+router.get(
+  "/trips",
+  authenticateAggregator,
+  aggregatorLimiter,
+  enforceAggregatorQuota,
+  availabilityController.getFeed
+);
+
+/**
+ * @swagger
+ * /api/availability/usage:
+ *   get:
+ *     summary: Requests and cost of the calling aggregator per day
+ *     description: >
+ *       Does not count against the daily request quota. quota is null when the
+ *       aggregator has no daily cost limit.
+ *     tags: [Availability Feed]
+ *     security:
+ *       - aggregatorApiKey: []
+ *     parameters:
+ *       - in: query
+ *         name: days
+ *         schema:
+ *           type: integer
+ *           default: 30
+ *     responses:
+ *       200:
+ *         description: "{ days: [{ day, requests, costUnits, errors }], totals, quota }"
+ *       401:
+ *         description: Invalid or unapproved API key
+ */
+router.get("/usage", authenticateAggregator, aggregatorLimiter, usageController.getAggregatorUsage);
+
+export default router;
@@ -0,0 +1,71 @@
+import { Router } from "express";
+import bookingTermsController from "../controllers/bookingTerms.controller.js";
+import { authenticate } from "../middleware/auth.middleware.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * tags:
+ *   name: Booking Terms
+ *   description: >
+ *     What each member agreed to when they booked (made their join request or
+ *     accepted an invite) or were added by the organizer: deposit, cancellation
+ *     policy, itinerary and code of conduct as they were then. Later edits of
+ *     the trip do not change them. `intact` is false if the stored terms no
+ *     longer match their hash.
+ */
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/booking-terms:
+ *   get:
+ *     summary: Terms you agreed to when booking a trip
+ *     description: One entry per booking, latest first (rejoining makes a new booking).
+ *     tags: [Booking Terms]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: "[{ bookingId, groupId, userId, agreedAt, terms: { trip, price, cancellationPolicy, rules, itinerary }, contentHash, intact }]"
+ *       404:
+ *         description: No booking terms (joined before they were recorded)
+ */
+router.get("/:groupId/booking-terms", authenticate, bookingTermsController.getMyTerms);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/booking-terms/{userId}:
+ *   get:
+ *     summary: Terms a member agreed to (organizer only)
+ *     tags: [Booking Terms]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: userId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Same shape as your own booking terms
+ *       403:
+ *         description: Not the organizer
+ *       404:
+ *         description: Group not found or no booking terms for that member
+ */
+router.get("/:groupId/booking-terms/:userId", authenticate, bookingTermsController.getMemberTerms);
+
+export default router;
@@ -0,0 +1,103 @@
+import { Router } from "express";
+import { authenticate } from "../middleware/auth.middleware.js";
+import calendarController from "../controllers/calendar.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * tags:
+ *   name: Calendar
+ *   description: >
+ *     iCalendar exports of trips. A trip is an all-day event over its dates and
+ *     each itinerary item an event on its day, at its local time when it has
+ *     one (floating time, so it shows at that hour wherever the phone is).
+ */
+
+/**
+ * @swagger
+ * /api/trips/{id}/calendar.ics:
+ *   get:
+ *     summary: iCalendar file of a trip
+ *     description: For members, or anyone when the trip is public.
+ *     tags: [Calendar]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         description: Group ID
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: text/calendar attachment
+ *       403:
+ *         description: Private trip you are not a member of
+ *       404:
+ *         description: Group not found
+ */
+router.get("/trips/:id/calendar.ics", authenticate, calendarController.getTripCalendar);
+
+/**
+ * @swagger
+ * /api/calendar/feed/{token}:
+ *   get:
+ *     summary: Calendar feed of a user's trips
+ *     description: >
+ *       The URL returned by POST /api/me/calendar-feed, for calendar apps to
+ *       subscribe to; the token is the only credential. Current and upcoming
+ *       trips are included, and those that ended in the last 90 days.
+ *     tags: [Calendar]
+ *     parameters:
+ *       - in: path
+ *         name: token
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: text/calendar
+ *       404:
+ *         description: Unknown or rotated token
+ */
+router.get("/calendar/feed/:token", calendarController.getFeed);
+
+/**
+ * @swagger
+ * /api/me/calendar-feed:
+ *   get:
+ *     summary: Whether you have a calendar feed
+ *     description: The URL is only shown when created.
+ *     tags: [Calendar]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: "{ enabled, tokenPrefix, createdAt, lastFetchedAt }"
+ *   post:
+ *     summary: Create your calendar feed, or give it a new URL
+ *     description: The previous URL stops working. Keep the URL private.
+ *     tags: [Calendar]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       201:
+ *         description: "{ url, webcalUrl, tokenPrefix, createdAt }"
+ *   delete:
+ *     summary: Delete your calendar feed
+ *     tags: [Calendar]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: Feed deleted
+ *       404:
+ *         description: No feed
+ */
+router.get("/me/calendar-feed", authenticate, calendarController.getFeedInfo);
+router.post("/me/calendar-feed", authenticate, calendarController.createFeed);
+router.delete("/me/calendar-feed", authenticate, calendarController.deleteFeed);
+
+export default router;
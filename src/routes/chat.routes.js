@@ -9,6 +9,7 @@ import {
   deleteAllChatHistory,
 } from "../controllers/chat.controller.js";
 import { authenticate } from "../middleware/auth.middleware.js";
+import { requireFeature } from "../middleware/policy.middleware.js";
 
 const router = Router();
 
@@ -83,7 +84,7 @@ router.use(authenticate);
  *             schema:
  *               $ref: '#/components/schemas/Error'
  */
-router.post("/messages", chatLimiter, sendMessage);
+router.post("/messages", requireFeature("aiChat"), chatLimiter, sendMessage);
 
 /**
  * @swagger
@@ -0,0 +1,40 @@
+import { Router } from "express";
+import { optionalAuthenticate } from "../middleware/auth.middleware.js";
+import clientConfigController from "../controllers/clientConfig.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/client-config:
+ *   get:
+ *     summary: Get the features and requirements that apply to the caller
+ *     description: >
+ *       Resolved with FEATURE_POLICIES from the user's country and tenant, or for
+ *       anonymous calls from `country` and the tenant of the custom domain.
+ *       Disabled features answer 403 FEATURE_UNAVAILABLE. With the
+ *       `idVerification` requirement, creating, joining and paying for trips
+ *       answer 403 ID_VERIFICATION_REQUIRED until an admin verifies the user.
+ *       `branding` is the theme of the tenant (logo and colors; `X-Tenant-Id`
+ *       also picks it), or JoinTravel's. `flags` are the
+ *       feature flags for the caller; with a partial rollout they are off
+ *       for anonymous calls.
+ *     tags: [Client Config]
+ *     parameters:
+ *       - in: query
+ *         name: country
+ *         schema:
+ *           type: string
+ *           example: AR
+ *         description: ISO 3166-1 alpha-2 code, ignored for authenticated users
+ *       - in: header
+ *         name: X-Tenant-Id
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: features, requirements, age rule, default currency, branding and flags
+ */
+router.get("/", optionalAuthenticate, clientConfigController.getClientConfig);
+
+export default router;
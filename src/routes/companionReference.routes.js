@@ -0,0 +1,113 @@
+import { Router } from "express";
+import companionReferenceController from "../controllers/companionReference.controller.js";
+import { authenticate } from "../middleware/auth.middleware.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/references:
+ *   post:
+ *     summary: Write a reference about a past co-traveler
+ *     description: >
+ *       Both users must have been members of the trip and the trip must have ended.
+ *       The reference stays pending until the subject confirms they traveled together.
+ *     tags: [References]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required:
+ *               - subjectId
+ *               - groupId
+ *               - content
+ *             properties:
+ *               subjectId:
+ *                 type: string
+ *               groupId:
+ *                 type: string
+ *                 description: Completed trip shared with the subject
+ *               content:
+ *                 type: string
+ *                 maxLength: 500
+ *     responses:
+ *       201:
+ *         description: Reference created, pending confirmation
+ *       400:
+ *         description: Invalid input or no shared completed trip
+ *       409:
+ *         description: Reference already written for this trip
+ */
+router.post("/", authenticate, companionReferenceController.createReference);
+
+/**
+ * @swagger
+ * /api/references/pending:
+ *   get:
+ *     summary: References about me waiting for confirmation
+ *     tags: [References]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: Pending references
+ */
+router.get("/pending", authenticate, companionReferenceController.getPendingReferences);
+
+/**
+ * @swagger
+ * /api/references/{id}/confirm:
+ *   post:
+ *     summary: Confirm having traveled with the author of a reference
+ *     tags: [References]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Reference confirmed and shown on the profile
+ *       403:
+ *         description: Not the subject of the reference
+ *       404:
+ *         description: Reference not found
+ *       409:
+ *         description: Reference already answered
+ */
+router.post("/:id/confirm", authenticate, companionReferenceController.confirmReference);
+
+/**
+ * @swagger
+ * /api/references/{id}/decline:
+ *   post:
+ *     summary: Decline a reference
+ *     tags: [References]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Reference declined, it is never shown
+ *       403:
+ *         description: Not the subject of the reference
+ *       404:
+ *         description: Reference not found
+ *       409:
+ *         description: Reference already answered
+ */
+router.post("/:id/decline", authenticate, companionReferenceController.declineReference);
+
+export default router;
@@ -1,22 +1,38 @@
 import { Router } from "express";
 import cronService from "../services/cron.service.js";
 import logger from "../config/logger.js";
+import config from "../config/index.js";
+import { deadline } from "../middleware/requestContext.middleware.js";
 
 const router = Router();
 
+// Tasks run inside the request and can take minutes
+router.use(deadline(config.requests.longTimeoutMs));
+
 /**
- * POST /api/cron/daily-maintenance
- * Manually trigger daily maintenance tasks (for testing/admin purposes)
- * In production, this should be called by a cron job scheduler
+ * @swagger
+ * /api/cron/daily-maintenance:
+ *   post:
+ *     summary: Run the daily maintenance tasks
+ *     description: Called daily by the external scheduler.
+ *     tags: [Cron]
+ *     security:
+ *       - schedulerSecret: []
+ *     responses:
+ *       200:
+ *         description: Task completed
+ *       401:
+ *         description: Missing or invalid CRON_SECRET
+ *       500:
+ *         description: Task failed
  */
 router.post("/daily-maintenance", async (req, res, next) => {
-  // In production, add authentication and admin-only access
-  // For now, allowing unauthenticated access for testing
-
   logger.info("Manual daily maintenance triggered");
 
   try {
-    const result = await cronService.runDailyMaintenance();
+    const result = await cronService.runTask("daily-maintenance", () =>
+      cronService.runDailyMaintenance()
+    );
 
     logger.info("Manual daily maintenance completed", result);
     res.status(200).json({
@@ -36,14 +52,28 @@ router.post("/daily-maintenance", async (req, res, next) => {
 });
 
 /**
- * POST /api/cron/recalculate-stats
- * Manually trigger user stats recalculation
+ * @swagger
+ * /api/cron/recalculate-stats:
+ *   post:
+ *     summary: Recalculate the user stats
+ *     tags: [Cron]
+ *     security:
+ *       - schedulerSecret: []
+ *     responses:
+ *       200:
+ *         description: Task completed
+ *       401:
+ *         description: Missing or invalid CRON_SECRET
+ *       500:
+ *         description: Task failed
  */
 router.post("/recalculate-stats", async (req, res, next) => {
   logger.info("Manual stats recalculation triggered");
 
   try {
-    const result = await cronService.recalculateAllUserStats();
+    const result = await cronService.runTask("recalculate-stats", () =>
+      cronService.recalculateAllUserStats()
+    );
 
     logger.info("Manual stats recalculation completed", result);
     res.status(200).json({
@@ -62,4 +92,170 @@ router.post("/recalculate-stats", async (req, res, next) => {
   }
 });
 
-export default router;
\ No newline at end of file
+/**
+ * @swagger
+ * /api/cron/monthly-recommendations:
+ *   post:
+ *     summary: Queue the monthly recommendation emails
+ *     description: Queues one background job per confirmed user; each job ranks the open trips for the user and sends the email.
+ *     tags: [Cron]
+ *     security:
+ *       - schedulerSecret: []
+ *     responses:
+ *       200:
+ *         description: Task completed
+ *       401:
+ *         description: Missing or invalid CRON_SECRET
+ *       500:
+ *         description: Task failed
+ */
+router.post("/monthly-recommendations", async (req, res, next) => {
+  logger.info("Manual monthly recommendations triggered");
+
+  try {
+    const result = await cronService.runTask("monthly-recommendations", () =>
+      cronService.runMonthlyRecommendations()
+    );
+
+    logger.info("Manual monthly recommendations completed", result);
+    res.status(200).json({
+      success: true,
+      message: "Monthly recommendations queued successfully",
+      data: result,
+    });
+  } catch (err) {
+    logger.error("Manual monthly recommendations failed:", err.message);
+
+    res.status(500).json({
+      success: false,
+      message: "Monthly recommendations failed",
+      error: err.message,
+    });
+  }
+});
+
+/**
+ * @swagger
+ * /api/cron/search-reindex:
+ *   post:
+ *     summary: Rebuild the search index
+ *     tags: [Cron]
+ *     security:
+ *       - schedulerSecret: []
+ *     responses:
+ *       200:
+ *         description: Task completed
+ *       401:
+ *         description: Missing or invalid CRON_SECRET
+ *       500:
+ *         description: Task failed
+ */
+router.post("/search-reindex", async (req, res, next) => {
+  logger.info("Manual search reindex triggered");
+
+  try {
+    const result = await cronService.runTask("search-reindex", () =>
+      cronService.runSearchReindex()
+    );
+
+    logger.info("Manual search reindex completed", result);
+    res.status(200).json({
+      success: true,
+      message: "Search reindex completed successfully",
+      data: result,
+    });
+  } catch (err) {
+    logger.error("Manual search reindex failed:", err.message);
+
+    res.status(500).json({
+      success: false,
+      message: "Search reindex failed",
+      error: err.message,
+    });
+  }
+});
+
+/**
+ * @swagger
+ * /api/cron/exchange-rates:
+ *   post:
+ *     summary: Refresh the exchange rates
+ *     tags: [Cron]
+ *     security:
+ *       - schedulerSecret: []
+ *     responses:
+ *       200:
+ *         description: Task completed
+ *       401:
+ *         description: Missing or invalid CRON_SECRET
+ *       500:
+ *         description: Task failed
+ */
+router.post("/exchange-rates", async (req, res, next) => {
+  logger.info("Manual exchange rate refresh triggered");
+
+  try {
+    const result = await cronService.runTask("exchange-rates", () =>
+      cronService.runExchangeRateRefresh()
+    );
+
+    logger.info("Manual exchange rate refresh completed", result);
+    res.status(200).json({
+      success: true,
+      message: "Exchange rate refresh completed successfully",
+      data: result,
+    });
+  } catch (err) {
+    logger.error("Manual exchange rate refresh failed:", err.message);
+
+    res.status(500).json({
+      success: false,
+      message: "Exchange rate refresh failed",
+      error: err.message,
+    });
+  }
+});
+
+/**
+ * @swagger
+ * /api/cron/scheduled-publications:
+ *   post:
+ *     summary: Publish trips whose scheduled launch is overdue
+ *     description: Run every few minutes as a safety net for the publication jobs.
+ *     tags: [Cron]
+ *     security:
+ *       - schedulerSecret: []
+ *     responses:
+ *       200:
+ *         description: Task completed
+ *       401:
+ *         description: Missing or invalid CRON_SECRET
+ *       500:
+ *         description: Task failed
+ */
+router.post("/scheduled-publications", async (req, res, next) => {
+  logger.info("Manual scheduled publications run triggered");
+
+  try {
+    const result = await cronService.runTask("scheduled-publications", () =>
+      cronService.runScheduledPublications()
+    );
+
+    logger.info("Manual scheduled publications run completed", result);
+    res.status(200).json({
+      success: true,
+      message: "Scheduled publications completed successfully",
+      data: result,
+    });
+  } catch (err) {
+    logger.error("Manual scheduled publications run failed:", err.message);
+
+    res.status(500).json({
+      success: false,
+      message: "Scheduled publications failed",
+      error: err.message,
+    });
+  }
+});
+
+export default router;
@@ -0,0 +1,89 @@
+import { Router } from "express";
+import cronService from "../services/cron.service.js";
+import logger from "../config/logger.js";
+
+// Monitoring of the /cron tasks; the tasks themselves are in cron.routes.js
+const router = Router();
+
+/**
+ * @swagger
+ * /api/cron/tasks:
+ *   get:
+ *     summary: Scheduled tasks with their expected interval and latest run
+ *     description: >
+ *       `overdue` is true when a scheduled task has not run within its interval
+ *       plus grace. API and worker processes check this every
+ *       SCHEDULER_CHECK_INTERVAL_MINUTES; a missed task gets a "missed" entry in
+ *       the run history and the admins are notified, once per missed interval.
+ *     tags: [Cron]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: "[{ task, everyMinutes, graceMinutes, lastStatus, lastRunAt, lastSucceededAt, lastDurationMs, lastError, overdue }]"
+ *       403:
+ *         description: Not an admin
+ */
+router.get("/tasks", async (req, res, next) => {
+  try {
+    const tasks = await cronService.getTaskOverview();
+    res.status(200).json({ success: true, data: tasks });
+  } catch (err) {
+    logger.error(`Get cron tasks failed: ${err.message}`);
+    next(err);
+  }
+});
+
+/**
+ * @swagger
+ * /api/cron/runs:
+ *   get:
+ *     summary: Run history of the scheduled tasks, newest first
+ *     tags: [Cron]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: task
+ *         schema:
+ *           type: string
+ *       - in: query
+ *         name: status
+ *         schema:
+ *           type: string
+ *           enum: [running, succeeded, failed, missed]
+ *       - in: query
+ *         name: limit
+ *         schema:
+ *           type: integer
+ *           default: 50
+ *           maximum: 200
+ *       - in: query
+ *         name: offset
+ *         schema:
+ *           type: integer
+ *           default: 0
+ *     responses:
+ *       200:
+ *         description: "{ runs: [{ id, task, status, startedAt, finishedAt, durationMs, error }], total, limit, offset }"
+ *       400:
+ *         description: Invalid filters
+ *       403:
+ *         description: Not an admin
+ */
+router.get("/runs", async (req, res, next) => {
+  try {
+    const result = await cronService.getRunHistory({
+      task: req.query.task,
+      status: req.query.status,
+      limit: req.query.limit,
+      offset: req.query.offset,
+    });
+    res.status(200).json({ success: true, data: result });
+  } catch (err) {
+    logger.error(`Get cron run history failed: ${err.message}`);
+    next(err);
+  }
+});
+
+export default router;
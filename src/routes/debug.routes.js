@@ -2,6 +2,7 @@ import { Router } from "express";
 import { AppDataSource } from "../load/typeorm.loader.js";
 import logger from "../config/logger.js";
 import seedDatabase from "../load/seed.loader.js";
+import { AppError, AuthenticationError } from "../utils/customErrors.js";
 
 const router = Router();
 
@@ -75,10 +76,7 @@ const router = Router();
 router.delete("/reset-database", async (req, res, next) => {
    try {
      if (!req.body.apikey || req.body.apikey !== process.env.DANGER_API_KEY) {
-       return res.status(401).json({
-         success: false,
-         message: "Invalid API key"
-       });
+       return next(new AuthenticationError("Invalid API key"));
      }
 
      logger.warn("Database reset requested via debug endpoint");
@@ -187,10 +185,7 @@ router.delete("/reset-database", async (req, res, next) => {
 router.post("/seed-database", async (req, res, next) => {
    try {
      if (!req.body.apikey || req.body.apikey !== process.env.DANGER_API_KEY) {
-       return res.status(401).json({
-         success: false,
-         message: "Invalid API key"
-       });
+       return next(new AuthenticationError("Invalid API key"));
      }
 
      logger.info("Database seeding requested via debug endpoint");
@@ -208,10 +203,7 @@ router.post("/seed-database", async (req, res, next) => {
   } catch (err) {
     logger.error("Database seeding failed:", err.message);
     if (err.message.includes("already has")) {
-      return res.status(409).json({
-        success: false,
-        message: "Database already contains data"
-      });
+      return next(new AppError("Database already contains data", 409));
     }
     next(err);
   }
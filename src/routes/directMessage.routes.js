@@ -1,6 +1,7 @@
 import express from "express";
 import directMessageController from "../controllers/directMessage.controller.js";
-import { authenticateToken } from "../middleware/auth.middleware.js";
+import { authenticateToken, blockAgeRestricted } from "../middleware/auth.middleware.js";
+import { requireFeature } from "../middleware/policy.middleware.js";
 
 const router = express.Router();
 
@@ -8,34 +9,128 @@ const router = express.Router();
 router.use(authenticateToken);
 
 /**
- * @route   POST /api/direct-messages
- * @desc    Send a direct message to another user
- * @access  Private
+ * @swagger
+ * /api/direct-messages:
+ *   post:
+ *     summary: Send a direct message to another user
+ *     tags: [Direct Messages]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required:
+ *               - receiverId
+ *               - content
+ *             properties:
+ *               receiverId:
+ *                 type: string
+ *               content:
+ *                 type: string
+ *     responses:
+ *       201:
+ *         description: Message sent
+ *       400:
+ *         description: Receiver or content missing
+ *       403:
+ *         description: Blocked, age-restricted or feature unavailable in the user's country
+ *       404:
+ *         description: Receiver not found
  */
-router.post("/", directMessageController.sendMessage);
+router.post(
+  "/",
+  blockAgeRestricted,
+  requireFeature("directMessages"),
+  directMessageController.sendMessage
+);
 
 /**
- * @route   GET /api/direct-messages/conversations
- * @desc    Get all conversations for the authenticated user
- * @access  Private
+ * @swagger
+ * /api/direct-messages/conversations:
+ *   get:
+ *     summary: Conversations of the authenticated user
+ *     tags: [Direct Messages]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: Conversations with their last message and unread count
  */
 router.get("/conversations", directMessageController.getConversations);
 
 /**
- * @route   GET /api/direct-messages/unread-count
- * @desc    Get unread message count for the authenticated user
- * @access  Private
+ * @swagger
+ * /api/direct-messages/unread-count:
+ *   get:
+ *     summary: Unread direct messages of the authenticated user
+ *     tags: [Direct Messages]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: Unread count
  */
 router.get("/unread-count", directMessageController.getUnreadCount);
 
 /**
- * @route   GET /api/direct-messages/conversation/:otherUserId
- * @desc    Get conversation history with another user
- * @access  Private
+ * @swagger
+ * /api/direct-messages/conversation/{otherUserId}:
+ *   get:
+ *     summary: Conversation history with another user
+ *     tags: [Direct Messages]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: otherUserId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: query
+ *         name: limit
+ *         schema:
+ *           type: integer
+ *           default: 50
+ *       - in: query
+ *         name: offset
+ *         schema:
+ *           type: integer
+ *           default: 0
+ *     responses:
+ *       200:
+ *         description: Messages, newest first
  */
 router.get(
   "/conversation/:otherUserId",
   directMessageController.getConversationHistory
 );
 
+/**
+ * @swagger
+ * /api/direct-messages/{messageId}:
+ *   delete:
+ *     summary: Delete a message sent by the authenticated user
+ *     description: The message is soft-deleted and can be restored by an admin.
+ *     tags: [Direct Messages]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: messageId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Message deleted
+ *       403:
+ *         description: Not the sender
+ *       404:
+ *         description: Message not found
+ */
+router.delete("/:messageId", directMessageController.deleteMessage);
+
 export default router;
@@ -16,6 +16,9 @@ const router = Router();
  * /api/groups/{groupId}/expenses:
  *   post:
  *     summary: Create a new expense for a group
+ *     description: >
+ *       Any member can record an expense they paid. The admin can also record it
+ *       on behalf of another member (`paidById`) or leave it unassigned.
  *     tags: [Expenses]
  *     security:
  *       - bearerAuth: []
@@ -43,6 +46,18 @@ const router = Router();
  *                 type: number
  *                 minimum: 0.01
  *                 maximum: 9999999.99
+ *               currency:
+ *                 type: string
+ *                 example: USD
+ *                 description: ISO 4217 code (defaults to EXPENSES_DEFAULT_CURRENCY)
+ *               paidById:
+ *                 type: string
+ *                 description: Payer (admin only; members always pay their own expenses)
+ *               participantIds:
+ *                 type: array
+ *                 items:
+ *                   type: string
+ *                 description: Members sharing the expense (defaults to every member)
  *     responses:
  *       201:
  *         description: Expense created successfully
@@ -73,6 +88,11 @@ router.post(
  *         required: true
  *         schema:
  *           type: string
+ *       - in: query
+ *         name: currency
+ *         schema:
+ *           type: string
+ *         description: Display currency (defaults to the user's preferred currency)
  *     responses:
  *       200:
  *         description: List of expenses retrieved successfully
@@ -87,6 +107,46 @@ router.get(
   expenseController.getGroupExpenses
 );
 
+/**
+ * @swagger
+ * /api/trips/{id}/balances:
+ *   get:
+ *     summary: Get per-member balances and a settlement plan for a trip
+ *     description: >
+ *       Each expense is split evenly among its participants. Balances are computed
+ *       per currency; positive means the member is owed money. `settlements` is the
+ *       shortest list of transfers found that leaves everyone at zero. With a display
+ *       currency, `converted` settles every expense together at the latest rates;
+ *       currencies with no rate are left out of it and listed in `converted.unconverted`
+ *       with their total.
+ *     tags: [Expenses]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: query
+ *         name: currency
+ *         schema:
+ *           type: string
+ *         description: Display currency (defaults to the user's preferred currency)
+ *     responses:
+ *       200:
+ *         description: Balances and settlements per currency
+ *       403:
+ *         description: Not a member of the group
+ *       404:
+ *         description: Group not found
+ */
+router.get(
+  "/trips/:id/balances",
+  authenticate,
+  expenseController.getGroupBalances
+);
+
 /**
  * @swagger
  * /api/expenses/user:
@@ -95,9 +155,18 @@ router.get(
  *     tags: [Expenses]
  *     security:
  *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: currency
+ *         schema:
+ *           type: string
+ *         description: Display currency (defaults to the user's preferred currency)
  *     responses:
  *       200:
- *         description: List of all user expenses retrieved successfully
+ *         description: >
+ *           List of all user expenses retrieved successfully. Expenses in a currency
+ *           with no rate have a null displayAmount and their totals are listed in
+ *           `unconverted` instead of being added to displayTotal.
  *       403:
  *         description: Not authorized
  */
@@ -0,0 +1,96 @@
+import { Router } from "express";
+import { authenticate, authorize } from "../middleware/auth.middleware.js";
+import featureFlagController from "../controllers/featureFlag.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * tags:
+ *   name: Feature Flags
+ *   description: >
+ *     Flags for rolling out features: on or off and, when on, for a
+ *     percentage of users (each user always lands in the same bucket).
+ *     Flags start from FEATURE_FLAGS; what is set here overrides them until
+ *     reset. Every instance applies a change within
+ *     FEATURE_FLAGS_CACHE_TTL_SECONDS. Apps get their flags in
+ *     /api/client-config.
+ */
+
+/**
+ * @swagger
+ * /api/admin/feature-flags:
+ *   get:
+ *     summary: List feature flags
+ *     tags: [Feature Flags]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: >
+ *           [{ key, description, enabled, rolloutPercent, source, default,
+ *           updatedById, updatedAt }]; source is "runtime" or "config" and
+ *           default the FEATURE_FLAGS value, if any
+ */
+router.get("/", authenticate, authorize(["admin"]), featureFlagController.listFlags);
+
+/**
+ * @swagger
+ * /api/admin/feature-flags/{key}:
+ *   put:
+ *     summary: Set a feature flag at runtime
+ *     description: Creates the flag if needed; fields left out keep their current value.
+ *     tags: [Feature Flags]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: key
+ *         required: true
+ *         schema:
+ *           type: string
+ *           example: tripRecommendations
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             properties:
+ *               enabled:
+ *                 type: boolean
+ *               rolloutPercent:
+ *                 type: integer
+ *                 minimum: 0
+ *                 maximum: 100
+ *               description:
+ *                 type: string
+ *                 nullable: true
+ *                 maxLength: 255
+ *     responses:
+ *       200:
+ *         description: The flag
+ *       400:
+ *         description: Invalid key or fields (errors by field)
+ *   delete:
+ *     summary: Reset a feature flag to its FEATURE_FLAGS value
+ *     description: Flags without a configured value turn off.
+ *     tags: [Feature Flags]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: key
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Flag reset
+ *       404:
+ *         description: The flag has no runtime setting
+ */
+router.put("/:key", authenticate, authorize(["admin"]), featureFlagController.setFlag);
+router.delete("/:key", authenticate, authorize(["admin"]), featureFlagController.resetFlag);
+
+export default router;
@@ -0,0 +1,40 @@
+import { Router } from "express";
+import feedController from "../controllers/feed.controller.js";
+import { authenticate } from "../middleware/auth.middleware.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/feed:
+ *   get:
+ *     summary: Activity of the users you follow
+ *     description: >
+ *       Public trips the users you follow published (as organizers) or completed
+ *       (as members), newest first. Follow users with POST /api/users/{userId}/follow.
+ *       Upcoming trips departing from your home city published in the last
+ *       DISCOVERY_FEED_BOOST_HOURS are shown first (trip.departsFromHomeCity).
+ *     tags: [Users]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: limit
+ *         schema:
+ *           type: integer
+ *           default: 20
+ *           maximum: 50
+ *       - in: query
+ *         name: offset
+ *         schema:
+ *           type: integer
+ *           default: 0
+ *     responses:
+ *       200:
+ *         description: >
+ *           items ({ type: trip_published | trip_completed, occurredAt, actor, trip })
+ *           and pagination ({ limit, offset, hasMore })
+ */
+router.get("/", authenticate, feedController.getFeed);
+
+export default router;
@@ -0,0 +1,174 @@
+import { Router } from "express";
+import { authenticate } from "../middleware/auth.middleware.js";
+import formController from "../controllers/form.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * tags:
+ *   name: Forms
+ *   description: >
+ *     Forms the apps render from a schema served by the backend, so fields can
+ *     be added without an app release. `onboarding` is answered by each user
+ *     and `trip_creation` by the organizer of each trip. Answers are stored by
+ *     field ID; a field ID keeps its type across versions.
+ */
+
+/**
+ * @swagger
+ * /api/forms/{formKey}:
+ *   get:
+ *     summary: Latest published version of a form
+ *     description: >
+ *       Field types: text, textarea, number, integer, boolean, date (YYYY-MM-DD),
+ *       select and multiselect. `validation` may hold minLength, maxLength and
+ *       pattern (text), min and max (numbers) or minItems and maxItems
+ *       (multiselect). Version 0 with no fields when nothing was published.
+ *     tags: [Forms]
+ *     parameters:
+ *       - in: path
+ *         name: formKey
+ *         required: true
+ *         schema:
+ *           type: string
+ *           enum: [onboarding, trip_creation]
+ *       - in: query
+ *         name: lang
+ *         description: Language of the labels; defaults to Accept-Language, then Spanish
+ *         schema:
+ *           type: string
+ *           enum: [es, en, pt]
+ *     responses:
+ *       200:
+ *         description: "{ formKey, version, language, publishedAt, fields: [{ id, type, required, label, help, options: [{ value, label }], validation }] }"
+ *       404:
+ *         description: Unknown form
+ */
+router.get("/forms/:formKey", formController.getForm);
+
+/**
+ * @swagger
+ * /api/forms/{formKey}/answers:
+ *   get:
+ *     summary: Your answers to a user form (e.g. onboarding)
+ *     description: >
+ *       `missingRequired` lists required fields of the current version without
+ *       an answer, e.g. fields added after you answered.
+ *     tags: [Forms]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: formKey
+ *         required: true
+ *         schema:
+ *           type: string
+ *           enum: [onboarding]
+ *     responses:
+ *       200:
+ *         description: "{ formKey, version, schemaVersion, answers, missingRequired, fields, updatedAt }"
+ *   put:
+ *     summary: Save your answers to a user form
+ *     description: >
+ *       Fields left out keep their answer and null clears it. The result must
+ *       answer every required field of the current version.
+ *     tags: [Forms]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: formKey
+ *         required: true
+ *         schema:
+ *           type: string
+ *           enum: [onboarding]
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [answers]
+ *             properties:
+ *               answers:
+ *                 type: object
+ *                 additionalProperties: true
+ *                 example: { travel_style: "backpacker", interests: ["hiking", "food"] }
+ *     responses:
+ *       200:
+ *         description: Answers saved
+ *       400:
+ *         description: Invalid answers; `errors` has the problem of each field
+ */
+router.get("/forms/:formKey/answers", authenticate, formController.getMyAnswers);
+router.put("/forms/:formKey/answers", authenticate, formController.saveMyAnswers);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/forms/{formKey}/answers:
+ *   get:
+ *     summary: Answers of a trip to a group form (e.g. trip_creation), for its admin and members
+ *     tags: [Forms]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: formKey
+ *         required: true
+ *         schema:
+ *           type: string
+ *           enum: [trip_creation]
+ *     responses:
+ *       200:
+ *         description: "{ formKey, version, schemaVersion, answers, missingRequired, fields, updatedAt }"
+ *       403:
+ *         description: Not a member of the group
+ *       404:
+ *         description: Group not found
+ *   put:
+ *     summary: Save answers of a trip to a group form (admin only)
+ *     description: Fields left out keep their answer and null clears it.
+ *     tags: [Forms]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: formKey
+ *         required: true
+ *         schema:
+ *           type: string
+ *           enum: [trip_creation]
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [answers]
+ *             properties:
+ *               answers:
+ *                 type: object
+ *                 additionalProperties: true
+ *     responses:
+ *       200:
+ *         description: Answers saved
+ *       400:
+ *         description: Invalid answers; `errors` has the problem of each field
+ *       403:
+ *         description: Not the group admin
+ */
+router.get("/groups/:groupId/forms/:formKey/answers", authenticate, formController.getGroupAnswers);
+router.put("/groups/:groupId/forms/:formKey/answers", authenticate, formController.saveGroupAnswers);
+
+export default router;
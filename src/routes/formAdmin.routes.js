@@ -0,0 +1,197 @@
+import { Router } from "express";
+import { authenticate, authorize } from "../middleware/auth.middleware.js";
+import formController from "../controllers/form.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/admin/forms/{formKey}/versions:
+ *   get:
+ *     summary: Every version of a form, newest first
+ *     tags: [Forms]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: formKey
+ *         required: true
+ *         schema:
+ *           type: string
+ *           enum: [onboarding, trip_creation]
+ *     responses:
+ *       200:
+ *         description: "[{ formKey, version, status: draft|published, fields, createdById, publishedAt, createdAt, updatedAt }]"
+ *   post:
+ *     summary: Draft the next version of a form
+ *     description: >
+ *       The apps keep getting the latest published version until the draft is
+ *       published. Labels, help texts and option labels are objects by language
+ *       with Spanish required. A field ID published before must keep its type.
+ *     tags: [Forms]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: formKey
+ *         required: true
+ *         schema:
+ *           type: string
+ *           enum: [onboarding, trip_creation]
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [fields]
+ *             properties:
+ *               fields:
+ *                 type: array
+ *                 maxItems: 50
+ *                 items:
+ *                   type: object
+ *                   required: [id, type, label]
+ *                   properties:
+ *                     id:
+ *                       type: string
+ *                       pattern: "^[a-z][a-z0-9_]{1,49}$"
+ *                     type:
+ *                       type: string
+ *                       enum: [text, textarea, number, integer, boolean, date, select, multiselect]
+ *                     required:
+ *                       type: boolean
+ *                       default: false
+ *                     label:
+ *                       type: object
+ *                       example: { es: "¿Cómo viajas?", en: "How do you travel?" }
+ *                     help:
+ *                       type: object
+ *                     options:
+ *                       type: array
+ *                       items:
+ *                         type: object
+ *                         properties:
+ *                           value:
+ *                             type: string
+ *                           label:
+ *                             type: object
+ *                     validation:
+ *                       type: object
+ *     responses:
+ *       201:
+ *         description: Draft created
+ *       400:
+ *         description: Invalid field definitions
+ */
+router.get("/:formKey/versions", authenticate, authorize(["admin"]), formController.listVersions);
+router.post("/:formKey/versions", authenticate, authorize(["admin"]), formController.createDraft);
+
+/**
+ * @swagger
+ * /api/admin/forms/{formKey}/versions/{version}:
+ *   get:
+ *     summary: A version of a form with its labels in every language
+ *     tags: [Forms]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: formKey
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: version
+ *         required: true
+ *         schema:
+ *           type: integer
+ *     responses:
+ *       200:
+ *         description: The version
+ *       404:
+ *         description: Version not found
+ *   put:
+ *     summary: Replace the fields of a draft
+ *     description: Same body as creating a draft. Published versions cannot change.
+ *     tags: [Forms]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: formKey
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: version
+ *         required: true
+ *         schema:
+ *           type: integer
+ *     responses:
+ *       200:
+ *         description: Draft updated
+ *       400:
+ *         description: Invalid fields, or the version is published
+ *   delete:
+ *     summary: Delete a draft
+ *     tags: [Forms]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: formKey
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: version
+ *         required: true
+ *         schema:
+ *           type: integer
+ *     responses:
+ *       200:
+ *         description: Draft deleted
+ *       400:
+ *         description: The version is published
+ */
+router.get("/:formKey/versions/:version", authenticate, authorize(["admin"]), formController.getVersion);
+router.put("/:formKey/versions/:version", authenticate, authorize(["admin"]), formController.updateDraft);
+router.delete("/:formKey/versions/:version", authenticate, authorize(["admin"]), formController.deleteDraft);
+
+/**
+ * @swagger
+ * /api/admin/forms/{formKey}/versions/{version}/publish:
+ *   post:
+ *     summary: Publish a draft
+ *     description: >
+ *       The apps get it within a minute. Stored answers are kept; required
+ *       fields new in this version show up in `missingRequired` until answered.
+ *     tags: [Forms]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: formKey
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: version
+ *         required: true
+ *         schema:
+ *           type: integer
+ *     responses:
+ *       200:
+ *         description: Version published
+ *       400:
+ *         description: Already published, or a newer version is published
+ */
+router.post(
+  "/:formKey/versions/:version/publish",
+  authenticate,
+  authorize(["admin"]),
+  formController.publishVersion
+);
+
+export default router;
@@ -1,5 +1,4 @@
 import { Router } from "express";
-import { authenticateToken } from "../middleware/auth.middleware.js";
 import {
   getUserStats,
   awardPoints,
@@ -11,8 +10,9 @@ import {
 
 const router = Router();
 
-// All gamification routes require authentication
-router.use(authenticateToken);
+// All gamification routes require authentication: the router is mounted in the
+// authenticated route group (see v1.routes.js). Being mounted at the API root, a
+// router-level middleware here would also run for every route mounted after it.
 
 /**
  * @swagger
@@ -1,6 +1,9 @@
 import { Router } from "express";
-import { authenticate } from "../middleware/auth.middleware.js";
+import { authenticate, blockAgeRestricted } from "../middleware/auth.middleware.js";
 import groupController from "../controllers/group.controller.js";
+import { idempotent } from "../middleware/idempotency.middleware.js";
+import { requireIdVerification } from "../middleware/policy.middleware.js";
+import { revalidate } from "../middleware/cacheControl.middleware.js";
 
 const router = Router();
 
@@ -19,6 +22,8 @@ const router = Router();
  *     tags: [Groups]
  *     security:
  *       - bearerAuth: []
+ *     parameters:
+ *       - $ref: '#/components/parameters/IdempotencyKey'
  *     requestBody:
  *       required: true
  *       content:
@@ -34,15 +39,64 @@ const router = Router();
  *                 maxLength: 50
  *               description:
  *                 type: string
+ *               capacity:
+ *                 type: integer
+ *                 minimum: 2
+ *                 description: Maximum number of members, admin included (omit for unlimited)
+ *               expectedSize:
+ *                 type: string
+ *                 enum: [small, medium, large]
+ *                 description: >
+ *                   Group size the trip is planned for (small = up to 6 travelers, medium = up to 12).
+ *                   Defaults to the size implied by the capacity
+ *               destinationName:
+ *                 type: string
+ *                 maxLength: 150
+ *                 description: Destination name, geocoded when no coordinates are given
+ *               destinationLat:
+ *                 type: number
+ *                 minimum: -90
+ *                 maximum: 90
+ *               destinationLng:
+ *                 type: number
+ *                 minimum: -180
+ *                 maximum: 180
+ *               originName:
+ *                 type: string
+ *                 maxLength: 150
+ *                 description: Departure city, geocoded when no coordinates are given
+ *               originLat:
+ *                 type: number
+ *                 minimum: -90
+ *                 maximum: 90
+ *               originLng:
+ *                 type: number
+ *                 minimum: -180
+ *                 maximum: 180
+ *               isPublic:
+ *                 type: boolean
+ *                 default: false
+ *                 description: Public groups are listed in nearby search
+ *               formAnswers:
+ *                 type: object
+ *                 additionalProperties: true
+ *                 description: >
+ *                   Answers to the published trip_creation form (GET /api/forms/trip_creation),
+ *                   by field ID. Checked against the form when sent; omit it on apps that do
+ *                   not render the form.
  *     responses:
  *       201:
  *         description: Group created successfully
  *       400:
  *         description: Invalid input
+ *       403:
+ *         description: >
+ *           Feature restricted for underage accounts (AGE_RESTRICTED), or the
+ *           policy requires a verified identity (ID_VERIFICATION_REQUIRED)
  *       409:
  *         description: Group name already exists
  */
-router.post("/", authenticate, groupController.createGroup);
+router.post("/", authenticate, blockAgeRestricted, requireIdVerification, idempotent, groupController.createGroup);
 
 /**
  * @swagger
@@ -54,10 +108,145 @@ router.post("/", authenticate, groupController.createGroup);
  *       - bearerAuth: []
  *     responses:
  *       200:
- *         description: List of groups retrieved successfully
+ *         description: List of groups retrieved successfully. Each group includes `unreadCount`, the number of group messages the user has not read yet.
  */
 router.get("/", authenticate, groupController.getUserGroups);
 
+/**
+ * @swagger
+ * /api/groups/browse:
+ *   get:
+ *     summary: Browse upcoming public groups by month of travel and trip length
+ *     description: >
+ *       Month and duration come from the dates of the assigned itinerary.
+ *       Durations are `weekend` (up to 3 days), `week` (4 to 9 days) and `long` (10 or more).
+ *     tags: [Groups]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: size
+ *         schema:
+ *           type: string
+ *           enum: [small, medium, large]
+ *         description: Only groups of this size (small = up to 6 travelers, medium = up to 12)
+ *       - in: query
+ *         name: month
+ *         schema:
+ *           type: integer
+ *           minimum: 1
+ *           maximum: 12
+ *       - in: query
+ *         name: duration
+ *         schema:
+ *           type: string
+ *           enum: [weekend, week, long]
+ *       - in: query
+ *         name: limit
+ *         schema:
+ *           type: integer
+ *           default: 20
+ *           maximum: 100
+ *       - in: query
+ *         name: offset
+ *         schema:
+ *           type: integer
+ *           default: 0
+ *     responses:
+ *       200:
+ *         description: >
+ *           Groups that have not started yet, sorted by `startDate`, with groups matching the
+ *           user's travelPreferences.groupSizes first (`sizeFit`)
+ *       400:
+ *         description: Invalid month or duration
+ */
+router.get("/browse", authenticate, groupController.browseGroups);
+
+/**
+ * @swagger
+ * /api/groups/departing:
+ *   get:
+ *     summary: Find public groups departing near a location
+ *     tags: [Groups]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: size
+ *         schema:
+ *           type: string
+ *           enum: [small, medium, large]
+ *         description: Only groups of this size (small = up to 6 travelers, medium = up to 12)
+ *       - in: query
+ *         name: lat
+ *         required: true
+ *         schema:
+ *           type: number
+ *       - in: query
+ *         name: lng
+ *         required: true
+ *         schema:
+ *           type: number
+ *       - in: query
+ *         name: radius_km
+ *         schema:
+ *           type: number
+ *           default: 50
+ *           maximum: 500
+ *       - in: query
+ *         name: limit
+ *         schema:
+ *           type: integer
+ *           default: 20
+ *           maximum: 100
+ *       - in: query
+ *         name: offset
+ *         schema:
+ *           type: integer
+ *           default: 0
+ *     responses:
+ *       200:
+ *         description: Groups that have not ended, soonest `startDate` first, with `originDistanceKm`
+ *       400:
+ *         description: Invalid coordinates or radius
+ */
+router.get("/departing", authenticate, groupController.getGroupsDepartingNear);
+
+/**
+ * @swagger
+ * /api/groups/from-my-city:
+ *   get:
+ *     summary: Public groups departing from the user's home city
+ *     description: Trip origins are matched within DISCOVERY_ORIGIN_RADIUS_KM of the `homeCity` set on the profile.
+ *     tags: [Groups]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: size
+ *         schema:
+ *           type: string
+ *           enum: [small, medium, large]
+ *         description: Only groups of this size (small = up to 6 travelers, medium = up to 12)
+ *       - in: query
+ *         name: limit
+ *         schema:
+ *           type: integer
+ *           default: 20
+ *           maximum: 100
+ *       - in: query
+ *         name: offset
+ *         schema:
+ *           type: integer
+ *           default: 0
+ *     responses:
+ *       200:
+ *         description: "{ homeCity, groups }, soonest `startDate` first"
+ *       400:
+ *         description: The user has no home city
+ */
+router.get("/from-my-city", authenticate, groupController.getGroupsFromMyCity);
+
 /**
  * @swagger
  * /api/groups/{id}:
@@ -75,10 +264,80 @@ router.get("/", authenticate, groupController.getUserGroups);
  *     responses:
  *       200:
  *         description: Group details retrieved successfully
+ *       304:
+ *         description: Not modified since the ETag sent in If-None-Match
+ *       404:
+ *         description: Group not found
+ */
+router.get("/:id", authenticate, revalidate, groupController.getGroupById);
+
+/**
+ * @swagger
+ * /api/groups/{id}:
+ *   patch:
+ *     summary: Update group details (admin only)
+ *     tags: [Groups]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             properties:
+ *               description:
+ *                 type: string
+ *               expectedSize:
+ *                 type: string
+ *                 enum: [small, medium, large]
+ *                 nullable: true
+ *               destinationName:
+ *                 type: string
+ *               destinationLat:
+ *                 type: number
+ *               destinationLng:
+ *                 type: number
+ *               originName:
+ *                 type: string
+ *                 nullable: true
+ *               originLat:
+ *                 type: number
+ *               originLng:
+ *                 type: number
+ *               isPublic:
+ *                 type: boolean
+ *               depositAmount:
+ *                 type: number
+ *                 nullable: true
+ *                 description: Deposit paid when requesting to join (null removes it)
+ *               depositCurrency:
+ *                 type: string
+ *                 example: ARS
+ *               cancellationPolicy:
+ *                 type: string
+ *                 nullable: true
+ *                 maxLength: 5000
+ *                 description: >
+ *                   Refund and cancellation terms. Changes only apply to later
+ *                   bookings; members keep the terms they agreed to.
+ *     responses:
+ *       200:
+ *         description: Group updated successfully
+ *       400:
+ *         description: Invalid input
+ *       403:
+ *         description: Not authorized
  *       404:
  *         description: Group not found
  */
-router.get("/:id", authenticate, groupController.getGroupById);
+router.patch("/:id", authenticate, groupController.updateGroup);
 
 /**
  * @swagger
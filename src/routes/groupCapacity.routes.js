@@ -0,0 +1,196 @@
+import { Router } from "express";
+import groupCapacityController from "../controllers/groupCapacity.controller.js";
+import { authenticate } from "../middleware/auth.middleware.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * components:
+ *   schemas:
+ *     CapacityChangeInput:
+ *       type: object
+ *       required:
+ *         - capacity
+ *       properties:
+ *         capacity:
+ *           type: integer
+ *           nullable: true
+ *           minimum: 2
+ *           description: New capacity, admin included (null for unlimited)
+ *         strategy:
+ *           type: string
+ *           enum: [join_order, tier, manual]
+ *           default: join_order
+ *           description: >
+ *             Who loses their spot when capacity goes below the member count:
+ *             last to join (`join_order`), lowest tier first (`tier`) or the
+ *             members listed in `memberIds` (`manual`). The admin always keeps their spot.
+ *         memberIds:
+ *           type: array
+ *           items:
+ *             type: string
+ *           description: Members to remove with the `manual` strategy
+ */
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/capacity/preview:
+ *   post:
+ *     summary: Preview a capacity change (admin only)
+ *     description: Lists the members that would lose their spot and the waitlisted users that would be promoted, without changing anything.
+ *     tags: [Group Capacity]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             $ref: '#/components/schemas/CapacityChangeInput'
+ *     responses:
+ *       200:
+ *         description: Members to remove and waitlist entries to promote
+ *       400:
+ *         description: Invalid capacity, strategy or member selection
+ *       403:
+ *         description: Not the group admin
+ *       404:
+ *         description: Group not found
+ */
+router.post(
+  "/:groupId/capacity/preview",
+  authenticate,
+  groupCapacityController.previewCapacityChange
+);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/capacity:
+ *   put:
+ *     summary: Change the capacity of a group (admin only)
+ *     description: >
+ *       Reducing capacity removes the members chosen by the strategy, queues their
+ *       refunds and puts them on the waitlist (unless `waitlistRemoved` is false).
+ *       Increasing it promotes waitlisted users in order. Everyone is notified and the
+ *       change is recorded.
+ *     tags: [Group Capacity]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             allOf:
+ *               - $ref: '#/components/schemas/CapacityChangeInput'
+ *               - type: object
+ *                 properties:
+ *                   reason:
+ *                     type: string
+ *                     maxLength: 500
+ *                   waitlistRemoved:
+ *                     type: boolean
+ *                     default: true
+ *     responses:
+ *       200:
+ *         description: Recorded capacity change
+ *       400:
+ *         description: Invalid capacity, strategy or member selection
+ *       403:
+ *         description: Not the group admin
+ *       404:
+ *         description: Group not found
+ */
+router.put("/:groupId/capacity", authenticate, groupCapacityController.changeCapacity);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/capacity/changes:
+ *   get:
+ *     summary: History of capacity changes of a group (admin only)
+ *     tags: [Group Capacity]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Capacity changes, newest first
+ *       403:
+ *         description: Not the group admin
+ *       404:
+ *         description: Group not found
+ */
+router.get(
+  "/:groupId/capacity/changes",
+  authenticate,
+  groupCapacityController.getCapacityChanges
+);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/members/{userId}/tier:
+ *   put:
+ *     summary: Set the tier of a member (admin only)
+ *     description: Members with a higher tier keep their spot when capacity is reduced with the `tier` strategy.
+ *     tags: [Group Capacity]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: userId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required:
+ *               - tier
+ *             properties:
+ *               tier:
+ *                 type: integer
+ *                 minimum: 0
+ *                 maximum: 100
+ *     responses:
+ *       200:
+ *         description: Tier updated
+ *       400:
+ *         description: Invalid tier
+ *       403:
+ *         description: Not the group admin
+ *       404:
+ *         description: Group or member not found
+ */
+router.put(
+  "/:groupId/members/:userId/tier",
+  authenticate,
+  groupCapacityController.setMemberTier
+);
+
+export default router;
@@ -0,0 +1,221 @@
+import { Router } from "express";
+import groupDocumentController from "../controllers/groupDocument.controller.js";
+import { authenticate } from "../middleware/auth.middleware.js";
+import { uploadDocument } from "../utils/fileUpload.js";
+import { deadline, keepContext } from "../middleware/requestContext.middleware.js";
+import config from "../config/index.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/documents:
+ *   post:
+ *     summary: Share a document (ticket, reservation...) with the group
+ *     description: >
+ *       Files are scanned by the antivirus in the background and can only be
+ *       downloaded once the scan is clean. Every version counts towards the group quota.
+ *     tags: [Group Documents]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         multipart/form-data:
+ *           schema:
+ *             type: object
+ *             required:
+ *               - file
+ *             properties:
+ *               file:
+ *                 type: string
+ *                 format: binary
+ *                 description: PDF or image (max 20MB by default)
+ *               title:
+ *                 type: string
+ *                 maxLength: 150
+ *               category:
+ *                 type: string
+ *                 enum: [ticket, reservation, insurance, other]
+ *     responses:
+ *       201:
+ *         description: Document shared
+ *       400:
+ *         description: Invalid file or fields
+ *       403:
+ *         description: Not a member of the group
+ *       413:
+ *         description: Group storage quota exceeded
+ *   get:
+ *     summary: List the documents of a group with quota usage
+ *     tags: [Group Documents]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Documents with their latest version
+ *       403:
+ *         description: Not a member of the group
+ */
+router.post(
+  "/:groupId/documents",
+  authenticate,
+  deadline(config.requests.longTimeoutMs),
+  keepContext(uploadDocument.single("file")),
+  groupDocumentController.createDocument
+);
+router.get("/:groupId/documents", authenticate, groupDocumentController.getDocuments);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/documents/{documentId}:
+ *   get:
+ *     summary: Get a document with its version history
+ *     tags: [Group Documents]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: documentId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Document and versions
+ *       404:
+ *         description: Document not found
+ *   delete:
+ *     summary: Delete a document and all its versions (uploader or group admin)
+ *     tags: [Group Documents]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: documentId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Document deleted
+ *       403:
+ *         description: Not allowed
+ */
+router.get(
+  "/:groupId/documents/:documentId",
+  authenticate,
+  groupDocumentController.getDocument
+);
+router.delete(
+  "/:groupId/documents/:documentId",
+  authenticate,
+  groupDocumentController.deleteDocument
+);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/documents/{documentId}/versions:
+ *   post:
+ *     summary: Upload a new version of a document
+ *     tags: [Group Documents]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: documentId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         multipart/form-data:
+ *           schema:
+ *             type: object
+ *             required:
+ *               - file
+ *             properties:
+ *               file:
+ *                 type: string
+ *                 format: binary
+ *     responses:
+ *       201:
+ *         description: Version uploaded
+ *       413:
+ *         description: Group storage quota exceeded
+ */
+router.post(
+  "/:groupId/documents/:documentId/versions",
+  authenticate,
+  deadline(config.requests.longTimeoutMs),
+  keepContext(uploadDocument.single("file")),
+  groupDocumentController.addVersion
+);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/documents/{documentId}/download:
+ *   get:
+ *     summary: Get a temporary download URL (only for files that passed the antivirus)
+ *     tags: [Group Documents]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: documentId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: query
+ *         name: version
+ *         schema:
+ *           type: integer
+ *         description: Version number (defaults to the current one)
+ *     responses:
+ *       200:
+ *         description: Download URL valid for 5 minutes
+ *       403:
+ *         description: File quarantined by the antivirus
+ *       409:
+ *         description: Scan still in progress
+ */
+router.get(
+  "/:groupId/documents/:documentId/download",
+  authenticate,
+  groupDocumentController.getDownloadUrl
+);
+
+export default router;
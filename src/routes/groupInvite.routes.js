@@ -0,0 +1,174 @@
+import { Router } from "express";
+import groupInviteController from "../controllers/groupInvite.controller.js";
+import { authenticate, optionalAuthenticate } from "../middleware/auth.middleware.js";
+import { idempotent } from "../middleware/idempotency.middleware.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * tags:
+ *   name: Group Invites
+ *   description: >
+ *     Shareable invite links. Opening one shows a preview of the trip;
+ *     accepting it joins without going through the approval queue.
+ */
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/invites:
+ *   post:
+ *     summary: Create an invite link (organizer only)
+ *     description: The URL is only returned here; only its hash is stored.
+ *     tags: [Group Invites]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             properties:
+ *               maxUses:
+ *                 type: integer
+ *                 minimum: 1
+ *                 maximum: 500
+ *                 nullable: true
+ *                 description: Omit for unlimited uses
+ *               expiresAt:
+ *                 type: string
+ *                 format: date-time
+ *                 nullable: true
+ *                 description: Within the next 365 days; omit for no expiry
+ *     responses:
+ *       201:
+ *         description: "{ id, token, url, tokenPrefix, maxUses, useCount, expiresAt, active, createdAt }"
+ *       400:
+ *         description: Invalid maxUses or expiresAt (errors by field)
+ *       403:
+ *         description: Not the organizer
+ *       409:
+ *         description: Too many active links (INVITE_LIMIT_REACHED)
+ *   get:
+ *     summary: List the invite links of a group (organizer only)
+ *     description: Revoked links are left out; expired and used-up ones have active false.
+ *     tags: [Group Invites]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Invite links, newest first
+ *       403:
+ *         description: Not the organizer
+ */
+router.post("/groups/:groupId/invites", authenticate, groupInviteController.createInvite);
+router.get("/groups/:groupId/invites", authenticate, groupInviteController.listInvites);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/invites/{inviteId}:
+ *   delete:
+ *     summary: Revoke an invite link (organizer only)
+ *     tags: [Group Invites]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: inviteId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Link revoked, it stops working right away
+ *       403:
+ *         description: Not the organizer
+ *       404:
+ *         description: Invite not found
+ */
+router.delete("/groups/:groupId/invites/:inviteId", authenticate, groupInviteController.revokeInvite);
+
+/**
+ * @swagger
+ * /api/invite/{token}:
+ *   get:
+ *     summary: Preview the trip of an invite link
+ *     description: >
+ *       Public, for the page the link opens. With a session, `viewer.isMember`
+ *       tells whether the caller already belongs to the trip.
+ *     tags: [Group Invites]
+ *     parameters:
+ *       - in: path
+ *         name: token
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: "{ invite: { expiresAt, usesLeft }, trip, viewer }"
+ *       404:
+ *         description: Unknown or revoked link
+ *       410:
+ *         description: Link expired (INVITE_EXPIRED), used up (INVITE_USED_UP) or trip ended (TRIP_ENDED)
+ */
+router.get("/invite/:token", optionalAuthenticate, groupInviteController.getPreview);
+
+/**
+ * @swagger
+ * /api/invite/{token}/accept:
+ *   post:
+ *     summary: Join the trip of an invite link
+ *     description: >
+ *       Joins right away, without the approval queue. An open join request of
+ *       the caller for the trip is admitted instead. If the trip has rules the
+ *       caller has not accepted, the request stays `awaiting_rules` until they
+ *       accept them.
+ *     tags: [Group Invites]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: token
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - $ref: '#/components/parameters/IdempotencyKey'
+ *     responses:
+ *       200:
+ *         description: Join request, `approved` or `awaiting_rules`
+ *       403:
+ *         description: >
+ *           Not eligible to join (same rules and errors as join requests, see
+ *           join-eligibility)
+ *       404:
+ *         description: Unknown or revoked link
+ *       409:
+ *         description: Already a member (ALREADY_MEMBER) or trip full (GROUP_FULL)
+ *       410:
+ *         description: Link expired (INVITE_EXPIRED), used up (INVITE_USED_UP) or trip ended (TRIP_ENDED)
+ */
+router.post(
+  "/invite/:token/accept",
+  authenticate,
+  idempotent,
+  groupInviteController.acceptInvite
+);
+
+export default router;
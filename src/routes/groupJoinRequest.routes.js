@@ -0,0 +1,194 @@
+import { Router } from "express";
+import groupJoinRequestController from "../controllers/groupJoinRequest.controller.js";
+import { authenticate } from "../middleware/auth.middleware.js";
+import { idempotent } from "../middleware/idempotency.middleware.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/join-requests:
+ *   post:
+ *     summary: Request to join a group
+ *     tags: [Group Join Requests]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - $ref: '#/components/parameters/IdempotencyKey'
+ *     requestBody:
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             properties:
+ *               message:
+ *                 type: string
+ *                 maxLength: 500
+ *                 example: "¡Hola! Me encantaría sumarme al viaje"
+ *     responses:
+ *       201:
+ *         description: Request created (status `waitlisted` if the group is full), the group admin is notified
+ *       403:
+ *         description: >
+ *           Not eligible: errorCode is the first failed rule (e.g. AGE_RESTRICTED,
+ *           FEATURE_UNAVAILABLE, EMAIL_NOT_VERIFIED, JOIN_QUOTA_EXCEEDED) and
+ *           errors lists every failed rule. See join-eligibility.
+ *       404:
+ *         description: Group not found
+ *       409:
+ *         description: Already a member or a request is already pending
+ */
+router.post(
+  "/:groupId/join-requests",
+  authenticate,
+  idempotent,
+  groupJoinRequestController.requestToJoin
+);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/join-eligibility:
+ *   get:
+ *     summary: Whether you can join a trip, and why (dry run)
+ *     description: >
+ *       Runs the join eligibility rules with the settings of your deployment and
+ *       tenant (JOIN_ELIGIBILITY_RULES), without requesting anything. Every rule
+ *       is reported, not just the first that fails: notBlocked, feature,
+ *       idVerification, country, ageRestriction, ageRange, emailVerified,
+ *       trustScore and quota. The country and tenant are those of your profile.
+ *       notBlocked, feature, idVerification and ageRestriction are always on. Whether you are already a member or the
+ *       trip is full is not part of it.
+ *     tags: [Group Join Requests]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: "{ groupId, userId, eligible, country, tenant, rules: [{ rule, enabled, passed, errorCode, reason, details, settings }] }"
+ *       404:
+ *         description: Group not found
+ */
+router.get("/:groupId/join-eligibility", authenticate, groupJoinRequestController.checkEligibility);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/join-requests:
+ *   get:
+ *     summary: List the join requests of a group (admin only), in join order
+ *     tags: [Group Join Requests]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: query
+ *         name: status
+ *         schema:
+ *           type: string
+ *           enum: [pending, waitlisted, awaiting_rules, approved, rejected, cancelled]
+ *     responses:
+ *       200:
+ *         description: Join requests (the waitlisted ones in promotion order)
+ *       403:
+ *         description: Not the group admin
+ */
+router.get(
+  "/:groupId/join-requests",
+  authenticate,
+  groupJoinRequestController.getGroupRequests
+);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/join-requests/{requestId}:
+ *   patch:
+ *     summary: Approve or reject a pending join request (admin only)
+ *     description: Approving when the group is full moves the request to the waitlist. Waitlisted requests can be rejected.
+ *     tags: [Group Join Requests]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: requestId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required:
+ *               - action
+ *             properties:
+ *               action:
+ *                 type: string
+ *                 enum: [approve, reject]
+ *     responses:
+ *       200:
+ *         description: >
+ *           Request processed, the requester is notified. Approving a requester who
+ *           has not accepted the current trip rules leaves the request
+ *           `awaiting_rules` until they accept them.
+ *       403:
+ *         description: Not the group admin
+ *       409:
+ *         description: Request already processed
+ */
+router.patch(
+  "/:groupId/join-requests/:requestId",
+  authenticate,
+  groupJoinRequestController.decideRequest
+);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/join-requests/{requestId}:
+ *   delete:
+ *     summary: Cancel your own pending, waitlisted or awaiting_rules join request
+ *     tags: [Group Join Requests]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: requestId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Request cancelled
+ *       404:
+ *         description: Request not found
+ */
+router.delete(
+  "/:groupId/join-requests/:requestId",
+  authenticate,
+  groupJoinRequestController.cancelRequest
+);
+
+export default router;
@@ -6,7 +6,7 @@ const router = Router();
 
 /**
  * @swagger
- * /api/groups/{groupId}/messages:
+ * /api/trips/{id}/messages:
  *   post:
  *     summary: Send a message to a group
  *     tags: [Group Messages]
@@ -14,11 +14,11 @@ const router = Router();
  *       - bearerAuth: []
  *     parameters:
  *       - in: path
- *         name: groupId
+ *         name: id
  *         required: true
  *         schema:
  *           type: string
- *         description: ID of the group
+ *         description: ID of the trip
  *     requestBody:
  *       required: true
  *       content:
@@ -32,25 +32,34 @@ const router = Router();
  *                 type: string
  *                 description: Message content
  *                 example: "Hola a todos!"
+ *               clientMessageId:
+ *                 type: string
+ *                 maxLength: 64
+ *                 description: Client-generated ID used to deduplicate messages queued while offline
+ *                 example: "b6a0c2b4-offline-1"
  *     responses:
+ *       200:
+ *         description: Message was already sent with this clientMessageId (returned as-is)
  *       201:
  *         description: Message sent successfully
  *       400:
  *         description: Invalid input
  *       403:
- *         description: Not a member of the group
+ *         description: >
+ *           Not a member of the group, or the member has not accepted the
+ *           current trip rules (RULES_ACCEPTANCE_REQUIRED)
  *       404:
  *         description: Group not found
  */
 router.post(
-  "/:groupId/messages",
+  "/:id/messages",
   authenticate,
   groupMessageController.sendMessage
 );
 
 /**
  * @swagger
- * /api/groups/{groupId}/messages:
+ * /api/trips/{id}/messages:
  *   get:
  *     summary: Get messages from a group
  *     tags: [Group Messages]
@@ -58,11 +67,11 @@ router.post(
  *       - bearerAuth: []
  *     parameters:
  *       - in: path
- *         name: groupId
+ *         name: id
  *         required: true
  *         schema:
  *           type: string
- *         description: ID of the group
+ *         description: ID of the trip
  *       - in: query
  *         name: limit
  *         schema:
@@ -74,19 +83,234 @@ router.post(
  *         schema:
  *           type: integer
  *           default: 0
- *         description: Pagination offset
+ *         description: Pagination offset (ignored when `before` is present)
+ *       - in: query
+ *         name: before
+ *         schema:
+ *           type: string
+ *         description: >
+ *           Cursor pagination. Pass the ID of the oldest message already loaded to get
+ *           the previous page, or an empty value to get the newest page. The response
+ *           includes `nextCursor` while older messages remain.
  *     responses:
  *       200:
- *         description: Messages retrieved successfully
+ *         description: Messages retrieved successfully, with the caller's unread count and read marker and the chat retention
+ *       400:
+ *         description: Invalid cursor
  *       403:
  *         description: Not a member of the group
  *       404:
  *         description: Group not found
  */
 router.get(
-  "/:groupId/messages",
+  "/:id/messages",
   authenticate,
   groupMessageController.getMessages
 );
 
+/**
+ * @swagger
+ * /api/trips/{id}/messages/read:
+ *   post:
+ *     summary: Mark group messages as read up to a message
+ *     tags: [Group Messages]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *         description: ID of the trip
+ *     requestBody:
+ *       required: false
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             properties:
+ *               messageId:
+ *                 type: string
+ *                 description: Last message read (defaults to the latest message in the group)
+ *     responses:
+ *       200:
+ *         description: Read marker updated
+ *       403:
+ *         description: Not a member of the group
+ *       404:
+ *         description: Group or message not found
+ */
+router.post(
+  "/:id/messages/read",
+  authenticate,
+  groupMessageController.markAsRead
+);
+
+/**
+ * @swagger
+ * /api/trips/{id}/messages/read-receipts:
+ *   get:
+ *     summary: Get the read marker of every member of a group
+ *     tags: [Group Messages]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *         description: ID of the trip
+ *     responses:
+ *       200:
+ *         description: Read receipts retrieved successfully
+ *       403:
+ *         description: Not a member of the group
+ *       404:
+ *         description: Group not found
+ */
+router.get(
+  "/:id/messages/read-receipts",
+  authenticate,
+  groupMessageController.getReadReceipts
+);
+
+/**
+ * @swagger
+ * /api/trips/{id}/messages/retention:
+ *   get:
+ *     summary: Get how long the group chat is kept
+ *     description: >
+ *       `policy` is "forever" or "after_trip_90d" (messages are deleted 90 days
+ *       after the trip ends). `deletesAt` is set once the trip has an end date;
+ *       members are notified a week before and can export the chat until then.
+ *     tags: [Group Messages]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: "{ policy, deletesAt, purgedAt, description }"
+ *       403:
+ *         description: Not a member of the group
+ *       404:
+ *         description: Group not found
+ *   put:
+ *     summary: Choose how long the group chat is kept (organizer only)
+ *     description: Members are notified of the change.
+ *     tags: [Group Messages]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required:
+ *               - policy
+ *             properties:
+ *               policy:
+ *                 type: string
+ *                 enum: [forever, after_trip_90d]
+ *     responses:
+ *       200:
+ *         description: Retention updated
+ *       400:
+ *         description: Invalid policy, or the chat was already deleted
+ *       403:
+ *         description: Not the organizer
+ *       404:
+ *         description: Group not found
+ */
+router.get(
+  "/:id/messages/retention",
+  authenticate,
+  groupMessageController.getRetention
+);
+router.put(
+  "/:id/messages/retention",
+  authenticate,
+  groupMessageController.setRetention
+);
+
+/**
+ * @swagger
+ * /api/trips/{id}/messages/export:
+ *   get:
+ *     summary: Download the whole group chat as JSON
+ *     description: >
+ *       Any member can keep a copy, e.g. before the chat is deleted by its
+ *       retention policy. Messages of blocked users are left out, as in the chat.
+ *     tags: [Group Messages]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: "{ group, exportedAt, retention, messages: [{ id, senderId, senderName, content, createdAt }] }"
+ *       403:
+ *         description: Not a member of the group
+ *       404:
+ *         description: Group not found
+ */
+router.get(
+  "/:id/messages/export",
+  authenticate,
+  groupMessageController.exportChat
+);
+
+/**
+ * @swagger
+ * /api/trips/{id}/messages/{messageId}:
+ *   delete:
+ *     summary: Delete a group message
+ *     description: Only the sender or the group admin. The message is soft-deleted and can be restored by a platform admin.
+ *     tags: [Group Messages]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *         description: ID of the trip
+ *       - in: path
+ *         name: messageId
+ *         required: true
+ *         schema:
+ *           type: string
+ *         description: ID of the message
+ *     responses:
+ *       200:
+ *         description: Message deleted
+ *       403:
+ *         description: Not the sender nor the group admin
+ *       404:
+ *         description: Group or message not found
+ */
+router.delete(
+  "/:id/messages/:messageId",
+  authenticate,
+  groupMessageController.deleteMessage
+);
+
 export default router;
@@ -0,0 +1,177 @@
+import { Router } from "express";
+import { authenticate } from "../middleware/auth.middleware.js";
+import groupSuccessionController from "../controllers/groupSuccession.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * tags:
+ *   name: Group Succession
+ *   description: Handover of trips whose organizer was suspended or abandoned them
+ */
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/succession:
+ *   get:
+ *     summary: Get the succession in progress of a trip
+ *     description: >
+ *       Started when the organizer is suspended or has not been seen for a
+ *       while before departure. While voting, includes the candidates with
+ *       their votes and the caller's vote. `data` is null when there is none.
+ *     tags: [Group Succession]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Succession in progress, or null
+ *       403:
+ *         description: Not a member of the group
+ *       404:
+ *         description: Group not found
+ *   delete:
+ *     summary: Cancel the succession and keep organizing the trip
+ *     tags: [Group Succession]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Succession cancelled
+ *       403:
+ *         description: Not the organizer the trip is being taken from
+ *       404:
+ *         description: No succession in progress
+ */
+router.get("/:groupId/succession", authenticate, groupSuccessionController.getSuccession);
+router.delete("/:groupId/succession", authenticate, groupSuccessionController.cancelSuccession);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/succession/offer:
+ *   post:
+ *     summary: Accept or decline organizing the trip
+ *     description: Declining, or letting the offer expire, passes it to the next candidate.
+ *     tags: [Group Succession]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [accept]
+ *             properties:
+ *               accept:
+ *                 type: boolean
+ *     responses:
+ *       200:
+ *         description: Offer answered
+ *       400:
+ *         description: accept missing
+ *       409:
+ *         description: The trip is not offered to the caller
+ */
+router.post("/:groupId/succession/offer", authenticate, groupSuccessionController.respondToOffer);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/succession/votes:
+ *   post:
+ *     summary: Vote for the new organizer
+ *     description: Voting again changes the vote. Ties go to the most senior member.
+ *     tags: [Group Succession]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [candidateId]
+ *             properties:
+ *               candidateId:
+ *                 type: string
+ *     responses:
+ *       200:
+ *         description: Vote recorded
+ *       400:
+ *         description: Candidate cannot organize the trip
+ *       403:
+ *         description: Not a member of the group
+ *       409:
+ *         description: No voting open
+ */
+router.post("/:groupId/succession/votes", authenticate, groupSuccessionController.vote);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/members/{userId}/co-organizer:
+ *   put:
+ *     summary: Mark or unmark a member as co-organizer (admin only)
+ *     description: Co-organizers are offered the trip first if the organizer is gone.
+ *     tags: [Group Succession]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: userId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [coOrganizer]
+ *             properties:
+ *               coOrganizer:
+ *                 type: boolean
+ *     responses:
+ *       200:
+ *         description: Co-organizer updated
+ *       403:
+ *         description: Not the group admin
+ *       404:
+ *         description: Group or member not found
+ */
+router.put(
+  "/:groupId/members/:userId/co-organizer",
+  authenticate,
+  groupSuccessionController.setCoOrganizer
+);
+
+export default router;
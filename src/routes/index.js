@@ -1,45 +1,20 @@
 import { Router } from "express";
-import authRoutes from "./auth.routes.js";
-import placesRoutes from "./places.routes.js";
-import mapsRoutes from "./maps.routes.js";
-import debugRoutes from "./debug.routes.js";
-import itineraryRoutes from "./itinerary.routes.js";
-import mediaRoutes from "./media.routes.js";
-import chatRoutes from "./chat.routes.js";
-import gamificationRoutes from "./gamification.routes.js";
-import cronRoutes from "./cron.routes.js";
-import usersRoutes from "./users.routes.js";
-import groupRoutes from "./group.routes.js";
-import groupMessageRoutes from "./groupMessage.routes.js";
-import directMessageRoutes from "./directMessage.routes.js";
-import expenseRoutes from "./expense.routes.js";
-import answerRoutes from "./answer.routes.js";
-import listRoutes from "./list.routes.js";
-import questionRoutes from "./question.routes.js";
-import notificationRoutes from "./notification.routes.js";
+import v1Routes from "./v1.routes.js";
 
-const router = Router();
+const apiRouter = Router();
 
-router.use("/auth", authRoutes);
-router.use("/places", placesRoutes);
-router.use("/maps", mapsRoutes);
-router.use("/debug", debugRoutes);
-router.use("/itineraries", itineraryRoutes);
-router.use("/media", mediaRoutes);
-router.use("/chat", chatRoutes);
-router.use("/users", usersRoutes);
-router.use("/direct-messages", directMessageRoutes);
-router.use("", gamificationRoutes);
-router.use("/cron", cronRoutes);
-router.use("/groups", groupRoutes);
-router.use("", expenseRoutes);
-router.use("", answerRoutes);
-router.use("/lists", listRoutes);
-router.use("/groups", groupMessageRoutes);
-router.use("", questionRoutes);
-router.use("/notifications", notificationRoutes);
+// One router per API version, built from the same controllers (see routeGroups.js)
+apiRouter.use("/api/v1", v1Routes);
 
-const apiRouter = Router();
-apiRouter.use("/api", router);
+// Unversioned paths predate /api/v1 and keep serving it for older app builds
+apiRouter.use(
+  "/api",
+  (req, res, next) => {
+    res.set("Deprecation", "true");
+    res.set("Link", `</api/v1${req.path}>; rel="successor-version"`);
+    next();
+  },
+  v1Routes
+);
 
 export default apiRouter;
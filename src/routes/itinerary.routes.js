@@ -345,13 +345,21 @@ router.get("/:id", authenticate, getItineraryById);
  *                 example: "Updated European Adventure"
  *               items:
  *                 type: array
- *                 description: Updated array of places to visit with dates
+ *                 description: >
+ *                   Updated array of places to visit with dates. Send the id of the
+ *                   items to keep: they are updated in place and keep their trip plan
+ *                   time, notes, cost and order. Items without an id are added at the
+ *                   end of their day; existing items left out are removed.
  *                 items:
  *                   type: object
  *                   required:
  *                     - placeId
  *                     - date
  *                   properties:
+ *                     id:
+ *                       type: string
+ *                       format: uuid
+ *                       description: ID of an existing item of this itinerary
  *                     placeId:
  *                       type: string
  *                       format: uuid
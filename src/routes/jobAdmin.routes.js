@@ -0,0 +1,264 @@
+import { Router } from "express";
+import { authenticate, authorize } from "../middleware/auth.middleware.js";
+import jobAdminController from "../controllers/jobAdmin.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/admin/jobs/queues:
+ *   get:
+ *     summary: Job queue depths
+ *     description: >
+ *       One entry per job type: pending, running, failed and quarantined jobs, when the oldest
+ *       due job was scheduled, whether the queue is paused and whether this
+ *       version of the API still handles the type.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: "[{ type, pending, running, failed, quarantined, oldestDueAt, paused, pausedAt, pauseReason, handled }]"
+ *       403:
+ *         description: Admin role required
+ */
+router.get("/queues", authenticate, authorize(["admin"]), jobAdminController.getQueues);
+
+/**
+ * @swagger
+ * /api/admin/jobs/queues/{type}/pause:
+ *   post:
+ *     summary: Pause a job queue
+ *     description: >
+ *       Workers stop picking up jobs of the type; running jobs finish and new
+ *       ones keep being enqueued until the queue is resumed.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: type
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             properties:
+ *               reason:
+ *                 type: string
+ *                 maxLength: 500
+ *     responses:
+ *       200:
+ *         description: Queue paused
+ *       409:
+ *         description: Queue already paused
+ */
+router.post("/queues/:type/pause", authenticate, authorize(["admin"]), jobAdminController.pauseQueue);
+
+/**
+ * @swagger
+ * /api/admin/jobs/queues/{type}/resume:
+ *   post:
+ *     summary: Resume a paused job queue
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: type
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Queue resumed
+ *       404:
+ *         description: Queue not paused
+ */
+router.post("/queues/:type/resume", authenticate, authorize(["admin"]), jobAdminController.resumeQueue);
+
+/**
+ * @swagger
+ * /api/admin/jobs/queues/{type}/retry-failed:
+ *   post:
+ *     summary: Requeue every failed (or quarantined) job of a type
+ *     description: The jobs get a fresh set of attempts and run as soon as a worker is free.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: type
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             properties:
+ *               status:
+ *                 type: string
+ *                 enum: [failed, quarantined]
+ *                 default: failed
+ *     responses:
+ *       200:
+ *         description: "{ type, status, requeued }"
+ */
+router.post(
+  "/queues/:type/retry-failed",
+  authenticate,
+  authorize(["admin"]),
+  jobAdminController.retryFailedOfType
+);
+
+/**
+ * @swagger
+ * /api/admin/jobs/failed:
+ *   get:
+ *     summary: Failed jobs
+ *     description: Jobs that ran out of attempts, most recently failed first, with their last error and a payload preview.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: type
+ *         schema:
+ *           type: string
+ *       - in: query
+ *         name: limit
+ *         schema:
+ *           type: integer
+ *           default: 50
+ *           maximum: 200
+ *       - in: query
+ *         name: offset
+ *         schema:
+ *           type: integer
+ *           default: 0
+ *     responses:
+ *       200:
+ *         description: "{ jobs, total, limit, offset }"
+ *       400:
+ *         description: Invalid limit or offset
+ */
+router.get("/failed", authenticate, authorize(["admin"]), jobAdminController.listFailed);
+
+/**
+ * @swagger
+ * /api/admin/jobs/quarantined:
+ *   get:
+ *     summary: Quarantined jobs
+ *     description: >
+ *       Jobs taken out of the queue because their payload failed
+ *       JOBS_POISON_THRESHOLD times across jobs in the last 7 days. New jobs with
+ *       the same payload are quarantined on enqueue until these are retried or
+ *       discarded. Each job has its last error and, when the failure was a crash
+ *       (a bug in the handler), its stack.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: type
+ *         schema:
+ *           type: string
+ *       - in: query
+ *         name: limit
+ *         schema:
+ *           type: integer
+ *           default: 50
+ *           maximum: 200
+ *       - in: query
+ *         name: offset
+ *         schema:
+ *           type: integer
+ *           default: 0
+ *     responses:
+ *       200:
+ *         description: "{ jobs, total, limit, offset }"
+ */
+router.get("/quarantined", authenticate, authorize(["admin"]), jobAdminController.listQuarantined);
+
+/**
+ * @swagger
+ * /api/admin/jobs/error-rates:
+ *   get:
+ *     summary: Job error rates against the error budget
+ *     description: >
+ *       Attempts per job type over the last `hours`: succeeded, failed and crashed
+ *       (failures caused by a bug rather than a dependency), the error rate, and how
+ *       many more failures the JOBS_ERROR_BUDGET tolerates at this volume.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: hours
+ *         schema:
+ *           type: integer
+ *           default: 24
+ *           maximum: 168
+ *     responses:
+ *       200:
+ *         description: "{ hours, errorBudget, types: [{ type, attempts, succeeded, failed, crashed, errorRate, budgetRemaining, budgetExhausted }] }"
+ *       400:
+ *         description: Invalid hours
+ */
+router.get("/error-rates", authenticate, authorize(["admin"]), jobAdminController.getErrorRates);
+
+/**
+ * @swagger
+ * /api/admin/jobs/{jobId}/retry:
+ *   post:
+ *     summary: Requeue a failed or quarantined job
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: jobId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Job requeued
+ *       404:
+ *         description: Job not found
+ *       409:
+ *         description: Job is neither failed nor quarantined
+ */
+router.post("/:jobId/retry", authenticate, authorize(["admin"]), jobAdminController.retryJob);
+
+/**
+ * @swagger
+ * /api/admin/jobs/{jobId}/discard:
+ *   post:
+ *     summary: Discard a failed or quarantined job
+ *     description: The job is kept with status "discarded" and never retried.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: jobId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Job discarded
+ *       404:
+ *         description: Job not found
+ *       409:
+ *         description: Job is neither failed nor quarantined
+ */
+router.post("/:jobId/discard", authenticate, authorize(["admin"]), jobAdminController.discardJob);
+
+export default router;
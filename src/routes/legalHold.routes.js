@@ -0,0 +1,218 @@
+import { Router } from "express";
+import { authenticate, authorize } from "../middleware/auth.middleware.js";
+import legalHoldController from "../controllers/legalHold.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * tags:
+ *   name: Admin Legal
+ *   description: >
+ *     Legal holds and targeted data exports for compliance requests. A hold blocks
+ *     every deletion of the account's data (423 LEGAL_HOLD) until released. Every
+ *     action, including viewing and downloading exports, is written to the access log.
+ */
+
+/**
+ * @swagger
+ * /api/admin/legal/holds:
+ *   post:
+ *     summary: Place a legal hold on an account
+ *     tags: [Admin Legal]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required:
+ *               - userId
+ *               - reference
+ *             properties:
+ *               userId:
+ *                 type: string
+ *               reference:
+ *                 type: string
+ *                 maxLength: 150
+ *                 description: Case number of the request
+ *               reason:
+ *                 type: string
+ *     responses:
+ *       201:
+ *         description: Hold created
+ *       403:
+ *         description: Admin role required
+ *       404:
+ *         description: User not found
+ *       409:
+ *         description: The user already has an active hold
+ *   get:
+ *     summary: List legal holds
+ *     tags: [Admin Legal]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: userId
+ *         schema:
+ *           type: string
+ *       - in: query
+ *         name: status
+ *         schema:
+ *           type: string
+ *           enum: [active, released]
+ *     responses:
+ *       200:
+ *         description: Holds, newest first
+ */
+router.post("/holds", authenticate, authorize(["admin"]), legalHoldController.createHold);
+router.get("/holds", authenticate, authorize(["admin"]), legalHoldController.getHolds);
+
+/**
+ * @swagger
+ * /api/admin/legal/holds/{id}/release:
+ *   post:
+ *     summary: Release a legal hold
+ *     tags: [Admin Legal]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Hold released
+ *       404:
+ *         description: Hold not found
+ *       409:
+ *         description: Hold already released
+ */
+router.post(
+  "/holds/:id/release",
+  authenticate,
+  authorize(["admin"]),
+  legalHoldController.releaseHold
+);
+
+/**
+ * @swagger
+ * /api/admin/legal/exports:
+ *   post:
+ *     summary: Request a complete export of an account's data
+ *     description: >
+ *       Generated in the background as a JSON file with every row referencing the
+ *       user (credentials excluded). Poll `/api/admin/legal/exports/{id}` until `ready`.
+ *     tags: [Admin Legal]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required:
+ *               - userId
+ *               - reference
+ *             properties:
+ *               userId:
+ *                 type: string
+ *               reference:
+ *                 type: string
+ *                 maxLength: 150
+ *     responses:
+ *       202:
+ *         description: Export queued
+ *       404:
+ *         description: User not found
+ *       503:
+ *         description: Storage not configured
+ */
+router.post("/exports", authenticate, authorize(["admin"]), legalHoldController.requestExport);
+
+/**
+ * @swagger
+ * /api/admin/legal/exports/{id}:
+ *   get:
+ *     summary: Get the status of an export (logged)
+ *     tags: [Admin Legal]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Export with row counts per table and SHA-256 of the file
+ *       404:
+ *         description: Export not found
+ */
+router.get("/exports/:id", authenticate, authorize(["admin"]), legalHoldController.getExport);
+
+/**
+ * @swagger
+ * /api/admin/legal/exports/{id}/download:
+ *   get:
+ *     summary: Get a temporary download URL for an export (logged)
+ *     tags: [Admin Legal]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Download URL valid for 5 minutes
+ *       409:
+ *         description: Export not ready yet
+ */
+router.get(
+  "/exports/:id/download",
+  authenticate,
+  authorize(["admin"]),
+  legalHoldController.getExportDownloadUrl
+);
+
+/**
+ * @swagger
+ * /api/admin/legal/access-log:
+ *   get:
+ *     summary: List the access log of the legal tool
+ *     tags: [Admin Legal]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: userId
+ *         schema:
+ *           type: string
+ *         description: Target account
+ *       - in: query
+ *         name: adminId
+ *         schema:
+ *           type: string
+ *       - in: query
+ *         name: limit
+ *         schema:
+ *           type: integer
+ *           default: 100
+ *           maximum: 500
+ *     responses:
+ *       200:
+ *         description: Entries, newest first
+ */
+router.get("/access-log", authenticate, authorize(["admin"]), legalHoldController.getAccessLog);
+
+export default router;
@@ -102,7 +102,27 @@ const router = Router();
 router.post("/", authenticate, createList);
 router.get("/", authenticate, getUserLists);
 
-// Public route to search lists (must be registered before /:id to avoid param collisions)
+/**
+ * @swagger
+ * /api/lists/search:
+ *   get:
+ *     summary: Search public lists
+ *     tags: [Lists]
+ *     parameters:
+ *       - in: query
+ *         name: q
+ *         schema:
+ *           type: string
+ *         description: Text in the list title or description
+ *       - in: query
+ *         name: city
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Matching public lists
+ */
+// Must be registered before /:id to avoid param collisions
 router.get("/search", searchPublicLists);
 
 /**
@@ -405,10 +425,22 @@ router.delete("/:id", authenticate, deleteList);
 router.post("/:listId/places/:placeId", authenticate, addPlaceToList);
 router.delete("/:listId/places/:placeId", authenticate, removePlaceFromList);
 
-// Public route to get lists by author (no authentication required)
+/**
+ * @swagger
+ * /api/lists/author/{authorId}:
+ *   get:
+ *     summary: Public lists of a user
+ *     tags: [Lists]
+ *     parameters:
+ *       - in: path
+ *         name: authorId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Public lists of the author
+ */
 router.get("/author/:authorId", getPublicListsByAuthor);
 
-// Public route to search lists (must be registered before /:id to avoid param collisions)
-router.get("/search", searchPublicLists);
-
 export default router;
\ No newline at end of file
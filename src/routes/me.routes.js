@@ -0,0 +1,48 @@
+import { Router } from "express";
+import meController from "../controllers/me.controller.js";
+import { authenticate } from "../middleware/auth.middleware.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/me/dashboard:
+ *   get:
+ *     summary: Participant home screen
+ *     description: >
+ *       Upcoming groups with countdown, outstanding tasks (unpaid expenses, pending
+ *       join requests), unread counts and suggested trips. Sections are loaded
+ *       independently: a section that fails is returned as null and listed in `errors`.
+ *     tags: [Me]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: Dashboard data
+ *       401:
+ *         description: Unauthorized
+ */
+router.get("/dashboard", authenticate, meController.getDashboard);
+
+/**
+ * @swagger
+ * /api/me/badges:
+ *   get:
+ *     summary: Unread badge counters
+ *     description: >
+ *       Cheap enough to poll every minute. messages counts unread direct and group
+ *       messages, joinRequests the pending requests on groups you organize and
+ *       unpaidBalances the unassigned expenses of your groups. Counters are kept
+ *       in Redis when REDIS_URL is set.
+ *     tags: [Me]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: "{ messages, notifications, joinRequests, unpaidBalances }"
+ *       401:
+ *         description: Unauthorized
+ */
+router.get("/badges", authenticate, meController.getBadges);
+
+export default router;
@@ -0,0 +1,250 @@
+import { Router } from "express";
+import { authenticate, authorize } from "../middleware/auth.middleware.js";
+import moderationController from "../controllers/moderation.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * tags:
+ *   name: Moderation
+ *   description: >
+ *     Bulk moderation actions and automation rules. Rules hide a trip, a trip
+ *     review or everything a user published once enough distinct users
+ *     reported it (optionally only reporters with a minimum trust score, only
+ *     some reasons or only recent reports). A rule acts once per target, so
+ *     content an admin restores is not hidden again by the same rule; hidden
+ *     content and its reports stay for an admin to review.
+ */
+
+/**
+ * @swagger
+ * /api/admin/moderation/users/{userId}/hide-content:
+ *   post:
+ *     summary: Hide every trip and trip review of a user
+ *     description: >
+ *       Listed and scheduled trips are made private and visible reviews hidden.
+ *       With `resolveReports` the open reports against the user are actioned
+ *       as `content_hidden`. The user is notified.
+ *     tags: [Moderation]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: userId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             properties:
+ *               resolveReports:
+ *                 type: boolean
+ *                 default: false
+ *               notes:
+ *                 type: string
+ *     responses:
+ *       200:
+ *         description: "{ tripsHidden, reviewsHidden, reportsResolved }"
+ *       404:
+ *         description: User not found
+ */
+router.post(
+  "/users/:userId/hide-content",
+  authenticate,
+  authorize(["admin"]),
+  moderationController.hideUserContent
+);
+
+/**
+ * @swagger
+ * /api/admin/moderation/reports/dismiss:
+ *   post:
+ *     summary: Dismiss several reports at once
+ *     description: Reports no longer open are skipped.
+ *     tags: [Moderation]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [reportIds]
+ *             properties:
+ *               reportIds:
+ *                 type: array
+ *                 maxItems: 500
+ *                 items:
+ *                   type: string
+ *               notes:
+ *                 type: string
+ *     responses:
+ *       200:
+ *         description: "{ dismissed, skipped }"
+ */
+router.post("/reports/dismiss", authenticate, authorize(["admin"]), moderationController.dismissReports);
+
+/**
+ * @swagger
+ * /api/admin/moderation/reports/dismiss-duplicates:
+ *   post:
+ *     summary: Dismiss duplicate open reports
+ *     description: >
+ *       Dismisses open reports that repeat an older open report from the same
+ *       reporter on the same trip (or user, for user reports) with the same
+ *       reason; the oldest one is kept. High severity reports (safety
+ *       incidents) are never dismissed.
+ *     tags: [Moderation]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             properties:
+ *               targetType:
+ *                 type: string
+ *                 enum: [user, trip]
+ *     responses:
+ *       200:
+ *         description: "{ targets, dismissed }"
+ */
+router.post(
+  "/reports/dismiss-duplicates",
+  authenticate,
+  authorize(["admin"]),
+  moderationController.dismissDuplicateReports
+);
+
+/**
+ * @swagger
+ * /api/admin/moderation/rules:
+ *   get:
+ *     summary: Automation rules
+ *     tags: [Moderation]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: "[{ id, name, targetType, action, minReporters, minTrustScore, reasons, windowHours, enabled, triggerCount, lastTriggeredAt }]"
+ *   post:
+ *     summary: Create an automation rule
+ *     tags: [Moderation]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [name, targetType, minReporters]
+ *             properties:
+ *               name:
+ *                 type: string
+ *                 maxLength: 100
+ *               targetType:
+ *                 type: string
+ *                 enum: [trip, user, trip_review]
+ *                 description: trip and user count user reports, trip_review counts review flags
+ *               minReporters:
+ *                 type: integer
+ *                 minimum: 2
+ *                 description: Distinct reporters needed
+ *               minTrustScore:
+ *                 type: integer
+ *                 minimum: 0
+ *                 maximum: 100
+ *                 default: 0
+ *                 description: Reporters with a lower trust score are not counted
+ *               reasons:
+ *                 type: array
+ *                 items:
+ *                   type: string
+ *                 description: Report (or flag) reasons that count; empty counts any
+ *               windowHours:
+ *                 type: integer
+ *                 nullable: true
+ *                 description: Only reports of the last hours count; null counts every open report
+ *               enabled:
+ *                 type: boolean
+ *                 default: true
+ *     responses:
+ *       201:
+ *         description: Rule created
+ *       400:
+ *         description: Invalid rule
+ */
+router.get("/rules", authenticate, authorize(["admin"]), moderationController.listRules);
+router.post("/rules", authenticate, authorize(["admin"]), moderationController.createRule);
+
+/**
+ * @swagger
+ * /api/admin/moderation/rules/{ruleId}:
+ *   patch:
+ *     summary: Change an automation rule
+ *     description: Same fields as creating one. Targets it already acted on are not evaluated again.
+ *     tags: [Moderation]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: ruleId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Rule updated
+ *       404:
+ *         description: Rule not found
+ *   delete:
+ *     summary: Delete an automation rule
+ *     description: Content it hid stays hidden.
+ *     tags: [Moderation]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: ruleId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Rule deleted
+ *       404:
+ *         description: Rule not found
+ */
+router.patch("/rules/:ruleId", authenticate, authorize(["admin"]), moderationController.updateRule);
+router.delete("/rules/:ruleId", authenticate, authorize(["admin"]), moderationController.deleteRule);
+
+/**
+ * @swagger
+ * /api/admin/moderation/rules/{ruleId}/hits:
+ *   get:
+ *     summary: Targets a rule acted on, newest first
+ *     tags: [Moderation]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: ruleId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: "[{ id, ruleId, targetType, targetId, reporterIds, createdAt }]"
+ *       404:
+ *         description: Rule not found
+ */
+router.get("/rules/:ruleId/hits", authenticate, authorize(["admin"]), moderationController.getRuleHits);
+
+export default router;
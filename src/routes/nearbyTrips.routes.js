@@ -0,0 +1,54 @@
+import { Router } from "express";
+import { authenticate } from "../middleware/auth.middleware.js";
+import groupController from "../controllers/group.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/trips/nearby:
+ *   get:
+ *     summary: Find public groups with a destination near a location
+ *     tags: [Groups]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: size
+ *         schema:
+ *           type: string
+ *           enum: [small, medium, large]
+ *         description: Only groups of this size (small = up to 6 travelers, medium = up to 12)
+ *       - in: query
+ *         name: lat
+ *         required: true
+ *         schema:
+ *           type: number
+ *       - in: query
+ *         name: lng
+ *         required: true
+ *         schema:
+ *           type: number
+ *       - in: query
+ *         name: radius_km
+ *         schema:
+ *           type: number
+ *           default: 50
+ *           maximum: 500
+ *       - in: query
+ *         name: limit
+ *         schema:
+ *           type: integer
+ *           default: 20
+ *           maximum: 100
+ *     responses:
+ *       200:
+ *         description: >
+ *           Groups that have not ended, sorted by `distanceKm`; groups matching the sizes in the
+ *           user's travelPreferences.groupSizes rank as if closer, others as if farther
+ *       400:
+ *         description: Invalid coordinates or radius
+ */
+router.get("/nearby", authenticate, groupController.getNearbyGroups);
+
+export default router;
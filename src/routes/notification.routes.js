@@ -4,27 +4,251 @@ import notificationController from "../controllers/notification.controller.js";
 
 const router = Router();
 
-// Get user notifications
+/**
+ * @swagger
+ * tags:
+ *   name: Notifications
+ *   description: In-app notification feed, devices and preferences
+ */
+
+/**
+ * @swagger
+ * /api/notifications:
+ *   get:
+ *     summary: Get the notification feed of the authenticated user (newest first)
+ *     tags: [Notifications]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: limit
+ *         schema:
+ *           type: integer
+ *           default: 50
+ *           maximum: 100
+ *       - in: query
+ *         name: before
+ *         schema:
+ *           type: string
+ *         description: Notification id cursor (nextCursor of the previous page)
+ *       - in: query
+ *         name: unreadOnly
+ *         schema:
+ *           type: boolean
+ *       - in: query
+ *         name: type
+ *         schema:
+ *           type: string
+ *         description: Filter by notification type (e.g. BADGE_EARNED)
+ *     responses:
+ *       200:
+ *         description: Notifications, unreadCount, hasMore and nextCursor
+ *       400:
+ *         description: Invalid cursor
+ */
 router.get("/", authenticate, notificationController.getNotifications);
 
-// Get unread count
+/**
+ * @swagger
+ * /api/notifications/unread/count:
+ *   get:
+ *     summary: Get the number of unread notifications
+ *     tags: [Notifications]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: Unread count
+ */
 router.get(
   "/unread/count",
   authenticate,
   notificationController.getUnreadCount
 );
 
-// Mark notification as read
+/**
+ * @swagger
+ * /api/notifications/{notificationId}/read:
+ *   patch:
+ *     summary: Mark one of the user's notifications as read
+ *     tags: [Notifications]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: notificationId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Marked as read, returns the remaining unreadCount
+ *       404:
+ *         description: Notification not found
+ */
 router.patch(
   "/:notificationId/read",
   authenticate,
   notificationController.markAsRead
 );
 
-// Mark all as read
+/**
+ * @swagger
+ * /api/notifications/read/all:
+ *   patch:
+ *     summary: Mark all notifications as read
+ *     tags: [Notifications]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: All notifications marked as read
+ */
 router.patch("/read/all", authenticate, notificationController.markAllAsRead);
 
-// Delete notification
+/**
+ * @swagger
+ * /api/notifications/devices:
+ *   post:
+ *     summary: Register a device token for push notifications
+ *     tags: [Notifications]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required:
+ *               - token
+ *               - platform
+ *             properties:
+ *               token:
+ *                 type: string
+ *                 description: FCM registration token or APNs device token
+ *               platform:
+ *                 type: string
+ *                 enum: [android, ios, web]
+ *               provider:
+ *                 type: string
+ *                 enum: [fcm, apns]
+ *                 description: Defaults to apns for iOS and fcm otherwise
+ *               deviceName:
+ *                 type: string
+ *                 example: "Pixel 8"
+ *     responses:
+ *       201:
+ *         description: Device registered
+ *       400:
+ *         description: Invalid token, platform or provider
+ */
+router.post("/devices", authenticate, notificationController.registerDevice);
+
+/**
+ * @swagger
+ * /api/notifications/devices/{token}:
+ *   delete:
+ *     summary: Unregister a device token (e.g. on logout)
+ *     tags: [Notifications]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: token
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Device unregistered
+ *       404:
+ *         description: Device not found
+ */
+router.delete(
+  "/devices/:token",
+  authenticate,
+  notificationController.unregisterDevice
+);
+
+/**
+ * @swagger
+ * /api/notifications/preferences:
+ *   get:
+ *     summary: Get the user's notification preferences
+ *     tags: [Notifications]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: Effective preferences (defaults to everything enabled)
+ *   put:
+ *     summary: Update the user's notification preferences
+ *     tags: [Notifications]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             properties:
+ *               pushEnabled:
+ *                 type: boolean
+ *               emailEnabled:
+ *                 type: boolean
+ *               categories:
+ *                 type: object
+ *                 description: Per-category channel overrides
+ *                 example: { "chat": { "push": false }, "trip_updates": { "email": true } }
+ *               quietHours:
+ *                 type: object
+ *                 nullable: true
+ *                 description: >
+ *                   Local time window (may cross midnight) in which push notifications
+ *                   are held and sent as one digest when it ends. Safety and payment
+ *                   events are still pushed right away. null disables it
+ *                 properties:
+ *                   start:
+ *                     type: string
+ *                     example: "22:00"
+ *                   end:
+ *                     type: string
+ *                     example: "08:00"
+ *               timezone:
+ *                 type: string
+ *                 description: IANA time zone of the quiet hours and of dates in push notifications
+ *                 example: America/Argentina/Buenos_Aires
+ *     responses:
+ *       200:
+ *         description: Preferences updated
+ *       400:
+ *         description: Invalid preferences
+ */
+router.get("/preferences", authenticate, notificationController.getPreferences);
+router.put("/preferences", authenticate, notificationController.updatePreferences);
+
+/**
+ * @swagger
+ * /api/notifications/{notificationId}:
+ *   delete:
+ *     summary: Delete a notification
+ *     tags: [Notifications]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: notificationId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Notification deleted
+ *       404:
+ *         description: Notification not found
+ */
 router.delete(
   "/:notificationId",
   authenticate,
@@ -0,0 +1,27 @@
+import { Router } from "express";
+import organizerController from "../controllers/organizer.controller.js";
+import { authenticate } from "../middleware/auth.middleware.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/organizer/dashboard:
+ *   get:
+ *     summary: Organizer home screen
+ *     description: >
+ *       Groups administered by the user that have not ended yet (dates come from the
+ *       assigned itinerary) with fill rate, pending join requests, unpaid expenses and
+ *       unread chat messages, plus the latest reviews of places in those itineraries.
+ *     tags: [Organizer]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: Dashboard data
+ *       401:
+ *         description: Unauthorized
+ */
+router.get("/dashboard", authenticate, organizerController.getDashboard);
+
+export default router;
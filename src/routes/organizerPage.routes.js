@@ -0,0 +1,76 @@
+import { Router } from "express";
+import cors from "cors";
+import organizerController from "../controllers/organizer.controller.js";
+import { optionalAuthenticate } from "../middleware/auth.middleware.js";
+import config from "../config/index.js";
+
+const router = Router();
+
+// The widget is fetched from partner sites: read-only, no cookies or credentials
+const widgetCors = cors({
+  origin: config.organizerWidget.allowedOrigins.includes("*")
+    ? "*"
+    : config.organizerWidget.allowedOrigins,
+  methods: ["GET"],
+  credentials: false,
+  maxAge: 86400,
+});
+
+/**
+ * @swagger
+ * /api/organizers/{organizerId}:
+ *   get:
+ *     summary: Public page of an organizer
+ *     description: >
+ *       Bio, rating and upcoming public trips. Bio and picture follow the organizer's
+ *       profile visibility. Only users with at least one public trip have a page.
+ *       Authentication is optional; anonymous responses are cacheable.
+ *     tags: [Organizers]
+ *     parameters:
+ *       - in: path
+ *         name: organizerId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: "{ id, name, bio, profilePicture, memberSince, rating, publicTrips, completedTrips, upcomingTrips, pageUrl }"
+ *       404:
+ *         description: Organizer not found
+ */
+router.get("/:organizerId", optionalAuthenticate, organizerController.getPublicPage);
+
+/**
+ * @swagger
+ * /api/organizers/{organizerId}/widget:
+ *   get:
+ *     summary: Embeddable organizer widget data
+ *     description: >
+ *       Compact organizer summary and next public trips, with links back to JoinTravel,
+ *       for partners to render on their own sites. CORS is open to the origins in
+ *       ORGANIZER_WIDGET_ORIGINS and responses are publicly cacheable.
+ *     tags: [Organizers]
+ *     security: []
+ *     parameters:
+ *       - in: path
+ *         name: organizerId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: query
+ *         name: limit
+ *         schema:
+ *           type: integer
+ *           default: 3
+ *           maximum: 10
+ *         description: Trips to include
+ *     responses:
+ *       200:
+ *         description: "{ organizer: { id, name, profilePicture, rating, completedTrips, url }, trips }"
+ *       404:
+ *         description: Organizer not found
+ */
+router.options("/:organizerId/widget", widgetCors);
+router.get("/:organizerId/widget", widgetCors, organizerController.getWidget);
+
+export default router;
@@ -0,0 +1,128 @@
+import { Router } from "express";
+import { authenticate } from "../middleware/auth.middleware.js";
+import { requireFeature, requireIdVerification } from "../middleware/policy.middleware.js";
+import { idempotent } from "../middleware/idempotency.middleware.js";
+import paymentController from "../controllers/payment.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * tags:
+ *   name: Payments
+ *   description: >
+ *     Trip deposits paid with Stripe. Status goes requires_payment → processing →
+ *     succeeded (or failed/canceled) and, when a join request is declined or
+ *     cancelled or the member loses their spot, refund_pending → refunded.
+ */
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/payments/deposit:
+ *   post:
+ *     summary: Create the deposit payment of your join request
+ *     description: >
+ *       Returns the Stripe `clientSecret` to confirm the payment from the app.
+ *       Calling it again resumes the same payment.
+ *     tags: [Payments]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - $ref: '#/components/parameters/IdempotencyKey'
+ *     responses:
+ *       201:
+ *         description: Payment and client secret
+ *       400:
+ *         description: The trip has no deposit or there is no open join request
+ *       403:
+ *         description: >
+ *           Payments unavailable in the user's country (FEATURE_UNAVAILABLE), or
+ *           the policy requires a verified identity (ID_VERIFICATION_REQUIRED)
+ *       409:
+ *         description: Deposit already paid
+ *       503:
+ *         description: Payments not configured
+ *   get:
+ *     summary: List the payments of a group (admin only)
+ *     tags: [Payments]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Payments, newest first
+ *       403:
+ *         description: Not the group admin
+ */
+router.post(
+  "/groups/:groupId/payments/deposit",
+  authenticate,
+  requireFeature("payments"),
+  requireIdVerification,
+  idempotent,
+  paymentController.createDepositIntent
+);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/payments:
+ *   get:
+ *     summary: Payments of a group
+ *     tags: [Payments]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Payments of the group, newest first
+ *       403:
+ *         description: Not the group admin
+ *       404:
+ *         description: Group not found
+ */
+router.get("/groups/:groupId/payments", authenticate, paymentController.getGroupPayments);
+
+/**
+ * @swagger
+ * /api/payments/me:
+ *   get:
+ *     summary: List your payments
+ *     tags: [Payments]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: Payments, newest first
+ */
+router.get("/payments/me", authenticate, paymentController.getMyPayments);
+
+/**
+ * @swagger
+ * /api/payments/webhook:
+ *   post:
+ *     summary: Stripe webhook (signed with STRIPE_WEBHOOK_SECRET)
+ *     tags: [Payments]
+ *     responses:
+ *       200:
+ *         description: Event processed
+ *       400:
+ *         description: Invalid or expired signature
+ */
+router.post("/payments/webhook", paymentController.handleWebhook);
+
+export default router;
@@ -593,6 +593,18 @@ router.get("/reviews", getAllReviews);
  *             schema:
  *               $ref: '#/components/schemas/Error'
  */
+/**
+ * @swagger
+ * /api/places/favorites:
+ *   get:
+ *     summary: Places the user marked as favorite
+ *     tags: [Places]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: Favorite places
+ */
 router.get("/favorites", authenticate, getUserFavorites);
 
 router.get("/:id", getPlaceById);
@@ -0,0 +1,69 @@
+import { Router } from "express";
+import rateLimit from "express-rate-limit";
+import pollController from "../controllers/poll.controller.js";
+import { authenticate } from "../middleware/auth.middleware.js";
+import { deadline } from "../middleware/requestContext.middleware.js";
+import config from "../config/index.js";
+
+const router = Router();
+
+// Polls answered at once (events waiting) loop straight back; this caps the loop
+const pollLimiter = rateLimit({
+  windowMs: 60 * 1000,
+  max: 120,
+  keyGenerator: (req) => req.user.id,
+  message: {
+    success: false,
+    message: "Demasiados pedidos, prueba de nuevo en un minuto.",
+    errorCode: "RATE_LIMITED",
+  },
+  standardHeaders: true,
+  legacyHeaders: false,
+});
+
+/**
+ * @swagger
+ * /api/poll:
+ *   get:
+ *     summary: Realtime events by long polling
+ *     description: >
+ *       Fallback for clients that cannot use the WebSocket (e.g. embedded
+ *       webviews); delivers the same events (new_notification, new_message,
+ *       new_group_message, read_state_updated...) for the user and their groups.
+ *       Call without a cursor to get the starting one, then call again with the
+ *       cursor of each response. The request is held up to 25 seconds until an
+ *       event arrives. Cursors are valid on any API instance. reset=true
+ *       means events were missed (more than 2 minutes without polling): reload
+ *       state, e.g. GET /api/sync/read-state, and continue with the new cursor. Each
+ *       connection holds one poll (a new one ends the previous); send
+ *       X-Poll-Connection to keep several tabs apart.
+ *     tags: [Notifications]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: cursor
+ *         schema:
+ *           type: string
+ *       - in: header
+ *         name: X-Poll-Connection
+ *         description: Client-chosen connection ID (letters, digits, - and _)
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: "{ events: [{ id, event, data, at }], cursor, reset, hasMore }"
+ *       429:
+ *         description: Too many polls, or too many connections held for the user
+ *       503:
+ *         description: The server holds too many polls; retry shortly
+ */
+router.get(
+  "/",
+  authenticate,
+  deadline((config.realtime.pollHoldSeconds + 10) * 1000),
+  pollLimiter,
+  pollController.poll
+);
+
+export default router;
@@ -0,0 +1,63 @@
+import { Router } from "express";
+import { authenticate } from "../middleware/auth.middleware.js";
+import recommendationController from "../controllers/recommendation.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * tags:
+ *   name: Recommendations
+ *   description: Personalized trip recommendations
+ */
+
+/**
+ * @swagger
+ * /api/recommendations:
+ *   get:
+ *     summary: Get the trips currently recommended to the authenticated user
+ *     tags: [Recommendations]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: limit
+ *         schema:
+ *           type: integer
+ *           default: 10
+ *           maximum: 50
+ *     responses:
+ *       200:
+ *         description: Ranked recommendations
+ *       401:
+ *         description: Unauthorized
+ */
+router.get("/", authenticate, recommendationController.getMyRecommendations);
+
+/**
+ * @swagger
+ * /api/recommendations/click/{emailId}/{groupId}:
+ *   get:
+ *     summary: Track a click on a recommendation email and redirect to the trip
+ *     description: Clicked trips boost their destination in the user's future recommendations.
+ *     tags: [Recommendations]
+ *     parameters:
+ *       - in: path
+ *         name: emailId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       302:
+ *         description: Redirect to the trip in the frontend
+ *       404:
+ *         description: Recommendation not found
+ */
+router.get("/click/:emailId/:groupId", recommendationController.trackClick);
+
+export default router;
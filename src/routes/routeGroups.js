@@ -0,0 +1,73 @@
+import { Router } from "express";
+import { authenticate, authorize, requireTwoFactor } from "../middleware/auth.middleware.js";
+import { enforceUserCostQuota } from "../middleware/usage.middleware.js";
+import { authenticateScheduler } from "../middleware/scheduler.middleware.js";
+
+/**
+ * Middleware every route of a group goes through, in mount order.
+ * - public: no session required (routes may still authenticate on their own)
+ * - websocket: HTTP side of the realtime layer (state the socket also pushes)
+ * - authenticated: a valid session, within the user's daily cost quota
+ * - admin: a valid session with the admin role that went through 2FA
+ * - scheduler: the external scheduler, by CRON_SECRET instead of a session
+ */
+export const GROUP_MIDDLEWARE = {
+  public: [],
+  websocket: [authenticate, enforceUserCostQuota],
+  authenticated: [authenticate, enforceUserCostQuota],
+  admin: [authenticate, authorize(["admin"]), requireTwoFactor],
+  scheduler: [authenticateScheduler],
+};
+
+export const ROUTE_GROUPS = Object.keys(GROUP_MIDDLEWARE);
+
+/**
+ * Router that only takes requests under the given prefixes (":param"
+ * segments match any value) and leaves the rest to the next mount
+ * @param {string[]} prefixes - e.g. ["/groups/:groupId/expenses", "/expenses"]
+ * @param {Function[]} handlers
+ * @returns {Router}
+ */
+const scopeTo = (prefixes, handlers) => {
+  const patterns = prefixes.map((prefix) => new RegExp(`^${prefix.replace(/:[^/]+/g, "[^/]+")}(?:/|$)`));
+  const scoped = Router();
+  scoped.use((req, res, next) => {
+    const inScope = patterns.some((pattern) => pattern.test(req.path));
+    next(inScope ? undefined : "router");
+  });
+  scoped.use(...handlers);
+  return scoped;
+};
+
+/**
+ * Builds the router of an API version from its route groups. Each group is a
+ * list of [path, router] mounts; the group middleware is applied per mount so
+ * it never runs for requests meant for another group. Routers whose routes
+ * carry their full paths are mounted at "" and, in groups with middleware,
+ * list the prefixes of those paths: ["", router, ["/expenses", ...]]. Paths
+ * matching no mount then fall through to a 404 instead of a 401.
+ *
+ * A new version reuses the routers (and controllers) of the previous one and
+ * only replaces the mounts that changed:
+ *
+ *   const v2 = { ...v1, public: [...v1.public.filter(...), ["/groups", groupRoutesV2]] };
+ *
+ * @param {Object} groups - { public, websocket, authenticated, admin, scheduler }
+ * @returns {Router}
+ */
+export const createVersionRouter = (groups) => {
+  const router = Router();
+  for (const group of ROUTE_GROUPS) {
+    const middleware = GROUP_MIDDLEWARE[group];
+    for (const [path, routes, prefixes] of groups[group] || []) {
+      if (prefixes) {
+        router.use(path, scopeTo(prefixes, [...middleware, routes]));
+      } else if (!path && middleware.length > 0) {
+        throw new Error(`Routers mounted at the root of the ${group} group must list their prefixes`);
+      } else {
+        router.use(path, ...middleware, routes);
+      }
+    }
+  }
+  return router;
+};
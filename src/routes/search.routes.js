@@ -0,0 +1,52 @@
+import { Router } from "express";
+import searchController from "../controllers/search.controller.js";
+import { authenticate } from "../middleware/auth.middleware.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/search:
+ *   get:
+ *     summary: Full-text search across trips, destinations and users
+ *     description: >
+ *       Results of every type are ranked together by relevance (name matches weigh
+ *       more than destination/city, which weigh more than descriptions). Only public
+ *       trips and confirmed users are returned. Supports web-style syntax:
+ *       "quoted phrases", `or` and `-excluded` words.
+ *     tags: [Search]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: q
+ *         required: true
+ *         schema:
+ *           type: string
+ *           minLength: 2
+ *           maxLength: 100
+ *       - in: query
+ *         name: type
+ *         schema:
+ *           type: string
+ *         description: Comma-separated types to include (trip, destination, user). Defaults to all.
+ *       - in: query
+ *         name: limit
+ *         schema:
+ *           type: integer
+ *           default: 20
+ *           maximum: 50
+ *       - in: query
+ *         name: offset
+ *         schema:
+ *           type: integer
+ *           default: 0
+ *     responses:
+ *       200:
+ *         description: Ranked results with `hasMore`
+ *       400:
+ *         description: Invalid query or type
+ */
+router.get("/", authenticate, searchController.search);
+
+export default router;
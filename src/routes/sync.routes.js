@@ -0,0 +1,70 @@
+import { Router } from "express";
+import { authenticate } from "../middleware/auth.middleware.js";
+import readStateController from "../controllers/readState.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/sync/read-state:
+ *   get:
+ *     summary: Read state across devices
+ *     description: >
+ *       Unread badges, unread direct messages per conversation and the read marker
+ *       of every group chat. Clients fetch it on start and on reconnect; while
+ *       connected, changes made on other devices arrive as the "read_state_updated"
+ *       socket event ({ scope, ...details, badges, serverTime }).
+ *     tags: [Notifications]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: >
+ *           badges ({ notifications, directMessages, groups }),
+ *           directMessages ([{ conversationId, otherUserId, unreadCount }]),
+ *           groups ([{ groupId, lastReadMessageId, lastReadAt, unreadCount }]) and serverTime
+ *   post:
+ *     summary: Apply reads made on a device
+ *     description: >
+ *       Marks the given notifications, conversations and groups as read (e.g. reads
+ *       queued while offline), broadcasts them to the user's other devices and
+ *       returns the resulting read state.
+ *     tags: [Notifications]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             properties:
+ *               notificationIds:
+ *                 type: array
+ *                 items:
+ *                   type: string
+ *               conversations:
+ *                 type: array
+ *                 description: IDs of the other user of each direct conversation
+ *                 items:
+ *                   type: string
+ *               groups:
+ *                 type: array
+ *                 items:
+ *                   type: object
+ *                   properties:
+ *                     groupId:
+ *                       type: string
+ *                     lastReadMessageId:
+ *                       type: string
+ *                       description: Defaults to the latest message
+ *     responses:
+ *       200:
+ *         description: Read state after applying the reads
+ *       400:
+ *         description: Invalid payload or more than 200 items
+ */
+router.get("/read-state", authenticate, readStateController.getReadState);
+router.post("/read-state", authenticate, readStateController.applyReads);
+
+export default router;
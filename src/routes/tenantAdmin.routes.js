@@ -0,0 +1,288 @@
+import { Router } from "express";
+import { authenticate, authorize } from "../middleware/auth.middleware.js";
+import tenantController from "../controllers/tenant.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/admin/tenants:
+ *   post:
+ *     summary: Create a white-label tenant
+ *     description: >
+ *       The id is the X-Tenant-Id its apps send; users who sign up with it get
+ *       the tenant's branding, sender and reply-to in every email. With a
+ *       senderDomain a DKIM key is issued: publish the records from
+ *       /dns-records, then check them there. Until then emails go out from the
+ *       default address under the tenant's name.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [id, name]
+ *             properties:
+ *               id:
+ *                 type: string
+ *                 example: andes-trips
+ *               name:
+ *                 type: string
+ *               branding:
+ *                 type: object
+ *                 properties:
+ *                   logoUrl:
+ *                     type: string
+ *                     description: https URL
+ *                   primaryColor:
+ *                     type: string
+ *                     example: "#0a7d5a"
+ *                   textColor:
+ *                     type: string
+ *                   footerText:
+ *                     type: string
+ *               senderDomain:
+ *                 type: string
+ *                 example: mail.andestrips.com
+ *               senderLocalPart:
+ *                 type: string
+ *                 default: no-reply
+ *               replyTo:
+ *                 type: string
+ *                 format: email
+ *     responses:
+ *       201:
+ *         description: Tenant created
+ *       400:
+ *         description: Invalid fields or id taken
+ *   get:
+ *     summary: List tenants
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: Tenants by name
+ */
+router.post("/", authenticate, authorize(["admin"]), tenantController.createTenant);
+router.get("/", authenticate, authorize(["admin"]), tenantController.listTenants);
+
+/**
+ * @swagger
+ * /api/admin/tenants/{tenantId}:
+ *   get:
+ *     summary: Tenant details
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: tenantId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Tenant
+ *       404:
+ *         description: Tenant not found
+ *   patch:
+ *     summary: Update a tenant
+ *     description: >
+ *       Any of name, branding (merged with the current one; null resets a
+ *       field), senderDomain, senderLocalPart, replyTo and active. A new
+ *       senderDomain gets a new DKIM key and must be verified again.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: tenantId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Tenant updated
+ *       404:
+ *         description: Tenant not found
+ */
+router.get("/:tenantId", authenticate, authorize(["admin"]), tenantController.getTenant);
+router.patch("/:tenantId", authenticate, authorize(["admin"]), tenantController.updateTenant);
+
+/**
+ * @swagger
+ * /api/admin/tenants/{tenantId}/dns-records:
+ *   get:
+ *     summary: DNS setup of the sender domain
+ *     description: >
+ *       The DKIM, SPF and DMARC TXT records to publish, each looked up now
+ *       (`found`). Once DKIM and SPF are found the tenant's domain is used as
+ *       sender and emails are signed; if they disappear it falls back to the
+ *       default address.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: tenantId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: "{ senderDomain, verified, verifiedAt, records: [{ type, name, value, purpose, found }] }"
+ *       400:
+ *         description: The tenant has no sender domain
+ */
+router.get("/:tenantId/dns-records", authenticate, authorize(["admin"]), tenantController.getDnsRecords);
+
+/**
+ * @swagger
+ * /api/admin/tenants/{tenantId}/rotate-dkim:
+ *   post:
+ *     summary: Rotate the DKIM key of the sender domain
+ *     description: >
+ *       Issues a key under a new selector. Emails go out from the default address
+ *       until the new record is published and found by /dns-records.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: tenantId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Tenant with the new selector
+ *       400:
+ *         description: The tenant has no sender domain
+ */
+router.post("/:tenantId/rotate-dkim", authenticate, authorize(["admin"]), tenantController.rotateDkimKey);
+
+/**
+ * @swagger
+ * /api/admin/tenants/{tenantId}/domains:
+ *   post:
+ *     summary: Add a custom domain
+ *     description: >
+ *       The tenant proves it controls the domain by publishing the TXT record in
+ *       `challengeRecord` and checking it with /verify. Point the domain (CNAME
+ *       or A record) at the API as well.
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: tenantId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [hostname]
+ *             properties:
+ *               hostname:
+ *                 type: string
+ *                 example: api.andestrips.com
+ *     responses:
+ *       201:
+ *         description: Domain pending verification, with its challenge record
+ *       400:
+ *         description: Invalid or already registered domain
+ *       404:
+ *         description: Tenant not found
+ *   get:
+ *     summary: Custom domains of a tenant
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: tenantId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: "[{ id, hostname, verified, challengeRecord, certificateStatus, ... }]"
+ */
+router.post("/:tenantId/domains", authenticate, authorize(["admin"]), tenantController.addDomain);
+router.get("/:tenantId/domains", authenticate, authorize(["admin"]), tenantController.listDomains);
+
+/**
+ * @swagger
+ * /api/admin/tenants/{tenantId}/domains/{domainId}/verify:
+ *   post:
+ *     summary: Check the DNS challenge of a custom domain
+ *     description: >
+ *       Once the TXT record is found, requests on the domain resolve to the
+ *       tenant (taking precedence over X-Tenant-Id), its https origin passes
+ *       CORS and its certificate is requested from the certificate hook.
+ *       `certificateStatus` goes none → requested → issued (or failed).
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: tenantId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: domainId
+ *         required: true
+ *         schema:
+ *           type: string
+ *           format: uuid
+ *     responses:
+ *       200:
+ *         description: Domain with `found`
+ *       404:
+ *         description: Domain not found
+ */
+router.post(
+  "/:tenantId/domains/:domainId/verify",
+  authenticate,
+  authorize(["admin"]),
+  tenantController.verifyDomain
+);
+
+/**
+ * @swagger
+ * /api/admin/tenants/{tenantId}/domains/{domainId}:
+ *   delete:
+ *     summary: Remove a custom domain
+ *     tags: [Admin]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: tenantId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: domainId
+ *         required: true
+ *         schema:
+ *           type: string
+ *           format: uuid
+ *     responses:
+ *       200:
+ *         description: Domain removed
+ *       404:
+ *         description: Domain not found
+ */
+router.delete("/:tenantId/domains/:domainId", authenticate, authorize(["admin"]), tenantController.removeDomain);
+
+export default router;
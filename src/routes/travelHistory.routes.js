@@ -0,0 +1,118 @@
+import { Router } from "express";
+import travelHistoryController from "../controllers/travelHistory.controller.js";
+import { authenticate } from "../middleware/auth.middleware.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/me/travel-history:
+ *   get:
+ *     summary: Travel history with countries-visited stats
+ *     description: >
+ *       Trips imported by the user, most recent first. Countries are counted from
+ *       imported trips with a countryCode; completedGroupTrips counts ended JoinTravel trips.
+ *     tags: [Travel History]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: "{ trips, stats: { importedTrips, completedGroupTrips, countriesCount, countriesVisited } }"
+ */
+router.get("/", authenticate, travelHistoryController.getHistory);
+
+/**
+ * @swagger
+ * /api/me/travel-history/import:
+ *   post:
+ *     summary: Import past trips
+ *     description: >
+ *       Backfills trips made outside JoinTravel, as places and dates or as photo
+ *       metadata (EXIF read on the device), which is grouped into trips by time and
+ *       distance. Entries overlapping a trip already in the history, or a JoinTravel
+ *       trip the user took part in, are skipped and reported.
+ *     tags: [Travel History]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             properties:
+ *               trips:
+ *                 type: array
+ *                 maxItems: 100
+ *                 items:
+ *                   type: object
+ *                   required: [startDate]
+ *                   properties:
+ *                     city:
+ *                       type: string
+ *                       description: Required unless lat/lng are sent
+ *                     countryCode:
+ *                       type: string
+ *                       example: "CL"
+ *                     lat:
+ *                       type: number
+ *                     lng:
+ *                       type: number
+ *                     startDate:
+ *                       type: string
+ *                       format: date
+ *                     endDate:
+ *                       type: string
+ *                       format: date
+ *                       description: Defaults to startDate
+ *               photos:
+ *                 type: array
+ *                 maxItems: 5000
+ *                 items:
+ *                   type: object
+ *                   required: [takenAt, lat, lng]
+ *                   properties:
+ *                     takenAt:
+ *                       type: string
+ *                       format: date-time
+ *                     lat:
+ *                       type: number
+ *                     lng:
+ *                       type: number
+ *                     city:
+ *                       type: string
+ *                     countryCode:
+ *                       type: string
+ *     responses:
+ *       201:
+ *         description: "{ imported, skipped: [{ source, index, reason: duplicate | group_trip, matchId }] }"
+ *       400:
+ *         description: Invalid entries
+ */
+router.post("/import", authenticate, travelHistoryController.importTrips);
+
+/**
+ * @swagger
+ * /api/me/travel-history/{id}:
+ *   delete:
+ *     summary: Remove an imported trip
+ *     tags: [Travel History]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Trip removed
+ *       403:
+ *         description: Not in your history
+ *       404:
+ *         description: Trip not found
+ */
+router.delete("/:id", authenticate, travelHistoryController.deleteTrip);
+
+export default router;
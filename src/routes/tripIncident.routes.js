@@ -0,0 +1,109 @@
+import { Router } from "express";
+import { authenticate } from "../middleware/auth.middleware.js";
+import tripIncidentController from "../controllers/tripIncident.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/incidents:
+ *   post:
+ *     summary: Report a safety incident during a trip
+ *     description: >
+ *       Opens a high severity moderation case, alerts the safety staff through
+ *       the priority channel and, per the reporter's settings, emails their
+ *       emergency contacts.
+ *     tags: [Reports]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [type, description]
+ *             properties:
+ *               type:
+ *                 type: string
+ *                 enum: [harassment, injury, theft, other]
+ *               description:
+ *                 type: string
+ *                 maxLength: 2000
+ *               involvedUserId:
+ *                 type: string
+ *                 description: Another traveler of the trip involved in the incident
+ *               lat:
+ *                 type: number
+ *               lng:
+ *                 type: number
+ *               notifyEmergencyContacts:
+ *                 type: boolean
+ *                 description: Alert emergency contacts when the setting is on_request
+ *     responses:
+ *       201:
+ *         description: Incident reported
+ *       400:
+ *         description: Invalid incident
+ *       403:
+ *         description: Not a traveler of the trip
+ *       404:
+ *         description: Trip not found
+ */
+router.post("/groups/:groupId/incidents", authenticate, tripIncidentController.reportIncident);
+
+/**
+ * @swagger
+ * /api/me/emergency-contacts:
+ *   get:
+ *     summary: Get my emergency contacts and when they are alerted
+ *     tags: [Users]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: Emergency contacts
+ *   put:
+ *     summary: Update my emergency contacts
+ *     tags: [Users]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             properties:
+ *               contacts:
+ *                 type: array
+ *                 maxItems: 3
+ *                 items:
+ *                   type: object
+ *                   required: [name]
+ *                   properties:
+ *                     name:
+ *                       type: string
+ *                     email:
+ *                       type: string
+ *                     phone:
+ *                       type: string
+ *               alerts:
+ *                 type: string
+ *                 enum: [never, on_request, always]
+ *     responses:
+ *       200:
+ *         description: Emergency contacts updated
+ *       400:
+ *         description: Invalid contacts
+ */
+router.get("/me/emergency-contacts", authenticate, tripIncidentController.getEmergencySettings);
+router.put("/me/emergency-contacts", authenticate, tripIncidentController.updateEmergencySettings);
+
+export default router;
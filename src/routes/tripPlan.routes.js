@@ -0,0 +1,223 @@
+import { Router } from "express";
+import tripPlanController from "../controllers/tripPlan.controller.js";
+import { authenticate } from "../middleware/auth.middleware.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * components:
+ *   schemas:
+ *     TripPlanItemInput:
+ *       type: object
+ *       properties:
+ *         date:
+ *           type: string
+ *           format: date
+ *           example: "2025-03-14"
+ *         time:
+ *           type: string
+ *           nullable: true
+ *           example: "09:30"
+ *         placeId:
+ *           type: string
+ *         notes:
+ *           type: string
+ *           nullable: true
+ *           maxLength: 2000
+ *         cost:
+ *           type: number
+ *           nullable: true
+ *           description: Estimated cost (same unit as expenses)
+ *           example: 25.5
+ */
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/plan:
+ *   get:
+ *     summary: Day-by-day plan of a trip
+ *     description: >
+ *       Items of the itinerary assigned to the group grouped by day, in order, with
+ *       the day number counted from the first day and the estimated cost per day.
+ *     tags: [Trip Plan]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Plan (empty when the trip has no itinerary yet)
+ *       403:
+ *         description: Not a member of the group
+ *       404:
+ *         description: Group not found
+ */
+router.get("/:groupId/plan", authenticate, tripPlanController.getPlan);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/plan/items:
+ *   post:
+ *     summary: Add an item to the trip plan (admin only)
+ *     description: The item goes at the end of its day. Trips without itinerary get one.
+ *     tags: [Trip Plan]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             allOf:
+ *               - $ref: '#/components/schemas/TripPlanItemInput'
+ *               - required: [date, placeId]
+ *     responses:
+ *       201:
+ *         description: Item created
+ *       400:
+ *         description: Invalid input
+ *       403:
+ *         description: Not the group admin
+ */
+router.post("/:groupId/plan/items", authenticate, tripPlanController.createItem);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/plan/items/{itemId}:
+ *   get:
+ *     summary: Get an item of the trip plan
+ *     tags: [Trip Plan]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: itemId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Item
+ *       404:
+ *         description: Item not found
+ *   patch:
+ *     summary: Update an item of the trip plan (admin only)
+ *     description: Moving an item to another date puts it at the end of that day.
+ *     tags: [Trip Plan]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: itemId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             $ref: '#/components/schemas/TripPlanItemInput'
+ *     responses:
+ *       200:
+ *         description: Item updated
+ *       400:
+ *         description: Invalid input
+ *       403:
+ *         description: Not the group admin
+ *       404:
+ *         description: Item not found
+ *   delete:
+ *     summary: Delete an item of the trip plan (admin only)
+ *     tags: [Trip Plan]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: itemId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Item deleted
+ *       403:
+ *         description: Not the group admin
+ *       404:
+ *         description: Item not found
+ */
+router.get("/:groupId/plan/items/:itemId", authenticate, tripPlanController.getItem);
+router.patch("/:groupId/plan/items/:itemId", authenticate, tripPlanController.updateItem);
+router.delete("/:groupId/plan/items/:itemId", authenticate, tripPlanController.deleteItem);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/plan/days/{date}/order:
+ *   put:
+ *     summary: Reorder the items of a day (admin only)
+ *     tags: [Trip Plan]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *       - in: path
+ *         name: date
+ *         required: true
+ *         schema:
+ *           type: string
+ *           format: date
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required:
+ *               - itemIds
+ *             properties:
+ *               itemIds:
+ *                 type: array
+ *                 items:
+ *                   type: string
+ *                 description: Every item of the day, in the new order
+ *     responses:
+ *       200:
+ *         description: Items of the day in their new order
+ *       400:
+ *         description: itemIds does not match the items of the day
+ *       403:
+ *         description: Not the group admin
+ */
+router.put("/:groupId/plan/days/:date/order", authenticate, tripPlanController.reorderDay);
+
+export default router;
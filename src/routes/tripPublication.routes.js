@@ -0,0 +1,93 @@
+import { Router } from "express";
+import tripPublicationController from "../controllers/tripPublication.controller.js";
+import { authenticate } from "../middleware/auth.middleware.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * tags:
+ *   name: Trip Publication
+ *   description: >
+ *     Scheduled trip launches. A private trip can be set to go public at a given
+ *     time; it is published automatically and the organizer's followers are
+ *     notified at that moment.
+ */
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/publication:
+ *   get:
+ *     summary: Launch status and countdown of a trip
+ *     tags: [Trip Publication]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: >
+ *           status (draft, scheduled, published), publishAt, publishedAt,
+ *           secondsRemaining and serverTime to sync client countdowns
+ *       404:
+ *         description: Group not found
+ *   put:
+ *     summary: Schedule or reschedule the launch of a private trip (admin only)
+ *     tags: [Trip Publication]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [publishAt]
+ *             properties:
+ *               publishAt:
+ *                 type: string
+ *                 format: date-time
+ *                 example: "2026-11-20T18:00:00-03:00"
+ *     responses:
+ *       200:
+ *         description: Launch scheduled
+ *       400:
+ *         description: Date missing, in the past or too far ahead
+ *       403:
+ *         description: Only the group admin can schedule the launch
+ *       409:
+ *         description: The trip is already public
+ *   delete:
+ *     summary: Cancel the scheduled launch (admin only)
+ *     tags: [Trip Publication]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Launch cancelled, the trip stays private
+ *       403:
+ *         description: Only the group admin can cancel the launch
+ *       404:
+ *         description: No launch scheduled
+ */
+router.get("/:groupId/publication", authenticate, tripPublicationController.getCountdown);
+router.put("/:groupId/publication", authenticate, tripPublicationController.schedulePublication);
+router.delete("/:groupId/publication", authenticate, tripPublicationController.cancelPublication);
+
+export default router;
@@ -0,0 +1,43 @@
+import { Router } from "express";
+import { authenticate } from "../middleware/auth.middleware.js";
+import tripRecommendationController from "../controllers/tripRecommendation.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/trips/recommended:
+ *   get:
+ *     summary: Open trips ranked for you
+ *     description: >
+ *       Upcoming public trips with room left that you have not joined, ranked by
+ *       how close they go to your past destinations (JoinTravel trips and your
+ *       imported travel history), the months and trip lengths you usually
+ *       travel, how their price compares to what you paid before and how many
+ *       of the people you follow organize or joined them. Trips overlapping one
+ *       you are already on are left out. `reasons` lists the signals that
+ *       weighed most: destination, followedUsers, month, budget, duration.
+ *       The ranking is cached per user and refreshed in the background once
+ *       TRIP_RECOMMENDATIONS_TTL_HOURS old (`stale` is true meanwhile).
+ *     tags: [Groups]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: limit
+ *         schema:
+ *           type: integer
+ *           default: 20
+ *           maximum: 100
+ *       - in: query
+ *         name: offset
+ *         schema:
+ *           type: integer
+ *           default: 0
+ *     responses:
+ *       200:
+ *         description: "{ trips: [{ id, name, description, destinationName, startDate, endDate, capacity, memberCount, depositAmount, depositCurrency, score, reasons }], total, computedAt, stale }"
+ */
+router.get("/recommended", authenticate, tripRecommendationController.getRecommendedTrips);
+
+export default router;
@@ -0,0 +1,251 @@
+import { Router } from "express";
+import { authenticate, authorize, requireTwoFactor } from "../middleware/auth.middleware.js";
+import tripReviewController from "../controllers/tripReview.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * tags:
+ *   name: Trip Reviews
+ *   description: >
+ *     Once a trip has ended its participants can rate the trip and the organizer,
+ *     once each. Reviews flagged by enough users are hidden until an administrator
+ *     moderates them.
+ */
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/reviews:
+ *   post:
+ *     summary: Review a finished trip and its organizer
+ *     tags: [Trip Reviews]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [tripRating, organizerRating]
+ *             properties:
+ *               tripRating:
+ *                 type: integer
+ *                 minimum: 1
+ *                 maximum: 5
+ *               organizerRating:
+ *                 type: integer
+ *                 minimum: 1
+ *                 maximum: 5
+ *               comment:
+ *                 type: string
+ *                 maxLength: 1000
+ *     responses:
+ *       201:
+ *         description: Review created
+ *       400:
+ *         description: Invalid ratings or comment
+ *       403:
+ *         description: Not a participant or the trip has not ended yet
+ *       409:
+ *         description: You already reviewed this trip (TRIP_REVIEW_EXISTS)
+ *   get:
+ *     summary: Visible reviews of a trip with average ratings
+ *     description: >
+ *       The weighted averages count each review by its author's trust score
+ *       and leave out suspected review rings; the plain ones count every
+ *       visible review the same.
+ *     tags: [Trip Reviews]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: >
+ *           averageTripRating, averageOrganizerRating, weightedTripRating,
+ *           weightedOrganizerRating, count and reviews
+ *       404:
+ *         description: Group not found
+ */
+router.post("/groups/:groupId/reviews", authenticate, tripReviewController.createReview);
+router.get("/groups/:groupId/reviews", authenticate, tripReviewController.getTripReviews);
+
+/**
+ * @swagger
+ * /api/trip-reviews/{id}:
+ *   patch:
+ *     summary: Edit your review
+ *     tags: [Trip Reviews]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             properties:
+ *               tripRating:
+ *                 type: integer
+ *               organizerRating:
+ *                 type: integer
+ *               comment:
+ *                 type: string
+ *     responses:
+ *       200:
+ *         description: Review updated
+ *       403:
+ *         description: Only the author can edit the review
+ *       404:
+ *         description: Review not found
+ *   delete:
+ *     summary: Delete a review (author or admin)
+ *     tags: [Trip Reviews]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Review deleted
+ *       403:
+ *         description: Not allowed
+ *       404:
+ *         description: Review not found
+ */
+router.patch("/trip-reviews/:id", authenticate, tripReviewController.updateReview);
+router.delete("/trip-reviews/:id", authenticate, tripReviewController.deleteReview);
+
+/**
+ * @swagger
+ * /api/trip-reviews/{id}/flags:
+ *   post:
+ *     summary: Flag a review as inappropriate
+ *     tags: [Trip Reviews]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [reason]
+ *             properties:
+ *               reason:
+ *                 type: string
+ *                 enum: [spam, offensive, false_information, other]
+ *               details:
+ *                 type: string
+ *                 maxLength: 500
+ *     responses:
+ *       201:
+ *         description: Flag recorded
+ *       400:
+ *         description: Invalid reason or flagging your own review
+ *       409:
+ *         description: You already flagged this review
+ */
+router.post("/trip-reviews/:id/flags", authenticate, tripReviewController.flagReview);
+
+/**
+ * @swagger
+ * /api/admin/trip-reviews/flagged:
+ *   get:
+ *     summary: Flagged reviews, most flagged first
+ *     description: >
+ *       Also lists reviews in a suspected review ring (`ringStatus: suspected`),
+ *       found daily among users who rate each other 5 stars as organizers.
+ *       Restoring one marks it `cleared` so it is not flagged again.
+ *     tags: [Trip Reviews]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: status
+ *         schema:
+ *           type: string
+ *           enum: [visible, hidden]
+ *     responses:
+ *       200:
+ *         description: Reviews with their flags, authorWeight and ringStatus
+ *       403:
+ *         description: Admin role required
+ */
+router.get(
+  "/admin/trip-reviews/flagged",
+  authenticate,
+  authorize(["admin"]),
+  requireTwoFactor,
+  tripReviewController.getFlaggedReviews
+);
+
+/**
+ * @swagger
+ * /api/admin/trip-reviews/{id}/moderation:
+ *   patch:
+ *     summary: Show or hide a review
+ *     tags: [Trip Reviews]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [status]
+ *             properties:
+ *               status:
+ *                 type: string
+ *                 enum: [visible, hidden]
+ *     responses:
+ *       200:
+ *         description: Review updated
+ *       403:
+ *         description: Admin role required
+ *       404:
+ *         description: Review not found
+ */
+router.patch(
+  "/admin/trip-reviews/:id/moderation",
+  authenticate,
+  authorize(["admin"]),
+  requireTwoFactor,
+  tripReviewController.moderateReview
+);
+
+export default router;
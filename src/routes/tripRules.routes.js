@@ -0,0 +1,136 @@
+import { Router } from "express";
+import tripRulesController from "../controllers/tripRules.controller.js";
+import { authenticate } from "../middleware/auth.middleware.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * tags:
+ *   name: Trip Rules
+ *   description: >
+ *     House rules / code of conduct of a trip. Approved joiners get their spot
+ *     once they accept the current version (their request waits as
+ *     `awaiting_rules`). When the organizer changes the rules every member is
+ *     asked to accept them again, and cannot write in the group chat until
+ *     they do.
+ */
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/rules:
+ *   get:
+ *     summary: Rules of a trip and whether you accepted them
+ *     tags: [Trip Rules]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: "{ groupId, rules, version, updatedAt, acceptedVersion, acceptedAt, needsAcceptance }"
+ *       404:
+ *         description: Group not found
+ *   put:
+ *     summary: Set or remove the rules of a trip (admin only)
+ *     description: >
+ *       Every change is a new version that members must accept again. Removing
+ *       the rules sends requests waiting on them (`awaiting_rules`) back to
+ *       the organizer as `pending`.
+ *     tags: [Trip Rules]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             properties:
+ *               rules:
+ *                 type: string
+ *                 nullable: true
+ *                 maxLength: 5000
+ *                 description: null or empty removes the rules
+ *     responses:
+ *       200:
+ *         description: Rules with their new version; members are notified
+ *       400:
+ *         description: Invalid rules
+ *       403:
+ *         description: Not the group admin
+ */
+router.get("/:groupId/rules", authenticate, tripRulesController.getRules);
+router.put("/:groupId/rules", authenticate, tripRulesController.updateRules);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/rules/acceptance:
+ *   post:
+ *     summary: Accept the current rules of a trip
+ *     description: >
+ *       Records the acceptance with its timestamp. A join request awaiting it is
+ *       confirmed (or waitlisted if the group filled up meanwhile).
+ *     tags: [Trip Rules]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [version]
+ *             properties:
+ *               version:
+ *                 type: integer
+ *                 description: Version of the rules shown to the user
+ *     responses:
+ *       200:
+ *         description: "{ version, acceptedAt, joinRequest }"
+ *       400:
+ *         description: The trip has no rules
+ *       409:
+ *         description: The rules changed since that version (RULES_CHANGED)
+ */
+router.post("/:groupId/rules/acceptance", authenticate, tripRulesController.acceptRules);
+
+/**
+ * @swagger
+ * /api/groups/{groupId}/rules/acceptances:
+ *   get:
+ *     summary: Which members accepted the current rules (admin only)
+ *     tags: [Trip Rules]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: groupId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: "{ version, members: [{ id, name, acceptedVersion, acceptedAt, acceptedCurrent }] }"
+ *       403:
+ *         description: Not the group admin
+ */
+router.get("/:groupId/rules/acceptances", authenticate, tripRulesController.getAcceptances);
+
+export default router;
@@ -0,0 +1,43 @@
+import { Router } from "express";
+import usageController from "../controllers/usage.controller.js";
+import { authenticate } from "../middleware/auth.middleware.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * tags:
+ *   name: Usage
+ *   description: >
+ *     Requests and cost per user and API key. Each request costs the weight of
+ *     its route (search, recommendations, uploads and reports weigh more; writes
+ *     count double) plus one unit per USAGE_MS_PER_UNIT it took. Daily aggregates
+ *     are kept for 13 months.
+ */
+
+/**
+ * @swagger
+ * /api/me/usage:
+ *   get:
+ *     summary: My requests and cost per day
+ *     description: Today includes requests not yet stored. quota is null when there is no daily cost limit.
+ *     tags: [Usage]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: days
+ *         schema:
+ *           type: integer
+ *           minimum: 1
+ *           maximum: 400
+ *           default: 30
+ *     responses:
+ *       200:
+ *         description: "{ days: [{ day, requests, costUnits, errors }], totals, quota: { dailyCostQuota, remainingToday, resetAt } }"
+ *       400:
+ *         description: Invalid days
+ */
+router.get("/", authenticate, usageController.getMyUsage);
+
+export default router;
@@ -0,0 +1,71 @@
+import { Router } from "express";
+import { authenticate, authorize } from "../middleware/auth.middleware.js";
+import usageController from "../controllers/usage.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/admin/usage:
+ *   get:
+ *     summary: Users and API keys with the highest cost
+ *     tags: [Usage]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: subjectType
+ *         schema:
+ *           type: string
+ *           enum: [user, api_key]
+ *       - in: query
+ *         name: days
+ *         description: Days back from today (UTC), today included
+ *         schema:
+ *           type: integer
+ *           default: 1
+ *       - in: query
+ *         name: limit
+ *         schema:
+ *           type: integer
+ *           default: 20
+ *           maximum: 100
+ *     responses:
+ *       200:
+ *         description: "{ from, to, consumers: [{ subjectType, subjectId, requests, costUnits, errors }] }"
+ */
+router.get("/", authenticate, authorize(["admin"]), usageController.listTopConsumers);
+
+/**
+ * @swagger
+ * /api/admin/usage/{subjectType}/{subjectId}:
+ *   get:
+ *     summary: Requests and cost per day of a user or API key
+ *     tags: [Usage]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: subjectType
+ *         required: true
+ *         schema:
+ *           type: string
+ *           enum: [user, api_key]
+ *       - in: path
+ *         name: subjectId
+ *         required: true
+ *         description: User ID or aggregator ID
+ *         schema:
+ *           type: string
+ *       - in: query
+ *         name: days
+ *         schema:
+ *           type: integer
+ *           default: 30
+ *     responses:
+ *       200:
+ *         description: "{ days: [{ day, requests, costUnits, errors }], totals }"
+ */
+router.get("/:subjectType/:subjectId", authenticate, authorize(["admin"]), usageController.getSubjectUsage);
+
+export default router;
@@ -0,0 +1,65 @@
+import { Router } from "express";
+import { authenticate } from "../middleware/auth.middleware.js";
+import userBlockController from "../controllers/userBlock.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/users/{userId}/block:
+ *   post:
+ *     summary: Block a user
+ *     description: >
+ *       Blocks work both ways: neither user sees the other's trips, messages or
+ *       profile, and they cannot message each other. Follows between both are removed.
+ *     tags: [Users]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: userId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       201:
+ *         description: User blocked
+ *       404:
+ *         description: User not found
+ *       409:
+ *         description: User already blocked
+ *   delete:
+ *     summary: Unblock a user
+ *     tags: [Users]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: userId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: User unblocked
+ *       404:
+ *         description: User not blocked
+ */
+router.post("/users/:userId/block", authenticate, userBlockController.blockUser);
+router.delete("/users/:userId/block", authenticate, userBlockController.unblockUser);
+
+/**
+ * @swagger
+ * /api/me/blocks:
+ *   get:
+ *     summary: Users you blocked
+ *     tags: [Users]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: Blocked users ({ id, name, profilePicture, blockedAt })
+ */
+router.get("/me/blocks", authenticate, userBlockController.getBlockedUsers);
+
+export default router;
@@ -0,0 +1,45 @@
+import { Router } from "express";
+import { authenticate } from "../middleware/auth.middleware.js";
+import userReportController from "../controllers/userReport.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/reports:
+ *   post:
+ *     summary: Report a user or a trip
+ *     tags: [Reports]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             required: [targetType, targetId, reason]
+ *             properties:
+ *               targetType:
+ *                 type: string
+ *                 enum: [user, trip]
+ *               targetId:
+ *                 type: string
+ *                 description: User ID or group ID of the trip
+ *               reason:
+ *                 type: string
+ *                 enum: [harassment, spam, scam, inappropriate_content, fake_profile, safety_concern, other]
+ *               details:
+ *                 type: string
+ *                 maxLength: 1000
+ *     responses:
+ *       201:
+ *         description: Report opened
+ *       404:
+ *         description: User or trip not found
+ *       409:
+ *         description: You already have an open report on this target
+ */
+router.post("/reports", authenticate, userReportController.createReport);
+
+export default router;
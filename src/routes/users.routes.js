@@ -16,11 +16,20 @@ import {
   getUserFollowers,
   getUserFollowing,
   updateUserProfile,
+  getMyProfile,
+  updateMyProfile,
+  deleteMyAccount,
+  exportMyData,
   uploadUserAvatar,
   deleteUserAvatar,
 } from "../controllers/users.controller.js";
+import companionReferenceController from "../controllers/companionReference.controller.js";
+import sessionController from "../controllers/session.controller.js";
 import { authenticate } from "../middleware/auth.middleware.js";
+import { revalidate } from "../middleware/cacheControl.middleware.js";
 import { uploadAvatar } from "../utils/fileUpload.js";
+import { deadline, keepContext } from "../middleware/requestContext.middleware.js";
+import config from "../config/index.js";
 
 const router = Router();
 
@@ -55,7 +64,7 @@ const searchLimiter = rateLimit({
  *         example: john.doe@example.com
  *     responses:
  *       200:
- *         description: Users found successfully
+ *         description: Users found successfully. Profile fields the user keeps private are omitted.
  *         content:
  *           application/json:
  *             schema:
@@ -171,7 +180,7 @@ router.get("/search", authenticate, searchLimiter, searchUsers);
  *         example: john.doe@example.com
  *     responses:
  *       200:
- *         description: User found successfully
+ *         description: User found successfully. Profile fields the user keeps private are omitted.
  *         content:
  *           application/json:
  *             schema:
@@ -224,12 +233,225 @@ router.get("/search", authenticate, searchLimiter, searchUsers);
  */
 router.get("/email/:email", authenticate, searchLimiter, getUserByEmail);
 
+/**
+ * @swagger
+ * /api/users/me:
+ *   get:
+ *     summary: Get the authenticated user's full profile
+ *     description: Includes every field regardless of visibility, plus the visibility settings
+ *     tags: [Users]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: Own profile
+ *       304:
+ *         description: Not modified since the ETag sent in If-None-Match
+ *   patch:
+ *     summary: Update the authenticated user's profile
+ *     tags: [Users]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       required: true
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             properties:
+ *               name:
+ *                 type: string
+ *                 maxLength: 30
+ *               bio:
+ *                 type: string
+ *                 maxLength: 500
+ *                 nullable: true
+ *               homeCity:
+ *                 type: string
+ *                 maxLength: 150
+ *                 nullable: true
+ *                 description: Home city, used to suggest trips departing nearby (never shown to others)
+ *               languages:
+ *                 type: array
+ *                 maxItems: 10
+ *                 items:
+ *                   type: string
+ *                 description: ISO 639-1 codes
+ *                 example: ["es", "en"]
+ *               locale:
+ *                 type: string
+ *                 enum: [es, en, pt, ru]
+ *                 nullable: true
+ *                 description: >
+ *                   Language of the API messages, emails and pushes. When null the
+ *                   Accept-Language header of each request is used (Spanish by default)
+ *               travelPreferences:
+ *                 type: object
+ *                 properties:
+ *                   styles:
+ *                     type: array
+ *                     items:
+ *                       type: string
+ *                       enum: [adventure, beach, culture, gastronomy, nature, nightlife, relax, road_trip]
+ *                   budget:
+ *                     type: string
+ *                     enum: [budget, moderate, luxury]
+ *                   pace:
+ *                     type: string
+ *                     enum: [relaxed, balanced, intense]
+ *                   groupSizes:
+ *                     type: array
+ *                     description: >
+ *                       Preferred group sizes: small (up to 6 travelers), medium (up to 12), large.
+ *                       Trips that fit rank higher in discovery
+ *                     items:
+ *                       type: string
+ *                       enum: [small, medium, large]
+ *               visibility:
+ *                 type: object
+ *                 description: >
+ *                   Which fields other users can see (public or private). Defaults: email
+ *                   and country private; age, bio, languages, travelPreferences and
+ *                   profilePicture public
+ *                 example: { "age": "private", "bio": "public" }
+ *               age:
+ *                 type: integer
+ *                 nullable: true
+ *               dateOfBirth:
+ *                 type: string
+ *                 format: date
+ *               preferredCurrency:
+ *                 type: string
+ *                 nullable: true
+ *     responses:
+ *       200:
+ *         description: Updated own profile
+ *       400:
+ *         description: Validation error
+ */
+router.get("/me", authenticate, revalidate, getMyProfile);
+router.patch("/me", authenticate, updateMyProfile);
+
+/**
+ * @swagger
+ * /api/users/me:
+ *   delete:
+ *     summary: Delete the authenticated user's account
+ *     description: >
+ *       Closes the account right away (login and tokens stop working) and erases its data
+ *       after ACCOUNT_DELETION_GRACE_DAYS. Private data is deleted; messages, reviews and
+ *       expenses shared with other users are kept under an anonymized account. Groups the
+ *       user organizes go to their longest-standing member, or are deleted if empty.
+ *       Confirmed with the password or, without it (e.g. accounts that sign in with
+ *       Google or Apple), by a session started within ACCOUNT_DELETION_RECENT_LOGIN_MINUTES.
+ *     tags: [Users]
+ *     security:
+ *       - bearerAuth: []
+ *     requestBody:
+ *       content:
+ *         application/json:
+ *           schema:
+ *             type: object
+ *             properties:
+ *               password:
+ *                 type: string
+ *                 description: Optional with a recent login
+ *     responses:
+ *       200:
+ *         description: "{ deletedAt, erasureScheduledFor }"
+ *       400:
+ *         description: Wrong password
+ *       403:
+ *         description: No password and no recent login (RECENT_LOGIN_REQUIRED)
+ *       409:
+ *         description: Deletion already requested (ACCOUNT_DELETION_PENDING)
+ *       423:
+ *         description: The account data cannot be deleted right now (LEGAL_HOLD)
+ */
+router.delete("/me", authenticate, deleteMyAccount);
+
+/**
+ * @swagger
+ * /api/users/me/export:
+ *   get:
+ *     summary: Download everything stored about the authenticated user
+ *     description: >
+ *       JSON archive with the account and every row referencing the user, grouped by table.
+ *       Sent as an attachment (jointravel-export-YYYY-MM-DD.json).
+ *     tags: [Users]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: "{ exportedAt, account, sections }"
+ */
+router.get("/me/export", authenticate, exportMyData);
+
+/**
+ * @swagger
+ * /api/users/me/sessions:
+ *   get:
+ *     summary: Devices the authenticated user is signed in on
+ *     description: >
+ *       One session per login, most recently used first. deviceName is what the
+ *       app sent on login (deviceName or X-Device-Name); current marks the
+ *       session of this request.
+ *     tags: [Users]
+ *     security:
+ *       - bearerAuth: []
+ *     responses:
+ *       200:
+ *         description: "[{ id, deviceName, userAgent, ipAddress, createdAt, lastSeenAt, expiresAt, current }]"
+ *   delete:
+ *     summary: Log out every other device
+ *     description: Their refresh and access tokens stop working at once.
+ *     tags: [Users]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: query
+ *         name: includeCurrent
+ *         description: Log this device out too
+ *         schema:
+ *           type: boolean
+ *     responses:
+ *       200:
+ *         description: "{ revoked }"
+ */
+router.get("/me/sessions", authenticate, sessionController.listSessions);
+router.delete("/me/sessions", authenticate, sessionController.revokeAllSessions);
+
+/**
+ * @swagger
+ * /api/users/me/sessions/{sessionId}:
+ *   delete:
+ *     summary: Log a device out
+ *     description: E.g. a lost phone. Its refresh and access tokens stop working at once.
+ *     tags: [Users]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: sessionId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: Session revoked
+ *       404:
+ *         description: No active session with that ID
+ */
+router.delete("/me/sessions/:sessionId", authenticate, sessionController.revokeSession);
+
 /**
  * @swagger
  * /api/users/{userId}:
  *   get:
  *     summary: Get user by ID
- *     description: Retrieve basic information for a specific user by their ID. Requires authentication.
+ *     description: >
+ *       Retrieve the profile of a user by their ID. Other users only get the fields
+ *       the owner made public; the owner gets the full profile. Requires authentication.
  *     tags: [Users]
  *     security:
  *       - bearerAuth: []
@@ -311,6 +533,8 @@ router.get("/email/:email", authenticate, searchLimiter, getUserByEmail);
  *                   type: string
  *                   nullable: true
  *                   example: null
+ *       304:
+ *         description: Not modified since the ETag sent in If-None-Match
  *       400:
  *         description: Invalid user ID
  *         content:
@@ -377,7 +601,7 @@ router.get("/email/:email", authenticate, searchLimiter, getUserByEmail);
  *                   type: string
  *                   example: "Error interno del servidor"
  */
-router.get("/:userId", authenticate, getUserById);
+router.get("/:userId", authenticate, revalidate, getUserById);
 
 /**
  * @swagger
@@ -1139,7 +1363,8 @@ router.delete("/:userId/follow", authenticate, unfollowUser);
  *         description: The unique identifier of the user to check
  *     responses:
  *       200:
- *         description: Check completed successfully
+ *         description: >
+ *           isFollowing, followsYou and isFriend (both follow each other)
  */
 router.get("/:userId/is-following", authenticate, isFollowingUser);
 
@@ -1236,11 +1461,37 @@ router.get("/:userId/followers", authenticate, getUserFollowers);
  */
 router.get("/:userId/following", authenticate, getUserFollowing);
 
+/**
+ * @swagger
+ * /api/users/{userId}/references:
+ *   get:
+ *     summary: Get the confirmed companion references of a user and their trust score
+ *     description: >
+ *       References are written by co-travelers of completed trips and confirmed by the
+ *       user. The trust score (0-100) weighs confirmed references the most, followed by
+ *       completed trips, account age, email confirmation and profile picture.
+ *     tags: [Users]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: userId
+ *         required: true
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: References and trust score with its breakdown
+ *       404:
+ *         description: User not found
+ */
+router.get("/:userId/references", authenticate, companionReferenceController.getUserReferences);
+
 /**
  * @swagger
  * /api/users/profile:
  *   put:
- *     summary: Update user profile (name and age)
+ *     summary: Update user profile (name, age and preferred currency)
  *     tags: [Users]
  *     security:
  *       - bearerAuth: []
@@ -1261,6 +1512,15 @@ router.get("/:userId/following", authenticate, getUserFollowing);
  *                 maximum: 120
  *                 nullable: true
  *                 example: 25
+ *               dateOfBirth:
+ *                 type: string
+ *                 format: date
+ *                 description: Can only be set once (accounts created before it was required); age is derived from it
+ *               preferredCurrency:
+ *                 type: string
+ *                 nullable: true
+ *                 example: USD
+ *                 description: ISO 4217 code with a known exchange rate, used to display amounts
  *     responses:
  *       200:
  *         description: Profile updated successfully
@@ -1294,7 +1554,13 @@ router.put("/profile", authenticate, updateUserProfile);
  *       400:
  *         description: Invalid file or validation error
  */
-router.post("/profile/avatar", authenticate, uploadAvatar.single("avatar"), uploadUserAvatar);
+router.post(
+  "/profile/avatar",
+  authenticate,
+  deadline(config.requests.longTimeoutMs),
+  keepContext(uploadAvatar.single("avatar")),
+  uploadUserAvatar
+);
 
 /**
  * @swagger
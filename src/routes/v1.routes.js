@@ -0,0 +1,158 @@
+import { createVersionRouter } from "./routeGroups.js";
+import authRoutes from "./auth.routes.js";
+import placesRoutes from "./places.routes.js";
+import mapsRoutes from "./maps.routes.js";
+import debugRoutes from "./debug.routes.js";
+import itineraryRoutes from "./itinerary.routes.js";
+import mediaRoutes from "./media.routes.js";
+import chatRoutes from "./chat.routes.js";
+import gamificationRoutes from "./gamification.routes.js";
+import cronRoutes from "./cron.routes.js";
+import cronAdminRoutes from "./cronAdmin.routes.js";
+import usersRoutes from "./users.routes.js";
+import groupRoutes from "./group.routes.js";
+import groupMessageRoutes from "./groupMessage.routes.js";
+import groupJoinRequestRoutes from "./groupJoinRequest.routes.js";
+import groupDocumentRoutes from "./groupDocument.routes.js";
+import groupCapacityRoutes from "./groupCapacity.routes.js";
+import tripRulesRoutes from "./tripRules.routes.js";
+import bookingTermsRoutes from "./bookingTerms.routes.js";
+import groupSuccessionRoutes from "./groupSuccession.routes.js";
+import weatherRoutes from "./weather.routes.js";
+import tripRecommendationRoutes from "./tripRecommendation.routes.js";
+import nearbyTripsRoutes from "./nearbyTrips.routes.js";
+import tripPlanRoutes from "./tripPlan.routes.js";
+import tripPublicationRoutes from "./tripPublication.routes.js";
+import directMessageRoutes from "./directMessage.routes.js";
+import expenseRoutes from "./expense.routes.js";
+import answerRoutes from "./answer.routes.js";
+import listRoutes from "./list.routes.js";
+import questionRoutes from "./question.routes.js";
+import notificationRoutes from "./notification.routes.js";
+import analyticsRoutes from "./analytics.routes.js";
+import recommendationRoutes from "./recommendation.routes.js";
+import organizerRoutes from "./organizer.routes.js";
+import organizerPageRoutes from "./organizerPage.routes.js";
+import attachmentRoutes from "./attachment.routes.js";
+import meRoutes from "./me.routes.js";
+import travelHistoryRoutes from "./travelHistory.routes.js";
+import feedRoutes from "./feed.routes.js";
+import searchRoutes from "./search.routes.js";
+import companionReferenceRoutes from "./companionReference.routes.js";
+import legalHoldRoutes from "./legalHold.routes.js";
+import ageReviewRoutes from "./ageReview.routes.js";
+import clientConfigRoutes from "./clientConfig.routes.js";
+import formRoutes from "./form.routes.js";
+import formAdminRoutes from "./formAdmin.routes.js";
+import calendarRoutes from "./calendar.routes.js";
+import groupInviteRoutes from "./groupInvite.routes.js";
+import moderationAdminRoutes from "./moderationAdmin.routes.js";
+import paymentRoutes from "./payment.routes.js";
+import tripReviewRoutes from "./tripReview.routes.js";
+import userBlockRoutes from "./userBlock.routes.js";
+import userReportRoutes from "./userReport.routes.js";
+import tripIncidentRoutes from "./tripIncident.routes.js";
+import syncRoutes from "./sync.routes.js";
+import pollRoutes from "./poll.routes.js";
+import adminRoutes from "./admin.routes.js";
+import tenantAdminRoutes from "./tenantAdmin.routes.js";
+import affiliateRoutes from "./affiliate.routes.js";
+import affiliateAdminRoutes from "./affiliateAdmin.routes.js";
+import availabilityRoutes from "./availability.routes.js";
+import usageRoutes from "./usage.routes.js";
+import usageAdminRoutes from "./usageAdmin.routes.js";
+import aggregatorAdminRoutes from "./aggregatorAdmin.routes.js";
+import jobAdminRoutes from "./jobAdmin.routes.js";
+import featureFlagAdminRoutes from "./featureFlagAdmin.routes.js";
+import analyticsEventRoutes from "./analyticsEvent.routes.js";
+
+/**
+ * Route groups of /api/v1. Routers whose routes all need a session go in the
+ * authenticated group; routers mixing public and private routes stay public
+ * and authenticate per route. Authenticated routers mounted at "" list the
+ * prefixes of their routes, so unknown paths get a 404 rather than a 401.
+ */
+export const v1Groups = {
+  public: [
+    ["/auth", authRoutes],
+    ["/places", placesRoutes],
+    ["/maps", mapsRoutes],
+    ["/debug", debugRoutes],
+    ["/media", mediaRoutes],
+    ["/users", usersRoutes],
+    ["", answerRoutes],
+    ["/lists", listRoutes],
+    ["/client-config", clientConfigRoutes],
+    ["", formRoutes],
+    ["", calendarRoutes],
+    ["", groupInviteRoutes],
+    ["", paymentRoutes],
+    ["/recommendations", recommendationRoutes],
+    ["/attachments", attachmentRoutes],
+    ["/organizers", organizerPageRoutes],
+    ["/events", analyticsEventRoutes],
+    // Aggregators authenticate with an API key instead of a session
+    ["/availability", availabilityRoutes],
+  ],
+  websocket: [
+    ["/sync", syncRoutes],
+    ["/poll", pollRoutes],
+  ],
+  authenticated: [
+    ["/itineraries", itineraryRoutes],
+    ["/chat", chatRoutes],
+    ["/direct-messages", directMessageRoutes],
+    [
+      "",
+      gamificationRoutes,
+      ["/users/:userId/stats", "/users/:userId/points", "/users/:userId/milestones", "/levels", "/badges", "/reviews"],
+    ],
+    ["/groups", groupRoutes],
+    ["", expenseRoutes, ["/groups/:groupId/expenses", "/trips/:id/balances", "/expenses"]],
+    ["/trips", groupMessageRoutes],
+    ["/groups", groupJoinRequestRoutes],
+    ["/groups", groupDocumentRoutes],
+    ["/groups", groupCapacityRoutes],
+    ["/groups", tripPlanRoutes],
+    ["/groups", tripPublicationRoutes],
+    ["/groups", tripRulesRoutes],
+    ["/groups", bookingTermsRoutes],
+    ["/groups", groupSuccessionRoutes],
+    ["/trips", weatherRoutes],
+    ["/trips", tripRecommendationRoutes],
+    ["/trips", nearbyTripsRoutes],
+    ["", questionRoutes, ["/:questionId/vote"]],
+    ["/notifications", notificationRoutes],
+    ["", ageReviewRoutes, ["/users/:userId/age-report", "/admin/age-reviews"]],
+    ["", tripReviewRoutes, ["/groups/:groupId/reviews", "/trip-reviews", "/admin/trip-reviews"]],
+    ["/organizer", organizerRoutes],
+    ["/me/travel-history", travelHistoryRoutes],
+    ["/me/usage", usageRoutes],
+    ["/me", meRoutes],
+    ["/feed", feedRoutes],
+    ["/search", searchRoutes],
+    ["/references", companionReferenceRoutes],
+    ["", userBlockRoutes, ["/users/:userId/block", "/me/blocks"]],
+    ["", userReportRoutes, ["/reports"]],
+    ["", tripIncidentRoutes, ["/groups/:groupId/incidents", "/me/emergency-contacts"]],
+    ["/affiliates", affiliateRoutes],
+  ],
+  admin: [
+    ["/admin/analytics", analyticsRoutes],
+    ["/admin/legal", legalHoldRoutes],
+    ["/admin/affiliates", affiliateAdminRoutes],
+    ["/admin/aggregators", aggregatorAdminRoutes],
+    ["/admin/usage", usageAdminRoutes],
+    ["/admin/forms", formAdminRoutes],
+    ["/admin/moderation", moderationAdminRoutes],
+    ["/admin/jobs", jobAdminRoutes],
+    ["/admin/feature-flags", featureFlagAdminRoutes],
+    ["/admin/tenants", tenantAdminRoutes],
+    ["/admin", adminRoutes],
+    ["/cron", cronAdminRoutes, ["/tasks", "/runs"]],
+  ],
+  // Triggered by the external scheduler, which has no user session
+  scheduler: [["/cron", cronRoutes]],
+};
+
+export default createVersionRouter(v1Groups);
@@ -0,0 +1,40 @@
+import { Router } from "express";
+import { authenticate } from "../middleware/auth.middleware.js";
+import weatherController from "../controllers/weather.controller.js";
+
+const router = Router();
+
+/**
+ * @swagger
+ * /api/trips/{id}/weather:
+ *   get:
+ *     summary: Weather forecast for the destination and dates of a trip
+ *     description: >
+ *       Daily figures in the destination's local dates, from met.no (about 9
+ *       days ahead) or OpenWeather (5 days) per WEATHER_PROVIDER. Trips without
+ *       dates get the whole forecast. Forecasts are cached per destination, so
+ *       `fetchedAt` may be up to an hour old (or older with `stale: true` while
+ *       the provider is down). When there is nothing to show `available` is
+ *       false and `reason` says why: no_destination, trip_ended, too_far (see
+ *       `availableFrom`) or no_data.
+ *     tags: [Groups]
+ *     security:
+ *       - bearerAuth: []
+ *     parameters:
+ *       - in: path
+ *         name: id
+ *         required: true
+ *         description: Group ID
+ *         schema:
+ *           type: string
+ *     responses:
+ *       200:
+ *         description: "{ available, reason, availableFrom, destination: { name, lat, lng }, tripStartDate, tripEndDate, provider, fetchedAt, stale, days: [{ date, minTemp, maxTemp, precipitationMm, windSpeedMax, condition }] }"
+ *       404:
+ *         description: Group not found
+ *       502:
+ *         description: Provider unavailable and no cached forecast
+ */
+router.get("/:id/weather", authenticate, weatherController.getTripWeather);
+
+export default router;
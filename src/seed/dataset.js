@@ -0,0 +1,172 @@
+import bcrypt from "bcrypt";
+import { v5 as uuidv5 } from "uuid";
+import { AppDataSource } from "../load/typeorm.loader.js";
+import groupRepository from "../repository/group.repository.js";
+
+// Namespace of the deterministic ids of seeded rows (any fixed UUID works)
+const SEED_NAMESPACE = "6f1c7a52-3f0e-4b8e-9a47-2d5b8c1e0f93";
+
+// Password of every seeded account
+export const SEED_PASSWORD = "JoinTravel123!";
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+/**
+ * Stable id of a seeded row. The same key always maps to the same UUID, so
+ * applying a dataset again updates its rows instead of duplicating them.
+ * @param {string} key - e.g. "dev:user:ana"
+ */
+export const seedId = (key) => uuidv5(key, SEED_NAMESPACE);
+
+const daysFrom = (now, days) => new Date(now.getTime() + days * DAY_MS);
+const dateOnly = (date) => date.toISOString().slice(0, 10);
+
+/**
+ * Upserts a dataset. Rows are keyed by `${namespace}:${type}:${key}`, dates
+ * are relative to `now` so trips keep their state (upcoming, in progress,
+ * completed) whenever the dataset is applied.
+ *
+ * Dataset shape (every reference is a key of the same dataset):
+ *   users:        [{ key, email, name, country, homeCity, role, ... }]
+ *   places:       [{ key, name, address, latitude, longitude, city }]
+ *   trips:        [{ key, name, admin, members, destination: { name, lat, lng }, startInDays,
+ *                    days, places, isPublic, publishInDays, capacity, depositAmount, deleted }]
+ *   joinRequests: [{ trip, user, status, message }]
+ *   messages:     [{ trip, sender, content, minutesAgo }]
+ *   expenses:     [{ trip, paidBy, concept, amount, currency, participants }]
+ *
+ * @param {string} namespace - "dev" or "fixture:<name>"
+ * @param {Object} dataset
+ * @param {Object} options - { now }
+ * @returns {Promise<Object>} Ids by type and key: { users: { ana: "<uuid>" }, trips: {...}, ... }
+ */
+export const applyDataset = async (namespace, dataset, { now = new Date() } = {}) => {
+  const ids = {};
+  const idOf = (type, key) => {
+    if (!key) return null;
+    ids[type] = ids[type] || {};
+    ids[type][key] = ids[type][key] || seedId(`${namespace}:${type}:${key}`);
+    return ids[type][key];
+  };
+  const password = await bcrypt.hash(SEED_PASSWORD, 10);
+
+  await AppDataSource.transaction(async (manager) => {
+    const upsert = (entity, rows) =>
+      rows.length ? manager.getRepository(entity).upsert(rows, ["id"]) : null;
+
+    await upsert(
+      "User",
+      (dataset.users || []).map(({ key, ...user }) => ({
+        isEmailConfirmed: true,
+        ...user,
+        id: idOf("users", key),
+        password,
+      }))
+    );
+
+    await upsert(
+      "Place",
+      (dataset.places || []).map(({ key, ...place }) => ({ ...place, id: idOf("places", key) }))
+    );
+
+    for (const trip of dataset.trips || []) {
+      const start = daysFrom(now, trip.startInDays);
+      const itineraryId = idOf("itineraries", trip.key);
+      await upsert("Itinerary", [
+        { id: itineraryId, name: `Itinerario: ${trip.name}`, userId: idOf("users", trip.admin) },
+      ]);
+      await upsert(
+        "ItineraryItem",
+        (trip.places || []).map((place, index) => ({
+          id: idOf("itineraryItems", `${trip.key}:${index}`),
+          itineraryId,
+          placeId: idOf("places", place),
+          // One place per day, the last one on the final day
+          date: dateOnly(daysFrom(start, index === trip.places.length - 1 ? trip.days - 1 : index)),
+          order: 0,
+        }))
+      );
+
+      await upsert("Group", [
+        {
+          id: idOf("trips", trip.key),
+          name: trip.name,
+          description: trip.description || null,
+          adminId: idOf("users", trip.admin),
+          assignedItineraryId: itineraryId,
+          capacity: trip.capacity ?? null,
+          destinationName: trip.destination.name,
+          destinationLat: trip.destination.lat,
+          destinationLng: trip.destination.lng,
+          isPublic: Boolean(trip.isPublic),
+          depositAmount: trip.depositAmount ?? null,
+          depositCurrency: trip.depositAmount ? trip.depositCurrency || "USD" : null,
+          publishAt: trip.publishInDays ? daysFrom(now, trip.publishInDays) : null,
+          publishedAt: trip.isPublic ? daysFrom(start, -30) : null,
+          deletedAt: trip.deleted ? daysFrom(now, -1) : null,
+        },
+      ]);
+
+      const memberKeys = [trip.admin, ...(trip.members || [])];
+      for (const [index, member] of memberKeys.entries()) {
+        await manager.query(
+          `INSERT INTO group_members ("groupId", "userId") VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+          [idOf("trips", trip.key), idOf("users", member)]
+        );
+        await upsert("GroupMembership", [
+          {
+            id: idOf("memberships", `${trip.key}:${member}`),
+            groupId: idOf("trips", trip.key),
+            userId: idOf("users", member),
+            joinedAt: daysFrom(now, -30 + index),
+          },
+        ]);
+      }
+    }
+
+    await upsert(
+      "GroupJoinRequest",
+      (dataset.joinRequests || []).map((request) => ({
+        id: idOf("joinRequests", `${request.trip}:${request.user}`),
+        groupId: idOf("trips", request.trip),
+        userId: idOf("users", request.user),
+        status: request.status || "pending",
+        message: request.message || null,
+      }))
+    );
+
+    await upsert(
+      "GroupMessage",
+      (dataset.messages || []).map((message, index) => ({
+        id: idOf("messages", `${message.trip}:${index}`),
+        groupId: idOf("trips", message.trip),
+        senderId: idOf("users", message.sender),
+        content: message.content,
+        createdAt: new Date(now.getTime() - message.minutesAgo * 60 * 1000),
+        updatedAt: new Date(now.getTime() - message.minutesAgo * 60 * 1000),
+      }))
+    );
+
+    await upsert(
+      "Expense",
+      (dataset.expenses || []).map((expense, index) => ({
+        id: idOf("expenses", `${expense.trip}:${index}`),
+        groupId: idOf("trips", expense.trip),
+        userId: idOf("users", expense.paidBy),
+        paidById: idOf("users", expense.paidBy),
+        concept: expense.concept,
+        amount: expense.amount,
+        currency: expense.currency || "ARS",
+        participantIds: (expense.participants || []).map((user) => idOf("users", user)),
+      }))
+    );
+  });
+
+  // Trip dates, month and duration bucket follow from the itinerary items
+  const tripIds = Object.values(ids.trips || {});
+  if (tripIds.length) {
+    await groupRepository.refreshTravelDimensions({ groupIds: tripIds });
+  }
+
+  return ids;
+};
@@ -0,0 +1,101 @@
+/**
+ * Development dataset: a handful of travelers and trips covering every state
+ * a trip goes through, with the join requests, chat and expenses around them.
+ * Log in as any user with SEED_PASSWORD.
+ */
+const email = (name) => `${name}@seed.jointravel.dev`;
+
+export default {
+  users: [
+    { key: "ana", email: email("ana"), name: "Ana Gómez", country: "AR", homeCity: "Buenos Aires", dateOfBirth: "1992-03-14", languages: ["es", "en"] },
+    { key: "bruno", email: email("bruno"), name: "Bruno Silva", country: "BR", homeCity: "São Paulo", dateOfBirth: "1988-11-02", languages: ["pt", "es"] },
+    { key: "carla", email: email("carla"), name: "Carla Rossi", country: "AR", homeCity: "Córdoba", dateOfBirth: "1996-07-21", languages: ["es", "it"] },
+    { key: "diego", email: email("diego"), name: "Diego Fernández", country: "CL", homeCity: "Santiago", dateOfBirth: "1990-01-30", languages: ["es"] },
+    { key: "elena", email: email("elena"), name: "Elena Martínez", country: "UY", homeCity: "Montevideo", dateOfBirth: "1985-05-09", languages: ["es", "en", "fr"] },
+    { key: "facu", email: email("facu"), name: "Facundo López", country: "AR", homeCity: "Rosario", dateOfBirth: "1999-09-17", languages: ["es"] },
+    { key: "gabi", email: email("gabi"), name: "Gabriela Torres", country: "PE", homeCity: "Lima", dateOfBirth: "1994-12-05", languages: ["es", "en"] },
+    { key: "admin", email: email("admin"), name: "Admin JoinTravel", country: "AR", homeCity: "Buenos Aires", dateOfBirth: "1980-01-01", role: "admin" },
+  ],
+  places: [
+    { key: "fitz-roy", name: "Laguna de los Tres", address: "El Chaltén, Santa Cruz, Argentina", latitude: -49.2716, longitude: -73.0032, city: "El Chaltén" },
+    { key: "perito-moreno", name: "Glaciar Perito Moreno", address: "Parque Nacional Los Glaciares, Santa Cruz, Argentina", latitude: -50.4957, longitude: -73.1376, city: "El Calafate" },
+    { key: "aconcagua", name: "Parque Provincial Aconcagua", address: "Ruta Nacional 7, Mendoza, Argentina", latitude: -32.8197, longitude: -69.9386, city: "Mendoza" },
+    { key: "bodega", name: "Bodega en Luján de Cuyo", address: "Luján de Cuyo, Mendoza, Argentina", latitude: -33.0363, longitude: -68.8775, city: "Mendoza" },
+    { key: "cerro-catedral", name: "Cerro Catedral", address: "San Carlos de Bariloche, Río Negro, Argentina", latitude: -41.1675, longitude: -71.4397, city: "Bariloche" },
+    { key: "cafayate", name: "Quebrada de las Conchas", address: "Ruta Nacional 68, Salta, Argentina", latitude: -25.8461, longitude: -65.7347, city: "Cafayate" },
+    { key: "garganta", name: "Garganta del Diablo", address: "Parque Nacional Iguazú, Misiones, Argentina", latitude: -25.6953, longitude: -54.4367, city: "Puerto Iguazú" },
+    { key: "champaqui", name: "Cerro Champaquí", address: "Sierras Grandes, Córdoba, Argentina", latitude: -31.9889, longitude: -64.9358, city: "Villa Alpina" },
+  ],
+  trips: [
+    // Published and accepting members
+    {
+      key: "patagonia", name: "Trekking en El Chaltén", admin: "ana", members: ["bruno", "carla"],
+      description: "Diez días de trekking entre el Fitz Roy y el Perito Moreno.",
+      destination: { name: "El Chaltén, Argentina", lat: -49.3315, lng: -72.8863 },
+      startInDays: 45, days: 10, places: ["fitz-roy", "perito-moreno"],
+      isPublic: true, capacity: 8, depositAmount: 5000,
+    },
+    // Published but full: new requests go to the waitlist
+    {
+      key: "mendoza", name: "Vendimia en Mendoza", admin: "diego", members: ["elena", "carla"],
+      destination: { name: "Mendoza, Argentina", lat: -32.8895, lng: -68.8458 },
+      startInDays: 20, days: 4, places: ["bodega", "aconcagua"],
+      isPublic: true, capacity: 3,
+    },
+    // Scheduled launch
+    {
+      key: "bariloche", name: "Esquí en Bariloche", admin: "carla",
+      destination: { name: "San Carlos de Bariloche, Argentina", lat: -41.1335, lng: -71.3103 },
+      startInDays: 90, days: 7, places: ["cerro-catedral"],
+      publishInDays: 3, capacity: 6,
+    },
+    // Draft, only the organizer sees it
+    {
+      key: "salta", name: "Norte argentino en auto", admin: "facu",
+      destination: { name: "Salta, Argentina", lat: -24.7821, lng: -65.4232 },
+      startInDays: 120, days: 12, places: ["cafayate"],
+    },
+    // In progress
+    {
+      key: "iguazu", name: "Cataratas del Iguazú", admin: "bruno", members: ["gabi", "facu"],
+      destination: { name: "Puerto Iguazú, Argentina", lat: -25.5972, lng: -54.5786 },
+      startInDays: -2, days: 5, places: ["garganta"],
+      isPublic: true, capacity: 5,
+    },
+    // Completed, with expenses to settle
+    {
+      key: "cordoba", name: "Sierras de Córdoba", admin: "elena", members: ["ana", "diego", "gabi"],
+      destination: { name: "Villa General Belgrano, Argentina", lat: -31.9787, lng: -64.5569 },
+      startInDays: -40, days: 3, places: ["champaqui"],
+      isPublic: true,
+    },
+    // Deleted by its organizer, in the admin trash
+    {
+      key: "mar-del-plata", name: "Fin de semana en la costa", admin: "gabi",
+      destination: { name: "Mar del Plata, Argentina", lat: -38.0055, lng: -57.5426 },
+      startInDays: 30, days: 2, places: ["champaqui"],
+      deleted: true,
+    },
+  ],
+  joinRequests: [
+    { trip: "patagonia", user: "diego", status: "pending", message: "¡Hola! Hice el Fitz Roy hace dos años y me encantaría volver." },
+    { trip: "patagonia", user: "gabi", status: "rejected" },
+    { trip: "mendoza", user: "facu", status: "waitlisted", message: "Si se libera un lugar, me sumo." },
+    { trip: "mendoza", user: "carla", status: "approved" },
+  ],
+  messages: [
+    { trip: "patagonia", sender: "ana", content: "¡Bienvenidos! Armé el itinerario, revísenlo cuando puedan.", minutesAgo: 4320 },
+    { trip: "patagonia", sender: "bruno", content: "Genial. ¿Llevamos carpa o reservamos refugio?", minutesAgo: 4200 },
+    { trip: "patagonia", sender: "carla", content: "Yo tengo una carpa para tres.", minutesAgo: 4100 },
+    { trip: "iguazu", sender: "bruno", content: "Mañana salimos 8:00 desde el hostel.", minutesAgo: 600 },
+    { trip: "iguazu", sender: "gabi", content: "¡Perfecto! Llevo protector y piloto.", minutesAgo: 590 },
+    { trip: "cordoba", sender: "elena", content: "Gracias a todos por el viaje, cargué los gastos.", minutesAgo: 50000 },
+    { trip: "cordoba", sender: "diego", content: "¡Un viaje increíble! Ya transfiero lo mío.", minutesAgo: 49000 },
+  ],
+  expenses: [
+    { trip: "cordoba", paidBy: "elena", concept: "Cabaña (3 noches)", amount: 18000000, participants: ["elena", "ana", "diego", "gabi"] },
+    { trip: "cordoba", paidBy: "ana", concept: "Nafta", amount: 4500000, participants: ["elena", "ana", "diego", "gabi"] },
+    { trip: "cordoba", paidBy: "diego", concept: "Asado", amount: 3200000, participants: ["elena", "ana", "diego"] },
+    { trip: "iguazu", paidBy: "bruno", concept: "Entradas al parque", amount: 9000, currency: "USD", participants: ["bruno", "gabi", "facu"] },
+  ],
+};
@@ -0,0 +1,73 @@
+/**
+ * Small datasets for integration tests. Each fixture lives in its own id
+ * namespace, so loading it never touches dev data or other fixtures.
+ */
+const email = (fixture, name) => `${name}+${fixture}@fixtures.jointravel.test`;
+
+export const FIXTURES = {
+  // An organizer, a member and an outsider around an upcoming public trip
+  openTrip: {
+    users: [
+      { key: "organizer", email: email("openTrip", "organizer"), name: "Organizer", country: "AR" },
+      { key: "member", email: email("openTrip", "member"), name: "Member", country: "AR" },
+      { key: "outsider", email: email("openTrip", "outsider"), name: "Outsider", country: "AR" },
+    ],
+    places: [
+      { key: "place", name: "Fixture place", address: "Buenos Aires, Argentina", latitude: -34.6037, longitude: -58.3816, city: "Buenos Aires" },
+    ],
+    trips: [
+      {
+        key: "trip", name: "Fixture trip", admin: "organizer", members: ["member"],
+        destination: { name: "Buenos Aires, Argentina", lat: -34.6037, lng: -58.3816 },
+        startInDays: 30, days: 3, places: ["place"], isPublic: true, capacity: 4,
+      },
+    ],
+    joinRequests: [{ trip: "trip", user: "outsider", status: "pending" }],
+  },
+
+  // A trip with no spots left
+  fullTrip: {
+    users: [
+      { key: "organizer", email: email("fullTrip", "organizer"), name: "Organizer", country: "AR" },
+      { key: "member", email: email("fullTrip", "member"), name: "Member", country: "AR" },
+      { key: "applicant", email: email("fullTrip", "applicant"), name: "Applicant", country: "AR" },
+    ],
+    places: [
+      { key: "place", name: "Fixture place", address: "Mendoza, Argentina", latitude: -32.8895, longitude: -68.8458, city: "Mendoza" },
+    ],
+    trips: [
+      {
+        key: "trip", name: "Full fixture trip", admin: "organizer", members: ["member"],
+        destination: { name: "Mendoza, Argentina", lat: -32.8895, lng: -68.8458 },
+        startInDays: 15, days: 2, places: ["place"], isPublic: true, capacity: 2,
+      },
+    ],
+  },
+
+  // An ended trip with shared expenses and chat history
+  completedTrip: {
+    users: [
+      { key: "organizer", email: email("completedTrip", "organizer"), name: "Organizer", country: "AR" },
+      { key: "member", email: email("completedTrip", "member"), name: "Member", country: "AR" },
+    ],
+    places: [
+      { key: "place", name: "Fixture place", address: "Córdoba, Argentina", latitude: -31.4201, longitude: -64.1888, city: "Córdoba" },
+    ],
+    trips: [
+      {
+        key: "trip", name: "Completed fixture trip", admin: "organizer", members: ["member"],
+        destination: { name: "Córdoba, Argentina", lat: -31.4201, lng: -64.1888 },
+        startInDays: -20, days: 3, places: ["place"], isPublic: true,
+      },
+    ],
+    messages: [
+      { trip: "trip", sender: "organizer", content: "Cargué los gastos", minutesAgo: 1000 },
+    ],
+    expenses: [
+      { trip: "trip", paidBy: "organizer", concept: "Alojamiento", amount: 1000000, participants: ["organizer", "member"] },
+      { trip: "trip", paidBy: "member", concept: "Comida", amount: 300000, participants: ["organizer", "member"] },
+    ],
+  },
+};
+
+export const FIXTURE_NAMES = Object.keys(FIXTURES);
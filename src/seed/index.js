@@ -0,0 +1,38 @@
+import logger from "../config/logger.js";
+import devData from "./devData.js";
+import { FIXTURES, FIXTURE_NAMES } from "./fixtures.js";
+import { applyDataset, seedId, SEED_PASSWORD } from "./dataset.js";
+
+export { seedId, SEED_PASSWORD, FIXTURE_NAMES };
+
+/**
+ * Loads the development dataset. Reruns update the same rows.
+ * @returns {Promise<Object>} Ids by type and key
+ */
+export const seedDevData = async () => {
+  const ids = await applyDataset("dev", devData);
+  logger.info(
+    `Dev data seeded: ${devData.users.length} users, ${devData.trips.length} trips ` +
+      `(password "${SEED_PASSWORD}")`
+  );
+  return ids;
+};
+
+/**
+ * Loads a test fixture (see fixtures.js) and returns the ids of its rows, e.g.
+ *
+ *   const { users, trips, emails } = await loadFixture("openTrip");
+ *   await request(app).post("/api/auth/login").send({ email: emails.organizer, password: SEED_PASSWORD });
+ *
+ * @param {string} name
+ * @returns {Promise<Object>} Ids by type and key ({ users: { organizer }, trips: { trip }, ... })
+ *                            plus the user emails by key
+ */
+export const loadFixture = async (name) => {
+  const fixture = FIXTURES[name];
+  if (!fixture) {
+    throw new Error(`Unknown fixture "${name}" (available: ${FIXTURE_NAMES.join(", ")})`);
+  }
+  const ids = await applyDataset(`fixture:${name}`, fixture);
+  return { ...ids, emails: Object.fromEntries(fixture.users.map((user) => [user.key, user.email])) };
+};
@@ -1,29 +1,38 @@
-import { createServer } from "http";
 import { Server } from "socket.io";
 import jwt from "jsonwebtoken";
 import app from "./app.js";
 import config from "./config/index.js";
 import logger from "./config/logger.js";
-import { AppDataSource } from "./load/typeorm.loader.js";
 import directMessageService from "./services/directMessage.service.js";
 import groupMessageService from "./services/groupMessage.service.js";
+import readStateService from "./services/readState.service.js";
+import sessionService from "./services/session.service.js";
 import groupRepository from "./repository/group.repository.js";
+import UserRepository from "./repository/user.repository.js";
 import { setIoInstance } from "./socket/socket.instance.js";
-
-import connectDB from "./load/database.loader.js";
-const server = createServer(app);
+import { publish } from "./socket/realtime.js";
+import { createAppLifecycle } from "./load/lifecycle.js";
+import { buildCorsOptions } from "./utils/cors.js";
+import { createHttpServer, createRedirectServer, listen, close } from "./load/httpServer.js";
+import { createAutocert } from "./load/autocert.js";
+import sentryClient from "./clients/sentry.client.js";
+import tenantDomainService from "./services/tenantDomain.service.js";
+
+const { tls } = config.server;
+const autocert = tls.enabled && tls.autocert.domains.length > 0 ? createAutocert(tls.autocert) : null;
+const server = createHttpServer(app, config.server, autocert);
+const userRepository = new UserRepository();
 
 const io = new Server(server, {
   cors: {
-    origin: true,
+    ...buildCorsOptions(config.cors, (origin) => tenantDomainService.isTenantOrigin(origin)),
     methods: ["GET", "POST"],
-    credentials: true,
   },
   serveClient: false,
 });
 
 // Socket.io authentication middleware
-io.use((socket, next) => {
+io.use(async (socket, next) => {
   const token = socket.handshake.auth.token;
   if (!token) {
     return next(new Error("Authentication error: Token required"));
@@ -43,7 +52,22 @@ io.use((socket, next) => {
 
   try {
     const decoded = jwt.verify(token, config.jwt.secret);
+    if (decoded.purpose) {
+      return next(new Error("Authentication error: Invalid token"));
+    }
+    if (!decoded.sid || !(await sessionService.touchIfActive(decoded.sid))) {
+      return next(new Error("Authentication error: Session revoked"));
+    }
+    // Same account checks as the HTTP authenticate middleware
+    const user = await userRepository.findById(decoded.id);
+    if (!user) {
+      return next(new Error("Authentication error: Account not found"));
+    }
+    if (user.suspendedAt) {
+      return next(new Error("Authentication error: Account suspended"));
+    }
     socket.userId = decoded.id;
+    socket.sessionId = decoded.sid;
     next();
   } catch (err) {
     logger.error(
@@ -60,6 +84,16 @@ io.on("connection", (socket) => {
 
   // Join user's own room for targeted messaging
   socket.join(userId);
+  // Revoking the session disconnects the device
+  if (socket.sessionId) {
+    socket.join(sessionService.socketRoom(socket.sessionId));
+  }
+
+  // Bring the device up to date with reads made elsewhere while it was offline
+  readStateService
+    .getReadState(userId)
+    .then((state) => socket.emit("read_state", state))
+    .catch((error) => logger.error("Error sending read state on connect:", error.message));
 
   socket.on("send_message", async (data) => {
     try {
@@ -80,7 +114,7 @@ io.on("connection", (socket) => {
       const message = result.data;
 
       // Emit to receiver (new message)
-      io.to(receiverId).emit("new_message", message);
+      publish(receiverId, "new_message", message);
 
       // Emit confirmation to sender
       socket.emit("message_sent", message);
@@ -107,7 +141,7 @@ io.on("connection", (socket) => {
       await directMessageService.markAsRead(userId, otherUserId);
 
       // Emit to the other user that messages were read
-      io.to(otherUserId).emit("messages_read", { userId });
+      publish(otherUserId, "messages_read", { userId });
 
       logger.info(`User ${userId} marked messages as read for ${otherUserId}`);
     } catch (error) {
@@ -118,6 +152,30 @@ io.on("connection", (socket) => {
     }
   });
 
+  // Read state on request (e.g. when the app returns to the foreground)
+  socket.on("sync_read_state", async () => {
+    try {
+      socket.emit("read_state", await readStateService.getReadState(userId));
+    } catch (error) {
+      logger.error("Error syncing read state via socket:", error.message);
+      socket.emit("message_error", {
+        error: error.message || "Failed to sync read state",
+      });
+    }
+  });
+
+  // Reads made on this device; the other devices get "read_state_updated"
+  socket.on("apply_reads", async (data) => {
+    try {
+      socket.emit("read_state", await readStateService.applyReads(userId, data || {}));
+    } catch (error) {
+      logger.error("Error applying reads via socket:", error.message);
+      socket.emit("message_error", {
+        error: error.message || "Failed to apply reads",
+      });
+    }
+  });
+
   // Join group rooms
   socket.on("join_group", async (data) => {
     try {
@@ -162,7 +220,7 @@ io.on("connection", (socket) => {
   // Send group message
   socket.on("send_group_message", async (data) => {
     try {
-      const { groupId, content } = data;
+      const { groupId, content, clientMessageId } = data;
 
       if (!groupId || !content) {
         return socket.emit("message_error", {
@@ -174,12 +232,13 @@ io.on("connection", (socket) => {
         groupId,
         senderId: userId,
         content,
+        clientMessageId: clientMessageId || null,
       });
 
       const message = result.data;
 
       // Emit to all members in the group room
-      io.to(`group_${groupId}`).emit("new_group_message", message);
+      publish(`group_${groupId}`, "new_group_message", message);
 
       logger.info(`Group message sent by ${userId} to group ${groupId}`);
     } catch (error) {
@@ -198,43 +257,77 @@ io.on("connection", (socket) => {
 // Set io instance for use in other modules (to avoid circular dependencies)
 setIoInstance(io);
 
-const PORT = config.port;
-
-(async () => {
-  await connectDB();
-  server.listen(PORT, () => {
-    logger.info(`Server running on http://localhost:${PORT}`);
-    logger.info(`Socket.io server initialized on port ${PORT}`);
+/**
+ * Runs the API and the socket server. Background jobs run in the same process
+ * unless JOBS_WORKER_ENABLED=false (then run `node src/cli.js worker` apart).
+ */
+export const startServer = async () => {
+  const { host, port } = config.server;
+  if (autocert && !tls.redirectPort) {
+    throw new Error("TLS_REDIRECT_PORT is required with TLS_AUTOCERT_DOMAINS (ACME HTTP-01 challenges)");
+  }
+  const lifecycle = createAppLifecycle().register("http", {
+    deps: ["database", "realtime", "search", "jobs", "usage", "analyticsEvents", "events"]
+      .concat(autocert ? ["autocert"] : []),
+    start: async () => {
+      await listen(server, port, host);
+      logger.info(`Server running on ${host}:${port} (${tls.enabled ? "https" : "http"})`);
+      logger.info(`Socket.io server initialized on port ${port}`);
+      return server;
+    },
+    // Closing socket.io also closes the HTTP server it is attached to
+    stop: () =>
+      new Promise((resolve) => {
+        io.close(() => {
+          logger.info("HTTP and Socket.io servers closed");
+          resolve();
+        });
+      }),
   });
 
-  // Graceful shutdown
-  const gracefulShutdown = async (signal) => {
-    logger.info(`${signal} received, shutting down gracefully`);
-
-    // Close Socket.io
-    io.close(() => {
-      logger.info("Socket.io server closed");
+  const components = ["http", "scheduler"];
+  if (tls.enabled && tls.redirectPort) {
+    components.push("httpRedirect");
+    lifecycle.register("httpRedirect", {
+      // Up before the HTTPS server when autocert needs it to answer the challenges
+      deps: autocert ? [] : ["http"],
+      start: async () => {
+        const redirectServer = await listen(createRedirectServer(config.server, autocert), tls.redirectPort, host);
+        logger.info(`Redirecting http://${host}:${tls.redirectPort} to HTTPS`);
+        return redirectServer;
+      },
+      stop: (redirectServer) => close(redirectServer),
+    });
+  }
+  if (autocert) {
+    lifecycle.register("autocert", {
+      deps: ["httpRedirect"],
+      start: async () => {
+        await autocert.start();
+        return autocert;
+      },
+      stop: (instance) => instance.stop(),
     });
+  }
 
-    // Close HTTP server
-    server.close(async () => {
-      logger.info("HTTP server closed");
-
-      // Close database connection
-      try {
-        if (AppDataSource.isInitialized) {
-          await AppDataSource.destroy();
-          logger.info("Database connection closed");
-        }
-      } catch (error) {
-        logger.error("Error closing database connection:", error);
-      }
+  await lifecycle.start(...components);
+  lifecycle.stopOnSignals((signal) => logger.info(`${signal} received, shutting down gracefully`));
 
-      logger.info("Process terminated");
-      process.exit(0);
+  // Errors of request handlers reach the error middleware; these come from
+  // timers, event listeners and promises nobody awaited
+  process.on("unhandledRejection", (reason) => {
+    logger.error("Unhandled rejection", {
+      error: reason instanceof Error ? reason.message : String(reason),
+      stack: reason instanceof Error ? reason.stack : undefined,
     });
-  };
+    sentryClient.captureException(reason);
+  });
+  process.once("uncaughtException", async (error) => {
+    logger.error("Server crashed", { error: error.message, stack: error.stack });
+    await sentryClient.captureException(error);
+    await lifecycle.stop();
+    process.exit(1);
+  });
+};
 
-  process.on("SIGTERM", () => gracefulShutdown("SIGTERM"));
-  process.on("SIGINT", () => gracefulShutdown("SIGINT"));
-})();
+export default startServer;
@@ -0,0 +1,172 @@
+import bcrypt from "bcrypt";
+import accountRepository from "../repository/account.repository.js";
+import groupRepository from "../repository/group.repository.js";
+import legalHoldRepository, { SELF_EXPORT_SECTIONS } from "../repository/legalHold.repository.js";
+import UserRepository from "../repository/user.repository.js";
+import userSessionRepository from "../repository/userSession.repository.js";
+import attachmentRepository from "../repository/attachment.repository.js";
+import legalHoldService from "./legalHold.service.js";
+import attachmentService from "./attachment.service.js";
+import jobQueueService from "./jobQueue.service.js";
+import sessionService from "./session.service.js";
+import searchService from "./search.service.js";
+import badgeService from "./badge.service.js";
+import auditService, { AUDIT_ACTIONS } from "./audit.service.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { ACCOUNT_DELETION_JOB } from "../jobs/accountDeletion.job.js";
+import { AppError, ValidationError, NotFoundError } from "../utils/customErrors.js";
+
+const userRepository = new UserRepository();
+
+/**
+ * Self-service data rights: closing an account (erased after a grace period)
+ * and downloading everything stored about it
+ */
+class AccountService {
+  /**
+   * Closes the account right away and schedules the erasure of its data.
+   * Confirmed with the password, or without one by a login within the last
+   * ACCOUNT_DELETION_RECENT_LOGIN_MINUTES (accounts created with Google or
+   * Apple have a random password nobody knows).
+   * @param {string} userId
+   * @param {Object} data - { password, sessionId: session of the request }
+   * @returns {Promise<Object>} - { deletedAt, erasureScheduledFor }
+   */
+  async requestDeletion(userId, { password, sessionId = null }) {
+    const user = await this.getUserOrFail(userId);
+    if (user.deletedAt) {
+      throw new AppError("La baja de la cuenta ya fue solicitada", 409, "ACCOUNT_DELETION_PENDING");
+    }
+    if (password) {
+      if (!(await bcrypt.compare(String(password), user.password))) {
+        throw new ValidationError("Confirma la baja con tu contraseña");
+      }
+    } else if (!(await this.isRecentLogin(userId, sessionId))) {
+      throw new AppError(
+        "Confirma la baja con tu contraseña o vuelve a iniciar sesión",
+        403,
+        "RECENT_LOGIN_REQUIRED"
+      );
+    }
+    await legalHoldService.assertNotOnHold(userId);
+
+    if (!(await userRepository.softDelete(userId))) {
+      throw new AppError("La baja de la cuenta ya fue solicitada", 409, "ACCOUNT_DELETION_PENDING");
+    }
+    sessionService.disconnectUser(userId);
+    const deletedAt = new Date();
+    const erasureScheduledFor = new Date(
+      deletedAt.getTime() + config.accountDeletion.graceDays * 24 * 60 * 60 * 1000
+    );
+    await jobQueueService.enqueue(ACCOUNT_DELETION_JOB, { userId }, { runAt: erasureScheduledFor });
+    await searchService.scheduleIndex("user", userId);
+
+    logger.info(`Account ${userId} closed, erasure scheduled for ${erasureScheduledFor.toISOString()}`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.ACCOUNT_DELETION_REQUESTED,
+      actorId: userId,
+      targetType: "user",
+      targetId: userId,
+      metadata: { erasureScheduledFor },
+    });
+    return { deletedAt, erasureScheduledFor };
+  }
+
+  /**
+   * Erases the data of a closed account (job handler). Groups it organized go
+   * to their longest-standing member, or are deleted when nobody else is left.
+   * The files the user uploaded are deleted from the storage.
+   * @param {string} userId
+   * @returns {Promise<boolean>} false when there was nothing to erase
+   */
+  async eraseAccount(userId) {
+    const user = await userRepository.findByIdWithDeleted(userId);
+    if (!user || !user.deletedAt || user.anonymizedAt) {
+      return false;
+    }
+    // Restored and closed again: the job of the latest request does the erasure
+    const gracePeriodEnd = user.deletedAt.getTime() + config.accountDeletion.graceDays * 24 * 60 * 60 * 1000;
+    if (gracePeriodEnd > Date.now()) {
+      return false;
+    }
+
+    if (await legalHoldRepository.findActiveByUser(userId)) {
+      // Retry once the grace period passes again; the hold may be released by then
+      const runAt = new Date(Date.now() + config.accountDeletion.graceDays * 24 * 60 * 60 * 1000);
+      await jobQueueService.enqueue(ACCOUNT_DELETION_JOB, { userId }, { runAt });
+      logger.warn(`Erasure of account ${userId} postponed: legal hold active`);
+      return false;
+    }
+
+    const groups = await accountRepository.findOrganizedGroupsWithSuccessor(userId);
+    for (const { groupId, successorId } of groups) {
+      if (successorId) {
+        await groupRepository.update(groupId, { adminId: successorId });
+      } else {
+        await groupRepository.delete(groupId);
+      }
+      await searchService.scheduleIndex("trip", groupId);
+    }
+
+    // Photos and avatars leave the storage before their rows go; a storage
+    // failure fails the job, which retries the whole erasure
+    for (const attachment of await attachmentRepository.findByUser(userId)) {
+      await attachmentService.deleteObjects(attachment);
+    }
+
+    await accountRepository.anonymize(userId);
+    await searchService.scheduleIndex("user", userId);
+    badgeService.invalidate([userId]);
+
+    logger.info(`Account ${userId} erased (${groups.length} organized groups handed over or deleted)`);
+    return true;
+  }
+
+  /**
+   * Everything stored about the user, as a JSON archive
+   * @param {string} userId
+   * @returns {Promise<Object>} - { exportedAt, account, sections }
+   */
+  async exportData(userId) {
+    const data = await legalHoldRepository.collectUserData(userId, SELF_EXPORT_SECTIONS);
+    if (!data) {
+      throw new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND");
+    }
+    await auditService.record({
+      action: AUDIT_ACTIONS.ACCOUNT_DATA_EXPORTED,
+      actorId: userId,
+      targetType: "user",
+      targetId: userId,
+    });
+    return {
+      exportedAt: new Date().toISOString(),
+      ...data,
+    };
+  }
+
+  // Refreshing keeps the session, so its creation is the time of the login
+  async isRecentLogin(userId, sessionId) {
+    if (!sessionId) {
+      return false;
+    }
+    const session = await userSessionRepository.findById(sessionId);
+    const maxAgeMs = config.accountDeletion.recentLoginMinutes * 60 * 1000;
+    return Boolean(
+      session &&
+        session.userId === userId &&
+        !session.revokedAt &&
+        Date.now() - session.createdAt.getTime() <= maxAgeMs
+    );
+  }
+
+  async getUserOrFail(userId) {
+    const user = await userRepository.findByIdWithDeleted(userId);
+    if (!user) {
+      throw new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND");
+    }
+    return user;
+  }
+}
+
+export default new AccountService();
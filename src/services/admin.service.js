@@ -0,0 +1,523 @@
+import { AppDataSource } from "../load/typeorm.loader.js";
+import UserRepository from "../repository/user.repository.js";
+import groupRepository from "../repository/group.repository.js";
+import userReportRepository from "../repository/userReport.repository.js";
+import groupMessageRepository from "../repository/groupMessage.repository.js";
+import directMessageRepository from "../repository/directMessage.repository.js";
+import searchService from "./search.service.js";
+import moderationService from "./moderation.service.js";
+import groupSuccessionService from "./groupSuccession.service.js";
+import sessionService from "./session.service.js";
+import auditService, { AUDIT_ACTIONS } from "./audit.service.js";
+import logger from "../config/logger.js";
+import { createAndEmitNotification } from "../socket/notification.emitter.js";
+import {
+  AppError,
+  ValidationError,
+  NotFoundError,
+  AuthorizationError,
+} from "../utils/customErrors.js";
+import { isAgeRestricted } from "../utils/ageVerification.js";
+
+const userRepository = new UserRepository();
+
+const USER_STATUSES = ["active", "suspended"];
+const USER_ROLES = ["user", "admin"];
+const REPORT_STATUSES = ["open", "actioned", "dismissed"];
+const REPORT_TARGET_TYPES = ["user", "trip"];
+const REPORT_ACTIONS = ["warning", "trip_hidden"];
+const MAX_PAGE_SIZE = 100;
+
+// Soft-deleted records admins can list and restore, by trash type
+const TRASH = {
+  trips: {
+    repository: groupRepository,
+    searchType: "trip",
+    format: (group) => ({
+      id: group.id,
+      name: group.name,
+      destinationName: group.destinationName,
+      organizer: group.admin ? { id: group.admin.id, name: group.admin.name } : { id: group.adminId },
+      deletedAt: group.deletedAt,
+    }),
+  },
+  group_messages: {
+    repository: groupMessageRepository,
+    format: (message) => ({
+      id: message.id,
+      groupId: message.groupId,
+      content: message.content,
+      sender: message.sender ? { id: message.sender.id, name: message.sender.name } : { id: message.senderId },
+      createdAt: message.createdAt,
+      deletedAt: message.deletedAt,
+    }),
+  },
+  direct_messages: {
+    repository: directMessageRepository,
+    format: (message) => ({
+      id: message.id,
+      content: message.content,
+      sender: message.sender ? { id: message.sender.id, name: message.sender.name } : { id: message.senderId },
+      receiver: message.receiver
+        ? { id: message.receiver.id, name: message.receiver.name }
+        : { id: message.receiverId },
+      createdAt: message.createdAt,
+      deletedAt: message.deletedAt,
+    }),
+  },
+  users: {
+    repository: userRepository,
+    searchType: "user",
+    format: (user) => ({ id: user.id, email: user.email, name: user.name, deletedAt: user.deletedAt }),
+  },
+};
+export const TRASH_TYPES = Object.keys(TRASH);
+
+/**
+ * Back-office operations for administrators: user suspension, trip removal,
+ * report review and platform stats. Callers must already be authorized as admin.
+ */
+class AdminService {
+  /**
+   * Lists users with their suspension status
+   * @param {Object} filters - { q, status, role, limit, offset }
+   * @returns {Promise<Object>} - { users, pagination }
+   */
+  async listUsers({ q = null, status = null, role = null, limit = 50, offset = 0 } = {}) {
+    if (status && !USER_STATUSES.includes(status)) {
+      throw new ValidationError(`status debe ser uno de: ${USER_STATUSES.join(", ")}`);
+    }
+    const pageSize = Math.min(Math.max(parseInt(limit) || 50, 1), MAX_PAGE_SIZE);
+    const skip = Math.max(parseInt(offset) || 0, 0);
+
+    const [users, total] = await userRepository.findForAdmin({
+      query: q ? String(q).trim() : null,
+      status,
+      role,
+      limit: pageSize,
+      offset: skip,
+    });
+
+    return {
+      users: users.map((user) => this.formatUser(user)),
+      pagination: { limit: pageSize, offset: skip, total },
+    };
+  }
+
+  /**
+   * Changes the role of a user
+   * @param {string} adminId
+   * @param {string} userId
+   * @param {string} role - "user" | "admin"
+   * @returns {Promise<Object>} - Updated user
+   */
+  async changeRole(adminId, userId, role) {
+    if (!USER_ROLES.includes(role)) {
+      throw new ValidationError(`role debe ser uno de: ${USER_ROLES.join(", ")}`);
+    }
+    const user = await this.getUserOrFail(userId);
+    if (user.id === adminId) {
+      throw new ValidationError("No puedes cambiar tu propio rol");
+    }
+    if (user.role === role) {
+      throw new AppError(`El usuario ya tiene el rol ${role}`, 409, "ROLE_UNCHANGED");
+    }
+
+    await userRepository.update(userId, { role });
+    logger.info(`Role of user ${userId} changed from ${user.role} to ${role} by admin ${adminId}`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.ROLE_CHANGED,
+      actorId: adminId,
+      targetType: "user",
+      targetId: userId,
+      metadata: { from: user.role, to: role },
+    });
+    return this.formatUser(await userRepository.findByIdWithDeleted(userId));
+  }
+
+  /**
+   * Suspends an account; its tokens stop working on the next request
+   * @param {string} adminId
+   * @param {string} userId
+   * @param {string} reason
+   */
+  async suspendUser(adminId, userId, reason) {
+    if (!reason || typeof reason !== "string" || reason.trim().length < 3) {
+      throw new ValidationError("Indica el motivo de la suspensión");
+    }
+    const user = await this.getUserOrFail(userId);
+    if (user.id === adminId) {
+      throw new ValidationError("No puedes suspender tu propia cuenta");
+    }
+    if (user.role === "admin") {
+      throw new AuthorizationError("No se puede suspender a otro administrador");
+    }
+    if (user.suspendedAt) {
+      throw new AppError("La cuenta ya está suspendida", 409, "USER_ALREADY_SUSPENDED");
+    }
+
+    await userRepository.update(userId, {
+      suspendedAt: new Date(),
+      suspensionReason: reason.trim(),
+    });
+    // Open sockets were authenticated before the suspension
+    sessionService.disconnectUser(userId);
+    // Suspended users disappear from search until reinstated
+    await searchService.scheduleIndex("user", userId);
+    // Their upcoming trips are offered to co-organizers and members
+    const successions = await groupSuccessionService.onOrganizerSuspended(userId);
+    logger.info(`User ${userId} suspended by admin ${adminId} (${successions} trip successions started)`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.USER_SUSPENDED,
+      actorId: adminId,
+      targetType: "user",
+      targetId: userId,
+      metadata: { reason: reason.trim() },
+    });
+    return this.formatUser(await userRepository.findByIdWithDeleted(userId));
+  }
+
+  /**
+   * Lifts the suspension of an account
+   */
+  async reinstateUser(adminId, userId) {
+    const user = await this.getUserOrFail(userId);
+    if (!user.suspendedAt) {
+      throw new AppError("La cuenta no está suspendida", 409, "USER_NOT_SUSPENDED");
+    }
+
+    await userRepository.update(userId, { suspendedAt: null, suspensionReason: null });
+    await searchService.scheduleIndex("user", userId);
+    await groupSuccessionService.onOrganizerReinstated(userId);
+    logger.info(`User ${userId} reinstated by admin ${adminId}`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.USER_REINSTATED,
+      actorId: adminId,
+      targetType: "user",
+      targetId: userId,
+    });
+    return this.formatUser(await userRepository.findByIdWithDeleted(userId));
+  }
+
+  /**
+   * Records that an admin checked the identity of a user (e.g. their ID
+   * document), for policies with requirements.idVerification
+   */
+  async verifyIdentity(adminId, userId) {
+    const user = await this.getUserOrFail(userId);
+    if (user.idVerifiedAt) {
+      throw new AppError("La identidad ya está verificada", 409, "USER_ALREADY_ID_VERIFIED");
+    }
+
+    await userRepository.update(userId, { idVerifiedAt: new Date() });
+    logger.info(`Identity of user ${userId} verified by admin ${adminId}`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.USER_ID_VERIFIED,
+      actorId: adminId,
+      targetType: "user",
+      targetId: userId,
+    });
+    return this.formatUser(await userRepository.findByIdWithDeleted(userId));
+  }
+
+  async revokeIdVerification(adminId, userId) {
+    const user = await this.getUserOrFail(userId);
+    if (!user.idVerifiedAt) {
+      throw new AppError("La identidad no está verificada", 409, "USER_NOT_ID_VERIFIED");
+    }
+
+    await userRepository.update(userId, { idVerifiedAt: null });
+    logger.info(`Identity verification of user ${userId} revoked by admin ${adminId}`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.USER_ID_VERIFICATION_REVOKED,
+      actorId: adminId,
+      targetType: "user",
+      targetId: userId,
+    });
+    return this.formatUser(await userRepository.findByIdWithDeleted(userId));
+  }
+
+  /**
+   * Deletes a trip regardless of who organizes it. Members are notified.
+   * The trip is soft-deleted and can be restored from the trash.
+   * @param {string} adminId
+   * @param {string} groupId
+   * @param {string|null} reason
+   */
+  async forceDeleteTrip(adminId, groupId, reason = null) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      throw new NotFoundError("Viaje no encontrado", "TRIP_NOT_FOUND");
+    }
+    const memberIds = group.members.map((member) => member.id);
+
+    await groupRepository.softDelete(groupId);
+    await searchService.scheduleIndex("trip", groupId);
+    logger.warn(
+      `Trip ${groupId} force-deleted by admin ${adminId}${reason ? `: ${reason}` : ""}`
+    );
+    await auditService.record({
+      action: AUDIT_ACTIONS.TRIP_FORCE_DELETED,
+      actorId: adminId,
+      targetType: "trip",
+      targetId: groupId,
+      metadata: { name: group.name, reason: reason || null },
+    });
+
+    const recipients = new Set([group.adminId, ...memberIds]);
+    for (const userId of recipients) {
+      try {
+        await createAndEmitNotification({
+          userId,
+          type: "TRIP_REMOVED_BY_ADMIN",
+          title: "Viaje eliminado",
+          message: `El viaje "${group.name}" fue eliminado por incumplir las normas de la comunidad.`,
+          data: { groupId, groupName: group.name },
+        });
+      } catch (notifError) {
+        logger.error(`Error notifying trip removal to ${userId}: ${notifError.message}`);
+      }
+    }
+
+    return { id: groupId, name: group.name, notifiedUsers: recipients.size };
+  }
+
+  /**
+   * Lists soft-deleted records of a type, most recently deleted first
+   * @param {string} type - One of TRASH_TYPES
+   * @param {Object} pagination - { limit, offset }
+   * @returns {Promise<Object>} - { type, items, pagination }
+   */
+  async listTrash(type, { limit = 50, offset = 0 } = {}) {
+    const trash = this.getTrashOrFail(type);
+    const pageSize = Math.min(Math.max(parseInt(limit) || 50, 1), MAX_PAGE_SIZE);
+    const skip = Math.max(parseInt(offset) || 0, 0);
+
+    const items = await trash.repository.findTrashed({ limit: pageSize, offset: skip });
+    return {
+      type,
+      items: items.map(trash.format),
+      pagination: { limit: pageSize, offset: skip },
+    };
+  }
+
+  /**
+   * Restores a soft-deleted record. Closed accounts can only be restored
+   * until their data is erased.
+   * @param {string} adminId
+   * @param {string} type - One of TRASH_TYPES
+   * @param {string} id
+   * @returns {Promise<Object>} - { type, id }
+   */
+  async restoreFromTrash(adminId, type, id) {
+    const trash = this.getTrashOrFail(type);
+    if (!(await trash.repository.restore(id))) {
+      throw new NotFoundError("No hay un elemento borrado y restaurable con ese ID");
+    }
+    if (trash.searchType) {
+      await searchService.scheduleIndex(trash.searchType, id);
+    }
+    logger.info(`${type} ${id} restored from trash by admin ${adminId}`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.RECORD_RESTORED,
+      actorId: adminId,
+      targetType: type,
+      targetId: id,
+    });
+    return { type, id };
+  }
+
+  getTrashOrFail(type) {
+    if (!TRASH[type]) {
+      throw new ValidationError(`type debe ser uno de: ${TRASH_TYPES.join(", ")}`);
+    }
+    return TRASH[type];
+  }
+
+  /**
+   * Lists reports, high severity (trip incidents) first, then oldest first
+   * @param {Object} filters - { status, targetType }
+   */
+  async getReports({ status = "open", targetType = null } = {}) {
+    if (status && !REPORT_STATUSES.includes(status)) {
+      throw new ValidationError("Estado de reporte inválido");
+    }
+    if (targetType && !REPORT_TARGET_TYPES.includes(targetType)) {
+      throw new ValidationError("Tipo de reporte inválido");
+    }
+    const reports = await userReportRepository.findAll({ status, targetType });
+    return await Promise.all(
+      reports.map(async (report) => ({
+        ...this.formatReport(report),
+        reportsAgainstUser: await userReportRepository.countByTargetUser(report.targetUserId),
+      }))
+    );
+  }
+
+  /**
+   * Resolves a report. Actioned reports apply their action: a warning
+   * notifies the reported user, trip_hidden makes the reported trip private.
+   * @param {string} adminId
+   * @param {string} reportId
+   * @param {Object} data - { status: "actioned" | "dismissed", action, notes }
+   */
+  async resolveReport(adminId, reportId, { status, action = null, notes = null }) {
+    if (!["actioned", "dismissed"].includes(status)) {
+      throw new ValidationError('El estado debe ser "actioned" o "dismissed"');
+    }
+    if (status === "actioned" && !REPORT_ACTIONS.includes(action)) {
+      throw new ValidationError(`La acción debe ser una de: ${REPORT_ACTIONS.join(", ")}`);
+    }
+
+    const report = await userReportRepository.findById(reportId);
+    if (!report) {
+      throw new NotFoundError("Reporte no encontrado");
+    }
+    if (report.status !== "open") {
+      throw new AppError("El reporte ya fue resuelto", 409, "REPORT_ALREADY_RESOLVED");
+    }
+    if (action === "trip_hidden" && !report.targetGroupId) {
+      throw new ValidationError("Solo se pueden ocultar viajes reportados");
+    }
+
+    if (status === "actioned") {
+      await this.applyReportAction(report, action);
+    }
+    const updated = await userReportRepository.update(reportId, {
+      status,
+      action: status === "actioned" ? action : null,
+      resolvedById: adminId,
+      resolutionNotes: notes ? String(notes).trim() : null,
+      resolvedAt: new Date(),
+    });
+    logger.info(`Report ${reportId} ${status} by admin ${adminId}${action ? ` (${action})` : ""}`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.REPORT_RESOLVED,
+      actorId: adminId,
+      targetType: "report",
+      targetId: reportId,
+      metadata: { status: updated.status, action: updated.action },
+    });
+    return this.formatReport(updated);
+  }
+
+  async applyReportAction(report, action) {
+    if (action === "trip_hidden") {
+      await moderationService.unlistTrip(report.targetGroupId);
+    }
+
+    const notifications = {
+      warning: {
+        type: "ACCOUNT_WARNING",
+        title: "Advertencia de la comunidad",
+        message: "Recibimos reportes sobre tu actividad. Revisa las normas de la comunidad.",
+        data: { reportId: report.id },
+      },
+      trip_hidden: {
+        type: "TRIP_HIDDEN_BY_MODERATION",
+        title: "Tu viaje fue ocultado",
+        message: `Ocultamos "${report.targetGroup?.name}" de las búsquedas tras revisar un reporte.`,
+        data: { reportId: report.id, groupId: report.targetGroupId },
+      },
+    };
+    try {
+      await createAndEmitNotification({ userId: report.targetUserId, ...notifications[action] });
+    } catch (notifError) {
+      logger.error(`Error notifying report action ${report.id}: ${notifError.message}`);
+    }
+  }
+
+  /**
+   * Platform-wide totals for the admin dashboard
+   * @returns {Promise<Object>} - { users, trips, reports, activity, generatedAt }
+   */
+  async getStats() {
+    const [[users], [trips], [reports], [activity]] = await Promise.all([
+      AppDataSource.query(
+        `SELECT COUNT(*)::int AS total,
+                COUNT(*) FILTER (WHERE "suspendedAt" IS NOT NULL)::int AS suspended,
+                COUNT(*) FILTER (WHERE "deletedAt" IS NOT NULL AND "anonymizedAt" IS NULL)::int AS deleted,
+                COUNT(*) FILTER (WHERE "createdAt" >= NOW() - INTERVAL '7 days')::int AS "newLast7Days",
+                COUNT(*) FILTER (WHERE "lastActivity" >= NOW() - INTERVAL '30 days')::int AS "activeLast30Days"
+         FROM users`
+      ),
+      AppDataSource.query(
+        `SELECT COUNT(*) FILTER (WHERE "deletedAt" IS NULL)::int AS total,
+                COUNT(*) FILTER (WHERE "isPublic" = true AND "deletedAt" IS NULL)::int AS public,
+                COUNT(*) FILTER (WHERE "publishAt" IS NOT NULL AND "deletedAt" IS NULL)::int AS scheduled,
+                COUNT(*) FILTER (WHERE "publishedAt" >= NOW() - INTERVAL '7 days')::int AS "publishedLast7Days",
+                COUNT(*) FILTER (WHERE "deletedAt" IS NOT NULL)::int AS deleted
+         FROM groups`
+      ),
+      AppDataSource.query(
+        `SELECT COUNT(*) FILTER (WHERE status = 'open')::int AS open,
+                COUNT(*) FILTER (WHERE "resolvedAt" >= NOW() - INTERVAL '7 days')::int AS "resolvedLast7Days"
+         FROM user_reports`
+      ),
+      AppDataSource.query(
+        `SELECT (SELECT COUNT(*)::int FROM direct_messages
+                 WHERE "createdAt" >= NOW() - INTERVAL '24 hours') AS "directMessagesLast24h",
+                (SELECT COUNT(*)::int FROM group_messages
+                 WHERE "createdAt" >= NOW() - INTERVAL '24 hours') AS "groupMessagesLast24h",
+                (SELECT COUNT(*)::int FROM group_join_requests
+                 WHERE status = 'pending') AS "pendingJoinRequests"`
+      ),
+    ]);
+
+    return { users, trips, reports, activity, generatedAt: new Date().toISOString() };
+  }
+
+  async getUserOrFail(userId) {
+    const user = await userRepository.findByIdWithDeleted(userId);
+    if (!user) {
+      throw new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND");
+    }
+    return user;
+  }
+
+  formatUser(user) {
+    return {
+      id: user.id,
+      email: user.email,
+      name: user.name,
+      role: user.role,
+      country: user.country,
+      isEmailConfirmed: user.isEmailConfirmed,
+      ageRestricted: isAgeRestricted(user),
+      idVerifiedAt: user.idVerifiedAt,
+      status: user.suspendedAt ? "suspended" : "active",
+      suspendedAt: user.suspendedAt,
+      suspensionReason: user.suspensionReason,
+      deletedAt: user.deletedAt,
+      lastActivity: user.lastActivity,
+      createdAt: user.createdAt,
+    };
+  }
+
+  formatReport(report) {
+    return {
+      id: report.id,
+      targetType: report.targetType,
+      reason: report.reason,
+      details: report.details,
+      severity: report.severity,
+      status: report.status,
+      action: report.action,
+      resolutionNotes: report.resolutionNotes,
+      resolvedById: report.resolvedById,
+      resolvedAt: report.resolvedAt,
+      createdAt: report.createdAt,
+      reporter: report.reporter
+        ? { id: report.reporter.id, name: report.reporter.name, email: report.reporter.email }
+        : { id: report.reporterId },
+      targetUser: report.targetUser
+        ? { id: report.targetUser.id, name: report.targetUser.name, email: report.targetUser.email }
+        : { id: report.targetUserId },
+      targetTrip: report.targetGroupId
+        ? { id: report.targetGroupId, name: report.targetGroup?.name || null }
+        : null,
+    };
+  }
+}
+
+export default new AdminService();
@@ -0,0 +1,313 @@
+import affiliateRepository from "../repository/affiliate.repository.js";
+import exchangeRateService from "./exchangeRate.service.js";
+import UserRepository from "../repository/user.repository.js";
+import auditService, { AUDIT_ACTIONS } from "./audit.service.js";
+import logger from "../config/logger.js";
+import { AppError, ValidationError, NotFoundError } from "../utils/customErrors.js";
+
+const UUID_REGEX = /^[0-9a-f]{8}-[0-9a-f]{4}-[1-5][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/i;
+const CODE_REGEX = /^[A-Z0-9_-]{3,40}$/;
+const CURRENCY_REGEX = /^[A-Z]{3}$/;
+const MONTH_REGEX = /^(\d{4})-(0[1-9]|1[0-2])$/;
+const MAX_UTM_LENGTH = 100;
+const DEFAULT_STATS_MONTHS = 12;
+const MAX_STATS_MONTHS = 24;
+
+const UPDATABLE_FIELDS = ["name", "contactEmail", "userId", "commissionRate", "signupBounty", "currency", "active"];
+
+/**
+ * Affiliate partners: attribution of signups brought in by a partner code (or
+ * UTM source), of their first paid booking, and the monthly commission reports
+ */
+class AffiliateService {
+  constructor() {
+    this.userRepository = new UserRepository();
+  }
+
+  /**
+   * Creates a partner
+   * @param {Object} data - { name, code, contactEmail, userId, commissionRate, signupBounty, currency }
+   */
+  async createPartner(data = {}) {
+    const code = this.normalizeCode(data.code);
+    if (!CODE_REGEX.test(code)) {
+      throw new ValidationError("El código debe tener entre 3 y 40 letras, números, - o _");
+    }
+    if (await affiliateRepository.findPartnerByCode(code)) {
+      throw new AppError("Ya existe un partner con ese código", 409, "AFFILIATE_CODE_TAKEN");
+    }
+    const fields = await this.validatePartnerFields({ currency: "USD", ...data }, { requireName: true });
+
+    const partner = await affiliateRepository.createPartner({ ...fields, code });
+    logger.info(`Affiliate partner ${partner.id} created with code ${code}`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.AFFILIATE_PARTNER_CREATED,
+      targetType: "affiliate_partner",
+      targetId: partner.id,
+      metadata: { code },
+    });
+    return this.formatPartner(partner);
+  }
+
+  async listPartners() {
+    const partners = await affiliateRepository.findPartners();
+    return partners.map((partner) => this.formatPartner(partner));
+  }
+
+  /**
+   * Updates a partner. The code cannot change: it is already out there in links.
+   * @param {string} partnerId
+   * @param {Object} data - Any of UPDATABLE_FIELDS
+   */
+  async updatePartner(partnerId, data = {}) {
+    const partner = UUID_REGEX.test(partnerId) ? await affiliateRepository.findPartnerById(partnerId) : null;
+    if (!partner) {
+      throw new NotFoundError("Partner no encontrado");
+    }
+    if (data.code !== undefined && this.normalizeCode(data.code) !== partner.code) {
+      throw new ValidationError("El código de un partner no se puede cambiar");
+    }
+    const changes = Object.fromEntries(
+      Object.entries(data).filter(([field]) => UPDATABLE_FIELDS.includes(field))
+    );
+    if (Object.keys(changes).length === 0) {
+      throw new ValidationError("No hay cambios para guardar", null, "NO_CHANGES");
+    }
+
+    const fields = await this.validatePartnerFields(changes, { partnerId });
+    const updated = await affiliateRepository.updatePartner(partnerId, fields);
+    await auditService.record({
+      action: AUDIT_ACTIONS.AFFILIATE_PARTNER_UPDATED,
+      targetType: "affiliate_partner",
+      targetId: partnerId,
+      metadata: { fields: Object.keys(changes) },
+    });
+    return this.formatPartner(updated);
+  }
+
+  /**
+   * Attributes a new signup to the partner of the affiliate code, or of the
+   * UTM source when no code was sent. Never throws: a bad code or a failed
+   * write must not fail the registration.
+   * @param {string} userId
+   * @param {Object} data - { code, utmSource, utmMedium, utmCampaign }
+   * @returns {Promise<Object|null>} The attribution, or null when no active partner matched
+   */
+  async recordSignup(userId, { code, utmSource, utmMedium, utmCampaign } = {}) {
+    try {
+      const candidates = [code, utmSource].map((value) => this.normalizeCode(value)).filter(Boolean);
+      let partner = null;
+      for (const candidate of candidates) {
+        partner = await affiliateRepository.findPartnerByCode(candidate);
+        if (partner) break;
+      }
+      if (!partner || !partner.active) {
+        return null;
+      }
+
+      const attribution = await affiliateRepository.createAttribution({
+        partnerId: partner.id,
+        userId,
+        utmSource: this.truncate(utmSource),
+        utmMedium: this.truncate(utmMedium),
+        utmCampaign: this.truncate(utmCampaign),
+      });
+      logger.info(`Signup ${userId} attributed to affiliate partner ${partner.id}`);
+      return attribution;
+    } catch (error) {
+      logger.error(`Failed to attribute signup ${userId}: ${error.message}`);
+      return null;
+    }
+  }
+
+  /**
+   * Records the first paid booking of an attributed user with the commission
+   * it earns, converted to the partner currency. Never throws: the payment is
+   * already confirmed.
+   * @param {Object} payment - Payment that just succeeded
+   */
+  async recordBooking(payment) {
+    try {
+      const attribution = await affiliateRepository.findAttributionByUser(payment.userId);
+      if (!attribution || attribution.firstBookingAt) {
+        return;
+      }
+      const partner = await affiliateRepository.findPartnerById(attribution.partnerId);
+      const amount = await exchangeRateService.convert(payment.amount, payment.currency, partner.currency);
+      if (amount === null) {
+        logger.warn(
+          `No exchange rate ${payment.currency} -> ${partner.currency} for affiliate booking ${payment.id}`
+        );
+      }
+
+      const recorded = await affiliateRepository.recordFirstBooking(payment.userId, {
+        firstBookingAt: new Date(),
+        firstBookingPaymentId: payment.id,
+        firstBookingAmount: amount,
+        commissionAmount: amount === null ? null : Math.round(amount * Number(partner.commissionRate)),
+      });
+      if (recorded) {
+        logger.info(`First booking of ${payment.userId} attributed to affiliate partner ${partner.id}`);
+      }
+    } catch (error) {
+      logger.error(`Failed to attribute booking ${payment.id}: ${error.message}`);
+    }
+  }
+
+  /**
+   * Commission report of every partner for a month. A signup earns the signup
+   * bounty once the user confirmed their email; refunded first bookings earn nothing.
+   * @param {string} month - YYYY-MM, defaults to the previous month
+   * @returns {Promise<Object>} - { month, partners: [{ partner, signups, confirmedSignups, firstBookings, bookingVolume, commission }] }
+   */
+  async getMonthlyReport(month) {
+    const { from, to, label } = this.monthRange(month ?? this.previousMonth());
+    const [partners, totals] = await Promise.all([
+      affiliateRepository.findPartners(),
+      affiliateRepository.getTotalsByPartner(from, to),
+    ]);
+    const totalsByPartner = new Map(totals.map((row) => [row.partnerId, row]));
+
+    return {
+      month: label,
+      partners: partners.map((partner) => ({
+        partner: this.formatPartner(partner),
+        ...this.formatTotals(partner, totalsByPartner.get(partner.id)),
+      })),
+    };
+  }
+
+  /**
+   * Stats of the partner linked to the user, per month
+   * @param {string} userId
+   * @param {Object} options - { months }
+   * @returns {Promise<Object>} - { partner, months: [{ month, signups, confirmedSignups, firstBookings, bookingVolume, commission }] }
+   */
+  async getPartnerStats(userId, { months } = {}) {
+    const partner = await affiliateRepository.findPartnerByUser(userId);
+    if (!partner) {
+      throw new NotFoundError("Tu cuenta no está vinculada a un partner");
+    }
+    const count = Math.min(parseInt(months, 10) || DEFAULT_STATS_MONTHS, MAX_STATS_MONTHS);
+    const start = new Date();
+    start.setUTCDate(1);
+    start.setUTCMonth(start.getUTCMonth() - (count - 1));
+
+    const rows = await affiliateRepository.getMonthlyTotals(partner.id, start.toISOString().slice(0, 8) + "01");
+    return {
+      partner: this.formatPartner(partner),
+      months: rows.map((row) => ({ month: row.month, ...this.formatTotals(partner, row) })),
+    };
+  }
+
+  async validatePartnerFields(data, { requireName = false, partnerId = null } = {}) {
+    const fields = {};
+    if (data.name !== undefined || requireName) {
+      const name = typeof data.name === "string" ? data.name.trim() : "";
+      if (!name || name.length > 120) {
+        throw new ValidationError("El nombre es requerido (hasta 120 caracteres)");
+      }
+      fields.name = name;
+    }
+    if (data.contactEmail !== undefined) {
+      fields.contactEmail = data.contactEmail ? String(data.contactEmail).trim().slice(0, 255) : null;
+    }
+    if (data.commissionRate !== undefined) {
+      const rate = Number(data.commissionRate);
+      if (!Number.isFinite(rate) || rate < 0 || rate > 1) {
+        throw new ValidationError("commissionRate debe ser una fracción entre 0 y 1");
+      }
+      fields.commissionRate = rate;
+    }
+    if (data.signupBounty !== undefined) {
+      const bounty = Number(data.signupBounty);
+      if (!Number.isInteger(bounty) || bounty < 0) {
+        throw new ValidationError("signupBounty debe ser un entero en centavos");
+      }
+      fields.signupBounty = bounty;
+    }
+    if (data.currency !== undefined) {
+      const currency = String(data.currency).toUpperCase();
+      if (!CURRENCY_REGEX.test(currency)) {
+        throw new ValidationError("currency debe ser un código ISO 4217");
+      }
+      fields.currency = currency;
+    }
+    if (data.active !== undefined) {
+      fields.active = Boolean(data.active);
+    }
+    if (data.userId !== undefined) {
+      fields.userId = data.userId ? await this.validatePartnerUser(data.userId, partnerId) : null;
+    }
+    return fields;
+  }
+
+  async validatePartnerUser(userId, partnerId) {
+    const user = UUID_REGEX.test(userId) ? await this.userRepository.findById(userId) : null;
+    if (!user) {
+      throw new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND");
+    }
+    const linked = await affiliateRepository.findPartnerByUser(userId);
+    if (linked && linked.id !== partnerId) {
+      throw new AppError("El usuario ya está vinculado a otro partner", 409, "AFFILIATE_USER_TAKEN");
+    }
+    return userId;
+  }
+
+  monthRange(month) {
+    const match = MONTH_REGEX.exec(month);
+    if (!match) {
+      throw new ValidationError("month debe tener formato YYYY-MM");
+    }
+    const year = Number(match[1]);
+    const monthIndex = Number(match[2]);
+    const next = monthIndex === 12 ? `${year + 1}-01` : `${year}-${String(monthIndex + 1).padStart(2, "0")}`;
+    return { from: `${month}-01`, to: `${next}-01`, label: month };
+  }
+
+  previousMonth() {
+    const date = new Date();
+    date.setUTCDate(1);
+    date.setUTCMonth(date.getUTCMonth() - 1);
+    return date.toISOString().slice(0, 7);
+  }
+
+  normalizeCode(code) {
+    return typeof code === "string" ? code.trim().toUpperCase() : "";
+  }
+
+  truncate(value) {
+    return typeof value === "string" && value.trim() ? value.trim().slice(0, MAX_UTM_LENGTH) : null;
+  }
+
+  formatTotals(partner, row) {
+    const totals = {
+      signups: row?.signups || 0,
+      confirmedSignups: row?.confirmedSignups || 0,
+      firstBookings: row?.firstBookings || 0,
+      bookingVolume: row?.bookingVolume || 0,
+    };
+    return {
+      ...totals,
+      commission: totals.confirmedSignups * partner.signupBounty + (row?.bookingCommission || 0),
+      currency: partner.currency,
+    };
+  }
+
+  formatPartner(partner) {
+    return {
+      id: partner.id,
+      name: partner.name,
+      code: partner.code,
+      userId: partner.userId,
+      contactEmail: partner.contactEmail,
+      commissionRate: Number(partner.commissionRate),
+      signupBounty: partner.signupBounty,
+      currency: partner.currency,
+      active: partner.active,
+      createdAt: partner.createdAt,
+    };
+  }
+}
+
+export default new AffiliateService();
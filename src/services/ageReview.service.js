@@ -0,0 +1,155 @@
+import ageReviewRepository from "../repository/ageReview.repository.js";
+import UserRepository from "../repository/user.repository.js";
+import logger from "../config/logger.js";
+import { createAndEmitNotification } from "../socket/notification.emitter.js";
+import { getAgeRule, getUserAge, isAgeRestricted } from "../utils/ageVerification.js";
+import { AppError, ValidationError, NotFoundError } from "../utils/customErrors.js";
+
+const userRepository = new UserRepository();
+
+const REVIEW_STATUSES = ["open", "cleared", "restricted"];
+const DECISIONS = ["cleared", "restricted"];
+
+/**
+ * Handling of accounts suspected to be under the minimum age of their
+ * jurisdiction: users report them, admins clear them or restrict their features
+ */
+class AgeReviewService {
+  /**
+   * Reports an account as possibly underage
+   * @param {string} reporterId
+   * @param {string} userId - Reported account
+   * @param {string|null} reason
+   * @returns {Promise<Object>} - The open review (existing or new)
+   */
+  async reportUser(reporterId, userId, reason = null) {
+    if (reporterId === userId) {
+      throw new ValidationError("No puedes reportarte a ti mismo", null, "SELF_REPORT");
+    }
+    if (reason && reason.length > 1000) {
+      throw new ValidationError("El motivo no puede superar los 1000 caracteres");
+    }
+    const user = await userRepository.findById(userId);
+    if (!user) {
+      throw new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND");
+    }
+
+    // One open case per account; further reports don't add noise to the queue
+    const existing = await ageReviewRepository.findOpenByUser(userId);
+    if (existing) {
+      return existing;
+    }
+
+    const review = await ageReviewRepository.create({
+      userId,
+      source: "report",
+      reportedById: reporterId,
+      reason: reason ? reason.trim() : null,
+    });
+    logger.info(`Age review ${review.id} opened for user ${userId} (reported by ${reporterId})`);
+    return review;
+  }
+
+  /**
+   * Opens a review when a profile change declares an age under the jurisdiction minimum
+   * @param {Object} user - { id, country }
+   * @param {number} age - Declared age
+   */
+  async flagDeclaredAge(user, age) {
+    const { minimumAge } = getAgeRule(user.country);
+    if (age >= minimumAge || (await ageReviewRepository.findOpenByUser(user.id))) {
+      return null;
+    }
+
+    const review = await ageReviewRepository.create({
+      userId: user.id,
+      source: "profile",
+      reason: `Edad declarada ${age}, mínimo ${minimumAge}`,
+    });
+    logger.info(`Age review ${review.id} opened for user ${user.id} (declared age ${age})`);
+    return review;
+  }
+
+  /**
+   * Lists reviews, oldest first so the queue is handled in order
+   */
+  async getReviews(status = "open") {
+    if (status && !REVIEW_STATUSES.includes(status)) {
+      throw new ValidationError("Estado de revisión inválido");
+    }
+    const reviews = await ageReviewRepository.findByStatus(status);
+    return reviews.map((review) => this.formatReview(review));
+  }
+
+  /**
+   * Resolves an open review (admin)
+   * @param {string} adminId
+   * @param {string} reviewId
+   * @param {Object} data - { decision: "cleared" | "restricted", notes }
+   */
+  async resolveReview(adminId, reviewId, { decision, notes = null }) {
+    if (!DECISIONS.includes(decision)) {
+      throw new ValidationError(`La decisión debe ser una de: ${DECISIONS.join(", ")}`);
+    }
+    const review = await ageReviewRepository.findById(reviewId);
+    if (!review) {
+      throw new NotFoundError("Revisión no encontrada");
+    }
+    if (review.status !== "open") {
+      throw new AppError("La revisión ya fue resuelta", 409, "AGE_REVIEW_RESOLVED");
+    }
+
+    await userRepository.update(review.userId, { ageRestricted: decision === "restricted" });
+    const resolved = await ageReviewRepository.update(reviewId, {
+      status: decision,
+      resolvedById: adminId,
+      resolutionNotes: notes,
+      resolvedAt: new Date(),
+    });
+    logger.info(`Age review ${reviewId} resolved as ${decision} by admin ${adminId}`);
+
+    if (decision === "restricted") {
+      try {
+        await createAndEmitNotification({
+          userId: review.userId,
+          type: "ACCOUNT_AGE_RESTRICTED",
+          title: "Tu cuenta tiene funciones limitadas",
+          message:
+            "Por la edad mínima de tu país no puedes crear viajes, unirte a grupos ni enviar mensajes directos",
+          data: { reviewId },
+        });
+      } catch (notifError) {
+        logger.error(`Error notifying age restriction ${reviewId}: ${notifError.message}`);
+      }
+    }
+
+    return this.formatReview(resolved);
+  }
+
+  formatReview(review) {
+    return {
+      id: review.id,
+      source: review.source,
+      reason: review.reason,
+      status: review.status,
+      reportedById: review.reportedById,
+      resolvedById: review.resolvedById,
+      resolutionNotes: review.resolutionNotes,
+      resolvedAt: review.resolvedAt,
+      createdAt: review.createdAt,
+      user: review.user
+        ? {
+            id: review.user.id,
+            name: review.user.name,
+            email: review.user.email,
+            dateOfBirth: review.user.dateOfBirth,
+            age: getUserAge(review.user),
+            country: review.user.country,
+            ageRestricted: isAgeRestricted(review.user),
+          }
+        : { id: review.userId },
+    };
+  }
+}
+
+export default new AgeReviewService();
@@ -0,0 +1,224 @@
+import crypto from "crypto";
+import aggregatorRepository from "../repository/aggregator.repository.js";
+import auditService, { AUDIT_ACTIONS } from "./audit.service.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { ValidationError, NotFoundError } from "../utils/customErrors.js";
+
+const UUID_REGEX = /^[0-9a-f]{8}-[0-9a-f]{4}-[1-5][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/i;
+const API_KEY_PREFIX = "jtag_";
+const MAX_QUOTA = 1000000;
+const MAX_COST_QUOTA = 100000000;
+const MAX_PRICE = 1000000;
+const USAGE_DAYS = 30;
+
+/**
+ * Aggregator partners approved to read the availability feed: API keys,
+ * approval (active flag) and daily quotas
+ */
+class AggregatorService {
+  /**
+   * Approves an aggregator and issues its API key
+   * @param {string} adminId
+   * @param {Object} data - { name, contactEmail, dailyQuota, dailyCostQuota, pricePerThousandUnits }
+   * @returns {Promise<Object>} - { partner, apiKey } (the key is not stored and is shown only now)
+   */
+  async createPartner(adminId, data = {}) {
+    const name = typeof data.name === "string" ? data.name.trim() : "";
+    if (!name || name.length > 120) {
+      throw new ValidationError("El nombre es requerido (hasta 120 caracteres)");
+    }
+    const { apiKey, apiKeyHash, apiKeyPrefix } = this.generateKey();
+
+    const partner = await aggregatorRepository.create({
+      name,
+      contactEmail: data.contactEmail ? String(data.contactEmail).trim().slice(0, 255) : null,
+      dailyQuota: this.validateQuota(data.dailyQuota ?? config.availabilityFeed.defaultDailyQuota),
+      dailyCostQuota: this.validateCostQuota(data.dailyCostQuota ?? null),
+      pricePerThousandUnits: this.validatePrice(data.pricePerThousandUnits ?? null),
+      apiKeyHash,
+      apiKeyPrefix,
+      approvedById: adminId,
+    });
+    logger.info(`Aggregator ${partner.id} (${name}) approved by ${adminId}`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.AGGREGATOR_CREATED,
+      actorId: adminId,
+      targetType: "aggregator_partner",
+      targetId: partner.id,
+    });
+    return { partner: this.formatPartner(partner), apiKey };
+  }
+
+  async listPartners() {
+    const partners = await aggregatorRepository.findAll();
+    return partners.map((partner) => this.formatPartner(partner));
+  }
+
+  /**
+   * Details of an aggregator with its usage over the last days
+   * @param {string} partnerId
+   */
+  async getPartner(partnerId) {
+    const partner = await this.getPartnerOrFail(partnerId);
+    const usage = await aggregatorRepository.findUsage(partnerId, USAGE_DAYS);
+    return { ...this.formatPartner(partner), usage };
+  }
+
+  /**
+   * Changes the name, contact, quotas, price or approval of an aggregator
+   * @param {string} partnerId
+   * @param {Object} data - { name, contactEmail, dailyQuota, dailyCostQuota, pricePerThousandUnits, active }
+   */
+  async updatePartner(partnerId, data = {}) {
+    await this.getPartnerOrFail(partnerId);
+    const changes = {};
+    if (data.name !== undefined) {
+      const name = typeof data.name === "string" ? data.name.trim() : "";
+      if (!name || name.length > 120) {
+        throw new ValidationError("El nombre es requerido (hasta 120 caracteres)");
+      }
+      changes.name = name;
+    }
+    if (data.contactEmail !== undefined) {
+      changes.contactEmail = data.contactEmail ? String(data.contactEmail).trim().slice(0, 255) : null;
+    }
+    if (data.dailyQuota !== undefined) {
+      changes.dailyQuota = this.validateQuota(data.dailyQuota);
+    }
+    if (data.dailyCostQuota !== undefined) {
+      changes.dailyCostQuota = this.validateCostQuota(data.dailyCostQuota);
+    }
+    if (data.pricePerThousandUnits !== undefined) {
+      changes.pricePerThousandUnits = this.validatePrice(data.pricePerThousandUnits);
+    }
+    if (data.active !== undefined) {
+      changes.active = Boolean(data.active);
+    }
+    if (Object.keys(changes).length === 0) {
+      throw new ValidationError("No hay cambios para guardar", null, "NO_CHANGES");
+    }
+    const partner = await aggregatorRepository.update(partnerId, changes);
+    await auditService.record({
+      action: AUDIT_ACTIONS.AGGREGATOR_UPDATED,
+      targetType: "aggregator_partner",
+      targetId: partnerId,
+      metadata: { fields: Object.keys(changes) },
+    });
+    return this.formatPartner(partner);
+  }
+
+  /**
+   * Replaces the API key of an aggregator; the old one stops working at once
+   * @param {string} partnerId
+   * @returns {Promise<Object>} - { partner, apiKey }
+   */
+  async rotateKey(partnerId) {
+    await this.getPartnerOrFail(partnerId);
+    const { apiKey, apiKeyHash, apiKeyPrefix } = this.generateKey();
+    const partner = await aggregatorRepository.update(partnerId, { apiKeyHash, apiKeyPrefix });
+    logger.info(`API key of aggregator ${partnerId} rotated`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.AGGREGATOR_KEY_ROTATED,
+      targetType: "aggregator_partner",
+      targetId: partnerId,
+    });
+    return { partner: this.formatPartner(partner), apiKey };
+  }
+
+  /**
+   * Aggregator an API key belongs to, if it is still approved
+   * @param {string} apiKey
+   * @returns {Promise<Object|null>}
+   */
+  async findByApiKey(apiKey) {
+    if (typeof apiKey !== "string" || !apiKey.startsWith(API_KEY_PREFIX)) {
+      return null;
+    }
+    const partner = await aggregatorRepository.findByKeyHash(this.hashKey(apiKey));
+    return partner && partner.active ? partner : null;
+  }
+
+  /**
+   * Counts a feed request against the daily quota of the aggregator
+   * @param {Object} partner
+   * @returns {Promise<Object>} - { limit, remaining, resetAt, exceeded }
+   */
+  async consumeQuota(partner) {
+    const used = await aggregatorRepository.incrementUsage(partner.id);
+    const resetAt = new Date();
+    resetAt.setUTCHours(24, 0, 0, 0);
+    return {
+      limit: partner.dailyQuota,
+      remaining: Math.max(partner.dailyQuota - used, 0),
+      resetAt,
+      exceeded: used > partner.dailyQuota,
+    };
+  }
+
+  async getPartnerOrFail(partnerId) {
+    const partner = UUID_REGEX.test(partnerId) ? await aggregatorRepository.findById(partnerId) : null;
+    if (!partner) {
+      throw new NotFoundError("Agregador no encontrado");
+    }
+    return partner;
+  }
+
+  validateQuota(value) {
+    const quota = Number(value);
+    if (!Number.isInteger(quota) || quota < 1 || quota > MAX_QUOTA) {
+      throw new ValidationError(`dailyQuota debe ser un entero entre 1 y ${MAX_QUOTA}`);
+    }
+    return quota;
+  }
+
+  // null removes the cost limit
+  validateCostQuota(value) {
+    if (value === null) return null;
+    const quota = Number(value);
+    if (!Number.isInteger(quota) || quota < 1 || quota > MAX_COST_QUOTA) {
+      throw new ValidationError(`dailyCostQuota debe ser un entero entre 1 y ${MAX_COST_QUOTA} o null`);
+    }
+    return quota;
+  }
+
+  // null bills at the configured default price
+  validatePrice(value) {
+    if (value === null) return null;
+    const price = Number(value);
+    if (!Number.isFinite(price) || price < 0 || price > MAX_PRICE) {
+      throw new ValidationError(`pricePerThousandUnits debe ser un número entre 0 y ${MAX_PRICE} o null`);
+    }
+    return price;
+  }
+
+  generateKey() {
+    const apiKey = `${API_KEY_PREFIX}${crypto.randomBytes(24).toString("base64url")}`;
+    return { apiKey, apiKeyHash: this.hashKey(apiKey), apiKeyPrefix: apiKey.slice(0, 12) };
+  }
+
+  hashKey(apiKey) {
+    return crypto.createHash("sha256").update(apiKey).digest("hex");
+  }
+
+  formatPartner(partner) {
+    return {
+      id: partner.id,
+      name: partner.name,
+      contactEmail: partner.contactEmail,
+      apiKeyPrefix: partner.apiKeyPrefix,
+      dailyQuota: partner.dailyQuota,
+      dailyCostQuota: partner.dailyCostQuota,
+      pricePerThousandUnits:
+        partner.pricePerThousandUnits === null || partner.pricePerThousandUnits === undefined
+          ? null
+          : Number(partner.pricePerThousandUnits),
+      active: partner.active,
+      approvedById: partner.approvedById,
+      lastUsedAt: partner.lastUsedAt,
+      createdAt: partner.createdAt,
+    };
+  }
+}
+
+export default new AggregatorService();
@@ -0,0 +1,186 @@
+import { AppDataSource } from "../load/typeorm.loader.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { suppressSmallCohorts } from "../utils/kAnonymity.js";
+import { NotFoundError, ValidationError } from "../utils/customErrors.js";
+
+/**
+ * Aggregated metric definitions. Every query must return rows shaped as
+ * { cohort, count } where `count` is the number of distinct users (or groups)
+ * behind the cohort, so k-anonymity can be enforced on it.
+ */
+const METRICS = {
+  usersByAgeBracket: {
+    description: "Registered users by age bracket",
+    unit: "users",
+    sql: `SELECT CASE
+              WHEN age IS NULL THEN 'unknown'
+              WHEN age < 18 THEN '13-17'
+              WHEN age < 25 THEN '18-24'
+              WHEN age < 35 THEN '25-34'
+              WHEN age < 45 THEN '35-44'
+              WHEN age < 55 THEN '45-54'
+              ELSE '55+'
+            END AS cohort,
+            COUNT(*)::int AS count
+          FROM (
+            -- Current age from the date of birth; the declared one for older accounts
+            SELECT COALESCE(EXTRACT(YEAR FROM age("dateOfBirth"))::int, age) AS age FROM users
+          ) users
+          GROUP BY cohort
+          ORDER BY cohort`,
+  },
+  usersByLevel: {
+    description: "Users by gamification level",
+    unit: "users",
+    sql: `SELECT "levelName" AS cohort, COUNT(*)::int AS count
+          FROM users
+          GROUP BY "levelName"
+          ORDER BY count DESC`,
+  },
+  signupsByMonth: {
+    description: "New signups per month",
+    unit: "users",
+    sql: `SELECT to_char(date_trunc('month', "createdAt"), 'YYYY-MM') AS cohort,
+            COUNT(*)::int AS count
+          FROM users
+          GROUP BY cohort
+          ORDER BY cohort`,
+  },
+  groupsBySize: {
+    description: "Groups by number of members",
+    unit: "groups",
+    sql: `SELECT CASE
+              WHEN members <= 1 THEN '1'
+              WHEN members <= 3 THEN '2-3'
+              WHEN members <= 6 THEN '4-6'
+              WHEN members <= 10 THEN '7-10'
+              ELSE '11+'
+            END AS cohort,
+            COUNT(*)::int AS count
+          FROM (
+            SELECT g.id, COUNT(gm."userId") AS members
+            FROM groups g
+            LEFT JOIN group_members gm ON gm."groupId" = g.id
+            WHERE g."deletedAt" IS NULL
+            GROUP BY g.id
+          ) sizes
+          GROUP BY cohort
+          ORDER BY cohort`,
+  },
+  reviewersByCity: {
+    description: "Distinct reviewers per place city",
+    unit: "users",
+    sql: `SELECT COALESCE(p.city, 'unknown') AS cohort,
+            COUNT(DISTINCT r."userId")::int AS count
+          FROM reviews r
+          JOIN places p ON p.id = r."placeId"
+          GROUP BY cohort
+          ORDER BY count DESC`,
+  },
+};
+
+class AnalyticsService {
+  /**
+   * Lists the available metrics
+   * @returns {Array<Object>} - [{ name, description, unit }]
+   */
+  listMetrics() {
+    return Object.entries(METRICS).map(([name, metric]) => ({
+      name,
+      description: metric.description,
+      unit: metric.unit,
+    }));
+  }
+
+  /**
+   * Computes a metric and suppresses cohorts below the k-anonymity threshold
+   * @param {string} name - Metric name
+   * @param {number} [k] - Threshold override (never lower than the configured one)
+   * @returns {Promise<Object>} - { metric, unit, k, cohorts, suppressedCohorts, generatedAt }
+   */
+  async getMetric(name, k) {
+    const metric = METRICS[name];
+    if (!metric) {
+      throw new NotFoundError(`Métrica no encontrada: ${name}`);
+    }
+
+    const threshold = this.resolveThreshold(k);
+    const rows = await AppDataSource.query(metric.sql);
+    const { rows: cohorts, suppressedCohorts } = suppressSmallCohorts(rows, {
+      k: threshold,
+    });
+
+    logger.info(
+      `Analytics metric ${name} computed: ${cohorts.length} cohorts exposed, ${suppressedCohorts} suppressed (k=${threshold})`
+    );
+
+    return {
+      metric: name,
+      unit: metric.unit,
+      k: threshold,
+      cohorts,
+      suppressedCohorts,
+      generatedAt: new Date().toISOString(),
+    };
+  }
+
+  /**
+   * Builds a warehouse export with every metric, already anonymized
+   * @param {string} format - "json" | "csv"
+   * @returns {Promise<Object>} - { contentType, body }
+   */
+  async exportMetrics(format = "json") {
+    if (!["json", "csv"].includes(format)) {
+      throw new ValidationError("Formato inválido. Use json o csv.");
+    }
+
+    const results = [];
+    for (const name of Object.keys(METRICS)) {
+      results.push(await this.getMetric(name));
+    }
+
+    if (format === "json") {
+      return {
+        contentType: "application/json",
+        body: JSON.stringify({
+          k: this.resolveThreshold(),
+          generatedAt: new Date().toISOString(),
+          metrics: results,
+        }),
+      };
+    }
+
+    const lines = ["metric,unit,cohort,count"];
+    for (const result of results) {
+      for (const cohort of result.cohorts) {
+        lines.push(
+          [result.metric, result.unit, cohort.cohort, cohort.count]
+            .map((value) => `"${String(value).replace(/"/g, '""')}"`)
+            .join(",")
+        );
+      }
+    }
+
+    return {
+      contentType: "text/csv",
+      body: lines.join("\n"),
+    };
+  }
+
+  /**
+   * Callers may ask for a stricter threshold but never a weaker one
+   * @param {number} [k]
+   * @returns {number}
+   */
+  resolveThreshold(k) {
+    const configured = config.analytics.kAnonymityThreshold;
+    const requested = parseInt(k, 10);
+    if (!requested || requested < configured) {
+      return configured;
+    }
+    return requested;
+  }
+}
+
+export default new AnalyticsService();
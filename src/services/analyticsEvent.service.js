@@ -0,0 +1,371 @@
+import crypto from "crypto";
+import redisClient from "../clients/redis.client.js";
+import warehouseSink from "../clients/warehouse.client.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { ValidationError } from "../utils/customErrors.js";
+
+export const EVENT_PLATFORMS = ["ios", "android", "web"];
+export const TRIP_VIEW_SOURCES = ["search", "feed", "recommended", "share_link", "notification", "profile"];
+
+const UUID_REGEX = /^[0-9a-f]{8}-[0-9a-f]{4}-[1-8][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/i;
+const MAX_SESSION_ID_LENGTH = 64;
+const MAX_APP_VERSION_LENGTH = 20;
+// Client clocks drift; events further ahead or older are rejected
+const MAX_FUTURE_SKEW_MS = 5 * 60 * 1000;
+const MAX_AGE_MS = 7 * 24 * 60 * 60 * 1000;
+
+/**
+ * Properties of each event the apps may send. An event with an unknown name,
+ * an unknown property or a property of the wrong type is rejected.
+ */
+export const EVENT_SCHEMAS = {
+  screen_view: {
+    screen: { type: "string", maxLength: 100, required: true },
+    previousScreen: { type: "string", maxLength: 100 },
+    durationMs: { type: "integer", min: 0 },
+  },
+  trip_view: {
+    groupId: { type: "uuid", required: true },
+    source: { type: "enum", values: TRIP_VIEW_SOURCES },
+  },
+  trip_share: {
+    groupId: { type: "uuid", required: true },
+    channel: { type: "string", maxLength: 40 },
+  },
+  trip_join_click: {
+    groupId: { type: "uuid", required: true },
+    source: { type: "enum", values: TRIP_VIEW_SOURCES },
+  },
+  trip_save: {
+    groupId: { type: "uuid", required: true },
+    saved: { type: "boolean", required: true },
+  },
+  search_performed: {
+    query: { type: "string", maxLength: 200 },
+    resultCount: { type: "integer", min: 0, required: true },
+  },
+  recommendation_click: {
+    groupId: { type: "uuid", required: true },
+    position: { type: "integer", min: 0, required: true },
+  },
+};
+
+const checkProperty = (rule, value) => {
+  switch (rule.type) {
+    case "string":
+      return typeof value === "string" && value.length <= rule.maxLength
+        ? null
+        : `Debe ser un texto de hasta ${rule.maxLength} caracteres`;
+    case "integer":
+      return Number.isInteger(value) && value >= rule.min ? null : `Debe ser un entero mayor o igual a ${rule.min}`;
+    case "boolean":
+      return typeof value === "boolean" ? null : "Debe ser booleano";
+    case "uuid":
+      return typeof value === "string" && UUID_REGEX.test(value) ? null : "Debe ser un UUID";
+    case "enum":
+      return rule.values.includes(value) ? null : `Debe ser uno de: ${rule.values.join(", ")}`;
+    default:
+      return "Tipo desconocido";
+  }
+};
+
+/**
+ * Errors of one event of a batch
+ * @param {Object} event - { name, occurredAt, sessionId, properties }
+ * @param {number} now - Time of the request
+ * @returns {Object} - { field: message }, empty when valid
+ */
+const validateEvent = (event, now) => {
+  if (!event || typeof event !== "object" || Array.isArray(event)) {
+    return { event: "Debe ser un objeto" };
+  }
+  const errors = {};
+  const schema = Object.hasOwn(EVENT_SCHEMAS, event.name) ? EVENT_SCHEMAS[event.name] : null;
+  if (!schema) {
+    errors.name = `Debe ser uno de: ${Object.keys(EVENT_SCHEMAS).join(", ")}`;
+  }
+
+  const occurredAt = typeof event.occurredAt === "string" ? Date.parse(event.occurredAt) : NaN;
+  if (Number.isNaN(occurredAt)) {
+    errors.occurredAt = "Debe ser una fecha ISO 8601";
+  } else if (occurredAt > now + MAX_FUTURE_SKEW_MS || occurredAt < now - MAX_AGE_MS) {
+    errors.occurredAt = "Debe ser de los últimos 7 días";
+  }
+
+  if (
+    event.sessionId !== undefined &&
+    event.sessionId !== null &&
+    (typeof event.sessionId !== "string" || event.sessionId.length > MAX_SESSION_ID_LENGTH)
+  ) {
+    errors.sessionId = `Debe ser un texto de hasta ${MAX_SESSION_ID_LENGTH} caracteres`;
+  }
+
+  const properties = event.properties ?? {};
+  if (typeof properties !== "object" || Array.isArray(properties)) {
+    errors.properties = "Debe ser un objeto";
+  } else if (schema) {
+    for (const key of Object.keys(properties)) {
+      if (!Object.hasOwn(schema, key)) {
+        errors[`properties.${key}`] = "Propiedad desconocida";
+      }
+    }
+    for (const [key, rule] of Object.entries(schema)) {
+      const value = properties[key];
+      if (value === undefined || value === null) {
+        if (rule.required) {
+          errors[`properties.${key}`] = "Es obligatoria";
+        }
+        continue;
+      }
+      const error = checkProperty(rule, value);
+      if (error) {
+        errors[`properties.${key}`] = error;
+      }
+    }
+  }
+  return errors;
+};
+
+/**
+ * Events waiting in this process; the oldest are dropped past maxBuffered
+ */
+class MemoryBuffer {
+  name = "memory";
+
+  constructor() {
+    this.events = [];
+  }
+
+  async push(events) {
+    this.events.push(...events);
+    const overflow = this.events.length - config.analyticsEvents.maxBuffered;
+    if (overflow > 0) {
+      this.events.splice(0, overflow);
+    }
+    return Math.max(overflow, 0);
+  }
+
+  async take(count) {
+    return this.events.splice(0, count);
+  }
+
+  async putBack(events) {
+    this.events.unshift(...events);
+  }
+
+  async size() {
+    return this.events.length;
+  }
+}
+
+/**
+ * Events waiting in a Redis list shared by every instance, so they survive a
+ * restart and any instance can flush them. LPOP with a count needs Redis 6.2.
+ */
+class RedisBuffer {
+  name = "redis";
+
+  get key() {
+    return config.analyticsEvents.redisKey;
+  }
+
+  async push(events) {
+    const length = await redisClient.command("RPUSH", this.key, ...events.map((event) => JSON.stringify(event)));
+    const overflow = length - config.analyticsEvents.maxBuffered;
+    if (overflow > 0) {
+      await redisClient.command("LTRIM", this.key, -config.analyticsEvents.maxBuffered, -1);
+    }
+    return Math.max(overflow, 0);
+  }
+
+  async take(count) {
+    const items = await redisClient.command("LPOP", this.key, count);
+    return (items || []).map((item) => JSON.parse(item));
+  }
+
+  async putBack(events) {
+    if (events.length > 0) {
+      // LPUSH prepends one at a time, so reversing keeps the original order
+      await redisClient.command("LPUSH", this.key, ...events.map((event) => JSON.stringify(event)).reverse());
+    }
+  }
+
+  async size() {
+    return await redisClient.command("LLEN", this.key);
+  }
+}
+
+/**
+ * Product analytics events sent by the apps (screen views, trip interactions).
+ * Batches are validated event by event, buffered, and written to the
+ * warehouse sink every ANALYTICS_EVENTS_FLUSH_INTERVAL_MS (and on shutdown),
+ * so ingesting never waits on the warehouse. Delivery is at least once: a
+ * failed write is retried whole and the warehouse deduplicates on eventId.
+ */
+class AnalyticsEventService {
+  constructor() {
+    this.memory = new MemoryBuffer();
+    this.redis = new RedisBuffer();
+    this.timer = null;
+    this.flushing = null;
+    this.dropped = 0;
+    this.lastFlushAt = null;
+    this.lastError = null;
+  }
+
+  // Redis is used only when configured; memory also catches what Redis refused
+  get buffers() {
+    return config.analyticsEvents.buffer === "redis" && redisClient.isConfigured()
+      ? [this.redis, this.memory]
+      : [this.memory];
+  }
+
+  start() {
+    if (!warehouseSink || this.timer) return;
+    this.timer = setInterval(() => {
+      this.flush().catch(() => {});
+    }, config.analyticsEvents.flushIntervalMs);
+    this.timer.unref();
+  }
+
+  async stop() {
+    clearInterval(this.timer);
+    this.timer = null;
+    await this.flush();
+  }
+
+  /**
+   * Validates and buffers a batch of events
+   * @param {Object} batch - { events: [{ name, occurredAt, sessionId, properties }], context: { platform, appVersion } }
+   * @param {Object} caller - { userId, tenant, locale }
+   * @returns {Promise<Object>} - { accepted, rejected: [{ index, errors }] }
+   */
+  async ingest(batch, { userId = null, tenant = null, locale = null } = {}) {
+    const { events, context = {} } = batch || {};
+    const { maxEventsPerRequest } = config.analyticsEvents;
+    const details = {};
+    if (!Array.isArray(events) || events.length === 0 || events.length > maxEventsPerRequest) {
+      details.events = `Debe ser una lista de 1 a ${maxEventsPerRequest} eventos`;
+    }
+    if (!context || typeof context !== "object" || Array.isArray(context)) {
+      details.context = "Debe ser un objeto";
+    } else {
+      if (context.platform !== undefined && !EVENT_PLATFORMS.includes(context.platform)) {
+        details["context.platform"] = `Debe ser uno de: ${EVENT_PLATFORMS.join(", ")}`;
+      }
+      if (
+        context.appVersion !== undefined &&
+        (typeof context.appVersion !== "string" || context.appVersion.length > MAX_APP_VERSION_LENGTH)
+      ) {
+        details["context.appVersion"] = `Debe ser un texto de hasta ${MAX_APP_VERSION_LENGTH} caracteres`;
+      }
+    }
+    if (Object.keys(details).length > 0) {
+      throw new ValidationError("Lote de eventos inválido", details, "INVALID_EVENT_BATCH");
+    }
+
+    const now = Date.now();
+    const receivedAt = new Date(now).toISOString();
+    const rows = [];
+    const rejected = [];
+    events.forEach((event, index) => {
+      const errors = validateEvent(event, now);
+      if (Object.keys(errors).length > 0) {
+        rejected.push({ index, errors });
+        return;
+      }
+      rows.push({
+        eventId: crypto.randomUUID(),
+        name: event.name,
+        occurredAt: new Date(event.occurredAt).toISOString(),
+        receivedAt,
+        userId,
+        sessionId: event.sessionId ?? null,
+        platform: context.platform ?? null,
+        appVersion: context.appVersion ?? null,
+        tenant,
+        locale,
+        properties: event.properties ?? {},
+      });
+    });
+
+    if (rows.length > 0 && warehouseSink) {
+      await this.buffer(rows);
+    }
+    return { accepted: rows.length, rejected };
+  }
+
+  async buffer(rows) {
+    const [primary] = this.buffers;
+    let dropped;
+    try {
+      dropped = await primary.push(rows);
+    } catch (error) {
+      logger.warn(`[AnalyticsEvents] Buffering ${rows.length} events in memory: ${error.message}`);
+      dropped = await this.memory.push(rows);
+    }
+    if (dropped > 0) {
+      this.dropped += dropped;
+      logger.warn(`[AnalyticsEvents] Buffer full, dropped ${dropped} events`);
+    }
+  }
+
+  /**
+   * Writes the buffered events to the sink in batches of
+   * ANALYTICS_EVENTS_FLUSH_BATCH_SIZE; a failed batch goes back to its buffer
+   * for the next attempt
+   */
+  async flush() {
+    if (this.flushing) return this.flushing;
+    if (!warehouseSink) return;
+    this.flushing = (async () => {
+      try {
+        for (const buffer of this.buffers) {
+          let events;
+          while ((events = await buffer.take(config.analyticsEvents.flushBatchSize)).length > 0) {
+            try {
+              await warehouseSink.write(events);
+            } catch (error) {
+              await buffer.putBack(events).catch((putBackError) => {
+                this.dropped += events.length;
+                logger.error(`[AnalyticsEvents] Lost ${events.length} events: ${putBackError.message}`);
+              });
+              throw error;
+            }
+            this.lastFlushAt = new Date();
+            this.lastError = null;
+          }
+        }
+      } catch (error) {
+        this.lastError = error.message;
+        logger.error(`[AnalyticsEvents] Could not write events to ${warehouseSink.name}: ${error.message}`);
+        throw error;
+      } finally {
+        this.flushing = null;
+      }
+    })();
+    return this.flushing;
+  }
+
+  /**
+   * For the status page
+   * @returns {Promise<Object>} - { sink, buffer, buffered, dropped, lastFlushAt, lastError }
+   */
+  async getStats() {
+    let buffered = 0;
+    for (const buffer of this.buffers) {
+      buffered += await buffer.size();
+    }
+    return {
+      sink: warehouseSink?.name ?? "none",
+      buffer: this.buffers[0].name,
+      buffered,
+      dropped: this.dropped,
+      lastFlushAt: this.lastFlushAt,
+      lastError: this.lastError,
+    };
+  }
+}
+
+export default new AnalyticsEventService();
@@ -0,0 +1,403 @@
+import crypto from "crypto";
+import path from "path";
+import attachmentRepository from "../repository/attachment.repository.js";
+import groupRepository from "../repository/group.repository.js";
+import UserRepository from "../repository/user.repository.js";
+import s3Client from "../clients/s3.client.js";
+import jobQueueService from "./jobQueue.service.js";
+import malwareScanService from "./malwareScan.service.js";
+import legalHoldService from "./legalHold.service.js";
+import { IMAGE_PROCESSING_JOB } from "../jobs/imageProcessing.job.js";
+import { ATTACHMENT_SCAN_JOB } from "../jobs/attachmentScan.job.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import {
+  ValidationError,
+  NotFoundError,
+  AuthorizationError,
+} from "../utils/customErrors.js";
+
+// Formats the image pipeline (sharp) can decode to strip metadata and build thumbnails
+const IMAGE_TYPES = ["image/jpeg", "image/png", "image/gif", "image/webp"];
+
+/**
+ * Upload kinds: what they attach to and which files they accept
+ */
+export const UPLOAD_KINDS = {
+  avatar: { entityType: "user", contentTypes: IMAGE_TYPES, maxSize: 5 * 1024 * 1024 },
+  group_photo: { entityType: "group", contentTypes: IMAGE_TYPES, maxSize: 15 * 1024 * 1024 },
+};
+
+class AttachmentService {
+  constructor() {
+    this.userRepository = new UserRepository();
+  }
+
+  /**
+   * Validates the declared file against the rules of its kind
+   */
+  validateFile(kind, contentType, size) {
+    const rules = UPLOAD_KINDS[kind];
+    if (!rules) {
+      throw new ValidationError(
+        `Tipo de subida inválido. Valores permitidos: ${Object.keys(UPLOAD_KINDS).join(", ")}`
+      );
+    }
+    if (!rules.contentTypes.includes(contentType)) {
+      throw new ValidationError(
+        `Tipo de archivo no permitido (${contentType}). Solo se permiten: ${rules.contentTypes.join(", ")}`
+      );
+    }
+    if (!Number.isInteger(size) || size <= 0) {
+      throw new ValidationError("El tamaño del archivo es inválido");
+    }
+    if (size > rules.maxSize) {
+      throw new ValidationError(
+        `El archivo excede el tamaño máximo de ${rules.maxSize / (1024 * 1024)}MB`
+      );
+    }
+    return rules;
+  }
+
+  /**
+   * Resolves the entity an upload belongs to and checks the user can attach files to it
+   * @returns {Promise<string>} Entity ID
+   */
+  async resolveEntity(kind, userId, groupId) {
+    if (UPLOAD_KINDS[kind].entityType === "user") {
+      return userId;
+    }
+
+    if (!groupId) {
+      throw new ValidationError("groupId es requerido para fotos de grupo");
+    }
+    await this.assertGroupMember(groupId, userId);
+    return groupId;
+  }
+
+  async assertGroupMember(groupId, userId) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      throw new NotFoundError("Grupo no encontrado", "GROUP_NOT_FOUND");
+    }
+    if (!group.members.some((member) => member.id === userId)) {
+      throw new AuthorizationError("No eres miembro de este grupo", "NOT_GROUP_MEMBER");
+    }
+    return group;
+  }
+
+  buildKey(kind, entityId, filename) {
+    const extension = filename ? path.extname(filename).toLowerCase().slice(0, 10) : "";
+    return `${kind}s/${entityId}/${crypto.randomUUID()}${extension}`;
+  }
+
+  /**
+   * Where the client uploads a presigned file. The attachment itself only
+   * points at its final key, which is never presigned for writing: a PUT
+   * after the upload was confirmed cannot replace the scanned file.
+   */
+  stagingKey(attachment) {
+    return `staging/${attachment.storageKey}`;
+  }
+
+  assertStorageConfigured() {
+    if (!s3Client.isConfigured()) {
+      const error = new Error("El almacenamiento de archivos no está disponible");
+      error.status = 503;
+      throw error;
+    }
+  }
+
+  /**
+   * Creates a pending attachment and a presigned URL the client uploads to directly
+   * @param {string} userId
+   * @param {Object} data - { kind, groupId, contentType, size, filename }
+   * @returns {Promise<Object>} - { attachment, upload: { url, method, headers, expiresIn } }
+   */
+  async createPresignedUpload(userId, { kind, groupId, contentType, size, filename }) {
+    this.assertStorageConfigured();
+    this.validateFile(kind, contentType, size);
+    const entityId = await this.resolveEntity(kind, userId, groupId);
+
+    const attachment = await attachmentRepository.create({
+      userId,
+      entityType: UPLOAD_KINDS[kind].entityType,
+      entityId,
+      kind,
+      storageKey: this.buildKey(kind, entityId, filename),
+      originalName: filename ? String(filename).slice(0, 255) : null,
+      contentType,
+      size,
+    });
+
+    const expiresIn = config.storage.presignExpiresIn;
+    return {
+      attachment: this.formatAttachment(attachment),
+      upload: {
+        url: s3Client.presignUrl("PUT", this.stagingKey(attachment), { expiresIn, contentType }),
+        method: "PUT",
+        headers: { "Content-Type": contentType },
+        expiresIn,
+      },
+    };
+  }
+
+  /**
+   * Confirms a presigned upload after checking what actually landed in the bucket
+   * @param {string} userId
+   * @param {string} attachmentId
+   * @returns {Promise<Object>} Uploaded attachment
+   */
+  async completeUpload(userId, attachmentId) {
+    this.assertStorageConfigured();
+    const attachment = await this.getOwnAttachment(userId, attachmentId);
+    if (attachment.status !== "pending") {
+      return this.formatAttachment(attachment);
+    }
+
+    const stagingKey = this.stagingKey(attachment);
+    const object = await s3Client.headObject(stagingKey);
+    if (!object) {
+      throw new ValidationError("El archivo todavía no fue subido");
+    }
+
+    // Presigned PUTs cannot enforce size, so oversized or mismatched uploads are discarded here
+    const rules = UPLOAD_KINDS[attachment.kind];
+    if (object.size > rules.maxSize || object.contentType !== attachment.contentType) {
+      await s3Client.deleteObject(stagingKey);
+      await attachmentRepository.delete(attachment.id);
+      throw new ValidationError("El archivo subido no coincide con el tipo o tamaño declarado");
+    }
+
+    // Scan and processing work on the copy, out of reach of the presigned URL
+    await s3Client.copyObject(stagingKey, attachment.storageKey);
+    await s3Client.deleteObject(stagingKey);
+
+    const uploaded = await attachmentRepository.update(attachment.id, {
+      status: "uploaded",
+      size: object.size,
+      scanStatus: malwareScanService.initialStatus(),
+    });
+    await this.afterUpload(uploaded);
+    return this.formatAttachment(uploaded);
+  }
+
+  /**
+   * Uploads a file through the API (multipart proxy upload)
+   * @param {string} userId
+   * @param {Object} data - { kind, groupId, file } where file comes from multer
+   * @returns {Promise<Object>} Uploaded attachment
+   */
+  async uploadFile(userId, { kind, groupId, file }) {
+    this.assertStorageConfigured();
+    if (!file) {
+      throw new ValidationError("No se proporcionó ningún archivo", null, "FILE_REQUIRED");
+    }
+    this.validateFile(kind, file.mimetype, file.size);
+    const entityId = await this.resolveEntity(kind, userId, groupId);
+
+    const storageKey = this.buildKey(kind, entityId, file.originalname);
+    await s3Client.putObject(storageKey, file.buffer, file.mimetype);
+
+    const attachment = await attachmentRepository.create({
+      userId,
+      entityType: UPLOAD_KINDS[kind].entityType,
+      entityId,
+      kind,
+      storageKey,
+      originalName: file.originalname ? file.originalname.slice(0, 255) : null,
+      contentType: file.mimetype,
+      size: file.size,
+      status: "uploaded",
+      scanStatus: malwareScanService.initialStatus(),
+    });
+    await this.afterUpload(attachment);
+    return this.formatAttachment(attachment);
+  }
+
+  /**
+   * Side effects of a finished upload: files wait for the antivirus before
+   * being released
+   */
+  async afterUpload(attachment) {
+    if (attachment.scanStatus === "pending") {
+      await malwareScanService.enqueueScan(ATTACHMENT_SCAN_JOB, { attachmentId: attachment.id });
+      return;
+    }
+    await this.releaseUpload(attachment);
+  }
+
+  /**
+   * Makes a clean (or unscanned) upload available
+   */
+  async releaseUpload(attachment) {
+    // Every upload kind is an image: strip metadata and build thumbnails in the background
+    await attachmentRepository.update(attachment.id, { processingStatus: "pending" });
+    attachment.processingStatus = "pending";
+    await jobQueueService.enqueue(
+      IMAGE_PROCESSING_JOB,
+      { attachmentId: attachment.id },
+      { maxAttempts: 3 }
+    );
+
+    if (attachment.kind === "avatar") {
+      await this.userRepository.updateUser(attachment.userId, {
+        profilePicture: this.getContentUrl(attachment),
+      });
+    }
+  }
+
+  /**
+   * Gets an attachment the user can see, with a temporary download URL
+   */
+  async getAttachment(userId, attachmentId) {
+    const attachment = await attachmentRepository.findById(attachmentId);
+    if (!attachment || attachment.status !== "uploaded") {
+      throw new NotFoundError("Archivo no encontrado", "FILE_NOT_FOUND");
+    }
+    await this.assertCanView(attachment, userId);
+    malwareScanService.assertDownloadable(attachment.scanStatus);
+    return this.formatAttachment(attachment, { withDownloadUrl: true });
+  }
+
+  /**
+   * Lists the photos of a group (members only). Photos still being scanned or
+   * quarantined are listed without download URLs.
+   */
+  async getGroupAttachments(userId, groupId) {
+    await this.assertGroupMember(groupId, userId);
+    const attachments = await attachmentRepository.findByEntity("group", groupId);
+    return attachments.map((attachment) =>
+      this.formatAttachment(attachment, { withDownloadUrl: true })
+    );
+  }
+
+  /**
+   * Resolves the download URL of a public attachment (avatars)
+   * @returns {Promise<string>} Presigned GET URL
+   */
+  async getPublicContentUrl(attachmentId) {
+    const attachment = await attachmentRepository.findById(attachmentId);
+    if (
+      !attachment ||
+      attachment.status !== "uploaded" ||
+      attachment.kind !== "avatar" ||
+      !this.isServable(attachment)
+    ) {
+      throw new NotFoundError("Archivo no encontrado", "FILE_NOT_FOUND");
+    }
+    return s3Client.presignUrl("GET", attachment.storageKey);
+  }
+
+  /**
+   * Deletes an attachment (uploader, or group admin for group photos)
+   */
+  async deleteAttachment(userId, attachmentId) {
+    const attachment = await attachmentRepository.findById(attachmentId);
+    if (!attachment) {
+      throw new NotFoundError("Archivo no encontrado", "FILE_NOT_FOUND");
+    }
+
+    if (attachment.userId !== userId) {
+      const group =
+        attachment.entityType === "group"
+          ? await groupRepository.findById(attachment.entityId)
+          : null;
+      if (!group || group.adminId !== userId) {
+        throw new AuthorizationError("No puedes eliminar este archivo");
+      }
+    }
+    await legalHoldService.assertNotOnHold(attachment.userId);
+
+    try {
+      await this.deleteObjects(attachment);
+    } catch (error) {
+      // The record goes away anyway; an orphan object is preferable to a dangling record
+      logger.error(`Failed to delete object ${attachment.storageKey}: ${error.message}`);
+    }
+    await attachmentRepository.delete(attachment.id);
+
+    if (attachment.kind === "avatar") {
+      const user = await this.userRepository.findUserById(attachment.userId);
+      if (user?.profilePicture === this.getContentUrl(attachment)) {
+        await this.userRepository.updateUser(attachment.userId, { profilePicture: null });
+      }
+    }
+  }
+
+  /**
+   * Deletes the stored files of an attachment: the file, its thumbnails and,
+   * for an upload never confirmed, what reached its upload URL
+   */
+  async deleteObjects(attachment) {
+    const keys = [attachment.storageKey, ...Object.values(attachment.variants || {}).map((variant) => variant.key)];
+    if (attachment.status === "pending") {
+      keys.push(this.stagingKey(attachment));
+    }
+    for (const key of keys) {
+      await s3Client.deleteObject(key);
+    }
+  }
+
+  async getOwnAttachment(userId, attachmentId) {
+    const attachment = await attachmentRepository.findById(attachmentId);
+    if (!attachment || attachment.userId !== userId) {
+      throw new NotFoundError("Archivo no encontrado", "FILE_NOT_FOUND");
+    }
+    return attachment;
+  }
+
+  async assertCanView(attachment, userId) {
+    if (attachment.entityType === "group") {
+      await this.assertGroupMember(attachment.entityId, userId);
+    }
+  }
+
+  /**
+   * Whether a photo can be served: it passed the antivirus and its EXIF/GPS
+   * metadata was stripped
+   */
+  isServable(attachment) {
+    return (
+      malwareScanService.isDownloadable(attachment.scanStatus) &&
+      attachment.processingStatus === "processed"
+    );
+  }
+
+  getContentUrl(attachment) {
+    return `${config.baseUrl}/api/attachments/${attachment.id}/content`;
+  }
+
+  formatAttachment(attachment, { withDownloadUrl = false } = {}) {
+    return {
+      id: attachment.id,
+      kind: attachment.kind,
+      entityType: attachment.entityType,
+      entityId: attachment.entityId,
+      userId: attachment.userId,
+      originalName: attachment.originalName,
+      contentType: attachment.contentType,
+      size: attachment.size,
+      status: attachment.status,
+      scanStatus: attachment.scanStatus,
+      scannedAt: attachment.scannedAt,
+      processingStatus: attachment.processingStatus,
+      createdAt: attachment.createdAt,
+      ...(withDownloadUrl && this.isServable(attachment) && {
+        downloadUrl: s3Client.presignUrl("GET", attachment.storageKey),
+        thumbnails: Object.fromEntries(
+          Object.entries(attachment.variants || {}).map(([name, variant]) => [
+            name,
+            {
+              url: s3Client.presignUrl("GET", variant.key),
+              width: variant.width,
+              height: variant.height,
+            },
+          ])
+        ),
+      }),
+    };
+  }
+}
+
+export default new AttachmentService();
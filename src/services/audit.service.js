@@ -0,0 +1,127 @@
+import auditLogRepository from "../repository/auditLog.repository.js";
+import logger from "../config/logger.js";
+import { ValidationError } from "../utils/customErrors.js";
+import { getContext } from "../utils/requestContext.js";
+
+/**
+ * Audited actions. Adding one here is all it takes to start recording it.
+ */
+export const AUDIT_ACTIONS = {
+  LOGIN: "auth.login",
+  LOGIN_FAILED: "auth.login_failed",
+  OAUTH_PROVIDER_LINKED: "auth.oauth_provider_linked",
+  TWO_FACTOR_ENABLED: "auth.two_factor_enabled",
+  TWO_FACTOR_DISABLED: "auth.two_factor_disabled",
+  PASSWORD_RESET: "auth.password_reset",
+  PASSWORD_CHANGED: "auth.password_changed",
+  SESSION_REVOKED: "auth.session_revoked",
+  ACCOUNT_DELETION_REQUESTED: "account.deletion_requested",
+  ACCOUNT_DATA_EXPORTED: "account.data_exported",
+  TRIP_DELETED: "trip.deleted",
+  TRIP_MEMBER_JOINED: "trip.member_joined",
+  ROLE_CHANGED: "admin.role_changed",
+  USER_SUSPENDED: "admin.user_suspended",
+  USER_REINSTATED: "admin.user_reinstated",
+  USER_ID_VERIFIED: "admin.user_id_verified",
+  USER_ID_VERIFICATION_REVOKED: "admin.user_id_verification_revoked",
+  TRIP_FORCE_DELETED: "admin.trip_deleted",
+  RECORD_RESTORED: "admin.record_restored",
+  REPORT_RESOLVED: "admin.report_resolved",
+  AFFILIATE_PARTNER_CREATED: "admin.affiliate_partner_created",
+  AFFILIATE_PARTNER_UPDATED: "admin.affiliate_partner_updated",
+  AGGREGATOR_CREATED: "admin.aggregator_created",
+  AGGREGATOR_UPDATED: "admin.aggregator_updated",
+  AGGREGATOR_KEY_ROTATED: "admin.aggregator_key_rotated",
+  JOB_RETRIED: "admin.job_retried",
+  JOB_DISCARDED: "admin.job_discarded",
+  JOB_QUEUE_PAUSED: "admin.job_queue_paused",
+  JOB_QUEUE_RESUMED: "admin.job_queue_resumed",
+  BOOKING_TIMELINE_VIEWED: "admin.booking_timeline_viewed",
+  PUSH_TEMPLATE_UPDATED: "admin.push_template_updated",
+  PUSH_TEMPLATE_RESET: "admin.push_template_reset",
+  FORM_DRAFT_SAVED: "admin.form_draft_saved",
+  FORM_PUBLISHED: "admin.form_published",
+  MODERATION_USER_CONTENT_HIDDEN: "admin.moderation_user_content_hidden",
+  MODERATION_REPORTS_DISMISSED: "admin.moderation_reports_dismissed",
+  MODERATION_RULE_CREATED: "admin.moderation_rule_created",
+  MODERATION_RULE_UPDATED: "admin.moderation_rule_updated",
+  MODERATION_RULE_DELETED: "admin.moderation_rule_deleted",
+  // Recorded without an actor: the rule acted on its own
+  MODERATION_RULE_TRIGGERED: "moderation.rule_triggered",
+  TRIP_REVIEW_RING_SUSPECTED: "moderation.review_ring_suspected",
+  FEATURE_FLAG_UPDATED: "admin.feature_flag_updated",
+  FEATURE_FLAG_RESET: "admin.feature_flag_reset",
+  TENANT_CREATED: "admin.tenant_created",
+  TENANT_UPDATED: "admin.tenant_updated",
+  TENANT_DKIM_ROTATED: "admin.tenant_dkim_rotated",
+  TENANT_DOMAIN_ADDED: "admin.tenant_domain_added",
+  TENANT_DOMAIN_VERIFIED: "admin.tenant_domain_verified",
+  TENANT_DOMAIN_REMOVED: "admin.tenant_domain_removed",
+};
+
+const MAX_PAGE_SIZE = 200;
+
+/**
+ * Append-only audit trail of security-relevant and admin actions
+ */
+class AuditService {
+  /**
+   * Records an action, called by the service that performs it. Never throws:
+   * an audit write failure is logged but does not undo or fail the action.
+   * The IP and user agent come from the request being served, if any.
+   * @param {Object} entry - { action, actorId, targetType, targetId, metadata }
+   *   actorId defaults to the authenticated user of the request (null outside one)
+   */
+  async record({ action, actorId, targetType = null, targetId = null, metadata = {} }) {
+    const context = getContext();
+    try {
+      await auditLogRepository.create({
+        actorId: actorId !== undefined ? actorId : context?.userId || null,
+        action,
+        targetType,
+        targetId: targetId ? String(targetId) : null,
+        metadata,
+        ip: context?.ip || null,
+        userAgent: context?.userAgent || null,
+      });
+    } catch (error) {
+      logger.error(`[Audit] Failed to record ${action}: ${error.message}`, { action, targetId });
+    }
+  }
+
+  /**
+   * Queries the audit trail, newest first
+   * @param {Object} filters - { actorId, action, from, to, before, limit }
+   * @returns {Promise<Object>} - { entries, nextCursor }
+   */
+  async query({ actorId, action, from, to, before, limit = 50 } = {}) {
+    const fromDate = from ? new Date(from) : null;
+    const toDate = to ? new Date(to) : null;
+    if ((fromDate && isNaN(fromDate)) || (toDate && isNaN(toDate))) {
+      throw new ValidationError("from y to deben ser fechas ISO 8601");
+    }
+    if (fromDate && toDate && fromDate >= toDate) {
+      throw new ValidationError("from debe ser anterior a to");
+    }
+    const pageSize = Math.min(Math.max(parseInt(limit) || 50, 1), MAX_PAGE_SIZE);
+
+    const entries = await auditLogRepository.find({
+      actorId: actorId || null,
+      action: action || null,
+      from: fromDate,
+      to: toDate,
+      before: before || null,
+      limit: pageSize,
+    });
+    if (entries === null) {
+      throw new ValidationError("Cursor inválido", null, "INVALID_CURSOR");
+    }
+
+    return {
+      entries,
+      nextCursor: entries.length === pageSize ? entries[entries.length - 1].id : null,
+    };
+  }
+}
+
+export default new AuditService();
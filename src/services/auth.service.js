@@ -1,14 +1,22 @@
 import logger from "../config/logger.js";
 import UserRepository from "../repository/user.repository.js";
 import emailService from "./email.service.js";
+import searchService from "./search.service.js";
+import tenantService from "./tenant.service.js";
 import bcrypt from "bcrypt";
 import crypto from "crypto";
 import jwt from "jsonwebtoken";
 import config from "../config/index.js";
 import { AppDataSource } from "../load/typeorm.loader.js";
 import RevokedToken from "../models/revokedToken.model.js";
+import userIdentityRepository from "../repository/userIdentity.repository.js";
+import oauthClient from "../clients/oauth.client.js";
+import twoFactorService from "./twoFactor.service.js";
+import sessionService from "./session.service.js";
+import auditService, { AUDIT_ACTIONS } from "./audit.service.js";
 import { isValidEmail, validatePassword } from "../utils/validators.js";
-import { ValidationError, AuthenticationError, DatabaseError } from "../utils/customErrors.js";
+import { parseDateOfBirth, normalizeCountry, evaluateAge } from "../utils/ageVerification.js";
+import { AppError, ValidationError, AuthenticationError, DatabaseError } from "../utils/customErrors.js";
 
 class AuthService {
   constructor() {
@@ -17,19 +25,21 @@ class AuthService {
   }
   /**
    * Registra un nuevo usuario
-   * @param {Object} userData - { email, password, name (optional), age (optional) }
+   * @param {Object} userData - { email, password, dateOfBirth, name (optional), country (optional),
+   *                              tenantId (optional, del dominio propio verificado),
+   *                              locale (optional, idioma del Accept-Language) }
    * @returns {Promise<Object>} - { user, message }
    */
-  async register({ email, password, name, age }) {
+  async register({ email, password, name, dateOfBirth, country, tenantId, locale }) {
     // 1. Validar formato de email
     if (!isValidEmail(email)) {
-      throw new ValidationError("Formato de correo inválido.");
+      throw new ValidationError("Formato de correo inválido.", null, "INVALID_EMAIL");
     }
 
     // 2. Validar requisitos de contraseña
     const passwordValidation = validatePassword(password);
     if (!passwordValidation.isValid) {
-      throw new ValidationError("La contraseña no cumple con los requisitos.", passwordValidation.errors);
+      throw new ValidationError("La contraseña no cumple con los requisitos.", passwordValidation.errors, "WEAK_PASSWORD");
     }
 
     // 3. Validar nombre si se proporciona
@@ -37,18 +47,29 @@ class AuthService {
       throw new ValidationError("El nombre no puede tener más de 30 caracteres.");
     }
 
-    // 4. Validar edad si se proporciona
-    if (age !== undefined && age !== null) {
-      const ageNum = Number(age);
-      if (isNaN(ageNum) || ageNum < 13 || ageNum > 120) {
-        throw new ValidationError("La edad debe estar entre 13 y 120 años.");
-      }
+    // 4. Fecha de nacimiento obligatoria y reglas de edad del país
+    const dob = parseDateOfBirth(dateOfBirth);
+    if (!dob) {
+      throw new ValidationError("La fecha de nacimiento es obligatoria (formato AAAA-MM-DD).");
+    }
+    const normalizedCountry = country ? normalizeCountry(country) : null;
+    if (country && !normalizedCountry) {
+      throw new ValidationError("País inválido (código ISO de 2 letras).", null, "INVALID_COUNTRY");
+    }
+    const ageCheck = evaluateAge(dob, normalizedCountry);
+    if (ageCheck.blocked) {
+      throw new AppError(
+        `Debes tener al menos ${ageCheck.minimumAge} años para registrarte.`,
+        403,
+        "UNDERAGE"
+      );
     }
 
     // 5. Verificar que el email no exista
-    const existingUser = await this.userRepository.findByEmail(email);
+    // Las cuentas dadas de baja conservan su email hasta que se anonimizan
+    const existingUser = await this.userRepository.findByEmailWithDeleted(email);
     if (existingUser) {
-      throw new ValidationError("El email ya está en uso. Intente iniciar sesión.");
+      throw new ValidationError("El email ya está en uso. Intente iniciar sesión.", null, "EMAIL_IN_USE");
     }
 
     // 6. Hash de la contraseña
@@ -74,10 +95,14 @@ class AuthService {
       userData.name = name.trim();
     }
 
-    // Agregar edad si se proporciona
-    if (age !== undefined && age !== null) {
-      userData.age = Number(age);
-    }
+    // La edad (y la restricción por edad) se calcula a partir de la fecha de nacimiento
+    userData.dateOfBirth = dateOfBirth;
+    userData.country = normalizedCountry;
+    userData.locale = locale || null;
+
+    // Los correos del usuario llevan la marca del tenant (se ignoran tenants desconocidos)
+    const tenant = tenantId ? await tenantService.findActive(tenantId) : null;
+    userData.tenantId = tenant?.id || null;
 
     // 9. Crear usuario
     const user = await this.userRepository.create(userData);
@@ -90,7 +115,8 @@ class AuthService {
     } else {
       emailPromise = emailService.sendConfirmationEmail(
         email,
-        confirmationToken
+        confirmationToken,
+        user.locale
       );
     }
 
@@ -131,11 +157,21 @@ class AuthService {
     };
   }
 
-  async login({ email, password }) {
-    // 1. Verificar que el usuario exista
+  /**
+   * Inicia sesión con email y contraseña
+   * @param {Object} credentials - { email, password }
+   * @param {Object} client - Dispositivo: { deviceName, userAgent, ipAddress }
+   * @returns {Promise<Object>} - Ver issueSession
+   */
+  async login({ email, password }, client = {}) {
+    return await this.auditLogin(() => this.passwordLogin({ email, password }, client), { email });
+  }
+
+  async passwordLogin({ email, password }, client) {
+    // 1. Verificar que el usuario exista (las cuentas dadas de baja no pueden ingresar)
     const user = await this.userRepository.findByEmail(email);
     if (!user) {
-      throw new AuthenticationError("Credenciales inválidas.");
+      throw new AuthenticationError("Credenciales inválidas.", "INVALID_CREDENTIALS");
     }
 
     // 2. Verificar que el email esté confirmado
@@ -146,14 +182,243 @@ class AuthService {
     // 3. Verificar la contraseña
     const isPasswordValid = await bcrypt.compare(password, user.password);
     if (!isPasswordValid) {
-      throw new AuthenticationError("Credenciales inválidas.");
+      throw new AuthenticationError("Credenciales inválidas.", "INVALID_CREDENTIALS");
+    }
+
+    // 4. Verificar que la cuenta no esté suspendida
+    if (user.suspendedAt) {
+      throw new AppError("Tu cuenta está suspendida.", 403, "ACCOUNT_SUSPENDED");
+    }
+
+    // 5. Generar tokens JWT, o pedir el segundo paso si tiene 2FA
+    return await this.issueSession(user, { client });
+  }
+
+  /**
+   * Segundo paso del login de cuentas con verificación en dos pasos
+   * @param {string} challengeToken - Devuelto por el primer paso
+   * @param {string} code - Código de la app o de respaldo
+   * @param {Object} client - Dispositivo: { deviceName, userAgent, ipAddress }
+   * @returns {Promise<Object>} - { user, accessToken, refreshToken, method }
+   */
+  async completeTwoFactorLogin(challengeToken, code, client = {}) {
+    return await this.auditLogin(
+      async () => {
+        if (!challengeToken || !code) {
+          throw new ValidationError("challengeToken y code son requeridos.");
+        }
+        const { user, method } = await twoFactorService.completeChallenge(challengeToken, code);
+        return { ...(await this.issueSession(user, { mfa: true, client })), method };
+      },
+      { step: "two_factor" },
+      (result) => ({ twoFactor: result.method })
+    );
+  }
+
+  /**
+   * Registra en la auditoría un intento de login: los fallidos siempre y los
+   * exitosos cuando no queda pendiente el segundo paso (que se registra aparte)
+   * @param {Function} attempt - async () => resultado de issueSession
+   * @param {Object} metadata - Datos del intento
+   * @param {Function} successMetadata - (resultado) => datos del login exitoso
+   * @returns {Promise<Object>} - El resultado del intento
+   */
+  async auditLogin(attempt, metadata, successMetadata = () => ({})) {
+    let result;
+    try {
+      result = await attempt();
+    } catch (err) {
+      await auditService.record({
+        action: AUDIT_ACTIONS.LOGIN_FAILED,
+        actorId: null,
+        metadata: { ...metadata, reason: err.message },
+      });
+      throw err;
+    }
+    if (!result.twoFactorRequired) {
+      await auditService.record({
+        action: AUDIT_ACTIONS.LOGIN,
+        actorId: result.user.id,
+        targetType: "user",
+        targetId: result.user.id,
+        metadata: successMetadata(result),
+      });
+    }
+    return result;
+  }
+
+  /**
+   * Abre una sesión (un dispositivo) para un usuario ya identificado. Con 2FA
+   * activa y sin haber pasado el segundo paso, devuelve el desafío en su lugar
+   * @param {Object} user - Usuario
+   * @param {Object} options - { mfa: si ya pasó el segundo paso, client: { deviceName, userAgent, ipAddress } }
+   * @returns {Promise<Object>} - { user, accessToken, refreshToken, twoFactorSetupRequired }
+   *                              o { user, twoFactorRequired, challengeToken }
+   */
+  async issueSession(user, { mfa = false, client = {} } = {}) {
+    if (user.twoFactorEnabledAt && !mfa) {
+      return { user, twoFactorRequired: true, challengeToken: twoFactorService.createChallenge(user) };
     }
+    const sessionId = crypto.randomUUID();
+    const refreshToken = this.generateRefreshToken(user, { mfa, sessionId });
+    await sessionService.open({ sessionId, userId: user.id, refreshToken, client });
+    return {
+      user,
+      accessToken: this.generateAccessToken(user, { mfa, sessionId }),
+      refreshToken,
+      twoFactorSetupRequired: twoFactorService.isRequiredFor(user) && !user.twoFactorEnabledAt,
+    };
+  }
 
-    // 4. Generar tokens JWT
-    const accessToken = this.generateAccessToken(user);
-    const refreshToken = this.generateRefreshToken(user);
+  /**
+   * Inicia sesión con una cuenta de Google o Apple. La primera vez la vincula
+   * a la cuenta con el mismo email verificado, o crea una nueva (ya
+   * confirmada, con una contraseña aleatoria que nadie conoce). Una cuenta
+   * sin confirmar también pierde su contraseña al vincularse
+   * @param {string} provider - google | apple
+   * @param {Object} data - { idToken, accessToken (solo google), nonce, name, dateOfBirth, country }
+   * @param {Object} context - { tenantId: del dominio propio verificado, client: dispositivo como en login }
+   * @returns {Promise<Object>} - { user, accessToken, refreshToken, isNewUser, linked }
+   *                              (o el desafío de 2FA en lugar de los tokens)
+   */
+  async loginWithProvider(provider, data, options = {}) {
+    return await this.auditLogin(() => this.providerLogin(provider, data, options), { provider }, () => ({ provider }));
+  }
 
-    return { user, accessToken, refreshToken };
+  async providerLogin(provider, { idToken, accessToken, nonce, name, dateOfBirth, country }, { tenantId, client = {} } = {}) {
+    if (!oauthClient.isEnabled(provider)) {
+      throw new AppError("Proveedor de inicio de sesión no disponible.", 404, "OAUTH_PROVIDER_UNAVAILABLE");
+    }
+    if (!idToken && !(provider === "google" && accessToken)) {
+      throw new ValidationError("idToken es requerido.");
+    }
+
+    // 1. Verificar el token con el proveedor
+    let profile;
+    try {
+      profile = idToken
+        ? await oauthClient.verifyIdToken(provider, idToken, nonce)
+        : await oauthClient.verifyGoogleAccessToken(accessToken);
+    } catch (error) {
+      logger.warn(`OAuth ${provider} token rejected: ${error.message}`);
+      throw new AuthenticationError("Token del proveedor inválido.");
+    }
+
+    // 2. Cuenta ya vinculada, vinculable por email verificado, o nueva
+    let isNewUser = false;
+    let linked = false;
+    let user;
+    const identity = await userIdentityRepository.findByProviderSubject(provider, profile.subject);
+    if (identity) {
+      user = await this.userRepository.findByIdWithDeleted(identity.userId);
+      await userIdentityRepository.touch(identity.id);
+    } else {
+      if (!profile.email || !profile.emailVerified) {
+        throw new AppError(
+          "El proveedor no confirmó tu email. Inicia sesión con tu contraseña.",
+          403,
+          "OAUTH_EMAIL_NOT_VERIFIED"
+        );
+      }
+      user = await this.userRepository.findByEmailWithDeleted(profile.email);
+      if (!user) {
+        user = await this.createProviderUser(profile, { name, dateOfBirth, country, tenantId });
+        isNewUser = true;
+      } else if (!user.isEmailConfirmed) {
+        // El proveedor ya comprobó que el email es suyo. Quien registró la
+        // cuenta sin confirmarla pudo no ser el dueño del email: su contraseña
+        // se reemplaza por una aleatoria y sus sesiones se cierran
+        await this.userRepository.update(user.id, {
+          isEmailConfirmed: true,
+          emailConfirmationToken: null,
+          emailConfirmationExpires: null,
+          password: await bcrypt.hash(crypto.randomBytes(32).toString("hex"), 10),
+          passwordResetToken: null,
+          passwordResetExpires: null,
+        });
+        await sessionService.revokeAll(user.id);
+        await auditService.record({
+          action: AUDIT_ACTIONS.PASSWORD_CHANGED,
+          actorId: user.id,
+          targetType: "user",
+          targetId: user.id,
+          metadata: { reason: "unconfirmed_account_linked", provider },
+        });
+        await this.onEmailConfirmed(user.id);
+        user = await this.userRepository.findByIdWithDeleted(user.id);
+      }
+      if (!user.deletedAt) {
+        await userIdentityRepository.create({
+          userId: user.id,
+          provider,
+          subject: profile.subject,
+          email: profile.email,
+          lastLoginAt: new Date(),
+        });
+        linked = !isNewUser;
+        if (linked) {
+          await auditService.record({
+            action: AUDIT_ACTIONS.OAUTH_PROVIDER_LINKED,
+            actorId: user.id,
+            targetType: "user",
+            targetId: user.id,
+            metadata: { provider },
+          });
+        }
+      }
+    }
+
+    // 3. Mismas reglas que el login con contraseña
+    if (!user || user.deletedAt) {
+      throw new AuthenticationError("Credenciales inválidas.", "INVALID_CREDENTIALS");
+    }
+    if (user.suspendedAt) {
+      throw new AppError("Tu cuenta está suspendida.", 403, "ACCOUNT_SUSPENDED");
+    }
+
+    return { ...(await this.issueSession(user, { client })), isNewUser, linked };
+  }
+
+  /**
+   * Crea la cuenta de quien entra por primera vez con un proveedor. Aplica
+   * las mismas reglas de edad que el registro, así que pide la fecha de nacimiento
+   * @returns {Promise<Object>} Usuario creado
+   */
+  async createProviderUser(profile, { name, dateOfBirth, country, tenantId }) {
+    const dob = parseDateOfBirth(dateOfBirth);
+    if (!dob) {
+      throw new AppError(
+        "Para crear tu cuenta necesitamos tu fecha de nacimiento (formato AAAA-MM-DD).",
+        400,
+        "DATE_OF_BIRTH_REQUIRED"
+      );
+    }
+    const normalizedCountry = country ? normalizeCountry(country) : null;
+    if (country && !normalizedCountry) {
+      throw new ValidationError("País inválido (código ISO de 2 letras).", null, "INVALID_COUNTRY");
+    }
+    const ageCheck = evaluateAge(dob, normalizedCountry);
+    if (ageCheck.blocked) {
+      throw new AppError(
+        `Debes tener al menos ${ageCheck.minimumAge} años para registrarte.`,
+        403,
+        "UNDERAGE"
+      );
+    }
+
+    const displayName = (name || profile.name || "").trim().slice(0, 30);
+    const tenant = tenantId ? await tenantService.findActive(tenantId) : null;
+    const user = await this.userRepository.create({
+      email: profile.email,
+      password: await bcrypt.hash(crypto.randomBytes(32).toString("hex"), 10),
+      isEmailConfirmed: true,
+      ...(displayName && { name: displayName }),
+      dateOfBirth,
+      country: normalizedCountry,
+      tenantId: tenant?.id || null,
+    });
+    await this.onEmailConfirmed(user.id);
+    return user;
   }
 
   /**
@@ -180,15 +445,7 @@ class AuthService {
       emailConfirmationExpires: null,
     });
 
-    // Award profile_completed action to enable level progression
-    try {
-      const gamificationService = (await import('./gamification.service.js')).default;
-      await gamificationService.awardPoints(user.id, 'profile_completed', {}, false);
-      logger.info(`Profile completed action awarded to user ${user.id} after email confirmation`);
-    } catch (gamificationError) {
-      console.error(`Failed to award profile_completed action for user ${user.id}:`, gamificationError);
-      // Don't fail email confirmation if gamification fails
-    }
+    await this.onEmailConfirmed(user.id);
 
     return { message: "Email confirmado exitosamente." };
   }
@@ -197,38 +454,61 @@ class AuthService {
     return this.userRepository.findAtus();
   }
 
+  /**
+   * Efectos de confirmar el email, por enlace o por un proveedor de login
+   * @param {string} userId
+   */
+  async onEmailConfirmed(userId) {
+    // Confirmed users become searchable
+    await searchService.scheduleIndex("user", userId);
+
+    // Award profile_completed action to enable level progression
+    try {
+      const gamificationService = (await import('./gamification.service.js')).default;
+      await gamificationService.awardPoints(userId, 'profile_completed', {}, false);
+      logger.info(`Profile completed action awarded to user ${userId} after email confirmation`);
+    } catch (gamificationError) {
+      console.error(`Failed to award profile_completed action for user ${userId}:`, gamificationError);
+      // Don't fail email confirmation if gamification fails
+    }
+  }
+
   /**
    * Genera un access token JWT
    * @param {Object} user - Usuario
+   * @param {Object} options - { mfa: la sesión pasó la verificación en dos pasos, sessionId }
    * @returns {string} - Access token
    */
-  generateAccessToken(user) {
+  generateAccessToken(user, { mfa = false, sessionId } = {}) {
     return jwt.sign(
-      { id: user.id, email: user.email, role: user.role || 'user' },
+      { id: user.id, email: user.email, role: user.role || 'user', mfa, sid: sessionId },
       config.jwt.secret,
       { expiresIn: config.jwt.expiresIn }
     );
   }
 
   /**
-   * Genera un refresh token JWT
+   * Genera un refresh token JWT de un solo uso (jti) para una sesión
    * @param {Object} user - Usuario
+   * @param {Object} options - { mfa, sessionId }, se conservan al refrescar
    * @returns {string} - Refresh token
    */
-  generateRefreshToken(user) {
+  generateRefreshToken(user, { mfa = false, sessionId } = {}) {
     return jwt.sign(
-      { id: user.id },
+      { id: user.id, mfa, sid: sessionId, jti: crypto.randomUUID() },
       config.jwt.refreshSecret,
       { expiresIn: config.jwt.refreshExpiresIn }
     );
   }
 
   /**
-   * Refresca un access token usando un refresh token válido
+   * Refresca un access token usando un refresh token válido. El refresh token
+   * se reemplaza por uno nuevo; volver a usar el anterior cierra la sesión
    * @param {string} refreshToken - Refresh token
+   * @param {Object} client - Dispositivo: { ipAddress }
    * @returns {Promise<Object>} - { accessToken, refreshToken }
    */
-  async refreshToken(refreshToken) {
+  async refreshToken(refreshToken, client = {}) {
     try {
       const decoded = jwt.verify(refreshToken, config.jwt.refreshSecret);
       const user = await this.userRepository.findById(decoded.id);
@@ -236,10 +516,24 @@ class AuthService {
       if (!user) {
         throw new AuthenticationError("Usuario no encontrado.");
       }
+      if (user.suspendedAt) {
+        throw new AuthenticationError("Tu cuenta está suspendida.");
+      }
 
-      // Generar nuevos tokens
-      const newAccessToken = this.generateAccessToken(user);
-      const newRefreshToken = this.generateRefreshToken(user);
+      // Los tokens emitidos antes de existir las sesiones no se pueden revocar:
+      // el cliente tiene que volver a iniciar sesión
+      if (!decoded.sid) {
+        throw new AuthenticationError("La sesión expiró.");
+      }
+
+      // Generar nuevos tokens para la misma sesión
+      const mfa = decoded.mfa === true;
+      const sessionId = decoded.sid;
+      const newRefreshToken = this.generateRefreshToken(user, { mfa, sessionId });
+      if (!(await sessionService.rotate(decoded, newRefreshToken, client))) {
+        throw new AuthenticationError("La sesión fue cerrada.");
+      }
+      const newAccessToken = this.generateAccessToken(user, { mfa, sessionId });
 
       return { accessToken: newAccessToken, refreshToken: newRefreshToken };
     } catch (error) {
@@ -248,7 +542,7 @@ class AuthService {
   }
 
   /**
-   * Revoca un token (lo agrega a la blacklist)
+   * Revoca un token (lo agrega a la blacklist) y cierra su sesión
    * @param {string} token - Token a revocar
    * @returns {Promise<void>}
    */
@@ -267,6 +561,11 @@ class AuthService {
         token,
         expiresAt,
       });
+
+      // El refresh token de la misma sesión deja de servir también
+      if (decoded.sid) {
+        await sessionService.end(decoded.id, decoded.sid);
+      }
     } catch (error) {
       console.error("Error revoking token:", error);
     }
@@ -291,7 +590,7 @@ class AuthService {
   async forgotPassword(email) {
     // 1. Validar formato de email
     if (!isValidEmail(email)) {
-      throw new ValidationError("Formato de correo inválido.");
+      throw new ValidationError("Formato de correo inválido.", null, "INVALID_EMAIL");
     }
 
     // 2. Buscar usuario por email
@@ -299,7 +598,7 @@ class AuthService {
     
     // Criterio de aceptación 2: Si no existe el correo, mostrar mensaje específico
     if (!user) {
-      throw new ValidationError("No existe una cuenta con este correo.");
+      throw new ValidationError("No existe una cuenta con este correo.", null, "ACCOUNT_NOT_FOUND");
     }
 
     // 3. Generar token de reseteo de contraseña
@@ -316,7 +615,7 @@ class AuthService {
 
     // 5. Enviar correo de recuperación
     try {
-      await this.emailService.sendPasswordResetEmail(email, resetToken);
+      await this.emailService.sendPasswordResetEmail(email, resetToken, user.locale);
     } catch (error) {
       console.error("Error al enviar correo de recuperación:", error);
       throw new ValidationError("Error al enviar el correo de recuperación. Por favor intenta de nuevo.");
@@ -336,7 +635,7 @@ class AuthService {
   async resetPassword(token, newPassword) {
     // 1. Validar que se proporcione el token y la nueva contraseña
     if (!token || !newPassword) {
-      throw new ValidationError("Token y contraseña son requeridos.");
+      throw new ValidationError("Token y contraseña son requeridos.", null, "RESET_FIELDS_REQUIRED");
     }
 
     // 2. Buscar usuario por token de reseteo
@@ -355,7 +654,7 @@ class AuthService {
     // 4. Validar requisitos de la nueva contraseña
     const passwordValidation = validatePassword(newPassword);
     if (!passwordValidation.isValid) {
-      throw new ValidationError("La contraseña no cumple con los requisitos.", passwordValidation.errors);
+      throw new ValidationError("La contraseña no cumple con los requisitos.", passwordValidation.errors, "WEAK_PASSWORD");
     }
 
     // 5. Hash de la nueva contraseña
@@ -368,10 +667,65 @@ class AuthService {
       passwordResetExpires: null,
     });
 
+    // 7. Cerrar las sesiones abiertas: quien tenía la contraseña anterior queda afuera
+    await sessionService.revokeAll(user.id);
+    await auditService.record({
+      action: AUDIT_ACTIONS.PASSWORD_RESET,
+      actorId: user.id,
+      targetType: "user",
+      targetId: user.id,
+    });
+
     return {
       message: "Contraseña restablecida exitosamente. Ahora puedes iniciar sesión con tu nueva contraseña.",
+      userId: user.id,
     };
   }
+
+  /**
+   * Cambia la contraseña de un usuario con sesión iniciada. Las demás
+   * sesiones se cierran; la actual sigue abierta
+   * @param {string} userId - ID del usuario
+   * @param {Object} passwords - { currentPassword, newPassword }
+   * @param {string|null} sessionId - Sesión de la solicitud
+   * @returns {Promise<Object>} - { message }
+   */
+  async changePassword(userId, { currentPassword, newPassword }, sessionId = null) {
+    if (!currentPassword || !newPassword) {
+      throw new ValidationError(
+        "La contraseña actual y la nueva son requeridas.",
+        null,
+        "PASSWORD_CHANGE_FIELDS_REQUIRED"
+      );
+    }
+
+    const user = await this.userRepository.findById(userId);
+    if (!user) {
+      throw new AuthenticationError("Usuario no encontrado.");
+    }
+    if (!(await bcrypt.compare(String(currentPassword), user.password))) {
+      throw new ValidationError("La contraseña actual es incorrecta.", null, "INVALID_CURRENT_PASSWORD");
+    }
+
+    const passwordValidation = validatePassword(newPassword);
+    if (!passwordValidation.isValid) {
+      throw new ValidationError("La contraseña no cumple con los requisitos.", passwordValidation.errors, "WEAK_PASSWORD");
+    }
+
+    await this.userRepository.update(user.id, {
+      password: await bcrypt.hash(newPassword, 10),
+      passwordResetToken: null,
+      passwordResetExpires: null,
+    });
+    await sessionService.revokeAll(user.id, sessionId);
+    await auditService.record({
+      action: AUDIT_ACTIONS.PASSWORD_CHANGED,
+      targetType: "user",
+      targetId: user.id,
+    });
+
+    return { message: "Contraseña actualizada. Se cerraron las sesiones de tus otros dispositivos." };
+  }
 }
 
 export default new AuthService();
@@ -0,0 +1,150 @@
+import crypto from "crypto";
+import tripAvailabilityRepository from "../repository/tripAvailability.repository.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { ValidationError } from "../utils/customErrors.js";
+
+const DEFAULT_PAGE_SIZE = 200;
+const MAX_PAGE_SIZE = 1000;
+// Lowest uuid: a `since` timestamp starts before every trip changed at that instant
+const NIL_UUID = "00000000-0000-0000-0000-000000000000";
+const UUID_REGEX = /^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$/i;
+
+/**
+ * Machine-readable trip availability for aggregators. Reads a snapshot
+ * (trip_availability) refreshed at most every refreshSeconds, so a busy
+ * aggregator never costs more than one pass over the trips per interval.
+ *
+ * Sync protocol:
+ * - Full sync: no cursor; returns open and full trips in change order.
+ * - Delta sync: `cursor` from the previous response (or `since`, an ISO
+ *   timestamp); returns every change after it, closed trips included.
+ * - Every response carries the cursor to resume from and an ETag/Last-Modified
+ *   for conditional requests (304 when nothing changed).
+ */
+class AvailabilityFeedService {
+  constructor() {
+    this.lastRefreshAt = 0;
+    this.refreshing = null;
+  }
+
+  /**
+   * Resolves a feed request: parses the query, refreshes the snapshot when due
+   * and computes the validators of the response
+   * @param {Object} query - { cursor, since, limit }
+   * @returns {Promise<Object>} - { filter, limit, etag, lastModified }
+   */
+  async prepare({ cursor, since, limit } = {}) {
+    const after = cursor ? this.decodeCursor(cursor) : since ? this.parseSince(since) : null;
+    const pageSize = Math.min(Math.max(parseInt(limit, 10) || DEFAULT_PAGE_SIZE, 1), MAX_PAGE_SIZE);
+    const filter = { after, includeClosed: Boolean(after) };
+
+    await this.refreshIfDue();
+    const { total, lastChangedAt } = await tripAvailabilityRepository.getState(filter);
+    const etag = crypto
+      .createHash("sha1")
+      .update(JSON.stringify([after, pageSize, total, lastChangedAt]))
+      .digest("hex");
+
+    return {
+      filter,
+      limit: pageSize,
+      etag: `"${etag}"`,
+      lastModified: lastChangedAt ? new Date(lastChangedAt) : null,
+    };
+  }
+
+  /**
+   * Reads a page of the feed
+   * @param {Object} prepared - Result of prepare()
+   * @returns {Promise<Object>} - { trips, cursor, hasMore }
+   */
+  async getPage({ filter, limit }) {
+    const rows = await tripAvailabilityRepository.findPage(filter, limit + 1);
+    const hasMore = rows.length > limit;
+    const page = rows.slice(0, limit);
+    const last = page[page.length - 1];
+
+    return {
+      trips: page.map((row) => this.formatTrip(row)),
+      // Resume from here on the next call, even when there is nothing left now
+      cursor: last
+        ? this.encodeCursor({ changedAt: new Date(last.changedAt).toISOString(), groupId: last.groupId })
+        : filter.after
+          ? this.encodeCursor(filter.after)
+          : null,
+      hasMore,
+    };
+  }
+
+  async refreshIfDue() {
+    if (Date.now() - this.lastRefreshAt < config.availabilityFeed.refreshSeconds * 1000) {
+      return;
+    }
+    if (!this.refreshing) {
+      this.refreshing = tripAvailabilityRepository
+        .refresh()
+        .then(() => {
+          this.lastRefreshAt = Date.now();
+        })
+        .catch((error) => {
+          // Serve the last snapshot rather than failing the feed
+          logger.error(`[AvailabilityFeed] Refresh failed: ${error.message}`);
+        })
+        .finally(() => {
+          this.refreshing = null;
+        });
+    }
+    await this.refreshing;
+  }
+
+  parseSince(since) {
+    const date = new Date(since);
+    if (Number.isNaN(date.getTime())) {
+      throw new ValidationError("since debe ser una fecha ISO 8601");
+    }
+    return { changedAt: date.toISOString(), groupId: NIL_UUID };
+  }
+
+  encodeCursor({ changedAt, groupId }) {
+    return Buffer.from(JSON.stringify([changedAt, groupId])).toString("base64url");
+  }
+
+  decodeCursor(cursor) {
+    try {
+      const [changedAt, groupId] = JSON.parse(Buffer.from(String(cursor), "base64url").toString("utf8"));
+      if (Number.isNaN(new Date(changedAt).getTime()) || !UUID_REGEX.test(groupId)) {
+        throw new Error("invalid");
+      }
+      return { changedAt, groupId };
+    } catch {
+      throw new ValidationError("cursor inválido");
+    }
+  }
+
+  formatTrip(row) {
+    const changedAt = new Date(row.changedAt).toISOString();
+    if (row.status === "closed") {
+      return { tripId: row.groupId, status: "closed", changedAt };
+    }
+    return {
+      tripId: row.groupId,
+      status: row.status,
+      name: row.name,
+      destination: {
+        name: row.destinationName,
+        lat: row.destinationLat !== null ? Number(row.destinationLat) : null,
+        lng: row.destinationLng !== null ? Number(row.destinationLng) : null,
+      },
+      startDate: row.startDate,
+      endDate: row.endDate,
+      capacity: row.capacity,
+      spotsLeft: row.spotsLeft,
+      price: row.priceAmount !== null ? { amount: row.priceAmount, currency: row.priceCurrency } : null,
+      url: `${config.frontendUrl}/groups/${row.groupId}`,
+      changedAt,
+    };
+  }
+}
+
+export default new AvailabilityFeedService();
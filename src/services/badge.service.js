@@ -0,0 +1,143 @@
+import redisClient from "../clients/redis.client.js";
+import notificationService from "./notification.service.js";
+import directMessageRepository from "../repository/directMessage.repository.js";
+import groupMessageReadRepository from "../repository/groupMessageRead.repository.js";
+import participantRepository from "../repository/participant.repository.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { AppDataSource } from "../load/typeorm.loader.js";
+
+export const BADGE_FIELDS = ["messages", "notifications", "joinRequests", "unpaidBalances"];
+
+// Only bumps counters that are already cached: a missing hash is rebuilt from
+// the database on the next read, so incrementing it would store a partial count
+const INCREMENT_IF_CACHED = `
+if redis.call("EXISTS", KEYS[1]) == 1 then
+  return redis.call("HINCRBY", KEYS[1], ARGV[1], ARGV[2])
+end
+return nil`;
+
+const keyFor = (userId) => `badges:${userId}`;
+
+/**
+ * Unread badge counters polled by clients every minute. Counters live in a
+ * Redis hash per user, built from the database on the first read and then
+ * kept up to date by the writes that change them: cheap events (a new
+ * message or notification) increment the hash, changes whose delta is not
+ * known cheaply drop it so the next read rebuilds it. Without Redis the
+ * counters are computed from the database on every request.
+ */
+class BadgeService {
+  /**
+   * Current badge counters of a user
+   * @param {string} userId
+   * @returns {Promise<Object>} - { messages, notifications, joinRequests, unpaidBalances }
+   */
+  async getBadges(userId) {
+    if (!redisClient.isConfigured()) {
+      return await this.computeBadges(userId);
+    }
+
+    try {
+      const cached = await redisClient.hgetall(keyFor(userId));
+      if (BADGE_FIELDS.every((field) => cached[field] !== undefined)) {
+        return Object.fromEntries(
+          BADGE_FIELDS.map((field) => [field, Math.max(0, parseInt(cached[field], 10) || 0)])
+        );
+      }
+    } catch (error) {
+      logger.warn(`[Badges] Cache read failed for ${userId}: ${error.message}`);
+      return await this.computeBadges(userId);
+    }
+
+    const badges = await this.computeBadges(userId);
+    this.store(userId, badges);
+    return badges;
+  }
+
+  /**
+   * Counts the badges from the database
+   */
+  async computeBadges(userId) {
+    const [notifications, directMessages, groups, joinRequests, unpaidExpenses] =
+      await Promise.all([
+        notificationService.getUnreadCount(userId),
+        directMessageRepository.countUnreadByUserId(userId),
+        groupMessageReadRepository.findReadStateByUser(userId),
+        this.countPendingJoinRequests(userId),
+        participantRepository.findUnpaidExpenses(userId),
+      ]);
+
+    return {
+      messages: directMessages + groups.reduce((total, group) => total + group.unreadCount, 0),
+      notifications,
+      joinRequests,
+      unpaidBalances: unpaidExpenses.reduce((total, group) => total + group.count, 0),
+    };
+  }
+
+  // Requests waiting on the groups the user organizes
+  async countPendingJoinRequests(userId) {
+    const [row] = await AppDataSource.query(
+      `SELECT COUNT(*)::int AS count
+       FROM group_join_requests r
+       JOIN groups g ON g.id = r."groupId"
+       WHERE g."adminId" = $1 AND g."deletedAt" IS NULL AND r.status = 'pending'`,
+      [userId]
+    );
+    return row.count;
+  }
+
+  async store(userId, badges) {
+    try {
+      await redisClient.command(
+        "HSET",
+        keyFor(userId),
+        ...BADGE_FIELDS.flatMap((field) => [field, badges[field]])
+      );
+      await redisClient.command("EXPIRE", keyFor(userId), config.badges.cacheTtlSeconds);
+    } catch (error) {
+      logger.warn(`[Badges] Cache write failed for ${userId}: ${error.message}`);
+    }
+  }
+
+  /**
+   * Adjusts a cached counter. Fire-and-forget: never throws.
+   * @param {string|string[]} userIds
+   * @param {string} field - One of BADGE_FIELDS
+   * @param {number} by - Delta (negative to decrement)
+   */
+  async increment(userIds, field, by = 1) {
+    if (!redisClient.isConfigured() || by === 0) {
+      return;
+    }
+    const ids = [].concat(userIds).filter(Boolean);
+    try {
+      await Promise.all(
+        ids.map((userId) => redisClient.eval(INCREMENT_IF_CACHED, [keyFor(userId)], [field, by]))
+      );
+    } catch (error) {
+      logger.warn(`[Badges] Increment of ${field} failed: ${error.message}`);
+      this.invalidate(ids);
+    }
+  }
+
+  /**
+   * Drops the cached counters so the next read rebuilds them. Never throws.
+   * @param {string|string[]} userIds
+   */
+  async invalidate(userIds) {
+    if (!redisClient.isConfigured()) {
+      return;
+    }
+    const ids = [].concat(userIds).filter(Boolean);
+    try {
+      await redisClient.del(...ids.map(keyFor));
+    } catch (error) {
+      // The TTL bounds how long a stale counter can be served
+      logger.warn(`[Badges] Invalidation failed: ${error.message}`);
+    }
+  }
+}
+
+export default new BadgeService();
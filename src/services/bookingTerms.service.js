@@ -0,0 +1,145 @@
+import crypto from "crypto";
+import bookingTermsRepository from "../repository/bookingTerms.repository.js";
+import groupRepository from "../repository/group.repository.js";
+import logger from "../config/logger.js";
+import { AuthorizationError, NotFoundError } from "../utils/customErrors.js";
+
+// Keys sorted at every level: jsonb does not keep the order they were written in
+const canonicalize = (value) => {
+  if (Array.isArray(value)) {
+    return `[${value.map(canonicalize).join(",")}]`;
+  }
+  if (value && typeof value === "object") {
+    return `{${Object.keys(value)
+      .sort()
+      .map((key) => `${JSON.stringify(key)}:${canonicalize(value[key])}`)
+      .join(",")}}`;
+  }
+  return JSON.stringify(value ?? null);
+};
+
+const hashTerms = (terms) => crypto.createHash("sha256").update(canonicalize(terms)).digest("hex");
+
+/**
+ * Terms a member agreed to when booking a trip. They are captured when the
+ * join request is made, before any deposit is paid against them, or when the
+ * organizer adds the member directly, and kept as they were whatever the
+ * organizer edits afterwards.
+ */
+class BookingTermsService {
+  /**
+   * Captures the current terms of a trip for a booking. Call it inside the
+   * transaction that creates the join request or adds the member.
+   * @param {Object} group - With its assigned itinerary, as groupRepository.findById returns it
+   * @param {string} userId
+   * @param {string|null} joinRequestId - The booking; null for members added by the organizer
+   * @returns {Promise<Object>} The stored terms
+   */
+  async capture(group, userId, joinRequestId = null) {
+    const itinerary = group.assignedItinerary;
+
+    const terms = {
+      trip: {
+        name: group.name,
+        description: group.description,
+        destinationName: group.destinationName,
+        tripStartDate: group.tripStartDate,
+        tripEndDate: group.tripEndDate,
+      },
+      // What paying the deposit means for this booking; payments are recorded on their own
+      price: {
+        depositAmount: group.depositAmount,
+        depositCurrency: group.depositCurrency,
+      },
+      cancellationPolicy: group.cancellationPolicy ?? null,
+      rules: group.rules ? { text: group.rules, version: group.rulesVersion } : null,
+      itinerary: itinerary
+        ? {
+            name: itinerary.name,
+            items: [...(itinerary.items || [])]
+              .sort((a, b) => String(a.date).localeCompare(String(b.date)) || a.order - b.order)
+              .map((item) => ({
+                date: item.date,
+                time: item.time,
+                place: item.place?.name ?? null,
+                notes: item.notes,
+                cost: item.cost,
+              })),
+          }
+        : null,
+    };
+
+    const stored = await bookingTermsRepository.create({
+      joinRequestId,
+      groupId: group.id,
+      userId,
+      terms,
+      contentHash: hashTerms(terms),
+    });
+    logger.info(
+      `[BookingTerms] Terms captured for ${joinRequestId ? `booking ${joinRequestId}` : `member ${userId}`} of group ${group.id}`
+    );
+    return stored;
+  }
+
+  /**
+   * Terms the caller agreed to on a trip, latest booking first
+   * @param {string} groupId
+   * @param {string} userId
+   * @returns {Promise<Array>}
+   */
+  async getMyTerms(groupId, userId) {
+    const snapshots = await bookingTermsRepository.findByGroupAndUser(groupId, userId);
+    if (snapshots.length === 0) {
+      throw new NotFoundError("No hay condiciones registradas de tu reserva en este viaje");
+    }
+    return snapshots.map((snapshot) => this.formatTerms(snapshot));
+  }
+
+  /**
+   * Terms a member agreed to (organizer only)
+   * @param {string} groupId
+   * @param {string} memberId
+   * @param {string} requesterId
+   * @returns {Promise<Array>}
+   */
+  async getMemberTerms(groupId, memberId, requesterId) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      throw new NotFoundError("Grupo no encontrado", "GROUP_NOT_FOUND");
+    }
+    if (group.adminId !== requesterId) {
+      throw new AuthorizationError("Solo el organizador puede ver las condiciones de los miembros");
+    }
+    const snapshots = await bookingTermsRepository.findByGroupAndUser(groupId, memberId);
+    if (snapshots.length === 0) {
+      throw new NotFoundError("No hay condiciones registradas de este miembro");
+    }
+    return snapshots.map((snapshot) => this.formatTerms(snapshot));
+  }
+
+  /**
+   * Terms of a booking, for the support timeline
+   * @param {string} joinRequestId
+   * @returns {Promise<Object|null>}
+   */
+  async getBookingTerms(joinRequestId) {
+    const snapshot = await bookingTermsRepository.findByJoinRequest(joinRequestId);
+    return snapshot ? this.formatTerms(snapshot) : null;
+  }
+
+  formatTerms(snapshot) {
+    return {
+      bookingId: snapshot.joinRequestId,
+      groupId: snapshot.groupId,
+      userId: snapshot.userId,
+      agreedAt: snapshot.createdAt,
+      terms: snapshot.terms,
+      contentHash: snapshot.contentHash,
+      // False means the row was edited outside the API
+      intact: hashTerms(snapshot.terms) === snapshot.contentHash,
+    };
+  }
+}
+
+export default new BookingTermsService();
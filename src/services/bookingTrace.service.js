@@ -0,0 +1,191 @@
+import bookingEventRepository from "../repository/bookingEvent.repository.js";
+import groupJoinRequestRepository from "../repository/groupJoinRequest.repository.js";
+import paymentRepository from "../repository/payment.repository.js";
+import txManager from "../repository/transactionManager.js";
+import bookingTermsService from "./bookingTerms.service.js";
+import auditService, { AUDIT_ACTIONS } from "./audit.service.js";
+import logger from "../config/logger.js";
+import { getRequestId } from "../utils/requestContext.js";
+import { ValidationError, NotFoundError } from "../utils/customErrors.js";
+
+const UUID_REGEX = /^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$/i;
+
+/**
+ * Correlation of a booking across subsystems. A booking is a join request
+ * and its payments; its ID (the join request ID) travels as `bookingId` in
+ * notification data, Stripe metadata and job payloads, and each subsystem
+ * records its steps here. Support reads it all back as one timeline.
+ */
+class BookingTraceService {
+  /**
+   * Records a step of a booking with the ID of the request or job handling it.
+   * Inside a transaction it is written once the transaction commits. Never
+   * throws: tracing must not break the booking.
+   * @param {string|null} bookingId - Nothing is recorded without one
+   * @param {Object} event - { source: "booking" | "payment" | "webhook" | "job", type, data }
+   */
+  async record(bookingId, { source, type, data = null }) {
+    if (!bookingId) {
+      return;
+    }
+    const event = {
+      bookingId,
+      source,
+      type,
+      data,
+      requestId: getRequestId() || null,
+      occurredAt: new Date(),
+    };
+    await txManager.afterCommit(async () => {
+      try {
+        await bookingEventRepository.create(event);
+      } catch (error) {
+        logger.error(
+          `Failed to record booking event ${source}.${type} of ${bookingId}: ${error.message}`
+        );
+      }
+    });
+  }
+
+  /**
+   * Everything known about a booking, in order
+   * @param {string} bookingId
+   * @returns {Promise<Object>} - { booking, payments, terms, requestIds, timeline: [{ at, source, type, requestId, data }] }
+   */
+  async getTimeline(bookingId) {
+    if (!UUID_REGEX.test(bookingId || "")) {
+      throw new ValidationError("ID de reserva inválido");
+    }
+    const request = await groupJoinRequestRepository.findById(bookingId);
+    if (!request) {
+      throw new NotFoundError("Reserva no encontrada");
+    }
+
+    const payments = await paymentRepository.findByJoinRequest(bookingId);
+    const paymentIds = payments.map((payment) => payment.id);
+    const [events, notifications, jobs, auditEntries, terms] = await Promise.all([
+      bookingEventRepository.findByBooking(bookingId),
+      bookingEventRepository.findNotifications(bookingId, paymentIds),
+      bookingEventRepository.findJobs(bookingId),
+      bookingEventRepository.findAuditEntries([bookingId, ...paymentIds]),
+      bookingTermsService.getBookingTerms(bookingId),
+    ]);
+
+    const timeline = events.map((event) => ({
+      at: event.occurredAt,
+      source: event.source,
+      type: event.type,
+      requestId: event.requestId,
+      data: event.data,
+    }));
+
+    // Bookings made before tracing existed only have their current state
+    if (!events.some((event) => event.source === "booking")) {
+      timeline.push({
+        at: request.createdAt,
+        source: "booking",
+        type: "requested",
+        requestId: null,
+        data: null,
+      });
+      if (request.decidedAt) {
+        timeline.push({
+          at: request.decidedAt,
+          source: "booking",
+          type: request.status,
+          requestId: null,
+          data: { decidedById: request.decidedById },
+        });
+      }
+    }
+    for (const payment of payments) {
+      const traced = events.some((event) => event.data?.paymentId === payment.id);
+      if (!traced) {
+        timeline.push({
+          at: payment.createdAt,
+          source: "payment",
+          type: "created",
+          requestId: null,
+          data: { paymentId: payment.id, status: payment.status },
+        });
+      }
+    }
+
+    for (const notification of notifications) {
+      timeline.push({
+        at: notification.createdAt,
+        source: "notification",
+        type: notification.type,
+        requestId: null,
+        data: {
+          notificationId: notification.id,
+          userId: notification.userId,
+          title: notification.title,
+          read: notification.read,
+        },
+      });
+    }
+    for (const job of jobs) {
+      timeline.push({
+        at: job.createdAt,
+        source: "job",
+        type: job.type,
+        requestId: `job-${job.id}`,
+        data: { jobId: job.id, status: job.status, attempts: job.attempts, lastError: job.lastError },
+      });
+    }
+    for (const entry of auditEntries) {
+      timeline.push({
+        at: entry.createdAt,
+        source: "audit",
+        type: entry.action,
+        requestId: null,
+        data: {
+          actorId: entry.actorId,
+          targetType: entry.targetType,
+          targetId: entry.targetId,
+          metadata: entry.metadata,
+        },
+      });
+    }
+
+    timeline.sort((a, b) => new Date(a.at) - new Date(b.at));
+    // Shows personal data of the traveler
+    await auditService.record({
+      action: AUDIT_ACTIONS.BOOKING_TIMELINE_VIEWED,
+      targetType: "booking",
+      targetId: request.id,
+    });
+    return {
+      booking: {
+        id: request.id,
+        status: request.status,
+        groupId: request.groupId,
+        groupName: request.group?.name || null,
+        userId: request.userId,
+        userEmail: request.user?.email || null,
+        createdAt: request.createdAt,
+        decidedAt: request.decidedAt,
+        decidedById: request.decidedById,
+      },
+      payments: payments.map((payment) => ({
+        id: payment.id,
+        kind: payment.kind,
+        amount: (payment.amount / 100).toFixed(2),
+        currency: payment.currency,
+        status: payment.status,
+        stripePaymentIntentId: payment.stripePaymentIntentId,
+        stripeRefundId: payment.stripeRefundId,
+        failureReason: payment.failureReason,
+        createdAt: payment.createdAt,
+        updatedAt: payment.updatedAt,
+      })),
+      terms,
+      // Search the logs for these to see the requests and jobs in detail
+      requestIds: [...new Set(timeline.map((entry) => entry.requestId).filter(Boolean))],
+      timeline,
+    };
+  }
+}
+
+export default new BookingTraceService();
@@ -0,0 +1,168 @@
+import crypto from "crypto";
+import calendarFeedRepository from "../repository/calendarFeed.repository.js";
+import groupRepository from "../repository/group.repository.js";
+import UserRepository from "../repository/user.repository.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { AuthorizationError, NotFoundError } from "../utils/customErrors.js";
+import { buildCalendar } from "../utils/ical.js";
+
+const TOKEN_PREFIX = "cal_";
+// Trips that ended longer ago than this are left out of the feed
+const FEED_PAST_DAYS = 90;
+// Hint for calendar apps on how often to refresh the feed
+const FEED_REFRESH_HOURS = 6;
+
+const userRepository = new UserRepository();
+
+const hashToken = (token) => crypto.createHash("sha256").update(token).digest("hex");
+
+/**
+ * iCalendar exports of trips: one trip as a .ics download, or every trip of
+ * a user as a feed calendar apps subscribe to through a tokenized URL.
+ * Trips are all-day events over their dates and itinerary items are events
+ * on their day, at their local time when they have one.
+ */
+class CalendarService {
+  /**
+   * .ics of a trip, for its members or anyone when the trip is public
+   * @param {string} groupId
+   * @param {string} userId
+   * @returns {Promise<Object>} - { filename, content }
+   */
+  async getTripCalendar(groupId, userId) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      throw new NotFoundError("Grupo no encontrado", "GROUP_NOT_FOUND");
+    }
+    const isMember = group.adminId === userId || group.members?.some((member) => member.id === userId);
+    if (!isMember && !group.isPublic) {
+      throw new AuthorizationError("No eres miembro de este grupo", "NOT_GROUP_MEMBER");
+    }
+    const content = buildCalendar({ name: group.name, events: this.buildTripEvents(group) });
+    return { filename: `${this.slugify(group.name) || "viaje"}.ics`, content };
+  }
+
+  /**
+   * Feed of the user that owns a token, with their current and upcoming trips
+   * @param {string} token
+   * @returns {Promise<string|null>} The calendar, null for an unknown token
+   */
+  async getFeed(token) {
+    if (typeof token !== "string" || !token.startsWith(TOKEN_PREFIX)) {
+      return null;
+    }
+    const feed = await calendarFeedRepository.findByTokenHash(hashToken(token.replace(/\.ics$/, "")));
+    // A closed account stops publishing its trips right away, before its erasure
+    if (!feed || !(await userRepository.findById(feed.userId))) {
+      return null;
+    }
+    const cutoff = new Date(Date.now() - FEED_PAST_DAYS * 24 * 60 * 60 * 1000).toISOString().slice(0, 10);
+    const groups = (await groupRepository.findByUserId(feed.userId)).filter(
+      (group) => !group.deletedAt && (!group.tripEndDate || group.tripEndDate >= cutoff)
+    );
+    await calendarFeedRepository.touch(feed.userId);
+    return buildCalendar({
+      name: "JoinTravel",
+      description: "Tus viajes en JoinTravel",
+      refreshHours: FEED_REFRESH_HOURS,
+      events: groups.flatMap((group) => this.buildTripEvents(group)),
+    });
+  }
+
+  /**
+   * Whether the user has a feed; the URL itself is only shown when created
+   * @param {string} userId
+   * @returns {Promise<Object>} - { enabled, tokenPrefix, createdAt, lastFetchedAt }
+   */
+  async getFeedInfo(userId) {
+    const feed = await calendarFeedRepository.findByUser(userId);
+    return {
+      enabled: Boolean(feed),
+      tokenPrefix: feed?.tokenPrefix ?? null,
+      createdAt: feed?.createdAt ?? null,
+      lastFetchedAt: feed?.lastFetchedAt ?? null,
+    };
+  }
+
+  /**
+   * Creates the feed of a user, or gives it a new URL (the old one stops working)
+   * @param {string} userId
+   * @returns {Promise<Object>} - { url, webcalUrl, tokenPrefix, createdAt }
+   */
+  async createFeed(userId) {
+    const token = `${TOKEN_PREFIX}${crypto.randomBytes(24).toString("base64url")}`;
+    const feed = await calendarFeedRepository.upsert({
+      userId,
+      tokenHash: hashToken(token),
+      tokenPrefix: token.slice(0, 12),
+    });
+    logger.info(`[Calendar] Feed URL issued for user ${userId}`);
+    const url = `${config.baseUrl}/api/v1/calendar/feed/${token}.ics`;
+    return {
+      url,
+      webcalUrl: url.replace(/^https?:/, "webcal:"),
+      tokenPrefix: feed.tokenPrefix,
+      createdAt: feed.createdAt,
+    };
+  }
+
+  async deleteFeed(userId) {
+    if (!(await calendarFeedRepository.delete(userId))) {
+      throw new NotFoundError("No tienes un calendario suscrito");
+    }
+    logger.info(`[Calendar] Feed of user ${userId} deleted`);
+  }
+
+  /**
+   * Events of a trip: the trip itself over its dates and its itinerary items
+   */
+  buildTripEvents(group) {
+    const tripUrl = `${config.frontendUrl}/groups/${group.id}`;
+    const events = [];
+    if (group.tripStartDate) {
+      events.push({
+        uid: `trip-${group.id}@jointravel`,
+        summary: group.name,
+        description: [group.description, tripUrl].filter(Boolean).join("\n\n"),
+        location: group.destinationName,
+        geo:
+          group.destinationLat !== null && group.destinationLng !== null
+            ? { lat: group.destinationLat, lng: group.destinationLng }
+            : null,
+        url: tripUrl,
+        date: group.tripStartDate,
+        endDate: group.tripEndDate || group.tripStartDate,
+      });
+    }
+    for (const item of group.assignedItinerary?.items || []) {
+      const place = item.place;
+      events.push({
+        uid: `itinerary-item-${item.id}@jointravel`,
+        summary: place?.name ? `${place.name} · ${group.name}` : group.name,
+        description: [item.notes, tripUrl].filter(Boolean).join("\n\n"),
+        location: place ? [place.name, place.address].filter(Boolean).join(", ") : null,
+        geo:
+          place?.latitude !== null && place?.latitude !== undefined
+            ? { lat: place.latitude, lng: place.longitude }
+            : null,
+        url: tripUrl,
+        date: item.date,
+        time: item.time,
+        updatedAt: item.updatedAt,
+      });
+    }
+    return events;
+  }
+
+  slugify(name) {
+    return String(name)
+      .normalize("NFD")
+      .replace(/[\u0300-\u036f]/g, "")
+      .toLowerCase()
+      .replace(/[^a-z0-9]+/g, "-")
+      .replace(/^-|-$/g, "");
+  }
+}
+
+export default new CalendarService();
@@ -8,6 +8,7 @@ import placeRepository from "../repository/place.repository.js";
 import UserRepository from "../repository/user.repository.js";
 import itineraryRepository from "../repository/itinerary.repository.js";
 import logger from "../config/logger.js";
+import legalHoldService from "./legalHold.service.js";
 
 
 class ChatService {
@@ -549,6 +550,8 @@ Instrucciones:
      */
   async deleteCurrentConversation(userId) {
     try {
+      await legalHoldService.assertNotOnHold(userId);
+
       // Find the most recent conversation for the user
       const currentConversation = await conversationRepository.findMostRecentByUserId(userId);
 
@@ -594,6 +597,8 @@ Instrucciones:
      * @returns {Promise<Object>} Deletion result
      */
   async deleteAllChatHistory(userId) {
+    await legalHoldService.assertNotOnHold(userId);
+
     try {
       // Delete all messages for the user
       const deletedMessages = await chatMessageRepository.deleteByUserId(userId);
@@ -0,0 +1,231 @@
+import groupRepository from "../repository/group.repository.js";
+import groupMessageRepository from "../repository/groupMessage.repository.js";
+import groupMessageReadRepository from "../repository/groupMessageRead.repository.js";
+import legalHoldRepository from "../repository/legalHold.repository.js";
+import txManager from "../repository/transactionManager.js";
+import userBlockService from "./userBlock.service.js";
+import logger from "../config/logger.js";
+import { createAndEmitNotification } from "../socket/notification.emitter.js";
+import { ValidationError, NotFoundError, AuthorizationError } from "../utils/customErrors.js";
+
+export const CHAT_RETENTION_POLICIES = ["forever", "after_trip_90d"];
+
+// Days after the end of the trip the chat is kept with "after_trip_90d"
+const RETENTION_DAYS = 90;
+// Members are warned this long before the chat is deleted, so they can export it
+const NOTICE_DAYS = 7;
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+const toDateString = (date) => new Date(date).toISOString().slice(0, 10);
+
+/**
+ * Trip chat retention: the organizer chooses whether the group chat is kept
+ * forever or deleted 90 days after the trip ends. Members see the policy with
+ * the messages, are notified when it changes and a week before deletion, and
+ * can export the chat meanwhile. The retention job does the deletion.
+ */
+class ChatRetentionService {
+  /**
+   * When the chat of a group will be deleted, or null if it is kept. Never
+   * earlier than NOTICE_DAYS after members were (or will be) warned.
+   * @param {Object} group
+   * @param {Date} now
+   * @returns {Date|null}
+   */
+  deletionDate(group, now = new Date()) {
+    if (group.chatRetention !== "after_trip_90d" || !group.tripEndDate || group.chatPurgedAt) {
+      return null;
+    }
+    const tripEnd = new Date(`${toDateString(group.tripEndDate)}T00:00:00Z`).getTime();
+    const noticeAt = group.chatDeletionNoticeAt
+      ? new Date(group.chatDeletionNoticeAt).getTime()
+      : now.getTime();
+    return new Date(Math.max(tripEnd + RETENTION_DAYS * DAY_MS, noticeAt + NOTICE_DAYS * DAY_MS));
+  }
+
+  /**
+   * Retention as shown to members
+   * @param {Object} group
+   * @returns {Object} - { policy, deletesAt, purgedAt, description }
+   */
+  describe(group) {
+    const policy = group.chatRetention || "forever";
+    const deletesAt = this.deletionDate(group);
+    let description;
+    if (group.chatPurgedAt) {
+      description = "Los mensajes de este chat se eliminaron según la configuración del organizador.";
+    } else if (policy === "forever") {
+      description = "Los mensajes de este chat se conservan indefinidamente.";
+    } else if (deletesAt) {
+      description = `Los mensajes de este chat se eliminarán el ${toDateString(deletesAt)}. Puedes exportarlos antes.`;
+    } else {
+      description = `Los mensajes de este chat se eliminarán ${RETENTION_DAYS} días después de que termine el viaje. Puedes exportarlos antes.`;
+    }
+    return {
+      policy,
+      deletesAt,
+      purgedAt: group.chatPurgedAt || null,
+      description,
+    };
+  }
+
+  /**
+   * @param {string} groupId
+   * @param {string} userId - Member asking
+   * @returns {Promise<Object>} Retention (see describe)
+   */
+  async getRetention(groupId, userId) {
+    const group = await this.getGroupForMember(groupId, userId);
+    return this.describe(group);
+  }
+
+  /**
+   * Sets the chat retention of a group (organizer only) and tells the other
+   * members when it changes
+   * @param {string} groupId
+   * @param {string} adminId
+   * @param {string} policy - "forever" | "after_trip_90d"
+   * @returns {Promise<Object>} Retention (see describe)
+   */
+  async setRetention(groupId, adminId, policy) {
+    if (!CHAT_RETENTION_POLICIES.includes(policy)) {
+      throw new ValidationError(`policy debe ser uno de: ${CHAT_RETENTION_POLICIES.join(", ")}`);
+    }
+    const group = await this.getGroupForMember(groupId, adminId);
+    if (group.adminId !== adminId) {
+      throw new AuthorizationError("Solo el organizador puede cambiar la conservación del chat");
+    }
+    if (group.chatPurgedAt) {
+      throw new ValidationError("Los mensajes de este chat ya fueron eliminados");
+    }
+    if (group.chatRetention === policy) {
+      return this.describe(group);
+    }
+
+    // A new policy starts a new notice period
+    await groupRepository.updateChatRetention(groupId, { chatRetention: policy, chatDeletionNoticeAt: null });
+    const updated = { ...group, chatRetention: policy, chatDeletionNoticeAt: null };
+    const retention = this.describe(updated);
+    logger.info(`Chat retention of group ${groupId} set to ${policy} by ${adminId}`);
+
+    await this.notifyMembers(
+      group,
+      group.members.filter((member) => member.id !== adminId),
+      {
+        type: "CHAT_RETENTION_CHANGED",
+        title: `Cambió la conservación del chat de ${group.name}`,
+        message: retention.description,
+        data: { groupId, groupName: group.name, policy, deletesAt: retention.deletesAt },
+      }
+    );
+    return retention;
+  }
+
+  /**
+   * Full chat of a group for a member to keep, without the messages of users
+   * they block or are blocked by (as in the chat itself)
+   * @param {string} groupId
+   * @param {string} userId
+   * @returns {Promise<Object>} - { group, exportedAt, retention, messages }
+   */
+  async exportChat(groupId, userId) {
+    const group = await this.getGroupForMember(groupId, userId);
+    const [messages, hiddenUserIds] = await Promise.all([
+      groupMessageRepository.findAllByGroupId(groupId),
+      userBlockService.getHiddenUserIds(userId),
+    ]);
+
+    logger.info(`Chat of group ${groupId} exported by ${userId}`);
+    return {
+      group: { id: group.id, name: group.name },
+      exportedAt: new Date().toISOString(),
+      retention: this.describe(group),
+      messages: messages
+        .filter((message) => !hiddenUserIds.has(message.senderId))
+        .map((message) => ({
+          id: message.id,
+          senderId: message.senderId,
+          senderName: message.sender?.name || null,
+          content: message.content,
+          createdAt: message.createdAt,
+        })),
+    };
+  }
+
+  /**
+   * Run by the retention job: warns the members of chats that are about to be
+   * deleted and deletes the chats whose time has come. Chats with a member or
+   * author under a legal hold are kept until the hold is released.
+   * @param {Date} now
+   * @returns {Promise<Object>} - { noticesSent, chatsDeleted, heldBack }
+   */
+  async enforce(now = new Date()) {
+    const noticeCutoff = new Date(now.getTime() - (RETENTION_DAYS - NOTICE_DAYS) * DAY_MS);
+    const toWarn = await groupRepository.findExpiringChats(toDateString(noticeCutoff), {
+      unnoticedOnly: true,
+    });
+    for (const group of toWarn) {
+      await groupRepository.updateChatRetention(group.id, { chatDeletionNoticeAt: now });
+      const deletesAt = this.deletionDate({ ...group, chatDeletionNoticeAt: now }, now);
+      await this.notifyMembers(group, group.members, {
+        type: "CHAT_DELETION_SCHEDULED",
+        title: `El chat de ${group.name} se eliminará pronto`,
+        message: `Los mensajes se eliminarán el ${toDateString(deletesAt)}. Si quieres conservarlos, expórtalos antes.`,
+        data: { groupId: group.id, groupName: group.name, deletesAt },
+      });
+    }
+
+    const deleteCutoff = new Date(now.getTime() - RETENTION_DAYS * DAY_MS);
+    const expired = await groupRepository.findExpiringChats(toDateString(deleteCutoff));
+    let chatsDeleted = 0;
+    let heldBack = 0;
+    for (const group of expired) {
+      const deletesAt = this.deletionDate(group, now);
+      if (!group.chatDeletionNoticeAt || deletesAt > now) {
+        continue;
+      }
+      const authorIds = await groupMessageRepository.findSenderIds(group.id);
+      const userIds = [...new Set([...authorIds, ...group.members.map((member) => member.id)])];
+      if ((await legalHoldRepository.findUsersOnHold(userIds)).length) {
+        heldBack += 1;
+        continue;
+      }
+
+      await txManager.run(async () => {
+        await groupMessageRepository.deleteByGroupId(group.id);
+        await groupMessageReadRepository.deleteByGroupId(group.id);
+        await groupRepository.updateChatRetention(group.id, { chatPurgedAt: now });
+      });
+      chatsDeleted += 1;
+      logger.info(`Chat of group ${group.id} deleted by its retention policy`);
+    }
+
+    if (heldBack) {
+      logger.warn(`${heldBack} expired chats kept because of legal holds`);
+    }
+    return { noticesSent: toWarn.length, chatsDeleted, heldBack };
+  }
+
+  async getGroupForMember(groupId, userId) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      throw new NotFoundError("El grupo ya no existe.", "GROUP_DELETED");
+    }
+    if (!group.members?.some((member) => member.id === userId)) {
+      throw new AuthorizationError("No eres miembro de este grupo", "NOT_GROUP_MEMBER");
+    }
+    return group;
+  }
+
+  async notifyMembers(group, members, notification) {
+    for (const member of members) {
+      try {
+        await createAndEmitNotification({ userId: member.id, ...notification });
+      } catch (error) {
+        logger.error(`Error notifying chat retention of group ${group.id} to ${member.id}: ${error.message}`);
+      }
+    }
+  }
+}
+
+export default new ChatRetentionService();
@@ -0,0 +1,164 @@
+import companionReferenceRepository from "../repository/companionReference.repository.js";
+import UserRepository from "../repository/user.repository.js";
+import trustScoreService from "./trustScore.service.js";
+import logger from "../config/logger.js";
+import { createAndEmitNotification } from "../socket/notification.emitter.js";
+import {
+  AppError,
+  ValidationError,
+  NotFoundError,
+  AuthorizationError,
+} from "../utils/customErrors.js";
+
+const MAX_CONTENT_LENGTH = 500;
+
+class CompanionReferenceService {
+  constructor() {
+    this.userRepository = new UserRepository();
+  }
+
+  /**
+   * Writes a reference about a co-traveler. It stays pending until the subject
+   * confirms they traveled together.
+   * @param {string} authorId
+   * @param {Object} data - { subjectId, groupId, content }
+   * @returns {Promise<Object>} Created reference
+   */
+  async createReference(authorId, { subjectId, groupId, content }) {
+    const text = typeof content === "string" ? content.trim() : "";
+    if (!subjectId || !groupId) {
+      throw new ValidationError("subjectId y groupId son requeridos");
+    }
+    if (!text || text.length > MAX_CONTENT_LENGTH) {
+      throw new ValidationError(`La referencia debe tener entre 1 y ${MAX_CONTENT_LENGTH} caracteres`);
+    }
+    if (subjectId === authorId) {
+      throw new ValidationError("No puedes escribir una referencia sobre ti mismo");
+    }
+
+    const traveledTogether = await companionReferenceRepository.sharedCompletedTrip(
+      authorId,
+      subjectId,
+      groupId
+    );
+    if (!traveledTogether) {
+      throw new ValidationError("Solo puedes dejar referencias a compañeros de un viaje ya finalizado");
+    }
+    if (await companionReferenceRepository.findExisting(authorId, subjectId, groupId)) {
+      throw new AppError("Ya dejaste una referencia a este compañero para este viaje", 409, "REFERENCE_EXISTS");
+    }
+
+    const reference = await companionReferenceRepository.create({
+      authorId,
+      subjectId,
+      groupId,
+      content: text,
+    });
+
+    await this.notify(subjectId, {
+      type: "COMPANION_REFERENCE_RECEIVED",
+      title: "Nueva referencia de viaje",
+      message: "Un compañero de viaje escribió una referencia sobre ti. Confírmala para mostrarla en tu perfil",
+      data: { referenceId: reference.id, authorId, groupId },
+    });
+
+    logger.info(`Reference ${reference.id} written by ${authorId} about ${subjectId}`);
+    return this.formatReference(await companionReferenceRepository.findById(reference.id));
+  }
+
+  /**
+   * References about the user waiting for their confirmation
+   */
+  async getPendingReferences(userId) {
+    const references = await companionReferenceRepository.findBySubject(userId, "pending");
+    return references.map((reference) => this.formatReference(reference));
+  }
+
+  /**
+   * Confirms (or declines) having traveled with the author; only confirmed
+   * references are shown and count towards the trust score
+   * @param {string} referenceId
+   * @param {string} userId - Subject of the reference
+   * @param {boolean} confirm
+   * @returns {Promise<Object>} Updated reference
+   */
+  async respondToReference(referenceId, userId, confirm) {
+    const reference = await companionReferenceRepository.findById(referenceId);
+    if (!reference) {
+      throw new NotFoundError("Referencia no encontrada");
+    }
+    if (reference.subjectId !== userId) {
+      throw new AuthorizationError("Solo la persona referida puede confirmar la referencia");
+    }
+    if (reference.status !== "pending") {
+      throw new AppError("La referencia ya fue respondida", 409, "REFERENCE_ANSWERED");
+    }
+
+    const updated = await companionReferenceRepository.update(referenceId, {
+      status: confirm ? "confirmed" : "declined",
+      confirmedAt: confirm ? new Date() : null,
+    });
+
+    if (confirm) {
+      await this.notify(reference.authorId, {
+        type: "COMPANION_REFERENCE_CONFIRMED",
+        title: "Referencia confirmada",
+        message: "Tu referencia fue confirmada y ya se muestra en el perfil de tu compañero",
+        data: { referenceId, subjectId: userId, groupId: reference.groupId },
+      });
+    }
+    return this.formatReference(updated);
+  }
+
+  /**
+   * Confirmed references shown on a profile, with the user's trust score
+   * @param {string} userId
+   * @returns {Promise<Object>} - { references, trustScore }
+   */
+  async getUserReferences(userId) {
+    const user = await this.userRepository.findById(userId);
+    if (!user) {
+      throw new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND");
+    }
+
+    const [references, trustScore] = await Promise.all([
+      companionReferenceRepository.findBySubject(userId, "confirmed"),
+      trustScoreService.getTrustScore(userId),
+    ]);
+    return {
+      references: references.map((reference) => this.formatReference(reference)),
+      trustScore,
+    };
+  }
+
+  async notify(userId, notification) {
+    try {
+      await createAndEmitNotification({ userId, ...notification });
+    } catch (notifError) {
+      logger.error(`Error sending ${notification.type} to ${userId}: ${notifError.message}`);
+    }
+  }
+
+  formatReference(reference) {
+    return {
+      id: reference.id,
+      content: reference.content,
+      status: reference.status,
+      author: reference.author
+        ? {
+            id: reference.author.id,
+            name: reference.author.name,
+            profilePicture: reference.author.profilePicture,
+          }
+        : { id: reference.authorId },
+      subjectId: reference.subjectId,
+      trip: reference.group
+        ? { id: reference.group.id, name: reference.group.name }
+        : { id: reference.groupId },
+      confirmedAt: reference.confirmedAt,
+      createdAt: reference.createdAt,
+    };
+  }
+}
+
+export default new CompanionReferenceService();
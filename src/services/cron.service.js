@@ -1,8 +1,85 @@
 import gamificationService from "./gamification.service.js";
+import recommendationService from "./recommendation.service.js";
+import malwareScanService from "./malwareScan.service.js";
+import searchService from "./search.service.js";
+import jobQueueService from "./jobQueue.service.js";
+import exchangeRateService from "./exchangeRate.service.js";
+import tripPublicationService from "./tripPublication.service.js";
+import idempotencyService from "./idempotency.service.js";
+import groupSuccessionService from "./groupSuccession.service.js";
+import usageService from "./usage.service.js";
+import sessionService from "./session.service.js";
+import tripReviewService from "./tripReview.service.js";
+import domainEventService from "./domainEvent.service.js";
+import tripRecommendationService from "./tripRecommendation.service.js";
+import attachmentRepository from "../repository/attachment.repository.js";
+import groupDocumentRepository from "../repository/groupDocument.repository.js";
+import groupRepository from "../repository/group.repository.js";
+import cronRunRepository from "../repository/cronRun.repository.js";
+import UserRepository from "../repository/user.repository.js";
+import { createAndEmitNotification } from "../socket/notification.emitter.js";
+import { ATTACHMENT_SCAN_JOB } from "../jobs/attachmentScan.job.js";
+import { DOCUMENT_SCAN_JOB } from "../jobs/documentScan.job.js";
+import { EXCHANGE_RATE_REFRESH_JOB } from "../jobs/exchangeRateRefresh.job.js";
+import { CHAT_RETENTION_JOB } from "../jobs/chatRetention.job.js";
+import config from "../config/index.js";
 import { AppDataSource } from "../load/typeorm.loader.js";
 import logger from "../config/logger.js";
+import { ValidationError } from "../utils/customErrors.js";
+
+const DAY_MINUTES = 24 * 60;
+
+/**
+ * How often the external scheduler is expected to call each /cron task, and
+ * how late a run may be before it counts as missed. Tasks without an entry
+ * are only run by hand.
+ */
+export const CRON_SCHEDULES = {
+  "daily-maintenance": { everyMinutes: DAY_MINUTES, graceMinutes: 2 * 60 },
+  "scheduled-publications": { everyMinutes: 15, graceMinutes: 15 },
+  "monthly-recommendations": { everyMinutes: 31 * DAY_MINUTES, graceMinutes: DAY_MINUTES },
+};
+
+const RUN_STATUSES = ["running", "succeeded", "failed", "missed"];
+const MAX_PAGE_SIZE = 200;
+
+const userRepository = new UserRepository();
 
 class CronService {
+  constructor() {
+    this.watchTimer = null;
+  }
+
+  /**
+   * Runs a scheduled task recording the run in cron_runs, so the admin status
+   * page can tell when each task last ran and whether it failed
+   * @param {string} task - Task name, e.g. "daily-maintenance"
+   * @param {Function} run - Async function doing the work
+   * @returns {Promise<*>} Result of run
+   */
+  async runTask(task, run) {
+    // A database outage must not stop the task from being attempted
+    const record = await cronRunRepository.start(task).catch((error) => {
+      logger.warn(`Could not record cron run of ${task}: ${error.message}`);
+      return null;
+    });
+
+    try {
+      const result = await run();
+      if (record) {
+        await cronRunRepository.finish(record, { status: "succeeded" }).catch(() => {});
+      }
+      return result;
+    } catch (error) {
+      if (record) {
+        await cronRunRepository
+          .finish(record, { status: "failed", error: error.message })
+          .catch(() => {});
+      }
+      throw error;
+    }
+  }
+
   /**
    * Recalculate user stats for all users
    * This should be run daily to ensure data consistency
@@ -76,15 +153,51 @@ class CronService {
 
       const statsResult = await this.recalculateAllUserStats();
       const cleanupResult = await this.cleanupOldUserActions();
+      const scansResult = await this.requeuePendingScans();
+      const ratesJob = await this.scheduleExchangeRateRefresh();
+      const dimensionsResult = await this.backfillTravelDimensions();
+      const chatRetentionJob = await this.scheduleChatRetention();
+      const idempotencyKeysPurged = await idempotencyService.purgeExpired();
+      const successionsStarted = await groupSuccessionService.startForInactiveOrganizers();
+      const usageAggregatesPurged = await usageService.purgeExpired();
+      const sessionsPurged = await sessionService.purgeEnded();
+      const reviewWeightsRefreshed = await tripReviewService.refreshAuthorWeights();
+      const reviewRings = await tripReviewService.detectReviewRings();
+      const outboxEventsPurged = await domainEventService.purgePublished();
+      const tripRecommendations = await tripRecommendationService.scheduleDailyRefresh();
 
       logger.info("Daily maintenance tasks completed", {
         statsRecalculated: statsResult,
-        actionsCleaned: cleanupResult
+        actionsCleaned: cleanupResult,
+        scansRequeued: scansResult,
+        exchangeRatesJob: ratesJob,
+        travelDimensionsBackfilled: dimensionsResult,
+        chatRetentionJob,
+        idempotencyKeysPurged,
+        successionsStarted,
+        usageAggregatesPurged,
+        sessionsPurged,
+        reviewWeightsRefreshed,
+        reviewRings,
+        outboxEventsPurged,
+        tripRecommendations
       });
 
       return {
         statsRecalculated: statsResult,
-        actionsCleaned: cleanupResult
+        actionsCleaned: cleanupResult,
+        scansRequeued: scansResult,
+        exchangeRatesJob: ratesJob,
+        travelDimensionsBackfilled: dimensionsResult,
+        chatRetentionJob,
+        idempotencyKeysPurged,
+        successionsStarted,
+        usageAggregatesPurged,
+        sessionsPurged,
+        reviewWeightsRefreshed,
+        reviewRings,
+        outboxEventsPurged,
+        tripRecommendations
       };
 
     } catch (error) {
@@ -92,6 +205,286 @@ class CronService {
       throw error;
     }
   }
+
+  /**
+   * Queue again the antivirus scans of uploads still pending after a long
+   * scanner outage (their jobs ran out of attempts)
+   */
+  async requeuePendingScans() {
+    try {
+      if (!malwareScanService.isEnabled()) {
+        return { attachments: 0, documents: 0 };
+      }
+
+      const before = new Date(Date.now() - config.scanner.requeueAfterHours * 60 * 60 * 1000);
+      const attachments = await attachmentRepository.findPendingScans(before);
+      const versions = await groupDocumentRepository.findPendingScans(before);
+
+      for (const attachment of attachments) {
+        await malwareScanService.enqueueScan(ATTACHMENT_SCAN_JOB, { attachmentId: attachment.id });
+      }
+      for (const version of versions) {
+        await malwareScanService.enqueueScan(DOCUMENT_SCAN_JOB, { versionId: version.id });
+      }
+
+      logger.info(`Requeued pending scans: ${attachments.length} attachments, ${versions.length} documents`);
+      return { attachments: attachments.length, documents: versions.length };
+
+    } catch (error) {
+      logger.error("Failed to requeue pending scans:", error);
+      throw error;
+    }
+  }
+
+  /**
+   * Compute the travel month and duration bucket of groups with an itinerary
+   * that have none yet (groups created before these columns existed)
+   */
+  async backfillTravelDimensions() {
+    try {
+      const updated = await groupRepository.refreshTravelDimensions({ missing: true });
+      if (updated > 0) {
+        logger.info(`Backfilled travel dimensions of ${updated} groups`);
+      }
+      return updated;
+
+    } catch (error) {
+      logger.error("Failed to backfill travel dimensions:", error);
+      throw error;
+    }
+  }
+
+  /**
+   * Queue the daily exchange rate refresh (retried by the job queue if the provider is down)
+   */
+  async scheduleExchangeRateRefresh() {
+    try {
+      const job = await jobQueueService.enqueue(EXCHANGE_RATE_REFRESH_JOB, {}, { maxAttempts: 6 });
+      return job.id;
+
+    } catch (error) {
+      logger.error("Failed to schedule exchange rate refresh:", error);
+      throw error;
+    }
+  }
+
+  /**
+   * Queue the enforcement of trip chat retention (notices and deletions)
+   */
+  async scheduleChatRetention() {
+    try {
+      const job = await jobQueueService.enqueue(CHAT_RETENTION_JOB, {});
+      return job.id;
+
+    } catch (error) {
+      logger.error("Failed to schedule chat retention:", error);
+      throw error;
+    }
+  }
+
+  /**
+   * Refresh the exchange rates right away
+   */
+  async runExchangeRateRefresh() {
+    try {
+      logger.info("Starting exchange rate refresh");
+
+      const result = await exchangeRateService.refreshRates();
+
+      logger.info("Exchange rate refresh completed", result);
+      return result;
+
+    } catch (error) {
+      logger.error("Failed to refresh exchange rates:", error);
+      throw error;
+    }
+  }
+
+  /**
+   * Publish the trips whose scheduled launch time has passed
+   */
+  async runScheduledPublications() {
+    try {
+      logger.info("Starting scheduled publications");
+
+      const result = await tripPublicationService.publishDue();
+
+      logger.info("Scheduled publications completed", result);
+      return result;
+
+    } catch (error) {
+      logger.error("Failed to run scheduled publications:", error);
+      throw error;
+    }
+  }
+
+  /**
+   * Rebuild every search document (after enabling search or switching engines)
+   */
+  async runSearchReindex() {
+    try {
+      logger.info("Starting search reindex");
+
+      const result = await searchService.reindexAll();
+
+      logger.info("Search reindex completed", result);
+      return result;
+
+    } catch (error) {
+      logger.error("Failed to run search reindex:", error);
+      throw error;
+    }
+  }
+
+  /**
+   * Queue the monthly recommendation email of every user
+   * Users within the frequency cap or with the email disabled are skipped by the job
+   */
+  async runMonthlyRecommendations() {
+    try {
+      logger.info("Queueing monthly recommendation emails");
+
+      const result = await recommendationService.sendMonthlyRecommendations();
+
+      logger.info("Monthly recommendation emails queued", result);
+      return result;
+
+    } catch (error) {
+      logger.error("Failed to run monthly recommendations:", error);
+      throw error;
+    }
+  }
+
+  /**
+   * Runs of the scheduled tasks, newest first
+   * @param {Object} filters - { task, status, limit, offset }
+   * @returns {Promise<Object>} - { runs, total, limit, offset }
+   */
+  async getRunHistory({ task, status, limit, offset } = {}) {
+    const pageSize = limit === undefined ? 50 : parseInt(limit, 10);
+    const skip = offset === undefined ? 0 : parseInt(offset, 10);
+    if (!Number.isInteger(pageSize) || pageSize < 1 || pageSize > MAX_PAGE_SIZE) {
+      throw new ValidationError(`limit debe estar entre 1 y ${MAX_PAGE_SIZE}`);
+    }
+    if (!Number.isInteger(skip) || skip < 0) {
+      throw new ValidationError("offset debe ser un entero no negativo");
+    }
+    if (status && !RUN_STATUSES.includes(status)) {
+      throw new ValidationError(`status debe ser uno de: ${RUN_STATUSES.join(", ")}`);
+    }
+
+    const { runs, total } = await cronRunRepository.findHistory({
+      task: task || undefined,
+      status: status || undefined,
+      limit: pageSize,
+      offset: skip,
+    });
+    return { runs, total, limit: pageSize, offset: skip };
+  }
+
+  /**
+   * Every known task with its expected schedule and latest run
+   * @returns {Promise<Array>} - [{ task, everyMinutes, graceMinutes, lastStatus, lastRunAt,
+   *                               lastSucceededAt, lastDurationMs, lastError, overdue }]
+   */
+  async getTaskOverview() {
+    const latest = await cronRunRepository.findLatestByTask();
+    const tasks = new Set([...Object.keys(CRON_SCHEDULES), ...latest.map((run) => run.task)]);
+    const now = Date.now();
+
+    return [...tasks].sort().map((task) => {
+      const schedule = CRON_SCHEDULES[task] || null;
+      const run = latest.find((item) => item.task === task);
+      return {
+        task,
+        everyMinutes: schedule?.everyMinutes ?? null,
+        graceMinutes: schedule?.graceMinutes ?? null,
+        lastStatus: run?.status ?? null,
+        lastRunAt: run?.lastRunAt ?? null,
+        lastSucceededAt: run?.lastSucceededAt ?? null,
+        lastDurationMs: run?.durationMs ?? null,
+        lastError: run?.error ?? null,
+        overdue: Boolean(
+          schedule &&
+            run?.lastRunAt &&
+            now - new Date(run.lastRunAt).getTime() >
+              (schedule.everyMinutes + schedule.graceMinutes) * 60 * 1000
+        ),
+      };
+    });
+  }
+
+  /**
+   * Records a "missed" run and notifies the admins for every scheduled task
+   * that has not run within its interval plus grace. Reported again after
+   * each further interval without runs. Tasks that never ran are skipped:
+   * there is no telling when their schedule started.
+   * @returns {Promise<Array>} Tasks reported as missed
+   */
+  async checkMissedRuns() {
+    const latest = await cronRunRepository.findLatestByTask();
+    const reported = [];
+
+    for (const [task, { everyMinutes, graceMinutes }] of Object.entries(CRON_SCHEDULES)) {
+      const run = latest.find((item) => item.task === task);
+      if (!run?.lastRunAt) {
+        continue;
+      }
+      const since = new Date(Date.now() - (everyMinutes + graceMinutes) * 60 * 1000);
+      if (new Date(run.lastRunAt) > since) {
+        continue;
+      }
+      const missed = await cronRunRepository.recordMissed(task, since);
+      if (!missed) {
+        continue;
+      }
+
+      logger.error(`[Scheduler] ${task} has not run since ${new Date(run.lastRunAt).toISOString()}`);
+      reported.push(task);
+      await this.notifyAdmins(task, run.lastRunAt);
+    }
+    return reported;
+  }
+
+  async notifyAdmins(task, lastRunAt) {
+    try {
+      const adminIds = await userRepository.findAdminIds();
+      for (const userId of adminIds) {
+        await createAndEmitNotification({
+          userId,
+          type: "scheduler_missed_run",
+          title: "Tarea programada sin ejecutar",
+          message: `La tarea ${task} no se ejecuta desde ${new Date(lastRunAt).toISOString()}`,
+          data: { task, lastRunAt },
+          priority: "critical",
+        });
+      }
+    } catch (error) {
+      logger.error(`[Scheduler] Failed to notify admins about ${task}: ${error.message}`);
+    }
+  }
+
+  /**
+   * Checks for missed runs every SCHEDULER_CHECK_INTERVAL_MINUTES
+   */
+  startWatching() {
+    if (this.watchTimer) {
+      return;
+    }
+    this.watchTimer = setInterval(() => {
+      this.checkMissedRuns().catch((error) =>
+        logger.error(`[Scheduler] Missed run check failed: ${error.message}`)
+      );
+    }, config.scheduler.checkIntervalMinutes * 60 * 1000);
+    this.watchTimer.unref();
+  }
+
+  stopWatching() {
+    if (this.watchTimer) {
+      clearInterval(this.watchTimer);
+      this.watchTimer = null;
+    }
+  }
 }
 
 export default new CronService();
\ No newline at end of file
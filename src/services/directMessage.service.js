@@ -1,7 +1,11 @@
 import directMessageRepository from "../repository/directMessage.repository.js";
 import UserRepository from "../repository/user.repository.js";
+import userBlockService from "./userBlock.service.js";
+import readStateService from "./readState.service.js";
+import badgeService from "./badge.service.js";
 import logger from "../config/logger.js";
 import { createAndEmitNotification } from "../socket/notification.emitter.js";
+import { isAgeRestricted } from "../utils/ageVerification.js";
 
 class DirectMessageService {
   /**
@@ -35,6 +39,22 @@ class DirectMessageService {
         };
       }
 
+      // Also applies to messages sent through the socket
+      if (isAgeRestricted(sender)) {
+        throw {
+          status: 403,
+          message: "Esta función no está disponible para tu cuenta por la edad mínima de tu país.",
+          errorCode: "AGE_RESTRICTED",
+        };
+      }
+
+      if (await userBlockService.isBlockedBetween(senderId, receiverId)) {
+        throw {
+          status: 403,
+          message: "You cannot message this user",
+        };
+      }
+
       // Validate content
       if (!content || content.trim().length === 0) {
         throw {
@@ -57,6 +77,7 @@ class DirectMessageService {
         content: content.trim(),
         isRead: false,
       });
+      badgeService.increment(receiverId, "messages");
 
       logger.info(
         `Direct message sent from ${senderId} to ${receiverId} in conversation ${conversationId}`
@@ -111,6 +132,13 @@ class DirectMessageService {
    */
   async getConversationHistory(userId, otherUserId, limit = 50, offset = 0) {
     try {
+      if (await userBlockService.isBlockedBetween(userId, otherUserId)) {
+        throw {
+          status: 403,
+          message: "You cannot view this conversation",
+        };
+      }
+
       const conversationId = directMessageRepository.createConversationId(
         userId,
         otherUserId
@@ -123,7 +151,15 @@ class DirectMessageService {
       );
 
       // Mark messages as read for the current user
-      await directMessageRepository.markAsRead(conversationId, userId);
+      const markedRead = await directMessageRepository.markAsRead(conversationId, userId);
+      if (markedRead > 0) {
+        badgeService.increment(userId, "messages", -markedRead);
+        readStateService.publish(userId, {
+          scope: "direct_messages",
+          conversationId,
+          otherUserId,
+        });
+      }
 
       logger.info(
         `Retrieved ${messages.length} messages for conversation ${conversationId}`
@@ -150,6 +186,9 @@ class DirectMessageService {
         },
       };
     } catch (error) {
+      if (error.status) {
+        throw error;
+      }
       logger.error(`Error getting conversation history: ${error.message}`);
       throw {
         status: 500,
@@ -166,8 +205,7 @@ class DirectMessageService {
    */
   async getConversations(userId) {
     try {
-      const conversations =
-        await directMessageRepository.findConversationsByUserId(userId);
+      const conversations = await directMessageRepository.findConversationsByUserId(userId);
 
       const conversationsWithDetails = await Promise.all(
         conversations.map(async (conv) => {
@@ -249,7 +287,15 @@ class DirectMessageService {
         otherUserId
       );
 
-      await directMessageRepository.markAsRead(conversationId, userId);
+      const markedRead = await directMessageRepository.markAsRead(conversationId, userId);
+      if (markedRead > 0) {
+        badgeService.increment(userId, "messages", -markedRead);
+        readStateService.publish(userId, {
+          scope: "direct_messages",
+          conversationId,
+          otherUserId,
+        });
+      }
 
       logger.info(
         `Marked messages as read for conversation ${conversationId} by user ${userId}`
@@ -268,6 +314,40 @@ class DirectMessageService {
       };
     }
   }
+
+  /**
+   * Delete a message sent by the user (soft delete, restorable by an admin)
+   * @param {string} userId - Current user ID
+   * @param {string} messageId - Message ID
+   * @returns {Promise<Object>} Success response
+   */
+  async deleteMessage(userId, messageId) {
+    const message = await directMessageRepository.findById(messageId);
+    if (!message) {
+      throw {
+        status: 404,
+        message: "Message not found",
+      };
+    }
+    if (message.senderId !== userId) {
+      throw {
+        status: 403,
+        message: "You can only delete your own messages",
+      };
+    }
+
+    await directMessageRepository.softDelete(messageId);
+    if (!message.isRead) {
+      badgeService.invalidate(message.receiverId);
+    }
+    logger.info(`Direct message ${messageId} deleted by user ${userId}`);
+
+    return {
+      success: true,
+      data: { id: messageId, conversationId: message.conversationId },
+      message: "Message deleted successfully",
+    };
+  }
 }
 
 export default new DirectMessageService();
@@ -0,0 +1,216 @@
+import crypto from "crypto";
+import broker from "../clients/broker.client.js";
+import outboxEventRepository from "../repository/outboxEvent.repository.js";
+import txManager from "../repository/transactionManager.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+
+/**
+ * Domain events and the type of aggregate each one belongs to. Payloads carry
+ * IDs and the facts of the change, never personal data beyond user IDs, since
+ * they leave the API when a broker is configured.
+ */
+export const DOMAIN_EVENTS = {
+  // { groupId, adminId, isPublic, destinationName }
+  TRIP_CREATED: "TripCreated",
+  // { groupId, userId, addedById, via: "added" | "join_request" | "invite" | "waitlist" }
+  MEMBER_JOINED: "MemberJoined",
+  // { groupId, messageId, senderId }
+  MESSAGE_SENT: "MessageSent",
+};
+
+const AGGREGATE_TYPES = {
+  TripCreated: "trip",
+  MemberJoined: "trip",
+  MessageSent: "trip",
+};
+
+const EVENT_VERSION = 1;
+const RETRY_BASE_MS = 1000;
+const RETRY_MAX_MS = 5 * 60 * 1000;
+
+/**
+ * Domain event bus. Services emit events as part of their changes; handlers
+ * subscribed in-process (see events/index.js) run once the transaction
+ * commits, and with EVENTS_BROKER set the event is also written to the
+ * outbox in that same transaction and published by the relay, in order and
+ * at least once (consumers deduplicate on eventId).
+ */
+class DomainEventService {
+  constructor() {
+    // type -> [handler]
+    this.handlers = new Map();
+    this.timer = null;
+    this.relaying = false;
+  }
+
+  /**
+   * Subscribes an in-process handler; its failures are logged and do not
+   * reach the emitter or the other handlers
+   * @param {string} type - One of DOMAIN_EVENTS
+   * @param {Function} handler - async (event) => void
+   */
+  subscribe(type, handler) {
+    this.handlers.set(type, [...(this.handlers.get(type) || []), handler]);
+  }
+
+  /**
+   * Emits an event. Call it inside the transaction of the change so the
+   * event is only published and handled if the change commits.
+   * @param {string} type - One of DOMAIN_EVENTS
+   * @param {string} aggregateId - e.g. the group ID
+   * @param {Object} payload
+   * @returns {Promise<Object>} The event envelope
+   */
+  async emit(type, aggregateId, payload = {}) {
+    const aggregateType = AGGREGATE_TYPES[type];
+    if (!aggregateType) {
+      throw new Error(`Unknown domain event: ${type}`);
+    }
+    const event = {
+      eventId: crypto.randomUUID(),
+      type,
+      version: EVENT_VERSION,
+      aggregateType,
+      aggregateId: String(aggregateId),
+      occurredAt: new Date().toISOString(),
+      payload,
+    };
+
+    if (broker) {
+      await outboxEventRepository.create({
+        eventId: event.eventId,
+        type,
+        aggregateType,
+        aggregateId: event.aggregateId,
+        payload,
+        occurredAt: new Date(event.occurredAt),
+        availableAt: new Date(event.occurredAt),
+      });
+    }
+    await txManager.afterCommit(() => this.dispatch(event));
+    return event;
+  }
+
+  async dispatch(event) {
+    for (const handler of this.handlers.get(event.type) || []) {
+      try {
+        await handler(event);
+      } catch (error) {
+        logger.error(`[Events] Handler ${handler.name || "anonymous"} of ${event.type} ${event.eventId} failed: ${error.message}`);
+      }
+    }
+  }
+
+  /**
+   * Starts publishing the outbox to the broker (nothing to do without one)
+   */
+  startRelay() {
+    if (!broker || this.timer) {
+      return;
+    }
+    this.timer = setInterval(() => this.relay(), config.events.relayIntervalMs);
+    logger.info(`[Events] Outbox relay started (${broker.name})`);
+  }
+
+  async stopRelay() {
+    if (this.timer) {
+      clearInterval(this.timer);
+      this.timer = null;
+    }
+    await broker?.disconnect();
+  }
+
+  /**
+   * Publishes the next batch of the outbox. The batch is claimed in a short
+   * transaction and published outside it, so no transaction or lock stays
+   * open during network calls; only one relay across processes holds a claim
+   * at a time. A failed event is retried with backoff and holds back the ones
+   * after it until EVENTS_RELAY_MAX_ATTEMPTS, when it is marked failed.
+   * @returns {Promise<number>} Events published
+   */
+  async relay() {
+    if (this.relaying) {
+      return 0;
+    }
+    this.relaying = true;
+    const published = [];
+    let rows = [];
+    try {
+      const { relayBatchSize, relayClaimMs, relayMaxAttempts, timeoutMs } = config.events;
+      rows = await outboxEventRepository.claimNextBatch(relayBatchSize, relayClaimMs);
+      // Stop while the claim is still ours, leaving room for one more publish
+      const stopAt = Date.now() + relayClaimMs - timeoutMs;
+      for (const row of rows) {
+        if (Date.now() > stopAt) {
+          break;
+        }
+        try {
+          await broker.publish(this.toEnvelope(row));
+          published.push(row.id);
+        } catch (error) {
+          const delayMs = Math.min(RETRY_MAX_MS, RETRY_BASE_MS * 2 ** row.attempts);
+          const status = await outboxEventRepository.markFailed(
+            row.id,
+            error.message,
+            new Date(Date.now() + delayMs),
+            relayMaxAttempts
+          );
+          if (status === "failed") {
+            logger.error(
+              `[Events] Giving up on ${row.type} ${row.eventId} after ${row.attempts + 1} attempts: ${error.message}`
+            );
+            continue;
+          }
+          logger.warn(
+            `[Events] Publishing ${row.type} ${row.eventId} failed (attempt ${row.attempts + 1}), ` +
+              `retrying in ${Math.round(delayMs / 1000)}s: ${error.message}`
+          );
+          break;
+        }
+      }
+    } catch (error) {
+      logger.error(`[Events] Outbox relay failed: ${error.message}`);
+    }
+
+    try {
+      if (published.length) {
+        await outboxEventRepository.markPublished(published);
+      }
+      const sent = new Set(published);
+      const unsent = rows.filter((row) => !sent.has(row.id)).map((row) => row.id);
+      if (unsent.length) {
+        await outboxEventRepository.releaseClaims(unsent);
+      }
+    } catch (error) {
+      logger.error(`[Events] Could not record the relayed events: ${error.message}`);
+    } finally {
+      this.relaying = false;
+    }
+    return published.length;
+  }
+
+  /**
+   * Deletes published events past EVENTS_RETENTION_DAYS (daily maintenance)
+   * @returns {Promise<number>} Events deleted
+   */
+  async purgePublished() {
+    return await outboxEventRepository.purgePublished(
+      new Date(Date.now() - config.events.retentionDays * 24 * 60 * 60 * 1000)
+    );
+  }
+
+  toEnvelope(row) {
+    return {
+      eventId: row.eventId,
+      type: row.type,
+      version: EVENT_VERSION,
+      aggregateType: row.aggregateType,
+      aggregateId: row.aggregateId,
+      occurredAt: new Date(row.occurredAt).toISOString(),
+      payload: row.payload,
+    };
+  }
+}
+
+export default new DomainEventService();
@@ -1,6 +1,16 @@
 import nodemailer from "nodemailer";
 import config from "../config/index.js";
+import logger from "../config/logger.js";
+import tenantService from "./tenant.service.js";
 import { ExternalServiceError } from "../utils/customErrors.js";
+import { t, DEFAULT_LOCALE, DATE_LOCALES } from "../utils/i18n.js";
+import { escapeHtml } from "../utils/html.js";
+
+const LOGO_ATTACHMENT = {
+  filename: 'logo-32x32.png',
+  path: './src/assets/logo-32x32.png',
+  cid: 'logo'
+};
 
 class EmailService {
   constructor() {
@@ -22,55 +32,67 @@ class EmailService {
   }
 
   /**
-   * Envía un correo de confirmación de registro
+   * Botón con el color de la marca
+   * @param {Object} brand - Marca del destinatario
+   * @param {string} url
+   * @param {string} label
+   * @returns {string} HTML
+   */
+  button(brand, url, label) {
+    return `<a href="${url}" style="display: inline-block; padding: 10px 20px; background-color: ${brand.primaryColor}; color: white; text-decoration: none; border-radius: 5px; margin: 20px 0;">
+                            ${label}
+                        </a>`;
+  }
+
+  /**
+   * Textos de una plantilla: text() para el asunto y la versión en texto
+   * plano, html() con los valores escapados (nombres de tenants, viajes o
+   * personas los escriben usuarios)
+   * @param {string} locale
+   * @param {string} prefix - Prefijo de las claves, p. ej. "email.badge"
+   * @param {Object} params - Valores interpolados
+   * @returns {Object} - { text, html }
+   */
+  texts(locale, prefix, params) {
+    const escaped = Object.fromEntries(Object.entries(params).map(([name, value]) => [name, escapeHtml(value)]));
+    return {
+      text: (key) => t(locale, `${prefix}.${key}`, params),
+      html: (key) => t(locale, `${prefix}.${key}`, escaped),
+    };
+  }
+
+  /**
+   * Envía un correo con la marca del tenant del destinatario (logo, colores,
+   * remitente firmado con DKIM y dirección de respuesta), o la de JoinTravel
    * @param {string} email - Email del destinatario
-   * @param {string} token - Token de confirmación
-   * @param {string} [profilePicture] - URL o path de la imagen de perfil del remitente
+   * @param {Function} compose - (brand) => { subject, html, text }
+   * @param {string} errorMessage - Mensaje si falla el envío
    * @returns {Promise<boolean>} - true si se envió correctamente
    */
-  async sendConfirmationEmail(email, token) {
+  async send(email, compose, errorMessage) {
     try {
-      const confirmationUrl = `${config.frontendUrl}/confirm-email?token=${token}`;
-
-      // Preparar attachments
-      const attachments = [
-        {
-          filename: 'logo-32x32.png',
-          path: './src/assets/logo-32x32.png',
-          cid: 'logo'
-        }
-      ];
+      const brand = await tenantService.getEmailBranding(email);
+      const { subject, html, text } = compose(brand);
+      const logo = brand.logoUrl
+        ? `<img src="${escapeHtml(brand.logoUrl)}" alt="${escapeHtml(brand.name)}" style="max-height: 32px;">`
+        : `<img src="cid:logo" alt="${escapeHtml(brand.name)} Logo" style="max-width: 32px;">`;
 
       const mailOptions = {
-        from: `${config.email.from}`,
+        from: brand.from,
         to: email,
-        subject: "Confirma tu registro en JoinTravel",
+        subject,
         html: `
-                    <div style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto;">
-                        <h1 style="color: #333;">¡Bienvenido a JoinTravel!</h1>
-                        <p>Gracias por registrarte. Para completar tu registro, por favor confirma tu correo electrónico haciendo clic en el siguiente enlace:</p>
-                        <a href="${confirmationUrl}" style="display: inline-block; padding: 10px 20px; background-color: #007bff; color: white; text-decoration: none; border-radius: 5px; margin: 20px 0;">
-                            Confirmar mi correo
-                        </a>
-                        <p>Este enlace expirará en 24 horas.</p>
-                        <p>Si no creaste esta cuenta, puedes ignorar este correo.</p>
+                    <div style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto; color: ${brand.textColor};">
+                        ${html}
                         <hr style="margin: 30px 0; border: none; border-top: 1px solid #eee;">
-                        <p style="color: #666; font-size: 12px;">JoinTravel - Tu compañero de viajes</p>
-                        <img src="cid:logo" alt="JoinTravel Logo" style="max-width: 32px;">
+                        <p style="color: #666; font-size: 12px;">${escapeHtml(brand.footerText)}</p>
+                        ${logo}
                     </div>
                 `,
-        text: `
-                    ¡Bienvenido a JoinTravel!
-
-                    Gracias por registrarte. Para completar tu registro, por favor confirma tu correo electrónico visitando el siguiente enlace:
-
-                    ${confirmationUrl}
-
-                    Este enlace expirará en 24 horas.
-
-                    Si no creaste esta cuenta, puedes ignorar este correo.
-                `,
-        attachments: attachments
+        text,
+        attachments: brand.logoUrl ? [] : [LOGO_ATTACHMENT],
+        ...(brand.replyTo && { replyTo: brand.replyTo }),
+        ...(brand.dkim && { dkim: brand.dkim }),
       };
 
       // Add timeout to email sending (30 seconds)
@@ -82,151 +104,228 @@ class EmailService {
       await Promise.race([emailPromise, timeoutPromise]);
       return true;
     } catch (error) {
+      logger.error(`${errorMessage}: ${error.message}`);
       if (error instanceof ExternalServiceError) {
         throw error;
       }
-      throw new ExternalServiceError("Error al enviar el correo de confirmación");
+      throw new ExternalServiceError(errorMessage);
     }
   }
 
+  /**
+   * Envía un correo de confirmación de registro
+   * @param {string} email - Email del destinatario
+   * @param {string} token - Token de confirmación
+   * @param {string} locale - Idioma del destinatario (SUPPORTED_LOCALES)
+   * @returns {Promise<boolean>} - true si se envió correctamente
+   */
+  async sendConfirmationEmail(email, token, locale = DEFAULT_LOCALE) {
+    const confirmationUrl = `${config.frontendUrl}/confirm-email?token=${token}`;
+    return await this.send(
+      email,
+      (brand) => {
+        const { text, html } = this.texts(locale, "email.confirmation", { brand: brand.name });
+        return {
+          subject: text("subject"),
+          html: `
+                        <h1>${html("heading")}</h1>
+                        <p>${html("intro")}</p>
+                        ${this.button(brand, confirmationUrl, html("button"))}
+                        <p>${html("expires")}</p>
+                        <p>${html("ignore")}</p>`,
+          text: `
+                    ${text("heading")}
+
+                    ${text("introText")}
+
+                    ${confirmationUrl}
+
+                    ${text("expires")}
+
+                    ${text("ignore")}
+                `,
+        };
+      },
+      "Error al enviar el correo de confirmación"
+    );
+  }
+
   /**
    * Envía una notificación de insignia ganada
    * @param {string} email - Email del destinatario
    * @param {Object} badge - Datos de la insignia
+   * @param {string} locale - Idioma del destinatario (SUPPORTED_LOCALES)
    * @returns {Promise<boolean>} - true si se envió correctamente
    */
-  async sendBadgeNotification(email, badge) {
-    try {
-      // Preparar attachments
-      const attachments = [
-        {
-          filename: 'logo-32x32.png',
-          path: './src/assets/logo-32x32.png',
-          cid: 'logo'
-        }
-      ];
-
-      const mailOptions = {
-        from: `${config.email.from}`,
-        to: email,
-        subject: `¡Felicidades! Has ganado la insignia "${badge.name}" en JoinTravel`,
-        html: `
-                    <div style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto;">
-                        <h1 style="color: #333;">¡Felicidades! 🎉</h1>
-                        <p>Has ganado una nueva insignia en JoinTravel:</p>
+  async sendBadgeNotification(email, badge, locale = DEFAULT_LOCALE) {
+    return await this.send(
+      email,
+      (brand) => {
+        const { text, html } = this.texts(locale, "email.badge", { brand: brand.name, badge: badge.name });
+        return {
+          subject: text("subject"),
+          html: `
+                        <h1>${html("heading")} 🎉</h1>
+                        <p>${html("intro")}</p>
                         <div style="background-color: #f8f9fa; padding: 20px; border-radius: 10px; margin: 20px 0; text-align: center;">
-                            <h2 style="color: #28a745; margin: 0;">${badge.name}</h2>
-                            <p style="color: #666; margin: 10px 0 0 0;">${badge.description}</p>
+                            <h2 style="color: #28a745; margin: 0;">${escapeHtml(badge.name)}</h2>
+                            <p style="color: #666; margin: 10px 0 0 0;">${escapeHtml(badge.description)}</p>
                         </div>
-                        <p>¡Sigue explorando y compartiendo tus experiencias de viaje para ganar más insignias!</p>
-                        <a href="${config.frontendUrl}/profile" style="display: inline-block; padding: 10px 20px; background-color: #007bff; color: white; text-decoration: none; border-radius: 5px; margin: 20px 0;">
-                            Ver mi perfil
-                        </a>
-                        <hr style="margin: 30px 0; border: none; border-top: 1px solid #eee;">
-                        <p style="color: #666; font-size: 12px;">JoinTravel - Tu compañero de viajes</p>
-                        <img src="cid:logo" alt="JoinTravel Logo" style="max-width: 32px;">
-                    </div>
-                `,
-        text: `
-                    ¡Felicidades!
+                        <p>${html("outro")}</p>
+                        ${this.button(brand, `${config.frontendUrl}/profile`, html("button"))}`,
+          text: `
+                    ${text("heading")}
 
-                    Has ganado una nueva insignia en JoinTravel:
+                    ${text("intro")}
 
                     ${badge.name}
                     ${badge.description}
 
-                    ¡Sigue explorando y compartiendo tus experiencias de viaje para ganar más insignias!
+                    ${text("outro")}
 
-                    Ver mi perfil: ${config.frontendUrl}/profile
+                    ${text("button")}: ${config.frontendUrl}/profile
                 `,
-        attachments: attachments
-      };
-
-      // Add timeout to email sending (30 seconds)
-      const emailPromise = this.transporter.sendMail(mailOptions);
-      const timeoutPromise = new Promise((_, reject) =>
-        setTimeout(() => reject(new ExternalServiceError("Email service timeout")), 30000)
-      );
-
-      await Promise.race([emailPromise, timeoutPromise]);
-      return true;
-    } catch (error) {
-      if (error instanceof ExternalServiceError) {
-        throw error;
-      }
-      throw new ExternalServiceError("Error al enviar la notificación de insignia");
-    }
+        };
+      },
+      "Error al enviar la notificación de insignia"
+    );
   }
 
   /**
    * Envía un correo de recuperación de contraseña
    * @param {string} email - Email del destinatario
    * @param {string} token - Token de reseteo de contraseña
+   * @param {string} locale - Idioma del destinatario (SUPPORTED_LOCALES)
    * @returns {Promise<boolean>} - true si se envió correctamente
    */
-  async sendPasswordResetEmail(email, token) {
-    try {
-      const resetUrl = `${config.frontendUrl}/reset-password?token=${token}`;
+  async sendPasswordResetEmail(email, token, locale = DEFAULT_LOCALE) {
+    const resetUrl = `${config.frontendUrl}/reset-password?token=${token}`;
+    return await this.send(
+      email,
+      (brand) => {
+        const { text, html } = this.texts(locale, "email.passwordReset", { brand: brand.name });
+        return {
+          subject: text("subject"),
+          html: `
+                        <h1>${html("heading")}</h1>
+                        <p>${html("intro")}</p>
+                        <p>${html("action")}</p>
+                        ${this.button(brand, resetUrl, html("button"))}
+                        <p style="color: #d9534f; font-weight: bold;">${html("expires")}</p>
+                        <p>${html("ignore")}</p>`,
+          text: `
+                    ${text("subject")}
 
-      // Preparar attachments
-      const attachments = [
-        {
-          filename: 'logo-32x32.png',
-          path: './src/assets/logo-32x32.png',
-          cid: 'logo'
-        }
-      ];
+                    ${text("intro")}
 
-      const mailOptions = {
-        from: `${config.email.from}`,
-        to: email,
-        subject: "Recuperación de contraseña - JoinTravel",
-        html: `
-                    <div style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto;">
-                        <h1 style="color: #333;">Recuperación de contraseña</h1>
-                        <p>Has solicitado restablecer tu contraseña en JoinTravel.</p>
-                        <p>Para crear una nueva contraseña, haz clic en el siguiente enlace:</p>
-                        <a href="${resetUrl}" style="display: inline-block; padding: 10px 20px; background-color: #007bff; color: white; text-decoration: none; border-radius: 5px; margin: 20px 0;">
-                            Restablecer mi contraseña
-                        </a>
-                        <p style="color: #d9534f; font-weight: bold;">Este enlace expirará en 24 horas.</p>
-                        <p>Si no solicitaste restablecer tu contraseña, puedes ignorar este correo de forma segura. Tu contraseña no será cambiada.</p>
-                        <hr style="margin: 30px 0; border: none; border-top: 1px solid #eee;">
-                        <p style="color: #666; font-size: 12px;">JoinTravel - Tu compañero de viajes</p>
-                        <img src="cid:logo" alt="JoinTravel Logo" style="max-width: 32px;">
-                    </div>
+                    ${text("actionText")}
+
+                    ${resetUrl}
+
+                    ${text("expires")}
+
+                    ${text("ignore")}
                 `,
-        text: `
-                    Recuperación de contraseña - JoinTravel
+        };
+      },
+      "Error al enviar el correo de recuperación"
+    );
+  }
 
-                    Has solicitado restablecer tu contraseña en JoinTravel.
+  /**
+   * Envía el correo mensual de viajes recomendados
+   * @param {string} email - Email del destinatario
+   * @param {Array} items - Viajes recomendados [{ name, destinationName, startDate, url }], con url de seguimiento de clics
+   * @param {string} locale - Idioma del destinatario (SUPPORTED_LOCALES)
+   * @returns {Promise<boolean>} - true si se envió correctamente
+   */
+  async sendRecommendationEmail(email, items, locale = DEFAULT_LOCALE) {
+    return await this.send(
+      email,
+      (brand) => {
+        const { text, html } = this.texts(locale, "email.recommendations", { brand: brand.name });
+        const itemsHtml = items
+          .map(
+            (item) => `
+                        <div style="background-color: #f8f9fa; padding: 15px; border-radius: 10px; margin: 10px 0;">
+                            <h3 style="margin: 0;">${escapeHtml(item.name)}</h3>
+                            ${item.destinationName ? `<p style="color: #666; margin: 5px 0 10px 0;">${escapeHtml(item.destinationName)}${item.startDate ? ` · ${item.startDate}` : ""}</p>` : ""}
+                            <a href="${escapeHtml(item.url)}" style="color: ${brand.primaryColor}; text-decoration: none;">${html("viewTrip")}</a>
+                        </div>`
+          )
+          .join("");
+        const itemsText = items
+          .map((item) => `- ${item.name}${item.destinationName ? ` (${item.destinationName})` : ""}: ${item.url}`)
+          .join("\n");
 
-                    Para crear una nueva contraseña, visita el siguiente enlace:
+        return {
+          subject: text("subject"),
+          html: `
+                        <h1>${html("heading")} ✈️</h1>
+                        <p>${html("intro")}</p>
+                        ${itemsHtml}
+                        <p>${html("unsubscribe")}</p>`,
+          text: `
+                    ${text("heading")} - ${brand.name}
 
-                    ${resetUrl}
+                    ${text("intro")}
 
-                    Este enlace expirará en 24 horas.
+${itemsText}
 
-                    Si no solicitaste restablecer tu contraseña, puedes ignorar este correo de forma segura. Tu contraseña no será cambiada.
+                    ${text("unsubscribe")}
                 `,
-        attachments: attachments
-      };
+        };
+      },
+      "Error al enviar el correo de recomendaciones"
+    );
+  }
 
-      // Add timeout to email sending (30 seconds)
-      const emailPromise = this.transporter.sendMail(mailOptions);
-      const timeoutPromise = new Promise((_, reject) =>
-        setTimeout(() => reject(new ExternalServiceError("Email service timeout")), 30000)
-      );
+  /**
+   * Avisa a un contacto de emergencia que el usuario reportó un incidente en un viaje
+   * @param {string} email - Email del contacto
+   * @param {Object} alert - { contactName, reporterName, tripName, incidentType, mapUrl, reportedAt }
+   * @param {string} locale - Idioma del usuario que reportó, que suele ser el de sus contactos
+   * @returns {Promise<boolean>} - true si se envió correctamente
+   */
+  async sendEmergencyContactAlert(email, alert, locale = DEFAULT_LOCALE) {
+    const when = new Date(alert.reportedAt).toLocaleString(DATE_LOCALES[locale] || DATE_LOCALES[DEFAULT_LOCALE], {
+      timeZone: "UTC",
+    });
+    return await this.send(
+      email,
+      (brand) => {
+        const params = {
+          brand: brand.name,
+          contact: alert.contactName,
+          reporter: alert.reporterName,
+          trip: alert.tripName,
+          incident: t(locale, `email.emergencyAlert.incidentTypes.${alert.incidentType}`),
+          when,
+        };
+        const { text, html } = this.texts(locale, "email.emergencyAlert", params);
+        return {
+          subject: text("subject"),
+          html: `
+                        <h1>${html("heading")}</h1>
+                        <p>${html("greeting")}</p>
+                        <p>${html("body").replace(escapeHtml(alert.reporterName), `<strong>${escapeHtml(alert.reporterName)}</strong>`)}</p>
+                        ${alert.mapUrl ? `<p>${html("location")} <a href="${escapeHtml(alert.mapUrl)}" style="color: ${brand.primaryColor};">${html("viewMap")}</a></p>` : ""}
+                        <p>${html("advice")}</p>`,
+          text: `
+                    ${text("heading")}
 
-      await Promise.race([emailPromise, timeoutPromise]);
-      return true;
-    } catch (error) {
-      console.error("Error detallado al enviar correo de recuperación:", error);
-      if (error instanceof ExternalServiceError) {
-        throw error;
-      }
-      throw new ExternalServiceError("Error al enviar el correo de recuperación");
-    }
+                    ${text("greeting")}
+
+                    ${text("body")}
+
+                    ${alert.mapUrl ? `${text("location")} ${alert.mapUrl}` : ""}
+
+                    ${text("advice")}
+                `,
+        };
+      },
+      "Error al enviar el aviso al contacto de emergencia"
+    );
   }
 }
 
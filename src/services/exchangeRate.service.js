@@ -0,0 +1,73 @@
+import exchangeRateRepository from "../repository/exchangeRate.repository.js";
+import exchangeRateProvider from "../clients/exchangeRate.client.js";
+import logger from "../config/logger.js";
+
+// Rates change once a day; avoid a query per conversion
+const CACHE_TTL_MS = 60 * 60 * 1000;
+
+class ExchangeRateService {
+  constructor() {
+    this.cache = null;
+    this.cachedAt = 0;
+  }
+
+  /**
+   * Fetches the latest rates from the configured provider and stores them
+   * @returns {Promise<Object>} - { source, base, date, currencies }
+   */
+  async refreshRates() {
+    const { base, date, rates } = await exchangeRateProvider.fetchLatest();
+    const rows = Object.entries(rates)
+      .filter(([currency, rate]) => /^[A-Z]{3}$/.test(currency) && rate > 0)
+      .map(([currency, rate]) => ({
+        currency,
+        rate,
+        base,
+        source: exchangeRateProvider.name,
+        rateDate: date,
+      }));
+
+    await exchangeRateRepository.replaceAll(rows);
+    this.cache = null;
+    logger.info(`Exchange rates refreshed from ${exchangeRateProvider.name}: ${rows.length} currencies (${date})`);
+
+    return { source: exchangeRateProvider.name, base, date, currencies: rows.length };
+  }
+
+  /**
+   * @returns {Promise<Object>} - { [currency]: rate } relative to the stored base
+   */
+  async getRates() {
+    if (!this.cache || Date.now() - this.cachedAt > CACHE_TTL_MS) {
+      const rows = await exchangeRateRepository.findAll();
+      this.cache = Object.fromEntries(rows.map((row) => [row.currency, Number(row.rate)]));
+      this.cachedAt = Date.now();
+    }
+    return this.cache;
+  }
+
+  async isSupported(currency) {
+    const rates = await this.getRates();
+    return Boolean(rates[currency]);
+  }
+
+  /**
+   * Converts an amount in cents between currencies
+   * @param {number} amount - Cents in `from`
+   * @param {string} from
+   * @param {string} to
+   * @returns {Promise<number|null>} - Cents in `to`, or null when a rate is missing
+   */
+  async convert(amount, from, to) {
+    if (from === to) {
+      return amount;
+    }
+    const rates = await this.getRates();
+    if (!rates[from] || !rates[to]) {
+      return null;
+    }
+    return Math.round((amount * rates[to]) / rates[from]);
+  }
+}
+
+export default new ExchangeRateService();
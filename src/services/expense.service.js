@@ -8,13 +8,42 @@ import {
   AuthorizationError,
 } from "../utils/customErrors.js";
 import { createAndEmitNotification } from "../socket/notification.emitter.js";
+import { computeBalances, settleBalances } from "../utils/expenseSplit.js";
+import exchangeRateService from "./exchangeRate.service.js";
+import badgeService from "./badge.service.js";
+import config from "../config/index.js";
 
 const expenseRepository = AppDataSource.getRepository(Expense);
 const groupRepository = AppDataSource.getRepository(Group);
 const userRepository = AppDataSource.getRepository(User);
 
+const CURRENCY_REGEX = /^[A-Z]{3}$/;
+
+const groupMemberIds = (group) => [
+  ...new Set([group.adminId, ...group.members.map((member) => member.id)]),
+];
+
+const toDecimal = (cents) => (cents / 100).toFixed(2);
+
+/**
+ * Currency to display amounts in: the requested one, else the user's preferred one
+ * @returns {Promise<string|null>} - null when amounts are shown as recorded
+ */
+const resolveDisplayCurrency = async (userId, requested = null) => {
+  if (requested) {
+    const currency = String(requested).toUpperCase();
+    if (!(await exchangeRateService.isSupported(currency))) {
+      throw new ValidationError("Unsupported display currency");
+    }
+    return currency;
+  }
+  const user = await userRepository.findOne({ where: { id: userId } });
+  return user?.preferredCurrency || null;
+};
+
 export const createExpense = async (expenseData, userId) => {
   const { concept, amount, groupId } = expenseData;
+  const currency = (expenseData.currency || config.expenses.defaultCurrency).toUpperCase();
 
   // Validate input
   if (!concept || typeof concept !== "string" || concept.trim().length === 0) {
@@ -37,8 +66,12 @@ export const createExpense = async (expenseData, userId) => {
     throw new ValidationError("Amount must be a valid number");
   }
 
+  if (!CURRENCY_REGEX.test(currency)) {
+    throw new ValidationError("Currency must be a 3-letter ISO 4217 code");
+  }
+
   // Check for excessive decimal places (more than 2)
-  if (amount.includes(".") && amount.split(".")[1].length > 2) {
+  if (String(amount).includes(".") && String(amount).split(".")[1].length > 2) {
     throw new ValidationError("Amount can have at most 2 decimal places");
   }
 
@@ -60,21 +93,49 @@ export const createExpense = async (expenseData, userId) => {
     throw new NotFoundError("Group not found");
   }
 
-  // Only group admin can create expenses
-  if (group.adminId !== userId) {
-    throw new AuthorizationError("Only the group admin can add expenses");
+  const memberIds = groupMemberIds(group);
+  if (!memberIds.includes(userId)) {
+    throw new AuthorizationError("You must be a member of the group to add expenses");
+  }
+
+  // Members record what they paid themselves; the admin can record it for
+  // someone else or leave it unassigned and assign it later
+  const isAdmin = group.adminId === userId;
+  let paidById = isAdmin ? expenseData.paidById || null : userId;
+  if (!isAdmin && expenseData.paidById && expenseData.paidById !== userId) {
+    throw new AuthorizationError("Only the group admin can record expenses paid by others");
+  }
+  if (paidById && !memberIds.includes(paidById)) {
+    throw new ValidationError("Usuario inválido.", null, "INVALID_USER");
+  }
+
+  // Split among every current member unless a subset is given
+  let participantIds = memberIds;
+  if (expenseData.participantIds !== undefined) {
+    if (
+      !Array.isArray(expenseData.participantIds) ||
+      !expenseData.participantIds.length ||
+      expenseData.participantIds.some((id) => !memberIds.includes(id))
+    ) {
+      throw new ValidationError("participantIds must be a non-empty list of group members");
+    }
+    participantIds = [...new Set(expenseData.participantIds)];
   }
 
-  // Create expense without paidById
   const expense = expenseRepository.create({
     concept: concept.trim(),
     amount: amountInCents, // Store as cents
+    currency,
     groupId,
     userId,
-    paidById: null,
+    paidById,
+    participantIds,
   });
 
   await expenseRepository.save(expense);
+  if (!paidById) {
+    badgeService.increment(group.members.map((member) => member.id), "unpaidBalances");
+  }
 
   // Send notifications to all group members except the creator
   try {
@@ -133,7 +194,7 @@ export const createExpense = async (expenseData, userId) => {
   };
 };
 
-export const getGroupExpenses = async (groupId, userId) => {
+export const getGroupExpenses = async (groupId, userId, requestedCurrency = null) => {
   let expenses;
 
   if (groupId) {
@@ -177,11 +238,42 @@ export const getGroupExpenses = async (groupId, userId) => {
       .getMany();
   }
 
+  const displayCurrency = await resolveDisplayCurrency(userId, requestedCurrency);
+
   // Convert amounts back to decimal and calculate total
-  const expensesWithDecimal = expenses.map((expense) => ({
-    ...expense,
-    amount: (expense.amount / 100).toFixed(2),
-  }));
+  const expensesWithDecimal = [];
+  let displayTotalInCents = 0;
+  // Cents per currency with no rate, left out of displayTotal
+  const unconvertedInCents = {};
+  for (const expense of expenses) {
+    const item = {
+      ...expense,
+      amount: (expense.amount / 100).toFixed(2),
+    };
+    if (displayCurrency) {
+      const currency = expense.currency || config.expenses.defaultCurrency;
+      // null when there is no rate for the expense currency
+      const converted = await exchangeRateService.convert(Number(expense.amount), currency, displayCurrency);
+      item.displayAmount = converted === null ? null : toDecimal(converted);
+      item.displayCurrency = displayCurrency;
+      if (converted === null) {
+        unconvertedInCents[currency] = (unconvertedInCents[currency] || 0) + Number(expense.amount);
+      } else {
+        displayTotalInCents += converted;
+      }
+    }
+    expensesWithDecimal.push(item);
+  }
+  const display = displayCurrency
+    ? {
+        displayCurrency,
+        displayTotal: toDecimal(displayTotalInCents),
+        unconverted: Object.entries(unconvertedInCents).map(([currency, cents]) => ({
+          currency,
+          total: toDecimal(cents),
+        })),
+      }
+    : {};
 
   // Calculate total using cents to avoid floating point precision issues
   const totalInCents = expenses.reduce((sum, expense) => {
@@ -219,22 +311,113 @@ export const getGroupExpenses = async (groupId, userId) => {
     return {
       expenses: expensesWithDecimal,
       total: "0.00",
+      ...display,
     };
   }
 
   return {
     expenses: expensesWithDecimal,
     total: total.toFixed(2),
+    ...display,
+  };
+};
+
+/**
+ * Per-member balances of a trip and the transfers that settle them, one block
+ * per currency (amounts in different currencies are never mixed). With a display
+ * currency, `converted` also settles every expense at once in that currency;
+ * currencies with no rate are listed in `converted.unconverted` with their
+ * total and are only settled in their own block.
+ * Unassigned expenses are left out until someone is recorded as the payer.
+ */
+export const getGroupBalances = async (groupId, userId, requestedCurrency = null) => {
+  const group = await groupRepository.findOne({
+    where: { id: groupId },
+    relations: ["members"],
+  });
+
+  if (!group) {
+    throw new NotFoundError("Group not found");
+  }
+
+  const memberIds = groupMemberIds(group);
+  if (!memberIds.includes(userId)) {
+    throw new AuthorizationError(
+      "You must be a member of the group to view balances"
+    );
+  }
+
+  const expenses = await expenseRepository.find({ where: { groupId } });
+  const assigned = expenses.filter((expense) => expense.paidById);
+
+  const byCurrency = {};
+  for (const expense of assigned) {
+    const currency = expense.currency || config.expenses.defaultCurrency;
+    (byCurrency[currency] = byCurrency[currency] || []).push({
+      amount: Number(expense.amount),
+      paidById: expense.paidById,
+      // Expenses recorded before participants were tracked are shared by everyone
+      participantIds: expense.participantIds || memberIds,
+    });
+  }
+
+  const summarize = (currency, items) => {
+    const balances = computeBalances(items, memberIds);
+    return {
+      currency,
+      total: toDecimal(items.reduce((sum, item) => sum + item.amount, 0)),
+      balances: Object.entries(balances).map(([memberId, balance]) => ({
+        userId: memberId,
+        balance: toDecimal(balance),
+      })),
+      settlements: settleBalances(balances).map((transfer) => ({
+        ...transfer,
+        amount: toDecimal(transfer.amount),
+      })),
+    };
+  };
+  const currencies = Object.entries(byCurrency).map(([currency, items]) =>
+    summarize(currency, items)
+  );
+
+  let converted = null;
+  const displayCurrency = await resolveDisplayCurrency(userId, requestedCurrency);
+  if (displayCurrency) {
+    const convertedItems = [];
+    const unconverted = [];
+    for (const [currency, items] of Object.entries(byCurrency)) {
+      const amounts = await Promise.all(
+        items.map((item) => exchangeRateService.convert(item.amount, currency, displayCurrency))
+      );
+      // The rate is per currency: either every item converts or none does
+      if (amounts.includes(null)) {
+        unconverted.push({
+          currency,
+          total: toDecimal(items.reduce((sum, item) => sum + item.amount, 0)),
+        });
+        continue;
+      }
+      items.forEach((item, index) => convertedItems.push({ ...item, amount: amounts[index] }));
+    }
+    converted = { ...summarize(displayCurrency, convertedItems), unconverted };
+  }
+
+  return {
+    groupId,
+    currencies,
+    converted,
+    unassignedExpenses: expenses.length - assigned.length,
   };
 };
 
 export const deleteExpense = async (expenseId, userId) => {
   const expense = await expenseRepository.findOne({
     where: { id: expenseId },
-    relations: ["group"],
+    relations: ["group", "group.members"],
   });
 
-  if (!expense) {
+  // The group is not loaded when it was deleted
+  if (!expense || !expense.group) {
     throw new NotFoundError("Expense not found");
   }
 
@@ -243,7 +426,11 @@ export const deleteExpense = async (expenseId, userId) => {
     throw new AuthorizationError("Only the group admin can delete expenses");
   }
 
+  const wasUnpaid = !expense.paidById;
   await expenseRepository.remove(expense);
+  if (wasUnpaid) {
+    badgeService.increment(expense.group.members.map((member) => member.id), "unpaidBalances", -1);
+  }
   return { message: "Expense deleted successfully" };
 };
 
@@ -254,7 +441,8 @@ export const assignExpense = async (expenseId, paidById, userId) => {
     relations: ["group", "group.members"],
   });
 
-  if (!expense) {
+  // The group is not loaded when it was deleted
+  if (!expense || !expense.group) {
     throw new NotFoundError("Expense not found");
   }
 
@@ -271,12 +459,16 @@ export const assignExpense = async (expenseId, paidById, userId) => {
     expense.group.adminId === paidById;
 
   if (!isPaidByMember) {
-    throw new ValidationError("Usuario inválido.");
+    throw new ValidationError("Usuario inválido.", null, "INVALID_USER");
   }
 
   // Update expense
+  const wasUnpaid = !expense.paidById;
   expense.paidById = paidById;
   await expenseRepository.save(expense);
+  if (wasUnpaid) {
+    badgeService.increment(expense.group.members.map((member) => member.id), "unpaidBalances", -1);
+  }
 
   // Send notification to the assigned user (if not the admin)
   if (paidById !== userId) {
@@ -327,6 +519,7 @@ export const assignExpense = async (expenseId, paidById, userId) => {
 export default {
   createExpense,
   getGroupExpenses,
+  getGroupBalances,
   deleteExpense,
   assignExpense,
 };
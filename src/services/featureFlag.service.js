@@ -0,0 +1,222 @@
+import crypto from "crypto";
+import featureFlagRepository from "../repository/featureFlag.repository.js";
+import auditService, { AUDIT_ACTIONS } from "./audit.service.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { NotFoundError, ValidationError } from "../utils/customErrors.js";
+
+const KEY_REGEX = /^[a-zA-Z][a-zA-Z0-9_.-]{1,59}$/;
+const MAX_DESCRIPTION_LENGTH = 255;
+
+/**
+ * Bucket 0-99 of a user for a flag. Hashing the key too spreads the users of
+ * different flags independently.
+ */
+const getBucket = (key, userId) =>
+  crypto.createHash("sha256").update(`${key}:${userId}`).digest().readUInt32BE(0) % 100;
+
+/**
+ * Feature flags for rolling out features. Each flag is on or off, and when
+ * on, for a percentage of users. Flags start from FEATURE_FLAGS and admins
+ * override them at runtime; instances pick up a change within
+ * FEATURE_FLAGS_CACHE_TTL_SECONDS.
+ *
+ * Unlike the feature policies (policy.service.js), which turn features off
+ * for a country or tenant for good, flags are for features being released.
+ */
+class FeatureFlagService {
+  constructor() {
+    // { flags: Map(key -> settings), expiresAt }
+    this.cache = null;
+    this.loading = null;
+    // Bumped on every change, so a load that started before it is not cached
+    this.generation = 0;
+  }
+
+  /**
+   * Whether a flag is on for a user. Unknown flags are off; with a partial
+   * rollout the flag is off for anonymous callers.
+   * @param {string} key
+   * @param {Object} context - { userId }
+   * @returns {Promise<boolean>}
+   */
+  async isEnabled(key, { userId = null } = {}) {
+    const flag = (await this.getFlags()).get(key);
+    return flag ? this.evaluate(flag, userId) : false;
+  }
+
+  /**
+   * Every flag for a user, for the apps
+   * @param {Object} context - { userId }
+   * @returns {Promise<Object>} - { [key]: boolean }
+   */
+  async getAllForUser({ userId = null } = {}) {
+    const flags = await this.getFlags();
+    return Object.fromEntries([...flags.values()].map((flag) => [flag.key, this.evaluate(flag, userId)]));
+  }
+
+  evaluate(flag, userId) {
+    if (!flag.enabled) {
+      return false;
+    }
+    if (flag.rolloutPercent >= 100) {
+      return true;
+    }
+    return Boolean(userId) && getBucket(flag.key, userId) < flag.rolloutPercent;
+  }
+
+  /**
+   * Every flag with where its setting comes from, for admins
+   * @returns {Promise<Array>} - [{ key, description, enabled, rolloutPercent, source, default, updatedById, updatedAt }]
+   */
+  async listFlags() {
+    const overrides = await featureFlagRepository.findAll();
+    const defaults = this.getDefaults();
+    const keys = [...new Set([...defaults.keys(), ...overrides.map((flag) => flag.key)])].sort();
+
+    return keys.map((key) => {
+      const override = overrides.find((flag) => flag.key === key);
+      const fallback = defaults.get(key) || null;
+      const flag = override || fallback;
+      return {
+        key,
+        description: flag.description,
+        enabled: flag.enabled,
+        rolloutPercent: flag.rolloutPercent,
+        source: override ? "runtime" : "config",
+        default: fallback && { enabled: fallback.enabled, rolloutPercent: fallback.rolloutPercent },
+        updatedById: override?.updatedById ?? null,
+        updatedAt: override?.updatedAt ?? null,
+      };
+    });
+  }
+
+  /**
+   * Sets a flag at runtime. Fields left out keep their current value.
+   * @param {string} key
+   * @param {Object} data - { enabled, rolloutPercent, description }
+   * @param {string} adminId
+   * @returns {Promise<Object>} The flag
+   */
+  async setFlag(key, data, adminId) {
+    if (!KEY_REGEX.test(key || "")) {
+      throw new ValidationError(
+        "La clave debe empezar con una letra y tener entre 2 y 60 letras, números, puntos, guiones o guiones bajos"
+      );
+    }
+    const details = {};
+    if (data.enabled !== undefined && typeof data.enabled !== "boolean") {
+      details.enabled = "Debe ser booleano";
+    }
+    if (
+      data.rolloutPercent !== undefined &&
+      (!Number.isInteger(data.rolloutPercent) || data.rolloutPercent < 0 || data.rolloutPercent > 100)
+    ) {
+      details.rolloutPercent = "Debe ser un entero entre 0 y 100";
+    }
+    if (
+      data.description !== undefined &&
+      data.description !== null &&
+      (typeof data.description !== "string" || data.description.length > MAX_DESCRIPTION_LENGTH)
+    ) {
+      details.description = `Debe ser un texto de hasta ${MAX_DESCRIPTION_LENGTH} caracteres`;
+    }
+    if (Object.keys(details).length > 0) {
+      throw new ValidationError("Datos del flag inválidos", details);
+    }
+
+    const current = (await featureFlagRepository.findByKey(key)) ||
+      this.getDefaults().get(key) || { enabled: false, rolloutPercent: 100, description: null };
+    const flag = await featureFlagRepository.upsert({
+      key,
+      description: data.description !== undefined ? data.description : current.description,
+      enabled: data.enabled ?? current.enabled,
+      rolloutPercent: data.rolloutPercent ?? current.rolloutPercent,
+      updatedById: adminId,
+    });
+    this.invalidate();
+    logger.info(`[Flags] ${key} set to ${flag.enabled ? `on (${flag.rolloutPercent}%)` : "off"} by ${adminId}`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.FEATURE_FLAG_UPDATED,
+      actorId: adminId,
+      targetType: "feature_flag",
+      targetId: key,
+      metadata: { enabled: flag.enabled, rolloutPercent: flag.rolloutPercent },
+    });
+    return flag;
+  }
+
+  /**
+   * Drops the runtime setting of a flag, back to its FEATURE_FLAGS value
+   * (or off if it has none)
+   */
+  async resetFlag(key) {
+    if (!(await featureFlagRepository.delete(key))) {
+      throw new NotFoundError("El flag no tiene un valor en tiempo de ejecución");
+    }
+    this.invalidate();
+    logger.info(`[Flags] ${key} reset to its configured value`);
+    await auditService.record({ action: AUDIT_ACTIONS.FEATURE_FLAG_RESET, targetType: "feature_flag", targetId: key });
+  }
+
+  /**
+   * FEATURE_FLAGS overlaid with the runtime settings, cached for a while.
+   * If the database fails the last known flags (or the configured ones) are used.
+   * @returns {Promise<Map>} key -> { key, enabled, rolloutPercent, description }
+   */
+  async getFlags() {
+    if (this.cache && this.cache.expiresAt > Date.now()) {
+      return this.cache.flags;
+    }
+    if (!this.loading) {
+      const generation = this.generation;
+      this.loading = (async () => {
+        try {
+          const flags = this.getDefaults();
+          for (const flag of await featureFlagRepository.findAll()) {
+            flags.set(flag.key, flag);
+          }
+          if (generation !== this.generation) {
+            return flags;
+          }
+          this.cache = { flags, expiresAt: Date.now() + config.featureFlags.cacheTtlSeconds * 1000 };
+        } catch (error) {
+          logger.error(`[Flags] Could not load feature flags: ${error.message}`);
+          // Retry on the next call rather than hammering a failing database
+          this.cache = {
+            flags: this.cache?.flags || this.getDefaults(),
+            expiresAt: Date.now() + 5000,
+          };
+        } finally {
+          if (generation === this.generation) {
+            this.loading = null;
+          }
+        }
+        return this.cache.flags;
+      })();
+    }
+    return await this.loading;
+  }
+
+  getDefaults() {
+    return new Map(
+      Object.entries(config.featureFlags.defaults).map(([key, flag]) => [
+        key,
+        {
+          key,
+          enabled: flag?.enabled === true,
+          rolloutPercent: Number.isInteger(flag?.rolloutPercent) ? flag.rolloutPercent : 100,
+          description: flag?.description ?? null,
+        },
+      ])
+    );
+  }
+
+  invalidate() {
+    this.cache = null;
+    this.loading = null;
+    this.generation += 1;
+  }
+}
+
+export default new FeatureFlagService();
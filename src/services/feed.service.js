@@ -0,0 +1,53 @@
+import feedRepository from "../repository/feed.repository.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+
+const MAX_LIMIT = 50;
+
+class FeedService {
+  /**
+   * Activity of the users someone follows: trips they published and trips
+   * they completed (public trips only). Fresh trips departing from the user's
+   * home city are boosted to the top.
+   * @param {string} userId
+   * @param {Object} pagination - { limit, offset }
+   * @returns {Promise<Object>} - { success, data: { items, pagination } }
+   */
+  async getFeed(userId, { limit = 20, offset = 0 } = {}) {
+    try {
+      const pageSize = Math.min(Math.max(parseInt(limit) || 20, 1), MAX_LIMIT);
+      const start = Math.max(parseInt(offset) || 0, 0);
+
+      // One extra row tells whether there is a next page
+      const rows = await feedRepository.findActivity(userId, pageSize + 1, start, {
+        originRadiusKm: config.discovery.originRadiusKm,
+        boostHours: config.discovery.feedBoostHours,
+      });
+      const items = rows.slice(0, pageSize).map((row) => ({
+        type: row.type,
+        occurredAt: new Date(row.occurredAt).toISOString(),
+        actor: { id: row.actorId, name: row.actorName, profilePicture: row.actorPicture },
+        trip: {
+          id: row.groupId,
+          name: row.groupName,
+          destinationName: row.destinationName,
+          originName: row.originName,
+          departsFromHomeCity: row.departsFromHomeCity,
+        },
+      }));
+
+      return {
+        success: true,
+        data: {
+          items,
+          pagination: { limit: pageSize, offset: start, hasMore: rows.length > pageSize },
+        },
+      };
+    } catch (err) {
+      logger.error(`Error building feed for user ${userId}: ${err.message}`);
+      throw err;
+    }
+  }
+}
+
+export default new FeedService();
@@ -0,0 +1,153 @@
+import followerNotificationBatchRepository from "../repository/followerNotificationBatch.repository.js";
+import groupRepository from "../repository/group.repository.js";
+import UserFollowerRepository from "../repository/userFollower.repository.js";
+import jobQueueService from "./jobQueue.service.js";
+import notificationPreferenceService from "./notificationPreference.service.js";
+import { FOLLOWER_BATCH_JOB } from "../jobs/followerNotification.job.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { createAndEmitNotification } from "../socket/notification.emitter.js";
+
+/**
+ * Notifies followers when an organizer publishes trips. Trips published
+ * within the batching window are grouped into one notification and
+ * followers who muted the category are skipped. Quiet hours are handled by
+ * the push service like for any other notification.
+ */
+class FollowerNotificationService {
+  constructor() {
+    this.userFollowerRepository = new UserFollowerRepository();
+  }
+
+  /**
+   * Adds a published trip to the organizer's pending batch, opening a new
+   * batch (and its delivery job) when there is none
+   * @param {string} organizerId
+   * @param {string} groupId
+   */
+  async queueTripPublished(organizerId, groupId) {
+    if (await followerNotificationBatchRepository.appendToPending(organizerId, groupId)) {
+      return;
+    }
+
+    const sendAt = new Date(Date.now() + config.followerNotifications.batchWindowMinutes * 60 * 1000);
+    let batch;
+    try {
+      batch = await followerNotificationBatchRepository.create({
+        organizerId,
+        groupIds: [groupId],
+        sendAt,
+      });
+    } catch (error) {
+      // Another publication opened the batch first (unique pending batch per organizer)
+      if (error.code === "23505" && (await followerNotificationBatchRepository.appendToPending(organizerId, groupId))) {
+        return;
+      }
+      throw error;
+    }
+
+    await jobQueueService.enqueue(FOLLOWER_BATCH_JOB, { batchId: batch.id, afterId: null }, { runAt: sendAt });
+    logger.info(`Follower notification batch ${batch.id} opened for organizer ${organizerId}`);
+  }
+
+  /**
+   * Notifies a page of followers about a batch and queues the next page
+   * @param {string} batchId
+   * @param {string|null} afterId - Last follower of the previous page
+   * @returns {Promise<number>} Followers notified now
+   */
+  async deliverBatch(batchId, afterId = null) {
+    const batch = await followerNotificationBatchRepository.findById(batchId);
+    if (!batch || batch.status === "sent") {
+      return 0;
+    }
+    if (batch.status === "pending") {
+      // Trips published from now on start a new batch
+      await followerNotificationBatchRepository.transition(batchId, "pending", "sending");
+    }
+
+    const groups = await this.getPublishedGroups(batch);
+    if (groups.length === 0) {
+      await followerNotificationBatchRepository.transition(batchId, "sending", "sent");
+      return 0;
+    }
+
+    const pageSize = config.followerNotifications.pageSize;
+    const followerIds = await this.userFollowerRepository.getFollowerIdsBatch(
+      batch.organizerId,
+      pageSize,
+      afterId
+    );
+    const preferences = await notificationPreferenceService.getPreferencesForUsers(followerIds);
+
+    let notified = 0;
+    for (const followerId of followerIds) {
+      if (this.isMuted(preferences.get(followerId))) {
+        continue;
+      }
+      if (await this.notifyFollower(followerId, batch, groups)) {
+        notified++;
+      }
+    }
+
+    await followerNotificationBatchRepository.incrementNotified(batchId, notified);
+
+    if (followerIds.length === pageSize) {
+      await jobQueueService.enqueue(FOLLOWER_BATCH_JOB, {
+        batchId,
+        afterId: followerIds[followerIds.length - 1],
+      });
+    } else {
+      await followerNotificationBatchRepository.transition(batchId, "sending", "sent");
+    }
+
+    logger.info(
+      `Follower batch ${batchId}: ${notified} notified, ${followerIds.length - notified} muted or failed`
+    );
+    return notified;
+  }
+
+  /**
+   * Trips of a batch that are still public
+   */
+  async getPublishedGroups(batch) {
+    const groups = await Promise.all(batch.groupIds.map((groupId) => groupRepository.findById(groupId)));
+    return groups.filter((group) => group && group.isPublic);
+  }
+
+  /**
+   * Followers opt out by disabling every channel of the followed_organizers category
+   */
+  isMuted(preferences) {
+    const category = preferences.categories.followed_organizers;
+    return !category.push && !category.email;
+  }
+
+  async notifyFollower(userId, batch, groups) {
+    const organizerName = groups[0].admin?.name || "Un organizador que sigues";
+    const notification =
+      groups.length === 1
+        ? {
+            type: "TRIP_PUBLISHED",
+            title: "Nuevo viaje publicado",
+            message: `${organizerName} publicó el viaje "${groups[0].name}"`,
+            data: { groupId: groups[0].id, organizerId: batch.organizerId },
+          }
+        : {
+            type: "TRIPS_PUBLISHED",
+            title: "Nuevos viajes publicados",
+            message: `${organizerName} publicó ${groups.length} viajes nuevos`,
+            data: { groupIds: groups.map((group) => group.id), organizerId: batch.organizerId },
+          };
+
+    try {
+      await createAndEmitNotification({ userId, ...notification });
+      return true;
+    } catch (notifError) {
+      logger.error(`Error notifying follower ${userId} of batch ${batch.id}: ${notifError.message}`);
+      return false;
+    }
+  }
+}
+
+export default new FollowerNotificationService();
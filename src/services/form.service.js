@@ -0,0 +1,339 @@
+import formSchemaRepository from "../repository/formSchema.repository.js";
+import formAnswerRepository from "../repository/formAnswer.repository.js";
+import groupRepository from "../repository/group.repository.js";
+import auditService, { AUDIT_ACTIONS } from "./audit.service.js";
+import logger from "../config/logger.js";
+import { AuthorizationError, NotFoundError, ValidationError } from "../utils/customErrors.js";
+import { FORMS, localizeFields, mergeAnswers, validateFieldDefinitions } from "../utils/dynamicForms.js";
+
+// Published schemas are read on every form render and answer, and change rarely
+const SCHEMA_CACHE_TTL_MS = 60 * 1000;
+
+/**
+ * Forms the apps render from the backend (onboarding questionnaire, trip
+ * attributes asked at creation). Admins draft a new version of a form and
+ * publish it; the apps get the latest published version and answers are
+ * stored by field ID, so they outlive the version they were given for.
+ */
+class FormService {
+  constructor() {
+    // formKey -> { schema, expiresAt }
+    this.cache = new Map();
+  }
+
+  /**
+   * Latest published version of a form with its labels in one language.
+   * Version 0 with no fields when nothing was published yet.
+   * @param {string} formKey
+   * @param {string} language
+   * @returns {Promise<Object>} - { formKey, version, language, publishedAt, fields }
+   */
+  async getForm(formKey, language) {
+    this.assertForm(formKey);
+    const schema = await this.getPublishedSchema(formKey);
+    return {
+      formKey,
+      version: schema?.version ?? 0,
+      language,
+      publishedAt: schema?.publishedAt ?? null,
+      fields: localizeFields(schema?.fields ?? [], language),
+    };
+  }
+
+  async getPublishedSchema(formKey) {
+    const cached = this.cache.get(formKey);
+    if (cached && cached.expiresAt > Date.now()) {
+      return cached.schema;
+    }
+    const schema = await formSchemaRepository.findLatestPublished(formKey);
+    this.cache.set(formKey, { schema, expiresAt: Date.now() + SCHEMA_CACHE_TTL_MS });
+    return schema;
+  }
+
+  /**
+   * Every version of a form, newest first, for admins
+   * @param {string} formKey
+   */
+  async listVersions(formKey) {
+    this.assertForm(formKey);
+    const versions = await formSchemaRepository.findAllVersions(formKey);
+    return versions.map((schema) => this.formatVersion(schema));
+  }
+
+  async getVersion(formKey, version) {
+    return this.formatVersion(await this.getVersionOrFail(formKey, version));
+  }
+
+  /**
+   * Drafts the next version of a form
+   * @param {string} adminId
+   * @param {string} formKey
+   * @param {Array} fields - Field definitions, see validateFieldDefinitions
+   * @returns {Promise<Object>} The draft
+   */
+  async createDraft(adminId, formKey, fields) {
+    this.assertForm(formKey);
+    const normalized = validateFieldDefinitions(fields);
+    await this.assertStableFieldTypes(formKey, normalized);
+    const version = (await formSchemaRepository.findMaxVersion(formKey)) + 1;
+    const schema = await formSchemaRepository.create({
+      formKey,
+      version,
+      fields: normalized,
+      createdById: adminId,
+      publishedAt: null,
+    });
+    logger.info(`[Forms] Draft ${formKey} v${version} created by admin ${adminId}`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.FORM_DRAFT_SAVED,
+      actorId: adminId,
+      targetType: "form",
+      targetId: `${formKey}/${version}`,
+      metadata: { fields: normalized.map((field) => field.id) },
+    });
+    return this.formatVersion(schema);
+  }
+
+  /**
+   * Replaces the fields of a draft. Published versions cannot change.
+   */
+  async updateDraft(formKey, version, fields) {
+    const schema = await this.getVersionOrFail(formKey, version);
+    this.assertDraft(schema);
+    const normalized = validateFieldDefinitions(fields);
+    await this.assertStableFieldTypes(formKey, normalized);
+    if (!(await formSchemaRepository.updateDraft(schema.id, { fields: normalized }))) {
+      throw new ValidationError("La versión ya fue publicada y no se puede editar");
+    }
+    await auditService.record({
+      action: AUDIT_ACTIONS.FORM_DRAFT_SAVED,
+      targetType: "form",
+      targetId: `${formKey}/${schema.version}`,
+      metadata: { fields: normalized.map((field) => field.id) },
+    });
+    return this.formatVersion({ ...schema, fields: normalized, updatedAt: new Date() });
+  }
+
+  /**
+   * Publishes a draft: the apps get it from the next request on (within the
+   * cache TTL of other instances)
+   */
+  async publish(formKey, version) {
+    const schema = await this.getVersionOrFail(formKey, version);
+    this.assertDraft(schema);
+    await this.assertStableFieldTypes(formKey, schema.fields);
+    const latest = await formSchemaRepository.findLatestPublished(formKey);
+    if (latest && latest.version > schema.version) {
+      throw new ValidationError(
+        `Ya está publicada la versión ${latest.version}; crea un borrador nuevo a partir de ella`
+      );
+    }
+    const publishedAt = new Date();
+    if (!(await formSchemaRepository.updateDraft(schema.id, { publishedAt }))) {
+      throw new ValidationError("La versión ya fue publicada");
+    }
+    this.cache.delete(formKey);
+    logger.info(`[Forms] ${formKey} v${version} published`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.FORM_PUBLISHED,
+      targetType: "form",
+      targetId: `${formKey}/${schema.version}`,
+    });
+    return this.formatVersion({ ...schema, publishedAt });
+  }
+
+  async deleteDraft(formKey, version) {
+    const schema = await this.getVersionOrFail(formKey, version);
+    this.assertDraft(schema);
+    if (!(await formSchemaRepository.deleteDraft(schema.id))) {
+      throw new ValidationError("La versión ya fue publicada y no se puede eliminar");
+    }
+    logger.info(`[Forms] Draft ${formKey} v${version} deleted`);
+  }
+
+  /**
+   * Answers of the caller to a user form (e.g. onboarding)
+   * @param {string} formKey
+   * @param {string} userId
+   * @param {string} language
+   */
+  async getUserAnswers(formKey, userId, language) {
+    this.assertForm(formKey, "user");
+    return await this.getAnswers(formKey, userId, language);
+  }
+
+  /**
+   * Saves answers of the caller to a user form. Fields left out keep their
+   * answer, null clears it.
+   */
+  async saveUserAnswers(formKey, userId, answers, language) {
+    this.assertForm(formKey, "user");
+    await this.saveAnswers(formKey, userId, answers, userId);
+    return await this.getAnswers(formKey, userId, language);
+  }
+
+  /**
+   * Answers of a trip to a group form (e.g. trip attributes), for its
+   * administrator and members
+   * @param {string} formKey
+   * @param {string} groupId
+   * @param {string} requesterId
+   * @param {string} language
+   */
+  async getGroupAnswers(formKey, groupId, requesterId, language) {
+    this.assertForm(formKey, "group");
+    const group = await this.getGroupOrFail(groupId);
+    const isMember = group.adminId === requesterId || group.members.some((member) => member.id === requesterId);
+    if (!isMember) {
+      throw new AuthorizationError("No eres miembro de este grupo", "NOT_GROUP_MEMBER");
+    }
+    return await this.getAnswers(formKey, groupId, language);
+  }
+
+  /**
+   * Saves answers of a trip to a group form (organizer only)
+   */
+  async saveGroupAnswers(formKey, groupId, requesterId, answers, language) {
+    this.assertForm(formKey, "group");
+    const group = await this.getGroupOrFail(groupId);
+    if (group.adminId !== requesterId) {
+      throw new AuthorizationError("Solo el administrador puede editar los datos del viaje");
+    }
+    await this.saveAnswers(formKey, groupId, answers, requesterId);
+    return await this.getAnswers(formKey, groupId, language);
+  }
+
+  /**
+   * Checks trip creation answers before the group exists, so an invalid
+   * answer does not leave a half-created trip
+   * @param {Object} answers - By field ID
+   * @returns {Promise<Object>} - { schemaVersion, answers }
+   */
+  async prepareTripCreationAnswers(answers) {
+    const schema = await this.getPublishedSchema("trip_creation");
+    return {
+      schemaVersion: schema?.version ?? 0,
+      answers: mergeAnswers(schema?.fields ?? [], {}, answers),
+    };
+  }
+
+  async storeTripCreationAnswers(groupId, adminId, { schemaVersion, answers }) {
+    await formAnswerRepository.upsert({
+      formKey: "trip_creation",
+      subjectId: groupId,
+      schemaVersion,
+      answers,
+      updatedById: adminId,
+    });
+  }
+
+  async getAnswers(formKey, subjectId, language) {
+    const [schema, stored] = await Promise.all([
+      this.getPublishedSchema(formKey),
+      formAnswerRepository.findBySubject(formKey, subjectId),
+    ]);
+    const fields = schema?.fields ?? [];
+    const answers = stored?.answers ?? {};
+    return {
+      formKey,
+      version: schema?.version ?? 0,
+      schemaVersion: stored?.schemaVersion ?? null,
+      answers,
+      // Required fields of the current version without an answer, e.g. added after the user answered
+      missingRequired: fields
+        .filter((field) => field.required && (answers[field.id] === undefined || answers[field.id] === null))
+        .map((field) => field.id),
+      fields: localizeFields(fields, language),
+      updatedAt: stored?.updatedAt ?? null,
+    };
+  }
+
+  async saveAnswers(formKey, subjectId, changes, updatedById) {
+    const schema = await this.getPublishedSchema(formKey);
+    const stored = await formAnswerRepository.findBySubject(formKey, subjectId);
+    const answers = mergeAnswers(schema?.fields ?? [], stored?.answers ?? {}, changes);
+    await formAnswerRepository.upsert({
+      formKey,
+      subjectId,
+      schemaVersion: schema?.version ?? 0,
+      answers,
+      updatedById,
+    });
+    logger.info(`[Forms] ${formKey} answers of ${subjectId} saved against v${schema?.version ?? 0}`);
+  }
+
+  /**
+   * Answers are keyed by field ID, so a field cannot change its type once a
+   * version with it was published: the stored answers would not fit
+   */
+  async assertStableFieldTypes(formKey, fields) {
+    const published = await formSchemaRepository.findPublished(formKey);
+    const types = new Map();
+    for (const schema of published) {
+      for (const field of schema.fields) {
+        types.set(field.id, field.type);
+      }
+    }
+    for (const field of fields) {
+      const previous = types.get(field.id);
+      if (previous && previous !== field.type) {
+        throw new ValidationError(
+          `El campo ${field.id} ya se publicó como ${previous}; usa otro ID para un campo ${field.type}`
+        );
+      }
+    }
+  }
+
+  assertForm(formKey, subjectType) {
+    const form = FORMS[formKey];
+    if (!form) {
+      throw new NotFoundError("Formulario no encontrado");
+    }
+    if (subjectType && form.subjectType !== subjectType) {
+      throw new ValidationError(
+        form.subjectType === "group"
+          ? "Las respuestas de este formulario son de un viaje: usa /groups/{groupId}/forms/{formKey}/answers"
+          : "Las respuestas de este formulario son de cada usuario: usa /forms/{formKey}/answers"
+      );
+    }
+  }
+
+  assertDraft(schema) {
+    if (schema.publishedAt) {
+      throw new ValidationError("La versión ya fue publicada y no se puede modificar");
+    }
+  }
+
+  async getVersionOrFail(formKey, version) {
+    this.assertForm(formKey);
+    const number = Number(version);
+    const schema = Number.isInteger(number) ? await formSchemaRepository.findVersion(formKey, number) : null;
+    if (!schema) {
+      throw new NotFoundError("Versión del formulario no encontrada");
+    }
+    return schema;
+  }
+
+  async getGroupOrFail(groupId) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      throw new NotFoundError("Grupo no encontrado", "GROUP_NOT_FOUND");
+    }
+    return group;
+  }
+
+  formatVersion(schema) {
+    return {
+      formKey: schema.formKey,
+      version: schema.version,
+      status: schema.publishedAt ? "published" : "draft",
+      fields: schema.fields,
+      createdById: schema.createdById,
+      publishedAt: schema.publishedAt,
+      createdAt: schema.createdAt,
+      updatedAt: schema.updatedAt,
+    };
+  }
+}
+
+export default new FormService();
@@ -2,6 +2,8 @@ import { AppDataSource } from "../load/typeorm.loader.js";
 import { LEVELS_DATA, BADGES_DATA, POINTS_DATA } from "../load/seed.loader.js";
 import logger from "../config/logger.js";
 import emailService from "./email.service.js";
+import notificationPreferenceService from "./notificationPreference.service.js";
+import { createAndEmitNotification } from "../socket/notification.emitter.js";
 
 class GamificationService {
   constructor() {
@@ -29,7 +31,7 @@ class GamificationService {
       });
 
       if (!user) {
-        throw { status: 404, message: "Usuario no encontrado" };
+        throw { status: 404, message: "Usuario no encontrado", errorCode: "USER_NOT_FOUND" };
       }
 
       // Get next level info
@@ -113,7 +115,7 @@ class GamificationService {
        });
 
        if (!user) {
-         throw { status: 404, message: "Usuario no encontrado" };
+         throw { status: 404, message: "Usuario no encontrado", errorCode: "USER_NOT_FOUND" };
        }
 
        // Calculate points to award
@@ -475,7 +477,7 @@ class GamificationService {
   async awardBadge(queryRunner, userId, badge) {
     const user = await queryRunner.manager.findOne("User", {
       where: { id: userId },
-      select: ['badges', 'email']
+      select: ['badges', 'email', 'locale']
     });
 
     const newBadge = {
@@ -490,12 +492,34 @@ class GamificationService {
       badges: updatedBadges
     });
 
+    // Add the badge to the in-app feed (also triggers push) without blocking the transaction
+    setImmediate(() => {
+      createAndEmitNotification({
+        userId,
+        type: "BADGE_EARNED",
+        title: "¡Nueva insignia!",
+        message: `Has ganado la insignia "${badge.name}"`,
+        data: { badgeName: badge.name },
+      }).catch((notificationError) => {
+        logger.error(`Failed to create badge notification for user ${userId}:`, notificationError);
+      });
+    });
+
     // Send email notification asynchronously (don't block the transaction)
     try {
       if (user && user.email) {
         setImmediate(async () => {
           try {
-            await emailService.sendBadgeNotification(user.email, badge);
+            const emailEnabled = await notificationPreferenceService.isChannelEnabled(
+              userId,
+              "BADGE_EARNED",
+              "email"
+            );
+            if (!emailEnabled) {
+              logger.info(`Badge email skipped for user ${userId}: disabled by preferences`);
+              return;
+            }
+            await emailService.sendBadgeNotification(user.email, badge, user.locale);
             logger.info(`Badge notification sent to user ${userId} for badge: ${badge.name}`);
           } catch (emailError) {
             logger.error(`Failed to send badge notification email to user ${userId}:`, emailError);
@@ -539,7 +563,7 @@ class GamificationService {
       });
 
       if (!user) {
-        throw { status: 404, message: "Usuario no encontrado" };
+        throw { status: 404, message: "Usuario no encontrado", errorCode: "USER_NOT_FOUND" };
       }
 
       const milestones = [];
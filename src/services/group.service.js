@@ -1,8 +1,26 @@
 import groupRepository from "../repository/group.repository.js";
+import groupMessageRepository from "../repository/groupMessage.repository.js";
 import itineraryRepository from "../repository/itinerary.repository.js";
 import UserRepository from "../repository/user.repository.js";
+import geocodingClient from "../clients/geocoding.client.js";
+import searchService from "./search.service.js";
+import badgeService from "./badge.service.js";
+import followerNotificationService from "./followerNotification.service.js";
+import formService from "./form.service.js";
+import domainEventService, { DOMAIN_EVENTS } from "./domainEvent.service.js";
+import bookingTermsService from "./bookingTerms.service.js";
+import auditService, { AUDIT_ACTIONS } from "./audit.service.js";
+import txManager from "../repository/transactionManager.js";
+import config from "../config/index.js";
 import logger from "../config/logger.js";
 import { createAndEmitNotification } from "../socket/notification.emitter.js";
+import { GROUP_SIZE_NAMES, getSizeForCapacity } from "../utils/groupSize.js";
+import { isAgeRestricted } from "../utils/ageVerification.js";
+
+// Trip length buckets, by days from the first to the last itinerary day
+export const DURATION_BUCKETS = ["weekend", "week", "long"];
+
+const CANCELLATION_POLICY_MAX_LENGTH = 5000;
 
 class GroupService {
   constructor() {
@@ -10,10 +28,26 @@ class GroupService {
   }
   /**
    * Creates a new group and assigns the creator as admin and member
-   * @param {Object} data - { name, description, adminId }
+   * @param {Object} data - { name, description, capacity, expectedSize, destinationName, destinationLat,
+   *   destinationLng, originName, originLat, originLng, isPublic, adminId, formAnswers }.
+   *   formAnswers are the answers to the trip_creation form, by field ID
    * @returns {Promise<Object>} - { success, data, message }
    */
-  async createGroup({ name, description, adminId }) {
+  async createGroup({
+    name,
+    description,
+    capacity = null,
+    expectedSize = null,
+    destinationName,
+    destinationLat,
+    destinationLng,
+    originName,
+    originLat,
+    originLng,
+    isPublic = false,
+    adminId,
+    formAnswers = null,
+  }) {
     try {
       if (!name || name.length < 5 || name.length > 50) {
         const error = new Error(
@@ -22,6 +56,16 @@ class GroupService {
         error.status = 400;
         throw error;
       }
+      if (
+        capacity !== null &&
+        capacity !== undefined &&
+        (!Number.isInteger(capacity) || capacity < 2)
+      ) {
+        const error = new Error("La capacidad debe ser un número entero mayor o igual a 2.");
+        error.status = 400;
+        throw error;
+      }
+      this.validateExpectedSize(expectedSize, capacity ?? null);
       if (!adminId) {
         const error = new Error("El ID del administrador es requerido.");
         error.status = 400;
@@ -34,11 +78,45 @@ class GroupService {
         error.status = 409;
         throw error;
       }
-      // Create group
-      const group = await groupRepository.create({
-        name: name.trim(),
-        description: description ? description.trim() : null,
-        adminId,
+      if (typeof isPublic !== "boolean") {
+        const error = new Error("isPublic debe ser booleano.");
+        error.status = 400;
+        throw error;
+      }
+      const destination = await this.resolveDestination({
+        destinationName,
+        destinationLat,
+        destinationLng,
+      });
+      const origin = await this.resolveOrigin({ originName, originLat, originLng });
+      // Apps released before the form existed send no answers
+      const tripAnswers =
+        formAnswers !== null && formAnswers !== undefined
+          ? await formService.prepareTripCreationAnswers(formAnswers)
+          : null;
+      // Indexing and follower notifications are handled on TripCreated
+      const group = await txManager.run(async () => {
+        const group = await groupRepository.create({
+          name: name.trim(),
+          description: description ? description.trim() : null,
+          capacity: capacity ?? null,
+          expectedSize: expectedSize ?? null,
+          ...destination,
+          ...origin,
+          isPublic,
+          publishedAt: isPublic ? new Date() : null,
+          adminId,
+        });
+        if (tripAnswers) {
+          await formService.storeTripCreationAnswers(group.id, adminId, tripAnswers);
+        }
+        await domainEventService.emit(DOMAIN_EVENTS.TRIP_CREATED, group.id, {
+          groupId: group.id,
+          adminId,
+          isPublic,
+          destinationName: group.destinationName,
+        });
+        return group;
       });
       logger.info(`Group created: ${group.id}`);
       return {
@@ -53,16 +131,447 @@ class GroupService {
   }
 
   /**
-   * Gets all groups for a user
+   * Validates the destination of a group. When only the name is given the
+   * coordinates are geocoded; a geocoding failure leaves them empty.
+   * @param {Object} data - { destinationName, destinationLat, destinationLng }
+   * @returns {Promise<Object>} Destination columns to persist
+   */
+  async resolveDestination({ destinationName, destinationLat, destinationLng }) {
+    const place = await this.resolvePlace(destinationName, destinationLat, destinationLng, "destino");
+    if (!place) {
+      return {};
+    }
+    return {
+      destinationName: place.name,
+      destinationLat: place.lat,
+      destinationLng: place.lng,
+    };
+  }
+
+  /**
+   * Checks a declared group size; it cannot be smaller than the capacity allows
+   * (a "small" trip with room for 10)
+   * @param {string|null} expectedSize
+   * @param {number|null} capacity
+   */
+  validateExpectedSize(expectedSize, capacity) {
+    if (expectedSize === null || expectedSize === undefined) {
+      return;
+    }
+    if (!GROUP_SIZE_NAMES.includes(expectedSize)) {
+      const error = new Error(`El tamaño del grupo debe ser uno de: ${GROUP_SIZE_NAMES.join(", ")}.`);
+      error.status = 400;
+      throw error;
+    }
+    const capacitySize = getSizeForCapacity(capacity);
+    if (capacitySize && GROUP_SIZE_NAMES.indexOf(capacitySize) > GROUP_SIZE_NAMES.indexOf(expectedSize)) {
+      const error = new Error("La capacidad del grupo no coincide con el tamaño indicado.");
+      error.status = 400;
+      throw error;
+    }
+  }
+
+  /**
+   * Group sizes a user prefers (travel preferences), null when not set
+   * @param {string|null} userId
+   * @returns {Promise<string[]|null>}
+   */
+  async getPreferredSizes(userId) {
+    if (!userId) {
+      return null;
+    }
+    const user = await this.userRepository.findById(userId);
+    const sizes = user?.travelPreferences?.groupSizes;
+    return sizes?.length ? sizes : null;
+  }
+
+  /**
+   * @param {string|null} size - Size filter from the query string
+   * @returns {string|null}
+   */
+  validateSizeFilter(size) {
+    if (size !== null && !GROUP_SIZE_NAMES.includes(size)) {
+      const error = new Error(`El tamaño debe ser uno de: ${GROUP_SIZE_NAMES.join(", ")}.`);
+      error.status = 400;
+      throw error;
+    }
+    return size;
+  }
+
+  /**
+   * Validates the departure city of a group, geocoded like the destination
+   * @param {Object} data - { originName, originLat, originLng }
+   * @returns {Promise<Object>} Origin columns to persist
+   */
+  async resolveOrigin({ originName, originLat, originLng }) {
+    const place = await this.resolvePlace(originName, originLat, originLng, "origen");
+    if (!place) {
+      return {};
+    }
+    return {
+      originName: place.name,
+      originLat: place.lat,
+      originLng: place.lng,
+    };
+  }
+
+  /**
+   * @param {string} label - Place in error messages ("destino" | "origen")
+   * @returns {Promise<Object|null>} - { name, lat, lng } or null when nothing was given
+   */
+  async resolvePlace(name, latValue, lngValue, label) {
+    if (!name && latValue == null && lngValue == null) {
+      return null;
+    }
+    if (name && (typeof name !== "string" || name.trim().length > 150)) {
+      const error = new Error(`El ${label} debe ser un texto de hasta 150 caracteres.`);
+      error.status = 400;
+      throw error;
+    }
+
+    const hasLat = latValue !== undefined && latValue !== null;
+    const hasLng = lngValue !== undefined && lngValue !== null;
+    if (hasLat !== hasLng) {
+      const error = new Error(`Se deben indicar latitud y longitud del ${label}.`);
+      error.status = 400;
+      throw error;
+    }
+
+    if (hasLat) {
+      const lat = Number(latValue);
+      const lng = Number(lngValue);
+      if (!Number.isFinite(lat) || lat < -90 || lat > 90 || !Number.isFinite(lng) || lng < -180 || lng > 180) {
+        const error = new Error(`Coordenadas del ${label} inválidas.`);
+        error.status = 400;
+        throw error;
+      }
+      return { name: name ? name.trim() : null, lat, lng };
+    }
+
+    const location = await geocodingClient.tryGeocode(name);
+    return {
+      name: name.trim(),
+      lat: location ? location.lat : null,
+      lng: location ? location.lng : null,
+    };
+  }
+
+  /**
+   * Updates the editable details of a group (admin only)
+   * @param {string} groupId
+   * @param {Object} data - { description, expectedSize, destinationName, destinationLat, destinationLng,
+   *   originName, originLat, originLng, isPublic, cancellationPolicy }
+   * @param {string} requesterId
+   * @returns {Promise<Object>} - { success, data, message }
+   */
+  async updateGroup(groupId, data, requesterId) {
+    try {
+      const group = await groupRepository.findById(groupId);
+      if (!group) {
+        const error = new Error("Grupo no encontrado");
+        error.status = 404;
+        error.errorCode = "GROUP_NOT_FOUND";
+        throw error;
+      }
+      if (group.adminId !== requesterId) {
+        const error = new Error("Solo el administrador puede editar el grupo");
+        error.status = 403;
+        throw error;
+      }
+
+      const update = {};
+      if (data.description !== undefined) {
+        update.description = data.description ? String(data.description).trim() : null;
+      }
+      if (data.cancellationPolicy !== undefined) {
+        const policy = data.cancellationPolicy ? String(data.cancellationPolicy).trim() : null;
+        if (policy && policy.length > CANCELLATION_POLICY_MAX_LENGTH) {
+          const error = new Error(
+            `La política de cancelación no puede superar los ${CANCELLATION_POLICY_MAX_LENGTH} caracteres.`
+          );
+          error.status = 400;
+          throw error;
+        }
+        update.cancellationPolicy = policy || null;
+      }
+      if (data.isPublic !== undefined) {
+        if (typeof data.isPublic !== "boolean") {
+          const error = new Error("isPublic debe ser booleano.");
+          error.status = 400;
+          throw error;
+        }
+        update.isPublic = data.isPublic;
+        // Publishing or hiding the trip by hand replaces any scheduled launch
+        update.publishAt = null;
+        if (data.isPublic && !group.publishedAt) {
+          update.publishedAt = new Date();
+        }
+      }
+      if (data.depositAmount !== undefined) {
+        Object.assign(update, this.resolveDeposit(data));
+      }
+      if (data.expectedSize !== undefined) {
+        this.validateExpectedSize(data.expectedSize, group.capacity ?? null);
+        update.expectedSize = data.expectedSize;
+      }
+      if (
+        data.destinationName !== undefined ||
+        data.destinationLat !== undefined ||
+        data.destinationLng !== undefined
+      ) {
+        const destination = await this.resolveDestination(data);
+        update.destinationName = destination.destinationName ?? null;
+        update.destinationLat = destination.destinationLat ?? null;
+        update.destinationLng = destination.destinationLng ?? null;
+      }
+      if (data.originName !== undefined || data.originLat !== undefined || data.originLng !== undefined) {
+        const origin = await this.resolveOrigin(data);
+        update.originName = origin.originName ?? null;
+        update.originLat = origin.originLat ?? null;
+        update.originLng = origin.originLng ?? null;
+      }
+
+      const updatedGroup = await groupRepository.update(groupId, update);
+      await searchService.scheduleIndex("trip", groupId);
+      // Followers hear about a trip only the first time it goes public
+      if (update.publishedAt) {
+        await this.notifyFollowers(updatedGroup);
+      }
+      return {
+        success: true,
+        data: updatedGroup,
+        message: "Grupo actualizado exitosamente",
+      };
+    } catch (err) {
+      logger.error(`Error updating group ${groupId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Queues the notification of the organizer's followers about a published trip
+   * @param {Object} group
+   */
+  async notifyFollowers(group) {
+    try {
+      await followerNotificationService.queueTripPublished(group.adminId, group.id);
+    } catch (error) {
+      logger.error(`Failed to queue follower notifications for group ${group.id}: ${error.message}`);
+    }
+  }
+
+  /**
+   * Validates a deposit change; null removes the deposit
+   * @param {Object} data - { depositAmount (decimal), depositCurrency }
+   * @returns {Object} - { depositAmount (cents), depositCurrency }
+   */
+  resolveDeposit({ depositAmount, depositCurrency }) {
+    if (depositAmount === null) {
+      return { depositAmount: null, depositCurrency: null };
+    }
+    const cents = Math.round(Number(depositAmount) * 100);
+    if (!Number.isFinite(cents) || cents < 100 || cents > 99999999) {
+      const error = new Error("La seña debe estar entre 1.00 y 999,999.99.");
+      error.status = 400;
+      throw error;
+    }
+    const currency = String(depositCurrency || config.expenses.defaultCurrency).toUpperCase();
+    if (!/^[A-Z]{3}$/.test(currency)) {
+      const error = new Error("Moneda inválida.");
+      error.status = 400;
+      throw error;
+    }
+    return { depositAmount: cents, depositCurrency: currency };
+  }
+
+  /**
+   * Finds public groups whose destination is within a radius, closest first.
+   * Groups of the viewer's preferred sizes get a ranking bonus, others a penalty.
+   * @param {Object} params - { lat, lng, radiusKm, limit, size, viewerId }
+   * @returns {Promise<Object>} - { success, data }
+   */
+  async findNearbyGroups({ lat, lng, radiusKm = 50, limit = 20, size = null, viewerId = null }) {
+    try {
+      if (!Number.isFinite(lat) || lat < -90 || lat > 90 || !Number.isFinite(lng) || lng < -180 || lng > 180) {
+        const error = new Error("Coordenadas inválidas.");
+        error.status = 400;
+        throw error;
+      }
+      if (!Number.isFinite(radiusKm) || radiusKm <= 0 || radiusKm > 500) {
+        const error = new Error("El radio debe estar entre 0 y 500 km.");
+        error.status = 400;
+        throw error;
+      }
+
+      this.validateSizeFilter(size);
+
+      const groups = await groupRepository.findNearby(
+        lat,
+        lng,
+        radiusKm,
+        Math.min(limit, 100),
+        viewerId,
+        { size, preferredSizes: await this.getPreferredSizes(viewerId) }
+      );
+      return {
+        success: true,
+        data: groups,
+      };
+    } catch (err) {
+      logger.error(`Error finding nearby groups: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Finds public groups departing near a location, soonest trips first
+   * @param {Object} params - { lat, lng, radiusKm, limit, offset, size, viewerId }
+   * @returns {Promise<Object>} - { success, data }
+   */
+  async findGroupsDepartingNear({
+    lat,
+    lng,
+    radiusKm = config.discovery.originRadiusKm,
+    limit = 20,
+    offset = 0,
+    size = null,
+    viewerId = null,
+  }) {
+    try {
+      if (!Number.isFinite(lat) || lat < -90 || lat > 90 || !Number.isFinite(lng) || lng < -180 || lng > 180) {
+        const error = new Error("Coordenadas inválidas.");
+        error.status = 400;
+        throw error;
+      }
+      if (!Number.isFinite(radiusKm) || radiusKm <= 0 || radiusKm > 500) {
+        const error = new Error("El radio debe estar entre 0 y 500 km.");
+        error.status = 400;
+        throw error;
+      }
+
+      this.validateSizeFilter(size);
+
+      const groups = await groupRepository.findDepartingNear(
+        lat,
+        lng,
+        radiusKm,
+        Math.min(Math.max(limit, 1), 100),
+        Math.max(offset, 0),
+        viewerId,
+        { size, preferredSizes: await this.getPreferredSizes(viewerId) }
+      );
+      return {
+        success: true,
+        data: groups,
+      };
+    } catch (err) {
+      logger.error(`Error finding groups departing near a location: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Public groups departing from the home city of a user
+   * @param {string} userId
+   * @param {Object} params - { limit, offset, size }
+   * @returns {Promise<Object>} - { success, data: { homeCity, groups } }
+   */
+  async findGroupsFromHomeCity(userId, { limit = 20, offset = 0, size = null } = {}) {
+    try {
+      const user = await this.userRepository.findById(userId);
+      if (!user) {
+        const error = new Error("Usuario no encontrado");
+        error.status = 404;
+        error.errorCode = "USER_NOT_FOUND";
+        throw error;
+      }
+      if (!user.homeCity) {
+        const error = new Error("Indica tu ciudad en el perfil para ver viajes que salen desde ahí.");
+        error.status = 400;
+        throw error;
+      }
+      // The city could not be geocoded: nothing to compare trip origins against
+      if (user.homeLat === null || user.homeLng === null) {
+        return { success: true, data: { homeCity: user.homeCity, groups: [] } };
+      }
+
+      const result = await this.findGroupsDepartingNear({
+        lat: Number(user.homeLat),
+        lng: Number(user.homeLng),
+        limit,
+        offset,
+        size,
+        viewerId: userId,
+      });
+      return {
+        success: true,
+        data: { homeCity: user.homeCity, groups: result.data },
+      };
+    } catch (err) {
+      logger.error(`Error finding groups from the home city of user ${userId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Browses upcoming public groups by month of travel, trip length and/or size,
+   * soonest first. Month and length come precomputed from the assigned itinerary.
+   * Groups of the viewer's preferred sizes are listed first.
+   * @param {Object} params - { month, duration, size, limit, offset, viewerId }
+   * @returns {Promise<Object>} - { success, data }
+   */
+  async browseGroups({ month = null, duration = null, size = null, limit = 20, offset = 0, viewerId = null }) {
+    try {
+      if (month !== null && (!Number.isInteger(month) || month < 1 || month > 12)) {
+        const error = new Error("El mes debe estar entre 1 y 12.");
+        error.status = 400;
+        throw error;
+      }
+      if (duration !== null && !DURATION_BUCKETS.includes(duration)) {
+        const error = new Error(`La duración debe ser una de: ${DURATION_BUCKETS.join(", ")}.`);
+        error.status = 400;
+        throw error;
+      }
+
+      this.validateSizeFilter(size);
+
+      const groups = await groupRepository.findByTravelDimensions({
+        month,
+        durationBucket: duration,
+        size,
+        preferredSizes: await this.getPreferredSizes(viewerId),
+        viewerId,
+        limit: Math.min(Math.max(limit, 1), 100),
+        offset: Math.max(offset, 0),
+      });
+      return {
+        success: true,
+        data: groups,
+      };
+    } catch (err) {
+      logger.error(`Error browsing groups: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Gets all groups for a user, each with the user's unread message count
    * @param {string} userId
    * @returns {Promise<Object>} - { success, data }
    */
   async getUserGroups(userId) {
     try {
       const groups = await groupRepository.findByUserId(userId);
+      const unreadCounts = await groupMessageRepository.countUnreadByGroupIds(
+        groups.map((group) => group.id),
+        userId
+      );
       return {
         success: true,
-        data: groups,
+        data: groups.map((group) => ({
+          ...group,
+          unreadCount: unreadCounts[group.id] || 0,
+        })),
       };
     } catch (err) {
       logger.error(`Error getting groups for user ${userId}: ${err.message}`);
@@ -81,6 +590,7 @@ class GroupService {
       if (!group) {
         const error = new Error("Grupo no encontrado");
         error.status = 404;
+        error.errorCode = "GROUP_NOT_FOUND";
         throw error;
       }
       return {
@@ -106,6 +616,7 @@ class GroupService {
       if (!group) {
         const error = new Error("Grupo no encontrado");
         error.status = 404;
+        error.errorCode = "GROUP_NOT_FOUND";
         throw error;
       }
       if (group.adminId !== requesterId) {
@@ -113,7 +624,39 @@ class GroupService {
         error.status = 403;
         throw error;
       }
-      const updatedGroup = await groupRepository.addMembers(groupId, userIds);
+      for (const userId of userIds) {
+        const user = await this.userRepository.findById(userId);
+        if (user && isAgeRestricted(user)) {
+          const error = new Error("Uno de los usuarios no puede unirse a grupos por su edad");
+          error.status = 403;
+          throw error;
+        }
+      }
+      if (group.capacity) {
+        const newMembers = new Set(
+          userIds.filter((id) => !group.members.some((m) => m.id === id))
+        );
+        if (group.members.length + newMembers.size > group.capacity) {
+          const error = new Error("El grupo no tiene lugar para todos los miembros");
+          error.status = 409;
+          throw error;
+        }
+      }
+      const joinedIds = [...new Set(userIds)].filter((id) => !group.members.some((m) => m.id === id));
+      const updatedGroup = await txManager.run(async () => {
+        const updated = await groupRepository.addMembers(groupId, userIds);
+        for (const userId of joinedIds) {
+          await domainEventService.emit(DOMAIN_EVENTS.MEMBER_JOINED, groupId, {
+            groupId,
+            userId,
+            addedById: requesterId,
+            via: "added",
+          });
+          // Added members did not book, but they join the trip as it is now
+          await bookingTermsService.capture(group, userId);
+        }
+        return updated;
+      });
 
       // Send notifications to newly added members
       try {
@@ -178,6 +721,7 @@ class GroupService {
       if (!group) {
         const error = new Error("Grupo no encontrado");
         error.status = 404;
+        error.errorCode = "GROUP_NOT_FOUND";
         throw error;
       }
       if (group.adminId !== requesterId) {
@@ -195,6 +739,7 @@ class GroupService {
         throw error;
       }
       const updatedGroup = await groupRepository.removeMember(groupId, userId);
+      badgeService.invalidate(userId);
       return {
         success: true,
         data: updatedGroup,
@@ -209,7 +754,7 @@ class GroupService {
   }
 
   /**
-   * Deletes a group
+   * Deletes a group. It is soft-deleted, so an admin can still restore it
    * @param {string} groupId
    * @param {string} requesterId
    * @returns {Promise<Object>} - { success, data, message }
@@ -220,6 +765,7 @@ class GroupService {
       if (!group) {
         const error = new Error("Grupo no encontrado");
         error.status = 404;
+        error.errorCode = "GROUP_NOT_FOUND";
         throw error;
       }
       if (group.adminId !== requesterId) {
@@ -229,11 +775,18 @@ class GroupService {
         error.status = 403;
         throw error;
       }
-      const deleted = await groupRepository.delete(groupId);
+      await groupRepository.softDelete(groupId);
       logger.info(`Group deleted: ${groupId}`);
+      await auditService.record({
+        action: AUDIT_ACTIONS.TRIP_DELETED,
+        actorId: requesterId,
+        targetType: "trip",
+        targetId: groupId,
+      });
+      await searchService.scheduleIndex("trip", groupId);
       return {
         success: true,
-        data: deleted,
+        data: group,
         message: "Grupo eliminado exitosamente",
       };
     } catch (err) {
@@ -256,6 +809,7 @@ class GroupService {
       if (!group) {
         const error = new Error("Grupo no encontrado");
         error.status = 404;
+        error.errorCode = "GROUP_NOT_FOUND";
         throw error;
       }
 
@@ -360,6 +914,7 @@ class GroupService {
       if (!group) {
         const error = new Error("Grupo no encontrado");
         error.status = 404;
+        error.errorCode = "GROUP_NOT_FOUND";
         throw error;
       }
 
@@ -0,0 +1,344 @@
+import groupRepository from "../repository/group.repository.js";
+import groupCapacityRepository from "../repository/groupCapacity.repository.js";
+import groupJoinRequestRepository from "../repository/groupJoinRequest.repository.js";
+import txManager from "../repository/transactionManager.js";
+import groupJoinRequestService from "./groupJoinRequest.service.js";
+import jobQueueService from "./jobQueue.service.js";
+import { MEMBER_REFUND_JOB } from "../jobs/memberRefund.job.js";
+import logger from "../config/logger.js";
+import { createAndEmitNotification } from "../socket/notification.emitter.js";
+
+/**
+ * How members losing their spot are chosen when capacity goes down:
+ * - join_order: last to join leaves first
+ * - tier: lowest tier leaves first, last to join within the same tier
+ * - manual: the organizer picks them (memberIds)
+ */
+export const CAPACITY_STRATEGIES = ["join_order", "tier", "manual"];
+
+const MAX_TIER = 100;
+
+const joinedAtMs = (member) => (member.joinedAt ? new Date(member.joinedAt).getTime() : 0);
+
+class GroupCapacityService {
+  /**
+   * Shows what a capacity change would do without applying it
+   * @param {string} groupId
+   * @param {string} requesterId
+   * @param {Object} data - { capacity, strategy, memberIds }
+   * @returns {Promise<Object>} - { success, data }
+   */
+  async previewCapacityChange(groupId, requesterId, data) {
+    try {
+      const group = await this.getGroupAsAdmin(groupId, requesterId);
+      const plan = await this.planChange(group, data);
+
+      return {
+        success: true,
+        data: {
+          previousCapacity: plan.previousCapacity,
+          newCapacity: plan.newCapacity,
+          strategy: plan.strategy,
+          membersToRemove: plan.removed.map((member) => this.formatMember(member)),
+          waitlistToPromote: plan.promoted.map((request) => this.formatWaitlisted(request)),
+        },
+      };
+    } catch (err) {
+      logger.error(`Error previewing capacity change of group ${groupId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Changes the capacity of a group (admin only). Reducing it removes the
+   * members chosen by the strategy, queues their refunds and (optionally) puts
+   * them on the waitlist; increasing it promotes waitlisted requests in order.
+   * Everyone affected is notified and the change is recorded.
+   * The whole change runs under the group lock join request decisions take,
+   * so neither can admit into seats the other is giving away.
+   * @param {string} groupId
+   * @param {string} requesterId
+   * @param {Object} data - { capacity, strategy, memberIds, reason, waitlistRemoved }
+   * @returns {Promise<Object>} - { success, data, message }
+   */
+  async changeCapacity(groupId, requesterId, { reason = null, waitlistRemoved = true, ...data }) {
+    try {
+      if (reason !== null && (typeof reason !== "string" || reason.length > 500)) {
+        const error = new Error("El motivo debe ser un texto de hasta 500 caracteres");
+        error.status = 400;
+        throw error;
+      }
+
+      const { plan, change, removedMembers, promotedMembers } = await txManager.run(async () => {
+        await groupRepository.lockForUpdate(groupId);
+        // Read after the lock so the plan sees seats taken meanwhile
+        const group = await this.getGroupAsAdmin(groupId, requesterId);
+        const plan = await this.planChange(group, data);
+
+        const removedMembers = [];
+        for (const member of plan.removed) {
+          await groupRepository.removeMember(groupId, member.userId);
+          // Refunds call the payment provider, so they run once this commits
+          const booking = await groupJoinRequestRepository.findLatestApproved(groupId, member.userId);
+          await jobQueueService.enqueue(MEMBER_REFUND_JOB, {
+            groupId,
+            userId: member.userId,
+            bookingId: booking?.id ?? null,
+          });
+          if (waitlistRemoved) {
+            await groupJoinRequestRepository.create({
+              groupId,
+              userId: member.userId,
+              status: "waitlisted",
+              decidedById: requesterId,
+              decidedAt: new Date(),
+            });
+          }
+          removedMembers.push({
+            userId: member.userId,
+            waitlisted: Boolean(waitlistRemoved),
+            refund: { status: "queued", amount: null },
+          });
+          await this.notify(member.userId, {
+            type: "GROUP_SPOT_REMOVED",
+            title: "Perdiste tu lugar en el grupo",
+            message: `El organizador redujo el cupo de "${group.name}"${
+              waitlistRemoved ? ". Quedaste en la lista de espera por si se libera un lugar" : ""
+            }. Te devolveremos la seña que hayas pagado`,
+            data: { groupId, groupName: group.name },
+          });
+        }
+
+        await groupRepository.update(groupId, { capacity: plan.newCapacity });
+
+        const promotedMembers = [];
+        for (const request of plan.promoted) {
+          // Requesters who still have to accept the trip rules keep waiting for that
+          const updated = await groupJoinRequestService.admit(group, request, requesterId, "promoted");
+          if (updated.status === "approved") {
+            promotedMembers.push(request.userId);
+          }
+        }
+
+        const change = await groupCapacityRepository.createChange({
+          groupId,
+          changedById: requesterId,
+          previousCapacity: plan.previousCapacity,
+          newCapacity: plan.newCapacity,
+          strategy: plan.removed.length > 0 ? plan.strategy : null,
+          reason: reason ? reason.trim() : null,
+          removedMembers,
+          promotedMembers,
+        });
+
+        // Members that kept their spot also hear about the new capacity
+        const affected = new Set([...removedMembers.map((m) => m.userId), ...promotedMembers]);
+        for (const member of group.members) {
+          if (member.id === requesterId || affected.has(member.id)) {
+            continue;
+          }
+          await this.notify(member.id, {
+            type: "GROUP_CAPACITY_CHANGED",
+            title: "Cambió el cupo del grupo",
+            message: `El grupo "${group.name}" ahora tiene ${
+              plan.newCapacity ? `cupo de ${plan.newCapacity} personas` : "cupo ilimitado"
+            }`,
+            data: { groupId, groupName: group.name, capacity: plan.newCapacity },
+          });
+        }
+        return { plan, change, removedMembers, promotedMembers };
+      });
+      logger.info(
+        `Group ${groupId} capacity changed ${plan.previousCapacity} -> ${plan.newCapacity} (${removedMembers.length} removed, ${promotedMembers.length} promoted)`
+      );
+      return {
+        success: true,
+        data: this.formatChange(change),
+        message: "Cupo actualizado",
+      };
+    } catch (err) {
+      logger.error(`Error changing capacity of group ${groupId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Lists the capacity changes of a group (admin only)
+   */
+  async getCapacityChanges(groupId, requesterId) {
+    try {
+      await this.getGroupAsAdmin(groupId, requesterId);
+      const changes = await groupCapacityRepository.findChanges(groupId);
+      return {
+        success: true,
+        data: changes.map((change) => this.formatChange(change)),
+      };
+    } catch (err) {
+      logger.error(`Error getting capacity changes of group ${groupId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Sets the tier of a member (admin only); higher tiers keep their spot
+   */
+  async setMemberTier(groupId, userId, tier, requesterId) {
+    try {
+      const group = await this.getGroupAsAdmin(groupId, requesterId);
+      if (!Number.isInteger(tier) || tier < 0 || tier > MAX_TIER) {
+        const error = new Error(`El nivel debe ser un entero entre 0 y ${MAX_TIER}`);
+        error.status = 400;
+        throw error;
+      }
+      if (!group.members.some((member) => member.id === userId)) {
+        const error = new Error("El usuario no es miembro del grupo");
+        error.status = 404;
+        error.errorCode = "USER_NOT_GROUP_MEMBER";
+        throw error;
+      }
+
+      await groupCapacityRepository.setTier(groupId, userId, tier);
+      return {
+        success: true,
+        data: { groupId, userId, tier },
+        message: "Nivel actualizado",
+      };
+    } catch (err) {
+      logger.error(`Error setting tier of ${userId} in group ${groupId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Works out who leaves and who gets promoted for a new capacity
+   * @returns {Promise<Object>} - { previousCapacity, newCapacity, strategy, removed, promoted }
+   */
+  async planChange(group, { capacity, strategy = "join_order", memberIds = [] }) {
+    if (capacity !== null && (!Number.isInteger(capacity) || capacity < 2)) {
+      const error = new Error("El cupo debe ser un número entero mayor o igual a 2, o null para ilimitado");
+      error.status = 400;
+      throw error;
+    }
+    if (!CAPACITY_STRATEGIES.includes(strategy)) {
+      const error = new Error(
+        `Estrategia inválida. Valores permitidos: ${CAPACITY_STRATEGIES.join(", ")}`
+      );
+      error.status = 400;
+      throw error;
+    }
+
+    const members = await groupCapacityRepository.findMembers(group.id);
+    const excess = capacity === null ? 0 : Math.max(members.length - capacity, 0);
+    const removed = excess > 0
+      ? this.selectMembersToRemove(members, group.adminId, excess, strategy, memberIds)
+      : [];
+
+    const freeSpots = capacity === null ? Infinity : capacity - (members.length - removed.length);
+    const promoted = freeSpots > 0
+      ? await groupJoinRequestRepository.findWaitlist(
+          group.id,
+          Number.isFinite(freeSpots) ? freeSpots : null
+        )
+      : [];
+
+    return {
+      previousCapacity: group.capacity ?? null,
+      newCapacity: capacity,
+      strategy,
+      removed,
+      promoted,
+    };
+  }
+
+  /**
+   * Picks the members that lose their spot. The admin always keeps theirs.
+   */
+  selectMembersToRemove(members, adminId, count, strategy, memberIds) {
+    const candidates = members.filter((member) => member.userId !== adminId);
+
+    if (strategy === "manual") {
+      const ids = Array.isArray(memberIds) ? memberIds : [];
+      const selected = candidates.filter((member) => ids.includes(member.userId));
+      if (selected.length !== ids.length || selected.length !== count) {
+        const error = new Error(
+          `Debes elegir exactamente ${count} miembros (sin incluir al administrador)`
+        );
+        error.status = 400;
+        throw error;
+      }
+      return selected;
+    }
+
+    const byLatestJoin = (a, b) => joinedAtMs(b) - joinedAtMs(a);
+    const sorted = [...candidates].sort(
+      strategy === "tier" ? (a, b) => a.tier - b.tier || byLatestJoin(a, b) : byLatestJoin
+    );
+    return sorted.slice(0, count);
+  }
+
+  async notify(userId, notification) {
+    try {
+      await createAndEmitNotification({ userId, ...notification });
+    } catch (notifError) {
+      // Inside a transaction the failed insert has aborted it
+      if (txManager.active) {
+        throw notifError;
+      }
+      logger.error(`Error sending ${notification.type} to ${userId}: ${notifError.message}`);
+    }
+  }
+
+  async getGroupAsAdmin(groupId, userId) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      const error = new Error("Grupo no encontrado");
+      error.status = 404;
+      error.errorCode = "GROUP_NOT_FOUND";
+      throw error;
+    }
+    if (group.adminId !== userId) {
+      const error = new Error("Solo el administrador puede cambiar el cupo del grupo");
+      error.status = 403;
+      throw error;
+    }
+    return group;
+  }
+
+  formatMember(member) {
+    return {
+      userId: member.userId,
+      name: member.name,
+      email: member.email,
+      tier: Number(member.tier),
+      joinedAt: member.joinedAt,
+    };
+  }
+
+  formatWaitlisted(request) {
+    return {
+      requestId: request.id,
+      userId: request.userId,
+      name: request.user?.name,
+      waitlistedAt: request.createdAt,
+    };
+  }
+
+  formatChange(change) {
+    return {
+      id: change.id,
+      groupId: change.groupId,
+      previousCapacity: change.previousCapacity,
+      newCapacity: change.newCapacity,
+      strategy: change.strategy,
+      reason: change.reason,
+      removedMembers: change.removedMembers,
+      promotedMembers: change.promotedMembers,
+      changedBy: change.changedBy
+        ? { id: change.changedBy.id, name: change.changedBy.name }
+        : { id: change.changedById },
+      createdAt: change.createdAt,
+    };
+  }
+}
+
+export default new GroupCapacityService();
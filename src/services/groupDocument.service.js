@@ -0,0 +1,264 @@
+import crypto from "crypto";
+import path from "path";
+import groupDocumentRepository from "../repository/groupDocument.repository.js";
+import groupRepository from "../repository/group.repository.js";
+import s3Client from "../clients/s3.client.js";
+import malwareScanService from "./malwareScan.service.js";
+import legalHoldService from "./legalHold.service.js";
+import { DOCUMENT_SCAN_JOB } from "../jobs/documentScan.job.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import {
+  AppError,
+  ValidationError,
+  NotFoundError,
+  AuthorizationError,
+} from "../utils/customErrors.js";
+
+export const DOCUMENT_CATEGORIES = ["ticket", "reservation", "insurance", "other"];
+
+export const DOCUMENT_CONTENT_TYPES = [
+  "application/pdf",
+  "image/jpeg",
+  "image/png",
+  "image/webp",
+  "image/heic",
+];
+
+class GroupDocumentService {
+  async assertMember(groupId, userId) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      throw new NotFoundError("Grupo no encontrado", "GROUP_NOT_FOUND");
+    }
+    if (!group.members.some((member) => member.id === userId)) {
+      throw new AuthorizationError("No eres miembro de este grupo", "NOT_GROUP_MEMBER");
+    }
+    return group;
+  }
+
+  async getDocumentOrFail(groupId, documentId) {
+    const document = await groupDocumentRepository.findByIdInGroup(groupId, documentId);
+    if (!document) {
+      throw new NotFoundError("Documento no encontrado");
+    }
+    return document;
+  }
+
+  /**
+   * Validates the file and checks it fits in the group quota
+   */
+  async validateFile(groupId, file) {
+    if (!s3Client.isConfigured()) {
+      throw new AppError("El almacenamiento de archivos no está disponible", 503, "STORAGE_UNAVAILABLE");
+    }
+    if (!file) {
+      throw new ValidationError("No se proporcionó ningún archivo", null, "FILE_REQUIRED");
+    }
+    if (!DOCUMENT_CONTENT_TYPES.includes(file.mimetype)) {
+      throw new ValidationError(
+        `Tipo de archivo no permitido (${file.mimetype}). Solo se permiten: PDF, JPG, PNG, WEBP, HEIC`
+      );
+    }
+    if (file.size > config.documents.maxFileSize) {
+      throw new ValidationError(
+        `El archivo excede el tamaño máximo de ${Math.round(config.documents.maxFileSize / (1024 * 1024))}MB`
+      );
+    }
+
+    const used = await groupDocumentRepository.sumStorageByGroup(groupId);
+    if (used + file.size > config.documents.groupQuotaBytes) {
+      throw new AppError(
+        "El grupo alcanzó su cuota de almacenamiento de documentos",
+        413,
+        "QUOTA_EXCEEDED"
+      );
+    }
+  }
+
+  /**
+   * Uploads the file and returns the version fields to persist
+   */
+  async storeFile(groupId, userId, file) {
+    const extension = path.extname(file.originalname || "").toLowerCase().slice(0, 10);
+    const storageKey = `documents/${groupId}/${crypto.randomUUID()}${extension}`;
+    await s3Client.putObject(storageKey, file.buffer, file.mimetype);
+
+    return {
+      storageKey,
+      originalName: file.originalname ? file.originalname.slice(0, 255) : null,
+      contentType: file.mimetype,
+      size: file.size,
+      uploadedById: userId,
+      // Without a scanner configured files are flagged instead of blocked forever
+      scanStatus: malwareScanService.initialStatus(),
+    };
+  }
+
+  async enqueueScan(version) {
+    if (version.scanStatus === "pending") {
+      await malwareScanService.enqueueScan(DOCUMENT_SCAN_JOB, { versionId: version.id });
+    }
+  }
+
+  /**
+   * Shares a new document with the group
+   * @param {string} groupId
+   * @param {string} userId
+   * @param {Object} data - { title, category, file }
+   */
+  async createDocument(groupId, userId, { title, category = "other", file }) {
+    await this.assertMember(groupId, userId);
+
+    const trimmedTitle = (title || file?.originalname || "").trim();
+    if (!trimmedTitle || trimmedTitle.length > 150) {
+      throw new ValidationError("El título es requerido y debe tener hasta 150 caracteres");
+    }
+    if (!DOCUMENT_CATEGORIES.includes(category)) {
+      throw new ValidationError(
+        `Categoría inválida. Valores permitidos: ${DOCUMENT_CATEGORIES.join(", ")}`
+      );
+    }
+    await this.validateFile(groupId, file);
+
+    const versionData = await this.storeFile(groupId, userId, file);
+    const { document, version } = await groupDocumentRepository.createWithVersion(
+      { groupId, title: trimmedTitle, category, createdById: userId },
+      versionData
+    );
+    await this.enqueueScan(version);
+
+    logger.info(`Document ${document.id} shared in group ${groupId} by ${userId}`);
+    return this.formatDocument(document, version);
+  }
+
+  /**
+   * Uploads a new version of a document
+   */
+  async addVersion(groupId, documentId, userId, file) {
+    await this.assertMember(groupId, userId);
+    const document = await this.getDocumentOrFail(groupId, documentId);
+    await this.validateFile(groupId, file);
+
+    const versionData = await this.storeFile(groupId, userId, file);
+    const version = await groupDocumentRepository.addVersion(document, versionData);
+    await this.enqueueScan(version);
+
+    return this.formatDocument({ ...document, currentVersion: version.version }, version);
+  }
+
+  /**
+   * Lists the documents of a group and the quota usage
+   */
+  async getDocuments(groupId, userId) {
+    await this.assertMember(groupId, userId);
+    const [documents, used] = await Promise.all([
+      groupDocumentRepository.findByGroupId(groupId),
+      groupDocumentRepository.sumStorageByGroup(groupId),
+    ]);
+
+    return {
+      documents: documents.map((document) =>
+        this.formatDocument(document, document.versions?.[0])
+      ),
+      storage: {
+        usedBytes: used,
+        quotaBytes: config.documents.groupQuotaBytes,
+      },
+    };
+  }
+
+  /**
+   * Gets a document with its full version history
+   */
+  async getDocument(groupId, documentId, userId) {
+    await this.assertMember(groupId, userId);
+    const document = await this.getDocumentOrFail(groupId, documentId);
+    const versions = await groupDocumentRepository.findVersions(documentId);
+
+    return {
+      ...this.formatDocument(
+        document,
+        versions.find((version) => version.version === document.currentVersion)
+      ),
+      versions: versions.map((version) => this.formatVersion(version)),
+    };
+  }
+
+  /**
+   * Resolves a temporary download URL for a version that passed the scan
+   * @param {number|null} versionNumber - Defaults to the current version
+   * @returns {Promise<string>}
+   */
+  async getDownloadUrl(groupId, documentId, userId, versionNumber = null) {
+    await this.assertMember(groupId, userId);
+    const document = await this.getDocumentOrFail(groupId, documentId);
+    const version = await groupDocumentRepository.findVersion(
+      documentId,
+      versionNumber || document.currentVersion
+    );
+    if (!version) {
+      throw new NotFoundError("Versión no encontrada");
+    }
+
+    malwareScanService.assertDownloadable(version.scanStatus);
+
+    return s3Client.presignUrl("GET", version.storageKey, { expiresIn: 300 });
+  }
+
+  /**
+   * Deletes a document and every version (uploader or group admin)
+   */
+  async deleteDocument(groupId, documentId, userId) {
+    const group = await this.assertMember(groupId, userId);
+    const document = await this.getDocumentOrFail(groupId, documentId);
+    if (document.createdById !== userId && group.adminId !== userId) {
+      throw new AuthorizationError("Solo quien compartió el documento o el administrador pueden eliminarlo");
+    }
+
+    const versions = await groupDocumentRepository.findVersions(documentId);
+    await legalHoldService.assertNoneOnHold([
+      document.createdById,
+      ...versions.map((version) => version.uploadedById),
+    ]);
+    await groupDocumentRepository.delete(documentId);
+
+    for (const version of versions) {
+      try {
+        await s3Client.deleteObject(version.storageKey);
+      } catch (error) {
+        logger.error(`Failed to delete object ${version.storageKey}: ${error.message}`);
+      }
+    }
+  }
+
+  formatVersion(version) {
+    return {
+      version: version.version,
+      originalName: version.originalName,
+      contentType: version.contentType,
+      size: version.size,
+      scanStatus: version.scanStatus,
+      uploadedBy: version.uploadedBy
+        ? { id: version.uploadedBy.id, name: version.uploadedBy.name }
+        : { id: version.uploadedById },
+      createdAt: version.createdAt,
+    };
+  }
+
+  formatDocument(document, currentVersion) {
+    return {
+      id: document.id,
+      groupId: document.groupId,
+      title: document.title,
+      category: document.category,
+      currentVersion: document.currentVersion,
+      createdById: document.createdById,
+      createdAt: document.createdAt,
+      updatedAt: document.updatedAt,
+      latest: currentVersion ? this.formatVersion(currentVersion) : null,
+    };
+  }
+}
+
+export default new GroupDocumentService();
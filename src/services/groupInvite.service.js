@@ -0,0 +1,280 @@
+import crypto from "crypto";
+import groupInviteRepository from "../repository/groupInvite.repository.js";
+import groupRepository from "../repository/group.repository.js";
+import groupJoinRequestRepository from "../repository/groupJoinRequest.repository.js";
+import txManager from "../repository/transactionManager.js";
+import groupJoinRequestService from "./groupJoinRequest.service.js";
+import profileService from "./profile.service.js";
+import userBlockService from "./userBlock.service.js";
+import joinEligibilityService from "./joinEligibility.service.js";
+import badgeService from "./badge.service.js";
+import bookingTraceService from "./bookingTrace.service.js";
+import bookingTermsService from "./bookingTerms.service.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { AppError, AuthorizationError, NotFoundError, ValidationError } from "../utils/customErrors.js";
+import { createAndEmitNotification } from "../socket/notification.emitter.js";
+
+const TOKEN_PREFIX = "inv_";
+const MAX_USES_LIMIT = 500;
+// Links are for planning a trip, not for keeping around
+const MAX_EXPIRY_DAYS = 365;
+// Active links per trip, so a leaked organizer session cannot mint them endlessly
+const MAX_ACTIVE_INVITES = 20;
+
+const hashToken = (token) => crypto.createHash("sha256").update(token).digest("hex");
+
+/**
+ * Shareable invite links of trips. The organizer creates a link, optionally
+ * limited in uses and time; whoever opens it sees a preview of the trip and,
+ * signed in, joins right away without waiting for approval. Capacity, blocks
+ * and the trip rules still apply: a joiner who has not accepted the rules
+ * holds their spot as awaiting_rules, like an approved request.
+ */
+class GroupInviteService {
+  /**
+   * Creates an invite link (organizer only). The URL is only returned here.
+   * @param {string} groupId
+   * @param {string} requesterId
+   * @param {Object} data - { maxUses, expiresAt }
+   * @returns {Promise<Object>} - The invite with its url
+   */
+  async createInvite(groupId, requesterId, { maxUses = null, expiresAt = null } = {}) {
+    const group = await this.getGroupAsAdmin(groupId, requesterId);
+
+    const details = {};
+    if (maxUses !== null && (!Number.isInteger(maxUses) || maxUses < 1 || maxUses > MAX_USES_LIMIT)) {
+      details.maxUses = `Debe ser un entero entre 1 y ${MAX_USES_LIMIT}`;
+    }
+    let expiry = null;
+    if (expiresAt !== null) {
+      expiry = new Date(expiresAt);
+      const maxExpiry = Date.now() + MAX_EXPIRY_DAYS * 24 * 60 * 60 * 1000;
+      if (typeof expiresAt !== "string" || Number.isNaN(expiry.getTime())) {
+        details.expiresAt = "Debe ser una fecha ISO 8601";
+      } else if (expiry.getTime() <= Date.now() || expiry.getTime() > maxExpiry) {
+        details.expiresAt = `Debe ser una fecha futura, dentro de los próximos ${MAX_EXPIRY_DAYS} días`;
+      }
+    }
+    if (Object.keys(details).length > 0) {
+      throw new ValidationError("Datos de la invitación inválidos", details);
+    }
+
+    const active = (await groupInviteRepository.findByGroup(groupId)).filter((invite) => this.isUsable(invite));
+    if (active.length >= MAX_ACTIVE_INVITES) {
+      throw new AppError(
+        `El viaje ya tiene ${MAX_ACTIVE_INVITES} links de invitación activos. Revoca alguno para crear otro`,
+        409,
+        "INVITE_LIMIT_REACHED"
+      );
+    }
+
+    const token = `${TOKEN_PREFIX}${crypto.randomBytes(18).toString("base64url")}`;
+    const invite = await groupInviteRepository.create({
+      groupId,
+      createdById: requesterId,
+      tokenHash: hashToken(token),
+      tokenPrefix: token.slice(0, 12),
+      maxUses,
+      expiresAt: expiry,
+    });
+    logger.info(`[Invites] Invite ${invite.id} created for group ${group.id} by ${requesterId}`);
+    return { ...this.formatInvite(invite), token, url: this.getInviteUrl(token) };
+  }
+
+  /**
+   * Links of a trip that were not revoked, expired and used-up ones included (organizer only)
+   */
+  async listInvites(groupId, requesterId) {
+    await this.getGroupAsAdmin(groupId, requesterId);
+    const invites = await groupInviteRepository.findByGroup(groupId);
+    return invites.map((invite) => this.formatInvite(invite));
+  }
+
+  async revokeInvite(groupId, inviteId, requesterId) {
+    await this.getGroupAsAdmin(groupId, requesterId);
+    const invite = await groupInviteRepository.findByIdInGroup(groupId, inviteId);
+    if (!invite || invite.revokedAt) {
+      throw new NotFoundError("Invitación no encontrada", "INVITE_NOT_FOUND");
+    }
+    await groupInviteRepository.revoke(invite.id);
+    logger.info(`[Invites] Invite ${invite.id} of group ${groupId} revoked by ${requesterId}`);
+  }
+
+  /**
+   * Public preview of the trip an invite is for
+   * @param {string} token
+   * @param {string|null} viewerId - Signed-in viewer, if any
+   * @returns {Promise<Object>} - { invite, trip, viewer }
+   */
+  async getPreview(token, viewerId = null) {
+    const { invite, group } = await this.getUsableInvite(token);
+    if (viewerId && (await userBlockService.isBlockedBetween(viewerId, group.adminId))) {
+      throw new NotFoundError("Invitación no encontrada", "INVITE_NOT_FOUND");
+    }
+
+    const organizer = group.admin;
+    const visibility = profileService.getVisibility(organizer);
+    const memberCount = group.members.length;
+    return {
+      invite: {
+        expiresAt: invite.expiresAt,
+        usesLeft: invite.maxUses === null ? null : invite.maxUses - invite.useCount,
+      },
+      trip: {
+        id: group.id,
+        name: group.name,
+        description: group.description,
+        destinationName: group.destinationName,
+        tripStartDate: group.tripStartDate,
+        tripEndDate: group.tripEndDate,
+        capacity: group.capacity,
+        memberCount,
+        isFull: Boolean(group.capacity) && memberCount >= group.capacity,
+        hasRules: Boolean(group.rules),
+        depositAmount: group.depositAmount,
+        depositCurrency: group.depositCurrency,
+        organizer: {
+          id: organizer.id,
+          name: organizer.name,
+          profilePicture: visibility.profilePicture === "public" ? organizer.profilePicture : null,
+        },
+      },
+      viewer: viewerId ? { isMember: group.members.some((member) => member.id === viewerId) } : null,
+    };
+  }
+
+  /**
+   * Joins the user to the trip of an invite, skipping the approval queue.
+   * An open request of theirs for the trip is admitted instead of a new one.
+   * @param {string} token
+   * @param {string} userId
+   * @returns {Promise<Object>} - { request, message }
+   */
+  async acceptInvite(token, userId) {
+    const { invite, group } = await this.getUsableInvite(token);
+    if (group.members.some((member) => member.id === userId)) {
+      throw new AppError("Ya eres miembro de este viaje", 409, "ALREADY_MEMBER");
+    }
+    await joinEligibilityService.assertEligible(userId, group);
+
+    // Same lock as approvals, so an invite never takes a seat an approval just took
+    const { admitted, wasPending } = await txManager.run(async () => {
+      await groupRepository.lockForUpdate(group.id);
+      const { members } = await groupRepository.findById(group.id);
+      if (members.some((member) => member.id === userId)) {
+        throw new AppError("Ya eres miembro de este viaje", 409, "ALREADY_MEMBER");
+      }
+      if (group.capacity && members.length >= group.capacity) {
+        throw new AppError("El viaje está completo", 409, "GROUP_FULL");
+      }
+      if (!(await groupInviteRepository.incrementUse(invite.id))) {
+        throw new AppError("La invitación ya no tiene usos disponibles", 410, "INVITE_USED_UP");
+      }
+
+      const open = await groupJoinRequestRepository.findPending(group.id, userId);
+      const request = open
+        ? await groupJoinRequestRepository.update(open.id, { inviteId: invite.id })
+        : await groupJoinRequestRepository.create({ groupId: group.id, userId, status: "pending", inviteId: invite.id });
+      if (!open) {
+        await bookingTraceService.record(request.id, {
+          source: "booking",
+          type: "requested",
+          data: { groupId: group.id, userId, inviteId: invite.id },
+        });
+        await bookingTermsService.capture(group, userId, request.id);
+      }
+      const admitted = await groupJoinRequestService.admit(group, request, invite.createdById, "approved");
+      return { admitted, wasPending: open?.status === "pending" };
+    });
+
+    if (wasPending) {
+      badgeService.increment(group.adminId, "joinRequests", -1);
+    }
+    logger.info(`[Invites] User ${userId} joined group ${group.id} through invite ${invite.id} (${admitted.status})`);
+    if (admitted.status !== "approved") {
+      return {
+        request: groupJoinRequestService.formatRequest(admitted),
+        message: "Tu lugar se confirma cuando aceptes las normas del viaje",
+      };
+    }
+
+    badgeService.invalidate(userId);
+    try {
+      await createAndEmitNotification({
+        userId: group.adminId,
+        type: "GROUP_INVITE_ACCEPTED",
+        title: "Nuevo miembro por invitación",
+        message: `Alguien se sumó al grupo "${group.name}" con un link de invitación`,
+        data: { groupId: group.id, groupName: group.name, userId, inviteId: invite.id },
+      });
+    } catch (notifError) {
+      logger.error(`Error notifying invite ${invite.id} accepted: ${notifError.message}`);
+    }
+    return {
+      request: groupJoinRequestService.formatRequest(admitted),
+      message: `Te sumaste al grupo "${group.name}"`,
+    };
+  }
+
+  /**
+   * Invite of a token with its trip, as long as both can still be joined
+   */
+  async getUsableInvite(token) {
+    if (typeof token !== "string" || !token.startsWith(TOKEN_PREFIX)) {
+      throw new NotFoundError("Invitación no encontrada", "INVITE_NOT_FOUND");
+    }
+    const invite = await groupInviteRepository.findByTokenHash(hashToken(token));
+    const group = invite && !invite.revokedAt ? await groupRepository.findById(invite.groupId) : null;
+    if (!group) {
+      throw new NotFoundError("Invitación no encontrada", "INVITE_NOT_FOUND");
+    }
+    if (invite.expiresAt && new Date(invite.expiresAt) <= new Date()) {
+      throw new AppError("La invitación venció", 410, "INVITE_EXPIRED");
+    }
+    if (invite.maxUses !== null && invite.useCount >= invite.maxUses) {
+      throw new AppError("La invitación ya no tiene usos disponibles", 410, "INVITE_USED_UP");
+    }
+    if (group.tripEndDate && group.tripEndDate < new Date().toISOString().slice(0, 10)) {
+      throw new AppError("El viaje ya terminó", 410, "TRIP_ENDED");
+    }
+    return { invite, group };
+  }
+
+  async getGroupAsAdmin(groupId, requesterId) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      throw new NotFoundError("Grupo no encontrado", "GROUP_NOT_FOUND");
+    }
+    if (group.adminId !== requesterId) {
+      throw new AuthorizationError("Solo el organizador puede gestionar las invitaciones");
+    }
+    return group;
+  }
+
+  isUsable(invite) {
+    return (
+      !invite.revokedAt &&
+      (!invite.expiresAt || new Date(invite.expiresAt) > new Date()) &&
+      (invite.maxUses === null || invite.useCount < invite.maxUses)
+    );
+  }
+
+  getInviteUrl(token) {
+    return `${config.frontendUrl}/invite/${token}`;
+  }
+
+  formatInvite(invite) {
+    return {
+      id: invite.id,
+      tokenPrefix: invite.tokenPrefix,
+      maxUses: invite.maxUses,
+      useCount: invite.useCount,
+      expiresAt: invite.expiresAt,
+      active: this.isUsable(invite),
+      createdAt: invite.createdAt,
+    };
+  }
+}
+
+export default new GroupInviteService();
@@ -0,0 +1,443 @@
+import groupRepository from "../repository/group.repository.js";
+import groupJoinRequestRepository from "../repository/groupJoinRequest.repository.js";
+import tripRuleAcceptanceRepository from "../repository/tripRuleAcceptance.repository.js";
+import txManager from "../repository/transactionManager.js";
+import jobQueueService from "./jobQueue.service.js";
+import joinEligibilityService from "./joinEligibility.service.js";
+import badgeService from "./badge.service.js";
+import bookingTraceService from "./bookingTrace.service.js";
+import bookingTermsService from "./bookingTerms.service.js";
+import domainEventService, { DOMAIN_EVENTS } from "./domainEvent.service.js";
+import logger from "../config/logger.js";
+import { BOOKING_REFUND_JOB } from "../jobs/bookingRefund.job.js";
+import { createAndEmitNotification } from "../socket/notification.emitter.js";
+
+const JOIN_REQUEST_STATUSES = ["pending", "waitlisted", "awaiting_rules", "approved", "rejected", "cancelled"];
+
+class GroupJoinRequestService {
+  /**
+   * Requests to join a group; the group admin is notified
+   * @param {string} groupId
+   * @param {string} userId
+   * @param {string|null} message - Optional note for the admin
+   * @returns {Promise<Object>} - { success, data, message }
+   */
+  async requestToJoin(groupId, userId, message = null) {
+    try {
+      const group = await this.getGroupOrFail(groupId);
+
+      if (group.members.some((member) => member.id === userId)) {
+        const error = new Error("Ya eres miembro de este grupo");
+        error.status = 409;
+        throw error;
+      }
+      await joinEligibilityService.assertEligible(userId, group);
+      if (message && message.length > 500) {
+        const error = new Error("El mensaje no puede superar los 500 caracteres");
+        error.status = 400;
+        throw error;
+      }
+      // The request, its trace and the admin's notification commit together;
+      // the group lock keeps the capacity check and duplicate check current
+      const { request, isFull } = await txManager.run(async () => {
+        await groupRepository.lockForUpdate(groupId);
+        if (await groupJoinRequestRepository.findPending(groupId, userId)) {
+          const error = new Error("Ya tienes una solicitud pendiente para este grupo");
+          error.status = 409;
+          throw error;
+        }
+
+        // A full group still collects requests, straight into the waitlist
+        const { members } = await groupRepository.findById(groupId);
+        const isFull = Boolean(group.capacity) && members.length >= group.capacity;
+        const request = await groupJoinRequestRepository.create({
+          groupId,
+          userId,
+          message: message ? message.trim() : null,
+          status: isFull ? "waitlisted" : "pending",
+        });
+        await bookingTraceService.record(request.id, {
+          source: "booking",
+          type: request.status === "waitlisted" ? "waitlisted" : "requested",
+          data: { groupId, userId },
+        });
+        // What the requester books is the trip as it is now
+        await bookingTermsService.capture(group, userId, request.id);
+        await createAndEmitNotification({
+          userId: group.adminId,
+          type: "JOIN_REQUEST_RECEIVED",
+          title: "Nueva solicitud para unirse",
+          message: `Alguien quiere unirse al grupo "${group.name}"`,
+          data: { groupId, groupName: group.name, requestId: request.id, bookingId: request.id, userId },
+        });
+        return { request, isFull };
+      });
+      if (!isFull) {
+        badgeService.increment(group.adminId, "joinRequests");
+      }
+
+      return {
+        success: true,
+        data: this.formatRequest(request),
+        message: isFull ? "El grupo está completo, quedaste en lista de espera" : "Solicitud enviada",
+      };
+    } catch (err) {
+      logger.error(`Error requesting to join group ${groupId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Lists the join requests of a group (admin only)
+   * @param {string} groupId
+   * @param {string} requesterId
+   * @param {string|null} status - Optional status filter
+   * @returns {Promise<Object>} - { success, data }
+   */
+  async getGroupRequests(groupId, requesterId, status = null) {
+    try {
+      const group = await this.getGroupOrFail(groupId);
+      this.assertAdmin(group, requesterId);
+
+      if (status && !JOIN_REQUEST_STATUSES.includes(status)) {
+        const error = new Error("Estado de solicitud inválido");
+        error.status = 400;
+        throw error;
+      }
+
+      const requests = await groupJoinRequestRepository.findByGroupId(groupId, status);
+      return {
+        success: true,
+        data: requests.map((request) => this.formatRequest(request)),
+      };
+    } catch (err) {
+      logger.error(`Error getting join requests of group ${groupId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Approves or rejects a pending join request (admin only).
+   * Approving adds the requester as a member if the group has room, otherwise
+   * the request moves to the waitlist; if the trip has rules the requester has
+   * not accepted, their spot waits for them to accept. Waitlisted requests
+   * can also be rejected; rejecting refunds any deposit paid.
+   * @param {string} groupId
+   * @param {string} requestId
+   * @param {string} requesterId
+   * @param {boolean} approve
+   * @returns {Promise<Object>} - { success, data, message }
+   */
+  async decideRequest(groupId, requestId, requesterId, approve) {
+    try {
+      const group = await this.getGroupOrFail(groupId);
+      this.assertAdmin(group, requesterId);
+
+      // The seat check, membership, request status and notification commit
+      // together; the group lock keeps two approvals from taking the last seat
+      const { request, updated } = await txManager.run(async () => {
+        await groupRepository.lockForUpdate(groupId);
+
+        const request = await groupJoinRequestRepository.findByIdInGroup(groupId, requestId);
+        if (!request) {
+          const error = new Error("Solicitud no encontrada");
+          error.status = 404;
+          error.errorCode = "JOIN_REQUEST_NOT_FOUND";
+          throw error;
+        }
+        const isOpen =
+          request.status === "pending" ||
+          (["waitlisted", "awaiting_rules"].includes(request.status) && !approve);
+        if (!isOpen) {
+          const error = new Error("La solicitud ya fue procesada");
+          error.status = 409;
+          error.errorCode = "REQUEST_ALREADY_DECIDED";
+          throw error;
+        }
+
+        const { members } = await groupRepository.findById(groupId);
+        if (approve && group.capacity && members.length >= group.capacity) {
+          const waitlisted = await groupJoinRequestRepository.update(requestId, {
+            status: "waitlisted",
+            decidedById: requesterId,
+            decidedAt: new Date(),
+          });
+          await this.notifyDecision(group, waitlisted, "waitlisted");
+          return { request, updated: waitlisted };
+        }
+
+        if (approve) {
+          return { request, updated: await this.admit(group, request, requesterId, "approved") };
+        }
+        const updated = await groupJoinRequestRepository.update(requestId, {
+          status: "rejected",
+          decidedById: requesterId,
+          decidedAt: new Date(),
+        });
+        await this.queueRefund(requestId);
+        await this.notifyDecision(group, updated, updated.status);
+        return { request, updated };
+      });
+
+      if (request.status === "pending") {
+        badgeService.increment(group.adminId, "joinRequests", -1);
+      }
+      if (updated.status === "waitlisted") {
+        return {
+          success: true,
+          data: this.formatRequest(updated),
+          message: "El grupo está completo, la solicitud quedó en lista de espera",
+        };
+      }
+      if (updated.status === "awaiting_rules") {
+        return {
+          success: true,
+          data: this.formatRequest(updated),
+          message: "Solicitud aceptada. El lugar se confirma cuando acepte las normas del viaje",
+        };
+      }
+      if (approve) {
+        // The new member now has the group's messages and expenses
+        badgeService.invalidate(request.userId);
+      }
+
+      return {
+        success: true,
+        data: this.formatRequest(updated),
+        message: approve ? "Solicitud aceptada" : "Solicitud rechazada",
+      };
+    } catch (err) {
+      logger.error(`Error deciding join request ${requestId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Gives an approved or promoted requester their spot, or holds it until
+   * they accept the current rules of the trip. The caller checks the seat
+   * (and holds the group lock when in a transaction).
+   * @param {Object} group
+   * @param {Object} request
+   * @param {string} decidedById
+   * @param {string} outcome - "approved" | "promoted"
+   * @returns {Promise<Object>} Updated request ("approved" or "awaiting_rules")
+   */
+  async admit(group, request, decidedById, outcome) {
+    if (!(await this.hasAcceptedRules(group, request.userId))) {
+      const awaiting = await groupJoinRequestRepository.update(request.id, {
+        status: "awaiting_rules",
+        decidedById,
+        decidedAt: new Date(),
+      });
+      await this.notifyDecision(group, awaiting, "awaiting_rules");
+      return awaiting;
+    }
+
+    await groupRepository.addMembers(group.id, [request.userId]);
+    await domainEventService.emit(DOMAIN_EVENTS.MEMBER_JOINED, group.id, {
+      groupId: group.id,
+      userId: request.userId,
+      addedById: decidedById,
+      via: request.inviteId ? "invite" : outcome === "promoted" ? "waitlist" : "join_request",
+    });
+    const approved = await groupJoinRequestRepository.update(request.id, {
+      status: "approved",
+      decidedById,
+      decidedAt: new Date(),
+    });
+    await this.notifyDecision(group, approved, outcome);
+    return approved;
+  }
+
+  /**
+   * Confirms the spot of a request that was waiting for its user to accept
+   * the trip rules, or waitlists it if the group filled up meanwhile
+   * @param {string} groupId
+   * @param {string} userId
+   * @returns {Promise<Object|null>} Updated request, or null without one waiting
+   */
+  async confirmAfterRules(groupId, userId) {
+    const confirmed = await txManager.run(async () => {
+      await groupRepository.lockForUpdate(groupId);
+      const request = await groupJoinRequestRepository.findPending(groupId, userId);
+      if (request?.status !== "awaiting_rules") {
+        return null;
+      }
+
+      const group = await groupRepository.findById(groupId);
+      if (group.capacity && group.members.length >= group.capacity) {
+        const waitlisted = await groupJoinRequestRepository.update(request.id, { status: "waitlisted" });
+        await this.notifyDecision(group, waitlisted, "waitlisted");
+        return waitlisted;
+      }
+      return await this.admit(group, request, request.decidedById, "approved");
+    });
+    if (confirmed?.status === "approved") {
+      badgeService.invalidate(userId);
+    }
+    return confirmed;
+  }
+
+  /**
+   * Whether a user accepted the current rules of a trip (trips without rules need no acceptance)
+   */
+  async hasAcceptedRules(group, userId) {
+    if (!group.rules) {
+      return true;
+    }
+    const latest = await tripRuleAcceptanceRepository.findLatestByUsers(group.id, [userId]);
+    return latest.get(userId)?.version === group.rulesVersion;
+  }
+
+  /**
+   * Notifies the requester about a decision on their request
+   * @param {Object} group
+   * @param {Object} request
+   * @param {string} outcome - "approved" | "rejected" | "waitlisted" | "promoted" | "awaiting_rules"
+   */
+  async notifyDecision(group, request, outcome) {
+    // Dropped with the decision if its transaction rolls back
+    await bookingTraceService.record(request.id, {
+      source: "booking",
+      type: outcome,
+      data: { groupId: group.id, decidedById: request.decidedById || null },
+    });
+
+    const notifications = {
+      approved: {
+        type: "JOIN_REQUEST_APPROVED",
+        title: "Solicitud aceptada",
+        message: `Ya eres miembro del grupo "${group.name}"`,
+      },
+      rejected: {
+        type: "JOIN_REQUEST_REJECTED",
+        title: "Solicitud rechazada",
+        message: `Tu solicitud para unirte a "${group.name}" fue rechazada`,
+      },
+      waitlisted: {
+        type: "JOIN_REQUEST_WAITLISTED",
+        title: "Estás en lista de espera",
+        message: `El grupo "${group.name}" está completo. Te avisaremos si se libera un lugar`,
+      },
+      promoted: {
+        type: "JOIN_REQUEST_APPROVED",
+        title: "¡Se liberó un lugar!",
+        message: `Saliste de la lista de espera y ya eres miembro del grupo "${group.name}"`,
+      },
+      awaiting_rules: {
+        type: "JOIN_REQUEST_AWAITING_RULES",
+        title: "Acepta las normas del viaje",
+        message: `Tu lugar en "${group.name}" se confirma cuando aceptes sus normas de convivencia`,
+      },
+    };
+
+    try {
+      await createAndEmitNotification({
+        userId: request.userId,
+        ...notifications[outcome],
+        data: { groupId: group.id, groupName: group.name, requestId: request.id, bookingId: request.id },
+      });
+    } catch (notifError) {
+      // Inside a transaction the failed insert has aborted it: roll back the
+      // decision rather than report it as done
+      if (txManager.active) {
+        throw notifError;
+      }
+      logger.error(`Error notifying join request decision ${request.id}: ${notifError.message}`);
+    }
+  }
+
+  /**
+   * Cancels the user's own pending, waitlisted or awaiting_rules request
+   * @param {string} groupId
+   * @param {string} requestId
+   * @param {string} userId
+   * @returns {Promise<Object>} - { success, message }
+   */
+  async cancelRequest(groupId, requestId, userId) {
+    try {
+      const request = await groupJoinRequestRepository.findByIdInGroup(groupId, requestId);
+      if (!request || request.userId !== userId) {
+        const error = new Error("Solicitud no encontrada");
+        error.status = 404;
+        error.errorCode = "JOIN_REQUEST_NOT_FOUND";
+        throw error;
+      }
+      if (!["pending", "waitlisted", "awaiting_rules"].includes(request.status)) {
+        const error = new Error("La solicitud ya fue procesada");
+        error.status = 409;
+        error.errorCode = "REQUEST_ALREADY_DECIDED";
+        throw error;
+      }
+
+      await txManager.run(async () => {
+        await groupJoinRequestRepository.update(requestId, { status: "cancelled" });
+        await bookingTraceService.record(requestId, {
+          source: "booking",
+          type: "cancelled",
+          data: { userId },
+        });
+        await this.queueRefund(requestId);
+      });
+      if (request.status === "pending") {
+        const group = await groupRepository.findById(groupId);
+        badgeService.increment(group?.adminId, "joinRequests", -1);
+      }
+      return {
+        success: true,
+        message: "Solicitud cancelada",
+      };
+    } catch (err) {
+      logger.error(`Error cancelling join request ${requestId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Queues the refund of a request's payments; it runs once the caller's
+   * transaction commits and shows in the booking trace
+   */
+  async queueRefund(requestId) {
+    await jobQueueService.enqueue(BOOKING_REFUND_JOB, { bookingId: requestId });
+  }
+
+  async getGroupOrFail(groupId) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      const error = new Error("Grupo no encontrado");
+      error.status = 404;
+      error.errorCode = "GROUP_NOT_FOUND";
+      throw error;
+    }
+    return group;
+  }
+
+  assertAdmin(group, userId) {
+    if (group.adminId !== userId) {
+      const error = new Error("Solo el administrador puede gestionar las solicitudes");
+      error.status = 403;
+      throw error;
+    }
+  }
+
+  formatRequest(request) {
+    return {
+      id: request.id,
+      groupId: request.groupId,
+      status: request.status,
+      message: request.message,
+      createdAt: request.createdAt,
+      decidedAt: request.decidedAt,
+      user: request.user
+        ? {
+            id: request.user.id,
+            name: request.user.name,
+            email: request.user.email,
+            profilePicture: request.user.profilePicture,
+          }
+        : { id: request.userId },
+    };
+  }
+}
+
+export default new GroupJoinRequestService();
@@ -1,21 +1,33 @@
 import groupMessageRepository from "../repository/groupMessage.repository.js";
+import groupMessageReadRepository from "../repository/groupMessageRead.repository.js";
 import groupRepository from "../repository/group.repository.js";
+import userBlockService from "./userBlock.service.js";
+import groupJoinRequestService from "./groupJoinRequest.service.js";
+import readStateService from "./readState.service.js";
+import badgeService from "./badge.service.js";
+import chatRetentionService from "./chatRetention.service.js";
+import domainEventService, { DOMAIN_EVENTS } from "./domainEvent.service.js";
+import txManager from "../repository/transactionManager.js";
 import logger from "../config/logger.js";
 import { createAndEmitNotification } from "../socket/notification.emitter.js";
+import { publish } from "../socket/realtime.js";
 
 class GroupMessageService {
   /**
    * Envía un mensaje a un grupo
-   * @param {Object} data - { groupId, senderId, content }
-   * @returns {Promise<Object>} - { success, data, message }
+   * @param {Object} data - { groupId, senderId, content, clientMessageId }
+   * @param {string} [data.clientMessageId] - ID generado por el cliente; permite
+   * reintentar envíos hechos sin conexión sin duplicar el mensaje
+   * @returns {Promise<Object>} - { success, data, message, duplicate }
    */
-  async sendMessage({ groupId, senderId, content }) {
+  async sendMessage({ groupId, senderId, content, clientMessageId = null }) {
     try {
       // Verificar que el grupo existe
       const group = await groupRepository.findById(groupId);
       if (!group) {
         const error = new Error("El grupo ya no existe.");
         error.status = 404;
+        error.errorCode = "GROUP_DELETED";
         throw error;
       }
 
@@ -24,22 +36,81 @@ class GroupMessageService {
       if (!isMember) {
         const error = new Error("No eres miembro de este grupo");
         error.status = 403;
+        error.errorCode = "NOT_GROUP_MEMBER";
+        throw error;
+      }
+
+      // Quien no aceptó la versión vigente de las normas del viaje no puede escribir
+      if (!(await groupJoinRequestService.hasAcceptedRules(group, senderId))) {
+        const error = new Error("Las normas del viaje cambiaron. Acéptalas para volver a escribir en el grupo");
+        error.status = 403;
+        error.errorCode = "RULES_ACCEPTANCE_REQUIRED";
         throw error;
       }
 
+      // Si el cliente ya envió este mensaje (reintento offline), devolver el existente
+      if (clientMessageId) {
+        const existing = await groupMessageRepository.findByClientMessageId(
+          groupId,
+          senderId,
+          clientMessageId
+        );
+        if (existing) {
+          logger.info(
+            `Duplicate group message ignored: ${existing.id} (clientMessageId ${clientMessageId})`
+          );
+          return {
+            success: true,
+            data: this.formatMessage(existing),
+            message: "Mensaje ya enviado",
+            duplicate: true,
+          };
+        }
+      }
+
       // Crear el mensaje
-      const message = await groupMessageRepository.create({
-        groupId,
-        senderId,
-        content,
+      const message = await txManager.run(async () => {
+        const message = await groupMessageRepository.create({
+          groupId,
+          senderId,
+          content,
+          clientMessageId,
+        });
+        await domainEventService.emit(DOMAIN_EVENTS.MESSAGE_SENT, groupId, {
+          groupId,
+          messageId: message.id,
+          senderId,
+        });
+        return message;
       });
 
       logger.info(`Group message sent: ${message.id} to group ${groupId}`);
 
+      // Los usuarios con bloqueo respecto al remitente no ven el mensaje: ni
+      // aviso ni badge (como en findReadStateByUser y countUnreadByGroupIds)
+      const hiddenUserIds = await userBlockService.getHiddenUserIds(senderId);
+      const recipientIds = new Set([group.adminId, ...group.members.map((m) => m.id)]);
+      recipientIds.delete(senderId);
+      badgeService.increment(
+        [...recipientIds].filter((id) => !hiddenUserIds.has(id)),
+        "messages"
+      );
+
+      // El remitente ya leyó su propio mensaje
+      try {
+        await groupMessageReadRepository.markRead(groupId, senderId, message);
+      } catch (readError) {
+        logger.error(
+          `Error updating read marker for sender ${senderId}: ${readError.message}`
+        );
+      }
+
       // Enviar notificaciones a todos los miembros excepto el remitente
       try {
         const sender = group.members.find((m) => m.id === senderId);
-        const otherMembers = group.members.filter((m) => m.id !== senderId);
+        const otherMembers = group.members.filter(
+          (m) => m.id !== senderId && !hiddenUserIds.has(m.id)
+        );
 
         logger.info(
           `[Group Message] Sending notifications to ${otherMembers.length} members`
@@ -74,15 +145,9 @@ class GroupMessageService {
 
       return {
         success: true,
-        data: {
-          id: message.id,
-          groupId: message.groupId,
-          senderId: message.senderId,
-          senderEmail: message.sender?.email,
-          content: message.content,
-          createdAt: message.createdAt,
-        },
+        data: this.formatMessage(message),
         message: "Mensaje enviado exitosamente",
+        duplicate: false,
       };
     } catch (err) {
       logger.error(`Error sending group message: ${err.message}`);
@@ -91,54 +156,85 @@ class GroupMessageService {
   }
 
   /**
-   * Obtiene el historial de mensajes de un grupo
+   * Obtiene el historial de mensajes de un grupo.
+   * Si se indica `before` se usa paginación por cursor (mensajes anteriores
+   * al mensaje indicado); en caso contrario se mantiene la paginación por offset.
    * @param {string} groupId - ID del grupo
    * @param {string} userId - ID del usuario que solicita
    * @param {number} limit - Número de mensajes
    * @param {number} offset - Offset para paginación
+   * @param {string|null} before - ID del mensaje usado como cursor
    * @returns {Promise<Object>} - { success, data }
    */
-  async getGroupMessages(groupId, userId, limit = 50, offset = 0) {
+  async getGroupMessages(groupId, userId, limit = 50, offset = 0, before = null) {
     try {
-      // Verificar que el grupo existe
-      const group = await groupRepository.findById(groupId);
-      if (!group) {
-        const error = new Error("El grupo ya no existe.");
-        error.status = 404;
-        throw error;
-      }
+      const group = await this.assertMember(groupId, userId);
+      // Política de conservación del chat, visible para todos los miembros
+      const retention = chatRetentionService.describe(group);
 
-      // Verificar que el usuario es miembro del grupo
-      const isMember = group.members?.some((member) => member.id === userId);
-      if (!isMember) {
-        const error = new Error("No eres miembro de este grupo");
-        error.status = 403;
-        throw error;
+      const readMarker = await groupMessageReadRepository.findByGroupAndUser(
+        groupId,
+        userId
+      );
+      const unreadCount = await groupMessageRepository.countUnread(
+        groupId,
+        userId,
+        readMarker?.lastReadAt
+      );
+      // Los mensajes de usuarios bloqueados (en cualquier sentido) se ocultan en la consulta
+      const total = await groupMessageRepository.countByGroupId(groupId, userId);
+
+      if (before !== null && before !== undefined) {
+        const cursorMessage = before
+          ? await groupMessageRepository.findByIdInGroup(groupId, before)
+          : null;
+        if (before && !cursorMessage) {
+          const error = new Error("Cursor inválido");
+          error.status = 400;
+          error.errorCode = "INVALID_CURSOR";
+          throw error;
+        }
+
+        // Pedimos uno extra para saber si hay más páginas
+        const page = await groupMessageRepository.findPageBefore(groupId, {
+          before: cursorMessage,
+          limit: limit + 1,
+          viewerId: userId,
+        });
+        const hasMore = page.length > limit;
+        const messages = hasMore ? page.slice(1) : page;
+
+        return {
+          success: true,
+          data: {
+            messages: messages.map((msg) => this.formatMessage(msg)),
+            total,
+            hasMore,
+            nextCursor: hasMore && messages.length > 0 ? messages[0].id : null,
+            lastReadMessageId: readMarker?.lastReadMessageId || null,
+            unreadCount,
+            retention,
+          },
+        };
       }
 
       // Obtener mensajes
       const messages = await groupMessageRepository.findByGroupId(
         groupId,
         limit,
-        offset
+        offset,
+        userId
       );
-      const total = await groupMessageRepository.countByGroupId(groupId);
-
-      const formattedMessages = messages.map((msg) => ({
-        id: msg.id,
-        groupId: msg.groupId,
-        senderId: msg.senderId,
-        senderEmail: msg.sender?.email,
-        content: msg.content,
-        createdAt: msg.createdAt,
-      }));
 
       return {
         success: true,
         data: {
-          messages: formattedMessages,
+          messages: messages.map((msg) => this.formatMessage(msg)),
           total,
           hasMore: offset + messages.length < total,
+          lastReadMessageId: readMarker?.lastReadMessageId || null,
+          unreadCount,
+          retention,
         },
       };
     } catch (err) {
@@ -146,6 +242,199 @@ class GroupMessageService {
       throw err;
     }
   }
+
+  /**
+   * Marca los mensajes de un grupo como leídos hasta un mensaje dado
+   * @param {string} groupId - ID del grupo
+   * @param {string} userId - ID del usuario
+   * @param {string|null} messageId - Último mensaje leído (por defecto el más reciente)
+   * @returns {Promise<Object>} - { success, data, message }
+   */
+  async markAsRead(groupId, userId, messageId = null) {
+    try {
+      await this.assertMember(groupId, userId);
+
+      const message = messageId
+        ? await groupMessageRepository.findByIdInGroup(groupId, messageId)
+        : await groupMessageRepository.findLastByGroupId(groupId);
+
+      if (!message) {
+        if (messageId) {
+          const error = new Error("Mensaje no encontrado");
+          error.status = 404;
+          error.errorCode = "MESSAGE_NOT_FOUND";
+          throw error;
+        }
+        return {
+          success: true,
+          data: { groupId, userId, lastReadMessageId: null, lastReadAt: null },
+          message: "No hay mensajes para marcar como leídos",
+        };
+      }
+
+      const marker = await groupMessageReadRepository.markRead(
+        groupId,
+        userId,
+        message
+      );
+
+      badgeService.invalidate(userId);
+      // Sincronizar el badge en los demás dispositivos del usuario
+      readStateService.publish(userId, {
+        scope: "group",
+        groupId,
+        lastReadMessageId: marker.lastReadMessageId,
+        lastReadAt: marker.lastReadAt,
+      });
+
+      // Avisar al resto del grupo (confirmaciones de lectura en tiempo real)
+      try {
+        publish(`group_${groupId}`, "group_messages_read", {
+          groupId,
+          userId,
+          lastReadMessageId: marker.lastReadMessageId,
+          lastReadAt: marker.lastReadAt,
+        });
+      } catch (socketError) {
+        logger.warn(
+          `Could not emit read receipt for group ${groupId}: ${socketError.message}`
+        );
+      }
+
+      return {
+        success: true,
+        data: {
+          groupId,
+          userId,
+          lastReadMessageId: marker.lastReadMessageId,
+          lastReadAt: marker.lastReadAt,
+        },
+        message: "Mensajes marcados como leídos",
+      };
+    } catch (err) {
+      logger.error(`Error marking group messages as read: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Obtiene los marcadores de lectura de todos los miembros de un grupo
+   * @param {string} groupId - ID del grupo
+   * @param {string} userId - ID del usuario que solicita
+   * @returns {Promise<Object>} - { success, data }
+   */
+  async getReadReceipts(groupId, userId) {
+    try {
+      await this.assertMember(groupId, userId);
+
+      const markers = await groupMessageReadRepository.findByGroupId(groupId);
+
+      return {
+        success: true,
+        data: markers.map((marker) => ({
+          userId: marker.userId,
+          userEmail: marker.user?.email,
+          lastReadMessageId: marker.lastReadMessageId,
+          lastReadAt: marker.lastReadAt,
+        })),
+      };
+    } catch (err) {
+      logger.error(`Error getting group read receipts: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Borra un mensaje del grupo (borrado lógico, un administrador puede restaurarlo)
+   * @param {string} groupId - ID del grupo
+   * @param {string} messageId - ID del mensaje
+   * @param {string} userId - Autor del mensaje o administrador del grupo
+   * @returns {Promise<Object>} - { success, data, message }
+   */
+  async deleteMessage(groupId, messageId, userId) {
+    try {
+      const group = await this.assertMember(groupId, userId);
+
+      const message = await groupMessageRepository.findByIdInGroup(groupId, messageId);
+      if (!message) {
+        const error = new Error("Mensaje no encontrado");
+        error.status = 404;
+        error.errorCode = "MESSAGE_NOT_FOUND";
+        throw error;
+      }
+      if (message.senderId !== userId && group.adminId !== userId) {
+        const error = new Error("Solo el autor o el administrador del grupo pueden borrar el mensaje");
+        error.status = 403;
+        throw error;
+      }
+
+      await groupMessageRepository.softDelete(messageId);
+      logger.info(`Group message ${messageId} deleted by user ${userId}`);
+
+      try {
+        publish(`group_${groupId}`, "group_message_deleted", {
+          groupId,
+          messageId,
+        });
+      } catch (socketError) {
+        logger.warn(
+          `Could not emit message deletion for group ${groupId}: ${socketError.message}`
+        );
+      }
+
+      return {
+        success: true,
+        data: { groupId, messageId },
+        message: "Mensaje eliminado",
+      };
+    } catch (err) {
+      logger.error(`Error deleting group message: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Verifica que el grupo exista y que el usuario sea miembro
+   * @param {string} groupId - ID del grupo
+   * @param {string} userId - ID del usuario
+   * @returns {Promise<Object>} Grupo encontrado
+   */
+  async assertMember(groupId, userId) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      const error = new Error("El grupo ya no existe.");
+      error.status = 404;
+      error.errorCode = "GROUP_DELETED";
+      throw error;
+    }
+
+    const isMember = group.members?.some((member) => member.id === userId);
+    if (!isMember) {
+      const error = new Error("No eres miembro de este grupo");
+      error.status = 403;
+      error.errorCode = "NOT_GROUP_MEMBER";
+      throw error;
+    }
+
+    return group;
+  }
+
+  /**
+   * Da formato a un mensaje para la respuesta de la API
+   * @param {Object} msg - Mensaje de la base de datos
+   * @returns {Object} Mensaje formateado
+   */
+  formatMessage(msg) {
+    return {
+      id: msg.id,
+      groupId: msg.groupId,
+      senderId: msg.senderId,
+      senderEmail: msg.sender?.email,
+      content: msg.content,
+      clientMessageId: msg.clientMessageId || null,
+      createdAt: msg.createdAt,
+    };
+  }
 }
 
 export default new GroupMessageService();
@@ -0,0 +1,594 @@
+import groupRepository from "../repository/group.repository.js";
+import groupSuccessionRepository from "../repository/groupSuccession.repository.js";
+import UserRepository from "../repository/user.repository.js";
+import txManager from "../repository/transactionManager.js";
+import jobQueueService from "./jobQueue.service.js";
+import searchService from "./search.service.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { GROUP_SUCCESSION_JOB } from "../jobs/groupSuccession.job.js";
+import { createAndEmitNotification } from "../socket/notification.emitter.js";
+
+const userRepository = new UserRepository();
+
+const HOUR_MS = 60 * 60 * 1000;
+const DAY_MS = 24 * HOUR_MS;
+
+/**
+ * Hands a trip over when its organizer is suspended or abandons it before
+ * departure. Ownership is offered to each co-organizer and then to the most
+ * senior member, one at a time; if nobody takes it, the members vote. Every
+ * step is notified to the trip.
+ */
+class GroupSuccessionService {
+  /**
+   * Starts the succession of a trip, unless one is already in progress
+   * @param {string} groupId
+   * @param {string} reason - suspended | inactive
+   * @returns {Promise<Object|null>} Succession, or null if none was started
+   */
+  async startSuccession(groupId, reason) {
+    return await txManager.run(async () => {
+      await groupRepository.lockForUpdate(groupId);
+      if (await groupSuccessionRepository.findActiveByGroup(groupId)) {
+        return null;
+      }
+      const group = await groupRepository.findById(groupId);
+      if (!group || group.deletedAt) {
+        return null;
+      }
+
+      const eligible = await groupSuccessionRepository.findEligibleMembers(groupId, group.adminId);
+      if (eligible.length === 0) {
+        logger.warn(`Group ${groupId} needs a new organizer (${reason}) but has no eligible members`);
+        return null;
+      }
+      // Every co-organizer, then the most senior member
+      const coOrganizers = eligible.filter((member) => member.coOrganizer);
+      const senior = eligible.find((member) => !member.coOrganizer);
+      const candidates = [...coOrganizers, ...(senior ? [senior] : [])].map((member) => member.userId);
+
+      const succession = await groupSuccessionRepository.create({
+        groupId,
+        previousAdminId: group.adminId,
+        reason,
+        pendingCandidateIds: candidates,
+      });
+      logger.info(`Succession ${succession.id} of group ${groupId} started (${reason})`);
+
+      await this.notifyMembers(group, [group.adminId], {
+        type: "GROUP_SUCCESSION_STARTED",
+        title: "El viaje busca nuevo organizador",
+        message:
+          reason === "suspended"
+            ? `El organizador de "${group.name}" ya no puede gestionarlo. Vamos a ofrecerle el viaje a otro miembro`
+            : `El organizador de "${group.name}" no tiene actividad hace días. Vamos a ofrecerle el viaje a otro miembro`,
+        data: { groupId, groupName: group.name, successionId: succession.id, reason },
+      });
+      return await this.offerNext(succession, group);
+    });
+  }
+
+  /**
+   * Starts the succession of every ongoing trip of a suspended organizer
+   * @param {string} userId
+   * @returns {Promise<number>} Successions started
+   */
+  async onOrganizerSuspended(userId) {
+    const groupIds = await groupSuccessionRepository.findOngoingGroupIdsByAdmin(userId);
+    let started = 0;
+    for (const groupId of groupIds) {
+      try {
+        if (await this.startSuccession(groupId, "suspended")) {
+          started++;
+        }
+      } catch (error) {
+        logger.error(`Could not start succession of group ${groupId}: ${error.message}`);
+      }
+    }
+    return started;
+  }
+
+  /**
+   * A reinstated organizer keeps the trips not handed over yet
+   * @param {string} userId
+   * @returns {Promise<number>} Successions cancelled
+   */
+  async onOrganizerReinstated(userId) {
+    const successions = await groupSuccessionRepository.findActiveByPreviousAdmin(userId, "suspended");
+    let cancelled = 0;
+    for (const succession of successions) {
+      if (await this.cancel(succession, "El organizador volvió a estar disponible")) {
+        cancelled++;
+      }
+    }
+    return cancelled;
+  }
+
+  /**
+   * Daily check for organizers not seen for a while whose trip departs soon
+   * @returns {Promise<number>} Successions started
+   */
+  async startForInactiveOrganizers() {
+    const { inactiveDays, departureWindowDays } = config.succession;
+    const groups = await groupSuccessionRepository.findAbandonedGroups(
+      new Date(Date.now() - inactiveDays * DAY_MS),
+      new Date(Date.now() + departureWindowDays * DAY_MS)
+    );
+    let started = 0;
+    for (const { groupId } of groups) {
+      try {
+        if (await this.startSuccession(groupId, "inactive")) {
+          started++;
+        }
+      } catch (error) {
+        logger.error(`Could not start succession of group ${groupId}: ${error.message}`);
+      }
+    }
+    return started;
+  }
+
+  /**
+   * Moves a succession on once its offer expired or its voting ended
+   * @param {string} successionId
+   */
+  async advance(successionId) {
+    const succession = await groupSuccessionRepository.findById(successionId);
+    if (!succession) {
+      return;
+    }
+
+    await txManager.run(async () => {
+      await groupRepository.lockForUpdate(succession.groupId);
+      // Read again under the lock: an accept or cancel may have resolved it
+      const current = await groupSuccessionRepository.findById(successionId);
+      if (!["offering", "voting"].includes(current.status)) {
+        return;
+      }
+      // An inactive organizer that shows up again keeps the trip
+      if (current.reason === "inactive") {
+        const previousAdmin = await userRepository.findById(current.previousAdminId);
+        if (previousAdmin?.lastSeenAt && previousAdmin.lastSeenAt > current.createdAt) {
+          await this.cancel(current, "El organizador volvió a estar activo");
+          return;
+        }
+      }
+
+      const group = await groupRepository.findById(current.groupId);
+      const now = new Date();
+      if (current.status === "offering" && current.offerExpiresAt <= now) {
+        logger.info(`Succession ${successionId}: offer to ${current.currentCandidateId} expired`);
+        await this.offerNext(
+          await groupSuccessionRepository.update(successionId, {
+            declinedIds: [...current.declinedIds, current.currentCandidateId],
+          }),
+          group
+        );
+      } else if (current.status === "voting" && current.votingEndsAt <= now) {
+        await this.closeVoting(current, group);
+      }
+    });
+  }
+
+  /**
+   * Current succession of a trip, for its members and previous organizer
+   * @param {string} groupId
+   * @param {string} userId
+   * @returns {Promise<Object>} - { success, data }
+   */
+  async getSuccession(groupId, userId) {
+    try {
+      const group = await this.getGroupOrFail(groupId);
+      const succession = await groupSuccessionRepository.findActiveByGroup(groupId);
+      const isMember = group.members.some((member) => member.id === userId);
+      if (!isMember && group.adminId !== userId && succession?.previousAdminId !== userId) {
+        const error = new Error("Solo los miembros del grupo pueden ver la sucesión");
+        error.status = 403;
+        throw error;
+      }
+      return { success: true, data: succession ? await this.formatSuccession(succession, userId) : null };
+    } catch (err) {
+      logger.error(`Error getting succession of group ${groupId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Accepts or declines the offer to organize the trip
+   * @param {string} groupId
+   * @param {string} userId - Candidate the trip is offered to
+   * @param {boolean} accept
+   * @returns {Promise<Object>} - { success, data, message }
+   */
+  async respondToOffer(groupId, userId, accept) {
+    try {
+      if (typeof accept !== "boolean") {
+        const error = new Error("accept debe ser true o false");
+        error.status = 400;
+        throw error;
+      }
+      const updated = await txManager.run(async () => {
+        await groupRepository.lockForUpdate(groupId);
+        const succession = await groupSuccessionRepository.findActiveByGroup(groupId);
+        if (!succession || succession.status !== "offering" || succession.currentCandidateId !== userId) {
+          const error = new Error("No tienes una oferta pendiente para organizar este viaje");
+          error.status = 409;
+          throw error;
+        }
+        const group = await groupRepository.findById(groupId);
+        if (accept) {
+          return await this.complete(succession, group, userId);
+        }
+        logger.info(`Succession ${succession.id}: ${userId} declined`);
+        return await this.offerNext(
+          await groupSuccessionRepository.update(succession.id, {
+            declinedIds: [...succession.declinedIds, userId],
+          }),
+          group
+        );
+      });
+
+      return {
+        success: true,
+        data: await this.formatSuccession(updated, userId),
+        message: accept ? "¡Ahora eres el organizador del viaje!" : "Rechazaste la organización del viaje",
+      };
+    } catch (err) {
+      logger.error(`Error answering succession offer of group ${groupId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Votes for the new organizer; voting again changes the vote
+   * @param {string} groupId
+   * @param {string} voterId
+   * @param {string} candidateId
+   * @returns {Promise<Object>} - { success, data, message }
+   */
+  async vote(groupId, voterId, candidateId) {
+    try {
+      const group = await this.getGroupOrFail(groupId);
+      // Under the group lock, so a vote cannot land after the voting closed
+      const succession = await txManager.run(async () => {
+        await groupRepository.lockForUpdate(groupId);
+        const succession = await groupSuccessionRepository.findActiveByGroup(groupId);
+        if (!succession || succession.status !== "voting") {
+          const error = new Error("El viaje no tiene una votación abierta");
+          error.status = 409;
+          throw error;
+        }
+        if (voterId === succession.previousAdminId || !group.members.some((member) => member.id === voterId)) {
+          const error = new Error("Solo los miembros del grupo pueden votar");
+          error.status = 403;
+          throw error;
+        }
+        const candidates = await this.findVotingCandidates(succession);
+        if (!candidates.some((candidate) => candidate.userId === candidateId)) {
+          const error = new Error("El candidato no puede organizar el viaje");
+          error.status = 400;
+          throw error;
+        }
+
+        await groupSuccessionRepository.upsertVote(succession.id, voterId, candidateId);
+        return succession;
+      });
+      return {
+        success: true,
+        data: await this.formatSuccession(succession, voterId),
+        message: "Voto registrado",
+      };
+    } catch (err) {
+      logger.error(`Error voting in succession of group ${groupId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * The previous organizer keeps the trip and stops the succession
+   * @param {string} groupId
+   * @param {string} userId
+   * @returns {Promise<Object>} - { success, message }
+   */
+  async cancelSuccession(groupId, userId) {
+    try {
+      const succession = await groupSuccessionRepository.findActiveByGroup(groupId);
+      if (!succession) {
+        const error = new Error("El viaje no tiene una sucesión en curso");
+        error.status = 404;
+        throw error;
+      }
+      if (succession.previousAdminId !== userId) {
+        const error = new Error("Solo el organizador puede cancelar la sucesión");
+        error.status = 403;
+        throw error;
+      }
+      if (!(await this.cancel(succession, "El organizador sigue a cargo del viaje"))) {
+        const error = new Error("La sucesión ya terminó");
+        error.status = 409;
+        throw error;
+      }
+      return { success: true, message: "Sucesión cancelada. Sigues a cargo del viaje" };
+    } catch (err) {
+      logger.error(`Error cancelling succession of group ${groupId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Marks or unmarks a member as co-organizer (admin only)
+   * @param {string} groupId
+   * @param {string} userId
+   * @param {boolean} coOrganizer
+   * @param {string} requesterId
+   * @returns {Promise<Object>} - { success, data, message }
+   */
+  async setCoOrganizer(groupId, userId, coOrganizer, requesterId) {
+    try {
+      const group = await this.getGroupOrFail(groupId);
+      if (group.adminId !== requesterId) {
+        const error = new Error("Solo el administrador puede elegir co-organizadores");
+        error.status = 403;
+        throw error;
+      }
+      if (typeof coOrganizer !== "boolean") {
+        const error = new Error("coOrganizer debe ser true o false");
+        error.status = 400;
+        throw error;
+      }
+      if (userId === group.adminId || !group.members.some((member) => member.id === userId)) {
+        const error = new Error("El usuario no es miembro del grupo");
+        error.status = 404;
+        error.errorCode = "USER_NOT_GROUP_MEMBER";
+        throw error;
+      }
+
+      await groupSuccessionRepository.setCoOrganizer(groupId, userId, coOrganizer);
+      return {
+        success: true,
+        data: { groupId, userId, coOrganizer },
+        message: coOrganizer ? "Co-organizador agregado" : "Co-organizador quitado",
+      };
+    } catch (err) {
+      logger.error(`Error setting co-organizer ${userId} in group ${groupId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Offers the trip to the next candidate still eligible, or opens the
+   * voting when there is none left. Call inside txManager.run().
+   * @returns {Promise<Object>} Updated succession
+   */
+  async offerNext(succession, group) {
+    const eligibleIds = (
+      await groupSuccessionRepository.findEligibleMembers(group.id, succession.previousAdminId)
+    ).map((member) => member.userId);
+    const pending = succession.pendingCandidateIds.filter(
+      (id) => eligibleIds.includes(id) && !succession.declinedIds.includes(id)
+    );
+    const [candidateId, ...rest] = pending;
+
+    if (candidateId) {
+      const offerExpiresAt = new Date(Date.now() + config.succession.offerHours * HOUR_MS);
+      const updated = await groupSuccessionRepository.update(succession.id, {
+        status: "offering",
+        currentCandidateId: candidateId,
+        pendingCandidateIds: rest,
+        offerExpiresAt,
+      });
+      await jobQueueService.enqueue(GROUP_SUCCESSION_JOB, { successionId: succession.id }, { runAt: offerExpiresAt });
+      await this.notify(candidateId, {
+        type: "GROUP_SUCCESSION_OFFER",
+        title: "¿Quieres organizar el viaje?",
+        message: `"${group.name}" necesita un nuevo organizador y te lo ofrecemos a ti. Responde antes de ${config.succession.offerHours} horas`,
+        data: { groupId: group.id, groupName: group.name, successionId: succession.id, offerExpiresAt },
+      });
+      return updated;
+    }
+
+    const candidates = eligibleIds.filter((id) => !succession.declinedIds.includes(id));
+    if (candidates.length === 0) {
+      return await this.fail(succession, group);
+    }
+    const votingEndsAt = new Date(Date.now() + config.succession.votingHours * HOUR_MS);
+    const updated = await groupSuccessionRepository.update(succession.id, {
+      status: "voting",
+      currentCandidateId: null,
+      pendingCandidateIds: [],
+      offerExpiresAt: null,
+      votingEndsAt,
+    });
+    await jobQueueService.enqueue(GROUP_SUCCESSION_JOB, { successionId: succession.id }, { runAt: votingEndsAt });
+    await this.notifyMembers(group, [succession.previousAdminId], {
+      type: "GROUP_SUCCESSION_VOTING",
+      title: "Vota al nuevo organizador",
+      message: `Nadie aceptó organizar "${group.name}". Vota quién debería hacerlo en las próximas ${config.succession.votingHours} horas`,
+      data: { groupId: group.id, groupName: group.name, successionId: succession.id, votingEndsAt },
+    });
+    logger.info(`Succession ${succession.id}: voting open until ${votingEndsAt.toISOString()}`);
+    return updated;
+  }
+
+  /**
+   * Hands the trip to the most voted candidate; ties go to the most senior
+   * one. Call inside txManager.run().
+   */
+  async closeVoting(succession, group) {
+    const candidates = await this.findVotingCandidates(succession);
+    const votes = await groupSuccessionRepository.countVotes(succession.id);
+    // Candidates come ordered by seniority, so the first with the top count wins ties
+    const counts = new Map(votes.map((row) => [row.candidateId, row.votes]));
+    const top = Math.max(0, ...candidates.map((candidate) => counts.get(candidate.userId) || 0));
+    const winner = top > 0 ? candidates.find((candidate) => counts.get(candidate.userId) === top) : null;
+
+    if (!winner) {
+      return await this.fail(succession, group);
+    }
+    logger.info(`Succession ${succession.id}: ${winner.userId} elected with ${top} votes`);
+    return await this.complete(succession, group, winner.userId);
+  }
+
+  /**
+   * Makes the user the organizer of the trip. Call inside txManager.run().
+   */
+  async complete(succession, group, newAdminId) {
+    await groupRepository.update(group.id, { adminId: newAdminId });
+    const updated = await groupSuccessionRepository.update(succession.id, {
+      status: "completed",
+      newAdminId,
+      currentCandidateId: null,
+      offerExpiresAt: null,
+      resolvedAt: new Date(),
+    });
+    await searchService.scheduleIndex("trip", group.id);
+    logger.info(`Group ${group.id} handed over from ${succession.previousAdminId} to ${newAdminId}`);
+
+    const newAdmin = await userRepository.findById(newAdminId);
+    const data = { groupId: group.id, groupName: group.name, successionId: succession.id, newAdminId };
+    await this.notify(newAdminId, {
+      type: "GROUP_SUCCESSION_COMPLETED",
+      title: "Ahora organizas el viaje",
+      message: `Eres el nuevo organizador de "${group.name}"`,
+      data,
+    });
+    await this.notifyMembers(group, [newAdminId], {
+      type: "GROUP_SUCCESSION_COMPLETED",
+      title: "El viaje tiene nuevo organizador",
+      message: `${newAdmin?.name || newAdmin?.email || "Un miembro"} es el nuevo organizador de "${group.name}"`,
+      data,
+    });
+    return updated;
+  }
+
+  /**
+   * Nobody is left to take over; the trip stays as it was
+   */
+  async fail(succession, group) {
+    const updated = await groupSuccessionRepository.update(succession.id, {
+      status: "failed",
+      currentCandidateId: null,
+      offerExpiresAt: null,
+      resolvedAt: new Date(),
+    });
+    logger.warn(`Succession ${succession.id} of group ${group.id} ended without a new organizer`);
+    await this.notifyMembers(group, [succession.previousAdminId], {
+      type: "GROUP_SUCCESSION_FAILED",
+      title: "El viaje sigue sin organizador",
+      message: `Nadie tomó la organización de "${group.name}". Escríbenos a soporte si necesitas ayuda con el viaje`,
+      data: { groupId: group.id, groupName: group.name, successionId: succession.id },
+    });
+    return updated;
+  }
+
+  /**
+   * Stops a succession under the group lock, unless an accept or the voting
+   * resolved it first
+   * @returns {Promise<boolean>} Whether it was cancelled
+   */
+  async cancel(succession, message) {
+    return await txManager.run(async () => {
+      await groupRepository.lockForUpdate(succession.groupId);
+      const current = await groupSuccessionRepository.findById(succession.id);
+      if (!["offering", "voting"].includes(current?.status)) {
+        return false;
+      }
+      const group = await groupRepository.findById(succession.groupId);
+      await groupSuccessionRepository.update(succession.id, {
+        status: "cancelled",
+        currentCandidateId: null,
+        offerExpiresAt: null,
+        votingEndsAt: null,
+        resolvedAt: new Date(),
+      });
+      logger.info(`Succession ${succession.id} of group ${succession.groupId} cancelled`);
+      if (group) {
+        await this.notifyMembers(group, [], {
+          type: "GROUP_SUCCESSION_CANCELLED",
+          title: "Se canceló el cambio de organizador",
+          message: `${message}: "${group.name}" mantiene su organizador`,
+          data: { groupId: group.id, groupName: group.name, successionId: succession.id },
+        });
+      }
+      return true;
+    });
+  }
+
+  /**
+   * Members that can be voted: eligible and not among those who declined
+   */
+  async findVotingCandidates(succession) {
+    const eligible = await groupSuccessionRepository.findEligibleMembers(
+      succession.groupId,
+      succession.previousAdminId
+    );
+    return eligible.filter((member) => !succession.declinedIds.includes(member.userId));
+  }
+
+  async notifyMembers(group, exceptIds, notification) {
+    for (const member of group.members || []) {
+      if (!exceptIds.includes(member.id)) {
+        await this.notify(member.id, notification);
+      }
+    }
+  }
+
+  async notify(userId, notification) {
+    try {
+      await createAndEmitNotification({ userId, ...notification });
+    } catch (error) {
+      // Inside a transaction the failed insert has aborted it
+      if (txManager.active) {
+        throw error;
+      }
+      logger.error(`Failed to send ${notification.type} to ${userId}: ${error.message}`);
+    }
+  }
+
+  async getGroupOrFail(groupId) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      const error = new Error("Grupo no encontrado");
+      error.status = 404;
+      error.errorCode = "GROUP_NOT_FOUND";
+      throw error;
+    }
+    return group;
+  }
+
+  async formatSuccession(succession, userId) {
+    const formatted = {
+      id: succession.id,
+      groupId: succession.groupId,
+      reason: succession.reason,
+      status: succession.status,
+      previousAdminId: succession.previousAdminId,
+      newAdminId: succession.newAdminId,
+      offeredToMe: succession.status === "offering" && succession.currentCandidateId === userId,
+      offerExpiresAt: succession.offerExpiresAt,
+      votingEndsAt: succession.votingEndsAt,
+      createdAt: succession.createdAt,
+      resolvedAt: succession.resolvedAt,
+    };
+    if (succession.status !== "voting") {
+      return formatted;
+    }
+    const candidates = await this.findVotingCandidates(succession);
+    const counts = new Map(
+      (await groupSuccessionRepository.countVotes(succession.id)).map((row) => [row.candidateId, row.votes])
+    );
+    const myVote = await groupSuccessionRepository.findVote(succession.id, userId);
+    return {
+      ...formatted,
+      candidates: candidates.map((candidate) => ({
+        userId: candidate.userId,
+        name: candidate.name,
+        coOrganizer: candidate.coOrganizer,
+        votes: counts.get(candidate.userId) || 0,
+      })),
+      myVote: myVote?.candidateId || null,
+    };
+  }
+}
+
+export default new GroupSuccessionService();
@@ -0,0 +1,87 @@
+import idempotencyKeyRepository from "../repository/idempotencyKey.repository.js";
+import { hashPayload } from "./jobQueue.service.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { AppError } from "../utils/customErrors.js";
+
+/**
+ * Idempotency keys of mutating requests. The first request with a key runs
+ * and its response is stored; retries with the same key get that response
+ * back instead of running again.
+ */
+class IdempotencyService {
+  /**
+   * Takes the key for a request, or tells what to answer instead
+   * @param {string} scope - User ID
+   * @param {string} key - Idempotency-Key header
+   * @param {Object} request - { method, path, body }
+   * @returns {Promise<Object>} - { claimed: true } or { claimed: false, replay: { status, body } }
+   */
+  async begin(scope, key, { method, path, body }) {
+    const requestHash = hashPayload({ method, path, body: body ?? null });
+    const now = Date.now();
+    const claimed = await idempotencyKeyRepository.claim({
+      scope,
+      key,
+      requestHash,
+      lockedUntil: new Date(now + config.idempotency.lockSeconds * 1000),
+      expiresAt: new Date(now + config.idempotency.ttlHours * 60 * 60 * 1000),
+    });
+    if (claimed) {
+      return { claimed: true };
+    }
+
+    const existing = await idempotencyKeyRepository.find(scope, key);
+    if (!existing) {
+      // Removed between the insert and the read: just run the request
+      return await this.begin(scope, key, { method, path, body });
+    }
+    if (existing.requestHash !== requestHash) {
+      throw new AppError(
+        "La clave de idempotencia ya se usó con otra solicitud",
+        422,
+        "IDEMPOTENCY_KEY_REUSED"
+      );
+    }
+    if (existing.status !== "completed") {
+      throw new AppError(
+        "La solicitud original todavía se está procesando. Reintenta en unos segundos.",
+        409,
+        "IDEMPOTENCY_REQUEST_IN_PROGRESS"
+      );
+    }
+    return { claimed: false, replay: { status: existing.responseStatus, body: existing.responseBody } };
+  }
+
+  /**
+   * Stores the response of a request that held a key. Server errors release
+   * the key instead, so the retry runs the request again.
+   * @param {string} scope
+   * @param {string} key
+   * @param {Object} response - { status, body }
+   */
+  async finish(scope, key, { status, body }) {
+    try {
+      if (status >= 500 || status === 429) {
+        await idempotencyKeyRepository.release(scope, key);
+      } else {
+        await idempotencyKeyRepository.complete(scope, key, { responseStatus: status, responseBody: body });
+      }
+    } catch (error) {
+      // The key stays locked until lockedUntil, then the retry runs again
+      logger.error(`Could not store the response of idempotency key ${key}: ${error.message}`);
+    }
+  }
+
+  /**
+   * Removes keys past their TTL (daily maintenance)
+   * @returns {Promise<number>}
+   */
+  async purgeExpired() {
+    const removed = await idempotencyKeyRepository.deleteExpired();
+    logger.info(`Purged ${removed} expired idempotency keys`);
+    return removed;
+  }
+}
+
+export default new IdempotencyService();
@@ -1,5 +1,6 @@
 import itineraryRepository from "../repository/itinerary.repository.js";
 import logger from "../config/logger.js";
+import legalHoldService from "./legalHold.service.js";
 
 class ItineraryService {
   /**
@@ -293,6 +294,12 @@ class ItineraryService {
 
       const updatedItinerary = await itineraryRepository.updateItinerary(itineraryId, updateData);
 
+      // Item dates drive the travel month and duration of the groups using this itinerary
+      if (updateData.items) {
+        const { default: groupRepository } = await import("../repository/group.repository.js");
+        await groupRepository.refreshTravelDimensions({ itineraryId });
+      }
+
       logger.info(`Itinerary updated successfully: ${itineraryId}`);
 
       return {
@@ -363,6 +370,7 @@ class ItineraryService {
         };
       }
 
+      await legalHoldService.assertNotOnHold(userId);
       const deleted = await itineraryRepository.deleteItinerary(itineraryId);
 
       if (!deleted) {
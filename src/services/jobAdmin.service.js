@@ -0,0 +1,282 @@
+import jobRepository from "../repository/job.repository.js";
+import jobQueueService, { previewPayload } from "./jobQueue.service.js";
+import auditService, { AUDIT_ACTIONS } from "./audit.service.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { ValidationError, NotFoundError, AppError } from "../utils/customErrors.js";
+
+const UUID_REGEX = /^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$/i;
+const MAX_REASON_LENGTH = 500;
+const DEFAULT_PAGE_SIZE = 50;
+const MAX_PAGE_SIZE = 200;
+const DEFAULT_RATE_HOURS = 24;
+const MAX_RATE_HOURS = 7 * 24;
+
+/**
+ * Inspection of and manual intervention on the background job queue
+ * (a queue is the set of jobs of one type)
+ */
+class JobAdminService {
+  /**
+   * Depth of every queue
+   * @returns {Promise<Array>} - [{ type, pending, running, failed, quarantined, oldestDueAt,
+   *                               paused, pausedAt, pauseReason, handled }]
+   */
+  async getQueues() {
+    const [rows, pauses] = await Promise.all([
+      jobRepository.countByTypeAndStatus(),
+      jobRepository.findPauses(),
+    ]);
+    const types = new Set([
+      ...jobQueueService.handlers.keys(),
+      ...rows.map((row) => row.type),
+      ...pauses.map((pause) => pause.type),
+    ]);
+    const pausesByType = new Map(pauses.map((pause) => [pause.type, pause]));
+
+    return [...types].sort().map((type) => {
+      const counts = rows.filter((row) => row.type === type);
+      const count = (status) => counts.find((row) => row.status === status)?.count || 0;
+      const pause = pausesByType.get(type);
+      return {
+        type,
+        pending: count("pending"),
+        running: count("running"),
+        failed: count("failed"),
+        quarantined: count("quarantined"),
+        oldestDueAt: counts.find((row) => row.oldestDueAt)?.oldestDueAt || null,
+        paused: Boolean(pause),
+        pausedAt: pause?.pausedAt || null,
+        pauseReason: pause?.reason || null,
+        // false for types left in the table by code that no longer exists
+        handled: jobQueueService.handlers.has(type),
+      };
+    });
+  }
+
+  /**
+   * Failed or quarantined jobs with a preview of their payload
+   * @param {string} status - "failed" | "quarantined"
+   * @param {Object} filters - { type, limit, offset }
+   * @returns {Promise<Object>} - { jobs, total, limit, offset }
+   */
+  async listStuck(status, { type, limit, offset } = {}) {
+    const pageSize = limit === undefined ? DEFAULT_PAGE_SIZE : parseInt(limit, 10);
+    const skip = offset === undefined ? 0 : parseInt(offset, 10);
+    if (!Number.isInteger(pageSize) || pageSize < 1 || pageSize > MAX_PAGE_SIZE) {
+      throw new ValidationError(`limit debe estar entre 1 y ${MAX_PAGE_SIZE}`);
+    }
+    if (!Number.isInteger(skip) || skip < 0) {
+      throw new ValidationError("offset debe ser un entero no negativo");
+    }
+
+    const { jobs, total } = await jobRepository.findStuck({
+      status,
+      type: type || undefined,
+      limit: pageSize,
+      offset: skip,
+    });
+    return {
+      jobs: jobs.map((job) => this.formatJob(job)),
+      total,
+      limit: pageSize,
+      offset: skip,
+    };
+  }
+
+  /**
+   * Puts a failed or quarantined job back in the queue
+   * @param {string} jobId
+   * @returns {Promise<Object>} Requeued job
+   */
+  async retryJob(jobId) {
+    await this.findStuckJob(jobId);
+    const [requeued] = await jobRepository.requeueStuck({ id: jobId });
+    if (!requeued) {
+      throw new AppError("El job ya no está fallido ni en cuarentena", 409, "JOB_NOT_FAILED");
+    }
+
+    logger.info(`[Jobs] Job ${jobId} requeued by an admin`);
+    const job = await jobRepository.findById(jobId);
+    await auditService.record({
+      action: AUDIT_ACTIONS.JOB_RETRIED,
+      targetType: "job",
+      targetId: jobId,
+      metadata: { type: job.type },
+    });
+    return this.formatJob(job);
+  }
+
+  /**
+   * Puts every failed (or quarantined) job of a type back in the queue
+   * @param {string} type
+   * @param {string} status - "failed" (default) | "quarantined"
+   * @returns {Promise<Object>} - { type, status, requeued }
+   */
+  async retryStuckOfType(type, status = "failed") {
+    this.validateType(type);
+    if (!["failed", "quarantined"].includes(status)) {
+      throw new ValidationError("status debe ser failed o quarantined");
+    }
+    const ids = await jobRepository.requeueStuck({ type, status });
+
+    logger.info(`[Jobs] ${ids.length} ${status} ${type} jobs requeued by an admin`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.JOB_RETRIED,
+      targetType: "job_queue",
+      targetId: type,
+      metadata: { status, requeued: ids.length },
+    });
+    return { type, status, requeued: ids.length };
+  }
+
+  /**
+   * Gives up on a failed or quarantined job for good
+   * @param {string} jobId
+   * @returns {Promise<Object>} Discarded job
+   */
+  async discardJob(jobId) {
+    await this.findStuckJob(jobId);
+    if (!(await jobRepository.discardStuck(jobId))) {
+      throw new AppError("El job ya no está fallido ni en cuarentena", 409, "JOB_NOT_FAILED");
+    }
+
+    logger.info(`[Jobs] Job ${jobId} discarded by an admin`);
+    const job = await jobRepository.findById(jobId);
+    await auditService.record({
+      action: AUDIT_ACTIONS.JOB_DISCARDED,
+      targetType: "job",
+      targetId: jobId,
+      metadata: { type: job.type, lastError: job.lastError?.slice(0, 200) || null },
+    });
+    return this.formatJob(job);
+  }
+
+  /**
+   * Share of failed attempts per job type against the error budget
+   * @param {Object} options - { hours }
+   * @returns {Promise<Object>} - { hours, errorBudget, types: [{ type, attempts, succeeded, failed,
+   *                              crashed, errorRate, budgetRemaining, budgetExhausted }] }
+   */
+  async getErrorRates({ hours } = {}) {
+    const windowHours = hours === undefined ? DEFAULT_RATE_HOURS : parseInt(hours, 10);
+    if (!Number.isInteger(windowHours) || windowHours < 1 || windowHours > MAX_RATE_HOURS) {
+      throw new ValidationError(`hours debe estar entre 1 y ${MAX_RATE_HOURS}`);
+    }
+
+    const rows = await jobRepository.sumOutcomes(new Date(Date.now() - windowHours * 60 * 60 * 1000));
+    const budget = config.jobs.errorBudget;
+    return {
+      hours: windowHours,
+      errorBudget: budget,
+      types: rows.map((row) => {
+        const attempts = row.succeeded + row.failed;
+        const errorRate = attempts > 0 ? row.failed / attempts : 0;
+        return {
+          type: row.type,
+          attempts,
+          succeeded: row.succeeded,
+          failed: row.failed,
+          crashed: row.crashed,
+          errorRate: Math.round(errorRate * 10000) / 10000,
+          // Failed attempts still tolerated at this volume
+          budgetRemaining: Math.max(0, Math.floor(attempts * budget) - row.failed),
+          budgetExhausted: errorRate > budget,
+        };
+      }),
+    };
+  }
+
+  /**
+   * Stops workers from picking up jobs of a type. Jobs already running finish;
+   * new ones keep being enqueued and wait.
+   * @param {string} adminId
+   * @param {string} type
+   * @param {string} reason
+   * @returns {Promise<Object>} Pause
+   */
+  async pauseQueue(adminId, type, reason) {
+    this.validateType(type);
+    if (reason !== undefined && reason !== null && typeof reason !== "string") {
+      throw new ValidationError("reason debe ser un texto");
+    }
+    if (reason && reason.length > MAX_REASON_LENGTH) {
+      throw new ValidationError(`reason admite hasta ${MAX_REASON_LENGTH} caracteres`);
+    }
+    if (await jobRepository.findPause(type)) {
+      throw new AppError("La cola ya está pausada", 409, "QUEUE_ALREADY_PAUSED");
+    }
+
+    const pause = await jobRepository.createPause({
+      type,
+      reason: reason?.trim() || null,
+      pausedById: adminId,
+    });
+    logger.warn(`[Jobs] Queue ${type} paused by admin ${adminId}`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.JOB_QUEUE_PAUSED,
+      actorId: adminId,
+      targetType: "job_queue",
+      targetId: type,
+      metadata: { reason: pause.reason },
+    });
+    return pause;
+  }
+
+  /**
+   * Lets workers pick up jobs of a paused type again
+   * @param {string} type
+   */
+  async resumeQueue(type) {
+    this.validateType(type);
+    if (!(await jobRepository.deletePause(type))) {
+      throw new NotFoundError("La cola no está pausada");
+    }
+    logger.info(`[Jobs] Queue ${type} resumed`);
+    await auditService.record({ action: AUDIT_ACTIONS.JOB_QUEUE_RESUMED, targetType: "job_queue", targetId: type });
+  }
+
+  async findStuckJob(jobId) {
+    if (!UUID_REGEX.test(jobId || "")) {
+      throw new ValidationError("ID de job inválido");
+    }
+    const job = await jobRepository.findById(jobId);
+    if (!job) {
+      throw new NotFoundError("Job no encontrado");
+    }
+    if (!["failed", "quarantined"].includes(job.status)) {
+      throw new AppError(
+        "Solo se pueden reintentar o descartar jobs fallidos o en cuarentena",
+        409,
+        "JOB_NOT_FAILED"
+      );
+    }
+    return job;
+  }
+
+  validateType(type) {
+    if (typeof type !== "string" || !type.trim() || type.length > 100) {
+      throw new ValidationError("Tipo de job inválido");
+    }
+  }
+
+  formatJob(job) {
+    return {
+      id: job.id,
+      type: job.type,
+      status: job.status,
+      attempts: job.attempts,
+      maxAttempts: job.maxAttempts,
+      lastError: job.lastError,
+      crashed: Boolean(job.lastCrashStack),
+      lastCrashStack: job.lastCrashStack,
+      payloadHash: job.payloadHash,
+      payloadPreview: previewPayload(job.payload),
+      runAt: job.runAt,
+      createdAt: job.createdAt,
+      updatedAt: job.updatedAt,
+    };
+  }
+}
+
+export default new JobAdminService();
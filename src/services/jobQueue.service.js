@@ -0,0 +1,282 @@
+import { createHash } from "crypto";
+import jobRepository from "../repository/job.repository.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import sentryClient from "../clients/sentry.client.js";
+import { runWithContext } from "../utils/requestContext.js";
+
+// Window in which failures of the same payload add up towards quarantine
+const POISON_WINDOW_MS = 7 * 24 * 60 * 60 * 1000;
+const PAYLOAD_PREVIEW_LENGTH = 300;
+
+/**
+ * JSON with object keys sorted, so equal payloads hash the same
+ */
+const canonicalJson = (value) => {
+  if (Array.isArray(value)) {
+    return `[${value.map(canonicalJson).join(",")}]`;
+  }
+  if (value && typeof value === "object" && !(value instanceof Date)) {
+    return `{${Object.keys(value)
+      .sort()
+      .map((key) => `${JSON.stringify(key)}:${canonicalJson(value[key])}`)
+      .join(",")}}`;
+  }
+  return JSON.stringify(value ?? null);
+};
+
+export const hashPayload = (payload) =>
+  createHash("sha256").update(canonicalJson(payload)).digest("hex");
+
+export const previewPayload = (payload) => {
+  const json = JSON.stringify(payload ?? {});
+  return json.length > PAYLOAD_PREVIEW_LENGTH ? `${json.slice(0, PAYLOAD_PREVIEW_LENGTH)}…` : json;
+};
+
+/**
+ * A crash is a bug in the handler (or something thrown that is not an Error),
+ * as opposed to an expected failure such as a provider being down
+ */
+const isCrash = (error) =>
+  !(error instanceof Error) ||
+  error instanceof TypeError ||
+  error instanceof ReferenceError ||
+  error instanceof RangeError ||
+  error instanceof SyntaxError;
+
+/**
+ * Database-backed background job queue.
+ * Handlers are registered by job type; the worker polls the jobs table and
+ * retries failed jobs with exponential backoff. A payload that keeps failing
+ * across jobs is quarantined instead of retried (see config.jobs.poisonThreshold).
+ */
+class JobQueueService {
+  constructor() {
+    this.handlers = new Map();
+    this.timer = null;
+    this.running = 0;
+    this.stopped = true;
+    // Jobs being executed by this process, for crash reports
+    this.active = new Map();
+  }
+
+  /**
+   * Registers the handler of a job type
+   * @param {string} type
+   * @param {Function} handler - async (payload, job) => void
+   */
+  registerHandler(type, handler) {
+    this.handlers.set(type, handler);
+  }
+
+  /**
+   * Enqueues a job
+   * @param {string} type
+   * @param {Object} payload
+   * @param {Object} options - { runAt, maxAttempts }
+   * @returns {Promise<Object>} Created job
+   */
+  async enqueue(type, payload = {}, { runAt = new Date(), maxAttempts = 5 } = {}) {
+    const payloadHash = hashPayload(payload);
+    // The same payload would fail again: keep it with the quarantined one for an admin to review
+    if (await jobRepository.isQuarantined(type, payloadHash)) {
+      const job = await jobRepository.create({
+        type,
+        payload,
+        payloadHash,
+        runAt,
+        maxAttempts,
+        status: "quarantined",
+        lastError: "Payload in quarantine",
+      });
+      logger.warn(`[Jobs] Enqueued ${type} job ${job.id} straight into quarantine`);
+      return job;
+    }
+
+    const job = await jobRepository.create({ type, payload, payloadHash, runAt, maxAttempts });
+    logger.info(`[Jobs] Enqueued ${type} job ${job.id}`);
+    return job;
+  }
+
+  /**
+   * Starts polling for jobs
+   * @param {Object} options - { force: start even with JOBS_WORKER_ENABLED=false (standalone worker) }
+   */
+  start({ force = false } = {}) {
+    if ((!config.jobs.workerEnabled && !force) || !this.stopped) {
+      return;
+    }
+    this.stopped = false;
+
+    jobRepository
+      .releaseStale(config.jobs.staleAfterMs)
+      .then((released) => {
+        if (released > 0) {
+          logger.warn(`[Jobs] Released ${released} stale running jobs`);
+        }
+      })
+      .catch((error) => logger.error(`[Jobs] Failed to release stale jobs: ${error.message}`));
+
+    this.timer = setInterval(() => this.poll(), config.jobs.pollIntervalMs);
+    logger.info(`[Jobs] Worker started (concurrency ${config.jobs.concurrency})`);
+  }
+
+  /**
+   * Stops polling; jobs already running are left to finish
+   */
+  stop() {
+    this.stopped = true;
+    if (this.timer) {
+      clearInterval(this.timer);
+      this.timer = null;
+    }
+  }
+
+  /**
+   * Waits for the jobs already running to finish
+   * @param {number} timeoutMs
+   * @returns {Promise<boolean>} Whether every job finished in time
+   */
+  async drain(timeoutMs) {
+    const deadline = Date.now() + timeoutMs;
+    while (this.running > 0 && Date.now() < deadline) {
+      await new Promise((resolve) => setTimeout(resolve, 100));
+    }
+    return this.running === 0;
+  }
+
+  async poll() {
+    while (!this.stopped && this.running < config.jobs.concurrency) {
+      let job;
+      try {
+        job = await jobRepository.claimNext();
+      } catch (error) {
+        logger.error(`[Jobs] Failed to claim job: ${error.message}`);
+        return;
+      }
+      if (!job) {
+        return;
+      }
+
+      this.running++;
+      this.execute(job).finally(() => {
+        this.running--;
+      });
+    }
+  }
+
+  async execute(job) {
+    const handler = this.handlers.get(job.type);
+    if (!handler) {
+      logger.error(`[Jobs] No handler registered for ${job.type}`);
+      await jobRepository.markFailed(job, `No handler registered for ${job.type}`, null);
+      return;
+    }
+
+    this.active.set(job.id, job);
+    try {
+      // Tags the job's log lines; jobs have no deadline
+      // Jobs working on a booking carry its ID in the payload
+      const context = { requestId: `job-${job.id}`, bookingId: job.payload?.bookingId };
+      await runWithContext(context, () => handler(job.payload, job));
+      await jobRepository.markCompleted(job.id);
+      this.recordOutcome(job.type, { succeeded: true });
+      logger.info(`[Jobs] Completed ${job.type} job ${job.id}`);
+    } catch (error) {
+      await this.handleFailure(job, error);
+    } finally {
+      this.active.delete(job.id);
+    }
+  }
+
+  /**
+   * Retries the job later, gives up on it, or quarantines its payload when
+   * it has failed too often across jobs
+   */
+  async handleFailure(job, error) {
+    const crashed = isCrash(error);
+    const message = error instanceof Error ? error.message : `Non-error thrown: ${String(error)}`;
+    const crashStack = crashed ? (error instanceof Error ? error.stack : message) : null;
+    this.recordOutcome(job.type, { succeeded: false, crashed });
+
+    logger.error(
+      `[Jobs] ${job.type} job ${job.id} ${crashed ? "crashed" : "failed"} (attempt ${job.attempts}/${job.maxAttempts}): ${message}`,
+      {
+        jobId: job.id,
+        jobType: job.type,
+        attempt: job.attempts,
+        crashed,
+        payload: previewPayload(job.payload),
+        ...(crashed ? { stack: crashStack } : {}),
+      }
+    );
+    if (crashed) {
+      sentryClient.captureException(error, {
+        tags: { jobType: job.type, jobId: job.id },
+        extra: { attempt: job.attempts, payload: previewPayload(job.payload) },
+      });
+    }
+
+    try {
+      if (await this.isPoison(job)) {
+        await jobRepository.markQuarantined(job, message, crashStack);
+        logger.error(`[Jobs] Quarantined ${job.type} job ${job.id}: its payload keeps failing`, {
+          jobId: job.id,
+          jobType: job.type,
+          payloadHash: job.payloadHash,
+        });
+        return;
+      }
+
+      const retryAt =
+        job.attempts < job.maxAttempts
+          ? new Date(Date.now() + this.backoffMs(job.attempts))
+          : null;
+      await jobRepository.markFailed(job, message, retryAt, crashStack);
+    } catch (markError) {
+      logger.error(`[Jobs] Failed to record failure of job ${job.id}: ${markError.message}`);
+    }
+  }
+
+  /**
+   * Whether the payload of a job has failed poisonThreshold times, counting
+   * the other jobs with the same payload. A single job running out of its own
+   * attempts (e.g. scans during a scanner outage) just fails as usual.
+   */
+  async isPoison(job) {
+    if (!job.payloadHash) {
+      return false;
+    }
+    const others = await jobRepository.countPayloadFailures(
+      job,
+      new Date(Date.now() - POISON_WINDOW_MS)
+    );
+    return others > 0 && others + job.attempts >= config.jobs.poisonThreshold;
+  }
+
+  recordOutcome(type, outcome) {
+    jobRepository
+      .recordOutcome(type, outcome)
+      .catch((error) => logger.error(`[Jobs] Failed to record job stats: ${error.message}`));
+  }
+
+  /**
+   * Jobs running in this process, for crash reports
+   * @returns {Array} - [{ id, type, attempt, payload }]
+   */
+  activeJobs() {
+    return [...this.active.values()].map((job) => ({
+      id: job.id,
+      type: job.type,
+      attempt: job.attempts,
+      payload: previewPayload(job.payload),
+    }));
+  }
+
+  // 30s, 1m, 2m, 4m... capped at 1h
+  backoffMs(attempts) {
+    return Math.min(30 * 1000 * 2 ** (attempts - 1), 60 * 60 * 1000);
+  }
+}
+
+export default new JobQueueService();
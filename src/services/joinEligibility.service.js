@@ -0,0 +1,254 @@
+import UserRepository from "../repository/user.repository.js";
+import groupRepository from "../repository/group.repository.js";
+import groupJoinRequestRepository from "../repository/groupJoinRequest.repository.js";
+import policyService from "./policy.service.js";
+import trustScoreService from "./trustScore.service.js";
+import userBlockService from "./userBlock.service.js";
+import config from "../config/index.js";
+import { getUserAge, isAgeRestricted } from "../utils/ageVerification.js";
+import { AppError, NotFoundError } from "../utils/customErrors.js";
+
+/**
+ * Rules a user has to pass to join a trip, in the order they are checked,
+ * with their built-in settings. JOIN_ELIGIBILITY_RULES overrides the settings
+ * for the deployment and per tenant; required rules (notBlocked, feature,
+ * idVerification and ageRestriction) cannot be turned off.
+ *
+ * A rule returns null when the user passes it, or { errorCode, reason } when not.
+ */
+export const ELIGIBILITY_RULES = {
+  notBlocked: {
+    defaults: { enabled: true },
+    required: true,
+    evaluate: async ({ user, group }) =>
+      (await userBlockService.isBlockedBetween(user.id, group.adminId))
+        ? { errorCode: "BLOCKED", reason: "No puedes unirte a este grupo" }
+        : null,
+  },
+  // The joinRequests feature of the country/tenant policy (FEATURE_POLICIES)
+  feature: {
+    defaults: { enabled: true },
+    required: true,
+    evaluate: async ({ country, tenant }) =>
+      policyService.isEnabled("joinRequests", { country, tenant })
+        ? null
+        : { errorCode: "FEATURE_UNAVAILABLE", reason: "Esta función no está disponible en tu país." },
+  },
+  // The idVerification requirement of the country/tenant policy
+  idVerification: {
+    defaults: { enabled: true },
+    required: true,
+    evaluate: async ({ user, country, tenant }) =>
+      policyService.resolve({ country, tenant }).requirements.idVerification && !user.idVerifiedAt
+        ? {
+            errorCode: "ID_VERIFICATION_REQUIRED",
+            reason: "Necesitas verificar tu identidad para usar esta función.",
+          }
+        : null,
+  },
+  // Optional lists of countries allowed or blocked on top of the policy
+  country: {
+    defaults: { enabled: true, allowed: null, blocked: [] },
+    evaluate: async ({ country, params }) => {
+      const allowed = Array.isArray(params.allowed) ? params.allowed : null;
+      const blocked = Array.isArray(params.blocked) ? params.blocked : [];
+      if ((allowed && !allowed.includes(country)) || blocked.includes(country)) {
+        return {
+          errorCode: "COUNTRY_RESTRICTED",
+          reason: country
+            ? "Los viajes no están disponibles para cuentas de tu país."
+            : "Indica tu país en tu perfil para unirte a viajes.",
+        };
+      }
+      return null;
+    },
+  },
+  // Underage accounts with restricted features (see AGE_RULES)
+  ageRestriction: {
+    defaults: { enabled: true },
+    required: true,
+    evaluate: async ({ user }) =>
+      isAgeRestricted(user)
+        ? {
+            errorCode: "AGE_RESTRICTED",
+            reason: "Esta función no está disponible para tu cuenta por la edad mínima de tu país.",
+          }
+        : null,
+  },
+  ageRange: {
+    defaults: { enabled: false, min: null, max: null },
+    evaluate: async ({ user, params }) => {
+      const age = getUserAge(user);
+      if (age === null || age === undefined) {
+        return { errorCode: "AGE_UNKNOWN", reason: "Completa tu fecha de nacimiento para unirte a viajes." };
+      }
+      if ((params.min !== null && age < params.min) || (params.max !== null && age > params.max)) {
+        return {
+          errorCode: "AGE_OUT_OF_RANGE",
+          reason: `Los viajes son para personas de ${[
+            params.min !== null && `${params.min} años o más`,
+            params.max !== null && `hasta ${params.max} años`,
+          ]
+            .filter(Boolean)
+            .join(" y ")}.`,
+        };
+      }
+      return null;
+    },
+  },
+  emailVerified: {
+    defaults: { enabled: false },
+    evaluate: async ({ user }) =>
+      user.isEmailConfirmed
+        ? null
+        : { errorCode: "EMAIL_NOT_VERIFIED", reason: "Confirma tu email para unirte a viajes." },
+  },
+  trustScore: {
+    defaults: { enabled: false, minScore: 0 },
+    evaluate: async ({ user, params }) => {
+      const { score } = await trustScoreService.getTrustScore(user.id);
+      return score < params.minScore
+        ? {
+            errorCode: "TRUST_SCORE_TOO_LOW",
+            reason: `Necesitas un puntaje de confianza de al menos ${params.minScore} (tienes ${score}).`,
+            details: { score, minScore: params.minScore },
+          }
+        : null;
+    },
+  },
+  // Open requests and upcoming trips a user can have at once; null is no limit
+  quota: {
+    defaults: { enabled: false, maxOpenRequests: null, maxUpcomingTrips: null },
+    evaluate: async ({ user, params }) => {
+      const [openRequests, upcomingTrips] = await Promise.all([
+        groupJoinRequestRepository.countOpenByUser(user.id),
+        groupRepository.countUpcomingByMember(user.id),
+      ]);
+      if (params.maxOpenRequests !== null && openRequests >= params.maxOpenRequests) {
+        return {
+          errorCode: "JOIN_QUOTA_EXCEEDED",
+          reason: `Ya tienes ${openRequests} solicitudes abiertas, el máximo es ${params.maxOpenRequests}.`,
+          details: { openRequests, maxOpenRequests: params.maxOpenRequests },
+        };
+      }
+      if (params.maxUpcomingTrips !== null && upcomingTrips >= params.maxUpcomingTrips) {
+        return {
+          errorCode: "JOIN_QUOTA_EXCEEDED",
+          reason: `Ya estás en ${upcomingTrips} viajes por venir, el máximo es ${params.maxUpcomingTrips}.`,
+          details: { upcomingTrips, maxUpcomingTrips: params.maxUpcomingTrips },
+        };
+      }
+      return null;
+    },
+  },
+};
+
+/**
+ * Decides whether a user can join a trip by running ELIGIBILITY_RULES with
+ * the settings of their deployment/tenant. Join requests and invite links
+ * both go through it, and the dry run explains every rule's verdict.
+ * Membership state (already a member, an open request, a full trip) is not
+ * eligibility and stays with the flows that handle it.
+ */
+class JoinEligibilityService {
+  constructor() {
+    this.userRepository = new UserRepository();
+  }
+
+  /**
+   * Settings of every rule for a tenant. Layers are applied in order: the
+   * built-in defaults, JOIN_ELIGIBILITY_RULES.default and the tenant.
+   * @param {string|null} tenant
+   * @returns {Object} - { [rule]: { enabled, ...params } }
+   */
+  resolveRules(tenant = null) {
+    const overrides = config.joinEligibility;
+    const layers = [overrides.default, tenant && overrides.tenants?.[tenant]].filter(Boolean);
+
+    const rules = {};
+    for (const [name, rule] of Object.entries(ELIGIBILITY_RULES)) {
+      rules[name] = { ...rule.defaults };
+      for (const layer of layers) {
+        Object.assign(rules[name], layer[name] || {});
+      }
+      if (rule.required) {
+        rules[name].enabled = true;
+      }
+    }
+    return rules;
+  }
+
+  /**
+   * Runs every rule, including the ones after a failure, so the result
+   * explains all that stands in the way. The country and tenant are the
+   * user's own: what the request claims could pick laxer settings.
+   * @param {Object} user
+   * @param {Object} group
+   * @returns {Promise<Object>} - { eligible, country, tenant, rules: [{ rule, enabled, passed, errorCode, reason, details, settings }] }
+   */
+  async evaluate(user, group) {
+    const country = user.country || null;
+    const tenant = user.tenantId || null;
+    const settings = this.resolveRules(tenant);
+
+    const rules = [];
+    for (const [name, rule] of Object.entries(ELIGIBILITY_RULES)) {
+      const { enabled, ...params } = settings[name];
+      const failure = enabled ? await rule.evaluate({ user, group, country, tenant, params }) : null;
+      rules.push({
+        rule: name,
+        enabled: Boolean(enabled),
+        passed: !failure,
+        errorCode: failure?.errorCode ?? null,
+        reason: failure?.reason ?? null,
+        details: failure?.details ?? null,
+        settings: params,
+      });
+    }
+    return { eligible: rules.every((rule) => rule.passed), country, tenant, rules };
+  }
+
+  /**
+   * Throws with the first failed rule unless the user can join
+   * @param {string} userId
+   * @param {Object} group
+   */
+  async assertEligible(userId, group) {
+    const user = await this.getUserOrFail(userId);
+    const { eligible, rules } = await this.evaluate(user, group);
+    if (!eligible) {
+      const failed = rules.filter((rule) => !rule.passed);
+      throw new AppError(
+        failed[0].reason,
+        403,
+        failed[0].errorCode,
+        failed.map(({ rule, errorCode, reason }) => ({ rule, errorCode, reason }))
+      );
+    }
+  }
+
+  /**
+   * Dry run: whether a user can join a trip and why, without joining
+   * @param {string} groupId
+   * @param {string} userId
+   * @returns {Promise<Object>} - { groupId, userId, eligible, country, tenant, rules }
+   */
+  async explain(groupId, userId) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      throw new NotFoundError("Grupo no encontrado", "GROUP_NOT_FOUND");
+    }
+    const user = await this.getUserOrFail(userId);
+    return { groupId, userId, ...(await this.evaluate(user, group)) };
+  }
+
+  async getUserOrFail(userId) {
+    const user = await this.userRepository.findById(userId);
+    if (!user) {
+      throw new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND");
+    }
+    return user;
+  }
+}
+
+export default new JoinEligibilityService();
@@ -0,0 +1,291 @@
+import crypto from "crypto";
+import legalHoldRepository from "../repository/legalHold.repository.js";
+import UserRepository from "../repository/user.repository.js";
+import jobQueueService from "./jobQueue.service.js";
+import s3Client from "../clients/s3.client.js";
+import logger from "../config/logger.js";
+import { LEGAL_EXPORT_JOB } from "../jobs/legalExport.job.js";
+import { AppError, ValidationError, NotFoundError } from "../utils/customErrors.js";
+
+const userRepository = new UserRepository();
+
+const EXPORT_PREFIX = "legal-exports";
+const DOWNLOAD_URL_TTL = 300;
+
+/**
+ * Admin-only tool for compliance requests: legal holds freeze the deletion of an
+ * account's data and targeted exports gather all of it. Every action is written
+ * to the legal access log. Separate from the user-facing data export.
+ */
+class LegalHoldService {
+  /**
+   * Throws if the user is under an active legal hold. Call before deleting user data.
+   * @param {string} userId
+   */
+  async assertNotOnHold(userId) {
+    const hold = await legalHoldRepository.findActiveByUser(userId);
+    if (hold) {
+      throw new AppError(
+        "Estos datos no pueden eliminarse en este momento",
+        423,
+        "LEGAL_HOLD"
+      );
+    }
+  }
+
+  /**
+   * Throws if any of the users is under an active legal hold (shared data such as groups)
+   * @param {string[]} userIds
+   */
+  async assertNoneOnHold(userIds) {
+    const onHold = await legalHoldRepository.findUsersOnHold(userIds);
+    if (onHold.length) {
+      throw new AppError(
+        "Estos datos no pueden eliminarse en este momento",
+        423,
+        "LEGAL_HOLD"
+      );
+    }
+  }
+
+  /**
+   * Places a hold on an account
+   * @param {Object} admin - Requesting admin ({ id })
+   * @param {Object} data - { userId, reference, reason }
+   * @param {Object} context - { ip, userAgent }
+   */
+  async createHold(admin, { userId, reference, reason = null }, context = {}) {
+    if (!userId || !reference || !String(reference).trim()) {
+      throw new ValidationError("userId y reference son obligatorios");
+    }
+    await this.getUserOrFail(userId);
+
+    if (await legalHoldRepository.findActiveByUser(userId)) {
+      throw new AppError("El usuario ya tiene una retención legal activa", 409, "LEGAL_HOLD_EXISTS");
+    }
+
+    const hold = await legalHoldRepository.create({
+      userId,
+      reference: String(reference).trim().slice(0, 150),
+      reason,
+      createdById: admin.id,
+    });
+    await this.log(admin, "hold_created", userId, context, { holdId: hold.id });
+    logger.info(`Legal hold ${hold.id} placed on user ${userId} by admin ${admin.id}`);
+
+    return hold;
+  }
+
+  /**
+   * Lists holds, optionally filtered by user and status
+   */
+  async getHolds({ userId = null, status = null } = {}) {
+    if (status && !["active", "released"].includes(status)) {
+      throw new ValidationError("Estado de retención inválido");
+    }
+    const holds = await legalHoldRepository.findAll({ userId, status });
+    return holds.map((hold) => this.formatHold(hold));
+  }
+
+  /**
+   * Releases an active hold; deletions are allowed again afterwards
+   */
+  async releaseHold(admin, holdId, context = {}) {
+    const hold = await legalHoldRepository.findById(holdId);
+    if (!hold) {
+      throw new NotFoundError("Retención legal no encontrada");
+    }
+    if (hold.status !== "active") {
+      throw new AppError("La retención legal ya fue liberada", 409, "LEGAL_HOLD_RELEASED");
+    }
+
+    const released = await legalHoldRepository.update(holdId, {
+      status: "released",
+      releasedById: admin.id,
+      releasedAt: new Date(),
+    });
+    await this.log(admin, "hold_released", hold.userId, context, { holdId });
+    logger.info(`Legal hold ${holdId} released by admin ${admin.id}`);
+
+    return this.formatHold(released);
+  }
+
+  /**
+   * Requests a full export of an account; generated in the background
+   * @param {Object} data - { userId, reference }
+   */
+  async requestExport(admin, { userId, reference }, context = {}) {
+    if (!userId || !reference || !String(reference).trim()) {
+      throw new ValidationError("userId y reference son obligatorios");
+    }
+    if (!s3Client.isConfigured()) {
+      throw new AppError("El almacenamiento de archivos no está configurado", 503, "STORAGE_NOT_CONFIGURED");
+    }
+    await this.getUserOrFail(userId);
+
+    const legalExport = await legalHoldRepository.createExport({
+      userId,
+      reference: String(reference).trim().slice(0, 150),
+      requestedById: admin.id,
+    });
+    await this.log(admin, "export_requested", userId, context, { exportId: legalExport.id });
+    await jobQueueService.enqueue(LEGAL_EXPORT_JOB, { exportId: legalExport.id });
+
+    return this.formatExport(legalExport);
+  }
+
+  /**
+   * Builds and stores the export file (job handler)
+   * @param {string} exportId
+   */
+  async generateExport(exportId) {
+    const legalExport = await legalHoldRepository.findExportById(exportId);
+    if (!legalExport || legalExport.status !== "pending") {
+      return;
+    }
+
+    try {
+      const data = await legalHoldRepository.collectUserData(legalExport.userId);
+      if (!data) {
+        await legalHoldRepository.updateExport(exportId, {
+          status: "failed",
+          error: "El usuario ya no existe",
+          completedAt: new Date(),
+        });
+        return;
+      }
+
+      const body = Buffer.from(
+        JSON.stringify(
+          {
+            exportId,
+            reference: legalExport.reference,
+            userId: legalExport.userId,
+            generatedAt: new Date().toISOString(),
+            ...data,
+          },
+          null,
+          2
+        )
+      );
+      const storageKey = `${EXPORT_PREFIX}/${legalExport.userId}/${exportId}.json`;
+      await s3Client.putObject(storageKey, body, "application/json");
+
+      const summary = Object.fromEntries(
+        Object.entries(data.sections).map(([table, rows]) => [table, rows.length])
+      );
+      await legalHoldRepository.updateExport(exportId, {
+        status: "ready",
+        storageKey,
+        size: body.length,
+        sha256: crypto.createHash("sha256").update(body).digest("hex"),
+        summary,
+        completedAt: new Date(),
+      });
+      logger.info(`Legal export ${exportId} ready (${body.length} bytes)`);
+    } catch (err) {
+      logger.error(`Legal export ${exportId} failed: ${err.message}`);
+      await legalHoldRepository.updateExport(exportId, { error: err.message });
+      throw err;
+    }
+  }
+
+  /**
+   * Marks an export as failed once the job gives up
+   */
+  async failExport(exportId, message) {
+    await legalHoldRepository.updateExport(exportId, {
+      status: "failed",
+      error: message,
+      completedAt: new Date(),
+    });
+  }
+
+  async getExport(admin, exportId, context = {}) {
+    const legalExport = await this.getExportOrFail(exportId);
+    await this.log(admin, "export_viewed", legalExport.userId, context, { exportId });
+    return this.formatExport(legalExport);
+  }
+
+  /**
+   * Returns a short-lived download URL for a ready export
+   */
+  async getExportDownloadUrl(admin, exportId, context = {}) {
+    const legalExport = await this.getExportOrFail(exportId);
+    if (legalExport.status !== "ready") {
+      throw new AppError("La exportación todavía no está lista", 409, "EXPORT_NOT_READY");
+    }
+
+    await this.log(admin, "export_downloaded", legalExport.userId, context, { exportId });
+    return {
+      url: s3Client.presignUrl("GET", legalExport.storageKey, { expiresIn: DOWNLOAD_URL_TTL }),
+      expiresIn: DOWNLOAD_URL_TTL,
+      sha256: legalExport.sha256,
+    };
+  }
+
+  async getAccessLog({ targetUserId = null, actorId = null, limit = 100 } = {}) {
+    const parsedLimit = Math.min(Math.max(parseInt(limit, 10) || 100, 1), 500);
+    return await legalHoldRepository.findAccessLog({ targetUserId, actorId, limit: parsedLimit });
+  }
+
+  async log(admin, action, targetUserId, { ip = null, userAgent = null } = {}, refs = {}) {
+    await legalHoldRepository.logAccess({
+      actorId: admin.id,
+      action,
+      targetUserId,
+      ip,
+      userAgent: userAgent ? userAgent.slice(0, 255) : null,
+      ...refs,
+    });
+  }
+
+  async getUserOrFail(userId) {
+    const user = await userRepository.findByIdWithDeleted(userId);
+    if (!user) {
+      throw new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND");
+    }
+    return user;
+  }
+
+  async getExportOrFail(exportId) {
+    const legalExport = await legalHoldRepository.findExportById(exportId);
+    if (!legalExport) {
+      throw new NotFoundError("Exportación no encontrada");
+    }
+    return legalExport;
+  }
+
+  formatHold(hold) {
+    return {
+      id: hold.id,
+      userId: hold.userId,
+      user: hold.user ? { id: hold.user.id, name: hold.user.name, email: hold.user.email } : undefined,
+      reference: hold.reference,
+      reason: hold.reason,
+      status: hold.status,
+      createdById: hold.createdById,
+      createdAt: hold.createdAt,
+      releasedById: hold.releasedById,
+      releasedAt: hold.releasedAt,
+    };
+  }
+
+  formatExport(legalExport) {
+    return {
+      id: legalExport.id,
+      userId: legalExport.userId,
+      reference: legalExport.reference,
+      status: legalExport.status,
+      size: legalExport.size,
+      sha256: legalExport.sha256,
+      summary: legalExport.summary,
+      error: legalExport.status === "failed" ? legalExport.error : null,
+      requestedById: legalExport.requestedById,
+      createdAt: legalExport.createdAt,
+      completedAt: legalExport.completedAt,
+    };
+  }
+}
+
+export default new LegalHoldService();
@@ -2,6 +2,7 @@ import listRepository from "../repository/list.repository.js";
 import placeRepository from "../repository/place.repository.js";
 import { validateListData, validatePlaceId } from "../utils/validators.js";
 import logger from "../config/logger.js";
+import legalHoldService from "./legalHold.service.js";
 
 class ListService {
   /**
@@ -175,6 +176,7 @@ class ListService {
         throw error;
       }
 
+      await legalHoldService.assertNotOnHold(userId);
       await listRepository.delete(id);
 
       logger.info(`Deleted list ID: ${id}`);
@@ -0,0 +1,83 @@
+import realtimeHub from "../socket/realtime.js";
+import groupRepository from "../repository/group.repository.js";
+import config from "../config/index.js";
+import { AppError, RateLimitError } from "../utils/customErrors.js";
+
+/**
+ * Long polling over the realtime fan-out, for clients (embedded webviews)
+ * that cannot keep a WebSocket. A poll returns the events after its cursor
+ * at once, or holds up to REALTIME_POLL_HOLD_SECONDS for the next one.
+ * Each connection holds at most one poll: a new one releases the previous.
+ */
+class LongPollService {
+  constructor() {
+    // connection key -> release() of its held poll
+    this.held = new Map();
+    // userId -> held polls
+    this.heldByUser = new Map();
+  }
+
+  /**
+   * @param {Object} params - { userId, connectionKey, cursor, signal (aborts the hold) }
+   * @returns {Promise<Object>} - { events: [{ id, event, data, at }], cursor, reset, hasMore }
+   *   reset means events were missed (cursor past the buffer window):
+   *   resync state (e.g. GET /api/sync/read-state) and continue from the returned cursor
+   */
+  async poll({ userId, connectionKey, cursor, signal }) {
+    if (cursor === undefined || cursor === "") {
+      return { events: [], cursor: await realtimeHub.currentCursor(), reset: false, hasMore: false };
+    }
+    const afterId = realtimeHub.parseCursor(cursor);
+    if (afterId === null) {
+      return { events: [], cursor: await realtimeHub.currentCursor(), reset: true, hasMore: false };
+    }
+
+    // Same rooms a socket joins: the user's own and those of its groups
+    const groupIds = await groupRepository.findIdsByMember(userId);
+    const rooms = new Set([userId, ...groupIds.map((groupId) => `group_${groupId}`)]);
+    const limit = config.realtime.pollMaxEvents;
+
+    const ready = await realtimeHub.read(rooms, afterId, limit);
+    if (ready.events.length > 0 || ready.reset || signal?.aborted) {
+      return ready;
+    }
+
+    this.held.get(connectionKey)?.();
+    if ((this.heldByUser.get(userId) || 0) >= config.realtime.pollMaxPerUser) {
+      throw new RateLimitError("Demasiadas conexiones de long polling abiertas.");
+    }
+    if (realtimeHub.waiterCount >= config.realtime.pollMaxWaiters) {
+      throw new AppError("Servidor ocupado, reintenta en unos segundos.", 503, "POLL_CAPACITY_EXCEEDED");
+    }
+
+    return await new Promise((resolve) => {
+      let done = false;
+      const release = () => {
+        if (done) return;
+        done = true;
+        clearTimeout(timer);
+        removeWaiter();
+        signal?.removeEventListener("abort", release);
+        if (this.held.get(connectionKey) === release) {
+          this.held.delete(connectionKey);
+        }
+        const count = (this.heldByUser.get(userId) || 1) - 1;
+        if (count > 0) {
+          this.heldByUser.set(userId, count);
+        } else {
+          this.heldByUser.delete(userId);
+        }
+        resolve(realtimeHub.read(rooms, afterId, limit));
+      };
+
+      const timer = setTimeout(release, config.realtime.pollHoldSeconds * 1000);
+      // Events published in the same tick go out together
+      const removeWaiter = realtimeHub.wait({ rooms, wake: () => setImmediate(release) });
+      signal?.addEventListener("abort", release);
+      this.held.set(connectionKey, release);
+      this.heldByUser.set(userId, (this.heldByUser.get(userId) || 0) + 1);
+    });
+  }
+}
+
+export default new LongPollService();
@@ -0,0 +1,85 @@
+import s3Client from "../clients/s3.client.js";
+import clamAvClient from "../clients/clamav.client.js";
+import jobQueueService from "./jobQueue.service.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { AppError } from "../utils/customErrors.js";
+
+/**
+ * Antivirus pipeline for uploads stored in S3. Scans run in background jobs;
+ * scanner outages make the job fail so the queue retries it with backoff.
+ *
+ * Scan states shared by every uploaded file:
+ * - pending: waiting for the antivirus, not downloadable yet
+ * - clean: scanned and downloadable
+ * - quarantined: malware found, moved under the quarantine prefix
+ * - skipped: no scanner configured when it was uploaded
+ */
+class MalwareScanService {
+  isEnabled() {
+    return clamAvClient.isConfigured();
+  }
+
+  /**
+   * Scan status a new upload starts with
+   */
+  initialStatus() {
+    return this.isEnabled() ? "pending" : "skipped";
+  }
+
+  isDownloadable(scanStatus) {
+    return scanStatus === "clean" || scanStatus === "skipped";
+  }
+
+  /**
+   * Throws unless a file with this scan status can be downloaded
+   */
+  assertDownloadable(scanStatus) {
+    if (scanStatus === "pending") {
+      throw new AppError("El archivo se está analizando, inténtalo en unos minutos", 409, "SCAN_PENDING");
+    }
+    if (scanStatus === "quarantined") {
+      throw new AppError("El archivo fue puesto en cuarentena por el antivirus", 403, "FILE_QUARANTINED");
+    }
+  }
+
+  /**
+   * Enqueues the scan of an upload
+   * @param {string} jobType
+   * @param {Object} payload
+   */
+  async enqueueScan(jobType, payload) {
+    await jobQueueService.enqueue(jobType, payload, {
+      maxAttempts: config.scanner.maxAttempts,
+    });
+  }
+
+  /**
+   * Downloads an object and scans it. Scanner errors are rethrown so the job is retried.
+   * @param {string} storageKey
+   * @returns {Promise<Object>} - { clean, signature }
+   */
+  async scanObject(storageKey) {
+    const content = await s3Client.getObject(storageKey);
+    return await clamAvClient.scan(content);
+  }
+
+  /**
+   * Moves an infected object under the quarantine prefix, out of reach of
+   * download URLs built from the original key
+   * @param {string} storageKey
+   * @param {string} contentType
+   * @returns {Promise<string>} Quarantine key
+   */
+  async quarantineObject(storageKey, contentType) {
+    const quarantineKey = `${config.scanner.quarantinePrefix}${storageKey}`;
+    const content = await s3Client.getObject(storageKey);
+    await s3Client.putObject(quarantineKey, content, contentType);
+    await s3Client.deleteObject(storageKey);
+
+    logger.warn(`[Malware Scan] Object ${storageKey} moved to ${quarantineKey}`);
+    return quarantineKey;
+  }
+}
+
+export default new MalwareScanService();
@@ -0,0 +1,437 @@
+import moderationRuleRepository from "../repository/moderationRule.repository.js";
+import userReportRepository from "../repository/userReport.repository.js";
+import tripReviewRepository from "../repository/tripReview.repository.js";
+import groupRepository from "../repository/group.repository.js";
+import UserRepository from "../repository/user.repository.js";
+import searchService from "./search.service.js";
+import trustScoreService from "./trustScore.service.js";
+import auditService, { AUDIT_ACTIONS } from "./audit.service.js";
+import logger from "../config/logger.js";
+import { createAndEmitNotification } from "../socket/notification.emitter.js";
+import { NotFoundError, ValidationError } from "../utils/customErrors.js";
+import { REPORT_REASONS } from "./userReport.service.js";
+import { FLAG_REASONS } from "./tripReview.service.js";
+
+const userRepository = new UserRepository();
+
+// What a rule hides, by the target it watches. Reasons are read lazily: the
+// report and review services import this one to run the rules.
+const RULE_TARGETS = {
+  trip: { action: "hide_trip", reasons: () => REPORT_REASONS },
+  user: { action: "hide_user_content", reasons: () => REPORT_REASONS },
+  trip_review: { action: "hide_review", reasons: () => FLAG_REASONS },
+};
+export const RULE_TARGET_TYPES = Object.keys(RULE_TARGETS);
+
+const MAX_BULK_REPORTS = 500;
+const MAX_RULE_NAME_LENGTH = 100;
+
+/**
+ * Moderator tooling beyond one report at a time: bulk actions (hide
+ * everything a user published, dismiss many or duplicate reports) and
+ * automation rules that hide content once enough distinct trusted users
+ * reported it. Hidden content stays for an admin to review and restore.
+ */
+class ModerationService {
+  /**
+   * Hides every trip and trip review a user published. Trips are unlisted
+   * (not deleted) and reviews hidden, as a single report action would.
+   * @param {string} userId
+   * @param {Object} options - { resolveReports: also action the open reports against the user,
+   *   adminId, notes }
+   * @returns {Promise<Object>} - { tripsHidden, reviewsHidden, reportsResolved }
+   */
+  async hideUserContent(userId, { resolveReports = false, adminId = null, notes = null } = {}) {
+    const user = await userRepository.findByIdWithDeleted(userId);
+    if (!user) {
+      throw new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND");
+    }
+    const trips = await groupRepository.findListedByAdmin(userId);
+    for (const trip of trips) {
+      await this.unlistTrip(trip.id);
+    }
+    const reviewsHidden = await tripReviewRepository.hideByAuthor(userId);
+
+    let reportsResolved = 0;
+    if (resolveReports) {
+      const reports = await userReportRepository.findOpenByTargetUser(userId);
+      reportsResolved = await userReportRepository.resolveMany(
+        reports.map((report) => report.id),
+        {
+          status: "actioned",
+          action: "content_hidden",
+          resolvedById: adminId,
+          resolutionNotes: notes ? String(notes).trim() : null,
+          resolvedAt: new Date(),
+        }
+      );
+    }
+
+    if (trips.length > 0 || reviewsHidden > 0) {
+      try {
+        await createAndEmitNotification({
+          userId,
+          type: "CONTENT_HIDDEN_BY_MODERATION",
+          title: "Ocultamos tu contenido",
+          message:
+            "Tras revisar reportes sobre tu actividad ocultamos tus viajes y reseñas. Revisa las normas de la comunidad.",
+          data: { tripsHidden: trips.length, reviewsHidden },
+        });
+      } catch (notifError) {
+        logger.error(`Error notifying hidden content of ${userId}: ${notifError.message}`);
+      }
+    }
+    logger.info(
+      `[Moderation] Content of ${userId} hidden: ${trips.length} trips, ${reviewsHidden} reviews, ${reportsResolved} reports resolved`
+    );
+    const result = { tripsHidden: trips.length, reviewsHidden, reportsResolved };
+    // Rules record their own entry when they trigger
+    if (adminId) {
+      await auditService.record({
+        action: AUDIT_ACTIONS.MODERATION_USER_CONTENT_HIDDEN,
+        actorId: adminId,
+        targetType: "user",
+        targetId: userId,
+        metadata: { ...result, notes },
+      });
+    }
+    return result;
+  }
+
+  /**
+   * Takes a trip out of search and the feed, cancelling any scheduled launch
+   * @param {string} groupId
+   */
+  async unlistTrip(groupId) {
+    await groupRepository.update(groupId, { isPublic: false, publishAt: null });
+    await searchService.scheduleIndex("trip", groupId);
+  }
+
+  /**
+   * Dismisses several open reports; already resolved ones are skipped
+   * @param {string} adminId
+   * @param {string[]} reportIds
+   * @param {string|null} notes
+   * @returns {Promise<Object>} - { dismissed, skipped }
+   */
+  async dismissReports(adminId, reportIds, notes = null) {
+    if (!Array.isArray(reportIds) || reportIds.length === 0 || reportIds.length > MAX_BULK_REPORTS) {
+      throw new ValidationError(`reportIds debe tener entre 1 y ${MAX_BULK_REPORTS} IDs`);
+    }
+    const ids = [...new Set(reportIds.map(String))];
+    const open = await userReportRepository.findOpenByIds(ids);
+    const dismissed = await userReportRepository.resolveMany(
+      open.map((report) => report.id),
+      {
+        status: "dismissed",
+        resolvedById: adminId,
+        resolutionNotes: notes ? String(notes).trim() : null,
+        resolvedAt: new Date(),
+      }
+    );
+    logger.info(`[Moderation] ${dismissed} reports dismissed by admin ${adminId}`);
+    const result = { dismissed, skipped: ids.length - dismissed };
+    await auditService.record({
+      action: AUDIT_ACTIONS.MODERATION_REPORTS_DISMISSED,
+      actorId: adminId,
+      targetType: "report",
+      metadata: { reportIds, ...result },
+    });
+    return result;
+  }
+
+  /**
+   * Dismisses open reports that repeat an older open report: same reporter,
+   * same target (trip, or user for user reports) and same reason. The oldest
+   * report of each is kept open for review; reports from different people
+   * are kept since their count is what the automation rules act on
+   * @param {string} adminId
+   * @param {Object} filters - { targetType }
+   * @returns {Promise<Object>} - { targets, dismissed }
+   */
+  async dismissDuplicateReports(adminId, { targetType = null } = {}) {
+    if (targetType && !["user", "trip"].includes(targetType)) {
+      throw new ValidationError('targetType debe ser "user" o "trip"');
+    }
+    const reports = await userReportRepository.findAll({ status: "open", targetType });
+    const kept = new Map();
+    // Oldest open report of a reporter, target and reason -> IDs of the later ones
+    const byOriginal = new Map();
+    for (const report of [...reports].sort((a, b) => new Date(a.createdAt) - new Date(b.createdAt))) {
+      const key = [
+        report.reporterId,
+        report.targetType,
+        report.targetGroupId || report.targetUserId,
+        report.reason,
+      ].join(":");
+      const original = kept.get(key);
+      if (!original) {
+        kept.set(key, report);
+      } else if (report.severity !== "high") {
+        // Safety incidents are never folded into another report
+        byOriginal.set(original.id, [...(byOriginal.get(original.id) || []), report.id]);
+      }
+    }
+
+    let dismissed = 0;
+    for (const [originalId, duplicateIds] of byOriginal) {
+      dismissed += await userReportRepository.resolveMany(duplicateIds, {
+        status: "dismissed",
+        resolvedById: adminId,
+        resolutionNotes: `Duplicado del reporte ${originalId}`,
+        resolvedAt: new Date(),
+      });
+    }
+    logger.info(`[Moderation] ${dismissed} duplicate reports dismissed by admin ${adminId}`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.MODERATION_REPORTS_DISMISSED,
+      actorId: adminId,
+      targetType: "report",
+      metadata: { duplicates: true, targetType, targets: byOriginal.size, dismissed },
+    });
+    return { targets: byOriginal.size, dismissed };
+  }
+
+  async listRules() {
+    const rules = await moderationRuleRepository.findAll();
+    return rules.map((rule) => this.formatRule(rule));
+  }
+
+  /**
+   * @param {string} adminId
+   * @param {Object} data - { name, targetType, minReporters, minTrustScore, reasons, windowHours, enabled }
+   */
+  async createRule(adminId, data) {
+    const rule = await moderationRuleRepository.create({
+      ...this.validateRule(data),
+      createdById: adminId,
+    });
+    logger.info(`[Moderation] Rule ${rule.id} (${rule.name}) created by admin ${adminId}`);
+    const formatted = this.formatRule(rule);
+    await auditService.record({
+      action: AUDIT_ACTIONS.MODERATION_RULE_CREATED,
+      actorId: adminId,
+      targetType: "moderation_rule",
+      targetId: rule.id,
+      metadata: formatted,
+    });
+    return formatted;
+  }
+
+  /**
+   * Changes some settings of a rule. Targets it already acted on are not
+   * evaluated again.
+   */
+  async updateRule(ruleId, data) {
+    const rule = await this.getRuleOrFail(ruleId);
+    if (data.targetType !== undefined && data.targetType !== rule.targetType) {
+      throw new ValidationError("targetType no se puede cambiar; crea una regla nueva");
+    }
+    const changes = this.validateRule({ ...rule, ...data });
+    const updated = await moderationRuleRepository.update(ruleId, changes);
+    await auditService.record({
+      action: AUDIT_ACTIONS.MODERATION_RULE_UPDATED,
+      targetType: "moderation_rule",
+      targetId: ruleId,
+      metadata: data,
+    });
+    return this.formatRule(updated);
+  }
+
+  async deleteRule(ruleId) {
+    if (!(await moderationRuleRepository.delete(ruleId))) {
+      throw new NotFoundError("Regla no encontrada");
+    }
+    await auditService.record({
+      action: AUDIT_ACTIONS.MODERATION_RULE_DELETED,
+      targetType: "moderation_rule",
+      targetId: ruleId,
+    });
+  }
+
+  /**
+   * Targets a rule acted on, newest first
+   */
+  async getRuleHits(ruleId) {
+    await this.getRuleOrFail(ruleId);
+    return await moderationRuleRepository.findHits(ruleId);
+  }
+
+  /**
+   * Runs the enabled rules watching a target after a new report or flag on
+   * it. Never throws: a failing rule must not fail the report.
+   * @param {string} targetType - "trip" | "user" | "trip_review"
+   * @param {string} targetId - Group, user or trip review ID
+   */
+  async evaluateRules(targetType, targetId) {
+    try {
+      const rules = await moderationRuleRepository.findEnabledByTarget(targetType);
+      for (const rule of rules) {
+        if (await moderationRuleRepository.hasHit(rule.id, targetId)) {
+          continue;
+        }
+        const reporterIds = await this.countingReporters(rule, targetId);
+        if (reporterIds.length < rule.minReporters) {
+          continue;
+        }
+        // The hit is recorded first so concurrent reports act only once
+        const recorded = await moderationRuleRepository.recordHit({
+          ruleId: rule.id,
+          targetType,
+          targetId,
+          reporterIds,
+        });
+        if (!recorded) {
+          continue;
+        }
+        await this.applyRule(rule, targetId);
+        await auditService.record({
+          action: AUDIT_ACTIONS.MODERATION_RULE_TRIGGERED,
+          actorId: null,
+          targetType,
+          targetId,
+          metadata: { ruleId: rule.id, ruleName: rule.name, reporterIds },
+        });
+        logger.info(
+          `[Moderation] Rule ${rule.name} hid ${targetType} ${targetId} after ${reporterIds.length} reporters`
+        );
+      }
+    } catch (error) {
+      logger.error(`[Moderation] Rules failed for ${targetType} ${targetId}: ${error.message}`);
+    }
+  }
+
+  /**
+   * Distinct reporters of a target that count towards a rule: within its
+   * window, with one of its reasons and trusted enough
+   */
+  async countingReporters(rule, targetId) {
+    const since = rule.windowHours ? new Date(Date.now() - rule.windowHours * 60 * 60 * 1000) : null;
+    const reports =
+      rule.targetType === "trip_review"
+        ? (await tripReviewRepository.findFlags(targetId))
+            .filter((flag) => !since || new Date(flag.createdAt) >= since)
+            .map((flag) => ({ reporterId: flag.userId, reason: flag.reason }))
+        : await userReportRepository.findOpenByTarget({ targetType: rule.targetType, targetId }, since);
+
+    const reasons = rule.reasons || [];
+    const reporterIds = [
+      ...new Set(
+        reports
+          .filter((report) => reasons.length === 0 || reasons.includes(report.reason))
+          .map((report) => report.reporterId)
+      ),
+    ];
+    if (!rule.minTrustScore || reporterIds.length < rule.minReporters) {
+      return reporterIds;
+    }
+    const trusted = [];
+    for (const reporterId of reporterIds) {
+      const { score } = await trustScoreService.getTrustScore(reporterId).catch(() => ({ score: 0 }));
+      if (score >= rule.minTrustScore) {
+        trusted.push(reporterId);
+      }
+    }
+    return trusted;
+  }
+
+  async applyRule(rule, targetId) {
+    const { action } = RULE_TARGETS[rule.targetType];
+    if (action === "hide_review") {
+      await tripReviewRepository.update(targetId, { status: "hidden" });
+      return;
+    }
+    if (action === "hide_user_content") {
+      await this.hideUserContent(targetId);
+      return;
+    }
+    const group = await groupRepository.findById(targetId);
+    if (!group) {
+      return;
+    }
+    await this.unlistTrip(targetId);
+    try {
+      await createAndEmitNotification({
+        userId: group.adminId,
+        type: "TRIP_HIDDEN_BY_MODERATION",
+        title: "Tu viaje fue ocultado",
+        message: `Ocultamos "${group.name}" de las búsquedas tras varios reportes. Lo revisaremos a la brevedad.`,
+        data: { groupId: group.id, ruleId: rule.id },
+      });
+    } catch (notifError) {
+      logger.error(`Error notifying hidden trip ${targetId}: ${notifError.message}`);
+    }
+  }
+
+  validateRule({
+    name,
+    targetType,
+    minReporters,
+    minTrustScore = 0,
+    reasons = [],
+    windowHours = null,
+    enabled = true,
+  }) {
+    if (typeof name !== "string" || !name.trim() || name.length > MAX_RULE_NAME_LENGTH) {
+      throw new ValidationError(`name es requerido (máx. ${MAX_RULE_NAME_LENGTH} caracteres)`);
+    }
+    const target = RULE_TARGETS[targetType];
+    if (!target) {
+      throw new ValidationError(`targetType debe ser uno de: ${RULE_TARGET_TYPES.join(", ")}`);
+    }
+    if (!Number.isInteger(minReporters) || minReporters < 2 || minReporters > 1000) {
+      throw new ValidationError("minReporters debe ser un entero entre 2 y 1000");
+    }
+    if (!Number.isInteger(minTrustScore) || minTrustScore < 0 || minTrustScore > 100) {
+      throw new ValidationError("minTrustScore debe ser un entero entre 0 y 100");
+    }
+    const allowedReasons = target.reasons();
+    if (!Array.isArray(reasons) || reasons.some((reason) => !allowedReasons.includes(reason))) {
+      throw new ValidationError(`reasons debe ser una lista con valores de: ${allowedReasons.join(", ")}`);
+    }
+    if (windowHours !== null && (!Number.isInteger(windowHours) || windowHours < 1 || windowHours > 24 * 365)) {
+      throw new ValidationError("windowHours debe ser un entero entre 1 y 8760, o null");
+    }
+    if (typeof enabled !== "boolean") {
+      throw new ValidationError("enabled debe ser booleano");
+    }
+    return {
+      name: name.trim(),
+      targetType,
+      minReporters,
+      minTrustScore,
+      reasons: [...new Set(reasons)],
+      windowHours,
+      enabled,
+    };
+  }
+
+  async getRuleOrFail(ruleId) {
+    const rule = await moderationRuleRepository.findById(ruleId);
+    if (!rule) {
+      throw new NotFoundError("Regla no encontrada");
+    }
+    return rule;
+  }
+
+  formatRule(rule) {
+    return {
+      id: rule.id,
+      name: rule.name,
+      targetType: rule.targetType,
+      action: RULE_TARGETS[rule.targetType]?.action ?? null,
+      minReporters: rule.minReporters,
+      minTrustScore: rule.minTrustScore,
+      reasons: rule.reasons,
+      windowHours: rule.windowHours,
+      enabled: rule.enabled,
+      triggerCount: rule.triggerCount,
+      lastTriggeredAt: rule.lastTriggeredAt,
+      createdById: rule.createdById,
+      createdAt: rule.createdAt,
+      updatedAt: rule.updatedAt,
+    };
+  }
+}
+
+export default new ModerationService();
@@ -1,13 +1,21 @@
+import { In } from "typeorm";
 import { AppDataSource } from "../load/typeorm.loader.js";
 import Notification from "../models/notification.model.js";
+import txManager from "../repository/transactionManager.js";
+import readStateService from "./readState.service.js";
+import badgeService from "./badge.service.js";
 import logger from "../config/logger.js";
+import { ValidationError } from "../utils/customErrors.js";
 
 const notificationRepository = AppDataSource.getRepository(Notification);
 
 export const createNotification = async (notificationData) => {
   try {
-    const notification = notificationRepository.create(notificationData);
-    await notificationRepository.save(notification);
+    // Part of the caller's transaction, if any
+    const repository = txManager.getRepository(Notification);
+    const notification = repository.create(notificationData);
+    await repository.save(notification);
+    await txManager.afterCommit(() => badgeService.increment(notification.userId, "notifications"));
     return notification;
   } catch (error) {
     logger.error("Error creating notification:", error);
@@ -15,14 +23,49 @@ export const createNotification = async (notificationData) => {
   }
 };
 
-export const getUserNotifications = async (userId, limit = 50) => {
+/**
+ * Gets a page of the user's notification feed, newest first
+ * @param {string} userId
+ * @param {number} limit
+ * @param {Object} options - { before: notification id cursor, unreadOnly, type }
+ * @returns {Promise<Array>} Notifications
+ */
+export const getUserNotifications = async (
+  userId,
+  limit = 50,
+  { before = null, unreadOnly = false, type = null } = {}
+) => {
   try {
-    const notifications = await notificationRepository.find({
-      where: { userId },
-      order: { createdAt: "DESC" },
-      take: limit,
-    });
-    return notifications;
+    const query = notificationRepository
+      .createQueryBuilder("notification")
+      .where("notification.userId = :userId", { userId });
+
+    if (before) {
+      const cursor = await notificationRepository.findOne({
+        where: { id: before, userId },
+      });
+      if (!cursor) {
+        throw new ValidationError("Cursor inválido", null, "INVALID_CURSOR");
+      }
+      query.andWhere(
+        "(notification.createdAt < :createdAt OR (notification.createdAt = :createdAt AND notification.id < :id))",
+        { createdAt: cursor.createdAt, id: cursor.id }
+      );
+    }
+
+    if (unreadOnly) {
+      query.andWhere("notification.read = false");
+    }
+
+    if (type) {
+      query.andWhere("notification.type = :type", { type });
+    }
+
+    return await query
+      .orderBy("notification.createdAt", "DESC")
+      .addOrderBy("notification.id", "DESC")
+      .take(limit)
+      .getMany();
   } catch (error) {
     logger.error("Error getting user notifications:", error);
     throw error;
@@ -41,21 +84,75 @@ export const getUnreadCount = async (userId) => {
   }
 };
 
-export const markAsRead = async (notificationId) => {
+/**
+ * Gets the notifications of a user among the given IDs that are still unread
+ * @param {string} userId
+ * @param {string[]} ids
+ * @returns {Promise<Array>} Notifications, oldest first
+ */
+export const getUnreadByIds = async (userId, ids) => {
+  if (ids.length === 0) {
+    return [];
+  }
+  return await notificationRepository.find({
+    where: { userId, id: In(ids), read: false },
+    order: { createdAt: "ASC" },
+  });
+};
+
+export const markAsRead = async (notificationId, userId) => {
   try {
-    await notificationRepository.update(notificationId, { read: true });
+    // Only an unread one moves the badge; marking it again changes nothing
+    const result = await notificationRepository.update(
+      { id: notificationId, userId, read: false },
+      { read: true }
+    );
+    if (result.affected > 0) {
+      badgeService.increment(userId, "notifications", -1);
+      readStateService.publish(userId, { scope: "notifications", notificationIds: [notificationId] });
+      return true;
+    }
+    return (await notificationRepository.count({ where: { id: notificationId, userId } })) > 0;
   } catch (error) {
     logger.error("Error marking notification as read:", error);
     throw error;
   }
 };
 
+/**
+ * Marks several notifications of a user as read
+ * @param {string} userId
+ * @param {string[]} ids
+ * @returns {Promise<number>} Notifications updated
+ */
+export const markManyAsRead = async (userId, ids) => {
+  try {
+    const result = await notificationRepository.update(
+      { userId, id: In(ids), read: false },
+      { read: true }
+    );
+    if (result.affected > 0) {
+      badgeService.increment(userId, "notifications", -result.affected);
+      readStateService.publish(userId, { scope: "notifications", notificationIds: ids });
+    }
+    return result.affected || 0;
+  } catch (error) {
+    logger.error("Error marking notifications as read:", error);
+    throw error;
+  }
+};
+
 export const markAllAsRead = async (userId) => {
   try {
-    await notificationRepository.update(
+    const result = await notificationRepository.update(
       { userId, read: false },
       { read: true }
     );
+    if (result.affected > 0) {
+      badgeService.invalidate(userId);
+      readStateService.publish(userId, { scope: "notifications", all: true });
+    }
+    return result.affected || 0;
   } catch (error) {
     logger.error("Error marking all notifications as read:", error);
     throw error;
@@ -68,6 +165,14 @@ export const deleteNotification = async (notificationId, userId) => {
       id: notificationId,
       userId,
     });
+    if (result.affected > 0) {
+      // Unknown whether it was unread; rebuild the counter
+      badgeService.invalidate(userId);
+      readStateService.publish(userId, {
+        scope: "notifications",
+        deletedNotificationIds: [notificationId],
+      });
+    }
     return result.affected > 0;
   } catch (error) {
     logger.error("Error deleting notification:", error);
@@ -79,7 +184,9 @@ export default {
   createNotification,
   getUserNotifications,
   getUnreadCount,
+  getUnreadByIds,
   markAsRead,
+  markManyAsRead,
   markAllAsRead,
   deleteNotification,
 };
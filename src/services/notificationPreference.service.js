@@ -0,0 +1,212 @@
+import notificationPreferenceRepository from "../repository/notificationPreference.repository.js";
+import { ValidationError } from "../utils/customErrors.js";
+import { isValidTime, isValidTimeZone, getQuietHoursEnd } from "../utils/quietHours.js";
+
+/**
+ * Notification categories users can toggle independently.
+ * Notification types not listed fall back to "general".
+ */
+export const NOTIFICATION_CATEGORIES = {
+  chat: ["NEW_MESSAGE", "NEW_GROUP_MESSAGE"],
+  join_requests: [
+    "JOIN_REQUEST_APPROVED",
+    "JOIN_REQUEST_REJECTED",
+    "JOIN_REQUEST_RECEIVED",
+    "JOIN_REQUEST_WAITLISTED",
+    "GROUP_INVITE_ACCEPTED",
+  ],
+  trip_updates: [
+    "GROUP_INVITE",
+    "NEW_ITINERARY",
+    "EXPENSE_ADDED",
+    "EXPENSE_ASSIGNED",
+    "GROUP_CAPACITY_CHANGED",
+    "GROUP_SPOT_REMOVED",
+  ],
+  payments: ["PAYMENT_FAILED", "PAYMENT_REFUNDED"],
+  achievements: ["BADGE_EARNED"],
+  recommendations: ["MONTHLY_RECOMMENDATIONS"],
+  followed_organizers: ["TRIP_PUBLISHED", "TRIPS_PUBLISHED"],
+  general: [],
+};
+
+export const NOTIFICATION_CHANNELS = ["push", "email"];
+
+/**
+ * Resolves the category of a notification type
+ * @param {string} type - Notification type (e.g. NEW_GROUP_MESSAGE)
+ * @returns {string} Category name
+ */
+export const getCategoryForType = (type) => {
+  const entry = Object.entries(NOTIFICATION_CATEGORIES).find(([, types]) =>
+    types.includes(type)
+  );
+  return entry ? entry[0] : "general";
+};
+
+class NotificationPreferenceService {
+  /**
+   * Gets the effective preferences of a user (defaults when never saved)
+   * @param {string} userId
+   * @returns {Promise<Object>} - { pushEnabled, emailEnabled, categories, quietHours, timezone }
+   */
+  async getPreferences(userId) {
+    const stored = await notificationPreferenceRepository.findByUserId(userId);
+    return this.buildPreferences(stored);
+  }
+
+  /**
+   * Gets the effective preferences of several users at once
+   * @param {string[]} userIds
+   * @returns {Promise<Map<string, Object>>} Preferences by user ID
+   */
+  async getPreferencesForUsers(userIds) {
+    const stored = await notificationPreferenceRepository.findByUserIds(userIds);
+    const byUser = new Map(stored.map((preference) => [preference.userId, preference]));
+    return new Map(userIds.map((userId) => [userId, this.buildPreferences(byUser.get(userId))]));
+  }
+
+  buildPreferences(stored) {
+    const categories = {};
+    for (const category of Object.keys(NOTIFICATION_CATEGORIES)) {
+      categories[category] = {
+        push: stored?.categories?.[category]?.push ?? true,
+        email: stored?.categories?.[category]?.email ?? true,
+      };
+    }
+
+    return {
+      pushEnabled: stored?.pushEnabled ?? true,
+      emailEnabled: stored?.emailEnabled ?? true,
+      categories,
+      quietHours:
+        stored?.quietHoursStart && stored?.quietHoursEnd
+          ? { start: stored.quietHoursStart, end: stored.quietHoursEnd }
+          : null,
+      timezone: stored?.timezone || "UTC",
+    };
+  }
+
+  /**
+   * Updates the preferences of a user
+   * @param {string} userId
+   * @param {Object} data - { pushEnabled, emailEnabled, categories, quietHours, timezone }
+   * @returns {Promise<Object>} Effective preferences after the update
+   */
+  async updatePreferences(
+    userId,
+    { pushEnabled, emailEnabled, categories, quietHours, timezone }
+  ) {
+    const update = {};
+
+    if (pushEnabled !== undefined) {
+      if (typeof pushEnabled !== "boolean") {
+        throw new ValidationError("pushEnabled debe ser booleano");
+      }
+      update.pushEnabled = pushEnabled;
+    }
+
+    if (emailEnabled !== undefined) {
+      if (typeof emailEnabled !== "boolean") {
+        throw new ValidationError("emailEnabled debe ser booleano");
+      }
+      update.emailEnabled = emailEnabled;
+    }
+
+    if (categories !== undefined) {
+      if (typeof categories !== "object" || categories === null || Array.isArray(categories)) {
+        throw new ValidationError("categories debe ser un objeto");
+      }
+
+      const current = await this.getPreferences(userId);
+      const merged = { ...current.categories };
+
+      for (const [category, channels] of Object.entries(categories)) {
+        if (!NOTIFICATION_CATEGORIES[category]) {
+          throw new ValidationError(`Categoría de notificación inválida: ${category}`);
+        }
+        for (const [channel, enabled] of Object.entries(channels || {})) {
+          if (!NOTIFICATION_CHANNELS.includes(channel) || typeof enabled !== "boolean") {
+            throw new ValidationError(
+              `Preferencia inválida para ${category}: ${channel}`
+            );
+          }
+          merged[category] = { ...merged[category], [channel]: enabled };
+        }
+      }
+
+      update.categories = merged;
+    }
+
+    if (quietHours !== undefined) {
+      if (quietHours === null) {
+        update.quietHoursStart = null;
+        update.quietHoursEnd = null;
+      } else if (
+        typeof quietHours !== "object" ||
+        !isValidTime(quietHours.start) ||
+        !isValidTime(quietHours.end) ||
+        quietHours.start === quietHours.end
+      ) {
+        throw new ValidationError("quietHours debe tener start y end distintos en formato HH:MM");
+      } else {
+        update.quietHoursStart = quietHours.start;
+        update.quietHoursEnd = quietHours.end;
+      }
+    }
+
+    if (timezone !== undefined) {
+      if (!isValidTimeZone(timezone)) {
+        throw new ValidationError("Zona horaria inválida");
+      }
+      update.timezone = timezone;
+    }
+
+    await notificationPreferenceRepository.upsert(userId, update);
+    return await this.getPreferences(userId);
+  }
+
+  /**
+   * Checks whether a notification type may be delivered through a channel
+   * @param {string} userId
+   * @param {string} type - Notification type
+   * @param {string} channel - "push" | "email"
+   * @returns {Promise<boolean>}
+   */
+  async isChannelEnabled(userId, type, channel) {
+    const preferences = await this.getPreferences(userId);
+    return this.isChannelAllowed(preferences, type, channel);
+  }
+
+  /**
+   * Same check as isChannelEnabled on preferences already loaded
+   * @param {Object} preferences - Effective preferences
+   * @param {string} type
+   * @param {string} channel
+   * @returns {boolean}
+   */
+  isChannelAllowed(preferences, type, channel) {
+    const globalEnabled =
+      channel === "push" ? preferences.pushEnabled : preferences.emailEnabled;
+    if (!globalEnabled) {
+      return false;
+    }
+    const category = getCategoryForType(type);
+    return preferences.categories[category]?.[channel] !== false;
+  }
+
+  /**
+   * When the current quiet hours of a user end
+   * @param {Object} preferences - Effective preferences
+   * @param {Date} now
+   * @returns {Date|null} - null when the user is not in quiet hours
+   */
+  getQuietHoursEnd(preferences, now = new Date()) {
+    if (!preferences.quietHours) {
+      return null;
+    }
+    return getQuietHoursEnd({ ...preferences.quietHours, timezone: preferences.timezone }, now);
+  }
+}
+
+export default new NotificationPreferenceService();
@@ -0,0 +1,203 @@
+import organizerRepository from "../repository/organizer.repository.js";
+import groupMessageRepository from "../repository/groupMessage.repository.js";
+import UserRepository from "../repository/user.repository.js";
+import profileService from "./profile.service.js";
+import tripReviewService from "./tripReview.service.js";
+import userBlockService from "./userBlock.service.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { NotFoundError } from "../utils/customErrors.js";
+import { sumByCurrency } from "../utils/expenseSplit.js";
+
+const PAGE_UPCOMING_TRIPS = 20;
+const WIDGET_MAX_TRIPS = 10;
+const UUID_REGEX = /^[0-9a-f]{8}-[0-9a-f]{4}-[1-5][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/i;
+
+class OrganizerService {
+  constructor() {
+    this.userRepository = new UserRepository();
+  }
+
+  /**
+   * Builds the organizer home screen: upcoming groups with fill rate, pending
+   * join requests, unpaid expenses, unread chat messages and recent reviews
+   * @param {string} userId - Organizer (group admin) ID
+   * @returns {Promise<Object>} - { success, data }
+   */
+  async getDashboard(userId) {
+    try {
+      const groups = await organizerRepository.findUpcomingGroups(userId);
+      const groupIds = groups.map((group) => group.id);
+
+      if (groupIds.length === 0) {
+        return {
+          success: true,
+          data: {
+            summary: {
+              upcomingTrips: 0,
+              pendingRequests: 0,
+              unpaidAmounts: [],
+              unreadMessages: 0,
+            },
+            trips: [],
+            pendingRequests: [],
+            recentReviews: [],
+          },
+        };
+      }
+
+      // Every section is an independent aggregate over the same set of groups
+      const [pendingRequests, pendingCounts, unpaid, unreadCounts, recentReviews] =
+        await Promise.all([
+          organizerRepository.findPendingJoinRequests(groupIds),
+          organizerRepository.countPendingJoinRequests(groupIds),
+          organizerRepository.sumUnpaidExpenses(groupIds),
+          groupMessageRepository.countUnreadByGroupIds(groupIds, userId),
+          organizerRepository.findRecentReviews(groupIds),
+        ]);
+
+      const trips = groups.map((group) => ({
+        id: group.id,
+        name: group.name,
+        startDate: group.startDate,
+        endDate: group.endDate,
+        capacity: group.capacity,
+        memberCount: group.memberCount,
+        fillRate: group.capacity
+          ? Math.round((group.memberCount / group.capacity) * 100) / 100
+          : null,
+        pendingRequests: pendingCounts[group.id] || 0,
+        unpaidExpenses: unpaid[group.id] || { count: 0, totals: [] },
+        unreadMessages: unreadCounts[group.id] || 0,
+      }));
+
+      const sum = (values) => values.reduce((total, value) => total + value, 0);
+
+      return {
+        success: true,
+        data: {
+          summary: {
+            upcomingTrips: trips.length,
+            pendingRequests: sum(trips.map((trip) => trip.pendingRequests)),
+            unpaidAmounts: sumByCurrency(trips.flatMap((trip) => trip.unpaidExpenses.totals)),
+            unreadMessages: sum(trips.map((trip) => trip.unreadMessages)),
+          },
+          trips,
+          pendingRequests,
+          recentReviews,
+        },
+      };
+    } catch (err) {
+      logger.error(`Error building organizer dashboard for ${userId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Public page of an organizer: bio, rating and upcoming public trips.
+   * Bio and picture follow the organizer's profile visibility.
+   * @param {string} organizerId
+   * @param {string|null} viewerId - Logged-in viewer, if any
+   * @returns {Promise<Object>} - { success, data }
+   */
+  async getPublicPage(organizerId, viewerId = null) {
+    const organizer = await this.getOrganizerOrFail(organizerId);
+    if (viewerId && (await userBlockService.isBlockedBetween(organizerId, viewerId))) {
+      throw new NotFoundError("Organizador no encontrado", "ORGANIZER_NOT_FOUND");
+    }
+
+    const [counts, rating, trips] = await Promise.all([
+      organizerRepository.countPublicTrips(organizerId),
+      tripReviewService.getOrganizerRating(organizerId),
+      organizerRepository.findPublicUpcomingTrips(organizerId, PAGE_UPCOMING_TRIPS),
+    ]);
+    if (counts.publicTrips === 0) {
+      throw new NotFoundError("Organizador no encontrado", "ORGANIZER_NOT_FOUND");
+    }
+
+    const visibility = profileService.getVisibility(organizer);
+    return {
+      success: true,
+      data: {
+        id: organizer.id,
+        name: organizer.name,
+        bio: visibility.bio === "public" ? organizer.bio : null,
+        profilePicture: visibility.profilePicture === "public" ? organizer.profilePicture : null,
+        memberSince: organizer.createdAt,
+        rating,
+        publicTrips: counts.publicTrips,
+        completedTrips: counts.completedTrips,
+        upcomingTrips: trips.map((trip) => this.formatPublicTrip(trip)),
+        pageUrl: this.getPageUrl(organizerId),
+      },
+    };
+  }
+
+  /**
+   * Compact data for the widget partners embed on their sites: organizer
+   * summary and next trips with links back to JoinTravel
+   * @param {string} organizerId
+   * @param {Object} options - { limit }
+   * @returns {Promise<Object>} - { success, data }
+   */
+  async getWidget(organizerId, { limit = 3 } = {}) {
+    const organizer = await this.getOrganizerOrFail(organizerId);
+    const tripLimit = Math.min(Math.max(parseInt(limit) || 3, 1), WIDGET_MAX_TRIPS);
+
+    const [counts, rating, trips] = await Promise.all([
+      organizerRepository.countPublicTrips(organizerId),
+      tripReviewService.getOrganizerRating(organizerId),
+      organizerRepository.findPublicUpcomingTrips(organizerId, tripLimit),
+    ]);
+    if (counts.publicTrips === 0) {
+      throw new NotFoundError("Organizador no encontrado", "ORGANIZER_NOT_FOUND");
+    }
+
+    const visibility = profileService.getVisibility(organizer);
+    return {
+      success: true,
+      data: {
+        organizer: {
+          id: organizer.id,
+          name: organizer.name,
+          profilePicture: visibility.profilePicture === "public" ? organizer.profilePicture : null,
+          rating,
+          completedTrips: counts.completedTrips,
+          url: this.getPageUrl(organizerId),
+        },
+        trips: trips.map((trip) => ({
+          ...this.formatPublicTrip(trip),
+          url: `${config.frontendUrl}/groups/${trip.id}`,
+        })),
+      },
+    };
+  }
+
+  async getOrganizerOrFail(organizerId) {
+    if (!UUID_REGEX.test(organizerId)) {
+      throw new NotFoundError("Organizador no encontrado", "ORGANIZER_NOT_FOUND");
+    }
+    const organizer = await this.userRepository.findById(organizerId);
+    if (!organizer || organizer.suspendedAt) {
+      throw new NotFoundError("Organizador no encontrado", "ORGANIZER_NOT_FOUND");
+    }
+    return organizer;
+  }
+
+  getPageUrl(organizerId) {
+    return `${config.frontendUrl}/organizers/${organizerId}`;
+  }
+
+  formatPublicTrip(trip) {
+    return {
+      id: trip.id,
+      name: trip.name,
+      destinationName: trip.destinationName,
+      startDate: trip.startDate,
+      endDate: trip.endDate,
+      spotsLeft: trip.capacity ? Math.max(trip.capacity - trip.memberCount, 0) : null,
+    };
+  }
+}
+
+export default new OrganizerService();
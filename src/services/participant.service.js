@@ -0,0 +1,124 @@
+import participantRepository from "../repository/participant.repository.js";
+import groupMessageRepository from "../repository/groupMessage.repository.js";
+import groupJoinRequestRepository from "../repository/groupJoinRequest.repository.js";
+import directMessageRepository from "../repository/directMessage.repository.js";
+import notificationService from "./notification.service.js";
+import recommendationService from "./recommendation.service.js";
+import logger from "../config/logger.js";
+import { sumByCurrency } from "../utils/expenseSplit.js";
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+class ParticipantService {
+  /**
+   * Builds the participant home screen. Sections are loaded concurrently and a
+   * failing section is returned as null (listed in `errors`) instead of failing the whole response.
+   * @param {string} userId
+   * @returns {Promise<Object>} - { success, data }
+   */
+  async getDashboard(userId) {
+    const sections = {
+      upcomingTrips: () => this.getUpcomingTrips(userId),
+      tasks: () => this.getTasks(userId),
+      unread: () => this.getUnreadCounts(userId),
+      suggestedTrips: () => recommendationService.getRecommendations(userId, 5),
+    };
+
+    const names = Object.keys(sections);
+    const results = await Promise.allSettled(names.map((name) => sections[name]()));
+
+    const data = {};
+    const errors = [];
+    results.forEach((result, index) => {
+      const name = names[index];
+      if (result.status === "fulfilled") {
+        data[name] = result.value;
+      } else {
+        logger.error(`Participant dashboard section ${name} failed for ${userId}: ${result.reason?.message}`);
+        data[name] = null;
+        errors.push(name);
+      }
+    });
+
+    return {
+      success: true,
+      data: { ...data, errors },
+    };
+  }
+
+  /**
+   * Upcoming groups with the number of days until they start
+   */
+  async getUpcomingTrips(userId) {
+    const groups = await participantRepository.findUpcomingGroups(userId);
+    const today = new Date();
+    today.setHours(0, 0, 0, 0);
+
+    return groups.map((group) => ({
+      id: group.id,
+      name: group.name,
+      isOrganizer: group.adminId === userId,
+      memberCount: group.memberCount,
+      startDate: group.startDate,
+      endDate: group.endDate,
+      daysUntilStart: group.startDate
+        ? Math.max(0, Math.ceil((this.toLocalDate(group.startDate) - today) / DAY_MS))
+        : null,
+    }));
+  }
+
+  // pg may return DATE columns as Date objects or "YYYY-MM-DD" strings
+  toLocalDate(value) {
+    return value instanceof Date ? value : new Date(`${value}T00:00:00`);
+  }
+
+  /**
+   * Things the user still has to do
+   */
+  async getTasks(userId) {
+    const [unpaidExpenses, joinRequests] = await Promise.all([
+      participantRepository.findUnpaidExpenses(userId),
+      groupJoinRequestRepository.findByUserId(userId),
+    ]);
+
+    return {
+      unpaidExpenses,
+      unpaidShares: sumByCurrency(
+        unpaidExpenses.flatMap((group) =>
+          group.totals.map(({ currency, share }) => ({ currency, amount: share }))
+        )
+      ),
+      pendingJoinRequests: joinRequests
+        .filter((request) => request.status === "pending")
+        .map((request) => ({
+          id: request.id,
+          groupId: request.groupId,
+          groupName: request.group?.name,
+          createdAt: request.createdAt,
+        })),
+    };
+  }
+
+  /**
+   * Unread notifications, direct messages and group messages
+   */
+  async getUnreadCounts(userId) {
+    const groups = await participantRepository.findUpcomingGroups(userId);
+    const [notifications, directMessages, groupCounts] = await Promise.all([
+      notificationService.getUnreadCount(userId),
+      directMessageRepository.countUnreadByUserId(userId),
+      groupMessageRepository.countUnreadByGroupIds(
+        groups.map((group) => group.id),
+        userId
+      ),
+    ]);
+
+    return {
+      notifications,
+      directMessages,
+      groupMessages: Object.values(groupCounts).reduce((total, count) => total + count, 0),
+    };
+  }
+}
+
+export default new ParticipantService();
@@ -0,0 +1,326 @@
+import paymentRepository from "../repository/payment.repository.js";
+import groupRepository from "../repository/group.repository.js";
+import groupJoinRequestRepository from "../repository/groupJoinRequest.repository.js";
+import stripeClient from "../clients/stripe.client.js";
+import affiliateService from "./affiliate.service.js";
+import bookingTraceService from "./bookingTrace.service.js";
+import logger from "../config/logger.js";
+import { createAndEmitNotification } from "../socket/notification.emitter.js";
+import {
+  AppError,
+  ValidationError,
+  NotFoundError,
+  AuthorizationError,
+} from "../utils/customErrors.js";
+
+/**
+ * Allowed status changes. A failed intent can still be retried by the client,
+ * and a refund that fails puts the payment back to succeeded.
+ */
+export const PAYMENT_TRANSITIONS = {
+  requires_payment: ["processing", "succeeded", "failed", "canceled"],
+  processing: ["succeeded", "failed", "canceled"],
+  failed: ["processing", "succeeded", "canceled"],
+  succeeded: ["refund_pending", "refunded"],
+  refund_pending: ["refunded", "succeeded"],
+  canceled: [],
+  refunded: [],
+};
+
+const OPEN_STATUSES = ["requires_payment", "processing", "failed"];
+
+export const canTransition = (from, to) => (PAYMENT_TRANSITIONS[from] || []).includes(to);
+
+class PaymentService {
+  /**
+   * Moves a payment to a new status if the state machine allows it
+   * @returns {Promise<Object|null>} - Updated payment, or null when the change is not allowed
+   */
+  async transition(payment, to, data = {}) {
+    if (payment.status === to) {
+      return payment;
+    }
+    if (!canTransition(payment.status, to)) {
+      logger.warn(`Ignored payment ${payment.id} transition ${payment.status} -> ${to}`);
+      return null;
+    }
+
+    const updated = await paymentRepository.transition(payment.id, payment.status, {
+      status: to,
+      ...data,
+    });
+    if (!updated) {
+      // Another webhook got there first; retry from the current status
+      const current = await paymentRepository.findById(payment.id);
+      return current.status === to ? current : this.transition(current, to, data);
+    }
+    logger.info(`Payment ${payment.id}: ${payment.status} -> ${to}`);
+    await bookingTraceService.record(payment.joinRequestId, {
+      source: "payment",
+      type: to,
+      data: { paymentId: payment.id, from: payment.status },
+    });
+    return updated;
+  }
+
+  /**
+   * Creates (or resumes) the deposit payment of the user's open join request
+   * @param {string} groupId
+   * @param {string} userId
+   * @returns {Promise<Object>} - { payment, clientSecret }
+   */
+  async createDepositIntent(groupId, userId) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      throw new NotFoundError("Grupo no encontrado", "GROUP_NOT_FOUND");
+    }
+    if (!group.depositAmount) {
+      throw new ValidationError("Este viaje no requiere seña");
+    }
+
+    const request = await groupJoinRequestRepository.findPending(groupId, userId);
+    if (!request) {
+      throw new ValidationError("Primero tienes que solicitar unirte al grupo");
+    }
+
+    const payments = await paymentRepository.findByJoinRequest(request.id);
+    if (payments.some((payment) => ["succeeded", "refund_pending"].includes(payment.status))) {
+      throw new AppError("La seña ya fue pagada", 409, "PAYMENT_ALREADY_COMPLETED");
+    }
+
+    let payment = payments.find((item) => OPEN_STATUSES.includes(item.status));
+    if (!payment) {
+      payment = await paymentRepository.create({
+        userId,
+        groupId,
+        joinRequestId: request.id,
+        kind: "deposit",
+        amount: group.depositAmount,
+        currency: group.depositCurrency,
+      });
+    }
+
+    // Same idempotency key for the same payment: Stripe returns the existing intent
+    const intent = await stripeClient.createPaymentIntent(
+      {
+        amount: payment.amount,
+        currency: payment.currency,
+        description: `Seña - ${group.name}`,
+        metadata: {
+          paymentId: payment.id,
+          groupId,
+          userId,
+          joinRequestId: request.id,
+          bookingId: request.id,
+        },
+      },
+      `payment-${payment.id}`
+    );
+    if (payment.stripePaymentIntentId !== intent.id) {
+      payment = await paymentRepository.update(payment.id, { stripePaymentIntentId: intent.id });
+      await bookingTraceService.record(request.id, {
+        source: "payment",
+        type: "intent_created",
+        data: { paymentId: payment.id, paymentIntentId: intent.id, amount: payment.amount },
+      });
+    }
+
+    return { payment: this.formatPayment(payment), clientSecret: intent.client_secret };
+  }
+
+  /**
+   * Applies a verified Stripe webhook event
+   * @param {Object} event
+   */
+  async handleWebhookEvent(event) {
+    const object = event.data?.object || {};
+    const paymentIntentId =
+      event.type.startsWith("payment_intent.") ? object.id : object.payment_intent;
+    if (!paymentIntentId) {
+      return;
+    }
+
+    const payment = await paymentRepository.findByPaymentIntent(paymentIntentId);
+    if (!payment) {
+      logger.warn(`Stripe event ${event.id} for unknown payment intent ${paymentIntentId}`);
+      return;
+    }
+    await bookingTraceService.record(payment.joinRequestId, {
+      source: "webhook",
+      type: event.type,
+      data: { eventId: event.id, paymentId: payment.id },
+    });
+
+    switch (event.type) {
+      case "payment_intent.processing":
+        await this.transition(payment, "processing");
+        break;
+      case "payment_intent.succeeded": {
+        const succeeded = await this.transition(payment, "succeeded", { failureReason: null });
+        if (succeeded && succeeded.status === "succeeded") {
+          await affiliateService.recordBooking(succeeded);
+        }
+        break;
+      }
+      case "payment_intent.payment_failed":
+        await this.notifyChange(
+          payment,
+          await this.transition(payment, "failed", {
+            failureReason: object.last_payment_error?.message || null,
+          })
+        );
+        break;
+      case "payment_intent.canceled":
+        await this.transition(payment, "canceled");
+        break;
+      case "charge.refunded":
+        await this.notifyChange(payment, await this.transition(payment, "refunded"));
+        break;
+      case "refund.updated":
+      case "refund.failed":
+        if (object.status === "failed" || object.status === "canceled") {
+          await this.transition(payment, "succeeded", {
+            failureReason: `Reembolso fallido: ${object.failure_reason || object.status}`,
+          });
+        } else if (object.status === "succeeded") {
+          await this.notifyChange(payment, await this.transition(payment, "refunded"));
+        }
+        break;
+      default:
+        logger.info(`Unhandled Stripe event ${event.type}`);
+    }
+  }
+
+  /**
+   * Tells the payer that a payment failed or was refunded. These go out even
+   * during quiet hours.
+   * @param {Object} previous - Payment before the webhook
+   * @param {Object|null} updated - Payment after the transition
+   */
+  async notifyChange(previous, updated) {
+    if (!updated || updated.status === previous.status) {
+      return;
+    }
+    const amount = `${(updated.amount / 100).toFixed(2)} ${updated.currency}`;
+    const notifications = {
+      failed: {
+        type: "PAYMENT_FAILED",
+        title: "El pago no se pudo completar",
+        message: `Tu pago de ${amount} fue rechazado. Puedes intentarlo de nuevo.`,
+      },
+      refunded: {
+        type: "PAYMENT_REFUNDED",
+        title: "Reembolso realizado",
+        message: `Te devolvimos ${amount}.`,
+      },
+    };
+    const notification = notifications[updated.status];
+    if (!notification) {
+      return;
+    }
+
+    try {
+      await createAndEmitNotification({
+        userId: updated.userId,
+        ...notification,
+        data: {
+          paymentId: updated.id,
+          groupId: updated.groupId,
+          bookingId: updated.joinRequestId,
+          amount: (updated.amount / 100).toFixed(2),
+          currency: updated.currency,
+        },
+        priority: "critical",
+      });
+    } catch (notifError) {
+      logger.error(`Error notifying payment ${updated.id}: ${notifError.message}`);
+    }
+  }
+
+  /**
+   * Gives the money back for every payment of a join request: refunds what was
+   * paid and cancels what was not. Never throws so decisions are not blocked.
+   * @returns {Promise<Object>} - { status, amount } with the amount being refunded
+   */
+  async refundJoinRequest(joinRequestId) {
+    return await this.refundPayments(await paymentRepository.findByJoinRequest(joinRequestId));
+  }
+
+  /**
+   * Same as refundJoinRequest for a member removed from a group
+   */
+  async refundGroupMember(groupId, userId) {
+    return await this.refundPayments(await paymentRepository.findByGroupAndUser(groupId, userId));
+  }
+
+  async refundPayments(payments) {
+    let amount = 0;
+    let failed = false;
+
+    for (const payment of payments) {
+      try {
+        if (payment.status === "succeeded") {
+          const refund = await stripeClient.createRefund(
+            payment.stripePaymentIntentId,
+            `refund-${payment.id}`
+          );
+          await this.transition(
+            payment,
+            refund.status === "succeeded" ? "refunded" : "refund_pending",
+            { stripeRefundId: refund.id }
+          );
+          amount += payment.amount;
+        } else if (OPEN_STATUSES.includes(payment.status)) {
+          if (payment.stripePaymentIntentId) {
+            await stripeClient.cancelPaymentIntent(payment.stripePaymentIntentId);
+          }
+          await this.transition(payment, "canceled");
+        }
+      } catch (err) {
+        failed = true;
+        logger.error(`Refund of payment ${payment.id} failed: ${err.message}`);
+      }
+    }
+
+    if (failed) {
+      return { status: "failed", amount };
+    }
+    return { status: amount ? "refunded" : "not_applicable", amount };
+  }
+
+  async getGroupPayments(groupId, requesterId) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      throw new NotFoundError("Grupo no encontrado", "GROUP_NOT_FOUND");
+    }
+    if (group.adminId !== requesterId) {
+      throw new AuthorizationError("Solo el administrador puede ver los pagos del grupo");
+    }
+    const payments = await paymentRepository.findByGroup(groupId);
+    return payments.map((payment) => this.formatPayment(payment));
+  }
+
+  async getUserPayments(userId) {
+    const payments = await paymentRepository.findByUser(userId);
+    return payments.map((payment) => this.formatPayment(payment));
+  }
+
+  formatPayment(payment) {
+    return {
+      id: payment.id,
+      groupId: payment.groupId,
+      group: payment.group ? { id: payment.group.id, name: payment.group.name } : undefined,
+      user: payment.user ? { id: payment.user.id, name: payment.user.name } : { id: payment.userId },
+      joinRequestId: payment.joinRequestId,
+      kind: payment.kind,
+      amount: (payment.amount / 100).toFixed(2),
+      currency: payment.currency,
+      status: payment.status,
+      failureReason: payment.failureReason,
+      createdAt: payment.createdAt,
+      updatedAt: payment.updatedAt,
+    };
+  }
+}
+
+export default new PaymentService();
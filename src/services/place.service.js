@@ -2,6 +2,7 @@ import placeRepository from "../repository/place.repository.js";
 import userFavoriteRepository from "../repository/userFavorite.repository.js";
 import { validatePlaceData, validateDescription } from "../utils/validators.js";
 import logger from "../config/logger.js";
+import searchService from "./search.service.js";
 
 class PlaceService {
   /**
@@ -57,6 +58,7 @@ class PlaceService {
       });
 
       logger.info(`Place added successfully: ${place.id} - ${place.name}`);
+      await searchService.scheduleIndex("destination", place.id);
 
       // 4. Retornar lugar sin datos sensibles (aunque no hay ninguno)
       return {
@@ -68,6 +70,7 @@ class PlaceService {
       // Simular error de servicio externo si es necesario
       const error = new Error("Servicio externo no disponible.");
       error.status = 503;
+      error.errorCode = "EXTERNAL_SERVICE_UNAVAILABLE";
       throw error;
     }
   }
@@ -123,6 +126,7 @@ class PlaceService {
       // Simular error de servicio externo
       const error = new Error("Servicio externo no disponible.");
       error.status = 503;
+      error.errorCode = "EXTERNAL_SERVICE_UNAVAILABLE";
       throw error;
     }
   }
@@ -205,6 +209,7 @@ class PlaceService {
       });
 
       logger.info(`Updated description for place ID: ${id}`);
+      await searchService.scheduleIndex("destination", id);
 
       return {
         id: updatedPlace.id,
@@ -0,0 +1,77 @@
+import config from "../config/index.js";
+import { getAgeRule } from "../utils/ageVerification.js";
+
+/**
+ * Features that can be turned off per country or tenant, with their defaults
+ */
+export const FEATURES = {
+  payments: true,
+  directMessages: true,
+  aiChat: true,
+  joinRequests: true,
+};
+
+/**
+ * Requirements on the user. idVerification: creating trips, joining them and
+ * paying need an identity verified by an admin (requireIdVerification)
+ */
+export const REQUIREMENTS = {
+  idVerification: false,
+};
+
+/**
+ * Resolves which features are available for a country/tenant. The layers that
+ * apply (FEATURE_POLICIES.default, the country and the tenant) combine
+ * restrictively: a feature is on only if none of them turns it off, and a
+ * requirement applies if any of them sets it. A tenant cannot re-enable what
+ * a country forbids, nor waive what it requires.
+ */
+class PolicyService {
+  /**
+   * @param {Object} context - { country, tenant }
+   * @returns {Object} - { features, requirements }
+   */
+  resolve({ country = null, tenant = null } = {}) {
+    const { policies } = config;
+    const layers = [
+      policies.default,
+      country && policies.countries?.[country],
+      tenant && policies.tenants?.[tenant],
+    ].filter(Boolean);
+
+    const features = { ...FEATURES };
+    const requirements = { ...REQUIREMENTS };
+    for (const layer of layers) {
+      for (const [name, value] of Object.entries(layer.features || {})) {
+        if (name in FEATURES) features[name] = features[name] && Boolean(value);
+      }
+      for (const [name, value] of Object.entries(layer.requirements || {})) {
+        if (name in REQUIREMENTS) requirements[name] = requirements[name] || Boolean(value);
+      }
+    }
+
+    return { features, requirements };
+  }
+
+  isEnabled(feature, context = {}) {
+    return this.resolve(context).features[feature] === true;
+  }
+
+  /**
+   * Configuration the apps need at startup for a user/country
+   * @param {Object} context - { country, tenant }
+   */
+  getClientConfig({ country = null, tenant = null } = {}) {
+    const { features, requirements } = this.resolve({ country, tenant });
+    return {
+      country,
+      tenant,
+      features,
+      requirements,
+      age: getAgeRule(country),
+      defaultCurrency: config.expenses.defaultCurrency,
+    };
+  }
+}
+
+export default new PolicyService();
@@ -0,0 +1,325 @@
+import UserRepository from "../repository/user.repository.js";
+import exchangeRateService from "./exchangeRate.service.js";
+import searchService from "./search.service.js";
+import ageReviewService from "./ageReview.service.js";
+import userBlockService from "./userBlock.service.js";
+import geocodingClient from "../clients/geocoding.client.js";
+import logger from "../config/logger.js";
+import { ValidationError, NotFoundError } from "../utils/customErrors.js";
+import { parseDateOfBirth, calculateAge, getUserAge } from "../utils/ageVerification.js";
+import { GROUP_SIZE_NAMES } from "../utils/groupSize.js";
+import { SUPPORTED_LOCALES } from "../utils/i18n.js";
+
+/**
+ * Profile fields the owner can show or hide from other users, with their defaults
+ */
+export const PROFILE_VISIBILITY_DEFAULTS = {
+  email: "private",
+  age: "public",
+  country: "private",
+  bio: "public",
+  languages: "public",
+  travelPreferences: "public",
+  profilePicture: "public",
+};
+
+export const VISIBILITY_LEVELS = ["public", "private"];
+
+export const TRAVEL_STYLES = [
+  "adventure",
+  "beach",
+  "culture",
+  "gastronomy",
+  "nature",
+  "nightlife",
+  "relax",
+  "road_trip",
+];
+export const TRAVEL_BUDGETS = ["budget", "moderate", "luxury"];
+export const TRAVEL_PACES = ["relaxed", "balanced", "intense"];
+export const TRAVEL_GROUP_SIZES = GROUP_SIZE_NAMES;
+
+const LANGUAGE_REGEX = /^[a-z]{2}$/;
+const MAX_LANGUAGES = 10;
+const MAX_BIO_LENGTH = 500;
+
+class ProfileService {
+  constructor() {
+    this.userRepository = new UserRepository();
+  }
+
+  /**
+   * Full profile of the authenticated user
+   * @param {string} userId
+   */
+  async getOwnProfile(userId) {
+    const user = await this.getUserOrFail(userId);
+    return this.formatOwnProfile(user);
+  }
+
+  /**
+   * Profile of a user as seen by another user: only public fields
+   * @param {string} userId
+   * @param {string} viewerId
+   */
+  async getProfile(userId, viewerId) {
+    const user = await this.getUserOrFail(userId);
+    if (userId === viewerId) {
+      return this.formatOwnProfile(user);
+    }
+    // Blocked users look like they don't exist to each other
+    if (await userBlockService.isBlockedBetween(userId, viewerId)) {
+      throw new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND");
+    }
+    return this.formatForViewer(user, viewerId);
+  }
+
+  /**
+   * The fields of a user another user may see, per the user's visibility
+   * settings. Every response showing a user to someone else goes through it.
+   * @param {Object} user
+   * @param {string} viewerId
+   */
+  formatForViewer(user, viewerId) {
+    if (user.id === viewerId) {
+      return this.formatOwnProfile(user);
+    }
+    const visibility = this.getVisibility(user);
+    const fullProfile = this.formatOwnProfile(user);
+    const profile = {
+      id: user.id,
+      name: user.name,
+      isEmailConfirmed: user.isEmailConfirmed,
+      createdAt: user.createdAt.toISOString(),
+    };
+    for (const field of Object.keys(PROFILE_VISIBILITY_DEFAULTS)) {
+      if (visibility[field] === "public") {
+        profile[field] = fullProfile[field];
+      }
+    }
+    return profile;
+  }
+
+  /**
+   * Updates the profile of the authenticated user
+   * @param {string} userId
+   * @param {Object} data - { name, age, dateOfBirth, preferredCurrency, bio, homeCity, languages, locale, travelPreferences, visibility }
+   * @returns {Promise<Object>} Updated own profile
+   */
+  async updateProfile(userId, data) {
+    const currentUser = await this.getUserOrFail(userId);
+    const {
+      name,
+      age,
+      dateOfBirth,
+      preferredCurrency,
+      bio,
+      homeCity,
+      languages,
+      locale,
+      travelPreferences,
+      visibility,
+    } = data;
+    const updateData = {};
+
+    if (name !== undefined) {
+      if (typeof name !== "string" || name.trim().length === 0) {
+        throw new ValidationError("El nombre debe ser una cadena no vacía");
+      }
+      if (name.length > 30) {
+        throw new ValidationError("El nombre no puede exceder 30 caracteres");
+      }
+      updateData.name = name.trim();
+    }
+
+    // Con fecha de nacimiento la edad se calcula sola y no se puede editar
+    if (age !== undefined && currentUser.dateOfBirth) {
+      throw new ValidationError("La edad se calcula a partir de tu fecha de nacimiento");
+    }
+    if (age !== undefined) {
+      if (age !== null) {
+        const ageNum = parseInt(age);
+        if (isNaN(ageNum) || ageNum < 13 || ageNum > 120) {
+          throw new ValidationError("La edad debe estar entre 13 y 120 años");
+        }
+      }
+      updateData.age = age === null ? null : parseInt(age);
+    }
+
+    // Las cuentas anteriores a la verificación de edad pueden cargarla una sola vez
+    if (dateOfBirth !== undefined) {
+      if (currentUser.dateOfBirth) {
+        throw new ValidationError("La fecha de nacimiento ya fue registrada");
+      }
+      const dob = parseDateOfBirth(dateOfBirth);
+      if (!dob) {
+        throw new ValidationError("Fecha de nacimiento inválida (formato AAAA-MM-DD)");
+      }
+      updateData.dateOfBirth = dateOfBirth;
+    }
+
+    if (preferredCurrency !== undefined) {
+      if (preferredCurrency !== null) {
+        if (
+          typeof preferredCurrency !== "string" ||
+          !(await exchangeRateService.isSupported(preferredCurrency.toUpperCase()))
+        ) {
+          throw new ValidationError("Moneda no soportada");
+        }
+      }
+      updateData.preferredCurrency = preferredCurrency === null ? null : preferredCurrency.toUpperCase();
+    }
+
+    if (bio !== undefined) {
+      if (bio !== null && (typeof bio !== "string" || bio.length > MAX_BIO_LENGTH)) {
+        throw new ValidationError(`La biografía no puede superar los ${MAX_BIO_LENGTH} caracteres`);
+      }
+      updateData.bio = bio ? bio.trim() : null;
+    }
+
+    // La ciudad se geocodifica para encontrar viajes que salen cerca
+    if (homeCity !== undefined) {
+      if (homeCity !== null && (typeof homeCity !== "string" || homeCity.trim().length > 150)) {
+        throw new ValidationError("La ciudad debe ser un texto de hasta 150 caracteres");
+      }
+      const city = homeCity ? homeCity.trim() : null;
+      const location = city ? await geocodingClient.tryGeocode(city) : null;
+      updateData.homeCity = city || null;
+      updateData.homeLat = location ? location.lat : null;
+      updateData.homeLng = location ? location.lng : null;
+    }
+
+    if (languages !== undefined) {
+      updateData.languages = this.validateLanguages(languages);
+    }
+    // Sin idioma elegido se usa el Accept-Language de cada solicitud
+    if (locale !== undefined) {
+      if (locale !== null && !SUPPORTED_LOCALES.includes(locale)) {
+        throw new ValidationError(
+          "Idioma no soportado",
+          { locale: `Debe ser uno de: ${SUPPORTED_LOCALES.join(", ")}` },
+          "UNSUPPORTED_LOCALE"
+        );
+      }
+      updateData.locale = locale;
+    }
+    if (travelPreferences !== undefined) {
+      updateData.travelPreferences = this.validateTravelPreferences(travelPreferences);
+    }
+    if (visibility !== undefined) {
+      updateData.profileVisibility = { ...currentUser.profileVisibility, ...this.validateVisibility(visibility) };
+    }
+
+    logger.info(`Updating profile of user ${userId}: ${Object.keys(updateData).join(", ")}`);
+    await this.userRepository.updateUser(userId, updateData);
+    if (updateData.name !== undefined) {
+      await searchService.scheduleIndex("user", userId);
+    }
+    const declaredAge = updateData.dateOfBirth ? calculateAge(updateData.dateOfBirth) : updateData.age;
+    if (typeof declaredAge === "number") {
+      // A declared age under the jurisdiction minimum goes to admin review
+      await ageReviewService.flagDeclaredAge(currentUser, declaredAge);
+    }
+
+    return await this.getOwnProfile(userId);
+  }
+
+  validateLanguages(languages) {
+    if (!Array.isArray(languages) || languages.length > MAX_LANGUAGES) {
+      throw new ValidationError(`languages debe ser una lista de hasta ${MAX_LANGUAGES} idiomas`);
+    }
+    const codes = languages.map((code) => (typeof code === "string" ? code.trim().toLowerCase() : code));
+    if (codes.some((code) => !LANGUAGE_REGEX.test(code))) {
+      throw new ValidationError("Los idiomas deben ser códigos ISO 639-1 (ej: es, en, pt)");
+    }
+    return [...new Set(codes)];
+  }
+
+  validateTravelPreferences(preferences) {
+    if (preferences === null) {
+      return {};
+    }
+    if (typeof preferences !== "object" || Array.isArray(preferences)) {
+      throw new ValidationError("travelPreferences debe ser un objeto");
+    }
+
+    const { styles, budget, pace, groupSizes } = preferences;
+    const result = {};
+    if (styles !== undefined) {
+      if (!Array.isArray(styles) || styles.some((style) => !TRAVEL_STYLES.includes(style))) {
+        throw new ValidationError(`Los estilos de viaje deben ser de: ${TRAVEL_STYLES.join(", ")}`);
+      }
+      result.styles = [...new Set(styles)];
+    }
+    if (budget !== undefined && budget !== null) {
+      if (!TRAVEL_BUDGETS.includes(budget)) {
+        throw new ValidationError(`El presupuesto debe ser uno de: ${TRAVEL_BUDGETS.join(", ")}`);
+      }
+      result.budget = budget;
+    }
+    if (pace !== undefined && pace !== null) {
+      if (!TRAVEL_PACES.includes(pace)) {
+        throw new ValidationError(`El ritmo debe ser uno de: ${TRAVEL_PACES.join(", ")}`);
+      }
+      result.pace = pace;
+    }
+    if (groupSizes !== undefined && groupSizes !== null) {
+      if (!Array.isArray(groupSizes) || groupSizes.some((size) => !TRAVEL_GROUP_SIZES.includes(size))) {
+        throw new ValidationError(`Los tamaños de grupo deben ser de: ${TRAVEL_GROUP_SIZES.join(", ")}`);
+      }
+      result.groupSizes = [...new Set(groupSizes)];
+    }
+    return result;
+  }
+
+  validateVisibility(visibility) {
+    if (typeof visibility !== "object" || visibility === null || Array.isArray(visibility)) {
+      throw new ValidationError("visibility debe ser un objeto");
+    }
+    for (const [field, level] of Object.entries(visibility)) {
+      if (!(field in PROFILE_VISIBILITY_DEFAULTS)) {
+        throw new ValidationError(`Campo de perfil inválido: ${field}`);
+      }
+      if (!VISIBILITY_LEVELS.includes(level)) {
+        throw new ValidationError(`La visibilidad debe ser una de: ${VISIBILITY_LEVELS.join(", ")}`);
+      }
+    }
+    return visibility;
+  }
+
+  getVisibility(user) {
+    return { ...PROFILE_VISIBILITY_DEFAULTS, ...(user.profileVisibility || {}) };
+  }
+
+  async getUserOrFail(userId) {
+    const user = await this.userRepository.findById(userId);
+    if (!user) {
+      throw new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND");
+    }
+    return user;
+  }
+
+  formatOwnProfile(user) {
+    return {
+      id: user.id,
+      email: user.email,
+      name: user.name,
+      age: getUserAge(user),
+      dateOfBirth: user.dateOfBirth,
+      country: user.country,
+      homeCity: user.homeCity,
+      preferredCurrency: user.preferredCurrency,
+      profilePicture: user.profilePicture,
+      bio: user.bio,
+      languages: user.languages || [],
+      locale: user.locale,
+      travelPreferences: user.travelPreferences || {},
+      visibility: this.getVisibility(user),
+      isEmailConfirmed: user.isEmailConfirmed,
+      createdAt: user.createdAt.toISOString(),
+      updatedAt: user.updatedAt.toISOString(),
+    };
+  }
+}
+
+export default new ProfileService();
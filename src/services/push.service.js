@@ -0,0 +1,235 @@
+import deviceTokenRepository from "../repository/deviceToken.repository.js";
+import heldPushNotificationRepository from "../repository/heldPushNotification.repository.js";
+import notificationPreferenceService from "./notificationPreference.service.js";
+import jobQueueService from "./jobQueue.service.js";
+import pushTemplateService from "./pushTemplate.service.js";
+import { getUnreadByIds } from "./notification.service.js";
+import { PUSH_DIGEST_JOB } from "../jobs/pushDigest.job.js";
+import fcmClient from "../clients/fcm.client.js";
+import apnsClient from "../clients/apns.client.js";
+import logger from "../config/logger.js";
+import { ValidationError, NotFoundError } from "../utils/customErrors.js";
+
+const PLATFORMS = ["android", "ios", "web"];
+
+/**
+ * Safety and payment events are pushed right away, even during quiet hours
+ */
+export const CRITICAL_NOTIFICATION_TYPES = [
+  "ACCOUNT_AGE_RESTRICTED",
+  "GROUP_SPOT_REMOVED",
+  "PAYMENT_FAILED",
+  "PAYMENT_REFUNDED",
+];
+const PROVIDERS = {
+  fcm: fcmClient,
+  apns: apnsClient,
+};
+
+class PushService {
+  /**
+   * Registers (or refreshes) a device token for the user
+   * @param {string} userId
+   * @param {Object} data - { token, platform, provider, deviceName }
+   * @returns {Promise<Object>} Stored device token
+   */
+  async registerDevice(userId, { token, platform, provider, deviceName }) {
+    if (!token || typeof token !== "string" || token.length > 512) {
+      throw new ValidationError("El token del dispositivo es requerido (máx. 512 caracteres)");
+    }
+    if (!PLATFORMS.includes(platform)) {
+      throw new ValidationError(`Plataforma inválida. Use: ${PLATFORMS.join(", ")}`);
+    }
+
+    // iOS apps may use APNs directly or go through FCM; Android and web always use FCM
+    const resolvedProvider = provider || (platform === "ios" ? "apns" : "fcm");
+    if (!PROVIDERS[resolvedProvider]) {
+      throw new ValidationError(`Proveedor inválido. Use: ${Object.keys(PROVIDERS).join(", ")}`);
+    }
+    if (resolvedProvider === "apns" && platform !== "ios") {
+      throw new ValidationError("APNs solo está disponible para dispositivos iOS");
+    }
+
+    const device = await deviceTokenRepository.upsert({
+      userId,
+      token,
+      platform,
+      provider: resolvedProvider,
+      deviceName: deviceName ? String(deviceName).slice(0, 100) : null,
+    });
+
+    logger.info(`[Push] Device registered for user ${userId} (${platform}/${resolvedProvider})`);
+    return device;
+  }
+
+  /**
+   * Removes a device token of the user
+   * @param {string} userId
+   * @param {string} token
+   */
+  async unregisterDevice(userId, token) {
+    const deleted = await deviceTokenRepository.deleteForUser(userId, token);
+    if (!deleted) {
+      throw new NotFoundError("Dispositivo no encontrado");
+    }
+    logger.info(`[Push] Device unregistered for user ${userId}`);
+  }
+
+  /**
+   * Sends a push notification to every device of the notification's user,
+   * honoring the user's preferences. Non-critical pushes arriving during the
+   * user's quiet hours are held and sent as a digest when they end. The text
+   * is rendered in the user's language when it is sent.
+   * @param {Object} notification - { id, userId, type, title, message, data }
+   * @param {Object} options - { priority: "normal" | "critical" }
+   * @returns {Promise<Object>} - { sent, failed, skipped, held }
+   */
+  async dispatch(notification, { priority = "normal" } = {}) {
+    const { userId, type, data } = notification;
+
+    const preferences = await notificationPreferenceService.getPreferences(userId);
+    if (!notificationPreferenceService.isChannelAllowed(preferences, type, "push")) {
+      logger.info(`[Push] Skipped ${type} for user ${userId}: disabled by preferences`);
+      return { sent: 0, failed: 0, skipped: true };
+    }
+
+    const critical = priority === "critical" || CRITICAL_NOTIFICATION_TYPES.includes(type);
+    const releaseAt = critical ? null : notificationPreferenceService.getQuietHoursEnd(preferences);
+    if (releaseAt) {
+      await this.hold(notification, releaseAt);
+      return { sent: 0, failed: 0, skipped: false, held: true };
+    }
+
+    const { title, body } = await this.localize(notification, preferences);
+    return await this.sendToDevices(userId, type, {
+      title,
+      body,
+      data: { ...(data || {}), type, notificationId: notification.id },
+    });
+  }
+
+  /**
+   * Renders the push text of a notification for its recipient
+   * @param {Object} notification - { userId, type, title, message, data }
+   * @param {Object} preferences - Effective notification preferences of the recipient
+   * @returns {Promise<Object>} - { title, body, language }
+   */
+  async localize(notification, preferences) {
+    const language = await pushTemplateService.resolveLanguage(notification.userId);
+    return await pushTemplateService.render(notification, {
+      language,
+      timeZone: preferences.timezone,
+    });
+  }
+
+  /**
+   * Holds a push until the user's quiet hours end, scheduling the digest
+   * with the first push held for that time
+   */
+  async hold(notification, releaseAt) {
+    const first = await heldPushNotificationRepository.hold({
+      userId: notification.userId,
+      notificationId: notification.id,
+      releaseAt,
+    });
+    if (first) {
+      await jobQueueService.enqueue(PUSH_DIGEST_JOB, { userId: notification.userId }, { runAt: releaseAt });
+    }
+    logger.info(
+      `[Push] Held ${notification.type} for user ${notification.userId} until ${releaseAt.toISOString()}`
+    );
+  }
+
+  /**
+   * Sends the pushes held during quiet hours: a single one is sent as is,
+   * several are summarized. Notifications already read are left out.
+   * @param {string} userId
+   * @returns {Promise<Object>} - { sent, failed, skipped }
+   */
+  async deliverHeld(userId) {
+    const ids = await heldPushNotificationRepository.takeDue(userId);
+    const notifications = await getUnreadByIds(userId, ids);
+    if (notifications.length === 0) {
+      return { sent: 0, failed: 0, skipped: true };
+    }
+
+    // Preferences (and language) as they are now, not when the pushes were held
+    const preferences = await notificationPreferenceService.getPreferences(userId);
+    if (notifications.length === 1) {
+      const [notification] = notifications;
+      const { title, body } = await this.localize(notification, preferences);
+      return await this.sendToDevices(userId, notification.type, {
+        title,
+        body,
+        data: { ...(notification.data || {}), type: notification.type, notificationId: notification.id },
+      });
+    }
+
+    const { title, body } = await this.localize(
+      {
+        userId,
+        type: "NOTIFICATIONS_DIGEST",
+        title: "Mientras no estabas",
+        message: `Tienes ${notifications.length} notificaciones nuevas`,
+        data: { count: notifications.length },
+      },
+      preferences
+    );
+    return await this.sendToDevices(userId, "NOTIFICATIONS_DIGEST", {
+      title,
+      body,
+      data: {
+        type: "NOTIFICATIONS_DIGEST",
+        notificationIds: notifications.map((notification) => notification.id),
+      },
+    });
+  }
+
+  /**
+   * Sends a payload to every device of a user. Tokens rejected by the provider are removed.
+   * @returns {Promise<Object>} - { sent, failed, skipped }
+   */
+  async sendToDevices(userId, type, payload) {
+    const devices = await deviceTokenRepository.findByUserId(userId);
+    if (devices.length === 0) {
+      return { sent: 0, failed: 0, skipped: true };
+    }
+
+    let sent = 0;
+    let failed = 0;
+    const invalidTokens = [];
+
+    for (const device of devices) {
+      const client = PROVIDERS[device.provider];
+      if (!client || !client.isConfigured()) {
+        logger.debug(`[Push] Provider ${device.provider} not configured, skipping device`);
+        continue;
+      }
+
+      try {
+        const result = await client.send(device.token, payload);
+        if (result.success) {
+          sent++;
+        } else {
+          failed++;
+          if (result.invalidToken) {
+            invalidTokens.push(device.token);
+          }
+        }
+      } catch (error) {
+        failed++;
+        logger.error(`[Push] Error sending to ${device.provider} device: ${error.message}`);
+      }
+    }
+
+    if (invalidTokens.length > 0) {
+      const removed = await deviceTokenRepository.deleteTokens(invalidTokens);
+      logger.info(`[Push] Removed ${removed} invalid device tokens for user ${userId}`);
+    }
+
+    logger.info(`[Push] ${type} for user ${userId}: ${sent} sent, ${failed} failed`);
+    return { sent, failed, skipped: false };
+  }
+}
+
+export default new PushService();
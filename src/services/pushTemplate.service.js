@@ -0,0 +1,187 @@
+import pushTemplateOverrideRepository from "../repository/pushTemplateOverride.repository.js";
+import UserRepository from "../repository/user.repository.js";
+import auditService, { AUDIT_ACTIONS } from "./audit.service.js";
+import logger from "../config/logger.js";
+import { ValidationError, NotFoundError } from "../utils/customErrors.js";
+import {
+  PUSH_LANGUAGES,
+  DEFAULT_PUSH_LANGUAGE,
+  PUSH_TEMPLATES,
+  getPlaceholders,
+  fillTemplate,
+} from "../utils/pushTemplates.js";
+
+const userRepository = new UserRepository();
+
+const MAX_TITLE_LENGTH = 150;
+const MAX_BODY_LENGTH = 500;
+
+/**
+ * Push texts in the recipient's language and time zone. Templates live in
+ * code (utils/pushTemplates.js) and admins can override them per language.
+ * Texts are rendered when the push is sent, so a held push uses the
+ * templates and preferences of the moment it goes out.
+ */
+class PushTemplateService {
+  /**
+   * Language of the pushes of a user: the locale of the profile (the one of
+   * the API messages and emails), else the first supported language they
+   * speak, else Spanish
+   * @param {string} userId
+   * @returns {Promise<string>}
+   */
+  async resolveLanguage(userId) {
+    const user = await userRepository.findById(userId);
+    if (PUSH_LANGUAGES.includes(user?.locale)) {
+      return user.locale;
+    }
+    const spoken = Array.isArray(user?.languages) ? user.languages : [];
+    return spoken.find((language) => PUSH_LANGUAGES.includes(language)) || DEFAULT_PUSH_LANGUAGE;
+  }
+
+  /**
+   * Title and body of a push. Falls back to the default language when the
+   * template has no translation (or it lacks data), and to the stored title
+   * and message when the type has no template at all.
+   * @param {Object} notification - { type, title, message, data }
+   * @param {Object} locale - { language, timeZone }
+   * @returns {Promise<Object>} - { title, body, language }
+   */
+  async render(notification, { language, timeZone }) {
+    const { type, data } = notification;
+    const overrides = await this.findOverrides(type);
+    for (const candidate of [...new Set([language, DEFAULT_PUSH_LANGUAGE])]) {
+      const template = overrides.get(candidate) || PUSH_TEMPLATES[type]?.[candidate];
+      if (!template) {
+        continue;
+      }
+      const locale = { language: candidate, timeZone };
+      const title = fillTemplate(template.title, data, locale);
+      const body = fillTemplate(template.body, data, locale);
+      if (title !== null && body !== null) {
+        return { title, body, language: candidate };
+      }
+      logger.warn(`[Push] Template ${type}/${candidate} is missing data, falling back`);
+    }
+    return { title: notification.title, body: notification.message, language: null };
+  }
+
+  async findOverrides(type) {
+    try {
+      const overrides = await pushTemplateOverrideRepository.findByTypes([type]);
+      return new Map(overrides.map((override) => [override.language, override]));
+    } catch (error) {
+      // A push in the built-in text beats no push
+      logger.error(`[Push] Could not load template overrides of ${type}: ${error.message}`);
+      return new Map();
+    }
+  }
+
+  /**
+   * Every template with its built-in and overridden texts, for admins
+   * @returns {Promise<Array>} - [{ type, placeholders, languages: { es: { title, body, overridden,
+   *                              builtIn, updatedAt } } }]
+   */
+  async listTemplates() {
+    const overrides = await pushTemplateOverrideRepository.findAll();
+    return Object.entries(PUSH_TEMPLATES).map(([type, builtIns]) => {
+      const languages = {};
+      for (const language of PUSH_LANGUAGES) {
+        const builtIn = builtIns[language] || null;
+        const override = overrides.find(
+          (item) => item.type === type && item.language === language
+        );
+        languages[language] = {
+          title: override?.title ?? builtIn?.title ?? null,
+          body: override?.body ?? builtIn?.body ?? null,
+          overridden: Boolean(override),
+          builtIn,
+          updatedAt: override?.updatedAt || null,
+        };
+      }
+      return { type, placeholders: this.getAllowedPlaceholders(type), languages };
+    });
+  }
+
+  /**
+   * Overrides the text of a template in a language
+   * @param {string} adminId
+   * @param {string} type
+   * @param {string} language
+   * @param {Object} text - { title, body }
+   * @returns {Promise<Object>} Override
+   */
+  async setOverride(adminId, type, language, { title, body }) {
+    this.validateKey(type, language);
+    for (const [field, value, max] of [
+      ["title", title, MAX_TITLE_LENGTH],
+      ["body", body, MAX_BODY_LENGTH],
+    ]) {
+      if (typeof value !== "string" || !value.trim() || value.length > max) {
+        throw new ValidationError(`${field} es requerido (máx. ${max} caracteres)`);
+      }
+    }
+    // Placeholders the notifications of the type do not carry would never render
+    const allowed = this.getAllowedPlaceholders(type);
+    const unknown = getPlaceholders(`${title} ${body}`).filter((name) => !allowed.includes(name));
+    if (unknown.length) {
+      throw new ValidationError(
+        `Marcadores desconocidos: ${unknown.join(", ")}. Disponibles: ${allowed.join(", ") || "ninguno"}`
+      );
+    }
+
+    const override = await pushTemplateOverrideRepository.upsert({
+      type,
+      language,
+      title: title.trim(),
+      body: body.trim(),
+      updatedById: adminId,
+    });
+    logger.info(`[Push] Template ${type}/${language} overridden by admin ${adminId}`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.PUSH_TEMPLATE_UPDATED,
+      actorId: adminId,
+      targetType: "push_template",
+      targetId: `${type}/${language}`,
+      metadata: { title: override.title, body: override.body },
+    });
+    return override;
+  }
+
+  /**
+   * Goes back to the built-in text of a template in a language
+   * @param {string} type
+   * @param {string} language
+   */
+  async deleteOverride(type, language) {
+    this.validateKey(type, language);
+    if (!(await pushTemplateOverrideRepository.delete(type, language))) {
+      throw new NotFoundError("La plantilla no tiene un texto personalizado en ese idioma");
+    }
+    logger.info(`[Push] Template ${type}/${language} override removed`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.PUSH_TEMPLATE_RESET,
+      targetType: "push_template",
+      targetId: `${type}/${language}`,
+    });
+  }
+
+  getAllowedPlaceholders(type) {
+    const texts = Object.values(PUSH_TEMPLATES[type] || {}).flatMap((text) => [
+      text.title,
+      text.body,
+    ]);
+    return getPlaceholders(texts.join(" "));
+  }
+
+  validateKey(type, language) {
+    if (!PUSH_TEMPLATES[type]) {
+      throw new NotFoundError("Plantilla de notificación no encontrada");
+    }
+    if (!PUSH_LANGUAGES.includes(language)) {
+      throw new ValidationError(`language debe ser uno de: ${PUSH_LANGUAGES.join(", ")}`);
+    }
+  }
+}
+
+export default new PushTemplateService();
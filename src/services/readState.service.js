@@ -0,0 +1,94 @@
+import notificationService from "./notification.service.js";
+import directMessageService from "./directMessage.service.js";
+import groupMessageService from "./groupMessage.service.js";
+import directMessageRepository from "../repository/directMessage.repository.js";
+import groupMessageReadRepository from "../repository/groupMessageRead.repository.js";
+import logger from "../config/logger.js";
+import { publish as publishRealtime } from "../socket/realtime.js";
+import { ValidationError } from "../utils/customErrors.js";
+
+const MAX_SYNC_ITEMS = 200;
+
+/**
+ * Read state of a user (notifications, direct messages and group chats) kept
+ * in sync across their devices. Every change is pushed as "read_state_updated"
+ * to the user's socket room; clients that were offline catch up with
+ * getReadState.
+ */
+class ReadStateService {
+  /**
+   * Full read state of a user
+   * @param {string} userId
+   * @returns {Promise<Object>} - { badges, directMessages, groups, serverTime }
+   */
+  async getReadState(userId) {
+    const [notificationsUnread, conversations, groups] = await Promise.all([
+      notificationService.getUnreadCount(userId),
+      directMessageRepository.countUnreadGroupedByConversation(userId),
+      groupMessageReadRepository.findReadStateByUser(userId),
+    ]);
+
+    return {
+      badges: this.buildBadges(notificationsUnread, conversations, groups),
+      directMessages: conversations,
+      groups,
+      serverTime: new Date().toISOString(),
+    };
+  }
+
+  /**
+   * Applies reads made on a device (e.g. queued while offline) and returns the
+   * resulting state. Each read is broadcast to the user's other devices.
+   * @param {string} userId
+   * @param {Object} reads - { notificationIds, conversations, groups: [{ groupId, lastReadMessageId }] }
+   * @returns {Promise<Object>} Read state after applying the reads
+   */
+  async applyReads(userId, { notificationIds = [], conversations = [], groups = [] } = {}) {
+    if (![notificationIds, conversations, groups].every(Array.isArray)) {
+      throw new ValidationError("notificationIds, conversations y groups deben ser listas");
+    }
+    if (notificationIds.length + conversations.length + groups.length > MAX_SYNC_ITEMS) {
+      throw new ValidationError(`No se pueden sincronizar más de ${MAX_SYNC_ITEMS} elementos a la vez`);
+    }
+
+    if (notificationIds.length > 0) {
+      await notificationService.markManyAsRead(userId, notificationIds);
+    }
+    for (const otherUserId of conversations) {
+      await directMessageService.markAsRead(userId, otherUserId);
+    }
+    for (const { groupId, lastReadMessageId = null } of groups) {
+      if (!groupId) {
+        throw new ValidationError("Cada grupo debe incluir groupId");
+      }
+      await groupMessageService.markAsRead(groupId, userId, lastReadMessageId);
+    }
+
+    return await this.getReadState(userId);
+  }
+
+  /**
+   * Pushes a read state change to every device of the user. Never throws:
+   * a failed broadcast only means other devices catch up on their next sync.
+   * @param {string} userId
+   * @param {Object} change - { scope: "notifications" | "direct_messages" | "group", ...details }
+   */
+  async publish(userId, change) {
+    try {
+      const { badges, serverTime } = await this.getReadState(userId);
+      publishRealtime(userId, "read_state_updated", { ...change, badges, serverTime });
+    } catch (error) {
+      logger.warn(`Could not publish read state of user ${userId}: ${error.message}`);
+    }
+  }
+
+  buildBadges(notificationsUnread, conversations, groups) {
+    return {
+      notifications: notificationsUnread,
+      directMessages: conversations.reduce((sum, conv) => sum + conv.unreadCount, 0),
+      groups: groups.reduce((sum, group) => sum + group.unreadCount, 0),
+    };
+  }
+}
+
+export default new ReadStateService();
@@ -0,0 +1,185 @@
+import { IsNull } from "typeorm";
+import recommendationRepository from "../repository/recommendation.repository.js";
+import UserRepository from "../repository/user.repository.js";
+import tripRecommendationService from "./tripRecommendation.service.js";
+import jobQueueService from "./jobQueue.service.js";
+import notificationPreferenceService from "./notificationPreference.service.js";
+import emailService from "./email.service.js";
+import { createAndEmitNotification } from "../socket/notification.emitter.js";
+import { AppDataSource } from "../load/typeorm.loader.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { NotFoundError } from "../utils/customErrors.js";
+import { RECOMMENDATION_EMAIL_JOB } from "../jobs/recommendationEmail.job.js";
+
+const RECOMMENDATION_TYPE = "MONTHLY_RECOMMENDATIONS";
+const DAY_MS = 24 * 60 * 60 * 1000;
+const UUID_REGEX = /^[0-9a-f]{8}-[0-9a-f]{4}-[1-5][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/i;
+
+const userRepository = new UserRepository();
+
+/**
+ * Monthly "trips for you" email. Trips come ranked by tripRecommendationService
+ * (past destinations, travel months and lengths, budget, followed users, and
+ * trips overlapping the user's own travel dates left out); destinations the
+ * user clicked in previous emails are boosted on top.
+ */
+class RecommendationService {
+  /**
+   * Trips to recommend to the user, best first
+   * @param {string} userId
+   * @param {number} limit
+   * @returns {Promise<Array>} - [{ groupId, name, destinationName, startDate, endDate, score, reasons }]
+   */
+  async getRecommendations(userId, limit = config.recommendations.itemsPerEmail) {
+    const [{ trips }, clickedDestinations] = await Promise.all([
+      tripRecommendationService.getRecommended(userId, { limit: config.tripRecommendations.maxItems }),
+      recommendationRepository.findClickedDestinations(userId),
+    ]);
+
+    return trips
+      .map((trip) => {
+        const clicks = clickedDestinations[trip.destinationName] || 0;
+        return {
+          groupId: trip.id,
+          name: trip.name,
+          destinationName: trip.destinationName,
+          startDate: trip.startDate,
+          endDate: trip.endDate,
+          score: Math.round((trip.score + 2 * Math.log1p(clicks)) * 100) / 100,
+          reasons: clicks > 0 ? [...trip.reasons, "clicked"] : trip.reasons,
+        };
+      })
+      .sort((a, b) => b.score - a.score)
+      .slice(0, limit);
+  }
+
+  /**
+   * Checks the per-user frequency cap
+   * @param {string} userId
+   * @param {Date} now
+   * @returns {Promise<boolean>} true if the user can receive a new email
+   */
+  async canSendTo(userId, now = new Date()) {
+    const lastSentAt = await recommendationRepository.findLastSentAt(userId);
+    if (!lastSentAt) {
+      return true;
+    }
+    const minGap = config.recommendations.minDaysBetweenEmails * DAY_MS;
+    return now.getTime() - new Date(lastSentAt).getTime() >= minGap;
+  }
+
+  /**
+   * Builds and sends the recommendation email of a user (job handler)
+   * @param {string} userId
+   * @returns {Promise<string>} "sent" | "skipped" | "failed"
+   */
+  async sendToUser(userId) {
+    const user = await userRepository.findById(userId);
+    if (!user || !user.isEmailConfirmed || user.suspendedAt) {
+      return "skipped";
+    }
+    const emailAllowed = await notificationPreferenceService.isChannelEnabled(
+      user.id,
+      RECOMMENDATION_TYPE,
+      "email"
+    );
+    if (!emailAllowed || !(await this.canSendTo(user.id))) {
+      return "skipped";
+    }
+
+    const items = await this.getRecommendations(user.id);
+    if (items.length === 0) {
+      return "skipped";
+    }
+
+    // The record is created first so its id can be embedded in the tracking links
+    const record = await recommendationRepository.createEmail({
+      userId: user.id,
+      items: items.map(({ groupId, score }) => ({ groupId, score })),
+    });
+
+    try {
+      await emailService.sendRecommendationEmail(
+        user.email,
+        items.map((item) => ({
+          name: item.name,
+          destinationName: item.destinationName,
+          startDate: item.startDate,
+          url: `${config.baseUrl}/api/recommendations/click/${record.id}/${item.groupId}`,
+        })),
+        user.locale
+      );
+      await recommendationRepository.updateEmail(record.id, {
+        status: "sent",
+        sentAt: new Date(),
+      });
+    } catch (error) {
+      logger.error(`Failed to send recommendation email to user ${user.id}:`, error.message);
+      await recommendationRepository.updateEmail(record.id, { status: "failed" });
+      return "failed";
+    }
+
+    // Mirror the email in the in-app feed; a feed failure must not count as a failed send
+    try {
+      await createAndEmitNotification({
+        userId: user.id,
+        type: RECOMMENDATION_TYPE,
+        title: "Tus recomendaciones del mes",
+        message: `Tenemos ${items.length} viajes que te pueden gustar`,
+        data: {
+          recommendationEmailId: record.id,
+          groupIds: items.map((item) => item.groupId),
+        },
+      });
+    } catch (error) {
+      logger.error(`Failed to create recommendation notification for user ${user.id}:`, error.message);
+    }
+
+    return "sent";
+  }
+
+  /**
+   * Queues the monthly recommendation email of every confirmed, active user;
+   * each one is built and sent by its own job
+   * @returns {Promise<Object>} - { queued }
+   */
+  async sendMonthlyRecommendations() {
+    const users = await AppDataSource.getRepository("User").find({
+      select: ["id"],
+      where: { isEmailConfirmed: true, suspendedAt: IsNull(), deletedAt: IsNull() },
+    });
+
+    for (const user of users) {
+      await jobQueueService.enqueue(RECOMMENDATION_EMAIL_JOB, { userId: user.id });
+    }
+    return { queued: users.length };
+  }
+
+  /**
+   * Records a click on a recommendation email link
+   * @param {string} emailId
+   * @param {string} groupId
+   * @returns {Promise<string>} Frontend URL of the trip
+   */
+  async trackClick(emailId, groupId) {
+    if (!UUID_REGEX.test(emailId) || !UUID_REGEX.test(groupId)) {
+      throw new NotFoundError("Recomendación no encontrada");
+    }
+
+    const email = await recommendationRepository.findEmailById(emailId);
+    if (!email || !email.items.some((item) => item.groupId === groupId)) {
+      throw new NotFoundError("Recomendación no encontrada");
+    }
+
+    await recommendationRepository.createClick({
+      emailId,
+      userId: email.userId,
+      groupId,
+    });
+
+    return `${config.frontendUrl}/groups/${groupId}`;
+  }
+}
+
+export default new RecommendationService();
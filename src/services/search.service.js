@@ -0,0 +1,199 @@
+import { AppDataSource } from "../load/typeorm.loader.js";
+import searchDocumentRepository from "../repository/searchDocument.repository.js";
+import elasticsearchClient from "../clients/elasticsearch.client.js";
+import jobQueueService from "./jobQueue.service.js";
+import userBlockRepository from "../repository/userBlock.repository.js";
+import { SEARCH_INDEX_JOB } from "../jobs/searchIndex.job.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { ValidationError } from "../utils/customErrors.js";
+
+/**
+ * Searchable entity types and the table each one is built from
+ */
+export const SEARCH_TYPES = {
+  trip: "Group",
+  destination: "Place",
+  user: "User",
+};
+
+const REINDEX_BATCH_SIZE = 500;
+
+/**
+ * Full-text search over trips, destinations and users. Documents are kept in a
+ * search engine (Postgres tsvector by default, Elasticsearch optionally) and
+ * refreshed by background jobs whenever an entity is written.
+ */
+class SearchService {
+  get engine() {
+    return config.search.engine === "elasticsearch" ? elasticsearchClient : searchDocumentRepository;
+  }
+
+  /**
+   * Prepares the engine indexes; search keeps working without them, only slower
+   */
+  async init() {
+    try {
+      await this.engine.ensureIndexes();
+    } catch (error) {
+      logger.error(`[Search] Failed to prepare ${config.search.engine} indexes: ${error.message}`);
+    }
+  }
+
+  /**
+   * Queues the refresh of an entity's search document. Never throws so a
+   * search outage does not fail the write that triggered it.
+   * @param {string} entityType - "trip" | "destination" | "user"
+   * @param {string} entityId
+   */
+  async scheduleIndex(entityType, entityId) {
+    try {
+      await jobQueueService.enqueue(SEARCH_INDEX_JOB, { entityType, entityId }, { maxAttempts: 5 });
+    } catch (error) {
+      logger.error(`[Search] Failed to queue index of ${entityType} ${entityId}: ${error.message}`);
+    }
+  }
+
+  /**
+   * Builds the search document of an entity
+   * @returns {Promise<Object|null>} Document, or null when the entity no longer exists
+   */
+  async buildDocument(entityType, entityId) {
+    const entity = await AppDataSource.getRepository(SEARCH_TYPES[entityType]).findOne({
+      where: { id: entityId },
+    });
+    if (!entity) {
+      return null;
+    }
+
+    switch (entityType) {
+      case "trip":
+        return {
+          entityType,
+          entityId,
+          title: entity.name,
+          subtitle: entity.destinationName,
+          body: entity.description,
+          isPublic: entity.isPublic,
+        };
+      case "destination":
+        return {
+          entityType,
+          entityId,
+          title: entity.name,
+          subtitle: entity.city,
+          body: [entity.address, entity.description].filter(Boolean).join("\n"),
+          isPublic: true,
+        };
+      case "user":
+        return {
+          entityType,
+          entityId,
+          title: entity.name || entity.email.split("@")[0],
+          subtitle: null,
+          body: null,
+          isPublic: entity.isEmailConfirmed && !entity.suspendedAt && !entity.deletedAt,
+        };
+      default:
+        return null;
+    }
+  }
+
+  /**
+   * Writes (or removes) the search document of an entity
+   */
+  async indexEntity(entityType, entityId) {
+    if (!SEARCH_TYPES[entityType]) {
+      throw new Error(`Unknown search entity type: ${entityType}`);
+    }
+
+    const document = await this.buildDocument(entityType, entityId);
+    if (document) {
+      await this.engine.upsert(document);
+    } else {
+      await this.engine.remove(entityType, entityId);
+    }
+  }
+
+  /**
+   * Rebuilds every search document (backfill after enabling search or switching engines)
+   * @returns {Promise<Object>} Indexed documents per type
+   */
+  async reindexAll() {
+    const result = {};
+    for (const [entityType, entityName] of Object.entries(SEARCH_TYPES)) {
+      const repository = AppDataSource.getRepository(entityName);
+      let indexed = 0;
+
+      for (let skip = 0; ; skip += REINDEX_BATCH_SIZE) {
+        const rows = await repository.find({
+          select: ["id"],
+          order: { id: "ASC" },
+          skip,
+          take: REINDEX_BATCH_SIZE,
+        });
+        for (const row of rows) {
+          await this.indexEntity(entityType, row.id);
+          indexed++;
+        }
+        if (rows.length < REINDEX_BATCH_SIZE) {
+          break;
+        }
+      }
+      result[entityType] = indexed;
+    }
+
+    logger.info("[Search] Reindex completed", result);
+    return result;
+  }
+
+  /**
+   * Searches trips, destinations and users ranked by relevance
+   * @param {Object} params - { q, type, limit, offset, viewerId }
+   * @returns {Promise<Object>} - { success, data: { results, hasMore } }
+   */
+  async search({ q, type, limit = 20, offset = 0, viewerId = null }) {
+    const query = (q || "").trim();
+    if (query.length < 2 || query.length > 100) {
+      throw new ValidationError("La búsqueda debe tener entre 2 y 100 caracteres");
+    }
+
+    const types = type ? String(type).split(",") : Object.keys(SEARCH_TYPES);
+    const invalid = types.filter((value) => !SEARCH_TYPES[value]);
+    if (invalid.length > 0) {
+      throw new ValidationError(
+        `Tipo de búsqueda inválido. Valores permitidos: ${Object.keys(SEARCH_TYPES).join(", ")}`
+      );
+    }
+
+    const pageSize = Math.min(Math.max(limit, 1), 50);
+    // One extra row tells whether there is another page. Users blocked with
+    // the viewer and their trips are left out by the engine, before paginating
+    const rows = await this.engine.search(query, {
+      types,
+      limit: pageSize + 1,
+      offset: Math.max(offset, 0),
+      viewerId,
+      excludeIds:
+        viewerId && config.search.engine === "elasticsearch"
+          ? await userBlockRepository.findHiddenSearchIds(viewerId)
+          : [],
+    });
+
+    return {
+      success: true,
+      data: {
+        results: rows.slice(0, pageSize).map((row) => ({
+          type: row.entityType,
+          id: row.entityId,
+          title: row.title,
+          subtitle: row.subtitle,
+          score: row.score,
+        })),
+        hasMore: rows.length > pageSize,
+      },
+    };
+  }
+}
+
+export default new SearchService();
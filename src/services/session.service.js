@@ -0,0 +1,207 @@
+import jwt from "jsonwebtoken";
+import userSessionRepository from "../repository/userSession.repository.js";
+import auditService, { AUDIT_ACTIONS } from "./audit.service.js";
+import logger from "../config/logger.js";
+import { getIoInstance } from "../socket/socket.instance.js";
+import { NotFoundError } from "../utils/customErrors.js";
+
+const UUID_REGEX = /^[0-9a-f]{8}-[0-9a-f]{4}-[1-8][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/i;
+// lastSeenAt is written at most this often per session
+const LAST_SEEN_INTERVAL_MS = 5 * 60 * 1000;
+// Ended sessions are kept this long before being deleted
+const ENDED_RETENTION_DAYS = 30;
+
+/**
+ * Signed-in devices of a user. Every refresh token belongs to a session and
+ * can be used once: refreshing swaps it for a new one, and presenting an old
+ * one again revokes the session, since only a copied token would do that.
+ */
+class SessionService {
+  /**
+   * Records the device a refresh token was issued to
+   * @param {Object} data - { sessionId, userId, refreshToken, client: { deviceName, userAgent, ipAddress } }
+   */
+  async open({ sessionId, userId, refreshToken, client = {} }) {
+    const { jti, exp } = jwt.decode(refreshToken);
+    return await userSessionRepository.create({
+      id: sessionId,
+      userId,
+      deviceName: this.clean(client.deviceName, 100),
+      userAgent: this.clean(client.userAgent, 255),
+      ipAddress: this.clean(client.ipAddress, 45),
+      refreshJti: jti,
+      lastSeenAt: new Date(),
+      expiresAt: new Date(exp * 1000),
+    });
+  }
+
+  /**
+   * Moves a session to its new refresh token
+   * @param {Object} presented - Decoded refresh token being used ({ sid, jti })
+   * @param {string} refreshToken - The token replacing it
+   * @param {Object} client - { ipAddress }
+   * @returns {Promise<boolean>} False if the session was revoked, expired or the token already used
+   */
+  async rotate(presented, refreshToken, client = {}) {
+    const { jti, exp } = jwt.decode(refreshToken);
+    const session = await userSessionRepository.rotate(presented.sid, presented.jti, {
+      refreshJti: jti,
+      expiresAt: new Date(exp * 1000),
+      ipAddress: this.clean(client.ipAddress, 45),
+    });
+    if (session) {
+      return true;
+    }
+
+    const current = await userSessionRepository.findById(presented.sid);
+    if (current && !current.revokedAt && current.refreshJti !== presented.jti) {
+      await userSessionRepository.revoke(current.id, current.userId);
+      logger.warn(`Refresh token of session ${current.id} reused; session revoked`);
+    }
+    return false;
+  }
+
+  /**
+   * Whether the session of an access token is still active; refreshes its
+   * last activity while at it
+   * @param {string} sessionId
+   * @returns {Promise<boolean>}
+   */
+  async touchIfActive(sessionId) {
+    const session = await userSessionRepository.findById(sessionId);
+    if (!session || session.revokedAt || session.expiresAt <= new Date()) {
+      return false;
+    }
+    if (Date.now() - session.lastSeenAt.getTime() > LAST_SEEN_INTERVAL_MS) {
+      userSessionRepository.touch(sessionId).catch(() => {});
+    }
+    return true;
+  }
+
+  /**
+   * Active sessions of a user, the current one flagged
+   * @param {string} userId
+   * @param {string|null} currentSessionId - Session of the request
+   */
+  async listSessions(userId, currentSessionId = null) {
+    const sessions = await userSessionRepository.findActiveByUser(userId);
+    return sessions.map((session) => ({
+      id: session.id,
+      deviceName: session.deviceName,
+      userAgent: session.userAgent,
+      ipAddress: session.ipAddress,
+      createdAt: session.createdAt,
+      lastSeenAt: session.lastSeenAt,
+      expiresAt: session.expiresAt,
+      current: session.id === currentSessionId,
+    }));
+  }
+
+  /**
+   * Logs a device out
+   * @param {string} userId
+   * @param {string} sessionId
+   */
+  async revokeSession(userId, sessionId) {
+    if (!(await this.end(userId, sessionId))) {
+      throw new NotFoundError("Sesión no encontrada");
+    }
+    logger.info(`Session ${sessionId} of user ${userId} revoked`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.SESSION_REVOKED,
+      targetType: "user_session",
+      targetId: sessionId,
+    });
+  }
+
+  /**
+   * Logs the user out of their other devices, or of every one
+   * @param {string} userId
+   * @param {string|null} currentSessionId - Session of the request
+   * @param {Object} options - { includeCurrent: log this device out too }
+   * @returns {Promise<number>} Sessions revoked
+   */
+  async revokeDevices(userId, currentSessionId, { includeCurrent = false } = {}) {
+    const revoked = await this.revokeAll(userId, includeCurrent ? null : currentSessionId);
+    await auditService.record({
+      action: AUDIT_ACTIONS.SESSION_REVOKED,
+      targetType: "user",
+      targetId: userId,
+      metadata: { all: true, includeCurrent, revoked },
+    });
+    return revoked;
+  }
+
+  /**
+   * Ends a session on logout
+   * @returns {Promise<boolean>} False if there was no active session with that ID
+   */
+  async end(userId, sessionId) {
+    const revoked = UUID_REGEX.test(sessionId) && (await userSessionRepository.revoke(sessionId, userId));
+    if (revoked) {
+      this.disconnectSockets([sessionId]);
+    }
+    return revoked;
+  }
+
+  /**
+   * Logs every device of a user out, except the one given
+   * @param {string} userId
+   * @param {string|null} exceptSessionId
+   * @returns {Promise<number>} Sessions revoked
+   */
+  async revokeAll(userId, exceptSessionId = null) {
+    const sessions = await userSessionRepository.findActiveByUser(userId);
+    const revoked = await userSessionRepository.revokeAllByUser(userId, exceptSessionId);
+    if (revoked > 0) {
+      this.disconnectSockets(sessions.map((session) => session.id).filter((id) => id !== exceptSessionId));
+      logger.info(`${revoked} sessions of user ${userId} revoked`);
+    }
+    return revoked;
+  }
+
+  /**
+   * Deletes sessions that ended (expired or revoked) over a month ago
+   * @returns {Promise<number>}
+   */
+  async purgeEnded() {
+    const cutoff = new Date(Date.now() - ENDED_RETENTION_DAYS * 24 * 60 * 60 * 1000);
+    return await userSessionRepository.deleteEndedBefore(cutoff);
+  }
+
+  socketRoom(sessionId) {
+    return `session_${sessionId}`;
+  }
+
+  // Only the API process has the socket server; elsewhere the sockets go at their next reconnect
+  disconnectSockets(sessionIds) {
+    let io;
+    try {
+      io = getIoInstance();
+    } catch {
+      return;
+    }
+    for (const sessionId of sessionIds) {
+      io.in(this.socketRoom(sessionId)).disconnectSockets(true);
+    }
+  }
+
+  // Every device of a user, e.g. when the account is suspended or closed
+  disconnectUser(userId) {
+    let io;
+    try {
+      io = getIoInstance();
+    } catch {
+      return;
+    }
+    io.in(userId).disconnectSockets(true);
+  }
+
+  clean(value, maxLength) {
+    if (typeof value !== "string") return null;
+    const trimmed = value.trim();
+    return trimmed ? trimmed.slice(0, maxLength) : null;
+  }
+}
+
+export default new SessionService();
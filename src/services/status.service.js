@@ -0,0 +1,247 @@
+import { AppDataSource } from "../load/typeorm.loader.js";
+import redisClient from "../clients/redis.client.js";
+import { getHostStates } from "../clients/http.client.js";
+import jobRepository from "../repository/job.repository.js";
+import paymentRepository from "../repository/payment.repository.js";
+import cronRunRepository from "../repository/cronRun.repository.js";
+import outboxEventRepository from "../repository/outboxEvent.repository.js";
+import jobQueueService from "./jobQueue.service.js";
+import analyticsEventService from "./analyticsEvent.service.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+
+// A check that takes longer is reported as down instead of blocking the page
+const CHECK_TIMEOUT_MS = 3000;
+// Due jobs waiting longer than this mean the workers are behind or stopped
+const QUEUE_LAG_WARNING_SECONDS = 10 * 60;
+// Payments Stripe has not confirmed within this window are reported as backlog
+const WEBHOOK_BACKLOG_MINUTES = 30;
+// Domain events waiting longer than this mean the broker is unreachable
+const OUTBOX_LAG_WARNING_SECONDS = 5 * 60;
+
+const secondsSince = (date) =>
+  date ? Math.max(0, Math.round((Date.now() - new Date(date).getTime()) / 1000)) : null;
+
+const withTimeout = (promise, ms) =>
+  new Promise((resolve, reject) => {
+    const timer = setTimeout(() => reject(new Error(`timed out after ${ms}ms`)), ms);
+    promise.then(
+      (value) => {
+        clearTimeout(timer);
+        resolve(value);
+      },
+      (error) => {
+        clearTimeout(timer);
+        reject(error);
+      }
+    );
+  });
+
+/**
+ * Status of the dependencies the API relies on, for the admin runbook page.
+ * Every check reports { status: "ok" | "degraded" | "down" | "disabled", ... }
+ * and never throws, so one broken dependency does not hide the others.
+ */
+class StatusService {
+  constructor() {
+    // name -> () => { state, ... } of circuit breakers wrapping external clients
+    this.breakers = new Map();
+  }
+
+  /**
+   * Adds a circuit breaker to the status page
+   * @param {string} name - e.g. "stripe"
+   * @param {Function} getState - Returns { state: "closed" | "open" | "half_open", ... }
+   */
+  registerBreaker(name, getState) {
+    this.breakers.set(name, getState);
+  }
+
+  /**
+   * Runs every check concurrently
+   * @returns {Promise<Object>} - { status, checkedAt, checks: { database, redis, jobQueue, breakers, webhooks, scheduler, events, analyticsEvents } }
+   */
+  async getStatus() {
+    const entries = Object.entries({
+      database: () => this.checkDatabase(),
+      redis: () => this.checkRedis(),
+      jobQueue: () => this.checkJobQueue(),
+      breakers: () => this.checkBreakers(),
+      webhooks: () => this.checkWebhooks(),
+      scheduler: () => this.checkScheduler(),
+      events: () => this.checkEventOutbox(),
+      analyticsEvents: () => this.checkAnalyticsEvents(),
+    });
+
+    const results = await Promise.all(
+      entries.map(async ([name, check]) => {
+        const startedAt = Date.now();
+        try {
+          const result = await withTimeout(check(), CHECK_TIMEOUT_MS);
+          return [name, { ...result, latencyMs: Date.now() - startedAt }];
+        } catch (error) {
+          logger.warn(`Status check ${name} failed: ${error.message}`);
+          return [name, { status: "down", error: error.message, latencyMs: Date.now() - startedAt }];
+        }
+      })
+    );
+    const checks = Object.fromEntries(results);
+
+    return {
+      status: this.overallStatus(checks),
+      checkedAt: new Date().toISOString(),
+      checks,
+    };
+  }
+
+  /**
+   * The API cannot serve anything without the database; any other problem
+   * only degrades it
+   */
+  overallStatus(checks) {
+    if (checks.database.status === "down") {
+      return "down";
+    }
+    const degraded = Object.values(checks).some((check) =>
+      ["down", "degraded"].includes(check.status)
+    );
+    return degraded ? "degraded" : "ok";
+  }
+
+  async checkDatabase() {
+    await AppDataSource.query("SELECT 1");
+    const pool = AppDataSource.driver.master;
+
+    return {
+      status: pool && pool.waitingCount > 0 ? "degraded" : "ok",
+      pool: pool
+        ? {
+            total: pool.totalCount,
+            idle: pool.idleCount,
+            waiting: pool.waitingCount,
+            max: pool.options?.max ?? null,
+          }
+        : null,
+    };
+  }
+
+  async checkRedis() {
+    if (!redisClient.isConfigured()) {
+      return { status: "disabled" };
+    }
+    const reply = await redisClient.command("PING");
+    return { status: reply === "PONG" ? "ok" : "degraded", reply };
+  }
+
+  async checkJobQueue() {
+    const [rows, pauses] = await Promise.all([
+      jobRepository.countByTypeAndStatus(),
+      jobRepository.findPauses(),
+    ]);
+    const totals = { pending: 0, running: 0, failed: 0, quarantined: 0 };
+    const byType = {};
+    const pausedTypes = pauses.map((pause) => pause.type);
+    let oldestDueAt = null;
+
+    for (const row of rows) {
+      totals[row.status] += row.count;
+      byType[row.type] = byType[row.type] || { pending: 0, running: 0, failed: 0, quarantined: 0 };
+      byType[row.type][row.status] = row.count;
+      // Jobs of a paused queue are expected to wait
+      if (pausedTypes.includes(row.type)) {
+        continue;
+      }
+      if (row.oldestDueAt && (!oldestDueAt || new Date(row.oldestDueAt) < new Date(oldestDueAt))) {
+        oldestDueAt = row.oldestDueAt;
+      }
+    }
+    const oldestDueSeconds = secondsSince(oldestDueAt);
+
+    return {
+      status: oldestDueSeconds !== null && oldestDueSeconds > QUEUE_LAG_WARNING_SECONDS ? "degraded" : "ok",
+      // Whether this process polls the queue (false on API instances when a worker runs separately)
+      localWorker: !jobQueueService.stopped,
+      ...totals,
+      oldestDueSeconds,
+      pausedTypes,
+      byType,
+    };
+  }
+
+  async checkBreakers() {
+    const breakers = [
+      ...[...this.breakers].map(([name, getState]) => ({ name, ...getState() })),
+      // Hosts called through the shared HTTP client, with their call stats
+      ...getHostStates(),
+    ];
+    return {
+      status: breakers.some((breaker) => breaker.state === "open") ? "degraded" : "ok",
+      breakers,
+    };
+  }
+
+  async checkWebhooks() {
+    const rows = await paymentRepository.countAwaitingWebhook(
+      new Date(Date.now() - WEBHOOK_BACKLOG_MINUTES * 60 * 1000)
+    );
+    const pending = Object.fromEntries(rows.map((row) => [row.status, row.count]));
+    const oldest = rows
+      .map((row) => row.oldestUpdatedAt)
+      .sort((a, b) => new Date(a) - new Date(b))[0];
+    const backlog = rows.reduce((sum, row) => sum + row.count, 0);
+
+    return {
+      status: backlog > 0 ? "degraded" : "ok",
+      backlog,
+      olderThanMinutes: WEBHOOK_BACKLOG_MINUTES,
+      processing: pending.processing || 0,
+      refundPending: pending.refund_pending || 0,
+      oldestWaitingSeconds: secondsSince(oldest),
+    };
+  }
+
+  async checkScheduler() {
+    const tasks = (await cronRunRepository.findLatestByTask()).map((run) => ({
+      task: run.task,
+      lastStatus: run.status,
+      lastRunAt: run.lastRunAt,
+      lastStartedAt: run.startedAt,
+      lastFinishedAt: run.finishedAt,
+      lastSucceededAt: run.lastSucceededAt,
+      lastError: run.error,
+    }));
+
+    return {
+      status: tasks.some((task) => ["failed", "missed"].includes(task.lastStatus)) ? "degraded" : "ok",
+      tasks,
+    };
+  }
+
+  async checkEventOutbox() {
+    if (config.events.broker === "none") {
+      return { status: "disabled" };
+    }
+    const { pending, failed, oldestAt } = await outboxEventRepository.getBacklog();
+    const oldestPendingSeconds = secondsSince(oldestAt);
+    const lagging = oldestPendingSeconds !== null && oldestPendingSeconds > OUTBOX_LAG_WARNING_SECONDS;
+
+    return {
+      status: lagging || failed > 0 ? "degraded" : "ok",
+      broker: config.events.broker,
+      pending,
+      failed,
+      oldestPendingSeconds,
+    };
+  }
+
+  // Buffered counts are of this instance (plus the shared list with the Redis buffer)
+  async checkAnalyticsEvents() {
+    if (config.analyticsEvents.sink === "none") {
+      return { status: "disabled" };
+    }
+    const stats = await analyticsEventService.getStats();
+    return { status: stats.lastError ? "degraded" : "ok", ...stats };
+  }
+}
+
+export default new StatusService();
@@ -0,0 +1,368 @@
+import crypto from "crypto";
+import { promises as dns } from "dns";
+import tenantRepository from "../repository/tenant.repository.js";
+import UserRepository from "../repository/user.repository.js";
+import auditService, { AUDIT_ACTIONS } from "./audit.service.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { ValidationError, NotFoundError } from "../utils/customErrors.js";
+
+const userRepository = new UserRepository();
+
+const TENANT_ID_REGEX = /^[a-z0-9](?:[a-z0-9-]{0,38}[a-z0-9])?$/;
+export const DOMAIN_REGEX = /^(?=.{1,253}$)(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$/;
+const LOCAL_PART_REGEX = /^[a-z0-9](?:[a-z0-9._-]{0,62}[a-z0-9])?$/i;
+const EMAIL_REGEX = /^[^\s@]+@[^\s@]+\.[^\s@]+$/;
+const COLOR_REGEX = /^#[0-9a-f]{6}$/i;
+const DKIM_KEY_BITS = 2048;
+
+/**
+ * JoinTravel's own look, used for users without a tenant and for whatever a
+ * tenant leaves unset
+ */
+export const DEFAULT_BRANDING = {
+  name: "JoinTravel",
+  logoUrl: null,
+  primaryColor: "#007bff",
+  textColor: "#333333",
+  footerText: "JoinTravel - Tu compañero de viajes",
+};
+
+const BRANDING_FIELDS = {
+  logoUrl: (value) => {
+    try {
+      return new URL(value).protocol === "https:";
+    } catch {
+      return false;
+    }
+  },
+  primaryColor: (value) => COLOR_REGEX.test(value),
+  textColor: (value) => COLOR_REGEX.test(value),
+  footerText: (value) => value.length <= 200,
+};
+
+/**
+ * White-label tenants: their branding, sender domain with DKIM and reply-to
+ * address. The email service asks here how to dress each email.
+ */
+class TenantService {
+  /**
+   * @param {string} tenantId
+   * @returns {Promise<Object|null>} Active tenant, or null
+   */
+  async findActive(tenantId) {
+    if (typeof tenantId !== "string" || !TENANT_ID_REGEX.test(tenantId)) {
+      return null;
+    }
+    const tenant = await tenantRepository.findById(tenantId);
+    return tenant?.active ? tenant : null;
+  }
+
+  /**
+   * Theme of the apps of a tenant (the default one for unknown tenants)
+   * @param {string|null} tenantId
+   * @returns {Promise<Object>} - { name, logoUrl, primaryColor, textColor, footerText }
+   */
+  async getBranding(tenantId) {
+    const tenant = await this.findActive(tenantId);
+    return tenant ? { ...DEFAULT_BRANDING, ...tenant.branding, name: tenant.name } : DEFAULT_BRANDING;
+  }
+
+  /**
+   * How emails to a recipient look and who they come from. Falls back to the
+   * default brand when the recipient has no active tenant or the lookup fails.
+   * The tenant's domain is only used as sender once its records are verified.
+   * @param {string} email - Recipient
+   * @returns {Promise<Object>} - { name, logoUrl, primaryColor, textColor, footerText,
+   *                                from, replyTo, dkim }
+   */
+  async getEmailBranding(email) {
+    const fallback = { ...DEFAULT_BRANDING, from: config.email.from, replyTo: null, dkim: null };
+    try {
+      const user = await userRepository.findByEmail(email);
+      if (!user?.tenantId) {
+        return fallback;
+      }
+      const tenant = await tenantRepository.findWithSigningKey(user.tenantId);
+      if (!tenant?.active) {
+        return fallback;
+      }
+
+      const branding = { ...DEFAULT_BRANDING, ...tenant.branding, name: tenant.name };
+      const verified = Boolean(tenant.senderVerifiedAt && tenant.dkimPrivateKey);
+      return {
+        ...branding,
+        from: verified
+          ? `${tenant.name} <${tenant.senderLocalPart}@${tenant.senderDomain}>`
+          : `${tenant.name} <${this.addressOf(config.email.from)}>`,
+        replyTo: tenant.replyTo,
+        dkim: verified
+          ? {
+              domainName: tenant.senderDomain,
+              keySelector: tenant.dkimSelector,
+              privateKey: tenant.dkimPrivateKey,
+            }
+          : null,
+      };
+    } catch (error) {
+      logger.error(`Email branding lookup failed, using the default: ${error.message}`);
+      return fallback;
+    }
+  }
+
+  async listTenants() {
+    const tenants = await tenantRepository.findAll();
+    return tenants.map((tenant) => this.formatTenant(tenant));
+  }
+
+  async getTenant(tenantId) {
+    return this.formatTenant(await this.getTenantOrFail(tenantId));
+  }
+
+  /**
+   * @param {Object} data - { id, name, branding, senderDomain, senderLocalPart, replyTo }
+   * @returns {Promise<Object>} Tenant with the DNS records to publish
+   */
+  async createTenant(data = {}) {
+    const id = typeof data.id === "string" ? data.id.trim().toLowerCase() : "";
+    if (!TENANT_ID_REGEX.test(id)) {
+      throw new ValidationError("id debe ser un slug en minúsculas (hasta 40 caracteres)");
+    }
+    if (await tenantRepository.findById(id)) {
+      throw new ValidationError("Ya existe un tenant con ese id");
+    }
+
+    const changes = this.validateChanges({ senderLocalPart: "no-reply", ...data }, { creating: true });
+    if (changes.senderDomain) {
+      Object.assign(changes, this.generateDkimKeys());
+    }
+    const tenant = await tenantRepository.create({ id, ...changes });
+    logger.info(`Tenant ${id} created`);
+    await auditService.record({ action: AUDIT_ACTIONS.TENANT_CREATED, targetType: "tenant", targetId: id });
+    return this.formatTenant(tenant);
+  }
+
+  /**
+   * Changing the sender domain issues a new DKIM key and the domain has to be
+   * verified again before emails go out from it
+   * @param {string} tenantId
+   * @param {Object} data - { name, branding, senderDomain, senderLocalPart, replyTo, active }
+   */
+  async updateTenant(tenantId, data = {}) {
+    const tenant = await this.getTenantOrFail(tenantId);
+    const changes = this.validateChanges(data);
+    if (changes.branding) {
+      changes.branding = { ...tenant.branding, ...changes.branding };
+    }
+    if (changes.senderDomain !== undefined && changes.senderDomain !== tenant.senderDomain) {
+      Object.assign(
+        changes,
+        changes.senderDomain
+          ? this.generateDkimKeys()
+          : { dkimSelector: null, dkimPublicKey: null, dkimPrivateKey: null },
+        { senderVerifiedAt: null }
+      );
+    }
+    if (Object.keys(changes).length === 0) {
+      throw new ValidationError("No hay cambios para guardar", null, "NO_CHANGES");
+    }
+    const updated = await tenantRepository.update(tenantId, changes);
+    await auditService.record({
+      action: AUDIT_ACTIONS.TENANT_UPDATED,
+      targetType: "tenant",
+      targetId: tenantId,
+      metadata: { fields: Object.keys(data) },
+    });
+    return this.formatTenant(updated);
+  }
+
+  /**
+   * Issues a new DKIM key under a new selector. Emails keep going out from
+   * the default sender until the new record is published and verified.
+   * @param {string} tenantId
+   */
+  async rotateDkimKey(tenantId) {
+    const tenant = await this.getTenantOrFail(tenantId);
+    if (!tenant.senderDomain) {
+      throw new ValidationError("El tenant no tiene dominio de envío");
+    }
+    const updated = await tenantRepository.update(tenantId, {
+      ...this.generateDkimKeys(),
+      senderVerifiedAt: null,
+    });
+    logger.info(`DKIM key of tenant ${tenantId} rotated`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.TENANT_DKIM_ROTATED,
+      targetType: "tenant",
+      targetId: tenantId,
+      metadata: { dkimSelector: updated.dkimSelector },
+    });
+    return this.formatTenant(updated);
+  }
+
+  /**
+   * DNS records the tenant has to publish on its sender domain, each with
+   * whether it is in place now. Marks the sender as verified (or not) from
+   * the DKIM and SPF records.
+   * @param {string} tenantId
+   * @returns {Promise<Object>} - { senderDomain, verified, verifiedAt, records: [{ type, name, value, purpose, found }] }
+   */
+  async checkDnsRecords(tenantId) {
+    const tenant = await this.getTenantOrFail(tenantId);
+    if (!tenant.senderDomain) {
+      throw new ValidationError("El tenant no tiene dominio de envío");
+    }
+
+    const records = this.buildDnsRecords(tenant);
+    for (const record of records) {
+      record.found = await this.hasTxtRecord(record.name, record.match);
+      delete record.match;
+    }
+    // DMARC is recommended but not required to send
+    const verified = records.filter((record) => record.purpose !== "dmarc").every((record) => record.found);
+    const senderVerifiedAt = verified ? tenant.senderVerifiedAt || new Date() : null;
+    if (String(senderVerifiedAt) !== String(tenant.senderVerifiedAt)) {
+      await tenantRepository.update(tenantId, { senderVerifiedAt });
+      logger.info(`Sender domain of tenant ${tenantId} ${verified ? "verified" : "no longer verified"}`);
+    }
+    return { senderDomain: tenant.senderDomain, verified, verifiedAt: senderVerifiedAt, records };
+  }
+
+  buildDnsRecords(tenant) {
+    const publicKey = tenant.dkimPublicKey
+      .replace(/-----(BEGIN|END) PUBLIC KEY-----/g, "")
+      .replace(/\s+/g, "");
+    return [
+      {
+        type: "TXT",
+        name: `${tenant.dkimSelector}._domainkey.${tenant.senderDomain}`,
+        value: `v=DKIM1; k=rsa; p=${publicKey}`,
+        purpose: "dkim",
+        match: (value) => value.replace(/\s+/g, "").includes(`p=${publicKey}`),
+      },
+      {
+        type: "TXT",
+        name: tenant.senderDomain,
+        value: config.email.spfInclude ? `v=spf1 include:${config.email.spfInclude} ~all` : "v=spf1 mx ~all",
+        purpose: "spf",
+        match: (value) =>
+          value.startsWith("v=spf1") &&
+          (!config.email.spfInclude || value.includes(`include:${config.email.spfInclude}`)),
+      },
+      {
+        type: "TXT",
+        name: `_dmarc.${tenant.senderDomain}`,
+        value: "v=DMARC1; p=quarantine; adkim=s; aspf=r",
+        purpose: "dmarc",
+        match: (value) => value.startsWith("v=DMARC1"),
+      },
+    ];
+  }
+
+  async hasTxtRecord(name, match) {
+    try {
+      const entries = await dns.resolveTxt(name);
+      return entries.some((chunks) => match(chunks.join("")));
+    } catch (error) {
+      if (!["ENOTFOUND", "ENODATA"].includes(error.code)) {
+        logger.warn(`DNS lookup of ${name} failed: ${error.message}`);
+      }
+      return false;
+    }
+  }
+
+  generateDkimKeys() {
+    const { publicKey, privateKey } = crypto.generateKeyPairSync("rsa", {
+      modulusLength: DKIM_KEY_BITS,
+      publicKeyEncoding: { type: "spki", format: "pem" },
+      privateKeyEncoding: { type: "pkcs8", format: "pem" },
+    });
+    // A new selector lets the old record stay published while the new one propagates
+    const dkimSelector = `jt${new Date().toISOString().slice(0, 10).replace(/-/g, "")}${crypto
+      .randomBytes(2)
+      .toString("hex")}`;
+    return { dkimSelector, dkimPublicKey: publicKey, dkimPrivateKey: privateKey };
+  }
+
+  validateChanges(data, { creating = false } = {}) {
+    const changes = {};
+    if (data.name !== undefined || creating) {
+      const name = typeof data.name === "string" ? data.name.trim() : "";
+      if (!name || name.length > 120) {
+        throw new ValidationError("El nombre es requerido (hasta 120 caracteres)");
+      }
+      changes.name = name;
+    }
+    if (data.branding !== undefined) {
+      if (typeof data.branding !== "object" || data.branding === null || Array.isArray(data.branding)) {
+        throw new ValidationError("branding debe ser un objeto");
+      }
+      changes.branding = {};
+      for (const [field, value] of Object.entries(data.branding)) {
+        const isValid = BRANDING_FIELDS[field];
+        if (!isValid) {
+          throw new ValidationError(`Campo de marca desconocido: ${field}`);
+        }
+        if (value !== null && (typeof value !== "string" || !isValid(value))) {
+          throw new ValidationError(`Valor inválido para ${field}`);
+        }
+        changes.branding[field] = value;
+      }
+    }
+    if (data.senderDomain !== undefined) {
+      const domain = data.senderDomain ? String(data.senderDomain).trim().toLowerCase() : null;
+      if (domain && !DOMAIN_REGEX.test(domain)) {
+        throw new ValidationError("Dominio de envío inválido");
+      }
+      changes.senderDomain = domain;
+    }
+    if (data.senderLocalPart !== undefined) {
+      if (typeof data.senderLocalPart !== "string" || !LOCAL_PART_REGEX.test(data.senderLocalPart)) {
+        throw new ValidationError("Remitente inválido (la parte antes de @)");
+      }
+      changes.senderLocalPart = data.senderLocalPart.toLowerCase();
+    }
+    if (data.replyTo !== undefined) {
+      const replyTo = data.replyTo ? String(data.replyTo).trim() : null;
+      if (replyTo && (replyTo.length > 255 || !EMAIL_REGEX.test(replyTo))) {
+        throw new ValidationError("replyTo debe ser un email válido");
+      }
+      changes.replyTo = replyTo;
+    }
+    if (data.active !== undefined && !creating) {
+      changes.active = Boolean(data.active);
+    }
+    return changes;
+  }
+
+  async getTenantOrFail(tenantId) {
+    const tenant = await tenantRepository.findById(tenantId);
+    if (!tenant) {
+      throw new NotFoundError("Tenant no encontrado");
+    }
+    return tenant;
+  }
+
+  addressOf(from) {
+    const match = /<([^>]+)>/.exec(from || "");
+    return match ? match[1] : from;
+  }
+
+  formatTenant(tenant) {
+    return {
+      id: tenant.id,
+      name: tenant.name,
+      branding: { ...DEFAULT_BRANDING, ...tenant.branding, name: tenant.name },
+      senderDomain: tenant.senderDomain,
+      senderAddress: tenant.senderDomain ? `${tenant.senderLocalPart}@${tenant.senderDomain}` : null,
+      replyTo: tenant.replyTo,
+      dkimSelector: tenant.dkimSelector,
+      senderVerifiedAt: tenant.senderVerifiedAt,
+      active: tenant.active,
+      createdAt: tenant.createdAt,
+      updatedAt: tenant.updatedAt,
+    };
+  }
+}
+
+export default new TenantService();
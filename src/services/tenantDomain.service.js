@@ -0,0 +1,253 @@
+import crypto from "crypto";
+import fs from "fs";
+import path from "path";
+import tenantDomainRepository from "../repository/tenantDomain.repository.js";
+import tenantService, { DOMAIN_REGEX } from "./tenant.service.js";
+import jobQueueService from "./jobQueue.service.js";
+import auditService, { AUDIT_ACTIONS } from "./audit.service.js";
+import { CUSTOM_DOMAIN_CERTIFICATE_JOB } from "../jobs/customDomainCertificate.job.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { createHttpClient } from "../clients/http.client.js";
+import { ValidationError, NotFoundError, ExternalServiceError } from "../utils/customErrors.js";
+
+// TXT record checked for the challenge, at _jointravel-challenge.<hostname>
+const CHALLENGE_LABEL = "_jointravel-challenge";
+const CHALLENGE_PREFIX = "jointravel-domain-verification=";
+const HOOK_TIMEOUT_MS = 10000;
+
+// The certificate job has retries of its own
+const certificateHook = createHttpClient("certificate_hook", { timeoutMs: HOOK_TIMEOUT_MS, retries: 0 });
+
+/**
+ * Custom domains of tenants: the DNS TXT challenge that proves the tenant
+ * controls a domain, the hook that gets it a certificate, and resolving the
+ * tenant of a request from its Host.
+ */
+class TenantDomainService {
+  constructor() {
+    // hostname -> { tenantId, expiresAt }; unknown hosts are cached too
+    this.cache = new Map();
+  }
+
+  /**
+   * Tenant a verified custom domain belongs to. Cached for a short while, as
+   * it is looked up on every request.
+   * @param {string} hostname
+   * @returns {Promise<string|null>}
+   */
+  async resolveTenantId(hostname) {
+    const key = String(hostname || "").toLowerCase();
+    if (!DOMAIN_REGEX.test(key)) {
+      return null;
+    }
+    const cached = this.cache.get(key);
+    if (cached && cached.expiresAt > Date.now()) {
+      return cached.tenantId;
+    }
+
+    const domain = await tenantDomainRepository.findByHostname(key);
+    const tenantId = domain?.verifiedAt ? domain.tenantId : null;
+    this.cache.set(key, { tenantId, expiresAt: Date.now() + config.customDomains.cacheTtlSeconds * 1000 });
+    return tenantId;
+  }
+
+  /**
+   * Whether a browser origin is the https site of a verified custom domain,
+   * so tenant web apps can call the API cross-origin
+   * @param {string} origin
+   * @returns {Promise<boolean>}
+   */
+  async isTenantOrigin(origin) {
+    try {
+      const url = new URL(origin);
+      return url.protocol === "https:" && Boolean(await this.resolveTenantId(url.hostname));
+    } catch {
+      return false;
+    }
+  }
+
+  /**
+   * @param {string} tenantId
+   * @returns {Promise<Array>} Domains with their challenge record
+   */
+  async listDomains(tenantId) {
+    await tenantService.getTenantOrFail(tenantId);
+    const domains = await tenantDomainRepository.findByTenant(tenantId);
+    const refreshed = await Promise.all(domains.map((domain) => this.refreshCertificateStatus(domain)));
+    return refreshed.map((domain) => this.formatDomain(domain));
+  }
+
+  /**
+   * Adds a domain pending verification
+   * @param {string} tenantId
+   * @param {string} hostname
+   * @returns {Promise<Object>} Domain with the TXT record to publish
+   */
+  async addDomain(tenantId, hostname) {
+    await tenantService.getTenantOrFail(tenantId);
+    const normalized = typeof hostname === "string" ? hostname.trim().toLowerCase().replace(/\.$/, "") : "";
+    if (!DOMAIN_REGEX.test(normalized)) {
+      throw new ValidationError("Dominio inválido");
+    }
+    if (await tenantDomainRepository.findByHostname(normalized)) {
+      throw new ValidationError("El dominio ya está registrado");
+    }
+
+    const domain = await tenantDomainRepository.create({
+      tenantId,
+      hostname: normalized,
+      verificationToken: crypto.randomBytes(24).toString("hex"),
+    });
+    logger.info(`Custom domain ${normalized} added to tenant ${tenantId}`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.TENANT_DOMAIN_ADDED,
+      targetType: "tenant",
+      targetId: tenantId,
+      metadata: { hostname: normalized },
+    });
+    return this.formatDomain(domain);
+  }
+
+  /**
+   * Looks up the TXT challenge. The first time it is found the domain starts
+   * resolving to the tenant and its certificate is requested.
+   * @param {string} tenantId
+   * @param {string} domainId
+   * @returns {Promise<Object>} - { ...domain, found, newlyVerified }
+   */
+  async verifyDomain(tenantId, domainId) {
+    const domain = await this.getDomainOrFail(tenantId, domainId);
+    const record = this.buildChallengeRecord(domain);
+    const found = await tenantService.hasTxtRecord(record.name, (value) => value === record.value);
+    if (!found || domain.verifiedAt) {
+      return { ...this.formatDomain(domain), found, newlyVerified: false };
+    }
+
+    const verified = await tenantDomainRepository.update(domain.id, { verifiedAt: new Date() });
+    this.cache.delete(domain.hostname);
+    logger.info(`Custom domain ${domain.hostname} of tenant ${tenantId} verified`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.TENANT_DOMAIN_VERIFIED,
+      targetType: "tenant",
+      targetId: tenantId,
+      metadata: { hostname: domain.hostname },
+    });
+    await jobQueueService.enqueue(CUSTOM_DOMAIN_CERTIFICATE_JOB, { domainId: domain.id });
+    return { ...this.formatDomain(verified), found, newlyVerified: true };
+  }
+
+  /**
+   * Stops serving a domain for the tenant. Its certificate is left for ops to revoke.
+   * @param {string} tenantId
+   * @param {string} domainId
+   */
+  async removeDomain(tenantId, domainId) {
+    const domain = await this.getDomainOrFail(tenantId, domainId);
+    await tenantDomainRepository.delete(domain.id);
+    this.cache.delete(domain.hostname);
+    logger.info(`Custom domain ${domain.hostname} removed from tenant ${tenantId}`);
+    await auditService.record({
+      action: AUDIT_ACTIONS.TENANT_DOMAIN_REMOVED,
+      targetType: "tenant",
+      targetId: tenantId,
+      metadata: { hostname: domain.hostname },
+    });
+    return this.formatDomain(domain);
+  }
+
+  /**
+   * Asks the certificate hook to issue the certificate of a verified domain.
+   * Hook errors are thrown so the job queue retries them.
+   * @param {string} domainId
+   */
+  async requestCertificate(domainId) {
+    const domain = await tenantDomainRepository.findById(domainId);
+    if (!domain?.verifiedAt) {
+      return;
+    }
+    if (this.hasCertificate(domain.hostname)) {
+      await tenantDomainRepository.update(domain.id, { certificateStatus: "issued", certificateError: null });
+      return;
+    }
+    const { certificateHookUrl, certificateHookSecret } = config.customDomains;
+    if (!certificateHookUrl) {
+      logger.warn(`No certificate hook configured; issue the certificate of ${domain.hostname} manually`);
+      return;
+    }
+
+    try {
+      const response = await certificateHook.fetch(certificateHookUrl, {
+        method: "POST",
+        headers: {
+          "Content-Type": "application/json",
+          ...(certificateHookSecret && { Authorization: `Bearer ${certificateHookSecret}` }),
+        },
+        body: JSON.stringify({ hostname: domain.hostname, tenantId: domain.tenantId }),
+      });
+      if (!response.ok) {
+        throw new ExternalServiceError(`Certificate hook answered ${response.status}`);
+      }
+    } catch (error) {
+      await tenantDomainRepository.update(domain.id, {
+        certificateStatus: "failed",
+        certificateError: error.message,
+      });
+      throw error;
+    }
+    await tenantDomainRepository.update(domain.id, {
+      certificateStatus: "requested",
+      certificateError: null,
+      certificateRequestedAt: new Date(),
+    });
+    logger.info(`Certificate of ${domain.hostname} requested`);
+  }
+
+  /**
+   * Marks a requested certificate as issued once its files are in place
+   */
+  async refreshCertificateStatus(domain) {
+    if (domain.certificateStatus === "issued" || !domain.verifiedAt || !this.hasCertificate(domain.hostname)) {
+      return domain;
+    }
+    return await tenantDomainRepository.update(domain.id, { certificateStatus: "issued", certificateError: null });
+  }
+
+  hasCertificate(hostname) {
+    const dir = config.server.tls.customCertsDir;
+    return Boolean(dir) && fs.existsSync(path.join(dir, hostname, "fullchain.pem"));
+  }
+
+  buildChallengeRecord(domain) {
+    return {
+      type: "TXT",
+      name: `${CHALLENGE_LABEL}.${domain.hostname}`,
+      value: `${CHALLENGE_PREFIX}${domain.verificationToken}`,
+    };
+  }
+
+  async getDomainOrFail(tenantId, domainId) {
+    const domain = await tenantDomainRepository.findById(domainId);
+    if (!domain || domain.tenantId !== tenantId) {
+      throw new NotFoundError("Dominio no encontrado");
+    }
+    return domain;
+  }
+
+  formatDomain(domain) {
+    return {
+      id: domain.id,
+      tenantId: domain.tenantId,
+      hostname: domain.hostname,
+      verified: Boolean(domain.verifiedAt),
+      verifiedAt: domain.verifiedAt,
+      challengeRecord: this.buildChallengeRecord(domain),
+      certificateStatus: domain.certificateStatus,
+      certificateError: domain.certificateError,
+      certificateRequestedAt: domain.certificateRequestedAt,
+      createdAt: domain.createdAt,
+    };
+  }
+}
+
+export default new TenantDomainService();
@@ -0,0 +1,272 @@
+import pastTripRepository from "../repository/pastTrip.repository.js";
+import companionReferenceRepository from "../repository/companionReference.repository.js";
+import geocodingClient from "../clients/geocoding.client.js";
+import logger from "../config/logger.js";
+import { ValidationError, NotFoundError, AuthorizationError } from "../utils/customErrors.js";
+import { clusterPhotos, datesOverlap, samePlace, toDayNumber } from "../utils/travelHistory.js";
+
+const MAX_TRIPS_PER_IMPORT = 100;
+const MAX_PHOTOS_PER_IMPORT = 5000;
+// Place names geocoded per import; the rest are saved without coordinates
+const MAX_GEOCODED_PER_IMPORT = 20;
+const MAX_TRIP_DAYS = 365;
+const MAX_CITY_LENGTH = 120;
+// Two entries within this distance and overlapping dates are the same trip
+const DEDUP_RADIUS_KM = 50;
+const PHOTO_CLUSTERING = { maxGapDays: 2, radiusKm: 150 };
+
+const DATE_REGEX = /^\d{4}-\d{2}-\d{2}$/;
+const COUNTRY_CODE_REGEX = /^[A-Z]{2}$/;
+
+/**
+ * Travel history backfilled by the user: trips made outside JoinTravel,
+ * entered as places and dates or clustered from photo metadata read on the device
+ */
+class TravelHistoryService {
+  /**
+   * Imports past trips, skipping those already in the history or matching a
+   * JoinTravel trip the user took part in
+   * @param {string} userId
+   * @param {Object} data - { trips: [{ city, countryCode, lat, lng, startDate, endDate }],
+   *                          photos: [{ takenAt, lat, lng, city, countryCode }] }
+   * @returns {Promise<Object>} - { imported, skipped: [{ source, index, reason, matchId }] }
+   */
+  async importTrips(userId, { trips = [], photos = [] } = {}) {
+    if (!Array.isArray(trips) || !Array.isArray(photos)) {
+      throw new ValidationError("trips y photos deben ser listas");
+    }
+    if (trips.length === 0 && photos.length === 0) {
+      throw new ValidationError("Envía al menos un viaje o una foto");
+    }
+    if (trips.length > MAX_TRIPS_PER_IMPORT) {
+      throw new ValidationError(`Se pueden importar hasta ${MAX_TRIPS_PER_IMPORT} viajes por vez`);
+    }
+    if (photos.length > MAX_PHOTOS_PER_IMPORT) {
+      throw new ValidationError(`Se pueden importar hasta ${MAX_PHOTOS_PER_IMPORT} fotos por vez`);
+    }
+
+    const candidates = [
+      ...trips.map((trip, index) => ({ ...this.validateTrip(trip, index), source: "manual", index })),
+      ...clusterPhotos(
+        photos.map((photo, index) => this.validatePhoto(photo, index)),
+        PHOTO_CLUSTERING
+      ).map((trip, index) => ({ ...trip, source: "photos", index })),
+    ];
+    await this.geocodeMissing(candidates);
+
+    // Load once everything the import could collide with
+    const from = this.shiftDate(candidates.map((trip) => trip.startDate).sort()[0], -1);
+    const to = this.shiftDate(candidates.map((trip) => trip.endDate).sort().pop(), 1);
+    const [existing, groupTrips] = await Promise.all([
+      pastTripRepository.findByUserInRange(userId, from, to),
+      pastTripRepository.findGroupTripsInRange(userId, from, to),
+    ]);
+
+    const accepted = [];
+    const skipped = [];
+    for (const candidate of candidates) {
+      const groupTrip = groupTrips.find((trip) => this.isSameTrip(candidate, trip));
+      if (groupTrip) {
+        skipped.push({
+          source: candidate.source,
+          index: candidate.index,
+          reason: "group_trip",
+          matchId: groupTrip.id,
+        });
+        continue;
+      }
+      const duplicate = [...existing, ...accepted].find((trip) => this.isSameTrip(candidate, trip));
+      if (duplicate) {
+        skipped.push({
+          source: candidate.source,
+          index: candidate.index,
+          reason: "duplicate",
+          matchId: duplicate.id || null,
+        });
+        continue;
+      }
+      accepted.push(candidate);
+    }
+
+    const imported = accepted.length
+      ? await pastTripRepository.createMany(
+          accepted.map(({ source, index, ...trip }) => ({ ...trip, userId, source }))
+        )
+      : [];
+    logger.info(
+      `User ${userId} imported ${imported.length} past trips (${skipped.length} skipped as duplicates)`
+    );
+
+    return { imported: imported.map((trip) => this.formatTrip(trip)), skipped };
+  }
+
+  /**
+   * Travel history with countries-visited stats
+   * @param {string} userId
+   * @returns {Promise<Object>} - { trips, stats: { importedTrips, completedGroupTrips, countriesCount, countriesVisited } }
+   */
+  async getHistory(userId) {
+    const [trips, countries, completedGroupTrips] = await Promise.all([
+      pastTripRepository.findByUser(userId),
+      pastTripRepository.countCountries(userId),
+      companionReferenceRepository.countCompletedTrips(userId),
+    ]);
+
+    return {
+      trips: trips.map((trip) => this.formatTrip(trip)),
+      stats: {
+        importedTrips: trips.length,
+        completedGroupTrips,
+        countriesCount: countries.length,
+        countriesVisited: countries,
+      },
+    };
+  }
+
+  /**
+   * Removes an imported trip
+   * @param {string} userId
+   * @param {string} tripId
+   */
+  async deleteTrip(userId, tripId) {
+    const trip = await pastTripRepository.findById(tripId);
+    if (!trip) {
+      throw new NotFoundError("Viaje no encontrado", "TRIP_NOT_FOUND");
+    }
+    if (trip.userId !== userId) {
+      throw new AuthorizationError("Solo puedes borrar viajes de tu historial");
+    }
+    await pastTripRepository.delete(tripId);
+  }
+
+  validateTrip(trip, index) {
+    if (!trip || typeof trip !== "object") {
+      throw new ValidationError(`trips[${index}] debe ser un objeto`);
+    }
+    const city = typeof trip.city === "string" ? trip.city.trim() : "";
+    const hasCoordinates = trip.lat !== undefined && trip.lat !== null;
+    if (!city && !hasCoordinates) {
+      throw new ValidationError(`trips[${index}] necesita city o lat/lng`);
+    }
+    if (city.length > MAX_CITY_LENGTH) {
+      throw new ValidationError(`trips[${index}].city admite hasta ${MAX_CITY_LENGTH} caracteres`);
+    }
+    if (!this.isValidDate(trip.startDate)) {
+      throw new ValidationError(`trips[${index}].startDate debe tener formato YYYY-MM-DD`);
+    }
+    const endDate = trip.endDate ?? trip.startDate;
+    if (!this.isValidDate(endDate) || endDate < trip.startDate) {
+      throw new ValidationError(`trips[${index}].endDate debe ser una fecha igual o posterior a startDate`);
+    }
+    if (toDayNumber(endDate) - toDayNumber(trip.startDate) > MAX_TRIP_DAYS) {
+      throw new ValidationError(`trips[${index}] no puede durar más de ${MAX_TRIP_DAYS} días`);
+    }
+    if (endDate > new Date().toISOString().slice(0, 10)) {
+      throw new ValidationError(`trips[${index}] todavía no terminó`);
+    }
+
+    return {
+      city: city || null,
+      countryCode: this.validateCountryCode(trip.countryCode, `trips[${index}]`),
+      ...(hasCoordinates ? this.validateCoordinates(trip, `trips[${index}]`) : { lat: null, lng: null }),
+      startDate: trip.startDate,
+      endDate,
+      photoCount: null,
+    };
+  }
+
+  validatePhoto(photo, index) {
+    if (!photo || typeof photo !== "object") {
+      throw new ValidationError(`photos[${index}] debe ser un objeto`);
+    }
+    const takenAt = new Date(photo.takenAt);
+    if (!photo.takenAt || isNaN(takenAt.getTime()) || takenAt > new Date()) {
+      throw new ValidationError(`photos[${index}].takenAt debe ser una fecha pasada`);
+    }
+    const city = typeof photo.city === "string" ? photo.city.trim().slice(0, MAX_CITY_LENGTH) : null;
+
+    return {
+      takenAt: takenAt.toISOString(),
+      ...this.validateCoordinates(photo, `photos[${index}]`),
+      city: city || null,
+      countryCode: this.validateCountryCode(photo.countryCode, `photos[${index}]`),
+    };
+  }
+
+  validateCoordinates({ lat, lng }, field) {
+    const latitude = Number(lat);
+    const longitude = Number(lng);
+    if (
+      lat === null || lng === null || lat === undefined || lng === undefined ||
+      !Number.isFinite(latitude) || !Number.isFinite(longitude) ||
+      Math.abs(latitude) > 90 || Math.abs(longitude) > 180
+    ) {
+      throw new ValidationError(`${field} tiene coordenadas inválidas`);
+    }
+    return { lat: latitude, lng: longitude };
+  }
+
+  validateCountryCode(countryCode, field) {
+    if (countryCode === undefined || countryCode === null || countryCode === "") {
+      return null;
+    }
+    const code = String(countryCode).toUpperCase();
+    if (!COUNTRY_CODE_REGEX.test(code)) {
+      throw new ValidationError(`${field}.countryCode debe ser un código ISO de 2 letras`);
+    }
+    return code;
+  }
+
+  isValidDate(value) {
+    return (
+      typeof value === "string" &&
+      DATE_REGEX.test(value) &&
+      new Date(`${value}T00:00:00Z`).toISOString().slice(0, 10) === value
+    );
+  }
+
+  shiftDate(date, days) {
+    const shifted = new Date(`${date}T00:00:00Z`);
+    shifted.setUTCDate(shifted.getUTCDate() + days);
+    return shifted.toISOString().slice(0, 10);
+  }
+
+  isSameTrip(a, b) {
+    return datesOverlap(a, b) && samePlace(a, b, DEDUP_RADIUS_KM);
+  }
+
+  /**
+   * Adds coordinates to trips entered by place name, so they can be matched
+   * by distance against trips named differently
+   */
+  async geocodeMissing(candidates) {
+    const missing = candidates
+      .filter((trip) => trip.city && trip.lat === null)
+      .slice(0, MAX_GEOCODED_PER_IMPORT);
+    for (const trip of missing) {
+      const query = trip.countryCode ? `${trip.city}, ${trip.countryCode}` : trip.city;
+      const result = await geocodingClient.tryGeocode(query);
+      if (result) {
+        trip.lat = result.lat;
+        trip.lng = result.lng;
+      }
+    }
+  }
+
+  formatTrip(trip) {
+    return {
+      id: trip.id,
+      city: trip.city,
+      countryCode: trip.countryCode,
+      lat: trip.lat !== null ? Number(trip.lat) : null,
+      lng: trip.lng !== null ? Number(trip.lng) : null,
+      startDate: trip.startDate,
+      endDate: trip.endDate,
+      source: trip.source,
+      photoCount: trip.photoCount,
+      createdAt: trip.createdAt,
+    };
+  }
+}
+
+export default new TravelHistoryService();
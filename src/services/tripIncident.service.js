@@ -0,0 +1,257 @@
+import tripIncidentRepository from "../repository/tripIncident.repository.js";
+import userReportRepository from "../repository/userReport.repository.js";
+import groupRepository from "../repository/group.repository.js";
+import UserRepository from "../repository/user.repository.js";
+import emailService from "./email.service.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { createHttpClient } from "../clients/http.client.js";
+import { ValidationError, NotFoundError, AuthorizationError } from "../utils/customErrors.js";
+import { createAndEmitNotification } from "../socket/notification.emitter.js";
+
+const userRepository = new UserRepository();
+
+export const INCIDENT_TYPES = {
+  harassment: { label: "acoso", reportReason: "harassment" },
+  injury: { label: "lesión o problema de salud", reportReason: "safety_concern" },
+  theft: { label: "robo", reportReason: "safety_concern" },
+  other: { label: "otro problema de seguridad", reportReason: "safety_concern" },
+};
+export const EMERGENCY_CONTACT_ALERTS = ["never", "on_request", "always"];
+
+const MAX_DESCRIPTION_LENGTH = 2000;
+const MAX_EMERGENCY_CONTACTS = 3;
+const EMAIL_REGEX = /^[^\s@]+@[^\s@]+\.[^\s@]+$/;
+const PHONE_REGEX = /^\+?[0-9 ()-]{6,20}$/;
+const WEBHOOK_TIMEOUT_MS = 5000;
+
+const safetyWebhook = createHttpClient("safety_webhook", { timeoutMs: WEBHOOK_TIMEOUT_MS });
+
+/**
+ * Safety incidents reported during trips (harassment, injury, theft). Each
+ * one opens a high severity moderation case, alerts the safety staff right
+ * away and, per the reporter's settings, their emergency contacts.
+ */
+class TripIncidentService {
+  /**
+   * Reports an incident on a trip the user is part of
+   * @param {string} groupId
+   * @param {string} reporterId
+   * @param {Object} data - { type, description, involvedUserId, lat, lng, notifyEmergencyContacts }
+   * @returns {Promise<Object>} - { id, type, reportId, emergencyContactsNotified, createdAt }
+   */
+  async reportIncident(groupId, reporterId, data = {}) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      throw new NotFoundError("Viaje no encontrado", "TRIP_NOT_FOUND");
+    }
+    const memberIds = group.members.map((member) => member.id);
+    if (!memberIds.includes(reporterId) && group.adminId !== reporterId) {
+      throw new AuthorizationError("Solo los viajeros del grupo pueden reportar incidentes");
+    }
+    const incident = this.validateIncident(data, { memberIds, reporterId, adminId: group.adminId });
+
+    const reporter = await userRepository.findById(reporterId);
+    const report = await userReportRepository.create({
+      reporterId,
+      targetType: incident.involvedUserId ? "user" : "trip",
+      targetUserId: incident.involvedUserId || group.adminId,
+      targetGroupId: group.id,
+      reason: INCIDENT_TYPES[incident.type].reportReason,
+      details: `[Incidente en viaje: ${incident.type}] ${incident.description}`,
+      severity: "high",
+    });
+    const created = await tripIncidentRepository.create({ groupId, reporterId, reportId: report.id, ...incident });
+    logger.warn(`Trip incident ${created.id} (${incident.type}) reported on group ${groupId}, case ${report.id}`);
+
+    await this.alertSafetyStaff(created, { group, reporter });
+
+    const wantsContacts =
+      reporter.emergencyContactAlerts === "always" ||
+      (reporter.emergencyContactAlerts === "on_request" && data.notifyEmergencyContacts === true);
+    const notified = wantsContacts ? await this.alertEmergencyContacts(created, { group, reporter }) : 0;
+    if (notified > 0) {
+      await tripIncidentRepository.update(created.id, { emergencyContactsNotified: notified });
+    }
+
+    return {
+      id: created.id,
+      type: created.type,
+      reportId: report.id,
+      emergencyContactsNotified: notified,
+      createdAt: created.createdAt,
+    };
+  }
+
+  validateIncident({ type, description, involvedUserId = null, lat = null, lng = null }, { memberIds, reporterId, adminId }) {
+    if (!INCIDENT_TYPES[type]) {
+      throw new ValidationError(`type debe ser uno de: ${Object.keys(INCIDENT_TYPES).join(", ")}`);
+    }
+    if (typeof description !== "string" || !description.trim() || description.length > MAX_DESCRIPTION_LENGTH) {
+      throw new ValidationError(`La descripción es requerida (máx. ${MAX_DESCRIPTION_LENGTH} caracteres)`);
+    }
+    if (involvedUserId !== null) {
+      if (involvedUserId === reporterId || (!memberIds.includes(involvedUserId) && involvedUserId !== adminId)) {
+        throw new ValidationError("involvedUserId debe ser otro viajero del grupo");
+      }
+    }
+    const hasLocation = lat !== null || lng !== null;
+    if (
+      hasLocation &&
+      !(typeof lat === "number" && typeof lng === "number" && Math.abs(lat) <= 90 && Math.abs(lng) <= 180)
+    ) {
+      throw new ValidationError("Ubicación inválida");
+    }
+    return {
+      type,
+      description: description.trim(),
+      involvedUserId,
+      lat: hasLocation ? lat : null,
+      lng: hasLocation ? lng : null,
+    };
+  }
+
+  /**
+   * Critical notification to every admin (skips quiet hours) and a post to
+   * the safety webhook. Failures are logged: the case is already open.
+   */
+  async alertSafetyStaff(incident, { group, reporter }) {
+    const summary = `Incidente (${INCIDENT_TYPES[incident.type].label}) reportado por ${
+      reporter.name || reporter.email
+    } en "${group.name}"`;
+    try {
+      const adminIds = await userRepository.findAdminIds();
+      for (const userId of adminIds) {
+        await createAndEmitNotification({
+          userId,
+          type: "SAFETY_INCIDENT",
+          title: "Incidente de seguridad en un viaje",
+          message: summary,
+          data: { incidentId: incident.id, reportId: incident.reportId, groupId: group.id, type: incident.type },
+          priority: "critical",
+        });
+      }
+    } catch (error) {
+      logger.error(`Failed to notify admins about incident ${incident.id}: ${error.message}`);
+    }
+
+    if (!config.safety.alertWebhookUrl) {
+      return;
+    }
+    try {
+      const response = await safetyWebhook.fetch(config.safety.alertWebhookUrl, {
+        method: "POST",
+        // A repeated alert beats a lost one
+        idempotent: true,
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({
+          text: `:rotating_light: ${summary}. Caso ${incident.reportId}`,
+          incident: {
+            id: incident.id,
+            type: incident.type,
+            reportId: incident.reportId,
+            groupId: group.id,
+            reporterId: reporter.id,
+            involvedUserId: incident.involvedUserId,
+            lat: incident.lat,
+            lng: incident.lng,
+            createdAt: incident.createdAt,
+          },
+        }),
+      });
+      if (!response.ok) {
+        logger.error(`Safety webhook answered ${response.status} for incident ${incident.id}`);
+      }
+    } catch (error) {
+      logger.error(`Safety webhook failed for incident ${incident.id}: ${error.message}`);
+    }
+  }
+
+  /**
+   * Emails the reporter's emergency contacts. Contacts with only a phone are
+   * left to the safety staff, who see them in the case.
+   * @returns {Promise<number>} Contacts emailed
+   */
+  async alertEmergencyContacts(incident, { group, reporter }) {
+    const contacts = (reporter.emergencyContacts || []).filter((contact) => contact.email);
+    const lat = incident.lat !== null ? Number(incident.lat) : null;
+    const lng = incident.lng !== null ? Number(incident.lng) : null;
+    let notified = 0;
+    for (const contact of contacts) {
+      try {
+        await emailService.sendEmergencyContactAlert(contact.email, {
+          contactName: contact.name,
+          reporterName: reporter.name || reporter.email,
+          tripName: group.name,
+          incidentType: incident.type,
+          mapUrl: lat !== null ? `https://www.google.com/maps?q=${lat},${lng}` : null,
+          reportedAt: incident.createdAt,
+        }, reporter.locale);
+        notified++;
+      } catch (error) {
+        logger.error(`Emergency contact alert of incident ${incident.id} failed: ${error.message}`);
+      }
+    }
+    return notified;
+  }
+
+  /**
+   * @param {string} userId
+   * @returns {Promise<Object>} - { contacts, alerts }
+   */
+  async getEmergencySettings(userId) {
+    const user = await userRepository.findById(userId);
+    if (!user) {
+      throw new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND");
+    }
+    return { contacts: user.emergencyContacts || [], alerts: user.emergencyContactAlerts };
+  }
+
+  /**
+   * Replaces the emergency contacts and when they are alerted
+   * @param {string} userId
+   * @param {Object} data - { contacts: [{ name, email, phone }], alerts }
+   * @returns {Promise<Object>} - { contacts, alerts }
+   */
+  async updateEmergencySettings(userId, { contacts, alerts } = {}) {
+    const changes = {};
+    if (contacts !== undefined) {
+      if (!Array.isArray(contacts) || contacts.length > MAX_EMERGENCY_CONTACTS) {
+        throw new ValidationError(`contacts debe ser una lista de hasta ${MAX_EMERGENCY_CONTACTS} contactos`);
+      }
+      changes.emergencyContacts = contacts.map((contact) => this.validateContact(contact));
+    }
+    if (alerts !== undefined) {
+      if (!EMERGENCY_CONTACT_ALERTS.includes(alerts)) {
+        throw new ValidationError(`alerts debe ser uno de: ${EMERGENCY_CONTACT_ALERTS.join(", ")}`);
+      }
+      changes.emergencyContactAlerts = alerts;
+    }
+    if (Object.keys(changes).length === 0) {
+      throw new ValidationError("No hay cambios para guardar", null, "NO_CHANGES");
+    }
+    const user = await userRepository.update(userId, changes);
+    return { contacts: user.emergencyContacts, alerts: user.emergencyContactAlerts };
+  }
+
+  validateContact(contact) {
+    const name = typeof contact?.name === "string" ? contact.name.trim() : "";
+    const email = typeof contact?.email === "string" && contact.email.trim() ? contact.email.trim() : null;
+    const phone = typeof contact?.phone === "string" && contact.phone.trim() ? contact.phone.trim() : null;
+    if (!name || name.length > 100) {
+      throw new ValidationError("Cada contacto necesita un nombre (máx. 100 caracteres)");
+    }
+    if (!email && !phone) {
+      throw new ValidationError("Cada contacto necesita un email o un teléfono");
+    }
+    if (email && (email.length > 255 || !EMAIL_REGEX.test(email))) {
+      throw new ValidationError(`Email inválido para ${name}`);
+    }
+    if (phone && !PHONE_REGEX.test(phone)) {
+      throw new ValidationError(`Teléfono inválido para ${name}`);
+    }
+    return { name, email, phone };
+  }
+}
+
+export default new TripIncidentService();
@@ -0,0 +1,297 @@
+import tripPlanRepository from "../repository/tripPlan.repository.js";
+import groupRepository from "../repository/group.repository.js";
+import itineraryRepository from "../repository/itinerary.repository.js";
+import placeRepository from "../repository/place.repository.js";
+import txManager from "../repository/transactionManager.js";
+import logger from "../config/logger.js";
+import {
+  ValidationError,
+  NotFoundError,
+  AuthorizationError,
+} from "../utils/customErrors.js";
+
+const DATE_REGEX = /^\d{4}-\d{2}-\d{2}$/;
+const TIME_REGEX = /^([01]\d|2[0-3]):[0-5]\d$/;
+const MAX_COST = 100000000; // 1M in cents
+const MAX_NOTES_LENGTH = 2000;
+
+/**
+ * Day-by-day plan of a trip. Items live in the itinerary assigned to the
+ * group; the admin edits them and every member can read them.
+ */
+class TripPlanService {
+  /**
+   * Day-by-day view of the trip
+   * @param {string} groupId
+   * @param {string} userId
+   * @returns {Promise<Object>} - { itineraryId, startDate, endDate, totalCost, days }
+   */
+  async getPlan(groupId, userId) {
+    const group = await this.getGroupAsMember(groupId, userId);
+    if (!group.assignedItineraryId) {
+      return { itineraryId: null, startDate: null, endDate: null, totalCost: "0.00", days: [] };
+    }
+
+    const items = await tripPlanRepository.findItems(group.assignedItineraryId);
+    const days = [];
+    for (const item of items) {
+      const date = this.toDateString(item.date);
+      let day = days[days.length - 1];
+      if (!day || day.date !== date) {
+        day = { date, items: [], costCents: 0 };
+        days.push(day);
+      }
+      day.items.push(this.formatItem(item));
+      day.costCents += item.cost || 0;
+    }
+
+    const startDate = days[0]?.date ?? null;
+    const totalCents = days.reduce((sum, day) => sum + day.costCents, 0);
+    return {
+      itineraryId: group.assignedItineraryId,
+      startDate,
+      endDate: days[days.length - 1]?.date ?? null,
+      totalCost: (totalCents / 100).toFixed(2),
+      days: days.map(({ costCents, ...day }) => ({
+        ...day,
+        dayNumber: this.daysBetween(startDate, day.date) + 1,
+        totalCost: (costCents / 100).toFixed(2),
+      })),
+    };
+  }
+
+  async getItem(groupId, itemId, userId) {
+    const group = await this.getGroupAsMember(groupId, userId);
+    return this.formatItem(await this.getItemOrFail(group, itemId));
+  }
+
+  /**
+   * Adds an item at the end of its day. A trip without itinerary gets one,
+   * owned by the admin.
+   * @param {Object} data - { date, time, placeId, notes, cost }
+   */
+  async createItem(groupId, userId, data) {
+    const group = await this.getGroupAsAdmin(groupId, userId);
+    const fields = await this.validateItem(data, { partial: false });
+
+    let itineraryId = group.assignedItineraryId;
+    if (!itineraryId) {
+      const itinerary = await itineraryRepository.createItinerary({
+        name: `Itinerario de ${group.name}`.slice(0, 255),
+        userId: group.adminId,
+        items: [],
+      });
+      await groupRepository.assignItinerary(groupId, itinerary.id);
+      itineraryId = itinerary.id;
+      logger.info(`Created itinerary ${itineraryId} for group ${groupId}`);
+    }
+
+    const item = await tripPlanRepository.create({
+      ...fields,
+      itineraryId,
+      order: await tripPlanRepository.nextOrder(itineraryId, fields.date),
+    });
+    await this.refreshTravelDimensions(itineraryId);
+    return this.formatItem(item);
+  }
+
+  /**
+   * Updates an item; moving it to another day puts it at the end of that day
+   */
+  async updateItem(groupId, itemId, userId, data) {
+    const group = await this.getGroupAsAdmin(groupId, userId);
+    const item = await this.getItemOrFail(group, itemId);
+    const fields = await this.validateItem(data, { partial: true });
+
+    const previousDate = this.toDateString(item.date);
+    if (fields.date && fields.date !== previousDate) {
+      fields.order = await tripPlanRepository.nextOrder(item.itineraryId, fields.date);
+    }
+
+    const updated = await tripPlanRepository.update(item.itineraryId, itemId, fields);
+    if (fields.order !== undefined) {
+      await this.compactDay(item.itineraryId, previousDate);
+    }
+    if (fields.date) {
+      await this.refreshTravelDimensions(item.itineraryId);
+    }
+    return this.formatItem(updated);
+  }
+
+  async deleteItem(groupId, itemId, userId) {
+    const group = await this.getGroupAsAdmin(groupId, userId);
+    const item = await this.getItemOrFail(group, itemId);
+
+    await tripPlanRepository.delete(item.id);
+    await this.compactDay(item.itineraryId, this.toDateString(item.date));
+    await this.refreshTravelDimensions(item.itineraryId);
+  }
+
+  /**
+   * Reorders the items of a day
+   * @param {string} date - YYYY-MM-DD
+   * @param {Array<string>} itemIds - Every item of the day, in the new order
+   * @returns {Promise<Array>} Items of the day
+   */
+  async reorderDay(groupId, date, userId, itemIds) {
+    const group = await this.getGroupAsAdmin(groupId, userId);
+    if (!group.assignedItineraryId) {
+      throw new NotFoundError("El viaje no tiene itinerario");
+    }
+    if (!this.isValidDate(date)) {
+      throw new ValidationError("Fecha inválida, usa el formato YYYY-MM-DD");
+    }
+
+    const dayItems = await tripPlanRepository.findDayItems(group.assignedItineraryId, date);
+    const dayIds = new Set(dayItems.map((item) => item.id));
+    if (
+      !Array.isArray(itemIds) ||
+      itemIds.length !== dayIds.size ||
+      new Set(itemIds).size !== itemIds.length ||
+      !itemIds.every((id) => dayIds.has(id))
+    ) {
+      throw new ValidationError("itemIds debe incluir exactamente todos los ítems del día");
+    }
+
+    await tripPlanRepository.reorderDay(itemIds);
+    const reordered = await tripPlanRepository.findItems(group.assignedItineraryId);
+    return reordered
+      .filter((item) => this.toDateString(item.date) === date)
+      .map((item) => this.formatItem(item));
+  }
+
+  /**
+   * Item dates drive the travel dates, month and duration of the trips using
+   * the itinerary; they are recomputed once the change is committed
+   */
+  async refreshTravelDimensions(itineraryId) {
+    await txManager.afterCommit(() => groupRepository.refreshTravelDimensions({ itineraryId }));
+  }
+
+  /**
+   * Closes the gaps left in a day's positions
+   */
+  async compactDay(itineraryId, date) {
+    const items = await tripPlanRepository.findDayItems(itineraryId, date);
+    if (items.some((item, index) => item.order !== index)) {
+      await tripPlanRepository.reorderDay(items.map((item) => item.id));
+    }
+  }
+
+  /**
+   * Validates item fields
+   * @returns {Promise<Object>} Fields to persist (cost in cents)
+   */
+  async validateItem({ date, time, placeId, notes, cost }, { partial }) {
+    const fields = {};
+
+    if (date !== undefined || !partial) {
+      if (!this.isValidDate(date)) {
+        throw new ValidationError("Fecha inválida, usa el formato YYYY-MM-DD");
+      }
+      fields.date = date;
+    }
+    if (placeId !== undefined || !partial) {
+      if (!placeId || !(await placeRepository.findById(placeId))) {
+        throw new ValidationError("Lugar no encontrado");
+      }
+      fields.placeId = placeId;
+    }
+    if (time !== undefined) {
+      if (time !== null && !TIME_REGEX.test(time)) {
+        throw new ValidationError("Hora inválida, usa el formato HH:MM");
+      }
+      fields.time = time;
+    }
+    if (notes !== undefined) {
+      if (notes !== null && (typeof notes !== "string" || notes.length > MAX_NOTES_LENGTH)) {
+        throw new ValidationError(`Las notas deben tener hasta ${MAX_NOTES_LENGTH} caracteres`);
+      }
+      fields.notes = notes ? notes.trim() : null;
+    }
+    if (cost !== undefined) {
+      if (cost === null) {
+        fields.cost = null;
+      } else {
+        const cents = Math.round(Number(cost) * 100);
+        if (!Number.isFinite(cents) || cents < 0 || cents > MAX_COST) {
+          throw new ValidationError("El costo debe ser un número positivo");
+        }
+        fields.cost = cents;
+      }
+    }
+    return fields;
+  }
+
+  isValidDate(date) {
+    if (typeof date !== "string" || !DATE_REGEX.test(date)) {
+      return false;
+    }
+    // Rejects dates that roll over, like 2025-02-30
+    const parsed = new Date(`${date}T00:00:00Z`);
+    return !Number.isNaN(parsed.getTime()) && parsed.toISOString().startsWith(date);
+  }
+
+  toDateString(date) {
+    if (date instanceof Date) {
+      return `${date.getFullYear()}-${String(date.getMonth() + 1).padStart(2, "0")}-${String(date.getDate()).padStart(2, "0")}`;
+    }
+    return String(date).slice(0, 10);
+  }
+
+  daysBetween(from, to) {
+    return Math.round((new Date(`${to}T00:00:00Z`) - new Date(`${from}T00:00:00Z`)) / 86400000);
+  }
+
+  async getItemOrFail(group, itemId) {
+    const item = group.assignedItineraryId
+      ? await tripPlanRepository.findItem(group.assignedItineraryId, itemId)
+      : null;
+    if (!item) {
+      throw new NotFoundError("Ítem no encontrado");
+    }
+    return item;
+  }
+
+  async getGroupAsMember(groupId, userId) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      throw new NotFoundError("Grupo no encontrado", "GROUP_NOT_FOUND");
+    }
+    if (!group.members.some((member) => member.id === userId)) {
+      throw new AuthorizationError("No eres miembro de este grupo", "NOT_GROUP_MEMBER");
+    }
+    return group;
+  }
+
+  async getGroupAsAdmin(groupId, userId) {
+    const group = await this.getGroupAsMember(groupId, userId);
+    if (group.adminId !== userId) {
+      throw new AuthorizationError("Solo el administrador puede editar el itinerario del viaje");
+    }
+    return group;
+  }
+
+  formatItem(item) {
+    return {
+      id: item.id,
+      date: this.toDateString(item.date),
+      time: item.time ? String(item.time).slice(0, 5) : null,
+      order: item.order,
+      notes: item.notes,
+      cost: item.cost === null || item.cost === undefined ? null : (item.cost / 100).toFixed(2),
+      place: item.place
+        ? {
+            id: item.place.id,
+            name: item.place.name,
+            address: item.place.address,
+            city: item.place.city,
+            latitude: item.place.latitude,
+            longitude: item.place.longitude,
+          }
+        : { id: item.placeId },
+    };
+  }
+}
+
+export default new TripPlanService();
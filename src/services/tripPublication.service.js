@@ -0,0 +1,196 @@
+import groupRepository from "../repository/group.repository.js";
+import jobQueueService from "./jobQueue.service.js";
+import searchService from "./search.service.js";
+import followerNotificationService from "./followerNotification.service.js";
+import { TRIP_PUBLICATION_JOB } from "../jobs/tripPublication.job.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+
+/**
+ * Scheduled trip launches: the organizer picks when a private trip turns
+ * public, a queued job publishes it at that time and the organizer's
+ * followers are notified.
+ */
+class TripPublicationService {
+  /**
+   * Schedules (or reschedules) the launch of a trip (admin only)
+   * @param {string} groupId
+   * @param {string} requesterId
+   * @param {string} publishAt - ISO 8601 date-time
+   * @returns {Promise<Object>} - { success, data, message }
+   */
+  async schedulePublication(groupId, requesterId, publishAt) {
+    try {
+      const group = await this.getGroupAsAdmin(groupId, requesterId);
+      if (group.isPublic) {
+        const error = new Error("El viaje ya está publicado.");
+        error.status = 409;
+        throw error;
+      }
+
+      const date = new Date(publishAt);
+      const maxDate = Date.now() + config.tripPublication.maxScheduleDays * 24 * 60 * 60 * 1000;
+      if (!publishAt || Number.isNaN(date.getTime())) {
+        const error = new Error("La fecha de publicación es inválida.");
+        error.status = 400;
+        throw error;
+      }
+      if (date.getTime() <= Date.now()) {
+        const error = new Error("La fecha de publicación debe ser futura.");
+        error.status = 400;
+        throw error;
+      }
+      if (date.getTime() > maxDate) {
+        const error = new Error(
+          `La publicación no puede programarse con más de ${config.tripPublication.maxScheduleDays} días de anticipación.`
+        );
+        error.status = 400;
+        throw error;
+      }
+
+      const updated = await groupRepository.update(groupId, { publishAt: date });
+      await jobQueueService.enqueue(TRIP_PUBLICATION_JOB, { groupId }, { runAt: date });
+
+      logger.info(`Publication of group ${groupId} scheduled for ${date.toISOString()}`);
+      return {
+        success: true,
+        data: this.formatCountdown(updated),
+        message: "Publicación programada",
+      };
+    } catch (err) {
+      logger.error(`Error scheduling publication of group ${groupId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Cancels a scheduled launch; the trip stays private (admin only)
+   * @param {string} groupId
+   * @param {string} requesterId
+   * @returns {Promise<Object>} - { success, data, message }
+   */
+  async cancelPublication(groupId, requesterId) {
+    try {
+      const group = await this.getGroupAsAdmin(groupId, requesterId);
+      if (!group.publishAt) {
+        const error = new Error("El viaje no tiene una publicación programada.");
+        error.status = 404;
+        throw error;
+      }
+
+      // The queued job is left in place; it finds nothing due when it runs
+      const updated = await groupRepository.update(groupId, { publishAt: null });
+      logger.info(`Scheduled publication of group ${groupId} cancelled`);
+      return {
+        success: true,
+        data: this.formatCountdown(updated),
+        message: "Publicación cancelada",
+      };
+    } catch (err) {
+      logger.error(`Error cancelling publication of group ${groupId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Launch status and countdown of a trip. Private trips without a launch
+   * are only visible to their organizer.
+   * @param {string} groupId
+   * @param {string} requesterId
+   * @returns {Promise<Object>} - { success, data }
+   */
+  async getCountdown(groupId, requesterId) {
+    try {
+      const group = await groupRepository.findById(groupId);
+      if (!group || (!group.isPublic && !group.publishAt && group.adminId !== requesterId)) {
+        const error = new Error("Grupo no encontrado");
+        error.status = 404;
+        error.errorCode = "GROUP_NOT_FOUND";
+        throw error;
+      }
+      return { success: true, data: this.formatCountdown(group) };
+    } catch (err) {
+      logger.error(`Error getting countdown of group ${groupId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Publishes a trip if its launch time has passed and notifies the organizer's followers
+   * @param {string} groupId
+   * @returns {Promise<boolean>} true if the trip was published by this call
+   */
+  async publishIfDue(groupId) {
+    const published = await groupRepository.publishScheduled(groupId);
+    if (!published) {
+      return false;
+    }
+
+    logger.info(`Group ${groupId} published by schedule`);
+    const group = await groupRepository.findById(groupId);
+    await searchService.scheduleIndex("trip", groupId);
+    try {
+      await followerNotificationService.queueTripPublished(group.adminId, groupId);
+    } catch (error) {
+      logger.error(`Failed to queue follower notifications for group ${groupId}: ${error.message}`);
+    }
+    return true;
+  }
+
+  /**
+   * Publishes every trip whose launch is overdue (safety net for lost jobs)
+   * @returns {Promise<Object>} - { due, published }
+   */
+  async publishDue() {
+    const groups = await groupRepository.findDueForPublication();
+    let published = 0;
+    for (const group of groups) {
+      try {
+        if (await this.publishIfDue(group.id)) {
+          published++;
+        }
+      } catch (error) {
+        logger.error(`Failed to publish scheduled group ${group.id}: ${error.message}`);
+      }
+    }
+    return { due: groups.length, published };
+  }
+
+  async getGroupAsAdmin(groupId, requesterId) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      const error = new Error("Grupo no encontrado");
+      error.status = 404;
+      error.errorCode = "GROUP_NOT_FOUND";
+      throw error;
+    }
+    if (group.adminId !== requesterId) {
+      const error = new Error("Solo el administrador puede programar la publicación");
+      error.status = 403;
+      throw error;
+    }
+    return group;
+  }
+
+  /**
+   * Launch status: "published", "scheduled" or "draft" (private, no launch set)
+   */
+  formatCountdown(group) {
+    const now = Date.now();
+    const status = group.isPublic ? "published" : group.publishAt ? "scheduled" : "draft";
+    return {
+      groupId: group.id,
+      name: group.name,
+      status,
+      publishAt: group.publishAt ? new Date(group.publishAt).toISOString() : null,
+      publishedAt: group.publishedAt ? new Date(group.publishedAt).toISOString() : null,
+      secondsRemaining:
+        status === "scheduled"
+          ? Math.max(0, Math.ceil((new Date(group.publishAt).getTime() - now) / 1000))
+          : null,
+      serverTime: new Date(now).toISOString(),
+    };
+  }
+}
+
+export default new TripPublicationService();
@@ -0,0 +1,256 @@
+import tripRecommendationRepository from "../repository/tripRecommendation.repository.js";
+import exchangeRateService from "./exchangeRate.service.js";
+import jobQueueService from "./jobQueue.service.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { distanceKm, datesOverlap } from "../utils/travelHistory.js";
+import { TRIP_RECOMMENDATIONS_JOB } from "../jobs/tripRecommendation.job.js";
+
+const HOUR_MS = 60 * 60 * 1000;
+const DAY_MS = 24 * HOUR_MS;
+
+/**
+ * Weight of each signal (each one scores 0 to 1) in a trip's score
+ */
+const WEIGHTS = {
+  destination: 3,
+  followedUsers: 2.5,
+  month: 2,
+  budget: 1.5,
+  duration: 1,
+};
+// A signal scoring at least this much is given as a reason
+const REASON_THRESHOLD = 0.3;
+
+// Same buckets as groups.durationBucket (see groupRepository.refreshTravelDimensions)
+const getDurationBucket = (startDate, endDate) => {
+  const days = Math.round((new Date(endDate) - new Date(startDate)) / DAY_MS) + 1;
+  if (days <= 3) {
+    return "weekend";
+  }
+  return days <= 9 ? "week" : "long";
+};
+
+const hasCoordinates = (place) => Number.isFinite(place.lat) && Number.isFinite(place.lng);
+
+/**
+ * Open trips ranked for a user by their past destinations, the months and
+ * trip lengths they travel, what they paid for trips before and the users
+ * they follow. Rankings are cached per user: a stale one is still served
+ * while a background job computes the next.
+ */
+class TripRecommendationService {
+  /**
+   * Recommended trips of a user, best first
+   * @param {string} userId
+   * @param {Object} params - { limit, offset }
+   * @returns {Promise<Object>} - { trips: [{ ...trip, score, reasons }], total, computedAt, stale }
+   */
+  async getRecommended(userId, { limit = 20, offset = 0 } = {}) {
+    let cached = await tripRecommendationRepository.findByUser(userId);
+    if (!cached) {
+      cached = await this.refreshForUser(userId);
+    }
+    const stale = Date.now() - new Date(cached.computedAt).getTime() > config.tripRecommendations.ttlHours * HOUR_MS;
+    if (stale) {
+      await this.scheduleRefresh(userId);
+    }
+
+    // Trips that filled up, started or were joined since are left out
+    const trips = new Map(
+      (
+        await tripRecommendationRepository.findCandidateTripsByIds(
+          userId,
+          cached.items.map((item) => item.groupId)
+        )
+      ).map((trip) => [trip.id, trip])
+    );
+    const ranked = cached.items
+      .filter((item) => trips.has(item.groupId))
+      .map(({ groupId, score, reasons }) => ({ ...this.formatTrip(trips.get(groupId)), score, reasons }));
+
+    const pageSize = Math.min(Math.max(limit, 1), 100);
+    const start = Math.max(offset, 0);
+    return {
+      trips: ranked.slice(start, start + pageSize),
+      total: ranked.length,
+      computedAt: cached.computedAt,
+      stale,
+    };
+  }
+
+  /**
+   * Computes and stores the ranking of a user
+   * @param {string} userId
+   * @returns {Promise<Object>} The stored recommendations
+   */
+  async refreshForUser(userId) {
+    const [candidates, history] = await Promise.all([
+      tripRecommendationRepository.findCandidateTrips(userId, config.tripRecommendations.candidateLimit),
+      tripRecommendationRepository.findTravelHistory(userId),
+    ]);
+    const profile = await this.buildProfile(history);
+
+    const scored = [];
+    for (const trip of candidates) {
+      // The user is already travelling on those dates
+      if (trip.endDate && profile.upcoming.some((booked) => datesOverlap(booked, trip, 0))) {
+        continue;
+      }
+      scored.push({ groupId: trip.id, ...(await this.scoreTrip(trip, profile)) });
+    }
+    // Candidates come soonest first and the sort is stable, so ties go by date
+    scored.sort((a, b) => b.score - a.score);
+
+    const items = scored.slice(0, config.tripRecommendations.maxItems);
+    logger.info(`[Recommendations] Ranked ${items.length} of ${candidates.length} open trips for user ${userId}`);
+    return await tripRecommendationRepository.save(userId, items);
+  }
+
+  /**
+   * What the travel history of a user says about the trips they like
+   * @param {Array} history - Rows of findTravelHistory
+   * @returns {Promise<Object>} - { destinations, months, durations, trips, budget, upcoming }
+   */
+  async buildProfile(history) {
+    const today = new Date().toISOString().slice(0, 10);
+    const dated = history.filter((trip) => trip.startDate);
+    const months = {};
+    const durations = {};
+    for (const trip of dated) {
+      const month = Number(trip.startDate.slice(5, 7));
+      const bucket = getDurationBucket(trip.startDate, trip.endDate);
+      months[month] = (months[month] || 0) + 1;
+      durations[bucket] = (durations[bucket] || 0) + 1;
+    }
+
+    const prices = [];
+    for (const trip of history) {
+      if (trip.depositAmount && trip.depositCurrency) {
+        const price = await exchangeRateService.convert(
+          trip.depositAmount,
+          trip.depositCurrency,
+          config.expenses.defaultCurrency
+        );
+        if (price) {
+          prices.push(price);
+        }
+      }
+    }
+
+    return {
+      destinations: history.filter(hasCoordinates),
+      months,
+      durations,
+      trips: dated.length,
+      budget: prices.length > 0 ? { min: Math.min(...prices), max: Math.max(...prices) } : null,
+      upcoming: dated.filter((trip) => trip.endDate >= today),
+    };
+  }
+
+  /**
+   * @param {Object} trip - Candidate trip
+   * @param {Object} profile - From buildProfile
+   * @returns {Promise<Object>} - { score, reasons }
+   */
+  async scoreTrip(trip, profile) {
+    const signals = {
+      destination: this.scoreDestination(trip, profile),
+      followedUsers: Math.min(trip.followedCount, 3) / 3,
+      month: profile.trips > 0 && trip.travelMonth ? (profile.months[trip.travelMonth] || 0) / profile.trips : 0,
+      budget: await this.scoreBudget(trip, profile),
+      duration:
+        profile.trips > 0 && trip.durationBucket ? (profile.durations[trip.durationBucket] || 0) / profile.trips : 0,
+    };
+
+    const score = Object.entries(signals).reduce((sum, [signal, value]) => sum + WEIGHTS[signal] * value, 0);
+    return {
+      score: Math.round(score * 100) / 100,
+      reasons: Object.keys(signals).filter((signal) => signals[signal] >= REASON_THRESHOLD),
+    };
+  }
+
+  // 1 at a past destination, down to 0 at destinationRadiusKm from the closest one
+  scoreDestination(trip, profile) {
+    const destination = { lat: trip.destinationLat, lng: trip.destinationLng };
+    if (!hasCoordinates(destination) || profile.destinations.length === 0) {
+      return 0;
+    }
+    const closest = Math.min(...profile.destinations.map((place) => distanceKm(place, destination)));
+    return Math.max(0, 1 - closest / config.tripRecommendations.destinationRadiusKm);
+  }
+
+  // 1 within the range the user paid before, less the further off it is (0 at e times or 1/e of it)
+  async scoreBudget(trip, profile) {
+    if (!profile.budget || !trip.depositAmount || !trip.depositCurrency) {
+      return 0;
+    }
+    const price = await exchangeRateService.convert(
+      trip.depositAmount,
+      trip.depositCurrency,
+      config.expenses.defaultCurrency
+    );
+    if (!price) {
+      return 0;
+    }
+    const { min, max } = profile.budget;
+    if (price >= min && price <= max) {
+      return 1;
+    }
+    return Math.max(0, 1 - Math.abs(Math.log(price / (price < min ? min : max))));
+  }
+
+  /**
+   * Queues a refresh of a user's ranking unless one is pending
+   * @param {string} userId
+   * @returns {Promise<boolean>} Whether a job was queued
+   */
+  async scheduleRefresh(userId) {
+    try {
+      const queuedBefore = new Date(Date.now() - config.tripRecommendations.ttlHours * HOUR_MS);
+      if (!(await tripRecommendationRepository.markRefreshQueued(userId, queuedBefore))) {
+        return false;
+      }
+      await jobQueueService.enqueue(TRIP_RECOMMENDATIONS_JOB, { userId });
+      return true;
+    } catch (error) {
+      // The stale ranking is served meanwhile
+      logger.error(`[Recommendations] Could not queue the refresh of user ${userId}: ${error.message}`);
+      return false;
+    }
+  }
+
+  /**
+   * Daily: queues a refresh for every user with a ranking seen in the last
+   * activeDays, and drops the rankings of the rest
+   * @returns {Promise<Object>} - { queued, dropped }
+   */
+  async scheduleDailyRefresh() {
+    const activeSince = new Date(Date.now() - config.tripRecommendations.activeDays * DAY_MS);
+    const dropped = await tripRecommendationRepository.deleteInactive(activeSince);
+    let queued = 0;
+    for (const userId of await tripRecommendationRepository.findActiveUserIds(activeSince)) {
+      if (await this.scheduleRefresh(userId)) {
+        queued++;
+      }
+    }
+    return { queued, dropped };
+  }
+
+  formatTrip(trip) {
+    return {
+      id: trip.id,
+      name: trip.name,
+      description: trip.description,
+      destinationName: trip.destinationName,
+      startDate: trip.startDate,
+      endDate: trip.endDate,
+      capacity: trip.capacity,
+      memberCount: trip.memberCount,
+      depositAmount: trip.depositAmount,
+      depositCurrency: trip.depositCurrency,
+    };
+  }
+}
+
+export default new TripRecommendationService();
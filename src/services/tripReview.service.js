@@ -0,0 +1,311 @@
+import tripReviewRepository from "../repository/tripReview.repository.js";
+import groupRepository from "../repository/group.repository.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import moderationService from "./moderation.service.js";
+import trustScoreService from "./trustScore.service.js";
+import auditService, { AUDIT_ACTIONS } from "./audit.service.js";
+import { createAndEmitNotification } from "../socket/notification.emitter.js";
+import {
+  AppError,
+  ValidationError,
+  NotFoundError,
+  AuthorizationError,
+} from "../utils/customErrors.js";
+
+const MAX_COMMENT_LENGTH = 1000;
+export const FLAG_REASONS = ["spam", "offensive", "false_information", "other"];
+const MODERATION_STATUSES = ["visible", "hidden"];
+
+const roundRating = (value) => (value === null ? null : Math.round(value * 10) / 10);
+
+/**
+ * Reviews of finished trips: participants rate the trip and its organizer.
+ * Averages come plain and weighted by the trust of each author; users who
+ * rate each other 5 stars as organizers in a cluster (a review ring) are
+ * left out of the weighted ones and listed for moderation.
+ */
+class TripReviewService {
+  /**
+   * Validates ratings and comment
+   * @param {Object} data - { tripRating, organizerRating, comment }
+   * @param {boolean} partial - Only validate the fields present
+   */
+  validate({ tripRating, organizerRating, comment }, partial = false) {
+    const fields = {};
+    for (const [name, value] of Object.entries({ tripRating, organizerRating })) {
+      if (value === undefined && partial) continue;
+      if (!Number.isInteger(value) || value < 1 || value > 5) {
+        throw new ValidationError(`${name} debe ser un entero entre 1 y 5`);
+      }
+      fields[name] = value;
+    }
+    if (comment !== undefined) {
+      if (comment !== null && (typeof comment !== "string" || comment.length > MAX_COMMENT_LENGTH)) {
+        throw new ValidationError(`El comentario no puede superar los ${MAX_COMMENT_LENGTH} caracteres`);
+      }
+      fields.comment = comment ? comment.trim() : null;
+    }
+    return fields;
+  }
+
+  /**
+   * Creates the review of a finished trip (participants only, once per trip)
+   */
+  async createReview(groupId, authorId, data) {
+    const fields = this.validate(data);
+
+    const trip = await tripReviewRepository.findCompletedTripOfParticipant(authorId, groupId);
+    if (!trip) {
+      throw new AuthorizationError("Solo los participantes pueden reseñar un viaje ya finalizado");
+    }
+    if (await tripReviewRepository.findByGroupAndAuthor(groupId, authorId)) {
+      throw new AppError("Ya reseñaste este viaje", 409, "TRIP_REVIEW_EXISTS");
+    }
+
+    const review = await tripReviewRepository.create({
+      groupId,
+      authorId,
+      organizerId: trip.adminId,
+      authorWeight: await trustScoreService.getReviewWeight(authorId),
+      ...fields,
+    });
+
+    try {
+      await createAndEmitNotification({
+        userId: trip.adminId,
+        type: "TRIP_REVIEW_RECEIVED",
+        title: "Nueva reseña de tu viaje",
+        message: `Un participante calificó tu viaje con ${fields.tripRating} estrellas`,
+        data: { groupId, reviewId: review.id },
+      });
+    } catch (notifError) {
+      logger.error(`Error notifying trip review ${review.id}: ${notifError.message}`);
+    }
+
+    logger.info(`Trip review ${review.id} created by ${authorId} for group ${groupId}`);
+    return this.formatReview(await tripReviewRepository.findById(review.id));
+  }
+
+  /**
+   * Visible reviews of a trip with their averages
+   */
+  async getTripReviews(groupId) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      throw new NotFoundError("Grupo no encontrado", "GROUP_NOT_FOUND");
+    }
+
+    const [reviews, averages] = await Promise.all([
+      tripReviewRepository.findVisibleByGroup(groupId),
+      tripReviewRepository.getGroupAverages(groupId),
+    ]);
+    return {
+      averageTripRating: roundRating(averages.tripAverage),
+      averageOrganizerRating: roundRating(averages.organizerAverage),
+      weightedTripRating: roundRating(averages.weightedTripAverage),
+      weightedOrganizerRating: roundRating(averages.weightedOrganizerAverage),
+      count: averages.count,
+      reviews: reviews.map((review) => this.formatReview(review)),
+    };
+  }
+
+  /**
+   * Average rating a user gets as organizer
+   * @returns {Promise<Object>} - { average, weightedAverage, count }
+   */
+  async getOrganizerRating(userId) {
+    const { average, weightedAverage, count } = await tripReviewRepository.getOrganizerAverage(userId);
+    return { average: roundRating(average), weightedAverage: roundRating(weightedAverage), count };
+  }
+
+  /**
+   * Recomputes the weight of every author from their current trust score
+   * (daily maintenance)
+   * @returns {Promise<number>} Authors updated
+   */
+  async refreshAuthorWeights() {
+    const authorIds = await tripReviewRepository.findAuthorIds();
+    for (const authorId of authorIds) {
+      const weight = await trustScoreService.getReviewWeight(authorId).catch((error) => {
+        logger.warn(`Could not compute review weight of ${authorId}: ${error.message}`);
+        return null;
+      });
+      if (weight !== null) {
+        await tripReviewRepository.updateAuthorWeight(authorId, weight);
+      }
+    }
+    return authorIds.length;
+  }
+
+  /**
+   * Finds clusters of users rating each other 5 stars as organizers and
+   * marks their reciprocal reviews as a suspected ring for moderation
+   * (daily maintenance). A cluster needs TRIP_REVIEW_RING_MIN_MEMBERS users.
+   * @returns {Promise<Object>} - { rings, reviewsMarked }
+   */
+  async detectReviewRings() {
+    const pairs = await tripReviewRepository.findReciprocalTopRatings();
+
+    // Union-find over the users of the reciprocal pairs
+    const parents = new Map();
+    const find = (userId) => {
+      while (parents.get(userId) !== userId) {
+        parents.set(userId, parents.get(parents.get(userId)));
+        userId = parents.get(userId);
+      }
+      return userId;
+    };
+    for (const { authorId, organizerId } of pairs) {
+      for (const userId of [authorId, organizerId]) {
+        if (!parents.has(userId)) parents.set(userId, userId);
+      }
+      parents.set(find(authorId), find(organizerId));
+    }
+
+    const clusters = new Map();
+    for (const pair of pairs) {
+      const root = find(pair.authorId);
+      const cluster = clusters.get(root) || { memberIds: new Set(), reviewIds: new Set() };
+      cluster.memberIds.add(pair.authorId).add(pair.organizerId);
+      cluster.reviewIds.add(pair.reviewId);
+      clusters.set(root, cluster);
+    }
+
+    let rings = 0;
+    let reviewsMarked = 0;
+    for (const { memberIds, reviewIds } of clusters.values()) {
+      if (memberIds.size < config.tripReviews.ringMinMembers) continue;
+      const marked = await tripReviewRepository.markSuspectedRing([...reviewIds]);
+      if (!marked.length) continue;
+
+      rings += 1;
+      reviewsMarked += marked.length;
+      await auditService.record({
+        action: AUDIT_ACTIONS.TRIP_REVIEW_RING_SUSPECTED,
+        actorId: null,
+        targetType: "trip_review",
+        targetId: marked[0],
+        metadata: { memberIds: [...memberIds], reviewIds: marked },
+      });
+      logger.warn(`[Reviews] Suspected review ring of ${memberIds.size} users, ${marked.length} reviews marked`);
+    }
+    return { rings, reviewsMarked };
+  }
+
+  async updateReview(reviewId, userId, data) {
+    const review = await this.getReviewOrFail(reviewId);
+    if (review.authorId !== userId) {
+      throw new AuthorizationError("Solo el autor puede editar la reseña");
+    }
+
+    const fields = this.validate(data, true);
+    if (!Object.keys(fields).length) {
+      throw new ValidationError("No hay cambios para guardar", null, "NO_CHANGES");
+    }
+    return this.formatReview(await tripReviewRepository.update(reviewId, fields));
+  }
+
+  /**
+   * Deletes a review (author or platform admin)
+   */
+  async deleteReview(reviewId, user) {
+    const review = await this.getReviewOrFail(reviewId);
+    if (review.authorId !== user.id && user.role !== "admin") {
+      throw new AuthorizationError("No puedes eliminar esta reseña");
+    }
+    await tripReviewRepository.delete(reviewId);
+  }
+
+  /**
+   * Flags a review; enough flags hide it until an admin reviews it
+   * @param {Object} data - { reason, details }
+   */
+  async flagReview(reviewId, userId, { reason, details = null }) {
+    if (!FLAG_REASONS.includes(reason)) {
+      throw new ValidationError(`El motivo debe ser uno de: ${FLAG_REASONS.join(", ")}`);
+    }
+    const review = await this.getReviewOrFail(reviewId);
+    if (review.authorId === userId) {
+      throw new ValidationError("No puedes reportar tu propia reseña");
+    }
+    if (await tripReviewRepository.findFlag(reviewId, userId)) {
+      throw new AppError("Ya reportaste esta reseña", 409, "TRIP_REVIEW_ALREADY_FLAGGED");
+    }
+
+    const flagCount = await tripReviewRepository.addFlag({
+      reviewId,
+      userId,
+      reason,
+      details: details ? String(details).slice(0, 500) : null,
+    });
+    if (flagCount >= config.tripReviews.autoHideFlags && review.status === "visible") {
+      await tripReviewRepository.update(reviewId, { status: "hidden" });
+      logger.info(`Trip review ${reviewId} hidden after ${flagCount} flags`);
+    }
+    await moderationService.evaluateRules("trip_review", reviewId);
+  }
+
+  /**
+   * Flagged reviews for moderation, most flagged first (admin)
+   */
+  async getFlaggedReviews(status = null) {
+    if (status && !MODERATION_STATUSES.includes(status)) {
+      throw new ValidationError("Estado inválido");
+    }
+    const reviews = await tripReviewRepository.findFlagged(status);
+    return Promise.all(
+      reviews.map(async (review) => ({
+        ...this.formatReview(review),
+        authorWeight: Number(review.authorWeight),
+        ringStatus: review.ringStatus,
+        flags: await tripReviewRepository.findFlags(review.id),
+      }))
+    );
+  }
+
+  /**
+   * Shows or hides a review (admin)
+   */
+  async moderateReview(reviewId, status) {
+    if (!MODERATION_STATUSES.includes(status)) {
+      throw new ValidationError(`El estado debe ser uno de: ${MODERATION_STATUSES.join(", ")}`);
+    }
+    const review = await this.getReviewOrFail(reviewId);
+    // Restoring a review clears its flag count so it is only auto-hidden again
+    // by new flags, and a suspected ring so it is not marked again
+    const update = status === "visible" ? { status, flagCount: 0 } : { status };
+    if (status === "visible" && review.ringStatus === "suspected") {
+      update.ringStatus = "cleared";
+    }
+    return this.formatReview(await tripReviewRepository.update(reviewId, update));
+  }
+
+  async getReviewOrFail(reviewId) {
+    const review = await tripReviewRepository.findById(reviewId);
+    if (!review) {
+      throw new NotFoundError("Reseña no encontrada");
+    }
+    return review;
+  }
+
+  formatReview(review) {
+    return {
+      id: review.id,
+      groupId: review.groupId,
+      organizerId: review.organizerId,
+      tripRating: review.tripRating,
+      organizerRating: review.organizerRating,
+      comment: review.comment,
+      status: review.status,
+      flagCount: review.flagCount,
+      createdAt: review.createdAt,
+      updatedAt: review.updatedAt,
+      author: review.author
+        ? { id: review.author.id, name: review.author.name, profilePicture: review.author.profilePicture }
+        : { id: review.authorId },
+    };
+  }
+}
+
+export default new TripReviewService();
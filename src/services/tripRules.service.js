@@ -0,0 +1,246 @@
+import groupRepository from "../repository/group.repository.js";
+import tripRuleAcceptanceRepository from "../repository/tripRuleAcceptance.repository.js";
+import groupJoinRequestRepository from "../repository/groupJoinRequest.repository.js";
+import txManager from "../repository/transactionManager.js";
+import groupJoinRequestService from "./groupJoinRequest.service.js";
+import badgeService from "./badge.service.js";
+import logger from "../config/logger.js";
+import { createAndEmitNotification } from "../socket/notification.emitter.js";
+
+const MAX_RULES_LENGTH = 5000;
+
+/**
+ * House rules / code of conduct of a trip. Joiners accept the current
+ * version before their spot is confirmed; changing the rules asks every
+ * member to accept them again. Acceptances are kept per version, so a new
+ * version starts with nobody but the organizer having accepted it, and
+ * members who have not accepted it cannot write in the group chat.
+ */
+class TripRulesService {
+  /**
+   * Rules of a trip and whether the caller accepted the current version
+   * @param {string} groupId
+   * @param {string} userId
+   * @returns {Promise<Object>} - { success, data }
+   */
+  async getRules(groupId, userId) {
+    try {
+      const group = await this.getGroupOrFail(groupId);
+      const accepted = (await tripRuleAcceptanceRepository.findLatestByUsers(groupId, [userId])).get(userId);
+      return {
+        success: true,
+        data: {
+          ...this.formatRules(group),
+          acceptedVersion: accepted?.version ?? null,
+          acceptedAt: accepted?.acceptedAt ?? null,
+          needsAcceptance: Boolean(group.rules) && accepted?.version !== group.rulesVersion,
+        },
+      };
+    } catch (err) {
+      logger.error(`Error getting rules of group ${groupId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Sets or clears the rules of a trip (admin only). Members other than the
+   * organizer are asked to accept the new version. Clearing them sends the
+   * requests that were waiting on them back to the organizer as pending.
+   * @param {string} groupId
+   * @param {string} requesterId
+   * @param {string|null} rules - null or "" removes them
+   * @returns {Promise<Object>} - { success, data, message }
+   */
+  async updateRules(groupId, requesterId, rules) {
+    try {
+      const group = await this.getGroupOrFail(groupId);
+      if (group.adminId !== requesterId) {
+        const error = new Error("Solo el administrador puede cambiar las normas del viaje");
+        error.status = 403;
+        throw error;
+      }
+      if (rules !== null && typeof rules !== "string") {
+        const error = new Error("Las normas deben ser un texto");
+        error.status = 400;
+        throw error;
+      }
+      const text = rules ? rules.trim() : "";
+      if (text.length > MAX_RULES_LENGTH) {
+        const error = new Error(`Las normas no pueden superar los ${MAX_RULES_LENGTH} caracteres`);
+        error.status = 400;
+        throw error;
+      }
+      if ((text || null) === group.rules) {
+        return { success: true, data: this.formatRules(group), message: "Las normas no cambiaron" };
+      }
+
+      // Under the group lock, so no request moves to awaiting_rules after the
+      // rules are gone
+      const { updated, reopened } = await txManager.run(async () => {
+        await groupRepository.lockForUpdate(groupId);
+        const updated = await groupRepository.update(groupId, {
+          rules: text || null,
+          rulesVersion: group.rulesVersion + 1,
+          rulesUpdatedAt: new Date(),
+        });
+        if (updated.rules) {
+          // The organizer agrees to their own rules
+          await tripRuleAcceptanceRepository.accept(groupId, requesterId, updated.rulesVersion);
+          return { updated, reopened: [] };
+        }
+        // Without rules there is nothing to wait for; the organizer decides again
+        const waiting = await groupJoinRequestRepository.findByGroupId(groupId, "awaiting_rules");
+        for (const request of waiting) {
+          await groupJoinRequestRepository.update(request.id, {
+            status: "pending",
+            decidedById: null,
+            decidedAt: null,
+          });
+        }
+        return { updated, reopened: waiting };
+      });
+      if (updated.rules) {
+        await this.notifyMembers(updated, requesterId);
+      } else if (reopened.length > 0) {
+        badgeService.increment(updated.adminId, "joinRequests", reopened.length);
+      }
+
+      logger.info(`Rules of group ${groupId} set to version ${updated.rulesVersion}`);
+      return {
+        success: true,
+        data: this.formatRules(updated),
+        message: updated.rules ? "Normas actualizadas. Los miembros deberán aceptarlas." : "Normas eliminadas",
+      };
+    } catch (err) {
+      logger.error(`Error updating rules of group ${groupId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Records that the user accepts a version of the rules. It must be the
+   * current one, so nobody agrees to rules they were not shown. A join
+   * request waiting on the acceptance gets its spot confirmed.
+   * @param {string} groupId
+   * @param {string} userId
+   * @param {number} version - rulesVersion the user was shown
+   * @returns {Promise<Object>} - { success, data, message }
+   */
+  async acceptRules(groupId, userId, version) {
+    try {
+      const group = await this.getGroupOrFail(groupId);
+      if (!group.rules) {
+        const error = new Error("El viaje no tiene normas para aceptar");
+        error.status = 400;
+        throw error;
+      }
+      if (version !== group.rulesVersion) {
+        const error = new Error("Las normas cambiaron. Revisa la versión actual antes de aceptarlas");
+        error.status = 409;
+        error.errorCode = "RULES_CHANGED";
+        throw error;
+      }
+
+      const acceptance = await tripRuleAcceptanceRepository.accept(groupId, userId, version);
+      const request = await groupJoinRequestService.confirmAfterRules(groupId, userId);
+
+      return {
+        success: true,
+        data: {
+          version: acceptance.version,
+          acceptedAt: acceptance.acceptedAt,
+          joinRequest: request ? groupJoinRequestService.formatRequest(request) : null,
+        },
+        message: !request
+          ? "Normas aceptadas"
+          : request.status === "approved"
+            ? "Normas aceptadas. ¡Ya eres miembro del grupo!"
+            : "Normas aceptadas. El grupo se completó mientras tanto y quedaste en lista de espera",
+      };
+    } catch (err) {
+      logger.error(`Error accepting rules of group ${groupId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  /**
+   * Which members accepted the current rules, and when (admin only)
+   * @param {string} groupId
+   * @param {string} requesterId
+   * @returns {Promise<Object>} - { success, data: { version, members: [...] } }
+   */
+  async getAcceptances(groupId, requesterId) {
+    try {
+      const group = await this.getGroupOrFail(groupId);
+      if (group.adminId !== requesterId) {
+        const error = new Error("Solo el administrador puede ver quién aceptó las normas");
+        error.status = 403;
+        throw error;
+      }
+      const latest = await tripRuleAcceptanceRepository.findLatestByUsers(
+        groupId,
+        group.members.map((member) => member.id)
+      );
+      return {
+        success: true,
+        data: {
+          version: group.rulesVersion,
+          members: group.members.map((member) => {
+            const accepted = latest.get(member.id);
+            return {
+              id: member.id,
+              name: member.name,
+              acceptedVersion: accepted?.version ?? null,
+              acceptedAt: accepted?.acceptedAt ?? null,
+              acceptedCurrent: !group.rules || accepted?.version === group.rulesVersion,
+            };
+          }),
+        },
+      };
+    } catch (err) {
+      logger.error(`Error getting rule acceptances of group ${groupId}: ${err.message}`);
+      throw err;
+    }
+  }
+
+  async notifyMembers(group, organizerId) {
+    for (const member of group.members || []) {
+      if (member.id === organizerId) {
+        continue;
+      }
+      try {
+        await createAndEmitNotification({
+          userId: member.id,
+          type: "TRIP_RULES_UPDATED",
+          title: "Cambiaron las normas del viaje",
+          message: `El organizador actualizó las normas de "${group.name}". Revísalas y acéptalas`,
+          data: { groupId: group.id, groupName: group.name, rulesVersion: group.rulesVersion },
+        });
+      } catch (notifError) {
+        logger.error(`Error notifying rules change of group ${group.id}: ${notifError.message}`);
+      }
+    }
+  }
+
+  async getGroupOrFail(groupId) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      const error = new Error("Grupo no encontrado");
+      error.status = 404;
+      error.errorCode = "GROUP_NOT_FOUND";
+      throw error;
+    }
+    return group;
+  }
+
+  formatRules(group) {
+    return {
+      groupId: group.id,
+      rules: group.rules,
+      version: group.rulesVersion,
+      updatedAt: group.rulesUpdatedAt,
+    };
+  }
+}
+
+export default new TripRulesService();
@@ -0,0 +1,83 @@
+import UserRepository from "../repository/user.repository.js";
+import companionReferenceRepository from "../repository/companionReference.repository.js";
+import { NotFoundError } from "../utils/customErrors.js";
+
+/**
+ * Points per signal and their caps. Confirmed companion references weigh the
+ * most since they need two people vouching for the same trip.
+ */
+const TRUST_WEIGHTS = {
+  emailConfirmed: { points: 10, max: 10 },
+  profilePicture: { points: 5, max: 5 },
+  accountAgeMonths: { points: 1, max: 10 },
+  completedTrips: { points: 5, max: 25 },
+  confirmedReferences: { points: 10, max: 50 },
+};
+
+const MS_PER_MONTH = 30 * 24 * 60 * 60 * 1000;
+
+// Weight of a review by a brand-new or unverified account in weighted averages
+const MIN_REVIEW_WEIGHT = 0.2;
+
+class TrustScoreService {
+  constructor() {
+    this.userRepository = new UserRepository();
+  }
+
+  /**
+   * Computes the 0-100 trust score of a user
+   * @param {string} userId
+   * @returns {Promise<Object>} - { score, breakdown }
+   */
+  async getTrustScore(userId) {
+    const user = await this.userRepository.findById(userId);
+    if (!user) {
+      throw new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND");
+    }
+
+    const [completedTrips, confirmedReferences] = await Promise.all([
+      companionReferenceRepository.countCompletedTrips(userId),
+      companionReferenceRepository.countConfirmedAuthors(userId),
+    ]);
+
+    return this.computeScore({
+      emailConfirmed: user.isEmailConfirmed ? 1 : 0,
+      profilePicture: user.profilePicture ? 1 : 0,
+      accountAgeMonths: Math.floor((Date.now() - new Date(user.createdAt).getTime()) / MS_PER_MONTH),
+      completedTrips,
+      confirmedReferences,
+    });
+  }
+
+  /**
+   * Weight of a user's reviews in weighted averages: from MIN_REVIEW_WEIGHT
+   * up to 1 with their trust score, the minimum until they confirm their email
+   * @param {string} userId
+   * @returns {Promise<number>}
+   */
+  async getReviewWeight(userId) {
+    const { score, breakdown } = await this.getTrustScore(userId);
+    if (!breakdown.emailConfirmed.value) {
+      return MIN_REVIEW_WEIGHT;
+    }
+    return Math.round((MIN_REVIEW_WEIGHT + ((1 - MIN_REVIEW_WEIGHT) * score) / 100) * 100) / 100;
+  }
+
+  /**
+   * @param {Object} signals - Count of each signal in TRUST_WEIGHTS
+   * @returns {Object} - { score, breakdown: { [signal]: { value, points } } }
+   */
+  computeScore(signals) {
+    const breakdown = {};
+    let score = 0;
+    for (const [signal, { points, max }] of Object.entries(TRUST_WEIGHTS)) {
+      const value = signals[signal] || 0;
+      const earned = Math.min(value * points, max);
+      breakdown[signal] = { value, points: earned };
+      score += earned;
+    }
+    return { score, breakdown };
+  }
+}
+
+export default new TrustScoreService();
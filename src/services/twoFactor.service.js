@@ -0,0 +1,237 @@
+import crypto from "crypto";
+import jwt from "jsonwebtoken";
+import UserRepository from "../repository/user.repository.js";
+import auditService, { AUDIT_ACTIONS } from "./audit.service.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { encrypt, decrypt, parseKey } from "../utils/encryption.js";
+import { generateSecret, verifyTotp, buildProvisioningUri } from "../utils/totp.js";
+import { AppError, ValidationError, AuthenticationError } from "../utils/customErrors.js";
+
+const userRepository = new UserRepository();
+
+const BACKUP_CODE_COUNT = 10;
+const CHALLENGE_PURPOSE = "2fa";
+
+const hashBackupCode = (code) =>
+  crypto.createHash("sha256").update(code.toUpperCase().replace(/[^A-Z0-9]/g, "")).digest("hex");
+
+/**
+ * Two-factor authentication with TOTP authenticator apps. Secrets are
+ * stored encrypted with TWO_FACTOR_ENCRYPTION_KEY; backup codes only as
+ * hashes. Accounts with 2FA log in in two steps: the password returns a
+ * short-lived challenge token that a code exchanges for the session.
+ */
+class TwoFactorService {
+  constructor() {
+    this.key = null;
+  }
+
+  getKey() {
+    if (!config.twoFactor.encryptionKey) {
+      throw new AppError(
+        "La verificación en dos pasos no está disponible en este momento.",
+        503,
+        "TWO_FACTOR_UNAVAILABLE"
+      );
+    }
+    if (!this.key) {
+      this.key = parseKey(config.twoFactor.encryptionKey);
+    }
+    return this.key;
+  }
+
+  /**
+   * Whether admin routes need a session that went through 2FA
+   * @param {Object} user - { role }
+   */
+  isRequiredFor(user) {
+    return config.twoFactor.enforceForAdmins && user.role === "admin";
+  }
+
+  /**
+   * Starts enrollment: a new secret to add to the authenticator app. It is
+   * not active until a code from the app is confirmed with enable().
+   * @param {string} userId
+   * @returns {Promise<Object>} - { secret, otpauthUrl }
+   */
+  async startEnrollment(userId) {
+    const key = this.getKey();
+    const user = await userRepository.findById(userId);
+    if (user.twoFactorEnabledAt) {
+      throw new AppError("La verificación en dos pasos ya está activa.", 409, "TWO_FACTOR_ALREADY_ENABLED");
+    }
+    const secret = generateSecret();
+    await userRepository.update(userId, {
+      twoFactorSecret: encrypt(secret, key),
+      twoFactorLastStep: null,
+    });
+    return {
+      secret,
+      otpauthUrl: buildProvisioningUri({ issuer: config.twoFactor.issuer, account: user.email, secret }),
+    };
+  }
+
+  /**
+   * Turns 2FA on once the user proves the app generates valid codes
+   * @param {string} userId
+   * @param {string} code - Current code of the app
+   * @returns {Promise<Object>} - { backupCodes } shown only this once
+   */
+  async enable(userId, code) {
+    const key = this.getKey();
+    const user = await userRepository.findWithTwoFactorSecrets(userId);
+    if (user.twoFactorEnabledAt) {
+      throw new AppError("La verificación en dos pasos ya está activa.", 409, "TWO_FACTOR_ALREADY_ENABLED");
+    }
+    if (!user.twoFactorSecret) {
+      throw new ValidationError("Primero inicia la configuración de la verificación en dos pasos.");
+    }
+    const step = verifyTotp(decrypt(user.twoFactorSecret, key), code);
+    if (step === null || !(await userRepository.consumeTwoFactorStep(userId, step))) {
+      throw new AppError("Código inválido.", 400, "INVALID_TWO_FACTOR_CODE");
+    }
+
+    const backupCodes = this.generateBackupCodes();
+    await userRepository.update(userId, {
+      twoFactorEnabledAt: new Date(),
+      twoFactorBackupCodes: backupCodes.map(hashBackupCode),
+    });
+    logger.info(`Two-factor authentication enabled for user ${userId}`);
+    await auditService.record({ action: AUDIT_ACTIONS.TWO_FACTOR_ENABLED, targetType: "user", targetId: userId });
+    return { backupCodes };
+  }
+
+  /**
+   * Turns 2FA off; needs a valid code (or backup code)
+   * @param {string} userId
+   * @param {string} code
+   */
+  async disable(userId, code) {
+    const user = await this.getEnabledUser(userId);
+    if (!(await this.verifyCode(user, code))) {
+      throw new AppError("Código inválido.", 400, "INVALID_TWO_FACTOR_CODE");
+    }
+    await userRepository.update(userId, {
+      twoFactorSecret: null,
+      twoFactorEnabledAt: null,
+      twoFactorBackupCodes: null,
+      twoFactorLastStep: null,
+    });
+    logger.info(`Two-factor authentication disabled for user ${userId}`);
+    await auditService.record({ action: AUDIT_ACTIONS.TWO_FACTOR_DISABLED, targetType: "user", targetId: userId });
+  }
+
+  /**
+   * Replaces the backup codes; needs a code of the app
+   * @param {string} userId
+   * @param {string} code
+   * @returns {Promise<Object>} - { backupCodes }
+   */
+  async regenerateBackupCodes(userId, code) {
+    const user = await this.getEnabledUser(userId);
+    if ((await this.verifyCode(user, code, { allowBackup: false })) !== "totp") {
+      throw new AppError("Código inválido.", 400, "INVALID_TWO_FACTOR_CODE");
+    }
+    const backupCodes = this.generateBackupCodes();
+    await userRepository.update(userId, { twoFactorBackupCodes: backupCodes.map(hashBackupCode) });
+    return { backupCodes };
+  }
+
+  /**
+   * Whether 2FA is on and how many backup codes are left
+   * @param {string} userId
+   * @returns {Promise<Object>} - { enabled, enabledAt, backupCodesLeft, required }
+   */
+  async getStatus(userId) {
+    const user = await userRepository.findWithTwoFactorSecrets(userId);
+    return {
+      enabled: Boolean(user.twoFactorEnabledAt),
+      enabledAt: user.twoFactorEnabledAt,
+      backupCodesLeft: user.twoFactorEnabledAt ? (user.twoFactorBackupCodes || []).length : 0,
+      required: this.isRequiredFor(user),
+    };
+  }
+
+  /**
+   * Token for the second login step
+   * @param {Object} user
+   * @returns {string}
+   */
+  createChallenge(user) {
+    return jwt.sign({ id: user.id, purpose: CHALLENGE_PURPOSE }, config.jwt.secret, {
+      expiresIn: `${config.twoFactor.challengeMinutes}m`,
+    });
+  }
+
+  /**
+   * Second login step
+   * @param {string} challengeToken - From the password (or provider) step
+   * @param {string} code - Code of the app or a backup code
+   * @returns {Promise<Object>} - { user, method: "totp" | "backup" }
+   */
+  async completeChallenge(challengeToken, code) {
+    let decoded;
+    try {
+      decoded = jwt.verify(challengeToken, config.jwt.secret);
+    } catch {
+      throw new AuthenticationError("La verificación expiró. Inicia sesión de nuevo.", "TWO_FACTOR_CHALLENGE_EXPIRED");
+    }
+    if (decoded.purpose !== CHALLENGE_PURPOSE) {
+      throw new AuthenticationError("La verificación expiró. Inicia sesión de nuevo.", "TWO_FACTOR_CHALLENGE_EXPIRED");
+    }
+    const user = await userRepository.findWithTwoFactorSecrets(decoded.id);
+    if (!user || user.deletedAt || !user.twoFactorEnabledAt) {
+      throw new AuthenticationError("Credenciales inválidas.", "INVALID_CREDENTIALS");
+    }
+    if (user.suspendedAt) {
+      throw new AppError("Tu cuenta está suspendida.", 403, "ACCOUNT_SUSPENDED");
+    }
+    const method = await this.verifyCode(user, code);
+    if (!method) {
+      throw new AuthenticationError("Código inválido.");
+    }
+    if (method === "backup") {
+      logger.info(`User ${user.id} logged in with a backup code`);
+    }
+    return { user, method };
+  }
+
+  /**
+   * Checks a code of the app (each one works once) or a backup code (consumed)
+   * @returns {Promise<string|null>} "totp", "backup" or null
+   */
+  async verifyCode(user, code, { allowBackup = true } = {}) {
+    if (typeof code !== "string" || !code.trim()) {
+      return null;
+    }
+    const step = verifyTotp(decrypt(user.twoFactorSecret, this.getKey()), code.trim());
+    if (step !== null) {
+      return (await userRepository.consumeTwoFactorStep(user.id, step)) ? "totp" : null;
+    }
+    if (allowBackup && (await userRepository.consumeBackupCode(user.id, hashBackupCode(code)))) {
+      return "backup";
+    }
+    return null;
+  }
+
+  async getEnabledUser(userId) {
+    const user = await userRepository.findWithTwoFactorSecrets(userId);
+    if (!user.twoFactorEnabledAt) {
+      throw new AppError("La verificación en dos pasos no está activa.", 409, "TWO_FACTOR_NOT_ENABLED");
+    }
+    return user;
+  }
+
+  /**
+   * Backup codes like "3F9A-C21B"
+   */
+  generateBackupCodes() {
+    return Array.from({ length: BACKUP_CODE_COUNT }, () => {
+      const code = crypto.randomBytes(4).toString("hex").toUpperCase();
+      return `${code.slice(0, 4)}-${code.slice(4)}`;
+    });
+  }
+}
+
+export default new TwoFactorService();
@@ -0,0 +1,283 @@
+import apiUsageRepository from "../repository/apiUsage.repository.js";
+import aggregatorRepository from "../repository/aggregator.repository.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { ValidationError, NotFoundError } from "../utils/customErrors.js";
+
+export const USAGE_SUBJECTS = ["user", "api_key"];
+
+const MAX_DAYS = 400;
+const MAX_TOP = 100;
+// Stored cost of today is re-read at most this often per subject
+const TODAY_CACHE_MS = 60 * 1000;
+const MONTH_REGEX = /^(\d{4})-(0[1-9]|1[0-2])$/;
+const UUID_REGEX = /^[0-9a-f]{8}-[0-9a-f]{4}-[1-8][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/i;
+
+const utcDay = (date = new Date()) => date.toISOString().slice(0, 10);
+
+const addDays = (day, days) => {
+  const date = new Date(`${day}T00:00:00Z`);
+  date.setUTCDate(date.getUTCDate() + days);
+  return utcDay(date);
+};
+
+/**
+ * Request counts and compute-weighted costs per user and per API key.
+ * Requests are counted in memory and added to the daily aggregates every
+ * USAGE_FLUSH_SECONDS (and on shutdown), so metering never slows a request
+ * down; quotas read the stored total plus what is still buffered.
+ */
+class UsageService {
+  constructor() {
+    this.pending = new Map();
+    this.todayCache = new Map();
+    this.timer = null;
+    this.flushing = null;
+  }
+
+  start() {
+    if (!config.usage.enabled || this.timer) return;
+    this.timer = setInterval(() => {
+      this.flush().catch(() => {});
+    }, config.usage.flushSeconds * 1000);
+    this.timer.unref();
+  }
+
+  async stop() {
+    clearInterval(this.timer);
+    this.timer = null;
+    await this.flush();
+  }
+
+  /**
+   * Counts a finished request
+   * @param {Object} entry - { subjectType, subjectId, cost, failed }
+   */
+  record({ subjectType, subjectId, cost, failed = false }) {
+    if (!config.usage.enabled) return;
+    this.addPending({ subjectType, subjectId, day: utcDay(), requests: 1, costUnits: cost, errors: failed ? 1 : 0 });
+  }
+
+  /**
+   * Writes the buffered counters; on failure they go back to the buffer for
+   * the next attempt
+   */
+  async flush() {
+    if (this.flushing) return this.flushing;
+    if (this.pending.size === 0) return;
+    const rows = [...this.pending.values()];
+    this.pending = new Map();
+    this.flushing = (async () => {
+      try {
+        await apiUsageRepository.addBatch(rows);
+        for (const row of rows) {
+          const cached = this.todayCache.get(`${row.subjectType}:${row.subjectId}`);
+          if (cached && cached.day === row.day) {
+            cached.costUnits += row.costUnits;
+          }
+        }
+        // Entries past their refresh would be re-read anyway
+        for (const [key, cached] of this.todayCache) {
+          if (Date.now() - cached.fetchedAt > TODAY_CACHE_MS) this.todayCache.delete(key);
+        }
+      } catch (error) {
+        logger.error(`[Usage] Could not store ${rows.length} usage counters: ${error.message}`);
+        rows.forEach((row) => this.addPending(row));
+        throw error;
+      } finally {
+        this.flushing = null;
+      }
+    })();
+    return this.flushing;
+  }
+
+  /**
+   * Cost units a subject spent today (UTC), buffered requests included
+   * @param {string} subjectType
+   * @param {string} subjectId
+   * @returns {Promise<number>}
+   */
+  async getTodayCost(subjectType, subjectId) {
+    const day = utcDay();
+    const cacheKey = `${subjectType}:${subjectId}`;
+    let cached = this.todayCache.get(cacheKey);
+    if (!cached || cached.day !== day || Date.now() - cached.fetchedAt > TODAY_CACHE_MS) {
+      const stored = await apiUsageRepository.findDay(subjectType, subjectId, day);
+      cached = { day, costUnits: stored?.costUnits ?? 0, fetchedAt: Date.now() };
+      this.todayCache.set(cacheKey, cached);
+    }
+    const buffered = this.pending.get(`${subjectType}:${subjectId}:${day}`);
+    return cached.costUnits + (buffered?.costUnits ?? 0);
+  }
+
+  /**
+   * Checks a subject against a daily cost quota
+   * @param {string} subjectType
+   * @param {string} subjectId
+   * @param {number|null} limit - null or 0 for no limit
+   * @returns {Promise<Object|null>} - { limit, remaining, resetAt, exceeded }, null without a limit
+   */
+  async checkCostQuota(subjectType, subjectId, limit) {
+    if (!config.usage.enabled || !limit) return null;
+    const used = await this.getTodayCost(subjectType, subjectId);
+    const resetAt = new Date();
+    resetAt.setUTCHours(24, 0, 0, 0);
+    return { limit, remaining: Math.max(limit - used, 0), resetAt, exceeded: used >= limit };
+  }
+
+  /**
+   * Daily usage of a subject over the last days, with totals
+   * @param {string} subjectType
+   * @param {string} subjectId
+   * @param {Object} options - { days, dailyCostQuota }
+   */
+  async getUsage(subjectType, subjectId, { days = 30, dailyCostQuota = null } = {}) {
+    const span = this.validateDays(days);
+    const to = utcDay();
+    const rows = await apiUsageRepository.findRange(subjectType, subjectId, addDays(to, 1 - span), to);
+    const buffered = this.pending.get(`${subjectType}:${subjectId}:${to}`);
+    if (buffered) {
+      const today = rows.find((row) => row.day === to);
+      if (today) {
+        today.requests += buffered.requests;
+        today.costUnits += buffered.costUnits;
+        today.errors += buffered.errors;
+      } else {
+        rows.unshift({ day: to, requests: buffered.requests, costUnits: buffered.costUnits, errors: buffered.errors });
+      }
+    }
+    const quota = await this.checkCostQuota(subjectType, subjectId, dailyCostQuota);
+    return {
+      subjectType,
+      subjectId,
+      days: rows,
+      totals: this.sumRows(rows),
+      quota: quota && { dailyCostQuota: quota.limit, remainingToday: quota.remaining, resetAt: quota.resetAt },
+    };
+  }
+
+  /**
+   * Usage of the requesting user, with the user cost quota if there is one
+   */
+  async getUserUsage(user, days) {
+    const quota = user.role === "admin" ? null : config.usage.userDailyCostQuota;
+    return await this.getUsage("user", user.id, { days, dailyCostQuota: quota });
+  }
+
+  /**
+   * Usage of any subject, for admins
+   */
+  async getSubjectUsage(subjectType, subjectId, days) {
+    if (!USAGE_SUBJECTS.includes(subjectType)) {
+      throw new ValidationError(`subjectType debe ser uno de: ${USAGE_SUBJECTS.join(", ")}`);
+    }
+    if (!UUID_REGEX.test(subjectId)) {
+      throw new ValidationError("subjectId inválido");
+    }
+    return await this.getUsage(subjectType, subjectId, { days });
+  }
+
+  /**
+   * Users and API keys with the highest cost over the last days
+   * @param {Object} filters - { subjectType, days, limit }
+   */
+  async getTopConsumers({ subjectType, days = 1, limit = 20 } = {}) {
+    if (subjectType !== undefined && !USAGE_SUBJECTS.includes(subjectType)) {
+      throw new ValidationError(`subjectType debe ser uno de: ${USAGE_SUBJECTS.join(", ")}`);
+    }
+    const span = this.validateDays(days);
+    const top = Number(limit);
+    if (!Number.isInteger(top) || top < 1 || top > MAX_TOP) {
+      throw new ValidationError(`limit debe ser un entero entre 1 y ${MAX_TOP}`);
+    }
+    const to = utcDay();
+    const from = addDays(to, 1 - span);
+    await this.flush();
+    return { from, to, consumers: await apiUsageRepository.findTop({ subjectType, from, to, limit: top }) };
+  }
+
+  /**
+   * Billing summary of an aggregator for a calendar month (UTC)
+   * @param {string} partnerId
+   * @param {string} month - YYYY-MM, the current month by default
+   */
+  async getPartnerBilling(partnerId, month = utcDay().slice(0, 7)) {
+    const match = typeof month === "string" ? month.match(MONTH_REGEX) : null;
+    if (!match) {
+      throw new ValidationError("month debe tener el formato YYYY-MM");
+    }
+    const partner = UUID_REGEX.test(partnerId) ? await aggregatorRepository.findById(partnerId) : null;
+    if (!partner) {
+      throw new NotFoundError("Agregador no encontrado");
+    }
+    const from = `${month}-01`;
+    const to = addDays(utcDay(new Date(Date.UTC(Number(match[1]), Number(match[2]), 1))), -1);
+    await this.flush();
+    const rows = await apiUsageRepository.findRange("api_key", partnerId, from, to);
+    const totals = this.sumRows(rows);
+    const pricePerThousandUnits =
+      partner.pricePerThousandUnits !== null && partner.pricePerThousandUnits !== undefined
+        ? Number(partner.pricePerThousandUnits)
+        : config.usage.defaultPricePerThousandUnits;
+    return {
+      partnerId,
+      partnerName: partner.name,
+      month,
+      from,
+      to,
+      days: rows,
+      totals,
+      pricePerThousandUnits,
+      currency: config.usage.billingCurrency,
+      amount: Math.round((totals.costUnits / 1000) * pricePerThousandUnits * 100) / 100,
+    };
+  }
+
+  /**
+   * Deletes daily aggregates past the retention (USAGE_RETENTION_MONTHS)
+   * @returns {Promise<number>} Rows deleted
+   */
+  async purgeExpired() {
+    const cutoff = new Date();
+    cutoff.setUTCDate(1);
+    cutoff.setUTCMonth(cutoff.getUTCMonth() - config.usage.retentionMonths);
+    const deleted = await apiUsageRepository.deleteBefore(utcDay(cutoff));
+    if (deleted > 0) {
+      logger.info(`Purged ${deleted} usage aggregates older than ${config.usage.retentionMonths} months`);
+    }
+    return deleted;
+  }
+
+  addPending({ subjectType, subjectId, day, requests, costUnits, errors }) {
+    const key = `${subjectType}:${subjectId}:${day}`;
+    const counters = this.pending.get(key);
+    if (counters) {
+      counters.requests += requests;
+      counters.costUnits += costUnits;
+      counters.errors += errors;
+    } else {
+      this.pending.set(key, { subjectType, subjectId, day, requests, costUnits, errors });
+    }
+  }
+
+  validateDays(value) {
+    const days = Number(value);
+    if (!Number.isInteger(days) || days < 1 || days > MAX_DAYS) {
+      throw new ValidationError(`days debe ser un entero entre 1 y ${MAX_DAYS}`);
+    }
+    return days;
+  }
+
+  sumRows(rows) {
+    return rows.reduce(
+      (totals, row) => ({
+        requests: totals.requests + row.requests,
+        costUnits: totals.costUnits + row.costUnits,
+        errors: totals.errors + row.errors,
+      }),
+      { requests: 0, costUnits: 0, errors: 0 }
+    );
+  }
+}
+
+export default new UsageService();
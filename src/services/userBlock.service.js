@@ -0,0 +1,91 @@
+import userBlockRepository from "../repository/userBlock.repository.js";
+import directMessageRepository from "../repository/directMessage.repository.js";
+import UserRepository from "../repository/user.repository.js";
+import UserFollowerRepository from "../repository/userFollower.repository.js";
+import badgeService from "./badge.service.js";
+import logger from "../config/logger.js";
+import { AppError, ValidationError, NotFoundError } from "../utils/customErrors.js";
+
+const userRepository = new UserRepository();
+const userFollowerRepository = new UserFollowerRepository();
+
+/**
+ * Blocks between users. A block works both ways: neither user sees the
+ * other's trips, messages or profile, and they cannot message each other.
+ */
+class UserBlockService {
+  /**
+   * Blocks a user, removing the follows between both
+   * @param {string} blockerId
+   * @param {string} blockedId
+   */
+  async blockUser(blockerId, blockedId) {
+    if (blockerId === blockedId) {
+      throw new ValidationError("No puedes bloquearte a ti mismo");
+    }
+    const user = await userRepository.findById(blockedId);
+    if (!user) {
+      throw new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND");
+    }
+    if (await userBlockRepository.findOne(blockerId, blockedId)) {
+      throw new AppError("Ya bloqueaste a este usuario", 409, "USER_ALREADY_BLOCKED");
+    }
+
+    await userBlockRepository.create(blockerId, blockedId);
+    await Promise.all([
+      userFollowerRepository.unfollow(blockerId, blockedId),
+      userFollowerRepository.unfollow(blockedId, blockerId),
+      // Pending messages from the blocked user would otherwise stay in the unread badge
+      directMessageRepository.markAsRead(
+        directMessageRepository.createConversationId(blockerId, blockedId),
+        blockerId
+      ),
+    ]);
+    badgeService.invalidate(blockerId);
+    logger.info(`User ${blockerId} blocked ${blockedId}`);
+  }
+
+  async unblockUser(blockerId, blockedId) {
+    const removed = await userBlockRepository.delete(blockerId, blockedId);
+    if (!removed) {
+      throw new NotFoundError("No tienes bloqueado a este usuario");
+    }
+    logger.info(`User ${blockerId} unblocked ${blockedId}`);
+  }
+
+  /**
+   * Users blocked by someone
+   */
+  async getBlockedUsers(blockerId) {
+    const blocks = await userBlockRepository.findBlockedBy(blockerId);
+    return blocks.map((block) => ({
+      id: block.blocked.id,
+      name: block.blocked.name,
+      profilePicture: block.blocked.profilePicture,
+      blockedAt: block.createdAt,
+    }));
+  }
+
+  /**
+   * Whether either user blocked the other
+   */
+  async isBlockedBetween(userId, otherUserId) {
+    if (!userId || !otherUserId || userId === otherUserId) {
+      return false;
+    }
+    return await userBlockRepository.existsBetween(userId, otherUserId);
+  }
+
+  /**
+   * Users whose content is hidden from someone (blocked in either direction)
+   * @returns {Promise<Set<string>>}
+   */
+  async getHiddenUserIds(userId) {
+    if (!userId) {
+      return new Set();
+    }
+    return new Set(await userBlockRepository.findHiddenUserIds(userId));
+  }
+}
+
+export default new UserBlockService();
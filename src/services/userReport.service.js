@@ -0,0 +1,74 @@
+import userReportRepository from "../repository/userReport.repository.js";
+import groupRepository from "../repository/group.repository.js";
+import UserRepository from "../repository/user.repository.js";
+import moderationService from "./moderation.service.js";
+import logger from "../config/logger.js";
+import { AppError, ValidationError, NotFoundError } from "../utils/customErrors.js";
+
+const userRepository = new UserRepository();
+
+export const REPORT_REASONS = [
+  "harassment",
+  "spam",
+  "scam",
+  "inappropriate_content",
+  "fake_profile",
+  "safety_concern",
+  "other",
+];
+const TARGET_TYPES = ["user", "trip"];
+
+/**
+ * Reports of users and trips. Administrators review them through the admin API.
+ */
+class UserReportService {
+  /**
+   * Reports a user or a trip
+   * @param {string} reporterId
+   * @param {Object} data - { targetType, targetId, reason, details }
+   * @returns {Promise<Object>} Created report
+   */
+  async createReport(reporterId, { targetType, targetId, reason, details = null }) {
+    if (!TARGET_TYPES.includes(targetType)) {
+      throw new ValidationError(`targetType debe ser uno de: ${TARGET_TYPES.join(", ")}`);
+    }
+    if (!REPORT_REASONS.includes(reason)) {
+      throw new ValidationError(`El motivo debe ser uno de: ${REPORT_REASONS.join(", ")}`);
+    }
+    if (details && (typeof details !== "string" || details.length > 1000)) {
+      throw new ValidationError("Los detalles no pueden superar los 1000 caracteres");
+    }
+
+    let targetUserId = targetId;
+    let targetGroupId = null;
+    if (targetType === "trip") {
+      const group = await groupRepository.findById(targetId);
+      if (!group) {
+        throw new NotFoundError("Viaje no encontrado", "TRIP_NOT_FOUND");
+      }
+      targetUserId = group.adminId;
+      targetGroupId = group.id;
+    } else if (!(await userRepository.findById(targetId))) {
+      throw new NotFoundError("Usuario no encontrado", "USER_NOT_FOUND");
+    }
+    if (targetUserId === reporterId) {
+      throw new ValidationError("No puedes reportarte a ti mismo", null, "SELF_REPORT");
+    }
+
+    const target = { reporterId, targetType, targetUserId, targetGroupId };
+    if (await userReportRepository.findOpenDuplicate(target)) {
+      throw new AppError("Ya reportaste esto; lo estamos revisando", 409, "REPORT_ALREADY_OPEN");
+    }
+
+    const report = await userReportRepository.create({
+      ...target,
+      reason,
+      details: details ? details.trim() : null,
+    });
+    logger.info(`Report ${report.id} opened by ${reporterId} on ${targetType} ${targetId}`);
+    await moderationService.evaluateRules(targetType, targetGroupId || targetUserId);
+    return { id: report.id, status: report.status, createdAt: report.createdAt };
+  }
+}
+
+export default new UserReportService();
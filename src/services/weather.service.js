@@ -0,0 +1,163 @@
+import weatherProvider from "../clients/weather.client.js";
+import redisClient from "../clients/redis.client.js";
+import groupRepository from "../repository/group.repository.js";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import { ExternalServiceError, NotFoundError } from "../utils/customErrors.js";
+
+const DAY_MS = 24 * 60 * 60 * 1000;
+// In-process cache used without Redis
+const MAX_LOCAL_ENTRIES = 1000;
+
+// Two decimals (about 1 km): trips to the same town share one forecast
+const keyFor = (lat, lng) => `weather:${weatherProvider.name}:${lat.toFixed(2)}:${lng.toFixed(2)}`;
+
+const addDays = (date, days) =>
+  new Date(new Date(`${date}T00:00:00Z`).getTime() + days * DAY_MS).toISOString().slice(0, 10);
+
+/**
+ * Forecasts for trip destinations. Each destination is fetched at most once
+ * per TTL (the provider's Expires when longer than WEATHER_CACHE_TTL_MINUTES)
+ * and shared by every trip going there; the cache lives in Redis when
+ * REDIS_URL is set, in memory otherwise. When the provider fails a forecast
+ * up to WEATHER_STALE_HOURS old is served instead.
+ */
+class WeatherService {
+  constructor() {
+    this.local = new Map();
+    // key -> Promise, so concurrent requests for a destination share one call
+    this.inFlight = new Map();
+  }
+
+  /**
+   * Forecast for the destination of a trip over its dates. Without dates the
+   * whole forecast is returned; trips past the forecast horizon get
+   * available: false and the date the forecast starts covering them.
+   * @param {string} groupId
+   * @returns {Promise<Object>} - { available, reason, availableFrom, destination, tripStartDate,
+   *   tripEndDate, provider, fetchedAt, stale, days }
+   */
+  async getTripWeather(groupId) {
+    const group = await groupRepository.findById(groupId);
+    if (!group) {
+      throw new NotFoundError("Grupo no encontrado", "GROUP_NOT_FOUND");
+    }
+    const hasCoordinates = group.destinationLat !== null && group.destinationLng !== null;
+    const base = {
+      destination: hasCoordinates
+        ? { name: group.destinationName, lat: Number(group.destinationLat), lng: Number(group.destinationLng) }
+        : null,
+      tripStartDate: group.tripStartDate,
+      tripEndDate: group.tripEndDate,
+      provider: weatherProvider.name,
+    };
+    if (!hasCoordinates) {
+      return { ...base, available: false, reason: "no_destination", days: [] };
+    }
+
+    const today = new Date().toISOString().slice(0, 10);
+    const horizonEnd = addDays(today, weatherProvider.horizonDays);
+    if (group.tripEndDate && group.tripEndDate < today) {
+      return { ...base, available: false, reason: "trip_ended", days: [] };
+    }
+    if (group.tripStartDate && group.tripStartDate > horizonEnd) {
+      return {
+        ...base,
+        available: false,
+        reason: "too_far",
+        availableFrom: addDays(group.tripStartDate, -weatherProvider.horizonDays),
+        days: [],
+      };
+    }
+
+    const forecast = await this.getForecast(base.destination.lat, base.destination.lng);
+    const from = group.tripStartDate && group.tripStartDate > today ? group.tripStartDate : today;
+    const days = forecast.days.filter(
+      (day) => day.date >= from && (!group.tripEndDate || day.date <= group.tripEndDate)
+    );
+    return {
+      ...base,
+      available: days.length > 0,
+      reason: days.length > 0 ? null : "no_data",
+      fetchedAt: forecast.fetchedAt,
+      stale: forecast.stale,
+      days,
+    };
+  }
+
+  /**
+   * Forecast of a point, from the cache while fresh
+   * @returns {Promise<Object>} - { days, fetchedAt, stale }
+   */
+  async getForecast(lat, lng) {
+    const key = keyFor(lat, lng);
+    const cached = await this.read(key);
+    if (cached && new Date(cached.freshUntil) > new Date()) {
+      return { days: cached.days, fetchedAt: cached.fetchedAt, stale: false };
+    }
+
+    if (!this.inFlight.has(key)) {
+      this.inFlight.set(
+        key,
+        this.refresh(key, lat, lng).finally(() => this.inFlight.delete(key))
+      );
+    }
+    try {
+      return await this.inFlight.get(key);
+    } catch (error) {
+      if (cached) {
+        logger.warn(`[Weather] ${weatherProvider.name} failed for ${key}, serving stale forecast: ${error.message}`);
+        return { days: cached.days, fetchedAt: cached.fetchedAt, stale: true };
+      }
+      logger.error(`[Weather] ${weatherProvider.name} failed for ${key}: ${error.message}`);
+      throw new ExternalServiceError("El pronóstico del clima no está disponible en este momento");
+    }
+  }
+
+  async refresh(key, lat, lng) {
+    const { days, expiresAt } = await weatherProvider.fetchForecast(lat, lng);
+    const now = Date.now();
+    const ttlMs = Math.max(config.weather.cacheTtlMinutes * 60 * 1000, (expiresAt?.getTime() ?? 0) - now);
+    const entry = {
+      days,
+      fetchedAt: new Date(now).toISOString(),
+      freshUntil: new Date(now + ttlMs).toISOString(),
+    };
+    await this.write(key, entry, Math.ceil(ttlMs / 1000) + config.weather.staleHours * 3600);
+    logger.debug(`[Weather] ${key} fetched, fresh for ${Math.round(ttlMs / 60000)} min`);
+    return { days, fetchedAt: entry.fetchedAt, stale: false };
+  }
+
+  async read(key) {
+    if (!redisClient.isConfigured()) {
+      const entry = this.local.get(key);
+      return entry && entry.expiresAt > Date.now() ? entry.value : null;
+    }
+    try {
+      const value = await redisClient.command("GET", key);
+      return value ? JSON.parse(value) : null;
+    } catch (error) {
+      logger.warn(`[Weather] Cache read failed for ${key}: ${error.message}`);
+      return null;
+    }
+  }
+
+  async write(key, value, ttlSeconds) {
+    if (!redisClient.isConfigured()) {
+      this.local.delete(key);
+      if (this.local.size >= MAX_LOCAL_ENTRIES) {
+        // Oldest insertion first
+        this.local.delete(this.local.keys().next().value);
+      }
+      this.local.set(key, { value, expiresAt: Date.now() + ttlSeconds * 1000 });
+      return;
+    }
+    try {
+      await redisClient.command("SET", key, JSON.stringify(value), "EX", ttlSeconds);
+    } catch (error) {
+      logger.warn(`[Weather] Cache write failed for ${key}: ${error.message}`);
+    }
+  }
+}
+
+export default new WeatherService();
@@ -1,13 +1,15 @@
 import notificationService from "../services/notification.service.js";
+import pushService from "../services/push.service.js";
 import logger from "../config/logger.js";
-import { getIoInstance } from "./socket.instance.js";
+import txManager from "../repository/transactionManager.js";
+import { publish } from "./realtime.js";
 
 /**
  * Creates a notification and emits it via Socket.io
- * @param {Object} notificationData - Notification data
+ * @param {Object} notificationData - Notification data; priority "critical" skips quiet hours
  * @returns {Promise<Object>} Created notification
  */
-export const createAndEmitNotification = async (notificationData) => {
+export const createAndEmitNotification = async ({ priority = "normal", ...notificationData }) => {
   const startTime = Date.now();
   try {
     logger.info(
@@ -23,18 +25,26 @@ export const createAndEmitNotification = async (notificationData) => {
       `[Notification Emitter] ✓ Notification created in DB: ${notification.id} (${dbTime - startTime}ms)`
     );
 
-    // Get io instance and emit notification to user via socket
-    const io = getIoInstance();
-    logger.info(
-      `[Notification Emitter] Got io instance, emitting to room: ${notificationData.userId}`
-    );
+    // Inside a transaction the notification is only delivered once it commits
+    await txManager.afterCommit(() => {
+      // Emit notification to the user's sockets and long polls
+      publish(notificationData.userId, "new_notification", notification);
 
-    io.to(notificationData.userId).emit("new_notification", notification);
+      const emitTime = Date.now();
+      logger.info(
+        `[Notification Emitter] ✓ Notification emitted to user ${notificationData.userId}: ${notificationData.type} (${emitTime - dbTime}ms total: ${emitTime - startTime}ms)`
+      );
+
+      // Push delivery runs in the background so slow providers never block the caller
+      setImmediate(() => {
+        pushService.dispatch(notification, { priority }).catch((pushError) => {
+          logger.error(
+            `[Notification Emitter] ✗ Push dispatch failed for notification ${notification.id}: ${pushError.message}`
+          );
+        });
+      });
+    });
 
-    const emitTime = Date.now();
-    logger.info(
-      `[Notification Emitter] ✓ Notification emitted to user ${notificationData.userId}: ${notificationData.type} (${emitTime - dbTime}ms total: ${emitTime - startTime}ms)`
-    );
     return notification;
   } catch (error) {
     const errorTime = Date.now();
@@ -0,0 +1,211 @@
+import crypto from "crypto";
+import pg from "pg";
+import config from "../config/index.js";
+import logger from "../config/logger.js";
+import realtimeEventRepository, { REALTIME_CHANNEL } from "../repository/realtimeEvent.repository.js";
+import { getIoInstance } from "./socket.instance.js";
+
+// Tells this process's events apart from those of other instances
+const ORIGIN = `${process.pid}-${crypto.randomBytes(4).toString("hex")}`;
+const SWEEP_INTERVAL_MS = 30 * 1000;
+const RECONNECT_DELAY_MS = 5 * 1000;
+
+/**
+ * Realtime fan-out. Events go to the Socket.io room and into the
+ * realtime_events table, which long-polling clients (GET /api/poll) read
+ * from, so both transports see the same events in the same order whichever
+ * instance published them. Every API instance LISTENs on REALTIME_CHANNEL
+ * for events published elsewhere (another instance, the worker) to send them
+ * to its own sockets and wake its held polls.
+ */
+class RealtimeHub {
+  constructor() {
+    this.waiters = new Set();
+    this.listener = null;
+    this.sweeper = null;
+    this.reconnectTimer = null;
+    this.stopped = true;
+  }
+
+  /**
+   * Sends an event to a room (a user ID or group_<groupId>)
+   * @param {string} room
+   * @param {string} event
+   * @param {*} data
+   */
+  publish(room, event, data) {
+    try {
+      getIoInstance().to(room).emit(event, data);
+    } catch (error) {
+      // No socket server in this process (worker, CLI); API instances relay the event
+      logger.debug(`[Realtime] Socket emit skipped for ${event}: ${error.message}`);
+    }
+    realtimeEventRepository
+      .create({ room, event, data, origin: ORIGIN })
+      .then(() => this.wake(room))
+      .catch((error) => {
+        logger.error(`[Realtime] Error storing ${event}: ${error.message}`);
+      });
+  }
+
+  wake(room) {
+    for (const waiter of this.waiters) {
+      if (waiter.rooms.has(room)) {
+        waiter.wake();
+      }
+    }
+  }
+
+  /**
+   * Cursor pointing at the latest event, for clients starting to poll
+   */
+  async currentCursor() {
+    const { latest } = await realtimeEventRepository.getBounds();
+    return String(latest);
+  }
+
+  /**
+   * @returns {number|null} ID in a cursor, null when it is not one
+   */
+  parseCursor(cursor) {
+    if (typeof cursor !== "string" || !/^\d{1,15}$/.test(cursor)) return null;
+    return Number(cursor);
+  }
+
+  /**
+   * Stored events of some rooms after a cursor
+   * @param {Set<string>} rooms
+   * @param {number} afterId
+   * @param {number} limit
+   * @returns {Promise<Object>} - { events, reset, hasMore, cursor }
+   */
+  async read(rooms, afterId, limit) {
+    const roomList = [...rooms];
+    const { oldest, latest } = await realtimeEventRepository.getBounds();
+    // Past the latest event (a cursor from a wiped table), events swept after
+    // the cursor, or more in a room than the buffer keeps
+    if (
+      afterId > latest ||
+      afterId < oldest - 1 ||
+      (await realtimeEventRepository.anyRoomOver(roomList, afterId, config.realtime.pollBufferPerRoom))
+    ) {
+      return { events: [], reset: true, hasMore: false, cursor: String(latest) };
+    }
+    const found = await realtimeEventRepository.findAfter(roomList, afterId, limit + 1);
+    const events = found.slice(0, limit);
+    const hasMore = found.length > limit;
+    return {
+      events: events.map(({ id, event, data, createdAt }) => ({ id: Number(id), event, data, at: createdAt })),
+      reset: false,
+      hasMore,
+      // Without more events the cursor moves to the present: nothing in these rooms was missed
+      cursor: hasMore ? String(events[events.length - 1].id) : String(latest),
+    };
+  }
+
+  /**
+   * Registers a held poll; wake() is called when an event reaches its rooms
+   * @param {Object} waiter - { rooms: Set, wake: Function }
+   * @returns {Function} Removes the waiter
+   */
+  wait(waiter) {
+    this.waiters.add(waiter);
+    return () => this.waiters.delete(waiter);
+  }
+
+  get waiterCount() {
+    return this.waiters.size;
+  }
+
+  /**
+   * Listens for events of other processes and sweeps old ones (API process)
+   */
+  async start() {
+    this.stopped = false;
+    await this.listen();
+    if (!this.sweeper) {
+      this.sweeper = setInterval(() => {
+        realtimeEventRepository.deleteOlderThan(config.realtime.pollBufferSeconds).catch((error) => {
+          logger.warn(`[Realtime] Error sweeping events: ${error.message}`);
+        });
+      }, SWEEP_INTERVAL_MS);
+      this.sweeper.unref();
+    }
+  }
+
+  async stop() {
+    this.stopped = true;
+    clearInterval(this.sweeper);
+    clearTimeout(this.reconnectTimer);
+    this.sweeper = null;
+    const listener = this.listener;
+    this.listener = null;
+    await listener?.end().catch(() => {});
+  }
+
+  // A dedicated connection: LISTEN does not work through the pool
+  async listen() {
+    const client = new pg.Client({
+      host: config.db.host,
+      port: config.db.port,
+      user: config.db.user,
+      password: config.db.password,
+      database: config.db.name,
+    });
+    client.on("notification", (message) => this.onNotification(message));
+    client.on("error", (error) => this.reconnect(client, error));
+    client.on("end", () => this.reconnect(client, new Error("connection ended")));
+    await client.connect();
+    await client.query(`LISTEN ${REALTIME_CHANNEL}`);
+    this.listener = client;
+  }
+
+  onNotification(message) {
+    let payload;
+    try {
+      payload = JSON.parse(message.payload);
+    } catch {
+      return;
+    }
+    // This process emitted the event and woke its own pollers when it stored it
+    if (payload.origin === ORIGIN) return;
+    this.wake(payload.room);
+    this.relay(payload.id).catch((error) => {
+      logger.error(`[Realtime] Error relaying event ${payload.id} to sockets: ${error.message}`);
+    });
+  }
+
+  async relay(id) {
+    const stored = await realtimeEventRepository.findById(id);
+    if (stored) {
+      getIoInstance().to(stored.room).emit(stored.event, stored.data);
+    }
+  }
+
+  // Held polls still time out and read the table meanwhile; socket clients miss
+  // what other processes publish until the listener is back
+  reconnect(client, error) {
+    if (this.stopped || this.listener !== client || this.reconnectTimer) return;
+    this.listener = null;
+    client.end().catch(() => {});
+    logger.warn(`[Realtime] Lost the event listener, reconnecting: ${error.message}`);
+    const retry = () => {
+      this.reconnectTimer = setTimeout(() => {
+        this.reconnectTimer = null;
+        if (this.stopped) return;
+        this.listen().catch((listenError) => {
+          logger.warn(`[Realtime] Error reconnecting the event listener: ${listenError.message}`);
+          retry();
+        });
+      }, RECONNECT_DELAY_MS);
+      this.reconnectTimer.unref();
+    };
+    retry();
+  }
+}
+
+const realtimeHub = new RealtimeHub();
+
+export const publish = (room, event, data) => realtimeHub.publish(room, event, data);
+
+export default realtimeHub;
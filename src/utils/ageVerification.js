@@ -0,0 +1,100 @@
+import config from "../config/index.js";
+
+const DATE_REGEX = /^\d{4}-\d{2}-\d{2}$/;
+const COUNTRY_REGEX = /^[A-Z]{2}$/;
+
+/**
+ * Parses a YYYY-MM-DD date of birth
+ * @param {string} value
+ * @returns {Date|null} - null when the format or date is invalid or in the future
+ */
+export const parseDateOfBirth = (value) => {
+  if (typeof value !== "string" || !DATE_REGEX.test(value)) {
+    return null;
+  }
+  const date = new Date(`${value}T00:00:00Z`);
+  if (isNaN(date.getTime()) || date.toISOString().slice(0, 10) !== value || date > new Date()) {
+    return null;
+  }
+  return date;
+};
+
+/**
+ * Normalizes an ISO 3166-1 alpha-2 country code
+ * @returns {string|null} - null when invalid
+ */
+export const normalizeCountry = (value) => {
+  if (typeof value !== "string") {
+    return null;
+  }
+  const country = value.trim().toUpperCase();
+  return COUNTRY_REGEX.test(country) ? country : null;
+};
+
+/**
+ * Age in completed years at a given date
+ * @param {Date|string} dateOfBirth
+ * @param {Date} now
+ */
+export const calculateAge = (dateOfBirth, now = new Date()) => {
+  const dob = new Date(dateOfBirth);
+  let age = now.getUTCFullYear() - dob.getUTCFullYear();
+  const hadBirthday =
+    now.getUTCMonth() > dob.getUTCMonth() ||
+    (now.getUTCMonth() === dob.getUTCMonth() && now.getUTCDate() >= dob.getUTCDate());
+  if (!hadBirthday) {
+    age -= 1;
+  }
+  return age;
+};
+
+/**
+ * Age rule of a jurisdiction, falling back to the default one
+ * @param {string|null} country
+ * @returns {Object} - { minimumAge, mode }
+ */
+export const getAgeRule = (country = null) => {
+  const { rules } = config.ageVerification;
+  const rule = (country && rules[country]) || rules.default || {};
+  return {
+    minimumAge: rule.minimumAge ?? 18,
+    mode: rule.mode === "gate" ? "gate" : "block",
+  };
+};
+
+/**
+ * Applies the jurisdiction rules to a date of birth
+ * @returns {Object} - { age, minimumAge, blocked, restricted }
+ */
+export const evaluateAge = (dateOfBirth, country = null) => {
+  const age = calculateAge(dateOfBirth);
+  const { minimumAge, mode } = getAgeRule(country);
+  const underage = age < minimumAge;
+
+  return {
+    age,
+    minimumAge,
+    blocked: age < config.ageVerification.absoluteMinimumAge || (underage && mode === "block"),
+    restricted: underage && mode === "gate",
+  };
+};
+
+/**
+ * Current age of a user: from the date of birth, else the age declared by
+ * accounts created before it was required
+ * @param {Object} user - { dateOfBirth, age }
+ * @returns {number|null}
+ */
+export const getUserAge = (user) =>
+  user.dateOfBirth ? calculateAge(user.dateOfBirth) : user.age ?? null;
+
+/**
+ * Whether an account has restricted features: under the minimum age of a
+ * "gate" jurisdiction today (lifted on its own once of age), or restricted
+ * by an admin age review
+ * @param {Object} user - { dateOfBirth, country, ageRestricted }
+ * @returns {boolean}
+ */
+export const isAgeRestricted = (user) =>
+  Boolean(user.ageRestricted) ||
+  Boolean(user.dateOfBirth && evaluateAge(user.dateOfBirth, user.country).restricted);
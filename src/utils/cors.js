@@ -0,0 +1,64 @@
+/**
+ * Origin matching for the CORS policy (config.cors)
+ */
+
+const escapeRegex = (text) => text.replace(/[.+?^${}()|[\]\\]/g, "\\$&");
+
+/**
+ * Turns an origin pattern into a regular expression. "*." at the start of the
+ * host matches one or more subdomain labels (not the bare domain); ":*" at the
+ * end matches any port.
+ * @param {string} pattern - e.g. "https://*.jointravel.app", "*.jointravel.app", "http://localhost:*"
+ * @returns {RegExp}
+ */
+export const compileOriginPattern = (pattern) => {
+  const withScheme = pattern.includes("://") ? pattern : `https://${pattern}`;
+  const source = escapeRegex(withScheme.toLowerCase().replace(/\/+$/, ""))
+    .replace("://*\\.", "://(?:[a-z0-9-]+\\.)+")
+    .replace(/:\*$/, "(?::\\d+)?");
+  if (source.includes("*")) {
+    throw new Error(`Invalid CORS origin pattern: ${pattern}`);
+  }
+  return new RegExp(`^${source}$`);
+};
+
+/**
+ * @param {string[]} patterns - Allowed origins ("*" allows any)
+ * @returns {Function} - (origin) => boolean
+ */
+export const createOriginMatcher = (patterns) => {
+  if (patterns.includes("*")) {
+    return () => true;
+  }
+  const regexes = patterns.map(compileOriginPattern);
+  return (origin) => regexes.some((regex) => regex.test(origin.toLowerCase()));
+};
+
+/**
+ * Options for the cors middleware and Socket.io. Allowed origins are echoed
+ * back (never "*"), so credentials work with wildcard patterns too. Requests
+ * without an Origin header (server to server, same origin) are not affected.
+ * @param {Object} corsConfig - config.cors
+ * @param {Function} [isExtraOrigin] - async (origin) => boolean, for origins known at runtime
+ * @returns {Object}
+ */
+export const buildCorsOptions = ({ origins, credentials, maxAgeSeconds, exposedHeaders }, isExtraOrigin = null) => {
+  const isAllowed = createOriginMatcher(origins);
+  return {
+    origin: (origin, callback) => {
+      if (!origin || isAllowed(origin)) {
+        return callback(null, true);
+      }
+      if (!isExtraOrigin) {
+        return callback(null, false);
+      }
+      isExtraOrigin(origin).then(
+        (allowed) => callback(null, allowed),
+        () => callback(null, false)
+      );
+    },
+    credentials,
+    maxAge: maxAgeSeconds,
+    exposedHeaders,
+  };
+};
@@ -1,5 +1,8 @@
 /**
- * Custom error classes for specific error types
+ * Custom error classes for specific error types. The errorCode tells clients
+ * (and the catalogs in src/locales) which error it is; pass a specific one,
+ * e.g. new NotFoundError("Grupo no encontrado", "GROUP_NOT_FOUND"), when the
+ * generic code of the class is not enough.
  */
 
 export class AppError extends Error {
@@ -14,49 +17,60 @@ export class AppError extends Error {
 }
 
 export class ValidationError extends AppError {
-  constructor(message, details = null) {
-    super(message, 400, 'VALIDATION_ERROR', details);
+  constructor(message, details = null, errorCode = 'VALIDATION_ERROR') {
+    super(message, 400, errorCode, details);
   }
 }
 
 export class AuthenticationError extends AppError {
-  constructor(message = 'Authentication failed') {
-    super(message, 401, 'AUTHENTICATION_ERROR');
+  constructor(message = 'Authentication failed', errorCode = 'AUTHENTICATION_ERROR') {
+    super(message, 401, errorCode);
   }
 }
 
 export class AuthorizationError extends AppError {
-  constructor(message = 'Access denied') {
-    super(message, 403, 'AUTHORIZATION_ERROR');
+  constructor(message = 'Access denied', errorCode = 'AUTHORIZATION_ERROR') {
+    super(message, 403, errorCode);
   }
 }
 
 export class NotFoundError extends AppError {
-  constructor(message = 'Resource not found') {
-    super(message, 404, 'NOT_FOUND_ERROR');
+  constructor(message = 'Resource not found', errorCode = 'NOT_FOUND_ERROR') {
+    super(message, 404, errorCode);
   }
 }
 
 export class DatabaseError extends AppError {
-  constructor(message = 'Database operation failed') {
-    super(message, 500, 'DATABASE_ERROR');
+  constructor(message = 'Database operation failed', errorCode = 'DATABASE_ERROR') {
+    super(message, 500, errorCode);
   }
 }
 
 export class ConnectionTimeoutError extends AppError {
-  constructor(message = 'Connection timeout') {
-    super(message, 408, 'CONNECTION_TIMEOUT');
+  constructor(message = 'Connection timeout', errorCode = 'CONNECTION_TIMEOUT') {
+    super(message, 408, errorCode);
   }
 }
 
 export class ExternalServiceError extends AppError {
-  constructor(message = 'External service error') {
-    super(message, 502, 'EXTERNAL_SERVICE_ERROR');
+  constructor(message = 'External service error', errorCode = 'EXTERNAL_SERVICE_ERROR') {
+    super(message, 502, errorCode);
+  }
+}
+
+export class CircuitOpenError extends AppError {
+  constructor(message = 'External service temporarily unavailable', errorCode = 'CIRCUIT_OPEN') {
+    super(message, 503, errorCode);
   }
 }
 
 export class RateLimitError extends AppError {
-  constructor(message = 'Too many requests') {
-    super(message, 429, 'RATE_LIMIT_ERROR');
+  constructor(message = 'Too many requests', errorCode = 'RATE_LIMIT_ERROR') {
+    super(message, 429, errorCode);
   }
-}
\ No newline at end of file
+}
+export class DeadlineExceededError extends AppError {
+  constructor(message = 'Request deadline exceeded', errorCode = 'DEADLINE_EXCEEDED') {
+    super(message, 504, errorCode);
+  }
+}
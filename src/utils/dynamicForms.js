@@ -0,0 +1,287 @@
+import { ValidationError } from "./customErrors.js";
+
+/**
+ * Forms the apps render from a schema served by the backend, and what the
+ * answers of each one belong to
+ */
+export const FORMS = {
+  onboarding: { subjectType: "user" },
+  trip_creation: { subjectType: "group" },
+};
+
+export const FORM_LANGUAGES = ["es", "en", "pt"];
+export const DEFAULT_FORM_LANGUAGE = "es";
+
+export const FIELD_TYPES = ["text", "textarea", "number", "integer", "boolean", "date", "select", "multiselect"];
+
+const FIELD_ID_PATTERN = /^[a-z][a-z0-9_]{1,49}$/;
+const DATE_PATTERN = /^\d{4}-\d{2}-\d{2}$/;
+const MAX_FIELDS = 50;
+const MAX_OPTIONS = 100;
+const MAX_LABEL_LENGTH = 200;
+const MAX_TEXT_LENGTH = 5000;
+
+const TEXT_TYPES = ["text", "textarea"];
+const NUMBER_TYPES = ["number", "integer"];
+const CHOICE_TYPES = ["select", "multiselect"];
+
+// Validation rules each field type accepts
+const RULES_BY_TYPE = {
+  text: ["minLength", "maxLength", "pattern"],
+  textarea: ["minLength", "maxLength"],
+  number: ["min", "max"],
+  integer: ["min", "max"],
+  boolean: [],
+  date: [],
+  select: [],
+  multiselect: ["minItems", "maxItems"],
+};
+
+/**
+ * Localized text: { es, en?, pt? }, Spanish required
+ */
+const validateText = (text, name, { required = true } = {}) => {
+  if (text === undefined || text === null) {
+    if (required) {
+      throw new ValidationError(`${name} es requerido`);
+    }
+    return null;
+  }
+  if (typeof text !== "object" || Array.isArray(text)) {
+    throw new ValidationError(`${name} debe ser un objeto con un texto por idioma`);
+  }
+  const result = {};
+  for (const [language, value] of Object.entries(text)) {
+    if (!FORM_LANGUAGES.includes(language)) {
+      throw new ValidationError(`${name}: idioma no soportado ${language}`);
+    }
+    if (typeof value !== "string" || !value.trim() || value.length > MAX_LABEL_LENGTH) {
+      throw new ValidationError(`${name}.${language} debe tener entre 1 y ${MAX_LABEL_LENGTH} caracteres`);
+    }
+    result[language] = value.trim();
+  }
+  if (!result[DEFAULT_FORM_LANGUAGE]) {
+    throw new ValidationError(`${name}.${DEFAULT_FORM_LANGUAGE} es requerido`);
+  }
+  return result;
+};
+
+const isRegExp = (source) => {
+  try {
+    new RegExp(source);
+    return true;
+  } catch {
+    return false;
+  }
+};
+
+const validateRules = (field, validation) => {
+  if (validation === undefined || validation === null) {
+    return {};
+  }
+  if (typeof validation !== "object" || Array.isArray(validation)) {
+    throw new ValidationError(`${field.id}: validation debe ser un objeto`);
+  }
+  const allowed = RULES_BY_TYPE[field.type];
+  const rules = {};
+  for (const [rule, value] of Object.entries(validation)) {
+    if (!allowed.includes(rule)) {
+      throw new ValidationError(`${field.id}: la regla ${rule} no aplica a campos ${field.type}`);
+    }
+    if (rule === "pattern") {
+      if (typeof value !== "string" || !value || value.length > MAX_LABEL_LENGTH || !isRegExp(value)) {
+        throw new ValidationError(`${field.id}: pattern no es una expresión regular válida`);
+      }
+    } else if (typeof value !== "number" || !Number.isFinite(value)) {
+      throw new ValidationError(`${field.id}: ${rule} debe ser un número`);
+    } else if (rule !== "min" && rule !== "max" && (!Number.isInteger(value) || value < 0)) {
+      throw new ValidationError(`${field.id}: ${rule} debe ser un entero no negativo`);
+    }
+    rules[rule] = value;
+  }
+  for (const [low, high] of [
+    ["min", "max"],
+    ["minLength", "maxLength"],
+    ["minItems", "maxItems"],
+  ]) {
+    if (rules[low] !== undefined && rules[high] !== undefined && rules[low] > rules[high]) {
+      throw new ValidationError(`${field.id}: ${low} no puede ser mayor que ${high}`);
+    }
+  }
+  return rules;
+};
+
+const validateOptions = (field, options) => {
+  if (!CHOICE_TYPES.includes(field.type)) {
+    if (options !== undefined && options !== null) {
+      throw new ValidationError(`${field.id}: solo los campos select y multiselect tienen opciones`);
+    }
+    return undefined;
+  }
+  if (!Array.isArray(options) || options.length === 0 || options.length > MAX_OPTIONS) {
+    throw new ValidationError(`${field.id}: options debe tener entre 1 y ${MAX_OPTIONS} opciones`);
+  }
+  const values = new Set();
+  return options.map((option, index) => {
+    const value = option?.value;
+    if (typeof value !== "string" || !FIELD_ID_PATTERN.test(value)) {
+      throw new ValidationError(
+        `${field.id}: options[${index}].value debe usar minúsculas, números y _ (2 a 50 caracteres)`
+      );
+    }
+    if (values.has(value)) {
+      throw new ValidationError(`${field.id}: la opción ${value} está repetida`);
+    }
+    values.add(value);
+    return { value, label: validateText(option.label, `${field.id}.options.${value}.label`) };
+  });
+};
+
+/**
+ * Checks and normalizes the field definitions of a schema version
+ * @param {Array} fields - [{ id, type, required, label, help, options, validation }]
+ * @returns {Array} Normalized fields, in the order the apps show them
+ */
+export const validateFieldDefinitions = (fields) => {
+  if (!Array.isArray(fields) || fields.length === 0 || fields.length > MAX_FIELDS) {
+    throw new ValidationError(`fields debe tener entre 1 y ${MAX_FIELDS} campos`);
+  }
+  const ids = new Set();
+  return fields.map((definition, index) => {
+    const id = definition?.id;
+    if (typeof id !== "string" || !FIELD_ID_PATTERN.test(id)) {
+      throw new ValidationError(
+        `fields[${index}].id debe usar minúsculas, números y _ (2 a 50 caracteres)`
+      );
+    }
+    if (ids.has(id)) {
+      throw new ValidationError(`El campo ${id} está repetido`);
+    }
+    ids.add(id);
+    if (!FIELD_TYPES.includes(definition.type)) {
+      throw new ValidationError(`${id}: type debe ser uno de: ${FIELD_TYPES.join(", ")}`);
+    }
+    if (definition.required !== undefined && typeof definition.required !== "boolean") {
+      throw new ValidationError(`${id}: required debe ser booleano`);
+    }
+    const field = { id, type: definition.type, required: definition.required ?? false };
+    field.label = validateText(definition.label, `${id}.label`);
+    const help = validateText(definition.help, `${id}.help`, { required: false });
+    if (help) {
+      field.help = help;
+    }
+    const options = validateOptions(field, definition.options);
+    if (options) {
+      field.options = options;
+    }
+    field.validation = validateRules(field, definition.validation);
+    return field;
+  });
+};
+
+const localize = (text, language) => (text ? text[language] ?? text[DEFAULT_FORM_LANGUAGE] : undefined);
+
+/**
+ * Fields with their labels in one language, falling back to Spanish
+ * @param {Array} fields
+ * @param {string} language
+ */
+export const localizeFields = (fields, language) =>
+  fields.map(({ label, help, options, ...field }) => ({
+    ...field,
+    label: localize(label, language),
+    ...(help ? { help: localize(help, language) } : {}),
+    ...(options
+      ? { options: options.map((option) => ({ value: option.value, label: localize(option.label, language) })) }
+      : {}),
+  }));
+
+/**
+ * Message of the first rule an answer breaks, or null when it is valid
+ */
+const checkAnswer = (field, value) => {
+  const rules = field.validation || {};
+  if (TEXT_TYPES.includes(field.type)) {
+    if (typeof value !== "string") return "debe ser un texto";
+    if (value.length > MAX_TEXT_LENGTH) return `admite hasta ${MAX_TEXT_LENGTH} caracteres`;
+    if (rules.minLength !== undefined && value.length < rules.minLength) return `debe tener al menos ${rules.minLength} caracteres`;
+    if (rules.maxLength !== undefined && value.length > rules.maxLength) return `admite hasta ${rules.maxLength} caracteres`;
+    if (rules.pattern && !new RegExp(rules.pattern).test(value)) return "no tiene el formato esperado";
+    return null;
+  }
+  if (NUMBER_TYPES.includes(field.type)) {
+    if (typeof value !== "number" || !Number.isFinite(value)) return "debe ser un número";
+    if (field.type === "integer" && !Number.isInteger(value)) return "debe ser un número entero";
+    if (rules.min !== undefined && value < rules.min) return `debe ser mayor o igual a ${rules.min}`;
+    if (rules.max !== undefined && value > rules.max) return `debe ser menor o igual a ${rules.max}`;
+    return null;
+  }
+  if (field.type === "boolean") {
+    return typeof value === "boolean" ? null : "debe ser booleano";
+  }
+  if (field.type === "date") {
+    const valid = typeof value === "string" && DATE_PATTERN.test(value) && !Number.isNaN(new Date(value).getTime());
+    return valid ? null : "debe ser una fecha YYYY-MM-DD";
+  }
+  const values = field.options.map((option) => option.value);
+  if (field.type === "select") {
+    return values.includes(value) ? null : `debe ser una de: ${values.join(", ")}`;
+  }
+  if (!Array.isArray(value) || value.some((item) => !values.includes(item))) {
+    return `debe ser una lista con valores de: ${values.join(", ")}`;
+  }
+  if (new Set(value).size !== value.length) return "tiene valores repetidos";
+  if (rules.minItems !== undefined && value.length < rules.minItems) return `requiere al menos ${rules.minItems} opciones`;
+  if (rules.maxItems !== undefined && value.length > rules.maxItems) return `admite hasta ${rules.maxItems} opciones`;
+  return null;
+};
+
+const isEmpty = (value) =>
+  value === undefined || value === null || value === "" || (Array.isArray(value) && value.length === 0);
+
+/**
+ * Merges new answers into the stored ones and checks the result against the
+ * fields of a schema version. A null answer clears the field; answers to
+ * fields no longer in the schema are kept as they were.
+ * @param {Array} fields - Fields of the schema version
+ * @param {Object} current - Stored answers by field ID
+ * @param {Object} changes - New answers by field ID
+ * @returns {Object} Merged answers by field ID
+ */
+export const mergeAnswers = (fields, current, changes) => {
+  if (!changes || typeof changes !== "object" || Array.isArray(changes)) {
+    throw new ValidationError("answers debe ser un objeto con las respuestas por ID de campo");
+  }
+  const byId = new Map(fields.map((field) => [field.id, field]));
+  const errors = {};
+  const answers = { ...current };
+  for (const [id, value] of Object.entries(changes)) {
+    const field = byId.get(id);
+    if (!field) {
+      errors[id] = "no es un campo del formulario";
+      continue;
+    }
+    if (isEmpty(value)) {
+      delete answers[id];
+      continue;
+    }
+    const problem = checkAnswer(field, typeof value === "string" ? value.trim() : value);
+    if (problem) {
+      errors[id] = problem;
+    } else {
+      answers[id] = typeof value === "string" ? value.trim() : value;
+    }
+  }
+  for (const field of fields) {
+    if (field.required && isEmpty(answers[field.id]) && !errors[field.id]) {
+      errors[field.id] = "es obligatorio";
+    }
+  }
+  if (Object.keys(errors).length > 0) {
+    const summary = Object.entries(errors)
+      .map(([id, problem]) => `${id} ${problem}`)
+      .join("; ");
+    throw new ValidationError(`Respuestas inválidas: ${summary}`, errors);
+  }
+  return answers;
+};
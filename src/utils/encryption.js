@@ -0,0 +1,47 @@
+import crypto from "crypto";
+
+const ALGORITHM = "aes-256-gcm";
+const VERSION = "v1";
+
+/**
+ * 32-byte key from its base64 or hex form
+ * @param {string} key
+ * @returns {Buffer}
+ */
+export const parseKey = (key) => {
+  const buffer = /^[0-9a-f]{64}$/i.test(key) ? Buffer.from(key, "hex") : Buffer.from(key, "base64");
+  if (buffer.length !== 32) {
+    throw new Error("Encryption keys must be 32 bytes (base64 or hex)");
+  }
+  return buffer;
+};
+
+/**
+ * Encrypts a short secret for storage: "v1:<iv>:<tag>:<ciphertext>", base64 parts
+ * @param {string} plaintext
+ * @param {Buffer} key
+ * @returns {string}
+ */
+export const encrypt = (plaintext, key) => {
+  const iv = crypto.randomBytes(12);
+  const cipher = crypto.createCipheriv(ALGORITHM, key, iv);
+  const ciphertext = Buffer.concat([cipher.update(plaintext, "utf8"), cipher.final()]);
+  return [VERSION, iv, cipher.getAuthTag(), ciphertext]
+    .map((part) => (typeof part === "string" ? part : part.toString("base64")))
+    .join(":");
+};
+
+/**
+ * @param {string} payload - Output of encrypt()
+ * @param {Buffer} key
+ * @returns {string} Plaintext; throws if it was tampered with or the key is wrong
+ */
+export const decrypt = (payload, key) => {
+  const [version, iv, tag, ciphertext] = payload.split(":");
+  if (version !== VERSION) {
+    throw new Error(`Unknown encryption format ${version}`);
+  }
+  const decipher = crypto.createDecipheriv(ALGORITHM, key, Buffer.from(iv, "base64"));
+  decipher.setAuthTag(Buffer.from(tag, "base64"));
+  return Buffer.concat([decipher.update(Buffer.from(ciphertext, "base64")), decipher.final()]).toString("utf8");
+};
@@ -0,0 +1,96 @@
+/**
+ * Split math for trip expenses. Every amount is an integer in cents so balances
+ * always add up to zero; pure functions so they can be tested without a database.
+ */
+
+/**
+ * Splits an amount evenly, handing the leftover cents to the first participants
+ * (sorted by id so the result does not depend on input order)
+ * @param {number} amount - Amount in cents
+ * @param {string[]} participantIds
+ * @returns {Object} - { [participantId]: cents }
+ */
+export const splitEvenly = (amount, participantIds) => {
+  const ids = [...new Set(participantIds)].sort();
+  if (!ids.length) {
+    return {};
+  }
+
+  const base = Math.floor(amount / ids.length);
+  const remainder = amount - base * ids.length;
+  return Object.fromEntries(ids.map((id, index) => [id, base + (index < remainder ? 1 : 0)]));
+};
+
+/**
+ * Net balance per member: what they paid minus their share of every expense.
+ * Positive means the group owes them, negative means they owe the group.
+ * @param {Object[]} expenses - [{ amount, paidById, participantIds }]
+ * @param {string[]} memberIds - Members that always appear in the result
+ * @returns {Object} - { [memberId]: cents }
+ */
+export const computeBalances = (expenses, memberIds = []) => {
+  const balances = Object.fromEntries(memberIds.map((id) => [id, 0]));
+
+  for (const expense of expenses) {
+    if (!expense.paidById || !expense.participantIds?.length) {
+      continue;
+    }
+    balances[expense.paidById] = (balances[expense.paidById] || 0) + expense.amount;
+
+    const shares = splitEvenly(expense.amount, expense.participantIds);
+    for (const [id, share] of Object.entries(shares)) {
+      balances[id] = (balances[id] || 0) - share;
+    }
+  }
+
+  return balances;
+};
+
+/**
+ * Settlement plan: repeatedly matches the largest debtor with the largest
+ * creditor, which settles n members in at most n - 1 transfers
+ * @param {Object} balances - { [memberId]: cents }, must add up to zero
+ * @returns {Object[]} - [{ fromUserId, toUserId, amount }]
+ */
+export const settleBalances = (balances) => {
+  const byAmount = (a, b) => b.amount - a.amount || a.id.localeCompare(b.id);
+  const creditors = [];
+  const debtors = [];
+  for (const [id, amount] of Object.entries(balances)) {
+    if (amount > 0) creditors.push({ id, amount });
+    if (amount < 0) debtors.push({ id, amount: -amount });
+  }
+
+  const transfers = [];
+  while (creditors.length && debtors.length) {
+    creditors.sort(byAmount);
+    debtors.sort(byAmount);
+    const creditor = creditors[0];
+    const debtor = debtors[0];
+    const amount = Math.min(creditor.amount, debtor.amount);
+
+    transfers.push({ fromUserId: debtor.id, toUserId: creditor.id, amount });
+    creditor.amount -= amount;
+    debtor.amount -= amount;
+    if (!creditor.amount) creditors.shift();
+    if (!debtor.amount) debtors.shift();
+  }
+
+  return transfers;
+};
+
+/**
+ * Adds up amounts per currency; amounts in different currencies are never added
+ * together
+ * @param {Object[]} amounts - [{ currency, amount }] in currency units
+ * @returns {Object[]} - [{ currency, amount }] sorted by currency
+ */
+export const sumByCurrency = (amounts) => {
+  const cents = {};
+  for (const { currency, amount } of amounts) {
+    cents[currency] = (cents[currency] || 0) + Math.round(amount * 100);
+  }
+  return Object.keys(cents)
+    .sort()
+    .map((currency) => ({ currency, amount: cents[currency] / 100 }));
+};
@@ -89,6 +89,25 @@ export const uploadAvatar = multer({
   },
 });
 
+// Configuración de multer para archivos adjuntos subidos a través de la API
+// (el tipo y tamaño por clase de adjunto se validan en attachment.service)
+export const uploadAttachment = multer({
+  storage: memoryStorage,
+  limits: {
+    fileSize: 15 * 1024 * 1024, // 15MB, el máximo de cualquier tipo de adjunto
+    files: 1,
+  },
+});
+
+// Configuración de multer para documentos de grupo (tipo, tamaño y cuota se validan en groupDocument.service)
+export const uploadDocument = multer({
+  storage: memoryStorage,
+  limits: {
+    fileSize: parseInt(process.env.DOCUMENTS_MAX_FILE_SIZE, 10) || 20 * 1024 * 1024,
+    files: 1,
+  },
+});
+
 // Función para validar archivos después del upload
 export const validateUploadedFiles = (files) => {
   const errors = [];
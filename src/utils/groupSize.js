@@ -0,0 +1,48 @@
+/**
+ * Group size categories, by maximum number of travelers (admin included)
+ */
+export const GROUP_SIZES = {
+  small: 6,
+  medium: 12,
+  large: null,
+};
+
+export const GROUP_SIZE_NAMES = Object.keys(GROUP_SIZES);
+
+/**
+ * Category implied by a group capacity
+ * @param {number|null} capacity
+ * @returns {string|null} null for unlimited groups
+ */
+export const getSizeForCapacity = (capacity) => {
+  if (capacity === null || capacity === undefined) {
+    return null;
+  }
+  return GROUP_SIZE_NAMES.find((size) => GROUP_SIZES[size] === null || capacity <= GROUP_SIZES[size]);
+};
+
+/**
+ * SQL for the effective size of a group: the one the organizer declared,
+ * otherwise the one implied by its capacity
+ * @param {string} alias - Alias of the groups table in the query
+ */
+export const sizeCategorySql = (alias) =>
+  `COALESCE(${alias}."expectedSize", CASE
+     WHEN ${alias}.capacity IS NULL THEN NULL
+     WHEN ${alias}.capacity <= ${GROUP_SIZES.small} THEN 'small'
+     WHEN ${alias}.capacity <= ${GROUP_SIZES.medium} THEN 'medium'
+     ELSE 'large'
+   END)`;
+
+/**
+ * SQL for how well the size of a group fits the sizes a user prefers:
+ * 1 preferred (bonus), -1 not preferred (penalty), 0 when either is unknown
+ * @param {string} alias - Alias of the groups table in the query
+ * @param {string} param - Placeholder of the preferred sizes (text[] or null)
+ */
+export const sizeFitSql = (alias, param) =>
+  `CASE
+     WHEN ${param}::text[] IS NULL OR ${sizeCategorySql(alias)} IS NULL THEN 0
+     WHEN ${sizeCategorySql(alias)} = ANY(${param}::text[]) THEN 1
+     ELSE -1
+   END`;
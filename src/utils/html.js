@@ -0,0 +1,13 @@
+/**
+ * Escapes text for HTML element content and quoted attribute values, e.g.
+ * names written by users that end up in emails
+ * @param {*} value
+ * @returns {string}
+ */
+export const escapeHtml = (value) =>
+  String(value ?? "")
+    .replace(/&/g, "&amp;")
+    .replace(/</g, "&lt;")
+    .replace(/>/g, "&gt;")
+    .replace(/"/g, "&quot;")
+    .replace(/'/g, "&#39;");
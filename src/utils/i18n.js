@@ -0,0 +1,96 @@
+import fs from "fs";
+
+export const SUPPORTED_LOCALES = ["es", "en", "pt", "ru"];
+export const DEFAULT_LOCALE = "es";
+
+// Locale used to format dates in each language
+export const DATE_LOCALES = { es: "es-AR", en: "en-US", pt: "pt-BR", ru: "ru-RU" };
+
+const PARAM_REGEX = /\{(\w+)\}/g;
+
+/**
+ * Message catalogs in src/locales. Spanish is the source language of the
+ * code: errors are thrown in Spanish and the other catalogs translate them
+ * by errorCode ("errors"). Errors whose code is not in a catalog (or only has
+ * a generic one such as VALIDATION_ERROR) are answered as they were thrown.
+ */
+const CATALOGS = Object.fromEntries(
+  SUPPORTED_LOCALES.map((locale) => [
+    locale,
+    JSON.parse(fs.readFileSync(new URL(`../locales/${locale}.json`, import.meta.url), "utf8")),
+  ])
+);
+
+/**
+ * Best supported locale of the Accept-Language header of a request
+ * @param {Object} req
+ * @returns {string|null} null without the header or when none is supported
+ */
+export const negotiateLocale = (req) =>
+  req.get("Accept-Language") ? req.acceptsLanguages(...SUPPORTED_LOCALES) || null : null;
+
+export const isSupportedLocale = (locale) => SUPPORTED_LOCALES.includes(locale);
+
+/**
+ * Locale of a request: the one saved on the user's profile, else the best
+ * of Accept-Language, else Spanish
+ * @param {Object} req
+ * @returns {string}
+ */
+export const getRequestLocale = (req) =>
+  (isSupportedLocale(req.user?.locale) && req.user.locale) ||
+  negotiateLocale(req) ||
+  DEFAULT_LOCALE;
+
+const lookup = (locale, key) =>
+  key.split(".").reduce((node, part) => (node && typeof node === "object" ? node[part] : undefined), CATALOGS[locale]);
+
+const fill = (text, params) =>
+  text.replace(PARAM_REGEX, (placeholder, name) =>
+    params[name] !== undefined && params[name] !== null ? String(params[name]) : placeholder
+  );
+
+/**
+ * Text of a catalog key in a locale, falling back to Spanish
+ * @param {string} locale
+ * @param {string} key - e.g. "email.badge.subject"
+ * @param {Object} params - Values of the {placeholders}
+ * @returns {string} The key itself when no catalog has it
+ */
+export const t = (locale, key, params = {}) => {
+  const text = lookup(locale, key) ?? lookup(DEFAULT_LOCALE, key);
+  return typeof text === "string" ? fill(text, params) : key;
+};
+
+/**
+ * A message thrown in Spanish, in another locale, by its errorCode
+ * @param {string} locale
+ * @param {string} message
+ * @param {string} errorCode
+ * @returns {string} The message as thrown when the catalog lacks the code
+ */
+export const translateMessage = (locale, message, errorCode = null) => {
+  if (locale === DEFAULT_LOCALE || !CATALOGS[locale] || !errorCode) {
+    return message;
+  }
+  return CATALOGS[locale].errors?.[errorCode] || message;
+};
+
+/**
+ * Error details in another locale: lists of failed checks
+ * ([{ errorCode, reason }]) are translated by their errorCode; field
+ * messages ({ field: "..." }) are answered as thrown
+ * @param {string} locale
+ * @param {*} details
+ * @returns {*}
+ */
+export const translateDetails = (locale, details) => {
+  if (locale === DEFAULT_LOCALE || !Array.isArray(details)) {
+    return details;
+  }
+  return details.map((item) =>
+    item && typeof item.reason === "string"
+      ? { ...item, reason: translateMessage(locale, item.reason, item.errorCode) }
+      : item
+  );
+};
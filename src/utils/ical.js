@@ -0,0 +1,102 @@
+/**
+ * Minimal iCalendar (RFC 5545) writer for trip calendars
+ */
+
+const CRLF = "\r\n";
+const MAX_LINE_OCTETS = 75;
+
+export const escapeText = (value) =>
+  String(value)
+    .replace(/\\/g, "\\\\")
+    .replace(/;/g, "\\;")
+    .replace(/,/g, "\\,")
+    .replace(/\r?\n/g, "\\n");
+
+/**
+ * Splits a content line into 75-octet chunks, continuation lines starting
+ * with a space, without cutting a multi-byte character
+ */
+export const foldLine = (line) => {
+  if (Buffer.byteLength(line) <= MAX_LINE_OCTETS) {
+    return line;
+  }
+  const chunks = [];
+  let current = "";
+  let currentOctets = 0;
+  for (const char of line) {
+    const octets = Buffer.byteLength(char);
+    // Continuation lines lose one octet to the leading space
+    const limit = chunks.length === 0 ? MAX_LINE_OCTETS : MAX_LINE_OCTETS - 1;
+    if (currentOctets + octets > limit) {
+      chunks.push(current);
+      current = "";
+      currentOctets = 0;
+    }
+    current += char;
+    currentOctets += octets;
+  }
+  chunks.push(current);
+  return chunks.join(`${CRLF} `);
+};
+
+// "2026-10-15" -> "20261015"
+const formatDate = (date) => date.replace(/-/g, "");
+
+const addDay = (date) => {
+  const next = new Date(`${date}T00:00:00Z`);
+  next.setUTCDate(next.getUTCDate() + 1);
+  return next.toISOString().slice(0, 10);
+};
+
+// Date -> "20261015T093000Z"
+const formatTimestamp = (date) => new Date(date).toISOString().replace(/[-:]/g, "").replace(/\.\d{3}/, "");
+
+/**
+ * DTSTART/DTEND of an event: all-day when it has no time, otherwise a
+ * floating local time (itinerary times are local to the destination)
+ */
+const timeProperties = ({ date, endDate = null, time = null, durationMinutes = 60 }) => {
+  if (!time) {
+    return [`DTSTART;VALUE=DATE:${formatDate(date)}`, `DTEND;VALUE=DATE:${formatDate(addDay(endDate || date))}`];
+  }
+  const [hours, minutes] = time.split(":").map(Number);
+  const start = `${formatDate(date)}T${String(hours).padStart(2, "0")}${String(minutes).padStart(2, "0")}00`;
+  return [`DTSTART:${start}`, `DURATION:PT${durationMinutes}M`];
+};
+
+/**
+ * @param {Object} calendar - { name, description, refreshHours, events: [{ uid, summary, description,
+ *   location, geo: { lat, lng }, url, date, endDate, time, updatedAt }] }
+ * @returns {string} The VCALENDAR document
+ */
+export const buildCalendar = ({ name, description = null, refreshHours = null, events }) => {
+  const now = formatTimestamp(new Date());
+  const lines = [
+    "BEGIN:VCALENDAR",
+    "VERSION:2.0",
+    "PRODID:-//JoinTravel//Trips//ES",
+    "CALSCALE:GREGORIAN",
+    "METHOD:PUBLISH",
+    `X-WR-CALNAME:${escapeText(name)}`,
+  ];
+  if (description) {
+    lines.push(`X-WR-CALDESC:${escapeText(description)}`);
+  }
+  if (refreshHours) {
+    lines.push(`REFRESH-INTERVAL;VALUE=DURATION:PT${refreshHours}H`, `X-PUBLISHED-TTL:PT${refreshHours}H`);
+  }
+
+  for (const event of events) {
+    lines.push("BEGIN:VEVENT", `UID:${event.uid}`, `DTSTAMP:${now}`, ...timeProperties(event));
+    lines.push(`SUMMARY:${escapeText(event.summary)}`);
+    if (event.description) lines.push(`DESCRIPTION:${escapeText(event.description)}`);
+    if (event.location) lines.push(`LOCATION:${escapeText(event.location)}`);
+    if (event.geo) lines.push(`GEO:${Number(event.geo.lat).toFixed(6)};${Number(event.geo.lng).toFixed(6)}`);
+    if (event.url) lines.push(`URL:${event.url}`);
+    if (event.updatedAt) lines.push(`LAST-MODIFIED:${formatTimestamp(event.updatedAt)}`);
+    lines.push("END:VEVENT");
+  }
+  lines.push("END:VCALENDAR");
+
+  return lines.map(foldLine).join(CRLF) + CRLF;
+};
@@ -0,0 +1,74 @@
+/**
+ * Utilidades de k-anonimato para métricas agregadas.
+ *
+ * Una cohorte (fila agregada) con menos de `k` usuarios puede permitir
+ * re-identificar personas, por lo que se suprime antes de exponerla en
+ * endpoints de analítica o exportaciones.
+ */
+
+export const SUPPRESSED_LABEL = "suppressed";
+
+/**
+ * Suprime las cohortes con menos de `k` elementos.
+ *
+ * Además de la supresión primaria aplica supresión secundaria: si solo se
+ * suprimió una cohorte, su valor podría deducirse restando del total, así que
+ * también se suprime la cohorte visible más pequeña.
+ *
+ * Las cohortes suprimidas se agrupan en una fila `suppressed` solo si la suma
+ * alcanza `k`; si no, se descartan por completo.
+ *
+ * @param {Array<Object>} rows - Filas agregadas
+ * @param {Object} options
+ * @param {number} options.k - Tamaño mínimo de cohorte
+ * @param {string} [options.countField="count"] - Campo con el tamaño de la cohorte
+ * @param {string} [options.labelField="cohort"] - Campo con la etiqueta de la cohorte
+ * @returns {{ rows: Array<Object>, suppressedCohorts: number }}
+ */
+export const suppressSmallCohorts = (
+  rows,
+  { k, countField = "count", labelField = "cohort" }
+) => {
+  const normalized = rows.map((row) => ({
+    ...row,
+    [countField]: Number(row[countField]) || 0,
+  }));
+
+  const visible = normalized.filter((row) => row[countField] >= k);
+  const suppressed = normalized.filter((row) => row[countField] < k);
+
+  // Supresión secundaria para evitar deducir la cohorte por diferencia
+  if (suppressed.length === 1 && visible.length > 0) {
+    visible.sort((a, b) => a[countField] - b[countField]);
+    suppressed.push(visible.shift());
+  }
+
+  const result = [...visible];
+  const suppressedTotal = suppressed.reduce(
+    (sum, row) => sum + row[countField],
+    0
+  );
+
+  if (suppressed.length > 0 && suppressedTotal >= k) {
+    result.push({
+      [labelField]: SUPPRESSED_LABEL,
+      [countField]: suppressedTotal,
+    });
+  }
+
+  return {
+    rows: result,
+    suppressedCohorts: suppressed.length,
+  };
+};
+
+/**
+ * Devuelve un total solo si alcanza el umbral de k-anonimato
+ * @param {number} value - Total a exponer
+ * @param {number} k - Tamaño mínimo
+ * @returns {number|null} El total, o null si debe suprimirse
+ */
+export const thresholdCount = (value, k) => {
+  const count = Number(value) || 0;
+  return count >= k ? count : null;
+};
@@ -0,0 +1,290 @@
+import { SUPPORTED_LOCALES, DEFAULT_LOCALE } from "./i18n.js";
+
+// Pushes come in the languages of the rest of the API
+export const PUSH_LANGUAGES = SUPPORTED_LOCALES;
+export const DEFAULT_PUSH_LANGUAGE = DEFAULT_LOCALE;
+
+/**
+ * Built-in push texts by notification type and language. Placeholders are
+ * filled from the notification data: {groupName}, or {deletesAt:date} for a
+ * date shown in the recipient's time zone. Types without a template are
+ * pushed with the title and message stored with the notification.
+ */
+export const PUSH_TEMPLATES = {
+  JOIN_REQUEST_RECEIVED: {
+    es: { title: "Nueva solicitud para unirse", body: 'Alguien quiere unirse al grupo "{groupName}"' },
+    en: { title: "New request to join", body: 'Someone wants to join "{groupName}"' },
+    pt: { title: "Nova solicitação para participar", body: 'Alguém quer participar do grupo "{groupName}"' },
+    ru: { title: "Новая заявка на участие", body: "Кто-то хочет присоединиться к группе «{groupName}»" },
+  },
+  JOIN_REQUEST_APPROVED: {
+    es: { title: "Solicitud aceptada", body: 'Ya eres miembro del grupo "{groupName}"' },
+    en: { title: "Request accepted", body: 'You are now a member of "{groupName}"' },
+    pt: { title: "Solicitação aceita", body: 'Agora você é membro do grupo "{groupName}"' },
+    ru: { title: "Заявка принята", body: "Теперь вы участник группы «{groupName}»" },
+  },
+  JOIN_REQUEST_REJECTED: {
+    es: { title: "Solicitud rechazada", body: 'Tu solicitud para unirte a "{groupName}" fue rechazada' },
+    en: { title: "Request declined", body: 'Your request to join "{groupName}" was declined' },
+    pt: { title: "Solicitação recusada", body: 'Sua solicitação para participar de "{groupName}" foi recusada' },
+    ru: { title: "Заявка отклонена", body: "Ваша заявка на участие в «{groupName}» отклонена" },
+  },
+  JOIN_REQUEST_WAITLISTED: {
+    es: {
+      title: "Estás en lista de espera",
+      body: 'El grupo "{groupName}" está completo. Te avisaremos si se libera un lugar',
+    },
+    en: {
+      title: "You are on the waitlist",
+      body: '"{groupName}" is full. We will let you know if a spot opens up',
+    },
+    pt: {
+      title: "Você está na lista de espera",
+      body: 'O grupo "{groupName}" está completo. Avisaremos se uma vaga for liberada',
+    },
+    ru: {
+      title: "Вы в листе ожидания",
+      body: "Группа «{groupName}» заполнена. Мы сообщим, если освободится место",
+    },
+  },
+  JOIN_REQUEST_AWAITING_RULES: {
+    es: {
+      title: "Acepta las normas del viaje",
+      body: 'Tu lugar en "{groupName}" se confirma cuando aceptes sus normas de convivencia',
+    },
+    en: {
+      title: "Accept the trip rules",
+      body: 'Your spot in "{groupName}" is confirmed once you accept its code of conduct',
+    },
+    pt: {
+      title: "Aceite as regras da viagem",
+      body: 'Sua vaga em "{groupName}" é confirmada quando você aceitar as regras de convivência',
+    },
+    ru: {
+      title: "Примите правила поездки",
+      body: "Ваше место в «{groupName}» подтвердится, когда вы примете правила поведения",
+    },
+  },
+  GROUP_INVITE_ACCEPTED: {
+    es: { title: "Nuevo miembro por invitación", body: 'Alguien se sumó al grupo "{groupName}" con un link de invitación' },
+    en: { title: "New member by invite", body: 'Someone joined "{groupName}" with an invite link' },
+    pt: { title: "Novo membro por convite", body: 'Alguém entrou no grupo "{groupName}" com um link de convite' },
+    ru: {
+      title: "Новый участник по приглашению",
+      body: "Кто-то присоединился к группе «{groupName}» по ссылке-приглашению",
+    },
+  },
+  TRIP_RULES_UPDATED: {
+    es: {
+      title: "Cambiaron las normas del viaje",
+      body: 'El organizador actualizó las normas de "{groupName}". Revísalas y acéptalas',
+    },
+    en: {
+      title: "The trip rules changed",
+      body: 'The organizer updated the rules of "{groupName}". Please review and accept them',
+    },
+    pt: {
+      title: "As regras da viagem mudaram",
+      body: 'O organizador atualizou as regras de "{groupName}". Revise e aceite-as',
+    },
+    ru: {
+      title: "Правила поездки изменились",
+      body: "Организатор обновил правила «{groupName}». Ознакомьтесь с ними и примите их",
+    },
+  },
+  GROUP_SUCCESSION_STARTED: {
+    es: {
+      title: "El viaje busca nuevo organizador",
+      body: 'El organizador de "{groupName}" ya no está disponible. Vamos a ofrecerle el viaje a otro miembro',
+    },
+    en: {
+      title: "The trip needs a new organizer",
+      body: 'The organizer of "{groupName}" is no longer available. We will offer the trip to another member',
+    },
+    pt: {
+      title: "A viagem precisa de um novo organizador",
+      body: 'O organizador de "{groupName}" não está mais disponível. Vamos oferecer a viagem a outro membro',
+    },
+    ru: {
+      title: "Поездке нужен новый организатор",
+      body: "Организатор «{groupName}» больше недоступен. Мы предложим поездку другому участнику",
+    },
+  },
+  GROUP_SUCCESSION_OFFER: {
+    es: {
+      title: "¿Quieres organizar el viaje?",
+      body: '"{groupName}" necesita un nuevo organizador y te lo ofrecemos a ti',
+    },
+    en: {
+      title: "Do you want to organize the trip?",
+      body: '"{groupName}" needs a new organizer and we are offering it to you',
+    },
+    pt: {
+      title: "Quer organizar a viagem?",
+      body: '"{groupName}" precisa de um novo organizador e estamos oferecendo a você',
+    },
+    ru: {
+      title: "Хотите организовать поездку?",
+      body: "«{groupName}» нужен новый организатор, и мы предлагаем эту роль вам",
+    },
+  },
+  GROUP_SUCCESSION_VOTING: {
+    es: {
+      title: "Vota al nuevo organizador",
+      body: 'Nadie aceptó organizar "{groupName}". Vota quién debería hacerlo',
+    },
+    en: {
+      title: "Vote for the new organizer",
+      body: 'Nobody accepted organizing "{groupName}". Vote for who should do it',
+    },
+    pt: {
+      title: "Vote no novo organizador",
+      body: 'Ninguém aceitou organizar "{groupName}". Vote em quem deveria fazê-lo',
+    },
+    ru: {
+      title: "Проголосуйте за нового организатора",
+      body: "Никто не согласился организовать «{groupName}». Проголосуйте, кто должен это сделать",
+    },
+  },
+  GROUP_SUCCESSION_COMPLETED: {
+    es: { title: "Nuevo organizador", body: '"{groupName}" tiene un nuevo organizador' },
+    en: { title: "New organizer", body: '"{groupName}" has a new organizer' },
+    pt: { title: "Novo organizador", body: '"{groupName}" tem um novo organizador' },
+    ru: { title: "Новый организатор", body: "У «{groupName}» новый организатор" },
+  },
+  NEW_MESSAGE: {
+    es: { title: "Nuevo mensaje", body: "{senderEmail} te ha enviado un mensaje" },
+    en: { title: "New message", body: "{senderEmail} sent you a message" },
+    pt: { title: "Nova mensagem", body: "{senderEmail} enviou uma mensagem para você" },
+    ru: { title: "Новое сообщение", body: "{senderEmail} отправил(а) вам сообщение" },
+  },
+  NEW_GROUP_MESSAGE: {
+    es: { title: "Nuevo mensaje en {groupName}", body: "{senderEmail} ha enviado un mensaje" },
+    en: { title: "New message in {groupName}", body: "{senderEmail} sent a message" },
+    pt: { title: "Nova mensagem em {groupName}", body: "{senderEmail} enviou uma mensagem" },
+    ru: { title: "Новое сообщение в {groupName}", body: "{senderEmail} отправил(а) сообщение" },
+  },
+  GROUP_INVITE: {
+    es: { title: "Invitación a grupo", body: '{adminEmail} te ha agregado al grupo "{groupName}"' },
+    en: { title: "Group invitation", body: '{adminEmail} added you to "{groupName}"' },
+    pt: { title: "Convite para grupo", body: '{adminEmail} adicionou você ao grupo "{groupName}"' },
+    ru: { title: "Приглашение в группу", body: "{adminEmail} добавил(а) вас в группу «{groupName}»" },
+  },
+  NEW_ITINERARY: {
+    es: { title: "Nuevo itinerario en {groupName}", body: 'Se asignó el itinerario "{itineraryName}"' },
+    en: { title: "New itinerary in {groupName}", body: 'The itinerary "{itineraryName}" was assigned' },
+    pt: { title: "Novo roteiro em {groupName}", body: 'O roteiro "{itineraryName}" foi atribuído' },
+    ru: { title: "Новый маршрут в {groupName}", body: "Назначен маршрут «{itineraryName}»" },
+  },
+  EXPENSE_ADDED: {
+    es: { title: "Nuevo gasto en {groupName}", body: "Se añadió un gasto: {concept} ({amount})" },
+    en: { title: "New expense in {groupName}", body: "An expense was added: {concept} ({amount})" },
+    pt: { title: "Nova despesa em {groupName}", body: "Uma despesa foi adicionada: {concept} ({amount})" },
+    ru: { title: "Новый расход в {groupName}", body: "Добавлен расход: {concept} ({amount})" },
+  },
+  GROUP_CAPACITY_CHANGED: {
+    es: { title: "Cambió el cupo de {groupName}", body: "Ahora el grupo admite {capacity} viajeros" },
+    en: { title: "{groupName} capacity changed", body: "The group now takes {capacity} travelers" },
+    pt: { title: "A capacidade de {groupName} mudou", body: "Agora o grupo aceita {capacity} viajantes" },
+    ru: { title: "Изменилась вместимость {groupName}", body: "Теперь в группе {capacity} мест" },
+  },
+  PAYMENT_FAILED: {
+    es: {
+      title: "El pago no se pudo completar",
+      body: "Tu pago de {amount} {currency} fue rechazado. Puedes intentarlo de nuevo.",
+    },
+    en: {
+      title: "Your payment did not go through",
+      body: "Your payment of {amount} {currency} was declined. You can try again.",
+    },
+    pt: {
+      title: "Não foi possível concluir o pagamento",
+      body: "Seu pagamento de {amount} {currency} foi recusado. Você pode tentar novamente.",
+    },
+    ru: {
+      title: "Платёж не прошёл",
+      body: "Ваш платёж на {amount} {currency} отклонён. Попробуйте ещё раз.",
+    },
+  },
+  PAYMENT_REFUNDED: {
+    es: { title: "Reembolso realizado", body: "Te devolvimos {amount} {currency}." },
+    en: { title: "Refund issued", body: "We refunded you {amount} {currency}." },
+    pt: { title: "Reembolso realizado", body: "Devolvemos {amount} {currency} para você." },
+    ru: { title: "Возврат выполнен", body: "Мы вернули вам {amount} {currency}." },
+  },
+  BADGE_EARNED: {
+    es: { title: "¡Nueva insignia!", body: 'Has ganado la insignia "{badgeName}"' },
+    en: { title: "New badge!", body: 'You earned the "{badgeName}" badge' },
+    pt: { title: "Nova insígnia!", body: 'Você ganhou a insígnia "{badgeName}"' },
+    ru: { title: "Новый значок!", body: "Вы получили значок «{badgeName}»" },
+  },
+  CHAT_DELETION_SCHEDULED: {
+    es: {
+      title: "El chat de {groupName} se eliminará pronto",
+      body: "Los mensajes se eliminarán el {deletesAt:date}. Si quieres conservarlos, expórtalos antes.",
+    },
+    en: {
+      title: "The {groupName} chat will be deleted soon",
+      body: "Messages will be deleted on {deletesAt:date}. Export them first if you want to keep them.",
+    },
+    pt: {
+      title: "O chat de {groupName} será excluído em breve",
+      body: "As mensagens serão excluídas em {deletesAt:date}. Se quiser guardá-las, exporte antes.",
+    },
+    ru: {
+      title: "Чат {groupName} скоро будет удалён",
+      body: "Сообщения будут удалены {deletesAt:date}. Если хотите их сохранить, экспортируйте их заранее.",
+    },
+  },
+  NOTIFICATIONS_DIGEST: {
+    es: { title: "Mientras no estabas", body: "Tienes {count} notificaciones nuevas" },
+    en: { title: "While you were away", body: "You have {count} new notifications" },
+    pt: { title: "Enquanto você esteve fora", body: "Você tem {count} novas notificações" },
+    ru: { title: "Пока вас не было", body: "У вас новых уведомлений: {count}" },
+  },
+};
+
+const PLACEHOLDER_REGEX = /\{(\w+)(?::(date|datetime))?\}/g;
+
+/**
+ * Names of the placeholders used in a text ("deletesAt" for {deletesAt:date})
+ * @param {string} text
+ * @returns {string[]}
+ */
+export const getPlaceholders = (text) =>
+  [...new Set([...String(text).matchAll(PLACEHOLDER_REGEX)].map((match) => match[1]))];
+
+const formatDate = (value, format, language, timeZone) => {
+  const date = new Date(value);
+  if (Number.isNaN(date.getTime())) {
+    return null;
+  }
+  const options =
+    format === "date" ? { dateStyle: "long" } : { dateStyle: "long", timeStyle: "short" };
+  return new Intl.DateTimeFormat(language, { ...options, timeZone }).format(date);
+};
+
+/**
+ * Fills the placeholders of a text
+ * @param {string} text
+ * @param {Object} data - Placeholder values
+ * @param {Object} locale - { language, timeZone }
+ * @returns {string|null} null when a placeholder has no value
+ */
+export const fillTemplate = (text, data, { language, timeZone }) => {
+  let complete = true;
+  const filled = String(text).replace(PLACEHOLDER_REGEX, (placeholder, name, format) => {
+    const value = data?.[name];
+    if (value === undefined || value === null || value === "") {
+      complete = false;
+      return placeholder;
+    }
+    if (format) {
+      const formatted = formatDate(value, format, language, timeZone);
+      complete = complete && formatted !== null;
+      return formatted ?? placeholder;
+    }
+    return String(value);
+  });
+  return complete ? filled : null;
+};
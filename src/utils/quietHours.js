@@ -0,0 +1,67 @@
+const TIME_REGEX = /^([01]\d|2[0-3]):([0-5]\d)$/;
+
+const toMinutes = (time) => {
+  const [, hours, minutes] = time.match(TIME_REGEX);
+  return Number(hours) * 60 + Number(minutes);
+};
+
+/**
+ * Checks an "HH:MM" (24h) time
+ */
+export const isValidTime = (value) => typeof value === "string" && TIME_REGEX.test(value);
+
+/**
+ * Checks an IANA time zone name (e.g. America/Argentina/Buenos_Aires)
+ */
+export const isValidTimeZone = (value) => {
+  if (typeof value !== "string" || !value) {
+    return false;
+  }
+  try {
+    new Intl.DateTimeFormat("en-US", { timeZone: value });
+    return true;
+  } catch {
+    return false;
+  }
+};
+
+/**
+ * Minutes since local midnight of a date in a time zone
+ */
+const localMinutes = (date, timeZone) => {
+  const parts = new Intl.DateTimeFormat("en-US", {
+    timeZone,
+    hour: "2-digit",
+    minute: "2-digit",
+    hourCycle: "h23",
+  }).formatToParts(date);
+  const value = (type) => Number(parts.find((part) => part.type === type).value);
+  return value("hour") * 60 + value("minute");
+};
+
+/**
+ * When the quiet hours of a user end, if they are in them right now.
+ * Windows may cross midnight (22:00-08:00).
+ * @param {Object} quietHours - { start, end, timezone } ("HH:MM" local times)
+ * @param {Date} now
+ * @returns {Date|null} - End of the current quiet period, null outside quiet hours
+ */
+export const getQuietHoursEnd = ({ start, end, timezone }, now = new Date()) => {
+  if (!isValidTime(start) || !isValidTime(end) || start === end) {
+    return null;
+  }
+  const startMin = toMinutes(start);
+  const endMin = toMinutes(end);
+  const current = localMinutes(now, isValidTimeZone(timezone) ? timezone : "UTC");
+
+  const inside =
+    startMin < endMin ? current >= startMin && current < endMin : current >= startMin || current < endMin;
+  if (!inside) {
+    return null;
+  }
+
+  const minutesLeft = (endMin - current + 24 * 60) % (24 * 60);
+  const endsAt = new Date(now.getTime() + minutesLeft * 60 * 1000);
+  endsAt.setSeconds(0, 0);
+  return endsAt;
+};
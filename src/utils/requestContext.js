@@ -0,0 +1,57 @@
+import { AsyncLocalStorage } from "node:async_hooks";
+import { DeadlineExceededError } from "./customErrors.js";
+
+/**
+ * Context of the HTTP request being served: its ID, deadline and an abort
+ * signal that fires when the deadline passes or the client goes away.
+ *
+ * It flows through every await of the request, so services and repositories
+ * read it from here instead of taking it as an argument. Code running outside
+ * a request (jobs, cron, CLI) has no context and no deadline.
+ */
+const storage = new AsyncLocalStorage();
+
+/**
+ * Runs fn with a context
+ * @param {Object} context - { requestId, deadline (epoch ms), signal, bookingId, ip, userAgent, userId }
+ * @param {Function} fn
+ */
+export const runWithContext = (context, fn) => storage.run(context, fn);
+
+/**
+ * @returns {Object|undefined} - { requestId, deadline, signal, ip, userAgent, userId }
+ */
+export const getContext = () => storage.getStore();
+
+export const getRequestId = () => storage.getStore()?.requestId;
+
+/**
+ * Milliseconds left before the deadline, or null without one
+ */
+export const getRemainingMs = () => {
+  const context = storage.getStore();
+  return context?.deadline ? Math.max(0, context.deadline - Date.now()) : null;
+};
+
+/**
+ * Throws when the request has already timed out or been abandoned, so no new
+ * work starts for a response nobody will read
+ */
+export const throwIfExpired = () => {
+  const context = storage.getStore();
+  if (context?.signal?.aborted) {
+    throw new DeadlineExceededError("La solicitud excedió su tiempo límite");
+  }
+};
+
+/**
+ * Signal for an outgoing call: aborts after timeoutMs or when the request
+ * is aborted, whichever comes first
+ * @param {number} timeoutMs - Timeout of the call itself
+ * @returns {AbortSignal}
+ */
+export const requestSignal = (timeoutMs) => {
+  const signal = AbortSignal.timeout(timeoutMs);
+  const context = storage.getStore();
+  return context?.signal ? AbortSignal.any([signal, context.signal]) : signal;
+};
@@ -0,0 +1,31 @@
+// Route families heavier than a plain read: full-text search, ranking,
+// uploads and reports. Matched against the path without /api or /api/v1.
+const ROUTE_WEIGHTS = [
+  [/^\/search(\/|$)/, 3],
+  [/^\/recommendations(\/|$)/, 3],
+  [/^\/availability(\/|$)/, 2],
+  [/^\/media(\/|$)/, 4],
+  [/^\/attachments(\/|$)/, 4],
+  [/^\/admin\/analytics(\/|$)/, 5],
+];
+
+// Routes that spend their time waiting (long polling), not computing
+const IDLE_ROUTES = [/^\/poll(\/|$)/];
+
+const WRITE_METHODS = new Set(["POST", "PUT", "PATCH", "DELETE"]);
+const VERSION_PREFIX_REGEX = /^\/api(\/v\d+)?(?=\/|$)/;
+
+/**
+ * Cost units of a request: the weight of its route (doubled for writes)
+ * plus one unit for every msPerUnit it took (long polls are not charged for the wait)
+ * @param {Object} request - { method, path, durationMs }
+ * @param {number} msPerUnit
+ * @returns {number}
+ */
+export const computeRequestCost = ({ method, path, durationMs }, msPerUnit) => {
+  const routePath = path.replace(VERSION_PREFIX_REGEX, "") || "/";
+  const weight = ROUTE_WEIGHTS.find(([regex]) => regex.test(routePath))?.[1] ?? 1;
+  const methodFactor = WRITE_METHODS.has(method) ? 2 : 1;
+  const busyMs = IDLE_ROUTES.some((regex) => regex.test(routePath)) ? 0 : Math.max(durationMs, 0);
+  return weight * methodFactor + Math.floor(busyMs / msPerUnit);
+};
@@ -0,0 +1,103 @@
+import crypto from "crypto";
+
+const BASE32_ALPHABET = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567";
+const STEP_SECONDS = 30;
+const DIGITS = 6;
+const CODE_REGEX = /^\d{6}$/;
+
+/**
+ * RFC 4648 base32 without padding, the format authenticator apps expect
+ */
+export const base32Encode = (buffer) => {
+  let bits = 0;
+  let value = 0;
+  let output = "";
+  for (const byte of buffer) {
+    value = (value << 8) | byte;
+    bits += 8;
+    while (bits >= 5) {
+      output += BASE32_ALPHABET[(value >>> (bits - 5)) & 31];
+      bits -= 5;
+    }
+  }
+  if (bits > 0) {
+    output += BASE32_ALPHABET[(value << (5 - bits)) & 31];
+  }
+  return output;
+};
+
+export const base32Decode = (text) => {
+  const clean = text.replace(/=+$/, "").toUpperCase();
+  let bits = 0;
+  let value = 0;
+  const bytes = [];
+  for (const char of clean) {
+    const index = BASE32_ALPHABET.indexOf(char);
+    if (index === -1) {
+      throw new Error("Invalid base32 character");
+    }
+    value = (value << 5) | index;
+    bits += 5;
+    if (bits >= 8) {
+      bytes.push((value >>> (bits - 8)) & 255);
+      bits -= 8;
+    }
+  }
+  return Buffer.from(bytes);
+};
+
+/**
+ * New shared secret (160 bits, as RFC 4226 recommends), base32 encoded
+ */
+export const generateSecret = () => base32Encode(crypto.randomBytes(20));
+
+/**
+ * HOTP code of a counter (RFC 4226)
+ */
+const hotp = (secret, counter) => {
+  const message = Buffer.alloc(8);
+  message.writeBigUInt64BE(BigInt(counter));
+  const hmac = crypto.createHmac("sha1", base32Decode(secret)).update(message).digest();
+  const offset = hmac[hmac.length - 1] & 15;
+  const binary = hmac.readUInt32BE(offset) & 0x7fffffff;
+  return String(binary % 10 ** DIGITS).padStart(DIGITS, "0");
+};
+
+/**
+ * Checks a TOTP code (RFC 6238) allowing `window` steps of clock drift
+ * either way
+ * @param {string} secret - base32
+ * @param {string} code
+ * @param {Object} options - { window, now }
+ * @returns {number|null} Time step the code belongs to, or null if invalid
+ */
+export const verifyTotp = (secret, code, { window = 1, now = Date.now() } = {}) => {
+  if (typeof code !== "string" || !CODE_REGEX.test(code)) {
+    return null;
+  }
+  const current = Math.floor(now / 1000 / STEP_SECONDS);
+  for (let step = current - window; step <= current + window; step++) {
+    const expected = hotp(secret, step);
+    if (crypto.timingSafeEqual(Buffer.from(expected), Buffer.from(code))) {
+      return step;
+    }
+  }
+  return null;
+};
+
+/**
+ * otpauth:// URI authenticator apps read from a QR code
+ * @param {Object} params - { issuer, account, secret }
+ * @returns {string}
+ */
+export const buildProvisioningUri = ({ issuer, account, secret }) => {
+  const label = encodeURIComponent(`${issuer}:${account}`);
+  const params = new URLSearchParams({
+    secret,
+    issuer,
+    algorithm: "SHA1",
+    digits: String(DIGITS),
+    period: String(STEP_SECONDS),
+  });
+  return `otpauth://totp/${label}?${params}`;
+};
@@ -0,0 +1,112 @@
+const EARTH_RADIUS_KM = 6371;
+const DAY_MS = 24 * 60 * 60 * 1000;
+
+/**
+ * Great-circle distance between two points
+ * @param {Object} a - { lat, lng }
+ * @param {Object} b - { lat, lng }
+ * @returns {number} Kilometers
+ */
+export const distanceKm = (a, b) => {
+  const toRadians = (degrees) => (degrees * Math.PI) / 180;
+  const dLat = toRadians(b.lat - a.lat);
+  const dLng = toRadians(b.lng - a.lng);
+  const h =
+    Math.sin(dLat / 2) ** 2 +
+    Math.cos(toRadians(a.lat)) * Math.cos(toRadians(b.lat)) * Math.sin(dLng / 2) ** 2;
+  return 2 * EARTH_RADIUS_KM * Math.asin(Math.sqrt(h));
+};
+
+/**
+ * Days since the epoch of a YYYY-MM-DD date (or Date)
+ */
+export const toDayNumber = (date) => Math.floor(new Date(date).getTime() / DAY_MS);
+
+/**
+ * Whether two date ranges overlap, allowing a gap of up to toleranceDays
+ * between them (a trip logged from the day after landing is still the same trip)
+ * @param {Object} a - { startDate, endDate }
+ * @param {Object} b - { startDate, endDate }
+ * @param {number} toleranceDays
+ */
+export const datesOverlap = (a, b, toleranceDays = 1) =>
+  toDayNumber(a.startDate) <= toDayNumber(b.endDate) + toleranceDays &&
+  toDayNumber(b.startDate) <= toDayNumber(a.endDate) + toleranceDays;
+
+const hasCoordinates = (place) =>
+  place.lat !== null && place.lat !== undefined && place.lng !== null && place.lng !== undefined;
+
+/**
+ * Whether two places are the same destination: same city name or, when both
+ * have coordinates, within radiusKm of each other
+ * @param {Object} a - { city, lat, lng }
+ * @param {Object} b - { city, lat, lng }
+ * @param {number} radiusKm
+ */
+export const samePlace = (a, b, radiusKm) => {
+  if (a.city && b.city && a.city.trim().toLowerCase() === b.city.trim().toLowerCase()) {
+    return true;
+  }
+  if (hasCoordinates(a) && hasCoordinates(b)) {
+    return (
+      distanceKm(
+        { lat: Number(a.lat), lng: Number(a.lng) },
+        { lat: Number(b.lat), lng: Number(b.lng) }
+      ) <= radiusKm
+    );
+  }
+  return false;
+};
+
+const mostCommon = (values) => {
+  const counts = new Map();
+  for (const value of values.filter(Boolean)) {
+    counts.set(value, (counts.get(value) || 0) + 1);
+  }
+  let best = null;
+  for (const [value, count] of counts) {
+    if (best === null || count > counts.get(best)) {
+      best = value;
+    }
+  }
+  return best;
+};
+
+/**
+ * Groups photo metadata into trips: photos taken close in time and space are
+ * one trip. A new trip starts after more than maxGapDays without photos or
+ * when a photo is more than radiusKm away from the trip's first photo.
+ * @param {Array} photos - [{ takenAt, lat, lng, city, countryCode }], already validated
+ * @param {Object} options - { maxGapDays, radiusKm }
+ * @returns {Array} - [{ city, countryCode, lat, lng, startDate, endDate, photoCount }]
+ */
+export const clusterPhotos = (photos, { maxGapDays, radiusKm }) => {
+  const sorted = [...photos].sort((a, b) => new Date(a.takenAt) - new Date(b.takenAt));
+  const clusters = [];
+  let current = null;
+
+  for (const photo of sorted) {
+    const day = toDayNumber(photo.takenAt);
+    const startsNewTrip =
+      !current ||
+      day - current.lastDay > maxGapDays ||
+      distanceKm(current.anchor, photo) > radiusKm;
+
+    if (startsNewTrip) {
+      current = { anchor: photo, lastDay: day, photos: [] };
+      clusters.push(current);
+    }
+    current.photos.push(photo);
+    current.lastDay = day;
+  }
+
+  return clusters.map(({ anchor, photos: clustered }) => ({
+    city: mostCommon(clustered.map((photo) => photo.city)),
+    countryCode: mostCommon(clustered.map((photo) => photo.countryCode)),
+    lat: anchor.lat,
+    lng: anchor.lng,
+    startDate: new Date(clustered[0].takenAt).toISOString().slice(0, 10),
+    endDate: new Date(clustered[clustered.length - 1].takenAt).toISOString().slice(0, 10),
+    photoCount: clustered.length,
+  }));
+};
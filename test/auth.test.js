@@ -12,6 +12,7 @@ describe("Authentication Tests", () => {
   let testUser = {
     email: "test@example.com",
     password: "TestPassword123!",
+    dateOfBirth: "1995-04-21",
   };
   let confirmationToken;
   let accessToken;
@@ -0,0 +1,128 @@
+import {
+  splitEvenly,
+  computeBalances,
+  settleBalances,
+  sumByCurrency,
+} from "../src/utils/expenseSplit.js";
+
+const sum = (values) => values.reduce((total, value) => total + value, 0);
+
+describe("Expense split math", () => {
+  describe("splitEvenly", () => {
+    it("should split an exact amount in equal shares", () => {
+      expect(splitEvenly(900, ["a", "b", "c"])).toEqual({ a: 300, b: 300, c: 300 });
+    });
+
+    it("should hand leftover cents to the first participants by id", () => {
+      const shares = splitEvenly(1000, ["c", "a", "b"]);
+
+      expect(shares).toEqual({ a: 334, b: 333, c: 333 });
+      expect(sum(Object.values(shares))).toBe(1000);
+    });
+
+    it("should ignore duplicated participants", () => {
+      expect(splitEvenly(100, ["a", "a", "b"])).toEqual({ a: 50, b: 50 });
+    });
+
+    it("should return no shares without participants", () => {
+      expect(splitEvenly(100, [])).toEqual({});
+    });
+  });
+
+  describe("computeBalances", () => {
+    it("should credit the payer and debit every participant", () => {
+      const balances = computeBalances(
+        [{ amount: 3000, paidById: "a", participantIds: ["a", "b", "c"] }],
+        ["a", "b", "c"]
+      );
+
+      expect(balances).toEqual({ a: 2000, b: -1000, c: -1000 });
+    });
+
+    it("should only split among the participants of each expense", () => {
+      const balances = computeBalances(
+        [
+          { amount: 3000, paidById: "a", participantIds: ["a", "b", "c"] },
+          { amount: 1000, paidById: "b", participantIds: ["b", "c"] },
+        ],
+        ["a", "b", "c"]
+      );
+
+      expect(balances).toEqual({ a: 2000, b: -500, c: -1500 });
+    });
+
+    it("should include members without expenses and skip unassigned ones", () => {
+      const balances = computeBalances(
+        [{ amount: 500, paidById: null, participantIds: ["a", "b"] }],
+        ["a", "b"]
+      );
+
+      expect(balances).toEqual({ a: 0, b: 0 });
+    });
+
+    it("should always add up to zero", () => {
+      const balances = computeBalances(
+        [
+          { amount: 1001, paidById: "a", participantIds: ["a", "b", "c"] },
+          { amount: 777, paidById: "c", participantIds: ["a", "b", "c", "d"] },
+          { amount: 5, paidById: "d", participantIds: ["b", "d"] },
+        ],
+        ["a", "b", "c", "d"]
+      );
+
+      expect(sum(Object.values(balances))).toBe(0);
+    });
+  });
+
+  describe("settleBalances", () => {
+    it("should return no transfers when everyone is even", () => {
+      expect(settleBalances({ a: 0, b: 0 })).toEqual([]);
+    });
+
+    it("should settle one creditor with every debtor", () => {
+      expect(settleBalances({ a: 2000, b: -1000, c: -1000 })).toEqual([
+        { fromUserId: "b", toUserId: "a", amount: 1000 },
+        { fromUserId: "c", toUserId: "a", amount: 1000 },
+      ]);
+    });
+
+    it("should match debts directly when they cancel out", () => {
+      const transfers = settleBalances({ a: 500, b: 300, c: -500, d: -300 });
+
+      expect(transfers).toEqual([
+        { fromUserId: "c", toUserId: "a", amount: 500 },
+        { fromUserId: "d", toUserId: "b", amount: 300 },
+      ]);
+    });
+
+    it("should leave every balance at zero in at most n - 1 transfers", () => {
+      const balances = { a: 1234, b: -999, c: 400, d: -635, e: 0 };
+      const transfers = settleBalances(balances);
+
+      const result = { ...balances };
+      for (const { fromUserId, toUserId, amount } of transfers) {
+        expect(amount).toBeGreaterThan(0);
+        result[fromUserId] += amount;
+        result[toUserId] -= amount;
+      }
+
+      expect(Object.values(result).every((value) => value === 0)).toBe(true);
+      expect(transfers.length).toBeLessThanOrEqual(Object.keys(balances).length - 1);
+    });
+  });
+
+  describe("sumByCurrency", () => {
+    it("should keep one total per currency", () => {
+      expect(
+        sumByCurrency([
+          { currency: "USD", amount: 10.1 },
+          { currency: "ARS", amount: 5000 },
+          { currency: "USD", amount: 0.2 },
+        ])
+      ).toEqual([
+        { currency: "ARS", amount: 5000 },
+        { currency: "USD", amount: 10.3 },
+      ]);
+    });
+  });
+});
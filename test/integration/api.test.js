@@ -0,0 +1,176 @@
+import { setupIntegration, closeIntegration, api, login, loadFixture } from "./harness.js";
+import aggregatorService from "../../src/services/aggregator.service.js";
+import twoFactorService from "../../src/services/twoFactor.service.js";
+import legalHoldRepository from "../../src/repository/legalHold.repository.js";
+import bookingTraceService from "../../src/services/bookingTrace.service.js";
+import { BOOKING_REFUND_JOB } from "../../src/jobs/bookingRefund.job.js";
+
+describe("API integration", () => {
+  let openTrip;
+  let completedTrip;
+  let organizerToken;
+  let outsiderToken;
+
+  beforeAll(async () => {
+    await setupIntegration();
+    openTrip = await loadFixture("openTrip");
+    completedTrip = await loadFixture("completedTrip");
+    organizerToken = await login(openTrip.emails.organizer);
+    outsiderToken = await login(openTrip.emails.outsider);
+  });
+
+  afterAll(async () => {
+    await closeIntegration();
+  });
+
+  describe("route groups", () => {
+    it("should reject authenticated routes without a token", async () => {
+      await api().get("/api/v1/groups").expect(401);
+    });
+
+    it("should reject admin routes for regular users", async () => {
+      await api()
+        .get("/api/v1/admin/users")
+        .set("Authorization", `Bearer ${organizerToken}`)
+        .expect(403);
+    });
+
+    it("should serve the legacy /api prefix with deprecation headers", async () => {
+      const response = await api()
+        .get("/api/groups")
+        .set("Authorization", `Bearer ${organizerToken}`)
+        .expect(200);
+
+      expect(response.headers.deprecation).toBe("true");
+      expect(response.headers.link).toContain("/api/v1/groups");
+    });
+  });
+
+  describe("two-factor login", () => {
+    it("should not accept a challenge token as a session", async () => {
+      const challengeToken = twoFactorService.createChallenge({ id: openTrip.users.organizer });
+
+      await api()
+        .get("/api/v1/groups")
+        .set("Authorization", `Bearer ${challengeToken}`)
+        .expect(401);
+    });
+  });
+
+  describe("trips", () => {
+    it("should list the trips the user organizes", async () => {
+      const response = await api()
+        .get("/api/v1/groups")
+        .set("Authorization", `Bearer ${organizerToken}`)
+        .expect(200);
+
+      const ids = response.body.data.map((group) => group.id);
+      expect(ids).toContain(openTrip.trips.trip);
+    });
+
+    it("should derive the trip dates from the itinerary", async () => {
+      const response = await api()
+        .get(`/api/v1/groups/${openTrip.trips.trip}`)
+        .set("Authorization", `Bearer ${organizerToken}`)
+        .expect(200);
+
+      expect(response.body.data.tripStartDate).toBeTruthy();
+      expect(response.body.data.durationBucket).toBe("weekend");
+    });
+
+    it("should settle the expenses of a completed trip", async () => {
+      const memberToken = await login(completedTrip.emails.member);
+      const response = await api()
+        .get(`/api/v1/trips/${completedTrip.trips.trip}/balances`)
+        .set("Authorization", `Bearer ${memberToken}`)
+        .expect(200);
+
+      const [summary] = response.body.data.currencies;
+      expect(summary.settlements).toEqual([
+        {
+          fromUserId: completedTrip.users.member,
+          toUserId: completedTrip.users.organizer,
+          amount: "3500.00",
+        },
+      ]);
+    });
+
+    it("should keep balances private to members", async () => {
+      await api()
+        .get(`/api/v1/trips/${completedTrip.trips.trip}/balances`)
+        .set("Authorization", `Bearer ${outsiderToken}`)
+        .expect(403);
+    });
+  });
+
+  describe("booking trace", () => {
+    it("should include the jobs of a booking", async () => {
+      const bookingId = openTrip.joinRequests["trip:outsider"];
+      await api()
+        .patch(`/api/v1/groups/${openTrip.trips.trip}/join-requests/${bookingId}`)
+        .set("Authorization", `Bearer ${organizerToken}`)
+        .send({ action: "reject" })
+        .expect(200);
+
+      const { timeline } = await bookingTraceService.getTimeline(bookingId);
+      expect(timeline).toEqual(
+        expect.arrayContaining([
+          expect.objectContaining({ source: "booking", type: "rejected" }),
+          expect.objectContaining({ source: "job", type: BOOKING_REFUND_JOB }),
+        ])
+      );
+    });
+  });
+
+  describe("legal exports", () => {
+    it("should collect every section of a user's data", async () => {
+      const data = await legalHoldRepository.collectUserData(openTrip.users.organizer);
+
+      expect(data.account.id).toBe(openTrip.users.organizer);
+      expect(data.account.password).toBeUndefined();
+      expect(data.sections.itineraries.length).toBeGreaterThan(0);
+      expect(data.sections.itinerary_items.length).toBeGreaterThan(0);
+    });
+  });
+
+  describe("account data", () => {
+    it("should export the data of the user", async () => {
+      const response = await api()
+        .get("/api/v1/users/me/export")
+        .set("Authorization", `Bearer ${organizerToken}`)
+        .expect(200);
+
+      expect(response.body.data.account.id).toBe(openTrip.users.organizer);
+      expect(response.body.data.sections.itinerary_items).toEqual(expect.any(Array));
+    });
+  });
+
+  describe("availability feed", () => {
+    let apiKey;
+
+    beforeAll(async () => {
+      ({ apiKey } = await aggregatorService.createPartner(null, { name: "Integration aggregator" }));
+    });
+
+    it("should require an API key", async () => {
+      await api().get("/api/v1/availability/trips").expect(401);
+    });
+
+    it("should list open trips and answer conditional requests", async () => {
+      const response = await api()
+        .get("/api/v1/availability/trips")
+        .set("X-Api-Key", apiKey)
+        .expect(200);
+
+      const trip = response.body.data.trips.find((item) => item.tripId === openTrip.trips.trip);
+      expect(trip).toMatchObject({ status: "open", capacity: 4, spotsLeft: 2 });
+      expect(response.headers["x-ratelimit-remaining"]).toBeDefined();
+
+      await api()
+        .get("/api/v1/availability/trips")
+        .set("X-Api-Key", apiKey)
+        .set("If-None-Match", response.headers.etag)
+        .expect(304);
+    });
+  });
+});
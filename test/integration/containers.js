@@ -0,0 +1,46 @@
+import { execFileSync } from "child_process";
+
+const docker = (...args) => execFileSync("docker", args, { encoding: "utf8" }).trim();
+
+const sleep = (ms) => new Promise((resolve) => setTimeout(resolve, ms));
+
+/**
+ * Starts a throwaway container publishing a port on a random host port
+ * @returns {Object} - { id, port }
+ */
+export const startContainer = ({ image, port, env = {} }) => {
+  const id = docker(
+    "run", "-d", "--rm",
+    "--label", "jointravel-integration=true",
+    ...Object.entries(env).flatMap(([key, value]) => ["-e", `${key}=${value}`]),
+    "-p", `127.0.0.1::${port}`,
+    image
+  );
+  // "127.0.0.1:49153"
+  const mapping = docker("port", id, `${port}/tcp`).split("\n")[0];
+  return { id, port: Number(mapping.split(":").pop()) };
+};
+
+/**
+ * Runs a command inside the container until it succeeds
+ */
+export const waitUntilReady = async (id, command, { timeoutMs = 60000 } = {}) => {
+  const deadline = Date.now() + timeoutMs;
+  while (Date.now() < deadline) {
+    try {
+      docker("exec", id, ...command);
+      return;
+    } catch {
+      await sleep(500);
+    }
+  }
+  throw new Error(`Container ${id.slice(0, 12)} not ready after ${timeoutMs}ms: ${command.join(" ")}`);
+};
+
+export const stopContainer = (id) => {
+  try {
+    docker("stop", id);
+  } catch {
+    // Already gone
+  }
+};
@@ -0,0 +1,47 @@
+import { execFileSync } from "child_process";
+import { startContainer, waitUntilReady } from "./containers.js";
+
+const DB = { user: "jointravel_test", password: "jointravel_test", name: "jointravel_test" };
+
+/**
+ * Starts Postgres and Redis in throwaway containers, points the app at them
+ * (test workers inherit process.env) and migrates the schema once.
+ *
+ * Set INTEGRATION_USE_EXISTING=true to skip the containers and use the
+ * POSTGRES_* / REDIS_URL already in the environment (e.g. CI service containers).
+ */
+export default async function globalSetup() {
+  process.env.NODE_ENV = "test";
+  process.env.JWT_SECRET ||= "integration-jwt-secret";
+  process.env.JWT_REFRESH_SECRET ||= "integration-jwt-refresh-secret";
+  process.env.DB_SEED_ON_START = "false";
+  process.env.JOBS_WORKER_ENABLED = "false";
+
+  if (process.env.INTEGRATION_USE_EXISTING !== "true") {
+    const postgres = startContainer({
+      image: "postgres:17-alpine",
+      port: 5432,
+      env: { POSTGRES_USER: DB.user, POSTGRES_PASSWORD: DB.password, POSTGRES_DB: DB.name },
+    });
+    const redis = startContainer({ image: "redis:7-alpine", port: 6379 });
+    globalThis.__INTEGRATION_CONTAINERS__ = [postgres.id, redis.id];
+
+    await Promise.all([
+      // -h forces TCP: the socket answers before the server accepts connections
+      waitUntilReady(postgres.id, ["pg_isready", "-h", "127.0.0.1", "-U", DB.user, "-d", DB.name]),
+      waitUntilReady(redis.id, ["redis-cli", "ping"]),
+    ]);
+
+    Object.assign(process.env, {
+      POSTGRES_HOST: "127.0.0.1",
+      POSTGRES_PORT: String(postgres.port),
+      POSTGRES_USER: DB.user,
+      POSTGRES_PASSWORD: DB.password,
+      POSTGRES_DB: DB.name,
+      REDIS_URL: `redis://127.0.0.1:${redis.port}`,
+    });
+  }
+
+  // Same path as a deploy: the CLI migrate command
+  execFileSync("node", ["src/cli.js", "migrate"], { env: process.env, stdio: "inherit" });
+}
@@ -0,0 +1,7 @@
+import { stopContainer } from "./containers.js";
+
+export default async function globalTeardown() {
+  for (const id of globalThis.__INTEGRATION_CONTAINERS__ || []) {
+    stopContainer(id);
+  }
+}
@@ -0,0 +1,41 @@
+import request from "supertest";
+import app from "../../src/app.js";
+import { AppDataSource } from "../../src/load/typeorm.loader.js";
+import redisClient from "../../src/clients/redis.client.js";
+import { loadFixture, SEED_PASSWORD } from "../../src/seed/index.js";
+
+/**
+ * Connects the test file to the integration database. Call from beforeAll;
+ * pair with closeIntegration in afterAll.
+ */
+export const setupIntegration = async () => {
+  if (!AppDataSource.isInitialized) {
+    await AppDataSource.initialize();
+  }
+};
+
+export const closeIntegration = async () => {
+  redisClient.disconnect();
+  if (AppDataSource.isInitialized) {
+    await AppDataSource.destroy();
+  }
+};
+
+/**
+ * Supertest client of the app, going through every route group middleware
+ */
+export const api = () => request(app);
+
+/**
+ * Logs in a seeded user and returns its access token
+ * @param {string} email
+ */
+export const login = async (email) => {
+  const response = await api().post("/api/v1/auth/login").send({ email, password: SEED_PASSWORD });
+  if (response.status !== 200) {
+    throw new Error(`Login of ${email} failed (${response.status}): ${JSON.stringify(response.body)}`);
+  }
+  return response.body.data.accessToken;
+};
+
+export { loadFixture };
@@ -0,0 +1,88 @@
+process.env.NODE_ENV = 'test';
+
+import { useMemoryRepositories } from "../src/repository/memory/index.js";
+import { softDeleteById, restoreById, findTrashed } from "../src/repository/softDelete.js";
+import pastTripRepository from "../src/repository/pastTrip.repository.js";
+import travelHistoryService from "../src/services/travelHistory.service.js";
+import { NotFoundError, AuthorizationError } from "../src/utils/customErrors.js";
+
+const OWNER_ID = "5b0f7d3e-3a0c-4a4e-9a59-3f6c1f0f9e01";
+const OTHER_ID = "5b0f7d3e-3a0c-4a4e-9a59-3f6c1f0f9e02";
+
+describe("In-memory repositories", () => {
+  let memory;
+
+  beforeAll(() => {
+    memory = useMemoryRepositories();
+  });
+
+  afterEach(() => {
+    memory.reset();
+  });
+
+  afterAll(() => {
+    memory.restore();
+  });
+
+  describe("travel history service", () => {
+    let trip;
+
+    beforeEach(async () => {
+      [trip] = await memory.seed("PastTrip", [
+        { userId: OWNER_ID, city: "Salta", startDate: "2024-03-01", endDate: "2024-03-05" },
+      ]);
+    });
+
+    it("should fill in the generated id and defaults", () => {
+      expect(trip.id).toEqual(expect.any(String));
+      expect(trip.source).toBe("manual");
+      expect(trip.createdAt).toBeInstanceOf(Date);
+    });
+
+    it("should not let another user delete the trip", async () => {
+      await expect(travelHistoryService.deleteTrip(OTHER_ID, trip.id)).rejects.toThrow(
+        AuthorizationError
+      );
+      expect(await pastTripRepository.findById(trip.id)).not.toBeNull();
+    });
+
+    it("should delete the trip of its owner", async () => {
+      await travelHistoryService.deleteTrip(OWNER_ID, trip.id);
+
+      expect(await pastTripRepository.findById(trip.id)).toBeNull();
+      await expect(travelHistoryService.deleteTrip(OWNER_ID, trip.id)).rejects.toThrow(
+        NotFoundError
+      );
+    });
+
+    it("should order the history by start date", async () => {
+      await memory.seed("PastTrip", [
+        { userId: OWNER_ID, city: "Jujuy", startDate: "2024-06-01", endDate: "2024-06-02" },
+        { userId: OTHER_ID, city: "Lima", startDate: "2024-07-01", endDate: "2024-07-02" },
+      ]);
+
+      const trips = await pastTripRepository.findByUser(OWNER_ID);
+
+      expect(trips.map((item) => item.city)).toEqual(["Jujuy", "Salta"]);
+    });
+
+    it("should reject methods built on raw SQL", async () => {
+      await expect(pastTripRepository.countCountries(OWNER_ID)).rejects.toThrow(/Raw SQL/);
+    });
+  });
+
+  describe("soft delete", () => {
+    it("should hide deleted rows unless asked for them", async () => {
+      const groups = memory.repository("Group");
+      const [group] = await memory.seed("Group", [{ name: "Norte", adminId: OWNER_ID }]);
+
+      expect(await softDeleteById(groups, group.id)).toBe(true);
+      expect(await softDeleteById(groups, group.id)).toBe(false);
+      expect(await groups.findOne({ where: { id: group.id } })).toBeNull();
+      expect((await findTrashed(groups)).map((item) => item.id)).toEqual([group.id]);
+
+      expect(await restoreById(groups, group.id)).toBe(true);
+      expect(await groups.findOne({ where: { id: group.id } })).not.toBeNull();
+    });
+  });
+});